@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+// AlertEnrichmentConfig configures the enrichment of the alerts with the annotations returned by the HTTP
+// service configured for each tenant with the alertmanager_alert_enrichment_url limit.
+type AlertEnrichmentConfig struct {
+	Timeout                time.Duration `yaml:"timeout" category:"experimental"`
+	CacheTTL               time.Duration `yaml:"cache_ttl" category:"experimental"`
+	MaxResponseSizeBytes   int           `yaml:"max_response_size_bytes" category:"experimental"`
+	MaxAnnotationsPerAlert int           `yaml:"max_annotations_per_alert" category:"experimental"`
+}
+
+// RegisterFlagsWithPrefix registers flags with prefix.
+func (cfg *AlertEnrichmentConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&cfg.Timeout, prefix+".timeout", 2*time.Second, "Timeout of the requests to the alert enrichment service. When the request fails or times out, the notification is sent without the enrichment annotations.")
+	f.DurationVar(&cfg.CacheTTL, prefix+".cache-ttl", 5*time.Minute, "How long the annotations returned by the alert enrichment service for an alert are cached. 0 to disable the cache.")
+	f.IntVar(&cfg.MaxResponseSizeBytes, prefix+".max-response-size-bytes", 64*1024, "Maximum size of the response of the alert enrichment service. Larger responses are discarded.")
+	f.IntVar(&cfg.MaxAnnotationsPerAlert, prefix+".max-annotations-per-alert", 10, "Maximum number of annotations the alert enrichment service can return for a single alert. Responses with more annotations for an alert are discarded.")
+}
+
+// alertEnrichmentRequest is the body of the requests sent to the alert enrichment service.
+type alertEnrichmentRequest struct {
+	Alerts []alertEnrichmentRequestAlert `json:"alerts"`
+}
+
+type alertEnrichmentRequestAlert struct {
+	Labels model.LabelSet `json:"labels"`
+}
+
+// alertEnrichmentResponse is the body of the responses of the alert enrichment service. The alerts are in the
+// same order as in the request.
+type alertEnrichmentResponse struct {
+	Alerts []alertEnrichmentResponseAlert `json:"alerts"`
+}
+
+type alertEnrichmentResponseAlert struct {
+	Annotations model.LabelSet `json:"annotations"`
+}
+
+type alertEnrichmentCacheEntry struct {
+	annotations model.LabelSet
+	expires     time.Time
+}
+
+// alertEnricher is a notification stage adding to the alerts the annotations returned by the tenant's alert
+// enrichment service, so that annotations like the runbook links or the ownership information can be managed
+// centrally. The annotations set by the alerting rules take precedence over the returned ones.
+type alertEnricher struct {
+	cfg    AlertEnrichmentConfig
+	url    func() string
+	client *http.Client
+	logger log.Logger
+
+	// now is overridden in the tests.
+	now func() time.Time
+
+	mtx       sync.Mutex
+	cacheURL  string
+	cache     map[model.Fingerprint]alertEnrichmentCacheEntry
+	lastPurge time.Time
+
+	requests       prometheus.Counter
+	failedRequests prometheus.Counter
+}
+
+func newAlertEnricher(cfg AlertEnrichmentConfig, url func() string, client *http.Client, logger log.Logger, reg prometheus.Registerer) *alertEnricher {
+	return &alertEnricher{
+		cfg:    cfg,
+		url:    url,
+		client: client,
+		logger: logger,
+		now:    time.Now,
+		cache:  map[model.Fingerprint]alertEnrichmentCacheEntry{},
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alert_enrichment_requests_total",
+			Help: "Number of requests sent to the alert enrichment service.",
+		}),
+		failedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alert_enrichment_requests_failed_total",
+			Help: "Number of requests to the alert enrichment service which failed. The alerts are notified without enrichment.",
+		}),
+	}
+}
+
+// wrap returns a stage which enriches the alerts before running the input stage.
+func (e *alertEnricher) wrap(next notify.Stage) notify.Stage {
+	return notify.StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		return next.Exec(ctx, l, e.enrich(ctx, alerts)...)
+	})
+}
+
+// enrich returns copies of the alerts with the annotations returned by the alert enrichment service, or the
+// input alerts if the enrichment is disabled or fails.
+func (e *alertEnricher) enrich(ctx context.Context, alerts []*types.Alert) []*types.Alert {
+	url := e.url()
+	if url == "" || len(alerts) == 0 {
+		return alerts
+	}
+
+	annotations := make([]model.LabelSet, len(alerts))
+	var missing []int
+
+	e.mtx.Lock()
+	now := e.now()
+	e.resetCacheIfURLChanged(url)
+	e.purgeExpiredCacheEntries(now)
+	for i, a := range alerts {
+		if entry, ok := e.cache[a.Fingerprint()]; ok && now.Before(entry.expires) {
+			annotations[i] = entry.annotations
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	e.mtx.Unlock()
+
+	if len(missing) > 0 {
+		fetched, err := e.fetch(ctx, url, alerts, missing)
+		if err != nil {
+			e.failedRequests.Inc()
+			level.Warn(e.logger).Log("msg", "failed to enrich the alerts, notifying them without enrichment", "url", url, "err", err)
+		} else {
+			e.mtx.Lock()
+			// The URL may have changed while the request was in progress.
+			if e.cacheURL == url && e.cfg.CacheTTL > 0 {
+				for j, i := range missing {
+					e.cache[alerts[i].Fingerprint()] = alertEnrichmentCacheEntry{annotations: fetched[j], expires: now.Add(e.cfg.CacheTTL)}
+				}
+			}
+			e.mtx.Unlock()
+
+			for j, i := range missing {
+				annotations[i] = fetched[j]
+			}
+		}
+	}
+
+	enriched := make([]*types.Alert, 0, len(alerts))
+	for i, a := range alerts {
+		enriched = append(enriched, enrichAlert(a, annotations[i]))
+	}
+	return enriched
+}
+
+// fetch requests the annotations of the alerts at the given indexes to the alert enrichment service.
+func (e *alertEnricher) fetch(ctx context.Context, url string, alerts []*types.Alert, indexes []int) ([]model.LabelSet, error) {
+	e.requests.Inc()
+
+	reqBody := alertEnrichmentRequest{Alerts: make([]alertEnrichmentRequestAlert, 0, len(indexes))}
+	for _, i := range indexes {
+		reqBody.Alerts = append(reqBody.Alerts, alertEnrichmentRequestAlert{Labels: alerts[i].Labels})
+	}
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected response status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(e.cfg.MaxResponseSizeBytes)+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "read response")
+	}
+	if len(body) > e.cfg.MaxResponseSizeBytes {
+		return nil, fmt.Errorf("the response exceeds the max size of %d bytes", e.cfg.MaxResponseSizeBytes)
+	}
+
+	var respBody alertEnrichmentResponse
+	if err := json.Unmarshal(body, &respBody); err != nil {
+		return nil, errors.Wrap(err, "decode response")
+	}
+	if len(respBody.Alerts) != len(indexes) {
+		return nil, fmt.Errorf("the response has %d alerts, expected %d", len(respBody.Alerts), len(indexes))
+	}
+
+	annotations := make([]model.LabelSet, 0, len(indexes))
+	for _, a := range respBody.Alerts {
+		if len(a.Annotations) > e.cfg.MaxAnnotationsPerAlert {
+			return nil, fmt.Errorf("the response has %d annotations for an alert, exceeding the max of %d", len(a.Annotations), e.cfg.MaxAnnotationsPerAlert)
+		}
+		if err := a.Annotations.Validate(); err != nil {
+			return nil, errors.Wrap(err, "invalid annotations")
+		}
+		annotations = append(annotations, a.Annotations)
+	}
+	return annotations, nil
+}
+
+// resetCacheIfURLChanged empties the cache if the alert enrichment service of the tenant has changed.
+// Must be called with the mutex held.
+func (e *alertEnricher) resetCacheIfURLChanged(url string) {
+	if e.cacheURL != url {
+		e.cacheURL = url
+		e.cache = map[model.Fingerprint]alertEnrichmentCacheEntry{}
+	}
+}
+
+// purgeExpiredCacheEntries removes the expired entries from the cache, at most once per cache TTL.
+// Must be called with the mutex held.
+func (e *alertEnricher) purgeExpiredCacheEntries(now time.Time) {
+	if now.Sub(e.lastPurge) < e.cfg.CacheTTL {
+		return
+	}
+	for fp, entry := range e.cache {
+		if !now.Before(entry.expires) {
+			delete(e.cache, fp)
+		}
+	}
+	e.lastPurge = now
+}
+
+// enrichAlert returns a copy of the alert with the input annotations added, unless the alert already has them.
+func enrichAlert(a *types.Alert, annotations model.LabelSet) *types.Alert {
+	if len(annotations) == 0 {
+		return a
+	}
+
+	enriched := *a
+	enriched.Annotations = make(model.LabelSet, len(a.Annotations)+len(annotations))
+	for name, value := range annotations {
+		enriched.Annotations[name] = value
+	}
+	for name, value := range a.Annotations {
+		enriched.Annotations[name] = value
+	}
+	return &enriched
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestAlertEnricher(t *testing.T) {
+	var (
+		requests atomic.Int64
+		fail     atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var req alertEnrichmentRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		resp := alertEnrichmentResponse{}
+		for _, a := range req.Alerts {
+			resp.Alerts = append(resp.Alerts, alertEnrichmentResponseAlert{Annotations: model.LabelSet{
+				"runbook_url": "https://runbooks.example.com/" + a.Labels["alertname"],
+				"summary":     "enriched summary",
+			}})
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := AlertEnrichmentConfig{Timeout: time.Second, CacheTTL: time.Minute, MaxResponseSizeBytes: 1024, MaxAnnotationsPerAlert: 2}
+	url := server.URL
+	reg := prometheus.NewPedanticRegistry()
+	e := newAlertEnricher(cfg, func() string { return url }, http.DefaultClient, log.NewNopLogger(), reg)
+
+	now := time.Now()
+	e.now = func() time.Time { return now }
+
+	var notified []*types.Alert
+	stage := e.wrap(notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		notified = alerts
+		return ctx, alerts, nil
+	}))
+	exec := func(alerts ...*types.Alert) []*types.Alert {
+		_, _, err := stage.Exec(context.Background(), log.NewNopLogger(), alerts...)
+		require.NoError(t, err)
+		return notified
+	}
+
+	alert1 := &types.Alert{Alert: model.Alert{
+		Labels:      model.LabelSet{"alertname": "HighLatency"},
+		Annotations: model.LabelSet{"summary": "original summary"},
+	}}
+	alert2 := &types.Alert{Alert: model.Alert{
+		Labels: model.LabelSet{"alertname": "HighErrorRate"},
+	}}
+
+	// The returned annotations are added, without overriding the alert's ones.
+	alerts := exec(alert1, alert2)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, model.LabelSet{"summary": "original summary", "runbook_url": "https://runbooks.example.com/HighLatency"}, alerts[0].Annotations)
+	assert.Equal(t, model.LabelSet{"summary": "enriched summary", "runbook_url": "https://runbooks.example.com/HighErrorRate"}, alerts[1].Annotations)
+	assert.Equal(t, int64(1), requests.Load())
+
+	// The input alerts are not modified.
+	assert.Equal(t, model.LabelSet{"summary": "original summary"}, alert1.Annotations)
+	assert.Empty(t, alert2.Annotations)
+
+	// The annotations are cached.
+	alerts = exec(alert1, alert2)
+	assert.Equal(t, "https://runbooks.example.com/HighErrorRate", string(alerts[1].Annotations["runbook_url"]))
+	assert.Equal(t, int64(1), requests.Load())
+
+	// The alerts are notified without enrichment if the service fails.
+	now = now.Add(2 * time.Minute)
+	fail.Store(true)
+	alerts = exec(alert1, alert2)
+	assert.Same(t, alert1, alerts[0])
+	assert.Same(t, alert2, alerts[1])
+	assert.Equal(t, int64(2), requests.Load())
+
+	// No request is sent if the enrichment is disabled.
+	url = ""
+	alerts = exec(alert1)
+	assert.Same(t, alert1, alerts[0])
+	assert.Equal(t, int64(2), requests.Load())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP alertmanager_alert_enrichment_requests_failed_total Number of requests to the alert enrichment service which failed. The alerts are notified without enrichment.
+		# TYPE alertmanager_alert_enrichment_requests_failed_total counter
+		alertmanager_alert_enrichment_requests_failed_total 1
+
+		# HELP alertmanager_alert_enrichment_requests_total Number of requests sent to the alert enrichment service.
+		# TYPE alertmanager_alert_enrichment_requests_total counter
+		alertmanager_alert_enrichment_requests_total 2
+	`)))
+}
+
+func TestAlertEnricher_ShouldDiscardInvalidResponses(t *testing.T) {
+	tests := map[string]struct {
+		response string
+		timeout  time.Duration
+	}{
+		"response exceeding the max size": {
+			response: `{"alerts": [{"annotations": {"summary": "` + strings.Repeat("x", 1024) + `"}}]}`,
+		},
+		"too many annotations": {
+			response: `{"alerts": [{"annotations": {"a": "1", "b": "2", "c": "3"}}]}`,
+		},
+		"invalid annotation name": {
+			response: `{"alerts": [{"annotations": {"invalid-name": "1"}}]}`,
+		},
+		"wrong number of alerts": {
+			response: `{"alerts": []}`,
+		},
+		"malformed response": {
+			response: `{"alerts":`,
+		},
+		"timeout": {
+			response: `{"alerts": [{"annotations": {"summary": "slow"}}]}`,
+			timeout:  100 * time.Millisecond,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.timeout > 0 {
+					select {
+					case <-time.After(10 * tc.timeout):
+					case <-r.Context().Done():
+					}
+				}
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			t.Cleanup(server.Close)
+
+			cfg := AlertEnrichmentConfig{Timeout: time.Second, CacheTTL: time.Minute, MaxResponseSizeBytes: 1024, MaxAnnotationsPerAlert: 2}
+			if tc.timeout > 0 {
+				cfg.Timeout = tc.timeout
+			}
+			e := newAlertEnricher(cfg, func() string { return server.URL }, http.DefaultClient, log.NewNopLogger(), nil)
+
+			alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "HighLatency"}}}
+			alerts := e.enrich(context.Background(), []*types.Alert{alert})
+			require.Len(t, alerts, 1)
+			assert.Same(t, alert, alerts[0])
+			assert.Equal(t, float64(1), testutil.ToFloat64(e.failedRequests))
+			assert.Empty(t, e.cache)
+		})
+	}
+}
@@ -87,6 +87,15 @@ type Config struct {
 	Replicator        Replicator
 	Store             alertstore.AlertStore
 	PersisterConfig   PersisterConfig
+
+	// Whether only the leader replica of the tenant dispatches the notifications.
+	NotificationCoordinationEnabled bool
+
+	// Resolves the secrets referenced by the receiver configs. Nil if disabled.
+	SecretsResolver *ReceiverSecretsResolver
+
+	// Configures the enrichment of the alerts, enabled per tenant by the limits.
+	AlertEnrichment AlertEnrichmentConfig
 }
 
 // An Alertmanager manages the alerts for one user.
@@ -121,6 +130,14 @@ type Alertmanager struct {
 	configHashMetric prometheus.Gauge
 
 	rateLimitedNotifications *prometheus.CounterVec
+
+	unmatchedAlerts *unmatchedAlertsTracker
+
+	// Set only if the notification coordination is enabled.
+	notificationCoordinator *notificationCoordinator
+
+	// Set only if the limits are configured.
+	alertEnricher *alertEnricher
 }
 
 var (
@@ -156,6 +173,9 @@ type Replicator interface {
 	// The alertmanager replication protocol relies on a position related to other replicas.
 	// This position is then used to identify who should notify about the alert first.
 	GetPositionForUser(userID string) int
+	// LookupPositionForUser is like GetPositionForUser, but returns an error if the position
+	// can't be determined, instead of falling back to the first position.
+	LookupPositionForUser(userID string) (int, error)
 	// ReadFullStateForUser obtains the full state from other replicas in the cluster.
 	// If all the replicas were successfully contacted, but the user was not found in
 	// all the replicas, then errAllReplicasUserNotFound is returned.
@@ -244,12 +264,33 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		am.wg.Done()
 	}()
 
-	var callback mem.AlertStoreCallback
+	am.unmatchedAlerts = newUnmatchedAlertsTracker(reg)
+
+	if cfg.NotificationCoordinationEnabled {
+		am.notificationCoordinator = newNotificationCoordinator(am.state.LookupPosition, log.With(am.logger, "component", "notification-coordinator"), am.registry)
+	}
+
+	if cfg.Limits != nil {
+		// The alert enrichment service is configured by the tenant, so it's subject to the same firewall as the receivers.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = util_net.NewFirewallDialer(newFirewallDialerConfigProvider(cfg.UserID, cfg.Limits)).DialContext
+
+		am.alertEnricher = newAlertEnricher(
+			cfg.AlertEnrichment,
+			func() string { return cfg.Limits.AlertmanagerAlertEnrichmentURL(cfg.UserID) },
+			&http.Client{Transport: transport},
+			log.With(am.logger, "component", "alert-enricher"),
+			am.registry,
+		)
+	}
+
+	callbacks := alertStoreCallbacks{am.unmatchedAlerts}
 	if am.cfg.Limits != nil {
-		callback = newAlertsLimiter(am.cfg.UserID, am.cfg.Limits, reg)
+		// The limiter must be the first callback, so that the others aren't called if it rejects the alert.
+		callbacks = append(alertStoreCallbacks{newAlertsLimiter(am.cfg.UserID, am.cfg.Limits, reg)}, callbacks...)
 	}
 
-	am.alerts, err = mem.NewAlerts(context.Background(), am.marker, 30*time.Minute, callback, am.logger, reg)
+	am.alerts, err = mem.NewAlerts(context.Background(), am.marker, 30*time.Minute, callbacks, am.logger, reg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create alerts: %v", err)
 	}
@@ -287,6 +328,15 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		am.mux.Handle(a, http.NotFoundHandler())
 	}
 
+	// List the alerts which matched no route of the routing tree.
+	am.mux.Handle(path.Join(am.cfg.ExternalURL.Path, "/api/v1/alerts/unmatched"), am.unmatchedAlerts)
+
+	// Create and expire silences in bulk.
+	am.mux.Handle(path.Join(am.cfg.ExternalURL.Path, "/api/v1/silences/bulk"), &bulkSilencesHandler{
+		silences: am.silences,
+		logger:   log.With(am.logger, "component", "bulk-silences"),
+	})
+
 	am.dispatcherMetrics = dispatch.NewDispatcherMetrics(true, am.registry)
 
 	//TODO: From this point onward, the alertmanager _might_ receive requests - we need to make sure we've settled and are ready.
@@ -341,6 +391,10 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	am.inhibitor = inhibit.NewInhibitor(am.alerts, conf.InhibitRules, am.marker, log.With(am.logger, "component", "inhibitor"))
 
 	waitFunc := clusterWait(am.state.Position, am.cfg.PeerTimeout)
+	if am.notificationCoordinator != nil {
+		// Only the leader dispatches the notifications, so there's no need to wait for the other replicas.
+		waitFunc = am.notificationCoordinator.waitFunc(waitFunc)
+	}
 
 	timeoutFunc := func(d time.Duration) time.Duration {
 		if d < notify.MinTimeout {
@@ -352,7 +406,15 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	// Create a firewall binded to the per-tenant config.
 	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider(userID, am.cfg.Limits))
 
-	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
+	// Resolve the secrets referenced by the receiver configs in the tenant's namespace of the secrets stores.
+	resolveSecret := func(secretReference) (string, error) { return "", errReceiverSecretsDisabled }
+	if am.cfg.SecretsResolver != nil {
+		resolveSecret = func(ref secretReference) (string, error) {
+			return am.cfg.SecretsResolver.resolve(userID, ref)
+		}
+	}
+
+	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, resolveSecret, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
 		if am.cfg.Limits != nil {
 			rl := &tenantRateLimits{
 				tenant:      userID,
@@ -377,7 +439,7 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 		timeIntervals[ti.Name] = ti.TimeIntervals
 	}
 
-	pipeline := am.pipelineBuilder.New(
+	var pipeline notify.Stage = am.pipelineBuilder.New(
 		integrationsMap,
 		waitFunc,
 		am.inhibitor,
@@ -386,10 +448,21 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 		am.nflog,
 		am.state,
 	)
+	if am.alertEnricher != nil {
+		pipeline = am.alertEnricher.wrap(pipeline)
+	}
+	if am.notificationCoordinator != nil {
+		// The alerts are enriched only by the leader.
+		pipeline = am.notificationCoordinator.wrap(pipeline)
+	}
 	am.lastPipeline = pipeline
+
+	route := dispatch.NewRoute(conf.Route, nil)
+	am.unmatchedAlerts.setRoute(route)
+
 	am.dispatcher = dispatch.NewDispatcher(
 		am.alerts,
-		dispatch.NewRoute(conf.Route, nil),
+		route,
 		pipeline,
 		am.marker,
 		timeoutFunc,
@@ -446,10 +519,23 @@ func (am *Alertmanager) getFullState() (*clusterpb.FullState, error) {
 
 // buildIntegrationsMap builds a map of name to the list of integration notifiers off of a
 // list of receiver config.
-func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, notifierWrapper func(string, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
+// The secrets referenced by the receiver configs are resolved with resolveSecret at notification time.
+func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, resolveSecret func(secretReference) (string, error), logger log.Logger, notifierWrapper func(string, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
 	integrationsMap := make(map[string][]notify.Integration, len(nc))
 	for _, rcv := range nc {
-		integrations, err := buildReceiverIntegrations(rcv, tmpl, firewallDialer, logger, notifierWrapper)
+		var (
+			integrations []notify.Integration
+			err          error
+		)
+
+		if hasSecretReferences(rcv) {
+			build := func(rcv config.Receiver) ([]notify.Integration, error) {
+				return buildReceiverIntegrations(rcv, tmpl, firewallDialer, logger, func(_ string, n notify.Notifier) notify.Notifier { return n })
+			}
+			integrations, err = buildReceiverIntegrationsWithSecrets(rcv, resolveSecret, build, notifierWrapper)
+		} else {
+			integrations, err = buildReceiverIntegrations(rcv, tmpl, firewallDialer, logger, notifierWrapper)
+		}
 		if err != nil {
 			return nil, err
 		}
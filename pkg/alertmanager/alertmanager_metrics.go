@@ -74,6 +74,16 @@ type alertmanagerMetrics struct {
 	insertAlertFailures      *prometheus.Desc
 	alertsLimiterAlertsCount *prometheus.Desc
 	alertsLimiterAlertsSize  *prometheus.Desc
+
+	alertsMatchedNoRouteTotal *prometheus.Desc
+	alertsMatchedNoRoute      *prometheus.Desc
+
+	notificationCoordinationLeader        *prometheus.Desc
+	notificationCoordinationLeaderChanges *prometheus.Desc
+	notificationCoordinationSkipped       *prometheus.Desc
+
+	alertEnrichmentRequests       *prometheus.Desc
+	alertEnrichmentRequestsFailed *prometheus.Desc
 }
 
 func newAlertmanagerMetrics() *alertmanagerMetrics {
@@ -247,6 +257,34 @@ func newAlertmanagerMetrics() *alertmanagerMetrics {
 			"cortex_alertmanager_alerts_limiter_current_alerts_size_bytes",
 			"Total size of alerts tracked by alerts limiter.",
 			[]string{"user"}, nil),
+		alertsMatchedNoRouteTotal: prometheus.NewDesc(
+			"cortex_alertmanager_alerts_matched_no_route_total",
+			"Total number of alerts which matched no route of the routing tree, and were handled by the default route.",
+			[]string{"user"}, nil),
+		alertsMatchedNoRoute: prometheus.NewDesc(
+			"cortex_alertmanager_alerts_matched_no_route",
+			"Number of alerts currently stored which matched no route of the routing tree.",
+			[]string{"user"}, nil),
+		notificationCoordinationLeader: prometheus.NewDesc(
+			"cortex_alertmanager_notification_coordination_leader",
+			"Whether this replica is the leader dispatching the notifications of the tenant (1) or not (0).",
+			[]string{"user"}, nil),
+		notificationCoordinationLeaderChanges: prometheus.NewDesc(
+			"cortex_alertmanager_notification_coordination_leader_changes_total",
+			"Total number of times this replica has gained or lost the leadership to dispatch the notifications of the tenant.",
+			[]string{"user"}, nil),
+		notificationCoordinationSkipped: prometheus.NewDesc(
+			"cortex_alertmanager_notification_coordination_skipped_total",
+			"Total number of aggregation group flushes not dispatched because this replica is not the leader of the tenant.",
+			[]string{"user"}, nil),
+		alertEnrichmentRequests: prometheus.NewDesc(
+			"cortex_alertmanager_alert_enrichment_requests_total",
+			"Total number of requests sent to the alert enrichment service.",
+			[]string{"user"}, nil),
+		alertEnrichmentRequestsFailed: prometheus.NewDesc(
+			"cortex_alertmanager_alert_enrichment_requests_failed_total",
+			"Total number of requests to the alert enrichment service which failed. The alerts are notified without enrichment.",
+			[]string{"user"}, nil),
 	}
 }
 
@@ -303,6 +341,13 @@ func (m *alertmanagerMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.insertAlertFailures
 	out <- m.alertsLimiterAlertsCount
 	out <- m.alertsLimiterAlertsSize
+	out <- m.alertsMatchedNoRouteTotal
+	out <- m.alertsMatchedNoRoute
+	out <- m.notificationCoordinationLeader
+	out <- m.notificationCoordinationLeaderChanges
+	out <- m.notificationCoordinationSkipped
+	out <- m.alertEnrichmentRequests
+	out <- m.alertEnrichmentRequestsFailed
 }
 
 func (m *alertmanagerMetrics) Collect(out chan<- prometheus.Metric) {
@@ -357,4 +402,14 @@ func (m *alertmanagerMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfCountersPerTenant(out, m.insertAlertFailures, "alertmanager_alerts_insert_limited_total")
 	data.SendSumOfGaugesPerTenant(out, m.alertsLimiterAlertsCount, "alertmanager_alerts_limiter_current_alerts")
 	data.SendSumOfGaugesPerTenant(out, m.alertsLimiterAlertsSize, "alertmanager_alerts_limiter_current_alerts_size_bytes")
+
+	data.SendSumOfCountersPerTenant(out, m.alertsMatchedNoRouteTotal, "alertmanager_alerts_matched_no_route_total")
+	data.SendSumOfGaugesPerTenant(out, m.alertsMatchedNoRoute, "alertmanager_alerts_matched_no_route")
+
+	data.SendSumOfGaugesPerTenant(out, m.notificationCoordinationLeader, "alertmanager_notification_coordination_leader")
+	data.SendSumOfCountersPerTenant(out, m.notificationCoordinationLeaderChanges, "alertmanager_notification_coordination_leader_changes_total")
+	data.SendSumOfCountersPerTenant(out, m.notificationCoordinationSkipped, "alertmanager_notification_coordination_skipped_total")
+
+	data.SendSumOfCountersPerTenant(out, m.alertEnrichmentRequests, "alertmanager_alert_enrichment_requests_total")
+	data.SendSumOfCountersPerTenant(out, m.alertEnrichmentRequestsFailed, "alertmanager_alert_enrichment_requests_failed_total")
 }
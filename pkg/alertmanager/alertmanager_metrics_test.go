@@ -309,6 +309,16 @@ func TestAlertmanagerMetricsStore(t *testing.T) {
 		cortex_alertmanager_alerts_insert_limited_total{user="user1"} 7
 		cortex_alertmanager_alerts_insert_limited_total{user="user2"} 70
 		cortex_alertmanager_alerts_insert_limited_total{user="user3"} 700
+		# HELP cortex_alertmanager_alerts_matched_no_route_total Total number of alerts which matched no route of the routing tree, and were handled by the default route.
+		# TYPE cortex_alertmanager_alerts_matched_no_route_total counter
+		cortex_alertmanager_alerts_matched_no_route_total{user="user1"} 3
+		cortex_alertmanager_alerts_matched_no_route_total{user="user2"} 30
+		cortex_alertmanager_alerts_matched_no_route_total{user="user3"} 300
+		# HELP cortex_alertmanager_alerts_matched_no_route Number of alerts currently stored which matched no route of the routing tree.
+		# TYPE cortex_alertmanager_alerts_matched_no_route gauge
+		cortex_alertmanager_alerts_matched_no_route{user="user1"} 2
+		cortex_alertmanager_alerts_matched_no_route{user="user2"} 20
+		cortex_alertmanager_alerts_matched_no_route{user="user3"} 200
 `))
 	require.NoError(t, err)
 }
@@ -621,6 +631,16 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 						cortex_alertmanager_alerts_insert_limited_total{user="user1"} 7
 						cortex_alertmanager_alerts_insert_limited_total{user="user2"} 70
 						cortex_alertmanager_alerts_insert_limited_total{user="user3"} 700
+						# HELP cortex_alertmanager_alerts_matched_no_route_total Total number of alerts which matched no route of the routing tree, and were handled by the default route.
+						# TYPE cortex_alertmanager_alerts_matched_no_route_total counter
+						cortex_alertmanager_alerts_matched_no_route_total{user="user1"} 3
+						cortex_alertmanager_alerts_matched_no_route_total{user="user2"} 30
+						cortex_alertmanager_alerts_matched_no_route_total{user="user3"} 300
+						# HELP cortex_alertmanager_alerts_matched_no_route Number of alerts currently stored which matched no route of the routing tree.
+						# TYPE cortex_alertmanager_alerts_matched_no_route gauge
+						cortex_alertmanager_alerts_matched_no_route{user="user1"} 2
+						cortex_alertmanager_alerts_matched_no_route{user="user2"} 20
+						cortex_alertmanager_alerts_matched_no_route{user="user3"} 200
 
 `))
 	require.NoError(t, err)
@@ -878,6 +898,14 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 			# TYPE cortex_alertmanager_alerts_insert_limited_total counter
 			cortex_alertmanager_alerts_insert_limited_total{user="user1"} 7
 			cortex_alertmanager_alerts_insert_limited_total{user="user2"} 70
+			# HELP cortex_alertmanager_alerts_matched_no_route_total Total number of alerts which matched no route of the routing tree, and were handled by the default route.
+			# TYPE cortex_alertmanager_alerts_matched_no_route_total counter
+			cortex_alertmanager_alerts_matched_no_route_total{user="user1"} 3
+			cortex_alertmanager_alerts_matched_no_route_total{user="user2"} 30
+			# HELP cortex_alertmanager_alerts_matched_no_route Number of alerts currently stored which matched no route of the routing tree.
+			# TYPE cortex_alertmanager_alerts_matched_no_route gauge
+			cortex_alertmanager_alerts_matched_no_route{user="user1"} 2
+			cortex_alertmanager_alerts_matched_no_route{user="user2"} 20
 `))
 	require.NoError(t, err)
 }
@@ -938,6 +966,10 @@ func populateAlertmanager(base float64) *prometheus.Registry {
 	lm.size.Set(100 * base)
 	lm.insertFailures.Add(7 * base)
 
+	um := newUnmatchedAlertsMetrics(reg)
+	um.total.Add(3 * base)
+	um.current.Set(2 * base)
+
 	return reg
 }
 
@@ -1200,3 +1232,21 @@ func newLimiterMetrics(r prometheus.Registerer) *limiterMetrics {
 		insertFailures: insertAlertFailures,
 	}
 }
+
+type unmatchedAlertsMetrics struct {
+	total   prometheus.Counter
+	current prometheus.Gauge
+}
+
+func newUnmatchedAlertsMetrics(r prometheus.Registerer) *unmatchedAlertsMetrics {
+	return &unmatchedAlertsMetrics{
+		total: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alerts_matched_no_route_total",
+			Help: "Number of alerts which matched no route of the routing tree, and were handled by the default route.",
+		}),
+		current: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "alertmanager_alerts_matched_no_route",
+			Help: "Number of alerts currently stored which matched no route of the routing tree.",
+		}),
+	}
+}
@@ -50,6 +50,9 @@ func (*stubReplicator) ReplicateStateForUser(context.Context, string, *clusterpb
 func (*stubReplicator) GetPositionForUser(userID string) int {
 	return 0
 }
+func (*stubReplicator) LookupPositionForUser(userID string) (int, error) {
+	return 0, nil
+}
 func (*stubReplicator) ReadFullStateForUser(context.Context, string) ([]*clusterpb.FullState, error) {
 	return nil, nil
 }
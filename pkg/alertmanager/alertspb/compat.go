@@ -11,6 +11,15 @@ var (
 	ErrNotFound = errors.New("alertmanager storage object not found")
 )
 
+// FreezeMark is stored for the users whose Alertmanager configuration and silences are frozen in read-only mode.
+type FreezeMark struct {
+	// Unix timestamp (seconds) of when the user has been frozen.
+	FrozenAt int64 `json:"frozen_at"`
+
+	// Reason is an optional free text set by the operator who froze the user.
+	Reason string `json:"reason,omitempty"`
+}
+
 // ToProto transforms a yaml Alertmanager config and map of template files to an AlertConfigDesc
 func ToProto(cfg string, templates map[string]string, user string) AlertConfigDesc {
 	tmpls := []*TemplateDesc{}
@@ -8,6 +8,7 @@ package bucketclient
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"strings"
 	"sync"
@@ -34,6 +35,11 @@ const (
 	//     alertmanager/<user-id>/<object>
 	AlertmanagerPrefix = "alertmanager"
 
+	// FreezeMarksPrefix is the bucket prefix under which the freeze marks of the tenants whose alertmanager
+	// is frozen in read-only mode are stored. Note that objects stored under this prefix follow the pattern:
+	//     alertmanager-freeze-marks/<user-id>
+	FreezeMarksPrefix = "alertmanager-freeze-marks"
+
 	// The name of alertmanager full state objects (notification log + silences).
 	fullStateName = "fullstate"
 
@@ -44,18 +50,20 @@ const (
 // BucketAlertStore is used to support the AlertStore interface against an object storage backend. It is implemented
 // using the Thanos objstore.Bucket interface
 type BucketAlertStore struct {
-	alertsBucket objstore.Bucket
-	amBucket     objstore.Bucket
-	cfgProvider  bucket.TenantConfigProvider
-	logger       log.Logger
+	alertsBucket      objstore.Bucket
+	amBucket          objstore.Bucket
+	freezeMarksBucket objstore.Bucket
+	cfgProvider       bucket.TenantConfigProvider
+	logger            log.Logger
 }
 
 func NewBucketAlertStore(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *BucketAlertStore {
 	return &BucketAlertStore{
-		alertsBucket: bucket.NewPrefixedBucketClient(bkt, AlertsPrefix),
-		amBucket:     bucket.NewPrefixedBucketClient(bkt, AlertmanagerPrefix),
-		cfgProvider:  cfgProvider,
-		logger:       logger,
+		alertsBucket:      bucket.NewPrefixedBucketClient(bkt, AlertsPrefix),
+		amBucket:          bucket.NewPrefixedBucketClient(bkt, AlertmanagerPrefix),
+		freezeMarksBucket: bucket.NewPrefixedBucketClient(bkt, FreezeMarksPrefix),
+		cfgProvider:       cfgProvider,
+		logger:            logger,
 	}
 }
 
@@ -177,6 +185,59 @@ func (s *BucketAlertStore) DeleteFullState(ctx context.Context, userID string) e
 	return err
 }
 
+// ListFrozenUsers implements alertstore.AlertStore.
+func (s *BucketAlertStore) ListFrozenUsers(ctx context.Context) ([]string, error) {
+	var userIDs []string
+
+	err := s.freezeMarksBucket.Iter(ctx, "", func(key string) error {
+		userIDs = append(userIDs, key)
+		return nil
+	})
+
+	return userIDs, err
+}
+
+// GetFreezeMark implements alertstore.AlertStore.
+func (s *BucketAlertStore) GetFreezeMark(ctx context.Context, userID string) (alertspb.FreezeMark, error) {
+	mark := alertspb.FreezeMark{}
+
+	readCloser, err := s.getFreezeMarksUserBucket(userID).Get(ctx, userID)
+	if s.freezeMarksBucket.IsObjNotFoundErr(err) {
+		return mark, alertspb.ErrNotFound
+	} else if err != nil {
+		return mark, err
+	}
+
+	defer runutil.CloseWithLogOnErr(s.logger, readCloser, "close bucket reader")
+
+	if err := json.NewDecoder(readCloser).Decode(&mark); err != nil {
+		return mark, errors.Wrapf(err, "failed to deserialize alertmanager freeze mark for user %s", userID)
+	}
+
+	return mark, nil
+}
+
+// SetFreezeMark implements alertstore.AlertStore.
+func (s *BucketAlertStore) SetFreezeMark(ctx context.Context, userID string, mark alertspb.FreezeMark) error {
+	markBytes, err := json.Marshal(mark)
+	if err != nil {
+		return err
+	}
+
+	return s.getFreezeMarksUserBucket(userID).Upload(ctx, userID, bytes.NewBuffer(markBytes))
+}
+
+// DeleteFreezeMark implements alertstore.AlertStore.
+func (s *BucketAlertStore) DeleteFreezeMark(ctx context.Context, userID string) error {
+	userBkt := s.getFreezeMarksUserBucket(userID)
+
+	err := userBkt.Delete(ctx, userID)
+	if userBkt.IsObjNotFoundErr(err) {
+		return nil
+	}
+	return err
+}
+
 func (s *BucketAlertStore) getAlertConfig(ctx context.Context, userID string) (alertspb.AlertConfigDesc, error) {
 	config := alertspb.AlertConfigDesc{}
 	err := s.get(ctx, s.getUserBucket(userID), userID, &config)
@@ -209,6 +270,11 @@ func (s *BucketAlertStore) getUserBucket(userID string) objstore.Bucket {
 	return bucket.NewSSEBucketClient(userID, s.alertsBucket, s.cfgProvider)
 }
 
+func (s *BucketAlertStore) getFreezeMarksUserBucket(userID string) objstore.Bucket {
+	// Inject server-side encryption based on the tenant config.
+	return bucket.NewSSEBucketClient(userID, s.freezeMarksBucket, s.cfgProvider)
+}
+
 func (s *BucketAlertStore) getAlertmanagerUserBucket(userID string) objstore.Bucket {
 	return bucket.NewUserBucketClient(userID, s.amBucket, s.cfgProvider).WithExpectedErrs(s.amBucket.IsObjNotFoundErr)
 }
@@ -125,6 +125,26 @@ func (f *Store) DeleteFullState(ctx context.Context, user string) error {
 	return errState
 }
 
+// ListFrozenUsers implements alertstore.AlertStore.
+func (f *Store) ListFrozenUsers(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+// GetFreezeMark implements alertstore.AlertStore.
+func (f *Store) GetFreezeMark(_ context.Context, _ string) (alertspb.FreezeMark, error) {
+	return alertspb.FreezeMark{}, alertspb.ErrNotFound
+}
+
+// SetFreezeMark implements alertstore.AlertStore.
+func (f *Store) SetFreezeMark(_ context.Context, _ string, _ alertspb.FreezeMark) error {
+	return errReadOnly
+}
+
+// DeleteFreezeMark implements alertstore.AlertStore.
+func (f *Store) DeleteFreezeMark(_ context.Context, _ string) error {
+	return errReadOnly
+}
+
 func (f *Store) reloadConfigs() (map[string]alertspb.AlertConfigDesc, error) {
 	configs := map[string]alertspb.AlertConfigDesc{}
 	err := filepath.Walk(f.cfg.Path, func(path string, info os.FileInfo, err error) error {
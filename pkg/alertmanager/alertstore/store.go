@@ -50,6 +50,20 @@ type AlertStore interface {
 	// DeleteFullState deletes the alertmanager state for an user.
 	// If state for the user doesn't exist, no error is reported.
 	DeleteFullState(ctx context.Context, user string) error
+
+	// ListFrozenUsers returns the list of users whose alertmanager is frozen in read-only mode.
+	ListFrozenUsers(ctx context.Context) ([]string, error)
+
+	// GetFreezeMark loads and returns the freeze mark for the given user.
+	// If the user is not frozen, alertspb.ErrNotFound is returned.
+	GetFreezeMark(ctx context.Context, user string) (alertspb.FreezeMark, error)
+
+	// SetFreezeMark stores the freeze mark for the given user.
+	SetFreezeMark(ctx context.Context, user string, mark alertspb.FreezeMark) error
+
+	// DeleteFreezeMark deletes the freeze mark for the given user.
+	// If the user is not frozen, no error is reported.
+	DeleteFreezeMark(ctx context.Context, user string) error
 }
 
 // NewAlertStore returns a alertmanager store backend client based on the provided cfg.
@@ -239,3 +239,57 @@ func TestBucketAlertStore_GetSetDeleteFullState(t *testing.T) {
 		require.NoError(t, store.DeleteFullState(ctx, "user-1"))
 	}
 }
+
+func TestBucketAlertStore_GetSetDeleteFreezeMark(t *testing.T) {
+	bucket := objstore.NewInMemBucket()
+	store := bucketclient.NewBucketAlertStore(bucket, nil, log.NewNopLogger())
+
+	ctx := context.Background()
+	mark := alertspb.FreezeMark{FrozenAt: 1234, Reason: "incident investigation"}
+
+	// The storage is empty.
+	{
+		_, err := store.GetFreezeMark(ctx, "user-1")
+		assert.Equal(t, alertspb.ErrNotFound, err)
+
+		users, err := store.ListFrozenUsers(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+	}
+
+	// The storage contains a frozen user.
+	{
+		require.NoError(t, store.SetFreezeMark(ctx, "user-1", mark))
+
+		res, err := store.GetFreezeMark(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, mark, res)
+
+		_, err = store.GetFreezeMark(ctx, "user-2")
+		assert.Equal(t, alertspb.ErrNotFound, err)
+
+		// Ensure the mark is stored at the expected location, and doesn't look like an alertmanager state.
+		exists, err := bucket.Exists(ctx, "alertmanager-freeze-marks/user-1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		users, err := store.ListFrozenUsers(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user-1"}, users)
+
+		users, err = store.ListUsersWithFullState(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+	}
+
+	// The user has been unfrozen.
+	{
+		require.NoError(t, store.DeleteFreezeMark(ctx, "user-1"))
+
+		_, err := store.GetFreezeMark(ctx, "user-1")
+		assert.Equal(t, alertspb.ErrNotFound, err)
+
+		// Delete again (should be idempotent).
+		require.NoError(t, store.DeleteFreezeMark(ctx, "user-1"))
+	}
+}
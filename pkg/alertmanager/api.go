@@ -113,6 +113,10 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if am.rejectIfTenantFrozen(w, r, userID) {
+		return
+	}
+
 	var input io.Reader
 	maxConfigSize := am.limits.AlertmanagerMaxConfigSize(userID)
 	if maxConfigSize > 0 {
@@ -173,6 +177,10 @@ func (am *MultitenantAlertmanager) DeleteUserConfig(w http.ResponseWriter, r *ht
 		return
 	}
 
+	if am.rejectIfTenantFrozen(w, r, userID) {
+		return
+	}
+
 	err = am.store.DeleteAlertConfig(r.Context(), userID)
 	if err != nil {
 		level.Error(logger).Log("msg", errDeletingConfiguration, "err", err.Error())
@@ -203,6 +211,11 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		return err
 	}
 
+	// Validate the secrets referenced by the receiver configs.
+	if err := validateReceiverSecretReferences(amCfg.Receivers); err != nil {
+		return err
+	}
+
 	// Validate templates referenced in the alertmanager config.
 	for _, name := range amCfg.Templates {
 		if err := validateTemplateFilename(name); err != nil {
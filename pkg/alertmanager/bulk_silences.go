@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// maxBulkSilences is the max number of silences created or expired by a single bulk silences request.
+const maxBulkSilences = 1000
+
+// bulkSilencesHandler creates silences for a list of matcher sets sharing the same time window and comment,
+// and expires them by ID. It's meant to be used by automated maintenance tooling, which would otherwise loop
+// over the single silence API.
+type bulkSilencesHandler struct {
+	silences *silence.Silences
+	logger   log.Logger
+}
+
+type bulkSilencesMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	// IsEqual defaults to true when not set.
+	IsEqual *bool `json:"isEqual,omitempty"`
+}
+
+type bulkSilencesRequest struct {
+	// One silence is created for each matcher set.
+	MatcherSets [][]bulkSilencesMatcher `json:"matcherSets"`
+	StartsAt    time.Time               `json:"startsAt"`
+	EndsAt      time.Time               `json:"endsAt"`
+	CreatedBy   string                  `json:"createdBy"`
+	Comment     string                  `json:"comment"`
+}
+
+type bulkSilencesResponse struct {
+	// IDs of the silences, in the same order as the matcher sets of the request.
+	SilenceIDs []string `json:"silenceIDs"`
+}
+
+// ServeHTTP creates the silences of the request on POST, and expires the silences with the
+// IDs in the "id" query parameters on DELETE.
+func (h *bulkSilencesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.create(w, r)
+	case http.MethodDelete:
+		h.expire(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *bulkSilencesHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req bulkSilencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode the request body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	sils, err := req.toSilences()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The silences are all created, or none of them is: the ones already created are expired
+	// if any of the following can't be created.
+	ids := make([]string, 0, len(sils))
+	for i, sil := range sils {
+		id, err := h.silences.Set(sil)
+		if err != nil {
+			h.rollback(ids)
+			http.Error(w, fmt.Sprintf("failed to create the silence for matcher set %d: %s", i, err), http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	util.WriteJSONResponse(w, bulkSilencesResponse{SilenceIDs: ids})
+}
+
+func (h *bulkSilencesHandler) rollback(ids []string) {
+	for _, id := range ids {
+		if err := h.silences.Expire(id); err != nil {
+			level.Warn(h.logger).Log("msg", "failed to expire silence created by a failed bulk request", "id", id, "err", err)
+		}
+	}
+}
+
+func (h *bulkSilencesHandler) expire(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["id"]
+	if len(ids) == 0 {
+		http.Error(w, "no silence ID", http.StatusBadRequest)
+		return
+	}
+	if len(ids) > maxBulkSilences {
+		http.Error(w, fmt.Sprintf("too many silence IDs: the max is %d", maxBulkSilences), http.StatusBadRequest)
+		return
+	}
+
+	// Check that all silences exist before expiring any of them.
+	for _, id := range ids {
+		if _, err := h.silences.QueryOne(silence.QIDs(id)); err != nil {
+			if errors.Is(err, silence.ErrNotFound) {
+				http.Error(w, fmt.Sprintf("silence %s not found", id), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	for _, id := range ids {
+		if err := h.silences.Expire(id); err != nil {
+			http.Error(w, fmt.Sprintf("failed to expire silence %s: %s", id, err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	util.WriteJSONResponse(w, bulkSilencesResponse{SilenceIDs: ids})
+}
+
+// toSilences validates the request and returns the silences to create.
+func (req *bulkSilencesRequest) toSilences() ([]*silencepb.Silence, error) {
+	if len(req.MatcherSets) == 0 {
+		return nil, errors.New("no matcher set")
+	}
+	if len(req.MatcherSets) > maxBulkSilences {
+		return nil, fmt.Errorf("too many matcher sets: the max is %d", maxBulkSilences)
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		return nil, errors.New("start and end time are required")
+	}
+	if !req.StartsAt.Before(req.EndsAt) {
+		return nil, errors.New("start time must be before end time")
+	}
+	if req.EndsAt.Before(time.Now()) {
+		return nil, errors.New("end time can't be in the past")
+	}
+
+	sils := make([]*silencepb.Silence, 0, len(req.MatcherSets))
+	for i, set := range req.MatcherSets {
+		if len(set) == 0 {
+			return nil, fmt.Errorf("matcher set %d: at least one matcher required", i)
+		}
+
+		sil := &silencepb.Silence{
+			StartsAt:  req.StartsAt,
+			EndsAt:    req.EndsAt,
+			CreatedBy: req.CreatedBy,
+			Comment:   req.Comment,
+		}
+		for j, m := range set {
+			matcher := m.toProto()
+			if err := silence.ValidateMatcher(matcher); err != nil {
+				return nil, fmt.Errorf("matcher set %d: invalid label matcher %d: %s", i, j, err)
+			}
+			sil.Matchers = append(sil.Matchers, matcher)
+		}
+		sils = append(sils, sil)
+	}
+	return sils, nil
+}
+
+func (m bulkSilencesMatcher) toProto() *silencepb.Matcher {
+	isEqual := m.IsEqual == nil || *m.IsEqual
+
+	matcher := &silencepb.Matcher{Name: m.Name, Pattern: m.Value}
+	switch {
+	case isEqual && !m.IsRegex:
+		matcher.Type = silencepb.Matcher_EQUAL
+	case !isEqual && !m.IsRegex:
+		matcher.Type = silencepb.Matcher_NOT_EQUAL
+	case isEqual && m.IsRegex:
+		matcher.Type = silencepb.Matcher_REGEXP
+	default:
+		matcher.Type = silencepb.Matcher_NOT_REGEXP
+	}
+	return matcher
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkSilencesHandler(t *testing.T) {
+	silences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	h := &bulkSilencesHandler{silences: silences, logger: log.NewNopLogger()}
+
+	startsAt := time.Now().Add(-time.Minute).UTC()
+	endsAt := time.Now().Add(time.Hour).UTC()
+
+	createReq := func(matcherSets string) *httptest.ResponseRecorder {
+		body := `{
+			"matcherSets": ` + matcherSets + `,
+			"startsAt": "` + startsAt.Format(time.RFC3339Nano) + `",
+			"endsAt": "` + endsAt.Format(time.RFC3339Nano) + `",
+			"createdBy": "maintenance-bot",
+			"comment": "database maintenance"
+		}`
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/silences/bulk", strings.NewReader(body)))
+		return rec
+	}
+
+	activeSilences := func() int {
+		count, err := silences.CountState(types.SilenceStateActive)
+		require.NoError(t, err)
+		return count
+	}
+
+	// Create the silences.
+	rec := createReq(`[
+		[{"name": "cluster", "value": "eu-west"}, {"name": "namespace", "value": "db-.*", "isRegex": true}],
+		[{"name": "cluster", "value": "us-east", "isEqual": false}]
+	]`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res bulkSilencesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.SilenceIDs, 2)
+	assert.Equal(t, 2, activeSilences())
+
+	first, err := silences.QueryOne(silence.QIDs(res.SilenceIDs[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "database maintenance", first.Comment)
+	assert.Equal(t, "maintenance-bot", first.CreatedBy)
+	require.Len(t, first.Matchers, 2)
+	assert.Equal(t, "namespace", first.Matchers[1].Name)
+	assert.Equal(t, "db-.*", first.Matchers[1].Pattern)
+
+	// No silence is created if any of them is invalid.
+	rec = createReq(`[
+		[{"name": "cluster", "value": "eu-central"}],
+		[{"name": "cluster", "value": ""}]
+	]`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, 2, activeSilences())
+
+	rec = createReq(`[[{"name": "cluster", "value": "eu-central"}], []]`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, 2, activeSilences())
+
+	// Expiring unknown silences doesn't expire any of them.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/silences/bulk?id="+res.SilenceIDs[0]+"&id=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, 2, activeSilences())
+
+	// Expire the silences.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/silences/bulk?id="+res.SilenceIDs[0]+"&id="+res.SilenceIDs[1], nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 0, activeSilences())
+}
+
+func TestBulkSilencesRequest_ToSilences(t *testing.T) {
+	now := time.Now()
+	matcherSets := [][]bulkSilencesMatcher{{{Name: "cluster", Value: "eu-west"}}}
+
+	tests := map[string]struct {
+		req         bulkSilencesRequest
+		expectedErr string
+	}{
+		"valid": {
+			req: bulkSilencesRequest{MatcherSets: matcherSets, StartsAt: now, EndsAt: now.Add(time.Hour)},
+		},
+		"no matcher set": {
+			req:         bulkSilencesRequest{StartsAt: now, EndsAt: now.Add(time.Hour)},
+			expectedErr: "no matcher set",
+		},
+		"too many matcher sets": {
+			req:         bulkSilencesRequest{MatcherSets: make([][]bulkSilencesMatcher, maxBulkSilences+1), StartsAt: now, EndsAt: now.Add(time.Hour)},
+			expectedErr: "too many matcher sets",
+		},
+		"missing end time": {
+			req:         bulkSilencesRequest{MatcherSets: matcherSets, StartsAt: now},
+			expectedErr: "start and end time are required",
+		},
+		"end time before start time": {
+			req:         bulkSilencesRequest{MatcherSets: matcherSets, StartsAt: now, EndsAt: now.Add(-time.Minute)},
+			expectedErr: "start time must be before end time",
+		},
+		"end time in the past": {
+			req:         bulkSilencesRequest{MatcherSets: matcherSets, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(-time.Minute)},
+			expectedErr: "end time can't be in the past",
+		},
+		"invalid regexp": {
+			req:         bulkSilencesRequest{MatcherSets: [][]bulkSilencesMatcher{{{Name: "cluster", Value: "(", IsRegex: true}}}, StartsAt: now, EndsAt: now.Add(time.Hour)},
+			expectedErr: "matcher set 0: invalid label matcher 0",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			sils, err := testData.req.toSilences()
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, sils, len(testData.req.MatcherSets))
+		})
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -76,11 +78,11 @@ func (d *Distributor) isQuorumWritePath(p string) bool {
 }
 
 func (d *Distributor) isUnaryWritePath(p string) bool {
-	return strings.HasSuffix(p, "/silences")
+	return strings.HasSuffix(p, "/silences") || strings.HasSuffix(p, "/silences/bulk")
 }
 
 func (d *Distributor) isUnaryDeletePath(p string) bool {
-	return strings.HasSuffix(path.Dir(p), "/silence")
+	return strings.HasSuffix(path.Dir(p), "/silence") || strings.HasSuffix(p, "/silences/bulk")
 }
 
 func (d *Distributor) isQuorumReadPath(p string) (bool, merger.Merger) {
@@ -108,6 +110,41 @@ func (d *Distributor) isQuorumReadPath(p string) (bool, merger.Merger) {
 	return false, nil
 }
 
+// v2AlertsMerger returns the merger of the GET /v2/alerts responses, filtering, sorting and paginating the
+// alerts as requested by the query parameters. The other parameters are handled by the Alertmanager API.
+func v2AlertsMerger(query url.Values) (merger.V2Alerts, error) {
+	m := merger.V2Alerts{}
+
+	// The Alertmanager API accepts the unprocessed parameter, but ignores it.
+	if v := query.Get("unprocessed"); v != "" {
+		unprocessed, err := strconv.ParseBool(v)
+		if err != nil {
+			return m, errors.Wrap(err, "invalid unprocessed parameter")
+		}
+		m.ExcludeUnprocessed = !unprocessed
+	}
+
+	// A leading minus sorts in descending order, like "-startsAt".
+	m.SortBy = query.Get("sort")
+	if strings.HasPrefix(m.SortBy, "-") {
+		m.SortBy, m.SortDesc = m.SortBy[1:], true
+	}
+
+	for name, value := range map[string]*int{"offset": &m.Offset, "limit": &m.Limit} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return m, errors.Wrapf(err, "invalid %s parameter", name)
+		}
+		*value = n
+	}
+
+	return m, m.Validate()
+}
+
 // DistributeRequest shards the writes and returns as soon as the quorum is satisfied.
 // In case of reads, it proxies the request to one of the alertmanagers.
 func (d *Distributor) DistributeRequest(w http.ResponseWriter, r *http.Request) {
@@ -140,6 +177,12 @@ func (d *Distributor) DistributeRequest(w http.ResponseWriter, r *http.Request)
 	}
 	if r.Method == http.MethodGet || r.Method == http.MethodHead {
 		if ok, m := d.isQuorumReadPath(r.URL.Path); ok {
+			if _, isV2Alerts := m.(merger.V2Alerts); isV2Alerts {
+				if m, err = v2AlertsMerger(r.URL.Query()); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
 			d.doQuorum(userID, w, r, logger, m)
 			return
 		}
@@ -34,6 +34,7 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/grafana/mimir/pkg/alertmanager/alertmanagerpb"
+	"github.com/grafana/mimir/pkg/alertmanager/merger"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
 
@@ -104,6 +105,16 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 			expectedTotalCalls: 3,
 			route:              "/v2/alerts",
 			responseBody:       []byte(`[]`),
+		}, {
+			name:                "Read /v2/alerts with an unsupported sort field is rejected",
+			numAM:               5,
+			numHappyAM:          5,
+			replicationFactor:   3,
+			isRead:              true,
+			expStatusCode:       http.StatusBadRequest,
+			expectedTotalCalls:  0,
+			headersNotPreserved: true,
+			route:               "/v2/alerts?sort=status",
 		}, {
 			name:               "Read /v2/alerts/groups is sent to 3 AMs",
 			numAM:              5,
@@ -151,6 +162,23 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 			expStatusCode:      http.StatusOK,
 			expectedTotalCalls: 1,
 			route:              "/silences",
+		}, {
+			name:               "Write /silences/bulk is sent to only 1 AM",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 1,
+			route:              "/silences/bulk",
+		}, {
+			name:               "Delete /silences/bulk is sent to only 1 AM",
+			numAM:              5,
+			numHappyAM:         5,
+			replicationFactor:  3,
+			isDelete:           true,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 1,
+			route:              "/silences/bulk",
 		}, {
 			name:               "Read /v1/silence/id is sent to 3 AMs",
 			numAM:              5,
@@ -292,6 +320,62 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 
 }
 
+func TestV2AlertsMerger(t *testing.T) {
+	cases := map[string]struct {
+		query       string
+		expected    merger.V2Alerts
+		expectedErr string
+	}{
+		"no parameters": {
+			query:    "",
+			expected: merger.V2Alerts{},
+		},
+		"only Alertmanager API parameters": {
+			query:    `filter=alertname%3D~"High.*"&active=true&silenced=false`,
+			expected: merger.V2Alerts{},
+		},
+		"all parameters": {
+			query:    "unprocessed=false&sort=-labels.team&offset=20&limit=10",
+			expected: merger.V2Alerts{ExcludeUnprocessed: true, SortBy: "labels.team", SortDesc: true, Offset: 20, Limit: 10},
+		},
+		"unprocessed alerts included": {
+			query:    "unprocessed=true&sort=startsAt",
+			expected: merger.V2Alerts{SortBy: "startsAt"},
+		},
+		"invalid unprocessed": {
+			query:       "unprocessed=maybe",
+			expectedErr: `invalid unprocessed parameter: strconv.ParseBool: parsing "maybe": invalid syntax`,
+		},
+		"invalid limit": {
+			query:       "limit=ten",
+			expectedErr: `invalid limit parameter: strconv.Atoi: parsing "ten": invalid syntax`,
+		},
+		"negative offset": {
+			query:       "offset=-1",
+			expectedErr: "offset must be greater than or equal to 0",
+		},
+		"unsupported sort field": {
+			query:       "sort=-status",
+			expectedErr: `unsupported sort field "status"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			query, err := url.ParseQuery(c.query)
+			require.NoError(t, err)
+
+			m, err := v2AlertsMerger(query)
+			if c.expectedErr != "" {
+				require.EqualError(t, err, c.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, m)
+		})
+	}
+}
+
 func prepare(t *testing.T, numAM, numHappyAM, replicationFactor int, responseBody []byte) (*Distributor, []*mockAlertmanager, func()) {
 	ams := []*mockAlertmanager{}
 	for i := 0; i < numHappyAM; i++ {
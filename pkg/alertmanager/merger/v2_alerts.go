@@ -7,19 +7,55 @@ package merger
 
 import (
 	"errors"
+	"fmt"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	v2_models "github.com/prometheus/alertmanager/api/v2/models"
 )
 
+// V2AlertsSortLabelPrefix is the prefix of the V2Alerts.SortBy values sorting the alerts by the value of a label.
+const V2AlertsSortLabelPrefix = "labels."
+
 // V2Alerts implements the Merger interface for GET /v2/alerts. It returns the union
 // of alerts over all the responses. When the same alert exists in multiple responses, the
 // instance of that alert with the most recent UpdatedAt timestamp is returned in the response.
-type V2Alerts struct{}
+//
+// The merged alerts are then filtered, sorted and paginated as configured, since the
+// Alertmanager API doesn't support it and each response only holds the alerts of a replica.
+type V2Alerts struct {
+	// ExcludeUnprocessed filters out the alerts in the unprocessed state.
+	ExcludeUnprocessed bool
+
+	// SortBy is the field the alerts are sorted by: fingerprint (the default), startsAt, endsAt,
+	// updatedAt, or the value of a label, with the label name prefixed by V2AlertsSortLabelPrefix.
+	// The alerts with the same value are sorted by fingerprint.
+	SortBy   string
+	SortDesc bool
+
+	// Offset is the number of alerts skipped, and Limit the max number of alerts returned, 0 for no limit.
+	Offset int
+	Limit  int
+}
+
+// Validate returns an error if the configured sorting or pagination is invalid.
+func (m V2Alerts) Validate() error {
+	if _, err := v2AlertsSortKey(m.SortBy); err != nil {
+		return err
+	}
+	if m.Offset < 0 {
+		return errors.New("offset must be greater than or equal to 0")
+	}
+	if m.Limit < 0 {
+		return errors.New("limit must be greater than or equal to 0")
+	}
+	return nil
+}
 
-func (V2Alerts) MergeResponses(in [][]byte) ([]byte, error) {
+func (m V2Alerts) MergeResponses(in [][]byte) ([]byte, error) {
 	alerts := make(v2_models.GettableAlerts, 0)
 	for _, body := range in {
 		parsed := make(v2_models.GettableAlerts, 0)
@@ -34,9 +70,87 @@ func (V2Alerts) MergeResponses(in [][]byte) ([]byte, error) {
 		return nil, err
 	}
 
+	merged, err = m.page(merged)
+	if err != nil {
+		return nil, err
+	}
+
 	return swag.WriteJSON(merged)
 }
 
+// page filters, sorts and paginates the alerts, sorted by fingerprint.
+func (m V2Alerts) page(alerts v2_models.GettableAlerts) (v2_models.GettableAlerts, error) {
+	if m.ExcludeUnprocessed {
+		filtered := alerts[:0]
+		for _, alert := range alerts {
+			if alert.Status == nil || alert.Status.State == nil || *alert.Status.State != v2_models.AlertStatusStateUnprocessed {
+				filtered = append(filtered, alert)
+			}
+		}
+		alerts = filtered
+	}
+
+	key, err := v2AlertsSortKey(m.SortBy)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil || m.SortDesc {
+		// The alerts are already sorted by fingerprint, so the stable sort keeps them sorted by
+		// fingerprint when the keys are equal.
+		sort.SliceStable(alerts, func(i, j int) bool {
+			if key == nil {
+				return *alerts[i].Fingerprint > *alerts[j].Fingerprint
+			}
+			ki, kj := key(alerts[i]), key(alerts[j])
+			if m.SortDesc {
+				return ki > kj
+			}
+			return ki < kj
+		})
+	}
+
+	if m.Offset >= len(alerts) {
+		return v2_models.GettableAlerts{}, nil
+	}
+	alerts = alerts[m.Offset:]
+	if m.Limit > 0 && m.Limit < len(alerts) {
+		alerts = alerts[:m.Limit]
+	}
+	return alerts, nil
+}
+
+// v2AlertsSortKey returns the function returning the key the alerts are sorted by, for the input V2Alerts.SortBy.
+// The returned function is nil when sorting by fingerprint.
+func v2AlertsSortKey(sortBy string) (func(*v2_models.GettableAlert) string, error) {
+	timeKey := func(field func(*v2_models.GettableAlert) *strfmt.DateTime) func(*v2_models.GettableAlert) string {
+		return func(alert *v2_models.GettableAlert) string {
+			if t := field(alert); t != nil {
+				// The fixed length format in UTC sorts as the timestamps.
+				return time.Time(*t).UTC().Format("2006-01-02T15:04:05.000000000Z")
+			}
+			return ""
+		}
+	}
+
+	switch {
+	case sortBy == "" || sortBy == "fingerprint":
+		return nil, nil
+	case sortBy == "startsAt":
+		return timeKey(func(alert *v2_models.GettableAlert) *strfmt.DateTime { return alert.StartsAt }), nil
+	case sortBy == "endsAt":
+		return timeKey(func(alert *v2_models.GettableAlert) *strfmt.DateTime { return alert.EndsAt }), nil
+	case sortBy == "updatedAt":
+		return timeKey(func(alert *v2_models.GettableAlert) *strfmt.DateTime { return alert.UpdatedAt }), nil
+	case strings.HasPrefix(sortBy, V2AlertsSortLabelPrefix) && len(sortBy) > len(V2AlertsSortLabelPrefix):
+		name := strings.TrimPrefix(sortBy, V2AlertsSortLabelPrefix)
+		return func(alert *v2_models.GettableAlert) string {
+			return alert.Labels[name]
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sort field %q", sortBy)
+	}
+}
+
 func mergeV2Alerts(in v2_models.GettableAlerts) (v2_models.GettableAlerts, error) {
 	// Select the most recently updated alert for each distinct alert.
 	alerts := make(map[string]*v2_models.GettableAlert)
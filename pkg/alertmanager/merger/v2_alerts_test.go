@@ -165,3 +165,90 @@ func TestMergeV2Alerts(t *testing.T) {
 		})
 	}
 }
+
+func TestV2Alerts_Page(t *testing.T) {
+	withStartsAt := func(alert *v2_models.GettableAlert, startsAt, team, state string) *v2_models.GettableAlert {
+		alert.StartsAt = v2ParseTime(startsAt)
+		alert.Labels = v2_models.LabelSet{"team": team}
+		alert.Status = &v2_models.AlertStatus{State: &state}
+		return alert
+	}
+	var (
+		alert1 = withStartsAt(v2alert("1111111111111111", "a1", "2020-01-01T12:00:00.000Z"), "2020-01-01T10:00:00.000Z", "b", v2_models.AlertStatusStateActive)
+		alert2 = withStartsAt(v2alert("2222222222222222", "a2", "2020-01-01T12:00:00.000Z"), "2020-01-01T09:00:00.000Z", "a", v2_models.AlertStatusStateUnprocessed)
+		alert3 = withStartsAt(v2alert("3333333333333333", "a3", "2020-01-01T12:00:00.000Z"), "2020-01-01T11:00:00.000Z", "b", v2_models.AlertStatusStateSuppressed)
+		alert4 = withStartsAt(v2alert("4444444444444444", "a4", "2020-01-01T12:00:00.000Z"), "2020-01-01T09:30:00.000Z", "a", v2_models.AlertStatusStateActive)
+	)
+
+	cases := map[string]struct {
+		merger V2Alerts
+		out    v2_models.GettableAlerts
+	}{
+		"no options": {
+			merger: V2Alerts{},
+			out:    v2alerts(alert1, alert2, alert3, alert4),
+		},
+		"exclude unprocessed": {
+			merger: V2Alerts{ExcludeUnprocessed: true},
+			out:    v2alerts(alert1, alert3, alert4),
+		},
+		"sort by fingerprint descending": {
+			merger: V2Alerts{SortDesc: true},
+			out:    v2alerts(alert4, alert3, alert2, alert1),
+		},
+		"sort by startsAt": {
+			merger: V2Alerts{SortBy: "startsAt"},
+			out:    v2alerts(alert2, alert4, alert1, alert3),
+		},
+		"sort by startsAt descending": {
+			merger: V2Alerts{SortBy: "startsAt", SortDesc: true},
+			out:    v2alerts(alert3, alert1, alert4, alert2),
+		},
+		"sort by label, then by fingerprint": {
+			merger: V2Alerts{SortBy: "labels.team"},
+			out:    v2alerts(alert2, alert4, alert1, alert3),
+		},
+		"sort by label descending, then by fingerprint": {
+			merger: V2Alerts{SortBy: "labels.team", SortDesc: true},
+			out:    v2alerts(alert1, alert3, alert2, alert4),
+		},
+		"limit": {
+			merger: V2Alerts{Limit: 2},
+			out:    v2alerts(alert1, alert2),
+		},
+		"offset and limit": {
+			merger: V2Alerts{SortBy: "startsAt", Offset: 1, Limit: 2},
+			out:    v2alerts(alert4, alert1),
+		},
+		"limit greater than the number of alerts": {
+			merger: V2Alerts{Offset: 3, Limit: 2},
+			out:    v2alerts(alert4),
+		},
+		"offset greater than the number of alerts": {
+			merger: V2Alerts{Offset: 4},
+			out:    v2_models.GettableAlerts{},
+		},
+		"all options": {
+			merger: V2Alerts{ExcludeUnprocessed: true, SortBy: "startsAt", SortDesc: true, Offset: 1, Limit: 1},
+			out:    v2alerts(alert1),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, c.merger.Validate())
+
+			out, err := c.merger.page(v2alerts(alert1, alert2, alert3, alert4))
+			require.NoError(t, err)
+			require.Equal(t, c.out, out)
+		})
+	}
+}
+
+func TestV2Alerts_Validate(t *testing.T) {
+	require.NoError(t, V2Alerts{SortBy: "updatedAt", Offset: 10, Limit: 10}.Validate())
+	require.EqualError(t, V2Alerts{SortBy: "labels."}.Validate(), `unsupported sort field "labels."`)
+	require.EqualError(t, V2Alerts{SortBy: "status"}.Validate(), `unsupported sort field "status"`)
+	require.EqualError(t, V2Alerts{Offset: -1}.Validate(), "offset must be greater than or equal to 0")
+	require.EqualError(t, V2Alerts{Limit: -1}.Validate(), "limit must be greater than or equal to 0")
+}
@@ -61,6 +61,7 @@ var (
 	errInvalidExternalURLMissingHostname   = errors.New("the configured external URL is invalid because it's missing the hostname")
 	errZoneAwarenessEnabledWithoutZoneInfo = errors.New("the configured alertmanager has zone awareness enabled but zone is not set")
 	errNotUploadingFallback                = errors.New("not uploading fallback configuration")
+	errInstanceNotInReplicationSet         = errors.New("this alertmanager instance is not a replica of the tenant")
 )
 
 // MultitenantAlertmanagerConfig is the configuration for a multitenant Alertmanager.
@@ -78,6 +79,8 @@ type MultitenantAlertmanagerConfig struct {
 
 	PeerTimeout time.Duration `yaml:"peer_timeout" category:"advanced"`
 
+	NotificationCoordinationEnabled bool `yaml:"notification_coordination_enabled" category:"experimental"`
+
 	EnableAPI bool `yaml:"enable_api" category:"advanced"`
 
 	MaxConcurrentGetRequestsPerTenant int `yaml:"max_concurrent_get_requests_per_tenant" category:"advanced"`
@@ -87,6 +90,10 @@ type MultitenantAlertmanagerConfig struct {
 
 	// For the state persister.
 	Persister PersisterConfig `yaml:",inline"`
+
+	ReceiverSecrets ReceiverSecretsConfig `yaml:"receiver_secrets"`
+
+	AlertEnrichment AlertEnrichmentConfig `yaml:"alert_enrichment"`
 }
 
 const (
@@ -111,8 +118,11 @@ func (cfg *MultitenantAlertmanagerConfig) RegisterFlags(f *flag.FlagSet, logger
 	cfg.AlertmanagerClient.RegisterFlagsWithPrefix("alertmanager.alertmanager-client", f)
 	cfg.Persister.RegisterFlagsWithPrefix("alertmanager", f)
 	cfg.ShardingRing.RegisterFlags(f, logger)
+	cfg.ReceiverSecrets.RegisterFlagsWithPrefix("alertmanager.receiver-secrets", f)
+	cfg.AlertEnrichment.RegisterFlagsWithPrefix("alertmanager.alert-enrichment", f)
 
 	f.DurationVar(&cfg.PeerTimeout, "alertmanager.peer-timeout", defaultPeerTimeout, "Time to wait between peers to send notifications.")
+	f.BoolVar(&cfg.NotificationCoordinationEnabled, "alertmanager.notification-coordination-enabled", false, "If enabled, only the first healthy replica of each tenant in the ring (the leader) dispatches the notifications, instead of all replicas dispatching them after waiting for the peer timeout. The leadership automatically fails over to another replica when the leader becomes unhealthy. This reduces the duplicated notifications during replica failures.")
 }
 
 // Validate config and returns error on failure
@@ -220,6 +230,10 @@ type Limits interface {
 	// AlertmanagerMaxAlertsSizeBytes returns total max size of alerts that tenant can have active at the same time. 0 = no limit.
 	// Size of the alert is computed from alert labels, annotations and generator URL.
 	AlertmanagerMaxAlertsSizeBytes(tenant string) int
+
+	// AlertmanagerAlertEnrichmentURL returns the URL of the HTTP service enriching the alerts of the tenant with
+	// additional annotations before they're notified. Empty = enrichment disabled.
+	AlertmanagerAlertEnrichmentURL(tenant string) string
 }
 
 // A MultitenantAlertmanager manages Alertmanager instances for multiple
@@ -269,12 +283,19 @@ type MultitenantAlertmanager struct {
 
 	limits Limits
 
+	// Resolves the secrets referenced by the receiver configs. Nil if disabled.
+	secretsResolver *ReceiverSecretsResolver
+
 	registry          prometheus.Registerer
 	ringCheckErrors   prometheus.Counter
 	tenantsOwned      prometheus.Gauge
 	tenantsDiscovered prometheus.Gauge
 	syncTotal         *prometheus.CounterVec
 	syncFailures      *prometheus.CounterVec
+
+	tenantsFrozen                prometheus.Gauge
+	tenantFrozen                 *prometheus.GaugeVec
+	frozenTenantRejectedRequests prometheus.Counter
 }
 
 // NewMultitenantAlertmanager creates a new MultitenantAlertmanager.
@@ -367,6 +388,22 @@ func createMultitenantAlertmanager(cfg *MultitenantAlertmanagerConfig, fallbackC
 			Name: "cortex_alertmanager_tenants_owned",
 			Help: "Current number of tenants owned by the Alertmanager instance.",
 		}),
+		tenantsFrozen: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_alertmanager_tenants_frozen",
+			Help: "Current number of tenants owned by the Alertmanager instance whose configuration and silences are frozen in read-only mode.",
+		}),
+		tenantFrozen: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_alertmanager_tenant_frozen",
+			Help: "Whether the configuration and silences of the tenant are frozen in read-only mode. Only the tenants owned by the Alertmanager instance are tracked.",
+		}, []string{"user"}),
+		frozenTenantRejectedRequests: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_alertmanager_frozen_tenant_rejected_requests_total",
+			Help: "Total number of requests changing the configuration or silences of a frozen tenant which have been rejected.",
+		}),
+	}
+
+	if cfg.ReceiverSecrets.Enabled {
+		am.secretsResolver = NewReceiverSecretsResolver(cfg.ReceiverSecrets, am.logger, registerer)
 	}
 
 	// Initialize the top-level metrics.
@@ -530,6 +567,7 @@ func (am *MultitenantAlertmanager) loadAndSyncConfigs(ctx context.Context, syncR
 	}
 
 	am.syncConfigs(cfgs)
+	am.syncFrozenTenants(ctx, cfgs)
 	am.deleteUnusedLocalUserState()
 
 	// Note when cleaning up remote state, remember that the user may not necessarily be configured
@@ -638,6 +676,9 @@ func (am *MultitenantAlertmanager) syncConfigs(cfgs map[string]alertspb.AlertCon
 			am.multitenantMetrics.lastReloadSuccessful.DeleteLabelValues(userID)
 			am.multitenantMetrics.lastReloadSuccessfulTimestamp.DeleteLabelValues(userID)
 			am.alertmanagerMetrics.removeUserRegistry(userID)
+			if am.secretsResolver != nil {
+				am.secretsResolver.removeTenant(userID)
+			}
 		}
 	}
 	am.alertmanagersMtx.Unlock()
@@ -778,6 +819,9 @@ func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amco
 		Store:                             am.store,
 		PersisterConfig:                   am.cfg.Persister,
 		Limits:                            am.limits,
+		NotificationCoordinationEnabled:   am.cfg.NotificationCoordinationEnabled,
+		SecretsResolver:                   am.secretsResolver,
+		AlertEnrichment:                   am.cfg.AlertEnrichment,
 	}, reg)
 	if err != nil {
 		return nil, fmt.Errorf("unable to start Alertmanager for user %v: %v", userID, err)
@@ -793,28 +837,37 @@ func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amco
 
 // GetPositionForUser returns the position this Alertmanager instance holds in the ring related to its other replicas for an specific user.
 func (am *MultitenantAlertmanager) GetPositionForUser(userID string) int {
+	position, err := am.LookupPositionForUser(userID)
+	if err != nil && !errors.Is(err, errInstanceNotInReplicationSet) {
+		level.Error(am.logger).Log("msg", "unable to read the ring while trying to determine the alertmanager position", "err", err)
+	}
+
+	// If we're unable to determine the position, we don't want a tenant to miss out on the notification - instead,
+	// just assume we're the first in line and run the risk of a double notification.
+	return position
+}
+
+// LookupPositionForUser returns the position this Alertmanager instance holds in the ring related to its other replicas
+// for an specific user. Unlike GetPositionForUser, it returns an error if the ring can't be read, and
+// errInstanceNotInReplicationSet if this instance isn't one of the replicas of the user.
+func (am *MultitenantAlertmanager) LookupPositionForUser(userID string) (int, error) {
 	// If we have a replication factor of 1 or less we don't need to do any work and can immediately return.
 	if am.ring == nil || am.ring.ReplicationFactor() <= 1 {
-		return 0
+		return 0, nil
 	}
 
 	set, err := am.ring.Get(shardByUser(userID), RingOp, nil, nil, nil)
 	if err != nil {
-		level.Error(am.logger).Log("msg", "unable to read the ring while trying to determine the alertmanager position", "err", err)
-		// If we're  unable to determine the position, we don't want a tenant to miss out on the notification - instead,
-		// just assume we're the first in line and run the risk of a double notification.
-		return 0
+		return 0, err
 	}
 
-	var position int
 	for i, instance := range set.Instances {
 		if instance.Addr == am.ringLifecycler.GetInstanceAddr() {
-			position = i
-			break
+			return i, nil
 		}
 	}
 
-	return position
+	return 0, errInstanceNotInReplicationSet
 }
 
 // ServeHTTP serves the Alertmanager's web UI and API.
@@ -824,6 +877,19 @@ func (am *MultitenantAlertmanager) ServeHTTP(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	// The silences of a frozen tenant can't be changed. The check is done here, before the request
+	// is distributed to the replicas.
+	if am.isSilencesMutation(req) {
+		userID, err := tenant.TenantID(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if am.rejectIfTenantFrozen(w, req, userID) {
+			return
+		}
+	}
+
 	am.distributor.DistributeRequest(w, req)
 }
 
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// notificationCoordinator elects, for each tenant, a single replica (the leader) which is the only one
+// dispatching the notifications, instead of having all replicas dispatching them with a delay based on
+// their position and relying on the notification log to deduplicate them.
+//
+// The leader is the first healthy replica of the tenant in the ring, so the leadership automatically
+// fails over to another replica once the leader is detected as unhealthy. The notifications already
+// sent by the previous leader are not sent again by the new one, because the notification log is
+// replicated between the replicas.
+//
+// If the position of this replica can't be determined, because the ring can't be read or this replica
+// isn't one of the replicas of the tenant, this replica isn't the leader but still dispatches the
+// notifications after waiting for the peer timeout based on its position, like when the notification
+// coordination is disabled.
+type notificationCoordinator struct {
+	position func() (int, error)
+	logger   log.Logger
+
+	mtx      sync.Mutex
+	leader   bool
+	resolved bool
+
+	leaderGauge   prometheus.Gauge
+	leaderChanges prometheus.Counter
+	skipped       prometheus.Counter
+}
+
+func newNotificationCoordinator(position func() (int, error), logger log.Logger, reg prometheus.Registerer) *notificationCoordinator {
+	return &notificationCoordinator{
+		position: position,
+		logger:   logger,
+		resolved: true,
+		leaderGauge: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "alertmanager_notification_coordination_leader",
+			Help: "Whether this replica is the leader dispatching the notifications of the tenant (1) or not (0).",
+		}),
+		leaderChanges: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_notification_coordination_leader_changes_total",
+			Help: "Number of times this replica has gained or lost the leadership to dispatch the notifications of the tenant.",
+		}),
+		skipped: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_notification_coordination_skipped_total",
+			Help: "Number of aggregation group flushes not dispatched because this replica is not the leader of the tenant.",
+		}),
+	}
+}
+
+// resolveLeadership returns whether this replica is currently the leader, and whether the leadership
+// could be determined at all. It tracks the leadership changes.
+func (c *notificationCoordinator) resolveLeadership() (leader, resolved bool) {
+	position, err := c.position()
+	resolved = err == nil
+	leader = resolved && position == 0
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !resolved && c.resolved {
+		level.Warn(c.logger).Log("msg", "unable to determine the leader dispatching the notifications, falling back to dispatching them after waiting for the peer timeout", "err", err)
+	}
+	c.resolved = resolved
+
+	if leader != c.leader {
+		c.leader = leader
+		c.leaderChanges.Inc()
+
+		if leader {
+			level.Info(c.logger).Log("msg", "this replica is now the leader dispatching the notifications")
+			c.leaderGauge.Set(1)
+		} else {
+			level.Info(c.logger).Log("msg", "this replica is not the leader dispatching the notifications anymore")
+			c.leaderGauge.Set(0)
+		}
+	}
+
+	return leader, resolved
+}
+
+// isLeader returns whether this replica was the leader the last time the leadership was resolved.
+func (c *notificationCoordinator) isLeader() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.leader
+}
+
+// wrap returns a stage which runs the input stage only if this replica is the leader, or if the leadership
+// can't be determined.
+func (c *notificationCoordinator) wrap(next notify.Stage) notify.Stage {
+	return notify.StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		if leader, resolved := c.resolveLeadership(); resolved && !leader {
+			c.skipped.Inc()
+			return ctx, nil, nil
+		}
+
+		return next.Exec(ctx, l, alerts...)
+	})
+}
+
+// waitFunc returns a function which doesn't wait for the other replicas if this replica is the leader,
+// and falls back to the input wait function otherwise.
+func (c *notificationCoordinator) waitFunc(fallback func() time.Duration) func() time.Duration {
+	return func() time.Duration {
+		if c.isLeader() {
+			return 0
+		}
+		return fallback()
+	}
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationCoordinator(t *testing.T) {
+	position := 1
+	var positionErr error
+	executions := 0
+
+	reg := prometheus.NewPedanticRegistry()
+	c := newNotificationCoordinator(func() (int, error) { return position, positionErr }, log.NewNopLogger(), reg)
+	wait := c.waitFunc(func() time.Duration { return time.Minute })
+
+	stage := c.wrap(notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		executions++
+		return ctx, alerts, nil
+	}))
+
+	exec := func() []*types.Alert {
+		_, alerts, err := stage.Exec(context.Background(), log.NewNopLogger(), &types.Alert{})
+		require.NoError(t, err)
+		return alerts
+	}
+
+	// A follower doesn't dispatch the notifications.
+	assert.Empty(t, exec())
+	assert.Equal(t, 0, executions)
+	assert.Equal(t, time.Minute, wait())
+
+	// The follower takes over the leadership once the leader becomes unhealthy.
+	position = 0
+	assert.Len(t, exec(), 1)
+	assert.Len(t, exec(), 1)
+	assert.Equal(t, 2, executions)
+	assert.Equal(t, time.Duration(0), wait())
+
+	// The leadership moves back to another replica.
+	position = 2
+	assert.Empty(t, exec())
+	assert.Equal(t, 2, executions)
+	assert.Equal(t, time.Minute, wait())
+
+	// If the ring can't be read, the replica isn't the leader, but dispatches the notifications after the peer wait.
+	position, positionErr = 0, errors.New("ring read error")
+	assert.Len(t, exec(), 1)
+	assert.Equal(t, 3, executions)
+	assert.Equal(t, time.Minute, wait())
+
+	// Same if the replica isn't one of the replicas of the tenant.
+	positionErr = errInstanceNotInReplicationSet
+	assert.Len(t, exec(), 1)
+	assert.Equal(t, 4, executions)
+	assert.Equal(t, time.Minute, wait())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP alertmanager_notification_coordination_leader Whether this replica is the leader dispatching the notifications of the tenant (1) or not (0).
+		# TYPE alertmanager_notification_coordination_leader gauge
+		alertmanager_notification_coordination_leader 0
+
+		# HELP alertmanager_notification_coordination_leader_changes_total Number of times this replica has gained or lost the leadership to dispatch the notifications of the tenant.
+		# TYPE alertmanager_notification_coordination_leader_changes_total counter
+		alertmanager_notification_coordination_leader_changes_total 2
+
+		# HELP alertmanager_notification_coordination_skipped_total Number of aggregation group flushes not dispatched because this replica is not the leader of the tenant.
+		# TYPE alertmanager_notification_coordination_skipped_total counter
+		alertmanager_notification_coordination_skipped_total 2
+	`)))
+}
@@ -0,0 +1,445 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/crypto/tls"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/exp/slices"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	// secretReferencePrefix is the prefix of the receiver config values referencing a secret
+	// in an external secrets store, in the form secret://<store>/<path>.
+	secretReferencePrefix = "secret://"
+
+	secretsStoreVault      = "vault"
+	secretsStoreKubernetes = "kubernetes"
+)
+
+var (
+	errReceiverSecretsDisabled = errors.New("the receiver config references a secret, but the resolution of the receiver secrets is disabled")
+)
+
+// ReceiverSecretsConfig configures the resolution of the secrets referenced by the receiver configs.
+type ReceiverSecretsConfig struct {
+	Enabled              bool          `yaml:"enabled" category:"experimental"`
+	KubernetesSecretsDir string        `yaml:"kubernetes_secrets_dir" category:"experimental"`
+	VaultPathPrefix      string        `yaml:"vault_path_prefix" category:"experimental"`
+	CacheTTL             time.Duration `yaml:"cache_ttl" category:"experimental"`
+
+	// VaultReader reads the secrets from Vault. Set only when Vault is enabled.
+	VaultReader tls.SecretReader `yaml:"-"`
+}
+
+// RegisterFlagsWithPrefix registers flags with prefix.
+func (cfg *ReceiverSecretsConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+".enabled", false, "Enable the resolution of the secrets referenced by the receiver configs, in the form secret://<store>/<path>, at notification time. Supported stores are: vault (requires -vault.enabled) and kubernetes. The secrets are looked up in the tenant's namespace of the store.")
+	f.StringVar(&cfg.KubernetesSecretsDir, prefix+".kubernetes-secrets-dir", "", "Directory where the Kubernetes Secrets holding the receiver secrets are mounted. The secret secret://kubernetes/<key> of a tenant is read from the file <dir>/<tenant>/<key>.")
+	f.StringVar(&cfg.VaultPathPrefix, prefix+".vault-path-prefix", "alertmanager", "Path prefix of the receiver secrets in Vault. The secret secret://vault/<path> of a tenant is read from <prefix>/<tenant>/<path>.")
+	f.DurationVar(&cfg.CacheTTL, prefix+".cache-ttl", 5*time.Minute, "How long the resolved receiver secrets are cached. Rotated secrets are picked up after this period. When a secret can't be resolved, the expired value is used, if any.")
+}
+
+// secretReference is a reference to a secret in an external secrets store.
+type secretReference struct {
+	store string
+	path  string
+}
+
+func (r secretReference) String() string {
+	return secretReferencePrefix + r.store + "/" + r.path
+}
+
+// parseSecretReference parses the input receiver config value, and returns false if it isn't a secret reference.
+func parseSecretReference(value string) (secretReference, bool, error) {
+	if !strings.HasPrefix(value, secretReferencePrefix) {
+		return secretReference{}, false, nil
+	}
+
+	store, p, _ := strings.Cut(strings.TrimPrefix(value, secretReferencePrefix), "/")
+	if store != secretsStoreVault && store != secretsStoreKubernetes {
+		return secretReference{}, true, fmt.Errorf("invalid secret reference %q: unsupported secrets store %q, supported stores are: %s, %s", value, store, secretsStoreVault, secretsStoreKubernetes)
+	}
+
+	// The path is relative to the tenant's namespace in the store, so it can't escape it.
+	if p == "" || path.IsAbs(p) || path.Clean(p) != p || p == ".." || strings.HasPrefix(p, "../") {
+		return secretReference{}, true, fmt.Errorf("invalid secret reference %q: the path must be a non-empty relative path", value)
+	}
+
+	return secretReference{store: store, path: p}, true, nil
+}
+
+// validateReceiverSecretReferences returns an error if the input receiver configs contain invalid secret references.
+func validateReceiverSecretReferences(receivers []config.Receiver) error {
+	for _, rcv := range receivers {
+		_, _, err := resolveSecretReferences(reflect.ValueOf(rcv), func(ref secretReference) (string, error) {
+			return ref.String(), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasSecretReferences returns whether the input receiver config contains secret references.
+func hasSecretReferences(rcv config.Receiver) bool {
+	// Invalid references have been rejected when the config was uploaded, so they're ignored here.
+	_, found, _ := resolveSecretReferences(reflect.ValueOf(rcv), func(ref secretReference) (string, error) {
+		return ref.String(), nil
+	})
+	return found
+}
+
+// resolveSecretReferences returns a copy of the input value with the secret references replaced by the
+// secrets returned by resolve. The parts of the value not containing secret references are shared with
+// the input value. Returns whether any secret reference has been found.
+func resolveSecretReferences(v reflect.Value, resolve func(ref secretReference) (string, error)) (reflect.Value, bool, error) {
+	switch v.Kind() {
+	case reflect.String:
+		ref, ok, err := parseSecretReference(v.String())
+		if !ok || err != nil {
+			return v, false, err
+		}
+
+		secret, err := resolve(ref)
+		if err != nil {
+			return v, true, err
+		}
+		return reflect.ValueOf(secret).Convert(v.Type()), true, nil
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false, nil
+		}
+
+		elem, found, err := resolveSecretReferences(v.Elem(), resolve)
+		if !found || err != nil {
+			return v, found, err
+		}
+
+		res := reflect.New(v.Type().Elem())
+		res.Elem().Set(elem)
+		return res, true, nil
+
+	case reflect.Struct:
+		// Copy the whole struct, including the unexported fields, and then replace the exported ones.
+		res := reflect.New(v.Type()).Elem()
+		res.Set(v)
+
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+
+			field, fieldFound, err := resolveSecretReferences(v.Field(i), resolve)
+			if err != nil {
+				return v, true, err
+			}
+			if fieldFound {
+				res.Field(i).Set(field)
+				found = true
+			}
+		}
+		return res, found, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return v, false, nil
+		}
+
+		var res reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			elem, found, err := resolveSecretReferences(v.Index(i), resolve)
+			if err != nil {
+				return v, true, err
+			}
+			if !found {
+				continue
+			}
+
+			// Copy the input on the first secret reference found.
+			if !res.IsValid() {
+				if v.Kind() == reflect.Slice {
+					res = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+					reflect.Copy(res, v)
+				} else {
+					res = reflect.New(v.Type()).Elem()
+					res.Set(v)
+				}
+			}
+			res.Index(i).Set(elem)
+		}
+
+		if !res.IsValid() {
+			return v, false, nil
+		}
+		return res, true, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false, nil
+		}
+
+		var res reflect.Value
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, found, err := resolveSecretReferences(iter.Value(), resolve)
+			if err != nil {
+				return v, true, err
+			}
+			if !found {
+				continue
+			}
+
+			// Copy the input on the first secret reference found.
+			if !res.IsValid() {
+				res = reflect.MakeMapWithSize(v.Type(), v.Len())
+				for _, key := range v.MapKeys() {
+					res.SetMapIndex(key, v.MapIndex(key))
+				}
+			}
+			res.SetMapIndex(iter.Key(), elem)
+		}
+
+		if !res.IsValid() {
+			return v, false, nil
+		}
+		return res, true, nil
+	}
+
+	return v, false, nil
+}
+
+// cachedSecret is a secret resolved from a secrets store.
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// ReceiverSecretsResolver resolves the secrets referenced by the receiver configs of the tenants,
+// caching them for the configured TTL.
+type ReceiverSecretsResolver struct {
+	cfg    ReceiverSecretsConfig
+	logger log.Logger
+
+	mtx   sync.Mutex
+	cache map[string]cachedSecret
+
+	resolutions        *prometheus.CounterVec
+	resolutionFailures *prometheus.CounterVec
+}
+
+// NewReceiverSecretsResolver makes a new ReceiverSecretsResolver.
+func NewReceiverSecretsResolver(cfg ReceiverSecretsConfig, logger log.Logger, reg prometheus.Registerer) *ReceiverSecretsResolver {
+	r := &ReceiverSecretsResolver{
+		cfg:    cfg,
+		logger: logger,
+		cache:  map[string]cachedSecret{},
+		resolutions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_alertmanager_receiver_secrets_resolutions_total",
+			Help: "Total number of receiver secrets fetched from the secrets stores.",
+		}, []string{"store"}),
+		resolutionFailures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_alertmanager_receiver_secrets_resolution_failures_total",
+			Help: "Total number of receiver secrets which have failed to be fetched from the secrets stores.",
+		}, []string{"store"}),
+	}
+
+	// Initialize the metrics.
+	for _, store := range []string{secretsStoreVault, secretsStoreKubernetes} {
+		r.resolutions.WithLabelValues(store)
+		r.resolutionFailures.WithLabelValues(store)
+	}
+
+	return r
+}
+
+// resolve returns the secret of the tenant referenced by the input reference.
+func (r *ReceiverSecretsResolver) resolve(userID string, ref secretReference) (string, error) {
+	key := userID + "/" + ref.String()
+
+	r.mtx.Lock()
+	cached, ok := r.cache[key]
+	r.mtx.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < r.cfg.CacheTTL {
+		return cached.value, nil
+	}
+
+	r.resolutions.WithLabelValues(ref.store).Inc()
+	value, err := r.fetch(userID, ref)
+	if err != nil {
+		r.resolutionFailures.WithLabelValues(ref.store).Inc()
+
+		if ok {
+			// Keep notifying with the previous secret, which may still be valid.
+			level.Warn(util_log.WithUserID(userID, r.logger)).Log("msg", "failed to resolve receiver secret, using the expired one", "secret", ref.String(), "err", err)
+			return cached.value, nil
+		}
+		return "", errors.Wrapf(err, "failed to resolve receiver secret %s", ref.String())
+	}
+
+	r.mtx.Lock()
+	r.cache[key] = cachedSecret{value: value, fetchedAt: time.Now()}
+	r.mtx.Unlock()
+
+	return value, nil
+}
+
+func (r *ReceiverSecretsResolver) fetch(userID string, ref secretReference) (string, error) {
+	switch ref.store {
+	case secretsStoreVault:
+		if r.cfg.VaultReader == nil {
+			return "", errors.New("the vault secrets store is not configured")
+		}
+
+		value, err := r.cfg.VaultReader.ReadSecret(path.Join(r.cfg.VaultPathPrefix, userID, ref.path))
+		if err != nil {
+			return "", err
+		}
+		return string(value), nil
+
+	case secretsStoreKubernetes:
+		if r.cfg.KubernetesSecretsDir == "" {
+			return "", errors.New("the kubernetes secrets store is not configured")
+		}
+
+		value, err := os.ReadFile(filepath.Join(r.cfg.KubernetesSecretsDir, userID, filepath.FromSlash(ref.path)))
+		if err != nil {
+			return "", err
+		}
+		// The secrets are often created from files ending with a newline, which is never part of the secret.
+		return strings.TrimRight(string(value), "\r\n"), nil
+	}
+
+	return "", fmt.Errorf("unsupported secrets store %q", ref.store)
+}
+
+// removeTenant removes the cached secrets of the tenant.
+func (r *ReceiverSecretsResolver) removeTenant(userID string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for key := range r.cache {
+		if strings.HasPrefix(key, userID+"/") {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// buildReceiverIntegrationsWithSecrets builds the integrations of a receiver config containing secret
+// references, resolving the secrets each time a notification is sent through them.
+func buildReceiverIntegrationsWithSecrets(nc config.Receiver, resolve func(ref secretReference) (string, error), build func(config.Receiver) ([]notify.Integration, error), wrapper func(string, notify.Notifier) notify.Notifier) ([]notify.Integration, error) {
+	var integrations []notify.Integration
+
+	// Split the receiver into receivers with a single integration each, so that the secrets of an integration
+	// are resolved only when notifying through it.
+	rcv := reflect.ValueOf(nc)
+	for i := 0; i < rcv.NumField(); i++ {
+		configs := rcv.Field(i)
+		if configs.Kind() != reflect.Slice {
+			continue
+		}
+
+		for idx := 0; idx < configs.Len(); idx++ {
+			single := reflect.New(rcv.Type()).Elem()
+			single.FieldByName("Name").Set(rcv.FieldByName("Name"))
+			single.Field(i).Set(configs.Slice(idx, idx+1))
+			singleCfg := single.Interface().(config.Receiver)
+
+			// Build the integration with the unresolved secrets, to check it's valid and get its name.
+			built, err := build(singleCfg)
+			if err != nil {
+				return nil, err
+			}
+
+			for j := range built {
+				n := &secretsResolvingNotifier{
+					receiver: singleCfg,
+					resolve:  resolve,
+					build:    build,
+				}
+				integrations = append(integrations, notify.NewIntegration(wrapper(built[j].Name(), n), &built[j], built[j].Name(), idx))
+			}
+		}
+	}
+
+	return integrations, nil
+}
+
+// secretsResolvingNotifier is a notify.Notifier resolving the secrets referenced by the config of a receiver
+// with a single integration at notification time, and rebuilding the integration when the secrets change.
+type secretsResolvingNotifier struct {
+	receiver config.Receiver
+	resolve  func(ref secretReference) (string, error)
+	build    func(config.Receiver) ([]notify.Integration, error)
+
+	mtx         sync.Mutex
+	secrets     []string
+	integration *notify.Integration
+}
+
+// Notify implements notify.Notifier.
+func (n *secretsResolvingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	integration, err := n.getIntegration()
+	if err != nil {
+		// The secrets store may be temporarily unavailable, so the notification is retried.
+		return true, err
+	}
+
+	return integration.Notify(ctx, alerts...)
+}
+
+func (n *secretsResolvingNotifier) getIntegration() (*notify.Integration, error) {
+	var secrets []string
+	resolved, _, err := resolveSecretReferences(reflect.ValueOf(n.receiver), func(ref secretReference) (string, error) {
+		secret, err := n.resolve(ref)
+		secrets = append(secrets, secret)
+		return secret, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	// Rebuild the integration only when the secrets have changed, e.g. because they have been rotated.
+	if n.integration != nil && slices.Equal(n.secrets, secrets) {
+		return n.integration, nil
+	}
+
+	integrations, err := n.build(resolved.Interface().(config.Receiver))
+	if err != nil {
+		return nil, err
+	}
+	if len(integrations) != 1 {
+		return nil, fmt.Errorf("unexpected number of integrations built from the receiver %s: %d", n.receiver.Name, len(integrations))
+	}
+
+	n.integration = &integrations[0]
+	n.secrets = secrets
+	return n.integration, nil
+}
@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	util_net "github.com/grafana/mimir/pkg/util/net"
+)
+
+func TestParseSecretReference(t *testing.T) {
+	tests := map[string]struct {
+		value       string
+		expectedRef secretReference
+		expectedOK  bool
+		expectedErr string
+	}{
+		"not a reference": {
+			value: "password",
+		},
+		"vault reference": {
+			value:       "secret://vault/slack/api-key",
+			expectedRef: secretReference{store: secretsStoreVault, path: "slack/api-key"},
+			expectedOK:  true,
+		},
+		"kubernetes reference": {
+			value:       "secret://kubernetes/pagerduty-key",
+			expectedRef: secretReference{store: secretsStoreKubernetes, path: "pagerduty-key"},
+			expectedOK:  true,
+		},
+		"unsupported store": {
+			value:       "secret://unknown/key",
+			expectedOK:  true,
+			expectedErr: `invalid secret reference "secret://unknown/key": unsupported secrets store "unknown", supported stores are: vault, kubernetes`,
+		},
+		"empty path": {
+			value:       "secret://vault/",
+			expectedOK:  true,
+			expectedErr: `invalid secret reference "secret://vault/": the path must be a non-empty relative path`,
+		},
+		"path escaping the tenant's namespace": {
+			value:       "secret://kubernetes/../user-2/key",
+			expectedOK:  true,
+			expectedErr: `invalid secret reference "secret://kubernetes/../user-2/key": the path must be a non-empty relative path`,
+		},
+		"absolute path": {
+			value:       "secret://kubernetes//etc/passwd",
+			expectedOK:  true,
+			expectedErr: `invalid secret reference "secret://kubernetes//etc/passwd": the path must be a non-empty relative path`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ref, ok, err := parseSecretReference(testData.value)
+			assert.Equal(t, testData.expectedOK, ok)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedRef, ref)
+		})
+	}
+}
+
+func TestValidateReceiverSecretReferences(t *testing.T) {
+	cfg, err := config.Load(`
+route:
+  receiver: webhook
+receivers:
+  - name: webhook
+    webhook_configs:
+      - url: http://localhost/webhook
+        http_config:
+          basic_auth:
+            username: user
+            password: secret://vault/webhook/../../password
+`)
+	require.NoError(t, err)
+	require.EqualError(t, validateReceiverSecretReferences(cfg.Receivers), `invalid secret reference "secret://vault/webhook/../../password": the path must be a non-empty relative path`)
+
+	cfg.Receivers[0].WebhookConfigs[0].HTTPConfig.BasicAuth.Password = "secret://vault/webhook/password"
+	require.NoError(t, validateReceiverSecretReferences(cfg.Receivers))
+}
+
+func TestReceiverSecretsResolver(t *testing.T) {
+	secretsDir := t.TempDir()
+	writeKubernetesSecret(t, secretsDir, "user-1", "key", "user-1-value\n")
+	writeKubernetesSecret(t, secretsDir, "user-2", "key", "user-2-value")
+
+	vault := &vaultReaderMock{secrets: map[string]string{"alertmanager/user-1/slack/key": "vault-value"}}
+
+	reg := prometheus.NewPedanticRegistry()
+	r := NewReceiverSecretsResolver(ReceiverSecretsConfig{
+		Enabled:              true,
+		KubernetesSecretsDir: secretsDir,
+		VaultPathPrefix:      "alertmanager",
+		CacheTTL:             time.Hour,
+		VaultReader:          vault,
+	}, log.NewNopLogger(), reg)
+
+	kubernetesRef := secretReference{store: secretsStoreKubernetes, path: "key"}
+	vaultRef := secretReference{store: secretsStoreVault, path: "slack/key"}
+
+	// The secrets are resolved in the tenant's namespace.
+	value, err := r.resolve("user-1", kubernetesRef)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1-value", value)
+
+	value, err = r.resolve("user-2", kubernetesRef)
+	require.NoError(t, err)
+	assert.Equal(t, "user-2-value", value)
+
+	value, err = r.resolve("user-1", vaultRef)
+	require.NoError(t, err)
+	assert.Equal(t, "vault-value", value)
+
+	_, err = r.resolve("user-2", vaultRef)
+	require.Error(t, err)
+
+	// The secrets are cached.
+	writeKubernetesSecret(t, secretsDir, "user-1", "key", "user-1-rotated")
+	value, err = r.resolve("user-1", kubernetesRef)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1-value", value)
+
+	// The rotated secret is resolved once the cached one has expired.
+	r.cfg.CacheTTL = 0
+	value, err = r.resolve("user-1", kubernetesRef)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1-rotated", value)
+
+	// The expired secret is used when the secret can't be resolved.
+	require.NoError(t, os.Remove(filepath.Join(secretsDir, "user-1", "key")))
+	value, err = r.resolve("user-1", kubernetesRef)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1-rotated", value)
+
+	// The cached secrets of a tenant are removed with the tenant.
+	r.removeTenant("user-1")
+	_, err = r.resolve("user-1", kubernetesRef)
+	require.Error(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_alertmanager_receiver_secrets_resolutions_total Total number of receiver secrets fetched from the secrets stores.
+		# TYPE cortex_alertmanager_receiver_secrets_resolutions_total counter
+		cortex_alertmanager_receiver_secrets_resolutions_total{store="kubernetes"} 5
+		cortex_alertmanager_receiver_secrets_resolutions_total{store="vault"} 2
+		# HELP cortex_alertmanager_receiver_secrets_resolution_failures_total Total number of receiver secrets which have failed to be fetched from the secrets stores.
+		# TYPE cortex_alertmanager_receiver_secrets_resolution_failures_total counter
+		cortex_alertmanager_receiver_secrets_resolution_failures_total{store="kubernetes"} 2
+		cortex_alertmanager_receiver_secrets_resolution_failures_total{store="vault"} 1
+	`), "cortex_alertmanager_receiver_secrets_resolutions_total", "cortex_alertmanager_receiver_secrets_resolution_failures_total"))
+}
+
+func TestBuildIntegrationsMap_ShouldResolveSecretsAtNotificationTime(t *testing.T) {
+	var (
+		passwordsMtx sync.Mutex
+		passwords    []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, password, _ := r.BasicAuth()
+
+		passwordsMtx.Lock()
+		passwords = append(passwords, password)
+		passwordsMtx.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	cfg, err := config.Load(`
+route:
+  receiver: webhook
+receivers:
+  - name: webhook
+    webhook_configs:
+      - url: ` + server.URL + `
+        http_config:
+          basic_auth:
+            username: user
+            password: secret://kubernetes/webhook-password
+      - url: ` + server.URL + `
+        http_config:
+          basic_auth:
+            username: user
+            password: plain-password
+`)
+	require.NoError(t, err)
+
+	tmpl, err := template.FromGlobs(nil, withCustomFunctions("user-1"))
+	require.NoError(t, err)
+	tmpl.ExternalURL, _ = url.Parse("http://localhost/alertmanager")
+
+	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider("user-1", &mockAlertManagerLimits{}))
+	noopWrapper := func(_ string, n notify.Notifier) notify.Notifier { return n }
+
+	secretsDir := t.TempDir()
+	writeKubernetesSecret(t, secretsDir, "user-1", "webhook-password", "first-password")
+
+	resolver := NewReceiverSecretsResolver(ReceiverSecretsConfig{Enabled: true, KubernetesSecretsDir: secretsDir, CacheTTL: 0}, log.NewNopLogger(), nil)
+	resolveSecret := func(ref secretReference) (string, error) { return resolver.resolve("user-1", ref) }
+
+	integrationsMap, err := buildIntegrationsMap(cfg.Receivers, tmpl, firewallDialer, resolveSecret, log.NewNopLogger(), noopWrapper)
+	require.NoError(t, err)
+
+	integrations := integrationsMap["webhook"]
+	require.Len(t, integrations, 2)
+	assert.Equal(t, "webhook[0]", integrations[0].String())
+	assert.Equal(t, "webhook[1]", integrations[1].String())
+
+	ctx := notify.WithGroupKey(context.Background(), "group")
+	alert := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}}}
+
+	notifyAll := func() []string {
+		passwordsMtx.Lock()
+		passwords = nil
+		passwordsMtx.Unlock()
+
+		for _, integration := range integrations {
+			_, err := integration.Notify(ctx, alert)
+			require.NoError(t, err)
+		}
+
+		passwordsMtx.Lock()
+		defer passwordsMtx.Unlock()
+		return passwords
+	}
+
+	assert.Equal(t, []string{"first-password", "plain-password"}, notifyAll())
+
+	// The rotated secret is used by the next notifications.
+	writeKubernetesSecret(t, secretsDir, "user-1", "webhook-password", "second-password")
+	assert.Equal(t, []string{"second-password", "plain-password"}, notifyAll())
+
+	// The notifications through integrations referencing secrets fail when the resolution is disabled.
+	integrationsMap, err = buildIntegrationsMap(cfg.Receivers, tmpl, firewallDialer, func(secretReference) (string, error) { return "", errReceiverSecretsDisabled }, log.NewNopLogger(), noopWrapper)
+	require.NoError(t, err)
+
+	retry, err := integrationsMap["webhook"][0].Notify(ctx, alert)
+	assert.True(t, retry)
+	assert.ErrorIs(t, err, errReceiverSecretsDisabled)
+}
+
+func writeKubernetesSecret(t *testing.T, dir, userID, key, value string) {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, userID), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, userID, key), []byte(value), 0600))
+}
+
+type vaultReaderMock struct {
+	secrets map[string]string
+}
+
+func (m *vaultReaderMock) ReadSecret(path string) ([]byte, error) {
+	value, ok := m.secrets[path]
+	if !ok {
+		return nil, errors.New("secret not found")
+	}
+	return []byte(value), nil
+}
@@ -172,6 +172,11 @@ func (s *state) Position() int {
 	return s.replicator.GetPositionForUser(s.userID)
 }
 
+// LookupPosition is like Position, but returns an error if the position can't be determined.
+func (s *state) LookupPosition() (int, error) {
+	return s.replicator.LookupPositionForUser(s.userID)
+}
+
 // GetFullState returns the full internal state.
 func (s *state) GetFullState() (*clusterpb.FullState, error) {
 	s.mtx.Lock()
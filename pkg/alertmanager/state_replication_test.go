@@ -74,6 +74,10 @@ func (f *fakeReplicator) GetPositionForUser(_ string) int {
 	return 0
 }
 
+func (f *fakeReplicator) LookupPositionForUser(_ string) (int, error) {
+	return 0, nil
+}
+
 func (f *fakeReplicator) ReadFullStateForUser(ctx context.Context, userID string) ([]*clusterpb.FullState, error) {
 	if userID != testUserID {
 		return nil, errors.New("unexpected userID")
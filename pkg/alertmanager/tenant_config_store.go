@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+)
+
+// TenantAlertmanagerStore deletes the Alertmanager configuration and state of the tenants when they're offboarded.
+type TenantAlertmanagerStore struct {
+	store alertstore.AlertStore
+}
+
+func NewTenantAlertmanagerStore(store alertstore.AlertStore) *TenantAlertmanagerStore {
+	return &TenantAlertmanagerStore{store: store}
+}
+
+// Name implements compactor.TenantConfigStore.
+func (s *TenantAlertmanagerStore) Name() string {
+	return "alertmanager"
+}
+
+// DeleteTenantConfig implements compactor.TenantConfigStore. The state of the tenant is deleted too, although
+// it could be written again by an Alertmanager replica until it stops the tenant's Alertmanager, and deletes it.
+func (s *TenantAlertmanagerStore) DeleteTenantConfig(ctx context.Context, userID string) error {
+	if err := s.store.DeleteAlertConfig(ctx, userID); err != nil {
+		return errors.Wrap(err, "delete configuration")
+	}
+	if err := s.store.DeleteFreezeMark(ctx, userID); err != nil {
+		return errors.Wrap(err, "delete freeze mark")
+	}
+	return errors.Wrap(s.store.DeleteFullState(ctx, userID), "delete state")
+}
+
+// TenantConfigDeleted implements compactor.TenantConfigStore.
+func (s *TenantAlertmanagerStore) TenantConfigDeleted(ctx context.Context, userID string) (bool, error) {
+	if _, err := s.store.GetAlertConfig(ctx, userID); !errors.Is(err, alertspb.ErrNotFound) {
+		return false, err
+	}
+	if _, err := s.store.GetFullState(ctx, userID); !errors.Is(err, alertspb.ErrNotFound) {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/alertmanager/alertstore/bucketclient"
+)
+
+func TestTenantAlertmanagerStore(t *testing.T) {
+	ctx := context.Background()
+	alertStore := bucketclient.NewBucketAlertStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	for _, userID := range []string{"user-1", "user-2"} {
+		require.NoError(t, alertStore.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: userID, RawConfig: "content"}))
+		require.NoError(t, alertStore.SetFullState(ctx, userID, alertspb.FullStateDesc{}))
+	}
+
+	store := NewTenantAlertmanagerStore(alertStore)
+
+	deleted, err := store.TenantConfigDeleted(ctx, "user-1")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	// The configuration and the state of the tenant are deleted, and deleting them again is a no-op.
+	require.NoError(t, store.DeleteTenantConfig(ctx, "user-1"))
+	require.NoError(t, store.DeleteTenantConfig(ctx, "user-1"))
+
+	deleted, err = store.TenantConfigDeleted(ctx, "user-1")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = store.TenantConfigDeleted(ctx, "user-2")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
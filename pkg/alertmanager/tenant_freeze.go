@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	errTenantFrozen          = "the Alertmanager configuration and silences of the tenant are frozen in read-only mode, the tenant must be unfrozen before changing them"
+	errCheckingTenantFrozen  = "unable to check whether the tenant is frozen"
+	errFreezingTenant        = "unable to freeze the tenant"
+	errUnfreezingTenant      = "unable to unfreeze the tenant"
+	errTenantIDRequired      = "the tenant ID is required"
+	maxTenantFreezeReasonLen = 1024
+)
+
+// TenantFreezeStatus is the response of the tenant freeze API.
+type TenantFreezeStatus struct {
+	TenantID string     `json:"tenant_id"`
+	Frozen   bool       `json:"frozen"`
+	FrozenAt *time.Time `json:"frozen_at,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+// GetTenantFreezeStatus returns whether the Alertmanager of the tenant is frozen in read-only mode.
+// The tenant is taken from the path rather than the X-Scope-OrgID header, because the tenant freeze API
+// is an operator API: a tenant must not be able to unfreeze itself.
+func (am *MultitenantAlertmanager) GetTenantFreezeStatus(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["tenant"]
+	if userID == "" {
+		http.Error(w, errTenantIDRequired, http.StatusBadRequest)
+		return
+	}
+
+	mark, err := am.store.GetFreezeMark(r.Context(), userID)
+	if errors.Is(err, alertspb.ErrNotFound) {
+		util.WriteJSONResponse(w, TenantFreezeStatus{TenantID: userID})
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("%s: %s", errCheckingTenantFrozen, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, newTenantFreezeStatus(userID, mark))
+}
+
+// FreezeTenant freezes the Alertmanager configuration and silences of the tenant in read-only mode. The
+// changes are rejected until the tenant is unfrozen, while the alerts keep being received and notified.
+func (am *MultitenantAlertmanager) FreezeTenant(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID := mux.Vars(r)["tenant"]
+	if userID == "" {
+		http.Error(w, errTenantIDRequired, http.StatusBadRequest)
+		return
+	}
+
+	reason := r.FormValue("reason")
+	if len(reason) > maxTenantFreezeReasonLen {
+		http.Error(w, fmt.Sprintf("the reason is too long: %d bytes (limit: %d bytes)", len(reason), maxTenantFreezeReasonLen), http.StatusBadRequest)
+		return
+	}
+
+	mark := alertspb.FreezeMark{FrozenAt: time.Now().Unix(), Reason: reason}
+	if err := am.store.SetFreezeMark(r.Context(), userID, mark); err != nil {
+		level.Error(logger).Log("msg", errFreezingTenant, "user", userID, "err", err)
+		http.Error(w, fmt.Sprintf("%s: %s", errFreezingTenant, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(logger).Log("msg", "tenant Alertmanager frozen in read-only mode", "user", userID, "reason", reason)
+	util.WriteJSONResponse(w, newTenantFreezeStatus(userID, mark))
+}
+
+// UnfreezeTenant unfreezes the Alertmanager configuration and silences of the tenant.
+// If the tenant is not frozen, no error is reported.
+func (am *MultitenantAlertmanager) UnfreezeTenant(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID := mux.Vars(r)["tenant"]
+	if userID == "" {
+		http.Error(w, errTenantIDRequired, http.StatusBadRequest)
+		return
+	}
+
+	if err := am.store.DeleteFreezeMark(r.Context(), userID); err != nil {
+		level.Error(logger).Log("msg", errUnfreezingTenant, "user", userID, "err", err)
+		http.Error(w, fmt.Sprintf("%s: %s", errUnfreezingTenant, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(logger).Log("msg", "tenant Alertmanager unfrozen", "user", userID)
+	util.WriteJSONResponse(w, TenantFreezeStatus{TenantID: userID})
+}
+
+func newTenantFreezeStatus(userID string, mark alertspb.FreezeMark) TenantFreezeStatus {
+	frozenAt := time.Unix(mark.FrozenAt, 0).UTC()
+	return TenantFreezeStatus{
+		TenantID: userID,
+		Frozen:   true,
+		FrozenAt: &frozenAt,
+		Reason:   mark.Reason,
+	}
+}
+
+// rejectIfTenantFrozen writes an error response and returns true if the tenant is frozen in read-only mode,
+// or if it can't be checked.
+func (am *MultitenantAlertmanager) rejectIfTenantFrozen(w http.ResponseWriter, r *http.Request, userID string) bool {
+	_, err := am.store.GetFreezeMark(r.Context(), userID)
+	if errors.Is(err, alertspb.ErrNotFound) {
+		return false
+	}
+
+	logger := util_log.WithContext(r.Context(), am.logger)
+	if err != nil {
+		level.Error(logger).Log("msg", errCheckingTenantFrozen, "user", userID, "err", err)
+		http.Error(w, fmt.Sprintf("%s: %s", errCheckingTenantFrozen, err.Error()), http.StatusInternalServerError)
+		return true
+	}
+
+	level.Warn(logger).Log("msg", "rejected change to a frozen tenant", "user", userID, "method", r.Method, "path", r.URL.Path)
+	am.frozenTenantRejectedRequests.Inc()
+	http.Error(w, errTenantFrozen, http.StatusLocked)
+	return true
+}
+
+// isSilencesMutation returns whether the request creates, updates or expires silences.
+func (am *MultitenantAlertmanager) isSilencesMutation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost:
+		return am.distributor.isUnaryWritePath(r.URL.Path)
+	case http.MethodDelete:
+		return am.distributor.isUnaryDeletePath(r.URL.Path)
+	default:
+		return false
+	}
+}
+
+// syncFrozenTenants updates the metrics tracking the frozen tenants owned by the instance.
+func (am *MultitenantAlertmanager) syncFrozenTenants(ctx context.Context, ownedUsers map[string]alertspb.AlertConfigDesc) {
+	frozenUsers, err := am.store.ListFrozenUsers(ctx)
+	if err != nil {
+		level.Warn(am.logger).Log("msg", "failed to list frozen tenants", "err", err)
+		return
+	}
+
+	am.tenantFrozen.Reset()
+	numFrozen := 0
+	for _, userID := range frozenUsers {
+		if _, ok := ownedUsers[userID]; !ok {
+			continue
+		}
+		am.tenantFrozen.WithLabelValues(userID).Set(1)
+		numFrozen++
+	}
+	am.tenantsFrozen.Set(float64(numFrozen))
+}
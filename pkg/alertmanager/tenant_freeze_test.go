@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/alertmanager/alertspb"
+)
+
+func TestMultitenantAlertmanager_TenantFreeze(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	store := prepareInMemoryAlertStore()
+	require.NoError(t, store.SetAlertConfig(ctx, alertspb.AlertConfigDesc{User: userID, RawConfig: simpleConfigOne}))
+
+	amConfig := mockAlertmanagerConfig(t)
+	externalURL := flagext.URLValue{}
+	require.NoError(t, externalURL.Set("http://localhost:8080/alertmanager"))
+	amConfig.ExternalURL = externalURL
+
+	reg := prometheus.NewPedanticRegistry()
+	am := setupSingleMultitenantAlertmanager(t, amConfig, store, &mockAlertManagerLimits{}, log.NewNopLogger(), reg)
+
+	userCtx := user.InjectOrgID(ctx, userID)
+	do := func(handler http.HandlerFunc, method, url string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewReader(body))
+		req.Header.Set("content-type", "application/json")
+		rec := httptest.NewRecorder()
+		handler(rec, req.WithContext(userCtx))
+		return rec
+	}
+	// The tenant freeze API takes the tenant from the path, regardless of the tenant of the request.
+	doAdmin := func(handler http.HandlerFunc, method, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		req = mux.SetURLVars(req, map[string]string{"tenant": userID})
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	getStatus := func() TenantFreezeStatus {
+		rec := doAdmin(am.GetTenantFreezeStatus, http.MethodGet, "/multitenant_alertmanager/tenant/user-1/freeze")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var status TenantFreezeStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return status
+	}
+
+	silence, err := json.Marshal(types.Silence{
+		Matchers: labels.Matchers{{Name: "instance", Value: "prometheus-one"}},
+		Comment:  "Created for a test case.",
+		StartsAt: time.Now(),
+		EndsAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	silencesURL := externalURL.String() + "/api/v2/silences"
+	configURL := "http://localhost/api/v1/alerts"
+	config := []byte(`
+alertmanager_config: |
+  route:
+    receiver: default-receiver
+  receivers:
+    - name: default-receiver
+`)
+
+	// The tenant is not frozen.
+	assert.Equal(t, TenantFreezeStatus{TenantID: userID}, getStatus())
+
+	// The tenant of the request can't be frozen or unfrozen without the tenant in the path.
+	rec := do(am.FreezeTenant, http.MethodPost, "/multitenant_alertmanager/tenant//freeze", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = do(am.UnfreezeTenant, http.MethodDelete, "/multitenant_alertmanager/tenant//freeze", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Freeze the tenant.
+	rec = doAdmin(am.FreezeTenant, http.MethodPost, "/multitenant_alertmanager/tenant/user-1/freeze?reason=incident")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	status := getStatus()
+	assert.True(t, status.Frozen)
+	assert.Equal(t, "incident", status.Reason)
+	require.NotNil(t, status.FrozenAt)
+	assert.WithinDuration(t, time.Now(), *status.FrozenAt, time.Minute)
+
+	// The changes to the configuration and silences are rejected.
+	rec = do(am.SetUserConfig, http.MethodPost, configURL, config)
+	assert.Equal(t, http.StatusLocked, rec.Code)
+	assert.Contains(t, rec.Body.String(), errTenantFrozen)
+
+	rec = do(am.DeleteUserConfig, http.MethodDelete, configURL, nil)
+	assert.Equal(t, http.StatusLocked, rec.Code)
+
+	rec = do(am.ServeHTTP, http.MethodPost, silencesURL, silence)
+	assert.Equal(t, http.StatusLocked, rec.Code)
+
+	rec = do(am.ServeHTTP, http.MethodDelete, externalURL.String()+"/api/v2/silence/some-id", nil)
+	assert.Equal(t, http.StatusLocked, rec.Code)
+
+	// The configuration and the silences can still be read.
+	_, err = store.GetAlertConfig(ctx, userID)
+	require.NoError(t, err)
+
+	rec = do(am.ServeHTTP, http.MethodGet, silencesURL, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The frozen tenant is tracked once the configs are synced.
+	require.NoError(t, am.loadAndSyncConfigs(ctx, reasonPeriodic))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_alertmanager_frozen_tenant_rejected_requests_total Total number of requests changing the configuration or silences of a frozen tenant which have been rejected.
+		# TYPE cortex_alertmanager_frozen_tenant_rejected_requests_total counter
+		cortex_alertmanager_frozen_tenant_rejected_requests_total 4
+		# HELP cortex_alertmanager_tenant_frozen Whether the configuration and silences of the tenant are frozen in read-only mode. Only the tenants owned by the Alertmanager instance are tracked.
+		# TYPE cortex_alertmanager_tenant_frozen gauge
+		cortex_alertmanager_tenant_frozen{user="user-1"} 1
+		# HELP cortex_alertmanager_tenants_frozen Current number of tenants owned by the Alertmanager instance whose configuration and silences are frozen in read-only mode.
+		# TYPE cortex_alertmanager_tenants_frozen gauge
+		cortex_alertmanager_tenants_frozen 1
+	`), "cortex_alertmanager_frozen_tenant_rejected_requests_total", "cortex_alertmanager_tenant_frozen", "cortex_alertmanager_tenants_frozen"))
+
+	// Unfreeze the tenant.
+	rec = doAdmin(am.UnfreezeTenant, http.MethodDelete, "/multitenant_alertmanager/tenant/user-1/freeze")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, TenantFreezeStatus{TenantID: userID}, getStatus())
+
+	// The changes are accepted again.
+	rec = do(am.ServeHTTP, http.MethodPost, silencesURL, silence)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = do(am.SetUserConfig, http.MethodPost, configURL, config)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	require.NoError(t, am.loadAndSyncConfigs(ctx, reasonPeriodic))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_alertmanager_tenants_frozen Current number of tenants owned by the Alertmanager instance whose configuration and silences are frozen in read-only mode.
+		# TYPE cortex_alertmanager_tenants_frozen gauge
+		cortex_alertmanager_tenants_frozen 0
+	`), "cortex_alertmanager_tenant_frozen", "cortex_alertmanager_tenants_frozen"))
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// maxUnmatchedAlertsExamples is the max number of alerts returned by the unmatched alerts API.
+const maxUnmatchedAlertsExamples = 10
+
+// unmatchedAlertsTracker keeps track of the alerts which matched none of the child routes of
+// the routing tree, and so are handled by the default (root) route. These are usually caused by
+// a mismatch between the labels of the alerting rules and the matchers of the routing tree.
+type unmatchedAlertsTracker struct {
+	matchedNoRoute prometheus.Counter
+
+	mtx   sync.Mutex
+	route *dispatch.Route
+
+	// Alerts currently stored which matched no route, since the last time the configuration has been applied.
+	alerts map[model.Fingerprint]*unmatchedAlert
+}
+
+type unmatchedAlert struct {
+	Labels     model.LabelSet `json:"labels"`
+	ReceivedAt time.Time      `json:"receivedAt"`
+}
+
+type unmatchedAlertsResponse struct {
+	// Number of alerts currently stored which matched no route.
+	Count int `json:"count"`
+
+	// The most recently received alerts which matched no route, up to maxUnmatchedAlertsExamples.
+	Alerts []*unmatchedAlert `json:"alerts"`
+}
+
+func newUnmatchedAlertsTracker(reg prometheus.Registerer) *unmatchedAlertsTracker {
+	t := &unmatchedAlertsTracker{
+		alerts: map[model.Fingerprint]*unmatchedAlert{},
+		matchedNoRoute: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alerts_matched_no_route_total",
+			Help: "Number of alerts which matched no route of the routing tree, and were handled by the default route.",
+		}),
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "alertmanager_alerts_matched_no_route",
+		Help: "Number of alerts currently stored which matched no route of the routing tree.",
+	}, func() float64 {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+
+		return float64(len(t.alerts))
+	})
+
+	return t
+}
+
+// setRoute sets the root of the routing tree alerts are matched against. Tracked alerts
+// are reset, because they may match the new routing tree.
+func (t *unmatchedAlertsTracker) setRoute(route *dispatch.Route) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.route = route
+	t.alerts = map[model.Fingerprint]*unmatchedAlert{}
+}
+
+// PreStore implements mem.AlertStoreCallback.
+func (t *unmatchedAlertsTracker) PreStore(_ *types.Alert, _ bool) error {
+	return nil
+}
+
+// PostStore implements mem.AlertStoreCallback.
+func (t *unmatchedAlertsTracker) PostStore(alert *types.Alert, _ bool) {
+	if alert == nil {
+		return
+	}
+
+	fp := alert.Fingerprint()
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if !matchesNoRoute(t.route, alert.Labels) {
+		delete(t.alerts, fp)
+		return
+	}
+
+	if existing, ok := t.alerts[fp]; ok {
+		existing.ReceivedAt = time.Now()
+		return
+	}
+
+	t.alerts[fp] = &unmatchedAlert{Labels: alert.Labels.Clone(), ReceivedAt: time.Now()}
+	t.matchedNoRoute.Inc()
+}
+
+// PostDelete implements mem.AlertStoreCallback.
+func (t *unmatchedAlertsTracker) PostDelete(alert *types.Alert) {
+	if alert == nil {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.alerts, alert.Fingerprint())
+}
+
+func (t *unmatchedAlertsTracker) response() unmatchedAlertsResponse {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	alerts := make([]*unmatchedAlert, 0, len(t.alerts))
+	for _, a := range t.alerts {
+		// Copy the alert, given it may be updated once the lock is released.
+		alerts = append(alerts, &unmatchedAlert{Labels: a.Labels, ReceivedAt: a.ReceivedAt})
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].ReceivedAt.After(alerts[j].ReceivedAt)
+	})
+	if len(alerts) > maxUnmatchedAlertsExamples {
+		alerts = alerts[:maxUnmatchedAlertsExamples]
+	}
+
+	return unmatchedAlertsResponse{Count: len(t.alerts), Alerts: alerts}
+}
+
+// ServeHTTP serves the alerts which matched no route.
+func (t *unmatchedAlertsTracker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, t.response())
+}
+
+// matchesNoRoute returns whether the alert with the input labels matches none of the child routes of
+// the root route. If the root route has no child routes, all alerts are meant to be handled by it, and
+// so they're not considered unmatched.
+func matchesNoRoute(root *dispatch.Route, lset model.LabelSet) bool {
+	if root == nil || len(root.Routes) == 0 {
+		return false
+	}
+
+	matches := root.Match(lset)
+	return len(matches) == 1 && matches[0] == root
+}
+
+// alertStoreCallbacks calls multiple callbacks of the in-memory alert store.
+type alertStoreCallbacks []mem.AlertStoreCallback
+
+func (c alertStoreCallbacks) PreStore(alert *types.Alert, existing bool) error {
+	for _, callback := range c {
+		if err := callback.PreStore(alert, existing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c alertStoreCallbacks) PostStore(alert *types.Alert, existing bool) {
+	for _, callback := range c {
+		callback.PostStore(alert, existing)
+	}
+}
+
+func (c alertStoreCallbacks) PostDelete(alert *types.Alert) {
+	for _, callback := range c {
+		callback.PostDelete(alert)
+	}
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package alertmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unmatchedAlertsTestConfig = `receivers:
+- name: 'default'
+- name: 'team-a'
+
+route:
+  receiver: 'default'
+  routes:
+  - receiver: 'team-a'
+    matchers:
+    - team="a"`
+
+func TestUnmatchedAlertsTracker(t *testing.T) {
+	cfg, err := config.Load(unmatchedAlertsTestConfig)
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	tracker := newUnmatchedAlertsTracker(reg)
+	tracker.setRoute(dispatch.NewRoute(cfg.Route, nil))
+
+	matched := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "matched", "team": "a"}}}
+	unmatched := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "unmatched", "team": "b"}}}
+
+	tracker.PostStore(matched, false)
+	tracker.PostStore(unmatched, false)
+
+	// Receiving the same alert again doesn't count it twice.
+	tracker.PostStore(unmatched, true)
+
+	res := tracker.response()
+	assert.Equal(t, 1, res.Count)
+	require.Len(t, res.Alerts, 1)
+	assert.Equal(t, unmatched.Labels, res.Alerts[0].Labels)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP alertmanager_alerts_matched_no_route Number of alerts currently stored which matched no route of the routing tree.
+		# TYPE alertmanager_alerts_matched_no_route gauge
+		alertmanager_alerts_matched_no_route 1
+		# HELP alertmanager_alerts_matched_no_route_total Number of alerts which matched no route of the routing tree, and were handled by the default route.
+		# TYPE alertmanager_alerts_matched_no_route_total counter
+		alertmanager_alerts_matched_no_route_total 1
+	`)))
+
+	// Deleted alerts are no longer tracked.
+	tracker.PostDelete(unmatched)
+	assert.Equal(t, 0, tracker.response().Count)
+
+	// Applying a new routing tree resets the tracked alerts.
+	tracker.PostStore(unmatched, false)
+	assert.Equal(t, 1, tracker.response().Count)
+	tracker.setRoute(dispatch.NewRoute(cfg.Route, nil))
+	assert.Equal(t, 0, tracker.response().Count)
+}
+
+func TestUnmatchedAlertsTracker_RootRouteOnly(t *testing.T) {
+	cfg, err := config.Load(`receivers:
+- name: 'default'
+
+route:
+  receiver: 'default'`)
+	require.NoError(t, err)
+
+	tracker := newUnmatchedAlertsTracker(nil)
+	tracker.setRoute(dispatch.NewRoute(cfg.Route, nil))
+
+	// When there are no child routes, all alerts are meant to be handled by the root route.
+	tracker.PostStore(&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}}}, false)
+	assert.Equal(t, 0, tracker.response().Count)
+}
+
+func TestUnmatchedAlertsTracker_MaxExamples(t *testing.T) {
+	cfg, err := config.Load(unmatchedAlertsTestConfig)
+	require.NoError(t, err)
+
+	tracker := newUnmatchedAlertsTracker(nil)
+	tracker.setRoute(dispatch.NewRoute(cfg.Route, nil))
+
+	numAlerts := maxUnmatchedAlertsExamples + 5
+	for i := 0; i < numAlerts; i++ {
+		tracker.PostStore(&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert-%d", i))}}}, false)
+	}
+
+	res := tracker.response()
+	assert.Equal(t, numAlerts, res.Count)
+	require.Len(t, res.Alerts, maxUnmatchedAlertsExamples)
+
+	// The most recently received alerts come first.
+	for i := 1; i < len(res.Alerts); i++ {
+		assert.False(t, res.Alerts[i].ReceivedAt.After(res.Alerts[i-1].ReceivedAt))
+	}
+}
+
+func TestAlertmanager_UnmatchedAlertsAPI(t *testing.T) {
+	user := "test"
+	am, err := New(&Config{
+		UserID:            user,
+		Logger:            log.NewNopLogger(),
+		Limits:            &mockAlertManagerLimits{maxDispatcherAggregationGroups: 10},
+		TenantDataDir:     t.TempDir(),
+		ExternalURL:       &url.URL{Path: "/am"},
+		ShardingEnabled:   true,
+		Store:             prepareInMemoryAlertStore(),
+		Replicator:        &stubReplicator{},
+		ReplicationFactor: 1,
+		PersisterConfig:   PersisterConfig{Interval: time.Hour},
+	}, prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	defer am.StopAndWait()
+
+	cfg, err := config.Load(unmatchedAlertsTestConfig)
+	require.NoError(t, err)
+	require.NoError(t, am.ApplyConfig(user, cfg, unmatchedAlertsTestConfig))
+
+	now := time.Now()
+	require.NoError(t, am.alerts.Put(
+		&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "matched", "team": "a"}, StartsAt: now, EndsAt: now.Add(5 * time.Minute)}, UpdatedAt: now},
+		&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "unmatched", "team": "b"}, StartsAt: now, EndsAt: now.Add(5 * time.Minute)}, UpdatedAt: now},
+	))
+
+	rec := httptest.NewRecorder()
+	am.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/am/api/v1/alerts/unmatched", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	res := unmatchedAlertsResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, 1, res.Count)
+	require.Len(t, res.Alerts, 1)
+	assert.Equal(t, model.LabelSet{"alertname": "unmatched", "team": "b"}, res.Alerts[0].Labels)
+}
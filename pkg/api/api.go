@@ -40,6 +40,8 @@ import (
 	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/usagetracker"
+	"github.com/grafana/mimir/pkg/usagetracker/usagetrackerpb"
 	"github.com/grafana/mimir/pkg/util/gziphandler"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/push"
@@ -183,6 +185,9 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, api
 	a.RegisterRoute("/multitenant_alertmanager/configs", http.HandlerFunc(am.ListAllConfigs), false, true, "GET")
 	a.RegisterRoute("/multitenant_alertmanager/ring", http.HandlerFunc(am.RingHandler), false, true, "GET", "POST")
 	a.RegisterRoute("/multitenant_alertmanager/delete_tenant_config", http.HandlerFunc(am.DeleteUserConfig), true, true, "POST")
+	a.RegisterRoute("/multitenant_alertmanager/tenant/{tenant}/freeze", http.HandlerFunc(am.GetTenantFreezeStatus), false, true, "GET")
+	a.RegisterRoute("/multitenant_alertmanager/tenant/{tenant}/freeze", http.HandlerFunc(am.FreezeTenant), false, true, "POST")
+	a.RegisterRoute("/multitenant_alertmanager/tenant/{tenant}/freeze", http.HandlerFunc(am.UnfreezeTenant), false, true, "DELETE")
 	a.RegisterRoute(path.Join(a.cfg.AlertmanagerHTTPPrefix, "/api/v1/status/buildinfo"), buildInfoHandler, false, true, "GET")
 
 	// UI components lead to a large number of routes to support, utilize a path prefix instead
@@ -250,6 +255,8 @@ type Ingester interface {
 	ShutdownHandler(http.ResponseWriter, *http.Request)
 	PushWithCleanup(context.Context, *push.Request) (*mimirpb.WriteResponse, error)
 	UserRegistryHandler(http.ResponseWriter, *http.Request)
+	SeriesChurnHandler(http.ResponseWriter, *http.Request)
+	HeadMemoryHandler(http.ResponseWriter, *http.Request)
 }
 
 // RegisterIngester registers the ingesters HTTP and GRPC service
@@ -265,6 +272,8 @@ func (a *API) RegisterIngester(i Ingester, pushConfig distributor.Config) {
 	a.RegisterRoute("/ingester/shutdown", http.HandlerFunc(i.ShutdownHandler), false, true, "GET", "POST")
 	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.SkipLabelNameValidationHeader, i.PushWithCleanup), true, false, "POST") // For testing and debugging.
 	a.RegisterRoute("/ingester/tsdb_metrics", http.HandlerFunc(i.UserRegistryHandler), true, true, "GET")
+	a.RegisterRoute("/ingester/series_churn", http.HandlerFunc(i.SeriesChurnHandler), true, true, "GET")
+	a.RegisterRoute("/ingester/head_memory", http.HandlerFunc(i.HeadMemoryHandler), true, true, "GET")
 }
 
 // RegisterRuler registers routes associated with the Ruler service.
@@ -280,6 +289,9 @@ func (a *API) RegisterRuler(r *ruler.Ruler) {
 	// List all user rule groups
 	a.RegisterRoute("/ruler/rule_groups", http.HandlerFunc(r.ListAllRules), false, true, "GET")
 
+	// Health of the rules sync, evaluation and notifications of a tenant.
+	a.RegisterRoute("/ruler/tenant/{tenant}/health", http.HandlerFunc(r.TenantHealthHandler), false, true, "GET")
+
 	ruler.RegisterRulerServer(a.server.GRPC, r)
 }
 
@@ -295,6 +307,8 @@ func (a *API) RegisterRulerAPI(r *ruler.API, configAPIEnabled bool, buildInfoHan
 	if configAPIEnabled {
 		// Long-term maintained configuration API routes
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules"), http.HandlerFunc(r.ListRules), true, true, "GET")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/analysis/duplicate_rules"), http.HandlerFunc(r.ListDuplicateRules), true, true, "GET")
+		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/analysis/rule_dependencies"), http.HandlerFunc(r.GetRuleDependencies), true, true, "GET")
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}"), http.HandlerFunc(r.ListRules), true, true, "GET")
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}/{groupName}"), http.HandlerFunc(r.GetRuleGroup), true, true, "GET")
 		a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/config/v1/rules/{namespace}"), http.HandlerFunc(r.CreateRuleGroup), true, true, "POST")
@@ -322,6 +336,9 @@ func (a *API) RegisterStoreGateway(s *storegateway.StoreGateway) {
 	a.RegisterRoute("/store-gateway/ring", http.HandlerFunc(s.RingHandler), false, true, "GET", "POST")
 	a.RegisterRoute("/store-gateway/tenants", http.HandlerFunc(s.TenantsHandler), false, true, "GET")
 	a.RegisterRoute("/store-gateway/tenant/{tenant}/blocks", http.HandlerFunc(s.BlocksHandler), false, true, "GET")
+	a.RegisterRoute("/store-gateway/tenant/{tenant}/warmup", http.HandlerFunc(s.WarmUpHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/store-gateway/loaded_tenants", http.HandlerFunc(s.LoadedTenantsHandler), false, true, "GET")
+	a.RegisterRoute("/store-gateway/tenant/{tenant}/loaded_blocks", http.HandlerFunc(s.LoadedBlocksHandler), false, true, "GET")
 }
 
 // RegisterCompactor registers routes associated with the compactor.
@@ -336,6 +353,9 @@ func (a *API) RegisterCompactor(c *compactor.MultitenantCompactor) {
 	a.RegisterRoute("/api/v1/upload/block/{block}/check", http.HandlerFunc(c.GetBlockUploadStateHandler), true, false, http.MethodGet)
 	a.RegisterRoute("/compactor/delete_tenant", http.HandlerFunc(c.DeleteTenant), true, true, "POST")
 	a.RegisterRoute("/compactor/delete_tenant_status", http.HandlerFunc(c.DeleteTenantStatus), true, true, "GET")
+	a.RegisterRoute("/compactor/offboard_tenant", http.HandlerFunc(c.OffboardTenant), true, true, "POST")
+	a.RegisterRoute("/compactor/offboard_tenant_status", http.HandlerFunc(c.OffboardTenantStatus), true, true, "GET")
+	a.RegisterRoute("/compactor/tenant_block_ranges", http.HandlerFunc(c.TenantBlockRanges), true, true, "GET")
 }
 
 type Distributor interface {
@@ -366,6 +386,7 @@ func (a *API) RegisterQueryAPI(handler http.Handler, buildInfoHandler http.Handl
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/metadata"), handler, true, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_names"), handler, true, true, "GET", "POST")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/cardinality/label_values"), handler, true, true, "GET", "POST")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/functions"), handler, true, true, "GET")
 }
 
 // RegisterQueryFrontendHandler registers the Prometheus routes supported by the
@@ -373,6 +394,14 @@ func (a *API) RegisterQueryAPI(handler http.Handler, buildInfoHandler http.Handl
 // with the Querier.
 func (a *API) RegisterQueryFrontendHandler(h http.Handler, buildInfoHandler http.Handler) {
 	a.RegisterQueryAPI(h, buildInfoHandler)
+
+	// The query plan is built by the query-frontend, without sending any request to the queriers.
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_plan"), h, true, true, "GET", "POST")
+}
+
+// RegisterQueryFrontendRejectedQueryHandler registers the API reporting which limit rejected a query.
+func (a *API) RegisterQueryFrontendRejectedQueryHandler(h http.Handler) {
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/rejected_query"), h, true, true, "GET")
 }
 
 func (a *API) RegisterQueryFrontend1(f *frontendv1.Frontend) {
@@ -386,11 +415,21 @@ func (a *API) RegisterQueryFrontend2(f *frontendv2.Frontend) {
 func (a *API) RegisterQueryScheduler(f *scheduler.Scheduler) {
 	a.indexPage.AddLinks(defaultWeight, "Query-scheduler", []IndexPageLink{
 		{Desc: "Ring status", Path: "/query-scheduler/ring"},
+		{Desc: "Tenant queues", Path: "/query-scheduler/tenants"},
 	})
 	a.RegisterRoute("/query-scheduler/ring", http.HandlerFunc(f.RingHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/query-scheduler/tenants", http.HandlerFunc(f.TenantsHandler), false, true, "GET")
+	a.RegisterRoute("/query-scheduler/tenant/{tenant}/queue", http.HandlerFunc(f.TenantQueueHandler), false, true, "GET")
+	a.RegisterRoute("/query-scheduler/tenant/{tenant}/queue/drop", http.HandlerFunc(f.DropQueuedRequestHandler), false, true, "POST")
+	a.RegisterRoute("/query-scheduler/tenant/{tenant}/queue/drain", http.HandlerFunc(f.DrainTenantQueueHandler), false, true, "POST")
+	a.RegisterRoute("/query-scheduler/queue-snapshots", http.HandlerFunc(f.QueueSnapshotsHandler), false, true, "GET")
 
 	schedulerpb.RegisterSchedulerForFrontendServer(a.server.GRPC, f)
 	schedulerpb.RegisterSchedulerForQuerierServer(a.server.GRPC, f)
+
+	// The query-frontends which can't connect over gRPC open the FrontendLoop stream over plain HTTP/2, which is
+	// negotiated at the connection level, so it's served before the HTTP middlewares and router.
+	a.server.HTTPServer.Handler = schedulerpb.NewFrontendLoopH2CHandler(a.server.HTTPServer.Handler, f)
 }
 
 func (a *API) RegisterOverridesExporter(oe *exporter.OverridesExporter) {
@@ -400,6 +439,12 @@ func (a *API) RegisterOverridesExporter(oe *exporter.OverridesExporter) {
 	a.RegisterRoute("/overrides-exporter/ring", http.HandlerFunc(oe.RingHandler), false, true, "GET", "POST")
 }
 
+func (a *API) RegisterUsageTracker(t *usagetracker.UsageTracker) {
+	a.RegisterRoute("/usage-tracker/usage", http.HandlerFunc(t.UsageHandler), true, true, "GET")
+
+	usagetrackerpb.RegisterUsageTrackerServer(a.server.GRPC, t)
+}
+
 // RegisterServiceMapHandler registers the Mimir structs service handler
 // TODO: Refactor this code to be accomplished using the services.ServiceManager
 // or a future module manager #2291
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/prometheus/promql"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// chunkedJSONCodec encodes the matrix query results as JSON, writing each series of the result on its own line.
+// The encoded response is a valid Prometheus API JSON response, which can also be decoded one series at a time
+// without buffering the whole result. The other responses are left to the other codecs.
+type chunkedJSONCodec struct{}
+
+func (c chunkedJSONCodec) ContentType() v1.MIMEType {
+	return v1.MIMEType{Type: mimirpb.QueryResponseMimeTypeType, SubType: mimirpb.QueryResponseChunkedJSONMimeTypeSubType}
+}
+
+func (c chunkedJSONCodec) CanEncode(resp *v1.Response) bool {
+	data, ok := resp.Data.(*v1.QueryData)
+	if !ok {
+		return false
+	}
+
+	_, ok = data.Result.(promql.Matrix)
+	return ok
+}
+
+func (c chunkedJSONCodec) Encode(resp *v1.Response) ([]byte, error) {
+	data := resp.Data.(*v1.QueryData)
+	matrix := data.Result.(promql.Matrix)
+
+	// The series are encoded with the encoders registered by the Prometheus JSON codec, so that they're
+	// encoded exactly as the Prometheus API does.
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	stream := json.BorrowStream(nil)
+	defer json.ReturnStream(stream)
+
+	stream.WriteObjectStart()
+	stream.WriteObjectField("status")
+	stream.WriteString(string(resp.Status))
+	stream.WriteMore()
+	stream.WriteObjectField("data")
+	stream.WriteObjectStart()
+	stream.WriteObjectField("resultType")
+	stream.WriteString(string(data.ResultType))
+	stream.WriteMore()
+	stream.WriteObjectField("result")
+	stream.WriteArrayStart()
+	for i, series := range matrix {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		stream.WriteRaw("\n")
+		stream.WriteVal(series)
+	}
+	stream.WriteRaw("\n")
+	stream.WriteArrayEnd()
+	if data.Stats != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("stats")
+		stream.WriteVal(data.Stats)
+	}
+	stream.WriteObjectEnd()
+	if len(resp.Warnings) > 0 {
+		stream.WriteMore()
+		stream.WriteObjectField("warnings")
+		stream.WriteVal(resp.Warnings)
+	}
+	stream.WriteObjectEnd()
+
+	if stream.Error != nil {
+		return nil, stream.Error
+	}
+
+	// The stream buffer is reused once the stream is returned.
+	return append([]byte(nil), stream.Buffer()...), nil
+}
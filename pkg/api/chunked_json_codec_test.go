@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkedJSONCodec_CanEncode(t *testing.T) {
+	scenarios := map[string]struct {
+		response          *v1.Response
+		expectedCanEncode bool
+	}{
+		"matrix": {
+			response:          &v1.Response{Status: "success", Data: &v1.QueryData{ResultType: parser.ValueTypeMatrix, Result: promql.Matrix{}}},
+			expectedCanEncode: true,
+		},
+		"vector": {
+			response:          &v1.Response{Status: "success", Data: &v1.QueryData{ResultType: parser.ValueTypeVector, Result: promql.Vector{}}},
+			expectedCanEncode: false,
+		},
+		"error": {
+			response:          &v1.Response{Status: "error", Data: nil},
+			expectedCanEncode: false,
+		},
+		"another type of result": {
+			response:          &v1.Response{Status: "success", Data: "a string"},
+			expectedCanEncode: false,
+		},
+	}
+
+	for name, scenario := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, scenario.expectedCanEncode, chunkedJSONCodec{}.CanEncode(scenario.response))
+		})
+	}
+}
+
+func TestChunkedJSONCodec_Encode(t *testing.T) {
+	scenarios := map[string]*v1.Response{
+		"empty matrix": {
+			Status: "success",
+			Data:   &v1.QueryData{ResultType: parser.ValueTypeMatrix, Result: promql.Matrix{}},
+		},
+		"matrix with multiple series": {
+			Status: "success",
+			Data: &v1.QueryData{
+				ResultType: parser.ValueTypeMatrix,
+				Result: promql.Matrix{
+					{Metric: labels.FromStrings("__name__", "foo", "job", "a"), Points: []promql.Point{{T: 1000, V: 1}, {T: 2000, V: 2}}},
+					{Metric: labels.FromStrings("__name__", "foo", "job", "b"), Points: []promql.Point{{T: 1000, V: 3}}},
+				},
+			},
+		},
+		"matrix with warnings": {
+			Status: "success",
+			Data: &v1.QueryData{
+				ResultType: parser.ValueTypeMatrix,
+				Result: promql.Matrix{
+					{Metric: labels.FromStrings("__name__", "foo"), Points: []promql.Point{{T: 1000, V: 1}}},
+				},
+			},
+			Warnings: []string{"something happened"},
+		},
+	}
+
+	for name, response := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			actual, err := chunkedJSONCodec{}.Encode(response)
+			require.NoError(t, err)
+
+			// The response is encoded like the Prometheus API does.
+			expected, err := v1.JSONCodec{}.Encode(response)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), string(actual))
+
+			// Each series is encoded on its own line.
+			series := response.Data.(*v1.QueryData).Result.(promql.Matrix)
+			lines := strings.Split(string(actual), "\n")
+			require.Len(t, lines, len(series)+2)
+			for i := range series {
+				require.True(t, strings.HasPrefix(lines[i+1], `{"metric":`), lines[i+1])
+			}
+		})
+	}
+}
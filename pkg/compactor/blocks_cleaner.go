@@ -28,6 +28,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storage/tsdb/seriesfilter"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -43,6 +44,8 @@ type BlocksCleanerConfig struct {
 	CleanupConcurrency      int
 	TenantCleanupDelay      time.Duration // Delay before removing tenant deletion mark and "debug".
 	DeleteBlocksConcurrency int
+	BlocksChangedNotifier   BlocksChangedNotifier // Optional, notified when the blocks of a tenant have changed.
+	SeriesFilterDir         string                // Directory the blocks index is temporarily downloaded to, to build the series filter index.
 }
 
 type BlocksCleaner struct {
@@ -72,6 +75,7 @@ type BlocksCleaner struct {
 	tenantMarkedBlocks             *prometheus.GaugeVec
 	tenantPartialBlocks            *prometheus.GaugeVec
 	tenantBucketIndexLastUpdate    *prometheus.GaugeVec
+	seriesFilterBlockFailures      prometheus.Counter
 }
 
 func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, ownUser func(userID string) (bool, error), cfgProvider ConfigProvider, logger log.Logger, reg prometheus.Registerer) *BlocksCleaner {
@@ -107,6 +111,10 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Bucket, own
 			Name: "cortex_compactor_block_cleanup_failures_total",
 			Help: "Total number of blocks failed to be deleted.",
 		}),
+		seriesFilterBlockFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_series_filter_block_failures_total",
+			Help: "Total number of blocks whose filter failed to be built in the series filter index.",
+		}),
 		blocksMarkedForDeletion: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name:        blocksMarkedForDeletionName,
 			Help:        blocksMarkedForDeletionHelp,
@@ -264,6 +272,10 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	}
 	c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
 
+	if err := seriesfilter.DeleteIndex(ctx, c.bucketClient, userID, c.cfgProvider); err != nil {
+		return err
+	}
+
 	var deletedBlocks, failed int
 	err := userBucket.Iter(ctx, "", func(name string) error {
 		if err := ctx.Err(); err != nil {
@@ -386,6 +398,12 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 		c.applyUserRetentionPeriod(ctx, idx, retention, userBucket, userLogger)
 	}
 
+	// Keep track of the blocks in the previous index, to detect whether they have changed.
+	var prevBlocks, prevDeletionMarks []ulid.ULID
+	if idx != nil {
+		prevBlocks, prevDeletionMarks = idx.Blocks.GetULIDs(), idx.BlockDeletionMarks.GetULIDs()
+	}
+
 	// Generate an updated in-memory version of the bucket index.
 	w := bucketindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.logger)
 	idx, partials, err := w.UpdateIndex(ctx, idx)
@@ -419,9 +437,57 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string) (returnErr
 	c.tenantPartialBlocks.WithLabelValues(userID).Set(float64(len(partials)))
 	c.tenantBucketIndexLastUpdate.WithLabelValues(userID).SetToCurrentTime()
 
+	if c.cfg.BlocksChangedNotifier != nil && (!sameULIDs(prevBlocks, idx.Blocks.GetULIDs()) || !sameULIDs(prevDeletionMarks, idx.BlockDeletionMarks.GetULIDs())) {
+		c.cfg.BlocksChangedNotifier.NotifyBlocksChanged(ctx, userID)
+	}
+
+	// The series filter index is a best effort, since the store-gateways don't skip the blocks without a filter.
+	if c.cfgProvider.CompactorSeriesFilterEnabled(userID) {
+		if err := c.updateSeriesFilterIndex(ctx, userID, idx, userLogger); err != nil {
+			level.Warn(userLogger).Log("msg", "failed to update series filter index", "err", err)
+		}
+	}
+
 	return nil
 }
 
+// updateSeriesFilterIndex updates the series filter index of the user with the blocks in the input bucket index.
+func (c *BlocksCleaner) updateSeriesFilterIndex(ctx context.Context, userID string, idx *bucketindex.Index, userLogger log.Logger) error {
+	old, err := seriesfilter.ReadIndex(ctx, c.bucketClient, userID, c.cfgProvider, c.logger)
+	if errors.Is(err, seriesfilter.ErrIndexCorrupted) {
+		level.Warn(userLogger).Log("msg", "found a corrupted series filter index, recreating it")
+	} else if err != nil && !errors.Is(err, seriesfilter.ErrIndexNotFound) {
+		return err
+	}
+
+	w := seriesfilter.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.cfg.SeriesFilterDir, c.logger)
+	filterIdx, failed, err := w.UpdateIndex(ctx, old, idx.Blocks)
+	if err != nil {
+		return err
+	}
+	c.seriesFilterBlockFailures.Add(float64(len(failed)))
+
+	return seriesfilter.WriteIndex(ctx, c.bucketClient, userID, c.cfgProvider, filterIdx)
+}
+
+// sameULIDs returns whether the two input lists contain the same ULIDs, regardless of their order.
+func sameULIDs(a, b []ulid.ULID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := make(map[ulid.ULID]struct{}, len(a))
+	for _, id := range a {
+		set[id] = struct{}{}
+	}
+	for _, id := range b {
+		if _, ok := set[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 // Concurrently deletes blocks marked for deletion, and removes blocks from index.
 func (c *BlocksCleaner) deleteBlocksMarkedForDeletion(ctx context.Context, idx *bucketindex.Index, userBucket objstore.Bucket, userLogger log.Logger) {
 	blocksToDelete := make([]ulid.ULID, 0, len(idx.BlockDeletionMarks))
@@ -14,6 +14,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storage/tsdb/seriesfilter"
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/test"
@@ -455,6 +457,92 @@ func TestBlocksCleaner_ShouldNotCleanupUserThatDoesntBelongToShardAnymore(t *tes
 	require.ElementsMatch(t, []string{}, cleaner.lastOwnedUsers)
 }
 
+func TestBlocksCleaner_ShouldNotifyTenantsWhoseBlocksHaveChanged(t *testing.T) {
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	// Create blocks.
+	block1 := createTSDBBlock(t, bucketClient, "user-1", 10, 20, 2, nil)
+	createTSDBBlock(t, bucketClient, "user-2", 20, 30, 2, nil)
+
+	notifier := &blocksChangedNotifierMock{}
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           time.Hour,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+		BlocksChangedNotifier:   notifier,
+	}
+
+	ctx := context.Background()
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, newMockConfigProvider(), log.NewNopLogger(), nil)
+
+	// The bucket indexes are created, so both tenants are notified.
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.ElementsMatch(t, []string{"user-1", "user-2"}, notifier.getNotifiedUsers())
+
+	// Nothing has changed, so no tenant is notified.
+	notifier.reset()
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.Empty(t, notifier.getNotifiedUsers())
+
+	// A new block has been uploaded for user-2.
+	notifier.reset()
+	createTSDBBlock(t, bucketClient, "user-2", 30, 40, 2, nil)
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.ElementsMatch(t, []string{"user-2"}, notifier.getNotifiedUsers())
+
+	// A block has been marked for deletion for user-1.
+	notifier.reset()
+	createDeletionMark(t, bucketClient, "user-1", block1, time.Now())
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assert.ElementsMatch(t, []string{"user-1"}, notifier.getNotifiedUsers())
+}
+
+func TestBlocksCleaner_ShouldUpdateSeriesFilterIndexOfEnabledTenants(t *testing.T) {
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
+
+	// Create blocks.
+	block1 := createTSDBBlock(t, bucketClient, "user-1", 10, 20, 2, nil)
+	createTSDBBlock(t, bucketClient, "user-2", 20, 30, 2, nil)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           time.Hour,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+		SeriesFilterDir:         t.TempDir(),
+	}
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.seriesFilterEnabled["user-1"] = true
+
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, logger, nil)
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+
+	// The series filter index includes the blocks of the enabled tenant only.
+	idx, err := seriesfilter.ReadIndex(ctx, bucketClient, "user-1", nil, logger)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 1)
+	assert.Equal(t, block1, idx.Blocks[0].ID)
+	assert.NotEmpty(t, idx.Blocks[0].Filter.Bits)
+
+	_, err = seriesfilter.ReadIndex(ctx, bucketClient, "user-2", nil, logger)
+	require.Equal(t, seriesfilter.ErrIndexNotFound, err)
+
+	// The series filter index is updated with the new blocks.
+	block2 := createTSDBBlock(t, bucketClient, "user-1", 20, 30, 2, nil)
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+
+	idx, err = seriesfilter.ReadIndex(ctx, bucketClient, "user-1", nil, logger)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 2)
+	assert.ElementsMatch(t, []ulid.ULID{block1, block2}, []ulid.ULID{idx.Blocks[0].ID, idx.Blocks[1].ID})
+}
+
 func TestBlocksCleaner_ListBlocksOutsideRetentionPeriod(t *testing.T) {
 	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
 	bucketClient = bucketindex.BucketWithGlobalMarkers(bucketClient)
@@ -976,6 +1064,10 @@ type mockConfigProvider struct {
 	userPartialBlockDelay        map[string]time.Duration
 	userPartialBlockDelayInvalid map[string]bool
 	verifyChunks                 map[string]bool
+	cardinalityIndexEnabled      map[string]bool
+	seriesFilterEnabled          map[string]bool
+	blockRanges                  map[string]tsdb.DurationList
+	maxExemplarsBytesPerJob      map[string]int
 }
 
 func newMockConfigProvider() *mockConfigProvider {
@@ -988,6 +1080,9 @@ func newMockConfigProvider() *mockConfigProvider {
 		userPartialBlockDelay:        make(map[string]time.Duration),
 		userPartialBlockDelayInvalid: make(map[string]bool),
 		verifyChunks:                 make(map[string]bool),
+		cardinalityIndexEnabled:      make(map[string]bool),
+		seriesFilterEnabled:          make(map[string]bool),
+		blockRanges:                  make(map[string]tsdb.DurationList),
 	}
 }
 
@@ -1035,6 +1130,22 @@ func (m *mockConfigProvider) CompactorBlockUploadVerifyChunks(tenantID string) b
 	return m.verifyChunks[tenantID]
 }
 
+func (m *mockConfigProvider) CompactorCardinalityIndexEnabled(tenantID string) bool {
+	return m.cardinalityIndexEnabled[tenantID]
+}
+
+func (m *mockConfigProvider) CompactorSeriesFilterEnabled(tenantID string) bool {
+	return m.seriesFilterEnabled[tenantID]
+}
+
+func (m *mockConfigProvider) CompactorBlockRanges(tenantID string) tsdb.DurationList {
+	return m.blockRanges[tenantID]
+}
+
+func (m *mockConfigProvider) CompactorMaxExemplarsBytesPerJob(tenantID string) int {
+	return m.maxExemplarsBytesPerJob[tenantID]
+}
+
 func (m *mockConfigProvider) S3SSEType(user string) string {
 	return ""
 }
@@ -1055,3 +1166,28 @@ func (c *BlocksCleaner) runCleanupWithErr(ctx context.Context) error {
 
 	return c.cleanUsers(ctx, allUsers, isDeleted)
 }
+
+type blocksChangedNotifierMock struct {
+	services.Service
+
+	mtx           sync.Mutex
+	notifiedUsers []string
+}
+
+func (m *blocksChangedNotifierMock) NotifyBlocksChanged(_ context.Context, userID string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.notifiedUsers = append(m.notifiedUsers, userID)
+}
+
+func (m *blocksChangedNotifierMock) getNotifiedUsers() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return append([]string(nil), m.notifiedUsers...)
+}
+
+func (m *blocksChangedNotifierMock) reset() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.notifiedUsers = nil
+}
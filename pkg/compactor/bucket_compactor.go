@@ -20,6 +20,8 @@ import (
 	"github.com/grafana/dskit/multierror"
 	"github.com/grafana/dskit/runutil"
 	"github.com/oklog/ulid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -28,11 +30,14 @@ import (
 	"github.com/thanos-io/objstore"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/cardinalityindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
 )
 
 type DeduplicateFilter interface {
@@ -235,6 +240,14 @@ type Planner interface {
 	Plan(ctx context.Context, metasByMinTime []*metadata.Meta) ([]*metadata.Meta, error)
 }
 
+// blockRangesPlanner is a Planner which depends on the compaction time ranges, which can be overridden per tenant.
+type blockRangesPlanner interface {
+	Planner
+
+	// withBlockRanges returns a planner for the input compaction time ranges.
+	withBlockRanges(ranges []int64) Planner
+}
+
 // Compactor provides compaction against an underlying storage of time series data.
 // This is similar to tsdb.Compactor just without Plan method.
 // TODO(bwplotka): Split the Planner from Compactor on upstream as well, so we can import it.
@@ -267,9 +280,17 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	jobLogger := log.With(c.logger, "groupKey", job.Key())
 	subDir := filepath.Join(c.compactDir, job.Key())
 
+	jobSpan, ctx := opentracing.StartSpanFromContext(ctx, "CompactionJob")
+	jobSpan.SetTag("user", job.UserID())
+	jobSpan.SetTag("group_key", job.Key())
+	jobSpan.SetTag("resolution", job.Resolution())
+	jobSpan.SetTag("split_shards", job.SplittingShards())
+
 	defer func() {
 		elapsed := time.Since(jobBeginTime)
 
+		finishSpan(jobSpan, rerr)
+
 		if rerr == nil {
 			level.Info(jobLogger).Log("msg", "compaction job succeeded", "duration", elapsed, "duration_ms", elapsed.Milliseconds())
 		} else {
@@ -285,15 +306,24 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 		return false, nil, errors.Wrap(err, "create compaction job dir")
 	}
 
+	planSpan, _ := opentracing.StartSpanFromContext(ctx, "CompactionJob.Plan")
+	planSpan.SetTag("candidate_blocks", len(job.metasByMinTime))
 	toCompact, err := c.planner.Plan(ctx, job.metasByMinTime)
 	if err != nil {
+		finishSpan(planSpan, err)
 		return false, nil, errors.Wrap(err, "plan compaction")
 	}
+	planSpan.SetTag("planned_blocks", len(toCompact))
+	planSpan.Finish()
+
 	if len(toCompact) == 0 {
 		// Nothing to do.
 		return false, nil, nil
 	}
 
+	jobSpan.SetTag("source_blocks", fmt.Sprintf("%v", blockIDs(toCompact)))
+	jobSpan.SetTag("source_blocks_size_bytes", blocksSizeBytes(toCompact))
+
 	// The planner returned some blocks to compact, so we can enrich the logger
 	// with the min/max time between all blocks to compact.
 	jobLogger = log.With(jobLogger, "minTime", minTime(toCompact).String(), "maxTime", maxTime(toCompact).String())
@@ -302,10 +332,18 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 
 	// Once we have a plan we need to download the actual data.
 	downloadBegin := time.Now()
+	downloadSpan, downloadCtx := opentracing.StartSpanFromContext(ctx, "CompactionJob.Download")
+	downloadSpan.SetTag("blocks", len(toCompact))
+	downloadSpan.SetTag("size_bytes", blocksSizeBytes(toCompact))
 
-	err = concurrency.ForEachJob(ctx, len(toCompact), c.blockSyncConcurrency, func(ctx context.Context, idx int) error {
+	err = concurrency.ForEachJob(downloadCtx, len(toCompact), c.blockSyncConcurrency, func(ctx context.Context, idx int) (rerr error) {
 		meta := toCompact[idx]
 
+		blockSpan, ctx := opentracing.StartSpanFromContext(ctx, "CompactionJob.DownloadBlock")
+		blockSpan.SetTag("block", meta.ULID.String())
+		blockSpan.SetTag("size_bytes", blockSizeBytes(meta))
+		defer func() { finishSpan(blockSpan, rerr) }()
+
 		// Must be the same as in blocksToCompactDirs.
 		bdir := filepath.Join(subDir, meta.ULID.String())
 
@@ -336,6 +374,7 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 		}
 		return nil
 	})
+	finishSpan(downloadSpan, err)
 	if err != nil {
 		return false, nil, err
 	}
@@ -351,11 +390,18 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	compactionBegin := time.Now()
 
 	if job.UseSplitting() {
+		compactSpan, _ := opentracing.StartSpanFromContext(ctx, "CompactionJob.Split")
+		compactSpan.SetTag("shards", job.SplittingShards())
 		compIDs, err = c.comp.CompactWithSplitting(subDir, blocksToCompactDirs, nil, uint64(job.SplittingShards()))
+		compactSpan.SetTag("result_blocks", fmt.Sprintf("%v", compIDs))
+		finishSpan(compactSpan, err)
 	} else {
+		compactSpan, _ := opentracing.StartSpanFromContext(ctx, "CompactionJob.Merge")
 		var compID ulid.ULID
 		compID, err = c.comp.Compact(subDir, blocksToCompactDirs, nil)
 		compIDs = append(compIDs, compID)
+		compactSpan.SetTag("result_blocks", fmt.Sprintf("%v", compIDs))
+		finishSpan(compactSpan, err)
 	}
 	if err != nil {
 		return false, nil, errors.Wrapf(err, "compact blocks %v", blocksToCompactDirs)
@@ -378,13 +424,25 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	elapsed = time.Since(compactionBegin)
 	level.Info(jobLogger).Log("msg", "compacted blocks", "new", fmt.Sprintf("%v", compIDs), "blocks", fmt.Sprintf("%v", blocksToCompactDirs), "duration", elapsed, "duration_ms", elapsed.Milliseconds())
 
+	// Exemplars persisted in the source blocks are carried over to the compacted blocks.
+	sourceExemplars := readBlocksExemplars(blocksToCompactDirs, c.maxExemplarsBytes, c.metrics.exemplarsFilesSkipped, jobLogger)
+
 	uploadBegin := time.Now()
 	uploadedBlocks := atomic.NewInt64(0)
+	uploadedBytes := atomic.NewInt64(0)
 
 	blocksToUpload := convertCompactionResultToForEachJobs(compIDs, job.UseSplitting(), jobLogger)
-	err = concurrency.ForEachJob(ctx, len(blocksToUpload), c.blockSyncConcurrency, func(ctx context.Context, idx int) error {
+	uploadSpan, uploadCtx := opentracing.StartSpanFromContext(ctx, "CompactionJob.Upload")
+	uploadSpan.SetTag("blocks", len(blocksToUpload))
+
+	err = concurrency.ForEachJob(uploadCtx, len(blocksToUpload), c.blockSyncConcurrency, func(ctx context.Context, idx int) (rerr error) {
 		blockToUpload := blocksToUpload[idx]
 
+		blockSpan, ctx := opentracing.StartSpanFromContext(ctx, "CompactionJob.UploadBlock")
+		blockSpan.SetTag("block", blockToUpload.ulid.String())
+		blockSpan.SetTag("shard_index", blockToUpload.shardIndex)
+		defer func() { finishSpan(blockSpan, rerr) }()
+
 		uploadedBlocks.Inc()
 
 		bdir := filepath.Join(subDir, blockToUpload.ulid.String())
@@ -414,15 +472,40 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 			return errors.Wrapf(err, "invalid result block %s", bdir)
 		}
 
+		blockExemplars := sourceExemplars
+		if job.UseSplitting() {
+			blockExemplars = filterSeriesExemplarsByShard(sourceExemplars, uint64(blockToUpload.shardIndex), uint64(job.SplittingShards()))
+		}
+		if len(blockExemplars) > 0 {
+			if err := block.WriteExemplarsFile(bdir, blockExemplars); err != nil {
+				return errors.Wrapf(err, "write exemplars of block %s", bdir)
+			}
+		}
+
+		// The cardinality summary is a best effort, so the block is uploaded without it if it fails to be written.
+		if c.writeCardinalitySummaries {
+			if err := cardinalityindex.WriteSummaryFile(bdir); err != nil {
+				c.metrics.cardinalitySummaryFailures.Inc()
+				level.Warn(jobLogger).Log("msg", "failed to write cardinality summary of block", "block", blockToUpload.ulid, "err", err)
+			}
+		}
+
 		begin := time.Now()
-		if err := block.Upload(ctx, jobLogger, c.bkt, bdir, nil); err != nil {
+		if err := block.Upload(ctx, jobLogger, c.bkt, bdir, newMeta); err != nil {
 			return errors.Wrapf(err, "upload of %s failed", blockToUpload.ulid)
 		}
 
+		// The upload gathered the file stats of the block, so its size is now known.
+		size := blockSizeBytes(newMeta)
+		blockSpan.SetTag("size_bytes", size)
+		uploadedBytes.Add(size)
+
 		elapsed := time.Since(begin)
 		level.Info(jobLogger).Log("msg", "uploaded block", "result_block", blockToUpload.ulid, "duration", elapsed, "duration_ms", elapsed.Milliseconds(), "external_labels", labels.FromMap(newLabels))
 		return nil
 	})
+	uploadSpan.SetTag("size_bytes", uploadedBytes.Load())
+	finishSpan(uploadSpan, err)
 	if err != nil {
 		return false, nil, err
 	}
@@ -433,15 +516,116 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 	// Mark for deletion the blocks we just compacted from the job and bucket so they do not get included
 	// into the next planning cycle.
 	// Eventually the block we just uploaded should get synced into the job again (including sync-delay).
+	cleanupSpan, _ := opentracing.StartSpanFromContext(ctx, "CompactionJob.Cleanup")
+	cleanupSpan.SetTag("blocks", fmt.Sprintf("%v", blockIDs(toCompact)))
 	for _, meta := range toCompact {
 		if err := deleteBlock(c.bkt, meta.ULID, filepath.Join(subDir, meta.ULID.String()), jobLogger, c.metrics.blocksMarkedForDeletion); err != nil {
+			finishSpan(cleanupSpan, err)
 			return false, nil, errors.Wrapf(err, "mark old block for deletion from bucket")
 		}
 	}
+	cleanupSpan.Finish()
+
+	jobSpan.SetTag("result_blocks", fmt.Sprintf("%v", compIDs))
+	jobSpan.SetTag("result_blocks_size_bytes", uploadedBytes.Load())
 
 	return true, compIDs, nil
 }
 
+// finishSpan records err, if any, on the span and finishes it.
+func finishSpan(span opentracing.Span, err error) {
+	if err != nil {
+		ext.LogError(span, err)
+	}
+	span.Finish()
+}
+
+func blockIDs(metas []*metadata.Meta) []ulid.ULID {
+	ids := make([]ulid.ULID, 0, len(metas))
+	for _, meta := range metas {
+		ids = append(ids, meta.ULID)
+	}
+	return ids
+}
+
+// blockSizeBytes returns the size of the block as reported by the files listed in its meta.json.
+// Blocks without file stats (e.g. uploaded by an old version) report a size of 0.
+func blockSizeBytes(meta *metadata.Meta) int64 {
+	size := int64(0)
+	for _, f := range meta.Thanos.Files {
+		size += f.SizeBytes
+	}
+	return size
+}
+
+func blocksSizeBytes(metas []*metadata.Meta) int64 {
+	size := int64(0)
+	for _, meta := range metas {
+		size += blockSizeBytes(meta)
+	}
+	return size
+}
+
+const (
+	exemplarsFileSkippedReasonReadFailure = "read-failure"
+	exemplarsFileSkippedReasonTooLarge    = "too-large"
+)
+
+// readBlocksExemplars reads and merges the exemplars persisted in the input blocks. Exemplars are persisted on a
+// best-effort basis, so blocks whose exemplars can't be read are skipped, as well as the blocks whose exemplars file
+// would exceed the maxBytes total size of the exemplars files read, which bounds the memory used to carry over the
+// exemplars to the compacted blocks. 0 disables the limit. The skipped files are counted in skipped, by reason.
+func readBlocksExemplars(blockDirs []string, maxBytes int64, skipped *prometheus.CounterVec, logger log.Logger) []storepb.SeriesExemplars {
+	var (
+		sets      [][]storepb.SeriesExemplars
+		readBytes int64
+	)
+	for _, dir := range blockDirs {
+		info, err := os.Stat(filepath.Join(dir, block.ExemplarsFilename))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to read exemplars of source block, exemplars won't be carried over to the compacted block", "block", dir, "err", err)
+			skipped.WithLabelValues(exemplarsFileSkippedReasonReadFailure).Inc()
+			continue
+		}
+		if maxBytes > 0 && readBytes+info.Size() > maxBytes {
+			level.Warn(logger).Log("msg", "exemplars of source block are too large, exemplars won't be carried over to the compacted block", "block", dir, "size", info.Size(), "read_size", readBytes, "max_size", maxBytes)
+			skipped.WithLabelValues(exemplarsFileSkippedReasonTooLarge).Inc()
+			continue
+		}
+		readBytes += info.Size()
+
+		series, err := block.ReadExemplarsFile(dir)
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to read exemplars of source block, exemplars won't be carried over to the compacted block", "block", dir, "err", err)
+			skipped.WithLabelValues(exemplarsFileSkippedReasonReadFailure).Inc()
+			continue
+		}
+		if len(series) > 0 {
+			sets = append(sets, series)
+		}
+	}
+
+	if len(sets) == 0 {
+		return nil
+	}
+	return block.MergeSeriesExemplars(sets...)
+}
+
+// filterSeriesExemplarsByShard returns the exemplars of the series belonging to the input shard, using the same
+// series-sharding algorithm of the split compaction.
+func filterSeriesExemplarsByShard(series []storepb.SeriesExemplars, shardIndex, shardCount uint64) []storepb.SeriesExemplars {
+	var res []storepb.SeriesExemplars
+	for _, s := range series {
+		if labels.StableHash(mimirpb.FromLabelAdaptersToLabels(s.Labels))%shardCount == shardIndex {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
 // convertCompactionResultToForEachJobs filters out empty ULIDs.
 // When handling result of split compactions, shard index is index in the slice returned by compaction.
 func convertCompactionResultToForEachJobs(compactedBlocks []ulid.ULID, splitJob bool, jobLogger log.Logger) []ulidWithShardIndex {
@@ -591,6 +775,8 @@ type BucketCompactorMetrics struct {
 	blocksMarkedForDeletion      prometheus.Counter
 	blocksMarkedForNoCompact     prometheus.Counter
 	blocksMaxTimeDelta           prometheus.Histogram
+	cardinalitySummaryFailures   prometheus.Counter
+	exemplarsFilesSkipped        *prometheus.CounterVec
 }
 
 // NewBucketCompactorMetrics makes a new BucketCompactorMetrics.
@@ -623,6 +809,14 @@ func NewBucketCompactorMetrics(blocksMarkedForDeletion prometheus.Counter, reg p
 			Help:    "Difference between now and the max time of a block being compacted in seconds.",
 			Buckets: prometheus.LinearBuckets(86400, 43200, 8), // 1 to 5 days, in 12 hour intervals
 		}),
+		cardinalitySummaryFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_cardinality_summary_failures_total",
+			Help: "Total number of compacted blocks uploaded without a cardinality summary, because it failed to be written or was too large.",
+		}),
+		exemplarsFilesSkipped: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_compactor_exemplars_files_skipped_total",
+			Help: "Total number of exemplars files of the source blocks whose exemplars weren't carried over to the compacted blocks, because they failed to be read or exceeded the max size of the exemplars read by a compaction job.",
+		}, []string{"reason"}),
 	}
 }
 
@@ -648,6 +842,8 @@ type BucketCompactor struct {
 	sortJobs                       JobsOrderFunc
 	waitPeriod                     time.Duration
 	blockSyncConcurrency           int
+	writeCardinalitySummaries      bool
+	maxExemplarsBytes              int64
 	metrics                        *BucketCompactorMetrics
 }
 
@@ -666,6 +862,8 @@ func NewBucketCompactor(
 	sortJobs JobsOrderFunc,
 	waitPeriod time.Duration,
 	blockSyncConcurrency int,
+	writeCardinalitySummaries bool,
+	maxExemplarsBytes int64,
 	metrics *BucketCompactorMetrics,
 ) (*BucketCompactor, error) {
 	if concurrency <= 0 {
@@ -685,6 +883,8 @@ func NewBucketCompactor(
 		sortJobs:                       sortJobs,
 		waitPeriod:                     waitPeriod,
 		blockSyncConcurrency:           blockSyncConcurrency,
+		writeCardinalitySummaries:      writeCardinalitySummaries,
+		maxExemplarsBytes:              maxExemplarsBytes,
 		metrics:                        metrics,
 	}, nil
 }
@@ -23,6 +23,8 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/runutil"
 	"github.com/oklog/ulid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -43,6 +45,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/cardinalityindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
 )
 
@@ -248,7 +251,7 @@ func TestGroupCompactE2E(t *testing.T) {
 		planner := NewSplitAndMergePlanner([]int64{1000, 3000})
 		grouper := NewSplitAndMergeGrouper("user-1", []int64{1000, 3000}, 0, 0, logger)
 		metrics := NewBucketCompactorMetrics(blocksMarkedForDeletion, prometheus.NewPedanticRegistry())
-		bComp, err := NewBucketCompactor(logger, sy, grouper, planner, comp, dir, bkt, 2, true, ownAllJobs, sortJobsByNewestBlocksFirst, 0, 4, metrics)
+		bComp, err := NewBucketCompactor(logger, sy, grouper, planner, comp, dir, bkt, 2, true, ownAllJobs, sortJobsByNewestBlocksFirst, 0, 4, false, 0, metrics)
 		require.NoError(t, err)
 
 		// Compaction on empty should not fail.
@@ -563,6 +566,159 @@ func TestGarbageCollectDoesntCreateEmptyBlocksWithDeletionMarksOnly(t *testing.T
 	})
 }
 
+func TestBucketCompactor_runCompactionJob_Tracing(t *testing.T) {
+	for _, useSplitting := range []bool{false, true} {
+		useSplitting := useSplitting
+
+		t.Run(fmt.Sprintf("splitting=%t", useSplitting), func(t *testing.T) {
+			// Spans are started through the global tracer, so the test must not run in parallel.
+			tracer := mocktracer.New()
+			prevTracer := opentracing.GlobalTracer()
+			opentracing.SetGlobalTracer(tracer)
+			t.Cleanup(func() { opentracing.SetGlobalTracer(prevTracer) })
+			ctx := context.Background()
+
+			bkt := objstore.NewInMemBucket()
+			logger := log.NewNopLogger()
+			extLabels := labels.FromStrings("e1", "1")
+
+			created := createAndUpload(t, bkt, []blockgenSpec{
+				{numSamples: 100, mint: 0, maxt: 1000, extLset: extLabels, series: []labels.Labels{labels.FromStrings("a", "1")}},
+				{numSamples: 100, mint: 1000, maxt: 2000, extLset: extLabels, series: []labels.Labels{labels.FromStrings("a", "2")}},
+			}, nil)
+
+			shards := uint32(0)
+			if useSplitting {
+				shards = 2
+			}
+
+			job := NewJob("user-1", "job-1", extLabels, 0, useSplitting, shards, "")
+			var sourceSize int64
+			for _, m := range created {
+				// Use the meta stored in the bucket, which includes the file stats.
+				meta, err := block.DownloadMeta(ctx, logger, bkt, m.ULID)
+				require.NoError(t, err)
+				require.NoError(t, job.AppendMeta(&meta))
+				sourceSize += blockSizeBytes(&meta)
+			}
+			require.Greater(t, sourceSize, int64(0))
+
+			comp, err := tsdb.NewLeveledCompactor(ctx, nil, logger, []int64{1000, 3000}, nil, nil, true)
+			require.NoError(t, err)
+
+			blocksMarkedForDeletion := promauto.With(nil).NewCounter(prometheus.CounterOpts{})
+			metrics := NewBucketCompactorMetrics(blocksMarkedForDeletion, prometheus.NewPedanticRegistry())
+			bComp, err := NewBucketCompactor(logger, nil, nil, NewSplitAndMergePlanner([]int64{1000, 3000}), comp, t.TempDir(), bkt, 2, true, ownAllJobs, sortJobsByNewestBlocksFirst, 0, 4, false, 0, metrics)
+			require.NoError(t, err)
+
+			_, compIDs, err := bComp.runCompactionJob(ctx, job)
+			require.NoError(t, err)
+			require.NotEmpty(t, compIDs)
+
+			spansByName := map[string][]*mocktracer.MockSpan{}
+			for _, span := range tracer.FinishedSpans() {
+				spansByName[span.OperationName] = append(spansByName[span.OperationName], span)
+			}
+
+			require.Len(t, spansByName["CompactionJob"], 1)
+			jobSpan := spansByName["CompactionJob"][0]
+			assert.Equal(t, "user-1", jobSpan.Tag("user"))
+			assert.Equal(t, "job-1", jobSpan.Tag("group_key"))
+			assert.Equal(t, fmt.Sprintf("%v", []ulid.ULID{created[0].ULID, created[1].ULID}), jobSpan.Tag("source_blocks"))
+			assert.Equal(t, sourceSize, jobSpan.Tag("source_blocks_size_bytes"))
+			assert.Equal(t, fmt.Sprintf("%v", compIDs), jobSpan.Tag("result_blocks"))
+			assert.Greater(t, jobSpan.Tag("result_blocks_size_bytes"), int64(0))
+
+			stages := []string{"CompactionJob.Plan", "CompactionJob.Download", "CompactionJob.Upload", "CompactionJob.Cleanup"}
+			if useSplitting {
+				stages = append(stages, "CompactionJob.Split")
+				assert.Empty(t, spansByName["CompactionJob.Merge"])
+			} else {
+				stages = append(stages, "CompactionJob.Merge")
+				assert.Empty(t, spansByName["CompactionJob.Split"])
+			}
+			for _, stage := range stages {
+				require.Len(t, spansByName[stage], 1, stage)
+				assert.Equal(t, jobSpan.SpanContext.SpanID, spansByName[stage][0].ParentID, stage)
+			}
+
+			downloadSpan := spansByName["CompactionJob.Download"][0]
+			assert.Equal(t, sourceSize, downloadSpan.Tag("size_bytes"))
+			require.Len(t, spansByName["CompactionJob.DownloadBlock"], 2)
+			for _, span := range spansByName["CompactionJob.DownloadBlock"] {
+				assert.Equal(t, downloadSpan.SpanContext.SpanID, span.ParentID)
+				assert.NotEmpty(t, span.Tag("block"))
+				assert.Greater(t, span.Tag("size_bytes"), int64(0))
+			}
+
+			uploadSpan := spansByName["CompactionJob.Upload"][0]
+			require.NotEmpty(t, spansByName["CompactionJob.UploadBlock"])
+			for _, span := range spansByName["CompactionJob.UploadBlock"] {
+				assert.Equal(t, uploadSpan.SpanContext.SpanID, span.ParentID)
+				assert.NotEmpty(t, span.Tag("block"))
+				assert.Greater(t, span.Tag("size_bytes"), int64(0))
+			}
+		})
+	}
+}
+
+func TestBucketCompactor_runCompactionJob_ShouldWriteCardinalitySummaries(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		enabled := enabled
+
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			ctx := context.Background()
+			bkt := objstore.NewInMemBucket()
+			logger := log.NewNopLogger()
+			extLabels := labels.FromStrings("e1", "1")
+
+			created := createAndUpload(t, bkt, []blockgenSpec{
+				{numSamples: 100, mint: 0, maxt: 1000, extLset: extLabels, series: []labels.Labels{labels.FromStrings("__name__", "up", "job", "a")}},
+				{numSamples: 100, mint: 1000, maxt: 2000, extLset: extLabels, series: []labels.Labels{labels.FromStrings("__name__", "up", "job", "b")}},
+			}, nil)
+
+			job := NewJob("user-1", "job-1", extLabels, 0, false, 0, "")
+			for _, m := range created {
+				require.NoError(t, job.AppendMeta(m))
+			}
+
+			comp, err := tsdb.NewLeveledCompactor(ctx, nil, logger, []int64{1000, 3000}, nil, nil, true)
+			require.NoError(t, err)
+
+			metrics := NewBucketCompactorMetrics(promauto.With(nil).NewCounter(prometheus.CounterOpts{}), prometheus.NewPedanticRegistry())
+			bComp, err := NewBucketCompactor(logger, nil, nil, NewSplitAndMergePlanner([]int64{1000, 3000}), comp, t.TempDir(), bkt, 2, true, ownAllJobs, sortJobsByNewestBlocksFirst, 0, 4, enabled, 0, metrics)
+			require.NoError(t, err)
+
+			_, compIDs, err := bComp.runCompactionJob(ctx, job)
+			require.NoError(t, err)
+			require.Len(t, compIDs, 1)
+
+			summary, err := cardinalityindex.ReadSummary(ctx, objstore.WithNoopInstr(bkt), compIDs[0], logger)
+			if !enabled {
+				require.Equal(t, cardinalityindex.ErrSummaryNotFound, err)
+				return
+			}
+			require.NoError(t, err)
+
+			b, err := summary.Block(compIDs[0], 0, 2000)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]*cardinalityindex.Metric{
+				"up": {Series: 2, Labels: map[string]map[string]uint64{"job": {"a": 1, "b": 1}}},
+			}, b.Metrics)
+
+			// The summary is listed in the files of the block.
+			meta, err := block.DownloadMeta(ctx, logger, bkt, compIDs[0])
+			require.NoError(t, err)
+			var files []string
+			for _, f := range meta.Thanos.Files {
+				files = append(files, f.RelPath)
+			}
+			assert.Contains(t, files, block.CardinalitySummaryFilename)
+			assert.Equal(t, 0.0, promtest.ToFloat64(metrics.cardinalitySummaryFailures))
+		})
+	}
+}
+
 func listBlocksMarkedForDeletion(ctx context.Context, bkt objstore.Bucket) ([]ulid.ULID, error) {
 	var rem []ulid.ULID
 	err := bkt.Iter(ctx, "", func(n string) error {
@@ -7,6 +7,9 @@ package compactor
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -22,9 +25,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util/extprom"
 )
 
@@ -121,7 +126,7 @@ func TestBucketCompactor_FilterOwnJobs(t *testing.T) {
 	m := NewBucketCompactorMetrics(promauto.With(nil).NewCounter(prometheus.CounterOpts{}), nil)
 	for testName, testCase := range tests {
 		t.Run(testName, func(t *testing.T) {
-			bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, false, testCase.ownJob, nil, 0, 4, m)
+			bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, false, testCase.ownJob, nil, 0, 4, false, 0, m)
 			require.NoError(t, err)
 
 			res, err := bc.filterOwnJobs(jobsFn())
@@ -157,7 +162,7 @@ func TestBlockMaxTimeDeltas(t *testing.T) {
 
 	metrics := NewBucketCompactorMetrics(promauto.With(nil).NewCounter(prometheus.CounterOpts{}), nil)
 	now := time.UnixMilli(1500002900159)
-	bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, false, nil, nil, 0, 4, metrics)
+	bc, err := NewBucketCompactor(log.NewNopLogger(), nil, nil, nil, nil, "", nil, 2, false, nil, nil, 0, 4, false, 0, metrics)
 	require.NoError(t, err)
 
 	deltas := bc.blockMaxTimeDeltas(now, []*Job{j1, j2})
@@ -282,3 +287,59 @@ func TestConvertCompactionResultToForEachJobs(t *testing.T) {
 	require.Equal(t, ulidWithShardIndex{ulid: ulid1, shardIndex: 1}, res[0])
 	require.Equal(t, ulidWithShardIndex{ulid: ulid2, shardIndex: 3}, res[1])
 }
+
+func TestReadBlocksExemplarsAndFilterByShard(t *testing.T) {
+	const shardCount = 2
+
+	var (
+		dir1, dir2, dir3 = t.TempDir(), t.TempDir(), t.TempDir()
+		exemplar1        = mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "1"}}, TimestampMs: 10, Value: 1}
+		exemplar2        = mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "2"}}, TimestampMs: 20, Value: 2}
+	)
+
+	var series []storepb.SeriesExemplars
+	for i := 0; i < 10; i++ {
+		series = append(series, storepb.SeriesExemplars{
+			Labels:    mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, fmt.Sprintf("series_%d", i))),
+			Exemplars: []mimirpb.Exemplar{exemplar1},
+		})
+	}
+
+	// The first block has exemplars for all series, the second one for the first series only,
+	// while the third block has no exemplars file.
+	require.NoError(t, block.WriteExemplarsFile(dir1, series))
+	require.NoError(t, block.WriteExemplarsFile(dir2, []storepb.SeriesExemplars{{Labels: series[0].Labels, Exemplars: []mimirpb.Exemplar{exemplar2}}}))
+
+	skipped := NewBucketCompactorMetrics(nil, nil).exemplarsFilesSkipped
+
+	// A 0 max total size disables the limit.
+	merged := readBlocksExemplars([]string{dir1, dir2, dir3}, 0, skipped, log.NewNopLogger())
+	require.Len(t, merged, len(series))
+	assert.Equal(t, []mimirpb.Exemplar{exemplar1, exemplar2}, merged[0].Exemplars)
+	assert.Equal(t, float64(0), testutil.ToFloat64(skipped.WithLabelValues(exemplarsFileSkippedReasonTooLarge)))
+
+	// The exemplars files exceeding the max total size are skipped.
+	info, err := os.Stat(filepath.Join(dir2, block.ExemplarsFilename))
+	require.NoError(t, err)
+	capped := readBlocksExemplars([]string{dir2, dir1, dir3}, info.Size(), skipped, log.NewNopLogger())
+	require.Len(t, capped, 1)
+	assert.Equal(t, []mimirpb.Exemplar{exemplar2}, capped[0].Exemplars)
+	assert.Equal(t, float64(1), testutil.ToFloat64(skipped.WithLabelValues(exemplarsFileSkippedReasonTooLarge)))
+
+	// The exemplars files which can't be read are skipped.
+	require.NoError(t, os.WriteFile(filepath.Join(dir3, block.ExemplarsFilename), []byte("corrupted"), 0o644))
+	readable := readBlocksExemplars([]string{dir2, dir3}, 0, skipped, log.NewNopLogger())
+	require.Len(t, readable, 1)
+	assert.Equal(t, []mimirpb.Exemplar{exemplar2}, readable[0].Exemplars)
+	assert.Equal(t, float64(1), testutil.ToFloat64(skipped.WithLabelValues(exemplarsFileSkippedReasonReadFailure)))
+
+	// Each series should end up in exactly one shard.
+	numSeries := 0
+	for shardIndex := uint64(0); shardIndex < shardCount; shardIndex++ {
+		for _, s := range filterSeriesExemplarsByShard(merged, shardIndex, shardCount) {
+			assert.Equal(t, shardIndex, labels.StableHash(mimirpb.FromLabelAdaptersToLabels(s.Labels))%shardCount)
+			numSeries++
+		}
+	}
+	assert.Equal(t, len(series), numSeries)
+}
@@ -14,6 +14,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -113,6 +114,8 @@ type Config struct {
 
 	CompactionJobsOrder string `yaml:"compaction_jobs_order" category:"advanced"`
 
+	StoreGatewaysNotificationEnabled bool `yaml:"store_gateways_notification_enabled" category:"experimental"`
+
 	// No need to add options to customize the retry backoff,
 	// given the defaults should be fine, but allow to override
 	// it in tests.
@@ -122,6 +125,12 @@ type Config struct {
 	// Allow downstream projects to customise the blocks compactor.
 	BlocksGrouperFactory   BlocksGrouperFactory   `yaml:"-"`
 	BlocksCompactorFactory BlocksCompactorFactory `yaml:"-"`
+
+	// Notifier of the tenants whose blocks have changed. Set when the store-gateways notification is enabled.
+	BlocksChangedNotifier BlocksChangedNotifier `yaml:"-"`
+
+	// Stores of the configurations of the tenants deleted when a tenant is offboarded.
+	TenantConfigStores []TenantConfigStore `yaml:"-"`
 }
 
 // RegisterFlags registers the MultitenantCompactor flags.
@@ -155,6 +164,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.IntVar(&cfg.SymbolsFlushersConcurrency, "compactor.symbols-flushers-concurrency", 1, "Number of symbols flushers used when doing split compaction.")
 	f.IntVar(&cfg.MaxBlockUploadValidationConcurrency, "compactor.max-block-upload-validation-concurrency", 1, "Max number of uploaded blocks that can be validated concurrently. 0 = no limit.")
 
+	f.BoolVar(&cfg.StoreGatewaysNotificationEnabled, "compactor.store-gateways-notification-enabled", false, "When enabled, the compactor notifies the store-gateways owning a tenant after the tenant's bucket index has been updated with new or deleted blocks, so that the store-gateways sync the tenant without waiting for the next periodic sync. The store-gateways are discovered through the store-gateway ring, which must be configured in the compactor too. When the store-gateways metadata cache is enabled, the changes are discovered once the cached bucket index expires, as configured by -blocks-storage.bucket-store.metadata-cache.bucket-index-content-ttl.")
+
 	f.Var(&cfg.EnabledTenants, "compactor.enabled-tenants", "Comma separated list of tenants that can be compacted. If specified, only these tenants will be compacted by compactor, otherwise all tenants can be compacted. Subject to sharding.")
 	f.Var(&cfg.DisabledTenants, "compactor.disabled-tenants", "Comma separated list of tenants that cannot be compacted by this compactor. If specified, and compactor would normally pick given tenant for compaction (via -compactor.enabled-tenants or sharding), it will be ignored instead.")
 }
@@ -189,6 +200,14 @@ func (cfg *Config) Validate(logger log.Logger) error {
 	return nil
 }
 
+// BlocksChangedNotifier is notified when the blocks of a tenant in the bucket have changed.
+type BlocksChangedNotifier interface {
+	services.Service
+
+	// NotifyBlocksChanged notifies that the blocks of the given tenant have changed.
+	NotifyBlocksChanged(ctx context.Context, userID string)
+}
+
 // ConfigProvider defines the per-tenant config provider for the MultitenantCompactor.
 type ConfigProvider interface {
 	bucket.TenantConfigProvider
@@ -219,6 +238,20 @@ type ConfigProvider interface {
 
 	// CompactorBlockUploadVerifyChunks returns whether chunk verification is enabled for a given tenant.
 	CompactorBlockUploadVerifyChunks(tenantID string) bool
+
+	// CompactorCardinalityIndexEnabled returns whether the compactor writes the cardinality summaries of the blocks of a given tenant.
+	CompactorCardinalityIndexEnabled(tenantID string) bool
+
+	// CompactorSeriesFilterEnabled returns whether the series filter index is enabled for a given tenant.
+	CompactorSeriesFilterEnabled(tenantID string) bool
+
+	// CompactorBlockRanges returns the compaction time ranges of a given tenant. If empty, the ones configured
+	// in the compactor are used.
+	CompactorBlockRanges(tenantID string) mimir_tsdb.DurationList
+
+	// CompactorMaxExemplarsBytesPerJob returns the maximum total size of the exemplars files read by a compaction job
+	// of a given tenant. 0 = no limit.
+	CompactorMaxExemplarsBytesPerJob(tenantID string) int
 }
 
 // MultitenantCompactor is a multi-tenant TSDB blocks compactor based on Thanos.
@@ -269,6 +302,7 @@ type MultitenantCompactor struct {
 	compactionRunFailedTenants     prometheus.Gauge
 	compactionRunInterval          prometheus.Gauge
 	blocksMarkedForDeletion        prometheus.Counter
+	tenantBlockRanges              *prometheus.GaugeVec
 
 	// Metrics shared across all BucketCompactor instances.
 	bucketCompactorMetrics *BucketCompactorMetrics
@@ -372,6 +406,10 @@ func newMultitenantCompactor(
 			Help:        blocksMarkedForDeletionHelp,
 			ConstLabels: prometheus.Labels{"reason": "compaction"},
 		}),
+		tenantBlockRanges: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_compactor_tenant_block_range_seconds",
+			Help: "The compaction time ranges applied to the tenants owned by the compactor, by compaction level, as of the last compaction run.",
+		}, []string{"user", "level"}),
 	}
 
 	promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
@@ -428,7 +466,12 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 		return err
 	}
 
-	c.ringSubservices, err = services.NewManager(c.ringLifecycler, c.ring)
+	ringSubservices := []services.Service{c.ringLifecycler, c.ring}
+	if c.compactorCfg.BlocksChangedNotifier != nil {
+		ringSubservices = append(ringSubservices, c.compactorCfg.BlocksChangedNotifier)
+	}
+
+	c.ringSubservices, err = services.NewManager(ringSubservices...)
 	if err != nil {
 		return errors.Wrap(err, "unable to create compactor ring dependencies")
 	}
@@ -481,6 +524,8 @@ func (c *MultitenantCompactor) starting(ctx context.Context) error {
 		CleanupConcurrency:      c.compactorCfg.CleanupConcurrency,
 		TenantCleanupDelay:      c.compactorCfg.TenantCleanupDelay,
 		DeleteBlocksConcurrency: defaultDeleteBlocksConcurrency,
+		BlocksChangedNotifier:   c.compactorCfg.BlocksChangedNotifier,
+		SeriesFilterDir:         filepath.Join(c.compactorCfg.DataDir, "series-filter"),
 	}, c.bucketClient, c.shardingStrategy.blocksCleanerOwnUser, c.cfgProvider, c.parentLogger, c.registerer)
 
 	// Start blocks cleaner asynchronously, don't wait until initial cleanup is finished.
@@ -646,6 +691,15 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
 	}
 
+	// Expose the block ranges of the owned tenants only, so that the ones of the tenants
+	// now owned by other compactors are removed.
+	c.tenantBlockRanges.Reset()
+	for userID := range ownedUsers {
+		for i, r := range c.blockRangesForUser(userID) {
+			c.tenantBlockRanges.WithLabelValues(userID, strconv.Itoa(i+1)).Set(r.Seconds())
+		}
+	}
+
 	// Delete local files for unowned tenants, if there are any. This cleans up
 	// leftover local files for tenants that belong to different compactors now,
 	// or have been deleted completely.
@@ -676,6 +730,14 @@ func (c *MultitenantCompactor) compactUsers(ctx context.Context) {
 	succeeded = true
 }
 
+// blockRangesForUser returns the compaction time ranges of the tenant, which may be overridden per tenant.
+func (c *MultitenantCompactor) blockRangesForUser(userID string) mimir_tsdb.DurationList {
+	if ranges := c.cfgProvider.CompactorBlockRanges(userID); len(ranges) > 0 {
+		return ranges
+	}
+	return c.compactorCfg.BlockRanges
+}
+
 func (c *MultitenantCompactor) compactUserWithRetries(ctx context.Context, userID string) error {
 	var lastErr error
 
@@ -753,11 +815,21 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		return errors.Wrap(err, "failed to create syncer")
 	}
 
+	// The block ranges may be overridden per tenant.
+	blockRanges := c.blockRangesForUser(userID)
+	userCfg := c.compactorCfg
+	userCfg.BlockRanges = blockRanges
+
+	planner := c.blocksPlanner
+	if p, ok := planner.(blockRangesPlanner); ok {
+		planner = p.withBlockRanges(blockRanges.ToMilliseconds())
+	}
+
 	compactor, err := NewBucketCompactor(
 		userLogger,
 		syncer,
-		c.blocksGrouperFactory(ctx, c.compactorCfg, c.cfgProvider, userID, userLogger, reg),
-		c.blocksPlanner,
+		c.blocksGrouperFactory(ctx, userCfg, c.cfgProvider, userID, userLogger, reg),
+		planner,
 		c.blocksCompactor,
 		path.Join(c.compactorCfg.DataDir, "compact"),
 		userBucket,
@@ -767,6 +839,8 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		c.jobsOrder,
 		c.compactorCfg.CompactionWaitPeriod,
 		c.compactorCfg.BlockSyncConcurrency,
+		c.cfgProvider.CompactorCardinalityIndexEnabled(userID),
+		int64(c.cfgProvider.CompactorMaxExemplarsBytesPerJob(userID)),
 		c.bucketCompactorMetrics,
 	)
 	if err != nil {
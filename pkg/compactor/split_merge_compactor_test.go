@@ -744,6 +744,93 @@ func TestMultitenantCompactor_ShouldSupportSplitAndMergeCompactor(t *testing.T)
 	}
 }
 
+func TestMultitenantCompactor_ShouldApplyTheTenantBlockRanges(t *testing.T) {
+	const (
+		numSeries  = 10
+		blockRange = 2 * time.Hour
+	)
+
+	blockRangeMillis := blockRange.Milliseconds()
+
+	workDir := t.TempDir()
+	storageDir := t.TempDir()
+	fetcherDir := t.TempDir()
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	storageCfg.Bucket.Backend = bucket.Filesystem
+	storageCfg.Bucket.Filesystem.Directory = storageDir
+
+	compactorCfg := prepareConfig(t)
+	compactorCfg.DataDir = workDir
+	compactorCfg.BlockRanges = mimir_tsdb.DurationList{blockRange}
+
+	// Only the second tenant compacts the blocks in the 2nd range.
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.blockRanges["user-2"] = mimir_tsdb.DurationList{blockRange, 2 * blockRange}
+
+	logger := log.NewLogfmtLogger(os.Stdout)
+	reg := prometheus.NewPedanticRegistry()
+	ctx := context.Background()
+
+	bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", logger, nil)
+	require.NoError(t, err)
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		createTSDBBlock(t, bucketClient, userID, 1, blockRangeMillis, numSeries, nil)
+		createTSDBBlock(t, bucketClient, userID, blockRangeMillis, 2*blockRangeMillis, numSeries, nil)
+
+		// Add another block as "most recent one" otherwise the previous blocks are not compacted
+		// because the most recent blocks must cover the full range to be compacted.
+		createTSDBBlock(t, bucketClient, userID, 2*blockRangeMillis, 2*blockRangeMillis+time.Minute.Milliseconds(), numSeries, nil)
+	}
+
+	c, err := NewMultitenantCompactor(compactorCfg, storageCfg, cfgProvider, logger, reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+	})
+
+	// Wait until the first compaction run completed.
+	test.Poll(t, 15*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_compactor_runs_completed_total Total number of compaction runs successfully completed.
+			# TYPE cortex_compactor_runs_completed_total counter
+			cortex_compactor_runs_completed_total 1
+		`), "cortex_compactor_runs_completed_total")
+	})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_tenant_block_range_seconds The compaction time ranges applied to the tenants owned by the compactor, by compaction level, as of the last compaction run.
+		# TYPE cortex_compactor_tenant_block_range_seconds gauge
+		cortex_compactor_tenant_block_range_seconds{level="1",user="user-1"} 7200
+		cortex_compactor_tenant_block_range_seconds{level="1",user="user-2"} 7200
+		cortex_compactor_tenant_block_range_seconds{level="2",user="user-2"} 14400
+	`), "cortex_compactor_tenant_block_range_seconds"))
+
+	// The first block of the second tenant covers the 2nd range.
+	for userID, expected := range map[string]struct {
+		numBlocks         int
+		firstBlockMaxTime int64
+	}{
+		"user-1": {numBlocks: 3, firstBlockMaxTime: blockRangeMillis},
+		"user-2": {numBlocks: 2, firstBlockMaxTime: 2 * blockRangeMillis},
+	} {
+		userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
+		fetcher, err := block.NewMetaFetcher(logger, 1, userBucket, filepath.Join(fetcherDir, userID), nil, []block.MetadataFilter{NewExcludeMarkedForDeletionFilter(userBucket)})
+		require.NoError(t, err)
+		metas, partials, err := fetcher.Fetch(ctx)
+		require.NoError(t, err)
+		require.Empty(t, partials)
+
+		actual := sortMetasByMinTime(convertMetasMapToSlice(metas))
+		require.Len(t, actual, expected.numBlocks, userID)
+		assert.Equal(t, int64(1), actual[0].MinTime, userID)
+		assert.Equal(t, expected.firstBlockMaxTime, actual[0].MaxTime, userID)
+	}
+}
+
 func TestMultitenantCompactor_ShouldGuaranteeSeriesShardingConsistencyOverTheTime(t *testing.T) {
 	const (
 		userID     = "user-1"
@@ -19,6 +19,11 @@ func NewSplitAndMergePlanner(ranges []int64) *SplitAndMergePlanner {
 	}
 }
 
+// withBlockRanges implements blockRangesPlanner.
+func (c *SplitAndMergePlanner) withBlockRanges(ranges []int64) Planner {
+	return NewSplitAndMergePlanner(ranges)
+}
+
 // Plan implements compact.Planner.
 func (c *SplitAndMergePlanner) Plan(_ context.Context, metasByMinTime []*metadata.Meta) ([]*metadata.Meta, error) {
 	// The split-and-merge grouper creates single groups of blocks that are expected to be
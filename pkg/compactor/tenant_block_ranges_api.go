@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"net/http"
+
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+type TenantBlockRangesResponse struct {
+	TenantID    string   `json:"tenant_id"`
+	BlockRanges []string `json:"block_ranges"`
+}
+
+// TenantBlockRanges returns the compaction time ranges applied to the tenant, taking in account the per-tenant overrides.
+func (c *MultitenantCompactor) TenantBlockRanges(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ranges := c.blockRangesForUser(userID)
+
+	result := TenantBlockRangesResponse{
+		TenantID:    userID,
+		BlockRanges: make([]string, 0, len(ranges)),
+	}
+	for _, r := range ranges {
+		result.BlockRanges = append(result.BlockRanges, r.String())
+	}
+
+	util.WriteJSONResponse(w, result)
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+func TestTenantBlockRanges(t *testing.T) {
+	cfg := prepareConfig(t)
+	cfg.BlockRanges = tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour}
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.blockRanges["user-2"] = tsdb.DurationList{2 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+
+	c, _, _, _, _ := prepareWithConfigProvider(t, cfg, objstore.NewInMemBucket(), cfgProvider)
+
+	t.Run("missing tenant", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		c.TenantBlockRanges(resp, &http.Request{})
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	for userID, expected := range map[string]string{
+		"user-1": `{"tenant_id":"user-1","block_ranges":["2h0m0s","12h0m0s","24h0m0s"]}`,
+		"user-2": `{"tenant_id":"user-2","block_ranges":["2h0m0s","24h0m0s","168h0m0s"]}`,
+	} {
+		t.Run(userID, func(t *testing.T) {
+			req := (&http.Request{}).WithContext(user.InjectOrgID(context.Background(), userID))
+			resp := httptest.NewRecorder()
+			c.TenantBlockRanges(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.JSONEq(t, expected, resp.Body.String())
+		})
+	}
+}
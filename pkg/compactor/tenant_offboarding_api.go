@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/multierror"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// TenantConfigStore is a store of the configuration of the tenants, like their rule groups or their Alertmanager
+// configuration, which is deleted when a tenant is offboarded.
+type TenantConfigStore interface {
+	// Name returns the name of the configuration, reported by the tenant offboarding status.
+	Name() string
+
+	// DeleteTenantConfig deletes the configuration of the tenant. No error is returned if the tenant has none.
+	DeleteTenantConfig(ctx context.Context, userID string) error
+
+	// TenantConfigDeleted returns whether the tenant has no configuration left in the store.
+	TenantConfigDeleted(ctx context.Context, userID string) (bool, error)
+}
+
+type OffboardTenantStatusResponse struct {
+	TenantID string `json:"tenant_id"`
+
+	// DeletionTime and BlocksDeletionFinishedTime are taken from the tenant deletion mark, once written.
+	DeletionTime               *time.Time `json:"deletion_time,omitempty"`
+	BlocksDeletionFinishedTime *time.Time `json:"blocks_deletion_finished_time,omitempty"`
+
+	RemainingBlocks int             `json:"remaining_blocks"`
+	ConfigsDeleted  map[string]bool `json:"configs_deleted"`
+
+	// Completed is true once the blocks and the configurations of the tenant have all been deleted.
+	Completed bool `json:"completed"`
+}
+
+// OffboardTenant deletes the blocks and the configurations of the tenant: it writes the tenant deletion mark, if not
+// written yet, so that the blocks of the tenant get deleted by the compactor, and deletes the configurations of
+// the tenant from the TenantConfigStores. It responds with the progress of the offboarding, like OffboardTenantStatus.
+// It can be safely retried.
+func (c *MultitenantCompactor) OffboardTenant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		// When Mimir is running, it uses Auth Middleware for checking X-Scope-OrgID and injecting tenant into context.
+		// Auth Middleware sends http.StatusUnauthorized if X-Scope-OrgID is missing, so we do too here, for consistency.
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	logger := util_log.WithUserID(userID, c.logger)
+
+	// The deletion mark isn't overwritten, not to postpone the cleanup of the tenant.
+	exists, err := mimir_tsdb.TenantDeletionMarkExists(ctx, c.bucketClient, userID)
+	if err == nil && !exists {
+		err = mimir_tsdb.WriteTenantDeletionMark(ctx, c.bucketClient, userID, c.cfgProvider, mimir_tsdb.NewTenantDeletionMark(time.Now()))
+		if err == nil {
+			level.Info(logger).Log("msg", "tenant deletion mark in blocks storage created")
+		}
+	}
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to write tenant deletion mark", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	errs := multierror.New()
+	for _, store := range c.compactorCfg.TenantConfigStores {
+		if err := store.DeleteTenantConfig(ctx, userID); err != nil {
+			level.Error(logger).Log("msg", "failed to delete tenant configuration", "config", store.Name(), "err", err)
+			errs.Add(errors.Wrapf(err, "delete %s", store.Name()))
+			continue
+		}
+		level.Info(logger).Log("msg", "tenant configuration deleted", "config", store.Name())
+	}
+	if err := errs.Err(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	c.writeOffboardTenantStatus(ctx, w, userID)
+}
+
+// OffboardTenantStatus responds with the progress of the offboarding of the tenant.
+func (c *MultitenantCompactor) OffboardTenantStatus(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.writeOffboardTenantStatus(r.Context(), w, userID)
+}
+
+func (c *MultitenantCompactor) writeOffboardTenantStatus(ctx context.Context, w http.ResponseWriter, userID string) {
+	status, err := c.offboardTenantStatus(ctx, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, status)
+}
+
+func (c *MultitenantCompactor) offboardTenantStatus(ctx context.Context, userID string) (OffboardTenantStatusResponse, error) {
+	status := OffboardTenantStatusResponse{
+		TenantID:       userID,
+		ConfigsDeleted: map[string]bool{},
+	}
+
+	mark, err := mimir_tsdb.ReadTenantDeletionMark(ctx, c.bucketClient, userID)
+	if err != nil {
+		return status, err
+	}
+	if mark != nil {
+		deletionTime := time.Unix(mark.DeletionTime, 0).UTC()
+		status.DeletionTime = &deletionTime
+		if mark.FinishedTime > 0 {
+			finishedTime := time.Unix(mark.FinishedTime, 0).UTC()
+			status.BlocksDeletionFinishedTime = &finishedTime
+		}
+	}
+
+	if status.RemainingBlocks, err = c.countBlocksForUser(ctx, userID); err != nil {
+		return status, errors.Wrap(err, "count blocks")
+	}
+
+	status.Completed = mark != nil && status.RemainingBlocks == 0
+	for _, store := range c.compactorCfg.TenantConfigStores {
+		deleted, err := store.TenantConfigDeleted(ctx, userID)
+		if err != nil {
+			return status, errors.Wrapf(err, "check %s", store.Name())
+		}
+		status.ConfigsDeleted[store.Name()] = deleted
+		status.Completed = status.Completed && deleted
+	}
+
+	return status, nil
+}
+
+func (c *MultitenantCompactor) countBlocksForUser(ctx context.Context, userID string) (int, error) {
+	count := 0
+	userBucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.cfgProvider)
+	err := userBucket.Iter(ctx, "", func(name string) error {
+		if _, ok := block.IsBlockDir(name); ok {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
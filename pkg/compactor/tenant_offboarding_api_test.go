@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+type mockTenantConfigStore struct {
+	name      string
+	configs   map[string]bool
+	deleteErr error
+}
+
+func (s *mockTenantConfigStore) Name() string {
+	return s.name
+}
+
+func (s *mockTenantConfigStore) DeleteTenantConfig(_ context.Context, userID string) error {
+	if s.deleteErr != nil {
+		return s.deleteErr
+	}
+	delete(s.configs, userID)
+	return nil
+}
+
+func (s *mockTenantConfigStore) TenantConfigDeleted(_ context.Context, userID string) (bool, error) {
+	return !s.configs[userID], nil
+}
+
+func TestOffboardTenant(t *testing.T) {
+	const blockMeta = "user-1/01EQK4QKFHVSZYVJ908Y7HH9E0/meta.json"
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	bkt := objstore.NewInMemBucket()
+	require.NoError(t, bkt.Upload(context.Background(), blockMeta, strings.NewReader("data")))
+
+	rules := &mockTenantConfigStore{name: "rule_groups", configs: map[string]bool{"user-1": true, "user-2": true}}
+	alertmanager := &mockTenantConfigStore{name: "alertmanager", configs: map[string]bool{"user-1": true}}
+
+	cfg := prepareConfig(t)
+	cfg.TenantConfigStores = []TenantConfigStore{rules, alertmanager}
+	c, _, _, _, _ := prepare(t, cfg, bkt)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	t.Cleanup(stopServiceFn(t, c))
+
+	status := func(handler http.HandlerFunc) OffboardTenantStatusResponse {
+		resp := httptest.NewRecorder()
+		handler(resp, (&http.Request{}).WithContext(ctx))
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		var status OffboardTenantStatusResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		return status
+	}
+
+	t.Run("missing tenant", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		c.OffboardTenant(resp, &http.Request{})
+		require.Equal(t, http.StatusUnauthorized, resp.Code)
+
+		resp = httptest.NewRecorder()
+		c.OffboardTenantStatus(resp, &http.Request{})
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	// Nothing has been deleted before the tenant is offboarded.
+	res := status(c.OffboardTenantStatus)
+	assert.Nil(t, res.DeletionTime)
+	assert.Equal(t, 1, res.RemainingBlocks)
+	assert.Equal(t, map[string]bool{"rule_groups": false, "alertmanager": false}, res.ConfigsDeleted)
+	assert.False(t, res.Completed)
+
+	// The configurations are deleted, while the blocks are left to the compactor.
+	res = status(c.OffboardTenant)
+	require.NotNil(t, res.DeletionTime)
+	assert.Equal(t, 1, res.RemainingBlocks)
+	assert.Equal(t, map[string]bool{"rule_groups": true, "alertmanager": true}, res.ConfigsDeleted)
+	assert.False(t, res.Completed)
+	assert.True(t, rules.configs["user-2"], "the configurations of the other tenants are kept")
+
+	mark, err := tsdb.ReadTenantDeletionMark(context.Background(), bkt, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, mark)
+
+	// Retrying doesn't overwrite the deletion mark.
+	previous := *mark
+	previous.DeletionTime = time.Now().Add(-time.Hour).Unix()
+	require.NoError(t, tsdb.WriteTenantDeletionMark(context.Background(), bkt, "user-1", nil, &previous))
+
+	res = status(c.OffboardTenant)
+	require.NotNil(t, res.DeletionTime)
+	assert.Equal(t, previous.DeletionTime, res.DeletionTime.Unix())
+
+	// The offboarding is completed once the blocks are deleted.
+	require.NoError(t, bkt.Delete(context.Background(), blockMeta))
+
+	res = status(c.OffboardTenantStatus)
+	assert.Equal(t, 0, res.RemainingBlocks)
+	assert.True(t, res.Completed)
+
+	t.Run("failed configuration deletion", func(t *testing.T) {
+		alertmanager.deleteErr = errors.New("failed")
+		t.Cleanup(func() { alertmanager.deleteErr = nil })
+
+		resp := httptest.NewRecorder()
+		c.OffboardTenant(resp, (&http.Request{}).WithContext(ctx))
+		require.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Contains(t, resp.Body.String(), "delete alertmanager: failed")
+	})
+}
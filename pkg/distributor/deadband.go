@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/value"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/extract"
+)
+
+// deadbandCounterSuffixes are the suffixes of the metric names which are considered counters
+// when the strict counters option of the ingestion deadband is enabled.
+var deadbandCounterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+// deadbandFilter drops the samples of the ingestion deadband metrics whose value is unchanged since the
+// last sample of the series, as long as the last ingested sample is more recent than the deadband window.
+// It tracks the last sample of each series, so its state is local to the distributor.
+type deadbandFilter struct {
+	mtx sync.Mutex
+	// Series state by tenant and series labels hash.
+	series map[string]map[uint64]*deadbandSeries
+}
+
+type deadbandSeries struct {
+	// Timestamp of the last ingested sample.
+	lastIngestedTs int64
+	// Timestamp and value of the last received sample.
+	lastTs    int64
+	lastValue uint64
+}
+
+func newDeadbandFilter() *deadbandFilter {
+	return &deadbandFilter{
+		series: map[string]map[uint64]*deadbandSeries{},
+	}
+}
+
+// deadbandRequest is the configuration of the ingestion deadband for the request of a tenant.
+type deadbandRequest struct {
+	metrics        []string
+	windowMs       int64
+	strictCounters bool
+}
+
+// filter drops the unchanged samples of the input request, and returns the number of dropped
+// samples by metric name. The series left without samples, histograms and exemplars are not removed
+// from the request.
+func (f *deadbandFilter) filter(userID string, req *mimirpb.WriteRequest, cfg deadbandRequest) map[string]int {
+	var counters map[string]struct{}
+	if cfg.strictCounters {
+		counters = counterMetricFamilies(req.Metadata)
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	var dropped map[string]int
+	for _, ts := range req.Timeseries {
+		// Native histograms are always ingested.
+		if len(ts.Samples) == 0 || len(ts.Histograms) > 0 {
+			continue
+		}
+
+		metric, err := extract.UnsafeMetricNameFromLabelAdapters(ts.Labels)
+		if err != nil || !isDeadbandMetric(metric, cfg, counters) {
+			continue
+		}
+
+		userSeries := f.series[userID]
+		if userSeries == nil {
+			userSeries = map[uint64]*deadbandSeries{}
+			f.series[userID] = userSeries
+		}
+
+		hash := mimirpb.FromLabelAdaptersToLabels(ts.Labels).Hash()
+		numDropped := filterDeadbandSamples(ts.TimeSeries, userSeries, hash, cfg.windowMs)
+		if numDropped > 0 {
+			if dropped == nil {
+				dropped = map[string]int{}
+			}
+			// The metric name is referencing the request buffer, which is reused once the request is done.
+			dropped[strings.Clone(metric)] += numDropped
+		}
+	}
+
+	return dropped
+}
+
+// filterDeadbandSamples drops the samples of the input series which have the same value of the previous
+// sample, and returns the number of dropped samples.
+func filterDeadbandSamples(ts *mimirpb.TimeSeries, userSeries map[uint64]*deadbandSeries, hash uint64, windowMs int64) int {
+	state := userSeries[hash]
+	numDropped := 0
+
+	samples := ts.Samples[:0]
+	for _, s := range ts.Samples {
+		// Staleness markers are always ingested, and the sample following them too.
+		if value.IsStaleNaN(s.Value) {
+			delete(userSeries, hash)
+			state = nil
+			samples = append(samples, s)
+			continue
+		}
+
+		bits := math.Float64bits(s.Value)
+		switch {
+		case state == nil:
+			state = &deadbandSeries{lastIngestedTs: s.TimestampMs, lastTs: s.TimestampMs, lastValue: bits}
+			userSeries[hash] = state
+		case s.TimestampMs <= state.lastTs:
+			// Out-of-order or duplicated samples are ingested as they are, and left to the ingesters.
+		case bits == state.lastValue && s.TimestampMs-state.lastIngestedTs < windowMs:
+			state.lastTs = s.TimestampMs
+			numDropped++
+			continue
+		default:
+			state.lastIngestedTs = s.TimestampMs
+			state.lastTs = s.TimestampMs
+			state.lastValue = bits
+		}
+		samples = append(samples, s)
+	}
+	ts.Samples = samples
+
+	return numDropped
+}
+
+// purge removes the state of the series whose last ingested sample is older than the deadband window
+// of their tenant, since their next sample is going to be ingested anyway.
+func (f *deadbandFilter) purge(now time.Time, window func(userID string) time.Duration) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	for userID, userSeries := range f.series {
+		beforeMs := now.Add(-window(userID)).UnixMilli()
+		for hash, state := range userSeries {
+			if state.lastIngestedTs < beforeMs {
+				delete(userSeries, hash)
+			}
+		}
+		if len(userSeries) == 0 {
+			delete(f.series, userID)
+		}
+	}
+}
+
+func (f *deadbandFilter) deleteUser(userID string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	delete(f.series, userID)
+}
+
+func isDeadbandMetric(metric string, cfg deadbandRequest, counters map[string]struct{}) bool {
+	if !slices.Contains(cfg.metrics, metric) {
+		return false
+	}
+
+	if !cfg.strictCounters {
+		return true
+	}
+	for _, suffix := range deadbandCounterSuffixes {
+		if strings.HasSuffix(metric, suffix) {
+			return false
+		}
+	}
+	_, isCounter := counters[metric]
+	return !isCounter
+}
+
+// counterMetricFamilies returns the names of the counter, histogram and summary metric families
+// in the input metadata.
+func counterMetricFamilies(metadata []*mimirpb.MetricMetadata) map[string]struct{} {
+	var families map[string]struct{}
+	for _, m := range metadata {
+		if m == nil {
+			continue
+		}
+		switch m.Type {
+		case mimirpb.COUNTER, mimirpb.HISTOGRAM, mimirpb.GAUGEHISTOGRAM, mimirpb.SUMMARY:
+			if families == nil {
+				families = map[string]struct{}{}
+			}
+			families[m.MetricFamilyName] = struct{}{}
+		}
+	}
+	return families
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestDeadbandFilter_Filter(t *testing.T) {
+	staleNaN := math.Float64frombits(value.StaleNaN)
+	cfg := deadbandRequest{metrics: []string{"gauge", "summary"}, windowMs: 60000, strictCounters: true}
+
+	tests := map[string]struct {
+		metric           string
+		samples          []mimirpb.Sample
+		metadata         []*mimirpb.MetricMetadata
+		expectedSamples  []mimirpb.Sample
+		expectedDropped  map[string]int
+		disableStrictCfg bool
+	}{
+		"should drop the unchanged samples within the window": {
+			metric:          "gauge",
+			samples:         []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 30000, Value: 1}, {TimestampMs: 60000, Value: 1}, {TimestampMs: 90000, Value: 1}},
+			expectedSamples: []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 60000, Value: 1}},
+			expectedDropped: map[string]int{"gauge": 2},
+		},
+		"should keep the samples whose value changed": {
+			metric:          "gauge",
+			samples:         []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: 2}, {TimestampMs: 30000, Value: 2}, {TimestampMs: 45000, Value: 1}},
+			expectedSamples: []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: 2}, {TimestampMs: 45000, Value: 1}},
+			expectedDropped: map[string]int{"gauge": 1},
+		},
+		"should keep the staleness markers and the sample following them": {
+			metric:          "gauge",
+			samples:         []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: staleNaN}, {TimestampMs: 30000, Value: 1}, {TimestampMs: 45000, Value: 1}},
+			expectedSamples: []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: staleNaN}, {TimestampMs: 30000, Value: 1}},
+			expectedDropped: map[string]int{"gauge": 1},
+		},
+		"should keep the out-of-order samples": {
+			metric:          "gauge",
+			samples:         []mimirpb.Sample{{TimestampMs: 30000, Value: 1}, {TimestampMs: 15000, Value: 1}},
+			expectedSamples: []mimirpb.Sample{{TimestampMs: 30000, Value: 1}, {TimestampMs: 15000, Value: 1}},
+		},
+		"should not drop the samples of the metrics not configured": {
+			metric:          "other",
+			samples:         []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: 1}},
+			expectedSamples: []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: 1}},
+		},
+		"should not drop the samples of the metrics with the counter type in the request metadata": {
+			metric:          "summary",
+			samples:         []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: 1}},
+			metadata:        []*mimirpb.MetricMetadata{{Type: mimirpb.SUMMARY, MetricFamilyName: "summary"}},
+			expectedSamples: []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: 1}},
+		},
+		"should drop the samples of the metrics with the counter type if strict counters are disabled": {
+			metric:           "summary",
+			samples:          []mimirpb.Sample{{TimestampMs: 0, Value: 1}, {TimestampMs: 15000, Value: 1}},
+			metadata:         []*mimirpb.MetricMetadata{{Type: mimirpb.SUMMARY, MetricFamilyName: "summary"}},
+			expectedSamples:  []mimirpb.Sample{{TimestampMs: 0, Value: 1}},
+			expectedDropped:  map[string]int{"summary": 1},
+			disableStrictCfg: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			reqCfg := cfg
+			reqCfg.strictCounters = !testData.disableStrictCfg
+
+			// Push each sample in a different request, like it happens with scraped series.
+			f := newDeadbandFilter()
+			dropped := map[string]int{}
+			var actualSamples []mimirpb.Sample
+			for _, s := range testData.samples {
+				req := mimirpb.ToWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, testData.metric)}, []mimirpb.Sample{s}, nil, testData.metadata, mimirpb.API)
+				for metric, count := range f.filter("user", req, reqCfg) {
+					dropped[metric] += count
+				}
+				actualSamples = append(actualSamples, req.Timeseries[0].Samples...)
+			}
+
+			require.Len(t, actualSamples, len(testData.expectedSamples))
+			for i, s := range testData.expectedSamples {
+				assert.Equal(t, s.TimestampMs, actualSamples[i].TimestampMs)
+				assert.Equal(t, math.Float64bits(s.Value), math.Float64bits(actualSamples[i].Value))
+			}
+			if testData.expectedDropped == nil {
+				assert.Empty(t, dropped)
+			} else {
+				assert.Equal(t, testData.expectedDropped, dropped)
+			}
+		})
+	}
+}
+
+func TestDeadbandFilter_Purge(t *testing.T) {
+	now := time.Now()
+	f := newDeadbandFilter()
+	cfg := deadbandRequest{metrics: []string{"gauge"}, windowMs: time.Minute.Milliseconds()}
+
+	push := func(userID string, ts time.Time) {
+		req := mimirpb.ToWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "gauge")}, []mimirpb.Sample{{TimestampMs: ts.UnixMilli(), Value: 1}}, nil, nil, mimirpb.API)
+		f.filter(userID, req, cfg)
+	}
+	push("user-1", now.Add(-2*time.Minute))
+	push("user-2", now.Add(-30*time.Second))
+	require.Len(t, f.series, 2)
+
+	f.purge(now, func(string) time.Duration { return time.Minute })
+	require.Len(t, f.series, 1)
+	assert.Contains(t, f.series, "user-2")
+
+	f.deleteUser("user-2")
+	assert.Empty(t, f.series)
+}
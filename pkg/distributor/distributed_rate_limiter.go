@@ -0,0 +1,478 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/kv/codec"
+	"github.com/grafana/dskit/kv/memberlist"
+	"github.com/grafana/dskit/limiter"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+const (
+	// usageKey is the key under which the distributors exchange their usage in the KV store.
+	usageKey = "distributors-usage"
+
+	// usageEWMAAlpha is the weight of the latest update period in the moving average of the local usage.
+	usageEWMAAlpha = 0.2
+
+	// minTrackedRate is the rate below which a tenant is no longer tracked, because it's not sending requests anymore.
+	minTrackedRate = 0.001
+)
+
+var (
+	errInvalidDistributedRateLimiterUpdatePeriod = errors.New("the distributed rate limiter update period must be greater than 0")
+	errInvalidDistributedRateLimiterStaleTimeout = errors.New("the distributed rate limiter stale timeout must be greater than the update period")
+	errDistributedRateLimiterRequiresMemberlist  = errors.New("the distributed rate limiter requires memberlist as the KV store of the distributors ring")
+)
+
+// DistributedRateLimiterConfig configures the rate limiter which shares the usage of each tenant between the distributors.
+type DistributedRateLimiterConfig struct {
+	Enabled      bool          `yaml:"enabled" category:"experimental"`
+	UpdatePeriod time.Duration `yaml:"update_period" category:"experimental"`
+	StaleTimeout time.Duration `yaml:"stale_timeout" category:"experimental"`
+}
+
+func (cfg *DistributedRateLimiterConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.distributed-rate-limiter.enabled", false, "True to enforce the global ingestion and request rate limits by sharing the recent usage of each tenant between the distributors through memberlist, and giving each distributor a share of the tenant's limit proportional to the tenant's traffic it receives. When disabled, each distributor enforces the tenant's limit divided by the number of healthy distributors, which is accurate only when the traffic is evenly balanced. Requires memberlist as the KV store of the distributors ring.")
+	f.DurationVar(&cfg.UpdatePeriod, "distributor.distributed-rate-limiter.update-period", time.Second, "How frequently each distributor publishes its usage and updates its view of the usage of the other distributors.")
+	f.DurationVar(&cfg.StaleTimeout, "distributor.distributed-rate-limiter.stale-timeout", 10*time.Second, "The usage of a distributor not updated within this timeout is ignored. If the distributor can't exchange its own usage within this timeout, it falls back to dividing the limits by the number of healthy distributors.")
+}
+
+func (cfg *DistributedRateLimiterConfig) Validate(ringCfg RingConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.UpdatePeriod <= 0 {
+		return errInvalidDistributedRateLimiterUpdatePeriod
+	}
+	if cfg.StaleTimeout <= cfg.UpdatePeriod {
+		return errInvalidDistributedRateLimiterStaleTimeout
+	}
+	if ringCfg.Common.KVStore.Store != "memberlist" {
+		return errDistributedRateLimiterRequiresMemberlist
+	}
+	return nil
+}
+
+// TenantUsage is the recent usage of a tenant received by a distributor.
+type TenantUsage struct {
+	IngestionRate float64 `json:"ingestion_rate"`
+	RequestRate   float64 `json:"request_rate"`
+}
+
+// DistributorUsage is the recent usage of the tenants received by a distributor, as of Timestamp.
+type DistributorUsage struct {
+	// Timestamp is the time, in milliseconds, when the usage has been published.
+	Timestamp int64                  `json:"timestamp"`
+	Tenants   map[string]TenantUsage `json:"tenants,omitempty"`
+}
+
+// UsageDesc is the usage of the tenants received by each distributor, keyed by distributor ID.
+// It's exchanged between the distributors through memberlist.
+type UsageDesc struct {
+	Distributors map[string]DistributorUsage `json:"distributors"`
+}
+
+// Merge implements memberlist.Mergeable. The most recent usage of each distributor wins.
+func (d *UsageDesc) Merge(mergeable memberlist.Mergeable, _ bool) (memberlist.Mergeable, error) {
+	if mergeable == nil {
+		return nil, nil
+	}
+	other, ok := mergeable.(*UsageDesc)
+	if !ok {
+		return nil, fmt.Errorf("expected *distributor.UsageDesc, got %T", mergeable)
+	}
+	if other == nil {
+		return nil, nil
+	}
+
+	if d.Distributors == nil {
+		d.Distributors = map[string]DistributorUsage{}
+	}
+
+	change := map[string]DistributorUsage{}
+	for id, usage := range other.Distributors {
+		if current, ok := d.Distributors[id]; ok && current.Timestamp >= usage.Timestamp {
+			continue
+		}
+		d.Distributors[id] = usage
+		change[id] = usage
+	}
+
+	if len(change) == 0 {
+		return nil, nil
+	}
+	return &UsageDesc{Distributors: change}, nil
+}
+
+// MergeContent implements memberlist.Mergeable.
+func (d *UsageDesc) MergeContent() []string {
+	ids := make([]string, 0, len(d.Distributors))
+	for id := range d.Distributors {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// RemoveTombstones implements memberlist.Mergeable. The usage of the distributors not updated since
+// the limit, for example because they have left, is removed.
+func (d *UsageDesc) RemoveTombstones(limit time.Time) (total, removed int) {
+	if limit.IsZero() {
+		return 0, 0
+	}
+	for id, usage := range d.Distributors {
+		if time.UnixMilli(usage.Timestamp).Before(limit) {
+			delete(d.Distributors, id)
+			removed++
+		}
+	}
+	return 0, removed
+}
+
+// Clone implements memberlist.Mergeable.
+func (d *UsageDesc) Clone() memberlist.Mergeable {
+	clone := &UsageDesc{Distributors: make(map[string]DistributorUsage, len(d.Distributors))}
+	for id, usage := range d.Distributors {
+		tenants := make(map[string]TenantUsage, len(usage.Tenants))
+		for tenantID, tenantUsage := range usage.Tenants {
+			tenants[tenantID] = tenantUsage
+		}
+		clone.Distributors[id] = DistributorUsage{Timestamp: usage.Timestamp, Tenants: tenants}
+	}
+	return clone
+}
+
+// usageCodec is the codec of the UsageDesc, encoded as JSON and compressed with snappy.
+type usageCodec struct{}
+
+// GetUsageCodec returns the codec used to exchange the usage of the distributors.
+func GetUsageCodec() codec.Codec {
+	return usageCodec{}
+}
+
+func (usageCodec) CodecID() string {
+	return "distributorUsageDesc"
+}
+
+func (usageCodec) Decode(b []byte) (interface{}, error) {
+	b, err := snappy.Decode(nil, b)
+	if err != nil {
+		return nil, err
+	}
+	desc := &UsageDesc{}
+	if err := json.Unmarshal(b, desc); err != nil {
+		return nil, err
+	}
+	return desc, nil
+}
+
+func (usageCodec) Encode(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v.(*UsageDesc))
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, b), nil
+}
+
+// tenantUsageRates tracks the usage of a tenant received by the local distributor.
+type tenantUsageRates struct {
+	ingestion *util_math.EwmaRate
+	requests  *util_math.EwmaRate
+}
+
+// usageExchange periodically publishes the usage of the tenants received by the local distributor,
+// and keeps the usage received by the other distributors.
+type usageExchange struct {
+	services.Service
+
+	cfg        DistributedRateLimiterConfig
+	kv         kv.Client
+	instanceID string
+	logger     log.Logger
+
+	tenantsMtx sync.RWMutex
+	tenants    map[string]*tenantUsageRates
+
+	stateMtx         sync.RWMutex
+	lastUpdate       time.Time
+	local            map[string]TenantUsage
+	remote           map[string]TenantUsage
+	remoteTimestamps map[string]int64
+
+	localMode           prometheus.Gauge
+	peers               prometheus.Gauge
+	updatesFailed       prometheus.Counter
+	propagationDuration prometheus.Histogram
+}
+
+func newUsageExchange(cfg DistributedRateLimiterConfig, ringCfg RingConfig, logger log.Logger, reg prometheus.Registerer) (*usageExchange, error) {
+	kvClient, err := kv.NewClient(ringCfg.Common.KVStore, GetUsageCodec(), kv.RegistererWithKVName(reg, "distributor-usage"), logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize the distributors usage KV store")
+	}
+	return newUsageExchangeWithKV(cfg, kvClient, ringCfg.Common.InstanceID, logger, reg), nil
+}
+
+func newUsageExchangeWithKV(cfg DistributedRateLimiterConfig, kvClient kv.Client, instanceID string, logger log.Logger, reg prometheus.Registerer) *usageExchange {
+	u := &usageExchange{
+		cfg:              cfg,
+		kv:               kvClient,
+		instanceID:       instanceID,
+		logger:           logger,
+		tenants:          map[string]*tenantUsageRates{},
+		remoteTimestamps: map[string]int64{},
+		localMode: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_distributor_distributed_rate_limiter_local_mode",
+			Help: "Whether the distributed rate limiter has fallen back to dividing the limits by the number of healthy distributors, because the usage couldn't be exchanged with the other distributors.",
+		}),
+		peers: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_distributor_distributed_rate_limiter_peers",
+			Help: "Number of other distributors whose recent usage is known by the distributed rate limiter.",
+		}),
+		updatesFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_distributed_rate_limiter_updates_failed_total",
+			Help: "Total number of failed exchanges of the usage with the other distributors.",
+		}),
+		propagationDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_distributor_distributed_rate_limiter_convergence_seconds",
+			Help:    "Time taken by the usage published by the other distributors to be received by this distributor.",
+			Buckets: []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}),
+	}
+
+	// Until the usage has been exchanged, the limits are enforced in local mode.
+	u.localMode.Set(1)
+	u.Service = services.NewTimerService(cfg.UpdatePeriod, nil, u.iteration, u.stopping)
+	return u
+}
+
+// recordIngestion records the number of samples, exemplars and metadata received for the tenant.
+func (u *usageExchange) recordIngestion(tenantID string, n int) {
+	u.getOrCreateTenant(tenantID).ingestion.Add(int64(n))
+}
+
+// recordRequest records a request received for the tenant.
+func (u *usageExchange) recordRequest(tenantID string) {
+	u.getOrCreateTenant(tenantID).requests.Inc()
+}
+
+func (u *usageExchange) getOrCreateTenant(tenantID string) *tenantUsageRates {
+	u.tenantsMtx.RLock()
+	rates, ok := u.tenants[tenantID]
+	u.tenantsMtx.RUnlock()
+	if ok {
+		return rates
+	}
+
+	u.tenantsMtx.Lock()
+	defer u.tenantsMtx.Unlock()
+
+	if rates, ok = u.tenants[tenantID]; !ok {
+		rates = &tenantUsageRates{
+			ingestion: util_math.NewEWMARate(usageEWMAAlpha, u.cfg.UpdatePeriod),
+			requests:  util_math.NewEWMARate(usageEWMAAlpha, u.cfg.UpdatePeriod),
+		}
+		u.tenants[tenantID] = rates
+	}
+	return rates
+}
+
+// tickLocalUsage updates the moving average of the local usage of each tenant, and stops tracking the idle tenants.
+func (u *usageExchange) tickLocalUsage() map[string]TenantUsage {
+	u.tenantsMtx.Lock()
+	defer u.tenantsMtx.Unlock()
+
+	usage := make(map[string]TenantUsage, len(u.tenants))
+	for tenantID, rates := range u.tenants {
+		rates.ingestion.Tick()
+		rates.requests.Tick()
+
+		tenantUsage := TenantUsage{IngestionRate: rates.ingestion.Rate(), RequestRate: rates.requests.Rate()}
+		if tenantUsage.IngestionRate < minTrackedRate && tenantUsage.RequestRate < minTrackedRate {
+			delete(u.tenants, tenantID)
+			continue
+		}
+		usage[tenantID] = tenantUsage
+	}
+	return usage
+}
+
+func (u *usageExchange) iteration(ctx context.Context) error {
+	local := u.tickLocalUsage()
+	if err := u.publish(ctx, local); err != nil {
+		u.updatesFailed.Inc()
+		level.Warn(u.logger).Log("msg", "failed to exchange the usage with the other distributors", "err", err)
+	}
+
+	u.stateMtx.Lock()
+	localMode := u.isLocalModeLocked(time.Now())
+	u.stateMtx.Unlock()
+
+	if localMode {
+		u.localMode.Set(1)
+	} else {
+		u.localMode.Set(0)
+	}
+
+	// The exchange failures are never fatal: the limits are enforced in local mode instead.
+	return nil
+}
+
+// publish publishes the local usage, and updates the usage of the other distributors.
+func (u *usageExchange) publish(ctx context.Context, local map[string]TenantUsage) error {
+	return u.kv.CAS(ctx, usageKey, func(in interface{}) (out interface{}, retry bool, err error) {
+		now := time.Now()
+		desc, _ := in.(*UsageDesc)
+		if desc == nil {
+			desc = &UsageDesc{}
+		}
+		if desc.Distributors == nil {
+			desc.Distributors = map[string]DistributorUsage{}
+		}
+
+		u.updateState(desc, local, now)
+
+		desc.Distributors[u.instanceID] = DistributorUsage{Timestamp: now.UnixMilli(), Tenants: local}
+		return desc, true, nil
+	})
+}
+
+// updateState updates the usage of the other distributors, ignoring the stale ones.
+func (u *usageExchange) updateState(desc *UsageDesc, local map[string]TenantUsage, now time.Time) {
+	remote := map[string]TenantUsage{}
+	peers := 0
+
+	u.stateMtx.Lock()
+	defer u.stateMtx.Unlock()
+
+	for id, usage := range desc.Distributors {
+		if id == u.instanceID {
+			continue
+		}
+
+		if usage.Timestamp > u.remoteTimestamps[id] {
+			u.remoteTimestamps[id] = usage.Timestamp
+			u.propagationDuration.Observe(util_math.Max(0, now.Sub(time.UnixMilli(usage.Timestamp)).Seconds()))
+		}
+
+		if now.Sub(time.UnixMilli(usage.Timestamp)) > u.cfg.StaleTimeout {
+			continue
+		}
+
+		peers++
+		for tenantID, tenantUsage := range usage.Tenants {
+			sum := remote[tenantID]
+			sum.IngestionRate += tenantUsage.IngestionRate
+			sum.RequestRate += tenantUsage.RequestRate
+			remote[tenantID] = sum
+		}
+	}
+
+	// Forget the distributors which are gone.
+	for id := range u.remoteTimestamps {
+		if _, ok := desc.Distributors[id]; !ok {
+			delete(u.remoteTimestamps, id)
+		}
+	}
+
+	u.local = local
+	u.remote = remote
+	u.lastUpdate = now
+	u.peers.Set(float64(peers))
+}
+
+func (u *usageExchange) isLocalModeLocked(now time.Time) bool {
+	return u.lastUpdate.IsZero() || now.Sub(u.lastUpdate) > u.cfg.StaleTimeout
+}
+
+// localShare returns the share of the tenant's limit to enforce by the local distributor, which is proportional
+// to the tenant's usage received by the local distributor. The returned bool is false if the share is unknown,
+// because the usage couldn't be exchanged recently or the local distributor hasn't received the tenant's traffic.
+func (u *usageExchange) localShare(tenantID string, usageRate func(TenantUsage) float64) (float64, bool) {
+	u.stateMtx.RLock()
+	defer u.stateMtx.RUnlock()
+
+	if u.isLocalModeLocked(time.Now()) {
+		return 0, false
+	}
+
+	local := usageRate(u.local[tenantID])
+	if local <= 0 {
+		return 0, false
+	}
+	return local / (local + usageRate(u.remote[tenantID])), true
+}
+
+// stopping publishes no usage, so that the other distributors stop counting the local distributor's one.
+func (u *usageExchange) stopping(_ error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), u.cfg.UpdatePeriod)
+	defer cancel()
+
+	if err := u.publish(ctx, nil); err != nil {
+		level.Warn(u.logger).Log("msg", "failed to clear the usage of the distributor", "err", err)
+	}
+	return nil
+}
+
+func ingestionUsageRate(usage TenantUsage) float64 {
+	return usage.IngestionRate
+}
+
+func requestUsageRate(usage TenantUsage) float64 {
+	return usage.RequestRate
+}
+
+// distributedStrategy enforces a share of the tenant's limit proportional to the tenant's usage received by the
+// local distributor, compared to the one received by all the distributors. It falls back to the input strategy
+// when the share is unknown.
+type distributedStrategy struct {
+	baseStrategy     limiter.RateLimiterStrategy
+	fallbackStrategy limiter.RateLimiterStrategy
+	usage            *usageExchange
+	usageRate        func(TenantUsage) float64
+}
+
+func newDistributedRateStrategy(baseStrategy, fallbackStrategy limiter.RateLimiterStrategy, usage *usageExchange, usageRate func(TenantUsage) float64) limiter.RateLimiterStrategy {
+	return &distributedStrategy{
+		baseStrategy:     baseStrategy,
+		fallbackStrategy: fallbackStrategy,
+		usage:            usage,
+		usageRate:        usageRate,
+	}
+}
+
+func (s *distributedStrategy) Limit(tenantID string) float64 {
+	limit := s.baseStrategy.Limit(tenantID)
+	if limit == float64(rate.Inf) {
+		return limit
+	}
+
+	share, ok := s.usage.localShare(tenantID, s.usageRate)
+	if !ok {
+		return s.fallbackStrategy.Limit(tenantID)
+	}
+	return limit * share
+}
+
+func (s *distributedStrategy) Burst(tenantID string) int {
+	// Like for the global strategy, the meaning of burst doesn't change.
+	return s.baseStrategy.Burst(tenantID)
+}
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestDistributedRateLimiterConfig_Validate(t *testing.T) {
+	memberlistRing := RingConfig{}
+	memberlistRing.Common.KVStore.Store = "memberlist"
+	consulRing := RingConfig{}
+	consulRing.Common.KVStore.Store = "consul"
+
+	tests := map[string]struct {
+		cfg      DistributedRateLimiterConfig
+		ringCfg  RingConfig
+		expected error
+	}{
+		"disabled": {
+			cfg:     DistributedRateLimiterConfig{},
+			ringCfg: consulRing,
+		},
+		"enabled with memberlist": {
+			cfg:     DistributedRateLimiterConfig{Enabled: true, UpdatePeriod: time.Second, StaleTimeout: 10 * time.Second},
+			ringCfg: memberlistRing,
+		},
+		"enabled with consul": {
+			cfg:      DistributedRateLimiterConfig{Enabled: true, UpdatePeriod: time.Second, StaleTimeout: 10 * time.Second},
+			ringCfg:  consulRing,
+			expected: errDistributedRateLimiterRequiresMemberlist,
+		},
+		"invalid update period": {
+			cfg:      DistributedRateLimiterConfig{Enabled: true, StaleTimeout: 10 * time.Second},
+			ringCfg:  memberlistRing,
+			expected: errInvalidDistributedRateLimiterUpdatePeriod,
+		},
+		"stale timeout not greater than the update period": {
+			cfg:      DistributedRateLimiterConfig{Enabled: true, UpdatePeriod: time.Second, StaleTimeout: time.Second},
+			ringCfg:  memberlistRing,
+			expected: errInvalidDistributedRateLimiterStaleTimeout,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.cfg.Validate(tc.ringCfg))
+		})
+	}
+}
+
+func TestUsageDesc_Merge(t *testing.T) {
+	desc := &UsageDesc{Distributors: map[string]DistributorUsage{
+		"distributor-1": {Timestamp: 10, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 1}}},
+		"distributor-2": {Timestamp: 20, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 2}}},
+	}}
+
+	other := &UsageDesc{Distributors: map[string]DistributorUsage{
+		"distributor-1": {Timestamp: 15, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 10}}},
+		"distributor-2": {Timestamp: 5, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 20}}},
+		"distributor-3": {Timestamp: 30, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 30}}},
+	}}
+
+	change, err := desc.Merge(other.Clone(), false)
+	require.NoError(t, err)
+
+	// The most recent usage of each distributor wins.
+	assert.Equal(t, &UsageDesc{Distributors: map[string]DistributorUsage{
+		"distributor-1": {Timestamp: 15, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 10}}},
+		"distributor-3": {Timestamp: 30, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 30}}},
+	}}, change)
+	assert.Equal(t, &UsageDesc{Distributors: map[string]DistributorUsage{
+		"distributor-1": {Timestamp: 15, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 10}}},
+		"distributor-2": {Timestamp: 20, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 2}}},
+		"distributor-3": {Timestamp: 30, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 30}}},
+	}}, desc)
+
+	// Merging the same state again doesn't change anything.
+	change, err = desc.Merge(other.Clone(), false)
+	require.NoError(t, err)
+	assert.Nil(t, change)
+}
+
+func TestUsageDesc_RemoveTombstones(t *testing.T) {
+	now := time.Now()
+	desc := &UsageDesc{Distributors: map[string]DistributorUsage{
+		"distributor-1": {Timestamp: now.Add(-time.Hour).UnixMilli()},
+		"distributor-2": {Timestamp: now.UnixMilli()},
+	}}
+
+	// A zero limit doesn't remove anything.
+	_, removed := desc.RemoveTombstones(time.Time{})
+	assert.Equal(t, 0, removed)
+
+	_, removed = desc.RemoveTombstones(now.Add(-time.Minute))
+	assert.Equal(t, 1, removed)
+	assert.ElementsMatch(t, []string{"distributor-2"}, desc.MergeContent())
+}
+
+func TestUsageCodec(t *testing.T) {
+	desc := &UsageDesc{Distributors: map[string]DistributorUsage{
+		"distributor-1": {Timestamp: 10, Tenants: map[string]TenantUsage{"user-1": {IngestionRate: 1, RequestRate: 2}}},
+	}}
+
+	c := GetUsageCodec()
+	encoded, err := c.Encode(desc)
+	require.NoError(t, err)
+
+	decoded, err := c.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, desc, decoded)
+}
+
+func TestUsageExchange(t *testing.T) {
+	ctx := context.Background()
+	cfg := DistributedRateLimiterConfig{Enabled: true, UpdatePeriod: time.Second, StaleTimeout: 10 * time.Second}
+
+	kvStore, closer := consul.NewInMemoryClient(GetUsageCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	reg := prometheus.NewPedanticRegistry()
+	first := newUsageExchangeWithKV(cfg, kvStore, "distributor-1", log.NewNopLogger(), reg)
+	second := newUsageExchangeWithKV(cfg, kvStore, "distributor-2", log.NewNopLogger(), nil)
+
+	// Until the usage has been exchanged, the share is unknown.
+	first.recordIngestion("user-1", 300)
+	first.recordRequest("user-1")
+	_, ok := first.localShare("user-1", ingestionUsageRate)
+	assert.False(t, ok)
+
+	second.recordIngestion("user-1", 100)
+	second.recordIngestion("user-2", 100)
+	second.recordRequest("user-1")
+
+	require.NoError(t, first.iteration(ctx))
+	require.NoError(t, second.iteration(ctx))
+
+	// The second distributor knows the usage of the first one.
+	share, ok := second.localShare("user-1", ingestionUsageRate)
+	require.True(t, ok)
+	assert.InDelta(t, 0.25, share, 0.001)
+
+	share, ok = second.localShare("user-1", requestUsageRate)
+	require.True(t, ok)
+	assert.InDelta(t, 0.5, share, 0.001)
+
+	share, ok = second.localShare("user-2", ingestionUsageRate)
+	require.True(t, ok)
+	assert.Equal(t, 1.0, share)
+
+	// The share of a tenant not received by the local distributor is unknown.
+	_, ok = first.localShare("user-2", ingestionUsageRate)
+	assert.False(t, ok)
+
+	// The first distributor knows the usage of the second one once it has exchanged its usage again.
+	first.recordIngestion("user-1", 300)
+	require.NoError(t, first.iteration(ctx))
+
+	share, ok = first.localShare("user-1", ingestionUsageRate)
+	require.True(t, ok)
+	assert.InDelta(t, 0.75, share, 0.001)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_distributed_rate_limiter_local_mode Whether the distributed rate limiter has fallen back to dividing the limits by the number of healthy distributors, because the usage couldn't be exchanged with the other distributors.
+		# TYPE cortex_distributor_distributed_rate_limiter_local_mode gauge
+		cortex_distributor_distributed_rate_limiter_local_mode 0
+		# HELP cortex_distributor_distributed_rate_limiter_peers Number of other distributors whose recent usage is known by the distributed rate limiter.
+		# TYPE cortex_distributor_distributed_rate_limiter_peers gauge
+		cortex_distributor_distributed_rate_limiter_peers 1
+	`), "cortex_distributor_distributed_rate_limiter_local_mode", "cortex_distributor_distributed_rate_limiter_peers"))
+	assert.Equal(t, 1, testutil.CollectAndCount(first.propagationDuration))
+
+	// Once the second distributor has stopped, its usage is no longer counted.
+	require.NoError(t, second.stopping(nil))
+	first.recordIngestion("user-1", 300)
+	require.NoError(t, first.iteration(ctx))
+
+	share, ok = first.localShare("user-1", ingestionUsageRate)
+	require.True(t, ok)
+	assert.Equal(t, 1.0, share)
+}
+
+func TestUsageExchange_ShouldFallBackToLocalModeIfTheUsageCantBeExchanged(t *testing.T) {
+	ctx := context.Background()
+	cfg := DistributedRateLimiterConfig{Enabled: true, UpdatePeriod: time.Second, StaleTimeout: 10 * time.Second}
+
+	reg := prometheus.NewPedanticRegistry()
+	u := newUsageExchangeWithKV(cfg, failingKV{}, "distributor-1", log.NewNopLogger(), reg)
+
+	u.recordIngestion("user-1", 100)
+	require.NoError(t, u.iteration(ctx))
+
+	_, ok := u.localShare("user-1", ingestionUsageRate)
+	assert.False(t, ok)
+	assert.Equal(t, float64(1), testutil.ToFloat64(u.localMode))
+	assert.Equal(t, float64(1), testutil.ToFloat64(u.updatesFailed))
+
+	// The distributed strategy falls back to the input one.
+	strategy := newDistributedRateStrategy(mockRateStrategy{limit: 100, burst: 10}, mockRateStrategy{limit: 50, burst: 10}, u, ingestionUsageRate)
+	assert.Equal(t, 50.0, strategy.Limit("user-1"))
+	assert.Equal(t, 10, strategy.Burst("user-1"))
+}
+
+func TestDistributedRateStrategy(t *testing.T) {
+	ctx := context.Background()
+	cfg := DistributedRateLimiterConfig{Enabled: true, UpdatePeriod: time.Second, StaleTimeout: 10 * time.Second}
+
+	kvStore, closer := consul.NewInMemoryClient(GetUsageCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	first := newUsageExchangeWithKV(cfg, kvStore, "distributor-1", log.NewNopLogger(), nil)
+	second := newUsageExchangeWithKV(cfg, kvStore, "distributor-2", log.NewNopLogger(), nil)
+
+	first.recordIngestion("user-1", 100)
+	second.recordIngestion("user-1", 300)
+	require.NoError(t, first.iteration(ctx))
+	require.NoError(t, second.iteration(ctx))
+
+	fallback := mockRateStrategy{limit: 500, burst: 10}
+
+	// The limit is shared proportionally to the usage received by each distributor.
+	strategy := newDistributedRateStrategy(mockRateStrategy{limit: 1000, burst: 10}, fallback, second, ingestionUsageRate)
+	assert.InDelta(t, 750, strategy.Limit("user-1"), 0.1)
+	assert.Equal(t, 10, strategy.Burst("user-1"))
+
+	// The fallback strategy is used for the tenants not received by the distributor.
+	assert.Equal(t, 500.0, strategy.Limit("user-2"))
+
+	// An infinite limit is not shared.
+	strategy = newDistributedRateStrategy(mockRateStrategy{limit: float64(rate.Inf)}, fallback, second, ingestionUsageRate)
+	assert.Equal(t, float64(rate.Inf), strategy.Limit("user-1"))
+}
+
+type mockRateStrategy struct {
+	limit float64
+	burst int
+}
+
+func (s mockRateStrategy) Limit(string) float64 {
+	return s.limit
+}
+
+func (s mockRateStrategy) Burst(string) int {
+	return s.burst
+}
+
+// failingKV is a kv.Client whose operations always fail.
+type failingKV struct {
+	kv.Client
+}
+
+func (failingKV) CAS(context.Context, string, func(in interface{}) (out interface{}, retry bool, err error)) error {
+	return assert.AnError
+}
@@ -7,6 +7,7 @@ package distributor
 
 import (
 	"context"
+	"crypto/rand"
 	"flag"
 	"fmt"
 	"io"
@@ -23,6 +24,7 @@ import (
 	"github.com/grafana/dskit/ring"
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -30,6 +32,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/scrape"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/instrument"
@@ -44,7 +47,10 @@ import (
 	"github.com/grafana/mimir/pkg/distributor/forwarding"
 	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/pagination"
+	"github.com/grafana/mimir/pkg/usagetracker"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/push"
@@ -67,6 +73,9 @@ const (
 
 const (
 	instanceIngestionRateTickInterval = time.Second
+
+	// deadbandPurgeInterval is how frequently the state of the series tracked by the ingestion deadband is purged.
+	deadbandPurgeInterval = time.Minute
 )
 
 // Distributor forwards appends and queries to individual ingesters.
@@ -77,8 +86,16 @@ type Distributor struct {
 	log           log.Logger
 	ingestersRing ring.ReadRing
 	ingesterPool  *ring_client.Pool
-	limits        *validation.Overrides
-	forwarder     forwarding.Forwarder
+
+	// Pool of the clients used for the read path. It's the same as ingesterPool, unless
+	// the ingesters serve the read path from a dedicated gRPC server.
+	ingesterReadPool *ring_client.Pool
+
+	limits    *validation.Overrides
+	forwarder forwarding.Forwarder
+
+	// Client of the usage-tracker, nil if the usage-tracker is not used.
+	usageTracker usagetracker.Client
 
 	// The global rate limiter requires a distributors ring to count
 	// the number of healthy instances
@@ -89,10 +106,17 @@ type Distributor struct {
 	// For handling HA replicas.
 	HATracker *haTracker
 
+	// For dropping the unchanged samples of the ingestion deadband metrics.
+	deadband *deadbandFilter
+
 	// Per-user rate limiters.
 	requestRateLimiter   *limiter.RateLimiter
 	ingestionRateLimiter *limiter.RateLimiter
 
+	// usageExchange shares the usage of the tenants with the other distributors. Nil if the
+	// distributed rate limiter is disabled.
+	usageExchange *usageExchange
+
 	// Manager for subservices (HA Tracker, distributor ring, forwarder and client pool)
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -118,6 +142,8 @@ type Distributor struct {
 	incomingMetadata                 *prometheus.CounterVec
 	nonHASamples                     *prometheus.CounterVec
 	dedupedSamples                   *prometheus.CounterVec
+	stalenessMarkers                 *prometheus.CounterVec
+	deadbandDroppedSamples           *prometheus.CounterVec
 	labelsHistogram                  prometheus.Histogram
 	sampleDelayHistogram             prometheus.Histogram
 	replicationFactor                prometheus.Gauge
@@ -128,6 +154,8 @@ type Distributor struct {
 	discardedRequestsRateLimited      *prometheus.CounterVec
 	discardedExemplarsRateLimited     *prometheus.CounterVec
 	discardedMetadataRateLimited      *prometheus.CounterVec
+	discardedSamplesActiveSeriesLimit *prometheus.CounterVec
+	usageTrackerFailures              prometheus.Counter
 
 	sampleValidationMetrics   *validation.SampleValidationMetrics
 	exemplarValidationMetrics *validation.ExemplarValidationMetrics
@@ -146,6 +174,10 @@ type Config struct {
 	MaxRecvMsgSize int           `yaml:"max_recv_msg_size" category:"advanced"`
 	RemoteTimeout  time.Duration `yaml:"remote_timeout" category:"advanced"`
 
+	IngesterReadPathGRPCPort int `yaml:"ingester_read_path_grpc_port" category:"experimental"`
+
+	WriteRequestIDsEnabled bool `yaml:"write_request_ids_enabled" category:"experimental"`
+
 	// Distributors ring
 	DistributorRing RingConfig `yaml:"ring"`
 
@@ -166,6 +198,12 @@ type Config struct {
 	// Configuration for forwarding of metrics to alternative ingestion endpoint.
 	Forwarding forwarding.Config
 
+	PushCost PushCostConfig `yaml:"push_cost"`
+
+	DistributedRateLimiter DistributedRateLimiterConfig `yaml:"distributed_rate_limiter"`
+
+	UsageTrackerClient usagetracker.ClientConfig `yaml:"usage_tracker_client"`
+
 	// This allows downstream projects to wrap the distributor push function
 	// and access the deserialized write requests before/after they are pushed.
 	// These functions will only receive samples that don't get forwarded to an
@@ -183,9 +221,14 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.HATrackerConfig.RegisterFlags(f)
 	cfg.DistributorRing.RegisterFlags(f, logger)
 	cfg.Forwarding.RegisterFlags(f)
+	cfg.PushCost.RegisterFlags(f)
+	cfg.DistributedRateLimiter.RegisterFlags(f)
+	cfg.UsageTrackerClient.RegisterFlagsWithPrefix("distributor.usage-tracker-client", f)
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "Max message size in bytes that the distributors will accept for incoming push requests to the remote write API. If exceeded, the request will be rejected.")
 	f.DurationVar(&cfg.RemoteTimeout, "distributor.remote-timeout", 2*time.Second, "Timeout for downstream ingesters.")
+	f.IntVar(&cfg.IngesterReadPathGRPCPort, "distributor.ingester-read-path-grpc-port", 0, "When set, the read requests to the ingesters, like the queries, are sent to this port of the ingesters instead of the gRPC port the ingesters registered in the ring. Set it to the port the ingesters read path gRPC server listens on (-ingester.read-path-grpc-server.listen-port). 0 to disable.")
+	f.BoolVar(&cfg.WriteRequestIDsEnabled, "distributor.write-request-ids-enabled", false, "Assign a unique ID to each write request, and propagate it to the ingesters. The ID is logged by the distributor and added to the request trace, and the ingesters with -ingester.write-request-log-enabled record it along with the series of the request, so that the request which introduced a series or a sample can be found.")
 
 	cfg.DefaultLimits.RegisterFlags(f)
 }
@@ -201,6 +244,14 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return err
 	}
 
+	if err := cfg.PushCost.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.DistributedRateLimiter.Validate(cfg.DistributorRing); err != nil {
+		return err
+	}
+
 	return cfg.Forwarding.Validate()
 }
 
@@ -236,6 +287,7 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		healthyInstancesCount: atomic.NewUint32(0),
 		limits:                limits,
 		HATracker:             haTracker,
+		deadband:              newDeadbandFilter(),
 		ingestionRate:         util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
 
 		queryDuration: instrument.NewHistogramCollector(promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
@@ -304,6 +356,16 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name:      "distributor_deduped_samples_total",
 			Help:      "The total number of deduplicated samples.",
 		}, []string{"user", "cluster"}),
+		stalenessMarkers: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_staleness_markers_total",
+			Help:      "The total number of staleness markers received by the distributor, by the policy applied to them.",
+		}, []string{"user", "policy"}),
+		deadbandDroppedSamples: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_deadband_dropped_samples_total",
+			Help:      "The total number of samples dropped by the ingestion deadband because their value was unchanged.",
+		}, []string{"user", "metric"}),
 		labelsHistogram: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 			Namespace: "cortex",
 			Name:      "labels_per_sample",
@@ -344,6 +406,11 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		discardedRequestsRateLimited:      validation.DiscardedRequestsCounter(reg, validation.ReasonRateLimited),
 		discardedExemplarsRateLimited:     validation.DiscardedExemplarsCounter(reg, validation.ReasonRateLimited),
 		discardedMetadataRateLimited:      validation.DiscardedMetadataCounter(reg, validation.ReasonRateLimited),
+		discardedSamplesActiveSeriesLimit: validation.DiscardedSamplesCounter(reg, validation.ReasonMaxActiveSeriesPerUser),
+		usageTrackerFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_distributor_usage_tracker_failures_total",
+			Help: "The total number of write requests whose series couldn't be tracked in the usage-tracker, and have been accepted regardless of the active series limit.",
+		}),
 
 		sampleValidationMetrics:   validation.NewSampleValidationMetrics(reg),
 		exemplarValidationMetrics: validation.NewExemplarValidationMetrics(reg),
@@ -413,6 +480,19 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		subservices = append(subservices, distributorsLifecycler, distributorsRing)
 		requestRateStrategy = newGlobalRateStrategy(newRequestRateStrategy(limits), d)
 		ingestionRateStrategy = newGlobalRateStrategy(newIngestionRateStrategy(limits), d)
+
+		// The distributed rate limiter falls back to the global strategy when the usage of
+		// the tenant can't be exchanged with the other distributors.
+		if cfg.DistributedRateLimiter.Enabled {
+			d.usageExchange, err = newUsageExchange(cfg.DistributedRateLimiter, cfg.DistributorRing, log, reg)
+			if err != nil {
+				return nil, err
+			}
+
+			subservices = append(subservices, d.usageExchange)
+			requestRateStrategy = newDistributedRateStrategy(newRequestRateStrategy(limits), requestRateStrategy, d.usageExchange, requestUsageRate)
+			ingestionRateStrategy = newDistributedRateStrategy(newIngestionRateStrategy(limits), ingestionRateStrategy, d.usageExchange, ingestionUsageRate)
+		}
 	}
 
 	d.requestRateLimiter = limiter.NewRateLimiter(requestRateStrategy, 10*time.Second)
@@ -430,9 +510,23 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		subservices = append(subservices, d.forwarder)
 	}
 
+	if cfg.UsageTrackerClient.Address != "" {
+		d.usageTracker, err = usagetracker.NewClient(cfg.UsageTrackerClient, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create usage-tracker client")
+		}
+	}
+
 	d.PushWithMiddlewares = d.wrapPushWithMiddlewares(d.push)
 
 	subservices = append(subservices, d.ingesterPool, d.activeUsers)
+
+	d.ingesterReadPool = d.ingesterPool
+	if cfg.IngesterReadPathGRPCPort > 0 {
+		d.ingesterReadPool = NewReadPathPool(cfg.PoolConfig, ingestersRing, cfg.IngesterReadPathGRPCPort, cfg.IngesterClientFactory, log)
+		subservices = append(subservices, d.ingesterReadPool)
+	}
+
 	d.subservices, err = services.NewManager(subservices...)
 	if err != nil {
 		return nil, err
@@ -498,6 +592,9 @@ func (d *Distributor) running(ctx context.Context) error {
 	ingestionRateTicker := time.NewTicker(instanceIngestionRateTickInterval)
 	defer ingestionRateTicker.Stop()
 
+	deadbandPurgeTicker := time.NewTicker(deadbandPurgeInterval)
+	defer deadbandPurgeTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -506,6 +603,9 @@ func (d *Distributor) running(ctx context.Context) error {
 		case <-ingestionRateTicker.C:
 			d.ingestionRate.Tick()
 
+		case now := <-deadbandPurgeTicker.C:
+			d.deadband.purge(now, d.limits.IngestionDeadbandWindow)
+
 		case err := <-d.subservicesWatcher.Chan():
 			return errors.Wrap(err, "distributor subservice failed")
 		}
@@ -530,8 +630,11 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 
 	filter := prometheus.Labels{"user": userID}
 	d.dedupedSamples.DeletePartialMatch(filter)
+	d.stalenessMarkers.DeletePartialMatch(filter)
+	d.deadbandDroppedSamples.DeletePartialMatch(filter)
 	d.discardedSamplesTooManyHaClusters.DeletePartialMatch(filter)
 	d.discardedSamplesRateLimited.DeletePartialMatch(filter)
+	d.discardedSamplesActiveSeriesLimit.DeletePartialMatch(filter)
 	d.discardedRequestsRateLimited.DeleteLabelValues(userID)
 	d.discardedExemplarsRateLimited.DeleteLabelValues(userID)
 	d.discardedMetadataRateLimited.DeleteLabelValues(userID)
@@ -540,6 +643,8 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.exemplarValidationMetrics.DeleteUserMetrics(userID)
 	d.metadataValidationMetrics.DeleteUserMetrics(userID)
 
+	d.deadband.deleteUser(userID)
+
 	if d.forwarder != nil {
 		d.forwarder.DeleteMetricsForUser(userID)
 	}
@@ -549,11 +654,18 @@ func (d *Distributor) RemoveGroupMetricsForUser(userID, group string) {
 	d.dedupedSamples.DeleteLabelValues(userID, group)
 	d.discardedSamplesTooManyHaClusters.DeleteLabelValues(userID, group)
 	d.discardedSamplesRateLimited.DeleteLabelValues(userID, group)
+	d.discardedSamplesActiveSeriesLimit.DeleteLabelValues(userID, group)
 	d.sampleValidationMetrics.DeleteUserMetricsForGroup(userID, group)
 }
 
 // Called after distributor is asked to stop via StopAsync.
 func (d *Distributor) stopping(_ error) error {
+	if d.usageTracker != nil {
+		if err := d.usageTracker.Close(); err != nil {
+			level.Warn(d.log).Log("msg", "failed to close the usage-tracker client", "err", err)
+		}
+	}
+
 	return services.StopManagerAndAwaitStopped(context.Background(), d.subservices)
 }
 
@@ -709,8 +821,11 @@ func (d *Distributor) wrapPushWithMiddlewares(next push.Func) push.Func {
 	middlewares = append(middlewares, d.metricsMiddleware)
 	middlewares = append(middlewares, d.prePushHaDedupeMiddleware)
 	middlewares = append(middlewares, d.prePushRelabelMiddleware)
+	middlewares = append(middlewares, d.prePushStalenessMarkersMiddleware)
 	middlewares = append(middlewares, d.prePushValidationMiddleware)
+	middlewares = append(middlewares, d.prePushDeadbandMiddleware)
 	middlewares = append(middlewares, d.prePushForwardingMiddleware)
+	middlewares = append(middlewares, d.prePushUsageTrackerMiddleware)
 	middlewares = append(middlewares, d.cfg.PushWrappers...)
 
 	for ix := len(middlewares) - 1; ix >= 0; ix-- {
@@ -859,6 +974,156 @@ func (d *Distributor) prePushRelabelMiddleware(next push.Func) push.Func {
 	}
 }
 
+// prePushStalenessMarkersMiddleware applies the tenant's staleness markers policy to the staleness
+// markers in the request, which may be ingested as they are, dropped or converted to regular NaN samples.
+func (d *Distributor) prePushStalenessMarkersMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		cleanupInDefer := true
+		defer func() {
+			if cleanupInDefer {
+				pushReq.CleanUp()
+			}
+		}()
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		policy := d.limits.StalenessMarkersPolicy(userID)
+		if policy == "" {
+			policy = validation.StalenessMarkersPolicyIngest
+		}
+
+		var (
+			numMarkers      int
+			removeTsIndexes []int
+		)
+		for tsIdx, ts := range req.Timeseries {
+			numMarkers += applyStalenessMarkersPolicy(ts.TimeSeries, policy)
+
+			// The series whose samples were all staleness markers have nothing left to ingest.
+			if policy == validation.StalenessMarkersPolicyDrop && len(ts.Samples) == 0 && len(ts.Histograms) == 0 && len(ts.Exemplars) == 0 {
+				removeTsIndexes = append(removeTsIndexes, tsIdx)
+			}
+		}
+
+		if numMarkers > 0 {
+			d.stalenessMarkers.WithLabelValues(userID, policy).Add(float64(numMarkers))
+		}
+
+		if len(removeTsIndexes) > 0 {
+			for _, removeTsIndex := range removeTsIndexes {
+				mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeTsIndex])
+			}
+			req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeTsIndexes)
+		}
+
+		cleanupInDefer = false
+		return next(ctx, pushReq)
+	}
+}
+
+// prePushDeadbandMiddleware drops the samples of the tenant's ingestion deadband metrics whose value
+// is unchanged since the previous sample of the series.
+func (d *Distributor) prePushDeadbandMiddleware(next push.Func) push.Func {
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		cleanupInDefer := true
+		defer func() {
+			if cleanupInDefer {
+				pushReq.CleanUp()
+			}
+		}()
+
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics := d.limits.IngestionDeadbandMetrics(userID)
+		window := d.limits.IngestionDeadbandWindow(userID)
+		if len(metrics) == 0 || window <= 0 {
+			cleanupInDefer = false
+			return next(ctx, pushReq)
+		}
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		dropped := d.deadband.filter(userID, req, deadbandRequest{
+			metrics:        metrics,
+			windowMs:       window.Milliseconds(),
+			strictCounters: d.limits.IngestionDeadbandStrictCounters(userID),
+		})
+		for metric, count := range dropped {
+			d.deadbandDroppedSamples.WithLabelValues(userID, metric).Add(float64(count))
+		}
+
+		if len(dropped) > 0 {
+			var removeTsIndexes []int
+			for tsIdx, ts := range req.Timeseries {
+				if len(ts.Samples) == 0 && len(ts.Histograms) == 0 && len(ts.Exemplars) == 0 {
+					removeTsIndexes = append(removeTsIndexes, tsIdx)
+				}
+			}
+			for _, removeTsIndex := range removeTsIndexes {
+				mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeTsIndex])
+			}
+			req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeTsIndexes)
+		}
+
+		cleanupInDefer = false
+		return next(ctx, pushReq)
+	}
+}
+
+// applyStalenessMarkersPolicy applies the input policy to the staleness markers of the input series,
+// and returns the number of staleness markers found.
+func applyStalenessMarkersPolicy(ts *mimirpb.TimeSeries, policy string) int {
+	numMarkers := 0
+
+	samples := ts.Samples[:0]
+	for _, s := range ts.Samples {
+		if value.IsStaleNaN(s.Value) {
+			numMarkers++
+
+			switch policy {
+			case validation.StalenessMarkersPolicyDrop:
+				continue
+			case validation.StalenessMarkersPolicyConvert:
+				s.Value = math.NaN()
+			}
+		}
+		samples = append(samples, s)
+	}
+	ts.Samples = samples
+
+	histograms := ts.Histograms[:0]
+	for _, h := range ts.Histograms {
+		if value.IsStaleNaN(h.Sum) {
+			numMarkers++
+
+			switch policy {
+			case validation.StalenessMarkersPolicyDrop:
+				continue
+			case validation.StalenessMarkersPolicyConvert:
+				h.Sum = math.NaN()
+			}
+		}
+		histograms = append(histograms, h)
+	}
+	ts.Histograms = histograms
+
+	return numMarkers
+}
+
 func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
 		cleanupInDefer := true
@@ -974,6 +1239,9 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 		}
 
 		totalN := validatedSamples + validatedExemplars + validatedMetadata
+		if d.usageExchange != nil {
+			d.usageExchange.recordIngestion(userID, totalN)
+		}
 		if !d.ingestionRateLimiter.AllowN(now, userID, totalN) {
 			d.discardedSamplesRateLimited.WithLabelValues(userID, group).Add(float64(validatedSamples))
 			d.discardedExemplarsRateLimited.WithLabelValues(userID).Add(float64(validatedExemplars))
@@ -991,7 +1259,7 @@ func (d *Distributor) prePushValidationMiddleware(next push.Func) push.Func {
 		res, err := next(ctx, pushReq)
 		if err != nil {
 			// Errors resulting from the pushing to the ingesters have priority over validation errors.
-			return nil, err
+			return res, err
 		}
 
 		return res, firstPartialErr
@@ -1041,6 +1309,104 @@ func (d *Distributor) prePushForwardingMiddleware(next push.Func) push.Func {
 	}
 }
 
+// prePushUsageTrackerMiddleware is used as push.Func middleware in front of push method.
+// It tracks the series in the usage-tracker, and removes the series rejected because they would exceed
+// the tenant active series limit.
+func (d *Distributor) prePushUsageTrackerMiddleware(next push.Func) push.Func {
+	if d.usageTracker == nil {
+		// The usage-tracker is not used, no need to wrap "next".
+		return next
+	}
+
+	return func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+		cleanupInDefer := true
+		defer func() {
+			if cleanupInDefer {
+				pushReq.CleanUp()
+			}
+		}()
+
+		req, err := pushReq.WriteRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		limit := d.limits.MaxActiveSeriesPerUser(userID)
+		if limit <= 0 || len(req.Timeseries) == 0 {
+			cleanupInDefer = false
+			return next(ctx, pushReq)
+		}
+
+		hashes := make([]uint64, 0, len(req.Timeseries))
+		indexes := make(map[uint64][]int, len(req.Timeseries))
+		for tsIdx, ts := range req.Timeseries {
+			hash := mimirpb.FromLabelAdaptersToLabels(ts.Labels).Hash()
+			hashes = append(hashes, hash)
+			indexes[hash] = append(indexes[hash], tsIdx)
+		}
+
+		rejected, err := d.usageTracker.TrackSeries(ctx, hashes)
+		if err != nil {
+			// The usage-tracker is not on the critical path: if it's unavailable we accept the series,
+			// instead of failing the write request.
+			d.usageTrackerFailures.Inc()
+			level.Warn(d.log).Log("msg", "failed to track series in the usage-tracker", "user", userID, "err", err)
+
+			cleanupInDefer = false
+			return next(ctx, pushReq)
+		}
+
+		if len(rejected) == 0 {
+			cleanupInDefer = false
+			return next(ctx, pushReq)
+		}
+
+		var removeIndexes []int
+		for _, hash := range rejected {
+			removeIndexes = append(removeIndexes, indexes[hash]...)
+			// The same hash may be rejected multiple times if the request contains duplicated series.
+			delete(indexes, hash)
+		}
+		sort.Ints(removeIndexes)
+
+		rejectedSamples := 0
+		for _, removeIndex := range removeIndexes {
+			rejectedSamples += len(req.Timeseries[removeIndex].Samples) + len(req.Timeseries[removeIndex].Histograms)
+		}
+
+		group := validation.GroupLabel(d.limits, userID, req.Timeseries)
+		d.discardedSamplesActiveSeriesLimit.WithLabelValues(userID, group).Add(float64(rejectedSamples))
+
+		for _, removeIndex := range removeIndexes {
+			mimirpb.ReusePreallocTimeseries(&req.Timeseries[removeIndex])
+		}
+		req.Timeseries = util.RemoveSliceIndexes(req.Timeseries, removeIndexes)
+
+		limitErr := httpgrpc.Errorf(http.StatusBadRequest, globalerror.MaxActiveSeriesPerUser.MessageWithPerTenantLimitConfig(
+			fmt.Sprintf("per-user active series limit of %d exceeded", limit),
+			validation.MaxActiveSeriesPerUserFlag,
+		))
+
+		if len(req.Timeseries) == 0 && len(req.Metadata) == 0 {
+			return &mimirpb.WriteResponse{}, limitErr
+		}
+
+		cleanupInDefer = false
+		res, err := next(ctx, pushReq)
+		if err != nil {
+			// Errors resulting from the pushing to the ingesters have priority over the limit error.
+			return res, err
+		}
+
+		return res, limitErr
+	}
+}
+
 // metricsMiddleware updates metrics which are expected to account for all received data,
 // including data that later gets modified or dropped.
 func (d *Distributor) metricsMiddleware(next push.Func) push.Func {
@@ -1113,6 +1479,9 @@ func (d *Distributor) limitsMiddleware(next push.Func) push.Func {
 		}
 
 		now := mtime.Now()
+		if d.usageExchange != nil {
+			d.usageExchange.recordRequest(userID)
+		}
 		if !d.requestRateLimiter.AllowN(now, userID, 1) {
 			d.discardedRequestsRateLimited.WithLabelValues(userID).Add(1)
 
@@ -1226,6 +1595,14 @@ func (d *Distributor) push(ctx context.Context, pushReq *push.Request) (*mimirpb
 	if sp := opentracing.SpanFromContext(ctx); sp != nil {
 		localCtx = opentracing.ContextWithSpan(localCtx, sp)
 	}
+	if d.cfg.WriteRequestIDsEnabled {
+		id := newWriteRequestID()
+		localCtx = util.AddWriteRequestIDToOutgoingContext(localCtx, id)
+		if span != nil {
+			span.SetTag("write_request_id", id)
+		}
+		level.Debug(d.log).Log("msg", "assigned write request ID", "user", userID, "write_request_id", id, "series", len(req.Timeseries), "metadata", len(req.Metadata))
+	}
 
 	// All tokens, stored in order: series, metadata.
 	keys := make([]uint32, len(seriesKeys)+len(metadataKeys))
@@ -1233,6 +1610,12 @@ func (d *Distributor) push(ctx context.Context, pushReq *push.Request) (*mimirpb
 	copy(keys, seriesKeys)
 	copy(keys[initialMetadataIndex:], metadataKeys)
 
+	// Count the samples of each series before the buffers are released by DoBatch.
+	var costTracker *pushCostTracker
+	if d.cfg.PushCost.Enabled {
+		costTracker = newPushCostTracker(req.Timeseries)
+	}
+
 	// we must not re-use buffers now until all DoBatch goroutines have finished,
 	// so set this flag false and pass cleanup() to DoBatch.
 	cleanupInDefer = false
@@ -1258,13 +1641,20 @@ func (d *Distributor) push(ctx context.Context, pushReq *push.Request) (*mimirpb
 			}
 		}
 
-		err := d.send(localCtx, ingester, timeseries, metadata, req.Source)
+		resp, err := d.send(localCtx, ingester, timeseries, metadata, req.Source)
 		if errors.Is(err, context.DeadlineExceeded) {
 			return httpgrpc.Errorf(500, "exceeded configured distributor remote timeout: %s", err.Error())
 		}
+		if err == nil && costTracker != nil {
+			costTracker.record(indexes, resp)
+		}
 		return err
 	}, func() { pushReq.CleanUp(); cancel() })
 
+	if costTracker != nil {
+		// The cost is returned on failed requests too, because some samples may have been accepted anyway.
+		return &mimirpb.WriteResponse{Cost: costTracker.cost(d.cfg.PushCost)}, err
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1303,6 +1693,12 @@ func (d *Distributor) updateReceivedMetrics(req *mimirpb.WriteRequest, userID st
 	d.receivedMetadata.WithLabelValues(userID).Add(float64(receivedMetadata))
 }
 
+// newWriteRequestID returns a unique ID for a write request. IDs are ULIDs, so that they sort by the time
+// the requests have been received.
+func newWriteRequestID() string {
+	return ulid.MustNew(ulid.Now(), rand.Reader).String()
+}
+
 func copyString(s string) string {
 	return string([]byte(s))
 }
@@ -1329,10 +1725,10 @@ func sortLabelsIfNeeded(labels []mimirpb.LabelAdapter) {
 	})
 }
 
-func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) error {
+func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, timeseries []mimirpb.PreallocTimeseries, metadata []*mimirpb.MetricMetadata, source mimirpb.WriteRequest_SourceEnum) (*mimirpb.WriteResponse, error) {
 	h, err := d.ingesterPool.GetClientFor(ingester.Addr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	c := h.(ingester_client.IngesterClient)
 
@@ -1341,18 +1737,18 @@ func (d *Distributor) send(ctx context.Context, ingester ring.InstanceDesc, time
 		Metadata:   metadata,
 		Source:     source,
 	}
-	_, err = c.Push(ctx, &req)
+	writeResp, err := c.Push(ctx, &req)
 	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
 		// Wrap HTTP gRPC error with more explanatory message.
-		return httpgrpc.Errorf(int(resp.Code), "failed pushing to ingester: %s", resp.Body)
+		return nil, httpgrpc.Errorf(int(resp.Code), "failed pushing to ingester: %s", resp.Body)
 	}
-	return errors.Wrap(err, "failed pushing to ingester")
+	return writeResp, errors.Wrap(err, "failed pushing to ingester")
 }
 
 // forReplicationSet runs f, in parallel, for all ingesters in the input replication set.
 func (d *Distributor) forReplicationSet(ctx context.Context, replicationSet ring.ReplicationSet, f func(context.Context, ingester_client.IngesterClient) (interface{}, error)) ([]interface{}, error) {
 	return replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
-		client, err := d.ingesterPool.GetClientFor(ing.Addr)
+		client, err := d.ingesterReadPool.GetClientFor(readPathAddr(ing.Addr, d.cfg.IngesterReadPathGRPCPort))
 		if err != nil {
 			return nil, err
 		}
@@ -1373,6 +1769,19 @@ func (d *Distributor) LabelValuesForLabelName(ctx context.Context, from, to mode
 		return nil, err
 	}
 
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page := pagination.FromContext(ctx)
+	req.Limit, req.After = int64(page.Limit), page.After
+
+	// The size limit is pushed down to the ingesters, so that they don't transfer label values
+	// which would be rejected anyway.
+	maxSizeBytes := d.limits.LabelValuesResultsMaxSizeBytes(userID)
+	req.MaxSizeBytes = int64(maxSizeBytes)
+
 	resps, err := d.forReplicationSet(ctx, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 		return client.LabelValues(ctx, req)
 	})
@@ -1395,6 +1804,11 @@ func (d *Distributor) LabelValuesForLabelName(ctx context.Context, from, to mode
 	// We need the values returned to be sorted.
 	slices.Sort(values)
 
+	values = page.Apply(values)
+	if err := validation.CheckLabelValuesResultsSizeBytes(values, maxSizeBytes); err != nil {
+		return nil, err
+	}
+
 	return values, nil
 }
 
@@ -1674,6 +2088,9 @@ func (d *Distributor) LabelNames(ctx context.Context, from, to model.Time, match
 		return nil, err
 	}
 
+	page := pagination.FromContext(ctx)
+	req.Limit, req.After = int64(page.Limit), page.After
+
 	resps, err := d.forReplicationSet(ctx, replicationSet, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 		return client.LabelNames(ctx, req)
 	})
@@ -1695,7 +2112,7 @@ func (d *Distributor) LabelNames(ctx context.Context, from, to model.Time, match
 
 	slices.Sort(values)
 
-	return values, nil
+	return page.Apply(values), nil
 }
 
 // MetricsForLabelMatchers gets the metrics that match said matchers
@@ -1825,7 +2242,7 @@ func (d *Distributor) AllUserStats(ctx context.Context) ([]UserIDStats, error) {
 		return nil, err
 	}
 	for _, ingester := range replicationSet.Instances {
-		client, err := d.ingesterPool.GetClientFor(ingester.Addr)
+		client, err := d.ingesterReadPool.GetClientFor(readPathAddr(ingester.Addr, d.cfg.IngesterReadPathGRPCPort))
 		if err != nil {
 			return nil, err
 		}
@@ -28,12 +28,14 @@ import (
 	"github.com/grafana/dskit/services"
 	"github.com/grafana/dskit/tenant"
 	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/model/value"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
@@ -44,6 +46,7 @@ import (
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/grafana/mimir/pkg/distributor/forwarding"
@@ -51,6 +54,7 @@ import (
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/chunk"
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/chunkcompat"
 	"github.com/grafana/mimir/pkg/util/globalerror"
 	"github.com/grafana/mimir/pkg/util/limiter"
@@ -331,6 +335,124 @@ func TestDistributor_Push(t *testing.T) {
 	}
 }
 
+func TestDistributor_PushCost(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now().UnixMilli()
+
+	t.Run("should not estimate the cost if disabled", func(t *testing.T) {
+		ds, _, _ := prepare(t, prepConfig{
+			numIngesters:      3,
+			happyIngesters:    3,
+			numDistributors:   1,
+			replicationFactor: 3,
+		})
+
+		resp, err := ds[0].Push(ctx, makeWriteRequest(now, 5, 0, false, false))
+		require.NoError(t, err)
+		assert.Nil(t, resp.Cost)
+	})
+
+	t.Run("should estimate the cost of accepted samples and created series", func(t *testing.T) {
+		ds, _, _ := prepare(t, prepConfig{
+			numIngesters:      3,
+			happyIngesters:    3,
+			numDistributors:   1,
+			replicationFactor: 3,
+			pushCost:          &PushCostConfig{Enabled: true, SampleWeight: 1, CreatedSeriesWeight: 10},
+		})
+
+		resp, err := ds[0].Push(ctx, makeWriteRequest(now, 5, 0, false, true))
+		require.NoError(t, err)
+		assert.Equal(t, &mimirpb.WriteCost{AcceptedSamples: 10, CreatedSeries: 5, Units: 60}, resp.Cost)
+
+		// Series already existing in the ingesters are not created again.
+		resp, err = ds[0].Push(ctx, makeWriteRequest(now+10, 5, 0, false, false))
+		require.NoError(t, err)
+		assert.Equal(t, &mimirpb.WriteCost{AcceptedSamples: 5, CreatedSeries: 0, Units: 5}, resp.Cost)
+	})
+
+	t.Run("should estimate the cost of the samples accepted by a partially failed request", func(t *testing.T) {
+		ds, ingesters, _ := prepare(t, prepConfig{
+			numIngesters:      2,
+			happyIngesters:    1,
+			numDistributors:   1,
+			replicationFactor: 1,
+			pushCost:          &PushCostConfig{Enabled: true, SampleWeight: 1, CreatedSeriesWeight: 10},
+		})
+
+		// The failing ingester responds after the successful one, so that the request fails once the
+		// series sent to the successful one have been written.
+		ingesters[1].pushDelay = 100 * time.Millisecond
+
+		resp, err := ds[0].Push(ctx, makeWriteRequest(now, 1, 0, false, true, "series_1", "series_2", "series_3", "series_4", "series_5", "series_6"))
+		require.Error(t, err)
+		require.NotNil(t, resp)
+
+		// Only the samples of the series written to the successful ingester are accepted.
+		writtenSeries := ingesters[0].series()
+		require.Greater(t, len(writtenSeries), 0)
+		require.Less(t, len(writtenSeries), 6)
+
+		writtenSamples := uint64(0)
+		for _, ts := range writtenSeries {
+			writtenSamples += uint64(len(ts.Samples) + len(ts.Histograms))
+		}
+		assert.Equal(t, &mimirpb.WriteCost{
+			AcceptedSamples: writtenSamples,
+			CreatedSeries:   uint64(len(writtenSeries)),
+			Units:           float64(writtenSamples) + float64(len(writtenSeries))*10,
+		}, resp.Cost)
+	})
+}
+
+func TestDistributor_WriteRequestIDs(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	now := time.Now().UnixMilli()
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			ds, ingesters, _ := prepare(t, prepConfig{
+				numIngesters:      1,
+				happyIngesters:    1,
+				numDistributors:   1,
+				replicationFactor: 1,
+				writeRequestIDs:   enabled,
+			})
+
+			_, err := ds[0].Push(ctx, makeWriteRequest(now, 5, 0, false, false))
+			require.NoError(t, err)
+			_, err = ds[0].Push(ctx, makeWriteRequest(now+10, 5, 0, false, false))
+			require.NoError(t, err)
+
+			ingesters[0].Lock()
+			ids := ingesters[0].writeRequestIDs
+			ingesters[0].Unlock()
+
+			require.Len(t, ids, 2)
+			if !enabled {
+				assert.Equal(t, []string{"", ""}, ids)
+				return
+			}
+
+			// Each write request has its own ID.
+			for _, id := range ids {
+				_, err := ulid.Parse(id)
+				require.NoError(t, err)
+			}
+			assert.NotEqual(t, ids[0], ids[1])
+		})
+	}
+}
+
+func TestPushCostConfig_Validate(t *testing.T) {
+	cfg := PushCostConfig{}
+	flagext.DefaultValues(&cfg)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.CreatedSeriesWeight = -1
+	assert.ErrorIs(t, cfg.Validate(), errInvalidPushCostWeight)
+}
+
 func TestDistributor_ContextCanceledRequest(t *testing.T) {
 	now := time.Now()
 	mtime.NowForce(now)
@@ -1066,7 +1188,7 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunksPerQueryLimitIsReac
 		limits:          limits,
 	})
 
-	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, maxChunksLimit))
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, maxChunksLimit, 0, 0, nil))
 
 	// Push a number of series below the max chunks limit. Each series has 1 sample,
 	// so expect 1 chunk per series when querying back.
@@ -1105,13 +1227,61 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunksPerQueryLimitIsReac
 	assert.ErrorContains(t, err, "the query exceeded the maximum number of chunks")
 }
 
+func TestDistributor_QueryStream_ShouldReturnErrorIfMaxEstimatedChunksPerQueryLimitIsReached(t *testing.T) {
+	const maxEstimatedChunksLimit = 30 // Chunks are duplicated due to replication factor.
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+
+	// Prepare distributors.
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+		limits:          limits,
+	})
+
+	// The max chunks limit isn't enforced, so that the query is only rejected on the estimate.
+	queryCtx := limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0, maxEstimatedChunksLimit, 0, nil))
+
+	// Push a number of series below the max estimated chunks limit. Each series has 1 sample,
+	// so expect 1 chunk per series when querying back.
+	writeReq := makeWriteRequest(0, maxEstimatedChunksLimit/3, 0, false, false)
+	_, err := ds[0].Push(ctx, writeReq)
+	require.NoError(t, err)
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	queryRes, err := ds[0].QueryStream(queryCtx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.NoError(t, err)
+	assert.Len(t, queryRes.Chunkseries, maxEstimatedChunksLimit/3)
+
+	// Push more series to exceed the limit once we'll query back all series.
+	writeReq = &mimirpb.WriteRequest{}
+	for i := 0; i < maxEstimatedChunksLimit; i++ {
+		writeReq.Timeseries = append(writeReq.Timeseries,
+			makeWriteRequestTimeseries([]mimirpb.LabelAdapter{{Name: model.MetricNameLabel, Value: fmt.Sprintf("another_series_%d", i)}}, 0, 0),
+		)
+	}
+	_, err = ds[0].Push(ctx, writeReq)
+	require.NoError(t, err)
+
+	queryCtx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, 0, 0, maxEstimatedChunksLimit, 0, nil))
+	_, err = ds[0].QueryStream(queryCtx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "the query is estimated to fetch more than the maximum number of chunks")
+}
+
 func TestDistributor_QueryStream_ShouldReturnErrorIfMaxSeriesPerQueryLimitIsReached(t *testing.T) {
 	const maxSeriesLimit = 10
 
 	ctx := user.InjectOrgID(context.Background(), "user")
 	limits := &validation.Limits{}
 	flagext.DefaultValues(limits)
-	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(maxSeriesLimit, 0, 0))
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(maxSeriesLimit, 0, 0, 0, 0, nil))
 
 	// Prepare distributors.
 	ds, _, _ := prepare(t, prepConfig{
@@ -1155,6 +1325,46 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxSeriesPerQueryLimitIsReac
 	assert.ErrorContains(t, err, "the query exceeded the maximum number of series")
 }
 
+func TestDistributor_QueryStream_ShouldReturnErrorIfMaxSeriesPerSelectorLimitIsReached(t *testing.T) {
+	const maxSeriesLimit = 10
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	ctx = limiter.AddSelectorSeriesLimiterToContext(ctx, limiter.NewSelectorSeriesLimiter(maxSeriesLimit, nil))
+
+	// Prepare distributors.
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:    3,
+		happyIngesters:  3,
+		numDistributors: 1,
+		limits:          limits,
+	})
+
+	// Push more series than the limit.
+	writeReq := makeWriteRequest(0, maxSeriesLimit+1, 0, false, true)
+	writeRes, err := ds[0].Push(ctx, writeReq)
+	assert.Equal(t, &mimirpb.WriteResponse{}, writeRes)
+	assert.Nil(t, err)
+
+	// A selector matching a number of series equal to the limit succeeds.
+	someSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+		labels.MustNewMatcher(labels.MatchRegexp, "sample", "[0-9]"),
+	}
+	queryRes, err := ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, someSeriesMatchers...)
+	require.NoError(t, err)
+	assert.Len(t, queryRes.Chunkseries, maxSeriesLimit)
+
+	// A selector matching all series fails, naming the selector.
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+	_, err = ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `the query selector {__name__=~".+"} exceeded the maximum number of series`)
+}
+
 func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIsReached(t *testing.T) {
 	const seriesToAdd = 10
 
@@ -1192,7 +1402,7 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIs
 	maxBytesLimit := (seriesToAdd) * responseChunkSize
 
 	// Update the limiter with the calculated limits.
-	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, maxBytesLimit, 0))
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(0, maxBytesLimit, 0, 0, 0, nil))
 
 	// Push a number of series below the max chunk bytes limit. Subtract one for the series added above.
 	writeReq = makeWriteRequest(0, seriesToAdd-1, 0, false, false)
@@ -1223,6 +1433,56 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIs
 	assert.ErrorContains(t, err, fmt.Sprintf(limiter.MaxChunkBytesHitMsgFormat, maxBytesLimit))
 }
 
+func TestDistributor_QueryStream_ShouldReturnErrorIfMaxEstimatedMemoryPerQueryLimitIsReached(t *testing.T) {
+	const seriesToAdd = 10
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+
+	// Prepare distributors.
+	// Use replication factor of 1 so that we always wait the response from all ingesters.
+	// This guarantees us to always read the same series and have a stable test.
+	ds, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		limits:            limits,
+		replicationFactor: 1,
+	})
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	writeReq := makeWriteRequest(0, seriesToAdd, 0, false, false)
+	writeRes, err := ds[0].Push(ctx, writeReq)
+	assert.Equal(t, &mimirpb.WriteResponse{}, writeRes)
+	assert.Nil(t, err)
+
+	// Run the query without limit, to get the estimated memory consumed by the query.
+	tracker := limiter.NewMemoryConsumptionTracker(0, nil)
+	queryRes, err := ds[0].QueryStream(limiter.AddMemoryConsumptionTrackerToContext(ctx, tracker), math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.NoError(t, err)
+	assert.Len(t, queryRes.Chunkseries, seriesToAdd)
+
+	consumedBytes := tracker.CurrentEstimatedMemoryConsumptionBytes()
+	require.Greater(t, consumedBytes, uint64(0))
+
+	// Since the estimated memory consumption is equal to the limit (but doesn't
+	// exceed it), we expect the query to succeed.
+	tracker = limiter.NewMemoryConsumptionTracker(consumedBytes, nil)
+	queryRes, err = ds[0].QueryStream(limiter.AddMemoryConsumptionTrackerToContext(ctx, tracker), math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.NoError(t, err)
+	assert.Len(t, queryRes.Chunkseries, seriesToAdd)
+
+	// Since the estimated memory consumption is exceeding the limit, we expect the query to fail.
+	tracker = limiter.NewMemoryConsumptionTracker(consumedBytes - 1, nil)
+	_, err = ds[0].QueryStream(limiter.AddMemoryConsumptionTrackerToContext(ctx, tracker), math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, fmt.Sprintf(limiter.MaxEstimatedMemoryPerQueryHitMsgFormat, consumedBytes-1))
+}
+
 func TestDistributor_Push_LabelRemoval(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 
@@ -3244,6 +3504,111 @@ func TestRelabelMiddleware(t *testing.T) {
 	}
 }
 
+func TestUsageTrackerMiddleware(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	tests := map[string]struct {
+		limit             int
+		trackErr          error
+		expectedSeries    int
+		expectedErr       bool
+		expectedDiscarded int
+	}{
+		"series are not tracked if the limit is disabled": {
+			limit:          0,
+			expectedSeries: 5,
+		},
+		"series within the limit are accepted": {
+			limit:          10,
+			expectedSeries: 5,
+		},
+		"series exceeding the limit are rejected": {
+			limit:             3,
+			expectedSeries:    3,
+			expectedErr:       true,
+			expectedDiscarded: 4, // Each series has a float sample and a histogram sample.
+		},
+		"series are accepted if the usage-tracker fails": {
+			limit:          3,
+			trackErr:       fmt.Errorf("usage-tracker unavailable"),
+			expectedSeries: 5,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.MaxActiveSeriesPerUser = testData.limit
+
+			ds, _, _ := prepare(t, prepConfig{
+				numDistributors: 1,
+				limits:          &limits,
+			})
+			ds[0].usageTracker = &mockUsageTrackerClient{limit: testData.limit, err: testData.trackErr}
+
+			var gotSeries int
+			middleware := ds[0].prePushUsageTrackerMiddleware(func(ctx context.Context, pushReq *push.Request) (*mimirpb.WriteResponse, error) {
+				req, err := pushReq.WriteRequest()
+				require.NoError(t, err)
+				gotSeries = len(req.Timeseries)
+				pushReq.CleanUp()
+				return &mimirpb.WriteResponse{}, nil
+			})
+
+			req := makeWriteRequestForGenerators(5, labelSetGenForStringPairs(t, "__name__", "metric", "label", "value"), nil, nil)
+			_, err := middleware(ctx, push.NewParsedRequest(req))
+
+			if testData.expectedErr {
+				require.Error(t, err)
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+				assert.Contains(t, string(resp.Body), globalerror.MaxActiveSeriesPerUser.Message("per-user active series limit of 3 exceeded"))
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, testData.expectedSeries, gotSeries)
+			assert.Equal(t, float64(testData.expectedDiscarded), testutil.ToFloat64(ds[0].discardedSamplesActiveSeriesLimit.WithLabelValues("user", "")))
+		})
+	}
+}
+
+// mockUsageTrackerClient is a usagetracker.Client tracking the series in memory, and rejecting
+// the new series once the limit is reached.
+type mockUsageTrackerClient struct {
+	limit  int
+	err    error
+	series map[uint64]struct{}
+}
+
+func (c *mockUsageTrackerClient) TrackSeries(_ context.Context, seriesHashes []uint64) ([]uint64, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.series == nil {
+		c.series = map[uint64]struct{}{}
+	}
+
+	var rejected []uint64
+	for _, hash := range seriesHashes {
+		if _, ok := c.series[hash]; ok {
+			continue
+		}
+		if len(c.series) >= c.limit {
+			rejected = append(rejected, hash)
+			continue
+		}
+		c.series[hash] = struct{}{}
+	}
+	return rejected, nil
+}
+
+func (c *mockUsageTrackerClient) Close() error {
+	return nil
+}
+
 func TestHaDedupeAndRelabelBeforeForwarding(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "user")
 	const replica1 = "replicaA"
@@ -3509,6 +3874,8 @@ type prepConfig struct {
 	labelNamesStreamZonesResponseDelay map[string]time.Duration
 	forwarding                         bool
 	getForwarder                       func() forwarding.Forwarder
+	pushCost                           *PushCostConfig
+	writeRequestIDs                    bool
 
 	timeOut bool
 }
@@ -3620,6 +3987,11 @@ func prepare(t *testing.T, cfg prepConfig) ([]*Distributor, []mockIngester, []*p
 		distributorCfg.DefaultLimits.MaxIngestionRate = cfg.maxIngestionRate
 		distributorCfg.ShuffleShardingLookbackPeriod = time.Hour
 
+		if cfg.pushCost != nil {
+			distributorCfg.PushCost = *cfg.pushCost
+		}
+		distributorCfg.WriteRequestIDsEnabled = cfg.writeRequestIDs
+
 		if cfg.forwarding {
 			distributorCfg.Forwarding.Enabled = true
 			distributorCfg.Forwarding.RequestTimeout = 10 * time.Second
@@ -3981,6 +4353,7 @@ type mockIngester struct {
 	labelNamesStreamResponseDelay time.Duration
 	timeOut                       bool
 	tokens                        []uint32
+	writeRequestIDs               []string
 }
 
 func (i *mockIngester) series() map[uint32]*mimirpb.PreallocTimeseries {
@@ -4019,6 +4392,10 @@ func (i *mockIngester) Push(ctx context.Context, req *mimirpb.WriteRequest, opts
 		return nil, errFail
 	}
 
+	// The client sends the outgoing metadata, which the ingester receives as incoming metadata.
+	md, _ := metadata.FromOutgoingContext(ctx)
+	i.writeRequestIDs = append(i.writeRequestIDs, util.GetWriteRequestIDFromIncomingCtx(metadata.NewIncomingContext(ctx, md)))
+
 	if i.timeOut {
 		return nil, context.DeadlineExceeded
 	}
@@ -4036,10 +4413,13 @@ func (i *mockIngester) Push(ctx context.Context, req *mimirpb.WriteRequest, opts
 		return nil, err
 	}
 
+	createdSeries := uint64(0)
 	for _, series := range req.Timeseries {
 		hash := shardByAllLabels(orgid, series.Labels)
 		existing, ok := i.timeseries[hash]
 		if !ok {
+			createdSeries++
+
 			// Make a copy because the request Timeseries are reused
 			item := mimirpb.TimeSeries{
 				Labels:     make([]mimirpb.LabelAdapter, len(series.TimeSeries.Labels)),
@@ -4068,7 +4448,7 @@ func (i *mockIngester) Push(ctx context.Context, req *mimirpb.WriteRequest, opts
 		set[*m] = struct{}{}
 	}
 
-	return &mimirpb.WriteResponse{}, nil
+	return &mimirpb.WriteResponse{CreatedSeries: createdSeries}, nil
 }
 
 func makeWireChunk(c chunk.EncodedChunk) client.Chunk {
@@ -4190,6 +4570,16 @@ func (i *mockIngester) QueryStream(ctx context.Context, req *client.QueryRequest
 			},
 		})
 	}
+
+	if req.EstimateChunks {
+		estimate := &client.QueryStreamResponse{}
+		for _, res := range results {
+			estimate.EstimatedChunksCount += uint64(res.ChunksCount())
+			estimate.EstimatedChunksBytes += uint64(res.ChunksSize())
+		}
+		results = append([]*client.QueryStreamResponse{estimate}, results...)
+	}
+
 	return &stream{
 		results: results,
 	}, nil
@@ -4785,6 +5175,156 @@ func TestDistributor_Push_Relabel(t *testing.T) {
 	}
 }
 
+func TestDistributor_Push_StalenessMarkersPolicy(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+	staleNaN := math.Float64frombits(value.StaleNaN)
+
+	// describeSamples returns the values of the input samples, describing the NaN ones.
+	describeSamples := func(samples []mimirpb.Sample) []string {
+		var res []string
+		for _, s := range samples {
+			switch {
+			case value.IsStaleNaN(s.Value):
+				res = append(res, "stale")
+			case math.IsNaN(s.Value):
+				res = append(res, "NaN")
+			default:
+				res = append(res, strconv.FormatFloat(s.Value, 'f', -1, 64))
+			}
+		}
+		return res
+	}
+
+	tests := map[string]struct {
+		policy          string
+		expectedSeries  map[string][]string
+		expectedMetrics string
+	}{
+		"ingest": {
+			policy: validation.StalenessMarkersPolicyIngest,
+			expectedSeries: map[string][]string{
+				`{__name__="series_1"}`: {"1", "stale"},
+				`{__name__="series_2"}`: {"stale"},
+			},
+			expectedMetrics: `
+				# HELP cortex_distributor_staleness_markers_total The total number of staleness markers received by the distributor, by the policy applied to them.
+				# TYPE cortex_distributor_staleness_markers_total counter
+				cortex_distributor_staleness_markers_total{policy="ingest",user="user"} 2
+			`,
+		},
+		"drop": {
+			policy: validation.StalenessMarkersPolicyDrop,
+			expectedSeries: map[string][]string{
+				`{__name__="series_1"}`: {"1"},
+			},
+			expectedMetrics: `
+				# HELP cortex_distributor_staleness_markers_total The total number of staleness markers received by the distributor, by the policy applied to them.
+				# TYPE cortex_distributor_staleness_markers_total counter
+				cortex_distributor_staleness_markers_total{policy="drop",user="user"} 2
+			`,
+		},
+		"convert": {
+			policy: validation.StalenessMarkersPolicyConvert,
+			expectedSeries: map[string][]string{
+				`{__name__="series_1"}`: {"1", "NaN"},
+				`{__name__="series_2"}`: {"NaN"},
+			},
+			expectedMetrics: `
+				# HELP cortex_distributor_staleness_markers_total The total number of staleness markers received by the distributor, by the policy applied to them.
+				# TYPE cortex_distributor_staleness_markers_total counter
+				cortex_distributor_staleness_markers_total{policy="convert",user="user"} 2
+			`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.StalenessMarkersPolicy = testData.policy
+
+			ds, ingesters, regs := prepare(t, prepConfig{
+				numIngesters:      1,
+				happyIngesters:    1,
+				numDistributors:   1,
+				replicationFactor: 1,
+				limits:            &limits,
+			})
+
+			req := mimirpb.ToWriteRequest(
+				[]labels.Labels{labels.FromStrings(labels.MetricName, "series_1"), labels.FromStrings(labels.MetricName, "series_1"), labels.FromStrings(labels.MetricName, "series_2")},
+				[]mimirpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 2, Value: staleNaN}, {TimestampMs: 2, Value: staleNaN}},
+				nil, nil, mimirpb.API)
+			_, err := ds[0].Push(ctx, req)
+			require.NoError(t, err)
+
+			actualSeries := map[string][]string{}
+			for _, ts := range ingesters[0].series() {
+				actualSeries[mimirpb.FromLabelAdaptersToLabels(ts.Labels).String()] = describeSamples(ts.Samples)
+			}
+			assert.Equal(t, testData.expectedSeries, actualSeries)
+
+			assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(testData.expectedMetrics), "cortex_distributor_staleness_markers_total"))
+		})
+	}
+}
+
+func TestDistributor_Push_IngestionDeadband(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.IngestionDeadbandMetrics = []string{"gauge", "requests_total"}
+	limits.IngestionDeadbandWindow = model.Duration(time.Minute)
+
+	ds, ingesters, regs := prepare(t, prepConfig{
+		numIngesters:      1,
+		happyIngesters:    1,
+		numDistributors:   1,
+		replicationFactor: 1,
+		limits:            &limits,
+	})
+
+	push := func(metric string, ts int64, value float64) {
+		req := mimirpb.ToWriteRequest(
+			[]labels.Labels{labels.FromStrings(labels.MetricName, metric)},
+			[]mimirpb.Sample{{TimestampMs: ts, Value: value}},
+			nil, nil, mimirpb.API)
+		_, err := ds[0].Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	for _, metric := range []string{"gauge", "requests_total", "other"} {
+		push(metric, 0, 1)
+		push(metric, 15000, 1)
+		push(metric, 30000, 2)
+		push(metric, 45000, 2)
+		push(metric, 90000, 2)
+	}
+
+	actualTimestamps := map[string][]int64{}
+	for _, ts := range ingesters[0].series() {
+		for _, s := range ts.Samples {
+			metric := mimirpb.FromLabelAdaptersToLabels(ts.Labels).Get(labels.MetricName)
+			actualTimestamps[metric] = append(actualTimestamps[metric], s.TimestampMs)
+		}
+	}
+
+	// The unchanged samples of the gauge are dropped, unless the last ingested sample is older
+	// than the window, while the samples of the counter and of the other metrics are all ingested.
+	assert.Equal(t, map[string][]int64{
+		"gauge":          {0, 30000, 90000},
+		"requests_total": {0, 15000, 30000, 45000, 90000},
+		"other":          {0, 15000, 30000, 45000, 90000},
+	}, actualTimestamps)
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_deadband_dropped_samples_total The total number of samples dropped by the ingestion deadband because their value was unchanged.
+		# TYPE cortex_distributor_deadband_dropped_samples_total counter
+		cortex_distributor_deadband_dropped_samples_total{metric="gauge",user="user"} 2
+	`), "cortex_distributor_deadband_dropped_samples_total"))
+}
+
 func countMockIngestersCalls(ingesters []mockIngester, name string) int {
 	count := 0
 	for i := 0; i < len(ingesters); i++ {
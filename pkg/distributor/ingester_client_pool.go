@@ -7,6 +7,8 @@ package distributor
 
 import (
 	"flag"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/go-kit/log"
@@ -45,3 +47,38 @@ func NewPool(cfg PoolConfig, ring ring.ReadRing, factory ring_client.PoolFactory
 
 	return ring_client.NewPool("ingester", poolCfg, ring_client.NewRingServiceDiscovery(ring), factory, clients, logger)
 }
+
+// NewReadPathPool makes a new Pool of clients connecting to the given port of the ingesters,
+// where the ingesters serve the read path from a dedicated gRPC server.
+func NewReadPathPool(cfg PoolConfig, ring ring.ReadRing, port int, factory ring_client.PoolFactory, logger log.Logger) *ring_client.Pool {
+	poolCfg := ring_client.PoolConfig{
+		CheckInterval:      cfg.ClientCleanupPeriod,
+		HealthCheckEnabled: cfg.HealthCheckIngesters,
+		HealthCheckTimeout: cfg.RemoteTimeout,
+	}
+
+	discovery := ring_client.NewRingServiceDiscovery(ring)
+	readPathDiscovery := func() ([]string, error) {
+		addrs, err := discovery()
+		for i, addr := range addrs {
+			addrs[i] = readPathAddr(addr, port)
+		}
+		return addrs, err
+	}
+
+	return ring_client.NewPool("ingester-read-path", poolCfg, readPathDiscovery, factory, clients, logger)
+}
+
+// readPathAddr returns the address of the ingester read path gRPC server, given the address the
+// ingester registered in the ring. If port is 0, the input address is returned.
+func readPathAddr(addr string, port int) string {
+	if port <= 0 {
+		return addr
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPathAddr(t *testing.T) {
+	tests := map[string]struct {
+		addr     string
+		port     int
+		expected string
+	}{
+		"should return the input address if the port is disabled": {
+			addr:     "10.0.0.1:9095",
+			port:     0,
+			expected: "10.0.0.1:9095",
+		},
+		"should replace the port of an IPv4 address": {
+			addr:     "10.0.0.1:9095",
+			port:     9096,
+			expected: "10.0.0.1:9096",
+		},
+		"should replace the port of an IPv6 address": {
+			addr:     "[::1]:9095",
+			port:     9096,
+			expected: "[::1]:9096",
+		},
+		"should replace the port of a hostname": {
+			addr:     "ingester-1.ingester:9095",
+			port:     9096,
+			expected: "ingester-1.ingester:9096",
+		},
+		"should return the input address if it has no port": {
+			addr:     "ingester-1.ingester",
+			port:     9096,
+			expected: "ingester-1.ingester",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, readPathAddr(testData.addr, testData.port))
+		})
+	}
+}
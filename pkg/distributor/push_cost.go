@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package distributor
+
+import (
+	"flag"
+	"math"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+var errInvalidPushCostWeight = errors.New("push cost weights must be greater than or equal to 0")
+
+// PushCostConfig configures the estimation of the ingestion cost of write requests.
+type PushCostConfig struct {
+	Enabled             bool    `yaml:"enabled" category:"experimental"`
+	SampleWeight        float64 `yaml:"sample_weight" category:"experimental"`
+	CreatedSeriesWeight float64 `yaml:"created_series_weight" category:"experimental"`
+}
+
+func (cfg *PushCostConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.push-cost.enabled", false, "True to estimate the ingestion cost of each write request, and return it to the client in the response headers of the pushes, including the failed ones.")
+	f.Float64Var(&cfg.SampleWeight, "distributor.push-cost.sample-weight", 1, "Cost units of each sample or histogram accepted by a write request.")
+	f.Float64Var(&cfg.CreatedSeriesWeight, "distributor.push-cost.created-series-weight", 100, "Cost units of each series created by a write request.")
+}
+
+func (cfg *PushCostConfig) Validate() error {
+	if cfg.SampleWeight < 0 || cfg.CreatedSeriesWeight < 0 {
+		return errInvalidPushCostWeight
+	}
+	return nil
+}
+
+// pushCostTracker accumulates the responses of the ingesters a write request has been sent to.
+type pushCostTracker struct {
+	mtx sync.Mutex
+
+	// Number of samples of each series in the write request, and whether each series has been
+	// successfully written to at least one ingester.
+	seriesSamples []uint64
+	seriesWritten []bool
+
+	// Number of series sent to the ingesters which have successfully responded, and
+	// number of series created reported by them. Each series is counted once for each replica.
+	sentSeries    uint64
+	createdSeries uint64
+}
+
+// newPushCostTracker returns a pushCostTracker for the input series. It must be called before the
+// series buffers are released.
+func newPushCostTracker(series []mimirpb.PreallocTimeseries) *pushCostTracker {
+	t := &pushCostTracker{
+		seriesSamples: make([]uint64, len(series)),
+		seriesWritten: make([]bool, len(series)),
+	}
+	for i, ts := range series {
+		t.seriesSamples[i] = uint64(len(ts.Samples) + len(ts.Histograms))
+	}
+	return t
+}
+
+// record records the successful response of an ingester to which the series and metadata at the input
+// indexes have been sent. The indexes of the metadata follow the ones of the series.
+func (t *pushCostTracker) record(indexes []int, resp *mimirpb.WriteResponse) {
+	if resp == nil {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for _, i := range indexes {
+		if i < len(t.seriesWritten) {
+			t.seriesWritten[i] = true
+			t.sentSeries++
+		}
+	}
+	t.createdSeries += resp.CreatedSeries
+}
+
+// cost returns the estimated cost of the write request.
+//
+// The accepted samples are the samples of the series successfully written to at least one ingester by the time
+// the write request returns. The ingesters rejecting a part of the samples of a write request fail it, so their
+// samples aren't counted as accepted.
+//
+// The write request returns as soon as each series has been successfully written to a quorum of ingesters,
+// so the responses of some replicas may be missing. The number of created series is estimated applying the
+// ratio of created series observed in the received responses to the accepted series.
+func (t *pushCostTracker) cost(cfg PushCostConfig) *mimirpb.WriteCost {
+	t.mtx.Lock()
+	var acceptedSeries, acceptedSamples, createdSeries uint64
+	for i, written := range t.seriesWritten {
+		if written {
+			acceptedSeries++
+			acceptedSamples += t.seriesSamples[i]
+		}
+	}
+	if t.sentSeries > 0 {
+		createdSeries = uint64(math.Round(float64(t.createdSeries) * float64(acceptedSeries) / float64(t.sentSeries)))
+	}
+	t.mtx.Unlock()
+
+	return &mimirpb.WriteCost{
+		AcceptedSamples: acceptedSamples,
+		CreatedSeries:   createdSeries,
+		Units:           float64(acceptedSamples)*cfg.SampleWeight + float64(createdSeries)*cfg.CreatedSeriesWeight,
+	}
+}
@@ -62,12 +62,18 @@ func (d *Distributor) QueryStream(ctx context.Context, from, to model.Time, matc
 			return err
 		}
 
+		// The ingesters estimate the chunks they're going to send only if the query is limited on the estimates.
+		req.EstimateChunks = limiter.QueryLimiterFromContextWithFallback(ctx).EstimatedChunksLimited()
+
+		// The querier requests the ingesters to downsample the samples of some range selectors.
+		req.Downsampling = ingester_client.DownsamplingHintFromContext(ctx)
+
 		replicationSet, err := d.GetIngesters(ctx)
 		if err != nil {
 			return err
 		}
 
-		result, err = d.queryIngesterStream(ctx, replicationSet, req)
+		result, err = d.queryIngesterStream(ctx, replicationSet, req, limiter.Selector(matchers))
 		if err != nil {
 			return err
 		}
@@ -129,7 +135,7 @@ func (d *Distributor) queryIngestersExemplars(ctx context.Context, replicationSe
 	// Fetch exemplars from multiple ingesters in parallel, using the replicationSet
 	// to deal with consistency.
 	results, err := replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
-		client, err := d.ingesterPool.GetClientFor(ing.Addr)
+		client, err := d.ingesterReadPool.GetClientFor(readPathAddr(ing.Addr, d.cfg.IngesterReadPathGRPCPort))
 		if err != nil {
 			return nil, err
 		}
@@ -178,12 +184,15 @@ func mergeExemplarQueryResponses(results []interface{}) *ingester_client.Exempla
 	return &ingester_client.ExemplarQueryResponse{Timeseries: result}
 }
 
-// queryIngesterStream queries the ingesters using the new streaming API.
-func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.QueryRequest) (*ingester_client.QueryStreamResponse, error) {
+// queryIngesterStream queries the ingesters using the new streaming API. The input selector is the
+// one of the queried matchers, and it's used to enforce the max series per selector limit.
+func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.QueryRequest, selector string) (*ingester_client.QueryStreamResponse, error) {
 	var (
-		queryLimiter = limiter.QueryLimiterFromContextWithFallback(ctx)
-		reqStats     = stats.FromContext(ctx)
-		results      = make(chan *ingester_client.QueryStreamResponse)
+		queryLimiter    = limiter.QueryLimiterFromContextWithFallback(ctx)
+		selectorLimiter = limiter.SelectorSeriesLimiterFromContextWithFallback(ctx)
+		memoryTracker   = limiter.MemoryConsumptionTrackerFromContextWithFallback(ctx)
+		reqStats        = stats.FromContext(ctx)
+		results         = make(chan *ingester_client.QueryStreamResponse)
 		// Note we can't signal goroutines to stop by closing 'results', because it has multiple concurrent senders.
 		stop        = make(chan struct{}) // Signal all background goroutines to stop.
 		doneReading = make(chan struct{}) // Signal that the reader has stopped.
@@ -245,7 +254,7 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 
 	// Fetch samples from multiple ingesters, and send them to the results chan
 	_, err := replicationSet.Do(ctx, 0, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
-		client, err := d.ingesterPool.GetClientFor(ing.Addr)
+		client, err := d.ingesterReadPool.GetClientFor(readPathAddr(ing.Addr, d.cfg.IngesterReadPathGRPCPort))
 		if err != nil {
 			return nil, err
 		}
@@ -264,6 +273,11 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 				return nil, err
 			}
 
+			// Enforce the max estimated chunks limits, before any chunk is fetched.
+			if estimateLimitErr := queryLimiter.AddEstimatedChunks(int(resp.EstimatedChunksCount), int(resp.EstimatedChunksBytes)); estimateLimitErr != nil {
+				return nil, validation.LimitError(estimateLimitErr.Error())
+			}
+
 			// Enforce the max chunks limits.
 			if chunkLimitErr := queryLimiter.AddChunks(resp.ChunksCount()); chunkLimitErr != nil {
 				return nil, validation.LimitError(chunkLimitErr.Error())
@@ -273,6 +287,9 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 				if limitErr := queryLimiter.AddSeries(series.Labels); limitErr != nil {
 					return nil, validation.LimitError(limitErr.Error())
 				}
+				if limitErr := selectorLimiter.AddSeries(selector, series.Labels); limitErr != nil {
+					return nil, validation.LimitError(limitErr.Error())
+				}
 			}
 
 			if chunkBytesLimitErr := queryLimiter.AddChunkBytes(resp.ChunksSize()); chunkBytesLimitErr != nil {
@@ -283,6 +300,14 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 				if limitErr := queryLimiter.AddSeries(series.Labels); limitErr != nil {
 					return nil, validation.LimitError(limitErr.Error())
 				}
+				if limitErr := selectorLimiter.AddSeries(selector, series.Labels); limitErr != nil {
+					return nil, validation.LimitError(limitErr.Error())
+				}
+			}
+
+			// The response is retained in memory until the query completes.
+			if err := memoryTracker.IncreaseMemoryConsumption(uint64(resp.Size())); err != nil {
+				return nil, err
 			}
 
 			// This goroutine could be left running after replicationSet.Do() returns,
@@ -21,6 +21,8 @@ import (
 	"github.com/grafana/mimir/pkg/util"
 )
 
+var errRejectedQueriesCacheWithoutResultsCache = errors.New("the rejected queries cache requires the query-frontend results cache backend to be configured")
+
 // CombinedFrontendConfig combines several configuration options together to preserve backwards compatibility.
 type CombinedFrontendConfig struct {
 	Handler    transport.HandlerConfig `yaml:",inline"`
@@ -42,22 +44,41 @@ func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet, logger log.Log
 }
 
 func (cfg *CombinedFrontendConfig) Validate(log log.Logger) error {
+	if err := cfg.Handler.Validate(); err != nil {
+		return err
+	}
 	if err := cfg.FrontendV2.Validate(log); err != nil {
 		return err
 	}
 	if err := cfg.QueryMiddleware.Validate(); err != nil {
 		return err
 	}
+
+	// The rejected queries are stored in the results cache, shared by all the query-frontends.
+	if cfg.Handler.RejectedQueriesCacheTTL > 0 {
+		if cfg.QueryMiddleware.ResultsCacheConfig.Backend == "" {
+			return errRejectedQueriesCacheWithoutResultsCache
+		}
+		if err := cfg.QueryMiddleware.ResultsCacheConfig.Validate(); err != nil {
+			return errors.Wrap(err, "invalid query-frontend results cache config")
+		}
+	}
 	return nil
 }
 
+// Limits needed for the query-frontend, either v1 or v2.
+type Limits interface {
+	v1.Limits
+	v2.Limits
+}
+
 // InitFrontend initializes frontend (either V1 -- without scheduler, or V2 -- with scheduler) or no frontend at
 // all if downstream Prometheus URL is used instead.
 //
 // Returned RoundTripper can be wrapped in more round-tripper middlewares, and then eventually registered
 // into HTTP server using the Handler from this package. Returned RoundTripper is always non-nil
 // (if there are no errors), and it uses the returned frontend (if any).
-func InitFrontend(cfg CombinedFrontendConfig, limits v1.Limits, grpcListenPort int, log log.Logger, reg prometheus.Registerer) (http.RoundTripper, *v1.Frontend, *v2.Frontend, error) {
+func InitFrontend(cfg CombinedFrontendConfig, limits Limits, grpcListenPort int, log log.Logger, reg prometheus.Registerer) (http.RoundTripper, *v1.Frontend, *v2.Frontend, error) {
 	switch {
 	case cfg.DownstreamURL != "":
 		// If the user has specified a downstream Prometheus, then we should use that.
@@ -79,7 +100,7 @@ func InitFrontend(cfg CombinedFrontendConfig, limits v1.Limits, grpcListenPort i
 			cfg.FrontendV2.Port = grpcListenPort
 		}
 
-		fr, err := v2.NewFrontend(cfg.FrontendV2, log, reg)
+		fr, err := v2.NewFrontend(cfg.FrontendV2, limits, log, reg)
 		return transport.AdaptGrpcRoundTripperToHTTPRoundTripper(fr), nil, fr, err
 
 	default:
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package frontend
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombinedFrontendConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup       func(cfg *CombinedFrontendConfig)
+		expectedErr error
+	}{
+		"default config": {
+			setup: func(cfg *CombinedFrontendConfig) {},
+		},
+		"rejected queries cache without results cache backend": {
+			setup: func(cfg *CombinedFrontendConfig) {
+				cfg.Handler.RejectedQueriesCacheTTL = time.Minute
+			},
+			expectedErr: errRejectedQueriesCacheWithoutResultsCache,
+		},
+		"rejected queries cache with results cache backend": {
+			setup: func(cfg *CombinedFrontendConfig) {
+				cfg.Handler.RejectedQueriesCacheTTL = time.Minute
+				cfg.QueryMiddleware.ResultsCacheConfig.Backend = cache.BackendMemcached
+				cfg.QueryMiddleware.ResultsCacheConfig.Memcached.Addresses = []string{"localhost:11211"}
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := CombinedFrontendConfig{}
+			cfg.RegisterFlags(flag.NewFlagSet("", flag.PanicOnError), log.NewNopLogger())
+			testData.setup(&cfg)
+
+			assert.ErrorIs(t, cfg.Validate(log.NewNopLogger()), testData.expectedErr)
+		})
+	}
+}
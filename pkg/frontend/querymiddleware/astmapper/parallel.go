@@ -29,6 +29,8 @@ var NonParallelFuncs = []string{
 	"histogram_quantile",
 	"sort_desc",
 	"sort",
+	"sort_by_label",
+	"sort_by_label_desc",
 	"time",
 	"vector",
 }
@@ -90,6 +90,11 @@ func (c *cardinalityEstimation) Do(ctx context.Context, request Request) (Respon
 		return nil, err
 	}
 
+	// The series of a planned query aren't fetched, so there's no actual cardinality to store.
+	if queryPlanFromContext(ctx) != nil {
+		return res, nil
+	}
+
 	statistics := stats.FromContext(ctx)
 	actualCardinality := statistics.GetFetchedSeriesCount()
 	spanLog.LogFields(otlog.Uint64("actual cardinality", actualCardinality))
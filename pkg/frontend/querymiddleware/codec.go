@@ -33,6 +33,7 @@ import (
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 )
@@ -41,7 +42,7 @@ var (
 	errEndBeforeStart = apierror.New(apierror.TypeBadData, `invalid parameter "end": end timestamp must not be before start time`)
 	errNegativeStep   = apierror.New(apierror.TypeBadData, `invalid parameter "step": zero or negative query resolution step widths are not accepted. Try a positive integer`)
 	errStepTooSmall   = apierror.New(apierror.TypeBadData, "exceeded maximum resolution of 11,000 points per timeseries. Try decreasing the query resolution (?step=XX)")
-	allFormats        = []string{formatJSON, formatProtobuf}
+	allFormats        = []string{formatJSON, formatProtobuf, formatChunkedJSON}
 )
 
 const (
@@ -58,8 +59,9 @@ const (
 	operationEncode = "encode"
 	operationDecode = "decode"
 
-	formatJSON     = "json"
-	formatProtobuf = "protobuf"
+	formatJSON        = "json"
+	formatProtobuf    = "protobuf"
+	formatChunkedJSON = "chunked-json"
 )
 
 // Codec is used to encode/decode query range requests and responses so they can be passed down to middlewares.
@@ -165,6 +167,7 @@ var jsonFormatterInstance = jsonFormatter{}
 var knownFormats = []formatter{
 	jsonFormatterInstance,
 	protobufFormatter{},
+	chunkedJSONFormatter{},
 }
 
 func NewPrometheusCodec(registerer prometheus.Registerer, queryResultResponseFormat string) Codec {
@@ -340,10 +343,18 @@ func (c prometheusCodec) EncodeRequest(ctx context.Context, r Request) (*http.Re
 		req.Header.Set("Accept", jsonMimeType)
 	case formatProtobuf:
 		req.Header.Set("Accept", mimirpb.QueryResponseMimeType+","+jsonMimeType)
+	case formatChunkedJSON:
+		// The queriers encode only the matrix results as chunked JSON, and the other results as JSON.
+		req.Header.Set("Accept", mimirpb.QueryResponseChunkedJSONMimeType+","+jsonMimeType)
 	default:
 		return nil, fmt.Errorf("unknown query result response format '%s'", c.preferredQueryResultResponseFormat)
 	}
 
+	// Propagate the cardinality estimate, if any, so that it can be sent to the query-scheduler.
+	if v, ok := r.GetHints().GetCardinalityEstimate().(*Hints_EstimatedSeriesCount); ok {
+		ctx = stats.ContextWithEstimatedSeriesCount(ctx, v.EstimatedSeriesCount)
+	}
+
 	return req.WithContext(ctx), nil
 }
 
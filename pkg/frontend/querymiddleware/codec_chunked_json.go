@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/common/model"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// chunkedJSONFormatter encodes and decodes the query responses as JSON, with each series of the matrix results
+// on its own line. The responses with other result types are encoded as plain JSON.
+type chunkedJSONFormatter struct{}
+
+func (f chunkedJSONFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, error) {
+	if resp.Data == nil || resp.Data.ResultType != model.ValMatrix.String() {
+		return json.Marshal(resp)
+	}
+
+	stream := json.BorrowStream(nil)
+	defer json.ReturnStream(stream)
+
+	stream.WriteObjectStart()
+	stream.WriteObjectField("status")
+	stream.WriteString(resp.Status)
+	stream.WriteMore()
+	stream.WriteObjectField("data")
+	stream.WriteObjectStart()
+	stream.WriteObjectField("resultType")
+	stream.WriteString(resp.Data.ResultType)
+	stream.WriteMore()
+	stream.WriteObjectField("result")
+	stream.WriteArrayStart()
+	for i := range resp.Data.Result {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		stream.WriteRaw("\n")
+		stream.WriteVal(&resp.Data.Result[i])
+	}
+	stream.WriteRaw("\n")
+	stream.WriteArrayEnd()
+	stream.WriteObjectEnd()
+	stream.WriteObjectEnd()
+
+	if stream.Error != nil {
+		return nil, stream.Error
+	}
+
+	// The stream buffer is reused once the stream is returned.
+	return append([]byte(nil), stream.Buffer()...), nil
+}
+
+func (f chunkedJSONFormatter) DecodeResponse(buf []byte) (*PrometheusResponse, error) {
+	resp, ok, err := decodeChunkedJSONMatrixResponse(buf)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// Only the matrix results are chunked.
+		return jsonFormatterInstance.DecodeResponse(buf)
+	}
+	return resp, nil
+}
+
+// decodeChunkedJSONMatrixResponse decodes the input response one series at a time, and returns false if the
+// response doesn't have a matrix result, or its result type follows the result.
+func decodeChunkedJSONMatrixResponse(buf []byte) (*PrometheusResponse, bool, error) {
+	iter := json.BorrowIterator(buf)
+	defer json.ReturnIterator(iter)
+
+	resp := &PrometheusResponse{}
+	isMatrix := true
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, field string) bool {
+		switch field {
+		case "status":
+			resp.Status = iter.ReadString()
+		case "errorType":
+			resp.ErrorType = iter.ReadString()
+		case "error":
+			resp.Error = iter.ReadString()
+		case "data":
+			resp.Data, isMatrix = decodeChunkedJSONMatrixData(iter, buf)
+		default:
+			iter.Skip()
+		}
+		return isMatrix
+	})
+
+	if !isMatrix {
+		return nil, false, nil
+	}
+	if iter.Error != nil {
+		return nil, false, iter.Error
+	}
+	return resp, true, nil
+}
+
+func decodeChunkedJSONMatrixData(iter *jsoniter.Iterator, buf []byte) (*PrometheusData, bool) {
+	if iter.ReadNil() {
+		return nil, true
+	}
+
+	data := &PrometheusData{}
+	isMatrix := true
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, field string) bool {
+		switch field {
+		case "resultType":
+			data.ResultType = iter.ReadString()
+			isMatrix = data.ResultType == model.ValMatrix.String()
+		case "result":
+			if data.ResultType == "" {
+				isMatrix = false
+				break
+			}
+
+			// There's a series per line, so the number of lines is an upper bound of the number of series.
+			data.Result = make([]SampleStream, 0, bytes.Count(buf, []byte("\n")))
+			iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+				data.Result = append(data.Result, SampleStream{})
+				iter.ReadVal(&data.Result[len(data.Result)-1])
+				return true
+			})
+		default:
+			iter.Skip()
+		}
+		return isMatrix
+	})
+	return data, isMatrix
+}
+
+func (f chunkedJSONFormatter) Name() string {
+	return formatChunkedJSON
+}
+
+func (f chunkedJSONFormatter) ContentType() v1.MIMEType {
+	return v1.MIMEType{Type: mimirpb.QueryResponseMimeTypeType, SubType: mimirpb.QueryResponseChunkedJSONMimeTypeSubType}
+}
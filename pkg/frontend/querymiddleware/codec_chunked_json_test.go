@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestChunkedJSONFormat_DecodeResponse(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body     string
+		expected *PrometheusResponse
+	}{
+		"matrix": {
+			body: `{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{"__name__":"foo","job":"a"},"values":[[1,"1"],[2,"2"]]},
+{"metric":{"__name__":"foo","job":"b"},"values":[[1,"3"]]}
+],"stats":{"timings":{}}},"warnings":["something happened"]}`,
+			expected: &PrometheusResponse{
+				Status: statusSuccess,
+				Data: &PrometheusData{
+					ResultType: model.ValMatrix.String(),
+					Result: []SampleStream{
+						{
+							Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "job", Value: "a"}},
+							Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}, {TimestampMs: 2000, Value: 2}},
+						},
+						{
+							Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "job", Value: "b"}},
+							Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 3}},
+						},
+					},
+				},
+			},
+		},
+		"empty matrix": {
+			body: `{"status":"success","data":{"resultType":"matrix","result":[
+]}}`,
+			expected: &PrometheusResponse{
+				Status: statusSuccess,
+				Data:   &PrometheusData{ResultType: model.ValMatrix.String(), Result: []SampleStream{}},
+			},
+		},
+		"vector": {
+			body: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"foo"},"value":[1,"1"]}]}}`,
+			expected: &PrometheusResponse{
+				Status: statusSuccess,
+				Data: &PrometheusData{
+					ResultType: model.ValVector.String(),
+					Result: []SampleStream{
+						{
+							Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}},
+							Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}},
+						},
+					},
+				},
+			},
+		},
+		"matrix with the result before the result type": {
+			body: `{"status":"success","data":{"result":[{"metric":{"__name__":"foo"},"values":[[1,"1"]]}],"resultType":"matrix"}}`,
+			expected: &PrometheusResponse{
+				Status: statusSuccess,
+				Data: &PrometheusData{
+					ResultType: model.ValMatrix.String(),
+					Result: []SampleStream{
+						{
+							Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}},
+							Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}},
+						},
+					},
+				},
+			},
+		},
+		"error": {
+			body: `{"status":"error","errorType":"execution","error":"something went wrong"}`,
+			expected: &PrometheusResponse{
+				Status:    statusError,
+				ErrorType: "execution",
+				Error:     "something went wrong",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := chunkedJSONFormatter{}.DecodeResponse([]byte(tc.body))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+
+			// The response is decoded like the JSON format does.
+			expected, err := jsonFormatter{}.DecodeResponse([]byte(tc.body))
+			require.NoError(t, err)
+			require.Equal(t, expected, actual)
+		})
+	}
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := chunkedJSONFormatter{}.DecodeResponse([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":`))
+		require.Error(t, err)
+	})
+}
+
+func TestChunkedJSONFormat_EncodeResponse(t *testing.T) {
+	resp := &PrometheusResponse{
+		Status: statusSuccess,
+		Data: &PrometheusData{
+			ResultType: model.ValMatrix.String(),
+			Result: []SampleStream{
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "job", Value: "a"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}, {TimestampMs: 2000, Value: 2}},
+				},
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "job", Value: "b"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 3}},
+				},
+			},
+		},
+	}
+
+	actual, err := chunkedJSONFormatter{}.EncodeResponse(resp)
+	require.NoError(t, err)
+
+	expected, err := jsonFormatter{}.EncodeResponse(resp)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(actual))
+
+	// Each series is encoded on its own line.
+	require.Len(t, strings.Split(string(actual), "\n"), len(resp.Data.Result)+2)
+
+	decoded, err := chunkedJSONFormatter{}.DecodeResponse(actual)
+	require.NoError(t, err)
+	require.Equal(t, resp, decoded)
+}
+
+func TestPrometheusCodec_DecodeResponse_ChunkedJSON(t *testing.T) {
+	body := []byte(`{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{"__name__":"foo"},"values":[[1,"1"]]}
+]}}`)
+
+	codec := NewPrometheusCodec(prometheus.NewPedanticRegistry(), formatChunkedJSON)
+	decoded, err := codec.DecodeResponse(context.Background(), &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{mimirpb.QueryResponseChunkedJSONMimeType}},
+		Body:          io.NopCloser(bytes.NewBuffer(body)),
+		ContentLength: int64(len(body)),
+	}, nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	require.Equal(t, &PrometheusResponse{
+		Status: statusSuccess,
+		Data: &PrometheusData{
+			ResultType: model.ValMatrix.String(),
+			Result: []SampleStream{
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}},
+				},
+			},
+		},
+		Headers: []*PrometheusResponseHeader{{Name: "Content-Type", Values: []string{mimirpb.QueryResponseChunkedJSONMimeType}}},
+	}, decoded)
+}
@@ -24,12 +24,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
 )
 
 var (
@@ -121,6 +123,8 @@ func TestPrometheusCodec_EncodeRequest_AcceptHeader(t *testing.T) {
 				require.Equal(t, "application/json", encodedRequest.Header.Get("Accept"))
 			case formatProtobuf:
 				require.Equal(t, "application/vnd.mimir.queryresponse+protobuf,application/json", encodedRequest.Header.Get("Accept"))
+			case formatChunkedJSON:
+				require.Equal(t, "application/vnd.mimir.queryresponse.chunked+json,application/json", encodedRequest.Header.Get("Accept"))
 			default:
 				t.Fatalf(fmt.Sprintf("unknown query result payload format: %v", queryResultPayloadFormat))
 			}
@@ -128,6 +132,20 @@ func TestPrometheusCodec_EncodeRequest_AcceptHeader(t *testing.T) {
 	}
 }
 
+func TestPrometheusCodec_EncodeRequest_EstimatedSeriesCount(t *testing.T) {
+	codec := NewPrometheusCodec(prometheus.NewPedanticRegistry(), formatJSON)
+
+	// The cardinality estimate is propagated through the context.
+	req := (&PrometheusInstantQueryRequest{}).WithEstimatedSeriesCountHint(1000)
+	encodedRequest, err := codec.EncodeRequest(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), stats.EstimatedSeriesCountFromContext(encodedRequest.Context()))
+
+	encodedRequest, err = codec.EncodeRequest(context.Background(), &PrometheusInstantQueryRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), stats.EstimatedSeriesCountFromContext(encodedRequest.Context()))
+}
+
 func TestPrometheusCodec_EncodeResponse_ContentNegotiation(t *testing.T) {
 	testResponse := &PrometheusResponse{
 		Status:    statusError,
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	etagHeader        = "ETag"
+	ifNoneMatchHeader = "If-None-Match"
+)
+
+type etagMetrics struct {
+	notModified prometheus.Counter
+}
+
+func newETagMetrics(registerer prometheus.Registerer) *etagMetrics {
+	return &etagMetrics{
+		notModified: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_not_modified_total",
+			Help: "Total number of queries responded with 304 Not Modified, because the If-None-Match header of the request matched the ETag of the response.",
+		}),
+	}
+}
+
+// newETagRoundTripper returns a http.RoundTripper which sets the ETag header of the successful responses, and
+// responds with 304 Not Modified and no body to the requests whose If-None-Match header matches the ETag.
+// The ETag is the hash of the tenants, the content type and the body of the response, so it changes whenever
+// the query result does.
+func newETagRoundTripper(next http.RoundTripper, metrics *etagMetrics) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		tenantIDs, err := tenant.TenantIDs(r.Context())
+		// This should never happen anyways because we have auth middleware before this.
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(r)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return resp, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		etag := responseETag(tenant.JoinTenantIDs(tenantIDs), resp.Header.Get("Content-Type"), body)
+		resp.Header.Set(etagHeader, etag)
+
+		if etagMatches(r.Header.Values(ifNoneMatchHeader), etag) {
+			metrics.notModified.Inc()
+			resp.StatusCode = http.StatusNotModified
+			resp.Header.Del("Content-Length")
+			resp.Body = io.NopCloser(bytes.NewReader(nil))
+			resp.ContentLength = 0
+			return resp, nil
+		}
+
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	})
+}
+
+func responseETag(tenantID, contentType string, body []byte) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(tenantID))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(contentType))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(body)
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches returns whether any of the If-None-Match header values matches the ETag, using the weak
+// comparison as required for If-None-Match.
+func etagMatches(ifNoneMatch []string, etag string) bool {
+	for _, value := range ifNoneMatch {
+		for _, candidate := range strings.Split(value, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestETagRoundTripper(t *testing.T) {
+	body := []byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	statusCode := http.StatusOK
+	next := RoundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    statusCode,
+			Header:        http.Header{"Content-Type": []string{jsonMimeType}, "Content-Length": []string{"63"}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+		}, nil
+	})
+
+	metrics := newETagMetrics(prometheus.NewPedanticRegistry())
+	rt := newETagRoundTripper(next, metrics)
+
+	roundTrip := func(tenantID string, ifNoneMatch ...string) (*http.Response, []byte) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), tenantID))
+		for _, v := range ifNoneMatch {
+			req.Header.Add(ifNoneMatchHeader, v)
+		}
+
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, respBody
+	}
+
+	// The first request gets the full response, with its ETag.
+	resp, respBody := roundTrip("user-1")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, body, respBody)
+	etag := resp.Header.Get(etagHeader)
+	require.NotEmpty(t, etag)
+
+	// The ETag is stable, and depends on the tenant.
+	resp, _ = roundTrip("user-1")
+	assert.Equal(t, etag, resp.Header.Get(etagHeader))
+	resp, _ = roundTrip("user-2")
+	assert.NotEqual(t, etag, resp.Header.Get(etagHeader))
+
+	// A request matching the ETag gets no body.
+	resp, respBody = roundTrip("user-1", `"other", `+etag)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, etag, resp.Header.Get(etagHeader))
+	assert.Empty(t, resp.Header.Get("Content-Length"))
+	assert.Empty(t, respBody)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.notModified))
+
+	// A request not matching the ETag gets the full response.
+	resp, respBody = roundTrip("user-1", `"other"`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, body, respBody)
+
+	// The ETag changes with the response.
+	body = []byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1,"1"]}]}}`)
+	resp, respBody = roundTrip("user-1", etag)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, body, respBody)
+	assert.NotEqual(t, etag, resp.Header.Get(etagHeader))
+
+	// The unsuccessful responses are returned as they are.
+	statusCode = http.StatusBadRequest
+	resp, respBody = roundTrip("user-1", "*")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(etagHeader))
+	assert.Equal(t, body, respBody)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.notModified))
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc"`
+
+	tests := map[string]struct {
+		ifNoneMatch []string
+		expected    bool
+	}{
+		"no header":              {},
+		"matching":               {ifNoneMatch: []string{`"abc"`}, expected: true},
+		"not matching":           {ifNoneMatch: []string{`"def"`}},
+		"matching weak":          {ifNoneMatch: []string{`W/"abc"`}, expected: true},
+		"matching in list":       {ifNoneMatch: []string{`"def", "abc"`}, expected: true},
+		"matching in any header": {ifNoneMatch: []string{`"def"`, `"abc"`}, expected: true},
+		"wildcard":               {ifNoneMatch: []string{`*`}, expected: true},
+		"not quoted":             {ifNoneMatch: []string{`abc`}},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, etagMatches(testData.ifNoneMatch, etag))
+		})
+	}
+}
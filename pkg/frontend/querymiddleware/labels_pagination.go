@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/pagination"
+)
+
+const (
+	labelNamesPathSuffix  = "/api/v1/labels"
+	labelValuesPathSuffix = "/values"
+	labelValuesPathPart   = "/api/v1/label/"
+)
+
+// newLabelsPaginationRoundTripper validates the pagination params of the label names and values
+// requests, so that the invalid requests are rejected by the query-frontend instead of being run
+// by the queriers.
+func newLabelsPaginationRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if err := validateLabelsPagination(r); err != nil {
+			return nil, apierror.New(apierror.TypeBadData, err.Error())
+		}
+		return next.RoundTrip(r)
+	})
+}
+
+// validateLabelsPagination parses the pagination params of a copy of the input request, so that the
+// body of the request forwarded to the queriers isn't consumed.
+func validateLabelsPagination(r *http.Request) error {
+	parsed := r.Clone(r.Context())
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		parsed.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if err := parsed.ParseForm(); err != nil {
+		return err
+	}
+
+	_, err := pagination.ParseRequest(parsed)
+	return err
+}
+
+func isLabelsQuery(path string) bool {
+	return strings.HasSuffix(path, labelNamesPathSuffix) ||
+		(strings.Contains(path, labelValuesPathPart) && strings.HasSuffix(path, labelValuesPathSuffix))
+}
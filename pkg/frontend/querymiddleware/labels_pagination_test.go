@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/pagination"
+)
+
+func TestLabelsPaginationRoundTripper(t *testing.T) {
+	tests := map[string]struct {
+		params      url.Values
+		expectedErr string
+	}{
+		"no pagination": {
+			params: url.Values{},
+		},
+		"valid pagination": {
+			params: url.Values{"limit": []string{"10"}, "page_token": []string{pagination.PageToken("job")}},
+		},
+		"invalid limit": {
+			params:      url.Values{"limit": []string{"0"}},
+			expectedErr: "invalid 'limit' param: must be a positive integer",
+		},
+		"page token without limit": {
+			params:      url.Values{"page_token": []string{pagination.PageToken("job")}},
+			expectedErr: "the 'page_token' param requires the 'limit' param",
+		},
+	}
+
+	for name, testData := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			t.Run(name+" "+method, func(t *testing.T) {
+				var req *http.Request
+				if method == http.MethodGet {
+					req = httptest.NewRequest(method, "/prometheus/api/v1/labels?"+testData.params.Encode(), nil)
+				} else {
+					req = httptest.NewRequest(method, "/prometheus/api/v1/labels", strings.NewReader(testData.params.Encode()))
+					req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				}
+
+				var forwarded *http.Request
+				rt := newLabelsPaginationRoundTripper(RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+					forwarded = r
+					return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+				}))
+
+				_, err := rt.RoundTrip(req)
+				if testData.expectedErr != "" {
+					require.Error(t, err)
+					assert.True(t, apierror.IsAPIError(err))
+					assert.Contains(t, err.Error(), testData.expectedErr)
+					assert.Nil(t, forwarded)
+					return
+				}
+
+				require.NoError(t, err)
+				require.NotNil(t, forwarded)
+
+				// The request forwarded downstream must keep its params.
+				require.NoError(t, forwarded.ParseForm())
+				for key := range testData.params {
+					assert.Equal(t, testData.params.Get(key), forwarded.Form.Get(key))
+				}
+			})
+		}
+	}
+}
+
+func TestIsLabelsQuery(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"/prometheus/api/v1/labels":                   true,
+		"/prometheus/api/v1/label/job/values":         true,
+		"/prometheus/api/v1/label/__name__/values":    true,
+		"/prometheus/api/v1/query":                    false,
+		"/prometheus/api/v1/series":                   false,
+		"/prometheus/api/v1/cardinality/label_values": false,
+	} {
+		assert.Equal(t, expected, isLabelsQuery(path), path)
+	}
+}
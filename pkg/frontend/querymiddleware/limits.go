@@ -14,13 +14,16 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/model/timestamp"
 	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/dskit/tenant"
 
 	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -62,6 +65,9 @@ type Limits interface {
 	// SplitInstantQueriesByInterval returns the time interval to split instant queries for a given tenant.
 	SplitInstantQueriesByInterval(userID string) time.Duration
 
+	// MinQueryStep returns the minimum step of the range queries for a given tenant.
+	MinQueryStep(userID string) time.Duration
+
 	// CompactorSplitAndMergeShards returns the number of shards to use when splitting blocks
 	// This method is copied from compactor.ConfigProvider.
 	CompactorSplitAndMergeShards(userID string) int
@@ -85,6 +91,13 @@ type Limits interface {
 
 	// ResultsCacheForOutOfOrderWindowTTL returns TTL for cached results for query that falls into out-of-order ingestion window.
 	ResultsCacheTTLForOutOfOrderTimeWindow(userID string) time.Duration
+
+	// QueryResultRelabelConfigs returns the relabel configs applied to the series of the query results of a given tenant.
+	QueryResultRelabelConfigs(userID string) []*relabel.Config
+
+	// QueryResultRoundValuesDecimalPlaces returns the number of decimal places the values of the query results
+	// of a given tenant are rounded to. A negative value means the values aren't rounded.
+	QueryResultRoundValuesDecimalPlaces(userID string) int
 }
 
 type limitsMiddleware struct {
@@ -162,10 +175,26 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 		}
 	}
 
+	// Enforce the min step of range queries.
+	if rangeReq, ok := r.(*PrometheusRangeQueryRequest); ok {
+		minStep := validation.LargestPositiveNonZeroDurationPerTenant(tenantIDs, l.MinQueryStep)
+		if minStep > 0 && rangeReq.GetStep() < minStep.Milliseconds() {
+			// Replace the step in the request.
+			level.Debug(log).Log(
+				"msg", "the step of the query has been manipulated because of the 'min query step' setting",
+				"original", time.Duration(rangeReq.GetStep())*time.Millisecond,
+				"updated", minStep,
+				"minQueryStep", minStep)
+
+			r = rangeReq.WithStep(minStep.Milliseconds())
+		}
+	}
+
 	// Enforce max query size, in bytes.
 	if maxQuerySize := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, l.MaxQueryExpressionSizeBytes); maxQuerySize > 0 {
 		querySize := len(r.GetQuery())
 		if querySize > maxQuerySize {
+			recordRejection(ctx, globalerror.MaxQueryExpressionSizeBytes, float64(querySize), float64(maxQuerySize))
 			return nil, apierror.New(apierror.TypeBadData, validation.NewMaxQueryExpressionSizeBytesError(querySize, maxQuerySize).Error())
 		}
 	}
@@ -174,6 +203,7 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 	if maxQueryLength := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.MaxTotalQueryLength); maxQueryLength > 0 {
 		queryLen := timestamp.Time(r.GetEnd()).Sub(timestamp.Time(r.GetStart()))
 		if queryLen > maxQueryLength {
+			recordRejection(ctx, globalerror.MaxTotalQueryLength, queryLen.Seconds(), maxQueryLength.Seconds())
 			return nil, apierror.New(apierror.TypeBadData, validation.NewMaxTotalQueryLengthError(queryLen, maxQueryLength).Error())
 		}
 	}
@@ -181,6 +211,16 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 	return l.next.Do(ctx, r)
 }
 
+// recordRejection records the limit which rejected the query in the query stats, if enabled.
+func recordRejection(ctx context.Context, limit globalerror.ID, measured, configured float64) {
+	stats.FromContext(ctx).RecordRejection(&stats.Rejection{
+		Limit:           string(limit),
+		Component:       "query-frontend",
+		MeasuredValue:   measured,
+		ConfiguredLimit: configured,
+	})
+}
+
 type limitedParallelismRoundTripper struct {
 	downstream Handler
 	limits     Limits
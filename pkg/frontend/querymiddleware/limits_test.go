@@ -16,12 +16,14 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util"
 )
 
@@ -185,16 +187,18 @@ func TestLimitsMiddleware_MaxQueryExpressionSizeBytes(t *testing.T) {
 			inner := &mockHandler{}
 			inner.On("Do", mock.Anything, mock.Anything).Return(innerRes, nil)
 
-			ctx := user.InjectOrgID(context.Background(), "test1|test2")
+			queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "test1|test2"))
 			outer := middleware.Wrap(inner)
 			res, err := outer.Do(ctx, req)
 
 			if testData.expectError {
 				require.Error(t, err)
 				require.Contains(t, err.Error(), "err-mimir-max-query-expression-size-bytes")
+				assert.Equal(t, &stats.Rejection{Limit: "max-query-expression-size-bytes", Component: "query-frontend", MeasuredValue: float64(len(testData.query)), ConfiguredLimit: 100}, queryStats.LoadRejection())
 			} else {
 				require.NoError(t, err)
 				require.Same(t, innerRes, res)
+				assert.Nil(t, queryStats.LoadRejection())
 			}
 		})
 	}
@@ -351,6 +355,64 @@ func TestLimitsMiddleware_CreationGracePeriod(t *testing.T) {
 	}
 }
 
+func TestLimitsMiddleware_MinQueryStep(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		req          Request
+		minQueryStep time.Duration
+		expectedStep int64
+	}{
+		"should not manipulate the step if min query step is disabled": {
+			req:          &PrometheusRangeQueryRequest{Step: 15000},
+			minQueryStep: 0,
+			expectedStep: 15000,
+		},
+		"should not manipulate the step of a query with a step greater than min query step": {
+			req:          &PrometheusRangeQueryRequest{Step: 600000},
+			minQueryStep: 5 * time.Minute,
+			expectedStep: 600000,
+		},
+		"should manipulate the step of a query with a step lower than min query step": {
+			req:          &PrometheusRangeQueryRequest{Step: 15000},
+			minQueryStep: 5 * time.Minute,
+			expectedStep: 300000,
+		},
+		"should not manipulate instant queries": {
+			req:          &PrometheusInstantQueryRequest{},
+			minQueryStep: 5 * time.Minute,
+			expectedStep: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := testData.req.WithStartEnd(util.TimeToMillis(now.Add(-time.Hour)), util.TimeToMillis(now))
+
+			limits := mockLimits{minQueryStep: testData.minQueryStep}
+			middleware := newLimitsMiddleware(limits, log.NewNopLogger())
+
+			innerRes := newEmptyPrometheusResponse()
+			inner := &mockHandler{}
+			inner.On("Do", mock.Anything, mock.Anything).Return(innerRes, nil)
+
+			ctx := user.InjectOrgID(context.Background(), "test")
+			outer := middleware.Wrap(inner)
+			res, err := outer.Do(ctx, req)
+			require.NoError(t, err)
+
+			// We expect the response returned by the inner handler.
+			assert.Same(t, innerRes, res)
+
+			// Assert on the step of the request passed to the inner handler.
+			require.Len(t, inner.Calls, 1)
+			assert.Equal(t, testData.expectedStep, inner.Calls[0].Arguments.Get(1).(Request).GetStep())
+			assert.Equal(t, req.GetStart(), inner.Calls[0].Arguments.Get(1).(Request).GetStart())
+			assert.Equal(t, req.GetEnd(), inner.Calls[0].Arguments.Get(1).(Request).GetEnd())
+		})
+	}
+}
+
 type multiTenantMockLimits struct {
 	byTenant map[string]mockLimits
 }
@@ -395,6 +457,10 @@ func (m multiTenantMockLimits) SplitInstantQueriesByInterval(userID string) time
 	return m.byTenant[userID].splitInstantQueriesInterval
 }
 
+func (m multiTenantMockLimits) MinQueryStep(userID string) time.Duration {
+	return m.byTenant[userID].minQueryStep
+}
+
 func (m multiTenantMockLimits) CompactorSplitAndMergeShards(userID string) int {
 	return m.byTenant[userID].compactorShards
 }
@@ -423,6 +489,14 @@ func (m multiTenantMockLimits) NativeHistogramsIngestionEnabled(userID string) b
 	return m.byTenant[userID].nativeHistogramsIngestionEnabled
 }
 
+func (m multiTenantMockLimits) QueryResultRelabelConfigs(userID string) []*relabel.Config {
+	return m.byTenant[userID].queryResultRelabelConfigs
+}
+
+func (m multiTenantMockLimits) QueryResultRoundValuesDecimalPlaces(userID string) int {
+	return m.byTenant[userID].QueryResultRoundValuesDecimalPlaces(userID)
+}
+
 type mockLimits struct {
 	maxQueryLookback                 time.Duration
 	maxQueryLength                   time.Duration
@@ -433,6 +507,7 @@ type mockLimits struct {
 	maxShardedQueries                int
 	maxRegexpSizeBytes               int
 	splitInstantQueriesInterval      time.Duration
+	minQueryStep                     time.Duration
 	totalShards                      int
 	compactorShards                  int
 	compactorBlocksRetentionPeriod   time.Duration
@@ -441,6 +516,8 @@ type mockLimits struct {
 	nativeHistogramsIngestionEnabled bool
 	resultsCacheTTL                  time.Duration
 	resultsCacheOutOfOrderWindowTTL  time.Duration
+	queryResultRelabelConfigs        []*relabel.Config
+	queryResultRoundValuesDecimals   *int
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -489,6 +566,10 @@ func (m mockLimits) SplitInstantQueriesByInterval(string) time.Duration {
 	return m.splitInstantQueriesInterval
 }
 
+func (m mockLimits) MinQueryStep(string) time.Duration {
+	return m.minQueryStep
+}
+
 func (m mockLimits) CompactorSplitAndMergeShards(userID string) int {
 	return m.compactorShards
 }
@@ -517,6 +598,17 @@ func (m mockLimits) NativeHistogramsIngestionEnabled(userID string) bool {
 	return m.nativeHistogramsIngestionEnabled
 }
 
+func (m mockLimits) QueryResultRelabelConfigs(string) []*relabel.Config {
+	return m.queryResultRelabelConfigs
+}
+
+func (m mockLimits) QueryResultRoundValuesDecimalPlaces(string) int {
+	if m.queryResultRoundValuesDecimals == nil {
+		return -1 // Flag default.
+	}
+	return *m.queryResultRoundValuesDecimals
+}
+
 type mockHandler struct {
 	mock.Mock
 }
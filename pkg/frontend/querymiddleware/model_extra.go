@@ -61,6 +61,13 @@ func (q *PrometheusRangeQueryRequest) WithQuery(query string) Request {
 	return &new
 }
 
+// WithStep clones the current `PrometheusRangeQueryRequest` with a new step.
+func (q *PrometheusRangeQueryRequest) WithStep(step int64) Request {
+	new := *q
+	new.Step = step
+	return &new
+}
+
 // WithTotalQueriesHint clones the current `PrometheusRangeQueryRequest` with an
 // added Hint value for TotalQueries.
 func (q *PrometheusRangeQueryRequest) WithTotalQueriesHint(totalQueries int32) Request {
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+)
+
+const queryPlanPathSuffix = "/query_plan"
+
+type queryPlanContextKey int
+
+const queryPlanCtxKey = queryPlanContextKey(0)
+
+// queryPlan collects the rewrites of a query and the requests sent downstream to the queriers, while the query
+// is planned by the query middlewares. All methods can be called on a nil *queryPlan.
+type queryPlan struct {
+	mtx        sync.Mutex
+	rewrites   []queryPlanRewrite
+	downstream []queryPlanRequest
+}
+
+type queryPlanRewrite struct {
+	// Middleware which rewrote the query.
+	Middleware     string `json:"middleware"`
+	Query          string `json:"query"`
+	RewrittenQuery string `json:"rewrittenQuery"`
+}
+
+type queryPlanRequest struct {
+	Query string `json:"query"`
+	// Start, end and step in milliseconds.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Step  int64 `json:"step"`
+	// Matchers of each selector of the query.
+	Matchers []string `json:"matchers"`
+}
+
+type queryPlanData struct {
+	Query              string             `json:"query"`
+	Rewrites           []queryPlanRewrite `json:"rewrites"`
+	DownstreamRequests []queryPlanRequest `json:"downstreamRequests"`
+}
+
+type queryPlanResponse struct {
+	Status string         `json:"status"`
+	Data   *queryPlanData `json:"data"`
+}
+
+func contextWithQueryPlan(ctx context.Context, plan *queryPlan) context.Context {
+	return context.WithValue(ctx, queryPlanCtxKey, plan)
+}
+
+// queryPlanFromContext returns the query plan collected for the query being planned, or nil if the query
+// is executed.
+func queryPlanFromContext(ctx context.Context) *queryPlan {
+	plan, _ := ctx.Value(queryPlanCtxKey).(*queryPlan)
+	return plan
+}
+
+func (p *queryPlan) addRewrite(middleware, query, rewrittenQuery string) {
+	if p == nil {
+		return
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.rewrites = append(p.rewrites, queryPlanRewrite{Middleware: middleware, Query: query, RewrittenQuery: rewrittenQuery})
+}
+
+func (p *queryPlan) addDownstreamRequest(r Request) {
+	if p == nil {
+		return
+	}
+
+	req := queryPlanRequest{
+		Query:    r.GetQuery(),
+		Start:    r.GetStart(),
+		End:      r.GetEnd(),
+		Step:     r.GetStep(),
+		Matchers: []string{},
+	}
+	if expr, err := parser.ParseExpr(r.GetQuery()); err == nil {
+		for _, selector := range parser.ExtractSelectors(expr) {
+			req.Matchers = append(req.Matchers, matchersString(selector))
+		}
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.downstream = append(p.downstream, req)
+}
+
+// data returns the collected plan. The downstream requests are sent concurrently, so they're sorted to
+// return a stable plan.
+func (p *queryPlan) data(query string) *queryPlanData {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	downstream := append([]queryPlanRequest{}, p.downstream...)
+	sort.Slice(downstream, func(i, j int) bool {
+		if downstream[i].Start != downstream[j].Start {
+			return downstream[i].Start < downstream[j].Start
+		}
+		return downstream[i].Query < downstream[j].Query
+	})
+
+	return &queryPlanData{
+		Query:              query,
+		Rewrites:           append([]queryPlanRewrite{}, p.rewrites...),
+		DownstreamRequests: downstream,
+	}
+}
+
+func matchersString(matchers []*labels.Matcher) string {
+	parts := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		parts = append(parts, m.String())
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// queryPlanHandler is the downstream Handler of the query middlewares when planning a query: it records the
+// requests which would be sent to the queriers, and returns an empty response without running them.
+type queryPlanHandler struct{}
+
+func (queryPlanHandler) Do(ctx context.Context, r Request) (Response, error) {
+	queryPlanFromContext(ctx).addDownstreamRequest(r)
+	return newEmptyPrometheusResponse(), nil
+}
+
+// newQueryPlanRoundTripper returns a http.RoundTripper running the query of a query plan request through
+// the range or instant query middlewares, according to its parameters, and returning the rewrites of the
+// query and the requests that would be sent to the queriers. The query is not executed, and its results
+// are neither fetched from nor stored to the results cache.
+func newQueryPlanRoundTripper(codec Codec, limits Limits, queryRangeMiddleware, queryInstantMiddleware []Middleware) http.RoundTripper {
+	queryrange := limitedParallelismRoundTripper{
+		downstream: queryPlanHandler{},
+		codec:      codec,
+		limits:     limits,
+		middleware: MergeMiddlewares(queryRangeMiddleware...),
+	}
+	instant := defaultInstantQueryParamsRoundTripper(limitedParallelismRoundTripper{
+		downstream: queryPlanHandler{},
+		codec:      codec,
+		limits:     limits,
+		middleware: MergeMiddlewares(queryInstantMiddleware...),
+	})
+
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if err := r.ParseForm(); err != nil {
+			return nil, apierror.New(apierror.TypeBadData, err.Error())
+		}
+
+		query := r.Form.Get("query")
+		if query == "" {
+			return nil, apierror.New(apierror.TypeBadData, "missing query parameter")
+		}
+
+		// A query with a step is planned as a range query, otherwise as an instant query.
+		next := instant
+		pathSuffix := instantQueryPathSuffix
+		if r.Form.Get("step") != "" {
+			next = queryrange
+			pathSuffix = queryRangePathSuffix
+		}
+
+		plan := &queryPlan{}
+		planned := r.Clone(contextWithQueryPlan(r.Context(), plan))
+		planned.Method = http.MethodGet
+		planned.URL.Path = strings.TrimSuffix(r.URL.Path, queryPlanPathSuffix) + pathSuffix
+		planned.URL.RawQuery = r.Form.Encode()
+		planned.Body = http.NoBody
+		planned.Form = nil
+		planned.PostForm = nil
+		if planned.Header == nil {
+			planned.Header = http.Header{}
+		}
+		planned.Header.Set(cacheControlHeader, noStoreValue)
+
+		if _, err := next.RoundTrip(planned); err != nil {
+			return nil, err
+		}
+
+		body, err := json.Marshal(queryPlanResponse{Status: statusSuccess, Data: plan.data(query)})
+		if err != nil {
+			return nil, apierror.New(apierror.TypeInternal, err.Error())
+		}
+
+		return &http.Response{
+			Header: http.Header{
+				"Content-Type": []string{"application/json"},
+			},
+			Body:          io.NopCloser(bytes.NewBuffer(body)),
+			StatusCode:    http.StatusOK,
+			ContentLength: int64(len(body)),
+		}, nil
+	})
+}
+
+func isQueryPlan(path string) bool {
+	return strings.HasSuffix(path, queryPlanPathSuffix)
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestTripperware_QueryPlan(t *testing.T) {
+	const totalShards = 2
+
+	tw, err := NewTripperware(
+		Config{
+			ShardedQueries:         true,
+			SplitQueriesByInterval: 24 * time.Hour,
+		},
+		log.NewNopLogger(),
+		mockLimits{totalShards: totalShards, splitInstantQueriesInterval: time.Hour},
+		newTestPrometheusCodec(),
+		nil,
+		nil,
+		promql.EngineOpts{
+			Logger:     log.NewNopLogger(),
+			MaxSamples: 1000,
+			Timeout:    time.Minute,
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	// The queriers are never called to plan a query.
+	tripper := tw(RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		require.Fail(t, "unexpected request to the queriers", r.URL.String())
+		return nil, nil
+	}))
+
+	plan := func(t *testing.T, params url.Values) (*http.Response, queryPlanResponse) {
+		req, err := http.NewRequest(http.MethodPost, "/api/v1/query_plan", strings.NewReader(params.Encode()))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+		resp, err := tripper.RoundTrip(req)
+		require.NoError(t, err)
+
+		var res queryPlanResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+		return resp, res
+	}
+
+	t.Run("range query", func(t *testing.T) {
+		resp, res := plan(t, url.Values{
+			"query": []string{`sum(rate(metric{job="test"}[5m]))`},
+			"start": []string{"0"},
+			"end":   []string{"172800"},
+			"step":  []string{"60"},
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, statusSuccess, res.Status)
+		assert.Equal(t, `sum(rate(metric{job="test"}[5m]))`, res.Data.Query)
+
+		// The query is split by day, and each split query is sharded.
+		require.Len(t, res.Data.Rewrites, 2)
+		for _, rewrite := range res.Data.Rewrites {
+			assert.Equal(t, "querysharding", rewrite.Middleware)
+			assert.Contains(t, rewrite.RewrittenQuery, "__embedded_queries__")
+		}
+
+		require.Len(t, res.Data.DownstreamRequests, 2*totalShards)
+		for _, req := range res.Data.DownstreamRequests {
+			assert.Equal(t, int64(60000), req.Step)
+			require.Len(t, req.Matchers, 1)
+			assert.Contains(t, req.Matchers[0], `job="test"`)
+			assert.Contains(t, req.Matchers[0], `__query_shard__="`)
+		}
+		assert.Equal(t, int64(0), res.Data.DownstreamRequests[0].Start)
+	})
+
+	t.Run("instant query", func(t *testing.T) {
+		resp, res := plan(t, url.Values{
+			"query": []string{`sum(rate(metric[2h]))`},
+			"time":  []string{"7200"},
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// The query is split by interval, and then each split query is sharded.
+		require.Len(t, res.Data.Rewrites, 3)
+		assert.Equal(t, "split_instant_query_by_interval", res.Data.Rewrites[0].Middleware)
+		assert.Equal(t, "querysharding", res.Data.Rewrites[1].Middleware)
+		assert.Equal(t, "querysharding", res.Data.Rewrites[2].Middleware)
+
+		require.Len(t, res.Data.DownstreamRequests, 2*totalShards)
+		for _, req := range res.Data.DownstreamRequests {
+			assert.Equal(t, int64(7200000), req.Start)
+			assert.Equal(t, int64(7200000), req.End)
+		}
+	})
+
+	t.Run("missing query", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/api/v1/query_plan", http.NoBody)
+		require.NoError(t, err)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+		_, err = tripper.RoundTrip(req)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing query parameter")
+	})
+}
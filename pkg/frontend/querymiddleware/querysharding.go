@@ -135,6 +135,7 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 	}
 
 	level.Debug(log).Log("msg", "query has been rewritten into a shardable query", "original", r.GetQuery(), "rewritten", shardedQuery, "sharded_queries", shardingStats.GetShardedQueries())
+	queryPlanFromContext(ctx).addRewrite("querysharding", r.GetQuery(), shardedQuery)
 
 	// Update metrics.
 	s.shardingSuccesses.Inc()
@@ -145,6 +146,12 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 	queryStats := stats.FromContext(ctx)
 	queryStats.AddShardedQueries(uint32(shardingStats.GetShardedQueries()))
 
+	// Each sharded query touches a fraction of the series touched by the whole query, so the cardinality
+	// estimate (if any) is split between them.
+	if v, ok := r.GetHints().GetCardinalityEstimate().(*Hints_EstimatedSeriesCount); ok {
+		r = r.WithEstimatedSeriesCountHint(v.EstimatedSeriesCount / uint64(shardingStats.GetShardedQueries()))
+	}
+
 	r = r.WithQuery(shardedQuery)
 	shardedQueryable := newShardedQueryable(r, s.next)
 
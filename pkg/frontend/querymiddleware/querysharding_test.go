@@ -1468,24 +1468,28 @@ func TestQuerySharding_ShouldUseCardinalityEstimate(t *testing.T) {
 	}
 
 	tests := []struct {
-		name          string
-		req           Request
-		expectedCalls int
+		name                        string
+		req                         Request
+		expectedCalls               int
+		expectedShardEstimatedCount uint64
 	}{
 		{
 			"range query",
 			req.WithStartEnd(util.TimeToMillis(start), util.TimeToMillis(end)).WithEstimatedSeriesCountHint(55_000),
 			6,
+			9_166,
 		},
 		{
 			"instant query",
 			req.WithEstimatedSeriesCountHint(29_000),
 			3,
+			9_666,
 		},
 		{
 			"no hints",
 			req,
 			16,
+			0,
 		},
 	}
 
@@ -1507,6 +1511,11 @@ func TestQuerySharding_ShouldUseCardinalityEstimate(t *testing.T) {
 			assert.Equal(t, statusSuccess, res.(*PrometheusResponse).GetStatus())
 			downstream.AssertCalled(t, "Do", mock.Anything, mock.Anything)
 			downstream.AssertNumberOfCalls(t, "Do", tt.expectedCalls)
+
+			// The cardinality estimate is split between the sharded queries.
+			for _, call := range downstream.Calls {
+				assert.Equal(t, tt.expectedShardEstimatedCount, call.Arguments.Get(1).(Request).GetHints().GetEstimatedSeriesCount())
+			}
 		})
 	}
 
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	resultTransformationRelabel     = "relabel"
+	resultTransformationRoundValues = "round_values"
+)
+
+// resultTransformer transforms the data of the query results of some tenants.
+type resultTransformer interface {
+	// name returns the name of the transformation, used to label its metrics.
+	name() string
+
+	// transform returns the transformed copy of the input data, and the number of series dropped from it.
+	// The input data must not be modified. It returns false if there's nothing to transform for the tenants.
+	transform(tenantIDs []string, data *PrometheusData) (transformed *PrometheusData, droppedSeries int, ok bool)
+}
+
+// resultTransformationMetrics holds the metrics tracked by the result transformation middlewares.
+type resultTransformationMetrics struct {
+	transformedResults *prometheus.CounterVec
+	droppedSeries      *prometheus.CounterVec
+}
+
+func newResultTransformationMetrics(registerer prometheus.Registerer) *resultTransformationMetrics {
+	return &resultTransformationMetrics{
+		transformedResults: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_result_transformations_total",
+			Help: "Total number of query results transformed before being returned to the tenant.",
+		}, []string{"transformation"}),
+		droppedSeries: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_result_transformation_dropped_series_total",
+			Help: "Total number of series dropped from the query results by the transformations.",
+		}, []string{"transformation"}),
+	}
+}
+
+type resultTransformationMiddleware struct {
+	next        Handler
+	transformer resultTransformer
+	logger      log.Logger
+
+	transformedResults prometheus.Counter
+	droppedSeries      prometheus.Counter
+}
+
+// newResultTransformationMiddleware makes a new Middleware applying the input transformation to the successful
+// query results. The transformation middlewares can be chained, each one transforming the result of the next one.
+func newResultTransformationMiddleware(transformer resultTransformer, metrics *resultTransformationMetrics, logger log.Logger) Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &resultTransformationMiddleware{
+			next:               next,
+			transformer:        transformer,
+			logger:             logger,
+			transformedResults: metrics.transformedResults.WithLabelValues(transformer.name()),
+			droppedSeries:      metrics.droppedSeries.WithLabelValues(transformer.name()),
+		}
+	})
+}
+
+func (m *resultTransformationMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	resp, err := m.next.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	promResp, ok := resp.(*PrometheusResponse)
+	if !ok || promResp.Status != statusSuccess || promResp.Data == nil {
+		return resp, nil
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, droppedSeries, ok := m.transformer.transform(tenantIDs, promResp.Data)
+	if !ok {
+		return resp, nil
+	}
+
+	m.transformedResults.Inc()
+	m.droppedSeries.Add(float64(droppedSeries))
+	if droppedSeries > 0 {
+		level.Debug(m.logger).Log("msg", "dropped series from the query result", "transformation", m.transformer.name(), "dropped_series", droppedSeries)
+	}
+
+	transformed := *promResp
+	transformed.Data = data
+	return &transformed, nil
+}
+
+// newResultRelabelMiddleware makes a new Middleware applying the per-tenant relabel configs
+// to the series of the vector and matrix query results.
+func newResultRelabelMiddleware(limits Limits, metrics *resultTransformationMetrics, logger log.Logger) Middleware {
+	return newResultTransformationMiddleware(resultRelabeler{limits: limits}, metrics, logger)
+}
+
+type resultRelabeler struct {
+	limits Limits
+}
+
+func (r resultRelabeler) name() string {
+	return resultTransformationRelabel
+}
+
+func (r resultRelabeler) transform(tenantIDs []string, data *PrometheusData) (*PrometheusData, int, bool) {
+	if data.ResultType != model.ValVector.String() && data.ResultType != model.ValMatrix.String() {
+		return nil, 0, false
+	}
+
+	// The relabel configs of all the tenants of a cross-tenant query are applied, in order.
+	var cfgs []*relabel.Config
+	for _, tenantID := range tenantIDs {
+		cfgs = append(cfgs, r.limits.QueryResultRelabelConfigs(tenantID)...)
+	}
+	if len(cfgs) == 0 {
+		return nil, 0, false
+	}
+
+	transformed := &PrometheusData{ResultType: data.ResultType, Result: make([]SampleStream, 0, len(data.Result))}
+	for _, series := range data.Result {
+		// The labels are copied because relabeling can modify them in place.
+		lbls, keep := relabel.Process(mimirpb.FromLabelAdaptersToLabels(series.Labels).Copy(), cfgs...)
+		if !keep {
+			continue
+		}
+
+		transformed.Result = append(transformed.Result, SampleStream{
+			Labels:     mimirpb.FromLabelsToLabelAdapters(lbls),
+			Samples:    series.Samples,
+			Histograms: series.Histograms,
+		})
+	}
+	return transformed, len(data.Result) - len(transformed.Result), true
+}
+
+// newResultRoundValuesMiddleware makes a new Middleware rounding the float values of the scalar, vector
+// and matrix query results to the per-tenant number of decimal places.
+func newResultRoundValuesMiddleware(limits Limits, metrics *resultTransformationMetrics, logger log.Logger) Middleware {
+	return newResultTransformationMiddleware(resultValuesRounder{limits: limits}, metrics, logger)
+}
+
+type resultValuesRounder struct {
+	limits Limits
+}
+
+func (r resultValuesRounder) name() string {
+	return resultTransformationRoundValues
+}
+
+func (r resultValuesRounder) transform(tenantIDs []string, data *PrometheusData) (*PrometheusData, int, bool) {
+	if data.ResultType == model.ValString.String() {
+		return nil, 0, false
+	}
+
+	// The values of a cross-tenant query are rounded to the lowest number of decimal places of its tenants.
+	decimalPlaces := -1
+	for _, tenantID := range tenantIDs {
+		if d := r.limits.QueryResultRoundValuesDecimalPlaces(tenantID); d >= 0 && (decimalPlaces < 0 || d < decimalPlaces) {
+			decimalPlaces = d
+		}
+	}
+	if decimalPlaces < 0 {
+		return nil, 0, false
+	}
+
+	factor := math.Pow10(decimalPlaces)
+	transformed := &PrometheusData{ResultType: data.ResultType, Result: make([]SampleStream, 0, len(data.Result))}
+	for _, series := range data.Result {
+		samples := make([]mimirpb.Sample, 0, len(series.Samples))
+		for _, s := range series.Samples {
+			samples = append(samples, mimirpb.Sample{TimestampMs: s.TimestampMs, Value: roundValue(s.Value, factor)})
+		}
+
+		transformed.Result = append(transformed.Result, SampleStream{
+			Labels:     series.Labels,
+			Samples:    samples,
+			Histograms: series.Histograms,
+		})
+	}
+	return transformed, 0, true
+}
+
+// roundValue rounds the input value to the decimal places of the input factor (a power of 10).
+// The values which can't be rounded without overflowing are returned as is.
+func roundValue(v, factor float64) float64 {
+	rounded := math.Round(v*factor) / factor
+	if math.IsInf(rounded, 0) || math.IsNaN(rounded) {
+		return v
+	}
+	return rounded
+}
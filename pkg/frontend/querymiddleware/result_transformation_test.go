@@ -0,0 +1,240 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestResultTransformationMiddlewares(t *testing.T) {
+	dropJobLabel := &relabel.Config{Action: relabel.LabelDrop, Regex: relabel.MustNewRegexp("job")}
+	dropSeriesB := &relabel.Config{
+		Action:       relabel.Drop,
+		SourceLabels: model.LabelNames{"instance"},
+		Regex:        relabel.MustNewRegexp("b"),
+	}
+	twoDecimals := 2
+	zeroDecimals := 0
+
+	newResponse := func() *PrometheusResponse {
+		return &PrometheusResponse{
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: model.ValMatrix.String(),
+				Result: []SampleStream{
+					{
+						Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "instance", Value: "a"}, {Name: "job", Value: "x"}},
+						Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1.2345}, {TimestampMs: 2000, Value: math.NaN()}},
+					},
+					{
+						Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "instance", Value: "b"}, {Name: "job", Value: "x"}},
+						Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 5.6789}},
+					},
+				},
+			},
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		tenantIDs             []string
+		limits                map[string]mockLimits
+		expectedResult        []SampleStream
+		expectedMetrics       string
+		expectedUntransformed bool
+	}{
+		"no transformation configured": {
+			tenantIDs:             []string{"tenant-1"},
+			limits:                map[string]mockLimits{"tenant-1": {}},
+			expectedUntransformed: true,
+			expectedMetrics: `
+				# HELP cortex_frontend_query_result_transformation_dropped_series_total Total number of series dropped from the query results by the transformations.
+				# TYPE cortex_frontend_query_result_transformation_dropped_series_total counter
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="relabel"} 0
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="round_values"} 0
+				# HELP cortex_frontend_query_result_transformations_total Total number of query results transformed before being returned to the tenant.
+				# TYPE cortex_frontend_query_result_transformations_total counter
+				cortex_frontend_query_result_transformations_total{transformation="relabel"} 0
+				cortex_frontend_query_result_transformations_total{transformation="round_values"} 0
+			`,
+		},
+		"relabeling": {
+			tenantIDs: []string{"tenant-1"},
+			limits: map[string]mockLimits{
+				"tenant-1": {queryResultRelabelConfigs: []*relabel.Config{dropJobLabel, dropSeriesB}},
+			},
+			expectedResult: []SampleStream{
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "instance", Value: "a"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1.2345}, {TimestampMs: 2000, Value: math.NaN()}},
+				},
+			},
+			expectedMetrics: `
+				# HELP cortex_frontend_query_result_transformation_dropped_series_total Total number of series dropped from the query results by the transformations.
+				# TYPE cortex_frontend_query_result_transformation_dropped_series_total counter
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="relabel"} 1
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="round_values"} 0
+				# HELP cortex_frontend_query_result_transformations_total Total number of query results transformed before being returned to the tenant.
+				# TYPE cortex_frontend_query_result_transformations_total counter
+				cortex_frontend_query_result_transformations_total{transformation="relabel"} 1
+				cortex_frontend_query_result_transformations_total{transformation="round_values"} 0
+			`,
+		},
+		"rounding": {
+			tenantIDs: []string{"tenant-1"},
+			limits: map[string]mockLimits{
+				"tenant-1": {queryResultRoundValuesDecimals: &twoDecimals},
+			},
+			expectedResult: []SampleStream{
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "instance", Value: "a"}, {Name: "job", Value: "x"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1.23}, {TimestampMs: 2000, Value: math.NaN()}},
+				},
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "instance", Value: "b"}, {Name: "job", Value: "x"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 5.68}},
+				},
+			},
+			expectedMetrics: `
+				# HELP cortex_frontend_query_result_transformation_dropped_series_total Total number of series dropped from the query results by the transformations.
+				# TYPE cortex_frontend_query_result_transformation_dropped_series_total counter
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="relabel"} 0
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="round_values"} 0
+				# HELP cortex_frontend_query_result_transformations_total Total number of query results transformed before being returned to the tenant.
+				# TYPE cortex_frontend_query_result_transformations_total counter
+				cortex_frontend_query_result_transformations_total{transformation="relabel"} 0
+				cortex_frontend_query_result_transformations_total{transformation="round_values"} 1
+			`,
+		},
+		"cross-tenant query applies the relabel configs of all tenants and the lowest number of decimal places": {
+			tenantIDs: []string{"tenant-1", "tenant-2", "tenant-3"},
+			limits: map[string]mockLimits{
+				"tenant-1": {queryResultRelabelConfigs: []*relabel.Config{dropJobLabel}, queryResultRoundValuesDecimals: &twoDecimals},
+				"tenant-2": {queryResultRelabelConfigs: []*relabel.Config{dropSeriesB}, queryResultRoundValuesDecimals: &zeroDecimals},
+				"tenant-3": {},
+			},
+			expectedResult: []SampleStream{
+				{
+					Labels:  []mimirpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "instance", Value: "a"}},
+					Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}, {TimestampMs: 2000, Value: math.NaN()}},
+				},
+			},
+			expectedMetrics: `
+				# HELP cortex_frontend_query_result_transformation_dropped_series_total Total number of series dropped from the query results by the transformations.
+				# TYPE cortex_frontend_query_result_transformation_dropped_series_total counter
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="relabel"} 1
+				cortex_frontend_query_result_transformation_dropped_series_total{transformation="round_values"} 0
+				# HELP cortex_frontend_query_result_transformations_total Total number of query results transformed before being returned to the tenant.
+				# TYPE cortex_frontend_query_result_transformations_total counter
+				cortex_frontend_query_result_transformations_total{transformation="relabel"} 1
+				cortex_frontend_query_result_transformations_total{transformation="round_values"} 1
+			`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			metrics := newResultTransformationMetrics(reg)
+			limits := multiTenantMockLimits{byTenant: tc.limits}
+
+			original := newResponse()
+			downstream := HandlerFunc(func(context.Context, Request) (Response, error) {
+				return original, nil
+			})
+			handler := MergeMiddlewares(
+				newResultRelabelMiddleware(limits, metrics, log.NewNopLogger()),
+				newResultRoundValuesMiddleware(limits, metrics, log.NewNopLogger()),
+			).Wrap(downstream)
+
+			ctx := user.InjectOrgID(context.Background(), strings.Join(tc.tenantIDs, "|"))
+			resp, err := handler.Do(ctx, &PrometheusRangeQueryRequest{Query: "foo"})
+			require.NoError(t, err)
+
+			actual := resp.(*PrometheusResponse)
+			if tc.expectedUntransformed {
+				assert.Same(t, original, actual)
+			} else {
+				assert.Equal(t, statusSuccess, actual.Status)
+				assert.Equal(t, model.ValMatrix.String(), actual.Data.ResultType)
+				assertSampleStreamsEqual(t, tc.expectedResult, actual.Data.Result)
+			}
+
+			// The downstream response is never modified.
+			assertSampleStreamsEqual(t, newResponse().Data.Result, original.Data.Result)
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(tc.expectedMetrics)))
+		})
+	}
+}
+
+func TestResultTransformationMiddlewares_ShouldNotTransformStringAndErrorResults(t *testing.T) {
+	twoDecimals := 2
+	limits := mockLimits{
+		queryResultRelabelConfigs:      []*relabel.Config{{Action: relabel.LabelDrop, Regex: relabel.MustNewRegexp("job")}},
+		queryResultRoundValuesDecimals: &twoDecimals,
+	}
+
+	for name, resp := range map[string]*PrometheusResponse{
+		"string result": {
+			Status: statusSuccess,
+			Data:   &PrometheusData{ResultType: model.ValString.String(), Result: []SampleStream{{Labels: []mimirpb.LabelAdapter{{Name: "value", Value: "foo"}}}}},
+		},
+		"error": {
+			Status:    statusError,
+			ErrorType: "execution",
+			Error:     "something went wrong",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			metrics := newResultTransformationMetrics(nil)
+			handler := MergeMiddlewares(
+				newResultRelabelMiddleware(limits, metrics, log.NewNopLogger()),
+				newResultRoundValuesMiddleware(limits, metrics, log.NewNopLogger()),
+			).Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+				return resp, nil
+			}))
+
+			actual, err := handler.Do(user.InjectOrgID(context.Background(), "tenant-1"), &PrometheusInstantQueryRequest{Query: "foo"})
+			require.NoError(t, err)
+			assert.Same(t, resp, actual)
+		})
+	}
+}
+
+func TestRoundValue(t *testing.T) {
+	assert.Equal(t, 1.23, roundValue(1.2345, 100))
+	assert.Equal(t, -1.24, roundValue(-1.2351, 100))
+	assert.Equal(t, float64(2), roundValue(1.5, 1))
+	assert.Equal(t, math.Inf(1), roundValue(math.Inf(1), 100))
+	assert.Equal(t, math.MaxFloat64, roundValue(math.MaxFloat64, 100))
+	assert.True(t, math.IsNaN(roundValue(math.NaN(), 100)))
+}
+
+// assertSampleStreamsEqual compares the input sample streams, considering the NaN values equal.
+func assertSampleStreamsEqual(t *testing.T, expected, actual []SampleStream) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].Labels, actual[i].Labels)
+		require.Len(t, actual[i].Samples, len(expected[i].Samples))
+		for j, s := range expected[i].Samples {
+			assert.Equal(t, s.TimestampMs, actual[i].Samples[j].TimestampMs)
+			if math.IsNaN(s.Value) {
+				assert.True(t, math.IsNaN(actual[i].Samples[j].Value))
+			} else {
+				assert.Equal(t, s.Value, actual[i].Samples[j].Value)
+			}
+		}
+	}
+}
@@ -93,8 +93,9 @@ func errUnsupportedResultsCacheBackend(backend string) error {
 	return fmt.Errorf("%w: %q, supported values: %v", errUnsupportedBackend, backend, supportedResultsCacheBackends)
 }
 
-// newResultsCache creates a new results cache based on the input configuration.
-func newResultsCache(cfg ResultsCacheConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
+// NewResultsCache creates a new results cache client based on the input configuration. The client is shared
+// by the query results cache and the rejected queries cache.
+func NewResultsCache(cfg ResultsCacheConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
 	// Add the "component" label similarly to other components, so that metrics don't clash and have the same labels set
 	// when running in monolithic mode.
 	reg = prometheus.WrapRegistererWith(prometheus.Labels{"component": "query-frontend"}, reg)
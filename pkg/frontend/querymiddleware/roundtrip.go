@@ -42,6 +42,7 @@ type Config struct {
 	ShardedQueries         bool   `yaml:"parallelize_shardable_queries"`
 	CacheUnalignedRequests bool   `yaml:"cache_unaligned_requests" category:"advanced"`
 	TargetSeriesPerShard   uint64 `yaml:"query_sharding_target_series_per_shard" category:"experimental"`
+	ETagEnabled            bool   `yaml:"etag_enabled" category:"experimental"`
 
 	// CacheSplitter allows to inject a CacheSplitter to use for generating cache keys.
 	// If nil, the querymiddleware package uses a ConstSplitter with SplitQueriesByInterval.
@@ -58,6 +59,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.CacheResults, "query-frontend.cache-results", false, "Cache query results.")
 	f.BoolVar(&cfg.ShardedQueries, "query-frontend.parallelize-shardable-queries", false, "True to enable query sharding.")
 	f.BoolVar(&cfg.CacheUnalignedRequests, "query-frontend.cache-unaligned-requests", false, "Cache requests that are not step-aligned.")
+	f.BoolVar(&cfg.ETagEnabled, "query-frontend.etag-enabled", false, "Set the ETag header of the instant and range query responses, and respond with 304 Not Modified and no body to the requests whose If-None-Match header matches the ETag of the response.")
 	f.Uint64Var(&cfg.TargetSeriesPerShard, "query-frontend.query-sharding-target-series-per-shard", 0, "How many series a single sharded partial query should load at most. This is not a strict requirement guaranteed to be honoured by query sharding, but a hint given to the query sharding when the query execution is initially planned. 0 to disable cardinality-based hints.")
 	f.StringVar(&cfg.QueryResultResponseFormat, "query-frontend.query-result-response-format", formatProtobuf, fmt.Sprintf("Format to use when retrieving query results from queriers. Supported values: %s", strings.Join(allFormats, ", ")))
 	cfg.ResultsCacheConfig.RegisterFlags(f)
@@ -71,7 +73,7 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
-	if cfg.CacheResults || cfg.cardinalityBasedShardingEnabled() {
+	if cfg.ResultsCacheEnabled() {
 		if err := cfg.ResultsCacheConfig.Validate(); err != nil {
 			return errors.Wrap(err, "invalid query-frontend results cache config")
 		}
@@ -88,6 +90,12 @@ func (cfg *Config) cardinalityBasedShardingEnabled() bool {
 	return cfg.TargetSeriesPerShard > 0
 }
 
+// ResultsCacheEnabled returns whether the results cache is used by the query middlewares, either to cache
+// the query results or the cardinality estimates of the queries.
+func (cfg *Config) ResultsCacheEnabled() bool {
+	return cfg.CacheResults || cfg.cardinalityBasedShardingEnabled()
+}
+
 // HandlerFunc is like http.HandlerFunc, but for Handler.
 type HandlerFunc func(context.Context, Request) (Response, error)
 
@@ -148,16 +156,18 @@ func (f RoundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
 }
 
 // NewTripperware returns a Tripperware configured with middlewares to limit, align, split, retry and cache requests.
+// The resultsCache is the client created with NewResultsCache, and is required if cfg.ResultsCacheEnabled().
 func NewTripperware(
 	cfg Config,
 	log log.Logger,
 	limits Limits,
 	codec Codec,
 	cacheExtractor Extractor,
+	resultsCache cache.Cache,
 	engineOpts promql.EngineOpts,
 	registerer prometheus.Registerer,
 ) (Tripperware, error) {
-	queryRangeTripperware, err := newQueryTripperware(cfg, log, limits, codec, cacheExtractor, engineOpts, registerer)
+	queryRangeTripperware, err := newQueryTripperware(cfg, log, limits, codec, cacheExtractor, resultsCache, engineOpts, registerer)
 	if err != nil {
 		return nil, err
 	}
@@ -173,6 +183,7 @@ func newQueryTripperware(
 	limits Limits,
 	codec Codec,
 	cacheExtractor Extractor,
+	resultsCache cache.Cache,
 	engineOpts promql.EngineOpts,
 	registerer prometheus.Registerer,
 ) (Tripperware, error) {
@@ -183,24 +194,32 @@ func newQueryTripperware(
 	// Metric used to keep track of each middleware execution duration.
 	metrics := newInstrumentMiddlewareMetrics(registerer)
 
+	// The query results are transformed once all the other middlewares have run, so that the results cache
+	// keeps storing the results as returned by the queriers.
+	resultTransformationMetrics := newResultTransformationMetrics(registerer)
+	resultTransformationMiddlewares := []Middleware{
+		newInstrumentMiddleware("result_relabel", metrics, log),
+		newResultRelabelMiddleware(limits, resultTransformationMetrics, log),
+		newInstrumentMiddleware("result_round_values", metrics, log),
+		newResultRoundValuesMiddleware(limits, resultTransformationMetrics, log),
+	}
+
 	queryRangeMiddleware := []Middleware{
 		// Track query range statistics. Added first before any subsequent middleware modifies the request.
 		newQueryStatsMiddleware(registerer),
 		newLimitsMiddleware(limits, log),
 	}
+	queryRangeMiddleware = append(queryRangeMiddleware, resultTransformationMiddlewares...)
 	if cfg.AlignQueriesWithStep {
 		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("step_align", metrics, log), newStepAlignMiddleware())
 	}
 
 	var c cache.Cache
-	if cfg.CacheResults || cfg.cardinalityBasedShardingEnabled() {
-		var err error
-
-		c, err = newResultsCache(cfg.ResultsCacheConfig, log, registerer)
-		if err != nil {
-			return nil, err
+	if cfg.ResultsCacheEnabled() {
+		if resultsCache == nil {
+			return nil, errUnsupportedResultsCacheBackend(cfg.ResultsCacheConfig.Backend)
 		}
-		c = cache.NewCompression(cfg.ResultsCacheConfig.Compression, c, log)
+		c = cache.NewCompression(cfg.ResultsCacheConfig.Compression, resultsCache, log)
 	}
 
 	// Inject the middleware to split requests by interval + results cache (if at least one of the two is enabled).
@@ -232,6 +251,7 @@ func newQueryTripperware(
 	}
 
 	queryInstantMiddleware := []Middleware{newLimitsMiddleware(limits, log)}
+	queryInstantMiddleware = append(queryInstantMiddleware, resultTransformationMiddlewares...)
 
 	queryInstantMiddleware = append(
 		queryInstantMiddleware,
@@ -281,17 +301,32 @@ func newQueryTripperware(
 		queryInstantMiddleware = append(queryInstantMiddleware, newInstrumentMiddleware("retry", metrics, log), newRetryMiddleware(log, cfg.MaxRetries, retryMiddlewareMetrics))
 	}
 
+	var etagMetrics *etagMetrics
+	if cfg.ETagEnabled {
+		etagMetrics = newETagMetrics(registerer)
+	}
+
 	return func(next http.RoundTripper) http.RoundTripper {
 		queryrange := newLimitedParallelismRoundTripper(next, codec, limits, queryRangeMiddleware...)
 		instant := defaultInstantQueryParamsRoundTripper(
 			newLimitedParallelismRoundTripper(next, codec, limits, queryInstantMiddleware...),
 		)
+		labels := newLabelsPaginationRoundTripper(next)
+		plan := newQueryPlanRoundTripper(codec, limits, queryRangeMiddleware, queryInstantMiddleware)
+		if etagMetrics != nil {
+			queryrange = newETagRoundTripper(queryrange, etagMetrics)
+			instant = newETagRoundTripper(instant, etagMetrics)
+		}
 		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 			switch {
 			case isRangeQuery(r.URL.Path):
 				return queryrange.RoundTrip(r)
 			case isInstantQuery(r.URL.Path):
 				return instant.RoundTrip(r)
+			case isQueryPlan(r.URL.Path):
+				return plan.RoundTrip(r)
+			case isLabelsQuery(r.URL.Path):
+				return labels.RoundTrip(r)
 			default:
 				return next.RoundTrip(r)
 			}
@@ -70,6 +70,7 @@ func TestRangeTripperware(t *testing.T) {
 		mockLimits{},
 		newTestPrometheusCodec(),
 		nil,
+		nil,
 		promql.EngineOpts{
 			Logger:     log.NewNopLogger(),
 			Reg:        nil,
@@ -107,6 +108,77 @@ func TestRangeTripperware(t *testing.T) {
 	}
 }
 
+func TestRangeTripperware_ETag(t *testing.T) {
+	var (
+		query        = "/api/v1/query_range?end=1536716880&query=sum%28container_memory_rss%29+by+%28namespace%29&start=1536673680&step=120"
+		responseBody = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"foo":"bar"},"values":[[1536673680,"137"],[1536673780,"137"]]}]}}`
+	)
+
+	s := httptest.NewServer(
+		middleware.AuthenticateUser.Wrap(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", jsonMimeType)
+				_, _ = w.Write([]byte(responseBody))
+			}),
+		),
+	)
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	require.NoError(t, err)
+
+	downstream := singleHostRoundTripper{
+		host: u.Host,
+		next: http.DefaultTransport,
+	}
+
+	tw, err := NewTripperware(Config{ETagEnabled: true},
+		log.NewNopLogger(),
+		mockLimits{},
+		newTestPrometheusCodec(),
+		nil,
+		nil,
+		promql.EngineOpts{
+			Logger:     log.NewNopLogger(),
+			Reg:        nil,
+			MaxSamples: 1000,
+			Timeout:    time.Minute,
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	roundTrip := func(ifNoneMatch string) (*http.Response, string) {
+		req, err := http.NewRequest("GET", query, http.NoBody)
+		require.NoError(t, err)
+		if ifNoneMatch != "" {
+			req.Header.Set(ifNoneMatchHeader, ifNoneMatch)
+		}
+
+		ctx := user.InjectOrgID(context.Background(), "user-1")
+		req = req.WithContext(ctx)
+		require.NoError(t, user.InjectOrgIDIntoHTTPRequest(ctx, req))
+
+		resp, err := tw(downstream).RoundTrip(req)
+		require.NoError(t, err)
+
+		bs, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(bs)
+	}
+
+	resp, body := roundTrip("")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, responseBody, body)
+	etag := resp.Header.Get(etagHeader)
+	require.NotEmpty(t, etag)
+
+	resp, body = roundTrip(etag)
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+	require.Equal(t, etag, resp.Header.Get(etagHeader))
+	require.Empty(t, body)
+}
+
 func TestInstantTripperware(t *testing.T) {
 	const totalShards = 8
 
@@ -121,6 +193,7 @@ func TestInstantTripperware(t *testing.T) {
 		mockLimits{totalShards: totalShards},
 		codec,
 		nil,
+		nil,
 		promql.EngineOpts{
 			Logger:     log.NewNopLogger(),
 			Reg:        nil,
@@ -294,6 +367,7 @@ func TestTripperware_Metrics(t *testing.T) {
 				mockLimits{},
 				newTestPrometheusCodec(),
 				nil,
+				nil,
 				promql.EngineOpts{
 					Logger:     log.NewNopLogger(),
 					Reg:        nil,
@@ -341,7 +415,7 @@ func TestConfig_Validate(t *testing.T) {
 		},
 		"unknown query result payload format": {
 			config:        Config{QueryResultResponseFormat: "something-else"},
-			expectedError: errors.New("unknown query result response format 'something-else'. Supported values: json, protobuf"),
+			expectedError: errors.New("unknown query result response format 'something-else'. Supported values: json, protobuf, chunked-json"),
 		},
 	}
 
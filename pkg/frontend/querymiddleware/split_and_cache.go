@@ -8,6 +8,7 @@ package querymiddleware
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -257,6 +258,12 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 					continue
 				}
 
+				// The cached extents are not extended with the downstream requests ending before the pinned
+				// evaluation time of the query, which look beyond their time range.
+				if !areEvaluationTimeModifiersCachable(downstreamReq, maxCacheTime, s.logger) {
+					continue
+				}
+
 				extent, err := toExtent(ctx, downstreamReq, s.extractor.ResponseWithoutHeaders(downstreamRes), queryTime)
 				if err != nil {
 					return nil, err
@@ -301,6 +308,11 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 // splitRequestByInterval splits the given Request by configured interval. Returns the input request if splitting is disabled.
 func (s *splitAndCacheMiddleware) splitRequestByInterval(req Request) (splitRequests, error) {
 	if !s.splitEnabled {
+		// The at modifier functions are evaluated anyway, because the cache key is generated from the query.
+		req, err := evaluateRequestAtModifierFunction(req)
+		if err != nil {
+			return nil, err
+		}
 		return splitRequests{{orig: req}}, nil
 	}
 
@@ -621,27 +633,48 @@ func splitQueryByInterval(r Request, interval time.Duration) ([]Request, error)
 
 // evaluateAtModifierFunction parse the query and evaluates the `start()` and `end()` at modifier functions into actual constant timestamps.
 // For example given the start of the query is 10.00, `http_requests_total[1h] @ start()` query will be replaced with `http_requests_total[1h] @ 10.00`
-// If the modifier is already a constant, it will be returned as is.
+// The modifier is evaluated both on the selectors and on the subqueries. If the modifier is already a constant, it will be returned as is.
 func evaluateAtModifierFunction(query string, start, end int64) (string, error) {
 	expr, err := parser.ParseExpr(query)
 	if err != nil {
 		return "", apierror.New(apierror.TypeBadData, err.Error())
 	}
+	evaluate := func(timestamp **int64, startOrEnd *parser.ItemType) {
+		switch *startOrEnd {
+		case parser.START:
+			*timestamp = &start
+		case parser.END:
+			*timestamp = &end
+		}
+		*startOrEnd = 0
+	}
 	parser.Inspect(expr, func(n parser.Node, _ []parser.Node) error {
-		if selector, ok := n.(*parser.VectorSelector); ok {
-			switch selector.StartOrEnd {
-			case parser.START:
-				selector.Timestamp = &start
-			case parser.END:
-				selector.Timestamp = &end
-			}
-			selector.StartOrEnd = 0
+		switch e := n.(type) {
+		case *parser.VectorSelector:
+			evaluate(&e.Timestamp, &e.StartOrEnd)
+		case *parser.SubqueryExpr:
+			evaluate(&e.Timestamp, &e.StartOrEnd)
 		}
 		return nil
 	})
 	return expr.String(), nil
 }
 
+// evaluateRequestAtModifierFunction returns the input request with the `start()` and `end()` at modifier
+// functions of its query evaluated into constant timestamps, so that the request can be safely cached and
+// its time range changed. The request is returned as is if its query has no at modifier.
+func evaluateRequestAtModifierFunction(r Request) (Request, error) {
+	if !strings.Contains(r.GetQuery(), "@") {
+		return r, nil
+	}
+
+	query, err := evaluateAtModifierFunction(r.GetQuery(), r.GetStart(), r.GetEnd())
+	if err != nil {
+		return nil, err
+	}
+	return r.WithQuery(query), nil
+}
+
 // Round up to the step before the next interval boundary.
 func nextIntervalBoundary(t, step int64, interval time.Duration) int64 {
 	intervalMillis := interval.Milliseconds()
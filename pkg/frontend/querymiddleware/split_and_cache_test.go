@@ -335,6 +335,61 @@ func TestSplitAndCacheMiddleware_ResultsCache(t *testing.T) {
 	assert.Equal(t, uint32(2), queryStats.LoadSplitQueries())
 }
 
+func TestSplitAndCacheMiddleware_ResultsCache_ShouldEvaluateAtModifierWhenSplitIsDisabled(t *testing.T) {
+	cacheBackend := cache.NewInstrumentedMockCache()
+
+	mw := newSplitAndCacheMiddleware(
+		false,
+		true,
+		24*time.Hour,
+		false,
+		mockLimits{maxCacheFreshness: 10 * time.Minute, resultsCacheTTL: resultsCacheTTL, resultsCacheOutOfOrderWindowTTL: resultsCacheLowerTTL},
+		newTestPrometheusCodec(),
+		cacheBackend,
+		ConstSplitter(day),
+		PrometheusResponseExtractor{},
+		resultsCacheAlwaysEnabled,
+		log.NewNopLogger(),
+		prometheus.NewPedanticRegistry(),
+	)
+
+	var downstreamQueries []string
+	rc := mw.Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+		downstreamQueries = append(downstreamQueries, req.GetQuery())
+		return &PrometheusResponse{
+			Status: "success",
+			Data:   &PrometheusData{ResultType: model.ValMatrix.String(), Result: []SampleStream{}},
+		}, nil
+	}))
+
+	step := int64(120 * 1000)
+	start := parseTimeRFC3339(t, "2021-10-15T10:00:00Z").Unix() * 1000
+	end := parseTimeRFC3339(t, "2021-10-15T12:00:00Z").Unix() * 1000
+	req := Request(&PrometheusRangeQueryRequest{
+		Path:  "/api/v1/query_range",
+		Start: start,
+		End:   end,
+		Step:  step,
+		Query: `rate(metric[5m] @ start())`,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "1")
+	_, err := rc.Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, []string{`rate(metric[5m] @ 1634292000.000)`}, downstreamQueries)
+
+	// Doing the same request again should hit the cache.
+	_, err = rc.Do(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, downstreamQueries, 1)
+
+	// Doing a request with a new start time pins the query at a different time, so the cached results
+	// of the previous request can't be used.
+	_, err = rc.Do(ctx, req.WithStartEnd(start+step, end))
+	require.NoError(t, err)
+	require.Equal(t, []string{`rate(metric[5m] @ 1634292000.000)`, `rate(metric[5m] @ 1634292120.000)`}, downstreamQueries)
+}
+
 func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheIfStepIsNotAligned(t *testing.T) {
 	cacheBackend := cache.NewInstrumentedMockCache()
 	reg := prometheus.NewPedanticRegistry()
@@ -1734,6 +1789,8 @@ func Test_evaluateAtModifier(t *testing.T) {
 		{"topk(5, rate(http_requests_total[1h] @ start()))", "topk(5, rate(http_requests_total[1h] @ 1546300.800))", nil},
 		{"topk(5, rate(http_requests_total[1h] @ 0))", "topk(5, rate(http_requests_total[1h] @ 0.000))", nil},
 		{"http_requests_total[1h] @ 10.001", "http_requests_total[1h] @ 10.001", nil},
+		{"max_over_time(rate(http_requests_total[5m])[1h:1m] @ start())", "max_over_time(rate(http_requests_total[5m])[1h:1m] @ 1546300.800)", nil},
+		{"max_over_time(rate(http_requests_total[5m] @ end())[1h:1m] @ start())", "max_over_time(rate(http_requests_total[5m] @ 1646300.800)[1h:1m] @ 1546300.800)", nil},
 		{
 			`min_over_time(
 				sum by(cluster) (
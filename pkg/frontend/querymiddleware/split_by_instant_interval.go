@@ -160,6 +160,7 @@ func (s *splitInstantQueryByIntervalMiddleware) Do(ctx context.Context, req Requ
 	}
 
 	level.Debug(spanLog).Log("msg", "instant query has been split by interval", "rewritten", instantSplitQuery, "split_queries", mapperStats.GetSplitQueries())
+	queryPlanFromContext(ctx).addRewrite("split_instant_query_by_interval", req.GetQuery(), instantSplitQuery.String())
 
 	// Update query stats.
 	queryStats := stats.FromContext(ctx)
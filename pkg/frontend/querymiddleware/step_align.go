@@ -10,10 +10,16 @@ import (
 )
 
 // newStepAlignMiddleware creates a middleware that aligns the start and end of request to the step to
-// improved the cacheability of the query results.
+// improved the cacheability of the query results. The `start()` and `end()` at modifier functions are
+// evaluated with the original start and end, so that the query is still evaluated at the requested time.
 func newStepAlignMiddleware() Middleware {
 	return MiddlewareFunc(func(next Handler) Handler {
 		return HandlerFunc(func(ctx context.Context, r Request) (Response, error) {
+			r, err := evaluateRequestAtModifierFunction(r)
+			if err != nil {
+				return nil, err
+			}
+
 			start := (r.GetStart() / r.GetStep()) * r.GetStep()
 			end := (r.GetEnd() / r.GetStep()) * r.GetStep()
 			return next.Do(ctx, r.WithStartEnd(start, end))
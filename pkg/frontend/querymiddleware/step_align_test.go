@@ -42,6 +42,21 @@ func TestStepAlignMiddleware(t *testing.T) {
 				Step:  10,
 			},
 		},
+
+		{
+			input: &PrometheusRangeQueryRequest{
+				Start: 2000,
+				End:   102000,
+				Step:  10000,
+				Query: "rate(metric[1m] @ start())",
+			},
+			expected: &PrometheusRangeQueryRequest{
+				Start: 0,
+				End:   100000,
+				Step:  10000,
+				Query: "rate(metric[1m] @ 2.000)",
+			},
+		},
 	} {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			var result *PrometheusRangeQueryRequest
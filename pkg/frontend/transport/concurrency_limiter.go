@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+)
+
+const (
+	rejectReasonQueueFull = "queue_full"
+	rejectReasonTimeout   = "timeout"
+)
+
+var (
+	errQueueFull = httpgrpc.Errorf(http.StatusTooManyRequests, globalerror.QueryFrontendMaxConcurrentRequestsPerTenant.MessageWithPerInstanceLimitConfig(
+		"the tenant reached the limit of concurrent requests to the query-frontend and the queue of waiting requests is full",
+		maxConcurrentRequestsPerTenantFlag, maxQueuedRequestsPerTenantFlag,
+	))
+	errQueueTimeout = httpgrpc.Errorf(http.StatusTooManyRequests, globalerror.QueryFrontendMaxConcurrentRequestsPerTenant.MessageWithPerInstanceLimitConfig(
+		"the tenant reached the limit of concurrent requests to the query-frontend and the request timed out while waiting in the queue",
+		maxConcurrentRequestsPerTenantFlag, maxQueuedRequestsPerTenantFlag, queuedRequestsTimeoutFlag,
+	))
+)
+
+// tenantConcurrencyLimiter limits the number of concurrent requests per tenant. The requests exceeding
+// the limit wait in a bounded per-tenant queue, and are admitted in arrival order as soon as a slot is released.
+// The requests which can't be queued, or are still waiting when the queue timeout expires, are rejected.
+type tenantConcurrencyLimiter struct {
+	maxConcurrent int
+	maxQueued     int
+	queueTimeout  time.Duration
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantConcurrency
+
+	// Metrics.
+	queueDuration    prometheus.Histogram
+	rejectedRequests *prometheus.CounterVec
+	activeUsers      *util.ActiveUsersCleanupService
+}
+
+type tenantConcurrency struct {
+	inflight int
+
+	// The channels of the queued requests, which get closed when the request is admitted.
+	queue *list.List
+}
+
+func newTenantConcurrencyLimiter(maxConcurrent, maxQueued int, queueTimeout time.Duration, reg prometheus.Registerer) *tenantConcurrencyLimiter {
+	l := &tenantConcurrencyLimiter{
+		maxConcurrent: maxConcurrent,
+		maxQueued:     maxQueued,
+		queueTimeout:  queueTimeout,
+		tenants:       map[string]*tenantConcurrency{},
+
+		queueDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_query_frontend_tenant_concurrency_queue_duration_seconds",
+			Help:    "Time spent by requests waiting in the query-frontend per-tenant concurrency queue before being admitted.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 4, 7),
+		}),
+		rejectedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_tenant_concurrency_rejected_requests_total",
+			Help: "Number of requests rejected by the query-frontend because the tenant reached the limit of concurrent requests.",
+		}, []string{"user", "reason"}),
+	}
+
+	l.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(func(user string) {
+		l.rejectedRequests.DeleteLabelValues(user, rejectReasonQueueFull)
+		l.rejectedRequests.DeleteLabelValues(user, rejectReasonTimeout)
+	})
+	// If cleaner stops or fail, we will simply not clean the metrics for inactive users.
+	_ = l.activeUsers.StartAsync(context.Background())
+
+	return l
+}
+
+// acquire waits until the request of the input tenant can be run, and returns the function to call once
+// the request completes. It returns an error if the request has been rejected or ctx has been canceled
+// while waiting.
+func (l *tenantConcurrencyLimiter) acquire(ctx context.Context, userID string) (func(), error) {
+	l.mtx.Lock()
+
+	t, ok := l.tenants[userID]
+	if !ok {
+		t = &tenantConcurrency{queue: list.New()}
+		l.tenants[userID] = t
+	}
+
+	if t.inflight < l.maxConcurrent {
+		t.inflight++
+		l.mtx.Unlock()
+		return func() { l.release(userID) }, nil
+	}
+
+	if t.queue.Len() >= l.maxQueued {
+		l.mtx.Unlock()
+		l.reject(userID, rejectReasonQueueFull)
+		return nil, errQueueFull
+	}
+
+	admitted := make(chan struct{})
+	elem := t.queue.PushBack(admitted)
+	l.mtx.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-admitted:
+		l.queueDuration.Observe(time.Since(start).Seconds())
+		return func() { l.release(userID) }, nil
+	case <-timer.C:
+		err = errQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mtx.Lock()
+	select {
+	case <-admitted:
+		// The request has been admitted concurrently to the timeout or cancellation,
+		// so the slot it has been given is passed to the next request.
+		l.mtx.Unlock()
+		l.release(userID)
+	default:
+		t.queue.Remove(elem)
+		l.deleteTenantIfIdle(userID, t)
+		l.mtx.Unlock()
+	}
+
+	l.queueDuration.Observe(time.Since(start).Seconds())
+	if err == errQueueTimeout {
+		l.reject(userID, rejectReasonTimeout)
+	}
+	return nil, err
+}
+
+// release releases a slot of the input tenant, handing it over to the oldest queued request if any.
+func (l *tenantConcurrencyLimiter) release(userID string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	t, ok := l.tenants[userID]
+	if !ok {
+		return
+	}
+
+	if front := t.queue.Front(); front != nil {
+		t.queue.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+
+	t.inflight--
+	l.deleteTenantIfIdle(userID, t)
+}
+
+// deleteTenantIfIdle must be called with the mutex held.
+func (l *tenantConcurrencyLimiter) deleteTenantIfIdle(userID string, t *tenantConcurrency) {
+	if t.inflight <= 0 && t.queue.Len() == 0 {
+		delete(l.tenants, userID)
+	}
+}
+
+// rejection returns the limit rejecting the requests which can't be run nor queued. The requests are only
+// rejected when the tenant runs the max number of concurrent requests.
+func (l *tenantConcurrencyLimiter) rejection() *querier_stats.Rejection {
+	return &querier_stats.Rejection{
+		Limit:           string(globalerror.QueryFrontendMaxConcurrentRequestsPerTenant),
+		Component:       rejectionComponent,
+		MeasuredValue:   float64(l.maxConcurrent),
+		ConfiguredLimit: float64(l.maxConcurrent),
+	}
+}
+
+func (l *tenantConcurrencyLimiter) reject(userID, reason string) {
+	l.rejectedRequests.WithLabelValues(userID, reason).Inc()
+	l.activeUsers.UpdateUserTimestamp(userID, time.Now())
+}
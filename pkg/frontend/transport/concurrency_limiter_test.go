@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantConcurrencyLimiter(t *testing.T) {
+	t.Run("should admit the requests up to the limit and queue the other ones", func(t *testing.T) {
+		l := newTenantConcurrencyLimiter(2, 1, time.Minute, nil)
+
+		release1, err := l.acquire(context.Background(), "user-1")
+		require.NoError(t, err)
+		release2, err := l.acquire(context.Background(), "user-1")
+		require.NoError(t, err)
+
+		// Another tenant isn't affected by the limit reached by user-1.
+		releaseOther, err := l.acquire(context.Background(), "user-2")
+		require.NoError(t, err)
+		releaseOther()
+
+		queued := make(chan func())
+		go func() {
+			release, err := l.acquire(context.Background(), "user-1")
+			assert.NoError(t, err)
+			queued <- release
+		}()
+
+		// Wait until the request has been queued.
+		require.Eventually(t, func() bool { return queueLength(l, "user-1") == 1 }, time.Second, time.Millisecond)
+
+		// The queue is full.
+		_, err = l.acquire(context.Background(), "user-1")
+		require.Equal(t, errQueueFull, err)
+
+		select {
+		case <-queued:
+			require.FailNow(t, "the queued request has been admitted before a slot has been released")
+		default:
+		}
+
+		release1()
+		release3 := <-queued
+
+		release2()
+		release3()
+
+		l.mtx.Lock()
+		assert.Empty(t, l.tenants)
+		l.mtx.Unlock()
+	})
+
+	t.Run("should admit the queued requests in arrival order", func(t *testing.T) {
+		l := newTenantConcurrencyLimiter(1, 3, time.Minute, nil)
+
+		release, err := l.acquire(context.Background(), "user-1")
+		require.NoError(t, err)
+
+		admitted := make(chan int, 3)
+		for i := 0; i < 3; i++ {
+			i := i
+			go func() {
+				release, err := l.acquire(context.Background(), "user-1")
+				assert.NoError(t, err)
+				admitted <- i
+				release()
+			}()
+
+			require.Eventually(t, func() bool { return queueLength(l, "user-1") == i+1 }, time.Second, time.Millisecond)
+		}
+
+		release()
+
+		for i := 0; i < 3; i++ {
+			require.Equal(t, i, <-admitted)
+		}
+	})
+
+	t.Run("should reject the queued requests once the timeout expires", func(t *testing.T) {
+		reg := prometheus.NewPedanticRegistry()
+		l := newTenantConcurrencyLimiter(1, 1, 50*time.Millisecond, reg)
+
+		release, err := l.acquire(context.Background(), "user-1")
+		require.NoError(t, err)
+		defer release()
+
+		_, err = l.acquire(context.Background(), "user-1")
+		require.Equal(t, errQueueTimeout, err)
+		assert.Equal(t, 0, queueLength(l, "user-1"))
+
+		_, err = l.acquire(context.Background(), "user-1")
+		require.Equal(t, errQueueTimeout, err)
+
+		assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_query_frontend_tenant_concurrency_rejected_requests_total Number of requests rejected by the query-frontend because the tenant reached the limit of concurrent requests.
+			# TYPE cortex_query_frontend_tenant_concurrency_rejected_requests_total counter
+			cortex_query_frontend_tenant_concurrency_rejected_requests_total{reason="timeout",user="user-1"} 2
+		`), "cortex_query_frontend_tenant_concurrency_rejected_requests_total"))
+	})
+
+	t.Run("should stop waiting if the context is canceled", func(t *testing.T) {
+		l := newTenantConcurrencyLimiter(1, 1, time.Minute, nil)
+
+		release, err := l.acquire(context.Background(), "user-1")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = l.acquire(ctx, "user-1")
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 0, queueLength(l, "user-1"))
+
+		// The slot is still available once released.
+		release()
+		release, err = l.acquire(context.Background(), "user-1")
+		require.NoError(t, err)
+		release()
+	})
+}
+
+func queueLength(l *tenantConcurrencyLimiter, userID string) int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if t, ok := l.tenants[userID]; ok {
+		return t.queue.Len()
+	}
+	return 0
+}
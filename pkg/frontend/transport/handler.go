@@ -20,6 +20,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -45,6 +46,18 @@ var (
 	errCanceled              = httpgrpc.Errorf(StatusClientClosedRequest, context.Canceled.Error())
 	errDeadlineExceeded      = httpgrpc.Errorf(http.StatusGatewayTimeout, context.DeadlineExceeded.Error())
 	errRequestEntityTooLarge = httpgrpc.Errorf(http.StatusRequestEntityTooLarge, "http: request body too large")
+
+	errInvalidMaxConcurrentRequestsPerTenant = fmt.Errorf("the -%s setting must be greater than or equal to 0", maxConcurrentRequestsPerTenantFlag)
+	errInvalidMaxQueuedRequestsPerTenant     = fmt.Errorf("the -%s setting must be greater than or equal to 0", maxQueuedRequestsPerTenantFlag)
+	errInvalidQueuedRequestsTimeout          = fmt.Errorf("the -%s setting must be greater than 0", queuedRequestsTimeoutFlag)
+	errInvalidRejectedQueriesCacheTTL        = fmt.Errorf("the -%s setting must be greater than or equal to 0", rejectedQueriesCacheTTLFlag)
+)
+
+const (
+	maxConcurrentRequestsPerTenantFlag = "query-frontend.max-concurrent-requests-per-tenant"
+	maxQueuedRequestsPerTenantFlag     = "query-frontend.max-queued-requests-per-tenant"
+	queuedRequestsTimeoutFlag          = "query-frontend.queued-requests-timeout"
+	rejectedQueriesCacheTTLFlag        = "query-frontend.rejected-queries-cache-ttl"
 )
 
 // Config for a Handler.
@@ -52,12 +65,38 @@ type HandlerConfig struct {
 	LogQueriesLongerThan time.Duration `yaml:"log_queries_longer_than"`
 	MaxBodySize          int64         `yaml:"max_body_size" category:"advanced"`
 	QueryStatsEnabled    bool          `yaml:"query_stats_enabled" category:"advanced"`
+
+	MaxConcurrentRequestsPerTenant int           `yaml:"max_concurrent_requests_per_tenant" category:"experimental"`
+	MaxQueuedRequestsPerTenant     int           `yaml:"max_queued_requests_per_tenant" category:"experimental"`
+	QueuedRequestsTimeout          time.Duration `yaml:"queued_requests_timeout" category:"experimental"`
+
+	RejectedQueriesCacheTTL time.Duration `yaml:"rejected_queries_cache_ttl" category:"experimental"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.LogQueriesLongerThan, "query-frontend.log-queries-longer-than", 0, "Log queries that are slower than the specified duration. Set to 0 to disable. Set to < 0 to enable on all queries.")
 	f.Int64Var(&cfg.MaxBodySize, "query-frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "query-frontend.query-stats-enabled", true, "False to disable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
+	f.IntVar(&cfg.MaxConcurrentRequestsPerTenant, maxConcurrentRequestsPerTenantFlag, 0, "Maximum number of HTTP requests of a single tenant that each query-frontend handles concurrently, before splitting and sharding the queries. The requests exceeding the limit wait in a per-tenant queue, and are rejected with the HTTP status code 429 when the queue is full. 0 to disable the limit.")
+	f.IntVar(&cfg.MaxQueuedRequestsPerTenant, maxQueuedRequestsPerTenantFlag, 10, "Maximum number of HTTP requests of a single tenant waiting in each query-frontend for a slot, when the limit configured with -"+maxConcurrentRequestsPerTenantFlag+" is reached. The queued requests are admitted in arrival order.")
+	f.DurationVar(&cfg.RejectedQueriesCacheTTL, rejectedQueriesCacheTTLFlag, 0, "How long the queries rejected because of a limit are kept, to report which limit rejected them through the rejected query API. The rejected queries are stored in the results cache, configured with -query-frontend.results-cache.*, so that they can be looked up on any query-frontend. Both the limits enforced by the query-frontend and by the queriers are reported, regardless of -query-frontend.query-stats-enabled. 0 to disable.")
+	f.DurationVar(&cfg.QueuedRequestsTimeout, queuedRequestsTimeoutFlag, 5*time.Second, "Maximum time an HTTP request waits in the per-tenant queue of the query-frontend, when the limit configured with -"+maxConcurrentRequestsPerTenantFlag+" is reached. The requests still waiting when the timeout expires are rejected with the HTTP status code 429.")
+}
+
+func (cfg *HandlerConfig) Validate() error {
+	if cfg.MaxConcurrentRequestsPerTenant < 0 {
+		return errInvalidMaxConcurrentRequestsPerTenant
+	}
+	if cfg.MaxConcurrentRequestsPerTenant > 0 && cfg.MaxQueuedRequestsPerTenant < 0 {
+		return errInvalidMaxQueuedRequestsPerTenant
+	}
+	if cfg.MaxConcurrentRequestsPerTenant > 0 && cfg.QueuedRequestsTimeout <= 0 {
+		return errInvalidQueuedRequestsTimeout
+	}
+	if cfg.RejectedQueriesCacheTTL < 0 {
+		return errInvalidRejectedQueriesCacheTTL
+	}
+	return nil
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can wait on in-flight requests and log slow queries,
@@ -76,6 +115,12 @@ type Handler struct {
 	queryIndexBytes *prometheus.CounterVec
 	activeUsers     *util.ActiveUsersCleanupService
 
+	// Limits the concurrent requests per tenant. Nil if the limit is disabled.
+	concurrencyLimiter *tenantConcurrencyLimiter
+
+	// The queries rejected because of a limit, stored in the results cache. Nil if the rejected queries cache is disabled.
+	rejectedQueries *rejectedQueriesCache
+
 	mtx              sync.Mutex
 	inflightRequests int
 	stopped          bool
@@ -83,7 +128,8 @@ type Handler struct {
 }
 
 // NewHandler creates a new frontend handler.
-func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker) *Handler {
+// The resultsCache is used to store the rejected queries, and can be nil if the rejected queries cache is disabled.
+func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, resultsCache cache.Cache, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker) *Handler {
 	h := &Handler{
 		cfg:          cfg,
 		log:          log,
@@ -92,6 +138,14 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 	}
 	h.cond = sync.NewCond(&h.mtx)
 
+	if cfg.MaxConcurrentRequestsPerTenant > 0 {
+		h.concurrencyLimiter = newTenantConcurrencyLimiter(cfg.MaxConcurrentRequestsPerTenant, cfg.MaxQueuedRequestsPerTenant, cfg.QueuedRequestsTimeout, reg)
+	}
+
+	if cfg.RejectedQueriesCacheTTL > 0 && resultsCache != nil {
+		h.rejectedQueries = newRejectedQueriesCache(resultsCache, cfg.RejectedQueriesCacheTTL, log)
+	}
+
 	if cfg.QueryStatsEnabled {
 		h.querySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_seconds_total",
@@ -163,11 +217,27 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		f.mtx.Unlock()
 	}()
 
+	if f.concurrencyLimiter != nil {
+		// The requests without a tenant are left to fail further down the request chain.
+		if tenantIDs, err := tenant.TenantIDs(r.Context()); err == nil {
+			release, err := f.concurrencyLimiter.acquire(r.Context(), tenant.JoinTenantIDs(tenantIDs))
+			if err != nil {
+				if errors.Is(err, errQueueFull) || errors.Is(err, errQueueTimeout) {
+					// The request body hasn't been read yet, so the query is identified by its URL params only.
+					f.recordRejectedQuery(r, r.URL.Query(), f.concurrencyLimiter.rejection(), err, w.Header())
+				}
+				writeError(w, err)
+				return
+			}
+			defer release()
+		}
+	}
+
 	var stats *querier_stats.Stats
 
 	// Initialise the stats in the context and make sure it's propagated
-	// down the request chain.
-	if f.cfg.QueryStatsEnabled {
+	// down the request chain. The stats carry the limit rejecting the query too.
+	if f.cfg.QueryStatsEnabled || f.rejectedQueries != nil {
 		var ctx context.Context
 		stats, ctx = querier_stats.ContextWithEmptyStats(r.Context())
 		r = r.WithContext(ctx)
@@ -204,6 +274,7 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	queryResponseTime := time.Since(startTime)
 
 	if err != nil {
+		f.recordRejectedQuery(r, params, stats.LoadRejection(), err, w.Header())
 		writeError(w, err)
 		f.reportQueryStats(r, params, queryResponseTime, stats, err)
 		return
@@ -214,6 +285,10 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		hs[h] = vs
 	}
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		f.recordRejectedQuery(r, params, stats.LoadRejection(), nil, hs)
+	}
+
 	if f.cfg.QueryStatsEnabled {
 		writeServiceTimingHeader(queryResponseTime, hs, stats)
 	}
@@ -223,7 +298,7 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	_, _ = io.Copy(w, resp.Body)
 
 	if f.cfg.LogQueriesLongerThan > 0 && queryResponseTime > f.cfg.LogQueriesLongerThan {
-		f.reportSlowQuery(r, params, queryResponseTime)
+		f.reportSlowQuery(r, params, queryResponseTime, stats)
 	}
 	if f.cfg.QueryStatsEnabled {
 		f.reportQueryStats(r, params, queryResponseTime, stats, nil)
@@ -231,14 +306,18 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // reportSlowQuery reports slow queries.
-func (f *Handler) reportSlowQuery(r *http.Request, queryString url.Values, queryResponseTime time.Duration) {
-	logMessage := append([]interface{}{
+func (f *Handler) reportSlowQuery(r *http.Request, queryString url.Values, queryResponseTime time.Duration, stats *querier_stats.Stats) {
+	logMessage := []interface{}{
 		"msg", "slow query detected",
 		"method", r.Method,
 		"host", r.Host,
 		"path", r.URL.Path,
 		"time_taken", queryResponseTime.String(),
-	}, formatQueryString(queryString)...)
+	}
+	if f.cfg.QueryStatsEnabled {
+		logMessage = append(logMessage, queryTimingBreakdown(stats)...)
+	}
+	logMessage = append(logMessage, formatQueryString(queryString)...)
 
 	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
 }
@@ -256,7 +335,7 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 	numIndexBytes := stats.LoadFetchedIndexBytes()
 	sharded := strconv.FormatBool(stats.GetShardedQueries() > 0)
 
-	if stats != nil {
+	if f.cfg.QueryStatsEnabled {
 		// Track stats.
 		f.querySeconds.WithLabelValues(userID, sharded).Add(wallTime.Seconds())
 		f.querySeries.WithLabelValues(userID).Add(float64(numSeries))
@@ -282,7 +361,8 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 		"sharded_queries", stats.LoadShardedQueries(),
 		"split_queries", stats.LoadSplitQueries(),
 		"estimated_series_count", stats.GetEstimatedSeriesCount(),
-	}, formatQueryString(queryString)...)
+	}, queryTimingBreakdown(stats)...)
+	logMessage = append(logMessage, formatQueryString(queryString)...)
 
 	if queryErr != nil {
 		logStatus := "failed"
@@ -303,6 +383,16 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
 }
 
+// queryTimingBreakdown returns the time spent by the queriers in each stage of the query execution, as log fields.
+func queryTimingBreakdown(stats *querier_stats.Stats) []interface{} {
+	return []interface{}{
+		"ingester_fetch_time_seconds", stats.LoadIngesterFetchTime().Seconds(),
+		"store_gateway_fetch_time_seconds", stats.LoadStoreGatewayFetchTime().Seconds(),
+		"merge_time_seconds", stats.LoadMergeTime().Seconds(),
+		"eval_time_seconds", stats.LoadEvalTime().Seconds(),
+	}
+}
+
 func formatQueryString(queryString url.Values) (fields []interface{}) {
 	for k, v := range queryString {
 		fields = append(fields, fmt.Sprintf("param_%s", k), strings.Join(v, ","))
@@ -335,6 +425,10 @@ func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Head
 	if stats != nil {
 		parts := make([]string, 0)
 		parts = append(parts, statsValue("querier_wall_time", stats.LoadWallTime()))
+		parts = append(parts, statsValue("ingester_fetch_time", stats.LoadIngesterFetchTime()))
+		parts = append(parts, statsValue("store_gateway_fetch_time", stats.LoadStoreGatewayFetchTime()))
+		parts = append(parts, statsValue("merge_time", stats.LoadMergeTime()))
+		parts = append(parts, statsValue("eval_time", stats.LoadEvalTime()))
 		parts = append(parts, statsValue("response_time", queryResponseTime))
 		headers.Set(ServiceTimingHeaderName, strings.Join(parts, ", "))
 	}
@@ -31,6 +31,7 @@ import (
 	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
 
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
 )
 
@@ -136,6 +137,13 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				assert.NoError(t, req.ParseForm())
 				assert.Equal(t, tt.expectedParams, req.Form)
 
+				// The timing breakdown is tracked by the queriers.
+				queryStats := querier_stats.FromContext(req.Context())
+				queryStats.AddIngesterFetchTime(time.Second)
+				queryStats.AddStoreGatewayFetchTime(2 * time.Second)
+				queryStats.AddMergeTime(3 * time.Second)
+				queryStats.AddEvalTime(4 * time.Second)
+
 				return &http.Response{
 					StatusCode: http.StatusOK,
 					Body:       io.NopCloser(strings.NewReader("{}")),
@@ -149,7 +157,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			t.Cleanup(func() { require.NoError(t, at.Close()) })
 
 			logger := &testLogger{}
-			handler := NewHandler(tt.cfg, roundTripper, logger, reg, at)
+			handler := NewHandler(tt.cfg, roundTripper, nil, logger, reg, at)
 
 			req := tt.request().WithContext(user.InjectOrgID(context.Background(), "12345"))
 			resp := httptest.NewRecorder()
@@ -178,7 +186,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				require.Len(t, logger.logMessages, 1)
 
 				msg := logger.logMessages[0]
-				require.Len(t, msg, 17+len(tt.expectedParams))
+				require.Len(t, msg, 21+len(tt.expectedParams))
 				require.Equal(t, level.InfoValue(), msg["level"])
 				require.Equal(t, "query stats", msg["msg"])
 				require.Equal(t, "query-frontend", msg["component"])
@@ -196,6 +204,11 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				require.EqualValues(t, 0, msg["sharded_queries"])
 				require.EqualValues(t, 0, msg["split_queries"])
 				require.EqualValues(t, 0, msg["estimated_series_count"])
+				require.EqualValues(t, 1, msg["ingester_fetch_time_seconds"])
+				require.EqualValues(t, 2, msg["store_gateway_fetch_time_seconds"])
+				require.EqualValues(t, 3, msg["merge_time_seconds"])
+				require.EqualValues(t, 4, msg["eval_time_seconds"])
+				require.Contains(t, resp.Header().Get(ServiceTimingHeaderName), "ingester_fetch_time;dur=1000, store_gateway_fetch_time;dur=2000, merge_time;dur=3000, eval_time;dur=4000")
 
 				for name, values := range tt.expectedParams {
 					logMessageKey := fmt.Sprintf("param_%v", name)
@@ -243,7 +256,7 @@ func TestHandler_FailedRoundTrip(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
 			logs := &concurrency.SyncBuffer{}
 			logger := log.NewLogfmtLogger(logs)
-			handler := NewHandler(test.cfg, roundTripper, logger, reg, nil)
+			handler := NewHandler(test.cfg, roundTripper, nil, logger, reg, nil)
 
 			ctx := user.InjectOrgID(context.Background(), "12345")
 			req := httptest.NewRequest("GET", test.path, nil)
@@ -274,6 +287,57 @@ func TestHandler_FailedRoundTrip(t *testing.T) {
 	}
 }
 
+func TestHandler_MaxConcurrentRequestsPerTenant(t *testing.T) {
+	unblock := make(chan struct{})
+	var inProgress atomic.Int32
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		inProgress.Inc()
+		<-unblock
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	cfg := HandlerConfig{MaxBodySize: 1024, MaxConcurrentRequestsPerTenant: 1, MaxQueuedRequestsPerTenant: 1, QueuedRequestsTimeout: time.Minute}
+	handler := NewHandler(cfg, roundTripper, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil)
+
+	serve := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), userID))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// Run the request reaching the limit, and the one waiting in the queue.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, serve("user-1").Code)
+		}()
+	}
+
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return queueLength(handler.concurrencyLimiter, "user-1")
+	})
+	require.Equal(t, int32(1), inProgress.Load())
+
+	// The request exceeding the queue is rejected.
+	resp := serve("user-1")
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+	require.Contains(t, resp.Body.String(), "err-mimir-query-frontend-max-concurrent-requests-per-tenant")
+
+	close(unblock)
+	wg.Wait()
+
+	// The requests of other tenants aren't affected.
+	require.Equal(t, http.StatusOK, serve("user-2").Code)
+	require.Equal(t, int32(3), inProgress.Load())
+}
+
 // Test Handler.Stop.
 func TestHandler_Stop(t *testing.T) {
 	const (
@@ -295,7 +359,7 @@ func TestHandler_Stop(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
 	cfg := HandlerConfig{MaxBodySize: 1024}
 	logger := &testLogger{}
-	handler := NewHandler(cfg, roundTripper, logger, reg, nil)
+	handler := NewHandler(cfg, roundTripper, nil, logger, reg, nil)
 
 	var wg sync.WaitGroup
 	for i := 0; i < requests; i++ {
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/tenant"
+
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// RejectedQueryIDHeaderName is the response header holding the ID of a rejected query.
+	RejectedQueryIDHeaderName = "X-Rejected-Query-Id"
+	// RejectedQueryFingerprintHeaderName is the response header holding the fingerprint of a rejected query.
+	RejectedQueryFingerprintHeaderName = "X-Rejected-Query-Fingerprint"
+
+	rejectionComponent = "query-frontend"
+)
+
+// RejectedQuery describes why a query has been rejected.
+type RejectedQuery struct {
+	ID          string            `json:"id"`
+	Fingerprint string            `json:"fingerprint"`
+	Time        time.Time         `json:"time"`
+	Path        string            `json:"path"`
+	Params      map[string]string `json:"params"`
+	Error       string            `json:"error"`
+
+	// The limit which rejected the query, along with the measured value and the configured limit,
+	// and the component which enforced it.
+	Limit           string  `json:"limit"`
+	Component       string  `json:"component"`
+	MeasuredValue   float64 `json:"measured_value"`
+	ConfiguredLimit float64 `json:"configured_limit"`
+}
+
+// rejectedQueriesCache keeps the queries rejected because of a limit in the results cache, which is shared by
+// all the query-frontends, so that a rejected query can be looked up on any replica. Each query is stored both
+// by query ID and by query fingerprint, and expires after the TTL.
+type rejectedQueriesCache struct {
+	cache  cache.Cache
+	ttl    time.Duration
+	logger log.Logger
+}
+
+func newRejectedQueriesCache(c cache.Cache, ttl time.Duration, logger log.Logger) *rejectedQueriesCache {
+	return &rejectedQueriesCache{
+		cache:  c,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// add stores the rejected query of the tenant. The fingerprint refers to the latest rejection of the query.
+func (c *rejectedQueriesCache) add(tenantID string, q *RejectedQuery) {
+	data, err := json.Marshal(q)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to encode the rejected query", "err", err)
+		return
+	}
+
+	c.cache.StoreAsync(map[string][]byte{
+		rejectedQueryIDCacheKey(tenantID, q.ID):                   data,
+		rejectedQueryFingerprintCacheKey(tenantID, q.Fingerprint): data,
+	}, c.ttl)
+}
+
+// get returns the rejected query of the tenant with the input ID or fingerprint, if any.
+func (c *rejectedQueriesCache) get(ctx context.Context, tenantID, id, fingerprint string) (*RejectedQuery, bool) {
+	key := rejectedQueryIDCacheKey(tenantID, id)
+	if id == "" {
+		key = rejectedQueryFingerprintCacheKey(tenantID, fingerprint)
+	}
+
+	data, ok := c.cache.Fetch(ctx, []string{key})[key]
+	if !ok {
+		return nil, false
+	}
+
+	q := &RejectedQuery{}
+	if err := json.Unmarshal(data, q); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to decode the cached rejected query", "key", key, "err", err)
+		return nil, false
+	}
+	return q, true
+}
+
+// rejectedQueryIDCacheKey returns the cache key of the rejected query of the tenant with the input ID. The tenant
+// is hashed together with the ID, so that the key has a bounded length and the tenants can't see each other's queries.
+func rejectedQueryIDCacheKey(tenantID, id string) string {
+	return "RQ:id:" + hashRejectedQueryKey(tenantID, id)
+}
+
+// rejectedQueryFingerprintCacheKey returns the cache key of the latest rejection of the query of the tenant
+// with the input fingerprint.
+func rejectedQueryFingerprintCacheKey(tenantID, fingerprint string) string {
+	return "RQ:fp:" + hashRejectedQueryKey(tenantID, fingerprint)
+}
+
+func hashRejectedQueryKey(tenantID, key string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(tenantID))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// queryFingerprint returns the fingerprint of the query of the input tenant, so that the same query
+// run multiple times can be looked up regardless of the query ID it has been given.
+func queryFingerprint(tenantID, path string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(tenantID))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(path))
+	for _, k := range keys {
+		for _, v := range params[k] {
+			_, _ = h.Write([]byte{0})
+			_, _ = h.Write([]byte(k))
+			_, _ = h.Write([]byte{'='})
+			_, _ = h.Write([]byte(v))
+		}
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// recordRejectedQuery records the query rejected because of the input limit in the cache, and sets the
+// headers identifying it in the response. It's a no-op if the query hasn't been rejected by a limit, or if
+// the rejected queries cache is disabled.
+func (f *Handler) recordRejectedQuery(r *http.Request, params url.Values, rejection *querier_stats.Rejection, queryErr error, headers http.Header) {
+	if f.rejectedQueries == nil || rejection == nil {
+		return
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return
+	}
+	tenantID := tenant.JoinTenantIDs(tenantIDs)
+
+	q := &RejectedQuery{
+		ID:              fmt.Sprintf("%016x", rand.Uint64()),
+		Fingerprint:     queryFingerprint(tenantID, r.URL.Path, params),
+		Time:            time.Now(),
+		Path:            r.URL.Path,
+		Params:          make(map[string]string, len(params)),
+		Limit:           rejection.Limit,
+		Component:       rejection.Component,
+		MeasuredValue:   rejection.MeasuredValue,
+		ConfiguredLimit: rejection.ConfiguredLimit,
+	}
+	for k, v := range params {
+		q.Params[k] = strings.Join(v, ",")
+	}
+	if queryErr != nil {
+		q.Error = queryErr.Error()
+	}
+
+	f.rejectedQueries.add(tenantID, q)
+	headers.Set(RejectedQueryIDHeaderName, q.ID)
+	headers.Set(RejectedQueryFingerprintHeaderName, q.Fingerprint)
+}
+
+// RejectedQueryHandler returns, for the authenticated tenant, why the query with the input ID or fingerprint
+// has been rejected: the limit which rejected it, the measured value versus the configured limit and the
+// component which enforced it. The queries are only kept for -query-frontend.rejected-queries-cache-ttl.
+func (f *Handler) RejectedQueryHandler(w http.ResponseWriter, r *http.Request) {
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if f.rejectedQueries == nil {
+		http.Error(w, "the rejected queries cache is disabled", http.StatusNotFound)
+		return
+	}
+
+	id, fingerprint := r.FormValue("id"), r.FormValue("fingerprint")
+	if id == "" && fingerprint == "" {
+		http.Error(w, "either the id or the fingerprint of the query is required", http.StatusBadRequest)
+		return
+	}
+
+	q, ok := f.rejectedQueries.get(r.Context(), tenant.JoinTenantIDs(tenantIDs), id, fingerprint)
+	if !ok {
+		http.Error(w, "rejected query not found", http.StatusNotFound)
+		return
+	}
+
+	util.WriteJSONResponse(w, q)
+}
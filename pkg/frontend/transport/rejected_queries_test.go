@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+)
+
+func TestRejectedQueriesCache(t *testing.T) {
+	ctx := context.Background()
+	c := newRejectedQueriesCache(cache.NewMockCache(), time.Minute, log.NewNopLogger())
+
+	c.add("user-1", &RejectedQuery{ID: "1", Fingerprint: "a", Limit: "max-series-per-query"})
+	c.add("user-1", &RejectedQuery{ID: "2", Fingerprint: "a", Limit: "max-chunks-per-query"})
+	c.add("user-2", &RejectedQuery{ID: "3", Fingerprint: "b"})
+
+	q, ok := c.get(ctx, "user-1", "1", "")
+	require.True(t, ok)
+	assert.Equal(t, "max-series-per-query", q.Limit)
+
+	// The fingerprint refers to the latest rejection of the query.
+	q, ok = c.get(ctx, "user-1", "", "a")
+	require.True(t, ok)
+	assert.Equal(t, "2", q.ID)
+
+	// The queries of other tenants aren't returned.
+	_, ok = c.get(ctx, "user-1", "3", "")
+	assert.False(t, ok)
+	_, ok = c.get(ctx, "user-1", "", "b")
+	assert.False(t, ok)
+	q, ok = c.get(ctx, "user-2", "3", "")
+	require.True(t, ok)
+	assert.Equal(t, "b", q.Fingerprint)
+
+	_, ok = c.get(ctx, "user-1", "unknown", "")
+	assert.False(t, ok)
+}
+
+func TestRejectedQueriesCache_ShouldExpireTheQueries(t *testing.T) {
+	c := newRejectedQueriesCache(cache.NewMockCache(), time.Millisecond, log.NewNopLogger())
+	c.add("user-1", &RejectedQuery{ID: "1", Fingerprint: "a"})
+
+	time.Sleep(10 * time.Millisecond)
+
+	_, ok := c.get(context.Background(), "user-1", "1", "")
+	assert.False(t, ok)
+	_, ok = c.get(context.Background(), "user-1", "", "a")
+	assert.False(t, ok)
+}
+
+func TestQueryFingerprint(t *testing.T) {
+	params := url.Values{"query": []string{"up"}, "time": []string{"1"}}
+
+	assert.Equal(t, queryFingerprint("user-1", "/api/v1/query", params), queryFingerprint("user-1", "/api/v1/query", url.Values{"time": []string{"1"}, "query": []string{"up"}}))
+	assert.NotEqual(t, queryFingerprint("user-1", "/api/v1/query", params), queryFingerprint("user-2", "/api/v1/query", params))
+	assert.NotEqual(t, queryFingerprint("user-1", "/api/v1/query", params), queryFingerprint("user-1", "/api/v1/query_range", params))
+	assert.NotEqual(t, queryFingerprint("user-1", "/api/v1/query", params), queryFingerprint("user-1", "/api/v1/query", url.Values{"query": []string{"up"}}))
+}
+
+func TestHandler_RejectedQueryHandler(t *testing.T) {
+	rejection := &querier_stats.Rejection{Limit: "max-series-per-query", Component: "querier", MeasuredValue: 11, ConfiguredLimit: 10}
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// The rejection is propagated from the querier through the query stats.
+		querier_stats.FromContext(req.Context()).RecordRejection(rejection)
+		return nil, httpgrpc.Errorf(http.StatusUnprocessableEntity, "the query exceeded the maximum number of series")
+	})
+
+	// The rejected queries are stored in the results cache, which is shared by all the query-frontends.
+	resultsCache := cache.NewMockCache()
+	cfg := HandlerConfig{MaxBodySize: 1024, RejectedQueriesCacheTTL: time.Minute}
+	handler := NewHandler(cfg, roundTripper, resultsCache, log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil)
+	otherReplica := NewHandler(cfg, roundTripper, resultsCache, log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil).WithContext(ctx))
+	require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+
+	id := resp.Header().Get(RejectedQueryIDHeaderName)
+	fingerprint := resp.Header().Get(RejectedQueryFingerprintHeaderName)
+	require.NotEmpty(t, id)
+	require.NotEmpty(t, fingerprint)
+
+	tests := map[string]struct {
+		handler        *Handler
+		ctx            context.Context
+		url            string
+		expectedStatus int
+	}{
+		"by id": {
+			ctx:            ctx,
+			url:            "/api/v1/rejected_query?id=" + id,
+			expectedStatus: http.StatusOK,
+		},
+		"by fingerprint": {
+			ctx:            ctx,
+			url:            "/api/v1/rejected_query?fingerprint=" + fingerprint,
+			expectedStatus: http.StatusOK,
+		},
+		"on another query-frontend": {
+			handler:        otherReplica,
+			ctx:            ctx,
+			url:            "/api/v1/rejected_query?id=" + id,
+			expectedStatus: http.StatusOK,
+		},
+		"unknown id": {
+			ctx:            ctx,
+			url:            "/api/v1/rejected_query?id=unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		"query of another tenant": {
+			ctx:            user.InjectOrgID(context.Background(), "user-2"),
+			url:            "/api/v1/rejected_query?id=" + id,
+			expectedStatus: http.StatusNotFound,
+		},
+		"missing id and fingerprint": {
+			ctx:            ctx,
+			url:            "/api/v1/rejected_query",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"missing tenant": {
+			ctx:            context.Background(),
+			url:            "/api/v1/rejected_query?id=" + id,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			h := handler
+			if testData.handler != nil {
+				h = testData.handler
+			}
+
+			rec := httptest.NewRecorder()
+			h.RejectedQueryHandler(rec, httptest.NewRequest(http.MethodGet, testData.url, nil).WithContext(testData.ctx))
+			require.Equal(t, testData.expectedStatus, rec.Code)
+			if testData.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var q RejectedQuery
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &q))
+			assert.Equal(t, id, q.ID)
+			assert.Equal(t, fingerprint, q.Fingerprint)
+			assert.Equal(t, "/api/v1/query", q.Path)
+			assert.Equal(t, map[string]string{"query": "up"}, q.Params)
+			assert.Equal(t, "rpc error: code = Code(422) desc = the query exceeded the maximum number of series", q.Error)
+			assert.Equal(t, "max-series-per-query", q.Limit)
+			assert.Equal(t, "querier", q.Component)
+			assert.Equal(t, 11.0, q.MeasuredValue)
+			assert.Equal(t, 10.0, q.ConfiguredLimit)
+		})
+	}
+
+	t.Run("disabled cache", func(t *testing.T) {
+		handler := NewHandler(HandlerConfig{MaxBodySize: 1024}, roundTripper, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil)
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil).WithContext(ctx))
+		require.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+		assert.Empty(t, resp.Header().Get(RejectedQueryIDHeaderName))
+
+		rec := httptest.NewRecorder()
+		handler.RejectedQueryHandler(rec, httptest.NewRequest(http.MethodGet, "/api/v1/rejected_query?id="+id, nil).WithContext(ctx))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestHandler_RejectedQueryHandler_ConcurrencyLimit(t *testing.T) {
+	unblock := make(chan struct{})
+	started := make(chan struct{})
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-unblock
+		return nil, context.Canceled
+	})
+
+	cfg := HandlerConfig{MaxBodySize: 1024, MaxConcurrentRequestsPerTenant: 1, MaxQueuedRequestsPerTenant: 0, QueuedRequestsTimeout: time.Minute, RejectedQueriesCacheTTL: time.Minute}
+	handler := NewHandler(cfg, roundTripper, cache.NewMockCache(), log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil).WithContext(ctx))
+	}()
+	<-started
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil).WithContext(ctx))
+	require.Equal(t, http.StatusTooManyRequests, resp.Code)
+
+	close(unblock)
+	<-done
+
+	q, ok := handler.rejectedQueries.get(ctx, "user-1", resp.Header().Get(RejectedQueryIDHeaderName), "")
+	require.True(t, ok)
+	assert.Equal(t, "query-frontend-max-concurrent-requests-per-tenant", q.Limit)
+	assert.Equal(t, "query-frontend", q.Component)
+	assert.Equal(t, 1.0, q.MeasuredValue)
+	assert.Equal(t, 1.0, q.ConfiguredLimit)
+}
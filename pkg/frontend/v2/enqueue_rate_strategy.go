@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package v2
+
+import (
+	"math"
+
+	"github.com/grafana/dskit/limiter"
+	"github.com/grafana/dskit/tenant"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// enqueueRateStrategy is the rate limiter strategy of the queries enqueued by each tenant to the query-schedulers.
+// The limit is enforced by each query-frontend replica, so it's not split between them.
+type enqueueRateStrategy struct {
+	limits Limits
+}
+
+func newEnqueueRateStrategy(limits Limits) limiter.RateLimiterStrategy {
+	return &enqueueRateStrategy{limits: limits}
+}
+
+func (s *enqueueRateStrategy) Limit(userID string) float64 {
+	if limit := s.tenantsLimit(userID); limit > 0 {
+		return limit
+	}
+	return float64(rate.Inf)
+}
+
+func (s *enqueueRateStrategy) Burst(userID string) int {
+	limit := s.tenantsLimit(userID)
+	if limit <= 0 {
+		// Burst is ignored when limit = rate.Inf
+		return 0
+	}
+
+	tenantIDs, err := tenant.TenantIDsFromOrgID(userID)
+	if err != nil {
+		return 0
+	}
+	if burst := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.EnqueueBurstSize); burst > 0 {
+		return burst
+	}
+	return int(math.Ceil(limit))
+}
+
+// tenantsLimit returns the enqueue rate limit of the given user, which may be a multi-tenant user ID.
+func (s *enqueueRateStrategy) tenantsLimit(userID string) float64 {
+	tenantIDs, err := tenant.TenantIDsFromOrgID(userID)
+	if err != nil {
+		return 0
+	}
+	return validation.SmallestPositiveNonZeroFloat64PerTenant(tenantIDs, s.limits.EnqueueRateLimit)
+}
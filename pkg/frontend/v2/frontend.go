@@ -7,10 +7,13 @@ package v2
 
 import (
 	"context"
+	cryptorand "crypto/rand"
+	"encoding/binary"
 	"flag"
 	"fmt"
 	"math/rand"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +21,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/limiter"
 	"github.com/grafana/dskit/netutil"
 	"github.com/grafana/dskit/services"
 	"github.com/opentracing/opentracing-go"
@@ -32,9 +36,37 @@ import (
 	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerdiscovery"
+	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 )
 
+const (
+	rejectReasonUnknownQuery  = "unknown_query"
+	rejectReasonUserMismatch  = "user_mismatch"
+	rejectReasonNonceMismatch = "nonce_mismatch"
+)
+
+const (
+	// SchedulerTransportGRPC is the transport connecting the query-frontend to the query-scheduler over gRPC.
+	SchedulerTransportGRPC = "grpc"
+
+	// SchedulerTransportHTTP2 is the transport connecting the query-frontend to the query-scheduler over plain
+	// HTTP/2, for the environments where gRPC is blocked between them.
+	SchedulerTransportHTTP2 = "http2"
+)
+
+var schedulerTransports = []string{SchedulerTransportGRPC, SchedulerTransportHTTP2}
+
+// Limits needed for the query-frontend.
+type Limits interface {
+	// EnqueueRateLimit returns the max rate of queries per second a tenant can enqueue to the query-schedulers,
+	// or 0 if there's no limit.
+	EnqueueRateLimit(user string) float64
+
+	// EnqueueBurstSize returns the burst size for the rate of queries a tenant can enqueue to the query-schedulers.
+	EnqueueBurstSize(user string) int
+}
+
 // Config for a Frontend.
 type Config struct {
 	SchedulerAddress  string            `yaml:"scheduler_address"`
@@ -42,6 +74,11 @@ type Config struct {
 	WorkerConcurrency int               `yaml:"scheduler_worker_concurrency" category:"advanced"`
 	GRPCClientConfig  grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the query-frontends and the query-schedulers."`
 
+	SchedulerTenantAffinityEnabled bool `yaml:"scheduler_tenant_affinity_enabled" category:"experimental"`
+
+	SchedulerTransport string `yaml:"scheduler_transport" category:"experimental"`
+	SchedulerHTTPPort  int    `yaml:"scheduler_http_port" category:"experimental"`
+
 	// Used to find local IP address, that is sent to scheduler and querier-worker.
 	InfNames []string `yaml:"instance_interface_names" category:"advanced" doc:"default=[<private network interfaces>]"`
 
@@ -57,6 +94,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.StringVar(&cfg.SchedulerAddress, "query-frontend.scheduler-address", "", fmt.Sprintf("Address of the query-scheduler component, in host:port format. The host should resolve to all query-scheduler instances. This option should be set only when query-scheduler component is in use and -%s is set to '%s'.", schedulerdiscovery.ModeFlagName, schedulerdiscovery.ModeDNS))
 	f.DurationVar(&cfg.DNSLookupPeriod, "query-frontend.scheduler-dns-lookup-period", 10*time.Second, "How often to resolve the scheduler-address, in order to look for new query-scheduler instances.")
 	f.IntVar(&cfg.WorkerConcurrency, "query-frontend.scheduler-worker-concurrency", 5, "Number of concurrent workers forwarding queries to single query-scheduler.")
+	f.BoolVar(&cfg.SchedulerTenantAffinityEnabled, "query-frontend.scheduler-tenant-affinity-enabled", false, fmt.Sprintf("If enabled, the queries of each tenant are all enqueued to the same query-scheduler, picked via rendezvous hashing among the query-scheduler instances in use, instead of being spread across all query-schedulers. This allows the query-scheduler to enforce the per-tenant limits on the whole tenant queue. This option can be set only when -%s is set to '%s'.", schedulerdiscovery.ModeFlagName, schedulerdiscovery.ModeRing))
+	f.StringVar(&cfg.SchedulerTransport, "query-frontend.scheduler-transport", SchedulerTransportGRPC, fmt.Sprintf("Transport used to connect to the query-schedulers. Supported values are: %s. The %s transport connects over plain HTTP/2 to the HTTP server of the query-schedulers, for the environments where gRPC is blocked between the query-frontends and the query-schedulers.", strings.Join(schedulerTransports, ", "), SchedulerTransportHTTP2))
+	f.IntVar(&cfg.SchedulerHTTPPort, "query-frontend.scheduler-http-port", 8080, fmt.Sprintf("Port of the HTTP server of the query-schedulers, used when -query-frontend.scheduler-transport is set to '%s'.", SchedulerTransportHTTP2))
 
 	cfg.InfNames = netutil.PrivateNetworkInterfacesWithFallback([]string{"eth0", "en0"}, logger)
 	f.Var((*flagext.StringSlice)(&cfg.InfNames), "query-frontend.instance-interface-names", "List of network interface names to look up when finding the instance IP address. This address is sent to query-scheduler and querier, which uses it to send the query response back to query-frontend.")
@@ -70,6 +110,12 @@ func (cfg *Config) Validate(log log.Logger) error {
 	if cfg.QuerySchedulerDiscovery.Mode == schedulerdiscovery.ModeRing && cfg.SchedulerAddress != "" {
 		return fmt.Errorf("scheduler address cannot be specified when query-scheduler service discovery mode is set to '%s'", cfg.QuerySchedulerDiscovery.Mode)
 	}
+	if cfg.SchedulerTenantAffinityEnabled && cfg.QuerySchedulerDiscovery.Mode != schedulerdiscovery.ModeRing {
+		return fmt.Errorf("the query-scheduler tenant affinity can be enabled only when query-scheduler service discovery mode is set to '%s'", schedulerdiscovery.ModeRing)
+	}
+	if !util.StringsContain(schedulerTransports, cfg.SchedulerTransport) {
+		return fmt.Errorf("unsupported query-scheduler transport %q, supported values are: %s", cfg.SchedulerTransport, strings.Join(schedulerTransports, ", "))
+	}
 
 	return cfg.GRPCClientConfig.Validate(log)
 }
@@ -87,9 +133,16 @@ type Frontend struct {
 	// frontend workers will read from this channel, and send request to scheduler.
 	requestsCh chan *frontendRequest
 
-	schedulerWorkers        *frontendSchedulerWorkers
-	schedulerWorkersWatcher *services.FailureWatcher
-	requests                *requestsInProgress
+	schedulerWorkers   *frontendSchedulerWorkers
+	activeUsers        *util.ActiveUsersCleanupService
+	subservices        *services.Manager
+	subservicesWatcher *services.FailureWatcher
+	requests           *requestsInProgress
+
+	enqueueRateLimiter *limiter.RateLimiter
+
+	rejectedQueryResults *prometheus.CounterVec
+	throttledQueries     *prometheus.CounterVec
 }
 
 type frontendRequest struct {
@@ -98,6 +151,12 @@ type frontendRequest struct {
 	userID       string
 	statsEnabled bool
 
+	// Random nonce sent along with the query, which queriers must send back with the query result.
+	nonce uint64
+
+	// Estimated number of series touched by the query, or 0 if unknown.
+	estimatedSeriesCount uint64
+
 	cancel context.CancelFunc
 
 	enqueue  chan enqueueResult
@@ -121,27 +180,39 @@ type enqueueResult struct {
 }
 
 // NewFrontend creates a new frontend.
-func NewFrontend(cfg Config, log log.Logger, reg prometheus.Registerer) (*Frontend, error) {
+func NewFrontend(cfg Config, limits Limits, log log.Logger, reg prometheus.Registerer) (*Frontend, error) {
 	requestsCh := make(chan *frontendRequest)
+	requests := newRequestsInProgress()
 
-	schedulerWorkers, err := newFrontendSchedulerWorkers(cfg, fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port), requestsCh, log, reg)
+	schedulerWorkers, err := newFrontendSchedulerWorkers(cfg, fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port), requestsCh, requests, log, reg)
 	if err != nil {
 		return nil, err
 	}
 
 	f := &Frontend{
-		cfg:                     cfg,
-		log:                     log,
-		requestsCh:              requestsCh,
-		schedulerWorkers:        schedulerWorkers,
-		schedulerWorkersWatcher: services.NewFailureWatcher(),
-		requests:                newRequestsInProgress(),
+		cfg:                cfg,
+		log:                log,
+		requestsCh:         requestsCh,
+		schedulerWorkers:   schedulerWorkers,
+		subservicesWatcher: services.NewFailureWatcher(),
+		requests:           requests,
+		enqueueRateLimiter: limiter.NewRateLimiter(newEnqueueRateStrategy(limits), 10*time.Second),
 	}
 	// Randomize to avoid getting responses from queries sent before restart, which could lead to mixing results
 	// between different queries. Note that frontend verifies the user, so it cannot leak results between tenants.
 	// This isn't perfect, but better than nothing.
 	f.lastQueryID.Store(rand.Uint64())
 
+	f.rejectedQueryResults = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_frontend_rejected_query_results_total",
+		Help: "Total number of query results received from queriers and rejected by the frontend.",
+	}, []string{"reason"})
+
+	f.throttledQueries = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_frontend_enqueue_throttled_queries_total",
+		Help: "Total number of queries rejected by the frontend because the tenant exceeded the rate limit of queries enqueued to the query-schedulers.",
+	}, []string{"user"})
+
 	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
 		Name: "cortex_query_frontend_queries_in_progress",
 		Help: "Number of queries in progress handled by this frontend.",
@@ -156,27 +227,37 @@ func NewFrontend(cfg Config, log log.Logger, reg prometheus.Registerer) (*Fronte
 		return float64(f.schedulerWorkers.getWorkersCount())
 	})
 
+	f.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(f.cleanupInactiveUserMetrics)
+	f.subservices, err = services.NewManager(f.schedulerWorkers, f.activeUsers)
+	if err != nil {
+		return nil, err
+	}
+
 	f.Service = services.NewBasicService(f.starting, f.running, f.stopping)
 	return f, nil
 }
 
 func (f *Frontend) starting(ctx context.Context) error {
-	f.schedulerWorkersWatcher.WatchService(f.schedulerWorkers)
+	f.subservicesWatcher.WatchManager(f.subservices)
 
-	return errors.Wrap(services.StartAndAwaitRunning(ctx, f.schedulerWorkers), "failed to start frontend scheduler workers")
+	return errors.Wrap(services.StartManagerAndAwaitHealthy(ctx, f.subservices), "failed to start frontend subservices")
 }
 
 func (f *Frontend) running(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
 		return nil
-	case err := <-f.schedulerWorkersWatcher.Chan():
+	case err := <-f.subservicesWatcher.Chan():
 		return errors.Wrap(err, "query-frontend subservice failed")
 	}
 }
 
 func (f *Frontend) stopping(_ error) error {
-	return errors.Wrap(services.StopAndAwaitTerminated(context.Background(), f.schedulerWorkers), "failed to stop frontend scheduler workers")
+	return errors.Wrap(services.StopManagerAndAwaitStopped(context.Background(), f.subservices), "failed to stop frontend subservices")
+}
+
+func (f *Frontend) cleanupInactiveUserMetrics(user string) {
+	f.throttledQueries.DeleteLabelValues(user)
 }
 
 // RoundTripGRPC round trips a proto (instead of an HTTP request).
@@ -191,6 +272,13 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 	}
 	userID := tenant.JoinTenantIDs(tenantIDs)
 
+	now := time.Now()
+	f.activeUsers.UpdateUserTimestamp(userID, now)
+	if !f.enqueueRateLimiter.AllowN(now, userID, 1) {
+		f.throttledQueries.WithLabelValues(userID).Inc()
+		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, "the tenant exceeded the rate limit of queries enqueued by the query-frontend to the query-schedulers (limit: %g queries per second)", f.enqueueRateLimiter.Limit(now, userID))
+	}
+
 	// Propagate trace context in gRPC too - this will be ignored if using HTTP.
 	tracer, span := opentracing.GlobalTracer(), opentracing.SpanFromContext(ctx)
 	if tracer != nil && span != nil {
@@ -208,6 +296,9 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 		request:      req,
 		userID:       userID,
 		statsEnabled: stats.IsEnabled(ctx),
+		nonce:        newQueryNonce(),
+
+		estimatedSeriesCount: stats.EstimatedSeriesCountFromContext(ctx),
 
 		cancel: cancel,
 
@@ -224,11 +315,30 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 
 enqueueAgain:
 	var cancelCh chan<- uint64
+
+	// If the scheduler tenant affinity is enabled, the request is enqueued to the query-scheduler the tenant
+	// is assigned to. If there's no query-scheduler yet, it's enqueued to the first one which becomes available.
+	requestsCh := f.requestsCh
+	var workerDone <-chan struct{}
+	if f.cfg.SchedulerTenantAffinityEnabled {
+		if w := f.schedulerWorkers.getWorkerForTenant(userID); w != nil {
+			requestsCh, workerDone = w.tenantRequestCh, w.ctx.Done()
+		}
+	}
+
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 
-	case f.requestsCh <- freq:
+	case <-workerDone:
+		// The query-scheduler has been removed before the request was enqueued, so pick the query-scheduler again.
+		retries--
+		if retries > 0 {
+			goto enqueueAgain
+		}
+		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to enqueue request")
+
+	case requestsCh <- freq:
 		// Enqueued, let's wait for response.
 		enqRes := <-freq.enqueue
 		if enqRes.status == waitForResponse {
@@ -275,10 +385,25 @@ func (f *Frontend) QueryResult(ctx context.Context, qrReq *frontendv2pb.QueryRes
 	userID := tenant.JoinTenantIDs(tenantIDs)
 
 	req := f.requests.get(qrReq.QueryID)
-	// It is possible that some old response belonging to different user was received, if frontend has restarted.
-	// To avoid leaking query results between users, we verify the user here.
-	// To avoid mixing results from different queries, we randomize queryID counter on start.
-	if req != nil && req.userID == userID {
+
+	switch {
+	case req == nil:
+		// The query has already completed or has been canceled.
+		f.rejectedQueryResults.WithLabelValues(rejectReasonUnknownQuery).Inc()
+
+	case req.userID != userID:
+		// It is possible that some old response belonging to different user was received, if frontend has restarted.
+		// To avoid leaking query results between users, we verify the user here.
+		// To avoid mixing results from different queries, we randomize queryID counter on start.
+		f.rejectedQueryResults.WithLabelValues(rejectReasonUserMismatch).Inc()
+		level.Warn(f.log).Log("msg", "rejected query result for a query of a different user", "queryID", qrReq.QueryID, "user", userID)
+
+	case req.nonce != qrReq.Nonce:
+		// The querier sent a result for a query which hasn't been dispatched to it.
+		f.rejectedQueryResults.WithLabelValues(rejectReasonNonceMismatch).Inc()
+		level.Warn(f.log).Log("msg", "rejected query result with an invalid nonce", "queryID", qrReq.QueryID, "user", userID)
+
+	default:
 		select {
 		case req.response <- qrReq:
 			// Should always be possible, unless QueryResult is called multiple times with the same queryID.
@@ -290,6 +415,16 @@ func (f *Frontend) QueryResult(ctx context.Context, qrReq *frontendv2pb.QueryRes
 	return &frontendv2pb.QueryResultResponse{}, nil
 }
 
+// newQueryNonce returns a random nonce for a query. The nonce is generated using a cryptographically
+// secure random generator, so that queriers can't guess the nonce of queries not dispatched to them.
+func newQueryNonce() uint64 {
+	var b [8]byte
+	if _, err := cryptorand.Read(b[:]); err != nil {
+		return rand.Uint64()
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
 // CheckReady determines if the query frontend is ready.  Function parameters/return
 // chosen to match the same method in the ingester
 func (f *Frontend) CheckReady(_ context.Context) error {
@@ -7,7 +7,11 @@ package v2
 
 import (
 	"context"
+	"hash/fnv"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -44,6 +48,9 @@ type frontendSchedulerWorkers struct {
 	// Channel with requests that should be forwarded to the scheduler.
 	requestsCh <-chan *frontendRequest
 
+	// Requests in progress, used to fail requests the scheduler reports as waiting too long in the queue.
+	requests *requestsInProgress
+
 	schedulerDiscovery        services.Service
 	schedulerDiscoveryWatcher *services.FailureWatcher
 
@@ -54,12 +61,13 @@ type frontendSchedulerWorkers struct {
 	enqueuedRequests *prometheus.CounterVec
 }
 
-func newFrontendSchedulerWorkers(cfg Config, frontendAddress string, requestsCh <-chan *frontendRequest, log log.Logger, reg prometheus.Registerer) (*frontendSchedulerWorkers, error) {
+func newFrontendSchedulerWorkers(cfg Config, frontendAddress string, requestsCh <-chan *frontendRequest, requests *requestsInProgress, log log.Logger, reg prometheus.Registerer) (*frontendSchedulerWorkers, error) {
 	f := &frontendSchedulerWorkers{
 		cfg:                       cfg,
 		log:                       log,
 		frontendAddress:           frontendAddress,
 		requestsCh:                requestsCh,
+		requests:                  requests,
 		workers:                   map[string]*frontendSchedulerWorker{},
 		schedulerDiscoveryWatcher: services.NewFailureWatcher(),
 		enqueuedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
@@ -127,14 +135,14 @@ func (f *frontendSchedulerWorkers) addScheduler(address string) {
 	f.mu.Unlock()
 
 	level.Info(f.log).Log("msg", "adding connection to query-scheduler", "addr", address)
-	conn, err := f.connectToScheduler(context.Background(), address)
+	client, conn, err := f.connectToScheduler(context.Background(), address)
 	if err != nil {
 		level.Error(f.log).Log("msg", "error connecting to query-scheduler", "addr", address, "err", err)
 		return
 	}
 
 	// No worker for this address yet, start a new one.
-	w = newFrontendSchedulerWorker(conn, address, f.frontendAddress, f.requestsCh, f.cfg.WorkerConcurrency, f.enqueuedRequests.WithLabelValues(address), f.log)
+	w = newFrontendSchedulerWorker(client, conn, address, f.frontendAddress, f.requestsCh, f.requests, f.cfg.WorkerConcurrency, f.enqueuedRequests.WithLabelValues(address), f.log)
 
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -190,26 +198,82 @@ func (f *frontendSchedulerWorkers) getWorkersCount() int {
 	return len(f.workers)
 }
 
-func (f *frontendSchedulerWorkers) connectToScheduler(ctx context.Context, address string) (*grpc.ClientConn, error) {
+// getWorkerForTenant returns the worker of the query-scheduler the requests of the input tenant should be
+// enqueued to, or nil if there's no worker. The query-scheduler is picked via rendezvous hashing among the
+// query-schedulers in use, so that all query-frontends enqueue the requests of a tenant to the same
+// query-scheduler, and only the requests of the tenants assigned to a removed query-scheduler are moved
+// to another one.
+func (f *frontendSchedulerWorkers) getWorkerForTenant(userID string) *frontendSchedulerWorker {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var (
+		selected       *frontendSchedulerWorker
+		selectedWeight uint64
+	)
+
+	for addr, w := range f.workers {
+		weight := rendezvousWeight(userID, addr)
+
+		// Break ties on the address, to pick the same query-scheduler regardless of the map iteration order.
+		if selected == nil || weight > selectedWeight || (weight == selectedWeight && addr < selected.schedulerAddr) {
+			selected, selectedWeight = w, weight
+		}
+	}
+
+	return selected
+}
+
+// rendezvousWeight returns the weight of the query-scheduler for the input tenant. The tenant is assigned
+// to the query-scheduler with the highest weight.
+func rendezvousWeight(userID, schedulerAddr string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(schedulerAddr))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(userID))
+
+	// FNV doesn't spread well the hashes of inputs differing only by few bytes (e.g. the addresses of
+	// the query-schedulers), so the bits are mixed with the SplitMix64 finalizer.
+	weight := h.Sum64()
+	weight = (weight ^ (weight >> 30)) * 0xbf58476d1ce4e5b9
+	weight = (weight ^ (weight >> 27)) * 0x94d049bb133111eb
+	return weight ^ (weight >> 31)
+}
+
+// connectToScheduler returns the client of the query-scheduler at the input address over the configured transport,
+// and the connection to close once the client is no longer used.
+func (f *frontendSchedulerWorkers) connectToScheduler(ctx context.Context, address string) (schedulerpb.SchedulerForFrontendClient, io.Closer, error) {
+	if f.cfg.SchedulerTransport == SchedulerTransportHTTP2 {
+		// The query-scheduler addresses are the gRPC ones, so the port of the HTTP server is used instead.
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		client := schedulerpb.NewSchedulerForFrontendHTTPClient(net.JoinHostPort(host, strconv.Itoa(f.cfg.SchedulerHTTPPort)))
+		return client, client, nil
+	}
+
 	// Because we only use single long-running method, it doesn't make sense to inject user ID, send over tracing or add metrics.
 	opts, err := f.cfg.GRPCClientConfig.DialOption(nil, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	conn, err := grpc.DialContext(ctx, address, opts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return conn, nil
+	return schedulerpb.NewSchedulerForFrontendClient(conn), conn, nil
 }
 
-// Worker managing single gRPC connection to Scheduler. Each worker starts multiple goroutines for forwarding
+// Worker managing single connection to Scheduler. Each worker starts multiple goroutines for forwarding
 // requests and cancellations to scheduler.
 type frontendSchedulerWorker struct {
 	log log.Logger
 
-	conn          *grpc.ClientConn
+	client        schedulerpb.SchedulerForFrontendClient
+	conn          io.Closer
 	concurrency   int
 	schedulerAddr string
 	frontendAddr  string
@@ -221,6 +285,10 @@ type frontendSchedulerWorker struct {
 
 	// Shared between all frontend workers.
 	requestCh <-chan *frontendRequest
+	requests  *requestsInProgress
+
+	// Requests of the tenants assigned to this scheduler, when the scheduler tenant affinity is enabled.
+	tenantRequestCh chan *frontendRequest
 
 	// Cancellation requests for this scheduler are received via this channel. It is passed to frontend after
 	// query has been enqueued to scheduler.
@@ -230,14 +298,17 @@ type frontendSchedulerWorker struct {
 	enqueuedRequests prometheus.Counter
 }
 
-func newFrontendSchedulerWorker(conn *grpc.ClientConn, schedulerAddr string, frontendAddr string, requestCh <-chan *frontendRequest, concurrency int, enqueuedRequests prometheus.Counter, log log.Logger) *frontendSchedulerWorker {
+func newFrontendSchedulerWorker(client schedulerpb.SchedulerForFrontendClient, conn io.Closer, schedulerAddr string, frontendAddr string, requestCh <-chan *frontendRequest, requests *requestsInProgress, concurrency int, enqueuedRequests prometheus.Counter, log log.Logger) *frontendSchedulerWorker {
 	w := &frontendSchedulerWorker{
 		log:              log,
+		client:           client,
 		conn:             conn,
 		concurrency:      concurrency,
 		schedulerAddr:    schedulerAddr,
 		frontendAddr:     frontendAddr,
 		requestCh:        requestCh,
+		requests:         requests,
+		tenantRequestCh:  make(chan *frontendRequest),
 		cancelCh:         make(chan uint64, schedulerWorkerCancelChanCapacity),
 		enqueuedRequests: enqueuedRequests,
 	}
@@ -247,12 +318,11 @@ func newFrontendSchedulerWorker(conn *grpc.ClientConn, schedulerAddr string, fro
 }
 
 func (w *frontendSchedulerWorker) start() {
-	client := schedulerpb.NewSchedulerForFrontendClient(w.conn)
 	for i := 0; i < w.concurrency; i++ {
 		w.wg.Add(1)
 		go func() {
 			defer w.wg.Done()
-			w.runOne(w.ctx, client)
+			w.runOne(w.ctx, w.client)
 		}()
 	}
 }
@@ -320,6 +390,47 @@ func (w *frontendSchedulerWorker) schedulerLoop(loop schedulerpb.SchedulerForFro
 
 	ctx := loop.Context()
 
+	// The scheduler replies to each ENQUEUE and CANCEL message in order, but it can also send TOO_LONG_IN_QUEUE
+	// and DROPPED_FROM_QUEUE notifications at any time, so all messages are received by a dedicated goroutine.
+	// The goroutine terminates once the stream context is canceled after this function returns.
+	responses := make(chan *schedulerpb.SchedulerToFrontend)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			resp, err := loop.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			if resp.Status == schedulerpb.TOO_LONG_IN_QUEUE {
+				w.failEnqueuedRequest(resp.QueryID, "request has been waiting in the queue for too long")
+				continue
+			}
+			if resp.Status == schedulerpb.DROPPED_FROM_QUEUE {
+				w.failEnqueuedRequest(resp.QueryID, "request has been dropped from the queue by an operator")
+				continue
+			}
+
+			select {
+			case responses <- resp:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	recv := func() (*schedulerpb.SchedulerToFrontend, error) {
+		select {
+		case resp := <-responses:
+			return resp, nil
+		case err := <-recvErr:
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -332,60 +443,15 @@ func (w *frontendSchedulerWorker) schedulerLoop(loop schedulerpb.SchedulerForFro
 			return nil
 
 		case req := <-w.requestCh:
-			err := loop.Send(&schedulerpb.FrontendToScheduler{
-				Type:            schedulerpb.ENQUEUE,
-				QueryID:         req.queryID,
-				UserID:          req.userID,
-				HttpRequest:     req.request,
-				FrontendAddress: w.frontendAddr,
-				StatsEnabled:    req.statsEnabled,
-			})
-			w.enqueuedRequests.Inc()
-
-			if err != nil {
-				req.enqueue <- enqueueResult{status: failed}
+			if err := w.enqueueRequest(loop, recv, req); err != nil {
 				return err
 			}
 
-			resp, err := loop.Recv()
-			if err != nil {
-				req.enqueue <- enqueueResult{status: failed}
+		case req := <-w.tenantRequestCh:
+			if err := w.enqueueRequest(loop, recv, req); err != nil {
 				return err
 			}
 
-			switch resp.Status {
-			case schedulerpb.OK:
-				req.enqueue <- enqueueResult{status: waitForResponse, cancelCh: w.cancelCh}
-				// Response will come from querier.
-
-			case schedulerpb.SHUTTING_DOWN:
-				// Scheduler is shutting down, report failure to enqueue and stop this loop.
-				req.enqueue <- enqueueResult{status: failed}
-				return errors.New("scheduler is shutting down")
-
-			case schedulerpb.ERROR:
-				req.enqueue <- enqueueResult{status: waitForResponse}
-				req.response <- &frontendv2pb.QueryResultRequest{
-					HttpResponse: &httpgrpc.HTTPResponse{
-						Code: http.StatusInternalServerError,
-						Body: []byte(err.Error()),
-					},
-				}
-
-			case schedulerpb.TOO_MANY_REQUESTS_PER_TENANT:
-				req.enqueue <- enqueueResult{status: waitForResponse}
-				req.response <- &frontendv2pb.QueryResultRequest{
-					HttpResponse: &httpgrpc.HTTPResponse{
-						Code: http.StatusTooManyRequests,
-						Body: []byte("too many outstanding requests"),
-					},
-				}
-
-			default:
-				level.Error(w.log).Log("msg", "unknown response status from the scheduler", "resp", resp, "queryID", req.queryID)
-				req.enqueue <- enqueueResult{status: failed}
-			}
-
 		case reqID := <-w.cancelCh:
 			err := loop.Send(&schedulerpb.FrontendToScheduler{
 				Type:    schedulerpb.CANCEL,
@@ -396,7 +462,7 @@ func (w *frontendSchedulerWorker) schedulerLoop(loop schedulerpb.SchedulerForFro
 				return err
 			}
 
-			resp, err := loop.Recv()
+			resp, err := recv()
 			if err != nil {
 				return err
 			}
@@ -408,3 +474,88 @@ func (w *frontendSchedulerWorker) schedulerLoop(loop schedulerpb.SchedulerForFro
 		}
 	}
 }
+
+// enqueueRequest forwards the request to the scheduler, and reports the enqueue result to the request. It returns an
+// error if the stream to the scheduler should be closed.
+func (w *frontendSchedulerWorker) enqueueRequest(loop schedulerpb.SchedulerForFrontend_FrontendLoopClient, recv func() (*schedulerpb.SchedulerToFrontend, error), req *frontendRequest) error {
+	err := loop.Send(&schedulerpb.FrontendToScheduler{
+		Type:                 schedulerpb.ENQUEUE,
+		QueryID:              req.queryID,
+		UserID:               req.userID,
+		HttpRequest:          req.request,
+		FrontendAddress:      w.frontendAddr,
+		StatsEnabled:         req.statsEnabled,
+		Nonce:                req.nonce,
+		EstimatedSeriesCount: req.estimatedSeriesCount,
+	})
+	w.enqueuedRequests.Inc()
+
+	if err != nil {
+		req.enqueue <- enqueueResult{status: failed}
+		return err
+	}
+
+	resp, err := recv()
+	if err != nil {
+		req.enqueue <- enqueueResult{status: failed}
+		return err
+	}
+
+	switch resp.Status {
+	case schedulerpb.OK:
+		req.enqueue <- enqueueResult{status: waitForResponse, cancelCh: w.cancelCh}
+		// Response will come from querier.
+
+	case schedulerpb.SHUTTING_DOWN:
+		// Scheduler is shutting down, report failure to enqueue and stop this loop.
+		req.enqueue <- enqueueResult{status: failed}
+		return errors.New("scheduler is shutting down")
+
+	case schedulerpb.ERROR:
+		req.enqueue <- enqueueResult{status: waitForResponse}
+		req.response <- &frontendv2pb.QueryResultRequest{
+			HttpResponse: &httpgrpc.HTTPResponse{
+				Code: http.StatusInternalServerError,
+				Body: []byte(err.Error()),
+			},
+		}
+
+	case schedulerpb.TOO_MANY_REQUESTS_PER_TENANT:
+		req.enqueue <- enqueueResult{status: waitForResponse}
+		req.response <- &frontendv2pb.QueryResultRequest{
+			HttpResponse: &httpgrpc.HTTPResponse{
+				Code: http.StatusTooManyRequests,
+				Body: []byte("too many outstanding requests"),
+			},
+		}
+
+	default:
+		level.Error(w.log).Log("msg", "unknown response status from the scheduler", "resp", resp, "queryID", req.queryID)
+		req.enqueue <- enqueueResult{status: failed}
+	}
+
+	return nil
+}
+
+// failEnqueuedRequest responds to the request with the given ID with an error, after the scheduler reported
+// that the request won't be dispatched to queriers, either because it has been waiting in the queue longer
+// than the max queue wait time or because it has been dropped from the queue by an operator.
+func (w *frontendSchedulerWorker) failEnqueuedRequest(queryID uint64, reason string) {
+	req := w.requests.get(queryID)
+	if req == nil {
+		// Request already completed or canceled.
+		return
+	}
+
+	select {
+	case req.response <- &frontendv2pb.QueryResultRequest{
+		QueryID: queryID,
+		HttpResponse: &httpgrpc.HTTPResponse{
+			Code: http.StatusTooManyRequests,
+			Body: []byte(reason),
+		},
+	}:
+	default:
+		level.Warn(w.log).Log("msg", "failed to write response to the response channel for request not dispatched by the scheduler", "queryID", queryID, "addr", w.schedulerAddr, "reason", reason)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
 	"github.com/grafana/dskit/test"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -41,11 +42,21 @@ import (
 
 const testFrontendWorkerConcurrency = 5
 
-func setupFrontend(t *testing.T, reg prometheus.Registerer, schedulerReplyFunc func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend) (*Frontend, *mockScheduler) {
-	return setupFrontendWithConcurrencyAndServerOptions(t, reg, schedulerReplyFunc, testFrontendWorkerConcurrency)
+// forEachSchedulerTransport runs the test for each transport between the query-frontend and the query-scheduler.
+func forEachSchedulerTransport(t *testing.T, test func(t *testing.T, transport string)) {
+	for _, transport := range schedulerTransports {
+		transport := transport
+		t.Run(transport, func(t *testing.T) {
+			test(t, transport)
+		})
+	}
 }
 
-func setupFrontendWithConcurrencyAndServerOptions(t *testing.T, reg prometheus.Registerer, schedulerReplyFunc func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend, concurrency int, opts ...grpc.ServerOption) (*Frontend, *mockScheduler) {
+func setupFrontend(t *testing.T, transport string, reg prometheus.Registerer, schedulerReplyFunc func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend) (*Frontend, *mockScheduler) {
+	return setupFrontendWithConcurrencyAndServerOptions(t, transport, reg, mockLimits{}, schedulerReplyFunc, testFrontendWorkerConcurrency)
+}
+
+func setupFrontendWithConcurrencyAndServerOptions(t *testing.T, transport string, reg prometheus.Registerer, limits Limits, schedulerReplyFunc func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend, concurrency int, opts ...grpc.ServerOption) (*Frontend, *mockScheduler) {
 	l, err := net.Listen("tcp", "")
 	require.NoError(t, err)
 
@@ -63,9 +74,15 @@ func setupFrontendWithConcurrencyAndServerOptions(t *testing.T, reg prometheus.R
 	cfg.WorkerConcurrency = concurrency
 	cfg.Addr = h
 	cfg.Port = grpcPort
+	cfg.SchedulerTransport = transport
+
+	// The query-scheduler is also reachable over plain HTTP/2, on another port.
+	httpListener, err := net.Listen("tcp", "")
+	require.NoError(t, err)
+	cfg.SchedulerHTTPPort = httpListener.Addr().(*net.TCPAddr).Port
 
 	logger := log.NewLogfmtLogger(os.Stdout)
-	f, err := NewFrontend(cfg, logger, reg)
+	f, err := NewFrontend(cfg, limits, logger, reg)
 	require.NoError(t, err)
 
 	frontendv2pb.RegisterFrontendForQuerierServer(server, f)
@@ -73,13 +90,19 @@ func setupFrontendWithConcurrencyAndServerOptions(t *testing.T, reg prometheus.R
 	ms := newMockScheduler(t, f, schedulerReplyFunc)
 	schedulerpb.RegisterSchedulerForFrontendServer(server, ms)
 
+	httpServer := &http.Server{Handler: schedulerpb.NewFrontendLoopH2CHandler(http.NotFoundHandler(), ms)}
+
 	go func() {
 		_ = server.Serve(l)
 	}()
+	go func() {
+		_ = httpServer.Serve(httpListener)
+	}()
 
 	t.Cleanup(func() {
 		_ = l.Close()
 		server.GracefulStop()
+		_ = httpServer.Close()
 	})
 
 	require.NoError(t, services.StartAndAwaitRunning(context.Background(), f))
@@ -98,7 +121,7 @@ func setupFrontendWithConcurrencyAndServerOptions(t *testing.T, reg prometheus.R
 	return f, ms
 }
 
-func sendResponseWithDelay(f *Frontend, delay time.Duration, userID string, queryID uint64, resp *httpgrpc.HTTPResponse) {
+func sendResponseWithDelay(f *Frontend, delay time.Duration, userID string, queryID, nonce uint64, resp *httpgrpc.HTTPResponse) {
 	if delay > 0 {
 		time.Sleep(delay)
 	}
@@ -106,146 +129,297 @@ func sendResponseWithDelay(f *Frontend, delay time.Duration, userID string, quer
 	ctx := user.InjectOrgID(context.Background(), userID)
 	_, _ = f.QueryResult(ctx, &frontendv2pb.QueryResultRequest{
 		QueryID:      queryID,
+		Nonce:        nonce,
 		HttpResponse: resp,
 		Stats:        &stats.Stats{},
 	})
 }
 
 func TestFrontendBasicWorkflow(t *testing.T) {
-	const (
-		body   = "all fine here"
-		userID = "test"
-	)
-
-	f, _ := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
-		// We cannot call QueryResult directly, as Frontend is not yet waiting for the response.
-		// It first needs to be told that enqueuing has succeeded.
-		go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{
-			Code: 200,
-			Body: []byte(body),
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		const (
+			body   = "all fine here"
+			userID = "test"
+		)
+
+		f, _ := setupFrontend(t, transport, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+			// We cannot call QueryResult directly, as Frontend is not yet waiting for the response.
+			// It first needs to be told that enqueuing has succeeded.
+			go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, msg.Nonce, &httpgrpc.HTTPResponse{
+				Code: 200,
+				Body: []byte(body),
+			})
+
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
 		})
 
-		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(200), resp.Code)
+		require.Equal(t, []byte(body), resp.Body)
 	})
+}
 
-	resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
-	require.NoError(t, err)
-	require.Equal(t, int32(200), resp.Code)
-	require.Equal(t, []byte(body), resp.Body)
+func TestFrontendSendsEstimatedSeriesCountToScheduler(t *testing.T) {
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		const userID = "test"
+
+		estimatedSeriesCount := atomic.NewUint64(0)
+		f, _ := setupFrontend(t, transport, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+			estimatedSeriesCount.Store(msg.EstimatedSeriesCount)
+			go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, msg.Nonce, &httpgrpc.HTTPResponse{Code: 200})
+
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+		})
+
+		ctx := stats.ContextWithEstimatedSeriesCount(user.InjectOrgID(context.Background(), userID), 1000)
+		resp, err := f.RoundTripGRPC(ctx, &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(200), resp.Code)
+		require.Equal(t, uint64(1000), estimatedSeriesCount.Load())
+	})
 }
 
-func TestFrontendRequestsPerWorkerMetric(t *testing.T) {
-	const (
-		body   = "all fine here"
-		userID = "test"
-	)
-
-	reg := prometheus.NewRegistry()
-
-	f, _ := setupFrontend(t, reg, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
-		// We cannot call QueryResult directly, as Frontend is not yet waiting for the response.
-		// It first needs to be told that enqueuing has succeeded.
-		go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{
-			Code: 200,
-			Body: []byte(body),
+func TestFrontendRejectsInvalidQueryResults(t *testing.T) {
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		const (
+			body   = "all fine here"
+			userID = "test"
+		)
+
+		reg := prometheus.NewPedanticRegistry()
+		f, _ := setupFrontend(t, transport, reg, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+			go func() {
+				// Results with an invalid nonce or for a different user are rejected.
+				sendResponseWithDelay(f, 50*time.Millisecond, userID, msg.QueryID, msg.Nonce+1, &httpgrpc.HTTPResponse{Code: 200, Body: []byte("injected")})
+				sendResponseWithDelay(f, 0, "another-user", msg.QueryID, msg.Nonce, &httpgrpc.HTTPResponse{Code: 200, Body: []byte("injected")})
+
+				// Results for unknown queries are rejected.
+				sendResponseWithDelay(f, 0, userID, msg.QueryID+1, msg.Nonce, &httpgrpc.HTTPResponse{Code: 200, Body: []byte("injected")})
+
+				sendResponseWithDelay(f, 0, userID, msg.QueryID, msg.Nonce, &httpgrpc.HTTPResponse{Code: 200, Body: []byte(body)})
+			}()
+
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
 		})
 
-		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(200), resp.Code)
+		require.Equal(t, []byte(body), resp.Body)
+
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+				# HELP cortex_query_frontend_rejected_query_results_total Total number of query results received from queriers and rejected by the frontend.
+				# TYPE cortex_query_frontend_rejected_query_results_total counter
+				cortex_query_frontend_rejected_query_results_total{reason="nonce_mismatch"} 1
+				cortex_query_frontend_rejected_query_results_total{reason="unknown_query"} 1
+				cortex_query_frontend_rejected_query_results_total{reason="user_mismatch"} 1
+			`), "cortex_query_frontend_rejected_query_results_total"))
 	})
+}
 
-	expectedMetrics := fmt.Sprintf(`
-		# HELP cortex_query_frontend_workers_enqueued_requests_total Total number of requests enqueued by each query frontend worker (regardless of the result), labeled by scheduler address.
-		# TYPE cortex_query_frontend_workers_enqueued_requests_total counter
-		cortex_query_frontend_workers_enqueued_requests_total{scheduler_address="%s"} 0
-	`, f.cfg.SchedulerAddress)
-	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_query_frontend_workers_enqueued_requests_total"))
+func TestFrontendRequestsPerWorkerMetric(t *testing.T) {
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		const (
+			body   = "all fine here"
+			userID = "test"
+		)
 
-	resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
-	require.NoError(t, err)
-	require.Equal(t, int32(200), resp.Code)
-	require.Equal(t, []byte(body), resp.Body)
-
-	expectedMetrics = fmt.Sprintf(`
-		# HELP cortex_query_frontend_workers_enqueued_requests_total Total number of requests enqueued by each query frontend worker (regardless of the result), labeled by scheduler address.
-		# TYPE cortex_query_frontend_workers_enqueued_requests_total counter
-		cortex_query_frontend_workers_enqueued_requests_total{scheduler_address="%s"} 1
-	`, f.cfg.SchedulerAddress)
-	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_query_frontend_workers_enqueued_requests_total"))
-
-	// Manually remove the address, check that label is removed.
-	f.schedulerWorkers.InstanceRemoved(servicediscovery.Instance{Address: f.cfg.SchedulerAddress, InUse: true})
-	expectedMetrics = ``
-	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_query_frontend_workers_enqueued_requests_total"))
+		reg := prometheus.NewRegistry()
+
+		f, _ := setupFrontend(t, transport, reg, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+			// We cannot call QueryResult directly, as Frontend is not yet waiting for the response.
+			// It first needs to be told that enqueuing has succeeded.
+			go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, msg.Nonce, &httpgrpc.HTTPResponse{
+				Code: 200,
+				Body: []byte(body),
+			})
+
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+		})
+
+		expectedMetrics := fmt.Sprintf(`
+				# HELP cortex_query_frontend_workers_enqueued_requests_total Total number of requests enqueued by each query frontend worker (regardless of the result), labeled by scheduler address.
+				# TYPE cortex_query_frontend_workers_enqueued_requests_total counter
+				cortex_query_frontend_workers_enqueued_requests_total{scheduler_address="%s"} 0
+			`, f.cfg.SchedulerAddress)
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_query_frontend_workers_enqueued_requests_total"))
+
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(200), resp.Code)
+		require.Equal(t, []byte(body), resp.Body)
+
+		expectedMetrics = fmt.Sprintf(`
+				# HELP cortex_query_frontend_workers_enqueued_requests_total Total number of requests enqueued by each query frontend worker (regardless of the result), labeled by scheduler address.
+				# TYPE cortex_query_frontend_workers_enqueued_requests_total counter
+				cortex_query_frontend_workers_enqueued_requests_total{scheduler_address="%s"} 1
+			`, f.cfg.SchedulerAddress)
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_query_frontend_workers_enqueued_requests_total"))
+
+		// Manually remove the address, check that label is removed.
+		f.schedulerWorkers.InstanceRemoved(servicediscovery.Instance{Address: f.cfg.SchedulerAddress, InUse: true})
+		expectedMetrics = ``
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_query_frontend_workers_enqueued_requests_total"))
+	})
 }
 
 func TestFrontendRetryEnqueue(t *testing.T) {
-	// Frontend uses worker concurrency to compute number of retries. We use one less failure.
-	failures := atomic.NewInt64(testFrontendWorkerConcurrency - 1)
-	const (
-		body   = "hello world"
-		userID = "test"
-	)
-
-	f, _ := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
-		fail := failures.Dec()
-		if fail >= 0 {
-			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.SHUTTING_DOWN}
-		}
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		// Frontend uses worker concurrency to compute number of retries. We use one less failure.
+		failures := atomic.NewInt64(testFrontendWorkerConcurrency - 1)
+		const (
+			body   = "hello world"
+			userID = "test"
+		)
 
-		go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{
-			Code: 200,
-			Body: []byte(body),
-		})
+		f, _ := setupFrontend(t, transport, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+			fail := failures.Dec()
+			if fail >= 0 {
+				return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.SHUTTING_DOWN}
+			}
 
-		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+			go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, msg.Nonce, &httpgrpc.HTTPResponse{
+				Code: 200,
+				Body: []byte(body),
+			})
+
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+		})
+		_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
 	})
-	_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
-	require.NoError(t, err)
 }
 
 func TestFrontendTooManyRequests(t *testing.T) {
-	f, _ := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
-		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_MANY_REQUESTS_PER_TENANT}
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		f, _ := setupFrontend(t, transport, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_MANY_REQUESTS_PER_TENANT}
+		})
+
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), "test"), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
 	})
+}
 
-	resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), "test"), &httpgrpc.HTTPRequest{})
-	require.NoError(t, err)
-	require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+func TestFrontendEnqueueRateLimit(t *testing.T) {
+	// Set a multi tenant resolver.
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() { tenant.WithDefaultResolver(tenant.NewSingleResolver()) })
+
+	reg := prometheus.NewPedanticRegistry()
+	limits := mockLimits{enqueueRateLimits: map[string]float64{"user-1": 0.0001, "user-3": 0.0001}, enqueueBurstSizes: map[string]int{"user-1": 2}}
+	enqueued := atomic.NewInt64(0)
+
+	f, _ := setupFrontendWithConcurrencyAndServerOptions(t, SchedulerTransportGRPC, reg, limits, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		enqueued.Inc()
+		go sendResponseWithDelay(f, 0, msg.UserID, msg.QueryID, msg.Nonce, &httpgrpc.HTTPResponse{Code: http.StatusOK})
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	}, testFrontendWorkerConcurrency)
+
+	roundTrip := func(userID string) int32 {
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+		if err != nil {
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			require.True(t, ok)
+			return resp.Code
+		}
+		return resp.Code
+	}
+
+	// The burst of the tenant is enqueued, then its queries are throttled without reaching the query-scheduler.
+	require.Equal(t, int32(http.StatusOK), roundTrip("user-1"))
+	require.Equal(t, int32(http.StatusOK), roundTrip("user-1"))
+	require.Equal(t, int32(http.StatusTooManyRequests), roundTrip("user-1"))
+	require.Equal(t, int64(2), enqueued.Load())
+
+	// The other tenants are not affected.
+	require.Equal(t, int32(http.StatusOK), roundTrip("user-2"))
+	require.Equal(t, int32(http.StatusOK), roundTrip("user-2"))
+	require.Equal(t, int32(http.StatusOK), roundTrip("user-2"))
+
+	// The smallest limit of the tenants is enforced for the queries of multiple tenants, and the burst defaults to
+	// the limit rounded up.
+	require.Equal(t, int32(http.StatusOK), roundTrip("user-2|user-3"))
+	require.Equal(t, int32(http.StatusTooManyRequests), roundTrip("user-2|user-3"))
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_frontend_enqueue_throttled_queries_total Total number of queries rejected by the frontend because the tenant exceeded the rate limit of queries enqueued to the query-schedulers.
+		# TYPE cortex_query_frontend_enqueue_throttled_queries_total counter
+		cortex_query_frontend_enqueue_throttled_queries_total{user="user-1"} 1
+		cortex_query_frontend_enqueue_throttled_queries_total{user="user-2|user-3"} 1
+	`), "cortex_query_frontend_enqueue_throttled_queries_total"))
 }
 
-func TestFrontendEnqueueFailure(t *testing.T) {
-	f, _ := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
-		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.SHUTTING_DOWN}
+func TestFrontendTooLongInQueue(t *testing.T) {
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		f, ms := setupFrontend(t, transport, nil, nil)
+		ms.checkWithLock(func() {
+			ms.notifyFunc = func(msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+				return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_LONG_IN_QUEUE, QueryID: msg.QueryID}
+			}
+		})
+
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), "test"), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
 	})
+}
+
+func TestFrontendDroppedFromQueue(t *testing.T) {
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		f, ms := setupFrontend(t, transport, nil, nil)
+		ms.checkWithLock(func() {
+			ms.notifyFunc = func(msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+				return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.DROPPED_FROM_QUEUE, QueryID: msg.QueryID}
+			}
+		})
 
-	_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), "test"), &httpgrpc.HTTPRequest{})
-	require.Error(t, err)
-	require.True(t, strings.Contains(err.Error(), "failed to enqueue request"))
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), "test"), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(http.StatusTooManyRequests), resp.Code)
+		require.Equal(t, "request has been dropped from the queue by an operator", string(resp.Body))
+	})
+}
+
+func TestFrontendEnqueueFailure(t *testing.T) {
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		f, _ := setupFrontend(t, transport, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.SHUTTING_DOWN}
+		})
+
+		_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), "test"), &httpgrpc.HTTPRequest{})
+		require.Error(t, err)
+		require.True(t, strings.Contains(err.Error(), "failed to enqueue request"))
+	})
 }
 
 func TestFrontendCancellation(t *testing.T) {
-	f, ms := setupFrontend(t, nil, nil)
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		f, ms := setupFrontend(t, transport, nil, nil)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
-	defer cancel()
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
 
-	resp, err := f.RoundTripGRPC(user.InjectOrgID(ctx, "test"), &httpgrpc.HTTPRequest{})
-	require.EqualError(t, err, context.DeadlineExceeded.Error())
-	require.Nil(t, resp)
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(ctx, "test"), &httpgrpc.HTTPRequest{})
+		require.EqualError(t, err, context.DeadlineExceeded.Error())
+		require.Nil(t, resp)
 
-	// We wait a bit to make sure scheduler receives the cancellation request.
-	test.Poll(t, time.Second, 2, func() interface{} {
-		ms.mu.Lock()
-		defer ms.mu.Unlock()
+		// We wait a bit to make sure scheduler receives the cancellation request.
+		test.Poll(t, time.Second, 2, func() interface{} {
+			ms.mu.Lock()
+			defer ms.mu.Unlock()
 
-		return len(ms.msgs)
-	})
+			return len(ms.msgs)
+		})
 
-	ms.checkWithLock(func() {
-		require.Equal(t, 2, len(ms.msgs))
-		require.True(t, ms.msgs[0].Type == schedulerpb.ENQUEUE)
-		require.True(t, ms.msgs[1].Type == schedulerpb.CANCEL)
-		require.True(t, ms.msgs[0].QueryID == ms.msgs[1].QueryID)
+		ms.checkWithLock(func() {
+			require.Equal(t, 2, len(ms.msgs))
+			require.True(t, ms.msgs[0].Type == schedulerpb.ENQUEUE)
+			require.True(t, ms.msgs[1].Type == schedulerpb.CANCEL)
+			require.True(t, ms.msgs[0].QueryID == ms.msgs[1].QueryID)
+		})
 	})
 }
 
@@ -253,89 +427,106 @@ func TestFrontendCancellation(t *testing.T) {
 // we still need to make sure that the cancellation reach the scheduler at some point.
 // Issue: https://github.com/grafana/mimir/issues/740
 func TestFrontendWorkerCancellation(t *testing.T) {
-	f, ms := setupFrontend(t, nil, nil)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
-	defer cancel()
-
-	// send multiple requests > maxconcurrency of scheduler. So that it keeps all the frontend worker busy in serving requests.
-	reqCount := testFrontendWorkerConcurrency + 5
-	var wg sync.WaitGroup
-	for i := 0; i < reqCount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			resp, err := f.RoundTripGRPC(user.InjectOrgID(ctx, "test"), &httpgrpc.HTTPRequest{})
-			require.EqualError(t, err, context.DeadlineExceeded.Error())
-			require.Nil(t, resp)
-		}()
-	}
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		f, ms := setupFrontend(t, transport, nil, nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		// send multiple requests > maxconcurrency of scheduler. So that it keeps all the frontend worker busy in serving requests.
+		reqCount := testFrontendWorkerConcurrency + 5
+		var wg sync.WaitGroup
+		for i := 0; i < reqCount; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := f.RoundTripGRPC(user.InjectOrgID(ctx, "test"), &httpgrpc.HTTPRequest{})
+				require.EqualError(t, err, context.DeadlineExceeded.Error())
+				require.Nil(t, resp)
+			}()
+		}
 
-	wg.Wait()
+		wg.Wait()
 
-	// We wait a bit to make sure scheduler receives the cancellation request.
-	// 2 * reqCount because for every request, should also be corresponding cancel request
-	test.Poll(t, 5*time.Second, 2*reqCount, func() interface{} {
-		ms.mu.Lock()
-		defer ms.mu.Unlock()
+		// We wait a bit to make sure scheduler receives the cancellation request.
+		// 2 * reqCount because for every request, should also be corresponding cancel request
+		test.Poll(t, 5*time.Second, 2*reqCount, func() interface{} {
+			ms.mu.Lock()
+			defer ms.mu.Unlock()
 
-		return len(ms.msgs)
-	})
+			return len(ms.msgs)
+		})
 
-	ms.checkWithLock(func() {
-		require.Equal(t, 2*reqCount, len(ms.msgs))
-		msgTypeCounts := map[schedulerpb.FrontendToSchedulerType]int{}
-		for _, msg := range ms.msgs {
-			msgTypeCounts[msg.Type]++
-		}
-		expectedMsgTypeCounts := map[schedulerpb.FrontendToSchedulerType]int{
-			schedulerpb.ENQUEUE: reqCount,
-			schedulerpb.CANCEL:  reqCount,
-		}
-		require.Equalf(t, expectedMsgTypeCounts, msgTypeCounts,
-			"Should receive %d enqueue (%d) requests, and %d cancel (%d) requests.", reqCount, schedulerpb.ENQUEUE, reqCount, schedulerpb.CANCEL,
-		)
+		ms.checkWithLock(func() {
+			require.Equal(t, 2*reqCount, len(ms.msgs))
+			msgTypeCounts := map[schedulerpb.FrontendToSchedulerType]int{}
+			for _, msg := range ms.msgs {
+				msgTypeCounts[msg.Type]++
+			}
+			expectedMsgTypeCounts := map[schedulerpb.FrontendToSchedulerType]int{
+				schedulerpb.ENQUEUE: reqCount,
+				schedulerpb.CANCEL:  reqCount,
+			}
+			require.Equalf(t, expectedMsgTypeCounts, msgTypeCounts,
+				"Should receive %d enqueue (%d) requests, and %d cancel (%d) requests.", reqCount, schedulerpb.ENQUEUE, reqCount, schedulerpb.CANCEL,
+			)
+		})
 	})
 }
 
 func TestFrontendFailedCancellation(t *testing.T) {
-	f, ms := setupFrontend(t, nil, nil)
+	forEachSchedulerTransport(t, func(t *testing.T, transport string) {
+		f, ms := setupFrontend(t, transport, nil, nil)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-	go func() {
-		time.Sleep(100 * time.Millisecond)
-
-		// stop scheduler workers
-		addr := ""
-		f.schedulerWorkers.mu.Lock()
-		for k := range f.schedulerWorkers.workers {
-			addr = k
-			break
-		}
-		f.schedulerWorkers.mu.Unlock()
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+
+			// stop scheduler workers
+			addr := ""
+			f.schedulerWorkers.mu.Lock()
+			for k := range f.schedulerWorkers.workers {
+				addr = k
+				break
+			}
+			f.schedulerWorkers.mu.Unlock()
 
-		f.schedulerWorkers.InstanceRemoved(servicediscovery.Instance{Address: addr, InUse: true})
+			f.schedulerWorkers.InstanceRemoved(servicediscovery.Instance{Address: addr, InUse: true})
 
-		// Wait for worker goroutines to stop.
-		time.Sleep(100 * time.Millisecond)
+			// Wait for worker goroutines to stop.
+			time.Sleep(100 * time.Millisecond)
 
-		// Cancel request. Frontend will try to send cancellation to scheduler, but that will fail (not visible to user).
-		// Everything else should still work fine.
-		cancel()
-	}()
+			// Cancel request. Frontend will try to send cancellation to scheduler, but that will fail (not visible to user).
+			// Everything else should still work fine.
+			cancel()
+		}()
 
-	// send request
-	resp, err := f.RoundTripGRPC(user.InjectOrgID(ctx, "test"), &httpgrpc.HTTPRequest{})
-	require.EqualError(t, err, context.Canceled.Error())
-	require.Nil(t, resp)
+		// send request
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(ctx, "test"), &httpgrpc.HTTPRequest{})
+		require.EqualError(t, err, context.Canceled.Error())
+		require.Nil(t, resp)
 
-	ms.checkWithLock(func() {
-		require.Equal(t, 1, len(ms.msgs))
+		ms.checkWithLock(func() {
+			require.Equal(t, 1, len(ms.msgs))
+		})
 	})
 }
 
+type mockLimits struct {
+	enqueueRateLimits map[string]float64
+	enqueueBurstSizes map[string]int
+}
+
+func (m mockLimits) EnqueueRateLimit(userID string) float64 {
+	return m.enqueueRateLimits[userID]
+}
+
+func (m mockLimits) EnqueueBurstSize(userID string) int {
+	return m.enqueueBurstSizes[userID]
+}
+
 type mockScheduler struct {
 	t *testing.T
 	f *Frontend
@@ -345,6 +536,9 @@ type mockScheduler struct {
 	mu           sync.Mutex
 	frontendAddr map[string]int
 	msgs         []*schedulerpb.FrontendToScheduler
+
+	// If set, the returned message (if not nil) is sent to the frontend after the reply.
+	notifyFunc func(msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend
 }
 
 func newMockScheduler(t *testing.T, f *Frontend, replyFunc func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend) *mockScheduler {
@@ -381,6 +575,7 @@ func (m *mockScheduler) FrontendLoop(frontend schedulerpb.SchedulerForFrontend_F
 
 		m.mu.Lock()
 		m.msgs = append(m.msgs, msg)
+		notifyFunc := m.notifyFunc
 		m.mu.Unlock()
 
 		reply := &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
@@ -391,6 +586,14 @@ func (m *mockScheduler) FrontendLoop(frontend schedulerpb.SchedulerForFrontend_F
 		if err := frontend.Send(reply); err != nil {
 			return err
 		}
+
+		if notifyFunc != nil {
+			if notification := notifyFunc(msg); notification != nil {
+				if err := frontend.Send(notification); err != nil {
+					return err
+				}
+			}
+		}
 	}
 }
 
@@ -414,6 +617,30 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: `scheduler address cannot be specified when query-scheduler service discovery mode is set to 'ring'`,
 		},
+		"should pass if query-scheduler service discovery is set to ring, and scheduler tenant affinity is enabled": {
+			setup: func(cfg *Config) {
+				cfg.QuerySchedulerDiscovery.Mode = schedulerdiscovery.ModeRing
+				cfg.SchedulerTenantAffinityEnabled = true
+			},
+		},
+		"should fail if query-scheduler service discovery is set to dns, and scheduler tenant affinity is enabled": {
+			setup: func(cfg *Config) {
+				cfg.QuerySchedulerDiscovery.Mode = schedulerdiscovery.ModeDNS
+				cfg.SchedulerTenantAffinityEnabled = true
+			},
+			expectedErr: `the query-scheduler tenant affinity can be enabled only when query-scheduler service discovery mode is set to 'ring'`,
+		},
+		"should pass if the query-scheduler transport is http2": {
+			setup: func(cfg *Config) {
+				cfg.SchedulerTransport = SchedulerTransportHTTP2
+			},
+		},
+		"should fail if the query-scheduler transport is unsupported": {
+			setup: func(cfg *Config) {
+				cfg.SchedulerTransport = "http3"
+			},
+			expectedErr: `unsupported query-scheduler transport "http3"`,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -433,12 +660,53 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestFrontendSchedulerWorkers_getWorkerForTenant(t *testing.T) {
+	newWorkers := func(addrs ...string) *frontendSchedulerWorkers {
+		f := &frontendSchedulerWorkers{workers: map[string]*frontendSchedulerWorker{}}
+		for _, addr := range addrs {
+			f.workers[addr] = &frontendSchedulerWorker{schedulerAddr: addr}
+		}
+		return f
+	}
+
+	// No worker is returned if there's no query-scheduler.
+	assert.Nil(t, newWorkers().getWorkerForTenant("user-1"))
+
+	workers := newWorkers("scheduler-1:9095", "scheduler-2:9095", "scheduler-3:9095")
+
+	assigned := map[string]string{}
+	perScheduler := map[string]int{}
+	for i := 0; i < 100; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+
+		// The same query-scheduler is always picked for the same tenant.
+		addr := workers.getWorkerForTenant(userID).schedulerAddr
+		require.Equal(t, addr, workers.getWorkerForTenant(userID).schedulerAddr)
+
+		assigned[userID] = addr
+		perScheduler[addr]++
+	}
+
+	// Tenants are spread across all query-schedulers.
+	assert.Len(t, perScheduler, 3)
+
+	// Once a query-scheduler is removed, only its tenants are moved to another query-scheduler.
+	delete(workers.workers, "scheduler-2:9095")
+	for userID, addr := range assigned {
+		if addr == "scheduler-2:9095" {
+			assert.NotEqual(t, addr, workers.getWorkerForTenant(userID).schedulerAddr)
+		} else {
+			assert.Equal(t, addr, workers.getWorkerForTenant(userID).schedulerAddr)
+		}
+	}
+}
+
 func TestWithClosingGrpcServer(t *testing.T) {
 	// This test is easier with single frontend worker.
 	const frontendConcurrency = 1
 	const userID = "test"
 
-	f, _ := setupFrontendWithConcurrencyAndServerOptions(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+	f, _ := setupFrontendWithConcurrencyAndServerOptions(t, SchedulerTransportGRPC, nil, mockLimits{}, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
 		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_MANY_REQUESTS_PER_TENANT}
 	}, frontendConcurrency, grpc.KeepaliveParams(keepalive.ServerParameters{
 		MaxConnectionIdle:     100 * time.Millisecond,
@@ -35,6 +35,9 @@ type QueryResultRequest struct {
 	QueryID      uint64                 `protobuf:"varint,1,opt,name=queryID,proto3" json:"queryID,omitempty"`
 	HttpResponse *httpgrpc.HTTPResponse `protobuf:"bytes,2,opt,name=httpResponse,proto3" json:"httpResponse,omitempty"`
 	Stats        *stats.Stats           `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
+	// Nonce of the query, as received from the scheduler. The frontend rejects the result if it doesn't
+	// match the nonce the frontend generated when enqueuing the query.
+	Nonce uint64 `protobuf:"varint,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
 }
 
 func (m *QueryResultRequest) Reset()      { *m = QueryResultRequest{} }
@@ -90,6 +93,13 @@ func (m *QueryResultRequest) GetStats() *stats.Stats {
 	return nil
 }
 
+func (m *QueryResultRequest) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
 type QueryResultResponse struct {
 }
 
@@ -133,29 +143,29 @@ func init() {
 func init() { proto.RegisterFile("frontend.proto", fileDescriptor_eca3873955a29cfe) }
 
 var fileDescriptor_eca3873955a29cfe = []byte{
-	// 343 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x91, 0xbd, 0x4e, 0x3a, 0x41,
-	0x14, 0xc5, 0x67, 0xfe, 0x7f, 0x3f, 0x92, 0x81, 0x58, 0x8c, 0x1f, 0xd9, 0x50, 0xdc, 0xe0, 0x56,
-	0x54, 0x3b, 0x09, 0x1a, 0x0b, 0x4b, 0x62, 0x88, 0x76, 0xb2, 0x52, 0xd9, 0x2d, 0x38, 0x2c, 0x2b,
-	0xee, 0xce, 0x32, 0x33, 0x0b, 0xa1, 0xf3, 0x09, 0x8c, 0x8f, 0xe1, 0xa3, 0x58, 0x52, 0x52, 0xca,
-	0xd0, 0x58, 0xf2, 0x08, 0x66, 0x77, 0x80, 0x40, 0x4c, 0x6c, 0x26, 0xf7, 0xe6, 0x9e, 0x5f, 0xee,
-	0xb9, 0x67, 0xc8, 0x51, 0x4f, 0x8a, 0x44, 0xf3, 0xe4, 0xc9, 0x4b, 0xa5, 0xd0, 0x82, 0x96, 0xd7,
-	0xfd, 0xa8, 0x9e, 0x76, 0x2a, 0x27, 0xa1, 0x08, 0x45, 0x31, 0x60, 0x79, 0x65, 0x35, 0x95, 0xcb,
-	0x30, 0xd2, 0xfd, 0xac, 0xe3, 0x75, 0x45, 0xcc, 0xc6, 0x3c, 0x18, 0xf1, 0xb1, 0x90, 0x03, 0xc5,
-	0xba, 0x22, 0x8e, 0x45, 0xc2, 0xfa, 0x5a, 0xa7, 0xa1, 0x4c, 0xbb, 0x9b, 0x62, 0x45, 0x5d, 0x6d,
-	0x51, 0xa1, 0x0c, 0x7a, 0x41, 0x12, 0xb0, 0x38, 0x8a, 0x23, 0xc9, 0xd2, 0x41, 0xc8, 0x86, 0x19,
-	0x97, 0x11, 0x97, 0x4c, 0xe9, 0x40, 0x2b, 0xfb, 0x5a, 0xce, 0x7d, 0xc3, 0x84, 0xb6, 0x32, 0x2e,
-	0x27, 0x3e, 0x57, 0xd9, 0x8b, 0xf6, 0xf9, 0x30, 0xe3, 0x4a, 0x53, 0x87, 0x1c, 0xe6, 0xcc, 0xe4,
-	0xee, 0xc6, 0xc1, 0x55, 0x5c, 0xdb, 0xf3, 0xd7, 0x2d, 0xbd, 0x26, 0xe5, 0x7c, 0xb5, 0xcf, 0x55,
-	0x2a, 0x12, 0xc5, 0x9d, 0x7f, 0x55, 0x5c, 0x2b, 0xd5, 0xcf, 0xbc, 0x8d, 0x9f, 0xdb, 0x76, 0xfb,
-	0x7e, 0x3d, 0xf5, 0x77, 0xb4, 0xd4, 0x25, 0xfb, 0xc5, 0x6e, 0xe7, 0x7f, 0x01, 0x95, 0x3d, 0xeb,
-	0xe4, 0x21, 0x7f, 0x7d, 0x3b, 0x72, 0x4f, 0xc9, 0xf1, 0x8e, 0x1f, 0x8b, 0xd6, 0x9f, 0x09, 0x6d,
-	0xae, 0xb2, 0x6b, 0x0a, 0xd9, 0xb2, 0xf7, 0xd0, 0x36, 0x29, 0x6d, 0x89, 0x69, 0xd5, 0xdb, 0xce,
-	0xd7, 0xfb, 0x7d, 0x57, 0xe5, 0xfc, 0x0f, 0x85, 0xdd, 0xe4, 0xa2, 0x46, 0x63, 0x3a, 0x07, 0x34,
-	0x9b, 0x03, 0x5a, 0xce, 0x01, 0xbf, 0x1a, 0xc0, 0x1f, 0x06, 0xf0, 0xa7, 0x01, 0x3c, 0x35, 0x80,
-	0xbf, 0x0c, 0xe0, 0x6f, 0x03, 0x68, 0x69, 0x00, 0xbf, 0x2f, 0x00, 0x4d, 0x17, 0x80, 0x66, 0x0b,
-	0x40, 0x8f, 0x3b, 0x7f, 0xdb, 0x39, 0x28, 0xe2, 0xbd, 0xf8, 0x09, 0x00, 0x00, 0xff, 0xff, 0xfc,
-	0x56, 0xe4, 0xc6, 0x02, 0x02, 0x00, 0x00,
+	// 351 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x51, 0xb1, 0x6e, 0xea, 0x30,
+	0x14, 0xb5, 0xdf, 0x83, 0xf7, 0x24, 0x83, 0x3a, 0xb8, 0xb4, 0x8a, 0x18, 0x2c, 0x9a, 0x89, 0x29,
+	0x96, 0x68, 0xd5, 0xa1, 0x23, 0xaa, 0x50, 0xbb, 0x95, 0x94, 0xa9, 0x5b, 0x48, 0x4d, 0x48, 0x69,
+	0xec, 0x60, 0x3b, 0x20, 0xb6, 0x7e, 0x42, 0x3f, 0xa2, 0x43, 0x3f, 0xa5, 0x23, 0x23, 0x63, 0x31,
+	0x4b, 0x47, 0x3e, 0xa1, 0x4a, 0x0c, 0x08, 0x54, 0xa9, 0xcb, 0xd5, 0xbd, 0xba, 0xe7, 0xf8, 0x9c,
+	0x7b, 0x8c, 0x8e, 0x06, 0x52, 0x70, 0xcd, 0xf8, 0xa3, 0x97, 0x4a, 0xa1, 0x05, 0xae, 0x6e, 0xe7,
+	0x49, 0x2b, 0xed, 0xd7, 0x6b, 0x91, 0x88, 0x44, 0xb1, 0xa0, 0x79, 0x67, 0x31, 0xf5, 0x8b, 0x28,
+	0xd6, 0xc3, 0xac, 0xef, 0x85, 0x22, 0xa1, 0x53, 0x16, 0x4c, 0xd8, 0x54, 0xc8, 0x91, 0xa2, 0xa1,
+	0x48, 0x12, 0xc1, 0xe9, 0x50, 0xeb, 0x34, 0x92, 0x69, 0xb8, 0x6b, 0x36, 0xac, 0xcb, 0x3d, 0x56,
+	0x24, 0x83, 0x41, 0xc0, 0x03, 0x9a, 0xc4, 0x49, 0x2c, 0x69, 0x3a, 0x8a, 0xe8, 0x38, 0x63, 0x32,
+	0x66, 0x92, 0x2a, 0x1d, 0x68, 0x65, 0xab, 0xe5, 0xb9, 0x6f, 0x10, 0xe1, 0x6e, 0xc6, 0xe4, 0xcc,
+	0x67, 0x2a, 0x7b, 0xd6, 0x3e, 0x1b, 0x67, 0x4c, 0x69, 0xec, 0xa0, 0xff, 0x39, 0x67, 0x76, 0x7b,
+	0xed, 0xc0, 0x06, 0x6c, 0x96, 0xfc, 0xed, 0x88, 0xaf, 0x50, 0x35, 0x97, 0xf6, 0x99, 0x4a, 0x05,
+	0x57, 0xcc, 0xf9, 0xd3, 0x80, 0xcd, 0x4a, 0xeb, 0xd4, 0xdb, 0xf9, 0xb9, 0xe9, 0xf5, 0xee, 0xb6,
+	0x5b, 0xff, 0x00, 0x8b, 0x5d, 0x54, 0x2e, 0xb4, 0x9d, 0xbf, 0x05, 0xa9, 0xea, 0x59, 0x27, 0xf7,
+	0x79, 0xf5, 0xed, 0x0a, 0xd7, 0x50, 0x99, 0x0b, 0x1e, 0x32, 0xa7, 0x54, 0xe8, 0xda, 0xc1, 0x3d,
+	0x41, 0xc7, 0x07, 0x2e, 0xed, 0x83, 0xad, 0x27, 0x84, 0x3b, 0x9b, 0x44, 0x3b, 0x42, 0x76, 0xed,
+	0x95, 0xb8, 0x87, 0x2a, 0x7b, 0x60, 0xdc, 0xf0, 0xf6, 0x53, 0xf7, 0x7e, 0x5e, 0x5b, 0x3f, 0xfb,
+	0x05, 0x61, 0x95, 0x5c, 0xd0, 0x6e, 0xcf, 0x97, 0x04, 0x2c, 0x96, 0x04, 0xac, 0x97, 0x04, 0xbe,
+	0x18, 0x02, 0xdf, 0x0d, 0x81, 0x1f, 0x86, 0xc0, 0xb9, 0x21, 0xf0, 0xd3, 0x10, 0xf8, 0x65, 0x08,
+	0x58, 0x1b, 0x02, 0x5f, 0x57, 0x04, 0xcc, 0x57, 0x04, 0x2c, 0x56, 0x04, 0x3c, 0x1c, 0xfc, 0x78,
+	0xff, 0x5f, 0x11, 0xfa, 0xf9, 0xf7, 0x00, 0x3f, 0xdc, 0x94, 0x67, 0x18, 0x02, 0x00, 0x00,
 }
 
 func (this *QueryResultRequest) Equal(that interface{}) bool {
@@ -186,6 +196,9 @@ func (this *QueryResultRequest) Equal(that interface{}) bool {
 	if !this.Stats.Equal(that1.Stats) {
 		return false
 	}
+	if this.Nonce != that1.Nonce {
+		return false
+	}
 	return true
 }
 func (this *QueryResultResponse) Equal(that interface{}) bool {
@@ -213,7 +226,7 @@ func (this *QueryResultRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&frontendv2pb.QueryResultRequest{")
 	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
 	if this.HttpResponse != nil {
@@ -222,6 +235,7 @@ func (this *QueryResultRequest) GoString() string {
 	if this.Stats != nil {
 		s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
 	}
+	s = append(s, "Nonce: "+fmt.Sprintf("%#v", this.Nonce)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -343,6 +357,11 @@ func (m *QueryResultRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Nonce != 0 {
+		i = encodeVarintFrontend(dAtA, i, uint64(m.Nonce))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.Stats != nil {
 		{
 			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
@@ -426,6 +445,9 @@ func (m *QueryResultRequest) Size() (n int) {
 		l = m.Stats.Size()
 		n += 1 + l + sovFrontend(uint64(l))
 	}
+	if m.Nonce != 0 {
+		n += 1 + sovFrontend(uint64(m.Nonce))
+	}
 	return n
 }
 
@@ -452,6 +474,7 @@ func (this *QueryResultRequest) String() string {
 		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
 		`HttpResponse:` + strings.Replace(fmt.Sprintf("%v", this.HttpResponse), "HTTPResponse", "httpgrpc.HTTPResponse", 1) + `,`,
 		`Stats:` + strings.Replace(fmt.Sprintf("%v", this.Stats), "Stats", "stats.Stats", 1) + `,`,
+		`Nonce:` + fmt.Sprintf("%v", this.Nonce) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -593,6 +616,25 @@ func (m *QueryResultRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonce", wireType)
+			}
+			m.Nonce = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Nonce |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFrontend(dAtA[iNdEx:])
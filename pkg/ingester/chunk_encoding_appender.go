@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// histogramChunkEncoding returns the chunk encoding the integer native histograms are stored with, as configured
+// by the input histogram_chunk_encoding limit.
+func histogramChunkEncoding(limit string) chunkenc.Encoding {
+	if limit == validation.HistogramChunkEncodingFloatHistogram {
+		return chunkenc.EncFloatHistogram
+	}
+	return chunkenc.EncHistogram
+}
+
+// chunkEncodingAppender appends the samples to the TSDB head with the chunk encodings selected for the tenant.
+// The head cuts the chunks with the encoding of the value type of the samples appended to them, so the samples
+// are converted to the value type of the selected encoding before being appended. XOR is the only chunk encoding
+// of the float samples supported by the TSDB, so they're appended as they are.
+//
+// A sample whose append fails once converted, because a sample with the same timestamp has already been stored
+// with its original encoding, like a retried one after the encoding has been changed, falls back to its original
+// encoding.
+type chunkEncodingAppender struct {
+	extendedAppender
+
+	fallbacks prometheus.Counter
+}
+
+// newChunkEncodingAppender returns an appender storing the integer histograms appended to app in float histogram chunks.
+func newChunkEncodingAppender(app extendedAppender, fallbacks prometheus.Counter) extendedAppender {
+	return &chunkEncodingAppender{
+		extendedAppender: app,
+		fallbacks:        fallbacks,
+	}
+}
+
+func (a *chunkEncodingAppender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	if h == nil {
+		return a.extendedAppender.AppendHistogram(ref, l, t, nil, fh)
+	}
+
+	convertedRef, err := a.extendedAppender.AppendHistogram(ref, l, t, nil, h.ToFloat())
+	if err == nil || !errors.Is(err, storage.ErrDuplicateSampleForTimestamp) {
+		return convertedRef, err
+	}
+
+	a.fallbacks.Inc()
+	return a.extendedAppender.AppendHistogram(ref, l, t, h, nil)
+}
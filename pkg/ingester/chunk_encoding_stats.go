@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+)
+
+const (
+	// chunkEncodingStatsUpdatePeriod is how frequently the bytes per sample of the head chunks are updated.
+	chunkEncodingStatsUpdatePeriod = 5 * time.Minute
+
+	// chunkEncodingStatsMaxSeries is the max number of series sampled, for each tenant, to compute the bytes
+	// per sample of the head chunks.
+	chunkEncodingStatsMaxSeries = 10000
+)
+
+// chunkEncodingStats is the size and the number of samples of the chunks of an encoding.
+type chunkEncodingStats struct {
+	bytes   int64
+	samples int64
+}
+
+func (s chunkEncodingStats) bytesPerSample() float64 {
+	if s.samples == 0 {
+		return 0
+	}
+	return float64(s.bytes) / float64(s.samples)
+}
+
+// headChunkEncodingStats returns the size and the number of samples of the head chunks by encoding, including
+// the chunks being appended to and the ones memory mapped to disk. At most maxSeries series are sampled,
+// evenly across the head.
+func headChunkEncodingStats(head *tsdb.Head, maxSeries int) (map[chunkenc.Encoding]chunkEncodingStats, error) {
+	idx, err := head.Index()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the head index reader")
+	}
+	defer idx.Close()
+
+	chunkr, err := head.Chunks()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the head chunk reader")
+	}
+	defer chunkr.Close()
+
+	postings, err := idx.Postings(index.AllPostingsKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the head postings")
+	}
+
+	stride := 1
+	if numSeries := int(head.NumSeries()); maxSeries > 0 && numSeries > maxSeries {
+		stride = (numSeries + maxSeries - 1) / maxSeries
+	}
+
+	var (
+		stats   = map[chunkenc.Encoding]chunkEncodingStats{}
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+	)
+	for n := 0; postings.Next(); n++ {
+		if n%stride != 0 {
+			continue
+		}
+
+		if err := idx.Series(postings.At(), &builder, &chks); err != nil {
+			// The series may have been garbage collected in the meanwhile.
+			if errors.Is(err, storage.ErrNotFound) {
+				continue
+			}
+			return nil, errors.Wrap(err, "failed to read the head series")
+		}
+
+		for _, meta := range chks {
+			var chk chunkenc.Chunk
+			// The chunk is copied, if supported, because it may be concurrently appended to.
+			if r, ok := chunkr.(chunkWithCopyReader); ok {
+				chk, _, err = r.ChunkWithCopy(meta)
+			} else {
+				chk, err = chunkr.Chunk(meta)
+			}
+			// The chunk may have been truncated in the meanwhile.
+			if err != nil || chk == nil {
+				continue
+			}
+
+			s := stats[chk.Encoding()]
+			s.bytes += int64(len(chk.Bytes()))
+			s.samples += int64(chk.NumSamples())
+			stats[chk.Encoding()] = s
+		}
+	}
+	if err := postings.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to iterate the head postings")
+	}
+
+	return stats, nil
+}
+
+// updateChunkEncodingStats updates the bytes per sample of the head chunks of each tenant, by encoding.
+func (i *Ingester) updateChunkEncodingStats() {
+	for _, userID := range i.getTSDBUsers() {
+		db := i.getTSDB(userID)
+		if db == nil {
+			continue
+		}
+
+		stats, err := headChunkEncodingStats(db.Head(), chunkEncodingStatsMaxSeries)
+		if err != nil {
+			level.Warn(i.logger).Log("msg", "failed to compute the bytes per sample of the head chunks", "user", userID, "err", err)
+			continue
+		}
+
+		for _, enc := range []chunkenc.Encoding{chunkenc.EncXOR, chunkenc.EncHistogram, chunkenc.EncFloatHistogram} {
+			if s, ok := stats[enc]; ok && s.samples > 0 {
+				i.metrics.headChunkBytesPerSample.WithLabelValues(userID, enc.String()).Set(s.bytesPerSample())
+			} else {
+				i.metrics.headChunkBytesPerSample.DeleteLabelValues(userID, enc.String())
+			}
+		}
+	}
+}
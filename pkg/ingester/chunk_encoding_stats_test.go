@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	util_test "github.com/grafana/mimir/pkg/util/test"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestIngester_HistogramChunkEncoding(t *testing.T) {
+	tenantLimits := map[string]*validation.Limits{}
+	for userID, encoding := range map[string]string{
+		"user-histogram":       validation.HistogramChunkEncodingHistogram,
+		"user-float-histogram": validation.HistogramChunkEncodingFloatHistogram,
+	} {
+		limits := defaultLimitsTestConfig()
+		limits.NativeHistogramsIngestionEnabled = true
+		limits.HistogramChunkEncoding = encoding
+		tenantLimits[userID] = &limits
+	}
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), validation.NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	registry := prometheus.NewPedanticRegistry()
+	i, err := prepareIngesterWithBlockStorageAndOverrides(t, defaultIngesterTestConfig(t), overrides, "", "", registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	pushHistogram := func(t *testing.T, userID string, ts int64) {
+		req := mimirpb.NewWriteRequest(nil, mimirpb.API).AddHistogramSeries(
+			[]labels.Labels{labels.FromStrings(labels.MetricName, "test_histogram")},
+			[]mimirpb.Histogram{mimirpb.FromHistogramToHistogramProto(ts, util_test.GenerateTestHistogram(int(ts)))},
+			nil,
+		)
+		_, err := i.Push(user.InjectOrgID(context.Background(), userID), req)
+		require.NoError(t, err)
+	}
+
+	for userID := range tenantLimits {
+		for ts := int64(1); ts <= 10; ts++ {
+			pushHistogram(t, userID, ts)
+		}
+	}
+
+	headEncodings := func(userID string) []chunkenc.Encoding {
+		stats, err := headChunkEncodingStats(i.getTSDB(userID).Head(), 0)
+		require.NoError(t, err)
+
+		var encodings []chunkenc.Encoding
+		for enc, s := range stats {
+			assert.Equal(t, int64(10), s.samples)
+			assert.Greater(t, s.bytesPerSample(), float64(0))
+			encodings = append(encodings, enc)
+		}
+		return encodings
+	}
+	assert.Equal(t, []chunkenc.Encoding{chunkenc.EncHistogram}, headEncodings("user-histogram"))
+	assert.Equal(t, []chunkenc.Encoding{chunkenc.EncFloatHistogram}, headEncodings("user-float-histogram"))
+
+	i.updateChunkEncodingStats()
+	metrics, err := registry.Gather()
+	require.NoError(t, err)
+
+	var series []string
+	for _, mf := range metrics {
+		if mf.GetName() != "cortex_ingester_tsdb_head_chunk_bytes_per_sample" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			lbls := map[string]string{}
+			for _, l := range m.GetLabel() {
+				lbls[l.GetName()] = l.GetValue()
+			}
+			series = append(series, lbls["user"]+"/"+lbls["encoding"])
+		}
+	}
+	assert.ElementsMatch(t, []string{"user-histogram/histogram", "user-float-histogram/floathistogram"}, series)
+
+	t.Run("should fall back to the integer histogram chunk encoding for the samples already stored with it", func(t *testing.T) {
+		tenantLimits["user-histogram"].HistogramChunkEncoding = validation.HistogramChunkEncodingFloatHistogram
+
+		// The retried sample is a duplicate of the stored integer histogram once converted.
+		pushHistogram(t, "user-histogram", 10)
+		pushHistogram(t, "user-histogram", 11)
+
+		stats, err := headChunkEncodingStats(i.getTSDB("user-histogram").Head(), 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), stats[chunkenc.EncHistogram].samples)
+		assert.Equal(t, int64(1), stats[chunkenc.EncFloatHistogram].samples)
+		assert.Equal(t, 1.0, testutil.ToFloat64(i.metrics.chunkEncodingFallbacks.WithLabelValues("user-histogram")))
+	})
+}
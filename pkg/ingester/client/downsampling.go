@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package client
+
+import (
+	"context"
+)
+
+type downsamplingContextKey int
+
+const downsamplingHintCtxKey = downsamplingContextKey(0)
+
+// ContextWithDownsamplingHint returns a context carrying the downsampling hint to send to the ingesters
+// in the QueryRequest of the queries run with it.
+func ContextWithDownsamplingHint(ctx context.Context, hint *DownsamplingHint) context.Context {
+	return context.WithValue(ctx, downsamplingHintCtxKey, hint)
+}
+
+// DownsamplingHintFromContext returns the downsampling hint carried by the context, or nil if none.
+func DownsamplingHintFromContext(ctx context.Context) *DownsamplingHint {
+	hint, _ := ctx.Value(downsamplingHintCtxKey).(*DownsamplingHint)
+	return hint
+}
+
+// Enabled returns whether the hint requests the samples to be downsampled.
+func (m *DownsamplingHint) Enabled() bool {
+	return m != nil && m.StepMs > 0 && (m.Aggregation != AGGREGATION_NONE || m.Histograms)
+}
@@ -112,6 +112,40 @@ func (StreamChunk_Encoding) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_60f6df4f3586b478, []int{10, 0}
 }
 
+type DownsamplingHint_Aggregation int32
+
+const (
+	AGGREGATION_NONE DownsamplingHint_Aggregation = 0
+	// The sample with the minimum value.
+	AGGREGATION_MIN DownsamplingHint_Aggregation = 1
+	// The sample with the maximum value.
+	AGGREGATION_MAX DownsamplingHint_Aggregation = 2
+	// The average of the samples, at the timestamp of the last one.
+	AGGREGATION_AVG DownsamplingHint_Aggregation = 3
+	// The last sample.
+	AGGREGATION_LAST DownsamplingHint_Aggregation = 4
+)
+
+var DownsamplingHint_Aggregation_name = map[int32]string{
+	0: "AGGREGATION_NONE",
+	1: "AGGREGATION_MIN",
+	2: "AGGREGATION_MAX",
+	3: "AGGREGATION_AVG",
+	4: "AGGREGATION_LAST",
+}
+
+var DownsamplingHint_Aggregation_value = map[string]int32{
+	"AGGREGATION_NONE": 0,
+	"AGGREGATION_MIN":  1,
+	"AGGREGATION_MAX":  2,
+	"AGGREGATION_AVG":  3,
+	"AGGREGATION_LAST": 4,
+}
+
+func (DownsamplingHint_Aggregation) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{33, 0}
+}
+
 type LabelNamesAndValuesRequest struct {
 	Matchers []*LabelMatcher `protobuf:"bytes,1,rep,name=matchers,proto3" json:"matchers,omitempty"`
 }
@@ -647,6 +681,12 @@ type QueryRequest struct {
 	StartTimestampMs int64           `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64           `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
 	Matchers         []*LabelMatcher `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers,omitempty"`
+	// If true, the ingester sends the estimated number of chunks and their size in bytes in the first
+	// response of the stream, before any series.
+	EstimateChunks bool `protobuf:"varint,4,opt,name=estimate_chunks,json=estimateChunks,proto3" json:"estimate_chunks,omitempty"`
+	// If set, the ingester reduces the samples of each series before sending them, as described by the hint.
+	// The ingesters not supporting it send the raw samples.
+	Downsampling *DownsamplingHint `protobuf:"bytes,5,opt,name=downsampling,proto3" json:"downsampling,omitempty"`
 }
 
 func (m *QueryRequest) Reset()      { *m = QueryRequest{} }
@@ -702,6 +742,20 @@ func (m *QueryRequest) GetMatchers() []*LabelMatcher {
 	return nil
 }
 
+func (m *QueryRequest) GetEstimateChunks() bool {
+	if m != nil {
+		return m.EstimateChunks
+	}
+	return false
+}
+
+func (m *QueryRequest) GetDownsampling() *DownsamplingHint {
+	if m != nil {
+		return m.Downsampling
+	}
+	return nil
+}
+
 type ExemplarQueryRequest struct {
 	StartTimestampMs int64            `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64            `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
@@ -808,6 +862,10 @@ func (m *QueryResponse) GetTimeseries() []mimirpb.TimeSeries {
 type QueryStreamResponse struct {
 	Chunkseries []TimeSeriesChunk    `protobuf:"bytes,1,rep,name=chunkseries,proto3" json:"chunkseries"`
 	Timeseries  []mimirpb.TimeSeries `protobuf:"bytes,2,rep,name=timeseries,proto3" json:"timeseries"`
+	// The estimated number of chunks the query fetches from the ingester, and their size in bytes.
+	// Only set in the first response of the stream, when requested.
+	EstimatedChunksCount uint64 `protobuf:"varint,3,opt,name=estimated_chunks_count,json=estimatedChunksCount,proto3" json:"estimated_chunks_count,omitempty"`
+	EstimatedChunksBytes uint64 `protobuf:"varint,4,opt,name=estimated_chunks_bytes,json=estimatedChunksBytes,proto3" json:"estimated_chunks_bytes,omitempty"`
 }
 
 func (m *QueryStreamResponse) Reset()      { *m = QueryStreamResponse{} }
@@ -856,6 +914,20 @@ func (m *QueryStreamResponse) GetTimeseries() []mimirpb.TimeSeries {
 	return nil
 }
 
+func (m *QueryStreamResponse) GetEstimatedChunksCount() uint64 {
+	if m != nil {
+		return m.EstimatedChunksCount
+	}
+	return 0
+}
+
+func (m *QueryStreamResponse) GetEstimatedChunksBytes() uint64 {
+	if m != nil {
+		return m.EstimatedChunksBytes
+	}
+	return 0
+}
+
 type ExemplarQueryResponse struct {
 	Timeseries []mimirpb.TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3" json:"timeseries"`
 }
@@ -904,6 +976,9 @@ type LabelValuesRequest struct {
 	StartTimestampMs int64          `protobuf:"varint,2,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64          `protobuf:"varint,3,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
 	Matchers         *LabelMatchers `protobuf:"bytes,4,opt,name=matchers,proto3" json:"matchers,omitempty"`
+	Limit            int64          `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	After            string         `protobuf:"bytes,6,opt,name=after,proto3" json:"after,omitempty"`
+	MaxSizeBytes     int64          `protobuf:"varint,7,opt,name=max_size_bytes,json=maxSizeBytes,proto3" json:"max_size_bytes,omitempty"`
 }
 
 func (m *LabelValuesRequest) Reset()      { *m = LabelValuesRequest{} }
@@ -966,6 +1041,27 @@ func (m *LabelValuesRequest) GetMatchers() *LabelMatchers {
 	return nil
 }
 
+func (m *LabelValuesRequest) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *LabelValuesRequest) GetAfter() string {
+	if m != nil {
+		return m.After
+	}
+	return ""
+}
+
+func (m *LabelValuesRequest) GetMaxSizeBytes() int64 {
+	if m != nil {
+		return m.MaxSizeBytes
+	}
+	return 0
+}
+
 type LabelValuesResponse struct {
 	LabelValues []string `protobuf:"bytes,1,rep,name=label_values,json=labelValues,proto3" json:"label_values,omitempty"`
 }
@@ -1013,6 +1109,8 @@ type LabelNamesRequest struct {
 	StartTimestampMs int64          `protobuf:"varint,1,opt,name=start_timestamp_ms,json=startTimestampMs,proto3" json:"start_timestamp_ms,omitempty"`
 	EndTimestampMs   int64          `protobuf:"varint,2,opt,name=end_timestamp_ms,json=endTimestampMs,proto3" json:"end_timestamp_ms,omitempty"`
 	Matchers         *LabelMatchers `protobuf:"bytes,3,opt,name=matchers,proto3" json:"matchers,omitempty"`
+	Limit            int64          `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	After            string         `protobuf:"bytes,5,opt,name=after,proto3" json:"after,omitempty"`
 }
 
 func (m *LabelNamesRequest) Reset()      { *m = LabelNamesRequest{} }
@@ -1068,6 +1166,20 @@ func (m *LabelNamesRequest) GetMatchers() *LabelMatchers {
 	return nil
 }
 
+func (m *LabelNamesRequest) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *LabelNamesRequest) GetAfter() string {
+	if m != nil {
+		return m.After
+	}
+	return ""
+}
+
 type LabelNamesResponse struct {
 	LabelNames []string `protobuf:"bytes,1,rep,name=label_names,json=labelNames,proto3" json:"label_names,omitempty"`
 }
@@ -1776,10 +1888,75 @@ func (m *TimeSeriesFile) GetData() []byte {
 	return nil
 }
 
+// DownsamplingHint requests the ingester to reduce the samples between each pair of consecutive multiples
+// of the step to a single sample, while the samples at the multiples of the step are sent as-is.
+type DownsamplingHint struct {
+	StepMs int64 `protobuf:"varint,1,opt,name=step_ms,json=stepMs,proto3" json:"step_ms,omitempty"`
+	// The aggregation applied to the float samples between each pair of consecutive multiples of the step.
+	Aggregation DownsamplingHint_Aggregation `protobuf:"varint,2,opt,name=aggregation,proto3,enum=cortex.DownsamplingHint_Aggregation" json:"aggregation,omitempty"`
+	// If true, the native histogram samples are reduced to the last one between each pair of consecutive
+	// multiples of the step. Otherwise they're sent as-is.
+	Histograms bool `protobuf:"varint,3,opt,name=histograms,proto3" json:"histograms,omitempty"`
+}
+
+func (m *DownsamplingHint) Reset()      { *m = DownsamplingHint{} }
+func (*DownsamplingHint) ProtoMessage() {}
+func (*DownsamplingHint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{33}
+}
+func (m *DownsamplingHint) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DownsamplingHint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DownsamplingHint.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DownsamplingHint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DownsamplingHint.Merge(m, src)
+}
+func (m *DownsamplingHint) XXX_Size() int {
+	return m.Size()
+}
+func (m *DownsamplingHint) XXX_DiscardUnknown() {
+	xxx_messageInfo_DownsamplingHint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DownsamplingHint proto.InternalMessageInfo
+
+func (m *DownsamplingHint) GetStepMs() int64 {
+	if m != nil {
+		return m.StepMs
+	}
+	return 0
+}
+
+func (m *DownsamplingHint) GetAggregation() DownsamplingHint_Aggregation {
+	if m != nil {
+		return m.Aggregation
+	}
+	return AGGREGATION_NONE
+}
+
+func (m *DownsamplingHint) GetHistograms() bool {
+	if m != nil {
+		return m.Histograms
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterEnum("cortex.MatchType", MatchType_name, MatchType_value)
 	proto.RegisterEnum("cortex.ReadRequest_ResponseType", ReadRequest_ResponseType_name, ReadRequest_ResponseType_value)
 	proto.RegisterEnum("cortex.StreamChunk_Encoding", StreamChunk_Encoding_name, StreamChunk_Encoding_value)
+	proto.RegisterEnum("cortex.DownsamplingHint_Aggregation", DownsamplingHint_Aggregation_name, DownsamplingHint_Aggregation_value)
 	proto.RegisterType((*LabelNamesAndValuesRequest)(nil), "cortex.LabelNamesAndValuesRequest")
 	proto.RegisterType((*LabelNamesAndValuesResponse)(nil), "cortex.LabelNamesAndValuesResponse")
 	proto.RegisterType((*LabelValues)(nil), "cortex.LabelValues")
@@ -1814,118 +1991,135 @@ func init() {
 	proto.RegisterType((*LabelMatchers)(nil), "cortex.LabelMatchers")
 	proto.RegisterType((*LabelMatcher)(nil), "cortex.LabelMatcher")
 	proto.RegisterType((*TimeSeriesFile)(nil), "cortex.TimeSeriesFile")
+	proto.RegisterType((*DownsamplingHint)(nil), "cortex.DownsamplingHint")
 }
 
 func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
 
 var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1692 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcd, 0x6f, 0xe3, 0xc6,
-	0x15, 0xd7, 0x48, 0xb2, 0x6c, 0x3d, 0xd9, 0x5a, 0xed, 0x68, 0xbd, 0x56, 0xb8, 0x35, 0xed, 0xb2,
-	0xd8, 0x54, 0x6d, 0x13, 0xf9, 0x63, 0xb7, 0xc0, 0x26, 0x08, 0x10, 0xc8, 0xb6, 0xbc, 0x76, 0x6d,
-	0x49, 0x0e, 0x25, 0x37, 0x46, 0x81, 0x82, 0xa0, 0xa4, 0xb1, 0x4d, 0x98, 0xa4, 0x14, 0x92, 0x2a,
-	0xac, 0x5b, 0x81, 0xde, 0x8b, 0xa2, 0x97, 0xf6, 0x54, 0xa0, 0xb7, 0x1e, 0x8b, 0x5e, 0x7a, 0xeb,
-	0x39, 0x97, 0x00, 0x7b, 0x0c, 0x7a, 0x58, 0x74, 0xbd, 0x97, 0xf6, 0x96, 0x3f, 0xa1, 0xe0, 0xcc,
-	0xf0, 0x53, 0xf4, 0x47, 0x82, 0x78, 0x4f, 0xd2, 0xbc, 0xf7, 0x9b, 0xdf, 0xbc, 0xaf, 0x99, 0x79,
-	0x1c, 0x28, 0x6a, 0xe6, 0x19, 0xb1, 0x1d, 0x62, 0xd5, 0x46, 0xd6, 0xd0, 0x19, 0xe2, 0x5c, 0x7f,
-	0x68, 0x39, 0xe4, 0x52, 0xf8, 0xf0, 0x4c, 0x73, 0xce, 0xc7, 0xbd, 0x5a, 0x7f, 0x68, 0xac, 0x9d,
-	0x0d, 0xcf, 0x86, 0x6b, 0x54, 0xdd, 0x1b, 0x9f, 0xd2, 0x11, 0x1d, 0xd0, 0x7f, 0x6c, 0x9a, 0xb0,
-	0x1e, 0x86, 0x5b, 0xea, 0xa9, 0x6a, 0xaa, 0x6b, 0x86, 0x66, 0x68, 0xd6, 0xda, 0xe8, 0xe2, 0x8c,
-	0xfd, 0x1b, 0xf5, 0xd8, 0x2f, 0x9b, 0x21, 0xb5, 0x40, 0x38, 0x54, 0x7b, 0x44, 0x6f, 0xa9, 0x06,
-	0xb1, 0xeb, 0xe6, 0xe0, 0x97, 0xaa, 0x3e, 0x26, 0xb6, 0x4c, 0xbe, 0x18, 0x13, 0xdb, 0xc1, 0xeb,
-	0x30, 0x67, 0xa8, 0x4e, 0xff, 0x9c, 0x58, 0x76, 0x05, 0xad, 0x66, 0xaa, 0x85, 0xcd, 0x47, 0x35,
-	0x66, 0x59, 0x8d, 0xce, 0x6a, 0x32, 0xa5, 0xec, 0xa3, 0xa4, 0x3d, 0x78, 0x92, 0xc8, 0x67, 0x8f,
-	0x86, 0xa6, 0x4d, 0xf0, 0x4f, 0x60, 0x46, 0x73, 0x88, 0xe1, 0xb1, 0x95, 0x23, 0x6c, 0x1c, 0xcb,
-	0x10, 0xd2, 0x0e, 0x14, 0x42, 0x52, 0xbc, 0x0c, 0xa0, 0xbb, 0x43, 0xc5, 0x54, 0x0d, 0x52, 0x41,
-	0xab, 0xa8, 0x9a, 0x97, 0xf3, 0xba, 0xb7, 0x14, 0x7e, 0x0c, 0xb9, 0xdf, 0x50, 0x60, 0x25, 0xbd,
-	0x9a, 0xa9, 0xe6, 0x65, 0x3e, 0x92, 0x2c, 0x58, 0x0e, 0xb1, 0x6c, 0xab, 0xd6, 0x40, 0x33, 0x55,
-	0x5d, 0x73, 0x26, 0x9e, 0x8b, 0x2b, 0x50, 0x08, 0x78, 0x99, 0x5d, 0x79, 0x19, 0x7c, 0x62, 0x3b,
-	0x12, 0x83, 0xf4, 0x9d, 0x62, 0x70, 0x0c, 0xe2, 0x75, 0x6b, 0xf2, 0x30, 0x3c, 0x8b, 0x86, 0x61,
-	0x79, 0x3a, 0x0c, 0x1d, 0x62, 0x69, 0xc4, 0xde, 0x1e, 0x8e, 0x4d, 0xc7, 0x0b, 0xc8, 0x6b, 0x04,
-	0x8b, 0x89, 0x80, 0xdb, 0x62, 0xa3, 0x02, 0x66, 0x6a, 0x1a, 0x13, 0xc5, 0xa6, 0x33, 0xb9, 0x2f,
-	0xcf, 0x6e, 0x5c, 0x7a, 0x4a, 0xda, 0x30, 0x1d, 0x6b, 0x22, 0x97, 0xf4, 0x98, 0x58, 0xd8, 0x9e,
-	0x36, 0x8d, 0x42, 0x71, 0x09, 0x32, 0x17, 0x64, 0xc2, 0x6d, 0x72, 0xff, 0xe2, 0x47, 0x30, 0x43,
-	0xed, 0xa8, 0xa4, 0x57, 0x51, 0x35, 0x2b, 0xb3, 0xc1, 0xc7, 0xe9, 0x17, 0x48, 0xfa, 0x0a, 0x41,
-	0x41, 0x26, 0xea, 0xc0, 0x4b, 0x4d, 0x0d, 0x66, 0xbf, 0x18, 0x33, 0x63, 0x63, 0xc5, 0xf7, 0xd9,
-	0x98, 0x58, 0x5e, 0x06, 0x65, 0x0f, 0x84, 0x4f, 0x60, 0x49, 0xed, 0xf7, 0xc9, 0xc8, 0x21, 0x03,
-	0xc5, 0xe2, 0xa1, 0x56, 0x9c, 0xc9, 0x88, 0x3b, 0x5b, 0xdc, 0x5c, 0xf5, 0xe6, 0x87, 0x56, 0xa9,
-	0x79, 0x49, 0xe9, 0x4e, 0x46, 0x44, 0x5e, 0xf4, 0x08, 0xc2, 0x52, 0x5b, 0x7a, 0x0e, 0xf3, 0x61,
-	0x01, 0x2e, 0xc0, 0x6c, 0xa7, 0xde, 0x3c, 0x3a, 0x6c, 0x74, 0x4a, 0x29, 0xbc, 0x04, 0xe5, 0x4e,
-	0x57, 0x6e, 0xd4, 0x9b, 0x8d, 0x1d, 0xe5, 0xa4, 0x2d, 0x2b, 0xdb, 0x7b, 0xc7, 0xad, 0x83, 0x4e,
-	0x09, 0x49, 0x9f, 0xba, 0xb3, 0x54, 0x9f, 0x0a, 0xaf, 0xc1, 0xac, 0x45, 0xec, 0xb1, 0xee, 0x78,
-	0xfe, 0x2c, 0xc6, 0xfc, 0x61, 0x38, 0xd9, 0x43, 0x49, 0x13, 0xc0, 0x1d, 0xc7, 0x22, 0xaa, 0x11,
-	0xa1, 0xd9, 0x82, 0x62, 0xff, 0x7c, 0x6c, 0x5e, 0x90, 0x81, 0x97, 0x4a, 0xc6, 0xf6, 0xc4, 0x63,
-	0x63, 0x73, 0xb6, 0x19, 0x86, 0x25, 0x43, 0x5e, 0xe8, 0x87, 0x87, 0x6e, 0xd5, 0xbb, 0x51, 0x9b,
-	0x28, 0x9a, 0x39, 0x20, 0x97, 0x34, 0x15, 0x19, 0x19, 0xa8, 0x68, 0xdf, 0x95, 0x48, 0x7f, 0x47,
-	0x50, 0x4e, 0xe0, 0xc1, 0xa7, 0x90, 0xa3, 0xc9, 0x8f, 0xef, 0xe0, 0x51, 0x8f, 0xd5, 0xca, 0x91,
-	0xaa, 0x59, 0x5b, 0x1f, 0x7d, 0xf9, 0x7a, 0x25, 0xf5, 0xef, 0xd7, 0x2b, 0x1b, 0x77, 0x39, 0x8e,
-	0xd8, 0xbc, 0xfa, 0x40, 0x1d, 0x39, 0xc4, 0x92, 0x39, 0x3b, 0xde, 0x80, 0x1c, 0xb5, 0xd8, 0xab,
-	0xd3, 0x72, 0x82, 0x73, 0x5b, 0x59, 0x77, 0x1d, 0x99, 0x03, 0xa5, 0x3f, 0xa5, 0xa1, 0x10, 0xd2,
-	0x62, 0x11, 0x0a, 0x86, 0x66, 0x2a, 0x8e, 0x66, 0x10, 0x85, 0x6e, 0x35, 0xd7, 0xc7, 0xbc, 0xa1,
-	0x99, 0x5d, 0xcd, 0x20, 0x4d, 0x9b, 0xea, 0xd5, 0x4b, 0x5f, 0x9f, 0xe6, 0x7a, 0xf5, 0x92, 0xeb,
-	0xd7, 0x21, 0xeb, 0x16, 0x4f, 0x25, 0xb3, 0x8a, 0xaa, 0xc5, 0xcd, 0x1f, 0x24, 0x18, 0x50, 0x6b,
-	0x98, 0xfd, 0xe1, 0x40, 0x33, 0xcf, 0x64, 0x8a, 0xc4, 0x47, 0x90, 0x1d, 0xa8, 0x8e, 0x5a, 0xc9,
-	0xae, 0xa2, 0xea, 0xfc, 0xd6, 0x27, 0x3c, 0x0a, 0xcf, 0xef, 0x14, 0x85, 0x63, 0xd3, 0x56, 0x4f,
-	0xc9, 0xd6, 0xc4, 0x21, 0x1d, 0x5d, 0xeb, 0x13, 0x99, 0x32, 0x49, 0x3b, 0x30, 0xe7, 0xad, 0xe1,
-	0x16, 0xdd, 0x71, 0xeb, 0xa0, 0xd5, 0xfe, 0xbc, 0x55, 0x4a, 0xe1, 0x59, 0xc8, 0x9c, 0xb4, 0xe5,
-	0x12, 0xc2, 0x0b, 0x90, 0xdf, 0xdb, 0xef, 0x74, 0xdb, 0x2f, 0xe5, 0x7a, 0xb3, 0x94, 0xc6, 0x65,
-	0x78, 0xb0, 0x7b, 0xd8, 0xae, 0x77, 0x95, 0x40, 0x98, 0x91, 0xfe, 0x8c, 0x60, 0x3e, 0xbc, 0x65,
-	0xf0, 0x07, 0x80, 0x6d, 0x47, 0xb5, 0x1c, 0xea, 0xbc, 0xed, 0xa8, 0xc6, 0x28, 0x88, 0x50, 0x89,
-	0x6a, 0xba, 0x9e, 0xa2, 0x69, 0xe3, 0x2a, 0x94, 0x88, 0x39, 0x88, 0x62, 0x59, 0xb4, 0x8a, 0xc4,
-	0x1c, 0x84, 0x91, 0xe1, 0xb3, 0x32, 0x73, 0xa7, 0xb3, 0xf2, 0xaf, 0x08, 0x1e, 0x35, 0x2e, 0x89,
-	0x31, 0xd2, 0x55, 0xeb, 0x9d, 0x98, 0xb8, 0x31, 0x65, 0xe2, 0x62, 0x92, 0x89, 0x76, 0xc8, 0xc6,
-	0x03, 0x58, 0x88, 0x6c, 0x50, 0xfc, 0x31, 0x00, 0x5d, 0x29, 0xe9, 0x6c, 0x1a, 0xf5, 0x6a, 0xee,
-	0x72, 0x6c, 0xbb, 0xf0, 0x0a, 0x0d, 0xa1, 0xa5, 0x3f, 0x22, 0x28, 0x53, 0x36, 0x6f, 0x67, 0x73,
-	0xce, 0x4f, 0xa1, 0xc0, 0xea, 0x38, 0x4c, 0xba, 0xe4, 0x99, 0x16, 0x50, 0x86, 0x2b, 0x3f, 0x3c,
-	0x23, 0x66, 0x54, 0xfa, 0x5b, 0x19, 0xd5, 0x81, 0xc5, 0x58, 0x12, 0xbe, 0x07, 0x4f, 0xff, 0x85,
-	0x00, 0x87, 0xef, 0x75, 0x9e, 0xd8, 0x5b, 0x2e, 0xab, 0xe4, 0xbc, 0xa7, 0xbf, 0x45, 0xde, 0x33,
-	0xb7, 0xe6, 0xdd, 0xdd, 0x9f, 0x77, 0xc8, 0xfb, 0x0b, 0x28, 0x47, 0xec, 0xe7, 0x31, 0xf9, 0x21,
-	0xcc, 0x87, 0xae, 0x53, 0xaf, 0x65, 0x28, 0x04, 0x77, 0xa2, 0x2d, 0xfd, 0x05, 0xc1, 0xc3, 0xa0,
-	0x0d, 0x7a, 0xb7, 0x25, 0x7d, 0x27, 0xd7, 0x7e, 0xce, 0x53, 0xc3, 0xed, 0xe3, 0x9e, 0xdd, 0xd6,
-	0x0b, 0x49, 0x18, 0x4a, 0xc7, 0x36, 0xb1, 0x3a, 0x8e, 0xea, 0x78, 0x5e, 0x49, 0xff, 0x44, 0xf0,
-	0x30, 0x24, 0xe4, 0x54, 0x4f, 0xbd, 0x96, 0x56, 0x1b, 0x9a, 0x8a, 0xa5, 0x3a, 0x2c, 0xd3, 0x48,
-	0x5e, 0xf0, 0xa5, 0xb2, 0xea, 0x10, 0xb7, 0x18, 0xcc, 0xb1, 0x11, 0xb4, 0x24, 0x6e, 0x47, 0x90,
-	0x37, 0xc7, 0x06, 0xbf, 0x6d, 0x3e, 0x00, 0xac, 0x8e, 0x34, 0x25, 0xc6, 0x94, 0xa1, 0x4c, 0x25,
-	0x75, 0xa4, 0xed, 0x47, 0xc8, 0x6a, 0x50, 0xb6, 0xc6, 0x3a, 0x89, 0xc3, 0xb3, 0x14, 0xfe, 0xd0,
-	0x55, 0x45, 0xf0, 0xd2, 0xaf, 0xa1, 0xec, 0x1a, 0xbe, 0xbf, 0x13, 0x35, 0x7d, 0x09, 0x66, 0xc7,
-	0x36, 0xb1, 0x14, 0x6d, 0xc0, 0xab, 0x33, 0xe7, 0x0e, 0xf7, 0x07, 0xf8, 0x43, 0x7e, 0xbc, 0xa7,
-	0x69, 0x8c, 0xdf, 0xf3, 0x62, 0x3c, 0xe5, 0x3c, 0x3f, 0xbb, 0x5f, 0x02, 0x76, 0x55, 0x76, 0x94,
-	0x7d, 0x03, 0x66, 0x6c, 0x57, 0x10, 0xbf, 0xb4, 0x13, 0x2c, 0x91, 0x19, 0x52, 0xfa, 0x07, 0x02,
-	0xb1, 0x49, 0x1c, 0x4b, 0xeb, 0xdb, 0xbb, 0x43, 0x2b, 0x9a, 0xd2, 0x7b, 0x2e, 0xad, 0x17, 0x30,
-	0xef, 0xd5, 0x8c, 0x62, 0x13, 0xe7, 0xe6, 0x13, 0xb3, 0xe0, 0x41, 0x3b, 0xc4, 0x91, 0x0e, 0x60,
-	0xe5, 0x5a, 0x9b, 0x79, 0x28, 0xaa, 0x90, 0x33, 0x28, 0x84, 0xc7, 0xa2, 0x14, 0x1c, 0x2c, 0x6c,
-	0xaa, 0xcc, 0xf5, 0x52, 0x05, 0x1e, 0x73, 0xb2, 0x26, 0x71, 0x54, 0x37, 0xba, 0x5e, 0xf5, 0xb5,
-	0x61, 0x69, 0x4a, 0xc3, 0xe9, 0x9f, 0xc3, 0x9c, 0xc1, 0x65, 0x7c, 0x81, 0x4a, 0x7c, 0x01, 0x7f,
-	0x8e, 0x8f, 0x94, 0xfe, 0x87, 0xe0, 0x41, 0xec, 0xb4, 0x75, 0xe3, 0x75, 0x6a, 0x0d, 0x0d, 0xc5,
-	0xfb, 0x48, 0x0b, 0x4a, 0xa3, 0xe8, 0xca, 0xf7, 0xb9, 0x78, 0x7f, 0x10, 0xae, 0x9d, 0x74, 0xa4,
-	0x76, 0x82, 0xbe, 0x29, 0x73, 0xaf, 0x7d, 0xd3, 0xcf, 0xfc, 0xbe, 0x29, 0x4b, 0xd7, 0x59, 0xf0,
-	0x52, 0x95, 0xd4, 0x31, 0x7d, 0x85, 0x60, 0x86, 0x79, 0x78, 0x5f, 0xf5, 0x23, 0xc0, 0x1c, 0xe1,
-	0xfd, 0x0b, 0xdd, 0xb6, 0x33, 0xb2, 0x3f, 0xbe, 0x87, 0x6e, 0xa9, 0x0e, 0x0b, 0x91, 0x4a, 0xfb,
-	0x0e, 0xdf, 0xaf, 0x0a, 0xcc, 0x87, 0x35, 0xf8, 0x29, 0x6f, 0x02, 0x11, 0x6d, 0x02, 0x1f, 0x7a,
-	0xb3, 0xa9, 0x9a, 0x7e, 0x31, 0xb0, 0xce, 0x0f, 0x43, 0x96, 0x5e, 0x67, 0x2c, 0xe9, 0xf4, 0x7f,
-	0xf0, 0xa1, 0x93, 0xa1, 0x42, 0x36, 0x90, 0x7e, 0x87, 0xa0, 0x18, 0xd4, 0xd7, 0xae, 0xa6, 0x93,
-	0xef, 0xa3, 0xbc, 0x04, 0x98, 0x3b, 0xd5, 0x74, 0x42, 0x6d, 0x60, 0xcb, 0xf9, 0x63, 0xd7, 0xb6,
-	0x20, 0xce, 0x2c, 0x52, 0x3f, 0xfd, 0x05, 0xe4, 0x7d, 0x17, 0x70, 0x1e, 0x66, 0x1a, 0x9f, 0x1d,
-	0xd7, 0x0f, 0x4b, 0x29, 0xb7, 0x9b, 0x6c, 0xb5, 0xbb, 0x0a, 0x1b, 0x22, 0xfc, 0x00, 0x0a, 0x72,
-	0xe3, 0x65, 0xe3, 0x44, 0x69, 0xd6, 0xbb, 0xdb, 0x7b, 0xa5, 0x34, 0xc6, 0x50, 0x64, 0x82, 0x56,
-	0x9b, 0xcb, 0x32, 0x9b, 0xbf, 0x9f, 0x85, 0x39, 0xcf, 0x46, 0xfc, 0x11, 0x64, 0x8f, 0xc6, 0xf6,
-	0x39, 0x7e, 0x1c, 0xd4, 0xf7, 0xe7, 0x96, 0xe6, 0x10, 0xbe, 0x5f, 0x85, 0xa5, 0x29, 0x39, 0xdb,
-	0xad, 0x52, 0x0a, 0xef, 0x40, 0x21, 0xd4, 0x18, 0xe1, 0xc4, 0x8f, 0x3d, 0xe1, 0x49, 0x44, 0x1a,
-	0xed, 0xa1, 0xa4, 0xd4, 0x3a, 0xc2, 0x6d, 0x28, 0x52, 0x95, 0xd7, 0xcf, 0xd8, 0xd8, 0xef, 0xdc,
-	0x93, 0xfa, 0x4c, 0x61, 0xf9, 0x1a, 0xad, 0x6f, 0xd6, 0x5e, 0xf4, 0x1d, 0x42, 0x48, 0x7a, 0xb2,
-	0x88, 0x1b, 0x97, 0xd0, 0x36, 0x48, 0x29, 0xdc, 0x00, 0x08, 0x2e, 0x5d, 0xfc, 0x5e, 0x04, 0x1c,
-	0x6e, 0x14, 0x04, 0x21, 0x49, 0xe5, 0xd3, 0x6c, 0x41, 0xde, 0xbf, 0x72, 0x70, 0x25, 0xe1, 0x16,
-	0x62, 0x24, 0xd7, 0xdf, 0x4f, 0x52, 0x0a, 0xef, 0xc2, 0x7c, 0x5d, 0xd7, 0xef, 0x42, 0x23, 0x84,
-	0x35, 0x76, 0x9c, 0x47, 0xf7, 0x8f, 0xdf, 0xf8, 0x29, 0x8f, 0xdf, 0xf7, 0xf7, 0xca, 0x8d, 0x57,
-	0x97, 0xf0, 0xe3, 0x5b, 0x71, 0xfe, 0x6a, 0x5d, 0x78, 0x10, 0x3b, 0xec, 0xb1, 0x18, 0x9b, 0x1d,
-	0xbb, 0x1f, 0x84, 0x95, 0x6b, 0xf5, 0x3e, 0x6b, 0x8f, 0xb7, 0x79, 0xd1, 0x27, 0x2b, 0x2c, 0x4d,
-	0x27, 0x21, 0xfe, 0x3e, 0x26, 0xfc, 0xe8, 0x46, 0x4c, 0xa8, 0x2a, 0x2f, 0xe0, 0x71, 0xf2, 0x93,
-	0x10, 0x7e, 0x9a, 0x50, 0x33, 0xd3, 0xcf, 0x54, 0xc2, 0xfb, 0xb7, 0xc1, 0x82, 0xc5, 0xb6, 0x3e,
-	0x79, 0xf5, 0x46, 0x4c, 0x7d, 0xfd, 0x46, 0x4c, 0x7d, 0xf3, 0x46, 0x44, 0xbf, 0xbd, 0x12, 0xd1,
-	0xdf, 0xae, 0x44, 0xf4, 0xe5, 0x95, 0x88, 0x5e, 0x5d, 0x89, 0xe8, 0x3f, 0x57, 0x22, 0xfa, 0xef,
-	0x95, 0x98, 0xfa, 0xe6, 0x4a, 0x44, 0x7f, 0x78, 0x2b, 0xa6, 0x5e, 0xbd, 0x15, 0x53, 0x5f, 0xbf,
-	0x15, 0x53, 0xbf, 0xca, 0xf5, 0x75, 0x8d, 0x98, 0x4e, 0x2f, 0x47, 0x1f, 0x06, 0x9f, 0xfd, 0x3f,
-	0x00, 0x00, 0xff, 0xff, 0x3f, 0xf6, 0xb0, 0x6d, 0x93, 0x14, 0x00, 0x00,
+	// 1944 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xcf, 0x6f, 0x1b, 0xc7,
+	0xf5, 0xe7, 0x92, 0x14, 0x25, 0x3e, 0x52, 0xd4, 0x7a, 0xa8, 0x1f, 0x0c, 0xfd, 0x35, 0xa5, 0xef,
+	0x36, 0x4e, 0xd4, 0x36, 0xa1, 0x6c, 0xd9, 0x05, 0x9c, 0xc0, 0x40, 0x40, 0x49, 0xd4, 0x8f, 0x5a,
+	0x24, 0x9d, 0x25, 0x95, 0x08, 0x05, 0x8a, 0xc5, 0x88, 0x1c, 0x51, 0x0b, 0x73, 0x97, 0xcc, 0xce,
+	0xb0, 0x95, 0x72, 0x2a, 0xd0, 0x73, 0x8b, 0xdc, 0x7a, 0xee, 0xad, 0xc7, 0xa2, 0x97, 0xfe, 0x0b,
+	0x41, 0x81, 0x00, 0x3e, 0x06, 0x3d, 0x18, 0xb5, 0x7c, 0x69, 0x6f, 0xf9, 0x0b, 0xda, 0x62, 0x67,
+	0x66, 0x97, 0xbb, 0xcb, 0x95, 0x25, 0x17, 0x71, 0x4e, 0xe4, 0xbc, 0xdf, 0xf3, 0x79, 0x6f, 0xe6,
+	0xbd, 0x1d, 0x28, 0x98, 0x76, 0x9f, 0x50, 0x46, 0x9c, 0xea, 0xc8, 0x19, 0xb2, 0x21, 0xca, 0x74,
+	0x87, 0x0e, 0x23, 0xe7, 0xe5, 0x0f, 0xfb, 0x26, 0x3b, 0x1b, 0x9f, 0x54, 0xbb, 0x43, 0x6b, 0xa3,
+	0x3f, 0xec, 0x0f, 0x37, 0x38, 0xfb, 0x64, 0x7c, 0xca, 0x57, 0x7c, 0xc1, 0xff, 0x09, 0xb5, 0xf2,
+	0xbd, 0xa0, 0xb8, 0x83, 0x4f, 0xb1, 0x8d, 0x37, 0x2c, 0xd3, 0x32, 0x9d, 0x8d, 0xd1, 0xb3, 0xbe,
+	0xf8, 0x37, 0x3a, 0x11, 0xbf, 0x42, 0x43, 0x6b, 0x42, 0xf9, 0x10, 0x9f, 0x90, 0x41, 0x13, 0x5b,
+	0x84, 0xd6, 0xec, 0xde, 0x67, 0x78, 0x30, 0x26, 0x54, 0x27, 0x5f, 0x8c, 0x09, 0x65, 0xe8, 0x1e,
+	0xcc, 0x59, 0x98, 0x75, 0xcf, 0x88, 0x43, 0x4b, 0xca, 0x5a, 0x6a, 0x3d, 0xb7, 0xb9, 0x58, 0x15,
+	0x91, 0x55, 0xb9, 0x56, 0x43, 0x30, 0x75, 0x5f, 0x4a, 0xdb, 0x87, 0xdb, 0xb1, 0xf6, 0xe8, 0x68,
+	0x68, 0x53, 0x82, 0x7e, 0x0c, 0x33, 0x26, 0x23, 0x96, 0x67, 0xad, 0x18, 0xb2, 0x26, 0x65, 0x85,
+	0x84, 0xb6, 0x03, 0xb9, 0x00, 0x15, 0xdd, 0x01, 0x18, 0xb8, 0x4b, 0xc3, 0xc6, 0x16, 0x29, 0x29,
+	0x6b, 0xca, 0x7a, 0x56, 0xcf, 0x0e, 0x3c, 0x57, 0x68, 0x19, 0x32, 0xbf, 0xe2, 0x82, 0xa5, 0xe4,
+	0x5a, 0x6a, 0x3d, 0xab, 0xcb, 0x95, 0xe6, 0xc0, 0x9d, 0x80, 0x95, 0x6d, 0xec, 0xf4, 0x4c, 0x1b,
+	0x0f, 0x4c, 0x76, 0xe1, 0x6d, 0x71, 0x15, 0x72, 0x13, 0xbb, 0x22, 0xae, 0xac, 0x0e, 0xbe, 0x61,
+	0x1a, 0xc2, 0x20, 0x79, 0x23, 0x0c, 0x8e, 0xa0, 0x72, 0x95, 0x4f, 0x09, 0xc3, 0x83, 0x30, 0x0c,
+	0x77, 0xa6, 0x61, 0x68, 0x13, 0xc7, 0x24, 0x74, 0x7b, 0x38, 0xb6, 0x99, 0x07, 0xc8, 0x0b, 0x05,
+	0x96, 0x62, 0x05, 0xae, 0xc3, 0x06, 0x03, 0x12, 0x6c, 0x8e, 0x89, 0x41, 0xb9, 0xa6, 0xdc, 0xcb,
+	0x83, 0xd7, 0xba, 0x9e, 0xa2, 0xd6, 0x6d, 0xe6, 0x5c, 0xe8, 0xea, 0x20, 0x42, 0x2e, 0x6f, 0xc3,
+	0x52, 0xac, 0x28, 0x52, 0x21, 0xf5, 0x8c, 0x5c, 0xc8, 0x98, 0xdc, 0xbf, 0x68, 0x11, 0x66, 0x78,
+	0x1c, 0xa5, 0xe4, 0x9a, 0xb2, 0x9e, 0xd6, 0xc5, 0xe2, 0xe3, 0xe4, 0x23, 0x45, 0xfb, 0x46, 0x81,
+	0x9c, 0x4e, 0x70, 0xcf, 0x4b, 0x4d, 0x15, 0x66, 0xbf, 0x18, 0x8b, 0x60, 0x23, 0xc5, 0xf7, 0xe9,
+	0x98, 0x38, 0x5e, 0x06, 0x75, 0x4f, 0x08, 0x1d, 0xc3, 0x0a, 0xee, 0x76, 0xc9, 0x88, 0x91, 0x9e,
+	0xe1, 0x48, 0xa8, 0x0d, 0x76, 0x31, 0x92, 0x9b, 0x2d, 0x6c, 0xae, 0x79, 0xfa, 0x01, 0x2f, 0x55,
+	0x2f, 0x29, 0x9d, 0x8b, 0x11, 0xd1, 0x97, 0x3c, 0x03, 0x41, 0x2a, 0xd5, 0x1e, 0x42, 0x3e, 0x48,
+	0x40, 0x39, 0x98, 0x6d, 0xd7, 0x1a, 0x4f, 0x0f, 0xeb, 0x6d, 0x35, 0x81, 0x56, 0xa0, 0xd8, 0xee,
+	0xe8, 0xf5, 0x5a, 0xa3, 0xbe, 0x63, 0x1c, 0xb7, 0x74, 0x63, 0x7b, 0xff, 0xa8, 0xf9, 0xa4, 0xad,
+	0x2a, 0xda, 0x27, 0x90, 0x17, 0x8e, 0x64, 0xd6, 0x37, 0x60, 0xd6, 0x21, 0x74, 0x3c, 0x60, 0xde,
+	0x7e, 0x96, 0x22, 0xfb, 0x11, 0x72, 0xba, 0x27, 0xa5, 0x5d, 0x00, 0x6a, 0x33, 0x87, 0x60, 0x2b,
+	0x64, 0x66, 0x0b, 0x0a, 0xdd, 0xb3, 0xb1, 0xfd, 0x8c, 0xf4, 0xbc, 0x54, 0x0a, 0x6b, 0xb7, 0x3d,
+	0x6b, 0x42, 0x67, 0x5b, 0xc8, 0x88, 0x64, 0xe8, 0xf3, 0xdd, 0xe0, 0xd2, 0xad, 0x7a, 0x17, 0xb5,
+	0x0b, 0xc3, 0xb4, 0x7b, 0xe4, 0x9c, 0xa7, 0x22, 0xa5, 0x03, 0x27, 0x1d, 0xb8, 0x14, 0xed, 0xcf,
+	0x0a, 0x14, 0x63, 0xec, 0xa0, 0x53, 0xc8, 0xf0, 0xe4, 0x47, 0x4f, 0xf0, 0xe8, 0x44, 0xd4, 0xca,
+	0x53, 0x6c, 0x3a, 0x5b, 0x1f, 0x7d, 0xfd, 0x62, 0x35, 0xf1, 0xf7, 0x17, 0xab, 0xf7, 0x6f, 0x72,
+	0x1d, 0x09, 0xbd, 0x5a, 0x0f, 0x8f, 0x18, 0x71, 0x74, 0x69, 0x1d, 0xdd, 0x87, 0x0c, 0x8f, 0xd8,
+	0xab, 0xd3, 0x62, 0xcc, 0xe6, 0xb6, 0xd2, 0xae, 0x1f, 0x5d, 0x0a, 0x6a, 0x7f, 0x48, 0x42, 0x2e,
+	0xc0, 0x45, 0x15, 0xc8, 0x59, 0xa6, 0x6d, 0x30, 0xd3, 0x22, 0x06, 0x3f, 0x6a, 0xee, 0x1e, 0xb3,
+	0x96, 0x69, 0x77, 0x4c, 0x8b, 0x34, 0x28, 0xe7, 0xe3, 0x73, 0x9f, 0x9f, 0x94, 0x7c, 0x7c, 0x2e,
+	0xf9, 0xf7, 0x20, 0xed, 0x16, 0x4f, 0x29, 0xb5, 0xa6, 0xac, 0x17, 0x36, 0xff, 0x2f, 0x26, 0x80,
+	0x6a, 0xdd, 0xee, 0x0e, 0x7b, 0xa6, 0xdd, 0xd7, 0xb9, 0x24, 0x7a, 0x0a, 0xe9, 0x1e, 0x66, 0xb8,
+	0x94, 0x5e, 0x53, 0xd6, 0xf3, 0x5b, 0x8f, 0x25, 0x0a, 0x0f, 0x6f, 0x84, 0xc2, 0x91, 0x4d, 0xf1,
+	0x29, 0xd9, 0xba, 0x60, 0xa4, 0x3d, 0x30, 0xbb, 0x44, 0xe7, 0x96, 0xb4, 0x1d, 0x98, 0xf3, 0x7c,
+	0xb8, 0x45, 0x77, 0xd4, 0x7c, 0xd2, 0x6c, 0x7d, 0xde, 0x54, 0x13, 0x68, 0x16, 0x52, 0xc7, 0x2d,
+	0x5d, 0x55, 0xd0, 0x3c, 0x64, 0xf7, 0x0f, 0xda, 0x9d, 0xd6, 0x9e, 0x5e, 0x6b, 0xa8, 0x49, 0x54,
+	0x84, 0x85, 0xdd, 0xc3, 0x56, 0xad, 0x63, 0x4c, 0x88, 0x29, 0xed, 0x3f, 0x0a, 0xe4, 0x83, 0x47,
+	0x06, 0x7d, 0x00, 0x88, 0x32, 0xec, 0x30, 0xbe, 0x79, 0xca, 0xb0, 0x35, 0x9a, 0x20, 0xa4, 0x72,
+	0x4e, 0xc7, 0x63, 0x34, 0x28, 0x5a, 0x07, 0x95, 0xd8, 0xbd, 0xb0, 0xac, 0x40, 0xab, 0x40, 0xec,
+	0x5e, 0x50, 0x32, 0x78, 0x57, 0xa6, 0x6e, 0x72, 0x57, 0xa2, 0xf7, 0x61, 0x81, 0x50, 0x66, 0x5a,
+	0x98, 0x11, 0x43, 0x26, 0xdc, 0x45, 0x6f, 0x4e, 0x2f, 0x78, 0x64, 0x8e, 0x34, 0x45, 0x8f, 0x21,
+	0xdf, 0x1b, 0xfe, 0xda, 0xa6, 0xd8, 0x1a, 0x0d, 0x4c, 0xbb, 0x5f, 0x9a, 0x59, 0x53, 0xd6, 0x73,
+	0x9b, 0x25, 0xcf, 0xfc, 0x4e, 0x80, 0xb7, 0x6f, 0xda, 0x4c, 0x0f, 0x49, 0x6b, 0x7f, 0x54, 0x60,
+	0xb1, 0x7e, 0x4e, 0xac, 0xd1, 0x00, 0x3b, 0x3f, 0x08, 0x12, 0xf7, 0xa7, 0x90, 0x58, 0x8a, 0x43,
+	0x82, 0x06, 0xda, 0xc6, 0x13, 0x98, 0x0f, 0xdd, 0x03, 0xe8, 0x63, 0x00, 0xee, 0x29, 0xee, 0x0a,
+	0x1c, 0x9d, 0x54, 0x5d, 0x77, 0xe2, 0x54, 0xca, 0x83, 0x10, 0x90, 0xd6, 0xfe, 0xad, 0x40, 0x91,
+	0x5b, 0xf3, 0x2e, 0x10, 0x69, 0xf3, 0x13, 0xc8, 0x09, 0x98, 0x83, 0x46, 0x57, 0xbc, 0xd0, 0x26,
+	0x26, 0x83, 0x07, 0x2c, 0xa8, 0x11, 0x09, 0x2a, 0xf9, 0x26, 0x41, 0xa1, 0x87, 0xb0, 0xec, 0x65,
+	0xb5, 0x27, 0xb3, 0x6d, 0x74, 0xdd, 0x3e, 0xc3, 0xcf, 0x58, 0x5a, 0x5f, 0xf4, 0xb9, 0x22, 0xe9,
+	0xa2, 0xbb, 0xc5, 0x69, 0x9d, 0x5c, 0x30, 0x22, 0x2a, 0x65, 0x5a, 0xcb, 0x3d, 0x43, 0x54, 0x6b,
+	0xc3, 0x52, 0x24, 0xe1, 0xdf, 0x03, 0xaa, 0x5f, 0x25, 0x01, 0x05, 0x47, 0x15, 0x59, 0x44, 0xd7,
+	0xf4, 0xdf, 0xf8, 0x1a, 0x4b, 0xbe, 0x41, 0x8d, 0xa5, 0xae, 0xad, 0xb1, 0xf4, 0x9a, 0x72, 0x83,
+	0x1a, 0x73, 0x9b, 0xef, 0xc0, 0xb4, 0x4c, 0xc6, 0x8f, 0x4f, 0x4a, 0x17, 0x0b, 0x97, 0x8a, 0x4f,
+	0x19, 0x71, 0x4a, 0x19, 0x1e, 0xba, 0x58, 0xa0, 0x77, 0xa1, 0xe0, 0xde, 0x8f, 0xd4, 0xfc, 0x92,
+	0x48, 0xbc, 0x67, 0xb9, 0x52, 0xde, 0xc2, 0xe7, 0x6d, 0xf3, 0x4b, 0x22, 0x70, 0x7e, 0x04, 0xc5,
+	0x10, 0x22, 0x12, 0xe5, 0xff, 0x87, 0x7c, 0x60, 0xe6, 0xf0, 0xe6, 0xaa, 0xdc, 0x64, 0x70, 0xa0,
+	0xda, 0xdf, 0x14, 0xb8, 0x35, 0x99, 0x15, 0x7f, 0xd8, 0x03, 0xf9, 0x66, 0x60, 0xa5, 0x63, 0xc1,
+	0x9a, 0x09, 0x80, 0xa5, 0xfd, 0x0c, 0x50, 0x70, 0x2f, 0x12, 0x85, 0xeb, 0x86, 0x4b, 0x0d, 0x81,
+	0x7a, 0x44, 0x89, 0xd3, 0x66, 0x98, 0x79, 0x08, 0x68, 0x7f, 0x55, 0xe0, 0x56, 0x80, 0x28, 0x4d,
+	0xdd, 0xf5, 0xbe, 0x11, 0xcc, 0xa1, 0x6d, 0x38, 0x98, 0x89, 0x3a, 0x53, 0xf4, 0x79, 0x9f, 0xaa,
+	0x63, 0x46, 0xdc, 0x52, 0xb4, 0xc7, 0xd6, 0x64, 0xc6, 0x73, 0x0f, 0x48, 0xd6, 0x1e, 0x5b, 0xb2,
+	0x7d, 0x7f, 0x00, 0x08, 0x8f, 0x4c, 0x23, 0x62, 0x29, 0xc5, 0x2d, 0xa9, 0x78, 0x64, 0x1e, 0x84,
+	0x8c, 0x55, 0xa1, 0xe8, 0x8c, 0x07, 0x24, 0x2a, 0x9e, 0xe6, 0xe2, 0xb7, 0x5c, 0x56, 0x48, 0x5e,
+	0xfb, 0x25, 0x14, 0xdd, 0xc0, 0x0f, 0x76, 0xc2, 0xa1, 0xaf, 0xc0, 0xec, 0x98, 0x12, 0xc7, 0x30,
+	0x7b, 0xf2, 0x6c, 0x64, 0xdc, 0xe5, 0x41, 0x0f, 0x7d, 0x28, 0xfb, 0x65, 0x92, 0xe7, 0xe3, 0x1d,
+	0x2f, 0x1f, 0x53, 0x9b, 0x97, 0xcd, 0x70, 0x0f, 0x90, 0xcb, 0xa2, 0x61, 0xeb, 0xf7, 0x61, 0x86,
+	0xba, 0x84, 0xe8, 0x14, 0x14, 0x13, 0x89, 0x2e, 0x24, 0xb5, 0xbf, 0x28, 0x50, 0x69, 0x10, 0xe6,
+	0x98, 0x5d, 0xba, 0x3b, 0x74, 0xc2, 0xe9, 0x7f, 0xcb, 0x65, 0xf8, 0x08, 0xf2, 0x5e, 0x7d, 0x19,
+	0x94, 0xb0, 0xd7, 0xf7, 0x86, 0x9c, 0x27, 0xda, 0x26, 0x4c, 0x7b, 0x02, 0xab, 0x57, 0xc6, 0x2c,
+	0xa1, 0x58, 0x87, 0x8c, 0xc5, 0x45, 0x24, 0x16, 0xea, 0xe4, 0x5a, 0x13, 0xaa, 0xba, 0xe4, 0x6b,
+	0x25, 0x58, 0x96, 0xc6, 0x1a, 0x84, 0x61, 0x17, 0x5d, 0xaf, 0xfa, 0x5a, 0xb0, 0x32, 0xc5, 0x91,
+	0xe6, 0x1f, 0xc2, 0x9c, 0x25, 0x69, 0xd2, 0x41, 0x29, 0xea, 0xc0, 0xd7, 0xf1, 0x25, 0xb5, 0x7f,
+	0x29, 0xb0, 0x10, 0xe9, 0x2b, 0x2e, 0x5e, 0xa7, 0xce, 0xd0, 0x32, 0xbc, 0xaf, 0xde, 0x49, 0x69,
+	0x14, 0x5c, 0xfa, 0x81, 0x24, 0x1f, 0xf4, 0x82, 0xb5, 0x93, 0x0c, 0xd5, 0xce, 0x64, 0x10, 0x4d,
+	0xbd, 0xd5, 0x41, 0xf4, 0xa7, 0xfe, 0x20, 0x9a, 0xe6, 0x7e, 0xe6, 0xbd, 0x54, 0xc5, 0x8d, 0xa0,
+	0xdf, 0x28, 0x30, 0x23, 0x76, 0xf8, 0xb6, 0xea, 0xa7, 0x0c, 0x73, 0x44, 0x0e, 0x84, 0xfc, 0xd8,
+	0xce, 0xe8, 0xfe, 0xfa, 0x2d, 0x8c, 0x9f, 0x35, 0x98, 0x0f, 0x55, 0xda, 0xff, 0xf0, 0x20, 0x60,
+	0x40, 0x3e, 0xc8, 0x41, 0x77, 0xe5, 0x54, 0xad, 0xf0, 0xa9, 0xfa, 0x96, 0xa7, 0xcd, 0xd9, 0xfc,
+	0x13, 0x8c, 0xb3, 0x11, 0x82, 0x34, 0x6f, 0xa6, 0x22, 0xe9, 0xfc, 0xff, 0xe4, 0xcb, 0x31, 0x25,
+	0x6e, 0x5e, 0xbe, 0xd0, 0x7e, 0xab, 0x40, 0x61, 0x52, 0x5f, 0xbb, 0xe6, 0x80, 0x7c, 0x1f, 0xe5,
+	0x55, 0x86, 0xb9, 0x53, 0x73, 0x40, 0x78, 0x0c, 0xc2, 0x9d, 0xbf, 0x76, 0x63, 0x9b, 0xe0, 0x2c,
+	0x91, 0xfa, 0x5d, 0x12, 0xd4, 0xe8, 0x0c, 0xea, 0x5a, 0xa7, 0x8c, 0x04, 0x32, 0x9f, 0x71, 0x97,
+	0x0d, 0x8a, 0x76, 0x21, 0x87, 0xfb, 0x7d, 0x87, 0xf4, 0xb1, 0x7b, 0x77, 0x72, 0xd7, 0x85, 0xcd,
+	0x77, 0xaf, 0x9a, 0x65, 0xab, 0xb5, 0x89, 0xac, 0x1e, 0x54, 0x44, 0x15, 0x80, 0x33, 0x93, 0xb2,
+	0x61, 0xdf, 0xc1, 0x72, 0x4a, 0x98, 0xd3, 0x03, 0x14, 0xed, 0x1c, 0x72, 0x01, 0x5d, 0xb4, 0x08,
+	0x6a, 0x6d, 0x6f, 0x4f, 0xaf, 0xef, 0xd5, 0x3a, 0x07, 0xad, 0xa6, 0xd1, 0x6c, 0x35, 0xeb, 0x6a,
+	0xc2, 0xfd, 0x64, 0x08, 0x52, 0x1b, 0x07, 0x4d, 0x55, 0x99, 0x22, 0xd6, 0x8e, 0xd5, 0x64, 0x94,
+	0x58, 0xfb, 0x6c, 0x4f, 0x4d, 0x45, 0x8d, 0x1e, 0xd6, 0xda, 0x1d, 0x35, 0xfd, 0x93, 0x9f, 0x43,
+	0xd6, 0x4f, 0x29, 0xca, 0xc2, 0x4c, 0xfd, 0xd3, 0xa3, 0xda, 0xa1, 0x9a, 0x70, 0x3f, 0x57, 0x9a,
+	0xad, 0x8e, 0x21, 0x96, 0x0a, 0x5a, 0x80, 0x9c, 0x5e, 0xdf, 0xab, 0x1f, 0x1b, 0x8d, 0x5a, 0x67,
+	0x7b, 0x5f, 0x4d, 0x22, 0x04, 0x05, 0x41, 0x68, 0xb6, 0x24, 0x2d, 0xb5, 0xf9, 0xfb, 0x59, 0x98,
+	0xf3, 0x72, 0x86, 0x3e, 0x82, 0xf4, 0xd3, 0x31, 0x3d, 0x43, 0xcb, 0x93, 0xf3, 0xfe, 0xb9, 0x63,
+	0x32, 0x22, 0xef, 0xaf, 0xf2, 0xca, 0x14, 0x5d, 0xdc, 0x5e, 0x5a, 0x02, 0xed, 0x40, 0x2e, 0x30,
+	0x12, 0xa3, 0xd8, 0xd7, 0x84, 0xf2, 0xed, 0x10, 0x35, 0x3c, 0x3d, 0x6b, 0x89, 0x7b, 0x0a, 0x6a,
+	0x41, 0x81, 0xb3, 0xbc, 0xe9, 0x92, 0x22, 0xff, 0xd3, 0x30, 0xee, 0x0b, 0xa3, 0x7c, 0xe7, 0x0a,
+	0xae, 0x1f, 0xd6, 0x7e, 0xf8, 0xa1, 0xab, 0x1c, 0xf7, 0x26, 0x16, 0x0d, 0x2e, 0x66, 0xe4, 0xd2,
+	0x12, 0xa8, 0x0e, 0x30, 0x19, 0x42, 0xd0, 0x3b, 0x21, 0xe1, 0xe0, 0x90, 0x55, 0x2e, 0xc7, 0xb1,
+	0x7c, 0x33, 0x5b, 0x90, 0xf5, 0x5b, 0x30, 0x2a, 0xc5, 0x74, 0x65, 0x61, 0xe4, 0xea, 0x7e, 0xad,
+	0x25, 0xd0, 0x2e, 0xe4, 0x6b, 0x83, 0xc1, 0x4d, 0xcc, 0x94, 0x83, 0x1c, 0x1a, 0xb5, 0x33, 0x80,
+	0x95, 0x2b, 0xba, 0x1e, 0x7a, 0xcf, 0xbf, 0x3b, 0x5e, 0xdb, 0xca, 0xcb, 0xef, 0x5f, 0x2b, 0xe7,
+	0x7b, 0xeb, 0xc0, 0x42, 0xa4, 0xf9, 0xa1, 0x4a, 0x44, 0x3b, 0xd2, 0x2f, 0xcb, 0xab, 0x57, 0xf2,
+	0x7d, 0xab, 0x27, 0x50, 0x9c, 0xe0, 0xec, 0xbf, 0x89, 0x22, 0x6d, 0x3a, 0x09, 0xd1, 0x07, 0xd8,
+	0xf2, 0x8f, 0x5e, 0x2b, 0x13, 0xa8, 0xca, 0x67, 0xb0, 0x1c, 0xff, 0xe6, 0x88, 0xee, 0xc6, 0xd4,
+	0xcc, 0xf4, 0x3b, 0x68, 0xf9, 0xbd, 0xeb, 0xc4, 0x26, 0xce, 0xb6, 0x1e, 0x3f, 0x7f, 0x59, 0x49,
+	0x7c, 0xfb, 0xb2, 0x92, 0xf8, 0xee, 0x65, 0x45, 0xf9, 0xcd, 0x65, 0x45, 0xf9, 0xd3, 0x65, 0x45,
+	0xf9, 0xfa, 0xb2, 0xa2, 0x3c, 0xbf, 0xac, 0x28, 0xff, 0xb8, 0xac, 0x28, 0xff, 0xbc, 0xac, 0x24,
+	0xbe, 0xbb, 0xac, 0x28, 0x5f, 0xbd, 0xaa, 0x24, 0x9e, 0xbf, 0xaa, 0x24, 0xbe, 0x7d, 0x55, 0x49,
+	0xfc, 0x22, 0xd3, 0x1d, 0x98, 0xc4, 0x66, 0x27, 0x19, 0xfe, 0xf2, 0xfc, 0xe0, 0xbf, 0x03, 0x00,
+	0xe1, 0xae, 0x3e, 0x8b, 0xf4, 0x16, 0x00, 0x00,
 }
 
 func (x MatchType) String() string {
@@ -1949,6 +2143,13 @@ func (x StreamChunk_Encoding) String() string {
 	}
 	return strconv.Itoa(int(x))
 }
+func (x DownsamplingHint_Aggregation) String() string {
+	s, ok := DownsamplingHint_Aggregation_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
 func (this *LabelNamesAndValuesRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -2338,6 +2539,12 @@ func (this *QueryRequest) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.EstimateChunks != that1.EstimateChunks {
+		return false
+	}
+	if !this.Downsampling.Equal(that1.Downsampling) {
+		return false
+	}
 	return true
 }
 func (this *ExemplarQueryRequest) Equal(that interface{}) bool {
@@ -2439,6 +2646,12 @@ func (this *QueryStreamResponse) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.EstimatedChunksCount != that1.EstimatedChunksCount {
+		return false
+	}
+	if this.EstimatedChunksBytes != that1.EstimatedChunksBytes {
+		return false
+	}
 	return true
 }
 func (this *ExemplarQueryResponse) Equal(that interface{}) bool {
@@ -2501,6 +2714,15 @@ func (this *LabelValuesRequest) Equal(that interface{}) bool {
 	if !this.Matchers.Equal(that1.Matchers) {
 		return false
 	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if this.After != that1.After {
+		return false
+	}
+	if this.MaxSizeBytes != that1.MaxSizeBytes {
+		return false
+	}
 	return true
 }
 func (this *LabelValuesResponse) Equal(that interface{}) bool {
@@ -2560,6 +2782,12 @@ func (this *LabelNamesRequest) Equal(that interface{}) bool {
 	if !this.Matchers.Equal(that1.Matchers) {
 		return false
 	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if this.After != that1.After {
+		return false
+	}
 	return true
 }
 func (this *LabelNamesResponse) Equal(that interface{}) bool {
@@ -2983,6 +3211,36 @@ func (this *TimeSeriesFile) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *DownsamplingHint) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*DownsamplingHint)
+	if !ok {
+		that2, ok := that.(DownsamplingHint)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.StepMs != that1.StepMs {
+		return false
+	}
+	if this.Aggregation != that1.Aggregation {
+		return false
+	}
+	if this.Histograms != that1.Histograms {
+		return false
+	}
+	return true
+}
 func (this *LabelNamesAndValuesRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3138,13 +3396,17 @@ func (this *QueryRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 9)
 	s = append(s, "&client.QueryRequest{")
 	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
 	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
+	s = append(s, "EstimateChunks: "+fmt.Sprintf("%#v", this.EstimateChunks)+",\n")
+	if this.Downsampling != nil {
+		s = append(s, "Downsampling: "+fmt.Sprintf("%#v", this.Downsampling)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3182,7 +3444,7 @@ func (this *QueryStreamResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
+	s := make([]string, 0, 8)
 	s = append(s, "&client.QueryStreamResponse{")
 	if this.Chunkseries != nil {
 		vs := make([]*TimeSeriesChunk, len(this.Chunkseries))
@@ -3198,6 +3460,8 @@ func (this *QueryStreamResponse) GoString() string {
 		}
 		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	s = append(s, "EstimatedChunksCount: "+fmt.Sprintf("%#v", this.EstimatedChunksCount)+",\n")
+	s = append(s, "EstimatedChunksBytes: "+fmt.Sprintf("%#v", this.EstimatedChunksBytes)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3221,7 +3485,7 @@ func (this *LabelValuesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 11)
 	s = append(s, "&client.LabelValuesRequest{")
 	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
 	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
@@ -3229,6 +3493,9 @@ func (this *LabelValuesRequest) GoString() string {
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "After: "+fmt.Sprintf("%#v", this.After)+",\n")
+	s = append(s, "MaxSizeBytes: "+fmt.Sprintf("%#v", this.MaxSizeBytes)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3246,13 +3513,15 @@ func (this *LabelNamesRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 9)
 	s = append(s, "&client.LabelNamesRequest{")
 	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
 	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
 	if this.Matchers != nil {
 		s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
 	}
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "After: "+fmt.Sprintf("%#v", this.After)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3429,6 +3698,18 @@ func (this *TimeSeriesFile) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *DownsamplingHint) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&client.DownsamplingHint{")
+	s = append(s, "StepMs: "+fmt.Sprintf("%#v", this.StepMs)+",\n")
+	s = append(s, "Aggregation: "+fmt.Sprintf("%#v", this.Aggregation)+",\n")
+	s = append(s, "Histograms: "+fmt.Sprintf("%#v", this.Histograms)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringIngester(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -4466,6 +4747,28 @@ func (m *QueryRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Downsampling != nil {
+		{
+			size, err := m.Downsampling.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIngester(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.EstimateChunks {
+		i--
+		if m.EstimateChunks {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if len(m.Matchers) > 0 {
 		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -4597,6 +4900,16 @@ func (m *QueryStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.EstimatedChunksBytes != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EstimatedChunksBytes))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.EstimatedChunksCount != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.EstimatedChunksCount))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.Timeseries) > 0 {
 		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -4685,6 +4998,23 @@ func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.MaxSizeBytes != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MaxSizeBytes))
+		i--
+		dAtA[i] = 0x38
+	}
+	if len(m.After) > 0 {
+		i -= len(m.After)
+		copy(dAtA[i:], m.After)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.After)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x28
+	}
 	if m.Matchers != nil {
 		{
 			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
@@ -4769,6 +5099,18 @@ func (m *LabelNamesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.After) > 0 {
+		i -= len(m.After)
+		copy(dAtA[i:], m.After)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.After)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.Matchers != nil {
 		{
 			size, err := m.Matchers.MarshalToSizedBuffer(dAtA[:i])
@@ -5361,6 +5703,49 @@ func (m *TimeSeriesFile) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *DownsamplingHint) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DownsamplingHint) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DownsamplingHint) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Histograms {
+		i--
+		if m.Histograms {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Aggregation != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Aggregation))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.StepMs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.StepMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintIngester(dAtA []byte, offset int, v uint64) int {
 	offset -= sovIngester(v)
 	base := offset
@@ -5592,6 +5977,13 @@ func (m *QueryRequest) Size() (n int) {
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
+	if m.EstimateChunks {
+		n += 2
+	}
+	if m.Downsampling != nil {
+		l = m.Downsampling.Size()
+		n += 1 + l + sovIngester(uint64(l))
+	}
 	return n
 }
 
@@ -5649,6 +6041,12 @@ func (m *QueryStreamResponse) Size() (n int) {
 			n += 1 + l + sovIngester(uint64(l))
 		}
 	}
+	if m.EstimatedChunksCount != 0 {
+		n += 1 + sovIngester(uint64(m.EstimatedChunksCount))
+	}
+	if m.EstimatedChunksBytes != 0 {
+		n += 1 + sovIngester(uint64(m.EstimatedChunksBytes))
+	}
 	return n
 }
 
@@ -5687,6 +6085,16 @@ func (m *LabelValuesRequest) Size() (n int) {
 		l = m.Matchers.Size()
 		n += 1 + l + sovIngester(uint64(l))
 	}
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	l = len(m.After)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if m.MaxSizeBytes != 0 {
+		n += 1 + sovIngester(uint64(m.MaxSizeBytes))
+	}
 	return n
 }
 
@@ -5721,6 +6129,13 @@ func (m *LabelNamesRequest) Size() (n int) {
 		l = m.Matchers.Size()
 		n += 1 + l + sovIngester(uint64(l))
 	}
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	l = len(m.After)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
 	return n
 }
 
@@ -5970,6 +6385,24 @@ func (m *TimeSeriesFile) Size() (n int) {
 	return n
 }
 
+func (m *DownsamplingHint) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StepMs != 0 {
+		n += 1 + sovIngester(uint64(m.StepMs))
+	}
+	if m.Aggregation != 0 {
+		n += 1 + sovIngester(uint64(m.Aggregation))
+	}
+	if m.Histograms {
+		n += 2
+	}
+	return n
+}
+
 func sovIngester(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -6158,6 +6591,8 @@ func (this *QueryRequest) String() string {
 		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
 		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
 		`Matchers:` + repeatedStringForMatchers + `,`,
+		`EstimateChunks:` + fmt.Sprintf("%v", this.EstimateChunks) + `,`,
+		`Downsampling:` + strings.Replace(this.Downsampling.String(), "DownsamplingHint", "DownsamplingHint", 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6211,6 +6646,8 @@ func (this *QueryStreamResponse) String() string {
 	s := strings.Join([]string{`&QueryStreamResponse{`,
 		`Chunkseries:` + repeatedStringForChunkseries + `,`,
 		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`EstimatedChunksCount:` + fmt.Sprintf("%v", this.EstimatedChunksCount) + `,`,
+		`EstimatedChunksBytes:` + fmt.Sprintf("%v", this.EstimatedChunksBytes) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6239,6 +6676,9 @@ func (this *LabelValuesRequest) String() string {
 		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
 		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
 		`Matchers:` + strings.Replace(this.Matchers.String(), "LabelMatchers", "LabelMatchers", 1) + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`After:` + fmt.Sprintf("%v", this.After) + `,`,
+		`MaxSizeBytes:` + fmt.Sprintf("%v", this.MaxSizeBytes) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6261,6 +6701,8 @@ func (this *LabelNamesRequest) String() string {
 		`StartTimestampMs:` + fmt.Sprintf("%v", this.StartTimestampMs) + `,`,
 		`EndTimestampMs:` + fmt.Sprintf("%v", this.EndTimestampMs) + `,`,
 		`Matchers:` + strings.Replace(this.Matchers.String(), "LabelMatchers", "LabelMatchers", 1) + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`After:` + fmt.Sprintf("%v", this.After) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -6450,6 +6892,18 @@ func (this *TimeSeriesFile) String() string {
 	}, "")
 	return s
 }
+func (this *DownsamplingHint) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DownsamplingHint{`,
+		`StepMs:` + fmt.Sprintf("%v", this.StepMs) + `,`,
+		`Aggregation:` + fmt.Sprintf("%v", this.Aggregation) + `,`,
+		`Histograms:` + fmt.Sprintf("%v", this.Histograms) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringIngester(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -7867,20 +8321,76 @@ func (m *QueryRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIngester(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthIngester
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EstimateChunks", wireType)
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.EstimateChunks = bool(v != 0)
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Downsampling", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Downsampling == nil {
+				m.Downsampling = &DownsamplingHint{}
+			}
+			if err := m.Downsampling.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
 			}
 			iNdEx += skippy
 		}
@@ -8200,6 +8710,44 @@ func (m *QueryStreamResponse) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EstimatedChunksCount", wireType)
+			}
+			m.EstimatedChunksCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EstimatedChunksCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EstimatedChunksBytes", wireType)
+			}
+			m.EstimatedChunksBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EstimatedChunksBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -8446,6 +8994,76 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field After", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.After = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSizeBytes", wireType)
+			}
+			m.MaxSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -8658,6 +9276,57 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field After", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.After = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -10219,6 +10888,117 @@ func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *DownsamplingHint) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DownsamplingHint: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DownsamplingHint: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StepMs", wireType)
+			}
+			m.StepMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StepMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Aggregation", wireType)
+			}
+			m.Aggregation = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Aggregation |= DownsamplingHint_Aggregation(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Histograms", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Histograms = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipIngester(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"math"
+
+	"github.com/prometheus/prometheus/model/value"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+// downsampleSeries reduces the samples of the series as requested by the hint. The samples between each pair
+// of consecutive multiples of the step are reduced to a single sample, while the samples at the multiples of
+// the step are kept as-is. This way, the result of the functions over a range selector whose range and
+// evaluation timestamps are multiples of the step is not affected by the reduction, because each range covers
+// whole steps, plus the sample at its start. The series is reduced in place.
+func downsampleSeries(ts *mimirpb.TimeSeries, hint *client.DownsamplingHint) {
+	if hint.Aggregation != client.AGGREGATION_NONE {
+		ts.Samples = downsample(ts.Samples, hint.StepMs, sampleTimestamp, samplesAggregator(hint.Aggregation))
+	}
+	if hint.Histograms {
+		ts.Histograms = downsample(ts.Histograms, hint.StepMs, histogramTimestamp, lastHistogram)
+	}
+}
+
+// downsample reduces the items, sorted by timestamp, between each pair of consecutive multiples of the step
+// to the item returned by aggregate. The items are reduced in place.
+func downsample[T any](items []T, step int64, timestamp func(T) int64, aggregate func([]T) T) []T {
+	out := items[:0]
+	for i := 0; i < len(items); {
+		t := timestamp(items[i])
+		offset := t % step
+		if offset < 0 {
+			offset += step
+		}
+		if offset == 0 {
+			out = append(out, items[i])
+			i++
+			continue
+		}
+
+		// Find the items up to the next multiple of the step, excluded.
+		next := t - offset + step
+		j := i + 1
+		for j < len(items) && timestamp(items[j]) < next {
+			j++
+		}
+
+		// The output never overtakes the input, so the items being aggregated haven't been overwritten yet.
+		out = append(out, aggregate(items[i:j]))
+		i = j
+	}
+	return out
+}
+
+func sampleTimestamp(s mimirpb.Sample) int64 {
+	return s.TimestampMs
+}
+
+func histogramTimestamp(h mimirpb.Histogram) int64 {
+	return h.Timestamp
+}
+
+func samplesAggregator(aggregation client.DownsamplingHint_Aggregation) func([]mimirpb.Sample) mimirpb.Sample {
+	switch aggregation {
+	case client.AGGREGATION_MIN:
+		return minSample
+	case client.AGGREGATION_MAX:
+		return maxSample
+	case client.AGGREGATION_AVG:
+		return avgSample
+	default:
+		return lastSample
+	}
+}
+
+// The staleness markers are ignored by the aggregations below, because they're dropped by the PromQL engine,
+// unless all the samples are staleness markers.
+
+func minSample(samples []mimirpb.Sample) mimirpb.Sample {
+	res := samples[0]
+	for _, s := range samples[1:] {
+		// NaN values are replaced by any other value, like the min_over_time() function does.
+		if !value.IsStaleNaN(s.Value) && (s.Value < res.Value || math.IsNaN(res.Value)) {
+			res = s
+		}
+	}
+	return res
+}
+
+func maxSample(samples []mimirpb.Sample) mimirpb.Sample {
+	res := samples[0]
+	for _, s := range samples[1:] {
+		// NaN values are replaced by any other value, like the max_over_time() function does.
+		if !value.IsStaleNaN(s.Value) && (s.Value > res.Value || math.IsNaN(res.Value)) {
+			res = s
+		}
+	}
+	return res
+}
+
+func avgSample(samples []mimirpb.Sample) mimirpb.Sample {
+	sum, count := 0.0, 0
+	for _, s := range samples {
+		if !value.IsStaleNaN(s.Value) {
+			sum += s.Value
+			count++
+		}
+	}
+	if count == 0 {
+		return samples[len(samples)-1]
+	}
+	return mimirpb.Sample{TimestampMs: samples[len(samples)-1].TimestampMs, Value: sum / float64(count)}
+}
+
+func lastSample(samples []mimirpb.Sample) mimirpb.Sample {
+	for i := len(samples) - 1; i > 0; i-- {
+		if !value.IsStaleNaN(samples[i].Value) {
+			return samples[i]
+		}
+	}
+	return samples[0]
+}
+
+func lastHistogram(histograms []mimirpb.Histogram) mimirpb.Histogram {
+	return histograms[len(histograms)-1]
+}
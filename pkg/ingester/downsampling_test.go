@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestDownsampleSeries(t *testing.T) {
+	staleNaN := math.Float64frombits(value.StaleNaN)
+
+	samples := func(values ...float64) []mimirpb.Sample {
+		// The samples are 5ms apart, starting from 10ms.
+		out := make([]mimirpb.Sample, 0, len(values))
+		for i, v := range values {
+			out = append(out, mimirpb.Sample{TimestampMs: 10 + int64(i)*5, Value: v})
+		}
+		return out
+	}
+
+	tests := map[string]struct {
+		hint     *client.DownsamplingHint
+		input    []mimirpb.Sample
+		expected []mimirpb.Sample
+	}{
+		"min": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_MIN},
+			input:    samples(1, 5, 3, 4, 2, 7, 8),
+			expected: []mimirpb.Sample{{TimestampMs: 10, Value: 1}, {TimestampMs: 20, Value: 3}, {TimestampMs: 30, Value: 2}, {TimestampMs: 40, Value: 8}},
+		},
+		"max": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_MAX},
+			input:    samples(1, 5, 3, 4, 2, 7, 8),
+			expected: []mimirpb.Sample{{TimestampMs: 15, Value: 5}, {TimestampMs: 20, Value: 3}, {TimestampMs: 35, Value: 7}, {TimestampMs: 40, Value: 8}},
+		},
+		"avg": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_AVG},
+			input:    samples(1, 5, 3, 4, 2, 7, 8),
+			expected: []mimirpb.Sample{{TimestampMs: 15, Value: 3}, {TimestampMs: 20, Value: 3}, {TimestampMs: 35, Value: 13.0 / 3}, {TimestampMs: 40, Value: 8}},
+		},
+		"last": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_LAST},
+			input:    samples(1, 5, 3, 4, 2, 7, 8),
+			expected: []mimirpb.Sample{{TimestampMs: 15, Value: 5}, {TimestampMs: 20, Value: 3}, {TimestampMs: 35, Value: 7}, {TimestampMs: 40, Value: 8}},
+		},
+		"NaN values are replaced by any other value": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_MAX},
+			input:    samples(math.NaN(), 5, 3),
+			expected: []mimirpb.Sample{{TimestampMs: 15, Value: 5}, {TimestampMs: 20, Value: 3}},
+		},
+		"staleness markers are ignored unless there's no other sample": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_LAST},
+			input:    samples(1, staleNaN, 3, staleNaN),
+			expected: []mimirpb.Sample{{TimestampMs: 10, Value: 1}, {TimestampMs: 20, Value: 3}, {TimestampMs: 25, Value: staleNaN}},
+		},
+		"negative timestamps": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_MAX},
+			input:    []mimirpb.Sample{{TimestampMs: -25, Value: 1}, {TimestampMs: -20, Value: 2}, {TimestampMs: -15, Value: 3}, {TimestampMs: -5, Value: 4}},
+			expected: []mimirpb.Sample{{TimestampMs: -25, Value: 1}, {TimestampMs: -20, Value: 2}, {TimestampMs: -5, Value: 4}},
+		},
+		"no aggregation": {
+			hint:     &client.DownsamplingHint{StepMs: 20, Histograms: true},
+			input:    samples(1, 5, 3),
+			expected: samples(1, 5, 3),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts := &mimirpb.TimeSeries{Samples: tc.input}
+			downsampleSeries(ts, tc.hint)
+
+			assert.Len(t, ts.Samples, len(tc.expected))
+			for i, expected := range tc.expected {
+				assert.Equal(t, expected.TimestampMs, ts.Samples[i].TimestampMs)
+				if math.IsNaN(expected.Value) {
+					assert.Equal(t, math.Float64bits(expected.Value), math.Float64bits(ts.Samples[i].Value))
+				} else {
+					assert.InDelta(t, expected.Value, ts.Samples[i].Value, 1e-9)
+				}
+			}
+		})
+	}
+}
+
+func TestDownsampleSeries_Histograms(t *testing.T) {
+	ts := &mimirpb.TimeSeries{Histograms: []mimirpb.Histogram{
+		{Timestamp: 10, Sum: 1},
+		{Timestamp: 15, Sum: 2},
+		{Timestamp: 20, Sum: 3},
+		{Timestamp: 25, Sum: 4},
+	}}
+
+	// The histograms aren't downsampled unless requested.
+	downsampleSeries(ts, &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_MAX})
+	assert.Len(t, ts.Histograms, 4)
+
+	downsampleSeries(ts, &client.DownsamplingHint{StepMs: 20, Aggregation: client.AGGREGATION_MAX, Histograms: true})
+	assert.Equal(t, []mimirpb.Histogram{{Timestamp: 15, Sum: 2}, {Timestamp: 20, Sum: 3}, {Timestamp: 25, Sum: 4}}, ts.Histograms)
+}
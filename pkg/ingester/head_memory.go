@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// defaultHeadMemoryLabelNamesLimit is the default number of label names returned by the head memory API.
+	defaultHeadMemoryLabelNamesLimit = 20
+
+	// defaultHeadMemoryLabelValuesLimit is the default number of top values returned for each label name
+	// by the head memory API.
+	defaultHeadMemoryLabelValuesLimit = 10
+
+	// defaultHeadMemoryMaxSeries is the default max number of series sampled by the head memory API.
+	defaultHeadMemoryMaxSeries = 100000
+)
+
+// HeadMemoryResponse is the response of the head memory API.
+type HeadMemoryResponse struct {
+	Series         int64                 `json:"series"`
+	SampledSeries  int64                 `json:"sampled_series"`
+	EstimatedBytes int64                 `json:"estimated_bytes"`
+	LabelNames     []LabelNameHeadMemory `json:"label_names"`
+}
+
+// LabelNameHeadMemory is the memory of the head series having a label name. The series and bytes are
+// extrapolated from the sampled series.
+type LabelNameHeadMemory struct {
+	LabelName      string `json:"label_name"`
+	Series         int64  `json:"series"`
+	EstimatedBytes int64  `json:"estimated_bytes"`
+	// Number of distinct values of the label name among the sampled series.
+	SampledValues int                    `json:"sampled_values"`
+	TopValues     []LabelValueHeadMemory `json:"top_values"`
+}
+
+// LabelValueHeadMemory is the memory of the head series having a label value.
+type LabelValueHeadMemory struct {
+	LabelValue     string `json:"label_value"`
+	Series         int64  `json:"series"`
+	EstimatedBytes int64  `json:"estimated_bytes"`
+}
+
+type headMemoryCounts struct {
+	series int64
+	bytes  int64
+}
+
+type headMemoryLabelName struct {
+	headMemoryCounts
+	values map[string]*headMemoryCounts
+}
+
+// chunkWithCopyReader is implemented by the head chunk reader, to read a copy of the chunk being appended to.
+type chunkWithCopyReader interface {
+	ChunkWithCopy(meta chunks.Meta) (chunkenc.Chunk, int64, error)
+}
+
+// headMemoryReport estimates the memory of the series in the head, attributing the memory of each series to
+// each of its label names and values. The memory of a series is estimated as the size of its labels and of
+// its chunk being appended to, which is the only one held in memory. At most maxSeries series are sampled,
+// evenly across the head, and the reported figures are extrapolated to all the series in the head.
+func headMemoryReport(head *tsdb.Head, namesLimit, valuesLimit, maxSeries int) (HeadMemoryResponse, error) {
+	idx, err := head.Index()
+	if err != nil {
+		return HeadMemoryResponse{}, errors.Wrap(err, "failed to get the head index reader")
+	}
+	defer idx.Close()
+
+	chunkr, err := head.Chunks()
+	if err != nil {
+		return HeadMemoryResponse{}, errors.Wrap(err, "failed to get the head chunk reader")
+	}
+	defer chunkr.Close()
+
+	postings, err := idx.Postings(index.AllPostingsKey())
+	if err != nil {
+		return HeadMemoryResponse{}, errors.Wrap(err, "failed to get the head postings")
+	}
+
+	stride := int64(1)
+	if numSeries := int64(head.NumSeries()); maxSeries > 0 && numSeries > int64(maxSeries) {
+		stride = (numSeries + int64(maxSeries) - 1) / int64(maxSeries)
+	}
+
+	var (
+		res     HeadMemoryResponse
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+		byName  = map[string]*headMemoryLabelName{}
+	)
+	for postings.Next() {
+		res.Series++
+		if (res.Series-1)%stride != 0 {
+			continue
+		}
+
+		if err := idx.Series(postings.At(), &builder, &chks); err != nil {
+			// The series may have been garbage collected in the meanwhile.
+			if errors.Is(err, storage.ErrNotFound) {
+				res.Series--
+				continue
+			}
+			return HeadMemoryResponse{}, errors.Wrap(err, "failed to read the head series")
+		}
+
+		series := builder.Labels()
+		bytes := int64(0)
+		series.Range(func(l labels.Label) {
+			bytes += int64(len(l.Name) + len(l.Value))
+		})
+		if n := len(chks); n > 0 && chks[n-1].MaxTime == math.MaxInt64 {
+			bytes += int64(headChunkSize(chunkr, chks[n-1]))
+		}
+
+		res.SampledSeries++
+		res.EstimatedBytes += bytes
+		series.Range(func(l labels.Label) {
+			name, ok := byName[l.Name]
+			if !ok {
+				// The labels are cloned to not retain the labels of the series, which could be removed from the head.
+				name = &headMemoryLabelName{values: map[string]*headMemoryCounts{}}
+				byName[strings.Clone(l.Name)] = name
+			}
+			name.series++
+			name.bytes += bytes
+
+			value, ok := name.values[l.Value]
+			if !ok {
+				value = &headMemoryCounts{}
+				name.values[strings.Clone(l.Value)] = value
+			}
+			value.series++
+			value.bytes += bytes
+		})
+	}
+	if err := postings.Err(); err != nil {
+		return HeadMemoryResponse{}, errors.Wrap(err, "failed to iterate the head postings")
+	}
+
+	// Extrapolate the sampled series to all the series in the head.
+	scale := func(v int64) int64 {
+		if res.SampledSeries == 0 {
+			return 0
+		}
+		return int64(float64(v) * float64(res.Series) / float64(res.SampledSeries))
+	}
+	res.EstimatedBytes = scale(res.EstimatedBytes)
+
+	res.LabelNames = make([]LabelNameHeadMemory, 0, len(byName))
+	for name, counts := range byName {
+		memory := LabelNameHeadMemory{
+			LabelName:      name,
+			Series:         scale(counts.series),
+			EstimatedBytes: scale(counts.bytes),
+			SampledValues:  len(counts.values),
+			TopValues:      make([]LabelValueHeadMemory, 0, len(counts.values)),
+		}
+		for value, valueCounts := range counts.values {
+			memory.TopValues = append(memory.TopValues, LabelValueHeadMemory{
+				LabelValue:     value,
+				Series:         scale(valueCounts.series),
+				EstimatedBytes: scale(valueCounts.bytes),
+			})
+		}
+		sort.Slice(memory.TopValues, func(i, j int) bool {
+			if memory.TopValues[i].EstimatedBytes != memory.TopValues[j].EstimatedBytes {
+				return memory.TopValues[i].EstimatedBytes > memory.TopValues[j].EstimatedBytes
+			}
+			return memory.TopValues[i].LabelValue < memory.TopValues[j].LabelValue
+		})
+		if valuesLimit > 0 && len(memory.TopValues) > valuesLimit {
+			memory.TopValues = memory.TopValues[:valuesLimit]
+		}
+		res.LabelNames = append(res.LabelNames, memory)
+	}
+	sort.Slice(res.LabelNames, func(i, j int) bool {
+		if res.LabelNames[i].EstimatedBytes != res.LabelNames[j].EstimatedBytes {
+			return res.LabelNames[i].EstimatedBytes > res.LabelNames[j].EstimatedBytes
+		}
+		return res.LabelNames[i].LabelName < res.LabelNames[j].LabelName
+	})
+	if namesLimit > 0 && len(res.LabelNames) > namesLimit {
+		res.LabelNames = res.LabelNames[:namesLimit]
+	}
+
+	return res, nil
+}
+
+// headChunkSize returns the size of the chunk being appended to, or 0 if it can't be read.
+func headChunkSize(chunkr tsdb.ChunkReader, meta chunks.Meta) int {
+	var (
+		chk chunkenc.Chunk
+		err error
+	)
+	// The chunk is copied, if supported, because it's concurrently appended to.
+	if r, ok := chunkr.(chunkWithCopyReader); ok {
+		chk, _, err = r.ChunkWithCopy(meta)
+	} else {
+		chk, err = chunkr.Chunk(meta)
+	}
+	if err != nil || chk == nil {
+		return 0
+	}
+	return len(chk.Bytes())
+}
+
+// HeadMemoryHandler reports, for the authenticated tenant, the estimated memory of the series in the TSDB head
+// by label name and top label values, to find the labels driving the ingester memory usage.
+func (i *Ingester) HeadMemoryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	params := map[string]int{
+		"limit":        defaultHeadMemoryLabelNamesLimit,
+		"values_limit": defaultHeadMemoryLabelValuesLimit,
+		"max_series":   defaultHeadMemoryMaxSeries,
+	}
+	for name := range params {
+		if v := r.FormValue(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, name+" must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			params[name] = n
+		}
+	}
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		http.Error(w, "user TSDB not found", http.StatusNotFound)
+		return
+	}
+
+	res, err := headMemoryReport(db.Head(), params["limit"], params["values_limit"], params["max_series"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, res)
+}
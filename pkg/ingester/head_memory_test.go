@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestIngester_HeadMemoryHandler(t *testing.T) {
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	req := mimirpb.ToWriteRequest(
+		[]labels.Labels{
+			labels.FromStrings(labels.MetricName, "up", "pod", "a"),
+			labels.FromStrings(labels.MetricName, "up", "pod", "b"),
+			labels.FromStrings(labels.MetricName, "up", "pod", "c", "request_id", "a-very-long-request-id-label-value"),
+			labels.FromStrings(labels.MetricName, "build_info"),
+		},
+		[]mimirpb.Sample{{Value: 1, TimestampMs: 9}, {Value: 1, TimestampMs: 9}, {Value: 1, TimestampMs: 9}, {Value: 1, TimestampMs: 9}},
+		nil,
+		nil,
+		mimirpb.API,
+	)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	get := func(t *testing.T, url string) (int, HeadMemoryResponse) {
+		rec := httptest.NewRecorder()
+		i.HeadMemoryHandler(rec, httptest.NewRequest("GET", url, nil).WithContext(ctx))
+
+		var res HeadMemoryResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		}
+		return rec.Code, res
+	}
+
+	t.Run("all series", func(t *testing.T) {
+		code, res := get(t, "/ingester/head_memory")
+		require.Equal(t, http.StatusOK, code)
+
+		assert.Equal(t, int64(4), res.Series)
+		assert.Equal(t, int64(4), res.SampledSeries)
+		require.Len(t, res.LabelNames, 3)
+
+		// The memory includes both the labels (104 bytes in total) and the chunks of the series.
+		assert.Greater(t, res.EstimatedBytes, int64(104))
+
+		// The metric name is set on all series, so it's attributed the memory of all series.
+		assert.Equal(t, labels.MetricName, res.LabelNames[0].LabelName)
+		assert.Equal(t, int64(4), res.LabelNames[0].Series)
+		assert.Equal(t, res.EstimatedBytes, res.LabelNames[0].EstimatedBytes)
+		assert.Equal(t, 2, res.LabelNames[0].SampledValues)
+		require.Len(t, res.LabelNames[0].TopValues, 2)
+		assert.Equal(t, "up", res.LabelNames[0].TopValues[0].LabelValue)
+		assert.Equal(t, int64(3), res.LabelNames[0].TopValues[0].Series)
+
+		assert.Equal(t, "pod", res.LabelNames[1].LabelName)
+		assert.Equal(t, int64(3), res.LabelNames[1].Series)
+		assert.Equal(t, 3, res.LabelNames[1].SampledValues)
+		// The series with the extra label takes more memory.
+		assert.Equal(t, "c", res.LabelNames[1].TopValues[0].LabelValue)
+		assert.Greater(t, res.LabelNames[1].TopValues[0].EstimatedBytes, res.LabelNames[1].TopValues[1].EstimatedBytes)
+
+		assert.Equal(t, "request_id", res.LabelNames[2].LabelName)
+		assert.Equal(t, int64(1), res.LabelNames[2].Series)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		code, res := get(t, "/ingester/head_memory?limit=1&values_limit=1")
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, res.LabelNames, 1)
+		assert.Equal(t, labels.MetricName, res.LabelNames[0].LabelName)
+		assert.Len(t, res.LabelNames[0].TopValues, 1)
+		assert.Equal(t, 2, res.LabelNames[0].SampledValues)
+	})
+
+	t.Run("sampled series", func(t *testing.T) {
+		code, res := get(t, "/ingester/head_memory?max_series=2")
+		require.Equal(t, http.StatusOK, code)
+
+		// The figures are extrapolated from the sampled series.
+		assert.Equal(t, int64(4), res.Series)
+		assert.Equal(t, int64(2), res.SampledSeries)
+		assert.Equal(t, labels.MetricName, res.LabelNames[0].LabelName)
+		assert.Equal(t, int64(4), res.LabelNames[0].Series)
+		assert.Greater(t, res.EstimatedBytes, int64(0))
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		code, _ := get(t, "/ingester/head_memory?values_limit=-1")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		i.HeadMemoryHandler(rec, httptest.NewRequest("GET", "/ingester/head_memory", nil).WithContext(user.InjectOrgID(context.Background(), "unknown")))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/index"
+)
+
+// headPostingsForMatchersCache is the postings for matchers cache of the TSDB head of a tenant.
+// The ingester owns it, instead of the TSDB, to track the cache hits and misses of each tenant.
+type headPostingsForMatchersCache struct {
+	cache *tsdb.PostingsForMatchersCache
+	force bool
+
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+func newHeadPostingsForMatchersCache(ttl time.Duration, size int, force bool, reg prometheus.Registerer) *headPostingsForMatchersCache {
+	return &headPostingsForMatchersCache{
+		cache: tsdb.NewPostingsForMatchersCache(ttl, size, force),
+		force: force,
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prometheus_tsdb_head_postings_for_matchers_cache_hits_total",
+			Help: "Total number of postings for matchers calls in the head served by the cache or an in-flight call.",
+		}),
+		misses: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "prometheus_tsdb_head_postings_for_matchers_cache_misses_total",
+			Help: "Total number of postings for matchers calls in the head going through the cache and computing the postings.",
+		}),
+	}
+}
+
+// postingsForMatchers returns the postings for the matchers from the cache, computing them from ix on a miss.
+// The calls which don't go through the cache are neither counted as hits nor as misses.
+func (c *headPostingsForMatchersCache) postingsForMatchers(ix tsdb.IndexPostingsReader, concurrent bool, ms ...*labels.Matcher) (index.Postings, error) {
+	if !concurrent && !c.force {
+		return tsdb.PostingsForMatchers(ix, ms...)
+	}
+
+	// The cache computes the postings in the calling goroutine on a miss, so the postings are read
+	// from the index only on a miss.
+	tracked := &missTrackingIndexPostingsReader{IndexPostingsReader: ix}
+	p, err := c.cache.PostingsForMatchers(tracked, concurrent, ms...)
+	if tracked.read {
+		c.misses.Inc()
+	} else {
+		c.hits.Inc()
+	}
+	return p, err
+}
+
+// missTrackingIndexPostingsReader records whether the postings have been read from the wrapped index.
+type missTrackingIndexPostingsReader struct {
+	tsdb.IndexPostingsReader
+	read bool
+}
+
+func (r *missTrackingIndexPostingsReader) LabelValues(name string, matchers ...*labels.Matcher) ([]string, error) {
+	r.read = true
+	return r.IndexPostingsReader.LabelValues(name, matchers...)
+}
+
+func (r *missTrackingIndexPostingsReader) Postings(name string, values ...string) (index.Postings, error) {
+	r.read = true
+	return r.IndexPostingsReader.Postings(name, values...)
+}
+
+// cachedHeadIndexReader is a head index reader getting the postings for matchers through the
+// headPostingsForMatchersCache.
+type cachedHeadIndexReader struct {
+	tsdb.IndexReader
+
+	head       *tsdb.Head
+	mint, maxt int64
+	cache      *headPostingsForMatchersCache
+}
+
+func (r *cachedHeadIndexReader) PostingsForMatchers(concurrent bool, ms ...*labels.Matcher) (index.Postings, error) {
+	return r.cache.postingsForMatchers(r.IndexReader, concurrent, ms...)
+}
+
+// LabelNames is overridden because the head index reader gets the postings for the matchers
+// from its own PostingsForMatchers.
+func (r *cachedHeadIndexReader) LabelNames(matchers ...*labels.Matcher) ([]string, error) {
+	if len(matchers) == 0 {
+		return r.IndexReader.LabelNames()
+	}
+	if r.maxt < r.head.MinTime() || r.mint > r.head.MaxTime() {
+		return []string{}, nil
+	}
+
+	p, err := r.PostingsForMatchers(false, matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	var postings []storage.SeriesRef
+	for p.Next() {
+		postings = append(postings, p.At())
+	}
+	if p.Err() != nil {
+		return nil, errors.Wrapf(p.Err(), "postings for label names with matchers")
+	}
+
+	return r.LabelNamesFor(postings...)
+}
+
+// cachedHeadBlockReader is a head block reader returning a cachedHeadIndexReader.
+type cachedHeadBlockReader struct {
+	tsdb.BlockReader
+
+	head       *tsdb.Head
+	mint, maxt int64
+	cache      *headPostingsForMatchersCache
+}
+
+func (b *cachedHeadBlockReader) Index() (tsdb.IndexReader, error) {
+	ir, err := b.BlockReader.Index()
+	if err != nil {
+		return nil, err
+	}
+	return &cachedHeadIndexReader{IndexReader: ir, head: b.head, mint: b.mint, maxt: b.maxt, cache: b.cache}, nil
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadPostingsForMatchersCache(t *testing.T) {
+	for name, tc := range map[string]struct {
+		force          bool
+		concurrent     bool
+		expectedHits   int
+		expectedMisses int
+	}{
+		"concurrent calls go through the cache": {
+			concurrent:     true,
+			expectedHits:   2,
+			expectedMisses: 1,
+		},
+		"non-concurrent calls bypass the cache": {
+			concurrent: false,
+		},
+		"non-concurrent calls go through the cache when forced": {
+			force:          true,
+			expectedHits:   2,
+			expectedMisses: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			c := newHeadPostingsForMatchersCache(time.Hour, 10, tc.force, reg)
+			ix := &mockIndexPostingsReader{}
+
+			for n := 0; n < 3; n++ {
+				p, err := c.postingsForMatchers(ix, tc.concurrent, labels.MustNewMatcher(labels.MatchEqual, "foo", "bar"))
+				require.NoError(t, err)
+
+				refs, err := index.ExpandPostings(p)
+				require.NoError(t, err)
+				assert.Equal(t, []storage.SeriesRef{1, 2}, refs)
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+				# HELP prometheus_tsdb_head_postings_for_matchers_cache_hits_total Total number of postings for matchers calls in the head served by the cache or an in-flight call.
+				# TYPE prometheus_tsdb_head_postings_for_matchers_cache_hits_total counter
+				prometheus_tsdb_head_postings_for_matchers_cache_hits_total `+strconv.Itoa(tc.expectedHits)+`
+				# HELP prometheus_tsdb_head_postings_for_matchers_cache_misses_total Total number of postings for matchers calls in the head going through the cache and computing the postings.
+				# TYPE prometheus_tsdb_head_postings_for_matchers_cache_misses_total counter
+				prometheus_tsdb_head_postings_for_matchers_cache_misses_total `+strconv.Itoa(tc.expectedMisses)+`
+			`)))
+		})
+	}
+}
+
+type mockIndexPostingsReader struct{}
+
+func (mockIndexPostingsReader) LabelValues(string, ...*labels.Matcher) ([]string, error) {
+	return []string{"bar"}, nil
+}
+
+func (mockIndexPostingsReader) Postings(string, ...string) (index.Postings, error) {
+	return index.NewListPostings([]storage.SeriesRef{1, 2}), nil
+}
@@ -51,6 +51,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
 	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/ingester/writerequestlog"
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/chunk"
@@ -115,6 +116,8 @@ const (
 	maxTSDBOpenWithoutConcurrency = 10
 )
 
+var errInvalidWriteRequestLogRetention = errors.New("the write request log retention must be greater than 0")
+
 // BlocksUploader interface is used to have an easy way to mock it in tests.
 type BlocksUploader interface {
 	Sync(ctx context.Context) (uploaded int, err error)
@@ -149,6 +152,11 @@ type Config struct {
 
 	TSDBConfigUpdatePeriod time.Duration `yaml:"tsdb_config_update_period" category:"experimental"`
 
+	SeriesChurnTrackingWindow time.Duration `yaml:"series_churn_tracking_window" category:"experimental"`
+
+	WriteRequestLogEnabled   bool          `yaml:"write_request_log_enabled" category:"experimental"`
+	WriteRequestLogRetention time.Duration `yaml:"write_request_log_retention" category:"experimental"`
+
 	BlocksStorageConfig         mimir_tsdb.BlocksStorageConfig `yaml:"-"`
 	StreamChunksWhenUsingBlocks bool                           `yaml:"-" category:"advanced"`
 	// Runtime-override for type of streaming query to use (chunks or samples).
@@ -158,6 +166,8 @@ type Config struct {
 	InstanceLimitsFn func() *InstanceLimits `yaml:"-"`
 
 	IgnoreSeriesLimitForMetricNames string `yaml:"ignore_series_limit_for_metric_names" category:"advanced"`
+
+	ReadPathGRPCServer ReadPathGRPCServerConfig `yaml:"read_path_grpc_server"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet
@@ -173,13 +183,27 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 
 	f.BoolVar(&cfg.StreamChunksWhenUsingBlocks, "ingester.stream-chunks-when-using-blocks", true, "Stream chunks from ingesters to queriers.")
 	f.DurationVar(&cfg.TSDBConfigUpdatePeriod, "ingester.tsdb-config-update-period", 15*time.Second, "Period with which to update the per-tenant TSDB configuration.")
+	f.DurationVar(&cfg.SeriesChurnTrackingWindow, "ingester.series-churn-tracking-window", 0, "Time window over which the series created in and removed from the TSDB head of each tenant are tracked per label name, and reported by the /ingester/series_churn API. 0 to disable.")
+	f.BoolVar(&cfg.WriteRequestLogEnabled, "ingester.write-request-log-enabled", false, "Debug mode recording, next to the WAL of each tenant, the ID assigned by the distributor to each write request along with its series and the time range of their samples. The log can be searched with the write-request-log-search tool to find which write request introduced a series or a sample. It requires -distributor.write-request-ids-enabled, and increases the disk usage and the write latency of the ingesters.")
+	f.DurationVar(&cfg.WriteRequestLogRetention, "ingester.write-request-log-retention", 24*time.Hour, "How long the write requests are kept in the write request log. This applies only when -ingester.write-request-log-enabled is true.")
 
 	cfg.DefaultLimits.RegisterFlags(f)
 
 	f.StringVar(&cfg.IgnoreSeriesLimitForMetricNames, "ingester.ignore-series-limit-for-metric-names", "", "Comma-separated list of metric names, for which the -ingester.max-global-series-per-metric limit will be ignored. Does not affect the -ingester.max-global-series-per-user limit.")
+
+	cfg.ReadPathGRPCServer.RegisterFlags(f)
 }
 
 func (cfg *Config) Validate(logger log.Logger) error {
+	if cfg.SeriesChurnTrackingWindow < 0 {
+		return errInvalidSeriesChurnTrackingWindow
+	}
+	if cfg.WriteRequestLogEnabled && cfg.WriteRequestLogRetention <= 0 {
+		return errInvalidWriteRequestLogRetention
+	}
+	if err := cfg.ReadPathGRPCServer.Validate(); err != nil {
+		return err
+	}
 	return cfg.IngesterRing.Validate(logger)
 }
 
@@ -466,6 +490,9 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 	usageStatsUpdateTicker := time.NewTicker(usageStatsUpdateInterval)
 	defer usageStatsUpdateTicker.Stop()
 
+	chunkEncodingStatsTicker := time.NewTicker(chunkEncodingStatsUpdatePeriod)
+	defer chunkEncodingStatsTicker.Stop()
+
 	for {
 		select {
 		case <-metadataPurgeTicker.C:
@@ -489,6 +516,9 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 		case <-usageStatsUpdateTicker.C:
 			i.updateUsageStats()
 
+		case <-chunkEncodingStatsTicker.C:
+			i.updateChunkEncodingStats()
+
 		case <-ctx.Done():
 			return nil
 		case err := <-i.subservicesWatcher.Chan():
@@ -642,6 +672,7 @@ type pushStats struct {
 	newValueForTimestampCount int
 	perUserSeriesLimitCount   int
 	perMetricSeriesLimitCount int
+	createdSeriesCount        int
 }
 
 // PushWithCleanup is the Push() implementation for blocks storage and takes a WriteRequest and adds it to the TSDB head.
@@ -724,6 +755,9 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 
 	// Walk the samples, appending them to the users database
 	app := db.Appender(ctx).(extendedAppender)
+	if histogramChunkEncoding(i.limits.HistogramChunkEncoding(userID)) == chunkenc.EncFloatHistogram {
+		app = newChunkEncodingAppender(app, i.metrics.chunkEncodingFallbacks.WithLabelValues(userID))
+	}
 	level.Debug(spanlog).Log("event", "got appender for timeseries", "series", len(req.Timeseries))
 
 	var activeSeries *activeseries.ActiveSeries
@@ -762,6 +796,15 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 	i.metrics.appenderCommitDuration.Observe(commitDuration.Seconds())
 	level.Debug(spanlog).Log("event", "complete commit", "commitDuration", commitDuration.String())
 
+	// The write request log is a debugging aid, so failing to record the request doesn't fail it.
+	if db.writeRequestLog != nil {
+		if id := util.GetWriteRequestIDFromIncomingCtx(ctx); id != "" {
+			if err := db.writeRequestLog.Append(startAppend, id, req.Source, req.Timeseries); err != nil {
+				level.Warn(i.logger).Log("msg", "failed to record the write request in the write request log", "user", userID, "write_request_id", id, "err", err)
+			}
+		}
+	}
+
 	// If only invalid samples are pushed, don't change "last update", as TSDB was not modified.
 	if stats.succeededSamplesCount > 0 {
 		db.setLastUpdate(time.Now())
@@ -790,7 +833,7 @@ func (i *Ingester) PushWithCleanup(ctx context.Context, pushReq *push.Request) (
 		return &mimirpb.WriteResponse{}, httpgrpc.Errorf(code, wrapWithUser(firstPartialErr, userID).Error())
 	}
 
-	return &mimirpb.WriteResponse{}, nil
+	return &mimirpb.WriteResponse{CreatedSeries: uint64(stats.createdSeriesCount)}, nil
 }
 
 func (i *Ingester) updateMetricsFromPushStats(userID string, group string, stats *pushStats, samplesSource mimirpb.WriteRequest_SourceEnum, db *userTSDB, discarded *discardedMetrics) {
@@ -935,6 +978,7 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 		// Look up a reference for this series. The hash passed should be the output of Labels.Hash()
 		// and NOT the stable hashing because we use the stable hashing in ingesters only for query sharding.
 		ref, copiedLabels := app.GetRef(mimirpb.FromLabelAdaptersToLabels(ts.Labels), mimirpb.FromLabelAdaptersToLabels(ts.Labels).Hash())
+		seriesExisted := ref != 0
 
 		// To find out if any sample was added to this series, we keep old value.
 		oldSucceededSamplesCount := stats.succeededSamplesCount
@@ -1011,6 +1055,11 @@ func (i *Ingester) pushSamplesToAppender(userID string, timeseries []mimirpb.Pre
 			}
 		}
 
+		// The series has been created if it didn't exist in the head and a sample has been appended to it.
+		if !seriesExisted && ref != 0 {
+			stats.createdSeriesCount++
+		}
+
 		if activeSeries != nil && stats.succeededSamplesCount > oldSucceededSamplesCount {
 			activeSeries.UpdateSeries(mimirpb.FromLabelAdaptersToLabels(ts.Labels), startAppend, func(l labels.Labels) labels.Labels {
 				// we must already have copied the labels if succeededSamplesCount has been incremented.
@@ -1138,6 +1187,13 @@ func (i *Ingester) LabelValues(ctx context.Context, req *client.LabelValuesReque
 		return nil, err
 	}
 
+	vals = util.PaginateSortedSlice(vals, req.After, int(req.Limit))
+
+	// Fail the request instead of sending back label values which would be rejected by the querier anyway.
+	if err := validation.CheckLabelValuesResultsSizeBytes(vals, int(req.MaxSizeBytes)); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusUnprocessableEntity, err.Error())
+	}
+
 	return &client.LabelValuesResponse{
 		LabelValues: vals,
 	}, nil
@@ -1175,7 +1231,7 @@ func (i *Ingester) LabelNames(ctx context.Context, req *client.LabelNamesRequest
 	}
 
 	return &client.LabelNamesResponse{
-		LabelNames: names,
+		LabelNames: util.PaginateSortedSlice(names, req.After, int(req.Limit)),
 	}, nil
 }
 
@@ -1418,12 +1474,36 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 		}
 	}
 
-	if streamType == QueryStreamChunks {
+	if req.EstimateChunks {
+		numChunks, numBytes, err := i.estimateQueryChunks(ctx, db, int64(from), int64(through), matchers, shard)
+		if err != nil {
+			return err
+		}
+
+		// The estimate is sent before any series, so that the querier can reject the query before fetching them.
+		err = client.SendQueryStream(stream, &client.QueryStreamResponse{
+			EstimatedChunksCount: numChunks,
+			EstimatedChunksBytes: numBytes,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// The samples are streamed when they're downsampled, because the reduced samples aren't encoded in chunks.
+	downsampling := req.GetDownsampling()
+	if downsampling.Enabled() {
+		i.metrics.downsampledQueries.Inc()
+	} else {
+		downsampling = nil
+	}
+
+	if streamType == QueryStreamChunks && downsampling == nil {
 		level.Debug(spanlog).Log("msg", "using queryStreamChunks")
 		numSeries, numSamples, err = i.queryStreamChunks(ctx, db, int64(from), int64(through), matchers, shard, stream)
 	} else {
-		level.Debug(spanlog).Log("msg", "using queryStreamSamples")
-		numSeries, numSamples, err = i.queryStreamSamples(ctx, db, int64(from), int64(through), matchers, shard, stream)
+		level.Debug(spanlog).Log("msg", "using queryStreamSamples", "downsampling_step_ms", downsampling.GetStepMs(), "downsampling_aggregation", downsampling.GetAggregation())
+		numSeries, numSamples, err = i.queryStreamSamples(ctx, db, int64(from), int64(through), matchers, shard, downsampling, stream)
 	}
 	if err != nil {
 		return err
@@ -1435,7 +1515,7 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 	return nil
 }
 
-func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
+func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, downsampling *client.DownsamplingHint, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
 	q, err := db.Querier(ctx, from, through)
 	if err != nil {
 		return 0, 0, err
@@ -1480,6 +1560,11 @@ func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, t
 				return 0, 0, fmt.Errorf("unsupported value type: %v", valType)
 			}
 		}
+		if downsampling != nil {
+			numRaw := len(ts.Samples) + len(ts.Histograms)
+			downsampleSeries(&ts, downsampling)
+			i.metrics.downsamplingRemovedSamples.Add(float64(numRaw - len(ts.Samples) - len(ts.Histograms)))
+		}
 		numSamples += len(ts.Samples) + len(ts.Histograms)
 		numSeries++
 		tsSize := ts.Size()
@@ -1521,14 +1606,136 @@ func (i *Ingester) queryStreamSamples(ctx context.Context, db *userTSDB, from, t
 }
 
 // queryStreamChunks streams metrics from a TSDB. This implements the client.IngesterServer interface
-func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
-	var q storage.ChunkQuerier
-	var err error
+func (i *Ingester) chunkQuerier(ctx context.Context, db *userTSDB, from, through int64) (storage.ChunkQuerier, error) {
 	if i.limits.OutOfOrderTimeWindow(db.userID) > 0 {
-		q, err = db.UnorderedChunkQuerier(ctx, from, through)
-	} else {
-		q, err = db.ChunkQuerier(ctx, from, through)
+		return db.UnorderedChunkQuerier(ctx, from, through)
+	}
+	return db.ChunkQuerier(ctx, from, through)
+}
+
+// estimatedHeadChunkSize is the estimated size in bytes of a chunk of the head, whose length isn't known without
+// reading it: a full chunk of 120 float samples takes about 1.3 bytes per sample.
+const estimatedHeadChunkSize = 160
+
+// estimateQueryChunks returns the number of chunks the query fetches from the TSDB, and their size in bytes, without
+// sending them, so that the querier can reject the query before the chunks are transferred. The chunks are estimated
+// from the chunk metas in the index of the blocks and of the head, without reading them.
+func (i *Ingester) estimateQueryChunks(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector) (numChunks, numBytes uint64, _ error) {
+	estimate := func(ir tsdb.IndexReader, chunkSize func(chks []chunks.Meta, idx int) uint64) error {
+		defer ir.Close()
+
+		c, b, err := estimateIndexChunks(ctx, ir, from, through, matchers, shard, chunkSize)
+		numChunks += c
+		numBytes += b
+		return err
 	}
+
+	for _, b := range db.Blocks() {
+		if !b.OverlapsClosedInterval(from, through) {
+			continue
+		}
+
+		ir, err := b.Index()
+		if errors.Is(err, tsdb.ErrClosing) {
+			// The block has been removed by a compaction or a retention in the meanwhile.
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := estimate(ir, blockChunkSizeEstimator(b)); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	headChunkSize := func([]chunks.Meta, int) uint64 { return estimatedHeadChunkSize }
+
+	head := db.Head()
+	if through >= head.MinTime() {
+		ir, err := db.headBlockReader(from, through).Index()
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := estimate(ir, headChunkSize); err != nil {
+			return 0, 0, err
+		}
+	}
+	if from <= head.MaxOOOTime() && through >= head.MinOOOTime() {
+		ir, err := db.oooHeadBlockReader(from, through).Index()
+		if err != nil {
+			return 0, 0, err
+		}
+		if err := estimate(ir, headChunkSize); err != nil {
+			return 0, 0, err
+		}
+	}
+	return numChunks, numBytes, nil
+}
+
+// estimateIndexChunks returns the number of chunks within the time range of the series matching the input matchers
+// in the input index, and their size in bytes as estimated by chunkSize.
+func estimateIndexChunks(ctx context.Context, ir tsdb.IndexReader, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, chunkSize func(chks []chunks.Meta, idx int) uint64) (numChunks, numBytes uint64, _ error) {
+	postings, err := ir.PostingsForMatchers(true, matchers...)
+	if err != nil {
+		return 0, 0, err
+	}
+	if shard != nil {
+		postings = ir.ShardedPostings(postings, shard.ShardIndex, shard.ShardCount)
+	}
+
+	var (
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+	)
+	for postings.Next() {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+
+		err := ir.Series(postings.At(), &builder, &chks)
+		if errors.Is(err, storage.ErrNotFound) {
+			// The series has been garbage collected from the head in the meanwhile.
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for idx, c := range chks {
+			if c.MaxTime < from || c.MinTime > through {
+				continue
+			}
+			numChunks++
+			numBytes += chunkSize(chks, idx)
+		}
+	}
+	return numChunks, numBytes, postings.Err()
+}
+
+// blockChunkSizeEstimator returns a function estimating the size of the chunks of the input block from their refs,
+// which are offsets in the segment files: the length of a chunk is the difference with the ref of the next chunk of
+// the series. The length of the last chunk of a series is estimated as the average length of the chunks of the block.
+func blockChunkSizeEstimator(b *tsdb.Block) func(chks []chunks.Meta, idx int) uint64 {
+	avgChunkSize := uint64(mimir_tsdb.EstimatedMaxChunkSize)
+	if numChunks := b.Meta().Stats.NumChunks; numChunks > 0 {
+		// The size of the block includes its index, so the average is overestimated.
+		avgChunkSize = util_math.Min(avgChunkSize, uint64(b.Size())/numChunks)
+	}
+
+	return func(chks []chunks.Meta, idx int) uint64 {
+		if idx+1 < len(chks) {
+			segment, offset := chunks.BlockChunkRef(chks[idx].Ref).Unpack()
+			nextSegment, nextOffset := chunks.BlockChunkRef(chks[idx+1].Ref).Unpack()
+			if segment == nextSegment && nextOffset > offset {
+				return util_math.Min(uint64(nextOffset-offset), uint64(mimir_tsdb.EstimatedMaxChunkSize))
+			}
+		}
+		return avgChunkSize
+	}
+}
+
+func (i *Ingester) queryStreamChunks(ctx context.Context, db *userTSDB, from, through int64, matchers []*labels.Matcher, shard *sharding.ShardSelector, stream client.Ingester_QueryStreamServer) (numSeries, numSamples int, _ error) {
+	q, err := i.chunkQuerier(ctx, db, from, through)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -1716,6 +1923,16 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 
 	maxExemplars := i.limiter.convertGlobalToLocalLimit(userID, i.limits.MaxGlobalExemplarsPerUser(userID))
 	oooTW := i.limits.OutOfOrderTimeWindow(userID)
+	headPostingsForMatchersCacheSize := i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheSize
+	if size := i.limits.HeadPostingsForMatchersCacheSize(userID); size > 0 {
+		headPostingsForMatchersCacheSize = size
+	}
+	userDB.headPostingsCache = newHeadPostingsForMatchersCache(
+		i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheTTL,
+		headPostingsForMatchersCacheSize,
+		i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheForce,
+		tsdbPromReg,
+	)
 	// Create a new user database
 	db, err := tsdb.Open(udir, userLogger, tsdbPromReg, &tsdb.Options{
 		RetentionDuration:                  i.cfg.BlocksStorageConfig.TSDB.Retention.Milliseconds(),
@@ -1739,9 +1956,9 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 		AllowOverlappingCompaction:         false,                // always false since Mimir only uploads lvl 1 compacted blocks
 		OutOfOrderTimeWindow:               oooTW.Milliseconds(), // The unit must be same as our timestamps.
 		OutOfOrderCapMax:                   int64(i.cfg.BlocksStorageConfig.TSDB.OutOfOrderCapacityMax),
-		HeadPostingsForMatchersCacheTTL:    i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheTTL,
-		HeadPostingsForMatchersCacheSize:   i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheSize,
-		HeadPostingsForMatchersCacheForce:  i.cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheForce,
+		HeadPostingsForMatchersCacheTTL:    0, // The head is queried through userDB.headPostingsCache, so the TSDB only deduplicates in-flight calls.
+		HeadPostingsForMatchersCacheSize:   0,
+		HeadPostingsForMatchersCacheForce:  false,
 		BlockPostingsForMatchersCacheTTL:   i.cfg.BlocksStorageConfig.TSDB.BlockPostingsForMatchersCacheTTL,
 		BlockPostingsForMatchersCacheSize:  i.cfg.BlocksStorageConfig.TSDB.BlockPostingsForMatchersCacheSize,
 		BlockPostingsForMatchersCacheForce: i.cfg.BlocksStorageConfig.TSDB.BlockPostingsForMatchersCacheForce,
@@ -1765,6 +1982,13 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 	// We set the limiter here because we don't want to limit
 	// series during WAL replay.
 	userDB.limiter = i.limiter
+	// Same for the series churn: the series replayed from the WAL are not churn.
+	if i.cfg.SeriesChurnTrackingWindow > 0 {
+		userDB.seriesChurn = newSeriesChurnTracker(i.cfg.SeriesChurnTrackingWindow)
+	}
+	if i.cfg.WriteRequestLogEnabled {
+		userDB.writeRequestLog = writerequestlog.NewWriter(filepath.Join(udir, writerequestlog.DirName), i.cfg.WriteRequestLogRetention)
+	}
 
 	if db.Head().NumSeries() > 0 {
 		// If there are series in the head, use max time from head. If this time is too old,
@@ -1785,6 +2009,7 @@ func (i *Ingester) createTSDB(userID string, walReplayConcurrency int) (*userTSD
 			udir,
 			bucket.NewUserBucketClient(userID, i.bucket, i.limits),
 			metadata.ReceiveSource,
+			db,
 		)
 
 		// Initialise the shipper blocks cache.
@@ -158,6 +158,24 @@ func (i *ActivityTrackerWrapper) UserRegistryHandler(writer http.ResponseWriter,
 	i.ing.UserRegistryHandler(writer, request)
 }
 
+func (i *ActivityTrackerWrapper) SeriesChurnHandler(writer http.ResponseWriter, request *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(request.Context(), "Ingester/SeriesChurnHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.SeriesChurnHandler(writer, request)
+}
+
+func (i *ActivityTrackerWrapper) HeadMemoryHandler(writer http.ResponseWriter, request *http.Request) {
+	ix := i.tracker.Insert(func() string {
+		return requestActivity(request.Context(), "Ingester/HeadMemoryHandler", nil)
+	})
+	defer i.tracker.Delete(ix)
+
+	i.ing.HeadMemoryHandler(writer, request)
+}
+
 func requestActivity(ctx context.Context, name string, req interface{}) string {
 	userID, _ := tenant.TenantID(ctx)
 	traceID, _ := tracing.ExtractSampledTraceID(ctx)
@@ -216,7 +234,16 @@ func queryRequestToString(sb *bytes.Buffer, req *client.QueryRequest) {
 		labelMatcherToString(sb, m)
 		sb.WriteString(",")
 	}
-	sb.WriteString("},}")
+	sb.WriteString("},")
+
+	sb.WriteString("EstimateChunks:")
+	sb.WriteString(strconv.FormatBool(req.EstimateChunks))
+	sb.WriteString(",")
+
+	// The downsampling hint is rarely set, so it's fine to rely on its generated stringer.
+	sb.WriteString("Downsampling:")
+	sb.WriteString(req.Downsampling.String())
+	sb.WriteString(",}")
 }
 
 func labelMatcherToString(sb *bytes.Buffer, m *client.LabelMatcher) {
@@ -72,6 +72,14 @@ func TestQueryRequest_CustomStringer(t *testing.T) {
 				},
 			},
 		},
+		"estimate chunks and downsampling": {
+			request: &client.QueryRequest{
+				StartTimestampMs: rand.Int63(),
+				EndTimestampMs:   rand.Int63(),
+				EstimateChunks:   true,
+				Downsampling:     &client.DownsamplingHint{StepMs: 60000, Aggregation: client.AGGREGATION_MAX, Histograms: true},
+			},
+		},
 	}
 	for tn, tc := range tcs {
 		t.Run(tn, func(t *testing.T) {
@@ -1588,6 +1588,86 @@ func Test_Ingester_LabelNames(t *testing.T) {
 		require.NoError(t, err)
 		assert.ElementsMatch(t, expected, res.LabelNames)
 	})
+
+	t.Run("paginated", func(t *testing.T) {
+		res, err := i.LabelNames(ctx, &client.LabelNamesRequest{EndTimestampMs: math.MaxInt64, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"__name__", "route"}, res.LabelNames)
+
+		res, err = i.LabelNames(ctx, &client.LabelNamesRequest{EndTimestampMs: math.MaxInt64, Limit: 2, After: "route"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"status"}, res.LabelNames)
+	})
+}
+
+func TestIngester_HeadPostingsForMatchersCacheSizePerTenant(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheTTL = time.Hour
+	cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheSize = 2
+	cfg.BlocksStorageConfig.TSDB.HeadPostingsForMatchersCacheForce = true
+
+	// The first tenant has a smaller cache than the default one.
+	limits := map[string]*validation.Limits{
+		"user-1": {HeadPostingsForMatchersCacheSize: 1},
+	}
+	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), validation.NewMockTenantLimits(limits))
+	require.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	i, err := prepareIngesterWithBlockStorageAndOverrides(t, cfg, overrides, "", "", registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	labelNames := func(ctx context.Context, status string) []string {
+		req, err := client.ToLabelNamesRequest(0, model.Latest, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "status", status)})
+		require.NoError(t, err)
+
+		res, err := i.LabelNames(ctx, req)
+		require.NoError(t, err)
+		return res.LabelNames
+	}
+
+	for userID, expected := range map[string][]string{
+		// The postings of the first matchers are evicted from the cache of the first tenant by the second ones,
+		// so they're computed again and include the series pushed after they've been cached.
+		"user-1": {labels.MetricName, "route", "status"},
+		// The cache of the second tenant fits the postings of both matchers, so the cached ones are returned.
+		"user-2": {labels.MetricName, "status"},
+	} {
+		t.Run(userID, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), userID)
+
+			req, _, _, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test", "status", "200"), 1, 100000)
+			_, err := i.Push(ctx, req)
+			require.NoError(t, err)
+			require.Equal(t, []string{labels.MetricName, "status"}, labelNames(ctx, "200"))
+
+			req, _, _, _ = mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test", "status", "200", "route", "get_user"), 1, 100000)
+			_, err = i.Push(ctx, req)
+			require.NoError(t, err)
+			require.Empty(t, labelNames(ctx, "500"))
+
+			assert.Equal(t, expected, labelNames(ctx, "200"))
+		})
+	}
+
+	// The label names calls are all cache misses for the first tenant, while the last one is a hit for the second one.
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total Total number of postings for matchers calls in the TSDB head served by the cache or an in-flight call.
+		# TYPE cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total counter
+		cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total{user="user-1"} 0
+		cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total{user="user-2"} 1
+		# HELP cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total Total number of postings for matchers calls in the TSDB head going through the cache and computing the postings.
+		# TYPE cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total counter
+		cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total{user="user-1"} 3
+		cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total{user="user-2"} 2
+	`), "cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total", "cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total"))
 }
 
 func Test_Ingester_LabelValues(t *testing.T) {
@@ -1635,6 +1715,27 @@ func Test_Ingester_LabelValues(t *testing.T) {
 		require.NoError(t, err)
 		assert.ElementsMatch(t, expectedValues, res.LabelValues)
 	}
+
+	// Get paginated label values
+	res, err := i.LabelValues(ctx, &client.LabelValuesRequest{LabelName: "status", EndTimestampMs: math.MaxInt64, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"200"}, res.LabelValues)
+
+	res, err = i.LabelValues(ctx, &client.LabelValuesRequest{LabelName: "status", EndTimestampMs: math.MaxInt64, Limit: 1, After: "200"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"500"}, res.LabelValues)
+
+	// Get label values with a max size, which is enforced on the paginated values.
+	res, err = i.LabelValues(ctx, &client.LabelValuesRequest{LabelName: "status", EndTimestampMs: math.MaxInt64, Limit: 1, MaxSizeBytes: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"200"}, res.LabelValues)
+
+	_, err = i.LabelValues(ctx, &client.LabelValuesRequest{LabelName: "status", EndTimestampMs: math.MaxInt64, MaxSizeBytes: 5})
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusUnprocessableEntity), resp.Code)
+	assert.Contains(t, string(resp.Body), "err-mimir-max-label-values-results-size-bytes")
 }
 
 func Test_Ingester_Query(t *testing.T) {
@@ -2020,6 +2121,181 @@ func TestIngester_QueryStream_QuerySharding(t *testing.T) {
 	}
 }
 
+func TestIngester_QueryStream_EstimateChunks(t *testing.T) {
+	const numSeries = 100
+
+	cfg := defaultIngesterTestConfig(t)
+	cfg.StreamChunksWhenUsingBlocks = true
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	// Push the series both to a compacted block and the TSDB head.
+	for seriesID := 0; seriesID < numSeries; seriesID++ {
+		lbls := labels.FromStrings(labels.MetricName, "foo", "series_id", strconv.Itoa(seriesID))
+		req, _, _, _ := mockWriteRequest(t, lbls, float64(seriesID), int64(seriesID))
+		_, err = i.Push(ctx, req)
+		require.NoError(t, err)
+
+		if seriesID == numSeries/2 {
+			i.Flush()
+		}
+	}
+
+	shardMatcher := &client.LabelMatcher{Type: client.EQUAL, Name: sharding.ShardLabel, Value: sharding.ShardSelector{ShardIndex: 0, ShardCount: 2}.LabelValue()}
+
+	for _, sharded := range []bool{false, true} {
+		for _, estimateChunks := range []bool{false, true} {
+			t.Run(fmt.Sprintf("sharded: %t, estimate chunks: %t", sharded, estimateChunks), func(t *testing.T) {
+				req := &client.QueryRequest{
+					StartTimestampMs: math.MinInt64,
+					EndTimestampMs:   math.MaxInt64,
+					Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "foo"}},
+					EstimateChunks:   estimateChunks,
+				}
+				if sharded {
+					req.Matchers = append(req.Matchers, shardMatcher)
+				}
+
+				s := stream{ctx: ctx}
+				require.NoError(t, i.QueryStream(req, &s))
+				require.NotEmpty(t, s.responses)
+
+				responses := s.responses
+				if estimateChunks {
+					// The estimate is sent in the first response, before any series.
+					estimate := responses[0]
+					require.Empty(t, estimate.Chunkseries)
+					require.Empty(t, estimate.Timeseries)
+					responses = responses[1:]
+
+					var numChunks, numBytes uint64
+					for _, resp := range responses {
+						for _, series := range resp.Chunkseries {
+							for _, c := range series.Chunks {
+								numChunks++
+								numBytes += uint64(len(c.Data))
+							}
+						}
+					}
+					assert.Equal(t, numChunks, estimate.EstimatedChunksCount)
+
+					// The size of the chunks is estimated from the index, without reading them, so it's overestimated.
+					assert.GreaterOrEqual(t, estimate.EstimatedChunksBytes, numBytes)
+					assert.LessOrEqual(t, estimate.EstimatedChunksBytes, numChunks*mimir_tsdb.EstimatedMaxChunkSize)
+				}
+
+				for _, resp := range responses {
+					assert.Zero(t, resp.EstimatedChunksCount)
+					assert.Zero(t, resp.EstimatedChunksBytes)
+				}
+
+				res, err := chunkcompat.StreamsToMatrix(model.Earliest, model.Latest, responses)
+				require.NoError(t, err)
+				if sharded {
+					assert.NotEmpty(t, res)
+					assert.Less(t, len(res), numSeries)
+				} else {
+					assert.Len(t, res, numSeries)
+				}
+			})
+		}
+	}
+}
+
+func TestIngester_QueryStream_Downsampling(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.StreamChunksWhenUsingBlocks = true
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's healthy
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	lbls := labels.FromStrings(labels.MetricName, "foo")
+	for ts := int64(0); ts < 100; ts++ {
+		req, _, _, _ := mockWriteRequest(t, lbls, float64(ts%7), ts)
+		_, err = i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	tests := map[string]struct {
+		hint            *client.DownsamplingHint
+		expectedSamples int
+	}{
+		"no hint": {
+			expectedSamples: 100,
+		},
+		"hint not requesting any downsampling": {
+			hint:            &client.DownsamplingHint{StepMs: 10},
+			expectedSamples: 100,
+		},
+		"max over each step": {
+			hint: &client.DownsamplingHint{StepMs: 10, Aggregation: client.AGGREGATION_MAX},
+			// The samples at the multiples of the step, plus one sample between each pair of them.
+			expectedSamples: 20,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := &client.QueryRequest{
+				StartTimestampMs: math.MinInt64,
+				EndTimestampMs:   math.MaxInt64,
+				Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: model.MetricNameLabel, Value: "foo"}},
+				Downsampling:     tc.hint,
+			}
+
+			s := stream{ctx: ctx}
+			require.NoError(t, i.QueryStream(req, &s))
+
+			var samples []mimirpb.Sample
+			for _, resp := range s.responses {
+				if tc.hint.Enabled() {
+					// The downsampled samples are never streamed as chunks.
+					require.Empty(t, resp.Chunkseries)
+				}
+				for _, series := range resp.Timeseries {
+					samples = append(samples, series.Samples...)
+				}
+			}
+
+			if !tc.hint.Enabled() {
+				res, err := chunkcompat.StreamsToMatrix(model.Earliest, model.Latest, s.responses)
+				require.NoError(t, err)
+				require.Len(t, res, 1)
+				require.Len(t, res[0].Values, tc.expectedSamples)
+				return
+			}
+
+			require.Len(t, samples, tc.expectedSamples)
+			for _, sample := range samples {
+				if sample.TimestampMs%10 != 0 {
+					// The maximum of each interval between two multiples of the step is always 6, because the
+					// values cycle every 7 samples.
+					assert.Equal(t, 6.0, sample.Value)
+				}
+			}
+		})
+	}
+}
+
 func TestIngester_QueryStream_QueryShardingShouldGuaranteeSeriesShardingConsistencyOverTheTime(t *testing.T) {
 	const (
 		numSeries = 100
@@ -5457,6 +5733,31 @@ func TestIngester_Push_SeriesWithBlankLabel(t *testing.T) {
 	assert.Equal(t, expected, res)
 }
 
+func TestIngester_Push_ShouldReturnCreatedSeries(t *testing.T) {
+	ing, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return ing.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	series1 := labels.FromStrings(labels.MetricName, "testmetric", "series", "1")
+	series2 := labels.FromStrings(labels.MetricName, "testmetric", "series", "2")
+
+	res, err := ing.Push(ctx, mimirpb.ToWriteRequest([]labels.Labels{series1}, []mimirpb.Sample{{TimestampMs: 1, Value: 1}}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), res.CreatedSeries)
+
+	// Only the series which didn't exist in the head are counted as created.
+	res, err = ing.Push(ctx, mimirpb.ToWriteRequest([]labels.Labels{series1, series2}, []mimirpb.Sample{{TimestampMs: 2, Value: 2}, {TimestampMs: 2, Value: 2}}, nil, nil, mimirpb.API))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), res.CreatedSeries)
+}
+
 func TestIngesterUserLimitExceeded(t *testing.T) {
 	limits := defaultLimitsTestConfig()
 	limits.MaxGlobalSeriesPerUser = 1
@@ -29,6 +29,9 @@ type ingesterMetrics struct {
 	queriedExemplars prometheus.Histogram
 	queriedSeries    prometheus.Histogram
 
+	downsampledQueries         prometheus.Counter
+	downsamplingRemovedSamples prometheus.Counter
+
 	memMetadata             prometheus.Gauge
 	memUsers                prometheus.Gauge
 	memMetadataCreatedTotal *prometheus.CounterVec
@@ -53,6 +56,10 @@ type ingesterMetrics struct {
 	appenderCommitDuration prometheus.Histogram
 	idleTsdbChecks         *prometheus.CounterVec
 
+	// Size of the head chunks by encoding.
+	headChunkBytesPerSample *prometheus.GaugeVec
+	chunkEncodingFallbacks  *prometheus.CounterVec
+
 	// Open all existing TSDBs metrics
 	openExistingTSDB prometheus.Counter
 
@@ -133,6 +140,14 @@ func newIngesterMetrics(
 			// Could easily return 10m samples per query - 10*(8^(8-1)) = 20.9m.
 			Buckets: prometheus.ExponentialBuckets(10, 8, 8),
 		}),
+		downsampledQueries: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_downsampled_queries_total",
+			Help: "The total number of queries whose samples have been downsampled as requested by the querier.",
+		}),
+		downsamplingRemovedSamples: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_downsampling_removed_samples_total",
+			Help: "The total number of samples not returned from queries because of the downsampling requested by the querier.",
+		}),
 		queriedExemplars: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
 			Name: "cortex_ingester_queried_exemplars",
 			Help: "The total number of exemplars returned from queries.",
@@ -266,6 +281,15 @@ func newIngesterMetrics(
 
 		idleTsdbChecks: idleTsdbChecks,
 
+		headChunkBytesPerSample: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_tsdb_head_chunk_bytes_per_sample",
+			Help: "Average size in bytes of a sample in the TSDB head chunks, by chunk encoding, computed from a sample of the series of each user.",
+		}, []string{"user", "encoding"}),
+		chunkEncodingFallbacks: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_chunk_encoding_fallbacks_total",
+			Help: "The total number of samples appended with their original chunk encoding, because they couldn't be appended with the chunk encoding selected for the user.",
+		}, []string{"user"}),
+
 		openExistingTSDB: promauto.With(r).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_ingester_tsdb_open_duration_seconds_total",
 			Help: "The total time it takes to open all existing TSDBs at ingester startup. This time also includes the TSDBs WAL replay duration.",
@@ -288,6 +312,8 @@ func (m *ingesterMetrics) deletePerUserMetrics(userID string) {
 
 	filter := prometheus.Labels{"user": userID}
 	m.discarded.DeletePartialMatch(filter)
+	m.headChunkBytesPerSample.DeletePartialMatch(filter)
+	m.chunkEncodingFallbacks.DeleteLabelValues(userID)
 
 	m.discardedMetadataPerUserMetadataLimit.DeleteLabelValues(userID)
 	m.discardedMetadataPerMetricMetadataLimit.DeleteLabelValues(userID)
@@ -370,6 +396,8 @@ type tsdbMetrics struct {
 	tsdbChunks                        *prometheus.Desc
 	tsdbChunksCreatedTotal            *prometheus.Desc
 	tsdbChunksRemovedTotal            *prometheus.Desc
+	tsdbPostingsCacheHits             *prometheus.Desc
+	tsdbPostingsCacheMisses           *prometheus.Desc
 	tsdbMmapChunkCorruptionTotal      *prometheus.Desc
 	tsdbMmapChunkQueueOperationsTotal *prometheus.Desc
 	tsdbOOOHistogram                  *prometheus.Desc
@@ -494,6 +522,14 @@ func newTSDBMetrics(r prometheus.Registerer, logger log.Logger) *tsdbMetrics {
 			"cortex_ingester_tsdb_head_chunks_removed_total",
 			"Total number of series removed in the TSDB head.",
 			[]string{"user"}, nil),
+		tsdbPostingsCacheHits: prometheus.NewDesc(
+			"cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total",
+			"Total number of postings for matchers calls in the TSDB head served by the cache or an in-flight call.",
+			[]string{"user"}, nil),
+		tsdbPostingsCacheMisses: prometheus.NewDesc(
+			"cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total",
+			"Total number of postings for matchers calls in the TSDB head going through the cache and computing the postings.",
+			[]string{"user"}, nil),
 		tsdbMmapChunkCorruptionTotal: prometheus.NewDesc(
 			"cortex_ingester_tsdb_mmap_chunk_corruptions_total",
 			"Total number of memory-mapped TSDB chunk corruptions.",
@@ -622,6 +658,8 @@ func (sm *tsdbMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- sm.tsdbChunks
 	out <- sm.tsdbChunksCreatedTotal
 	out <- sm.tsdbChunksRemovedTotal
+	out <- sm.tsdbPostingsCacheHits
+	out <- sm.tsdbPostingsCacheMisses
 	out <- sm.tsdbMmapChunkCorruptionTotal
 	out <- sm.tsdbMmapChunkQueueOperationsTotal
 	out <- sm.tsdbOOOHistogram
@@ -676,6 +714,8 @@ func (sm *tsdbMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfGauges(out, sm.tsdbChunks, "prometheus_tsdb_head_chunks")
 	data.SendSumOfCountersPerTenant(out, sm.tsdbChunksCreatedTotal, "prometheus_tsdb_head_chunks_created_total")
 	data.SendSumOfCountersPerTenant(out, sm.tsdbChunksRemovedTotal, "prometheus_tsdb_head_chunks_removed_total")
+	data.SendSumOfCountersPerTenant(out, sm.tsdbPostingsCacheHits, "prometheus_tsdb_head_postings_for_matchers_cache_hits_total")
+	data.SendSumOfCountersPerTenant(out, sm.tsdbPostingsCacheMisses, "prometheus_tsdb_head_postings_for_matchers_cache_misses_total")
 	data.SendSumOfCounters(out, sm.tsdbMmapChunkCorruptionTotal, "prometheus_tsdb_mmap_chunk_corruptions_total")
 	data.SendSumOfCountersWithLabels(out, sm.tsdbMmapChunkQueueOperationsTotal, "prometheus_tsdb_chunk_write_queue_operations_total", "operation")
 	data.SendSumOfHistograms(out, sm.tsdbOOOHistogram, "prometheus_tsdb_sample_ooo_delta")
@@ -169,6 +169,18 @@ func TestTSDBMetrics(t *testing.T) {
 			cortex_ingester_tsdb_head_chunks_removed_total{user="user2"} 2058888
 			cortex_ingester_tsdb_head_chunks_removed_total{user="user3"} 23976
 
+			# HELP cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total Total number of postings for matchers calls in the TSDB head served by the cache or an in-flight call.
+			# TYPE cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total counter
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total{user="user1"} 370350
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total{user="user2"} 2573610
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total{user="user3"} 29970
+
+			# HELP cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total Total number of postings for matchers calls in the TSDB head going through the cache and computing the postings.
+			# TYPE cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total counter
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total{user="user1"} 382695
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total{user="user2"} 2659397
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total{user="user3"} 30969
+
 			# HELP cortex_ingester_tsdb_wal_truncate_duration_seconds Duration of TSDB WAL truncation.
 			# TYPE cortex_ingester_tsdb_wal_truncate_duration_seconds summary
 			cortex_ingester_tsdb_wal_truncate_duration_seconds_sum 75
@@ -409,6 +421,16 @@ func TestTSDBMetricsWithRemoval(t *testing.T) {
 			cortex_ingester_tsdb_head_chunks_removed_total{user="user1"} 296280
 			cortex_ingester_tsdb_head_chunks_removed_total{user="user2"} 2058888
 
+			# HELP cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total Total number of postings for matchers calls in the TSDB head served by the cache or an in-flight call.
+			# TYPE cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total counter
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total{user="user1"} 370350
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_hits_total{user="user2"} 2573610
+
+			# HELP cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total Total number of postings for matchers calls in the TSDB head going through the cache and computing the postings.
+			# TYPE cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total counter
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total{user="user1"} 382695
+			cortex_ingester_tsdb_head_postings_for_matchers_cache_misses_total{user="user2"} 2659397
+
 			# HELP cortex_ingester_tsdb_wal_truncate_duration_seconds Duration of TSDB WAL truncation.
 			# TYPE cortex_ingester_tsdb_wal_truncate_duration_seconds summary
 			cortex_ingester_tsdb_wal_truncate_duration_seconds_sum 75
@@ -641,6 +663,18 @@ func populateTSDBMetrics(base float64) *prometheus.Registry {
 	})
 	chunksRemoved.Add(24 * base)
 
+	postingsCacheHits := promauto.With(r).NewCounter(prometheus.CounterOpts{
+		Name: "prometheus_tsdb_head_postings_for_matchers_cache_hits_total",
+		Help: "Total number of postings for matchers calls in the head served by the cache.",
+	})
+	postingsCacheHits.Add(30 * base)
+
+	postingsCacheMisses := promauto.With(r).NewCounter(prometheus.CounterOpts{
+		Name: "prometheus_tsdb_head_postings_for_matchers_cache_misses_total",
+		Help: "Total number of postings for matchers calls in the head not served by the cache.",
+	})
+	postingsCacheMisses.Add(31 * base)
+
 	walTruncateDuration := promauto.With(r).NewSummary(prometheus.SummaryOpts{
 		Name: "prometheus_tsdb_wal_truncate_duration_seconds",
 		Help: "Duration of WAL truncation.",
@@ -0,0 +1,331 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/grpcutil"
+	"github.com/grafana/dskit/services"
+	otgrpc "github.com/opentracing-contrib/go-grpc"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/exporter-toolkit/web"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/server"
+	"golang.org/x/net/netutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+)
+
+// readPathGRPCServerWriteMethods are the ingester gRPC methods of the write path, which are not served
+// by the read path gRPC server.
+var readPathGRPCServerWriteMethods = map[string]struct{}{
+	"/cortex.Ingester/Push": {},
+}
+
+var (
+	errInvalidReadPathGRPCServerConnLimit             = errors.New("the read path gRPC server connection limit must be greater than or equal to 0")
+	errInvalidReadPathGRPCServerMaxConcurrentRequests = errors.New("the read path gRPC server max concurrent requests must be greater than or equal to 0")
+)
+
+// ReadPathGRPCServerConfig configures the dedicated gRPC server the ingester can serve the read path from.
+type ReadPathGRPCServerConfig struct {
+	ListenPort            int  `yaml:"listen_port" category:"experimental"`
+	ConnLimit             int  `yaml:"conn_limit" category:"experimental"`
+	MaxConcurrentStreams  uint `yaml:"max_concurrent_streams" category:"experimental"`
+	MaxConcurrentRequests int  `yaml:"max_concurrent_requests" category:"experimental"`
+}
+
+func (cfg *ReadPathGRPCServerConfig) RegisterFlags(f *flag.FlagSet) {
+	f.IntVar(&cfg.ListenPort, "ingester.read-path-grpc-server.listen-port", 0, "When set, the ingester also listens for gRPC requests on this port, with a dedicated gRPC server having its own connection limit and pool of workers, so that an overloaded read path can't delay the requests of the write path. Queriers send their requests to this port when -distributor.ingester-read-path-grpc-port is set to the same value. 0 to disable.")
+	f.IntVar(&cfg.ConnLimit, "ingester.read-path-grpc-server.conn-limit", 0, "Maximum number of simultaneous connections to the read path gRPC server. 0 to disable.")
+	f.UintVar(&cfg.MaxConcurrentStreams, "ingester.read-path-grpc-server.max-concurrent-streams", 100, "Limit on the number of concurrent streams for gRPC calls per client connection to the read path gRPC server. 0 = unlimited.")
+	f.IntVar(&cfg.MaxConcurrentRequests, "ingester.read-path-grpc-server.max-concurrent-requests", 0, "Size of the pool of workers running the requests received by the read path gRPC server. Requests received while all workers are busy wait for a worker to become available. 0 to disable.")
+}
+
+func (cfg *ReadPathGRPCServerConfig) Validate() error {
+	if cfg.ConnLimit < 0 {
+		return errInvalidReadPathGRPCServerConnLimit
+	}
+	if cfg.MaxConcurrentRequests < 0 {
+		return errInvalidReadPathGRPCServerMaxConcurrentRequests
+	}
+	return nil
+}
+
+// ReadPathGRPCServer is a gRPC server, separate from the main one, serving the ingester read path.
+type ReadPathGRPCServer struct {
+	services.Service
+
+	cfg       ReadPathGRPCServerConfig
+	serverCfg server.Config
+	logger    log.Logger
+
+	server   *grpc.Server
+	listener net.Listener
+
+	// Semaphore limiting the requests running at the same time. Nil if there's no limit.
+	workers chan struct{}
+
+	connections      prometheus.Gauge
+	inflightRequests prometheus.Gauge
+	queuedRequests   prometheus.Gauge
+}
+
+// NewReadPathGRPCServer makes a new ReadPathGRPCServer serving the input ingester. The gRPC options
+// not configured by cfg, like the max message sizes, keepalive, TLS and the middlewares, are taken from
+// the main server config.
+func NewReadPathGRPCServer(cfg ReadPathGRPCServerConfig, serverCfg server.Config, ingester client.IngesterServer, logger log.Logger, reg prometheus.Registerer) (*ReadPathGRPCServer, error) {
+	tlsConfig, err := grpcServerTLSConfig(serverCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "read path gRPC server TLS config")
+	}
+
+	s := &ReadPathGRPCServer{
+		cfg:       cfg,
+		serverCfg: serverCfg,
+		logger:    logger,
+
+		connections: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_read_path_grpc_connections",
+			Help: "Current number of connections to the read path gRPC server.",
+		}),
+		inflightRequests: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_read_path_grpc_inflight_requests",
+			Help: "Current number of requests running on the read path gRPC server.",
+		}),
+		queuedRequests: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_read_path_grpc_queued_requests",
+			Help: "Current number of requests received by the read path gRPC server waiting for a worker to become available.",
+		}),
+	}
+
+	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_ingester_read_path_grpc_connections_limit",
+		Help: "Maximum number of connections to the read path gRPC server. 0 means no limit.",
+	}).Set(float64(cfg.ConnLimit))
+	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_ingester_read_path_grpc_max_concurrent_requests",
+		Help: "Size of the pool of workers running the requests received by the read path gRPC server. 0 means no limit.",
+	}).Set(float64(cfg.MaxConcurrentRequests))
+
+	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cortex_ingester_read_path_grpc_request_duration_seconds",
+		Help:    "Time spent running the requests received by the read path gRPC server, including the time waiting for a worker.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status_code", "ws"})
+
+	if cfg.MaxConcurrentRequests > 0 {
+		s.workers = make(chan struct{}, cfg.MaxConcurrentRequests)
+	}
+
+	unaryMiddleware := []grpc.UnaryServerInterceptor{
+		rejectWriteMethodsInterceptor,
+		otgrpc.OpenTracingServerInterceptor(opentracing.GlobalTracer()),
+		middleware.UnaryServerInstrumentInterceptor(requestDuration),
+		s.unaryWorkerInterceptor,
+	}
+	unaryMiddleware = append(unaryMiddleware, serverCfg.GRPCMiddleware...)
+
+	streamMiddleware := []grpc.StreamServerInterceptor{
+		otgrpc.OpenTracingStreamServerInterceptor(opentracing.GlobalTracer()),
+		middleware.StreamServerInstrumentInterceptor(requestDuration),
+		s.streamWorkerInterceptor,
+	}
+	streamMiddleware = append(streamMiddleware, serverCfg.GRPCStreamMiddleware...)
+
+	// The keepalive settings must match the main server ones, otherwise the server would close the
+	// connections of the clients pinging more often than the gRPC defaults allow.
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryMiddleware...),
+		grpc.ChainStreamInterceptor(streamMiddleware...),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     serverCfg.GRPCServerMaxConnectionIdle,
+			MaxConnectionAge:      serverCfg.GRPCServerMaxConnectionAge,
+			MaxConnectionAgeGrace: serverCfg.GRPCServerMaxConnectionAgeGrace,
+			Time:                  serverCfg.GRPCServerTime,
+			Timeout:               serverCfg.GRPCServerTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             serverCfg.GRPCServerMinTimeBetweenPings,
+			PermitWithoutStream: serverCfg.GRPCServerPingWithoutStreamAllowed,
+		}),
+		grpc.MaxRecvMsgSize(serverCfg.GPRCServerMaxRecvMsgSize),
+		grpc.MaxSendMsgSize(serverCfg.GRPCServerMaxSendMsgSize),
+		grpc.MaxConcurrentStreams(uint32(cfg.MaxConcurrentStreams)),
+	}
+	options = append(options, serverCfg.GRPCOptions...)
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s.server = grpc.NewServer(options...)
+	client.RegisterIngesterServer(s.server, ingester)
+	grpc_health_v1.RegisterHealthServer(s.server, grpcutil.NewHealthCheckFrom(func(context.Context) bool {
+		return s.State() == services.Running
+	}))
+
+	s.Service = services.NewBasicService(s.starting, s.running, s.stopping)
+	return s, nil
+}
+
+// grpcServerTLSVersions are the TLS versions allowed by -server.tls-min-version.
+var grpcServerTLSVersions = map[string]uint16{
+	"VersionTLS10": tls.VersionTLS10,
+	"VersionTLS11": tls.VersionTLS11,
+	"VersionTLS12": tls.VersionTLS12,
+	"VersionTLS13": tls.VersionTLS13,
+}
+
+// grpcServerTLSConfig returns the TLS config of the main gRPC server, or nil if TLS isn't configured.
+func grpcServerTLSConfig(serverCfg server.Config) (*tls.Config, error) {
+	if serverCfg.GRPCTLSConfig.TLSCertPath == "" || serverCfg.GRPCTLSConfig.TLSKeyPath == "" {
+		return nil, nil
+	}
+
+	var cipherSuites []web.Cipher
+	if serverCfg.CipherSuites != "" {
+		supported := map[string]web.Cipher{}
+		for _, suite := range tls.CipherSuites() {
+			supported[suite.Name] = web.Cipher(suite.ID)
+		}
+		for _, suite := range tls.InsecureCipherSuites() {
+			supported[suite.Name] = web.Cipher(suite.ID)
+		}
+
+		for _, name := range strings.Split(serverCfg.CipherSuites, ",") {
+			cipher, ok := supported[name]
+			if !ok {
+				return nil, fmt.Errorf("cipher suite %q not recognized", name)
+			}
+			cipherSuites = append(cipherSuites, cipher)
+		}
+	}
+
+	var minVersion web.TLSVersion
+	if serverCfg.MinVersion != "" {
+		version, ok := grpcServerTLSVersions[serverCfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("TLS version %q not recognized", serverCfg.MinVersion)
+		}
+		minVersion = web.TLSVersion(version)
+	}
+
+	return web.ConfigToTLSConfig(&web.TLSConfig{
+		TLSCertPath:  serverCfg.GRPCTLSConfig.TLSCertPath,
+		TLSKeyPath:   serverCfg.GRPCTLSConfig.TLSKeyPath,
+		ClientAuth:   serverCfg.GRPCTLSConfig.ClientAuth,
+		ClientCAs:    serverCfg.GRPCTLSConfig.ClientCAs,
+		CipherSuites: cipherSuites,
+		MinVersion:   minVersion,
+	})
+}
+
+func (s *ReadPathGRPCServer) starting(_ context.Context) error {
+	listener, err := net.Listen(s.serverCfg.GRPCListenNetwork, fmt.Sprintf("%s:%d", s.serverCfg.GRPCListenAddress, s.cfg.ListenPort))
+	if err != nil {
+		return errors.Wrap(err, "listen on the read path gRPC server port")
+	}
+
+	listener = middleware.CountingListener(listener, s.connections)
+	if s.cfg.ConnLimit > 0 {
+		listener = netutil.LimitListener(listener, s.cfg.ConnLimit)
+	}
+	s.listener = listener
+
+	level.Info(s.logger).Log("msg", "read path gRPC server listening", "addr", listener.Addr())
+	return nil
+}
+
+func (s *ReadPathGRPCServer) running(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.server.Serve(s.listener)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errCh:
+		return errors.Wrap(err, "read path gRPC server failed")
+	}
+}
+
+func (s *ReadPathGRPCServer) stopping(_ error) error {
+	s.server.GracefulStop()
+	return nil
+}
+
+// Addr returns the address the server is listening on. It's available once the server is running.
+func (s *ReadPathGRPCServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// acquireWorker waits until a worker is available to run the request, or the context is done.
+// The returned function must be called once the request has completed.
+func (s *ReadPathGRPCServer) acquireWorker(ctx context.Context) (func(), error) {
+	if s.workers != nil {
+		s.queuedRequests.Inc()
+		select {
+		case s.workers <- struct{}{}:
+			s.queuedRequests.Dec()
+		case <-ctx.Done():
+			s.queuedRequests.Dec()
+			return nil, ctx.Err()
+		}
+	}
+
+	s.inflightRequests.Inc()
+	return func() {
+		s.inflightRequests.Dec()
+		if s.workers != nil {
+			<-s.workers
+		}
+	}, nil
+}
+
+// rejectWriteMethodsInterceptor rejects the write path requests, so that they can't bypass the limits of the
+// main server or take up the workers of the read path.
+func rejectWriteMethodsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if _, ok := readPathGRPCServerWriteMethods[info.FullMethod]; ok {
+		return nil, status.Errorf(codes.Unimplemented, "method %s is not served by the read path gRPC server", info.FullMethod)
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *ReadPathGRPCServer) unaryWorkerInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	release, err := s.acquireWorker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return handler(ctx, req)
+}
+
+func (s *ReadPathGRPCServer) streamWorkerInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	release, err := s.acquireWorker(ss.Context())
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return handler(srv, ss)
+}
@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/server"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/integration/ca"
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestReadPathGRPCServerConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *ReadPathGRPCServerConfig)
+		expected error
+	}{
+		"should pass with the default config": {
+			setup: func(*ReadPathGRPCServerConfig) {},
+		},
+		"should fail on negative connection limit": {
+			setup: func(cfg *ReadPathGRPCServerConfig) {
+				cfg.ConnLimit = -1
+			},
+			expected: errInvalidReadPathGRPCServerConnLimit,
+		},
+		"should fail on negative max concurrent requests": {
+			setup: func(cfg *ReadPathGRPCServerConfig) {
+				cfg.MaxConcurrentRequests = -1
+			},
+			expected: errInvalidReadPathGRPCServerMaxConcurrentRequests,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := ReadPathGRPCServerConfig{}
+			flagext.DefaultValues(&cfg)
+			testData.setup(&cfg)
+
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
+
+func TestReadPathGRPCServer_ShouldLimitConcurrentRequests(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := ReadPathGRPCServerConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.MaxConcurrentRequests = 1
+
+	serverCfg := server.Config{}
+	flagext.DefaultValues(&serverCfg)
+	serverCfg.GRPCListenAddress = "localhost"
+
+	ingester := &blockingLabelNamesIngester{
+		started: make(chan struct{}, 2),
+		unblock: make(chan struct{}),
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	s, err := NewReadPathGRPCServer(cfg, serverCfg, ingester, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, s))
+	})
+
+	conn, err := grpc.Dial(s.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+	c := client.NewIngesterClient(conn)
+
+	// Send two requests concurrently: the second one must wait for the first one to complete.
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := c.LabelNames(ctx, &client.LabelNamesRequest{})
+			errs <- err
+		}()
+	}
+
+	<-ingester.started
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(s.queuedRequests) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	select {
+	case <-ingester.started:
+		require.FailNow(t, "the second request should wait for a worker")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ingester_read_path_grpc_inflight_requests Current number of requests running on the read path gRPC server.
+		# TYPE cortex_ingester_read_path_grpc_inflight_requests gauge
+		cortex_ingester_read_path_grpc_inflight_requests 1
+
+		# HELP cortex_ingester_read_path_grpc_queued_requests Current number of requests received by the read path gRPC server waiting for a worker to become available.
+		# TYPE cortex_ingester_read_path_grpc_queued_requests gauge
+		cortex_ingester_read_path_grpc_queued_requests 1
+
+		# HELP cortex_ingester_read_path_grpc_max_concurrent_requests Size of the pool of workers running the requests received by the read path gRPC server. 0 means no limit.
+		# TYPE cortex_ingester_read_path_grpc_max_concurrent_requests gauge
+		cortex_ingester_read_path_grpc_max_concurrent_requests 1
+
+		# HELP cortex_ingester_read_path_grpc_connections Current number of connections to the read path gRPC server.
+		# TYPE cortex_ingester_read_path_grpc_connections gauge
+		cortex_ingester_read_path_grpc_connections 1
+	`), "cortex_ingester_read_path_grpc_inflight_requests", "cortex_ingester_read_path_grpc_queued_requests", "cortex_ingester_read_path_grpc_max_concurrent_requests", "cortex_ingester_read_path_grpc_connections"))
+
+	close(ingester.unblock)
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(s.inflightRequests))
+	assert.Equal(t, float64(0), testutil.ToFloat64(s.queuedRequests))
+}
+
+func TestReadPathGRPCServer_ShouldRejectWriteRequests(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := ReadPathGRPCServerConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.MaxConcurrentRequests = 1
+
+	serverCfg := server.Config{}
+	flagext.DefaultValues(&serverCfg)
+	serverCfg.GRPCListenAddress = "localhost"
+
+	ingester := &blockingLabelNamesIngester{
+		started: make(chan struct{}, 1),
+		unblock: make(chan struct{}),
+	}
+
+	s, err := NewReadPathGRPCServer(cfg, serverCfg, ingester, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, s))
+	})
+
+	conn, err := grpc.Dial(s.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+	c := client.NewIngesterClient(conn)
+
+	// Take up the only worker with a read request.
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.LabelNames(ctx, &client.LabelNamesRequest{})
+		errs <- err
+	}()
+	<-ingester.started
+
+	// The write requests are rejected without waiting for a worker.
+	_, err = c.Push(ctx, &mimirpb.WriteRequest{})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	close(ingester.unblock)
+	require.NoError(t, <-errs)
+}
+
+func TestReadPathGRPCServer_ShouldServeTheIngesterClientWithTheMainServerTLSAndKeepaliveConfig(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	caCertFile := filepath.Join(dir, "ca.crt")
+	serverCertFile, serverKeyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+
+	cert := ca.New("read path gRPC server test")
+	require.NoError(t, cert.WriteCACertificate(caCertFile))
+	require.NoError(t, cert.WriteCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		DNSNames:    []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, serverCertFile, serverKeyFile))
+
+	cfg := ReadPathGRPCServerConfig{}
+	flagext.DefaultValues(&cfg)
+
+	serverCfg := server.Config{}
+	flagext.DefaultValues(&serverCfg)
+	serverCfg.GRPCListenAddress = "localhost"
+	serverCfg.GRPCTLSConfig.TLSCertPath = serverCertFile
+	serverCfg.GRPCTLSConfig.TLSKeyPath = serverKeyFile
+	// Same keepalive enforcement policy as Mimir, allowing the pings of the gRPC clients.
+	serverCfg.GRPCServerMinTimeBetweenPings = 10 * time.Second
+	serverCfg.GRPCServerPingWithoutStreamAllowed = true
+
+	ingester := &blockingLabelNamesIngester{
+		started: make(chan struct{}, 1),
+		unblock: make(chan struct{}),
+	}
+	close(ingester.unblock)
+
+	s, err := NewReadPathGRPCServer(cfg, serverCfg, ingester, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, s))
+	})
+
+	clientCfg := client.Config{}
+	flagext.DefaultValues(&clientCfg)
+	clientCfg.GRPCClientConfig.TLSEnabled = true
+	clientCfg.GRPCClientConfig.TLS.CAPath = caCertFile
+	clientCfg.GRPCClientConfig.TLS.ServerName = "localhost"
+
+	c, err := client.MakeIngesterClient(s.Addr().String(), clientCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, c.Close())
+	})
+
+	_, err = c.LabelNames(user.InjectOrgID(ctx, "test"), &client.LabelNamesRequest{})
+	require.NoError(t, err)
+
+	// A plaintext client can't connect to the server.
+	conn, err := grpc.Dial(s.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+	_, err = client.NewIngesterClient(conn).LabelNames(ctx, &client.LabelNamesRequest{})
+	require.Error(t, err)
+}
+
+func TestReadPathGRPCServer_ShouldFailOnInvalidTLSConfig(t *testing.T) {
+	cfg := ReadPathGRPCServerConfig{}
+	flagext.DefaultValues(&cfg)
+
+	serverCfg := server.Config{}
+	flagext.DefaultValues(&serverCfg)
+	serverCfg.GRPCTLSConfig.TLSCertPath = "/does/not/exist.crt"
+	serverCfg.GRPCTLSConfig.TLSKeyPath = "/does/not/exist.key"
+
+	_, err := NewReadPathGRPCServer(cfg, serverCfg, &blockingLabelNamesIngester{}, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.Error(t, err)
+}
+
+// blockingLabelNamesIngester is an ingester whose LabelNames() blocks until unblock is closed.
+type blockingLabelNamesIngester struct {
+	client.IngesterServer
+
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (i *blockingLabelNamesIngester) LabelNames(context.Context, *client.LabelNamesRequest) (*client.LabelNamesResponse, error) {
+	i.started <- struct{}{}
+	<-i.unblock
+	return &client.LabelNamesResponse{}, nil
+}
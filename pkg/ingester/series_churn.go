@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// seriesChurnBuckets is the number of buckets the series churn tracking window is split in.
+	seriesChurnBuckets = 10
+
+	// defaultSeriesChurnLimit is the default number of label names returned by the series churn API.
+	defaultSeriesChurnLimit = 20
+)
+
+var errInvalidSeriesChurnTrackingWindow = errors.New("the series churn tracking window must be greater than or equal to 0")
+
+// seriesChurnTracker tracks, per label name, the series created in and removed from the TSDB head of a tenant
+// over a recent time window. The window is split in buckets, so that the oldest counts are discarded as time
+// goes by without keeping track of each series.
+type seriesChurnTracker struct {
+	window         time.Duration
+	bucketDuration time.Duration
+
+	mtx     sync.Mutex
+	buckets [seriesChurnBuckets]seriesChurnBucket
+}
+
+type seriesChurnBucket struct {
+	// Index of the time bucket the counts refer to, since the Unix epoch.
+	index      int64
+	created    int
+	removed    int
+	labelNames map[string]*seriesChurnCounts
+}
+
+type seriesChurnCounts struct {
+	created int
+	removed int
+}
+
+func newSeriesChurnTracker(window time.Duration) *seriesChurnTracker {
+	bucketDuration := window / seriesChurnBuckets
+	if bucketDuration <= 0 {
+		bucketDuration = 1
+	}
+
+	return &seriesChurnTracker{
+		window:         window,
+		bucketDuration: bucketDuration,
+	}
+}
+
+// bucketFor returns the bucket of the input time, resetting it if it was tracking an older time bucket.
+// Must be called with the lock held.
+func (t *seriesChurnTracker) bucketFor(now time.Time) *seriesChurnBucket {
+	idx := now.UnixNano() / int64(t.bucketDuration)
+
+	b := &t.buckets[idx%seriesChurnBuckets]
+	if b.index != idx || b.labelNames == nil {
+		*b = seriesChurnBucket{index: idx, labelNames: map[string]*seriesChurnCounts{}}
+	}
+	return b
+}
+
+// countsFor returns the counts of the input label name in the bucket. Must be called with the lock held.
+func (b *seriesChurnBucket) countsFor(name string) *seriesChurnCounts {
+	c, ok := b.labelNames[name]
+	if !ok {
+		// The label name is cloned to not retain the labels of the series, which could be removed from the head.
+		c = &seriesChurnCounts{}
+		b.labelNames[strings.Clone(name)] = c
+	}
+	return c
+}
+
+func (t *seriesChurnTracker) seriesCreated(now time.Time, series labels.Labels) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	b := t.bucketFor(now)
+	b.created++
+	series.Range(func(l labels.Label) {
+		b.countsFor(l.Name).created++
+	})
+}
+
+func (t *seriesChurnTracker) seriesRemoved(now time.Time, series ...labels.Labels) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	b := t.bucketFor(now)
+	b.removed += len(series)
+	for _, s := range series {
+		s.Range(func(l labels.Label) {
+			b.countsFor(l.Name).removed++
+		})
+	}
+}
+
+// SeriesChurnResponse is the response of the series churn API.
+type SeriesChurnResponse struct {
+	Window        string                 `json:"window"`
+	SeriesCreated int                    `json:"series_created"`
+	SeriesRemoved int                    `json:"series_removed"`
+	LabelNames    []LabelNameSeriesChurn `json:"label_names"`
+}
+
+// LabelNameSeriesChurn is the series churn of a label name.
+type LabelNameSeriesChurn struct {
+	LabelName     string `json:"label_name"`
+	SeriesCreated int    `json:"series_created"`
+	SeriesRemoved int    `json:"series_removed"`
+}
+
+// report returns the series churn over the tracking window, with at most limit label names sorted by
+// the number of series created and removed, in descending order. 0 means no limit.
+func (t *seriesChurnTracker) report(now time.Time, limit int) SeriesChurnResponse {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	res := SeriesChurnResponse{Window: t.window.String()}
+	byName := map[string]*LabelNameSeriesChurn{}
+
+	minIdx := now.UnixNano()/int64(t.bucketDuration) - seriesChurnBuckets
+	for i := range t.buckets {
+		b := &t.buckets[i]
+		if b.index <= minIdx || b.labelNames == nil {
+			continue
+		}
+
+		res.SeriesCreated += b.created
+		res.SeriesRemoved += b.removed
+		for name, c := range b.labelNames {
+			churn, ok := byName[name]
+			if !ok {
+				churn = &LabelNameSeriesChurn{LabelName: name}
+				byName[name] = churn
+			}
+			churn.SeriesCreated += c.created
+			churn.SeriesRemoved += c.removed
+		}
+	}
+
+	res.LabelNames = make([]LabelNameSeriesChurn, 0, len(byName))
+	for _, churn := range byName {
+		res.LabelNames = append(res.LabelNames, *churn)
+	}
+	sort.Slice(res.LabelNames, func(i, j int) bool {
+		ci := res.LabelNames[i].SeriesCreated + res.LabelNames[i].SeriesRemoved
+		cj := res.LabelNames[j].SeriesCreated + res.LabelNames[j].SeriesRemoved
+		if ci != cj {
+			return ci > cj
+		}
+		return res.LabelNames[i].LabelName < res.LabelNames[j].LabelName
+	})
+	if limit > 0 && len(res.LabelNames) > limit {
+		res.LabelNames = res.LabelNames[:limit]
+	}
+
+	return res
+}
+
+// SeriesChurnHandler reports, for the authenticated tenant, the series created in and removed from the
+// TSDB head per label name over the last -ingester.series-churn-tracking-window, to find the labels driving
+// the series churn.
+func (i *Ingester) SeriesChurnHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if i.cfg.SeriesChurnTrackingWindow <= 0 {
+		http.Error(w, "series churn tracking is disabled", http.StatusNotFound)
+		return
+	}
+
+	limit := defaultSeriesChurnLimit
+	if v := r.FormValue("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	db := i.getTSDB(userID)
+	if db == nil || db.seriesChurn == nil {
+		http.Error(w, "user TSDB not found", http.StatusNotFound)
+		return
+	}
+
+	util.WriteJSONResponse(w, db.seriesChurn.report(time.Now(), limit))
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestSeriesChurnTracker(t *testing.T) {
+	now := time.Now()
+	tracker := newSeriesChurnTracker(10 * time.Minute)
+
+	tracker.seriesCreated(now.Add(-15*time.Minute), labels.FromStrings(labels.MetricName, "old", "pod", "a"))
+	tracker.seriesCreated(now.Add(-5*time.Minute), labels.FromStrings(labels.MetricName, "up", "pod", "a"))
+	tracker.seriesCreated(now.Add(-time.Minute), labels.FromStrings(labels.MetricName, "up", "pod", "b", "job", "x"))
+	tracker.seriesRemoved(now, labels.FromStrings(labels.MetricName, "up", "pod", "c"), labels.FromStrings(labels.MetricName, "up", "pod", "d"))
+
+	// The series created out of the window are not reported.
+	assert.Equal(t, SeriesChurnResponse{
+		Window:        "10m0s",
+		SeriesCreated: 2,
+		SeriesRemoved: 2,
+		LabelNames: []LabelNameSeriesChurn{
+			{LabelName: labels.MetricName, SeriesCreated: 2, SeriesRemoved: 2},
+			{LabelName: "pod", SeriesCreated: 2, SeriesRemoved: 2},
+			{LabelName: "job", SeriesCreated: 1},
+		},
+	}, tracker.report(now, 0))
+
+	// The label names are limited to the ones with the highest churn.
+	assert.Equal(t, []LabelNameSeriesChurn{
+		{LabelName: labels.MetricName, SeriesCreated: 2, SeriesRemoved: 2},
+	}, tracker.report(now, 1).LabelNames)
+
+	// The counts expire once out of the window.
+	assert.Equal(t, SeriesChurnResponse{
+		Window:        "10m0s",
+		SeriesRemoved: 2,
+		LabelNames: []LabelNameSeriesChurn{
+			{LabelName: labels.MetricName, SeriesRemoved: 2},
+			{LabelName: "pod", SeriesRemoved: 2},
+		},
+	}, tracker.report(now.Add(9*time.Minute), 0))
+
+	assert.Equal(t, SeriesChurnResponse{Window: "10m0s", LabelNames: []LabelNameSeriesChurn{}}, tracker.report(now.Add(time.Hour), 0))
+}
+
+func TestIngester_SeriesChurnHandler(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.SeriesChurnTrackingWindow = time.Hour
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	req := mimirpb.ToWriteRequest(
+		[]labels.Labels{
+			labels.FromStrings(labels.MetricName, "up", "pod", "a"),
+			labels.FromStrings(labels.MetricName, "up", "pod", "b"),
+		},
+		[]mimirpb.Sample{{Value: 1, TimestampMs: 9}, {Value: 1, TimestampMs: 9}},
+		nil,
+		nil,
+		mimirpb.API,
+	)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		url              string
+		expectedStatus   int
+		expectedResponse SeriesChurnResponse
+	}{
+		"default limit": {
+			url:            "/ingester/series_churn",
+			expectedStatus: http.StatusOK,
+			expectedResponse: SeriesChurnResponse{
+				Window:        "1h0m0s",
+				SeriesCreated: 2,
+				LabelNames: []LabelNameSeriesChurn{
+					{LabelName: labels.MetricName, SeriesCreated: 2},
+					{LabelName: "pod", SeriesCreated: 2},
+				},
+			},
+		},
+		"custom limit": {
+			url:            "/ingester/series_churn?limit=1",
+			expectedStatus: http.StatusOK,
+			expectedResponse: SeriesChurnResponse{
+				Window:        "1h0m0s",
+				SeriesCreated: 2,
+				LabelNames: []LabelNameSeriesChurn{
+					{LabelName: labels.MetricName, SeriesCreated: 2},
+				},
+			},
+		},
+		"invalid limit": {
+			url:            "/ingester/series_churn?limit=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			i.SeriesChurnHandler(rec, httptest.NewRequest("GET", testData.url, nil).WithContext(ctx))
+			require.Equal(t, testData.expectedStatus, rec.Code)
+			if testData.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var res SeriesChurnResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, testData.expectedResponse, res)
+		})
+	}
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		i.SeriesChurnHandler(rec, httptest.NewRequest("GET", "/ingester/series_churn", nil).WithContext(user.InjectOrgID(context.Background(), "unknown")))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("disabled tracking", func(t *testing.T) {
+		i.cfg.SeriesChurnTrackingWindow = 0
+		t.Cleanup(func() { i.cfg.SeriesChurnTrackingWindow = time.Hour })
+
+		rec := httptest.NewRecorder()
+		i.SeriesChurnHandler(rec, httptest.NewRequest("GET", "/ingester/series_churn", nil).WithContext(ctx))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -22,12 +22,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/fileutil"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
 )
 
 type metrics struct {
@@ -67,6 +71,7 @@ func newMetrics(reg prometheus.Registerer) *metrics {
 
 type ShipperConfigProvider interface {
 	OutOfOrderBlocksExternalLabelEnabled(userID string) bool
+	ExemplarsPersistenceEnabled(userID string) bool
 }
 
 // Shipper watches a directory for matching files and directories and uploads
@@ -80,11 +85,15 @@ type Shipper struct {
 	metrics     *metrics
 	bucket      objstore.Bucket
 	source      metadata.SourceType
+
+	// Used to persist the exemplars of each block before uploading it. Optional.
+	exemplars storage.ExemplarQueryable
 }
 
 // NewShipper creates a new uploader that detects new TSDB blocks in dir and uploads them to
 // remote if necessary. It attaches the Thanos metadata section in each meta JSON file.
 // If uploadCompacted is enabled, it also uploads compacted blocks which are already in filesystem.
+// If exemplars is not nil, the exemplars of each block are persisted along with the block when enabled for the tenant.
 func NewShipper(
 	logger log.Logger,
 	cfgProvider ShipperConfigProvider,
@@ -93,6 +102,7 @@ func NewShipper(
 	dir string,
 	bucket objstore.Bucket,
 	source metadata.SourceType,
+	exemplars storage.ExemplarQueryable,
 ) *Shipper {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -106,6 +116,7 @@ func NewShipper(
 		bucket:      bucket,
 		metrics:     newMetrics(r),
 		source:      source,
+		exemplars:   exemplars,
 	}
 }
 
@@ -209,10 +220,47 @@ func (s *Shipper) upload(ctx context.Context, meta *metadata.Meta) error {
 		meta.Thanos.Labels[mimir_tsdb.OutOfOrderExternalLabel] = mimir_tsdb.OutOfOrderExternalLabelValue
 	}
 
+	if s.exemplars != nil && s.cfgProvider.ExemplarsPersistenceEnabled(s.userID) {
+		// Exemplars are persisted on a best-effort basis, so a failure doesn't prevent the block from being shipped.
+		if err := s.writeExemplars(ctx, blockDir, meta); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to persist exemplars of the block", "block", meta.ULID, "err", err)
+		}
+	}
+
 	// Upload block with custom metadata.
 	return block.Upload(ctx, s.logger, s.bucket, blockDir, meta)
 }
 
+// writeExemplars writes the exemplars within the block time range, which are still in memory, into the block directory.
+func (s *Shipper) writeExemplars(ctx context.Context, blockDir string, meta *metadata.Meta) error {
+	q, err := s.exemplars.ExemplarQuerier(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The block max time is exclusive, while the exemplars query end time is inclusive.
+	results, err := q.Select(meta.MinTime, meta.MaxTime-1, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")})
+	if err != nil {
+		return err
+	}
+
+	series := make([]storepb.SeriesExemplars, 0, len(results))
+	for _, r := range results {
+		if len(r.Exemplars) == 0 {
+			continue
+		}
+		series = append(series, storepb.SeriesExemplars{
+			Labels:    mimirpb.FromLabelsToLabelAdapters(r.SeriesLabels),
+			Exemplars: mimirpb.FromExemplarsToExemplarProtos(r.Exemplars),
+		})
+	}
+	if len(series) == 0 {
+		return nil
+	}
+
+	return block.WriteExemplarsFile(blockDir, block.MergeSeriesExemplars(series))
+}
+
 // blockMetasFromOldest returns the block meta of each block found in dir
 // sorted by minTime asc.
 func (s *Shipper) blockMetasFromOldest() (metas []*metadata.Meta, _ error) {
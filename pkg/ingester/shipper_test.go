@@ -20,10 +20,14 @@ import (
 	"github.com/grafana/dskit/concurrency"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
@@ -52,7 +56,7 @@ func TestShipper(t *testing.T) {
 	logger := log.NewLogfmtLogger(logs)
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	s := NewShipper(logger, overrides, "", nil, blocksDir, bkt, metadata.TestSource)
+	s := NewShipper(logger, overrides, "", nil, blocksDir, bkt, metadata.TestSource, nil)
 
 	t.Run("no shipper file yet", func(t *testing.T) {
 		// No shipper file = nothing is reported as shipped.
@@ -194,7 +198,7 @@ func TestShipper_DeceivingUploadErrors(t *testing.T) {
 	logger := log.NewLogfmtLogger(os.Stderr)
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	s := NewShipper(logger, overrides, "", nil, blocksDir, bkt, metadata.TestSource)
+	s := NewShipper(logger, overrides, "", nil, blocksDir, bkt, metadata.TestSource, nil)
 
 	// Create and upload a block
 	id1 := ulid.MustNew(1, nil)
@@ -260,7 +264,7 @@ func TestIterBlockMetas(t *testing.T) {
 	}.WriteToDir(log.NewNopLogger(), path.Join(dir, id3.String())))
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	shipper := NewShipper(nil, overrides, "", nil, dir, nil, metadata.TestSource)
+	shipper := NewShipper(nil, overrides, "", nil, dir, nil, metadata.TestSource, nil)
 	metas, err := shipper.blockMetasFromOldest()
 	require.NoError(t, err)
 	require.Equal(t, sort.SliceIsSorted(metas, func(i, j int) bool {
@@ -274,7 +278,7 @@ func TestShipperAddsSegmentFiles(t *testing.T) {
 	inmemory := objstore.NewInMemBucket()
 	overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
 	require.NoError(t, err)
-	s := NewShipper(nil, overrides, "", nil, dir, inmemory, metadata.TestSource)
+	s := NewShipper(nil, overrides, "", nil, dir, inmemory, metadata.TestSource, nil)
 
 	id := ulid.MustNew(1, nil)
 	blockDir := path.Join(dir, id.String())
@@ -448,7 +452,7 @@ func TestShipper_AddOOOLabel(t *testing.T) {
 			}
 			overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), validation.NewMockTenantLimits(tenantLimits))
 			require.NoError(t, err)
-			s := NewShipper(logger, overrides, "", nil, blocksDir, bkt, metadata.TestSource)
+			s := NewShipper(logger, overrides, "", nil, blocksDir, bkt, metadata.TestSource, nil)
 
 			createBlock(t, blocksDir, tc.meta.ULID, tc.meta)
 
@@ -470,3 +474,74 @@ func metaWithOOOHint(meta metadata.Meta) metadata.Meta {
 	meta.Compaction.SetOutOfOrder()
 	return meta
 }
+
+func TestShipper_PersistExemplars(t *testing.T) {
+	meta := metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:    ulid.MustNew(1, nil),
+			MinTime: 1000,
+			MaxTime: 2000,
+			Version: 1,
+			Stats: tsdb.BlockStats{
+				NumSamples: 100, // Shipper checks if number of samples is greater than 0.
+			},
+		},
+	}
+
+	exemplars := &shipperExemplarQueryableMock{res: []exemplar.QueryResult{{
+		SeriesLabels: labels.FromStrings(labels.MetricName, "series_1"),
+		Exemplars:    []exemplar.Exemplar{{Labels: labels.FromStrings("trace_id", "1"), Ts: 1500, Value: 1, HasTs: true}},
+	}}}
+
+	for _, persistenceEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("persistence enabled: %t", persistenceEnabled), func(t *testing.T) {
+			blocksDir := t.TempDir()
+			bkt := objstore.NewInMemBucket()
+
+			tenantLimits := map[string]*validation.Limits{
+				"": {
+					ExemplarsPersistenceEnabled: persistenceEnabled,
+				},
+			}
+			overrides, err := validation.NewOverrides(defaultLimitsTestConfig(), validation.NewMockTenantLimits(tenantLimits))
+			require.NoError(t, err)
+			s := NewShipper(log.NewNopLogger(), overrides, "", nil, blocksDir, bkt, metadata.TestSource, exemplars)
+
+			createBlock(t, blocksDir, meta.ULID, meta)
+
+			uploaded, err := s.Sync(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, uploaded)
+
+			exists, err := bkt.Exists(context.Background(), path.Join(meta.ULID.String(), block.ExemplarsFilename))
+			require.NoError(t, err)
+			require.Equal(t, persistenceEnabled, exists)
+
+			if persistenceEnabled {
+				// The exemplars should be queried within the block time range.
+				require.Equal(t, meta.MinTime, exemplars.start)
+				require.Equal(t, meta.MaxTime-1, exemplars.end)
+
+				series, err := block.ReadExemplarsFile(filepath.Join(blocksDir, meta.ULID.String()))
+				require.NoError(t, err)
+				require.Len(t, series, 1)
+				require.Equal(t, mimirpb.FromLabelsToLabelAdapters(exemplars.res[0].SeriesLabels), series[0].Labels)
+				require.Equal(t, mimirpb.FromExemplarsToExemplarProtos(exemplars.res[0].Exemplars), series[0].Exemplars)
+			}
+		})
+	}
+}
+
+type shipperExemplarQueryableMock struct {
+	res        []exemplar.QueryResult
+	start, end int64
+}
+
+func (m *shipperExemplarQueryableMock) ExemplarQuerier(context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *shipperExemplarQueryableMock) Select(start, end int64, _ ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	m.start, m.end = start, end
+	return m.res, nil
+}
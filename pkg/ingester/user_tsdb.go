@@ -19,6 +19,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	"github.com/grafana/mimir/pkg/ingester/writerequestlog"
 	"github.com/grafana/mimir/pkg/util/extract"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 )
@@ -78,6 +79,13 @@ type userTSDB struct {
 	seriesInMetric *metricCounter
 	limiter        *Limiter
 
+	// Optional: tracks the series churn of the head, if enabled. Set once the WAL has been replayed,
+	// so that the series replayed from the WAL are not tracked as created.
+	seriesChurn *seriesChurnTracker
+
+	// Optional: records the write requests with an ID, if enabled.
+	writeRequestLog *writerequestlog.Writer
+
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
 	instanceLimitsFn    func() *InstanceLimits
 
@@ -88,6 +96,9 @@ type userTSDB struct {
 	// Used to detect idle TSDBs.
 	lastUpdate atomic.Int64
 
+	// Postings for matchers cache of the head, tracking its hits and misses.
+	headPostingsCache *headPostingsForMatchersCache
+
 	// Thanos shipper used to upload blocks to the storage.
 	shipper BlocksUploader
 
@@ -117,16 +128,112 @@ func (u *userTSDB) Appender(ctx context.Context) storage.Appender {
 }
 
 // Querier returns a new querier over the data partition for the given time range.
-func (u *userTSDB) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
-	return u.db.Querier(ctx, mint, maxt)
+// The head is queried through the head postings for matchers cache of the tenant.
+func (u *userTSDB) Querier(_ context.Context, mint, maxt int64) (storage.Querier, error) {
+	queriers, err := blockQueriersForRange(u, mint, maxt, tsdb.NewBlockQuerier)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewMergeQuerier(queriers, nil, storage.ChainedSeriesMerge), nil
+}
+
+func (u *userTSDB) ChunkQuerier(_ context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
+	queriers, err := blockQueriersForRange(u, mint, maxt, tsdb.NewBlockChunkQuerier)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewMergeChunkQuerier(queriers, nil, storage.NewCompactingChunkSeriesMerger(storage.ChainedSeriesMerge)), nil
+}
+
+// UnorderedChunkQuerier returns a new chunk querier over the data partition for the given time range.
+// The chunks can be overlapping and not sorted.
+func (u *userTSDB) UnorderedChunkQuerier(_ context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
+	queriers, err := blockQueriersForRange(u, mint, maxt, tsdb.NewBlockChunkQuerier)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewMergeChunkQuerier(queriers, nil, storage.NewConcatenatingChunkSeriesMerger()), nil
 }
 
-func (u *userTSDB) ChunkQuerier(ctx context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
-	return u.db.ChunkQuerier(ctx, mint, maxt)
+// headBlockReader returns the reader of the in-order head for the given time range.
+func (u *userTSDB) headBlockReader(mint, maxt int64) tsdb.BlockReader {
+	head := u.db.Head()
+	return &cachedHeadBlockReader{BlockReader: tsdb.NewRangeHead(head, mint, maxt), head: head, mint: mint, maxt: maxt, cache: u.headPostingsCache}
 }
 
-func (u *userTSDB) UnorderedChunkQuerier(ctx context.Context, mint, maxt int64) (storage.ChunkQuerier, error) {
-	return u.db.UnorderedChunkQuerier(ctx, mint, maxt)
+// oooHeadBlockReader returns the reader of the out-of-order head for the given time range.
+func (u *userTSDB) oooHeadBlockReader(mint, maxt int64) tsdb.BlockReader {
+	head := u.db.Head()
+	return &cachedHeadBlockReader{BlockReader: tsdb.NewOOORangeHead(head, mint, maxt), head: head, mint: mint, maxt: maxt, cache: u.headPostingsCache}
+}
+
+// blockQueriersForRange returns the queriers of the persistent blocks, the in-order head and the out-of-order head
+// overlapping with the given time range. It mirrors tsdb.DB, which doesn't allow to wrap the head index.
+func blockQueriersForRange[Q storage.LabelQuerier](u *userTSDB, mint, maxt int64, newQuerier func(tsdb.BlockReader, int64, int64) (Q, error)) (_ []Q, returnErr error) {
+	var blockQueriers, headQueriers []Q
+	defer func() {
+		if returnErr != nil {
+			// If we fail, all previously opened queriers must be closed.
+			for _, q := range append(blockQueriers, headQueriers...) {
+				_ = q.Close()
+			}
+		}
+	}()
+
+	head := u.db.Head()
+
+	// The head queriers are taken before listing the blocks: the blocks created by a head truncation
+	// colliding with the head querier are available once the truncation is in process.
+	if maxt >= head.MinTime() {
+		q, err := newQuerier(u.headBlockReader(mint, maxt), mint, maxt)
+		if err != nil {
+			return nil, errors.Wrap(err, "open querier for head")
+		}
+
+		// Getting the querier above registers itself in the queue that the truncation waits on.
+		// So if the querier is currently not colliding with any truncation, we can continue to use it and still
+		// won't run into a race later since any truncation that comes after will wait on this querier if it overlaps.
+		shouldClose, getNew, newMint := head.IsQuerierCollidingWithTruncation(mint, maxt)
+		if shouldClose || getNew {
+			if err := q.Close(); err != nil {
+				return nil, errors.Wrap(err, "close querier for head")
+			}
+		}
+		if getNew {
+			q, err = newQuerier(u.headBlockReader(newMint, maxt), newMint, maxt)
+			if err != nil {
+				return nil, errors.Wrap(err, "open querier for head while getting new querier")
+			}
+		}
+		if !shouldClose {
+			headQueriers = append(headQueriers, q)
+		}
+	}
+
+	if maxt >= head.MinOOOTime() && mint <= head.MaxOOOTime() {
+		q, err := newQuerier(u.oooHeadBlockReader(mint, maxt), mint, maxt)
+		if err != nil {
+			return nil, errors.Wrap(err, "open querier for ooo head")
+		}
+		headQueriers = append(headQueriers, q)
+	}
+
+	for _, b := range u.db.Blocks() {
+		if !b.OverlapsClosedInterval(mint, maxt) {
+			continue
+		}
+		q, err := newQuerier(b, mint, maxt)
+		if errors.Is(err, tsdb.ErrClosing) {
+			// The block has been removed by a retention in the meanwhile.
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "open querier for block %s", b)
+		}
+		blockQueriers = append(blockQueriers, q)
+	}
+
+	return append(blockQueriers, headQueriers...), nil
 }
 
 func (u *userTSDB) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
@@ -142,6 +249,11 @@ func (u *userTSDB) Blocks() []*tsdb.Block {
 }
 
 func (u *userTSDB) Close() error {
+	if u.writeRequestLog != nil {
+		if err := u.writeRequestLog.Close(); err != nil {
+			return errors.Wrap(err, "close write request log")
+		}
+	}
 	return u.db.Close()
 }
 
@@ -240,6 +352,10 @@ func (u *userTSDB) PostCreation(metric labels.Labels) {
 		return
 	}
 	u.seriesInMetric.increaseSeriesForMetric(metricName)
+
+	if u.seriesChurn != nil {
+		u.seriesChurn.seriesCreated(time.Now(), metric)
+	}
 }
 
 func (u *userTSDB) PostDeletion(metrics ...labels.Labels) {
@@ -253,6 +369,10 @@ func (u *userTSDB) PostDeletion(metrics ...labels.Labels) {
 		}
 		u.seriesInMetric.decreaseSeriesForMetric(metricName)
 	}
+
+	if u.seriesChurn != nil {
+		u.seriesChurn.seriesRemoved(time.Now(), metrics...)
+	}
 }
 
 // blocksToDelete filters the input blocks and returns the blocks which are safe to be deleted from the ingester.
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ingester
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/ingester/writerequestlog"
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestIngester_WriteRequestLog(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.WriteRequestLogEnabled = true
+	cfg.WriteRequestLogRetention = time.Hour
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+	})
+
+	// Wait until the ingester is healthy
+	test.Poll(t, 100*time.Millisecond, 1, func() interface{} {
+		return i.lifecycler.HealthyInstancesCount()
+	})
+
+	push := func(ctx context.Context, series []labels.Labels, ts int64) {
+		samples := make([]mimirpb.Sample, 0, len(series))
+		for range series {
+			samples = append(samples, mimirpb.Sample{Value: 1, TimestampMs: ts})
+		}
+		_, err := i.Push(ctx, mimirpb.ToWriteRequest(series, samples, nil, nil, mimirpb.API))
+		require.NoError(t, err)
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	push(util.AddWriteRequestIDToIncomingContext(ctx, "req-1"), []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "pod", "a"),
+		labels.FromStrings(labels.MetricName, "up", "pod", "b"),
+	}, 10)
+	push(util.AddWriteRequestIDToIncomingContext(ctx, "req-2"), []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "pod", "a"),
+	}, 20)
+
+	// The requests without an ID are not recorded.
+	push(ctx, []labels.Labels{labels.FromStrings(labels.MetricName, "up", "pod", "a")}, 30)
+
+	dir := filepath.Join(i.cfg.BlocksStorageConfig.TSDB.BlocksDir("test"), writerequestlog.DirName)
+	search := func(ts int64, matchers ...*labels.Matcher) []string {
+		var ids []string
+		require.NoError(t, writerequestlog.Search(dir, matchers, ts, func(r writerequestlog.Record) error {
+			ids = append(ids, r.ID)
+			return nil
+		}))
+		return ids
+	}
+
+	assert.Equal(t, []string{"req-1", "req-2"}, search(0, labels.MustNewMatcher(labels.MatchEqual, "pod", "a")))
+	assert.Equal(t, []string{"req-1"}, search(0, labels.MustNewMatcher(labels.MatchEqual, "pod", "b")))
+	assert.Equal(t, []string{"req-2"}, search(20, labels.MustNewMatcher(labels.MatchEqual, "pod", "a")))
+	assert.Empty(t, search(30, labels.MustNewMatcher(labels.MatchEqual, "pod", "a")))
+}
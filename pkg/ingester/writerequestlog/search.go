@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package writerequestlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Search calls f, in the order they have been appended, for the records of the log in the input directory
+// which have a series matching all the matchers. If ts is not zero, the samples of the series must also span
+// a time range including ts. The records passed to f only include the matching series.
+func Search(dir string, matchers []*labels.Matcher, ts int64, f func(Record) error) error {
+	files, err := listFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := searchFile(file.path, matchers, ts, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func searchFile(path string, matchers []*labels.Matcher, ts int64, f func(Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "open write request log file")
+	}
+	defer file.Close()
+
+	// The records can be larger than the max token size of a bufio.Scanner.
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var record Record
+			// A partially written record, like the last one before a crash, is skipped.
+			if jsonErr := json.Unmarshal(line, &record); jsonErr == nil {
+				if matched := matchingSeries(record.Series, matchers, ts); len(matched) > 0 {
+					record.Series = matched
+					if err := f(record); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "read write request log file")
+		}
+	}
+}
+
+func matchingSeries(series []Series, matchers []*labels.Matcher, ts int64) []Series {
+	var matched []Series
+	for _, s := range series {
+		if ts != 0 && (ts < s.MinTime || ts > s.MaxTime) {
+			continue
+		}
+		if matchesAll(s.Labels, matchers) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+func matchesAll(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
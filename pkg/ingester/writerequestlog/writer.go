@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package writerequestlog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+const (
+	// DirName is the name of the directory, within the tenant's TSDB directory, the write request log is stored in.
+	DirName = "write-requests"
+
+	// The log is split into a file per hour, so that the files past the retention can be deleted.
+	fileTimeLayout = "2006-01-02T15"
+	fileExtension  = ".jsonl"
+)
+
+// Record is a write request received by an ingester.
+type Record struct {
+	// ID of the write request, assigned by the distributor.
+	ID string `json:"id"`
+
+	// Timestamp is when the ingester appended the write request (millis precision).
+	Timestamp int64 `json:"timestamp"`
+
+	// Source of the write request, like the remote write API or the ruler.
+	Source string `json:"source"`
+
+	// Series of the write request.
+	Series []Series `json:"series"`
+}
+
+// Series is a series of a write request, summarizing its samples.
+type Series struct {
+	Labels labels.Labels `json:"labels"`
+
+	// MinTime and MaxTime are the timestamps of the oldest and newest samples of the series in the request.
+	MinTime int64 `json:"min_time"`
+	MaxTime int64 `json:"max_time"`
+
+	// Number of samples, including the histograms, of the series in the request.
+	Samples int `json:"samples"`
+}
+
+// Writer appends the write requests received for a tenant to the log files in a directory, and deletes
+// the files past the retention.
+type Writer struct {
+	dir       string
+	retention time.Duration
+
+	mtx      sync.Mutex
+	file     *os.File
+	fileHour time.Time
+}
+
+// NewWriter makes a new Writer of the log in the input directory.
+func NewWriter(dir string, retention time.Duration) *Writer {
+	return &Writer{
+		dir:       dir,
+		retention: retention,
+	}
+}
+
+// Append appends the write request with the input ID, received at the input time, to the log.
+func (w *Writer) Append(now time.Time, id string, source mimirpb.WriteRequest_SourceEnum, timeseries []mimirpb.PreallocTimeseries) error {
+	record := Record{
+		ID:        id,
+		Timestamp: now.UnixMilli(),
+		Source:    source.String(),
+		Series:    make([]Series, 0, len(timeseries)),
+	}
+	for _, ts := range timeseries {
+		record.Series = append(record.Series, newSeries(ts))
+	}
+
+	// The record is encoded right away, since the labels of the series reference the request buffer.
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "encode write request log record")
+	}
+	data = append(data, '\n')
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if err := w.rotate(now); err != nil {
+		return err
+	}
+	_, err = w.file.Write(data)
+	return errors.Wrap(err, "write write request log record")
+}
+
+// Close closes the current log file.
+func (w *Writer) Close() error {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// rotate opens the file of the hour of the input time if it's not the current one, and deletes the files
+// past the retention. Must be called with the mutex held.
+func (w *Writer) rotate(now time.Time) error {
+	hour := now.UTC().Truncate(time.Hour)
+	if w.file != nil && hour.Equal(w.fileHour) {
+		return nil
+	}
+
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return errors.Wrap(err, "close write request log file")
+		}
+		w.file = nil
+	}
+
+	if err := os.MkdirAll(w.dir, os.ModePerm); err != nil {
+		return errors.Wrap(err, "create write request log directory")
+	}
+	f, err := os.OpenFile(filepath.Join(w.dir, hour.Format(fileTimeLayout)+fileExtension), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o666)
+	if err != nil {
+		return errors.Wrap(err, "open write request log file")
+	}
+	w.file = f
+	w.fileHour = hour
+
+	return w.deleteFilesBefore(now.Add(-w.retention))
+}
+
+// deleteFilesBefore deletes the files whose hour ended before the input time.
+func (w *Writer) deleteFilesBefore(minTime time.Time) error {
+	files, err := listFiles(w.dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.hour.Add(time.Hour).Before(minTime) {
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, "delete write request log file")
+			}
+		}
+	}
+	return nil
+}
+
+func newSeries(ts mimirpb.PreallocTimeseries) Series {
+	s := Series{Labels: mimirpb.FromLabelAdaptersToLabels(ts.Labels)}
+	observe := func(t int64) {
+		if s.Samples == 0 || t < s.MinTime {
+			s.MinTime = t
+		}
+		if s.Samples == 0 || t > s.MaxTime {
+			s.MaxTime = t
+		}
+		s.Samples++
+	}
+	for _, sample := range ts.Samples {
+		observe(sample.TimestampMs)
+	}
+	for _, h := range ts.Histograms {
+		observe(h.Timestamp)
+	}
+	return s
+}
+
+type logFile struct {
+	path string
+	hour time.Time
+}
+
+// listFiles returns the log files in the directory, sorted by hour.
+func listFiles(dir string) ([]logFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "list write request log files")
+	}
+
+	// The entries are sorted by name, so by hour too.
+	files := make([]logFile, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, fileExtension) {
+			continue
+		}
+		hour, err := time.Parse(fileTimeLayout, strings.TrimSuffix(name, fileExtension))
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(dir, name), hour: hour})
+	}
+	return files, nil
+}
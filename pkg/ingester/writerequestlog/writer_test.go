@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package writerequestlog
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+)
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWriter(dir, 2*time.Hour)
+	t.Cleanup(func() { require.NoError(t, w.Close()) })
+
+	series := func(lbls labels.Labels, timestamps ...int64) mimirpb.PreallocTimeseries {
+		ts := mimirpb.PreallocTimeseries{TimeSeries: &mimirpb.TimeSeries{Labels: mimirpb.FromLabelsToLabelAdapters(lbls)}}
+		for _, t := range timestamps {
+			ts.Samples = append(ts.Samples, mimirpb.Sample{TimestampMs: t, Value: 1})
+		}
+		return ts
+	}
+	up1 := labels.FromStrings(labels.MetricName, "up", "instance", "1")
+	up2 := labels.FromStrings(labels.MetricName, "up", "instance", "2")
+	cpu := labels.FromStrings(labels.MetricName, "cpu", "instance", "1")
+
+	now := time.Date(2023, 3, 10, 12, 30, 0, 0, time.UTC)
+	require.NoError(t, w.Append(now.Add(-3*time.Hour), "id-1", mimirpb.API, []mimirpb.PreallocTimeseries{series(up1, 10, 20)}))
+	require.NoError(t, w.Append(now.Add(-time.Hour), "id-2", mimirpb.API, []mimirpb.PreallocTimeseries{series(up1, 30, 40), series(cpu, 30)}))
+	require.NoError(t, w.Append(now, "id-3", mimirpb.RULE, []mimirpb.PreallocTimeseries{series(up2, 50), series(up1, 50, 60)}))
+
+	// The file past the retention has been deleted.
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	search := func(ts int64, matchers ...*labels.Matcher) []Record {
+		var records []Record
+		require.NoError(t, Search(dir, matchers, ts, func(r Record) error {
+			records = append(records, r)
+			return nil
+		}))
+		return records
+	}
+
+	t.Run("search by series", func(t *testing.T) {
+		assert.Equal(t, []Record{
+			{ID: "id-2", Timestamp: now.Add(-time.Hour).UnixMilli(), Source: "API", Series: []Series{{Labels: up1, MinTime: 30, MaxTime: 40, Samples: 2}}},
+			{ID: "id-3", Timestamp: now.UnixMilli(), Source: "RULE", Series: []Series{{Labels: up1, MinTime: 50, MaxTime: 60, Samples: 2}}},
+		}, search(0, labels.MustNewMatcher(labels.MatchEqual, "instance", "1"), labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up")))
+	})
+
+	t.Run("search by series and sample timestamp", func(t *testing.T) {
+		records := search(55, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"))
+		require.Len(t, records, 1)
+		assert.Equal(t, "id-3", records[0].ID)
+		assert.Equal(t, []Series{{Labels: up1, MinTime: 50, MaxTime: 60, Samples: 2}}, records[0].Series)
+	})
+
+	t.Run("search with no match", func(t *testing.T) {
+		assert.Empty(t, search(0, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "memory")))
+	})
+}
+
+func TestSearch_ShouldSkipPartiallyWrittenRecords(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/2023-03-10T12.jsonl", []byte(`{"id":"id-1","series":[{"labels":{"__name__":"up"}}]}
+{"id":"id-2","series":[{"lab`), 0o666))
+
+	var ids []string
+	require.NoError(t, Search(dir, nil, 0, func(r Record) error {
+		ids = append(ids, r.ID)
+		return nil
+	}))
+	assert.Equal(t, []string{"id-1"}, ids)
+}
@@ -18,6 +18,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/flagext"
 	"github.com/grafana/dskit/grpcutil"
 	"github.com/grafana/dskit/kv/memberlist"
@@ -29,7 +30,6 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/prometheus/promql"
 	prom_storage "github.com/prometheus/prometheus/storage"
 	"github.com/weaveworks/common/server"
 	"github.com/weaveworks/common/signals"
@@ -65,6 +65,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/usagestats"
+	"github.com/grafana/mimir/pkg/usagetracker"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
 	util_log "github.com/grafana/mimir/pkg/util/log"
@@ -130,6 +131,7 @@ type Config struct {
 	QueryScheduler      scheduler.Config                           `yaml:"query_scheduler"`
 	UsageStats          usagestats.Config                          `yaml:"usage_stats"`
 	OverridesExporter   exporter.Config                            `yaml:"overrides_exporter"`
+	UsageTracker        usagetracker.Config                        `yaml:"usage_tracker"`
 
 	Common CommonConfig `yaml:"common"`
 }
@@ -180,6 +182,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	c.QueryScheduler.RegisterFlags(f, logger)
 	c.UsageStats.RegisterFlags(f)
 	c.OverridesExporter.RegisterFlags(f, logger)
+	c.UsageTracker.RegisterFlags(f)
 
 	c.Common.RegisterFlags(f, logger)
 }
@@ -240,6 +243,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.IngesterClient.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid ingester_client config")
 	}
+	if err := c.Distributor.UsageTrackerClient.Validate(log); err != nil {
+		return errors.Wrap(err, "invalid distributor usage-tracker client config")
+	}
 	if err := c.Ingester.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid ingester config")
 	}
@@ -275,6 +281,11 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.OverridesExporter.Validate(); err != nil {
 		return errors.Wrap(err, "invalid overrides-exporter config")
 	}
+	if c.isModuleEnabled(UsageTracker) {
+		if err := c.UsageTracker.Validate(); err != nil {
+			return errors.Wrap(err, "invalid usage-tracker config")
+		}
+	}
 	return nil
 }
 
@@ -665,9 +676,10 @@ type Mimir struct {
 	QuerierQueryable         prom_storage.SampleAndChunkQueryable
 	ExemplarQueryable        prom_storage.ExemplarQueryable
 	MetadataSupplier         querier.MetadataSupplier
-	QuerierEngine            *promql.Engine
+	QuerierEngine            *querier.PerTenantEngine
 	QueryFrontendTripperware querymiddleware.Tripperware
 	QueryFrontendCodec       querymiddleware.Codec
+	QueryFrontendCache       cache.Cache
 	Ruler                    *ruler.Ruler
 	RulerStorage             rulestore.RuleStore
 	Alertmanager             *alertmanager.MultitenantAlertmanager
@@ -681,6 +693,12 @@ type Mimir struct {
 
 	// Queryables that the querier should use to query the long term storage.
 	StoreQueryables []querier.QueryableWithFilter
+
+	// Exemplar queryables that the querier should use to query the exemplars persisted in the long term storage.
+	StoreExemplarQueryables []prom_storage.ExemplarQueryable
+
+	// Cardinality summaries of the blocks persisted in the long term storage.
+	StoreBlocksCardinality querier.BlocksCardinality
 }
 
 // New makes a new Mimir.
@@ -26,14 +26,17 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/rules"
 	prom_storage "github.com/prometheus/prometheus/storage"
 	prom_remote "github.com/prometheus/prometheus/storage/remote"
+	"github.com/thanos-io/objstore"
 	httpgrpc_server "github.com/weaveworks/common/httpgrpc/server"
 	"github.com/weaveworks/common/server"
 
 	"github.com/grafana/mimir/pkg/alertmanager"
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
+	alertstorelocal "github.com/grafana/mimir/pkg/alertmanager/alertstore/local"
 	"github.com/grafana/mimir/pkg/api"
 	"github.com/grafana/mimir/pkg/compactor"
 	"github.com/grafana/mimir/pkg/distributor"
@@ -47,10 +50,12 @@ import (
 	"github.com/grafana/mimir/pkg/querier/tenantfederation"
 	querier_worker "github.com/grafana/mimir/pkg/querier/worker"
 	"github.com/grafana/mimir/pkg/ruler"
+	rulestorelocal "github.com/grafana/mimir/pkg/ruler/rulestore/local"
 	"github.com/grafana/mimir/pkg/scheduler"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/usagestats"
+	"github.com/grafana/mimir/pkg/usagetracker"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
 	util_log "github.com/grafana/mimir/pkg/util/log"
@@ -69,6 +74,7 @@ const (
 	RuntimeConfig              string = "runtime-config"
 	Overrides                  string = "overrides"
 	OverridesExporter          string = "overrides-exporter"
+	UsageTracker               string = "usage-tracker"
 	Server                     string = "server"
 	ActiveGroupsCleanupService string = "active-groups-cleanup-service"
 	Distributor                string = "distributor"
@@ -208,6 +214,9 @@ func (t *Mimir) initVault() (services.Service, error) {
 	t.Cfg.Alertmanager.AlertmanagerClient.GRPCClientConfig.TLS.Reader = t.Vault
 	t.Cfg.QueryScheduler.GRPCClientConfig.TLS.Reader = t.Vault
 
+	// Update Configs - Alertmanager receiver secrets
+	t.Cfg.Alertmanager.ReceiverSecrets.VaultReader = t.Vault
+
 	return nil, nil
 }
 
@@ -307,6 +316,13 @@ func (t *Mimir) initOverrides() (serv services.Service, err error) {
 	return nil, err
 }
 
+func (t *Mimir) initUsageTracker() (services.Service, error) {
+	usageTracker := usagetracker.New(t.Cfg.UsageTracker, t.Overrides, util_log.Logger, t.Registerer)
+	t.API.RegisterUsageTracker(usageTracker)
+
+	return usageTracker, nil
+}
+
 func (t *Mimir) initOverridesExporter() (services.Service, error) {
 	t.Cfg.OverridesExporter.Ring.Common.ListenPort = t.Cfg.Server.GRPCListenPort
 
@@ -370,7 +386,14 @@ func (t *Mimir) initQueryable() (serv services.Service, err error) {
 	querierRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "querier"}, t.Registerer)
 
 	// Create a querier queryable and PromQL engine
-	t.QuerierQueryable, t.ExemplarQueryable, t.QuerierEngine = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, querierRegisterer, util_log.Logger, t.ActivityTracker)
+	var prometheusEngine *promql.Engine
+	t.QuerierQueryable, t.ExemplarQueryable, prometheusEngine = querier.New(t.Cfg.Querier, t.Overrides, t.Distributor, t.StoreQueryables, querierRegisterer, util_log.Logger, t.ActivityTracker)
+	queryJournal, err := querier.NewQueryJournal(t.Cfg.Querier.QueryJournalFilepath, t.Cfg.Querier.QueryJournalMaxEntries, log.With(util_log.Logger, "component", "query-journal"), t.Registerer)
+	if err != nil {
+		return nil, err
+	}
+	t.QuerierEngine = querier.NewPerTenantEngine(t.Cfg.Querier.EngineConfig, prometheusEngine, t.Overrides, t.ActivityTracker, queryJournal, util_log.Logger, t.Registerer)
+	t.ExemplarQueryable = querier.NewExemplarQueryable(t.ExemplarQueryable, t.StoreExemplarQueryables, t.Cfg.Querier.QueryIngestersWithin, t.Overrides, util_log.Logger)
 
 	// Use the distributor to return metric metadata by default
 	t.MetadataSupplier = t.Distributor
@@ -457,6 +480,7 @@ func (t *Mimir) initQuerier() (serv services.Service, err error) {
 		t.MetadataSupplier,
 		t.QuerierEngine,
 		t.Distributor,
+		t.StoreBlocksCardinality,
 		t.Registerer,
 		util_log.Logger,
 		t.Overrides,
@@ -498,7 +522,7 @@ func (t *Mimir) initQuerier() (serv services.Service, err error) {
 		return nil, nil
 	}
 
-	return querier_worker.NewQuerierWorker(t.Cfg.Worker, httpgrpc_server.NewServer(internalQuerierRouter), util_log.Logger, t.Registerer)
+	return querier_worker.NewQuerierWorker(t.Cfg.Worker, httpgrpc_server.NewServer(internalQuerierRouter), t.Overrides, util_log.Logger, t.Registerer)
 }
 
 func (t *Mimir) initStoreQueryables() (services.Service, error) {
@@ -509,6 +533,8 @@ func (t *Mimir) initStoreQueryables() (services.Service, error) {
 		return nil, fmt.Errorf("failed to initialize querier: %v", err)
 	} else {
 		t.StoreQueryables = append(t.StoreQueryables, querier.UseAlwaysQueryable(q))
+		t.StoreExemplarQueryables = append(t.StoreExemplarQueryables, q)
+		t.StoreBlocksCardinality = q
 		servs = append(servs, q)
 	}
 
@@ -561,6 +587,11 @@ func (t *Mimir) initIngester() (serv services.Service, err error) {
 		ing = ingester.NewIngesterActivityTracker(t.Ingester, t.ActivityTracker)
 	}
 	t.API.RegisterIngester(ing, t.Cfg.Distributor)
+
+	// Serve the read path from a dedicated gRPC server too, if configured.
+	if t.Cfg.Ingester.ReadPathGRPCServer.ListenPort > 0 {
+		return ingester.NewReadPathGRPCServer(t.Cfg.Ingester.ReadPathGRPCServer, t.Cfg.Server, ing, util_log.Logger, t.Registerer)
+	}
 	return nil, nil
 }
 
@@ -587,12 +618,21 @@ func (t *Mimir) initQueryFrontendTripperware() (serv services.Service, err error
 	t.QueryFrontendCodec = querymiddleware.NewPrometheusCodec(t.Registerer, t.Cfg.Frontend.QueryMiddleware.QueryResultResponseFormat)
 	promqlEngineRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "query-frontend"}, t.Registerer)
 
+	// The results cache client is shared by the query middlewares and the rejected queries cache.
+	if t.Cfg.Frontend.QueryMiddleware.ResultsCacheEnabled() || t.Cfg.Frontend.Handler.RejectedQueriesCacheTTL > 0 {
+		t.QueryFrontendCache, err = querymiddleware.NewResultsCache(t.Cfg.Frontend.QueryMiddleware.ResultsCacheConfig, util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tripperware, err := querymiddleware.NewTripperware(
 		t.Cfg.Frontend.QueryMiddleware,
 		util_log.Logger,
 		t.Overrides,
 		t.QueryFrontendCodec,
 		querymiddleware.PrometheusResponseExtractor{},
+		t.QueryFrontendCache,
 		engine.NewPromQLEngineOptions(t.Cfg.Querier.EngineConfig, t.ActivityTracker, util_log.Logger, promqlEngineRegisterer),
 		t.Registerer,
 	)
@@ -615,8 +655,9 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
 
-	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, t.Registerer, t.ActivityTracker)
+	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, t.QueryFrontendCache, util_log.Logger, t.Registerer, t.ActivityTracker)
 	t.API.RegisterQueryFrontendHandler(handler, t.BuildInfoHandler)
+	t.API.RegisterQueryFrontendRejectedQueryHandler(http.HandlerFunc(handler.RejectedQueryHandler))
 
 	var frontendSvc services.Service
 	if frontendV1 != nil {
@@ -790,6 +831,30 @@ func (t *Mimir) initAlertManager() (serv services.Service, err error) {
 func (t *Mimir) initCompactor() (serv services.Service, err error) {
 	t.Cfg.Compactor.ShardingRing.Common.ListenPort = t.Cfg.Server.GRPCListenPort
 
+	if t.Cfg.Compactor.StoreGatewaysNotificationEnabled {
+		t.Cfg.Compactor.BlocksChangedNotifier, err = storegateway.NewBlocksChangedNotifier(t.Cfg.StoreGateway.ShardingRing, t.Cfg.Querier.StoreGatewayClient.TLSEnabled, t.Cfg.Querier.StoreGatewayClient.TLS, t.Overrides, util_log.Logger, t.Registerer)
+		if err != nil {
+			return
+		}
+	}
+
+	// The configurations of the tenants are deleted by the compactor when a tenant is offboarded. The stores
+	// are not instrumented, since they may already be by the ruler and the Alertmanager of the same process.
+	if t.Cfg.RulerStorage.Backend != rulestorelocal.Name {
+		rulesStore, err := ruler.NewRuleStore(context.Background(), t.Cfg.RulerStorage, t.Overrides, rules.FileLoader{}, util_log.Logger, nil)
+		if err != nil {
+			return nil, err
+		}
+		t.Cfg.Compactor.TenantConfigStores = append(t.Cfg.Compactor.TenantConfigStores, ruler.NewTenantRuleGroupsStore(rulesStore))
+	}
+	if t.Cfg.AlertmanagerStorage.Backend != alertstorelocal.Name {
+		alertStore, err := alertstore.NewAlertStore(context.Background(), t.Cfg.AlertmanagerStorage, t.Overrides, util_log.Logger, nil)
+		if err != nil {
+			return nil, err
+		}
+		t.Cfg.Compactor.TenantConfigStores = append(t.Cfg.Compactor.TenantConfigStores, alertmanager.NewTenantAlertmanagerStore(alertStore))
+	}
+
 	t.Compactor, err = compactor.NewMultitenantCompactor(t.Cfg.Compactor, t.Cfg.BlocksStorage, t.Overrides, util_log.Logger, t.Registerer)
 	if err != nil {
 		return
@@ -819,7 +884,7 @@ func (t *Mimir) initMemberlistKV() (services.Service, error) {
 	t.Cfg.MemberlistKV.MetricsRegisterer = reg
 
 	// Append to the list of codecs instead of overwriting the value to allow third parties to inject their own codecs.
-	t.Cfg.MemberlistKV.Codecs = append(t.Cfg.MemberlistKV.Codecs, ring.GetCodec())
+	t.Cfg.MemberlistKV.Codecs = append(t.Cfg.MemberlistKV.Codecs, ring.GetCodec(), distributor.GetUsageCodec())
 
 	dnsProviderReg := prometheus.WrapRegistererWithPrefix(
 		"cortex_",
@@ -848,7 +913,18 @@ func (t *Mimir) initMemberlistKV() (services.Service, error) {
 func (t *Mimir) initQueryScheduler() (services.Service, error) {
 	t.Cfg.QueryScheduler.ServiceDiscovery.SchedulerRing.ListenPort = t.Cfg.Server.GRPCListenPort
 
-	s, err := scheduler.NewScheduler(t.Cfg.QueryScheduler, t.Overrides, util_log.Logger, t.Registerer)
+	// The queue snapshots are stored in the blocks storage bucket, under the prefix reserved to Mimir internals.
+	var bucketClient objstore.Bucket
+	if t.Cfg.QueryScheduler.QueueSnapshots.Enabled() {
+		var err error
+		bucketClient, err = bucket.NewClient(context.Background(), t.Cfg.BlocksStorage.Bucket, QueryScheduler, util_log.Logger, t.Registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, "query-scheduler init")
+		}
+		bucketClient = bucket.NewPrefixedBucketClient(bucketClient, bucket.MimirInternalsPrefix)
+	}
+
+	s, err := scheduler.NewScheduler(t.Cfg.QueryScheduler, t.Overrides, bucketClient, util_log.Logger, t.Registerer)
 	if err != nil {
 		return nil, errors.Wrap(err, "query-scheduler init")
 	}
@@ -895,6 +971,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(Ring, t.initRing, modules.UserInvisibleModule)
 	mm.RegisterModule(Overrides, t.initOverrides, modules.UserInvisibleModule)
 	mm.RegisterModule(OverridesExporter, t.initOverridesExporter)
+	mm.RegisterModule(UsageTracker, t.initUsageTracker)
 	mm.RegisterModule(ActiveGroupsCleanupService, t.initActiveGroupsCleanupService, modules.UserInvisibleModule)
 	mm.RegisterModule(Distributor, t.initDistributor)
 	mm.RegisterModule(DistributorService, t.initDistributorService, modules.UserInvisibleModule)
@@ -929,6 +1006,7 @@ func (t *Mimir) setupModuleManager() error {
 		Ring:                     {API, RuntimeConfig, MemberlistKV, Vault},
 		Overrides:                {RuntimeConfig},
 		OverridesExporter:        {Overrides, MemberlistKV, Vault},
+		UsageTracker:             {API, Overrides},
 		Distributor:              {DistributorService, API, ActiveGroupsCleanupService, Vault},
 		DistributorService:       {Ring, Overrides, Vault},
 		Ingester:                 {IngesterService, API, ActiveGroupsCleanupService, Vault},
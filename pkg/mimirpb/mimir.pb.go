@@ -86,7 +86,7 @@ var MetricMetadata_MetricType_value = map[string]int32{
 }
 
 func (MetricMetadata_MetricType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{5, 0}
+	return fileDescriptor_86d4d7485f544059, []int{6, 0}
 }
 
 type Histogram_ResetHint int32
@@ -113,7 +113,7 @@ var Histogram_ResetHint_value = map[string]int32{
 }
 
 func (Histogram_ResetHint) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{8, 0}
+	return fileDescriptor_86d4d7485f544059, []int{9, 0}
 }
 
 // These values correspond to the possible status values defined in https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go.
@@ -135,7 +135,7 @@ var QueryResponse_Status_value = map[string]int32{
 }
 
 func (QueryResponse_Status) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{15, 0}
+	return fileDescriptor_86d4d7485f544059, []int{16, 0}
 }
 
 // These values correspond to the possible error type values defined in https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go.
@@ -178,7 +178,7 @@ var QueryResponse_ErrorType_value = map[string]int32{
 }
 
 func (QueryResponse_ErrorType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{15, 1}
+	return fileDescriptor_86d4d7485f544059, []int{16, 1}
 }
 
 type WriteRequest struct {
@@ -243,6 +243,10 @@ func (m *WriteRequest) GetSkipLabelNameValidation() bool {
 }
 
 type WriteResponse struct {
+	// Number of series created by the write request. Only set by ingesters.
+	CreatedSeries uint64 `protobuf:"varint,1,opt,name=created_series,json=createdSeries,proto3" json:"created_series,omitempty"`
+	// Estimated ingestion cost of the write request. Only set by distributors when push cost estimation is enabled.
+	Cost *WriteCost `protobuf:"bytes,2,opt,name=cost,proto3" json:"cost,omitempty"`
 }
 
 func (m *WriteResponse) Reset()      { *m = WriteResponse{} }
@@ -277,6 +281,82 @@ func (m *WriteResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_WriteResponse proto.InternalMessageInfo
 
+func (m *WriteResponse) GetCreatedSeries() uint64 {
+	if m != nil {
+		return m.CreatedSeries
+	}
+	return 0
+}
+
+func (m *WriteResponse) GetCost() *WriteCost {
+	if m != nil {
+		return m.Cost
+	}
+	return nil
+}
+
+type WriteCost struct {
+	// Number of samples and histograms accepted by the write request.
+	AcceptedSamples uint64 `protobuf:"varint,1,opt,name=accepted_samples,json=acceptedSamples,proto3" json:"accepted_samples,omitempty"`
+	// Estimated number of series created by the write request.
+	CreatedSeries uint64 `protobuf:"varint,2,opt,name=created_series,json=createdSeries,proto3" json:"created_series,omitempty"`
+	// Abstract cost units of the write request, computed from the number of accepted samples and created series.
+	Units float64 `protobuf:"fixed64,3,opt,name=units,proto3" json:"units,omitempty"`
+}
+
+func (m *WriteCost) Reset()      { *m = WriteCost{} }
+func (*WriteCost) ProtoMessage() {}
+func (*WriteCost) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86d4d7485f544059, []int{2}
+}
+func (m *WriteCost) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WriteCost) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WriteCost.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WriteCost) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteCost.Merge(m, src)
+}
+func (m *WriteCost) XXX_Size() int {
+	return m.Size()
+}
+func (m *WriteCost) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteCost.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteCost proto.InternalMessageInfo
+
+func (m *WriteCost) GetAcceptedSamples() uint64 {
+	if m != nil {
+		return m.AcceptedSamples
+	}
+	return 0
+}
+
+func (m *WriteCost) GetCreatedSeries() uint64 {
+	if m != nil {
+		return m.CreatedSeries
+	}
+	return 0
+}
+
+func (m *WriteCost) GetUnits() float64 {
+	if m != nil {
+		return m.Units
+	}
+	return 0
+}
+
 type TimeSeries struct {
 	Labels []LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=LabelAdapter" json:"labels"`
 	// Sorted by time, oldest sample first.
@@ -288,7 +368,7 @@ type TimeSeries struct {
 func (m *TimeSeries) Reset()      { *m = TimeSeries{} }
 func (*TimeSeries) ProtoMessage() {}
 func (*TimeSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{2}
+	return fileDescriptor_86d4d7485f544059, []int{3}
 }
 func (m *TimeSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -346,7 +426,7 @@ type LabelPair struct {
 func (m *LabelPair) Reset()      { *m = LabelPair{} }
 func (*LabelPair) ProtoMessage() {}
 func (*LabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{3}
+	return fileDescriptor_86d4d7485f544059, []int{4}
 }
 func (m *LabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -398,7 +478,7 @@ type Sample struct {
 func (m *Sample) Reset()      { *m = Sample{} }
 func (*Sample) ProtoMessage() {}
 func (*Sample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{4}
+	return fileDescriptor_86d4d7485f544059, []int{5}
 }
 func (m *Sample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -451,7 +531,7 @@ type MetricMetadata struct {
 func (m *MetricMetadata) Reset()      { *m = MetricMetadata{} }
 func (*MetricMetadata) ProtoMessage() {}
 func (*MetricMetadata) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{5}
+	return fileDescriptor_86d4d7485f544059, []int{6}
 }
 func (m *MetricMetadata) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -515,7 +595,7 @@ type Metric struct {
 func (m *Metric) Reset()      { *m = Metric{} }
 func (*Metric) ProtoMessage() {}
 func (*Metric) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{6}
+	return fileDescriptor_86d4d7485f544059, []int{7}
 }
 func (m *Metric) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -554,7 +634,7 @@ type Exemplar struct {
 func (m *Exemplar) Reset()      { *m = Exemplar{} }
 func (*Exemplar) ProtoMessage() {}
 func (*Exemplar) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{7}
+	return fileDescriptor_86d4d7485f544059, []int{8}
 }
 func (m *Exemplar) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -600,7 +680,6 @@ func (m *Exemplar) GetTimestampMs() int64 {
 // This is based on https://github.com/prometheus/prometheus/blob/main/prompb/types.proto
 type Histogram struct {
 	// Types that are valid to be assigned to Count:
-	//
 	//	*Histogram_CountInt
 	//	*Histogram_CountFloat
 	Count isHistogram_Count `protobuf_oneof:"count"`
@@ -615,7 +694,6 @@ type Histogram struct {
 	Schema        int32   `protobuf:"zigzag32,4,opt,name=schema,proto3" json:"schema,omitempty"`
 	ZeroThreshold float64 `protobuf:"fixed64,5,opt,name=zero_threshold,json=zeroThreshold,proto3" json:"zero_threshold,omitempty"`
 	// Types that are valid to be assigned to ZeroCount:
-	//
 	//	*Histogram_ZeroCountInt
 	//	*Histogram_ZeroCountFloat
 	ZeroCount isHistogram_ZeroCount `protobuf_oneof:"zero_count"`
@@ -641,7 +719,7 @@ type Histogram struct {
 func (m *Histogram) Reset()      { *m = Histogram{} }
 func (*Histogram) ProtoMessage() {}
 func (*Histogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{8}
+	return fileDescriptor_86d4d7485f544059, []int{9}
 }
 func (m *Histogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -848,7 +926,7 @@ type FloatHistogram struct {
 func (m *FloatHistogram) Reset()      { *m = FloatHistogram{} }
 func (*FloatHistogram) ProtoMessage() {}
 func (*FloatHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{9}
+	return fileDescriptor_86d4d7485f544059, []int{10}
 }
 func (m *FloatHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -964,7 +1042,7 @@ type BucketSpan struct {
 func (m *BucketSpan) Reset()      { *m = BucketSpan{} }
 func (*BucketSpan) ProtoMessage() {}
 func (*BucketSpan) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{10}
+	return fileDescriptor_86d4d7485f544059, []int{11}
 }
 func (m *BucketSpan) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1015,7 +1093,7 @@ type FloatHistogramPair struct {
 func (m *FloatHistogramPair) Reset()      { *m = FloatHistogramPair{} }
 func (*FloatHistogramPair) ProtoMessage() {}
 func (*FloatHistogramPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{11}
+	return fileDescriptor_86d4d7485f544059, []int{12}
 }
 func (m *FloatHistogramPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1070,7 +1148,7 @@ type SampleHistogram struct {
 func (m *SampleHistogram) Reset()      { *m = SampleHistogram{} }
 func (*SampleHistogram) ProtoMessage() {}
 func (*SampleHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{12}
+	return fileDescriptor_86d4d7485f544059, []int{13}
 }
 func (m *SampleHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1131,7 +1209,7 @@ type HistogramBucket struct {
 func (m *HistogramBucket) Reset()      { *m = HistogramBucket{} }
 func (*HistogramBucket) ProtoMessage() {}
 func (*HistogramBucket) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{13}
+	return fileDescriptor_86d4d7485f544059, []int{14}
 }
 func (m *HistogramBucket) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1197,7 +1275,7 @@ type SampleHistogramPair struct {
 func (m *SampleHistogramPair) Reset()      { *m = SampleHistogramPair{} }
 func (*SampleHistogramPair) ProtoMessage() {}
 func (*SampleHistogramPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{14}
+	return fileDescriptor_86d4d7485f544059, []int{15}
 }
 func (m *SampleHistogramPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1245,7 +1323,6 @@ type QueryResponse struct {
 	ErrorType QueryResponse_ErrorType `protobuf:"varint,2,opt,name=error_type,json=errorType,proto3,enum=cortexpb.QueryResponse_ErrorType" json:"error_type,omitempty"`
 	Error     string                  `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	// Types that are valid to be assigned to Data:
-	//
 	//	*QueryResponse_String_
 	//	*QueryResponse_Vector
 	//	*QueryResponse_Scalar
@@ -1256,7 +1333,7 @@ type QueryResponse struct {
 func (m *QueryResponse) Reset()      { *m = QueryResponse{} }
 func (*QueryResponse) ProtoMessage() {}
 func (*QueryResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{15}
+	return fileDescriptor_86d4d7485f544059, []int{16}
 }
 func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1384,7 +1461,7 @@ type StringData struct {
 func (m *StringData) Reset()      { *m = StringData{} }
 func (*StringData) ProtoMessage() {}
 func (*StringData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{16}
+	return fileDescriptor_86d4d7485f544059, []int{17}
 }
 func (m *StringData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1435,7 +1512,7 @@ type VectorData struct {
 func (m *VectorData) Reset()      { *m = VectorData{} }
 func (*VectorData) ProtoMessage() {}
 func (*VectorData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{17}
+	return fileDescriptor_86d4d7485f544059, []int{18}
 }
 func (m *VectorData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1488,7 +1565,7 @@ type VectorSample struct {
 func (m *VectorSample) Reset()      { *m = VectorSample{} }
 func (*VectorSample) ProtoMessage() {}
 func (*VectorSample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{18}
+	return fileDescriptor_86d4d7485f544059, []int{19}
 }
 func (m *VectorSample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1548,7 +1625,7 @@ type VectorHistogram struct {
 func (m *VectorHistogram) Reset()      { *m = VectorHistogram{} }
 func (*VectorHistogram) ProtoMessage() {}
 func (*VectorHistogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{19}
+	return fileDescriptor_86d4d7485f544059, []int{20}
 }
 func (m *VectorHistogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1606,7 +1683,7 @@ type ScalarData struct {
 func (m *ScalarData) Reset()      { *m = ScalarData{} }
 func (*ScalarData) ProtoMessage() {}
 func (*ScalarData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{20}
+	return fileDescriptor_86d4d7485f544059, []int{21}
 }
 func (m *ScalarData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1656,7 +1733,7 @@ type MatrixData struct {
 func (m *MatrixData) Reset()      { *m = MatrixData{} }
 func (*MatrixData) ProtoMessage() {}
 func (*MatrixData) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{21}
+	return fileDescriptor_86d4d7485f544059, []int{22}
 }
 func (m *MatrixData) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1702,7 +1779,7 @@ type MatrixSeries struct {
 func (m *MatrixSeries) Reset()      { *m = MatrixSeries{} }
 func (*MatrixSeries) ProtoMessage() {}
 func (*MatrixSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_86d4d7485f544059, []int{22}
+	return fileDescriptor_86d4d7485f544059, []int{23}
 }
 func (m *MatrixSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1760,6 +1837,7 @@ func init() {
 	proto.RegisterEnum("cortexpb.QueryResponse_ErrorType", QueryResponse_ErrorType_name, QueryResponse_ErrorType_value)
 	proto.RegisterType((*WriteRequest)(nil), "cortexpb.WriteRequest")
 	proto.RegisterType((*WriteResponse)(nil), "cortexpb.WriteResponse")
+	proto.RegisterType((*WriteCost)(nil), "cortexpb.WriteCost")
 	proto.RegisterType((*TimeSeries)(nil), "cortexpb.TimeSeries")
 	proto.RegisterType((*LabelPair)(nil), "cortexpb.LabelPair")
 	proto.RegisterType((*Sample)(nil), "cortexpb.Sample")
@@ -1786,117 +1864,121 @@ func init() {
 func init() { proto.RegisterFile("mimir.proto", fileDescriptor_86d4d7485f544059) }
 
 var fileDescriptor_86d4d7485f544059 = []byte{
-	// 1754 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xcf, 0x6f, 0x23, 0x49,
-	0x15, 0x76, 0xd9, 0x1d, 0xdb, 0xfd, 0x62, 0x3b, 0xbd, 0xb5, 0xa3, 0xc1, 0x3b, 0xda, 0x71, 0x32,
-	0x8d, 0x58, 0x02, 0x02, 0x0f, 0x9a, 0x85, 0x59, 0xed, 0x6a, 0x10, 0xb4, 0x9d, 0x9e, 0x49, 0xb2,
-	0x89, 0x1d, 0xca, 0xf6, 0x2c, 0xcb, 0xc5, 0xea, 0x38, 0x95, 0xb8, 0xb5, 0xdd, 0xee, 0xa6, 0x7f,
-	0x0c, 0x13, 0x4e, 0x5c, 0x40, 0x88, 0x13, 0x17, 0x2e, 0x88, 0x1b, 0x07, 0xf8, 0x0b, 0xf8, 0x1b,
-	0x46, 0x42, 0x48, 0x73, 0x5c, 0x71, 0x18, 0x31, 0x99, 0xcb, 0x1e, 0xf7, 0xc0, 0x89, 0x13, 0xaa,
-	0x57, 0xfd, 0xc3, 0xed, 0x24, 0xb0, 0xb0, 0x73, 0xeb, 0x7a, 0xf5, 0xbd, 0x57, 0x5f, 0xbd, 0xfa,
-	0xea, 0xf9, 0x95, 0x61, 0xdd, 0xb5, 0x5d, 0x3b, 0xe8, 0xfa, 0x81, 0x17, 0x79, 0xb4, 0x3e, 0xf3,
-	0x82, 0x88, 0x3f, 0xf5, 0x8f, 0x6f, 0x7d, 0xfb, 0xcc, 0x8e, 0xe6, 0xf1, 0x71, 0x77, 0xe6, 0xb9,
-	0x77, 0xcf, 0xbc, 0x33, 0xef, 0x2e, 0x02, 0x8e, 0xe3, 0x53, 0x1c, 0xe1, 0x00, 0xbf, 0xa4, 0xa3,
-	0xfe, 0x97, 0x32, 0x34, 0x3e, 0x0a, 0xec, 0x88, 0x33, 0xfe, 0xd3, 0x98, 0x87, 0x11, 0x3d, 0x02,
-	0x88, 0x6c, 0x97, 0x87, 0x3c, 0xb0, 0x79, 0xd8, 0x26, 0x5b, 0x95, 0xed, 0xf5, 0x7b, 0x37, 0xba,
-	0x69, 0xf8, 0xee, 0xd8, 0x76, 0xf9, 0x08, 0xe7, 0x7a, 0xb7, 0x9e, 0xbd, 0xd8, 0x2c, 0xfd, 0xfd,
-	0xc5, 0x26, 0x3d, 0x0a, 0xb8, 0xe5, 0x38, 0xde, 0x6c, 0x9c, 0xf9, 0xb1, 0xa5, 0x18, 0xf4, 0x7d,
-	0xa8, 0x8e, 0xbc, 0x38, 0x98, 0xf1, 0x76, 0x79, 0x8b, 0x6c, 0xb7, 0xee, 0xdd, 0xc9, 0xa3, 0x2d,
-	0xaf, 0xdc, 0x95, 0x20, 0x73, 0x11, 0xbb, 0x2c, 0x71, 0xa0, 0x1f, 0x40, 0xdd, 0xe5, 0x91, 0x75,
-	0x62, 0x45, 0x56, 0xbb, 0x82, 0x54, 0xda, 0xb9, 0xf3, 0x21, 0x8f, 0x02, 0x7b, 0x76, 0x98, 0xcc,
-	0xf7, 0x94, 0x67, 0x2f, 0x36, 0x09, 0xcb, 0xf0, 0xf4, 0x01, 0xdc, 0x0a, 0x3f, 0xb1, 0xfd, 0xa9,
-	0x63, 0x1d, 0x73, 0x67, 0xba, 0xb0, 0x5c, 0x3e, 0x7d, 0x62, 0x39, 0xf6, 0x89, 0x15, 0xd9, 0xde,
-	0xa2, 0xfd, 0x59, 0x6d, 0x8b, 0x6c, 0xd7, 0xd9, 0x57, 0x04, 0xe4, 0x40, 0x20, 0x06, 0x96, 0xcb,
-	0x1f, 0x67, 0xf3, 0xfa, 0x26, 0x40, 0xce, 0x87, 0xd6, 0xa0, 0x62, 0x1c, 0xed, 0x69, 0x25, 0x5a,
-	0x07, 0x85, 0x4d, 0x0e, 0x4c, 0x8d, 0xe8, 0x1b, 0xd0, 0x4c, 0xd8, 0x87, 0xbe, 0xb7, 0x08, 0xb9,
-	0xfe, 0x4f, 0x02, 0x90, 0x67, 0x87, 0x1a, 0x50, 0xc5, 0x95, 0xd3, 0x1c, 0xbe, 0x99, 0x13, 0xc7,
-	0xf5, 0x8e, 0x2c, 0x3b, 0xe8, 0xdd, 0x48, 0x52, 0xd8, 0x40, 0x93, 0x71, 0x62, 0xf9, 0x11, 0x0f,
-	0x58, 0xe2, 0x48, 0xbf, 0x03, 0xb5, 0xd0, 0x72, 0x7d, 0x87, 0x87, 0xed, 0x32, 0xc6, 0xd0, 0xf2,
-	0x18, 0x23, 0x9c, 0xc0, 0x4d, 0x97, 0x58, 0x0a, 0xa3, 0xf7, 0x41, 0xe5, 0x4f, 0xb9, 0xeb, 0x3b,
-	0x56, 0x10, 0x26, 0x09, 0xa3, 0xb9, 0x8f, 0x99, 0x4c, 0x25, 0x5e, 0x39, 0x94, 0xbe, 0x0f, 0x30,
-	0xb7, 0xc3, 0xc8, 0x3b, 0x0b, 0x2c, 0x37, 0x6c, 0x2b, 0xab, 0x84, 0x77, 0xd3, 0xb9, 0xc4, 0x73,
-	0x09, 0xac, 0x7f, 0x0f, 0xd4, 0x6c, 0x3f, 0x94, 0x82, 0x22, 0x12, 0xdd, 0x26, 0x5b, 0x64, 0xbb,
-	0xc1, 0xf0, 0x9b, 0xde, 0x80, 0xb5, 0x27, 0x96, 0x13, 0xcb, 0xd3, 0x6f, 0x30, 0x39, 0xd0, 0x0d,
-	0xa8, 0xca, 0x2d, 0xd0, 0x3b, 0xd0, 0x40, 0xb1, 0x44, 0x96, 0xeb, 0x4f, 0xdd, 0x10, 0x61, 0x15,
-	0xb6, 0x9e, 0xd9, 0x0e, 0xc3, 0x3c, 0x84, 0x88, 0x4b, 0xd2, 0x10, 0xbf, 0x2f, 0x43, 0xab, 0xa8,
-	0x01, 0xfa, 0x1e, 0x28, 0xd1, 0xb9, 0x2f, 0x71, 0xad, 0x7b, 0x5f, 0xbd, 0x4e, 0x2b, 0xc9, 0x70,
-	0x7c, 0xee, 0x73, 0x86, 0x0e, 0xf4, 0x5b, 0x40, 0x5d, 0xb4, 0x4d, 0x4f, 0x2d, 0xd7, 0x76, 0xce,
-	0x51, 0x2f, 0x48, 0x45, 0x65, 0x9a, 0x9c, 0x79, 0x88, 0x13, 0x42, 0x26, 0x62, 0x9b, 0x73, 0xee,
-	0xf8, 0x6d, 0x05, 0xe7, 0xf1, 0x5b, 0xd8, 0xe2, 0x85, 0x1d, 0xb5, 0xd7, 0xa4, 0x4d, 0x7c, 0xeb,
-	0xe7, 0x00, 0xf9, 0x4a, 0x74, 0x1d, 0x6a, 0x93, 0xc1, 0x87, 0x83, 0xe1, 0x47, 0x03, 0xad, 0x24,
-	0x06, 0xfd, 0xe1, 0x64, 0x30, 0x36, 0x99, 0x46, 0xa8, 0x0a, 0x6b, 0x8f, 0x8c, 0xc9, 0x23, 0x53,
-	0x2b, 0xd3, 0x26, 0xa8, 0xbb, 0x7b, 0xa3, 0xf1, 0xf0, 0x11, 0x33, 0x0e, 0xb5, 0x0a, 0xa5, 0xd0,
-	0xc2, 0x99, 0xdc, 0xa6, 0x08, 0xd7, 0xd1, 0xe4, 0xf0, 0xd0, 0x60, 0x1f, 0x6b, 0x6b, 0x42, 0x90,
-	0x7b, 0x83, 0x87, 0x43, 0xad, 0x4a, 0x1b, 0x50, 0x1f, 0x8d, 0x8d, 0xb1, 0x39, 0x32, 0xc7, 0x5a,
-	0x4d, 0xff, 0x10, 0xaa, 0x72, 0xe9, 0xd7, 0x20, 0x44, 0xfd, 0x57, 0x04, 0xea, 0xa9, 0x78, 0x5e,
-	0x87, 0xb0, 0x0b, 0x92, 0x48, 0xcf, 0xf3, 0x92, 0x10, 0x2a, 0x97, 0x84, 0xa0, 0xff, 0x75, 0x0d,
-	0xd4, 0x4c, 0x8c, 0xf4, 0x36, 0xa8, 0x33, 0x2f, 0x5e, 0x44, 0x53, 0x7b, 0x11, 0xe1, 0x91, 0x2b,
-	0xbb, 0x25, 0x56, 0x47, 0xd3, 0xde, 0x22, 0xa2, 0x77, 0x60, 0x5d, 0x4e, 0x9f, 0x3a, 0x9e, 0x15,
-	0xc9, 0xb5, 0x76, 0x4b, 0x0c, 0xd0, 0xf8, 0x50, 0xd8, 0xa8, 0x06, 0x95, 0x30, 0x76, 0x71, 0x25,
-	0xc2, 0xc4, 0x27, 0xbd, 0x09, 0xd5, 0x70, 0x36, 0xe7, 0xae, 0x85, 0x87, 0xfb, 0x06, 0x4b, 0x46,
-	0xf4, 0x6b, 0xd0, 0xfa, 0x39, 0x0f, 0xbc, 0x69, 0x34, 0x0f, 0x78, 0x38, 0xf7, 0x9c, 0x13, 0x3c,
-	0x68, 0xc2, 0x9a, 0xc2, 0x3a, 0x4e, 0x8d, 0xf4, 0x9d, 0x04, 0x96, 0xf3, 0xaa, 0x22, 0x2f, 0xc2,
-	0x1a, 0xc2, 0xde, 0x4f, 0xb9, 0x7d, 0x13, 0xb4, 0x25, 0x9c, 0x24, 0x58, 0x43, 0x82, 0x84, 0xb5,
-	0x32, 0xa4, 0x24, 0x69, 0x40, 0x6b, 0xc1, 0xcf, 0xac, 0xc8, 0x7e, 0xc2, 0xa7, 0xa1, 0x6f, 0x2d,
-	0xc2, 0x76, 0x7d, 0xb5, 0x2a, 0xf7, 0xe2, 0xd9, 0x27, 0x3c, 0x1a, 0xf9, 0xd6, 0x22, 0xb9, 0xa1,
-	0xcd, 0xd4, 0x43, 0xd8, 0x42, 0xfa, 0x75, 0xd8, 0xc8, 0x42, 0x9c, 0x70, 0x27, 0xb2, 0xc2, 0xb6,
-	0xba, 0x55, 0xd9, 0xa6, 0x2c, 0x8b, 0xbc, 0x83, 0xd6, 0x02, 0x10, 0xb9, 0x85, 0x6d, 0xd8, 0xaa,
-	0x6c, 0x93, 0x1c, 0x88, 0xc4, 0x44, 0x79, 0x6b, 0xf9, 0x5e, 0x68, 0x2f, 0x91, 0x5a, 0xff, 0xef,
-	0xa4, 0x52, 0x8f, 0x8c, 0x54, 0x16, 0x22, 0x21, 0xd5, 0x90, 0xa4, 0x52, 0x73, 0x4e, 0x2a, 0x03,
-	0x26, 0xa4, 0x9a, 0x92, 0x54, 0x6a, 0x4e, 0x48, 0x3d, 0x00, 0x08, 0x78, 0xc8, 0xa3, 0xe9, 0x5c,
-	0x64, 0xbe, 0x85, 0x45, 0xe0, 0xf6, 0x15, 0x65, 0xac, 0xcb, 0x04, 0x6a, 0xd7, 0x5e, 0x44, 0x4c,
-	0x0d, 0xd2, 0x4f, 0xfa, 0x36, 0xa8, 0x99, 0xd6, 0xda, 0x1b, 0x28, 0xbe, 0xdc, 0xa0, 0x7f, 0x00,
-	0x6a, 0xe6, 0x55, 0xbc, 0xca, 0x35, 0xa8, 0x7c, 0x6c, 0x8e, 0x34, 0x42, 0xab, 0x50, 0x1e, 0x0c,
-	0xb5, 0x72, 0x7e, 0x9d, 0x2b, 0xb7, 0x94, 0x5f, 0xff, 0xb1, 0x43, 0x7a, 0x35, 0x58, 0x43, 0xde,
-	0xbd, 0x06, 0x40, 0x7e, 0xec, 0xfa, 0xdf, 0x14, 0x68, 0xe1, 0x11, 0xe7, 0x92, 0x0e, 0x81, 0xe2,
-	0x1c, 0x0f, 0xa6, 0x2b, 0x3b, 0x69, 0xf6, 0xcc, 0x7f, 0xbd, 0xd8, 0x34, 0x96, 0x7e, 0xdd, 0xfd,
-	0xc0, 0x73, 0x79, 0x34, 0xe7, 0x71, 0xb8, 0xfc, 0xe9, 0x7a, 0x27, 0xdc, 0xb9, 0x9b, 0x15, 0xe8,
-	0x6e, 0x5f, 0x86, 0xcb, 0x77, 0xac, 0xcd, 0x56, 0x2c, 0x5f, 0x56, 0xf3, 0xb7, 0x97, 0x37, 0x25,
-	0x55, 0xcc, 0xd4, 0x4c, 0xc3, 0xe2, 0xb2, 0xcb, 0x99, 0xe4, 0xb2, 0xe3, 0xe0, 0x8a, 0x9b, 0xf7,
-	0x1a, 0x14, 0xf5, 0x1a, 0x6e, 0xca, 0x37, 0x40, 0xcb, 0x58, 0x1c, 0x23, 0x36, 0x15, 0x5b, 0xa6,
-	0x41, 0x19, 0x02, 0xa1, 0xd9, 0x6a, 0x29, 0x54, 0x5e, 0x96, 0xec, 0x0e, 0x25, 0xd0, 0x7d, 0xa5,
-	0x4e, 0xb4, 0xf2, 0xbe, 0x52, 0xaf, 0x6a, 0xb5, 0x7d, 0xa5, 0xae, 0x6a, 0xb0, 0xaf, 0xd4, 0x1b,
-	0x5a, 0x73, 0x5f, 0xa9, 0x6f, 0x68, 0x1a, 0xcb, 0xab, 0x18, 0x5b, 0xa9, 0x1e, 0x6c, 0xf5, 0xda,
-	0xb2, 0xd5, 0x2b, 0xb3, 0x2c, 0xd1, 0x07, 0x00, 0xf9, 0xf6, 0xc4, 0xa9, 0x7a, 0xa7, 0xa7, 0x21,
-	0x97, 0xa5, 0xf1, 0x0d, 0x96, 0x8c, 0x84, 0xdd, 0xe1, 0x8b, 0xb3, 0x68, 0x8e, 0x07, 0xd2, 0x64,
-	0xc9, 0x48, 0x8f, 0x81, 0x16, 0xc5, 0x88, 0xbf, 0xe8, 0x0f, 0x40, 0xcd, 0xb4, 0x84, 0x81, 0x0a,
-	0x2d, 0x58, 0xd1, 0x21, 0xed, 0x2b, 0x32, 0x87, 0x2f, 0xf0, 0xdb, 0xae, 0x2f, 0x60, 0x43, 0x36,
-	0x02, 0xf9, 0x25, 0xc8, 0x14, 0x43, 0xae, 0x50, 0x4c, 0x39, 0x57, 0xcc, 0xbb, 0x50, 0x4b, 0xf3,
-	0x2e, 0x7b, 0x9d, 0xb7, 0xae, 0x6a, 0x59, 0x10, 0xc1, 0x52, 0xa4, 0x1e, 0xc2, 0xc6, 0xca, 0x1c,
-	0xed, 0x00, 0x1c, 0x7b, 0xf1, 0xe2, 0xc4, 0x4a, 0x5a, 0x5e, 0xb2, 0xbd, 0xc6, 0x96, 0x2c, 0x82,
-	0x8f, 0xe3, 0xfd, 0x8c, 0x07, 0xa9, 0x82, 0x71, 0x20, 0xac, 0xb1, 0xef, 0xf3, 0x20, 0xd1, 0xb0,
-	0x1c, 0xe4, 0xdc, 0x95, 0x25, 0xee, 0xba, 0x03, 0x6f, 0xae, 0x6c, 0x12, 0x93, 0x5b, 0xa8, 0x38,
-	0xe5, 0x95, 0x8a, 0x43, 0xdf, 0xbb, 0x9c, 0xfa, 0xb7, 0x56, 0x1b, 0xc0, 0x2c, 0xde, 0x52, 0xd6,
-	0xf5, 0x3f, 0x29, 0xd0, 0xfc, 0x51, 0xcc, 0x83, 0xf3, 0xb4, 0x37, 0xa5, 0xf7, 0xa1, 0x1a, 0x46,
-	0x56, 0x14, 0x87, 0x49, 0x67, 0xd4, 0xc9, 0xe3, 0x14, 0x80, 0xdd, 0x11, 0xa2, 0x58, 0x82, 0xa6,
-	0x3f, 0x04, 0xe0, 0x41, 0xe0, 0x05, 0x53, 0xec, 0xaa, 0x2e, 0xb5, 0xef, 0x45, 0x5f, 0x53, 0x20,
-	0xb1, 0xa7, 0x52, 0x79, 0xfa, 0x29, 0xf2, 0x81, 0x03, 0xcc, 0x92, 0xca, 0xe4, 0x80, 0x76, 0x05,
-	0x9f, 0xc0, 0x5e, 0x9c, 0x61, 0x9a, 0x0a, 0x17, 0x74, 0x84, 0xf6, 0x1d, 0x2b, 0xb2, 0x76, 0x4b,
-	0x2c, 0x41, 0x09, 0xfc, 0x13, 0x3e, 0x8b, 0xbc, 0x00, 0x2b, 0x50, 0x01, 0xff, 0x18, 0xed, 0x29,
-	0x5e, 0xa2, 0x30, 0xfe, 0xcc, 0x72, 0xac, 0x00, 0x7f, 0x7e, 0x8b, 0xf1, 0xd1, 0x9e, 0xc5, 0xc7,
-	0x91, 0xc0, 0xbb, 0x56, 0x14, 0xd8, 0x4f, 0xb1, 0x7c, 0x15, 0xf0, 0x87, 0x68, 0x4f, 0xf1, 0x12,
-	0xa5, 0xbf, 0x03, 0x55, 0x99, 0x29, 0x51, 0xeb, 0x4d, 0xc6, 0x86, 0x4c, 0xb6, 0x74, 0xa3, 0x49,
-	0xbf, 0x6f, 0x8e, 0x46, 0x1a, 0x91, 0x85, 0x5f, 0xff, 0x1d, 0x01, 0x35, 0x4b, 0x8b, 0xe8, 0xd5,
-	0x06, 0xc3, 0x81, 0x29, 0xa1, 0xe3, 0xbd, 0x43, 0x73, 0x38, 0x19, 0x6b, 0x44, 0x34, 0x6e, 0x7d,
-	0x63, 0xd0, 0x37, 0x0f, 0xcc, 0x1d, 0xd9, 0x00, 0x9a, 0x3f, 0x36, 0xfb, 0x93, 0xf1, 0xde, 0x70,
-	0xa0, 0x55, 0xc4, 0x64, 0xcf, 0xd8, 0x99, 0xee, 0x18, 0x63, 0x43, 0x53, 0xc4, 0x68, 0x4f, 0xf4,
-	0x8c, 0x03, 0xe3, 0x40, 0x5b, 0xa3, 0x1b, 0xb0, 0x3e, 0x19, 0x18, 0x8f, 0x8d, 0xbd, 0x03, 0xa3,
-	0x77, 0x60, 0x6a, 0x55, 0xe1, 0x3b, 0x18, 0x8e, 0xa7, 0x0f, 0x87, 0x93, 0xc1, 0x8e, 0x56, 0x13,
-	0xcd, 0xa3, 0x18, 0x1a, 0xfd, 0xbe, 0x79, 0x34, 0x46, 0x48, 0x3d, 0xf9, 0x41, 0xaa, 0x82, 0x22,
-	0xfa, 0x60, 0xdd, 0x04, 0xc8, 0xf3, 0x5d, 0x6c, 0xb3, 0xd5, 0xeb, 0xda, 0xb2, 0x2b, 0xee, 0xf0,
-	0x2f, 0x09, 0x40, 0x7e, 0x0e, 0xf4, 0x7e, 0xfe, 0x6e, 0x91, 0x2d, 0xe2, 0xcd, 0xd5, 0xe3, 0xba,
-	0xfa, 0xf5, 0xf2, 0x83, 0xc2, 0x2b, 0xa4, 0xbc, 0x7a, 0xa5, 0xa5, 0xeb, 0x7f, 0x7a, 0x8b, 0x4c,
-	0xa1, 0xb1, 0x1c, 0x5f, 0x94, 0x3a, 0xd9, 0xbb, 0x23, 0x0f, 0x95, 0x25, 0xa3, 0xff, 0xbf, 0xff,
-	0xfc, 0x0d, 0x81, 0x8d, 0x15, 0x1a, 0xd7, 0x2e, 0x52, 0xa8, 0x9c, 0xe5, 0x2f, 0x5b, 0x39, 0xaf,
-	0x20, 0x23, 0x0e, 0x2f, 0x13, 0xf3, 0xd5, 0x6f, 0xa4, 0x2f, 0x72, 0x78, 0x3d, 0x80, 0x5c, 0xe3,
-	0xf4, 0xbb, 0x50, 0x2d, 0x3c, 0xfd, 0x6f, 0xae, 0xde, 0x84, 0xe4, 0xf1, 0x2f, 0x09, 0x27, 0x58,
-	0xfd, 0x0f, 0x04, 0x1a, 0xcb, 0xd3, 0xd7, 0x26, 0xe5, 0x7f, 0x7f, 0xd2, 0xf6, 0x0a, 0xa2, 0x90,
-	0x75, 0xfe, 0xed, 0xeb, 0xf2, 0x88, 0x6f, 0x8f, 0x4b, 0xba, 0xe8, 0x7d, 0xff, 0xf9, 0xcb, 0x4e,
-	0xe9, 0xd3, 0x97, 0x9d, 0xd2, 0xe7, 0x2f, 0x3b, 0xe4, 0x17, 0x17, 0x1d, 0xf2, 0xe7, 0x8b, 0x0e,
-	0x79, 0x76, 0xd1, 0x21, 0xcf, 0x2f, 0x3a, 0xe4, 0x1f, 0x17, 0x1d, 0xf2, 0xd9, 0x45, 0xa7, 0xf4,
-	0xf9, 0x45, 0x87, 0xfc, 0xf6, 0x55, 0xa7, 0xf4, 0xfc, 0x55, 0xa7, 0xf4, 0xe9, 0xab, 0x4e, 0xe9,
-	0x27, 0x35, 0xfc, 0x83, 0xc5, 0x3f, 0x3e, 0xae, 0xe2, 0x5f, 0x25, 0xef, 0xfe, 0x3b, 0x00, 0x00,
-	0xff, 0xff, 0xec, 0xdb, 0xa5, 0x25, 0x72, 0x11, 0x00, 0x00,
+	// 1820 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xcf, 0x8f, 0xe3, 0x48,
+	0xf5, 0x4f, 0x25, 0xce, 0x0f, 0xbf, 0x4e, 0xd2, 0xde, 0xda, 0xd1, 0x7c, 0xb3, 0xa3, 0x9d, 0x74,
+	0x8f, 0xbf, 0x62, 0xe9, 0x45, 0x90, 0x41, 0xb3, 0x30, 0xab, 0x5d, 0x0d, 0x02, 0x27, 0xed, 0x99,
+	0xee, 0xde, 0xee, 0xa4, 0xa9, 0x24, 0xb3, 0x2c, 0x97, 0xc8, 0x9d, 0xae, 0xee, 0x58, 0x6b, 0xc7,
+	0xc6, 0xae, 0x0c, 0xd3, 0x9c, 0xb8, 0x80, 0x10, 0x27, 0x2e, 0x5c, 0x10, 0x37, 0x0e, 0xf0, 0x17,
+	0xf0, 0x37, 0x8c, 0x84, 0x90, 0xe6, 0xb8, 0xe2, 0x30, 0x62, 0x7a, 0x2e, 0x7b, 0xdc, 0x03, 0x27,
+	0x4e, 0xa8, 0xaa, 0xec, 0x72, 0xe2, 0x4e, 0xc3, 0xc2, 0xce, 0xcd, 0xf5, 0xea, 0xf3, 0x9e, 0x3f,
+	0x7e, 0xf5, 0x79, 0x2f, 0xaf, 0x02, 0x1b, 0xbe, 0xeb, 0xbb, 0x51, 0x27, 0x8c, 0x02, 0x16, 0xe0,
+	0xda, 0x34, 0x88, 0x18, 0x7d, 0x1a, 0x9e, 0xdc, 0xfa, 0xd6, 0xb9, 0xcb, 0x66, 0x8b, 0x93, 0xce,
+	0x34, 0xf0, 0xef, 0x9e, 0x07, 0xe7, 0xc1, 0x5d, 0x01, 0x38, 0x59, 0x9c, 0x89, 0x95, 0x58, 0x88,
+	0x27, 0xe9, 0x68, 0xfe, 0xb9, 0x08, 0xf5, 0x8f, 0x23, 0x97, 0x51, 0x42, 0x7f, 0xb2, 0xa0, 0x31,
+	0xc3, 0xc7, 0x00, 0xcc, 0xf5, 0x69, 0x4c, 0x23, 0x97, 0xc6, 0x2d, 0xb4, 0x5d, 0xda, 0xd9, 0xb8,
+	0x77, 0xa3, 0x93, 0x86, 0xef, 0x8c, 0x5c, 0x9f, 0x0e, 0xc5, 0x5e, 0xf7, 0xd6, 0xb3, 0x17, 0x5b,
+	0x85, 0xbf, 0xbd, 0xd8, 0xc2, 0xc7, 0x11, 0x75, 0x3c, 0x2f, 0x98, 0x8e, 0x94, 0x1f, 0x59, 0x8a,
+	0x81, 0x3f, 0x80, 0xca, 0x30, 0x58, 0x44, 0x53, 0xda, 0x2a, 0x6e, 0xa3, 0x9d, 0xe6, 0xbd, 0x3b,
+	0x59, 0xb4, 0xe5, 0x37, 0x77, 0x24, 0xc8, 0x9e, 0x2f, 0x7c, 0x92, 0x38, 0xe0, 0x0f, 0xa1, 0xe6,
+	0x53, 0xe6, 0x9c, 0x3a, 0xcc, 0x69, 0x95, 0x04, 0x95, 0x56, 0xe6, 0x7c, 0x44, 0x59, 0xe4, 0x4e,
+	0x8f, 0x92, 0xfd, 0xae, 0xf6, 0xec, 0xc5, 0x16, 0x22, 0x0a, 0x8f, 0x1f, 0xc0, 0xad, 0xf8, 0x53,
+	0x37, 0x9c, 0x78, 0xce, 0x09, 0xf5, 0x26, 0x73, 0xc7, 0xa7, 0x93, 0x27, 0x8e, 0xe7, 0x9e, 0x3a,
+	0xcc, 0x0d, 0xe6, 0xad, 0xcf, 0xab, 0xdb, 0x68, 0xa7, 0x46, 0xfe, 0x8f, 0x43, 0x0e, 0x39, 0xa2,
+	0xef, 0xf8, 0xf4, 0xb1, 0xda, 0x37, 0xb7, 0x00, 0x32, 0x3e, 0xb8, 0x0a, 0x25, 0xeb, 0x78, 0xdf,
+	0x28, 0xe0, 0x1a, 0x68, 0x64, 0x7c, 0x68, 0x1b, 0xc8, 0x9c, 0x40, 0x23, 0x61, 0x1f, 0x87, 0xc1,
+	0x3c, 0xa6, 0xf8, 0x6b, 0xd0, 0x9c, 0x46, 0xd4, 0x61, 0xf4, 0x74, 0xa2, 0x92, 0x87, 0x76, 0x34,
+	0xd2, 0x48, 0xac, 0x32, 0x6b, 0xf8, 0xeb, 0xa0, 0x4d, 0x83, 0x98, 0x89, 0x5c, 0x6c, 0xdc, 0x7b,
+	0x33, 0x97, 0x8b, 0x5e, 0x10, 0x33, 0x22, 0x00, 0x66, 0x0c, 0xba, 0x32, 0xe1, 0x77, 0xc1, 0x70,
+	0xa6, 0x53, 0x1a, 0x8a, 0xe8, 0x8e, 0x1f, 0x7a, 0x2a, 0xfc, 0x66, 0x6a, 0x1f, 0x4a, 0xf3, 0x1a,
+	0x1e, 0xc5, 0x75, 0x3c, 0x6e, 0x40, 0x79, 0x31, 0x77, 0x59, 0xdc, 0x2a, 0x6d, 0xa3, 0x1d, 0x44,
+	0xe4, 0xc2, 0xfc, 0x07, 0x02, 0xc8, 0x8e, 0x18, 0x5b, 0x50, 0x11, 0xe9, 0x4b, 0x85, 0xb0, 0x44,
+	0x57, 0x24, 0xed, 0xd8, 0x71, 0xa3, 0xee, 0x8d, 0x44, 0x07, 0x75, 0x61, 0xb2, 0x4e, 0x9d, 0x90,
+	0xd1, 0x88, 0x24, 0x8e, 0xf8, 0xdb, 0x50, 0x4d, 0x09, 0x17, 0x45, 0x0c, 0x23, 0x8b, 0x21, 0x29,
+	0x8b, 0x93, 0x2b, 0x90, 0x14, 0x86, 0xef, 0x83, 0x4e, 0x9f, 0x52, 0x3f, 0xf4, 0x9c, 0x28, 0x4e,
+	0x4e, 0x1d, 0x67, 0x3e, 0x76, 0xb2, 0x95, 0x78, 0x65, 0x50, 0xfc, 0x01, 0xc0, 0xcc, 0x8d, 0x59,
+	0x70, 0x1e, 0x39, 0x7e, 0xdc, 0xd2, 0xf2, 0x84, 0xf7, 0xd2, 0xbd, 0xc4, 0x73, 0x09, 0x6c, 0x7e,
+	0x17, 0x74, 0xf5, 0x3d, 0x18, 0x83, 0xc6, 0xd5, 0x22, 0xf2, 0x5b, 0x27, 0xe2, 0x99, 0x67, 0xeb,
+	0x89, 0xe3, 0x2d, 0xa4, 0x84, 0xeb, 0x44, 0x2e, 0x4c, 0x0b, 0x2a, 0xf2, 0x13, 0xf0, 0x1d, 0xa8,
+	0x0b, 0xc5, 0x33, 0xc7, 0x0f, 0x27, 0xbe, 0x4c, 0x79, 0x89, 0x6c, 0x28, 0xdb, 0x51, 0x9c, 0x85,
+	0x40, 0x32, 0xe1, 0x32, 0xc4, 0xef, 0x8a, 0xd0, 0x5c, 0x15, 0x32, 0x7e, 0x1f, 0x34, 0x76, 0x11,
+	0x4a, 0x5c, 0xf3, 0xde, 0xff, 0x5f, 0x27, 0xf8, 0x64, 0x39, 0xba, 0x08, 0x29, 0x11, 0x0e, 0xf8,
+	0x9b, 0x80, 0x7d, 0x61, 0x9b, 0x9c, 0x39, 0xbe, 0xeb, 0x5d, 0x08, 0xd1, 0x0b, 0x2a, 0x3a, 0x31,
+	0xe4, 0xce, 0x43, 0xb1, 0xc1, 0xb5, 0xce, 0x3f, 0x73, 0x46, 0xbd, 0xb0, 0xa5, 0x89, 0x7d, 0xf1,
+	0xcc, 0x6d, 0x5c, 0x07, 0xad, 0xb2, 0xb4, 0xf1, 0x67, 0xf3, 0x02, 0x20, 0x7b, 0x13, 0xde, 0x80,
+	0xea, 0xb8, 0xff, 0x51, 0x7f, 0xf0, 0x71, 0xdf, 0x28, 0xf0, 0x45, 0x6f, 0x30, 0xee, 0x8f, 0x6c,
+	0x62, 0x20, 0xac, 0x43, 0xf9, 0x91, 0x35, 0x7e, 0x64, 0x1b, 0x45, 0xdc, 0x00, 0x7d, 0x6f, 0x7f,
+	0x38, 0x1a, 0x3c, 0x22, 0xd6, 0x91, 0x51, 0xc2, 0x18, 0x9a, 0x62, 0x27, 0xb3, 0x69, 0xdc, 0x75,
+	0x38, 0x3e, 0x3a, 0xb2, 0xc8, 0x27, 0x46, 0x99, 0x57, 0xd5, 0x7e, 0xff, 0xe1, 0xc0, 0xa8, 0xe0,
+	0x3a, 0xd4, 0x86, 0x23, 0x6b, 0x64, 0x0f, 0xed, 0x91, 0x51, 0x35, 0x3f, 0x82, 0x8a, 0x7c, 0xf5,
+	0x6b, 0x10, 0xa2, 0xf9, 0x4b, 0x04, 0xb5, 0x54, 0x3c, 0xaf, 0x43, 0xd8, 0x2b, 0x92, 0x48, 0xcf,
+	0xf3, 0x8a, 0x10, 0x4a, 0x57, 0x84, 0x60, 0xfe, 0xa5, 0x0c, 0xba, 0x12, 0x23, 0xbe, 0x0d, 0xfa,
+	0x34, 0x58, 0xcc, 0xd9, 0xc4, 0x9d, 0x33, 0x59, 0xd2, 0x7b, 0x05, 0x52, 0x13, 0xa6, 0xfd, 0x39,
+	0xc3, 0x77, 0x60, 0x43, 0x6e, 0x9f, 0x79, 0x81, 0x23, 0xbb, 0x06, 0xda, 0x2b, 0x10, 0x10, 0xc6,
+	0x87, 0xdc, 0x86, 0x0d, 0x28, 0xc5, 0x0b, 0x3f, 0xa9, 0x63, 0xfe, 0x88, 0x6f, 0x42, 0x25, 0x9e,
+	0xce, 0xa8, 0xef, 0x88, 0xc3, 0x7d, 0x83, 0x24, 0x2b, 0xde, 0x1a, 0x7e, 0x46, 0xa3, 0x60, 0xc2,
+	0x66, 0x11, 0x8d, 0x67, 0x81, 0x77, 0x2a, 0x0e, 0x1a, 0x91, 0x06, 0xb7, 0x8e, 0x52, 0x23, 0x7e,
+	0x27, 0x81, 0x65, 0xbc, 0x2a, 0x82, 0x17, 0x22, 0x75, 0x6e, 0xef, 0xa5, 0xdc, 0xbe, 0x01, 0xc6,
+	0x12, 0x4e, 0x12, 0xac, 0x0a, 0x82, 0x88, 0x34, 0x15, 0x52, 0x92, 0xb4, 0xa0, 0x39, 0xa7, 0xe7,
+	0x0e, 0x73, 0x9f, 0xd0, 0x49, 0x1c, 0x3a, 0xf3, 0xb8, 0x55, 0xcb, 0xff, 0xb4, 0x74, 0x17, 0xd3,
+	0x4f, 0x29, 0x1b, 0x86, 0xce, 0x3c, 0xa9, 0xd0, 0x46, 0xea, 0xc1, 0x6d, 0xbc, 0x73, 0x6e, 0xaa,
+	0x10, 0xa7, 0xd4, 0x63, 0x4e, 0xdc, 0xd2, 0xb7, 0x4b, 0x3b, 0x98, 0xa8, 0xc8, 0xbb, 0xc2, 0xba,
+	0x02, 0x14, 0xdc, 0xe2, 0x16, 0x6c, 0x97, 0x76, 0x50, 0x06, 0x14, 0xc4, 0x78, 0x7b, 0x6b, 0x86,
+	0x41, 0xec, 0x2e, 0x91, 0xda, 0xf8, 0xcf, 0xa4, 0x52, 0x0f, 0x45, 0x4a, 0x85, 0x48, 0x48, 0xd5,
+	0x25, 0xa9, 0xd4, 0x9c, 0x91, 0x52, 0xc0, 0x84, 0x54, 0x43, 0x92, 0x4a, 0xcd, 0x09, 0xa9, 0x07,
+	0x00, 0x11, 0x8d, 0x29, 0x9b, 0xcc, 0x78, 0xe6, 0x9b, 0xa2, 0x09, 0xdc, 0x5e, 0xd3, 0xc6, 0x3a,
+	0x84, 0xa3, 0xf6, 0xdc, 0x39, 0x23, 0x7a, 0x94, 0x3e, 0xe2, 0xb7, 0x41, 0x57, 0x5a, 0x6b, 0x6d,
+	0x0a, 0xf1, 0x65, 0x06, 0xf3, 0x43, 0xd0, 0x95, 0xd7, 0x6a, 0x29, 0x57, 0xa1, 0xf4, 0x89, 0x3d,
+	0x34, 0x10, 0xae, 0x40, 0xb1, 0x3f, 0x30, 0x8a, 0x59, 0x39, 0x97, 0x6e, 0x69, 0xbf, 0xfa, 0x43,
+	0x1b, 0x75, 0xab, 0x50, 0x16, 0xbc, 0xbb, 0x75, 0x80, 0xec, 0xd8, 0xcd, 0xbf, 0x6a, 0xd0, 0x14,
+	0x47, 0x9c, 0x49, 0x3a, 0x06, 0x2c, 0xf6, 0x68, 0x34, 0xc9, 0x7d, 0x49, 0xa3, 0x6b, 0xff, 0xf3,
+	0xc5, 0x96, 0xb5, 0x34, 0xa2, 0x84, 0x51, 0xe0, 0x53, 0x36, 0xa3, 0x8b, 0x78, 0xf9, 0xd1, 0x0f,
+	0x4e, 0xa9, 0x77, 0x57, 0x35, 0xe8, 0x4e, 0x4f, 0x86, 0xcb, 0xbe, 0xd8, 0x98, 0xe6, 0x2c, 0x5f,
+	0x55, 0xf3, 0xb7, 0x97, 0x3f, 0x4a, 0xaa, 0x98, 0xe8, 0x4a, 0xc3, 0xbc, 0xd8, 0xe5, 0x4e, 0x52,
+	0xec, 0x62, 0xb1, 0xa6, 0xf2, 0x5e, 0x83, 0xa2, 0x5e, 0x43, 0xa5, 0xbc, 0x0b, 0x86, 0x62, 0x71,
+	0x22, 0xb0, 0xa9, 0xd8, 0x94, 0x06, 0x65, 0x08, 0x01, 0x55, 0x6f, 0x4b, 0xa1, 0xb2, 0x58, 0x54,
+	0x0d, 0x25, 0xd0, 0x03, 0xad, 0x86, 0x8c, 0xe2, 0x81, 0x56, 0xab, 0x18, 0xd5, 0x03, 0xad, 0xa6,
+	0x1b, 0x70, 0xa0, 0xd5, 0xea, 0x46, 0xe3, 0x40, 0xab, 0x6d, 0x1a, 0x06, 0xc9, 0xba, 0x18, 0xc9,
+	0x75, 0x0f, 0x92, 0x2f, 0x5b, 0x92, 0x2f, 0x99, 0x65, 0x89, 0x3e, 0x00, 0xc8, 0x3e, 0x8f, 0x9f,
+	0x6a, 0x70, 0x76, 0x16, 0x53, 0xd9, 0x1a, 0xdf, 0x20, 0xc9, 0x8a, 0xdb, 0x3d, 0x3a, 0x3f, 0x67,
+	0x33, 0x71, 0x20, 0x0d, 0x92, 0xac, 0xcc, 0x05, 0xe0, 0x55, 0x31, 0x8a, 0x5f, 0xf4, 0x07, 0xa0,
+	0x2b, 0x2d, 0x89, 0x40, 0x2b, 0x73, 0xe4, 0xaa, 0x43, 0x3a, 0x57, 0x28, 0x87, 0x2f, 0xf1, 0xdb,
+	0x6e, 0xce, 0x61, 0x53, 0x0e, 0x02, 0x59, 0x11, 0x28, 0xc5, 0xa0, 0x35, 0x8a, 0x29, 0x66, 0x8a,
+	0x79, 0x0f, 0xaa, 0x69, 0xde, 0xe5, 0xac, 0xf3, 0xd6, 0xba, 0x91, 0x45, 0x20, 0x48, 0x8a, 0x34,
+	0x63, 0xd8, 0xcc, 0xed, 0xe1, 0x36, 0xc0, 0x49, 0xb0, 0x98, 0x9f, 0x3a, 0x6a, 0xf4, 0x2c, 0x93,
+	0x25, 0x0b, 0xe7, 0xe3, 0x05, 0x3f, 0xa5, 0x51, 0xaa, 0x60, 0xb1, 0xe0, 0xd6, 0x45, 0x18, 0xd2,
+	0x48, 0x4d, 0x81, 0x61, 0x28, 0xad, 0x92, 0xbb, 0xb6, 0xc4, 0xdd, 0xf4, 0xe0, 0xcd, 0xdc, 0x47,
+	0x8a, 0xe4, 0xae, 0x74, 0x9c, 0x62, 0xae, 0xe3, 0xe0, 0xf7, 0xaf, 0xa6, 0xfe, 0xad, 0xfc, 0x00,
+	0xa8, 0xe2, 0x2d, 0x65, 0xdd, 0xfc, 0xa3, 0x06, 0x8d, 0x1f, 0x2e, 0x68, 0x74, 0xa1, 0x06, 0xec,
+	0xfb, 0x50, 0x89, 0x99, 0xc3, 0x16, 0x71, 0x32, 0x19, 0xb5, 0xb3, 0x38, 0x2b, 0xc0, 0xce, 0x50,
+	0xa0, 0x48, 0x82, 0xc6, 0x3f, 0x00, 0xa0, 0x51, 0x14, 0x44, 0x13, 0x31, 0x55, 0x5d, 0xb9, 0x83,
+	0xac, 0xfa, 0xda, 0x1c, 0x29, 0x66, 0x2a, 0x9d, 0xa6, 0x8f, 0x3c, 0x1f, 0x62, 0x21, 0xb2, 0xa4,
+	0x13, 0xb9, 0xc0, 0x1d, 0xce, 0x27, 0x72, 0xe7, 0xe7, 0x22, 0x4d, 0x2b, 0x05, 0x3a, 0x14, 0xf6,
+	0x5d, 0x87, 0x39, 0x7b, 0x05, 0x92, 0xa0, 0x38, 0xfe, 0x09, 0x9d, 0xb2, 0x20, 0x6a, 0x95, 0xf3,
+	0xf8, 0xc7, 0xc2, 0x9e, 0xe2, 0x25, 0x4a, 0xc4, 0x9f, 0x3a, 0x9e, 0x13, 0xb5, 0x2a, 0x79, 0xfc,
+	0x50, 0xd8, 0x55, 0x7c, 0xb1, 0xe2, 0x78, 0xdf, 0x61, 0x91, 0xfb, 0xb4, 0x55, 0xcd, 0xe3, 0x8f,
+	0x84, 0x3d, 0xc5, 0x4b, 0x94, 0xf9, 0x0e, 0x54, 0x64, 0xa6, 0x78, 0xaf, 0xb7, 0x09, 0x19, 0x10,
+	0x39, 0xd2, 0x0d, 0xc7, 0xbd, 0x9e, 0x3d, 0x1c, 0x1a, 0x48, 0x36, 0x7e, 0xf3, 0xb7, 0x08, 0x74,
+	0x95, 0x16, 0x3e, 0xab, 0xf5, 0x07, 0x7d, 0x5b, 0x42, 0x47, 0xfb, 0x47, 0xf6, 0x60, 0x3c, 0x32,
+	0x10, 0x1f, 0xdc, 0x7a, 0x56, 0xbf, 0x67, 0x1f, 0xda, 0xbb, 0x72, 0x00, 0xb4, 0x7f, 0x64, 0xf7,
+	0xc6, 0xa3, 0xfd, 0x41, 0xdf, 0x28, 0xf1, 0xcd, 0xae, 0xb5, 0x3b, 0xd9, 0xb5, 0x46, 0x96, 0xa1,
+	0xf1, 0xd5, 0x3e, 0x9f, 0x19, 0xfb, 0xd6, 0xa1, 0x51, 0xc6, 0x9b, 0xb0, 0x31, 0xee, 0x5b, 0x8f,
+	0xad, 0xfd, 0x43, 0xab, 0x7b, 0x68, 0x1b, 0x15, 0xee, 0xdb, 0x1f, 0x8c, 0x26, 0x0f, 0x07, 0xe3,
+	0xfe, 0xae, 0x51, 0xe5, 0xc3, 0x23, 0x5f, 0x5a, 0xbd, 0x9e, 0x7d, 0x3c, 0x12, 0x90, 0x5a, 0xf2,
+	0x83, 0x54, 0x01, 0x8d, 0xcf, 0xc1, 0xa6, 0x0d, 0x90, 0xe5, 0x7b, 0x75, 0xcc, 0xd6, 0xaf, 0x1b,
+	0xcb, 0xd6, 0xd4, 0xf0, 0x2f, 0x10, 0x40, 0x76, 0x0e, 0xf8, 0x7e, 0x76, 0x6f, 0x91, 0x23, 0xe2,
+	0xcd, 0xfc, 0x71, 0xad, 0xbf, 0xbd, 0x7c, 0x7f, 0xe5, 0x16, 0x52, 0xcc, 0x97, 0xb4, 0x74, 0xfd,
+	0x77, 0x77, 0x91, 0x09, 0xd4, 0x97, 0xe3, 0xf3, 0x56, 0x27, 0x67, 0x77, 0xc1, 0x43, 0x27, 0xc9,
+	0xea, 0x7f, 0x9f, 0x3f, 0x7f, 0x8d, 0x60, 0x33, 0x47, 0xe3, 0xda, 0x97, 0xac, 0x74, 0xce, 0xe2,
+	0x57, 0xed, 0x9c, 0x6b, 0xc8, 0xf0, 0xc3, 0x53, 0x62, 0x5e, 0x7f, 0x47, 0xfa, 0x32, 0x87, 0xd7,
+	0x05, 0xc8, 0x34, 0x8e, 0xbf, 0x03, 0x95, 0x95, 0xff, 0x2f, 0x6e, 0xe6, 0x2b, 0x21, 0xf9, 0x07,
+	0x43, 0x12, 0x4e, 0xb0, 0xe6, 0xef, 0x11, 0xd4, 0x97, 0xb7, 0xaf, 0x4d, 0xca, 0x7f, 0x7f, 0xa5,
+	0xed, 0xae, 0x88, 0x42, 0xf6, 0xf9, 0xb7, 0xaf, 0xcb, 0xa3, 0xb8, 0x7b, 0x5c, 0xd1, 0x45, 0xf7,
+	0x7b, 0xcf, 0x5f, 0xb6, 0x0b, 0x9f, 0xbd, 0x6c, 0x17, 0xbe, 0x78, 0xd9, 0x46, 0x3f, 0xbf, 0x6c,
+	0xa3, 0x3f, 0x5d, 0xb6, 0xd1, 0xb3, 0xcb, 0x36, 0x7a, 0x7e, 0xd9, 0x46, 0x7f, 0xbf, 0x6c, 0xa3,
+	0xcf, 0x2f, 0xdb, 0x85, 0x2f, 0x2e, 0xdb, 0xe8, 0x37, 0xaf, 0xda, 0x85, 0xe7, 0xaf, 0xda, 0x85,
+	0xcf, 0x5e, 0xb5, 0x0b, 0x3f, 0xae, 0x8a, 0x7f, 0x89, 0xc2, 0x93, 0x93, 0x8a, 0xf8, 0xbf, 0xe7,
+	0xbd, 0x7f, 0x0d, 0x00, 0x2d, 0x4f, 0x8e, 0x14, 0x37, 0x12, 0x00, 0x00,
 }
 
 func (x WriteRequest_SourceEnum) String() string {
@@ -1996,6 +2078,42 @@ func (this *WriteResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
+	if this.CreatedSeries != that1.CreatedSeries {
+		return false
+	}
+	if !this.Cost.Equal(that1.Cost) {
+		return false
+	}
+	return true
+}
+func (this *WriteCost) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*WriteCost)
+	if !ok {
+		that2, ok := that.(WriteCost)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.AcceptedSamples != that1.AcceptedSamples {
+		return false
+	}
+	if this.CreatedSeries != that1.CreatedSeries {
+		return false
+	}
+	if this.Units != that1.Units {
+		return false
+	}
 	return true
 }
 func (this *TimeSeries) Equal(that interface{}) bool {
@@ -3009,8 +3127,24 @@ func (this *WriteResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 4)
+	s := make([]string, 0, 6)
 	s = append(s, "&mimirpb.WriteResponse{")
+	s = append(s, "CreatedSeries: "+fmt.Sprintf("%#v", this.CreatedSeries)+",\n")
+	if this.Cost != nil {
+		s = append(s, "Cost: "+fmt.Sprintf("%#v", this.Cost)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *WriteCost) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&mimirpb.WriteCost{")
+	s = append(s, "AcceptedSamples: "+fmt.Sprintf("%#v", this.AcceptedSamples)+",\n")
+	s = append(s, "CreatedSeries: "+fmt.Sprintf("%#v", this.CreatedSeries)+",\n")
+	s = append(s, "Units: "+fmt.Sprintf("%#v", this.Units)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3517,6 +3651,62 @@ func (m *WriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Cost != nil {
+		{
+			size, err := m.Cost.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMimir(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.CreatedSeries != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.CreatedSeries))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *WriteCost) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *WriteCost) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *WriteCost) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Units != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Units))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if m.CreatedSeries != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.CreatedSeries))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.AcceptedSamples != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.AcceptedSamples))
+		i--
+		dAtA[i] = 0x8
+	}
 	return len(dAtA) - i, nil
 }
 
@@ -3836,30 +4026,30 @@ func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.PositiveCounts) > 0 {
 		for iNdEx := len(m.PositiveCounts) - 1; iNdEx >= 0; iNdEx-- {
-			f1 := math.Float64bits(float64(m.PositiveCounts[iNdEx]))
+			f2 := math.Float64bits(float64(m.PositiveCounts[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f1))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f2))
 		}
 		i = encodeVarintMimir(dAtA, i, uint64(len(m.PositiveCounts)*8))
 		i--
 		dAtA[i] = 0x6a
 	}
 	if len(m.PositiveDeltas) > 0 {
-		var j2 int
-		dAtA4 := make([]byte, len(m.PositiveDeltas)*10)
+		var j3 int
+		dAtA5 := make([]byte, len(m.PositiveDeltas)*10)
 		for _, num := range m.PositiveDeltas {
-			x3 := (uint64(num) << 1) ^ uint64((num >> 63))
-			for x3 >= 1<<7 {
-				dAtA4[j2] = uint8(uint64(x3)&0x7f | 0x80)
-				j2++
-				x3 >>= 7
-			}
-			dAtA4[j2] = uint8(x3)
-			j2++
-		}
-		i -= j2
-		copy(dAtA[i:], dAtA4[:j2])
-		i = encodeVarintMimir(dAtA, i, uint64(j2))
+			x4 := (uint64(num) << 1) ^ uint64((num >> 63))
+			for x4 >= 1<<7 {
+				dAtA5[j3] = uint8(uint64(x4)&0x7f | 0x80)
+				j3++
+				x4 >>= 7
+			}
+			dAtA5[j3] = uint8(x4)
+			j3++
+		}
+		i -= j3
+		copy(dAtA[i:], dAtA5[:j3])
+		i = encodeVarintMimir(dAtA, i, uint64(j3))
 		i--
 		dAtA[i] = 0x62
 	}
@@ -3879,30 +4069,30 @@ func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.NegativeCounts) > 0 {
 		for iNdEx := len(m.NegativeCounts) - 1; iNdEx >= 0; iNdEx-- {
-			f5 := math.Float64bits(float64(m.NegativeCounts[iNdEx]))
+			f6 := math.Float64bits(float64(m.NegativeCounts[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f5))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f6))
 		}
 		i = encodeVarintMimir(dAtA, i, uint64(len(m.NegativeCounts)*8))
 		i--
 		dAtA[i] = 0x52
 	}
 	if len(m.NegativeDeltas) > 0 {
-		var j6 int
-		dAtA8 := make([]byte, len(m.NegativeDeltas)*10)
+		var j7 int
+		dAtA9 := make([]byte, len(m.NegativeDeltas)*10)
 		for _, num := range m.NegativeDeltas {
-			x7 := (uint64(num) << 1) ^ uint64((num >> 63))
-			for x7 >= 1<<7 {
-				dAtA8[j6] = uint8(uint64(x7)&0x7f | 0x80)
-				j6++
-				x7 >>= 7
-			}
-			dAtA8[j6] = uint8(x7)
-			j6++
-		}
-		i -= j6
-		copy(dAtA[i:], dAtA8[:j6])
-		i = encodeVarintMimir(dAtA, i, uint64(j6))
+			x8 := (uint64(num) << 1) ^ uint64((num >> 63))
+			for x8 >= 1<<7 {
+				dAtA9[j7] = uint8(uint64(x8)&0x7f | 0x80)
+				j7++
+				x8 >>= 7
+			}
+			dAtA9[j7] = uint8(x8)
+			j7++
+		}
+		i -= j7
+		copy(dAtA[i:], dAtA9[:j7])
+		i = encodeVarintMimir(dAtA, i, uint64(j7))
 		i--
 		dAtA[i] = 0x4a
 	}
@@ -4031,9 +4221,9 @@ func (m *FloatHistogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.PositiveBuckets) > 0 {
 		for iNdEx := len(m.PositiveBuckets) - 1; iNdEx >= 0; iNdEx-- {
-			f9 := math.Float64bits(float64(m.PositiveBuckets[iNdEx]))
+			f10 := math.Float64bits(float64(m.PositiveBuckets[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f9))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f10))
 		}
 		i = encodeVarintMimir(dAtA, i, uint64(len(m.PositiveBuckets)*8))
 		i--
@@ -4055,9 +4245,9 @@ func (m *FloatHistogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.NegativeBuckets) > 0 {
 		for iNdEx := len(m.NegativeBuckets) - 1; iNdEx >= 0; iNdEx-- {
-			f10 := math.Float64bits(float64(m.NegativeBuckets[iNdEx]))
+			f11 := math.Float64bits(float64(m.NegativeBuckets[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f10))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f11))
 		}
 		i = encodeVarintMimir(dAtA, i, uint64(len(m.NegativeBuckets)*8))
 		i--
@@ -4795,6 +4985,31 @@ func (m *WriteResponse) Size() (n int) {
 	}
 	var l int
 	_ = l
+	if m.CreatedSeries != 0 {
+		n += 1 + sovMimir(uint64(m.CreatedSeries))
+	}
+	if m.Cost != nil {
+		l = m.Cost.Size()
+		n += 1 + l + sovMimir(uint64(l))
+	}
+	return n
+}
+
+func (m *WriteCost) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.AcceptedSamples != 0 {
+		n += 1 + sovMimir(uint64(m.AcceptedSamples))
+	}
+	if m.CreatedSeries != 0 {
+		n += 1 + sovMimir(uint64(m.CreatedSeries))
+	}
+	if m.Units != 0 {
+		n += 9
+	}
 	return n
 }
 
@@ -5387,6 +5602,20 @@ func (this *WriteResponse) String() string {
 		return "nil"
 	}
 	s := strings.Join([]string{`&WriteResponse{`,
+		`CreatedSeries:` + fmt.Sprintf("%v", this.CreatedSeries) + `,`,
+		`Cost:` + strings.Replace(this.Cost.String(), "WriteCost", "WriteCost", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *WriteCost) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&WriteCost{`,
+		`AcceptedSamples:` + fmt.Sprintf("%v", this.AcceptedSamples) + `,`,
+		`CreatedSeries:` + fmt.Sprintf("%v", this.CreatedSeries) + `,`,
+		`Units:` + fmt.Sprintf("%v", this.Units) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5994,6 +6223,163 @@ func (m *WriteResponse) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: WriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedSeries", wireType)
+			}
+			m.CreatedSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedSeries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cost", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMimir
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cost == nil {
+				m.Cost = &WriteCost{}
+			}
+			if err := m.Cost.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMimir(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WriteCost) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMimir
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WriteCost: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WriteCost: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AcceptedSamples", wireType)
+			}
+			m.AcceptedSamples = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AcceptedSamples |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedSeries", wireType)
+			}
+			m.CreatedSeries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedSeries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Units", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Units = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
@@ -13,6 +13,11 @@ const QueryResponseMimeType = QueryResponseMimeTypeType + "/" + QueryResponseMim
 const QueryResponseMimeTypeType = "application"
 const QueryResponseMimeTypeSubType = "vnd.mimir.queryresponse+protobuf"
 
+// QueryResponseChunkedJSONMimeType is the MIME type of the JSON query responses whose matrix result
+// is encoded one series per line, so that it can be decoded one series at a time.
+const QueryResponseChunkedJSONMimeType = QueryResponseMimeTypeType + "/" + QueryResponseChunkedJSONMimeTypeSubType
+const QueryResponseChunkedJSONMimeTypeSubType = "vnd.mimir.queryresponse.chunked+json"
+
 func (s QueryResponse_Status) ToPrometheusString() (string, error) {
 	switch s {
 	case QueryResponse_SUCCESS:
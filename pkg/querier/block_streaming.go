@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// chunkStreamReader returns the chunks of the series streamed by a store-gateway.
+type chunkStreamReader interface {
+	// GetChunks returns the chunks of the series with the input index. The series must be requested in order.
+	GetChunks(seriesIndex uint64) ([]storepb.AggrChunk, error)
+}
+
+// blockStreamingQuerierSeriesSet is a storage.SeriesSet of the series streamed by a store-gateway, whose
+// chunks are read from the stream while the set is iterated.
+type blockStreamingQuerierSeriesSet struct {
+	series       []labels.Labels
+	streamReader chunkStreamReader
+
+	// next series to process
+	next int
+
+	currSeries storage.Series
+	err        error
+}
+
+func (bqss *blockStreamingQuerierSeriesSet) Next() bool {
+	bqss.currSeries = nil
+
+	if bqss.err != nil || bqss.next >= len(bqss.series) {
+		return false
+	}
+
+	currLabels := bqss.series[bqss.next]
+	currChunks, err := bqss.streamReader.GetChunks(uint64(bqss.next))
+	if err != nil {
+		bqss.err = err
+		return false
+	}
+
+	bqss.next++
+
+	// Merge chunks for current series. Chunks may come in multiple series, but as soon
+	// as the set has a new series, we can stop searching. Series are sorted.
+	for bqss.next < len(bqss.series) && labels.Compare(currLabels, bqss.series[bqss.next]) == 0 {
+		nextChunks, err := bqss.streamReader.GetChunks(uint64(bqss.next))
+		if err != nil {
+			bqss.err = err
+			return false
+		}
+		currChunks = append(currChunks, nextChunks...)
+		bqss.next++
+	}
+
+	bqss.currSeries = newBlockQuerierSeries(currLabels, currChunks)
+	return true
+}
+
+func (bqss *blockStreamingQuerierSeriesSet) At() storage.Series {
+	return bqss.currSeries
+}
+
+func (bqss *blockStreamingQuerierSeriesSet) Err() error {
+	return bqss.err
+}
+
+func (bqss *blockStreamingQuerierSeriesSet) Warnings() storage.Warnings {
+	return nil
+}
+
+// storeGatewayStreamReader reads the chunks streamed by a store-gateway after the series, one batch at a time.
+// It's not goroutine safe: the chunks are expected to be read by a single series set.
+type storeGatewayStreamReader struct {
+	client              storegatewaypb.StoreGateway_SeriesClient
+	cancel              context.CancelFunc
+	remoteAddress       string
+	expectedSeriesCount int
+
+	queryLimiter  *limiter.QueryLimiter
+	memoryTracker *limiter.MemoryConsumptionTracker
+	stats         *stats.Stats
+
+	// The series of the last received batch whose chunks haven't been read yet.
+	buffered []*storepb.StreamingChunks
+	err      error
+}
+
+func newStoreGatewayStreamReader(client storegatewaypb.StoreGateway_SeriesClient, cancel context.CancelFunc, remoteAddress string, expectedSeriesCount int, queryLimiter *limiter.QueryLimiter, memoryTracker *limiter.MemoryConsumptionTracker, stats *stats.Stats) *storeGatewayStreamReader {
+	return &storeGatewayStreamReader{
+		client:              client,
+		cancel:              cancel,
+		remoteAddress:       remoteAddress,
+		expectedSeriesCount: expectedSeriesCount,
+		queryLimiter:        queryLimiter,
+		memoryTracker:       memoryTracker,
+		stats:               stats,
+	}
+}
+
+// GetChunks implements chunkStreamReader.
+func (r *storeGatewayStreamReader) GetChunks(seriesIndex uint64) ([]storepb.AggrChunk, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if len(r.buffered) == 0 {
+		if err := r.readNextBatch(); err != nil {
+			r.err = errors.Wrapf(err, "failed to read chunks streamed by store-gateway %s", r.remoteAddress)
+			r.Close()
+			return nil, r.err
+		}
+	}
+
+	next := r.buffered[0]
+	r.buffered = r.buffered[1:]
+
+	if next.SeriesIndex != seriesIndex {
+		r.err = errors.Errorf("attempted to read the chunks of the series at index %d from store-gateway %s, but the stream has the chunks of the series at index %d", seriesIndex, r.remoteAddress, next.SeriesIndex)
+		r.Close()
+		return nil, r.err
+	}
+
+	// Once the chunks of the last series have been read, the rest of the stream is consumed to release it.
+	if seriesIndex == uint64(r.expectedSeriesCount-1) {
+		r.drain()
+	}
+
+	return next.Chunks, nil
+}
+
+func (r *storeGatewayStreamReader) readNextBatch() error {
+	for {
+		resp, err := r.client.Recv()
+		if errors.Is(err, io.EOF) {
+			return errors.New("the stream has been closed before all the expected chunks have been received")
+		}
+		if err != nil {
+			return err
+		}
+
+		if s := resp.GetStats(); s != nil {
+			r.stats.AddFetchedIndexBytes(s.FetchedIndexBytes)
+			continue
+		}
+
+		batch := resp.GetStreamingChunks()
+		if batch == nil || len(batch.Series) == 0 {
+			continue
+		}
+
+		chunksCount, chunksSize := 0, 0
+		for _, s := range batch.Series {
+			for _, c := range s.Chunks {
+				chunksCount++
+				chunksSize += c.Size()
+			}
+		}
+
+		if err := r.queryLimiter.AddChunkBytes(chunksSize); err != nil {
+			return validation.LimitError(err.Error())
+		}
+		if err := r.queryLimiter.AddChunks(chunksCount); err != nil {
+			return validation.LimitError(err.Error())
+		}
+
+		// The chunks are retained in memory until the query completes.
+		if err := r.memoryTracker.IncreaseMemoryConsumption(uint64(batch.Size())); err != nil {
+			return err
+		}
+
+		r.stats.AddFetchedChunks(uint64(chunksCount))
+		r.stats.AddFetchedChunkBytes(uint64(chunksSize))
+
+		r.buffered = batch.Series
+		return nil
+	}
+}
+
+// drain reads the remaining messages of the stream, which are expected to only be the stats, and closes it.
+func (r *storeGatewayStreamReader) drain() {
+	defer r.Close()
+
+	for {
+		resp, err := r.client.Recv()
+		if err != nil {
+			return
+		}
+		if s := resp.GetStats(); s != nil {
+			r.stats.AddFetchedIndexBytes(s.FetchedIndexBytes)
+		}
+	}
+}
+
+// Close releases the stream. It's safe to call it multiple times.
+func (r *storeGatewayStreamReader) Close() {
+	r.cancel()
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/limiter"
+)
+
+func TestBlockStreamingQuerierSeriesSet(t *testing.T) {
+	series1 := labels.FromStrings("foo", "1")
+	series2 := labels.FromStrings("foo", "2")
+
+	t.Run("should merge the chunks of consecutive series with the same labels", func(t *testing.T) {
+		reader := &mockChunkStreamReader{chunks: [][]storepb.AggrChunk{
+			{createAggrChunkWithSamples(promql.Point{T: 1, V: 1})},
+			{createAggrChunkWithSamples(promql.Point{T: 2, V: 2})},
+			{createAggrChunkWithSamples(promql.Point{T: 3, V: 3})},
+		}}
+
+		set := &blockStreamingQuerierSeriesSet{
+			series:       []labels.Labels{series1, series1, series2},
+			streamReader: reader,
+		}
+
+		require.True(t, set.Next())
+		assert.Equal(t, series1, set.At().Labels())
+		assert.Equal(t, []promql.Point{{T: 1, V: 1}, {T: 2, V: 2}}, readSeriesPoints(t, set))
+
+		require.True(t, set.Next())
+		assert.Equal(t, series2, set.At().Labels())
+		assert.Equal(t, []promql.Point{{T: 3, V: 3}}, readSeriesPoints(t, set))
+
+		require.False(t, set.Next())
+		require.NoError(t, set.Err())
+	})
+
+	t.Run("should stop and return the error if the chunks can't be read", func(t *testing.T) {
+		expectedErr := errors.New("stream failure")
+		reader := &mockChunkStreamReader{
+			chunks: [][]storepb.AggrChunk{{createAggrChunkWithSamples(promql.Point{T: 1, V: 1})}},
+			errAt:  1,
+			err:    expectedErr,
+		}
+
+		set := &blockStreamingQuerierSeriesSet{
+			series:       []labels.Labels{series1, series2},
+			streamReader: reader,
+		}
+
+		require.True(t, set.Next())
+		require.False(t, set.Next())
+		require.Equal(t, expectedErr, set.Err())
+		require.Nil(t, set.At())
+	})
+}
+
+func TestStoreGatewayStreamReader(t *testing.T) {
+	mockChunks := func(seriesIndex uint64) *storepb.StreamingChunks {
+		return &storepb.StreamingChunks{
+			SeriesIndex: seriesIndex,
+			Chunks:      []storepb.AggrChunk{createAggrChunkWithSamples(promql.Point{T: int64(seriesIndex), V: float64(seriesIndex)})},
+		}
+	}
+
+	mockBatch := func(series ...*storepb.StreamingChunks) *storepb.SeriesResponse {
+		return storepb.NewStreamingChunksResponse(&storepb.StreamingChunksBatch{Series: series})
+	}
+
+	t.Run("should read the chunks of all series and the stats at the end of the stream", func(t *testing.T) {
+		canceled := false
+		queryStats := &stats.Stats{}
+		client := &storeGatewaySeriesClientMock{mockedResponses: []*storepb.SeriesResponse{
+			mockBatch(mockChunks(0), mockChunks(1)),
+			mockBatch(mockChunks(2)),
+			mockStatsResponse(50),
+		}}
+
+		reader := newStoreGatewayStreamReader(client, func() { canceled = true }, "1.1.1.1", 3, limiter.NewQueryLimiter(0, 0, 0, 0, 0, nil), limiter.NewMemoryConsumptionTracker(0, nil), queryStats)
+
+		for i := uint64(0); i < 3; i++ {
+			chunks, err := reader.GetChunks(i)
+			require.NoError(t, err)
+			require.Equal(t, mockChunks(i).Chunks, chunks)
+		}
+
+		assert.True(t, canceled)
+		assert.Equal(t, uint64(3), queryStats.FetchedChunksCount)
+		assert.Equal(t, uint64(50), queryStats.FetchedIndexBytes)
+	})
+
+	t.Run("should fail if the series are read out of order", func(t *testing.T) {
+		canceled := false
+		client := &storeGatewaySeriesClientMock{mockedResponses: []*storepb.SeriesResponse{
+			mockBatch(mockChunks(0), mockChunks(1)),
+		}}
+
+		reader := newStoreGatewayStreamReader(client, func() { canceled = true }, "1.1.1.1", 2, limiter.NewQueryLimiter(0, 0, 0, 0, 0, nil), limiter.NewMemoryConsumptionTracker(0, nil), nil)
+
+		_, err := reader.GetChunks(1)
+		require.EqualError(t, err, "attempted to read the chunks of the series at index 1 from store-gateway 1.1.1.1, but the stream has the chunks of the series at index 0")
+		assert.True(t, canceled)
+
+		// Subsequent calls return the same error.
+		_, err = reader.GetChunks(0)
+		require.Error(t, err)
+	})
+
+	t.Run("should fail if the stream ends before all the chunks have been received", func(t *testing.T) {
+		client := &storeGatewaySeriesClientMock{mockedResponses: []*storepb.SeriesResponse{
+			mockBatch(mockChunks(0)),
+		}}
+
+		reader := newStoreGatewayStreamReader(client, func() {}, "1.1.1.1", 2, limiter.NewQueryLimiter(0, 0, 0, 0, 0, nil), limiter.NewMemoryConsumptionTracker(0, nil), nil)
+
+		_, err := reader.GetChunks(0)
+		require.NoError(t, err)
+
+		_, err = reader.GetChunks(1)
+		require.EqualError(t, err, "failed to read chunks streamed by store-gateway 1.1.1.1: the stream has been closed before all the expected chunks have been received")
+	})
+
+	t.Run("should fail if the chunks limit is exceeded", func(t *testing.T) {
+		client := &storeGatewaySeriesClientMock{mockedResponses: []*storepb.SeriesResponse{
+			mockBatch(mockChunks(0), mockChunks(1)),
+		}}
+
+		reader := newStoreGatewayStreamReader(client, func() {}, "1.1.1.1", 2, limiter.NewQueryLimiter(0, 0, 1, 0, 0, nil), limiter.NewMemoryConsumptionTracker(0, nil), nil)
+
+		_, err := reader.GetChunks(0)
+		require.ErrorContains(t, err, fmt.Sprintf(limiter.MaxChunksPerQueryLimitMsgFormat, 1))
+	})
+}
+
+type mockChunkStreamReader struct {
+	chunks [][]storepb.AggrChunk
+	errAt  uint64
+	err    error
+}
+
+func (m *mockChunkStreamReader) GetChunks(seriesIndex uint64) ([]storepb.AggrChunk, error) {
+	if m.err != nil && seriesIndex == m.errAt {
+		return nil, m.err
+	}
+
+	return m.chunks[seriesIndex], nil
+}
+
+func readSeriesPoints(t *testing.T, set *blockStreamingQuerierSeriesSet) []promql.Point {
+	var points []promql.Point
+
+	it := set.At().Iterator(nil)
+	for it.Next() != 0 {
+		ts, v := it.At()
+		points = append(points, promql.Point{T: ts, V: v})
+	}
+	require.NoError(t, it.Err())
+
+	return points
+}
@@ -8,13 +8,16 @@ package querier
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/services"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
@@ -30,6 +33,10 @@ type BucketIndexBlocksFinderConfig struct {
 	IndexLoader              bucketindex.LoaderConfig
 	MaxStalePeriod           time.Duration
 	IgnoreDeletionMarksDelay time.Duration
+
+	// StaleFallbackEnabled enables discovering the blocks of the tenants whose bucket index is older than
+	// MaxStalePeriod by scanning the bucket, instead of failing their queries.
+	StaleFallbackEnabled bool
 }
 
 // BucketIndexBlocksFinder implements BlocksFinder interface and find blocks in the bucket
@@ -37,16 +44,43 @@ type BucketIndexBlocksFinderConfig struct {
 type BucketIndexBlocksFinder struct {
 	services.Service
 
-	cfg    BucketIndexBlocksFinderConfig
-	loader *bucketindex.Loader
+	cfg         BucketIndexBlocksFinderConfig
+	loader      *bucketindex.Loader
+	bkt         objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	logger      log.Logger
+
+	// The bucket indexes built by scanning the bucket for the tenants whose bucket index is stale.
+	fallbackMtx     sync.Mutex
+	fallbackIndexes map[string]*fallbackIndex
+	fallbackScans   prometheus.Counter
+
+	// The total size of the blocks of each tenant, as of the last bucket index read.
+	blocksSizesMtx sync.Mutex
+	blocksSizes    map[string]int64
+}
+
+// fallbackIndex is a bucket index built by scanning the bucket.
+type fallbackIndex struct {
+	mtx sync.Mutex
+	idx *bucketindex.Index
 }
 
 func NewBucketIndexBlocksFinder(cfg BucketIndexBlocksFinderConfig, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) *BucketIndexBlocksFinder {
 	loader := bucketindex.NewLoader(cfg.IndexLoader, bkt, cfgProvider, logger, reg)
 
 	return &BucketIndexBlocksFinder{
-		cfg:     cfg,
-		loader:  loader,
+		cfg:             cfg,
+		loader:          loader,
+		bkt:             bkt,
+		cfgProvider:     cfgProvider,
+		logger:          logger,
+		fallbackIndexes: map[string]*fallbackIndex{},
+		blocksSizes:     map[string]int64{},
+		fallbackScans: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_bucket_index_stale_fallback_scans_total",
+			Help: "Total number of scans of the bucket done to discover the blocks of the tenants whose bucket index is older than the max stale period.",
+		}),
 		Service: loader,
 	}
 }
@@ -65,6 +99,7 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 	if errors.Is(err, bucketindex.ErrIndexNotFound) {
 		// This is a legit edge case, happening when a new tenant has not shipped blocks to the storage yet
 		// so the bucket index hasn't been created yet.
+		f.setBlocksSize(userID, 0)
 		return nil, nil, nil
 	}
 	if err != nil {
@@ -73,9 +108,20 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 
 	// Ensure the bucket index is not too old.
 	if time.Since(idx.GetUpdatedAt()) > f.cfg.MaxStalePeriod {
-		return nil, nil, newBucketIndexTooOldError(idx.GetUpdatedAt(), f.cfg.MaxStalePeriod)
+		if !f.cfg.StaleFallbackEnabled {
+			return nil, nil, newBucketIndexTooOldError(idx.GetUpdatedAt(), f.cfg.MaxStalePeriod)
+		}
+
+		idx, err = f.getFallbackIndex(ctx, userID, idx)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if f.cfg.StaleFallbackEnabled {
+		f.removeFallbackIndex(userID)
 	}
 
+	f.setBlocksSize(userID, idx.Blocks.SizeBytes())
+
 	var (
 		matchingBlocks        = map[ulid.ULID]*bucketindex.Block{}
 		matchingDeletionMarks = map[ulid.ULID]*bucketindex.BlockDeletionMark{}
@@ -114,6 +160,69 @@ func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string,
 	return blocks, matchingDeletionMarks, nil
 }
 
+// BlocksSize implements tenantBlocksSizes.
+func (f *BucketIndexBlocksFinder) BlocksSize(userID string) int64 {
+	f.blocksSizesMtx.Lock()
+	defer f.blocksSizesMtx.Unlock()
+
+	return f.blocksSizes[userID]
+}
+
+func (f *BucketIndexBlocksFinder) setBlocksSize(userID string, size int64) {
+	f.blocksSizesMtx.Lock()
+	defer f.blocksSizesMtx.Unlock()
+
+	if size == 0 {
+		delete(f.blocksSizes, userID)
+	} else {
+		f.blocksSizes[userID] = size
+	}
+}
+
+// getFallbackIndex returns the bucket index of the tenant built by scanning the bucket, starting from the stale
+// bucket index so that only the metas of the new blocks are fetched. The built index is reused by the queries of
+// the tenant until it's older than the interval the bucket indexes are updated at.
+func (f *BucketIndexBlocksFinder) getFallbackIndex(ctx context.Context, userID string, stale *bucketindex.Index) (*bucketindex.Index, error) {
+	f.fallbackMtx.Lock()
+	entry, ok := f.fallbackIndexes[userID]
+	if !ok {
+		entry = &fallbackIndex{}
+		f.fallbackIndexes[userID] = entry
+	}
+	f.fallbackMtx.Unlock()
+
+	// Concurrent queries of the tenant wait for the same scan.
+	entry.mtx.Lock()
+	defer entry.mtx.Unlock()
+
+	if entry.idx != nil && time.Since(entry.idx.GetUpdatedAt()) < f.cfg.IndexLoader.UpdateOnStaleInterval {
+		return entry.idx, nil
+	}
+
+	old := stale
+	if entry.idx != nil {
+		old = entry.idx
+	}
+
+	level.Warn(f.logger).Log("msg", "bucket index is too old, scanning the bucket to discover the blocks", "user", userID, "updated_at", stale.GetUpdatedAt().UTC().Format(time.RFC3339Nano))
+	f.fallbackScans.Inc()
+
+	idx, _, err := bucketindex.NewUpdater(f.bkt, userID, f.cfgProvider, f.logger).UpdateIndex(ctx, old)
+	if err != nil {
+		return nil, errors.Wrap(err, "scan bucket to update stale bucket index")
+	}
+
+	entry.idx = idx
+	return idx, nil
+}
+
+// removeFallbackIndex removes the bucket index of the tenant built by scanning the bucket, if any.
+func (f *BucketIndexBlocksFinder) removeFallbackIndex(userID string) {
+	f.fallbackMtx.Lock()
+	delete(f.fallbackIndexes, userID)
+	f.fallbackMtx.Unlock()
+}
+
 func newBucketIndexTooOldError(updatedAt time.Time, maxStalePeriod time.Duration) error {
 	return errors.New(globalerror.BucketIndexTooOld.Message(fmt.Sprintf("the bucket index is too old. It was last updated at %s, which exceeds the maximum allowed staleness period of %v", updatedAt.UTC().Format(time.RFC3339Nano), maxStalePeriod)))
 }
@@ -15,6 +15,8 @@ import (
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/services"
 	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
@@ -158,6 +160,32 @@ func BenchmarkBucketIndexBlocksFinder_GetBlocks(b *testing.B) {
 	}
 }
 
+func TestBucketIndexBlocksFinder_BlocksSize(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version: bucketindex.IndexVersion3,
+		Blocks: bucketindex.Blocks{
+			{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 15, SizeBytes: 100},
+			{ID: ulid.MustNew(2, nil), MinTime: 20, MaxTime: 30, SizeBytes: 200},
+		},
+		UpdatedAt: time.Now().Unix(),
+	}))
+
+	finder := prepareBucketIndexBlocksFinder(t, bkt)
+
+	// The size is unknown until the bucket index of the tenant has been read.
+	assert.Equal(t, int64(0), finder.BlocksSize(userID))
+
+	// The size includes all the blocks of the tenant, not only the ones matching the queried range.
+	_, _, err := finder.GetBlocks(ctx, userID, 10, 15)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), finder.BlocksSize(userID))
+}
+
 func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexDoesNotExist(t *testing.T) {
 	const userID = "user-1"
 
@@ -204,7 +232,46 @@ func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexIsTooOld(t *testing.T) {
 	require.EqualError(t, err, newBucketIndexTooOldError(idx.GetUpdatedAt(), finder.cfg.MaxStalePeriod).Error())
 }
 
+func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexIsTooOldWithStaleFallback(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	reg := prometheus.NewPedanticRegistry()
+	finder := prepareBucketIndexBlocksFinderWithConfig(t, bkt, reg, func(cfg *BucketIndexBlocksFinderConfig) {
+		cfg.StaleFallbackEnabled = true
+	})
+
+	// The stale bucket index doesn't include the last uploaded block.
+	block1 := mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 15)
+	block2 := mimir_testutil.MockStorageBlock(t, bkt, userID, 15, 20)
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion3,
+		Blocks:    bucketindex.Blocks{{ID: block1.ULID, MinTime: block1.MinTime, MaxTime: block1.MaxTime}},
+		UpdatedAt: time.Now().Add(-2 * time.Hour).Unix(),
+	}))
+
+	// The blocks are discovered by scanning the bucket, and the built index is reused by the next queries.
+	for i := 0; i < 2; i++ {
+		blocks, deletionMarks, err := finder.GetBlocks(ctx, userID, 10, 20)
+		require.NoError(t, err)
+		require.Len(t, blocks, 2)
+		assert.ElementsMatch(t, []ulid.ULID{block1.ULID, block2.ULID}, []ulid.ULID{blocks[0].ID, blocks[1].ID})
+		assert.Empty(t, deletionMarks)
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_bucket_index_stale_fallback_scans_total Total number of scans of the bucket done to discover the blocks of the tenants whose bucket index is older than the max stale period.
+		# TYPE cortex_querier_bucket_index_stale_fallback_scans_total counter
+		cortex_querier_bucket_index_stale_fallback_scans_total 1
+	`), "cortex_querier_bucket_index_stale_fallback_scans_total"))
+}
+
 func prepareBucketIndexBlocksFinder(t testing.TB, bkt objstore.Bucket) *BucketIndexBlocksFinder {
+	return prepareBucketIndexBlocksFinderWithConfig(t, bkt, nil, nil)
+}
+
+func prepareBucketIndexBlocksFinderWithConfig(t testing.TB, bkt objstore.Bucket, reg prometheus.Registerer, cfgFn func(*BucketIndexBlocksFinderConfig)) *BucketIndexBlocksFinder {
 	ctx := context.Background()
 	cfg := BucketIndexBlocksFinderConfig{
 		IndexLoader: bucketindex.LoaderConfig{
@@ -216,8 +283,11 @@ func prepareBucketIndexBlocksFinder(t testing.TB, bkt objstore.Bucket) *BucketIn
 		MaxStalePeriod:           time.Hour,
 		IgnoreDeletionMarksDelay: time.Hour,
 	}
+	if cfgFn != nil {
+		cfgFn(&cfg)
+	}
 
-	finder := NewBucketIndexBlocksFinder(cfg, bkt, nil, log.NewNopLogger(), nil)
+	finder := NewBucketIndexBlocksFinder(cfg, bkt, nil, log.NewNopLogger(), reg)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, finder))
 	t.Cleanup(func() {
 		require.NoError(t, services.StopAndAwaitTerminated(ctx, finder))
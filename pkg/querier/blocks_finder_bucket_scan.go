@@ -156,6 +156,14 @@ func (d *BucketScanBlocksFinder) GetBlocks(_ context.Context, userID string, min
 	return matchingMetas, matchingDeletionMarks, nil
 }
 
+// BlocksSize implements tenantBlocksSizes.
+func (d *BucketScanBlocksFinder) BlocksSize(userID string) int64 {
+	d.userMx.RLock()
+	defer d.userMx.RUnlock()
+
+	return d.userMetas[userID].SizeBytes()
+}
+
 func (d *BucketScanBlocksFinder) starting(ctx context.Context) error {
 	// Before the service is in the running state it must have successfully
 	// complete the initial scan.
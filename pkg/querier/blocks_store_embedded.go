@@ -0,0 +1,356 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	otgrpc "github.com/opentracing-contrib/go-grpc"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway"
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// embeddedStoreAddress is the address of the embedded store, used to identify it
+	// among the store-gateway instances.
+	embeddedStoreAddress = "embedded-store"
+
+	// embeddedStoreListenerBufferSize is the size of the in-memory buffer of the
+	// connection between the querier and the embedded store.
+	embeddedStoreListenerBufferSize = 1 << 20
+)
+
+// embeddedBlocksStoreSet is a BlocksStoreSet which queries the blocks of the tenants having
+// at most a configured number of blocks directly from the long-term storage, through a
+// BucketStores embedded in the querier, and falls back to the store-gateways for all other
+// tenants and for the blocks not loaded (yet) by the embedded store.
+//
+// The embedded store is exposed through a gRPC server listening on an in-memory connection
+// in order to run the exact same code path of the store-gateway.
+type embeddedBlocksStoreSet struct {
+	services.Service
+
+	remote       BlocksStoreSet
+	stores       *storegateway.BucketStores
+	limits       *validation.Overrides
+	syncInterval time.Duration
+	logger       log.Logger
+
+	listener *bufconn.Listener
+	server   *grpc.Server
+	client   *embeddedStoreClient
+
+	// Tenants whose blocks are currently loaded by the embedded store.
+	tenantsMx sync.RWMutex
+	tenants   map[string]struct{}
+
+	// Subservices manager.
+	subservices        *services.Manager
+	subservicesWatcher *services.FailureWatcher
+}
+
+func newEmbeddedBlocksStoreSet(
+	remote BlocksStoreSet,
+	storageCfg mimir_tsdb.BlocksStorageConfig,
+	syncDir string,
+	bucketClient objstore.Bucket,
+	limits *validation.Overrides,
+	logger log.Logger,
+	reg prometheus.Registerer,
+) (*embeddedBlocksStoreSet, error) {
+	s := &embeddedBlocksStoreSet{
+		remote:             remote,
+		limits:             limits,
+		syncInterval:       storageCfg.BucketStore.SyncInterval,
+		logger:             log.With(logger, "component", "embedded-store"),
+		tenants:            map[string]struct{}{},
+		subservicesWatcher: services.NewFailureWatcher(),
+	}
+
+	// The embedded store must not share the local directory with the store-gateway
+	// (when running in the same process) nor with the blocks finder.
+	storageCfg.BucketStore.SyncDir = syncDir
+
+	var err error
+	s.stores, err = storegateway.NewBucketStores(storageCfg, s, bucketClient, limits, s.logger, prometheus.WrapRegistererWith(prometheus.Labels{"component": "querier-embedded-store"}, reg))
+	if err != nil {
+		return nil, errors.Wrap(err, "create embedded bucket stores")
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_querier_embedded_store_tenants",
+		Help: "Number of tenants whose blocks are queried through the querier embedded store.",
+	}, func() float64 {
+		s.tenantsMx.RLock()
+		defer s.tenantsMx.RUnlock()
+		return float64(len(s.tenants))
+	})
+
+	s.listener = bufconn.Listen(embeddedStoreListenerBufferSize)
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(otgrpc.OpenTracingServerInterceptor(opentracing.GlobalTracer())),
+		grpc.ChainStreamInterceptor(otgrpc.OpenTracingStreamServerInterceptor(opentracing.GlobalTracer())),
+	)
+	storegatewaypb.RegisterStoreGatewayServer(s.server, embeddedStoreServer{stores: s.stores})
+
+	s.client, err = dialEmbeddedStoreClient(s.listener)
+	if err != nil {
+		return nil, err
+	}
+
+	s.subservices, err = services.NewManager(s.remote)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Service = services.NewBasicService(s.starting, s.running, s.stopping)
+
+	return s, nil
+}
+
+func (s *embeddedBlocksStoreSet) starting(ctx context.Context) error {
+	s.subservicesWatcher.WatchManager(s.subservices)
+
+	if err := services.StartManagerAndAwaitHealthy(ctx, s.subservices); err != nil {
+		return errors.Wrap(err, "unable to start embedded blocks store set subservices")
+	}
+
+	go func() {
+		if err := s.server.Serve(s.listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			level.Error(s.logger).Log("msg", "embedded store gRPC server failed", "err", err)
+		}
+	}()
+
+	// Queries are served by the store-gateways until the embedded store has loaded the
+	// tenants blocks, so a failure of the initial sync is not fatal.
+	if err := s.stores.InitialSync(ctx); err != nil {
+		level.Warn(s.logger).Log("msg", "failed to synchronize TSDB blocks", "err", err)
+	}
+
+	return nil
+}
+
+func (s *embeddedBlocksStoreSet) running(ctx context.Context) error {
+	// Apply a jitter to the sync frequency in order to increase the probability
+	// of hitting the shared cache (if any).
+	syncTicker := time.NewTicker(util.DurationWithJitter(s.syncInterval, 0.2))
+	defer syncTicker.Stop()
+
+	for {
+		select {
+		case <-syncTicker.C:
+			if err := s.stores.SyncBlocks(ctx); err != nil {
+				level.Warn(s.logger).Log("msg", "failed to synchronize TSDB blocks", "err", err)
+			}
+		case <-ctx.Done():
+			return nil
+		case err := <-s.subservicesWatcher.Chan():
+			return errors.Wrap(err, "embedded blocks store set subservice failed")
+		}
+	}
+}
+
+func (s *embeddedBlocksStoreSet) stopping(_ error) error {
+	_ = s.client.Close()
+	s.server.Stop()
+
+	return services.StopManagerAndAwaitStopped(context.Background(), s.subservices)
+}
+
+// GetClientsFor implements BlocksStoreSet.
+func (s *embeddedBlocksStoreSet) GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error) {
+	if !s.isTenantLoaded(userID) {
+		return s.remote.GetClientsFor(userID, blockIDs, exclude)
+	}
+
+	// Query all blocks from the embedded store, except the ones which have already been
+	// attempted, because missing in the embedded store (eg. not loaded yet).
+	var embeddedBlocks, remoteBlocks []ulid.ULID
+	for _, blockID := range blockIDs {
+		if slices.Contains(exclude[blockID], embeddedStoreAddress) {
+			remoteBlocks = append(remoteBlocks, blockID)
+		} else {
+			embeddedBlocks = append(embeddedBlocks, blockID)
+		}
+	}
+
+	clients := map[BlocksStoreClient][]ulid.ULID{}
+	if len(remoteBlocks) > 0 {
+		var err error
+		if clients, err = s.remote.GetClientsFor(userID, remoteBlocks, exclude); err != nil {
+			return nil, err
+		}
+	}
+	if len(embeddedBlocks) > 0 {
+		clients[s.client] = embeddedBlocks
+	}
+
+	return clients, nil
+}
+
+func (s *embeddedBlocksStoreSet) isTenantLoaded(userID string) bool {
+	s.tenantsMx.RLock()
+	defer s.tenantsMx.RUnlock()
+
+	_, ok := s.tenants[userID]
+	return ok
+}
+
+// FilterUsers implements storegateway.ShardingStrategy. The embedded store syncs the blocks
+// of all tenants for which the embedded store is enabled.
+func (s *embeddedBlocksStoreSet) FilterUsers(_ context.Context, userIDs []string) ([]string, error) {
+	var (
+		filteredIDs []string
+		included    = map[string]struct{}{}
+	)
+
+	for _, userID := range userIDs {
+		if s.limits.QuerierEmbeddedStoreMaxBlocks(userID) > 0 {
+			filteredIDs = append(filteredIDs, userID)
+			included[userID] = struct{}{}
+		}
+	}
+
+	// Stop querying the embedded store for the tenants which have been excluded, before
+	// their blocks get unloaded.
+	s.tenantsMx.Lock()
+	for userID := range s.tenants {
+		if _, ok := included[userID]; !ok {
+			delete(s.tenants, userID)
+		}
+	}
+	s.tenantsMx.Unlock()
+
+	return filteredIDs, nil
+}
+
+// FilterBlocks implements storegateway.ShardingStrategy. The embedded store loads all blocks of
+// a tenant having at most the configured max number of blocks, and no block otherwise.
+func (s *embeddedBlocksStoreSet) FilterBlocks(_ context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, _ int64, _ map[ulid.ULID]struct{}, synced block.GaugeVec) error {
+	maxBlocks := s.limits.QuerierEmbeddedStoreMaxBlocks(userID)
+
+	s.tenantsMx.Lock()
+	defer s.tenantsMx.Unlock()
+
+	if maxBlocks > 0 && len(metas) <= maxBlocks {
+		s.tenants[userID] = struct{}{}
+		return nil
+	}
+
+	// The tenant has too many blocks, so they're queried through the store-gateways. The tenant
+	// is removed before filtering out its blocks, so that the embedded store is not queried
+	// anymore before the blocks get unloaded.
+	delete(s.tenants, userID)
+
+	for blockID := range metas {
+		synced.WithLabelValues(storegateway.ShardExcludedMeta).Inc()
+		delete(metas, blockID)
+	}
+
+	return nil
+}
+
+// embeddedStoreServer exposes the BucketStores through the store-gateway gRPC API.
+type embeddedStoreServer struct {
+	stores *storegateway.BucketStores
+}
+
+// Series implements storegatewaypb.StoreGatewayServer.
+func (s embeddedStoreServer) Series(req *storepb.SeriesRequest, srv storegatewaypb.StoreGateway_SeriesServer) error {
+	return s.stores.Series(req, srv)
+}
+
+// LabelNames implements storegatewaypb.StoreGatewayServer.
+func (s embeddedStoreServer) LabelNames(ctx context.Context, req *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error) {
+	return s.stores.LabelNames(ctx, req)
+}
+
+// LabelValues implements storegatewaypb.StoreGatewayServer.
+func (s embeddedStoreServer) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	return s.stores.LabelValues(ctx, req)
+}
+
+// Exemplars implements storegatewaypb.StoreGatewayServer.
+func (s embeddedStoreServer) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	return s.stores.Exemplars(ctx, req)
+}
+
+// SyncTenants implements storegatewaypb.StoreGatewayServer.
+func (s embeddedStoreServer) SyncTenants(ctx context.Context, req *storegatewaypb.SyncTenantsRequest) (*storegatewaypb.SyncTenantsResponse, error) {
+	if err := s.stores.SyncTenantsBlocks(ctx, req.TenantIds); err != nil {
+		return nil, err
+	}
+	return &storegatewaypb.SyncTenantsResponse{}, nil
+}
+
+// embeddedStoreClient is the BlocksStoreClient used to query the embedded store.
+type embeddedStoreClient struct {
+	storegatewaypb.StoreGatewayClient
+	conn *grpc.ClientConn
+}
+
+func dialEmbeddedStoreClient(listener *bufconn.Listener) (*embeddedStoreClient, error) {
+	// We use the same message size limits of the store-gateway clients.
+	clientCfg := grpcclient.Config{
+		MaxRecvMsgSize: 100 << 20,
+		MaxSendMsgSize: 16 << 20,
+	}
+
+	opts, err := clientCfg.DialOption(
+		[]grpc.UnaryClientInterceptor{otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer())},
+		[]grpc.StreamClientInterceptor{otgrpc.OpenTracingStreamClientInterceptor(opentracing.GlobalTracer())},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+
+	conn, err := grpc.Dial(embeddedStoreAddress, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dial embedded store")
+	}
+
+	return &embeddedStoreClient{
+		StoreGatewayClient: storegatewaypb.NewStoreGatewayClient(conn),
+		conn:               conn,
+	}, nil
+}
+
+func (c *embeddedStoreClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *embeddedStoreClient) String() string {
+	return c.RemoteAddress()
+}
+
+// RemoteAddress implements BlocksStoreClient.
+func (c *embeddedStoreClient) RemoteAddress() string {
+	return embeddedStoreAddress
+}
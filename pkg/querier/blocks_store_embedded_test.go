@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	grpc_metadata "google.golang.org/grpc/metadata"
+
+	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/extprom"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestEmbeddedBlocksStoreSet_FilterUsersAndBlocks(t *testing.T) {
+	limits := defaultLimitsConfig()
+	tenantLimits := map[string]*validation.Limits{
+		"small": func() *validation.Limits { l := defaultLimitsConfig(); l.QuerierEmbeddedStoreMaxBlocks = 2; return &l }(),
+		"large": func() *validation.Limits { l := defaultLimitsConfig(); l.QuerierEmbeddedStoreMaxBlocks = 1; return &l }(),
+	}
+	overrides, err := validation.NewOverrides(limits, validation.NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	s := &embeddedBlocksStoreSet{limits: overrides, tenants: map[string]struct{}{}}
+	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{}, []string{"state"})
+
+	userIDs, err := s.FilterUsers(context.Background(), []string{"small", "large", "disabled"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"small", "large"}, userIDs)
+
+	newMetas := func() map[ulid.ULID]*metadata.Meta {
+		return map[ulid.ULID]*metadata.Meta{
+			ulid.MustNew(1, nil): {},
+			ulid.MustNew(2, nil): {},
+		}
+	}
+
+	// The blocks of the tenant below the threshold are all loaded.
+	metas := newMetas()
+	require.NoError(t, s.FilterBlocks(context.Background(), "small", metas, 0, nil, synced))
+	assert.Len(t, metas, 2)
+	assert.True(t, s.isTenantLoaded("small"))
+
+	// The blocks of the tenant above the threshold are all filtered out.
+	metas = newMetas()
+	require.NoError(t, s.FilterBlocks(context.Background(), "large", metas, 0, nil, synced))
+	assert.Empty(t, metas)
+	assert.False(t, s.isTenantLoaded("large"))
+
+	// A tenant for which the embedded store gets disabled is not queried anymore.
+	userIDs, err = s.FilterUsers(context.Background(), []string{"large", "disabled"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"large"}, userIDs)
+	assert.False(t, s.isTenantLoaded("small"))
+}
+
+func TestEmbeddedBlocksStoreSet_GetClientsFor(t *testing.T) {
+	var (
+		block1 = ulid.MustNew(1, nil)
+		block2 = ulid.MustNew(2, nil)
+		block3 = ulid.MustNew(3, nil)
+	)
+
+	remoteClient := &storeGatewayClientMock{remoteAddr: "1.1.1.1"}
+	embeddedClient := &embeddedStoreClient{}
+
+	tests := map[string]struct {
+		userID          string
+		blockIDs        []ulid.ULID
+		exclude         map[ulid.ULID][]string
+		remoteResponses []interface{}
+		expectedClients map[BlocksStoreClient][]ulid.ULID
+	}{
+		"should query the store-gateways for a tenant not loaded by the embedded store": {
+			userID:          "user-2",
+			blockIDs:        []ulid.ULID{block1, block2},
+			remoteResponses: []interface{}{map[BlocksStoreClient][]ulid.ULID{remoteClient: {block1, block2}}},
+			expectedClients: map[BlocksStoreClient][]ulid.ULID{remoteClient: {block1, block2}},
+		},
+		"should query the embedded store for a tenant loaded by the embedded store": {
+			userID:          "user-1",
+			blockIDs:        []ulid.ULID{block1, block2},
+			expectedClients: map[BlocksStoreClient][]ulid.ULID{embeddedClient: {block1, block2}},
+		},
+		"should query the store-gateways for the blocks missing in the embedded store": {
+			userID:          "user-1",
+			blockIDs:        []ulid.ULID{block2, block3},
+			exclude:         map[ulid.ULID][]string{block2: {embeddedStoreAddress}},
+			remoteResponses: []interface{}{map[BlocksStoreClient][]ulid.ULID{remoteClient: {block2}}},
+			expectedClients: map[BlocksStoreClient][]ulid.ULID{remoteClient: {block2}, embeddedClient: {block3}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			s := &embeddedBlocksStoreSet{
+				remote:  &blocksStoreSetMock{mockedResponses: testData.remoteResponses},
+				client:  embeddedClient,
+				tenants: map[string]struct{}{"user-1": {}},
+			}
+
+			clients, err := s.GetClientsFor(testData.userID, testData.blockIDs, testData.exclude)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedClients, clients)
+		})
+	}
+}
+
+func TestEmbeddedBlocksStoreSet_Series(t *testing.T) {
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+	)
+
+	ctx := context.Background()
+	storageDir := t.TempDir()
+	generateEmbeddedStoreBlock(t, storageDir, userID, metricName, 10, 100, 15)
+
+	bucketClient, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	storageCfg.BucketStore.BucketIndex.Enabled = false
+
+	limits := defaultLimitsConfig()
+	limits.QuerierEmbeddedStoreMaxBlocks = 1
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	remote := &blocksStoreSetMock{Service: services.NewIdleService(nil, nil)}
+	s, err := newEmbeddedBlocksStoreSet(remote, storageCfg, t.TempDir(), bucketClient, overrides, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, s))
+	})
+
+	require.True(t, s.isTenantLoaded(userID))
+
+	stream, err := s.client.Series(grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, userID), &storepb.SeriesRequest{
+		MinTime: 10,
+		MaxTime: 100,
+		Matchers: []storepb.LabelMatcher{{
+			Type:  storepb.LabelMatcher_EQ,
+			Name:  labels.MetricName,
+			Value: metricName,
+		}},
+	})
+	require.NoError(t, err)
+
+	var series []*storepb.Series
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		if s := resp.GetSeries(); s != nil {
+			series = append(series, s)
+		}
+	}
+
+	require.Len(t, series, 1)
+	assert.Equal(t, metricName, series[0].PromLabels().Get(labels.MetricName))
+}
+
+func generateEmbeddedStoreBlock(t *testing.T, storageDir, userID string, metricName string, minT, maxT int64, step int) {
+	userDir := filepath.Join(storageDir, userID)
+	require.NoError(t, os.MkdirAll(userDir, os.ModePerm))
+
+	// Create a temporary directory where the TSDB is opened,
+	// then it will be snapshotted to the storage directory.
+	db, err := tsdb.Open(t.TempDir(), log.NewNopLogger(), nil, tsdb.DefaultOptions(), nil)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, db.Close())
+	}()
+
+	series := labels.FromStrings(labels.MetricName, metricName)
+
+	app := db.Appender(context.Background())
+	for ts := minT; ts < maxT; ts += int64(step) {
+		_, err = app.Append(0, series, ts, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	require.NoError(t, db.Snapshot(userDir, true))
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/thanos-io/objstore"
@@ -34,6 +35,8 @@ import (
 	grpc_metadata "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/pagination"
 	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storage/series"
@@ -41,6 +44,7 @@ import (
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/cardinalityindex"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
@@ -96,7 +100,13 @@ type BlocksStoreLimits interface {
 
 	MaxLabelsQueryLength(userID string) time.Duration
 	MaxChunksPerQuery(userID string) int
+	LabelValuesResultsMaxSizeBytes(userID string) int
+	QueryStoreAfter(userID string) time.Duration
+	CompactorCardinalityIndexEnabled(userID string) bool
 	StoreGatewayTenantShardSize(userID string) int
+	StoreGatewayTenantReplicationFactor(userID string) int
+	StoreGatewayLargeTenantThreshold(userID string) int
+	StoreGatewayLargeTenantShardSize(userID string) int
 }
 
 type blocksStoreQueryableMetrics struct {
@@ -106,6 +116,8 @@ type blocksStoreQueryableMetrics struct {
 	blocksFound                                       prometheus.Counter
 	blocksQueried                                     prometheus.Counter
 	blocksWithCompactorShardButIncompatibleQueryShard prometheus.Counter
+
+	cardinalityIndexLookups *prometheus.CounterVec
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -135,6 +147,11 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Name: "cortex_querier_blocks_with_compactor_shard_but_incompatible_query_shard_total",
 			Help: "Blocks that couldn't be checked for query and compactor sharding optimization due to incompatible shard counts.",
 		}),
+
+		cardinalityIndexLookups: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_cardinality_index_lookups_total",
+			Help: "Number of label names and values requests looked up from the cardinality summaries of the blocks, by result. Requests which couldn't be looked up are sent to the store-gateways.",
+		}, []string{"result"}),
 	}
 }
 
@@ -151,6 +168,12 @@ type BlocksStoreQueryable struct {
 	metrics         *blocksStoreQueryableMetrics
 	limits          BlocksStoreLimits
 
+	// Optional: the loader of the cardinality summaries of the blocks the label names and values are looked up from.
+	cardinalityIndex *cardinalityindex.Loader
+
+	// The number of series per batch of chunks streamed by the store-gateways. 0 disables the streaming.
+	streamingChunksBatchSize uint64
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -162,6 +185,8 @@ func NewBlocksStoreQueryable(
 	consistency *BlocksConsistencyChecker,
 	limits BlocksStoreLimits,
 	queryStoreAfter time.Duration,
+	streamingChunksBatchSize uint64,
+	cardinalityIndex *cardinalityindex.Loader,
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (*BlocksStoreQueryable, error) {
@@ -180,6 +205,9 @@ func NewBlocksStoreQueryable(
 		subservicesWatcher: services.NewFailureWatcher(),
 		metrics:            newBlocksStoreQueryableMetrics(reg),
 		limits:             limits,
+		cardinalityIndex:   cardinalityIndex,
+
+		streamingChunksBatchSize: streamingChunksBatchSize,
 	}
 
 	q.Service = services.NewBasicService(q.starting, q.running, q.stopping)
@@ -187,28 +215,31 @@ func NewBlocksStoreQueryable(
 	return q, nil
 }
 
-func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegateway.Config, storageCfg mimir_tsdb.BlocksStorageConfig, limits BlocksStoreLimits, logger log.Logger, reg prometheus.Registerer) (*BlocksStoreQueryable, error) {
+func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegateway.Config, storageCfg mimir_tsdb.BlocksStorageConfig, limits *validation.Overrides, logger log.Logger, reg prometheus.Registerer) (*BlocksStoreQueryable, error) {
 	var (
 		stores       BlocksStoreSet
 		bucketClient objstore.Bucket
 	)
 
-	bucketClient, err := bucket.NewClient(context.Background(), storageCfg.Bucket, "querier", logger, reg)
+	rawBucketClient, err := bucket.NewClient(context.Background(), storageCfg.Bucket, "querier", logger, reg)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create bucket client")
 	}
 
 	// Blocks finder doesn't use chunks, but we pass config for consistency.
-	cachingBucket, err := mimir_tsdb.CreateCachingBucket(nil, storageCfg.BucketStore.ChunksCache, storageCfg.BucketStore.MetadataCache, bucketClient, logger, prometheus.WrapRegistererWith(prometheus.Labels{"component": "querier"}, reg))
+	cachingBucket, err := mimir_tsdb.CreateCachingBucket(nil, storageCfg.BucketStore.ChunksCache, storageCfg.BucketStore.MetadataCache, rawBucketClient, logger, prometheus.WrapRegistererWith(prometheus.Labels{"component": "querier"}, reg))
 	if err != nil {
 		return nil, errors.Wrap(err, "create caching bucket")
 	}
 	bucketClient = cachingBucket
 
 	// Create the blocks finder.
-	var finder BlocksFinder
+	var (
+		finder      BlocksFinder
+		blocksSizes tenantBlocksSizes
+	)
 	if storageCfg.BucketStore.BucketIndex.Enabled {
-		finder = NewBucketIndexBlocksFinder(BucketIndexBlocksFinderConfig{
+		indexFinder := NewBucketIndexBlocksFinder(BucketIndexBlocksFinderConfig{
 			IndexLoader: bucketindex.LoaderConfig{
 				CheckInterval:         time.Minute,
 				UpdateOnStaleInterval: storageCfg.BucketStore.SyncInterval,
@@ -217,15 +248,18 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 			},
 			MaxStalePeriod:           storageCfg.BucketStore.BucketIndex.MaxStalePeriod,
 			IgnoreDeletionMarksDelay: storageCfg.BucketStore.IgnoreDeletionMarksDelay,
+			StaleFallbackEnabled:     storageCfg.BucketStore.BucketIndex.StaleFallbackEnabled,
 		}, bucketClient, limits, logger, reg)
+		finder, blocksSizes = indexFinder, indexFinder
 	} else {
-		finder = NewBucketScanBlocksFinder(BucketScanBlocksFinderConfig{
+		scanFinder := NewBucketScanBlocksFinder(BucketScanBlocksFinderConfig{
 			ScanInterval:             storageCfg.BucketStore.SyncInterval,
 			TenantsConcurrency:       storageCfg.BucketStore.TenantSyncConcurrency,
 			MetasConcurrency:         storageCfg.BucketStore.MetaSyncConcurrency,
 			CacheDir:                 storageCfg.BucketStore.SyncDir,
 			IgnoreDeletionMarksDelay: storageCfg.BucketStore.IgnoreDeletionMarksDelay,
 		}, bucketClient, limits, logger, reg)
+		finder, blocksSizes = scanFinder, scanFinder
 	}
 
 	storesRingCfg := gatewayCfg.ShardingRing.ToRingConfig()
@@ -244,11 +278,24 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		return nil, errors.Wrap(err, "failed to create store-gateway ring client")
 	}
 
-	stores, err = newBlocksStoreReplicationSet(storesRing, randomLoadBalancing, limits, querierCfg.StoreGatewayClient, logger, reg)
+	balancingStrategy := randomLoadBalancing
+	if querierCfg.StoreGatewayPreferredZone != "" {
+		balancingStrategy = zoneAwareLoadBalancing
+	}
+
+	stores, err = newBlocksStoreReplicationSet(storesRing, balancingStrategy, querierCfg.StoreGatewayPreferredZone, limits, blocksSizes, querierCfg.StoreGatewayClient, logger, reg)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create store set")
 	}
 
+	if querierCfg.EmbeddedStoreEnabled {
+		// The embedded store builds its own caching bucket, including the chunks cache.
+		stores, err = newEmbeddedBlocksStoreSet(stores, storageCfg, querierCfg.EmbeddedStoreSyncDir, rawBucketClient, limits, logger, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create embedded store set")
+		}
+	}
+
 	consistency := NewBlocksConsistencyChecker(
 		// Exclude blocks which have been recently uploaded, in order to give enough time to store-gateways
 		// to discover and load them (3 times the sync interval).
@@ -261,7 +308,17 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		reg,
 	)
 
-	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, logger, reg)
+	var streamingChunksBatchSize uint64
+	if querierCfg.PreferStreamingChunksFromStoreGateways {
+		streamingChunksBatchSize = querierCfg.StreamingChunksPerStoreGatewaySeriesBatchSize
+	}
+
+	cardinalityIndex, err := cardinalityindex.NewLoader(storageCfg.BucketStore.SyncInterval, cardinalityindex.DefaultMaxLoadedSymbols, bucketClient, limits, logger, reg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cardinality summaries loader")
+	}
+
+	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, streamingChunksBatchSize, cardinalityIndex, logger, reg)
 }
 
 func (q *BlocksStoreQueryable) starting(ctx context.Context) error {
@@ -311,10 +368,71 @@ func (q *BlocksStoreQueryable) Querier(ctx context.Context, mint, maxt int64) (s
 		limits:          q.limits,
 		consistency:     q.consistency,
 		logger:          q.logger,
-		queryStoreAfter: q.queryStoreAfter,
+		queryStoreAfter: q.queryStoreAfterForTenant(userID),
+
+		cardinalityIndex:         q.cardinalityIndex,
+		streamingChunksBatchSize: q.streamingChunksBatchSize,
+	}, nil
+}
+
+// BlocksCardinality implements BlocksCardinality. It returns the cardinality summaries of the tenant's blocks in
+// the input time range, or cardinalityindex.ErrSummaryNotFound if any of them has no summary, like the blocks
+// which haven't been compacted yet.
+func (q *BlocksStoreQueryable) BlocksCardinality(ctx context.Context, userID string, minT, maxT int64) (cardinalityindex.Blocks, error) {
+	if q.cardinalityIndex == nil {
+		return nil, errCardinalitySummariesDisabled
+	}
+
+	knownBlocks, _, err := q.finder.GetBlocks(ctx, userID, minT, maxT)
+	if err != nil {
+		return nil, err
+	}
+	return q.cardinalityIndex.GetBlocks(ctx, userID, knownBlocks)
+}
+
+// queryStoreAfterForTenant returns the query-store-after of the tenant, which overrides the querier's one if set.
+func (q *BlocksStoreQueryable) queryStoreAfterForTenant(userID string) time.Duration {
+	if d := q.limits.QueryStoreAfter(userID); d > 0 {
+		return d
+	}
+	return q.queryStoreAfter
+}
+
+// ExemplarQuerier returns a new ExemplarQuerier on the exemplars persisted in the blocks storage.
+func (q *BlocksStoreQueryable) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	if s := q.State(); s != services.Running {
+		return nil, errors.Errorf("BlocksStoreQueryable is not running: %v", s)
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &blocksStoreExemplarQuerier{
+		querier: &blocksStoreQuerier{
+			ctx:             ctx,
+			userID:          userID,
+			finder:          q.finder,
+			stores:          q.stores,
+			metrics:         q.metrics,
+			limits:          q.limits,
+			consistency:     q.consistency,
+			logger:          q.logger,
+			queryStoreAfter: q.queryStoreAfterForTenant(userID),
+		},
 	}, nil
 }
 
+type blocksStoreExemplarQuerier struct {
+	querier *blocksStoreQuerier
+}
+
+// Select implements storage.ExemplarQuerier interface.
+func (q *blocksStoreExemplarQuerier) Select(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	return q.querier.selectExemplars(start, end, matchers...)
+}
+
 type blocksStoreQuerier struct {
 	ctx         context.Context
 	minT, maxT  int64
@@ -329,6 +447,16 @@ type blocksStoreQuerier struct {
 	// If set, the querier manipulates the max time to not be greater than
 	// "now - queryStoreAfter" so that most recent blocks are not queried.
 	queryStoreAfter time.Duration
+
+	// Optional: the loader of the cardinality summaries of the blocks the label names and values are looked up from.
+	cardinalityIndex *cardinalityindex.Loader
+
+	// The number of series per batch of chunks streamed by the store-gateways. 0 disables the streaming.
+	streamingChunksBatchSize uint64
+
+	// The readers of the streamed chunks, closed when the querier is closed.
+	streamReadersMtx sync.Mutex
+	streamReaders    []*storeGatewayStreamReader
 }
 
 // Select implements storage.Querier interface.
@@ -353,6 +481,12 @@ func (q *blocksStoreQuerier) LabelNames(matchers ...*labels.Matcher) ([]string,
 		minT = int64(clampTime(spanCtx, startTime, maxQueryLength, endTime.Add(-maxQueryLength), true, "start", "max label query length", spanLog))
 	}
 
+	if metricName, ok := metricNameFromMatchers(matchers); ok {
+		if blocks, ok := q.lookupCardinalityIndex(spanCtx, spanLog, minT, maxT); ok {
+			return pagination.FromContext(q.ctx).Apply(blocks.LabelNames(metricName)), nil, nil
+		}
+	}
+
 	var (
 		resNameSets       = [][]string{}
 		resWarnings       = storage.Warnings(nil)
@@ -376,7 +510,8 @@ func (q *blocksStoreQuerier) LabelNames(matchers ...*labels.Matcher) ([]string,
 		return nil, nil, err
 	}
 
-	return util.MergeSlices(resNameSets...), resWarnings, nil
+	// Each store-gateway returns a page of label names, so the merged names need to be paginated again.
+	return pagination.FromContext(q.ctx).Apply(util.MergeSlices(resNameSets...)), resWarnings, nil
 }
 
 func (q *blocksStoreQuerier) LabelValues(name string, matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
@@ -395,6 +530,16 @@ func (q *blocksStoreQuerier) LabelValues(name string, matchers ...*labels.Matche
 		minT = int64(clampTime(spanCtx, startTime, maxQueryLength, endTime.Add(-maxQueryLength), true, "start", "max label query length", spanLog))
 	}
 
+	if metricName, ok := metricNameFromMatchers(matchers); ok {
+		if blocks, ok := q.lookupCardinalityIndex(spanCtx, spanLog, minT, maxT); ok {
+			values := pagination.FromContext(q.ctx).Apply(blocks.LabelValues(name, metricName))
+			if err := validation.CheckLabelValuesResultsSizeBytes(values, q.limits.LabelValuesResultsMaxSizeBytes(q.userID)); err != nil {
+				return nil, nil, err
+			}
+			return values, nil, nil
+		}
+	}
+
 	var (
 		resValueSets = [][]string{}
 		resWarnings  = storage.Warnings(nil)
@@ -417,10 +562,73 @@ func (q *blocksStoreQuerier) LabelValues(name string, matchers ...*labels.Matche
 		return nil, nil, err
 	}
 
-	return util.MergeSlices(resValueSets...), resWarnings, nil
+	// Each store-gateway returns a page of label values, so the merged values need to be paginated again.
+	values := pagination.FromContext(q.ctx).Apply(util.MergeSlices(resValueSets...))
+	if err := validation.CheckLabelValuesResultsSizeBytes(values, q.limits.LabelValuesResultsMaxSizeBytes(q.userID)); err != nil {
+		return nil, nil, err
+	}
+
+	return values, resWarnings, nil
+}
+
+// lookupCardinalityIndex returns the cardinality summaries of the blocks to query in the input time range. It returns
+// false if the summaries are disabled, or if any of them is missing or can't be loaded, in which case the blocks
+// must be queried through the store-gateways.
+func (q *blocksStoreQuerier) lookupCardinalityIndex(ctx context.Context, logger log.Logger, minT, maxT int64) (cardinalityindex.Blocks, bool) {
+	if q.cardinalityIndex == nil || !q.limits.CompactorCardinalityIndexEnabled(q.userID) {
+		return nil, false
+	}
+
+	if q.queryStoreAfter > 0 {
+		maxT = math.Min(maxT, util.TimeToMillis(time.Now().Add(-q.queryStoreAfter)))
+	}
+	if maxT < minT {
+		// There are no blocks to query.
+		return nil, true
+	}
+
+	knownBlocks, _, err := q.finder.GetBlocks(ctx, q.userID, minT, maxT)
+	if err != nil {
+		return nil, false
+	}
+
+	blocks, err := q.cardinalityIndex.GetBlocks(ctx, q.userID, knownBlocks)
+	if errors.Is(err, cardinalityindex.ErrSummaryNotFound) {
+		level.Debug(logger).Log("msg", "some of the blocks to query have no cardinality summary, querying the store-gateways")
+		q.metrics.cardinalityIndexLookups.WithLabelValues("incomplete").Inc()
+		return nil, false
+	}
+	if err != nil {
+		level.Debug(logger).Log("msg", "unable to get the cardinality summaries of the blocks, querying the store-gateways", "err", err)
+		q.metrics.cardinalityIndexLookups.WithLabelValues("unavailable").Inc()
+		return nil, false
+	}
+
+	q.metrics.cardinalityIndexLookups.WithLabelValues("success").Inc()
+	return blocks, true
+}
+
+// metricNameFromMatchers returns the metric name the input matchers select, or an empty name if there are
+// no matchers. It returns false if the matchers select anything else, which can't be looked up from the
+// cardinality index.
+func metricNameFromMatchers(matchers []*labels.Matcher) (string, bool) {
+	switch {
+	case len(matchers) == 0:
+		return "", true
+	case len(matchers) == 1 && matchers[0].Name == labels.MetricName && matchers[0].Type == labels.MatchEqual && matchers[0].Value != "":
+		return matchers[0].Value, true
+	default:
+		return "", false
+	}
 }
 
 func (q *blocksStoreQuerier) Close() error {
+	q.streamReadersMtx.Lock()
+	defer q.streamReadersMtx.Unlock()
+
+	for _, r := range q.streamReaders {
+		r.Close()
+	}
 	return nil
 }
 
@@ -432,6 +640,7 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 
 	var (
 		convertedMatchers = convertMatchersToLabelMatcher(matchers)
+		selector          = limiter.Selector(matchers)
 		resSeriesSets     = []storage.SeriesSet(nil)
 		resWarnings       = storage.Warnings(nil)
 	)
@@ -442,7 +651,7 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 	}
 
 	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
-		seriesSets, queriedBlocks, warnings, err := q.fetchSeriesFromStores(spanCtx, sp, clients, minT, maxT, convertedMatchers)
+		seriesSets, queriedBlocks, warnings, err := q.fetchSeriesFromStores(spanCtx, sp, clients, minT, maxT, convertedMatchers, selector)
 		if err != nil {
 			return nil, err
 		}
@@ -453,7 +662,9 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 		return queriedBlocks, nil
 	}
 
+	start := time.Now()
 	err = q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, shard, queryFunc)
+	stats.FromContext(spanCtx).AddStoreGatewayFetchTime(time.Since(start))
 	if err != nil {
 		return storage.ErrSeriesSet(err)
 	}
@@ -467,6 +678,50 @@ func (q *blocksStoreQuerier) selectSorted(sp *storage.SelectHints, matchers ...*
 		resWarnings)
 }
 
+func (q *blocksStoreQuerier) selectExemplars(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	spanLog, spanCtx := spanlogger.NewWithLogger(q.ctx, q.logger, "blocksStoreQuerier.selectExemplars")
+	defer spanLog.Span.Finish()
+
+	level.Debug(spanLog).Log("start", util.TimeFromMillis(start).UTC().String(), "end",
+		util.TimeFromMillis(end).UTC().String(), "matchers", util.MultiMatchersStringer(matchers))
+
+	var (
+		resSeriesSets     = [][]storepb.SeriesExemplars{}
+		convertedMatchers = make([]storepb.ExemplarMatchers, 0, len(matchers))
+	)
+
+	for _, m := range matchers {
+		convertedMatchers = append(convertedMatchers, storepb.ExemplarMatchers{Matchers: convertMatchersToLabelMatcher(m)})
+	}
+
+	queryFunc := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
+		seriesSets, queriedBlocks, err := q.fetchExemplarsFromStores(spanCtx, clients, minT, maxT, convertedMatchers)
+		if err != nil {
+			return nil, err
+		}
+
+		resSeriesSets = append(resSeriesSets, seriesSets...)
+
+		return queriedBlocks, nil
+	}
+
+	err := q.queryWithConsistencyCheck(spanCtx, spanLog, start, end, nil, queryFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := block.MergeSeriesExemplars(resSeriesSets...)
+	res := make([]exemplar.QueryResult, 0, len(merged))
+	for _, s := range merged {
+		res = append(res, exemplar.QueryResult{
+			SeriesLabels: mimirpb.FromLabelAdaptersToLabels(s.Labels),
+			Exemplars:    mimirpb.FromExemplarProtosToExemplars(s.Exemplars),
+		})
+	}
+
+	return res, nil
+}
+
 func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT int64, shard *sharding.ShardSelector,
 	queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error)) error {
 	// If queryStoreAfter is enabled, we do manipulate the query maxt to query samples up until
@@ -676,19 +931,36 @@ func canBlockWithCompactorShardIndexContainQueryShard(queryShardIndex, queryShar
 // Errors while creating storepb.SeriesRequest, context cancellation, and unprocessable
 // requests to the store-gateways (e.g., if a chunk or series limit is hit) are
 // considered serious errors. All other errors are not returned, but they give rise to fetch retrials.
-func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *storage.SelectHints, clients map[BlocksStoreClient][]ulid.ULID, minT int64, maxT int64, convertedMatchers []storepb.LabelMatcher) ([]storage.SeriesSet, []ulid.ULID, storage.Warnings, error) {
+func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *storage.SelectHints, clients map[BlocksStoreClient][]ulid.ULID, minT int64, maxT int64, convertedMatchers []storepb.LabelMatcher, selector string) ([]storage.SeriesSet, []ulid.ULID, storage.Warnings, error) {
 	var (
-		reqCtx        = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, q.userID)
-		g, gCtx       = errgroup.WithContext(reqCtx)
-		mtx           = sync.Mutex{}
-		seriesSets    = []storage.SeriesSet(nil)
-		warnings      = storage.Warnings(nil)
-		queriedBlocks = []ulid.ULID(nil)
-		spanLog       = spanlogger.FromContext(ctx, q.logger)
-		queryLimiter  = limiter.QueryLimiterFromContextWithFallback(ctx)
-		reqStats      = stats.FromContext(ctx)
+		reqCtx          = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, q.userID)
+		g, gCtx         = errgroup.WithContext(reqCtx)
+		mtx             = sync.Mutex{}
+		seriesSets      = []storage.SeriesSet(nil)
+		warnings        = storage.Warnings(nil)
+		queriedBlocks   = []ulid.ULID(nil)
+		spanLog         = spanlogger.FromContext(ctx, q.logger)
+		queryLimiter    = limiter.QueryLimiterFromContextWithFallback(ctx)
+		selectorLimiter = limiter.SelectorSeriesLimiterFromContextWithFallback(ctx)
+		memoryTracker   = limiter.MemoryConsumptionTrackerFromContextWithFallback(ctx)
+		reqStats        = stats.FromContext(ctx)
 	)
 
+	// See: https://github.com/prometheus/prometheus/pull/8050
+	// TODO(goutham): we should ideally be passing the hints down to the storage layer
+	// and let the TSDB return us data with no chunks as in prometheus#8050.
+	// But this is an acceptable workaround for now.
+	skipChunks := sp != nil && sp.Func == "series"
+
+	var streamingChunksBatchSize uint64
+	if !skipChunks {
+		streamingChunksBatchSize = q.streamingChunksBatchSize
+	}
+
+	// The streams whose chunks are read while the series sets are iterated, after this function returns.
+	// They're closed if this function fails, otherwise they're closed by the querier.
+	var streamReaders []*storeGatewayStreamReader
+
 	// Concurrently fetch series from all clients.
 	for c, blockIDs := range clients {
 		// Change variables scope since it will be used in a goroutine.
@@ -696,18 +968,27 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 		blockIDs := blockIDs
 
 		g.Go(func() error {
-			// See: https://github.com/prometheus/prometheus/pull/8050
-			// TODO(goutham): we should ideally be passing the hints down to the storage layer
-			// and let the TSDB return us data with no chunks as in prometheus#8050.
-			// But this is an acceptable workaround for now.
-			skipChunks := sp != nil && sp.Func == "series"
-
-			req, err := createSeriesRequest(minT, maxT, convertedMatchers, skipChunks, blockIDs)
+			req, err := createSeriesRequest(minT, maxT, convertedMatchers, skipChunks, blockIDs, streamingChunksBatchSize)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create series request")
 			}
 
-			stream, err := c.Series(gCtx, req)
+			// When streaming chunks, the stream is read after the errgroup has completed, so it can't be
+			// bound to the errgroup context.
+			parentCtx := gCtx
+			if streamingChunksBatchSize > 0 {
+				parentCtx = reqCtx
+			}
+			streamCtx, cancelStream := context.WithCancel(parentCtx)
+
+			streamReleased := false
+			defer func() {
+				if !streamReleased {
+					cancelStream()
+				}
+			}()
+
+			stream, err := c.Series(streamCtx, req)
 			if err != nil {
 				if shouldStopQueryFunc(err) {
 					return err
@@ -718,11 +999,13 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 			}
 
 			mySeries := []*storepb.Series(nil)
+			myStreamingSeries := []labels.Labels(nil)
 			myWarnings := storage.Warnings(nil)
 			myQueriedBlocks := []ulid.ULID(nil)
 			indexBytesFetched := uint64(0)
+			endOfSeriesStream := false
 
-			for {
+			for !endOfSeriesStream {
 				// Ensure the context hasn't been canceled in the meanwhile (eg. an error occurred
 				// in another goroutine).
 				if gCtx.Err() != nil {
@@ -751,6 +1034,9 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 					if limitErr != nil {
 						return validation.LimitError(limitErr.Error())
 					}
+					if limitErr := selectorLimiter.AddSeries(selector, s.Labels); limitErr != nil {
+						return validation.LimitError(limitErr.Error())
+					}
 
 					chunksCount, chunksSize := countChunksAndBytes(s)
 					if chunkBytesLimitErr := queryLimiter.AddChunkBytes(chunksSize); chunkBytesLimitErr != nil {
@@ -759,6 +1045,17 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 					if chunkLimitErr := queryLimiter.AddChunks(chunksCount); chunkLimitErr != nil {
 						return validation.LimitError(chunkLimitErr.Error())
 					}
+
+					// The store-gateways only estimate the chunks when streaming them, otherwise the max estimated
+					// chunks limits are enforced on the chunks fetched with the series.
+					if limitErr := queryLimiter.AddEstimatedChunks(chunksCount, chunksSize); limitErr != nil {
+						return validation.LimitError(limitErr.Error())
+					}
+
+					// The series is retained in memory until the query completes.
+					if err := memoryTracker.IncreaseMemoryConsumption(uint64(s.Size())); err != nil {
+						return err
+					}
 				}
 
 				if w := resp.GetWarning(); w != "" {
@@ -782,9 +1079,42 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 				if s := resp.GetStats(); s != nil {
 					indexBytesFetched += s.FetchedIndexBytes
 				}
+
+				// The chunks of the streaming series are read from the stream while the series set is iterated.
+				if s := resp.GetStreamingSeries(); s != nil {
+					// Enforce the max estimated chunks limits, before any chunk is streamed.
+					if limitErr := queryLimiter.AddEstimatedChunks(int(s.EstimatedChunksCount), int(s.EstimatedChunksBytes)); limitErr != nil {
+						return validation.LimitError(limitErr.Error())
+					}
+
+					for _, series := range s.Series {
+						// Add series fingerprint to query limiter; will return error if we are over the limit
+						if limitErr := queryLimiter.AddSeries(series.Labels); limitErr != nil {
+							return validation.LimitError(limitErr.Error())
+						}
+						if limitErr := selectorLimiter.AddSeries(selector, series.Labels); limitErr != nil {
+							return validation.LimitError(limitErr.Error())
+						}
+
+						// The series labels are retained in memory until the query completes.
+						if err := memoryTracker.IncreaseMemoryConsumption(uint64(series.Size())); err != nil {
+							return err
+						}
+
+						myStreamingSeries = append(myStreamingSeries, mimirpb.FromLabelAdaptersToLabels(series.Labels))
+					}
+
+					endOfSeriesStream = s.IsEndOfSeriesStream
+				}
 			}
 
-			numSeries := len(mySeries)
+			var streamReader *storeGatewayStreamReader
+			if len(myStreamingSeries) > 0 {
+				streamReader = newStoreGatewayStreamReader(stream, cancelStream, c.RemoteAddress(), len(myStreamingSeries), queryLimiter, memoryTracker, reqStats)
+				streamReleased = true
+			}
+
+			numSeries := len(mySeries) + len(myStreamingSeries)
 			chunksFetched, chunkBytes := countChunksAndBytes(mySeries...)
 
 			reqStats.AddFetchedSeries(uint64(numSeries))
@@ -798,12 +1128,18 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 				"fetched chunk bytes", chunkBytes,
 				"fetched chunks", chunksFetched,
 				"fetched index bytes", indexBytesFetched,
+				"streaming chunks", streamReader != nil,
 				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "),
 				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "))
 
 			// Store the result.
 			mtx.Lock()
-			seriesSets = append(seriesSets, &blockQuerierSeriesSet{series: mySeries})
+			if streamReader != nil {
+				seriesSets = append(seriesSets, &blockStreamingQuerierSeriesSet{series: myStreamingSeries, streamReader: streamReader})
+				streamReaders = append(streamReaders, streamReader)
+			} else {
+				seriesSets = append(seriesSets, &blockQuerierSeriesSet{series: mySeries})
+			}
 			warnings = append(warnings, myWarnings...)
 			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
 			mtx.Unlock()
@@ -814,9 +1150,16 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 
 	// Wait until all client requests complete.
 	if err := g.Wait(); err != nil {
+		for _, r := range streamReaders {
+			r.Close()
+		}
 		return nil, nil, nil, err
 	}
 
+	q.streamReadersMtx.Lock()
+	q.streamReaders = append(q.streamReaders, streamReaders...)
+	q.streamReadersMtx.Unlock()
+
 	return seriesSets, queriedBlocks, warnings, nil
 }
 
@@ -858,7 +1201,7 @@ func (q *blocksStoreQuerier) fetchLabelNamesFromStore(
 		blockIDs := blockIDs
 
 		g.Go(func() error {
-			req, err := createLabelNamesRequest(minT, maxT, blockIDs, matchers)
+			req, err := createLabelNamesRequest(minT, maxT, blockIDs, matchers, pagination.FromContext(ctx))
 			if err != nil {
 				return errors.Wrapf(err, "failed to create label names request")
 			}
@@ -931,6 +1274,7 @@ func (q *blocksStoreQuerier) fetchLabelValuesFromStore(
 		warnings      = storage.Warnings(nil)
 		queriedBlocks = []ulid.ULID(nil)
 		spanLog       = spanlogger.FromContext(ctx, q.logger)
+		maxSizeBytes  = q.limits.LabelValuesResultsMaxSizeBytes(q.userID)
 	)
 
 	// Concurrently fetch series from all clients.
@@ -940,14 +1284,14 @@ func (q *blocksStoreQuerier) fetchLabelValuesFromStore(
 		blockIDs := blockIDs
 
 		g.Go(func() error {
-			req, err := createLabelValuesRequest(minT, maxT, name, blockIDs, matchers...)
+			req, err := createLabelValuesRequest(minT, maxT, name, blockIDs, pagination.FromContext(ctx), maxSizeBytes, matchers...)
 			if err != nil {
 				return errors.Wrapf(err, "failed to create label values request")
 			}
 
 			valuesResp, err := c.LabelValues(gCtx, req)
 			if err != nil {
-				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				if shouldStopQueryFunc(err) {
 					return err
 				}
 				level.Warn(spanLog).Log("msg", "failed to fetch label values", "remote", c.RemoteAddress(), "err", err)
@@ -999,7 +1343,84 @@ func (q *blocksStoreQuerier) fetchLabelValuesFromStore(
 	return valueSets, warnings, queriedBlocks, nil
 }
 
-func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skipChunks bool, blockIDs []ulid.ULID) (*storepb.SeriesRequest, error) {
+func (q *blocksStoreQuerier) fetchExemplarsFromStores(
+	ctx context.Context,
+	clients map[BlocksStoreClient][]ulid.ULID,
+	minT int64,
+	maxT int64,
+	matchers []storepb.ExemplarMatchers,
+) ([][]storepb.SeriesExemplars, []ulid.ULID, error) {
+	var (
+		reqCtx        = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, q.userID)
+		g, gCtx       = errgroup.WithContext(reqCtx)
+		mtx           = sync.Mutex{}
+		seriesSets    = [][]storepb.SeriesExemplars{}
+		queriedBlocks = []ulid.ULID(nil)
+		spanLog       = spanlogger.FromContext(ctx, q.logger)
+	)
+
+	// Concurrently fetch exemplars from all clients.
+	for c, blockIDs := range clients {
+		// Change variables scope since it will be used in a goroutine.
+		c := c
+		blockIDs := blockIDs
+
+		g.Go(func() error {
+			req, err := createExemplarsRequest(minT, maxT, blockIDs, matchers)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create exemplars request")
+			}
+
+			exemplarsResp, err := c.Exemplars(gCtx, req)
+			if err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+
+				level.Warn(spanLog).Log("msg", "failed to fetch exemplars", "remote", c.RemoteAddress(), "err", err)
+				return nil
+			}
+
+			myQueriedBlocks := []ulid.ULID(nil)
+			if exemplarsResp.Hints != nil {
+				hints := hintspb.ExemplarsResponseHints{}
+				if err := types.UnmarshalAny(exemplarsResp.Hints, &hints); err != nil {
+					return errors.Wrapf(err, "failed to unmarshal exemplars hints from %s", c.RemoteAddress())
+				}
+
+				ids, err := convertBlockHintsToULIDs(hints.QueriedBlocks)
+				if err != nil {
+					return errors.Wrapf(err, "failed to parse queried block IDs from received hints")
+				}
+
+				myQueriedBlocks = ids
+			}
+
+			level.Debug(spanLog).Log("msg", "received exemplars from store-gateway",
+				"instance", c.RemoteAddress(),
+				"num series", len(exemplarsResp.Series),
+				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "),
+				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "))
+
+			// Store the result.
+			mtx.Lock()
+			seriesSets = append(seriesSets, exemplarsResp.Series)
+			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	// Wait until all client requests complete.
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return seriesSets, queriedBlocks, nil
+}
+
+func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skipChunks bool, blockIDs []ulid.ULID, streamingChunksBatchSize uint64) (*storepb.SeriesRequest, error) {
 	// Selectively query only specific blocks.
 	hints := &hintspb.SeriesRequestHints{
 		BlockMatchers: []storepb.LabelMatcher{
@@ -1022,14 +1443,18 @@ func createSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, skip
 		Matchers:   matchers,
 		Hints:      anyHints,
 		SkipChunks: skipChunks,
+		// The store-gateways not supporting the streaming of chunks ignore this field, and send the series with their chunks.
+		StreamingChunksBatchSize: streamingChunksBatchSize,
 	}, nil
 }
 
-func createLabelNamesRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers []storepb.LabelMatcher) (*storepb.LabelNamesRequest, error) {
+func createLabelNamesRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers []storepb.LabelMatcher, page pagination.Request) (*storepb.LabelNamesRequest, error) {
 	req := &storepb.LabelNamesRequest{
 		Start:    minT,
 		End:      maxT,
 		Matchers: matchers,
+		Limit:    int64(page.Limit),
+		After:    page.After,
 	}
 
 	// Selectively query only specific blocks.
@@ -1053,12 +1478,15 @@ func createLabelNamesRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers []
 	return req, nil
 }
 
-func createLabelValuesRequest(minT, maxT int64, label string, blockIDs []ulid.ULID, matchers ...*labels.Matcher) (*storepb.LabelValuesRequest, error) {
+func createLabelValuesRequest(minT, maxT int64, label string, blockIDs []ulid.ULID, page pagination.Request, maxSizeBytes int, matchers ...*labels.Matcher) (*storepb.LabelValuesRequest, error) {
 	req := &storepb.LabelValuesRequest{
-		Start:    minT,
-		End:      maxT,
-		Label:    label,
-		Matchers: convertMatchersToLabelMatcher(matchers),
+		Start:        minT,
+		End:          maxT,
+		Label:        label,
+		Matchers:     convertMatchersToLabelMatcher(matchers),
+		Limit:        int64(page.Limit),
+		After:        page.After,
+		MaxSizeBytes: int64(maxSizeBytes),
 	}
 
 	// Selectively query only specific blocks.
@@ -1082,6 +1510,34 @@ func createLabelValuesRequest(minT, maxT int64, label string, blockIDs []ulid.UL
 	return req, nil
 }
 
+func createExemplarsRequest(minT, maxT int64, blockIDs []ulid.ULID, matchers []storepb.ExemplarMatchers) (*storepb.ExemplarsRequest, error) {
+	req := &storepb.ExemplarsRequest{
+		Start:    minT,
+		End:      maxT,
+		Matchers: matchers,
+	}
+
+	// Selectively query only specific blocks.
+	hints := &hintspb.ExemplarsRequestHints{
+		BlockMatchers: []storepb.LabelMatcher{
+			{
+				Type:  storepb.LabelMatcher_RE,
+				Name:  block.BlockIDLabel,
+				Value: strings.Join(convertULIDsToString(blockIDs), "|"),
+			},
+		},
+	}
+
+	anyHints, err := types.MarshalAny(hints)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal exemplars request hints")
+	}
+
+	req.Hints = anyHints
+
+	return req, nil
+}
+
 func convertULIDsToString(ids []ulid.ULID) []string {
 	res := make([]string, len(ids))
 	for idx, id := range ids {
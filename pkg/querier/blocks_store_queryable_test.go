@@ -11,6 +11,10 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -22,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
@@ -29,16 +34,22 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
 	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/sharding"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/cardinalityindex"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/storegateway/testhelper"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/limiter"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -59,7 +70,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 		metricNameLabel  = labels.FromStrings(labels.MetricName, metricName)
 		series1Label     = labels.FromStrings(labels.MetricName, metricName, "series", "1")
 		series2Label     = labels.FromStrings(labels.MetricName, metricName, "series", "2")
-		noOpQueryLimiter = limiter.NewQueryLimiter(0, 0, 0)
+		noOpQueryLimiter = limiter.NewQueryLimiter(0, 0, 0, 0, 0, nil)
 	)
 
 	type valueResult struct {
@@ -78,10 +89,13 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 		storeSetResponses []interface{}
 		limits            BlocksStoreLimits
 		queryLimiter      *limiter.QueryLimiter
+		selectorLimiter   *limiter.SelectorSeriesLimiter
+		memoryTracker     *limiter.MemoryConsumptionTracker
 		expectedSeries    []seriesResult
 		expectedErr       error
 		expectedMetrics   string
 		queryShardID      string
+		streamingChunks   bool
 	}{
 		"no block in the storage matching the query time range": {
 			finderResult: nil,
@@ -134,6 +148,64 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 				},
 			},
 		},
+		"a single store-gateway instance holds the required blocks (streaming chunks)": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockHintsResponse(block1, block2),
+						mockStreamingSeriesBatchResponse(false, metricNameLabel, series1Label),
+						mockStreamingSeriesBatchResponse(true, series2Label),
+						mockStreamingChunksBatchResponse(
+							mockStreamingChunks(0, minT, 1),
+							mockStreamingChunks(1, minT, 2),
+						),
+						mockStreamingChunksBatchResponse(
+							mockStreamingChunks(2, minT+1, 3),
+						),
+						mockStatsResponse(50),
+					}}: {block1, block2},
+				},
+			},
+			limits:          &blocksStoreLimitsMock{},
+			queryLimiter:    noOpQueryLimiter,
+			streamingChunks: true,
+			expectedSeries: []seriesResult{
+				{lbls: metricNameLabel, values: []valueResult{{t: minT, v: 1}}},
+				{lbls: series1Label, values: []valueResult{{t: minT, v: 2}}},
+				{lbls: series2Label, values: []valueResult{{t: minT + 1, v: 3}}},
+			},
+		},
+		"multiple store-gateway instances holds the required blocks without overlapping series (streaming chunks)": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockHintsResponse(block1),
+						mockStreamingSeriesBatchResponse(true, series1Label),
+						mockStreamingChunksBatchResponse(mockStreamingChunks(0, minT, 1)),
+					}}: {block1},
+					&storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockHintsResponse(block2),
+						mockStreamingSeriesBatchResponse(true, series2Label),
+						mockStreamingChunksBatchResponse(mockStreamingChunks(0, minT, 2)),
+					}}: {block2},
+				},
+			},
+			limits:          &blocksStoreLimitsMock{},
+			queryLimiter:    noOpQueryLimiter,
+			streamingChunks: true,
+			expectedSeries: []seriesResult{
+				{lbls: series1Label, values: []valueResult{{t: minT, v: 1}}},
+				{lbls: series2Label, values: []valueResult{{t: minT, v: 2}}},
+			},
+		},
 		"a single store-gateway instance holds the required blocks (multiple returned series)": {
 			finderResult: bucketindex.Blocks{
 				{ID: block1},
@@ -481,7 +553,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 				},
 			},
 			limits:       &blocksStoreLimitsMock{},
-			queryLimiter: limiter.NewQueryLimiter(0, 0, 1),
+			queryLimiter: limiter.NewQueryLimiter(0, 0, 1, 0, 0, nil),
 			expectedErr:  validation.LimitError(fmt.Sprintf(limiter.MaxChunksPerQueryLimitMsgFormat, 1)),
 		},
 		"max chunks per query limit hit while fetching chunks during subsequent attempts": {
@@ -519,7 +591,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 				},
 			},
 			limits:       &blocksStoreLimitsMock{},
-			queryLimiter: limiter.NewQueryLimiter(0, 0, 3),
+			queryLimiter: limiter.NewQueryLimiter(0, 0, 3, 0, 0, nil),
 			expectedErr:  validation.LimitError(fmt.Sprintf(limiter.MaxChunksPerQueryLimitMsgFormat, 3)),
 		},
 		"max series per query limit hit while fetching chunks": {
@@ -537,9 +609,28 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 				},
 			},
 			limits:       &blocksStoreLimitsMock{},
-			queryLimiter: limiter.NewQueryLimiter(1, 0, 0),
+			queryLimiter: limiter.NewQueryLimiter(1, 0, 0, 0, 0, nil),
 			expectedErr:  validation.LimitError(fmt.Sprintf(limiter.MaxSeriesHitMsgFormat, 1)),
 		},
+		"max series per selector limit hit while fetching chunks": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockSeriesResponse(series1Label, minT, 1),
+						mockSeriesResponse(series2Label, minT+1, 2),
+						mockHintsResponse(block1, block2),
+					}}: {block1, block2},
+				},
+			},
+			limits:          &blocksStoreLimitsMock{},
+			queryLimiter:    noOpQueryLimiter,
+			selectorLimiter: limiter.NewSelectorSeriesLimiter(1, nil),
+			expectedErr:     validation.LimitError(fmt.Sprintf(limiter.MaxSeriesPerSelectorHitMsgFormat, `{__name__="test_metric"}`, 1)),
+		},
 		"max chunk bytes per query limit hit while fetching chunks": {
 			finderResult: bucketindex.Blocks{
 				{ID: block1},
@@ -555,9 +646,116 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 				},
 			},
 			limits:       &blocksStoreLimitsMock{maxChunksPerQuery: 1},
-			queryLimiter: limiter.NewQueryLimiter(0, 8, 0),
+			queryLimiter: limiter.NewQueryLimiter(0, 8, 0, 0, 0, nil),
 			expectedErr:  validation.LimitError(fmt.Sprintf(limiter.MaxChunkBytesHitMsgFormat, 8)),
 		},
+		"max estimated chunks per query limit hit before streaming chunks": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockHintsResponse(block1, block2),
+						withEstimatedChunks(mockStreamingSeriesBatchResponse(false, series1Label), 2, 100),
+						withEstimatedChunks(mockStreamingSeriesBatchResponse(true, series2Label), 2, 100),
+						mockStreamingChunksBatchResponse(
+							mockStreamingChunks(0, minT, 1),
+							mockStreamingChunks(1, minT, 2),
+						),
+					}}: {block1, block2},
+				},
+			},
+			limits:          &blocksStoreLimitsMock{},
+			queryLimiter:    limiter.NewQueryLimiter(0, 0, 0, 3, 0, nil),
+			streamingChunks: true,
+			expectedErr:     validation.LimitError(fmt.Sprintf(limiter.MaxEstimatedChunksPerQueryLimitMsgFormat, 3)),
+		},
+		"max estimated chunk bytes per query limit hit before streaming chunks": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockHintsResponse(block1, block2),
+						withEstimatedChunks(mockStreamingSeriesBatchResponse(true, series1Label, series2Label), 2, 200),
+						mockStreamingChunksBatchResponse(
+							mockStreamingChunks(0, minT, 1),
+							mockStreamingChunks(1, minT, 2),
+						),
+					}}: {block1, block2},
+				},
+			},
+			limits:          &blocksStoreLimitsMock{},
+			queryLimiter:    limiter.NewQueryLimiter(0, 0, 0, 0, 150, nil),
+			streamingChunks: true,
+			expectedErr:     validation.LimitError(fmt.Sprintf(limiter.MaxEstimatedChunkBytesHitMsgFormat, 150)),
+		},
+		"max estimated chunks per query limit hit while fetching chunks without streaming": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockSeriesResponse(series1Label, minT, 1),
+						mockSeriesResponse(series2Label, minT+1, 2),
+						mockHintsResponse(block1, block2),
+					}}: {block1, block2},
+				},
+			},
+			limits:       &blocksStoreLimitsMock{},
+			queryLimiter: limiter.NewQueryLimiter(0, 0, 0, 1, 0, nil),
+			expectedErr:  validation.LimitError(fmt.Sprintf(limiter.MaxEstimatedChunksPerQueryLimitMsgFormat, 1)),
+		},
+		"max estimated chunks per query limit not hit": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockHintsResponse(block1, block2),
+						withEstimatedChunks(mockStreamingSeriesBatchResponse(true, series1Label, series2Label), 2, 200),
+						mockStreamingChunksBatchResponse(
+							mockStreamingChunks(0, minT, 1),
+							mockStreamingChunks(1, minT, 2),
+						),
+					}}: {block1, block2},
+				},
+			},
+			limits:          &blocksStoreLimitsMock{},
+			queryLimiter:    limiter.NewQueryLimiter(0, 0, 0, 2, 200, nil),
+			streamingChunks: true,
+			expectedSeries: []seriesResult{
+				{lbls: series1Label, values: []valueResult{{t: minT, v: 1}}},
+				{lbls: series2Label, values: []valueResult{{t: minT, v: 2}}},
+			},
+		},
+		"max estimated memory consumption per query limit hit while fetching series": {
+			finderResult: bucketindex.Blocks{
+				{ID: block1},
+				{ID: block2},
+			},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+						mockSeriesResponse(series1Label, minT, 1),
+						mockSeriesResponse(series2Label, minT+1, 2),
+						mockHintsResponse(block1, block2),
+					}}: {block1, block2},
+				},
+			},
+			limits:        &blocksStoreLimitsMock{},
+			queryLimiter:  noOpQueryLimiter,
+			memoryTracker: limiter.NewMemoryConsumptionTracker(10, nil),
+			expectedErr:   validation.LimitError(fmt.Sprintf(limiter.MaxEstimatedMemoryPerQueryHitMsgFormat, 10)),
+		},
 		"blocks with non-matching shard are filtered out": {
 			finderResult: bucketindex.Blocks{
 				{ID: block1, CompactorShardID: "1_of_4"},
@@ -764,6 +962,12 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
 			ctx := limiter.AddQueryLimiterToContext(context.Background(), testData.queryLimiter)
+			if testData.selectorLimiter != nil {
+				ctx = limiter.AddSelectorSeriesLimiterToContext(ctx, testData.selectorLimiter)
+			}
+			if testData.memoryTracker != nil {
+				ctx = limiter.AddMemoryConsumptionTrackerToContext(ctx, testData.memoryTracker)
+			}
 			reg := prometheus.NewPedanticRegistry()
 			stores := &blocksStoreSetMock{mockedResponses: testData.storeSetResponses}
 			finder := &blocksFinderMock{}
@@ -781,6 +985,10 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 				metrics:     newBlocksStoreQueryableMetrics(reg),
 				limits:      testData.limits,
 			}
+			if testData.streamingChunks {
+				q.streamingChunksBatchSize = 256
+			}
+			defer func() { require.NoError(t, q.Close()) }()
 
 			matchers := []*labels.Matcher{
 				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName),
@@ -847,7 +1055,7 @@ func TestBlocksStoreQuerier_Select_cancelledContext(t *testing.T) {
 
 	var (
 		block            = ulid.MustNew(1, nil)
-		noOpQueryLimiter = limiter.NewQueryLimiter(0, 0, 0)
+		noOpQueryLimiter = limiter.NewQueryLimiter(0, 0, 0, 0, 0, nil)
 	)
 
 	canceledRequestTests := map[string]bool{
@@ -1482,6 +1690,377 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 	})
 }
 
+func TestBlocksStoreQuerier_LabelValuesMaxSizeBytes(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	var (
+		block1 = ulid.MustNew(1, nil)
+		block2 = ulid.MustNew(2, nil)
+	)
+
+	tests := map[string]struct {
+		maxSizeBytes   int
+		expectedValues []string
+		expectedErr    string
+	}{
+		"no limit": {
+			maxSizeBytes:   0,
+			expectedValues: []string{"aaa", "bbb", "ccc"},
+		},
+		"the label values don't exceed the limit": {
+			maxSizeBytes:   9,
+			expectedValues: []string{"aaa", "bbb", "ccc"},
+		},
+		"the label values returned by a store-gateway exceed the limit": {
+			maxSizeBytes: 5,
+			expectedErr:  "rpc error: code = Code(422) desc = " + validation.NewMaxLabelValuesResultsSizeBytesError(5).Error(),
+		},
+		"the merged label values exceed the limit": {
+			maxSizeBytes: 8,
+			expectedErr:  validation.NewMaxLabelValuesResultsSizeBytesError(8).Error(),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			// The store-gateways are not retried when they fail because of the limit.
+			stores := &blocksStoreSetMock{mockedResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&maxSizeBytesStoreGatewayClientMock{storeGatewayClientMock: storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedLabelValuesResponse: &storepb.LabelValuesResponse{
+						Values: []string{"aaa", "bbb"},
+						Hints:  mockValuesHints(block1),
+					}}}: {block1},
+					&maxSizeBytesStoreGatewayClientMock{storeGatewayClientMock: storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedLabelValuesResponse: &storepb.LabelValuesResponse{
+						Values: []string{"bbb", "ccc"},
+						Hints:  mockValuesHints(block2),
+					}}}: {block2},
+				},
+				errors.New("no store-gateway remaining after exclude"),
+			}}
+
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{{ID: block1}, {ID: block2}}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+			q := &blocksStoreQuerier{
+				ctx:         user.InjectOrgID(context.Background(), "user-1"),
+				minT:        minT,
+				maxT:        maxT,
+				userID:      "user-1",
+				finder:      finder,
+				stores:      stores,
+				consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(nil),
+				limits:      &blocksStoreLimitsMock{labelValuesResultsMaxSizeBytes: testData.maxSizeBytes},
+			}
+
+			values, _, err := q.LabelValues(labels.MetricName)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedValues, values)
+		})
+	}
+}
+
+func TestBlocksStoreQuerier_LabelsFromCardinalityIndex(t *testing.T) {
+	const (
+		userID = "user-1"
+		minT   = int64(10)
+		maxT   = int64(20)
+	)
+
+	var (
+		block1 = ulid.MustNew(1, nil)
+		block2 = ulid.MustNew(2, nil)
+		block3 = ulid.MustNew(3, nil)
+	)
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	uploadCardinalitySummary(t, bkt, userID, block1, []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "job", "a"),
+		labels.FromStrings(labels.MetricName, "cpu", "mode", "user"),
+		labels.FromStrings(labels.MetricName, "cpu", "mode", "system"),
+	})
+	uploadCardinalitySummary(t, bkt, userID, block2, []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "job", "a"),
+		labels.FromStrings(labels.MetricName, "up", "job", "b"),
+		labels.FromStrings(labels.MetricName, "cpu", "mode", "user"),
+	})
+
+	tests := map[string]struct {
+		queriedBlocks  []ulid.ULID
+		matchers       []*labels.Matcher
+		disabled       bool
+		expectedNames  []string
+		expectedValues []string
+		expectedResult string
+	}{
+		"all the queried blocks have a summary": {
+			queriedBlocks:  []ulid.ULID{block1, block2},
+			expectedNames:  []string{"__name__", "job", "mode"},
+			expectedValues: []string{"a", "b"},
+			expectedResult: "success",
+		},
+		"metric name matcher": {
+			queriedBlocks:  []ulid.ULID{block1, block2},
+			matchers:       []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "cpu")},
+			expectedNames:  []string{"__name__", "mode"},
+			expectedValues: []string{},
+			expectedResult: "success",
+		},
+		"some queried blocks have no summary": {
+			queriedBlocks:  []ulid.ULID{block1, block3},
+			expectedNames:  []string{"from_store_gateway"},
+			expectedValues: []string{"from_store_gateway"},
+			expectedResult: "incomplete",
+		},
+		"matchers not supported by the summaries": {
+			queriedBlocks:  []ulid.ULID{block1, block2},
+			matchers:       []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "a")},
+			expectedNames:  []string{"from_store_gateway"},
+			expectedValues: []string{"from_store_gateway"},
+		},
+		"cardinality index disabled for the tenant": {
+			queriedBlocks:  []ulid.ULID{block1, block2},
+			disabled:       true,
+			expectedNames:  []string{"from_store_gateway"},
+			expectedValues: []string{"from_store_gateway"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			finderBlocks := bucketindex.Blocks{}
+			for _, id := range testData.queriedBlocks {
+				finderBlocks = append(finderBlocks, &bucketindex.Block{ID: id})
+			}
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, userID, minT, maxT).Return(finderBlocks, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+			client := &storeGatewayClientMock{
+				remoteAddr:                "1.1.1.1",
+				mockedLabelNamesResponse:  &storepb.LabelNamesResponse{Names: []string{"from_store_gateway"}, Hints: mockNamesHints(testData.queriedBlocks...)},
+				mockedLabelValuesResponse: &storepb.LabelValuesResponse{Values: []string{"from_store_gateway"}, Hints: mockValuesHints(testData.queriedBlocks...)},
+			}
+			stores := &blocksStoreSetMock{mockedResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{client: testData.queriedBlocks},
+				map[BlocksStoreClient][]ulid.ULID{client: testData.queriedBlocks},
+			}}
+
+			cardinalityIndex, err := cardinalityindex.NewLoader(time.Minute, cardinalityindex.DefaultMaxLoadedSymbols, bkt, nil, logger, nil)
+			require.NoError(t, err)
+
+			reg := prometheus.NewPedanticRegistry()
+			q := &blocksStoreQuerier{
+				ctx:              user.InjectOrgID(ctx, userID),
+				minT:             minT,
+				maxT:             maxT,
+				userID:           userID,
+				finder:           finder,
+				stores:           stores,
+				consistency:      NewBlocksConsistencyChecker(0, 0, logger, nil),
+				logger:           logger,
+				metrics:          newBlocksStoreQueryableMetrics(reg),
+				limits:           &blocksStoreLimitsMock{cardinalityIndexEnabled: !testData.disabled},
+				cardinalityIndex: cardinalityIndex,
+			}
+
+			names, _, err := q.LabelNames(testData.matchers...)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedNames, names)
+
+			values, _, err := q.LabelValues("job", testData.matchers...)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedValues, values)
+
+			expectedMetrics := ""
+			if testData.expectedResult != "" {
+				expectedMetrics = fmt.Sprintf(`
+					# HELP cortex_querier_cardinality_index_lookups_total Number of label names and values requests looked up from the cardinality summaries of the blocks, by result. Requests which couldn't be looked up are sent to the store-gateways.
+					# TYPE cortex_querier_cardinality_index_lookups_total counter
+					cortex_querier_cardinality_index_lookups_total{result="%s"} 2
+				`, testData.expectedResult)
+			}
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_querier_cardinality_index_lookups_total"))
+		})
+	}
+}
+
+// uploadCardinalitySummary uploads the cardinality summary of a block with the input series, as written by the compactor.
+func uploadCardinalitySummary(t *testing.T, bkt objstore.Bucket, userID string, blockID ulid.ULID, series []labels.Labels) {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	id, err := testhelper.CreateBlock(ctx, dir, series, 1, 0, 10, labels.EmptyLabels())
+	require.NoError(t, err)
+	require.NoError(t, cardinalityindex.WriteSummaryFile(filepath.Join(dir, id.String())))
+
+	f, err := os.Open(filepath.Join(dir, id.String(), block.CardinalitySummaryFilename))
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, blockID.String(), block.CardinalitySummaryFilename), f))
+}
+
+func TestBlocksStoreQuerier_SelectExemplars(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	var (
+		block1  = ulid.MustNew(1, nil)
+		block2  = ulid.MustNew(2, nil)
+		series1 = labels.FromStrings(labels.MetricName, "series_1")
+		series2 = labels.FromStrings(labels.MetricName, "series_2")
+		ex1     = mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "1"}}, TimestampMs: 11, Value: 1}
+		ex2     = mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "2"}}, TimestampMs: 12, Value: 2}
+		ex3     = mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "3"}}, TimestampMs: 13, Value: 3}
+	)
+
+	tests := map[string]struct {
+		finderResult      bucketindex.Blocks
+		storeSetResponses []interface{}
+		expected          []exemplar.QueryResult
+		expectedErr       string
+	}{
+		"no block in the storage matching the query time range": {
+			finderResult: nil,
+			expected:     []exemplar.QueryResult{},
+		},
+		"a single store-gateway instance holds the required blocks": {
+			finderResult: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr: "1.1.1.1",
+						mockedExemplarsResponse: &storepb.ExemplarsResponse{
+							Series: []storepb.SeriesExemplars{
+								{Labels: mimirpb.FromLabelsToLabelAdapters(series1), Exemplars: []mimirpb.Exemplar{ex1, ex2}},
+							},
+							Hints: mockExemplarsHints(block1, block2),
+						},
+					}: {block1, block2},
+				},
+			},
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: series1, Exemplars: mimirpb.FromExemplarProtosToExemplars([]mimirpb.Exemplar{ex1, ex2})},
+			},
+		},
+		"multiple store-gateway instances holds the required blocks with overlapping series": {
+			finderResult: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr: "1.1.1.1",
+						mockedExemplarsResponse: &storepb.ExemplarsResponse{
+							Series: []storepb.SeriesExemplars{
+								{Labels: mimirpb.FromLabelsToLabelAdapters(series2), Exemplars: []mimirpb.Exemplar{ex3}},
+								{Labels: mimirpb.FromLabelsToLabelAdapters(series1), Exemplars: []mimirpb.Exemplar{ex2}},
+							},
+							Hints: mockExemplarsHints(block1),
+						},
+					}: {block1},
+					&storeGatewayClientMock{
+						remoteAddr: "2.2.2.2",
+						mockedExemplarsResponse: &storepb.ExemplarsResponse{
+							Series: []storepb.SeriesExemplars{
+								{Labels: mimirpb.FromLabelsToLabelAdapters(series1), Exemplars: []mimirpb.Exemplar{ex1, ex2}},
+							},
+							Hints: mockExemplarsHints(block2),
+						},
+					}: {block2},
+				},
+			},
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: series1, Exemplars: mimirpb.FromExemplarProtosToExemplars([]mimirpb.Exemplar{ex1, ex2})},
+				{SeriesLabels: series2, Exemplars: mimirpb.FromExemplarProtosToExemplars([]mimirpb.Exemplar{ex3})},
+			},
+		},
+		"a store-gateway instance fails to query exemplars and the query is retried on another instance": {
+			finderResult: bucketindex.Blocks{{ID: block1}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr:         "1.1.1.1",
+						mockedExemplarsErr: errors.New("failed to query exemplars"),
+					}: {block1},
+				},
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr: "2.2.2.2",
+						mockedExemplarsResponse: &storepb.ExemplarsResponse{
+							Series: []storepb.SeriesExemplars{
+								{Labels: mimirpb.FromLabelsToLabelAdapters(series1), Exemplars: []mimirpb.Exemplar{ex1}},
+							},
+							Hints: mockExemplarsHints(block1),
+						},
+					}: {block1},
+				},
+			},
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: series1, Exemplars: mimirpb.FromExemplarProtosToExemplars([]mimirpb.Exemplar{ex1})},
+			},
+		},
+		"all store-gateway instances fail to query exemplars": {
+			finderResult: bucketindex.Blocks{{ID: block1}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr:         "1.1.1.1",
+						mockedExemplarsErr: errors.New("failed to query exemplars"),
+					}: {block1},
+				},
+				errors.New("no store-gateway remaining after exclude"),
+			},
+			expectedErr: "failed to fetch some blocks",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			stores := &blocksStoreSetMock{mockedResponses: testData.storeSetResponses}
+			finder := &blocksFinderMock{}
+			finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(testData.finderResult, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+			q := &blocksStoreExemplarQuerier{
+				querier: &blocksStoreQuerier{
+					ctx:         ctx,
+					minT:        minT,
+					maxT:        maxT,
+					userID:      "user-1",
+					finder:      finder,
+					stores:      stores,
+					consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+					logger:      log.NewNopLogger(),
+					metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+					limits:      &blocksStoreLimitsMock{},
+				},
+			}
+
+			res, err := q.Select(minT, maxT, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")})
+			if testData.expectedErr != "" {
+				require.ErrorContains(t, err, testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, testData.expected, res)
+		})
+	}
+}
+
 func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T) {
 	now := time.Now()
 
@@ -1560,6 +2139,15 @@ func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T)
 	}
 }
 
+func TestBlocksStoreQueryable_QueryStoreAfterForTenant(t *testing.T) {
+	limits := &blocksStoreLimitsMock{}
+	q := &BlocksStoreQueryable{limits: limits, queryStoreAfter: time.Hour}
+	assert.Equal(t, time.Hour, q.queryStoreAfterForTenant("user-1"))
+
+	limits.queryStoreAfter = 2 * time.Hour
+	assert.Equal(t, 2*time.Hour, q.queryStoreAfterForTenant("user-1"))
+}
+
 func TestBlocksStoreQuerier_MaxLabelsQueryRange(t *testing.T) {
 	const (
 		engineLookbackDelta = 5 * time.Minute
@@ -1732,7 +2320,7 @@ func TestBlocksStoreQuerier_PromQLExecution(t *testing.T) {
 
 			// Instantiate the querier that will be executed to run the query.
 			logger := log.NewNopLogger()
-			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, logger, nil)
+			queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, 0, nil, logger, nil)
 			require.NoError(t, err)
 			require.NoError(t, services.StartAndAwaitRunning(context.Background(), queryable))
 			defer services.StopAndAwaitTerminated(context.Background(), queryable) // nolint:errcheck
@@ -1902,6 +2490,8 @@ type storeGatewayClientMock struct {
 	mockedLabelNamesErr       error
 	mockedLabelValuesResponse *storepb.LabelValuesResponse
 	mockedLabelValuesErr      error
+	mockedExemplarsResponse   *storepb.ExemplarsResponse
+	mockedExemplarsErr        error
 }
 
 func (m *storeGatewayClientMock) Series(ctx context.Context, in *storepb.SeriesRequest, opts ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
@@ -1920,6 +2510,14 @@ func (m *storeGatewayClientMock) LabelValues(context.Context, *storepb.LabelValu
 	return m.mockedLabelValuesResponse, m.mockedLabelValuesErr
 }
 
+func (m *storeGatewayClientMock) Exemplars(context.Context, *storepb.ExemplarsRequest, ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	return m.mockedExemplarsResponse, m.mockedExemplarsErr
+}
+
+func (m *storeGatewayClientMock) SyncTenants(context.Context, *storegatewaypb.SyncTenantsRequest, ...grpc.CallOption) (*storegatewaypb.SyncTenantsResponse, error) {
+	return &storegatewaypb.SyncTenantsResponse{}, nil
+}
+
 func (m *storeGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
@@ -1982,14 +2580,46 @@ func (m *cancelerStoreGatewayClientMock) LabelValues(ctx context.Context, _ *sto
 	return nil, ctx.Err()
 }
 
+func (m *cancelerStoreGatewayClientMock) Exemplars(ctx context.Context, _ *storepb.ExemplarsRequest, _ ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	m.cancel()
+	return nil, ctx.Err()
+}
+
+func (m *cancelerStoreGatewayClientMock) SyncTenants(ctx context.Context, _ *storegatewaypb.SyncTenantsRequest, _ ...grpc.CallOption) (*storegatewaypb.SyncTenantsResponse, error) {
+	m.cancel()
+	return nil, ctx.Err()
+}
+
 func (m *cancelerStoreGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
 
+// maxSizeBytesStoreGatewayClientMock enforces the max size of the label values like the store-gateway does.
+type maxSizeBytesStoreGatewayClientMock struct {
+	storeGatewayClientMock
+}
+
+func (m *maxSizeBytesStoreGatewayClientMock) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest, opts ...grpc.CallOption) (*storepb.LabelValuesResponse, error) {
+	resp, err := m.storeGatewayClientMock.LabelValues(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.CheckLabelValuesResultsSizeBytes(resp.Values, int(req.MaxSizeBytes)); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusUnprocessableEntity, err.Error())
+	}
+	return resp, nil
+}
+
 type blocksStoreLimitsMock struct {
-	maxLabelsQueryLength        time.Duration
-	maxChunksPerQuery           int
-	storeGatewayTenantShardSize int
+	maxLabelsQueryLength                time.Duration
+	maxChunksPerQuery                   int
+	labelValuesResultsMaxSizeBytes      int
+	queryStoreAfter                     time.Duration
+	storeGatewayTenantShardSize         int
+	storeGatewayTenantReplicationFactor int
+	storeGatewayLargeTenantThreshold    int
+	storeGatewayLargeTenantShardSize    int
+	cardinalityIndexEnabled             bool
 }
 
 func (m *blocksStoreLimitsMock) MaxLabelsQueryLength(_ string) time.Duration {
@@ -2000,10 +2630,34 @@ func (m *blocksStoreLimitsMock) MaxChunksPerQuery(_ string) int {
 	return m.maxChunksPerQuery
 }
 
+func (m *blocksStoreLimitsMock) LabelValuesResultsMaxSizeBytes(_ string) int {
+	return m.labelValuesResultsMaxSizeBytes
+}
+
+func (m *blocksStoreLimitsMock) QueryStoreAfter(_ string) time.Duration {
+	return m.queryStoreAfter
+}
+
+func (m *blocksStoreLimitsMock) CompactorCardinalityIndexEnabled(_ string) bool {
+	return m.cardinalityIndexEnabled
+}
+
 func (m *blocksStoreLimitsMock) StoreGatewayTenantShardSize(_ string) int {
 	return m.storeGatewayTenantShardSize
 }
 
+func (m *blocksStoreLimitsMock) StoreGatewayTenantReplicationFactor(_ string) int {
+	return m.storeGatewayTenantReplicationFactor
+}
+
+func (m *blocksStoreLimitsMock) StoreGatewayLargeTenantThreshold(_ string) int {
+	return m.storeGatewayLargeTenantThreshold
+}
+
+func (m *blocksStoreLimitsMock) StoreGatewayLargeTenantShardSize(_ string) int {
+	return m.storeGatewayLargeTenantShardSize
+}
+
 func (m *blocksStoreLimitsMock) S3SSEType(_ string) string {
 	return ""
 }
@@ -2035,6 +2689,32 @@ func mockSeriesResponseWithChunks(lbls labels.Labels, chunks ...storepb.AggrChun
 	}
 }
 
+func mockStreamingSeriesBatchResponse(endOfStream bool, lbls ...labels.Labels) *storepb.SeriesResponse {
+	batch := &storepb.StreamingSeriesBatch{IsEndOfSeriesStream: endOfStream}
+	for _, l := range lbls {
+		batch.Series = append(batch.Series, &storepb.StreamingSeries{Labels: mimirpb.FromLabelsToLabelAdapters(l)})
+	}
+
+	return storepb.NewStreamingSeriesResponse(batch)
+}
+
+func withEstimatedChunks(resp *storepb.SeriesResponse, numChunks, numBytes uint64) *storepb.SeriesResponse {
+	resp.GetStreamingSeries().EstimatedChunksCount = numChunks
+	resp.GetStreamingSeries().EstimatedChunksBytes = numBytes
+	return resp
+}
+
+func mockStreamingChunksBatchResponse(series ...*storepb.StreamingChunks) *storepb.SeriesResponse {
+	return storepb.NewStreamingChunksResponse(&storepb.StreamingChunksBatch{Series: series})
+}
+
+func mockStreamingChunks(seriesIndex uint64, timeMillis int64, value float64) *storepb.StreamingChunks {
+	return &storepb.StreamingChunks{
+		SeriesIndex: seriesIndex,
+		Chunks:      []storepb.AggrChunk{createAggrChunkWithSamples(promql.Point{T: timeMillis, V: value})},
+	}
+}
+
 func mockStatsResponse(fetchedIndexBytes int) *storepb.SeriesResponse {
 	return &storepb.SeriesResponse{
 		Result: &storepb.SeriesResponse_Stats{
@@ -2089,6 +2769,20 @@ func mockValuesHints(ids ...ulid.ULID) *types.Any {
 	return any
 }
 
+func mockExemplarsHints(ids ...ulid.ULID) *types.Any {
+	hints := &hintspb.ExemplarsResponseHints{}
+	for _, id := range ids {
+		hints.AddQueriedBlock(id)
+	}
+
+	any, err := types.MarshalAny(hints)
+	if err != nil {
+		panic(err)
+	}
+
+	return any
+}
+
 func namesFromSeries(series ...labels.Labels) []string {
 	namesMap := map[string]struct{}{}
 	for _, s := range series {
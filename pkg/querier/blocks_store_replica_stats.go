@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+const (
+	// replicaStatsAlpha is the weight of the last request in the moving averages of the latency and errors.
+	replicaStatsAlpha = 0.2
+
+	// replicaStatsTTL is the period after which the stats of an instance not receiving requests are discarded, so
+	// that an instance avoided because of its past latency or errors gets requests again.
+	replicaStatsTTL = time.Minute
+
+	// replicaErrorPenalty is the latency added to the cost of an instance whose requests all fail. It's weighted
+	// by the error rate of the instance.
+	replicaErrorPenalty = 5 * time.Second
+)
+
+// replicaStats tracks the recent latency and error rate of the requests to a store-gateway instance, as
+// exponentially weighted moving averages.
+type replicaStats struct {
+	// latency is the average latency of the successful requests, in seconds.
+	latency float64
+	// errorRate is the average of 1 for the failed requests and 0 for the successful ones.
+	errorRate  float64
+	lastUpdate time.Time
+}
+
+// storeGatewayReplicaStats tracks the replicaStats of each store-gateway instance, by address.
+type storeGatewayReplicaStats struct {
+	mtx       sync.Mutex
+	instances map[string]*replicaStats
+
+	// now is overridden in the tests.
+	now func() time.Time
+}
+
+func newStoreGatewayReplicaStats() *storeGatewayReplicaStats {
+	return &storeGatewayReplicaStats{
+		instances: map[string]*replicaStats{},
+		now:       time.Now,
+	}
+}
+
+// observe records the result of a request to the instance. The errors not caused by the instance, like the
+// request being canceled or exceeding a limit, are not recorded.
+func (s *storeGatewayReplicaStats) observe(addr string, latency time.Duration, err error) {
+	if err != nil && !isReplicaFailure(err) {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	stats, ok := s.instances[addr]
+	if !ok || now.Sub(stats.lastUpdate) > replicaStatsTTL {
+		stats = &replicaStats{}
+		s.instances[addr] = stats
+
+		// The first request initialises the averages.
+		if err != nil {
+			stats.errorRate = 1
+		} else {
+			stats.latency = latency.Seconds()
+		}
+		stats.lastUpdate = now
+		return
+	}
+
+	if err != nil {
+		stats.errorRate += replicaStatsAlpha * (1 - stats.errorRate)
+	} else {
+		stats.errorRate -= replicaStatsAlpha * stats.errorRate
+		if stats.latency == 0 {
+			stats.latency = latency.Seconds()
+		} else {
+			stats.latency += replicaStatsAlpha * (latency.Seconds() - stats.latency)
+		}
+	}
+	stats.lastUpdate = now
+}
+
+// cost returns the expected cost of querying the instance, in seconds, from its recent latency and errors. The
+// returned bool is false if the instance has no recent stats.
+func (s *storeGatewayReplicaStats) cost(addr string) (float64, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	stats, ok := s.instances[addr]
+	if !ok || s.now().Sub(stats.lastUpdate) > replicaStatsTTL {
+		return 0, false
+	}
+	return stats.latency + stats.errorRate*replicaErrorPenalty.Seconds(), true
+}
+
+// removeExpired removes the stats of the instances which received no request within the TTL.
+func (s *storeGatewayReplicaStats) removeExpired() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	for addr, stats := range s.instances {
+		if now.Sub(stats.lastUpdate) > replicaStatsTTL {
+			delete(s.instances, addr)
+		}
+	}
+}
+
+// isReplicaFailure returns whether the error is caused by the store-gateway instance, as opposed to the request
+// being canceled, timing out or exceeding a limit.
+func isReplicaFailure(err error) bool {
+	if shouldStopQueryFunc(err) {
+		return false
+	}
+	if st, ok := status.FromError(errors.Cause(err)); ok {
+		return st.Code() != codes.Canceled && st.Code() != codes.DeadlineExceeded
+	}
+	return true
+}
+
+// replicaStatsBlocksStoreClient is a BlocksStoreClient recording the latency and the errors of its requests. The
+// latency of the Series() requests is the time until the first response is received.
+type replicaStatsBlocksStoreClient struct {
+	BlocksStoreClient
+	stats *storeGatewayReplicaStats
+}
+
+func newReplicaStatsBlocksStoreClient(c BlocksStoreClient, stats *storeGatewayReplicaStats) BlocksStoreClient {
+	return &replicaStatsBlocksStoreClient{BlocksStoreClient: c, stats: stats}
+}
+
+func (c *replicaStatsBlocksStoreClient) Series(ctx context.Context, req *storepb.SeriesRequest, opts ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
+	start := time.Now()
+	stream, err := c.BlocksStoreClient.Series(ctx, req, opts...)
+	if err != nil {
+		c.stats.observe(c.RemoteAddress(), time.Since(start), err)
+		return nil, err
+	}
+	return &replicaStatsSeriesClient{StoreGateway_SeriesClient: stream, addr: c.RemoteAddress(), start: start, stats: c.stats}, nil
+}
+
+func (c *replicaStatsBlocksStoreClient) LabelNames(ctx context.Context, req *storepb.LabelNamesRequest, opts ...grpc.CallOption) (*storepb.LabelNamesResponse, error) {
+	start := time.Now()
+	resp, err := c.BlocksStoreClient.LabelNames(ctx, req, opts...)
+	c.stats.observe(c.RemoteAddress(), time.Since(start), err)
+	return resp, err
+}
+
+func (c *replicaStatsBlocksStoreClient) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest, opts ...grpc.CallOption) (*storepb.LabelValuesResponse, error) {
+	start := time.Now()
+	resp, err := c.BlocksStoreClient.LabelValues(ctx, req, opts...)
+	c.stats.observe(c.RemoteAddress(), time.Since(start), err)
+	return resp, err
+}
+
+// replicaStatsSeriesClient records the result of the first Recv() of the stream.
+type replicaStatsSeriesClient struct {
+	storegatewaypb.StoreGateway_SeriesClient
+
+	addr     string
+	start    time.Time
+	stats    *storeGatewayReplicaStats
+	received bool
+}
+
+func (c *replicaStatsSeriesClient) Recv() (*storepb.SeriesResponse, error) {
+	resp, err := c.StoreGateway_SeriesClient.Recv()
+	if !c.received {
+		c.received = true
+
+		// The end of the stream is a successful response.
+		observedErr := err
+		if resp == nil && errors.Is(err, io.EOF) {
+			observedErr = nil
+		}
+		c.stats.observe(c.addr, time.Since(c.start), observedErr)
+	}
+	return resp, err
+}
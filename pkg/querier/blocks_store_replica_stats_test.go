@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStoreGatewayReplicaStats(t *testing.T) {
+	now := time.Now()
+	stats := newStoreGatewayReplicaStats()
+	stats.now = func() time.Time { return now }
+
+	_, ok := stats.cost("instance-1")
+	assert.False(t, ok)
+
+	// The first request initialises the averages.
+	stats.observe("instance-1", time.Second, nil)
+	cost, ok := stats.cost("instance-1")
+	require.True(t, ok)
+	assert.InDelta(t, 1, cost, 1e-9)
+
+	stats.observe("instance-1", 2*time.Second, nil)
+	cost, _ = stats.cost("instance-1")
+	assert.InDelta(t, 1.2, cost, 1e-9)
+
+	// The failed requests add a penalty weighted by the error rate, without affecting the latency.
+	stats.observe("instance-1", time.Millisecond, errors.New("failed"))
+	cost, _ = stats.cost("instance-1")
+	assert.InDelta(t, 1.2+0.2*replicaErrorPenalty.Seconds(), cost, 1e-9)
+
+	// The errors not caused by the instance are ignored.
+	stats.observe("instance-1", time.Millisecond, context.Canceled)
+	stats.observe("instance-1", time.Millisecond, status.Error(codes.Canceled, "canceled"))
+	stats.observe("instance-1", time.Millisecond, httpgrpc.Errorf(http.StatusUnprocessableEntity, "limit exceeded"))
+	cost, _ = stats.cost("instance-1")
+	assert.InDelta(t, 1.2+0.2*replicaErrorPenalty.Seconds(), cost, 1e-9)
+
+	// The stats expire if the instance receives no request.
+	stats.observe("instance-2", time.Second, nil)
+	now = now.Add(replicaStatsTTL + time.Second)
+	_, ok = stats.cost("instance-1")
+	assert.False(t, ok)
+
+	stats.observe("instance-2", 3*time.Second, nil)
+	cost, _ = stats.cost("instance-2")
+	assert.InDelta(t, 3, cost, 1e-9)
+
+	stats.removeExpired()
+	assert.Len(t, stats.instances, 1)
+}
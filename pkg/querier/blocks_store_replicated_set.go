@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/ring"
@@ -17,6 +18,7 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storegateway"
@@ -28,8 +30,22 @@ type loadBalancingStrategy int
 const (
 	noLoadBalancing = loadBalancingStrategy(iota)
 	randomLoadBalancing
+	// zoneAwareLoadBalancing prefers the instances in the preferred zone, unless they're much slower or failing
+	// compared to the instances in the other zones, according to the recent latency and errors of each instance.
+	zoneAwareLoadBalancing
 )
 
+// crossZoneCostFactor is how many times the cost of the best instance in the preferred zone must exceed the cost
+// of the best instance in the other zones for the latter to be queried.
+const crossZoneCostFactor = 2
+
+// tenantBlocksSizes is implemented by the BlocksFinder tracking the total size of the blocks of each tenant.
+type tenantBlocksSizes interface {
+	// BlocksSize returns the total size of the blocks of a tenant, as of the last time its blocks have
+	// been found, or 0 if unknown.
+	BlocksSize(userID string) int64
+}
+
 // BlocksStoreSet implementation used when the blocks are sharded and replicated across
 // a set of store-gateway instances.
 type blocksStoreReplicationSet struct {
@@ -40,6 +56,14 @@ type blocksStoreReplicationSet struct {
 	balancingStrategy loadBalancingStrategy
 	limits            BlocksStoreLimits
 
+	// Used to shard the blocks of the large tenants across the large tenant shard size. Optional.
+	blocksSizes tenantBlocksSizes
+
+	// Used by the zone-aware load balancing strategy only.
+	preferredZone string
+	replicaStats  *storeGatewayReplicaStats
+	zoneFallbacks prometheus.Counter
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -48,7 +72,9 @@ type blocksStoreReplicationSet struct {
 func newBlocksStoreReplicationSet(
 	storesRing *ring.Ring,
 	balancingStrategy loadBalancingStrategy,
+	preferredZone string,
 	limits BlocksStoreLimits,
+	blocksSizes tenantBlocksSizes,
 	clientConfig ClientConfig,
 	logger log.Logger,
 	reg prometheus.Registerer,
@@ -58,9 +84,19 @@ func newBlocksStoreReplicationSet(
 		clientsPool:        newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, logger, reg),
 		balancingStrategy:  balancingStrategy,
 		limits:             limits,
+		blocksSizes:        blocksSizes,
 		subservicesWatcher: services.NewFailureWatcher(),
 	}
 
+	if balancingStrategy == zoneAwareLoadBalancing {
+		s.preferredZone = preferredZone
+		s.replicaStats = newStoreGatewayReplicaStats()
+		s.zoneFallbacks = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_storegateway_preferred_zone_fallbacks_total",
+			Help: "Total number of blocks queried from a store-gateway outside the preferred zone while a replica in the preferred zone was available.",
+		})
+	}
+
 	var err error
 	s.subservices, err = services.NewManager(s.storesRing, s.clientsPool)
 	if err != nil {
@@ -83,10 +119,20 @@ func (s *blocksStoreReplicationSet) starting(ctx context.Context) error {
 }
 
 func (s *blocksStoreReplicationSet) running(ctx context.Context) error {
+	// The stats of the instances are only tracked by the zone-aware load balancing strategy.
+	var cleanup <-chan time.Time
+	if s.replicaStats != nil {
+		ticker := time.NewTicker(replicaStatsTTL)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-cleanup:
+			s.replicaStats.removeExpired()
 		case err := <-s.subservicesWatcher.Chan():
 			return errors.Wrap(err, "blocks store set subservice failed")
 		}
@@ -100,7 +146,13 @@ func (s *blocksStoreReplicationSet) stopping(_ error) error {
 func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error) {
 	shards := map[string][]ulid.ULID{}
 
-	userRing := storegateway.GetShuffleShardingSubring(s.storesRing, userID, s.limits)
+	var blocksSize int64
+	if s.blocksSizes != nil {
+		blocksSize = s.blocksSizes.BlocksSize(userID)
+	}
+
+	userRing := storegateway.GetBlocksShuffleShardingSubring(s.storesRing, userID, s.limits, blocksSize)
+	replicationFactor := storegateway.GetTenantReplicationFactor(userRing, userID, s.limits)
 
 	// Find the replication set of each block we need to query.
 	for _, blockID := range blockIDs {
@@ -108,13 +160,18 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 		// returned replication set.
 		bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
 
-		set, err := userRing.Get(mimir_tsdb.HashBlockID(blockID), storegateway.BlocksRead, bufDescs, bufHosts, bufZones)
+		set, err := storegateway.GetBlockReplicationSet(userRing, mimir_tsdb.HashBlockID(blockID), storegateway.BlocksRead, replicationFactor, bufDescs, bufHosts, bufZones)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get store-gateway replication set owning the block %s", blockID.String())
 		}
 
 		// Pick a non excluded store-gateway instance.
-		addr := getNonExcludedInstanceAddr(set, exclude[blockID], s.balancingStrategy)
+		var addr string
+		if s.balancingStrategy == zoneAwareLoadBalancing {
+			addr = s.getPreferredInstanceAddr(set, exclude[blockID])
+		} else {
+			addr = getNonExcludedInstanceAddr(set, exclude[blockID], s.balancingStrategy)
+		}
 		if addr == "" {
 			return nil, fmt.Errorf("no store-gateway instance left after checking exclude for block %s", blockID.String())
 		}
@@ -131,7 +188,11 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 			return nil, errors.Wrapf(err, "failed to get store-gateway client for %s", addr)
 		}
 
-		clients[c.(BlocksStoreClient)] = blockIDs
+		if s.replicaStats != nil {
+			clients[newReplicaStatsBlocksStoreClient(c.(BlocksStoreClient), s.replicaStats)] = blockIDs
+		} else {
+			clients[c.(BlocksStoreClient)] = blockIDs
+		}
 	}
 
 	return clients, nil
@@ -153,3 +214,42 @@ func getNonExcludedInstanceAddr(set ring.ReplicationSet, exclude []string, balan
 
 	return ""
 }
+
+// getPreferredInstanceAddr returns the non excluded instance with the lowest cost in the preferred zone, unless
+// its cost is more than crossZoneCostFactor times the lowest cost in the other zones. The instances without recent
+// stats have no cost, so that they get a request and their stats are known.
+func (s *blocksStoreReplicationSet) getPreferredInstanceAddr(set ring.ReplicationSet, exclude []string) string {
+	// Randomize the list of instances, so that the instances with the same cost get the same share of requests.
+	rand.Shuffle(len(set.Instances), func(i, j int) {
+		set.Instances[i], set.Instances[j] = set.Instances[j], set.Instances[i]
+	})
+
+	var (
+		localAddr, remoteAddr string
+		localCost, remoteCost float64
+	)
+	for _, instance := range set.Instances {
+		if util.StringsContain(exclude, instance.Addr) {
+			continue
+		}
+
+		cost, _ := s.replicaStats.cost(instance.Addr)
+		if instance.Zone == s.preferredZone {
+			if localAddr == "" || cost < localCost {
+				localAddr, localCost = instance.Addr, cost
+			}
+		} else if remoteAddr == "" || cost < remoteCost {
+			remoteAddr, remoteCost = instance.Addr, cost
+		}
+	}
+
+	switch {
+	case localAddr == "":
+		return remoteAddr
+	case remoteAddr != "" && localCost > crossZoneCostFactor*remoteCost:
+		s.zoneFallbacks.Inc()
+		return remoteAddr
+	default:
+		return localAddr
+	}
+}
@@ -7,6 +7,7 @@ package querier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -44,13 +45,17 @@ func TestBlocksStoreReplicationSet_GetClientsFor(t *testing.T) {
 	registeredAt := time.Now()
 
 	tests := map[string]struct {
-		tenantShardSize   int
-		replicationFactor int
-		setup             func(*ring.Desc)
-		queryBlocks       []ulid.ULID
-		exclude           map[ulid.ULID][]string
-		expectedClients   map[string][]ulid.ULID
-		expectedErr       error
+		tenantShardSize         int
+		tenantReplicationFactor int
+		largeTenantThreshold    int
+		largeTenantShardSize    int
+		blocksSize              int64
+		replicationFactor       int
+		setup                   func(*ring.Desc)
+		queryBlocks             []ulid.ULID
+		exclude                 map[ulid.ULID][]string
+		expectedClients         map[string][]ulid.ULID
+		expectedErr             error
 	}{
 		"shard size 0, single instance in the ring with RF = 1": {
 			tenantShardSize:   0,
@@ -100,6 +105,33 @@ func TestBlocksStoreReplicationSet_GetClientsFor(t *testing.T) {
 				"127.0.0.1": {block1, block2},
 			},
 		},
+		"shard size 0, multiple instances in the ring with RF = 2 and tenant RF = 1": {
+			tenantShardSize:         0,
+			tenantReplicationFactor: 1,
+			replicationFactor:       2,
+			setup: func(d *ring.Desc) {
+				d.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			queryBlocks: []ulid.ULID{block1, block2},
+			exclude: map[ulid.ULID][]string{
+				block1: {"127.0.0.1"},
+			},
+			expectedErr: fmt.Errorf("no store-gateway instance left after checking exclude for block %s", block1.String()),
+		},
+		"shard size 0, multiple instances in the ring with RF = 2, tenant RF = 1 and the block owner JOINING": {
+			tenantShardSize:         0,
+			tenantReplicationFactor: 1,
+			replicationFactor:       2,
+			setup: func(d *ring.Desc) {
+				d.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1}, ring.JOINING, registeredAt)
+				d.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			queryBlocks: []ulid.ULID{block1, block2},
+			expectedClients: map[string][]ulid.ULID{
+				"127.0.0.2": {block1, block2},
+			},
+		},
 		"shard size 0, multiple instances in the ring with each requested block belonging to a different store-gateway and RF = 1": {
 			tenantShardSize:   0,
 			replicationFactor: 1,
@@ -256,6 +288,41 @@ func TestBlocksStoreReplicationSet_GetClientsFor(t *testing.T) {
 				"127.0.0.3": {block2},
 			},
 		},
+		"shard size 1, large tenant shard size 2, large tenant, multiple instances in the ring with RF = 1": {
+			tenantShardSize:      1,
+			largeTenantThreshold: 1000,
+			largeTenantShardSize: 2,
+			blocksSize:           2000,
+			replicationFactor:    1,
+			setup: func(d *ring.Desc) {
+				d.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-3", "127.0.0.3", "", []uint32{block3Hash + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-4", "127.0.0.4", "", []uint32{block4Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			queryBlocks: []ulid.ULID{block1, block2, block4},
+			expectedClients: map[string][]ulid.ULID{
+				"127.0.0.1": {block1, block4},
+				"127.0.0.3": {block2},
+			},
+		},
+		"shard size 1, large tenant shard size 2, small tenant, multiple instances in the ring with RF = 1": {
+			tenantShardSize:      1,
+			largeTenantThreshold: 1000,
+			largeTenantShardSize: 2,
+			blocksSize:           500,
+			replicationFactor:    1,
+			setup: func(d *ring.Desc) {
+				d.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-3", "127.0.0.3", "", []uint32{block3Hash + 1}, ring.ACTIVE, registeredAt)
+				d.AddIngester("instance-4", "127.0.0.4", "", []uint32{block4Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			queryBlocks: []ulid.ULID{block1, block2, block4},
+			expectedClients: map[string][]ulid.ULID{
+				"127.0.0.1": {block1, block2, block4},
+			},
+		},
 		"shard size 4, multiple instances in the ring with RF = 1": {
 			tenantShardSize:   4,
 			replicationFactor: 1,
@@ -334,11 +401,15 @@ func TestBlocksStoreReplicationSet_GetClientsFor(t *testing.T) {
 			require.NoError(t, err)
 
 			limits := &blocksStoreLimitsMock{
-				storeGatewayTenantShardSize: testData.tenantShardSize,
+				storeGatewayTenantShardSize:         testData.tenantShardSize,
+				storeGatewayTenantReplicationFactor: testData.tenantReplicationFactor,
+				storeGatewayLargeTenantThreshold:    testData.largeTenantThreshold,
+				storeGatewayLargeTenantShardSize:    testData.largeTenantShardSize,
 			}
+			blocksSizes := tenantBlocksSizesMock{userID: testData.blocksSize}
 
 			reg := prometheus.NewPedanticRegistry()
-			s, err := newBlocksStoreReplicationSet(r, noLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg)
+			s, err := newBlocksStoreReplicationSet(r, noLoadBalancing, "", limits, blocksSizes, ClientConfig{}, log.NewNopLogger(), reg)
 			require.NoError(t, err)
 			require.NoError(t, services.StartAndAwaitRunning(ctx, s))
 			defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
@@ -398,7 +469,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ShouldSupportRandomLoadBalancin
 
 	limits := &blocksStoreLimitsMock{storeGatewayTenantShardSize: 0}
 	reg := prometheus.NewPedanticRegistry()
-	s, err := newBlocksStoreReplicationSet(r, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg)
+	s, err := newBlocksStoreReplicationSet(r, randomLoadBalancing, "", limits, nil, ClientConfig{}, log.NewNopLogger(), reg)
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
 	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
@@ -431,6 +502,91 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ShouldSupportRandomLoadBalancin
 	}
 }
 
+func TestBlocksStoreReplicationSet_GetClientsFor_ShouldSupportZoneAwareLoadBalancingStrategy(t *testing.T) {
+	ctx := context.Background()
+	userID := "user-A"
+	registeredAt := time.Now()
+	block1 := ulid.MustNew(1, nil)
+
+	// Create a ring with an instance in each zone.
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, ringStore.CAS(ctx, "test", func(in interface{}) (interface{}, bool, error) {
+		d := ring.NewDesc()
+		for n := 1; n <= 3; n++ {
+			d.AddIngester(fmt.Sprintf("instance-%d", n), fmt.Sprintf("127.0.0.%d", n), fmt.Sprintf("zone-%d", n), []uint32{uint32(n)}, ring.ACTIVE, registeredAt)
+		}
+		return d, true, nil
+	}))
+
+	ringCfg := ring.Config{}
+	flagext.DefaultValues(&ringCfg)
+	ringCfg.ReplicationFactor = 3
+	ringCfg.ZoneAwarenessEnabled = true
+
+	r, err := ring.NewWithStoreClientAndStrategy(ringCfg, "test", "test", ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	limits := &blocksStoreLimitsMock{storeGatewayTenantShardSize: 0}
+	reg := prometheus.NewPedanticRegistry()
+	s, err := newBlocksStoreReplicationSet(r, zoneAwareLoadBalancing, "zone-2", limits, nil, ClientConfig{}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+	// Wait until the ring client has initialised the state.
+	test.Poll(t, time.Second, true, func() interface{} {
+		all, err := r.GetAllHealthy(ring.Read)
+		return err == nil && len(all.Instances) > 0
+	})
+
+	getClientAddr := func(exclude map[ulid.ULID][]string) string {
+		clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, exclude)
+		require.NoError(t, err)
+		require.Len(t, clients, 1)
+
+		for c := range clients {
+			// The returned clients record the latency and errors of their requests.
+			require.IsType(t, &replicaStatsBlocksStoreClient{}, c)
+			return c.RemoteAddress()
+		}
+		return ""
+	}
+
+	// Without stats, the instance in the preferred zone is always queried.
+	for n := 0; n < 100; n++ {
+		assert.Equal(t, "127.0.0.2", getClientAddr(nil))
+	}
+
+	// The instance in the preferred zone is queried as long as it's not much slower than the others.
+	s.replicaStats.observe("127.0.0.1", 80*time.Millisecond, nil)
+	s.replicaStats.observe("127.0.0.2", 100*time.Millisecond, nil)
+	s.replicaStats.observe("127.0.0.3", 60*time.Millisecond, nil)
+	assert.Equal(t, "127.0.0.2", getClientAddr(nil))
+
+	// The fastest instance of the other zones is queried when the instance in the preferred zone is much slower.
+	s.replicaStats.observe("127.0.0.3", 10*time.Millisecond, nil)
+	s.replicaStats.observe("127.0.0.3", 10*time.Millisecond, nil)
+	s.replicaStats.observe("127.0.0.3", 10*time.Millisecond, nil)
+	assert.Equal(t, "127.0.0.3", getClientAddr(nil))
+	assert.Equal(t, "127.0.0.2", getClientAddr(map[ulid.ULID][]string{block1: {"127.0.0.3"}}))
+
+	// The instance in the preferred zone is not queried when it's failing.
+	s.replicaStats.observe("127.0.0.3", 100*time.Millisecond, nil)
+	s.replicaStats.observe("127.0.0.2", time.Millisecond, errors.New("failed"))
+	assert.Equal(t, "127.0.0.1", getClientAddr(map[ulid.ULID][]string{block1: {"127.0.0.3"}}))
+
+	// The instance in the preferred zone is excluded.
+	assert.Equal(t, "127.0.0.1", getClientAddr(map[ulid.ULID][]string{block1: {"127.0.0.2", "127.0.0.3"}}))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_storegateway_preferred_zone_fallbacks_total Total number of blocks queried from a store-gateway outside the preferred zone while a replica in the preferred zone was available.
+		# TYPE cortex_querier_storegateway_preferred_zone_fallbacks_total counter
+		cortex_querier_storegateway_preferred_zone_fallbacks_total 2
+	`), "cortex_querier_storegateway_preferred_zone_fallbacks_total"))
+}
+
 func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[string][]ulid.ULID {
 	addrs := map[string][]ulid.ULID{}
 	for c, blockIDs := range clients {
@@ -438,3 +594,9 @@ func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[s
 	}
 	return addrs
 }
+
+type tenantBlocksSizesMock map[string]int64
+
+func (m tenantBlocksSizesMock) BlocksSize(userID string) int64 {
+	return m[userID]
+}
@@ -3,10 +3,12 @@
 package querier
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
@@ -17,6 +19,7 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/tsdb/cardinalityindex"
 	"github.com/grafana/mimir/pkg/util"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -26,10 +29,24 @@ const (
 	minLimit     = 0
 	maxLimit     = 500
 	defaultLimit = 20
+
+	// sourceIngesters is the source of the cardinality analysis of the series in the ingesters.
+	sourceIngesters = "ingesters"
+	// sourceBlocks is the source of the cardinality analysis of the series in the blocks, looked up from their cardinality summaries.
+	sourceBlocks = "blocks"
 )
 
+var errCardinalitySummariesDisabled = errors.New("cardinality summaries are disabled")
+
+// BlocksCardinality looks up the cardinality summaries of the blocks of the tenants.
+type BlocksCardinality interface {
+	// BlocksCardinality returns the cardinality summaries of the tenant's blocks in the input time range.
+	BlocksCardinality(ctx context.Context, userID string, minT, maxT int64) (cardinalityindex.Blocks, error)
+}
+
 // LabelNamesCardinalityHandler creates handler for label names cardinality endpoint.
-func LabelNamesCardinalityHandler(d Distributor, limits *validation.Overrides) http.Handler {
+// The blocks cardinality is optional, and is required to analyse the cardinality of the blocks.
+func LabelNamesCardinalityHandler(d Distributor, blocksCardinality BlocksCardinality, limits *validation.Overrides) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		tenantID, err := tenant.TenantID(ctx)
@@ -41,11 +58,19 @@ func LabelNamesCardinalityHandler(d Distributor, limits *validation.Overrides) h
 			http.Error(w, fmt.Sprintf("cardinality analysis is disabled for the tenant: %v", tenantID), http.StatusBadRequest)
 			return
 		}
-		matchers, limit, err := extractLabelNamesRequestParams(r)
+		matchers, limit, source, err := extractLabelNamesRequestParams(r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if source == sourceBlocks {
+			blocks, metricName, ok := getCardinalityIndexBlocks(w, r, blocksCardinality, limits, tenantID, matchers)
+			if !ok {
+				return
+			}
+			util.WriteJSONResponse(w, toLabelNamesCardinalityResponse(labelNamesAndValuesFromBlocks(blocks, metricName), limit))
+			return
+		}
 		response, err := d.LabelNamesAndValues(ctx, matchers)
 		if err != nil {
 			respondFromError(err, w)
@@ -57,7 +82,8 @@ func LabelNamesCardinalityHandler(d Distributor, limits *validation.Overrides) h
 }
 
 // LabelValuesCardinalityHandler creates handler for label values cardinality endpoint.
-func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.Overrides) http.Handler {
+// The blocks cardinality is optional, and is required to analyse the cardinality of the blocks.
+func LabelValuesCardinalityHandler(distributor Distributor, blocksCardinality BlocksCardinality, limits *validation.Overrides) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		// Guarantee request's context is for a single tenant id
@@ -71,12 +97,22 @@ func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.O
 			return
 		}
 
-		labelNames, matchers, limit, err := extractLabelValuesRequestParams(r)
+		labelNames, matchers, limit, source, err := extractLabelValuesRequestParams(r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
+		if source == sourceBlocks {
+			blocks, metricName, ok := getCardinalityIndexBlocks(w, r, blocksCardinality, limits, tenantID, matchers)
+			if !ok {
+				return
+			}
+			seriesCountTotal, cardinalityResponse := labelValuesCardinalityFromBlocks(blocks, labelNames, metricName)
+			util.WriteJSONResponse(w, toLabelValuesCardinalityResponse(seriesCountTotal, cardinalityResponse, limit))
+			return
+		}
+
 		seriesCountTotal, cardinalityResponse, err := distributor.LabelValuesCardinality(ctx, labelNames, matchers)
 		if err != nil {
 			respondFromError(err, w)
@@ -87,44 +123,53 @@ func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.O
 	})
 }
 
-func extractLabelNamesRequestParams(r *http.Request) ([]*labels.Matcher, int, error) {
+func extractLabelNamesRequestParams(r *http.Request) ([]*labels.Matcher, int, string, error) {
 	err := r.ParseForm()
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
 	}
 	matchers, err := extractSelector(r)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
 	}
 	limit, err := extractLimit(r)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", err
 	}
-	return matchers, limit, nil
+	source, err := extractSource(r)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return matchers, limit, source, nil
 }
 
 // extractLabelValuesRequestParams parses query params from GET requests and parses request body from POST requests
-func extractLabelValuesRequestParams(r *http.Request) (labelNames []model.LabelName, matchers []*labels.Matcher, limit int, err error) {
+func extractLabelValuesRequestParams(r *http.Request) (labelNames []model.LabelName, matchers []*labels.Matcher, limit int, source string, err error) {
 	if err := r.ParseForm(); err != nil {
-		return nil, nil, 0, err
+		return nil, nil, 0, "", err
 	}
 
 	labelNames, err = extractLabelNames(r)
 	if err != nil {
-		return nil, nil, 0, err
+		return nil, nil, 0, "", err
 	}
 
 	matchers, err = extractSelector(r)
 	if err != nil {
-		return nil, nil, 0, err
+		return nil, nil, 0, "", err
 	}
 
 	limit, err = extractLimit(r)
 	if err != nil {
-		return nil, nil, 0, err
+		return nil, nil, 0, "", err
 	}
 
-	return labelNames, matchers, limit, nil
+	source, err = extractSource(r)
+	if err != nil {
+		return nil, nil, 0, "", err
+	}
+
+	return labelNames, matchers, limit, source, nil
 }
 
 // extractSelector parses and gets selector query parameter containing a single matcher
@@ -161,6 +206,21 @@ func extractLimit(r *http.Request) (limit int, err error) {
 	return limit, nil
 }
 
+// extractSource parses and validates request param `source` if it's defined, otherwise returns the ingesters source.
+func extractSource(r *http.Request) (string, error) {
+	sourceParams := r.Form["source"]
+	if len(sourceParams) == 0 {
+		return sourceIngesters, nil
+	}
+	if len(sourceParams) > 1 {
+		return "", fmt.Errorf("multiple 'source' params are not allowed")
+	}
+	if source := sourceParams[0]; source != sourceIngesters && source != sourceBlocks {
+		return "", fmt.Errorf("invalid 'source' param '%v', supported values are '%s' and '%s'", source, sourceIngesters, sourceBlocks)
+	}
+	return sourceParams[0], nil
+}
+
 // extractLabelNames parses and gets label_names query parameter containing an array of label values
 func extractLabelNames(r *http.Request) ([]model.LabelName, error) {
 	labelNamesParams := r.Form["label_names[]"]
@@ -180,6 +240,73 @@ func extractLabelNames(r *http.Request) ([]model.LabelName, error) {
 	return labelNames, nil
 }
 
+// getCardinalityIndexBlocks returns the cardinality summaries of the tenant's blocks within the max labels query
+// length, and the metric name selected by the matchers. If the blocks can't be analysed, it writes the error to
+// the response and returns false.
+func getCardinalityIndexBlocks(w http.ResponseWriter, r *http.Request, blocksCardinality BlocksCardinality, limits *validation.Overrides, tenantID string, matchers []*labels.Matcher) (cardinalityindex.Blocks, string, bool) {
+	if blocksCardinality == nil || !limits.CompactorCardinalityIndexEnabled(tenantID) {
+		http.Error(w, fmt.Sprintf("cardinality index is disabled for the tenant: %v", tenantID), http.StatusBadRequest)
+		return nil, "", false
+	}
+	metricName, ok := metricNameFromMatchers(matchers)
+	if !ok {
+		http.Error(w, fmt.Sprintf("only a metric name selector is supported with the '%s' source", sourceBlocks), http.StatusBadRequest)
+		return nil, "", false
+	}
+
+	now := time.Now()
+	minT, maxT := int64(0), util.TimeToMillis(now)
+	if maxQueryLength := limits.MaxLabelsQueryLength(tenantID); maxQueryLength > 0 {
+		minT = util.TimeToMillis(now.Add(-maxQueryLength))
+	}
+
+	blocks, err := blocksCardinality.BlocksCardinality(r.Context(), tenantID, minT, maxT)
+	switch {
+	case errors.Is(err, errCardinalitySummariesDisabled):
+		http.Error(w, fmt.Sprintf("cardinality index is disabled for the tenant: %v", tenantID), http.StatusBadRequest)
+		return nil, "", false
+	case errors.Is(err, cardinalityindex.ErrSummaryNotFound):
+		http.Error(w, fmt.Sprintf("%v: some of the blocks haven't been summarized yet", err), http.StatusNotFound)
+		return nil, "", false
+	case errors.Is(err, cardinalityindex.ErrLookupTooLarge):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return nil, "", false
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	return blocks, metricName, true
+}
+
+// labelNamesAndValuesFromBlocks returns the label names and values of the series of the input metric
+// (or of all the series if the metric name is empty) in the input blocks.
+func labelNamesAndValuesFromBlocks(blocks cardinalityindex.Blocks, metricName string) *ingester_client.LabelNamesAndValuesResponse {
+	labelNames := blocks.LabelNames(metricName)
+	items := make([]*ingester_client.LabelValues, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		items = append(items, &ingester_client.LabelValues{LabelName: labelName, Values: blocks.LabelValues(labelName, metricName)})
+	}
+	return &ingester_client.LabelNamesAndValuesResponse{Items: items}
+}
+
+// labelValuesCardinalityFromBlocks returns the estimated number of series of the input metric (or of all the
+// series if the metric name is empty) in the input blocks, in total and by value of the input labels.
+func labelValuesCardinalityFromBlocks(blocks cardinalityindex.Blocks, labelNames []model.LabelName, metricName string) (uint64, *ingester_client.LabelValuesCardinalityResponse) {
+	var seriesCountTotal uint64
+	for _, seriesCount := range blocks.SeriesCountByLabelValue(labels.MetricName, metricName) {
+		seriesCountTotal += seriesCount
+	}
+
+	items := make([]*ingester_client.LabelValueSeriesCount, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		items = append(items, &ingester_client.LabelValueSeriesCount{
+			LabelName:        string(labelName),
+			LabelValueSeries: blocks.SeriesCountByLabelValue(string(labelName), metricName),
+		})
+	}
+	return seriesCountTotal, &ingester_client.LabelValuesCardinalityResponse{Items: items}
+}
+
 func respondFromError(err error, w http.ResponseWriter) {
 	httpResp, ok := httpgrpc.HTTPResponseFromError(errors.Cause(err))
 	if !ok {
@@ -14,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/grafana/dskit/flagext"
+	"github.com/oklog/ulid"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/mock"
@@ -22,6 +23,7 @@ import (
 	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/tsdb/cardinalityindex"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
 
@@ -209,7 +211,7 @@ func TestLabelNamesCardinalityHandler_DistributorError(t *testing.T) {
 			limits.CardinalityAnalysisEnabled = true
 			overrides, err := validation.NewOverrides(limits, nil)
 			require.NoError(t, err)
-			handler := LabelNamesCardinalityHandler(distributor, overrides)
+			handler := LabelNamesCardinalityHandler(distributor, nil, overrides)
 			ctx := user.InjectOrgID(context.Background(), "test")
 
 			request, err := http.NewRequestWithContext(ctx, "GET", labelNamesURL, http.NoBody)
@@ -274,7 +276,7 @@ func TestLabelNamesCardinalityHandler_NegativeTests(t *testing.T) {
 			}
 			overrides, err := validation.NewOverrides(limits, nil)
 			require.NoError(t, err)
-			handler := LabelNamesCardinalityHandler(mockDistributorLabelNamesAndValues([]*client.LabelValues{}, nil), overrides)
+			handler := LabelNamesCardinalityHandler(mockDistributorLabelNamesAndValues([]*client.LabelValues{}, nil), nil, overrides)
 
 			recorder := httptest.NewRecorder()
 
@@ -631,7 +633,7 @@ func TestLabelValuesCardinalityHandler_FeatureFlag(t *testing.T) {
 			limits := validation.Limits{CardinalityAnalysisEnabled: testData.cardinalityAnalysisEnabled}
 			overrides, err := validation.NewOverrides(limits, nil)
 			require.NoError(t, err)
-			handler := LabelValuesCardinalityHandler(distributor, overrides)
+			handler := LabelValuesCardinalityHandler(distributor, nil, overrides)
 
 			recorder := httptest.NewRecorder()
 			handler.ServeHTTP(recorder, testData.request)
@@ -697,6 +699,14 @@ func TestLabelValuesCardinalityHandler_ParseError(t *testing.T) {
 				url:                  "/label_values?label_names[]=hello&limit=501",
 				expectedErrorMessage: "'limit' param cannot be greater than '500'",
 			},
+			"source param is invalid": {
+				url:                  "/label_values?label_names[]=hello&source=foo",
+				expectedErrorMessage: "invalid 'source' param 'foo', supported values are 'ingesters' and 'blocks'",
+			},
+			"multiple source params are provided": {
+				url:                  "/label_values?label_names[]=hello&source=blocks&source=ingesters",
+				expectedErrorMessage: "multiple 'source' params are not allowed",
+			},
 		}
 		for testName, testData := range tests {
 			t.Run(testName, func(t *testing.T) {
@@ -772,13 +782,119 @@ func TestLabelValuesCardinalityHandler_DistributorError(t *testing.T) {
 	}
 }
 
+func TestCardinalityHandlers_BlocksSource(t *testing.T) {
+	blocksCardinality := blocksCardinalityMock{
+		"team-a": cardinalityindex.Blocks{
+			{ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 10, Metrics: map[string]*cardinalityindex.Metric{
+				"up":  {Series: 3, Labels: map[string]map[string]uint64{"job": {"a": 2, "b": 1}}},
+				"cpu": {Series: 2, Labels: map[string]map[string]uint64{"job": {"a": 2}, "mode": {"user": 1, "system": 1}}},
+			}},
+			{ID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 20, Metrics: map[string]*cardinalityindex.Metric{
+				"up": {Series: 2, Labels: map[string]map[string]uint64{"job": {"a": 1, "c": 1}}},
+			}},
+		},
+	}
+
+	limits := validation.Limits{CardinalityAnalysisEnabled: true}
+	tenantLimits := &validation.Limits{CardinalityAnalysisEnabled: true, CompactorCardinalityIndexEnabled: true}
+	overrides, err := validation.NewOverrides(limits, validation.NewMockTenantLimits(map[string]*validation.Limits{"team-a": tenantLimits, "team-b": tenantLimits}))
+	require.NoError(t, err)
+
+	// The distributor must not be called.
+	distributor := &mockDistributor{}
+	labelNamesHandler := LabelNamesCardinalityHandler(distributor, blocksCardinality, overrides)
+	labelValuesHandler := LabelValuesCardinalityHandler(distributor, blocksCardinality, overrides)
+
+	t.Run("label names", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		labelNamesHandler.ServeHTTP(recorder, createRequest("/label_names?source=blocks", "team-a"))
+		require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+
+		var response LabelNamesCardinalityResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Equal(t, LabelNamesCardinalityResponse{
+			LabelValuesCountTotal: 7,
+			LabelNamesCount:       3,
+			Cardinality: []*LabelNamesCardinalityItem{
+				{LabelName: "job", LabelValuesCount: 3},
+				{LabelName: "__name__", LabelValuesCount: 2},
+				{LabelName: "mode", LabelValuesCount: 2},
+			},
+		}, response)
+	})
+
+	t.Run("label values", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		labelValuesHandler.ServeHTTP(recorder, createRequest("/label_values?source=blocks&label_names[]=job&selector=up", "team-a"))
+		require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+
+		var response labelValuesCardinalityResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Equal(t, labelValuesCardinalityResponse{
+			SeriesCountTotal: 3,
+			Labels: []labelNamesCardinality{{
+				LabelName:        "job",
+				LabelValuesCount: 3,
+				SeriesCount:      4,
+				Cardinality: []labelValuesCardinality{
+					{LabelValue: "a", SeriesCount: 2},
+					{LabelValue: "b", SeriesCount: 1},
+					{LabelValue: "c", SeriesCount: 1},
+				},
+			}},
+		}, response)
+	})
+
+	for testName, testData := range map[string]struct {
+		request              *http.Request
+		expectedStatusCode   int
+		expectedErrorMessage string
+	}{
+		"cardinality index disabled for the tenant": {
+			request:              createRequest("/label_values?source=blocks&label_names[]=job", "team-c"),
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedErrorMessage: "cardinality index is disabled for the tenant: team-c\n",
+		},
+		"selector not supported by the summaries": {
+			request:              createRequest("/label_values?source=blocks&label_names[]=job&selector={job=\"a\"}", "team-a"),
+			expectedStatusCode:   http.StatusBadRequest,
+			expectedErrorMessage: "only a metric name selector is supported with the 'blocks' source\n",
+		},
+		"cardinality summaries not found": {
+			request:              createRequest("/label_values?source=blocks&label_names[]=job", "team-b"),
+			expectedStatusCode:   http.StatusNotFound,
+			expectedErrorMessage: "cardinality summary not found: some of the blocks haven't been summarized yet\n",
+		},
+	} {
+		t.Run(testName, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			labelValuesHandler.ServeHTTP(recorder, testData.request)
+
+			require.Equal(t, testData.expectedStatusCode, recorder.Result().StatusCode)
+			require.Equal(t, testData.expectedErrorMessage, recorder.Body.String())
+		})
+	}
+}
+
+// blocksCardinalityMock returns the summaries of the blocks of each tenant, or cardinalityindex.ErrSummaryNotFound
+// for the tenants with no summaries.
+type blocksCardinalityMock map[string]cardinalityindex.Blocks
+
+func (m blocksCardinalityMock) BlocksCardinality(_ context.Context, userID string, _, _ int64) (cardinalityindex.Blocks, error) {
+	blocks, ok := m[userID]
+	if !ok {
+		return nil, cardinalityindex.ErrSummaryNotFound
+	}
+	return blocks, nil
+}
+
 // createEnabledHandler creates a cardinalityHandler that can be either a LabelNamesCardinalityHandler or a LabelValuesCardinalityHandler
-func createEnabledHandler(t *testing.T, cardinalityHandler func(Distributor, *validation.Overrides) http.Handler, distributor *mockDistributor) http.Handler {
+func createEnabledHandler(t *testing.T, cardinalityHandler func(Distributor, BlocksCardinality, *validation.Overrides) http.Handler, distributor *mockDistributor) http.Handler {
 	limits := validation.Limits{CardinalityAnalysisEnabled: true}
 	overrides, err := validation.NewOverrides(limits, nil)
 	require.NoError(t, err)
 
-	handler := cardinalityHandler(distributor, overrides)
+	handler := cardinalityHandler(distributor, nil, overrides)
 	return handler
 }
 
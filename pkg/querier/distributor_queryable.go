@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/labels"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/storage/series"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/chunkcompat"
@@ -38,46 +40,77 @@ type Distributor interface {
 	LabelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher) (uint64, *client.LabelValuesCardinalityResponse, error)
 }
 
-func newDistributorQueryable(distributor Distributor, iteratorFn chunkIteratorFunc, queryIngestersWithin time.Duration, logger log.Logger) QueryableWithFilter {
+func newDistributorQueryable(distributor Distributor, iteratorFn chunkIteratorFunc, router timeRangeRouter, downsampling ingesterDownsamplingConfig, logger log.Logger) QueryableWithFilter {
 	return distributorQueryable{
-		logger:               logger,
-		distributor:          distributor,
-		iteratorFn:           iteratorFn,
-		queryIngestersWithin: queryIngestersWithin,
+		logger:       logger,
+		distributor:  distributor,
+		iteratorFn:   iteratorFn,
+		router:       router,
+		downsampling: downsampling,
 	}
 }
 
 type distributorQueryable struct {
-	logger               log.Logger
-	distributor          Distributor
-	iteratorFn           chunkIteratorFunc
-	queryIngestersWithin time.Duration
+	logger       log.Logger
+	distributor  Distributor
+	iteratorFn   chunkIteratorFunc
+	router       timeRangeRouter
+	downsampling ingesterDownsamplingConfig
 }
 
 func (d distributorQueryable) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
-	return &distributorQuerier{
-		logger:               d.logger,
-		distributor:          d.distributor,
-		ctx:                  ctx,
-		mint:                 mint,
-		maxt:                 maxt,
-		chunkIterFn:          d.iteratorFn,
-		queryIngestersWithin: d.queryIngestersWithin,
-	}, nil
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &distributorQuerier{
+		logger:       d.logger,
+		distributor:  d.distributor,
+		ctx:          ctx,
+		mint:         mint,
+		maxt:         maxt,
+		chunkIterFn:  d.iteratorFn,
+		routing:      d.router.forTenant(queryStartTimeFromContext(ctx), userID),
+		downsampling: d.downsampling,
+	}
+	return d.router.trackCosts(q, timeRangeRoutingIngesters, mint, maxt), nil
 }
 
-func (d distributorQueryable) UseQueryable(now time.Time, _, queryMaxT int64) bool {
-	// Include ingester only if maxt is within QueryIngestersWithin w.r.t. current time.
-	return d.queryIngestersWithin == 0 || queryMaxT >= util.TimeToMillis(now.Add(-d.queryIngestersWithin))
+func (d distributorQueryable) UseQueryable(now time.Time, userID string, queryMinT, queryMaxT int64) bool {
+	// Include ingester only if maxt is within the tenant's query-ingesters-within w.r.t. current time.
+	routing := d.router.forTenant(now, userID)
+	d.router.trackOverlapRouting(routing, now, queryMinT, queryMaxT)
+	return routing.useIngesters(now, queryMaxT)
 }
 
 type distributorQuerier struct {
-	logger               log.Logger
-	distributor          Distributor
-	ctx                  context.Context
-	mint, maxt           int64
-	chunkIterFn          chunkIteratorFunc
-	queryIngestersWithin time.Duration
+	logger       log.Logger
+	distributor  Distributor
+	ctx          context.Context
+	mint, maxt   int64
+	chunkIterFn  chunkIteratorFunc
+	routing      timeRangeRouting
+	downsampling ingesterDownsamplingConfig
+}
+
+// clampMinT manipulates the query min time to not query the ingesters for the time range covered by the
+// store-gateways. This optimization is particularly important for the blocks storage where the blocks
+// retention in the ingesters could be way higher than query-ingesters-within.
+func (q *distributorQuerier) clampMinT(minT int64, logger log.Logger) int64 {
+	// The boundary is computed on the querier time range, which the store-gateways routing is decided on.
+	boundary := q.routing.ingestersMinT(time.Now(), q.mint, q.maxt)
+	if boundary == 0 || minT >= boundary {
+		return minT
+	}
+
+	level.Debug(logger).Log(
+		"msg", "the min time of the query has been manipulated because of the time range routing between ingesters and store-gateways",
+		"original", util.FormatTimeMillis(minT),
+		"updated", util.FormatTimeMillis(boundary),
+		"strict", q.routing.strict,
+		"cost_based", q.routing.costBased)
+	return boundary
 }
 
 // Select implements storage.Querier interface.
@@ -86,16 +119,17 @@ func (q *distributorQuerier) Select(_ bool, sp *storage.SelectHints, matchers ..
 	spanlog, ctx := spanlogger.NewWithLogger(q.ctx, q.logger, "distributorQuerier.Select")
 	defer spanlog.Finish()
 
+	start := time.Now()
+	defer func() {
+		stats.FromContext(ctx).AddIngesterFetchTime(time.Since(start))
+	}()
+
 	minT, maxT := q.mint, q.maxt
 	if sp != nil {
 		minT, maxT = sp.Start, sp.End
 	}
 
-	// If queryIngestersWithin is enabled, we do manipulate the query mint to query samples up until
-	// now - queryIngestersWithin, because older time ranges are covered by the storage. This
-	// optimization is particularly important for the blocks storage where the blocks retention in the
-	// ingesters could be way higher than queryIngestersWithin.
-	minT = int64(clampTime(q.ctx, model.Time(minT), q.queryIngestersWithin, model.Now().Add(-q.queryIngestersWithin), true, "min", "query ingesters within", spanlog))
+	minT = q.clampMinT(minT, spanlog)
 
 	if minT > maxT {
 		level.Debug(spanlog).Log("msg", "empty query time range after min time manipulation")
@@ -110,6 +144,11 @@ func (q *distributorQuerier) Select(_ bool, sp *storage.SelectHints, matchers ..
 		return series.LabelsToSeriesSet(ms)
 	}
 
+	if hint := q.downsampling.hint(sp); hint != nil {
+		level.Debug(spanlog).Log("msg", "requesting the ingesters to downsample the samples", "step", hint.StepMs, "aggregation", hint.Aggregation, "histograms", hint.Histograms)
+		ctx = client.ContextWithDownsamplingHint(ctx, hint)
+	}
+
 	return q.streamingSelect(ctx, minT, maxT, matchers)
 }
 
@@ -162,7 +201,7 @@ func (q *distributorQuerier) streamingSelect(ctx context.Context, minT, maxT int
 }
 
 func (q *distributorQuerier) LabelValues(name string, matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
-	minT := clampTime(q.ctx, model.Time(q.mint), q.queryIngestersWithin, model.Now().Add(-q.queryIngestersWithin), true, "min", "query ingesters within", q.logger)
+	minT := model.Time(q.clampMinT(q.mint, spanlogger.FromContext(q.ctx, q.logger)))
 
 	if minT > model.Time(q.maxt) {
 		level.Debug(q.logger).Log("msg", "empty time range after min time manipulation")
@@ -178,7 +217,7 @@ func (q *distributorQuerier) LabelNames(matchers ...*labels.Matcher) ([]string,
 	log, ctx := spanlogger.NewWithLogger(q.ctx, q.logger, "distributorQuerier.LabelNames")
 	defer log.Span.Finish()
 
-	minT := clampTime(q.ctx, model.Time(q.mint), q.queryIngestersWithin, model.Now().Add(-q.queryIngestersWithin), true, "min", "query ingesters within", log)
+	minT := model.Time(q.clampMinT(q.mint, log))
 
 	if minT > model.Time(q.maxt) {
 		level.Debug(q.logger).Log("msg", "empty time range after min time manipulation")
@@ -26,6 +26,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/storage/chunk"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/chunkcompat"
@@ -89,7 +90,7 @@ func TestDistributorQuerier_SelectShouldHonorQueryIngestersWithin(t *testing.T)
 			distributor.On("MetricsForLabelMatchers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]labels.Labels{}, nil)
 
 			ctx := user.InjectOrgID(context.Background(), "test")
-			queryable := newDistributorQueryable(distributor, nil, testData.queryIngestersWithin, log.NewNopLogger())
+			queryable := newDistributorQueryable(distributor, nil, newTimeRangeRouter(testData.queryIngestersWithin, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
 			querier, err := queryable.Querier(ctx, testData.queryMinT, testData.queryMaxT)
 			require.NoError(t, err)
 
@@ -114,18 +115,18 @@ func TestDistributorQuerier_SelectShouldHonorQueryIngestersWithin(t *testing.T)
 
 func TestDistributorQueryableFilter(t *testing.T) {
 	d := &mockDistributor{}
-	dq := newDistributorQueryable(d, nil, 1*time.Hour, log.NewNopLogger())
+	dq := newDistributorQueryable(d, nil, newTimeRangeRouter(1*time.Hour, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
 
 	now := time.Now()
 
 	queryMinT := util.TimeToMillis(now.Add(-5 * time.Minute))
 	queryMaxT := util.TimeToMillis(now)
 
-	require.True(t, dq.UseQueryable(now, queryMinT, queryMaxT))
-	require.True(t, dq.UseQueryable(now.Add(time.Hour), queryMinT, queryMaxT))
+	require.True(t, dq.UseQueryable(now, "user-1", queryMinT, queryMaxT))
+	require.True(t, dq.UseQueryable(now.Add(time.Hour), "user-1", queryMinT, queryMaxT))
 
 	// Same query, hour+1ms later, is not sent to ingesters.
-	require.False(t, dq.UseQueryable(now.Add(time.Hour).Add(1*time.Millisecond), queryMinT, queryMaxT))
+	require.False(t, dq.UseQueryable(now.Add(time.Hour).Add(1*time.Millisecond), "user-1", queryMinT, queryMaxT))
 }
 
 func TestIngesterStreaming(t *testing.T) {
@@ -165,13 +166,14 @@ func TestIngesterStreaming(t *testing.T) {
 		},
 		nil)
 
-	ctx := user.InjectOrgID(context.Background(), "0")
-	queryable := newDistributorQueryable(d, mergeChunks, 0, log.NewNopLogger())
+	queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "0"))
+	queryable := newDistributorQueryable(d, mergeChunks, newTimeRangeRouter(0, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
 	querier, err := queryable.Querier(ctx, mint, maxt)
 	require.NoError(t, err)
 
 	seriesSet := querier.Select(true, &storage.SelectHints{Start: mint, End: maxt})
 	require.NoError(t, seriesSet.Err())
+	assert.Greater(t, queryStats.LoadIngesterFetchTime(), time.Duration(0))
 
 	require.True(t, seriesSet.Next())
 	series := seriesSet.At()
@@ -242,7 +244,7 @@ func TestIngesterStreamingMixedResults(t *testing.T) {
 		nil)
 
 	ctx := user.InjectOrgID(context.Background(), "0")
-	queryable := newDistributorQueryable(d, mergeChunks, 0, log.NewNopLogger())
+	queryable := newDistributorQueryable(d, mergeChunks, newTimeRangeRouter(0, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
 	querier, err := queryable.Querier(ctx, mint, maxt)
 	require.NoError(t, err)
 
@@ -328,7 +330,7 @@ func TestIngesterStreamingMixedResultsHistograms(t *testing.T) {
 		nil)
 
 	ctx := user.InjectOrgID(context.Background(), "0")
-	queryable := newDistributorQueryable(d, mergeChunks, 0, log.NewNopLogger())
+	queryable := newDistributorQueryable(d, mergeChunks, newTimeRangeRouter(0, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
 	querier, err := queryable.Querier(ctx, mint, maxt)
 	require.NoError(t, err)
 
@@ -422,7 +424,7 @@ func TestIngesterStreamingMixedTypedResults(t *testing.T) {
 		nil)
 
 	ctx := user.InjectOrgID(context.Background(), "0")
-	queryable := newDistributorQueryable(d, mergeChunks, 0, log.NewNopLogger())
+	queryable := newDistributorQueryable(d, mergeChunks, newTimeRangeRouter(0, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
 	querier, err := queryable.Querier(ctx, mint, maxt)
 	require.NoError(t, err)
 
@@ -454,8 +456,8 @@ func TestDistributorQuerier_LabelNames(t *testing.T) {
 			d.On("LabelNames", mock.Anything, model.Time(mint), model.Time(maxt), someMatchers).
 				Return(labelNames, nil)
 
-			queryable := newDistributorQueryable(d, nil, 0, log.NewNopLogger())
-			querier, err := queryable.Querier(context.Background(), mint, maxt)
+			queryable := newDistributorQueryable(d, nil, newTimeRangeRouter(0, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
+			querier, err := queryable.Querier(user.InjectOrgID(context.Background(), "0"), mint, maxt)
 			require.NoError(t, err)
 
 			names, warnings, err := querier.LabelNames(someMatchers...)
@@ -504,7 +506,7 @@ func BenchmarkDistributorQueryable_Select(b *testing.B) {
 	d.On("QueryStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(response, nil)
 
 	ctx := user.InjectOrgID(context.Background(), "0")
-	queryable := newDistributorQueryable(d, mergeChunks, 0, log.NewNopLogger())
+	queryable := newDistributorQueryable(d, mergeChunks, newTimeRangeRouter(0, 0, nil, nil), ingesterDownsamplingConfig{}, log.NewNopLogger())
 	querier, err := queryable.Querier(ctx, math.MinInt64, math.MaxInt64)
 	require.NoError(b, err)
 
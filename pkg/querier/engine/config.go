@@ -28,6 +28,9 @@ type Config struct {
 	// LookbackDelta determines the time since the last sample after which a time
 	// series is considered stale.
 	LookbackDelta time.Duration `yaml:"lookback_delta" category:"advanced"`
+
+	MaxMemoizedSelectorSeriesBytesPerQuery int `yaml:"max_memoized_selector_series_bytes_per_query" category:"experimental"`
+	MaxMemoizedSubexpressionBytesPerQuery  int `yaml:"max_memoized_subexpression_bytes_per_query" category:"experimental"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -44,6 +47,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxSamples, "querier.max-samples", 50e6, sharedWithQueryFrontend("Maximum number of samples a single query can load into memory."))
 	f.DurationVar(&cfg.DefaultEvaluationInterval, "querier.default-evaluation-interval", time.Minute, sharedWithQueryFrontend("The default evaluation interval or step size for subqueries."))
 	f.DurationVar(&cfg.LookbackDelta, "querier.lookback-delta", 5*time.Minute, sharedWithQueryFrontend("Time since the last sample after which a time series is considered stale and ignored by expression evaluations."))
+	f.IntVar(&cfg.MaxMemoizedSelectorSeriesBytesPerQuery, "querier.max-memoized-selector-series-bytes-per-query", 0, "Maximum size, in bytes, of the series memoized for the identical selectors of a single query, so that the series of the selectors with the same label matchers are only fetched once from the storage. The series of the selectors not fitting in the limit are fetched again. 0 to disable.")
+	f.IntVar(&cfg.MaxMemoizedSubexpressionBytesPerQuery, "querier.max-memoized-subexpression-bytes-per-query", 0, "Maximum size, in bytes, of the results memoized for the identical subexpressions of a single query, like the aggregations, function calls and binary operations over the same selectors, so that they're only evaluated once. The subexpressions within subqueries aren't memoized. The results of the subexpressions not fitting in the limit are evaluated again. 0 to disable.")
 }
 
 // NewPromQLEngineOptions returns the PromQL engine options based on the provided config.
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	// AllExperimentalFunctions enables all the experimental PromQL functions, when configured as the enabled function.
+	AllExperimentalFunctions = "all"
+
+	madOverTime     = "mad_over_time"
+	sortByLabel     = "sort_by_label"
+	sortByLabelDesc = "sort_by_label_desc"
+)
+
+// ExperimentalFunctions is the list of the experimental PromQL functions. They're registered to the
+// Prometheus parser and engine like the other functions, but the queries using them are only run for
+// the tenants they have been enabled for.
+var ExperimentalFunctions = []string{madOverTime, sortByLabel, sortByLabelDesc}
+
+func init() {
+	registerFunction(&parser.Function{
+		Name:       madOverTime,
+		ArgTypes:   []parser.ValueType{parser.ValueTypeMatrix},
+		ReturnType: parser.ValueTypeVector,
+	}, funcMadOverTime)
+
+	registerFunction(&parser.Function{
+		Name:       sortByLabel,
+		ArgTypes:   []parser.ValueType{parser.ValueTypeVector, parser.ValueTypeString},
+		Variadic:   -1,
+		ReturnType: parser.ValueTypeVector,
+	}, funcSortByLabel)
+
+	registerFunction(&parser.Function{
+		Name:       sortByLabelDesc,
+		ArgTypes:   []parser.ValueType{parser.ValueTypeVector, parser.ValueTypeString},
+		Variadic:   -1,
+		ReturnType: parser.ValueTypeVector,
+	}, funcSortByLabelDesc)
+}
+
+func registerFunction(f *parser.Function, call promql.FunctionCall) {
+	// Never override a function, in case it gets implemented by Prometheus.
+	if _, ok := parser.Functions[f.Name]; ok {
+		return
+	}
+
+	parser.Functions[f.Name] = f
+	promql.FunctionCalls[f.Name] = call
+}
+
+// IsExperimentalFunction returns whether the input PromQL function is experimental.
+func IsExperimentalFunction(name string) bool {
+	for _, f := range ExperimentalFunctions {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FindExperimentalFunctions returns the sorted names of the experimental PromQL functions used by the input node.
+func FindExperimentalFunctions(node parser.Node) []string {
+	found := map[string]struct{}{}
+	parser.Inspect(node, func(node parser.Node, _ []parser.Node) error {
+		if call, ok := node.(*parser.Call); ok && IsExperimentalFunction(call.Func.Name) {
+			found[call.Func.Name] = struct{}{}
+		}
+		return nil
+	})
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsExperimentalFunctionEnabled returns whether the input experimental PromQL function is in the list of the enabled functions.
+func IsExperimentalFunctionEnabled(name string, enabled []string) bool {
+	for _, f := range enabled {
+		if f == name || f == AllExperimentalFunctions {
+			return true
+		}
+	}
+	return false
+}
+
+// === mad_over_time(Matrix parser.ValueTypeMatrix) Vector ===
+func funcMadOverTime(vals []parser.Value, _ parser.Expressions, enh *promql.EvalNodeHelper) promql.Vector {
+	values := make([]float64, 0, len(vals[0].(promql.Matrix)[0].Points))
+	for _, p := range vals[0].(promql.Matrix)[0].Points {
+		if p.H == nil {
+			values = append(values, p.V)
+		}
+	}
+	if len(values) == 0 {
+		return enh.Out
+	}
+
+	median := medianOf(values)
+	for i, v := range values {
+		values[i] = math.Abs(v - median)
+	}
+
+	return append(enh.Out, promql.Sample{
+		Point: promql.Point{V: medianOf(values)},
+	})
+}
+
+// medianOf returns the median of the input values, which get sorted.
+func medianOf(values []float64) float64 {
+	sort.Float64s(values)
+
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+	return values[mid-1] + (values[mid]-values[mid-1])/2
+}
+
+// === sort_by_label(vector parser.ValueTypeVector, label parser.ValueTypeString...) Vector ===
+func funcSortByLabel(vals []parser.Value, args parser.Expressions, _ *promql.EvalNodeHelper) promql.Vector {
+	return sortVectorByLabels(vals[0].(promql.Vector), stringsFromArgs(args[1:]), false)
+}
+
+// === sort_by_label_desc(vector parser.ValueTypeVector, label parser.ValueTypeString...) Vector ===
+func funcSortByLabelDesc(vals []parser.Value, args parser.Expressions, _ *promql.EvalNodeHelper) promql.Vector {
+	return sortVectorByLabels(vals[0].(promql.Vector), stringsFromArgs(args[1:]), true)
+}
+
+// sortVectorByLabels sorts the input vector by the values of the input labels, and then by the whole
+// label set, so that the order is deterministic when the input labels don't tell the series apart.
+func sortVectorByLabels(vector promql.Vector, names []string, desc bool) promql.Vector {
+	sort.SliceStable(vector, func(i, j int) bool {
+		for _, name := range names {
+			vi, vj := vector[i].Metric.Get(name), vector[j].Metric.Get(name)
+			if vi != vj {
+				return (vi < vj) != desc
+			}
+		}
+
+		if c := labels.Compare(vector[i].Metric, vector[j].Metric); c != 0 {
+			return (c < 0) != desc
+		}
+		return false
+	})
+
+	return vector
+}
+
+func stringsFromArgs(args parser.Expressions) []string {
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		for {
+			if e, ok := arg.(*parser.StepInvariantExpr); ok {
+				arg = e.Expr
+				continue
+			}
+			if e, ok := arg.(*parser.ParenExpr); ok {
+				arg = e.Expr
+				continue
+			}
+			break
+		}
+		values = append(values, arg.(*parser.StringLiteral).Val)
+	}
+	return values
+}
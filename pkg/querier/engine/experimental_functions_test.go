@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperimentalFunctions(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			some_metric{idx="1", group="b"} 1 2 4 8 16
+			some_metric{idx="2", group="a"} 3 3 3 3 3
+			some_metric{idx="3", group="a"} 1 10 1 10 1
+	`)
+	require.NoError(t, err)
+	t.Cleanup(test.Close)
+	require.NoError(t, test.Run())
+
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	eng := promql.NewEngine(NewPromQLEngineOptions(cfg, nil, log.NewNopLogger(), nil))
+
+	tests := map[string]struct {
+		query    string
+		sorted   bool
+		expected promql.Vector
+	}{
+		"mad_over_time": {
+			query: `mad_over_time(some_metric[5m])`,
+			expected: promql.Vector{
+				{Metric: labels.FromStrings("group", "b", "idx", "1"), Point: promql.Point{T: 240000, V: 3}},
+				{Metric: labels.FromStrings("group", "a", "idx", "2"), Point: promql.Point{T: 240000, V: 0}},
+				{Metric: labels.FromStrings("group", "a", "idx", "3"), Point: promql.Point{T: 240000, V: 0}},
+			},
+		},
+		"sort_by_label": {
+			query:  `sort_by_label(some_metric, "group")`,
+			sorted: true,
+			expected: promql.Vector{
+				{Metric: labels.FromStrings("__name__", "some_metric", "group", "a", "idx", "2"), Point: promql.Point{T: 240000, V: 3}},
+				{Metric: labels.FromStrings("__name__", "some_metric", "group", "a", "idx", "3"), Point: promql.Point{T: 240000, V: 1}},
+				{Metric: labels.FromStrings("__name__", "some_metric", "group", "b", "idx", "1"), Point: promql.Point{T: 240000, V: 16}},
+			},
+		},
+		"sort_by_label_desc": {
+			query:  `sort_by_label_desc(some_metric, "group", "idx")`,
+			sorted: true,
+			expected: promql.Vector{
+				{Metric: labels.FromStrings("__name__", "some_metric", "group", "b", "idx", "1"), Point: promql.Point{T: 240000, V: 16}},
+				{Metric: labels.FromStrings("__name__", "some_metric", "group", "a", "idx", "3"), Point: promql.Point{T: 240000, V: 1}},
+				{Metric: labels.FromStrings("__name__", "some_metric", "group", "a", "idx", "2"), Point: promql.Point{T: 240000, V: 3}},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			q, err := eng.NewInstantQuery(test.Queryable(), nil, testData.query, time.Unix(240, 0))
+			require.NoError(t, err)
+			defer q.Close()
+
+			res := q.Exec(context.Background())
+			require.NoError(t, res.Err)
+
+			actual, err := res.Vector()
+			require.NoError(t, err)
+			if testData.sorted {
+				assert.Equal(t, testData.expected, actual)
+			} else {
+				assert.ElementsMatch(t, testData.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFindExperimentalFunctions(t *testing.T) {
+	for query, expected := range map[string][]string{
+		`rate(some_metric[5m])`: {},
+		`sort_by_label(mad_over_time(some_metric[5m]), "idx") + sort_by_label(some_metric, "idx")`: {"mad_over_time", "sort_by_label"},
+		`sum(sort_by_label_desc(some_metric, "idx"))`:                                              {"sort_by_label_desc"},
+	} {
+		expr, err := parser.ParseExpr(query)
+		require.NoError(t, err)
+		assert.Equal(t, expected, FindExperimentalFunctions(expr), query)
+	}
+}
+
+func TestIsExperimentalFunctionEnabled(t *testing.T) {
+	assert.False(t, IsExperimentalFunctionEnabled("mad_over_time", nil))
+	assert.False(t, IsExperimentalFunctionEnabled("mad_over_time", []string{"sort_by_label"}))
+	assert.True(t, IsExperimentalFunctionEnabled("mad_over_time", []string{"sort_by_label", "mad_over_time"}))
+	assert.True(t, IsExperimentalFunctionEnabled("mad_over_time", []string{AllExperimentalFunctions}))
+}
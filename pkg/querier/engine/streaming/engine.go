@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package streaming implements a PromQL engine evaluating the queries one series at a time, instead of
+// loading all the series selected by a query in memory at once. It supports a subset of PromQL: queries
+// using an unsupported feature fail with a NotSupportedError, so that they can be run by the Prometheus
+// engine instead.
+package streaming
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// Same default as the Prometheus engine.
+const defaultLookbackDelta = 5 * time.Minute
+
+// Engine is the streaming PromQL engine.
+type Engine struct {
+	timeout       time.Duration
+	lookbackDelta time.Duration
+	tracker       promql.QueryTracker
+}
+
+// NewEngine makes a new Engine. It only honors the timeout, the lookback delta and the active query tracker of opts.
+func NewEngine(opts promql.EngineOpts) *Engine {
+	lookbackDelta := opts.LookbackDelta
+	if lookbackDelta == 0 {
+		lookbackDelta = defaultLookbackDelta
+	}
+
+	return &Engine{
+		timeout:       opts.Timeout,
+		lookbackDelta: lookbackDelta,
+		tracker:       opts.ActiveQueryTracker,
+	}
+}
+
+// NewInstantQuery returns an evaluation query for the given expression at the given time.
+func (e *Engine) NewInstantQuery(q storage.Queryable, opts *promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	return newQuery(e, q, opts, qs, ts, ts, 0)
+}
+
+// NewRangeQuery returns an evaluation query for the given time range and with the resolution set by the interval.
+func (e *Engine) NewRangeQuery(q storage.Queryable, opts *promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("%v is not a valid interval for a range query, must be greater than 0", interval)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("range query time range is invalid: end time %v is before start time %v", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+
+	return newQuery(e, q, opts, qs, start, end, interval)
+}
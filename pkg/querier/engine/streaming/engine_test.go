@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestEngine_ShouldReturnTheSameResultsAsThePrometheusEngine(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			some_metric{idx="1", group="a"} 0+1x10
+			some_metric{idx="2", group="a"} 0+2x3 0 3 stale 10+5x4
+			some_metric{idx="3", group="b"} _ _ 4 8 15 16 23 42 _ _ 50
+			some_metric{idx="4", group="b"} 100-10x10
+			other_metric{idx="1", group="a"} 1 2 3
+	`)
+	require.NoError(t, err)
+	t.Cleanup(test.Close)
+	require.NoError(t, test.Run())
+
+	opts := promql.EngineOpts{
+		MaxSamples:    1e6,
+		Timeout:       time.Minute,
+		LookbackDelta: 3 * time.Minute,
+	}
+	prometheusEngine := promql.NewEngine(opts)
+	streamingEngine := NewEngine(opts)
+
+	queries := []string{
+		`some_metric`,
+		`some_metric{idx="2"}`,
+		`some_metric offset 2m`,
+		`{__name__=~".+_metric", group="a"}`,
+		`non_existing_metric`,
+		`rate(some_metric[1m])`,
+		`rate(some_metric[3m])`,
+		`rate(some_metric[5m] offset 1m)`,
+		`rate(some_metric{group="b"}[10m])`,
+		`sum(some_metric)`,
+		`sum by (group) (some_metric)`,
+		`sum without (idx) (some_metric)`,
+		`sum by (group) (rate(some_metric[3m]))`,
+		`sum without (idx) ((rate(some_metric[2m])))`,
+		`sum by (__name__) ({group="a"})`,
+	}
+
+	for _, qs := range queries {
+		t.Run(qs, func(t *testing.T) {
+			ctx := context.Background()
+
+			for _, step := range []time.Duration{time.Minute, 17 * time.Second} {
+				start, end := time.Unix(0, 0), time.Unix(0, 0).Add(12*time.Minute)
+
+				expected, err := prometheusEngine.NewRangeQuery(test.Queryable(), nil, qs, start, end, step)
+				require.NoError(t, err)
+				expectedRes := expected.Exec(ctx)
+				require.NoError(t, expectedRes.Err)
+
+				actual, err := streamingEngine.NewRangeQuery(test.Queryable(), nil, qs, start, end, step)
+				require.NoError(t, err)
+				actualRes := actual.Exec(ctx)
+				require.NoError(t, actualRes.Err)
+
+				require.Equal(t, expectedRes.Value, actualRes.Value, "step: %s", step)
+			}
+
+			for ts := time.Unix(0, 0); ts.Before(time.Unix(0, 0).Add(12 * time.Minute)); ts = ts.Add(30 * time.Second) {
+				expected, err := prometheusEngine.NewInstantQuery(test.Queryable(), nil, qs, ts)
+				require.NoError(t, err)
+				expectedRes := expected.Exec(ctx)
+				require.NoError(t, expectedRes.Err)
+
+				actual, err := streamingEngine.NewInstantQuery(test.Queryable(), nil, qs, ts)
+				require.NoError(t, err)
+				actualRes := actual.Exec(ctx)
+				require.NoError(t, actualRes.Err)
+
+				// The order of the samples in an instant vector isn't defined.
+				require.Equal(t, sortedVector(t, expectedRes), sortedVector(t, actualRes), "time: %s", ts)
+			}
+		})
+	}
+}
+
+func TestEngine_ShouldReturnNotSupportedErrorOnUnsupportedQueries(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			some_metric{idx="1"} 0+1x10
+			other_metric{idx="1"} 0+1x10
+	`)
+	require.NoError(t, err)
+	t.Cleanup(test.Close)
+	require.NoError(t, test.Run())
+
+	app := test.Storage().Appender(context.Background())
+	for i := 0; i < 10; i++ {
+		_, err := app.AppendHistogram(0, labels.FromStrings(labels.MetricName, "histogram_metric"), int64(i)*time.Minute.Milliseconds(), tsdbutil.GenerateTestHistogram(i), nil)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	engine := NewEngine(promql.EngineOpts{Timeout: time.Minute})
+	start, end := time.Unix(0, 0), time.Unix(0, 0).Add(10*time.Minute)
+
+	queries := map[string]string{
+		"scalar":                        `1`,
+		"binary operation":              `some_metric + 1`,
+		"unary expression":              `-some_metric`,
+		"unsupported function":          `irate(some_metric[5m])`,
+		"unsupported aggregation":       `avg(some_metric)`,
+		"@ modifier":                    `some_metric @ 300`,
+		"@ modifier in range selector":  `rate(some_metric[5m] @ 300)`,
+		"subquery":                      `rate(some_metric[5m:1m])`,
+		"same labels once name dropped": `rate({idx="1"}[5m])`,
+	}
+
+	for name, qs := range queries {
+		t.Run(name, func(t *testing.T) {
+			// Unsupported features are detected either when the query is created or when it's executed.
+			q, err := engine.NewRangeQuery(test.Queryable(), nil, qs, start, end, time.Minute)
+			if err == nil {
+				err = q.Exec(context.Background()).Err
+			}
+
+			require.Error(t, err)
+			require.True(t, IsNotSupported(err), err.Error())
+		})
+	}
+
+	t.Run("native histograms", func(t *testing.T) {
+		q, err := engine.NewRangeQuery(test.Queryable(), nil, `histogram_metric`, start, end, time.Minute)
+		require.NoError(t, err)
+
+		res := q.Exec(context.Background())
+		require.True(t, IsNotSupported(res.Err))
+	})
+}
+
+func TestEngine_ShouldTrackMemoryConsumption(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			some_metric{idx="1", group="a"} 0+1x10
+			some_metric{idx="2", group="a"} 0+2x10
+			some_metric{idx="3", group="b"} 0+3x10
+	`)
+	require.NoError(t, err)
+	t.Cleanup(test.Close)
+	require.NoError(t, test.Run())
+
+	engine := NewEngine(promql.EngineOpts{Timeout: time.Minute, LookbackDelta: 5 * time.Minute})
+	start, end := time.Unix(0, 0), time.Unix(0, 0).Add(10*time.Minute)
+	steps := uint64(11)
+
+	tests := map[string]struct {
+		query        string
+		instant      bool
+		expectedPeak uint64
+		expectedEnd  uint64
+	}{
+		"vector selector, range query": {
+			query: `some_metric`,
+			// The points of all the series are returned.
+			expectedPeak: 3 * steps * pointSize,
+			expectedEnd:  3 * steps * pointSize,
+		},
+		"vector selector, instant query": {
+			query:   `some_metric`,
+			instant: true,
+			// The points of each series are released once copied to the vector.
+			expectedPeak: pointSize,
+			expectedEnd:  0,
+		},
+		"sum, range query": {
+			query: `sum by (group) (some_metric)`,
+			// Group "b" is being summed, while the points of group "a" are already in the result.
+			expectedPeak: 2*steps*pointSize + steps*sumGroupStepSize,
+			expectedEnd:  2 * steps * pointSize,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := limiter.NewMemoryConsumptionTracker(0, nil)
+			ctx := limiter.AddMemoryConsumptionTrackerToContext(context.Background(), tracker)
+
+			var q promql.Query
+			if testData.instant {
+				q, err = engine.NewInstantQuery(test.Queryable(), nil, testData.query, end)
+			} else {
+				q, err = engine.NewRangeQuery(test.Queryable(), nil, testData.query, start, end, time.Minute)
+			}
+			require.NoError(t, err)
+
+			res := q.Exec(ctx)
+			require.NoError(t, res.Err)
+			require.Equal(t, testData.expectedPeak, tracker.PeakEstimatedMemoryConsumptionBytes())
+			require.Equal(t, testData.expectedEnd, tracker.CurrentEstimatedMemoryConsumptionBytes())
+		})
+	}
+
+	t.Run("should fail when the query exceeds the max estimated memory consumption", func(t *testing.T) {
+		maxBytes := 2 * steps * pointSize
+		ctx := limiter.AddMemoryConsumptionTrackerToContext(context.Background(), limiter.NewMemoryConsumptionTracker(maxBytes, nil))
+
+		q, err := engine.NewRangeQuery(test.Queryable(), nil, `some_metric`, start, end, time.Minute)
+		require.NoError(t, err)
+
+		res := q.Exec(ctx)
+		require.Error(t, res.Err)
+		require.Equal(t, validation.LimitError(fmt.Sprintf(limiter.MaxEstimatedMemoryPerQueryHitMsgFormat, maxBytes)), res.Err)
+		require.False(t, IsNotSupported(res.Err))
+	})
+}
+
+func TestEngine_ShouldFailOnInvalidQueries(t *testing.T) {
+	engine := NewEngine(promql.EngineOpts{})
+
+	_, err := engine.NewInstantQuery(nil, nil, `sum(`, time.Now())
+	require.Error(t, err)
+	require.False(t, IsNotSupported(err))
+
+	_, err = engine.NewRangeQuery(nil, nil, `some_metric`, time.Now(), time.Now().Add(-time.Minute), time.Minute)
+	require.Error(t, err)
+	require.False(t, IsNotSupported(err))
+}
+
+func sortedVector(t *testing.T, res *promql.Result) promql.Vector {
+	vector, err := res.Vector()
+	require.NoError(t, err)
+
+	sort.Slice(vector, func(i, j int) bool {
+		return labels.Compare(vector[i].Metric, vector[j].Metric) < 0
+	})
+	return vector
+}
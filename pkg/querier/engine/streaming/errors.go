@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package streaming
+
+import (
+	"errors"
+)
+
+// NotSupportedError is returned when a query uses a PromQL feature not supported by the streaming engine.
+type NotSupportedError struct {
+	Feature string
+}
+
+func NewNotSupportedError(feature string) error {
+	return NotSupportedError{Feature: feature}
+}
+
+func (e NotSupportedError) Error() string {
+	return "not supported by the streaming engine: " + e.Feature
+}
+
+// IsNotSupported returns whether the error, or any error it wraps, is a NotSupportedError.
+func IsNotSupported(err error) bool {
+	var notSupportedErr NotSupportedError
+	return errors.As(err, &notSupportedErr)
+}
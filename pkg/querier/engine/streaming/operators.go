@@ -0,0 +1,488 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package streaming
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// errEndOfSeries is returned by instantVectorOperator.next() when there are no more series.
+var errEndOfSeries = errors.New("end of series")
+
+// instantVectorOperator evaluates an expression returning an instant vector, one series at a time.
+type instantVectorOperator interface {
+	// seriesMetadata returns the labels of the series returned by the operator, in the order next()
+	// returns them. It must be called once, before calling next().
+	seriesMetadata(ctx context.Context) ([]labels.Labels, error)
+
+	// next returns the points of the next series, one point for each step the series has a value at.
+	// It returns errEndOfSeries when there are no more series.
+	next(ctx context.Context) ([]promql.Point, error)
+
+	// close releases the resources held by the operator.
+	close()
+}
+
+// selector selects the series matching a vector selector.
+type selector struct {
+	eval     *evaluation
+	vs       *parser.VectorSelector
+	rangeMs  int64
+	funcName string
+
+	querier storage.Querier
+	series  []storage.Series
+}
+
+// newSelector makes a new selector. The range must be set only if the vector selector is part of a range vector selector.
+func newSelector(eval *evaluation, vs *parser.VectorSelector, selectRange time.Duration, funcName string) *selector {
+	return &selector{
+		eval:     eval,
+		vs:       vs,
+		rangeMs:  selectRange.Milliseconds(),
+		funcName: funcName,
+	}
+}
+
+// offset returns the offset of the vector selector, in milliseconds.
+func (s *selector) offset() int64 {
+	return s.vs.OriginalOffset.Milliseconds()
+}
+
+func (s *selector) seriesMetadata(ctx context.Context) ([]labels.Labels, error) {
+	lookback := s.rangeMs
+	if lookback == 0 {
+		lookback = s.eval.lookbackDelta
+	}
+
+	hints := &storage.SelectHints{
+		Start: s.eval.start - s.offset() - lookback,
+		End:   s.eval.end - s.offset(),
+		Step:  s.eval.interval,
+		Range: s.rangeMs,
+		Func:  s.funcName,
+	}
+
+	var err error
+	s.querier, err = s.eval.queryable.Querier(ctx, hints.Start, hints.End)
+	if err != nil {
+		return nil, err
+	}
+
+	set := s.querier.Select(false, hints, s.vs.LabelMatchers...)
+	var metadata []labels.Labels
+	for set.Next() {
+		series := set.At()
+		s.series = append(s.series, series)
+		metadata = append(metadata, series.Labels())
+	}
+	if err := set.Err(); err != nil {
+		return nil, err
+	}
+
+	s.eval.warnings = append(s.eval.warnings, set.Warnings()...)
+	return metadata, nil
+}
+
+// nextSeries returns the next series, or errEndOfSeries when there are no more series.
+func (s *selector) nextSeries(ctx context.Context) (storage.Series, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(s.series) == 0 {
+		return nil, errEndOfSeries
+	}
+
+	series := s.series[0]
+	// Release the series as soon as it's been evaluated.
+	s.series[0] = nil
+	s.series = s.series[1:]
+	return series, nil
+}
+
+func (s *selector) close() {
+	s.series = nil
+	if s.querier != nil {
+		_ = s.querier.Close()
+	}
+}
+
+// instantVectorSelector evaluates a vector selector.
+type instantVectorSelector struct {
+	*selector
+
+	chunkIterator chunkenc.Iterator
+}
+
+func (v *instantVectorSelector) next(ctx context.Context) ([]promql.Point, error) {
+	series, err := v.nextSeries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v.chunkIterator = series.Iterator(v.chunkIterator)
+	it := storage.NewMemoizedIterator(v.chunkIterator, v.eval.lookbackDelta)
+
+	var points []promql.Point
+	for ts := v.eval.start; ts <= v.eval.end; ts += v.eval.interval {
+		refTime := ts - v.offset()
+
+		var t int64
+		var val float64
+
+		valueType := it.Seek(refTime)
+		switch valueType {
+		case chunkenc.ValNone:
+			if it.Err() != nil {
+				return nil, it.Err()
+			}
+		case chunkenc.ValFloat:
+			t, val = it.At()
+		default:
+			return nil, NewNotSupportedError("native histograms")
+		}
+
+		if valueType == chunkenc.ValNone || t > refTime {
+			prevT, prevVal, h, fh, ok := it.PeekPrev()
+			if h != nil || fh != nil {
+				return nil, NewNotSupportedError("native histograms")
+			}
+			if !ok || prevT < refTime-v.eval.lookbackDelta {
+				continue
+			}
+			t, val = prevT, prevVal
+		}
+		if value.IsStaleNaN(val) {
+			continue
+		}
+
+		if points == nil {
+			points, err = v.eval.newPoints(v.eval.stepCount())
+			if err != nil {
+				return nil, err
+			}
+		}
+		points = append(points, promql.Point{T: ts, V: val})
+	}
+
+	return points, nil
+}
+
+// rateFunction evaluates the rate() function over a range vector selector.
+type rateFunction struct {
+	*selector
+
+	chunkIterator chunkenc.Iterator
+	window        []promql.Point
+}
+
+func (r *rateFunction) seriesMetadata(ctx context.Context) ([]labels.Labels, error) {
+	metadata, err := r.selector.seriesMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The function drops the metric name.
+	seen := make(map[string]struct{}, len(metadata))
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	for i, l := range metadata {
+		lb.Reset(l)
+		lb.Del(labels.MetricName)
+		metadata[i] = lb.Labels(labels.EmptyLabels())
+
+		// The Prometheus engine fails if series with the same labels have a value at the same step:
+		// we don't track the steps at which this happens, and leave such queries to the Prometheus engine.
+		key := metadata[i].String()
+		if _, ok := seen[key]; ok {
+			return nil, NewNotSupportedError("multiple series with the same labels once the metric name is dropped")
+		}
+		seen[key] = struct{}{}
+	}
+
+	return metadata, nil
+}
+
+func (r *rateFunction) next(ctx context.Context) ([]promql.Point, error) {
+	series, err := r.nextSeries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.chunkIterator = series.Iterator(r.chunkIterator)
+	it := r.chunkIterator
+	r.window = r.window[:0]
+
+	var (
+		points     []promql.Point
+		pending    promql.Point
+		hasPending bool
+		exhausted  bool
+	)
+
+	rangeSeconds := float64(r.rangeMs) / 1000
+
+	for ts := r.eval.start; ts <= r.eval.end; ts += r.eval.interval {
+		rangeEnd := ts - r.offset()
+		rangeStart := rangeEnd - r.rangeMs
+
+		// Drop the points which are not in the range anymore.
+		drop := 0
+		for drop < len(r.window) && r.window[drop].T < rangeStart {
+			drop++
+		}
+		r.window = append(r.window[:0], r.window[drop:]...)
+
+		// Add the points in the range, both ends included.
+		for !exhausted {
+			if !hasPending {
+				switch it.Next() {
+				case chunkenc.ValNone:
+					if it.Err() != nil {
+						return nil, it.Err()
+					}
+					exhausted = true
+					continue
+				case chunkenc.ValFloat:
+					pending.T, pending.V = it.At()
+					hasPending = true
+				default:
+					return nil, NewNotSupportedError("native histograms")
+				}
+			}
+
+			if pending.T > rangeEnd {
+				break
+			}
+
+			hasPending = false
+			if pending.T < rangeStart || value.IsStaleNaN(pending.V) {
+				continue
+			}
+			r.window = append(r.window, pending)
+		}
+
+		// No sense in trying to compute a rate without at least two points.
+		if len(r.window) < 2 {
+			continue
+		}
+
+		if points == nil {
+			points, err = r.eval.newPoints(r.eval.stepCount())
+			if err != nil {
+				return nil, err
+			}
+		}
+		points = append(points, promql.Point{T: ts, V: rate(r.window, rangeStart, rangeEnd, rangeSeconds)})
+	}
+
+	return points, nil
+}
+
+// rate computes the per-second rate of the counter points in the range, extrapolating
+// the result to the range boundaries the same way the Prometheus engine does.
+func rate(points []promql.Point, rangeStart, rangeEnd int64, rangeSeconds float64) float64 {
+	first, last := points[0], points[len(points)-1]
+
+	result := last.V - first.V
+	prevValue := first.V
+	for _, p := range points[1:] {
+		// Account for counter resets.
+		if p.V < prevValue {
+			result += prevValue
+		}
+		prevValue = p.V
+	}
+
+	// Duration between first/last samples and boundary of range.
+	durationToStart := float64(first.T-rangeStart) / 1000
+	durationToEnd := float64(rangeEnd-last.T) / 1000
+
+	sampledInterval := float64(last.T-first.T) / 1000
+	averageDurationBetweenSamples := sampledInterval / float64(len(points)-1)
+
+	// Counters can't be negative: don't extrapolate to before the counter was zero.
+	if result > 0 && first.V >= 0 {
+		durationToZero := sampledInterval * (first.V / result)
+		if durationToZero < durationToStart {
+			durationToStart = durationToZero
+		}
+	}
+
+	// Extrapolate to the range boundaries only if the first/last samples are close to them.
+	extrapolationThreshold := averageDurationBetweenSamples * 1.1
+	extrapolateToInterval := sampledInterval
+
+	if durationToStart < extrapolationThreshold {
+		extrapolateToInterval += durationToStart
+	} else {
+		extrapolateToInterval += averageDurationBetweenSamples / 2
+	}
+	if durationToEnd < extrapolationThreshold {
+		extrapolateToInterval += durationToEnd
+	} else {
+		extrapolateToInterval += averageDurationBetweenSamples / 2
+	}
+
+	factor := extrapolateToInterval / sampledInterval
+	factor /= rangeSeconds
+	return result * factor
+}
+
+// sumAggregation evaluates the sum aggregation. Each group is returned as soon as all the
+// series of the group have been read from the inner operator, so only the groups having
+// some, but not all, of their series read are kept in memory.
+type sumAggregation struct {
+	eval     *evaluation
+	inner    instantVectorOperator
+	grouping []string
+	without  bool
+
+	// Groups in the order they're returned.
+	groups []*sumGroup
+	// Group of each inner series, in the order the inner series are returned.
+	seriesGroups []*sumGroup
+
+	nextGroup int
+}
+
+type sumGroup struct {
+	labels labels.Labels
+
+	// Number of inner series of the group not yet read.
+	remainingSeries int
+	// Index of the last inner series of the group.
+	lastSeriesIndex int
+
+	sums    []float64
+	present []bool
+}
+
+// sumGroupStepSize is the estimated memory consumed by a sumGroup for each evaluation step, in bytes.
+const sumGroupStepSize = uint64(unsafe.Sizeof(float64(0)) + unsafe.Sizeof(false))
+
+func (a *sumAggregation) seriesMetadata(ctx context.Context) ([]labels.Labels, error) {
+	innerMetadata, err := a.inner.seriesMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groupsByKey := map[string]*sumGroup{}
+	a.seriesGroups = make([]*sumGroup, len(innerMetadata))
+	lb := labels.NewBuilder(labels.EmptyLabels())
+	buf := make([]byte, 0, 1024)
+
+	for i, l := range innerMetadata {
+		lb.Reset(l)
+		if a.without {
+			lb.Del(a.grouping...)
+			lb.Del(labels.MetricName)
+		} else {
+			lb.Keep(a.grouping...)
+		}
+		groupLabels := lb.Labels(labels.EmptyLabels())
+
+		buf = groupLabels.Bytes(buf)
+		group, ok := groupsByKey[string(buf)]
+		if !ok {
+			group = &sumGroup{labels: groupLabels}
+			groupsByKey[string(buf)] = group
+			a.groups = append(a.groups, group)
+		}
+
+		group.remainingSeries++
+		group.lastSeriesIndex = i
+		a.seriesGroups[i] = group
+	}
+
+	// Return the groups in the order they're completed.
+	sort.Slice(a.groups, func(i, j int) bool {
+		return a.groups[i].lastSeriesIndex < a.groups[j].lastSeriesIndex
+	})
+
+	metadata := make([]labels.Labels, 0, len(a.groups))
+	for _, g := range a.groups {
+		metadata = append(metadata, g.labels)
+	}
+	return metadata, nil
+}
+
+func (a *sumAggregation) next(ctx context.Context) ([]promql.Point, error) {
+	if a.nextGroup >= len(a.groups) {
+		return nil, errEndOfSeries
+	}
+
+	group := a.groups[a.nextGroup]
+	a.groups[a.nextGroup] = nil
+	a.nextGroup++
+
+	// Read the inner series until all the series of the group have been added.
+	for group.remainingSeries > 0 {
+		points, err := a.inner.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		seriesGroup := a.seriesGroups[0]
+		a.seriesGroups = a.seriesGroups[1:]
+
+		if seriesGroup.sums == nil {
+			if err := a.eval.memoryTracker.IncreaseMemoryConsumption(sumGroupStepSize * uint64(a.eval.stepCount())); err != nil {
+				return nil, err
+			}
+			seriesGroup.sums = make([]float64, a.eval.stepCount())
+			seriesGroup.present = make([]bool, a.eval.stepCount())
+		}
+
+		for _, p := range points {
+			idx := a.eval.stepIndex(p.T)
+			if seriesGroup.present[idx] {
+				seriesGroup.sums[idx] += p.V
+			} else {
+				seriesGroup.sums[idx] = p.V
+				seriesGroup.present[idx] = true
+			}
+		}
+
+		// The inner points have been added to the group, and are not needed anymore.
+		a.eval.releasePoints(points)
+		seriesGroup.remainingSeries--
+	}
+
+	var points []promql.Point
+	for idx, present := range group.present {
+		if !present {
+			continue
+		}
+		if points == nil {
+			var err error
+			points, err = a.eval.newPoints(len(group.present) - idx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		points = append(points, promql.Point{T: a.eval.start + int64(idx)*a.eval.interval, V: group.sums[idx]})
+	}
+
+	// The group has been returned, so its sums are not needed anymore.
+	if group.sums != nil {
+		a.eval.memoryTracker.DecreaseMemoryConsumption(sumGroupStepSize * uint64(len(group.sums)))
+		group.sums, group.present = nil, nil
+	}
+
+	return points, nil
+}
+
+func (a *sumAggregation) close() {
+	a.inner.close()
+}
@@ -0,0 +1,279 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/stats"
+
+	"github.com/grafana/mimir/pkg/util/limiter" //lint:ignore faillint the memory consumption is tracked with the limiter package
+)
+
+// pointSize is the estimated size of a promql.Point, in bytes.
+var pointSize = uint64(unsafe.Sizeof(promql.Point{}))
+
+// evaluation holds the parameters shared by all the operators evaluating a query.
+type evaluation struct {
+	queryable storage.Queryable
+
+	// Timestamps and interval of the evaluation steps, in milliseconds.
+	// Instant queries are evaluated as range queries with a single step.
+	start, end, interval int64
+
+	lookbackDelta int64
+
+	// Warnings returned by the queriers.
+	warnings storage.Warnings
+
+	// Tracks the memory consumed by the points held by the operators.
+	memoryTracker *limiter.MemoryConsumptionTracker
+}
+
+// stepCount returns the number of evaluation steps.
+func (e *evaluation) stepCount() int {
+	return int((e.end-e.start)/e.interval) + 1
+}
+
+// stepIndex returns the index of the evaluation step at the given timestamp.
+func (e *evaluation) stepIndex(ts int64) int {
+	return int((ts - e.start) / e.interval)
+}
+
+// newPoints returns an empty slice of points with the input capacity, tracking its memory consumption.
+// It fails if the query exceeds the max estimated memory consumption.
+func (e *evaluation) newPoints(capacity int) ([]promql.Point, error) {
+	if err := e.memoryTracker.IncreaseMemoryConsumption(uint64(capacity) * pointSize); err != nil {
+		return nil, err
+	}
+	return make([]promql.Point, 0, capacity), nil
+}
+
+// releasePoints tracks the memory of the input points, returned by newPoints(), as released.
+func (e *evaluation) releasePoints(points []promql.Point) {
+	e.memoryTracker.DecreaseMemoryConsumption(uint64(cap(points)) * pointSize)
+}
+
+type query struct {
+	engine    *Engine
+	qs        string
+	statement *parser.EvalStmt
+	instant   bool
+
+	eval   *evaluation
+	root   instantVectorOperator
+	timers *stats.QueryTimers
+	cancel context.CancelFunc
+}
+
+func newQuery(engine *Engine, queryable storage.Queryable, opts *promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (*query, error) {
+	expr, err := parser.ParseExpr(qs)
+	if err != nil {
+		return nil, err
+	}
+	if expr.Type() != parser.ValueTypeVector {
+		return nil, NewNotSupportedError(fmt.Sprintf("%s expression", expr.Type()))
+	}
+
+	lookbackDelta := engine.lookbackDelta
+	if opts != nil && opts.LookbackDelta > 0 {
+		lookbackDelta = opts.LookbackDelta
+	}
+
+	q := &query{
+		engine: engine,
+		qs:     qs,
+		statement: &parser.EvalStmt{
+			Expr:          expr,
+			Start:         start,
+			End:           end,
+			Interval:      interval,
+			LookbackDelta: lookbackDelta,
+		},
+		instant: interval == 0,
+		eval: &evaluation{
+			queryable:     queryable,
+			start:         timestamp.FromTime(start),
+			end:           timestamp.FromTime(end),
+			interval:      interval.Milliseconds(),
+			lookbackDelta: lookbackDelta.Milliseconds(),
+		},
+		timers: stats.NewQueryTimers(),
+	}
+
+	if q.instant {
+		q.eval.interval = 1
+	}
+
+	q.root, err = q.newOperator(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// newOperator returns the operator evaluating the input expression, or a NotSupportedError
+// if the expression uses features not supported by the streaming engine.
+func (q *query) newOperator(expr parser.Expr) (instantVectorOperator, error) {
+	switch e := expr.(type) {
+	case *parser.VectorSelector:
+		if e.Timestamp != nil || e.StartOrEnd != 0 {
+			return nil, NewNotSupportedError("@ modifier")
+		}
+
+		return &instantVectorSelector{selector: newSelector(q.eval, e, 0, "")}, nil
+
+	case *parser.Call:
+		if e.Func.Name != "rate" {
+			return nil, NewNotSupportedError(fmt.Sprintf("'%s' function", e.Func.Name))
+		}
+
+		matrix, ok := e.Args[0].(*parser.MatrixSelector)
+		if !ok {
+			return nil, NewNotSupportedError(fmt.Sprintf("%s as argument of the 'rate' function", parser.DocumentedType(e.Args[0].Type())))
+		}
+
+		vs := matrix.VectorSelector.(*parser.VectorSelector)
+		if vs.Timestamp != nil || vs.StartOrEnd != 0 {
+			return nil, NewNotSupportedError("@ modifier")
+		}
+
+		return &rateFunction{selector: newSelector(q.eval, vs, matrix.Range, e.Func.Name)}, nil
+
+	case *parser.AggregateExpr:
+		if e.Op != parser.SUM {
+			return nil, NewNotSupportedError(fmt.Sprintf("'%s' aggregation", e.Op))
+		}
+
+		inner, err := q.newOperator(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &sumAggregation{
+			eval:     q.eval,
+			inner:    inner,
+			grouping: e.Grouping,
+			without:  e.Without,
+		}, nil
+
+	case *parser.ParenExpr:
+		return q.newOperator(e.Expr)
+
+	default:
+		return nil, NewNotSupportedError(fmt.Sprintf("PromQL expression type %T", e))
+	}
+}
+
+// Exec implements promql.Query.
+func (q *query) Exec(ctx context.Context) *promql.Result {
+	defer q.timers.GetTimer(stats.ExecTotalTime).Start().Stop()
+
+	if q.engine.timeout > 0 {
+		ctx, q.cancel = context.WithTimeout(ctx, q.engine.timeout)
+	} else {
+		ctx, q.cancel = context.WithCancel(ctx)
+	}
+	defer q.cancel()
+
+	if q.engine.tracker != nil {
+		queryIndex, err := q.engine.tracker.Insert(ctx, q.qs)
+		if err != nil {
+			return &promql.Result{Err: err}
+		}
+		defer q.engine.tracker.Delete(queryIndex)
+	}
+
+	// The tracker may have been added by the caller, to track the memory consumed by the whole query.
+	q.eval.memoryTracker = limiter.MemoryConsumptionTrackerFromContextWithFallback(ctx)
+	ctx = limiter.AddMemoryConsumptionTrackerToContext(ctx, q.eval.memoryTracker)
+
+	defer q.timers.GetTimer(stats.EvalTotalTime).Start().Stop()
+	defer q.root.close()
+
+	value, err := q.evaluate(ctx)
+	if err != nil {
+		return &promql.Result{Err: err}
+	}
+
+	return &promql.Result{Value: value, Warnings: q.eval.warnings}
+}
+
+func (q *query) evaluate(ctx context.Context) (parser.Value, error) {
+	series, err := q.root.seriesMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.instant {
+		vector := make(promql.Vector, 0, len(series))
+		for _, metric := range series {
+			points, err := q.root.next(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if len(points) == 0 {
+				continue
+			}
+
+			vector = append(vector, promql.Sample{Metric: metric, Point: promql.Point{T: q.eval.start, V: points[0].V}})
+			q.eval.releasePoints(points)
+		}
+
+		return vector, nil
+	}
+
+	matrix := make(promql.Matrix, 0, len(series))
+	for _, metric := range series {
+		points, err := q.root.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(points) == 0 {
+			continue
+		}
+
+		matrix = append(matrix, promql.Series{Metric: metric, Points: points})
+	}
+
+	// Range query results are sorted by labels, like the Prometheus engine does.
+	sort.Sort(matrix)
+	return matrix, nil
+}
+
+// Close implements promql.Query.
+func (q *query) Close() {}
+
+// Statement implements promql.Query.
+func (q *query) Statement() parser.Statement {
+	return q.statement
+}
+
+// Stats implements promql.Query.
+func (q *query) Stats() *stats.Statistics {
+	return &stats.Statistics{
+		Timers:  q.timers,
+		Samples: stats.NewQuerySamples(false),
+	}
+}
+
+// Cancel implements promql.Query.
+func (q *query) Cancel() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+}
+
+// String implements promql.Query.
+func (q *query) String() string {
+	return q.qs
+}
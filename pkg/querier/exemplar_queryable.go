@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// ExemplarQueryableLimits is the interface that should be implemented by the limits provider of the exemplar queryable.
+type ExemplarQueryableLimits interface {
+	ExemplarsPersistenceEnabled(userID string) bool
+	MaxFetchedExemplarsPerQuery(userID string) int
+	QueryIngestersWithin(userID string) time.Duration
+}
+
+type mergeExemplarQueryable struct {
+	ingesters            storage.ExemplarQueryable
+	stores               []storage.ExemplarQueryable
+	queryIngestersWithin time.Duration
+	limits               ExemplarQueryableLimits
+	logger               log.Logger
+}
+
+// NewExemplarQueryable returns an ExemplarQueryable querying the exemplars from ingesters and, for the tenants
+// with exemplars persistence enabled, the exemplars persisted in the long-term storage too. In the latter case,
+// the ingesters are not queried for time ranges older than queryIngestersWithin, or the tenant's one if set,
+// because they're covered by the long-term storage.
+func NewExemplarQueryable(ingesters storage.ExemplarQueryable, stores []storage.ExemplarQueryable, queryIngestersWithin time.Duration, limits ExemplarQueryableLimits, logger log.Logger) storage.ExemplarQueryable {
+	return &mergeExemplarQueryable{
+		ingesters:            ingesters,
+		stores:               stores,
+		queryIngestersWithin: queryIngestersWithin,
+		limits:               limits,
+		logger:               logger,
+	}
+}
+
+func (m *mergeExemplarQueryable) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ingesters, err := m.ingesters.ExemplarQuerier(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stores []storage.ExemplarQuerier
+	if m.limits.ExemplarsPersistenceEnabled(userID) {
+		stores = make([]storage.ExemplarQuerier, 0, len(m.stores))
+		for _, q := range m.stores {
+			querier, err := q.ExemplarQuerier(ctx)
+			if err != nil {
+				return nil, err
+			}
+			stores = append(stores, querier)
+		}
+	}
+
+	queryIngestersWithin := m.queryIngestersWithin
+	if d := m.limits.QueryIngestersWithin(userID); d > 0 {
+		queryIngestersWithin = d
+	}
+
+	return &mergeExemplarQuerier{
+		ctx:                  ctx,
+		ingesters:            ingesters,
+		stores:               stores,
+		queryIngestersWithin: queryIngestersWithin,
+		maxExemplars:         m.limits.MaxFetchedExemplarsPerQuery(userID),
+		logger:               m.logger,
+	}, nil
+}
+
+type mergeExemplarQuerier struct {
+	ctx                  context.Context
+	ingesters            storage.ExemplarQuerier
+	stores               []storage.ExemplarQuerier
+	queryIngestersWithin time.Duration
+	maxExemplars         int
+	logger               log.Logger
+}
+
+// Select implements storage.ExemplarQuerier interface.
+func (m *mergeExemplarQuerier) Select(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	// Skip the merging when the exemplars are only queried from ingesters, to keep their results as they are.
+	if len(m.stores) == 0 {
+		res, err := m.ingesters.Select(start, end, matchers...)
+		if err != nil {
+			return nil, err
+		}
+		return res, m.checkExemplarsLimit(res)
+	}
+
+	spanlog, _ := spanlogger.NewWithLogger(m.ctx, m.logger, "mergeExemplarQuerier.Select")
+	defer spanlog.Finish()
+
+	queriers := m.stores
+	if m.shouldQueryIngesters(time.Now(), end) {
+		queriers = append([]storage.ExemplarQuerier{m.ingesters}, m.stores...)
+	} else {
+		level.Debug(spanlog).Log("msg", "not querying ingesters; query time range ends before the query-ingesters-within limit", "queryIngestersWithin", m.queryIngestersWithin)
+	}
+
+	var (
+		g, _ = errgroup.WithContext(m.ctx)
+		sets = make([][]exemplar.QueryResult, len(queriers))
+	)
+
+	for i, q := range queriers {
+		i, q := i, q
+		g.Go(func() error {
+			res, err := q.Select(start, end, matchers...)
+			if err != nil {
+				return err
+			}
+			sets[i] = res
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	res := mergeExemplarQueryResults(sets...)
+	level.Debug(spanlog).Log("numSeries", len(res))
+
+	return res, m.checkExemplarsLimit(res)
+}
+
+// shouldQueryIngesters returns whether the ingesters should be queried for a time range ending at end,
+// given that the exemplars older than queryIngestersWithin are covered by the long-term storage.
+func (m *mergeExemplarQuerier) shouldQueryIngesters(now time.Time, end int64) bool {
+	return m.queryIngestersWithin == 0 || end >= util.TimeToMillis(now.Add(-m.queryIngestersWithin))
+}
+
+func (m *mergeExemplarQuerier) checkExemplarsLimit(res []exemplar.QueryResult) error {
+	if m.maxExemplars <= 0 {
+		return nil
+	}
+
+	numExemplars := 0
+	for _, r := range res {
+		numExemplars += len(r.Exemplars)
+	}
+	if numExemplars > m.maxExemplars {
+		return validation.NewMaxExemplarsPerQueryError(m.maxExemplars)
+	}
+	return nil
+}
+
+// mergeExemplarQueryResults merges the input exemplars query results. The returned series are sorted by labels,
+// and the exemplars of each series are sorted by timestamp, with duplicated exemplars removed.
+func mergeExemplarQueryResults(sets ...[]exemplar.QueryResult) []exemplar.QueryResult {
+	bySeries := map[string]*exemplar.QueryResult{}
+	for _, set := range sets {
+		for _, r := range set {
+			key := r.SeriesLabels.String()
+			if merged, ok := bySeries[key]; ok {
+				merged.Exemplars = append(merged.Exemplars, r.Exemplars...)
+				continue
+			}
+			bySeries[key] = &exemplar.QueryResult{
+				SeriesLabels: r.SeriesLabels,
+				Exemplars:    append([]exemplar.Exemplar(nil), r.Exemplars...),
+			}
+		}
+	}
+
+	res := make([]exemplar.QueryResult, 0, len(bySeries))
+	for _, r := range bySeries {
+		sort.SliceStable(r.Exemplars, func(i, j int) bool {
+			return r.Exemplars[i].Ts < r.Exemplars[j].Ts
+		})
+
+		exemplars := r.Exemplars[:0]
+		for _, e := range r.Exemplars {
+			if len(exemplars) > 0 && isSameExemplar(exemplars[len(exemplars)-1], e) {
+				continue
+			}
+			exemplars = append(exemplars, e)
+		}
+		r.Exemplars = exemplars
+
+		res = append(res, *r)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return labels.Compare(res[i].SeriesLabels, res[j].SeriesLabels) < 0
+	})
+	return res
+}
+
+func isSameExemplar(a, b exemplar.Exemplar) bool {
+	return a.Ts == b.Ts && a.Value == b.Value && labels.Equal(a.Labels, b.Labels)
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestExemplarQueryable(t *testing.T) {
+	var (
+		series1 = labels.FromStrings(labels.MetricName, "series_1")
+		series2 = labels.FromStrings(labels.MetricName, "series_2")
+		ex1     = exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "1"), Ts: 10, Value: 1, HasTs: true}
+		ex2     = exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "2"), Ts: 20, Value: 2, HasTs: true}
+		ex3     = exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "3"), Ts: 30, Value: 3, HasTs: true}
+
+		ingesters = &exemplarQueryableMock{res: []exemplar.QueryResult{
+			{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{ex3}},
+			{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{ex2}},
+		}}
+		stores = &exemplarQueryableMock{res: []exemplar.QueryResult{
+			{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{ex1, ex2}},
+		}}
+	)
+
+	now := time.Now()
+
+	tests := map[string]struct {
+		persistenceEnabled   bool
+		queryIngestersWithin time.Duration
+		queryEnd             time.Time
+		maxExemplars         int
+		ingestersErr         error
+		expected             []exemplar.QueryResult
+		expectedErr          error
+	}{
+		"should query only ingesters if exemplars persistence is disabled": {
+			persistenceEnabled: false,
+			expected:           ingesters.res,
+		},
+		"should merge ingesters and store-gateways exemplars if exemplars persistence is enabled": {
+			persistenceEnabled: true,
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{ex1, ex2}},
+				{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{ex3}},
+			},
+		},
+		"should query only store-gateways if the query time range ends before the query-ingesters-within limit": {
+			persistenceEnabled:   true,
+			queryIngestersWithin: time.Hour,
+			queryEnd:             now.Add(-2 * time.Hour),
+			expected:             stores.res,
+		},
+		"should merge ingesters and store-gateways exemplars if the query time range ends within the query-ingesters-within limit": {
+			persistenceEnabled:   true,
+			queryIngestersWithin: time.Hour,
+			queryEnd:             now,
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{ex1, ex2}},
+				{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{ex3}},
+			},
+		},
+		"should query ingesters regardless of the query-ingesters-within limit if exemplars persistence is disabled": {
+			persistenceEnabled:   false,
+			queryIngestersWithin: time.Hour,
+			queryEnd:             now.Add(-2 * time.Hour),
+			expected:             ingesters.res,
+		},
+		"should fail if querying the ingesters fails while merging the store-gateways exemplars": {
+			persistenceEnabled: true,
+			ingestersErr:       errors.New("ingesters failure"),
+			expectedErr:        errors.New("ingesters failure"),
+		},
+		"should succeed if the number of fetched exemplars is within the limit": {
+			persistenceEnabled: true,
+			maxExemplars:       3,
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: series1, Exemplars: []exemplar.Exemplar{ex1, ex2}},
+				{SeriesLabels: series2, Exemplars: []exemplar.Exemplar{ex3}},
+			},
+		},
+		"should fail if the number of fetched exemplars exceeds the limit": {
+			persistenceEnabled: true,
+			maxExemplars:       2,
+			expectedErr:        validation.NewMaxExemplarsPerQueryError(2),
+		},
+		"should fail if the number of exemplars fetched from ingesters exceeds the limit": {
+			persistenceEnabled: false,
+			maxExemplars:       1,
+			expectedErr:        validation.NewMaxExemplarsPerQueryError(1),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := &exemplarQueryableLimitsMock{persistenceEnabled: testData.persistenceEnabled, maxExemplars: testData.maxExemplars}
+			ingesters := &exemplarQueryableMock{res: ingesters.res, err: testData.ingestersErr}
+			queryable := NewExemplarQueryable(ingesters, []storage.ExemplarQueryable{stores}, testData.queryIngestersWithin, limits, log.NewNopLogger())
+
+			q, err := queryable.ExemplarQuerier(user.InjectOrgID(context.Background(), "user-1"))
+			require.NoError(t, err)
+
+			queryEnd := int64(100)
+			if !testData.queryEnd.IsZero() {
+				queryEnd = util.TimeToMillis(testData.queryEnd)
+			}
+
+			res, err := q.Select(0, queryEnd, []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")})
+			if testData.expectedErr != nil {
+				require.Equal(t, testData.expectedErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, res)
+		})
+	}
+}
+
+type exemplarQueryableMock struct {
+	res []exemplar.QueryResult
+	err error
+}
+
+func (m *exemplarQueryableMock) ExemplarQuerier(context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *exemplarQueryableMock) Select(_, _ int64, _ ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.res, nil
+}
+
+type exemplarQueryableLimitsMock struct {
+	persistenceEnabled   bool
+	maxExemplars         int
+	queryIngestersWithin time.Duration
+}
+
+func (m *exemplarQueryableLimitsMock) ExemplarsPersistenceEnabled(string) bool {
+	return m.persistenceEnabled
+}
+
+func (m *exemplarQueryableLimitsMock) MaxFetchedExemplarsPerQuery(string) int {
+	return m.maxExemplars
+}
+
+func (m *exemplarQueryableLimitsMock) QueryIngestersWithin(string) time.Duration {
+	return m.queryIngestersWithin
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/querier/engine"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+type functionInfo struct {
+	Name         string `json:"name"`
+	Experimental bool   `json:"experimental"`
+	Enabled      bool   `json:"enabled"`
+}
+
+type functionsResult struct {
+	Status string         `json:"status"`
+	Data   []functionInfo `json:"data,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// FunctionsHandler creates a http.Handler listing the PromQL functions, and whether they're experimental
+// and enabled for the tenant. The experimental functions are only enabled if they're enabled for all the tenants
+// of the request.
+func FunctionsHandler(limits *validation.Overrides) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantIDs, err := tenant.TenantIDs(r.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			util.WriteJSONResponse(w, functionsResult{Status: statusError, Error: err.Error()})
+			return
+		}
+
+		functions := make([]functionInfo, 0, len(parser.Functions))
+		for name := range parser.Functions {
+			info := functionInfo{Name: name, Enabled: true}
+
+			if engine.IsExperimentalFunction(name) {
+				info.Experimental = true
+				for _, tenantID := range tenantIDs {
+					if !engine.IsExperimentalFunctionEnabled(name, limits.EnabledPromQLExperimentalFunctions(tenantID)) {
+						info.Enabled = false
+						break
+					}
+				}
+			}
+
+			functions = append(functions, info)
+		}
+
+		sort.Slice(functions, func(i, j int) bool {
+			return functions[i].Name < functions[j].Name
+		})
+
+		util.WriteJSONResponse(w, functionsResult{Status: statusSuccess, Data: functions})
+	})
+}
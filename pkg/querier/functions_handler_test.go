@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestFunctionsHandler(t *testing.T) {
+	enabledLimits := defaultLimitsConfig()
+	enabledLimits.EnabledPromQLExperimentalFunctions = []string{"sort_by_label"}
+	overrides, err := validation.NewOverrides(defaultLimitsConfig(), validation.NewMockTenantLimits(map[string]*validation.Limits{
+		"enabled": &enabledLimits,
+	}))
+	require.NoError(t, err)
+
+	handler := FunctionsHandler(overrides)
+
+	getFunctions := func(t *testing.T, tenantID string) map[string]functionInfo {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/functions", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), tenantID))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var result functionsResult
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+		require.Equal(t, statusSuccess, result.Status)
+
+		functions := map[string]functionInfo{}
+		for _, f := range result.Data {
+			functions[f.Name] = f
+		}
+		return functions
+	}
+
+	t.Run("tenant with an experimental function enabled", func(t *testing.T) {
+		functions := getFunctions(t, "enabled")
+		assert.Equal(t, functionInfo{Name: "rate", Experimental: false, Enabled: true}, functions["rate"])
+		assert.Equal(t, functionInfo{Name: "sort_by_label", Experimental: true, Enabled: true}, functions["sort_by_label"])
+		assert.Equal(t, functionInfo{Name: "mad_over_time", Experimental: true, Enabled: false}, functions["mad_over_time"])
+	})
+
+	t.Run("request without tenant", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/api/v1/functions", nil))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
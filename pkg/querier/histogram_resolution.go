@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"go.uber.org/atomic"
+)
+
+// minNativeHistogramSchema and maxNativeHistogramSchema are the lowest and highest resolution schemas
+// of the exponential native histograms.
+const (
+	minNativeHistogramSchema = -4
+	maxNativeHistogramSchema = 8
+)
+
+var errHistogramResolutionReduced = errors.New("the resolution of some native histograms has been reduced, to not exceed the max schema or the max number of buckets of the native histograms returned by the tenant's queries")
+
+type histogramResolutionContextKey int
+
+const histogramResolutionReducedKey histogramResolutionContextKey = 0
+
+// contextWithHistogramResolutionReduced adds a flag to the context recording whether the resolution of any
+// histogram selected by the query has been reduced. The PromQL engines collect the warnings of the series sets
+// before iterating their samples, so the warning of a reduction has to be added to the result of the query once
+// the query has been evaluated.
+func contextWithHistogramResolutionReduced(ctx context.Context) (context.Context, *atomic.Bool) {
+	reduced := atomic.NewBool(false)
+	return context.WithValue(ctx, histogramResolutionReducedKey, reduced), reduced
+}
+
+// histogramResolutionReducedFromContext returns the flag recording whether the resolution of any histogram
+// selected by the query has been reduced, or a new flag if the context has none.
+func histogramResolutionReducedFromContext(ctx context.Context) *atomic.Bool {
+	if reduced, ok := ctx.Value(histogramResolutionReducedKey).(*atomic.Bool); ok {
+		return reduced
+	}
+	return atomic.NewBool(false)
+}
+
+// addHistogramResolutionWarning adds the warning of the histograms resolution reduction to the input warnings,
+// unless they already have it.
+func addHistogramResolutionWarning(warnings storage.Warnings) storage.Warnings {
+	for _, w := range warnings {
+		if errors.Is(w, errHistogramResolutionReduced) {
+			return warnings
+		}
+	}
+	return append(warnings, errHistogramResolutionReduced)
+}
+
+// histogramResolutionQuerier is a storage.Querier reducing the resolution of the native histograms of the
+// selected series, so that they don't have a schema higher than maxSchema, and don't have more than maxBuckets
+// buckets. The reductions are recorded in the reduced flag while the samples are iterated, and a warning is
+// returned by the series sets iterated after the resolution of any histogram has been reduced.
+type histogramResolutionQuerier struct {
+	storage.Querier
+
+	maxSchema  int32
+	maxBuckets int
+	reduced    *atomic.Bool
+}
+
+func newHistogramResolutionQuerier(q storage.Querier, maxSchema, maxBuckets int, reduced *atomic.Bool) storage.Querier {
+	return &histogramResolutionQuerier{Querier: q, maxSchema: int32(maxSchema), maxBuckets: maxBuckets, reduced: reduced}
+}
+
+// Select implements storage.Querier.
+func (q *histogramResolutionQuerier) Select(sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	return &histogramResolutionSeriesSet{SeriesSet: q.Querier.Select(sortSeries, sp, matchers...), maxSchema: q.maxSchema, maxBuckets: q.maxBuckets, reduced: q.reduced}
+}
+
+// histogramResolutionSeriesSet reduces the resolution of the native histograms of the series of the wrapped set.
+type histogramResolutionSeriesSet struct {
+	storage.SeriesSet
+
+	maxSchema  int32
+	maxBuckets int
+	reduced    *atomic.Bool
+}
+
+func (s *histogramResolutionSeriesSet) At() storage.Series {
+	return &histogramResolutionSeries{Series: s.SeriesSet.At(), maxSchema: s.maxSchema, maxBuckets: s.maxBuckets, reduced: s.reduced}
+}
+
+func (s *histogramResolutionSeriesSet) Warnings() storage.Warnings {
+	warnings := s.SeriesSet.Warnings()
+	if s.reduced.Load() {
+		warnings = addHistogramResolutionWarning(warnings)
+	}
+	return warnings
+}
+
+type histogramResolutionSeries struct {
+	storage.Series
+
+	maxSchema  int32
+	maxBuckets int
+	reduced    *atomic.Bool
+}
+
+func (s *histogramResolutionSeries) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	if prev, ok := it.(*histogramResolutionIterator); ok {
+		it = prev.Iterator
+	}
+	return &histogramResolutionIterator{Iterator: s.Series.Iterator(it), maxSchema: s.maxSchema, maxBuckets: s.maxBuckets, reducedFlag: s.reduced}
+}
+
+// histogramResolutionIterator returns the histograms whose resolution has to be reduced as float histograms,
+// since the resolution of the integer histograms can't be reduced.
+type histogramResolutionIterator struct {
+	chunkenc.Iterator
+
+	maxSchema   int32
+	maxBuckets  int
+	reduced     *histogram.FloatHistogram
+	reducedFlag *atomic.Bool
+}
+
+func (it *histogramResolutionIterator) Next() chunkenc.ValueType {
+	return it.reduce(it.Iterator.Next())
+}
+
+func (it *histogramResolutionIterator) Seek(t int64) chunkenc.ValueType {
+	return it.reduce(it.Iterator.Seek(t))
+}
+
+func (it *histogramResolutionIterator) reduce(valType chunkenc.ValueType) chunkenc.ValueType {
+	it.reduced = nil
+
+	switch valType {
+	case chunkenc.ValHistogram:
+		_, h := it.Iterator.AtHistogram()
+		if !histogramNeedsReduction(h.Schema, len(h.PositiveBuckets)+len(h.NegativeBuckets), it.maxSchema, it.maxBuckets) {
+			return valType
+		}
+		it.reduced = reduceHistogramResolution(h.ToFloat(), it.maxSchema, it.maxBuckets)
+		it.reducedFlag.Store(true)
+		return chunkenc.ValFloatHistogram
+	case chunkenc.ValFloatHistogram:
+		_, h := it.Iterator.AtFloatHistogram()
+		if histogramNeedsReduction(h.Schema, len(h.PositiveBuckets)+len(h.NegativeBuckets), it.maxSchema, it.maxBuckets) {
+			it.reduced = reduceHistogramResolution(h, it.maxSchema, it.maxBuckets)
+			it.reducedFlag.Store(true)
+		}
+	}
+	return valType
+}
+
+func (it *histogramResolutionIterator) AtFloatHistogram() (int64, *histogram.FloatHistogram) {
+	if it.reduced != nil {
+		return it.Iterator.AtT(), it.reduced
+	}
+	return it.Iterator.AtFloatHistogram()
+}
+
+func histogramNeedsReduction(schema int32, buckets int, maxSchema int32, maxBuckets int) bool {
+	if schema > maxSchema {
+		return true
+	}
+	return maxBuckets > 0 && buckets > maxBuckets && schema > minNativeHistogramSchema
+}
+
+// reduceHistogramResolution returns a copy of the input histogram with the highest resolution not higher than
+// maxSchema, and with no more than maxBuckets buckets, unless the lowest resolution has been reached.
+func reduceHistogramResolution(h *histogram.FloatHistogram, maxSchema int32, maxBuckets int) *histogram.FloatHistogram {
+	reduced := h
+	if h.Schema > maxSchema {
+		reduced = h.CopyToSchema(maxSchema)
+	}
+	for maxBuckets > 0 && len(reduced.PositiveBuckets)+len(reduced.NegativeBuckets) > maxBuckets && reduced.Schema > minNativeHistogramSchema {
+		reduced = reduced.CopyToSchema(reduced.Schema - 1)
+	}
+
+	if reduced == h {
+		reduced = h.Copy()
+	}
+	reduced.CounterResetHint = h.CounterResetHint
+	return reduced
+}
@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/tsdbutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/engine"
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestHistogramResolutionSeriesSet(t *testing.T) {
+	// The test histograms have schema 1 and 8 buckets.
+	newSet := func() storage.SeriesSet {
+		return series.NewConcreteSeriesSet([]storage.Series{
+			series.NewConcreteSeries(labels.FromStrings("type", "float"), []model.SamplePair{{Timestamp: 1, Value: 1}}, nil),
+			series.NewConcreteSeries(labels.FromStrings("type", "histogram"), nil, []mimirpb.Histogram{
+				mimirpb.FromHistogramToHistogramProto(1, tsdbutil.GenerateTestHistogram(1)),
+				mimirpb.FromFloatHistogramToHistogramProto(2, tsdbutil.GenerateTestFloatHistogram(2)),
+			}),
+		})
+	}
+
+	tests := map[string]struct {
+		maxSchema         int32
+		maxBuckets        int
+		expectedSchema    int32
+		expectedReduction bool
+	}{
+		"no reduction": {
+			maxSchema:      maxNativeHistogramSchema,
+			maxBuckets:     8,
+			expectedSchema: 1,
+		},
+		"schema above the max schema": {
+			maxSchema:         0,
+			expectedSchema:    0,
+			expectedReduction: true,
+		},
+		"buckets above the max buckets": {
+			maxSchema:         maxNativeHistogramSchema,
+			maxBuckets:        4,
+			expectedSchema:    -1,
+			expectedReduction: true,
+		},
+		"buckets above the max buckets at the lowest resolution": {
+			maxSchema:         maxNativeHistogramSchema,
+			maxBuckets:        1,
+			expectedSchema:    minNativeHistogramSchema,
+			expectedReduction: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			set := &histogramResolutionSeriesSet{SeriesSet: newSet(), maxSchema: testData.maxSchema, maxBuckets: testData.maxBuckets, reduced: atomic.NewBool(false)}
+
+			var all []storage.Series
+			for set.Next() {
+				all = append(all, set.At())
+			}
+			require.NoError(t, set.Err())
+			require.Len(t, all, 2)
+
+			// The reduction is only known once the samples have been iterated.
+			assert.Empty(t, set.Warnings())
+
+			// The float samples are never changed.
+			it := all[0].Iterator(nil)
+			require.Equal(t, chunkenc.ValFloat, it.Next())
+			ts, v := it.At()
+			assert.Equal(t, int64(1), ts)
+			assert.Equal(t, float64(1), v)
+			require.Equal(t, chunkenc.ValNone, it.Next())
+
+			it = all[1].Iterator(it)
+			var histograms []*histogram.FloatHistogram
+			for valType := it.Next(); valType != chunkenc.ValNone; valType = it.Next() {
+				switch valType {
+				case chunkenc.ValHistogram:
+					require.False(t, testData.expectedReduction, "integer histograms are returned as float histograms once reduced")
+					_, h := it.AtHistogram()
+					histograms = append(histograms, h.ToFloat())
+				case chunkenc.ValFloatHistogram:
+					_, h := it.AtFloatHistogram()
+					histograms = append(histograms, h)
+				default:
+					require.Fail(t, "unexpected value type", valType)
+				}
+			}
+			require.NoError(t, it.Err())
+
+			expected := []*histogram.FloatHistogram{tsdbutil.GenerateTestHistogram(1).ToFloat(), tsdbutil.GenerateTestFloatHistogram(2)}
+			require.Len(t, histograms, len(expected))
+			for i, h := range histograms {
+				assert.Equal(t, testData.expectedSchema, h.Schema)
+				assert.Equal(t, expected[i].Count, h.Count)
+				assert.Equal(t, expected[i].Sum, h.Sum)
+				if testData.maxBuckets > 0 && h.Schema > minNativeHistogramSchema {
+					assert.LessOrEqual(t, len(h.PositiveBuckets)+len(h.NegativeBuckets), testData.maxBuckets)
+				}
+			}
+
+			assert.Equal(t, testData.expectedReduction, set.reduced.Load())
+			if testData.expectedReduction {
+				assert.Equal(t, storage.Warnings{errHistogramResolutionReduced}, set.Warnings())
+			} else {
+				assert.Empty(t, set.Warnings())
+			}
+		})
+	}
+}
+
+func TestHistogramResolutionSeriesSet_SeriesMixingFloatsAndHistograms(t *testing.T) {
+	s := series.NewConcreteSeries(labels.FromStrings("type", "mixed"), []model.SamplePair{{Timestamp: 1, Value: 1}}, []mimirpb.Histogram{
+		mimirpb.FromHistogramToHistogramProto(2, tsdbutil.GenerateTestHistogram(2)),
+	})
+	set := &histogramResolutionSeriesSet{SeriesSet: series.NewConcreteSeriesSet([]storage.Series{s}), maxSchema: 0, reduced: atomic.NewBool(false)}
+
+	require.True(t, set.Next())
+	it := set.At().Iterator(nil)
+	require.Equal(t, chunkenc.ValFloat, it.Next())
+	require.Equal(t, chunkenc.ValFloatHistogram, it.Next())
+	require.Equal(t, chunkenc.ValNone, it.Next())
+	require.False(t, set.Next())
+
+	assert.Equal(t, storage.Warnings{errHistogramResolutionReduced}, set.Warnings())
+}
+
+func TestPerTenantEngine_HistogramResolutionWarning(t *testing.T) {
+	overrides, err := validation.NewOverrides(defaultLimitsConfig(), nil)
+	require.NoError(t, err)
+
+	engineCfg := engine.Config{}
+	flagext.DefaultValues(&engineCfg)
+	prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+	e := NewPerTenantEngine(engineCfg, prometheusEngine, overrides, nil, nil, log.NewNopLogger(), nil)
+
+	// The float sample is iterated first, then the histogram whose resolution is reduced.
+	queryable := storage.QueryableFunc(func(ctx context.Context, _, _ int64) (storage.Querier, error) {
+		s := series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "some_metric"), []model.SamplePair{{Timestamp: 0, Value: 1}}, []mimirpb.Histogram{
+			mimirpb.FromHistogramToHistogramProto(60000, tsdbutil.GenerateTestHistogram(1)),
+		})
+		q := testQuerier{ts: series.NewConcreteSeriesSet([]storage.Series{s})}
+		return newHistogramResolutionQuerier(q, 0, 0, histogramResolutionReducedFromContext(ctx)), nil
+	})
+
+	q, err := e.NewRangeQuery(queryable, nil, `some_metric`, time.Unix(0, 0), time.Unix(60, 0), time.Minute)
+	require.NoError(t, err)
+	defer q.Close()
+
+	res := q.Exec(user.InjectOrgID(context.Background(), "user-1"))
+	require.NoError(t, res.Err)
+	assert.Equal(t, storage.Warnings{errHistogramResolutionReduced}, res.Warnings)
+}
+
+func TestHistogramResolutionIterator_Seek(t *testing.T) {
+	s := series.NewConcreteSeries(labels.FromStrings("type", "histogram"), nil, []mimirpb.Histogram{
+		mimirpb.FromHistogramToHistogramProto(1, tsdbutil.GenerateTestHistogram(1)),
+		mimirpb.FromHistogramToHistogramProto(2, tsdbutil.GenerateTestHistogram(2)),
+	})
+
+	it := (&histogramResolutionSeries{Series: s, maxSchema: 0, reduced: atomic.NewBool(false)}).Iterator(nil)
+	require.Equal(t, chunkenc.ValFloatHistogram, it.Seek(2))
+	ts, h := it.AtFloatHistogram()
+	assert.Equal(t, int64(2), ts)
+	assert.Equal(t, int32(0), h.Schema)
+	assert.Equal(t, tsdbutil.GenerateTestHistogram(2).Count, uint64(h.Count))
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+)
+
+// ingesterDownsamplingConfig configures the downsampling hint sent to the ingesters for the range selectors
+// much larger than the query step.
+type ingesterDownsamplingConfig struct {
+	enabled       bool
+	minRangeSteps int64
+	avg           bool
+	counters      bool
+	histograms    bool
+}
+
+func newIngesterDownsamplingConfig(cfg Config) ingesterDownsamplingConfig {
+	return ingesterDownsamplingConfig{
+		enabled:       cfg.IngesterDownsamplingEnabled,
+		minRangeSteps: int64(cfg.IngesterDownsamplingMinRangeSteps),
+		avg:           cfg.IngesterDownsamplingAvgEnabled,
+		counters:      cfg.IngesterDownsamplingCountersEnabled,
+		histograms:    cfg.IngesterDownsamplingHistogramsEnabled,
+	}
+}
+
+// hint returns the downsampling hint to send to the ingesters for the selector, or nil if its samples
+// can't be downsampled. The ingesters keep the samples at the multiples of the step, so the selector's
+// time range and range must be multiples of the step for its windows to cover whole steps.
+func (cfg ingesterDownsamplingConfig) hint(sp *storage.SelectHints) *client.DownsamplingHint {
+	if !cfg.enabled || sp == nil || sp.Step <= 0 || sp.Range <= 0 {
+		return nil
+	}
+	if sp.Start%sp.Step != 0 || sp.End%sp.Step != 0 || sp.Range%sp.Step != 0 || sp.Range < cfg.minRangeSteps*sp.Step {
+		return nil
+	}
+
+	hint := &client.DownsamplingHint{StepMs: sp.Step}
+	switch sp.Func {
+	case "min_over_time":
+		// These functions take each histogram into account as a zero value, so keeping a single histogram
+		// per step doesn't change their result.
+		hint.Aggregation = client.AGGREGATION_MIN
+		hint.Histograms = true
+	case "max_over_time":
+		hint.Aggregation = client.AGGREGATION_MAX
+		hint.Histograms = true
+	case "avg_over_time":
+		if !cfg.avg {
+			return nil
+		}
+		hint.Aggregation = client.AGGREGATION_AVG
+		hint.Histograms = cfg.histograms
+	case "rate", "increase":
+		if !cfg.counters {
+			return nil
+		}
+		hint.Aggregation = client.AGGREGATION_LAST
+		hint.Histograms = cfg.histograms
+	default:
+		return nil
+	}
+	return hint
+}
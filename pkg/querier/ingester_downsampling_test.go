@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+)
+
+func TestIngesterDownsamplingConfig_Hint(t *testing.T) {
+	enabled := ingesterDownsamplingConfig{enabled: true, minRangeSteps: 10}
+	hints := func(fn string, start, end, step, rng int64) *storage.SelectHints {
+		return &storage.SelectHints{Func: fn, Start: start, End: end, Step: step, Range: rng}
+	}
+
+	tests := map[string]struct {
+		cfg      ingesterDownsamplingConfig
+		hints    *storage.SelectHints
+		expected *client.DownsamplingHint
+	}{
+		"disabled": {
+			cfg:   ingesterDownsamplingConfig{minRangeSteps: 10},
+			hints: hints("max_over_time", 0, 6000, 60, 600),
+		},
+		"no hints": {
+			cfg: enabled,
+		},
+		"instant query": {
+			cfg:   enabled,
+			hints: hints("max_over_time", 0, 6000, 0, 600),
+		},
+		"instant selector": {
+			cfg:   enabled,
+			hints: hints("max_over_time", 0, 6000, 60, 0),
+		},
+		"max_over_time": {
+			cfg:      enabled,
+			hints:    hints("max_over_time", 0, 6000, 60, 600),
+			expected: &client.DownsamplingHint{StepMs: 60, Aggregation: client.AGGREGATION_MAX, Histograms: true},
+		},
+		"min_over_time": {
+			cfg:      enabled,
+			hints:    hints("min_over_time", 0, 6000, 60, 600),
+			expected: &client.DownsamplingHint{StepMs: 60, Aggregation: client.AGGREGATION_MIN, Histograms: true},
+		},
+		"range smaller than the minimum number of steps": {
+			cfg:   enabled,
+			hints: hints("max_over_time", 0, 6000, 60, 540),
+		},
+		"range not multiple of the step": {
+			cfg:   enabled,
+			hints: hints("max_over_time", 0, 6000, 60, 630),
+		},
+		"start not multiple of the step": {
+			cfg:   enabled,
+			hints: hints("max_over_time", 30, 6000, 60, 600),
+		},
+		"end not multiple of the step": {
+			cfg:   enabled,
+			hints: hints("max_over_time", 0, 6030, 60, 600),
+		},
+		"unsupported function": {
+			cfg:   enabled,
+			hints: hints("sum_over_time", 0, 6000, 60, 600),
+		},
+		"avg_over_time not enabled": {
+			cfg:   enabled,
+			hints: hints("avg_over_time", 0, 6000, 60, 600),
+		},
+		"avg_over_time enabled": {
+			cfg:      ingesterDownsamplingConfig{enabled: true, minRangeSteps: 10, avg: true},
+			hints:    hints("avg_over_time", 0, 6000, 60, 600),
+			expected: &client.DownsamplingHint{StepMs: 60, Aggregation: client.AGGREGATION_AVG},
+		},
+		"rate not enabled": {
+			cfg:   enabled,
+			hints: hints("rate", 0, 6000, 60, 600),
+		},
+		"rate enabled with histograms": {
+			cfg:      ingesterDownsamplingConfig{enabled: true, minRangeSteps: 10, counters: true, histograms: true},
+			hints:    hints("rate", 0, 6000, 60, 600),
+			expected: &client.DownsamplingHint{StepMs: 60, Aggregation: client.AGGREGATION_LAST, Histograms: true},
+		},
+		"increase enabled": {
+			cfg:      ingesterDownsamplingConfig{enabled: true, minRangeSteps: 10, counters: true},
+			hints:    hints("increase", 0, 6000, 60, 600),
+			expected: &client.DownsamplingHint{StepMs: 60, Aggregation: client.AGGREGATION_LAST},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.cfg.hint(tc.hints))
+		})
+	}
+}
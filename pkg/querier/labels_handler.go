@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/weaveworks/common/httpgrpc/server"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/pagination"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+var (
+	// The default time range of the label names and values requests, the same as the Prometheus API.
+	labelsMinTime = time.Unix(math.MinInt64/1000+62135596801, 0).UTC()
+	labelsMaxTime = time.Unix(math.MaxInt64/1000-62135596801, 999999999).UTC()
+)
+
+type labelsResult struct {
+	Status        string   `json:"status"`
+	Data          []string `json:"data"`
+	Warnings      []string `json:"warnings,omitempty"`
+	NextPageToken string   `json:"nextPageToken,omitempty"`
+}
+
+// LabelsHandler creates a http.Handler serving the label names and label values requests.
+// The paginated requests are served by the handler, which returns the continuation token of
+// the next page, if any, while the other requests are served by the next handler.
+func LabelsHandler(queryable storage.Queryable, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeLabelsError(w, apierror.Newf(apierror.TypeBadData, "error parsing form values: %v", err))
+			return
+		}
+
+		page, err := pagination.ParseRequest(r)
+		if err != nil {
+			writeLabelsError(w, apierror.New(apierror.TypeBadData, err.Error()))
+			return
+		}
+		if !page.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start, end, matcherSets, err := extractLabelsRequestParams(r)
+		if err != nil {
+			writeLabelsError(w, apierror.New(apierror.TypeBadData, err.Error()))
+			return
+		}
+
+		labelName, isLabelValues := mux.Vars(r)["name"]
+		if isLabelValues && !model.LabelName(labelName).IsValid() {
+			writeLabelsError(w, apierror.Newf(apierror.TypeBadData, "invalid label name: %q", labelName))
+			return
+		}
+
+		// Fetch one more result than the page size, to know whether there's a next page.
+		fetchPage := pagination.Request{Limit: page.Limit + 1, After: page.After}
+		ctx := pagination.ContextWithRequest(r.Context(), fetchPage)
+
+		q, err := queryable.Querier(ctx, start, end)
+		if err != nil {
+			writeLabelsError(w, toLabelsAPIError(err))
+			return
+		}
+		defer q.Close()
+
+		fetch := func(matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+			if isLabelValues {
+				return q.LabelValues(labelName, matchers...)
+			}
+			return q.LabelNames(matchers...)
+		}
+
+		var (
+			sets     [][]string
+			warnings storage.Warnings
+		)
+		if len(matcherSets) == 0 {
+			matcherSets = [][]*labels.Matcher{nil}
+		}
+		for _, matchers := range matcherSets {
+			values, ws, err := fetch(matchers...)
+			if err != nil {
+				writeLabelsError(w, toLabelsAPIError(err))
+				return
+			}
+			sets = append(sets, values)
+			warnings = append(warnings, ws...)
+		}
+
+		res := labelsResult{Status: statusSuccess, Data: fetchPage.Apply(util.MergeSlices(sets...))}
+		if len(res.Data) > page.Limit {
+			res.Data = res.Data[:page.Limit]
+			res.NextPageToken = pagination.PageToken(res.Data[page.Limit-1])
+		}
+		if res.Data == nil {
+			res.Data = []string{}
+		}
+		for _, w := range warnings {
+			res.Warnings = append(res.Warnings, w.Error())
+		}
+
+		util.WriteJSONResponse(w, res)
+	})
+}
+
+// extractLabelsRequestParams parses the time range and the series selectors of a label names or values request.
+func extractLabelsRequestParams(r *http.Request) (start, end int64, matcherSets [][]*labels.Matcher, err error) {
+	start, end = labelsMinTime.UnixMilli(), labelsMaxTime.UnixMilli()
+
+	if s := r.Form.Get("start"); s != "" {
+		if start, err = util.ParseTime(s); err != nil {
+			return 0, 0, nil, errors.New("invalid 'start' param")
+		}
+	}
+	if e := r.Form.Get("end"); e != "" {
+		if end, err = util.ParseTime(e); err != nil {
+			return 0, 0, nil, errors.New("invalid 'end' param")
+		}
+	}
+
+	for _, s := range r.Form["match[]"] {
+		matchers, err := parser.ParseMetricSelector(s)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
+	return start, end, matcherSets, nil
+}
+
+func toLabelsAPIError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return apierror.New(apierror.TypeCanceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return apierror.New(apierror.TypeTimeout, err.Error())
+	default:
+		return apierror.New(apierror.TypeExec, err.Error())
+	}
+}
+
+func writeLabelsError(w http.ResponseWriter, err error) {
+	resp, _ := apierror.HTTPResponseFromError(err)
+	_ = server.WriteResponse(w, resp)
+}
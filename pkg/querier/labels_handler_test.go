@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/querier/pagination"
+)
+
+func TestLabelsHandler(t *testing.T) {
+	queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		return &paginatedLabelsQuerier{
+			page:        pagination.FromContext(ctx),
+			labelNames:  []string{"__name__", "instance", "job", "status"},
+			labelValues: []string{"a", "b", "c", "d", "e"},
+		}, nil
+	})
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/labels").Handler(LabelsHandler(queryable, next))
+	router.Path("/api/v1/label/{name}/values").Handler(LabelsHandler(queryable, next))
+
+	request := func(path string, params url.Values) (int, labelsResult) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path+"?"+params.Encode(), nil))
+
+		var res labelsResult
+		if rec.Code != http.StatusTeapot {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		}
+		return rec.Code, res
+	}
+
+	t.Run("should pass the requests without pagination to the next handler", func(t *testing.T) {
+		code, _ := request("/api/v1/labels", url.Values{})
+		assert.Equal(t, http.StatusTeapot, code)
+	})
+
+	t.Run("should page through the label names", func(t *testing.T) {
+		code, res := request("/api/v1/labels", url.Values{"limit": []string{"3"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"__name__", "instance", "job"}, res.Data)
+		require.NotEmpty(t, res.NextPageToken)
+
+		code, res = request("/api/v1/labels", url.Values{"limit": []string{"3"}, "page_token": []string{res.NextPageToken}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"status"}, res.Data)
+		assert.Empty(t, res.NextPageToken)
+	})
+
+	t.Run("should page through the label values", func(t *testing.T) {
+		var (
+			values []string
+			token  string
+			pages  int
+		)
+		for {
+			params := url.Values{"limit": []string{"2"}, "match[]": []string{`{job="test"}`}}
+			if token != "" {
+				params.Set("page_token", token)
+			}
+
+			code, res := request("/api/v1/label/job/values", params)
+			require.Equal(t, http.StatusOK, code)
+			require.LessOrEqual(t, len(res.Data), 2)
+
+			values = append(values, res.Data...)
+			pages++
+			if res.NextPageToken == "" {
+				break
+			}
+			token = res.NextPageToken
+		}
+
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, values)
+		assert.Equal(t, 3, pages)
+	})
+
+	t.Run("should not return a page token when the last page is full", func(t *testing.T) {
+		code, res := request("/api/v1/label/job/values", url.Values{"limit": []string{"5"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, res.Data)
+		assert.Empty(t, res.NextPageToken)
+	})
+
+	t.Run("should fail on invalid requests", func(t *testing.T) {
+		for _, params := range []url.Values{
+			{"limit": []string{"0"}},
+			{"page_token": []string{pagination.PageToken("a")}},
+			{"limit": []string{"2"}, "match[]": []string{`{job=`}},
+			{"limit": []string{"2"}, "start": []string{"yesterday"}},
+		} {
+			code, res := request("/api/v1/labels", params)
+			assert.Equal(t, http.StatusBadRequest, code, params.Encode())
+			assert.Equal(t, statusError, res.Status)
+		}
+	})
+}
+
+// paginatedLabelsQuerier is a storage.Querier returning the pages of label names and values
+// selected by the pagination of the context it's been created with.
+type paginatedLabelsQuerier struct {
+	storage.Querier
+
+	page        pagination.Request
+	labelNames  []string
+	labelValues []string
+}
+
+func (q *paginatedLabelsQuerier) LabelNames(_ ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	return q.page.Apply(q.labelNames), nil, nil
+}
+
+func (q *paginatedLabelsQuerier) LabelValues(_ string, _ ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	return q.page.Apply(q.labelValues), nil, nil
+}
+
+func (q *paginatedLabelsQuerier) Close() error {
+	return nil
+}
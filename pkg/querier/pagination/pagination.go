@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package pagination implements the pagination of the label names and values requests.
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// LimitParam is the HTTP param with the max number of results of a page.
+	LimitParam = "limit"
+
+	// PageTokenParam is the HTTP param with the continuation token returned with the previous page.
+	PageTokenParam = "page_token"
+)
+
+type contextKey int
+
+const requestCtxKey contextKey = 0
+
+// Request is the pagination of a label names or values request. The zero value means no pagination.
+type Request struct {
+	// Limit is the max number of results to return. 0 means no limit.
+	Limit int
+
+	// After is the value the results must sort after. Empty means from the first result.
+	After string
+}
+
+// Enabled returns whether the request is paginated.
+func (r Request) Enabled() bool {
+	return r.Limit > 0
+}
+
+// Apply returns the page of the input sorted values selected by the request.
+func (r Request) Apply(values []string) []string {
+	return util.PaginateSortedSlice(values, r.After, r.Limit)
+}
+
+// ParseRequest parses the pagination of the input HTTP request. The request form must have been parsed.
+func ParseRequest(r *http.Request) (Request, error) {
+	var req Request
+
+	if limit := r.Form.Get(LimitParam); limit != "" {
+		var err error
+		req.Limit, err = strconv.Atoi(limit)
+		if err != nil || req.Limit <= 0 {
+			return Request{}, fmt.Errorf("invalid '%s' param: must be a positive integer", LimitParam)
+		}
+	}
+
+	if token := r.Form.Get(PageTokenParam); token != "" {
+		if !req.Enabled() {
+			return Request{}, fmt.Errorf("the '%s' param requires the '%s' param", PageTokenParam, LimitParam)
+		}
+
+		after, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(after) == 0 {
+			return Request{}, fmt.Errorf("invalid '%s' param", PageTokenParam)
+		}
+		req.After = string(after)
+	}
+
+	return req, nil
+}
+
+// PageToken returns the continuation token of the page ending with the input value.
+func PageToken(last string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(last))
+}
+
+// ContextWithRequest returns a context carrying the pagination of the request, so that the
+// label names and values can be paginated down to the ingesters and store-gateways.
+func ContextWithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestCtxKey, req)
+}
+
+// FromContext returns the pagination carried by the context, or the zero value if none.
+func FromContext(ctx context.Context) Request {
+	req, _ := ctx.Value(requestCtxKey).(Request)
+	return req
+}
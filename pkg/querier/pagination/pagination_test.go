@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pagination
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := map[string]struct {
+		params      url.Values
+		expected    Request
+		expectedErr string
+	}{
+		"no pagination": {
+			params:   url.Values{},
+			expected: Request{},
+		},
+		"first page": {
+			params:   url.Values{LimitParam: []string{"10"}},
+			expected: Request{Limit: 10},
+		},
+		"next page": {
+			params:   url.Values{LimitParam: []string{"10"}, PageTokenParam: []string{PageToken("job")}},
+			expected: Request{Limit: 10, After: "job"},
+		},
+		"invalid limit": {
+			params:      url.Values{LimitParam: []string{"ten"}},
+			expectedErr: "invalid 'limit' param: must be a positive integer",
+		},
+		"negative limit": {
+			params:      url.Values{LimitParam: []string{"-1"}},
+			expectedErr: "invalid 'limit' param: must be a positive integer",
+		},
+		"page token without limit": {
+			params:      url.Values{PageTokenParam: []string{PageToken("job")}},
+			expectedErr: "the 'page_token' param requires the 'limit' param",
+		},
+		"invalid page token": {
+			params:      url.Values{LimitParam: []string{"10"}, PageTokenParam: []string{"!!"}},
+			expectedErr: "invalid 'page_token' param",
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/labels?"+testData.params.Encode(), nil)
+			require.NoError(t, r.ParseForm())
+
+			actual, err := ParseRequest(r)
+			if testData.expectedErr != "" {
+				require.EqualError(t, err, testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestRequest_Apply(t *testing.T) {
+	values := []string{"a", "b", "c", "d"}
+
+	assert.Equal(t, values, Request{}.Apply(values))
+	assert.Equal(t, []string{"a", "b"}, Request{Limit: 2}.Apply(values))
+	assert.Equal(t, []string{"c", "d"}, Request{Limit: 2, After: "b"}.Apply(values))
+	assert.Equal(t, []string{"c"}, Request{Limit: 1, After: "bb"}.Apply(values))
+	assert.Empty(t, Request{Limit: 2, After: "d"}.Apply(values))
+}
+
+func TestContextWithRequest(t *testing.T) {
+	assert.Equal(t, Request{}, FromContext(context.Background()))
+
+	ctx := ContextWithRequest(context.Background(), Request{Limit: 5, After: "job"})
+	assert.Equal(t, Request{Limit: 5, After: "job"}, FromContext(ctx))
+}
@@ -0,0 +1,555 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/stats"
+
+	"github.com/grafana/mimir/pkg/querier/engine"
+	"github.com/grafana/mimir/pkg/querier/engine/streaming"
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// defaultCPUTimeCheckInterval is how often the CPU time consumed by the queries is checked against the limit.
+const defaultCPUTimeCheckInterval = 100 * time.Millisecond
+
+// pointSize is the estimated size of a promql.Point, in bytes.
+var pointSize = uint64(unsafe.Sizeof(promql.Point{}))
+
+// PerTenantEngine runs the queries with the PromQL engine configured for the tenant. The queries
+// not supported by the streaming engine are run with the Prometheus engine.
+type PerTenantEngine struct {
+	prometheus *promql.Engine
+	streaming  *streaming.Engine
+	limits     *validation.Overrides
+	journal    *QueryJournal
+	logger     log.Logger
+
+	// How often the CPU time consumed by the queries is checked against the limit.
+	cpuTimeCheckInterval time.Duration
+
+	// The max size of the series memoized for the identical selectors of a query, 0 if disabled.
+	maxMemoizedSelectorSeriesBytesPerQuery uint64
+	// The max size of the results memoized for the identical subexpressions of a query, 0 if disabled.
+	maxMemoizedSubexpressionBytesPerQuery uint64
+
+	queries                      *prometheus.CounterVec
+	fallbacks                    prometheus.Counter
+	queryDuration                *prometheus.HistogramVec
+	cpuTimeLimitedTotal          prometheus.Counter
+	selectorMemoizationHits      prometheus.Counter
+	subexpressionMemoizationHits prometheus.Counter
+}
+
+// NewPerTenantEngine makes a new PerTenantEngine running the queries either with the input Prometheus
+// engine or with a streaming engine configured like it. The running queries are recorded in the journal, if not nil.
+func NewPerTenantEngine(cfg engine.Config, prometheusEngine *promql.Engine, limits *validation.Overrides, tracker *activitytracker.ActivityTracker, journal *QueryJournal, logger log.Logger, reg prometheus.Registerer) *PerTenantEngine {
+	return &PerTenantEngine{
+		prometheus: prometheusEngine,
+		streaming:  streaming.NewEngine(engine.NewPromQLEngineOptions(cfg, tracker, logger, nil)),
+		limits:     limits,
+		journal:    journal,
+		logger:     logger,
+
+		cpuTimeCheckInterval:                   defaultCPUTimeCheckInterval,
+		maxMemoizedSelectorSeriesBytesPerQuery: uint64(cfg.MaxMemoizedSelectorSeriesBytesPerQuery),
+		maxMemoizedSubexpressionBytesPerQuery:  uint64(cfg.MaxMemoizedSubexpressionBytesPerQuery),
+
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_queries_total",
+			Help: "Total number of queries run by the querier, by PromQL engine.",
+		}, []string{"engine"}),
+		fallbacks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_streaming_engine_fallbacks_total",
+			Help: "Total number of queries of the tenants using the streaming engine run with the Prometheus engine, because not supported by the streaming engine.",
+		}),
+		queryDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_querier_query_duration_seconds",
+			Help:    "Time spent running the queries in the querier, by PromQL engine.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"engine"}),
+		cpuTimeLimitedTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_queries_cpu_time_limited_total",
+			Help: "Total number of queries failed because they exceeded the max CPU time per query.",
+		}),
+		selectorMemoizationHits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_selector_series_memoization_hits_total",
+			Help: "Total number of selectors whose series have been reused from an identical selector of the same query.",
+		}),
+		subexpressionMemoizationHits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_subexpression_memoization_hits_total",
+			Help: "Total number of subexpressions whose result has been reused from an identical subexpression of the same query.",
+		}),
+	}
+}
+
+// SetQueryLogger implements v1.QueryEngine. The query logger is only used by the Prometheus engine.
+func (e *PerTenantEngine) SetQueryLogger(l promql.QueryLogger) {
+	e.prometheus.SetQueryLogger(l)
+}
+
+// NewInstantQuery implements v1.QueryEngine.
+func (e *PerTenantEngine) NewInstantQuery(q storage.Queryable, opts *promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	q = e.memoizingQueryable(q)
+
+	// The query is always validated by the Prometheus engine, so that invalid queries fail the same way
+	// regardless of the engine they would have been run with.
+	prometheusQuery, err := e.prometheus.NewInstantQuery(q, opts, qs, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &perTenantQuery{
+		engine:          e,
+		queryable:       q,
+		opts:            opts,
+		qs:              qs,
+		stmt:            prometheusQuery.Statement(),
+		start:           ts,
+		end:             ts,
+		journalParams:   fmt.Sprintf("time=%d", ts.UnixMilli()),
+		prometheusQuery: prometheusQuery,
+		newPrometheusQuery: func(opts *promql.QueryOpts, qs string) (promql.Query, error) {
+			return e.prometheus.NewInstantQuery(q, opts, qs, ts)
+		},
+		newStreamingQuery: func(opts *promql.QueryOpts, qs string) (promql.Query, error) {
+			return e.streaming.NewInstantQuery(q, opts, qs, ts)
+		},
+	}, nil
+}
+
+// NewRangeQuery implements v1.QueryEngine.
+func (e *PerTenantEngine) NewRangeQuery(q storage.Queryable, opts *promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	q = e.memoizingQueryable(q)
+
+	prometheusQuery, err := e.prometheus.NewRangeQuery(q, opts, qs, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &perTenantQuery{
+		engine:          e,
+		queryable:       q,
+		opts:            opts,
+		qs:              qs,
+		stmt:            prometheusQuery.Statement(),
+		start:           start,
+		end:             end,
+		interval:        interval,
+		journalParams:   fmt.Sprintf("start=%d end=%d step=%d", start.UnixMilli(), end.UnixMilli(), interval.Milliseconds()),
+		prometheusQuery: prometheusQuery,
+		newPrometheusQuery: func(opts *promql.QueryOpts, qs string) (promql.Query, error) {
+			return e.prometheus.NewRangeQuery(q, opts, qs, start, end, interval)
+		},
+		newStreamingQuery: func(opts *promql.QueryOpts, qs string) (promql.Query, error) {
+			return e.streaming.NewRangeQuery(q, opts, qs, start, end, interval)
+		},
+	}, nil
+}
+
+// useStreamingEngine returns whether the queries of the tenants in the context should be run with the streaming engine.
+func (e *PerTenantEngine) useStreamingEngine(ctx context.Context) bool {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return false
+	}
+
+	for _, tenantID := range tenantIDs {
+		if e.limits.QueryEngine(tenantID) != validation.QueryEngineStreaming {
+			return false
+		}
+	}
+	return true
+}
+
+// checkExperimentalFunctions returns an error if the input statement uses experimental PromQL functions
+// not enabled for all the tenants in the context.
+func (e *PerTenantEngine) checkExperimentalFunctions(ctx context.Context, stmt parser.Statement) error {
+	functions := engine.FindExperimentalFunctions(stmt)
+	if len(functions) == 0 {
+		return nil
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		enabled := e.limits.EnabledPromQLExperimentalFunctions(tenantID)
+		for _, f := range functions {
+			if !engine.IsExperimentalFunctionEnabled(f, enabled) {
+				return fmt.Errorf("function %q is experimental and not enabled for the tenant %s", f, tenantID)
+			}
+		}
+	}
+	return nil
+}
+
+// checkAtModifier returns an error if the input statement uses the @ modifier and the modifier
+// is not enabled for all the tenants in the context.
+func (e *PerTenantEngine) checkAtModifier(ctx context.Context, stmt parser.Statement) error {
+	if !hasAtModifier(stmt) {
+		return nil
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tenantID := range tenantIDs {
+		if !e.limits.PromQLAtModifierEnabled(tenantID) {
+			return fmt.Errorf("the @ modifier is not enabled for the tenant %s", tenantID)
+		}
+	}
+	return nil
+}
+
+// hasAtModifier returns whether any selector or subquery of the input statement uses the @ modifier.
+func hasAtModifier(stmt parser.Statement) bool {
+	found := false
+	parser.Inspect(stmt, func(node parser.Node, _ []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.VectorSelector:
+			found = found || n.Timestamp != nil || n.StartOrEnd != 0
+		case *parser.SubqueryExpr:
+			found = found || n.Timestamp != nil || n.StartOrEnd != 0
+		}
+		return nil
+	})
+	return found
+}
+
+// queryOpts returns the options to run the query with: the lookback delta of the tenants in the context
+// overrides the engine's one, unless the query has its own lookback delta.
+func (e *PerTenantEngine) queryOpts(ctx context.Context, opts *promql.QueryOpts) *promql.QueryOpts {
+	if opts != nil && opts.LookbackDelta > 0 {
+		return opts
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return opts
+	}
+
+	lookbackDelta := validation.LargestPositiveNonZeroDurationPerTenant(tenantIDs, e.limits.LookbackDelta)
+	if lookbackDelta <= 0 {
+		return opts
+	}
+
+	tenantOpts := &promql.QueryOpts{LookbackDelta: lookbackDelta}
+	if opts != nil {
+		tenantOpts.EnablePerStepStats = opts.EnablePerStepStats
+	}
+	return tenantOpts
+}
+
+// addMemoryConsumptionTracker adds a tracker of the memory consumed by the query to the context, so that
+// the memory consumed by all the selectors of the query counts towards the same limit.
+func (e *PerTenantEngine) addMemoryConsumptionTracker(ctx context.Context) context.Context {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return ctx
+	}
+
+	maxBytes := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, e.limits.MaxEstimatedMemoryPerQuery)
+	return limiter.AddMemoryConsumptionTrackerToContext(ctx, limiter.NewMemoryConsumptionTracker(uint64(maxBytes), querier_stats.FromContext(ctx)))
+}
+
+// trackPrometheusEngineMemoryConsumption tracks the memory consumed by the peak number of samples loaded in
+// memory by the Prometheus engine to evaluate the input query, and fails the query if it exceeds the max
+// estimated memory consumption. Unlike the streaming engine, the Prometheus engine can't track the samples
+// while the query is being evaluated, so the samples are only checked against the limit once the query
+// has been evaluated.
+func trackPrometheusEngineMemoryConsumption(ctx context.Context, query promql.Query, res *promql.Result) *promql.Result {
+	if res.Err != nil {
+		return res
+	}
+
+	tracker, ok := limiter.MemoryConsumptionTrackerFromContext(ctx)
+	if !ok {
+		return res
+	}
+
+	queryStats := query.Stats()
+	if queryStats == nil || queryStats.Samples == nil {
+		return res
+	}
+
+	if err := tracker.IncreaseMemoryConsumption(uint64(queryStats.Samples.PeakSamples) * pointSize); err != nil {
+		return &promql.Result{Err: err}
+	}
+	return res
+}
+
+// memoizingQueryable wraps the input queryable to memoize the series selected by the identical selectors and
+// the results of the identical subexpressions of the queries, if enabled.
+func (e *PerTenantEngine) memoizingQueryable(q storage.Queryable) storage.Queryable {
+	if e.maxMemoizedSelectorSeriesBytesPerQuery == 0 && e.maxMemoizedSubexpressionBytesPerQuery == 0 {
+		return q
+	}
+	return newMemoizingQueryable(q)
+}
+
+// addSelectorMemoization adds the memoization of the identical selectors of the input statement to the context,
+// so that it's only used by a single query execution.
+func (e *PerTenantEngine) addSelectorMemoization(ctx context.Context, stmt parser.Statement) context.Context {
+	if e.maxMemoizedSelectorSeriesBytesPerQuery == 0 {
+		return ctx
+	}
+
+	m := newSelectorMemoization(stmt, e.maxMemoizedSelectorSeriesBytesPerQuery, e.selectorMemoizationHits)
+	if m == nil {
+		return ctx
+	}
+	return contextWithSelectorMemoization(ctx, m)
+}
+
+// addSubexpressionMemoization adds the memoization of the identical subexpressions of the input query to the
+// context, and returns the query to run with the subexpressions replaced by their placeholder selectors.
+// The subexpressions are evaluated with the Prometheus engine, on the input queryable and time range.
+func (e *PerTenantEngine) addSubexpressionMemoization(ctx context.Context, q *perTenantQuery, opts *promql.QueryOpts) (context.Context, string) {
+	if e.maxMemoizedSubexpressionBytesPerQuery == 0 {
+		return ctx, q.qs
+	}
+
+	m, qs := newSubexpressionMemoization(q.qs, e.maxMemoizedSubexpressionBytesPerQuery, e.subexpressionMemoizationHits)
+	if m == nil {
+		return ctx, q.qs
+	}
+
+	m.queryable = q.queryable
+	m.engine = e.prometheus
+	m.opts = opts
+	m.start, m.end, m.interval = q.start, q.end, q.interval
+	return contextWithSubexpressionMemoization(ctx, m), qs
+}
+
+// maxCPUTimePerQuery returns the max CPU time of the queries of the tenants in the context, or 0 if there's no limit.
+func (e *PerTenantEngine) maxCPUTimePerQuery(ctx context.Context) time.Duration {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return 0
+	}
+
+	return validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, e.limits.MaxCPUTimePerQuery)
+}
+
+// perTenantQuery is a promql.Query picking the engine to run with when it's executed, once the tenant is known.
+type perTenantQuery struct {
+	engine             *PerTenantEngine
+	queryable          storage.Queryable
+	opts               *promql.QueryOpts
+	qs                 string
+	stmt               parser.Statement
+	journalParams      string
+	prometheusQuery    promql.Query
+	newPrometheusQuery func(opts *promql.QueryOpts, qs string) (promql.Query, error)
+	newStreamingQuery  func(opts *promql.QueryOpts, qs string) (promql.Query, error)
+
+	// The time range of the query, with a 0 interval for instant queries.
+	start, end time.Time
+	interval   time.Duration
+
+	// The streaming query, if it has been created.
+	streamingQuery promql.Query
+	// The query run, once the query has been executed.
+	executedQuery promql.Query
+}
+
+// Exec implements promql.Query.
+func (q *perTenantQuery) Exec(ctx context.Context) *promql.Result {
+	// The time spent evaluating the query is tracked excluding the time spent fetching and merging
+	// the series, which are tracked on their own while the query is running.
+	queryStats := querier_stats.FromContext(ctx)
+	fetchTimeBefore := seriesFetchTime(queryStats)
+	start := time.Now()
+	defer func() {
+		evalTime := time.Since(start) - (seriesFetchTime(queryStats) - fetchTimeBefore)
+		queryStats.AddEvalTime(util_math.Max(evalTime, 0))
+	}()
+
+	journalIndex := q.engine.journal.Insert(ctx, q.qs, q.journalParams)
+	defer q.engine.journal.Delete(journalIndex)
+
+	if maxCPUTime := q.engine.maxCPUTimePerQuery(ctx); maxCPUTime > 0 {
+		return q.execWithCPUTimeLimit(ctx, maxCPUTime)
+	}
+	return q.exec(ctx)
+}
+
+// seriesFetchTime returns the time spent fetching and merging the series of the queries tracked by the stats.
+func seriesFetchTime(s *querier_stats.Stats) time.Duration {
+	return s.LoadIngesterFetchTime() + s.LoadStoreGatewayFetchTime() + s.LoadMergeTime()
+}
+
+// execWithCPUTimeLimit runs the query tracking the CPU time consumed by its evaluation, and cancels it
+// once it exceeds the input limit. The CPU time is sampled periodically, because the evaluation can't be
+// interrupted from within the PromQL engines other than through the context.
+func (q *perTenantQuery) execWithCPUTimeLimit(ctx context.Context, maxCPUTime time.Duration) *promql.Result {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tracker := limiter.NewCPUTimeTracker(maxCPUTime, querier_stats.FromContext(ctx))
+	tracker.Start()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(q.engine.cpuTimeCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if tracker.CheckCPUTime() != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	res := q.exec(ctx)
+	close(done)
+	tracker.Stop()
+
+	// The query fails even if it completed before being canceled, so that the limit is enforced
+	// regardless of when the CPU time has been sampled.
+	if err := tracker.CheckCPUTime(); err != nil {
+		q.engine.cpuTimeLimitedTotal.Inc()
+		return &promql.Result{Err: err}
+	}
+	return res
+}
+
+func (q *perTenantQuery) exec(ctx context.Context) *promql.Result {
+	if err := q.engine.checkExperimentalFunctions(ctx, q.stmt); err != nil {
+		return &promql.Result{Err: err}
+	}
+	if err := q.engine.checkAtModifier(ctx, q.stmt); err != nil {
+		return &promql.Result{Err: err}
+	}
+
+	ctx = q.engine.addMemoryConsumptionTracker(ctx)
+	ctx = q.engine.addSelectorMemoization(ctx, q.stmt)
+
+	ctx, histogramsReduced := contextWithHistogramResolutionReduced(ctx)
+
+	res := q.execWithEngine(ctx)
+
+	// The warning of the reduction of the histograms resolution is added once the query has been evaluated,
+	// because the PromQL engines collect the warnings of the series sets before iterating their samples.
+	if res.Err == nil && histogramsReduced.Load() {
+		res.Warnings = addHistogramResolutionWarning(res.Warnings)
+	}
+	return res
+}
+
+// execWithEngine runs the query with the engine selected for the tenants in the context.
+func (q *perTenantQuery) execWithEngine(ctx context.Context) *promql.Result {
+	// The Prometheus query has been created before the tenant was known, so it's re-created
+	// if the tenant has its own query options, or if the query has identical subexpressions to memoize.
+	opts := q.engine.queryOpts(ctx, q.opts)
+	ctx, qs := q.engine.addSubexpressionMemoization(ctx, q, opts)
+	if opts != q.opts || qs != q.qs {
+		prometheusQuery, err := q.newPrometheusQuery(opts, qs)
+		if err != nil {
+			return &promql.Result{Err: err}
+		}
+
+		q.prometheusQuery.Close()
+		q.prometheusQuery = prometheusQuery
+	}
+
+	if q.engine.useStreamingEngine(ctx) {
+		start := time.Now()
+		res := q.execStreaming(ctx, opts, qs)
+
+		if !streaming.IsNotSupported(res.Err) {
+			q.executedQuery = q.streamingQuery
+			q.observe(validation.QueryEngineStreaming, start)
+			return res
+		}
+
+		q.engine.fallbacks.Inc()
+		spanLog := spanlogger.FromContext(ctx, q.engine.logger)
+		level.Debug(spanLog).Log("msg", "running the query with the Prometheus engine, because not supported by the streaming engine", "query", q.qs, "reason", res.Err)
+	}
+
+	start := time.Now()
+	res := trackPrometheusEngineMemoryConsumption(ctx, q.prometheusQuery, q.prometheusQuery.Exec(ctx))
+	q.executedQuery = q.prometheusQuery
+	q.observe(validation.QueryEnginePrometheus, start)
+	return res
+}
+
+func (q *perTenantQuery) execStreaming(ctx context.Context, opts *promql.QueryOpts, qs string) *promql.Result {
+	streamingQuery, err := q.newStreamingQuery(opts, qs)
+	if err != nil {
+		return &promql.Result{Err: err}
+	}
+
+	q.streamingQuery = streamingQuery
+	return streamingQuery.Exec(ctx)
+}
+
+func (q *perTenantQuery) observe(engine string, start time.Time) {
+	q.engine.queries.WithLabelValues(engine).Inc()
+	q.engine.queryDuration.WithLabelValues(engine).Observe(time.Since(start).Seconds())
+}
+
+// Close implements promql.Query.
+func (q *perTenantQuery) Close() {
+	q.prometheusQuery.Close()
+	if q.streamingQuery != nil {
+		q.streamingQuery.Close()
+	}
+}
+
+// Statement implements promql.Query. It returns the statement of the query as submitted, even if the query
+// has been run with its identical subexpressions replaced by placeholders.
+func (q *perTenantQuery) Statement() parser.Statement {
+	return q.stmt
+}
+
+// Stats implements promql.Query. It returns the statistics of the query executed by the selected engine.
+func (q *perTenantQuery) Stats() *stats.Statistics {
+	if q.executedQuery != nil {
+		return q.executedQuery.Stats()
+	}
+	return q.prometheusQuery.Stats()
+}
+
+// Cancel implements promql.Query.
+func (q *perTenantQuery) Cancel() {
+	q.prometheusQuery.Cancel()
+	if q.streamingQuery != nil {
+		q.streamingQuery.Cancel()
+	}
+}
+
+// String implements promql.Query.
+func (q *perTenantQuery) String() string {
+	return q.qs
+}
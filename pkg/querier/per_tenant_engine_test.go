@@ -0,0 +1,339 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/querier/engine"
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/activitytracker"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestPerTenantEngine(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			some_metric{idx="1", group="a"} 0+1x10
+			some_metric{idx="2", group="a"} 0+2x10
+			some_metric{idx="3", group="b"} 0+3x10
+	`)
+	require.NoError(t, err)
+	t.Cleanup(test.Close)
+	require.NoError(t, test.Run())
+
+	engineCfg := engine.Config{}
+	flagext.DefaultValues(&engineCfg)
+
+	limits := defaultLimitsConfig()
+	tenantLimits := map[string]*validation.Limits{
+		"streaming": func() *validation.Limits {
+			l := defaultLimitsConfig()
+			l.QueryEngine = validation.QueryEngineStreaming
+			return &l
+		}(),
+	}
+	overrides, err := validation.NewOverrides(limits, validation.NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	start, end, step := time.Unix(0, 0), time.Unix(0, 0).Add(10*time.Minute), time.Minute
+
+	tests := map[string]struct {
+		tenantID         string
+		query            string
+		expectedEngine   string
+		expectedFallback bool
+	}{
+		"tenant using the Prometheus engine": {
+			tenantID:       "prometheus",
+			query:          `sum by (group) (rate(some_metric[5m]))`,
+			expectedEngine: validation.QueryEnginePrometheus,
+		},
+		"tenant using the streaming engine, running a supported query": {
+			tenantID:       "streaming",
+			query:          `sum by (group) (rate(some_metric[5m]))`,
+			expectedEngine: validation.QueryEngineStreaming,
+		},
+		"tenant using the streaming engine, running an unsupported query": {
+			tenantID:         "streaming",
+			query:            `avg by (group) (rate(some_metric[5m]))`,
+			expectedEngine:   validation.QueryEnginePrometheus,
+			expectedFallback: true,
+		},
+		"multiple tenants, not all using the streaming engine": {
+			tenantID:       "streaming|prometheus",
+			query:          `sum by (group) (rate(some_metric[5m]))`,
+			expectedEngine: validation.QueryEnginePrometheus,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+			e := NewPerTenantEngine(engineCfg, prometheusEngine, overrides, nil, nil, log.NewNopLogger(), reg)
+
+			ctx := user.InjectOrgID(context.Background(), testData.tenantID)
+
+			expected, err := prometheusEngine.NewRangeQuery(test.Queryable(), nil, testData.query, start, end, step)
+			require.NoError(t, err)
+			expectedRes := expected.Exec(ctx)
+			require.NoError(t, expectedRes.Err)
+
+			q, err := e.NewRangeQuery(test.Queryable(), nil, testData.query, start, end, step)
+			require.NoError(t, err)
+			res := q.Exec(ctx)
+			require.NoError(t, res.Err)
+			require.Equal(t, expectedRes.Value, res.Value)
+			require.NotNil(t, q.Stats())
+			q.Close()
+
+			fallbacks := 0
+			if testData.expectedFallback {
+				fallbacks = 1
+			}
+
+			assert.Equal(t, float64(fallbacks), testutil.ToFloat64(e.fallbacks))
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+				# HELP cortex_querier_queries_total Total number of queries run by the querier, by PromQL engine.
+				# TYPE cortex_querier_queries_total counter
+				cortex_querier_queries_total{engine="`+testData.expectedEngine+`"} 1
+			`), "cortex_querier_queries_total"))
+		})
+	}
+
+	t.Run("should only run the queries using experimental functions enabled for the tenant", func(t *testing.T) {
+		enabledLimits := defaultLimitsConfig()
+		enabledLimits.EnabledPromQLExperimentalFunctions = []string{"mad_over_time"}
+		experimentalOverrides, err := validation.NewOverrides(defaultLimitsConfig(), validation.NewMockTenantLimits(map[string]*validation.Limits{
+			"enabled": &enabledLimits,
+		}))
+		require.NoError(t, err)
+
+		prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+		e := NewPerTenantEngine(engineCfg, prometheusEngine, experimentalOverrides, nil, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+
+		for _, testData := range []struct {
+			tenantID    string
+			query       string
+			expectedErr string
+		}{
+			{tenantID: "enabled", query: `mad_over_time(some_metric[5m])`},
+			{tenantID: "disabled", query: `mad_over_time(some_metric[5m])`, expectedErr: `function "mad_over_time" is experimental and not enabled for the tenant disabled`},
+			{tenantID: "enabled", query: `sort_by_label(some_metric, "idx")`, expectedErr: `function "sort_by_label" is experimental and not enabled for the tenant enabled`},
+			{tenantID: "disabled", query: `rate(some_metric[5m])`},
+		} {
+			q, err := e.NewRangeQuery(test.Queryable(), nil, testData.query, start, end, step)
+			require.NoError(t, err)
+
+			res := q.Exec(user.InjectOrgID(context.Background(), testData.tenantID))
+			if testData.expectedErr != "" {
+				require.EqualError(t, res.Err, testData.expectedErr)
+			} else {
+				require.NoError(t, res.Err)
+			}
+			q.Close()
+		}
+	})
+
+	t.Run("should only run the queries using the @ modifier if enabled for the tenant", func(t *testing.T) {
+		disabledLimits := defaultLimitsConfig()
+		disabledLimits.PromQLAtModifierEnabled = false
+		atModifierOverrides, err := validation.NewOverrides(defaultLimitsConfig(), validation.NewMockTenantLimits(map[string]*validation.Limits{
+			"disabled": &disabledLimits,
+		}))
+		require.NoError(t, err)
+
+		prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+		e := NewPerTenantEngine(engineCfg, prometheusEngine, atModifierOverrides, nil, nil, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+
+		for _, testData := range []struct {
+			tenantID    string
+			query       string
+			expectedErr string
+		}{
+			{tenantID: "enabled", query: `rate(some_metric[5m] @ start())`},
+			{tenantID: "disabled", query: `rate(some_metric[5m] @ start())`, expectedErr: `the @ modifier is not enabled for the tenant disabled`},
+			{tenantID: "disabled", query: `max_over_time(rate(some_metric[5m])[10m:1m] @ 100)`, expectedErr: `the @ modifier is not enabled for the tenant disabled`},
+			{tenantID: "disabled", query: `rate(some_metric[5m])`},
+		} {
+			q, err := e.NewRangeQuery(test.Queryable(), nil, testData.query, start, end, step)
+			require.NoError(t, err)
+
+			res := q.Exec(user.InjectOrgID(context.Background(), testData.tenantID))
+			if testData.expectedErr != "" {
+				require.EqualError(t, res.Err, testData.expectedErr)
+			} else {
+				require.NoError(t, res.Err)
+			}
+			q.Close()
+		}
+	})
+
+	for _, queryEngine := range []string{validation.QueryEngineStreaming, validation.QueryEnginePrometheus} {
+		t.Run(fmt.Sprintf("should fail when the query exceeds the max estimated memory consumption with the %s engine", queryEngine), func(t *testing.T) {
+			limitedLimits := defaultLimitsConfig()
+			limitedLimits.QueryEngine = queryEngine
+			limitedLimits.MaxEstimatedMemoryPerQuery = 100
+			limitedOverrides, err := validation.NewOverrides(limits, validation.NewMockTenantLimits(map[string]*validation.Limits{"limited": &limitedLimits}))
+			require.NoError(t, err)
+
+			prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+			e := NewPerTenantEngine(engineCfg, prometheusEngine, limitedOverrides, nil, nil, log.NewNopLogger(), nil)
+
+			q, err := e.NewRangeQuery(test.Queryable(), nil, `some_metric`, start, end, step)
+			require.NoError(t, err)
+
+			res := q.Exec(user.InjectOrgID(context.Background(), "limited"))
+			require.Error(t, res.Err)
+			require.Equal(t, validation.LimitError(fmt.Sprintf(limiter.MaxEstimatedMemoryPerQueryHitMsgFormat, 100)), res.Err)
+		})
+	}
+
+	t.Run("should fail when the query exceeds the max CPU time", func(t *testing.T) {
+		if !limiter.CPUTimeTrackingSupported {
+			t.Skip("the CPU time isn't tracked on this platform")
+		}
+
+		limitedLimits := defaultLimitsConfig()
+		limitedLimits.MaxCPUTimePerQuery = model.Duration(time.Nanosecond)
+		limitedOverrides, err := validation.NewOverrides(limits, validation.NewMockTenantLimits(map[string]*validation.Limits{"limited": &limitedLimits}))
+		require.NoError(t, err)
+
+		prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+		e := NewPerTenantEngine(engineCfg, prometheusEngine, limitedOverrides, nil, nil, log.NewNopLogger(), nil)
+
+		q, err := e.NewRangeQuery(test.Queryable(), nil, `sum by (group) (rate(some_metric[5m]))`, start, end, step)
+		require.NoError(t, err)
+
+		res := q.Exec(user.InjectOrgID(context.Background(), "limited"))
+		require.Equal(t, validation.LimitError(fmt.Sprintf(limiter.MaxCPUTimePerQueryHitMsgFormat, time.Nanosecond)), res.Err)
+		q.Close()
+		assert.Equal(t, float64(1), testutil.ToFloat64(e.cpuTimeLimitedTotal))
+
+		// The queries of the tenants without a limit aren't tracked.
+		q, err = e.NewRangeQuery(test.Queryable(), nil, `sum by (group) (rate(some_metric[5m]))`, start, end, step)
+		require.NoError(t, err)
+		require.NoError(t, q.Exec(user.InjectOrgID(context.Background(), "unlimited")).Err)
+		q.Close()
+		assert.Equal(t, float64(1), testutil.ToFloat64(e.cpuTimeLimitedTotal))
+	})
+
+	t.Run("should track the time spent evaluating the query", func(t *testing.T) {
+		prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+		e := NewPerTenantEngine(engineCfg, prometheusEngine, overrides, nil, nil, log.NewNopLogger(), nil)
+
+		q, err := e.NewRangeQuery(test.Queryable(), nil, `sum by (group) (rate(some_metric[5m]))`, start, end, step)
+		require.NoError(t, err)
+
+		queryStats, ctx := querier_stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-1"))
+		require.NoError(t, q.Exec(ctx).Err)
+		q.Close()
+		assert.Greater(t, queryStats.LoadEvalTime(), time.Duration(0))
+	})
+
+	t.Run("should run the queries with the lookback delta of the tenant", func(t *testing.T) {
+		lookbackLimits := defaultLimitsConfig()
+		lookbackLimits.LookbackDelta = model.Duration(10 * time.Minute)
+		streamingLookbackLimits := lookbackLimits
+		streamingLookbackLimits.QueryEngine = validation.QueryEngineStreaming
+		lookbackOverrides, err := validation.NewOverrides(limits, validation.NewMockTenantLimits(map[string]*validation.Limits{
+			"prometheus-lookback": &lookbackLimits,
+			"streaming-lookback":  &streamingLookbackLimits,
+		}))
+		require.NoError(t, err)
+
+		prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+		e := NewPerTenantEngine(engineCfg, prometheusEngine, lookbackOverrides, nil, nil, log.NewNopLogger(), nil)
+
+		// The last samples are at 10m, so they're only within the lookback delta of the tenants overriding it.
+		ts := time.Unix(0, 0).Add(16 * time.Minute)
+
+		for _, testData := range []struct {
+			tenantID        string
+			opts            *promql.QueryOpts
+			expectedSamples int
+		}{
+			{tenantID: "prometheus", expectedSamples: 0},
+			{tenantID: "streaming", expectedSamples: 0},
+			{tenantID: "prometheus-lookback", expectedSamples: 3},
+			{tenantID: "streaming-lookback", expectedSamples: 3},
+			{tenantID: "prometheus-lookback", opts: &promql.QueryOpts{LookbackDelta: time.Minute}, expectedSamples: 0},
+		} {
+			t.Run(testData.tenantID, func(t *testing.T) {
+				q, err := e.NewInstantQuery(test.Queryable(), testData.opts, `some_metric`, ts)
+				require.NoError(t, err)
+				defer q.Close()
+
+				res := q.Exec(user.InjectOrgID(context.Background(), testData.tenantID))
+				require.NoError(t, res.Err)
+
+				vector, err := res.Vector()
+				require.NoError(t, err)
+				assert.Len(t, vector, testData.expectedSamples)
+			})
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+		e := NewPerTenantEngine(engineCfg, prometheusEngine, overrides, nil, nil, log.NewNopLogger(), nil)
+
+		_, err := e.NewInstantQuery(test.Queryable(), nil, `sum(`, start)
+		require.Error(t, err)
+	})
+}
+
+func TestPerTenantEngine_QueryJournal(t *testing.T) {
+	engineCfg := engine.Config{}
+	flagext.DefaultValues(&engineCfg)
+
+	overrides, err := validation.NewOverrides(defaultLimitsConfig(), nil)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "query-journal.log")
+	journal, err := NewQueryJournal(file, 10, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, journal.Close()) })
+
+	prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+	e := NewPerTenantEngine(engineCfg, prometheusEngine, overrides, nil, journal, log.NewNopLogger(), nil)
+
+	// The query is in the journal while it's running.
+	var running []activitytracker.Entry
+	queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		running, err = activitytracker.LoadUnfinishedEntries(file)
+		return storage.NoopQuerier(), err
+	})
+
+	q, err := e.NewRangeQuery(queryable, nil, `sum(some_metric)`, time.UnixMilli(0), time.UnixMilli(60000), time.Minute)
+	require.NoError(t, err)
+	res := q.Exec(user.InjectOrgID(context.Background(), "user-1"))
+	require.NoError(t, res.Err)
+	q.Close()
+
+	require.Len(t, running, 1)
+	assert.Regexp(t, `^fingerprint=[0-9a-f]{16} tenant=user-1 start=0 end=60000 step=60000 query=sum\(some_metric\)$`, running[0].Activity)
+
+	// The query is removed from the journal once completed.
+	entries, err := activitytracker.LoadUnfinishedEntries(file)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -16,17 +16,21 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/grafana/dskit/multierror"
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/mimir/pkg/querier/batch"
 	"github.com/grafana/mimir/pkg/querier/engine"
 	"github.com/grafana/mimir/pkg/querier/iterators"
+	"github.com/grafana/mimir/pkg/querier/pagination"
+	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/storage/chunk"
 	"github.com/grafana/mimir/pkg/storage/lazyquery"
 	"github.com/grafana/mimir/pkg/util"
@@ -46,10 +50,28 @@ type Config struct {
 	QueryStoreAfter    time.Duration `yaml:"query_store_after" category:"advanced"`
 	MaxQueryIntoFuture time.Duration `yaml:"max_query_into_future" category:"advanced"`
 
-	StoreGatewayClient ClientConfig `yaml:"store_gateway_client"`
+	StoreGatewayClient        ClientConfig `yaml:"store_gateway_client"`
+	StoreGatewayPreferredZone string       `yaml:"store_gateway_preferred_zone" category:"experimental"`
 
 	ShuffleShardingIngestersEnabled bool `yaml:"shuffle_sharding_ingesters_enabled" category:"advanced"`
 
+	EmbeddedStoreEnabled bool   `yaml:"embedded_store_enabled" category:"experimental"`
+	EmbeddedStoreSyncDir string `yaml:"embedded_store_sync_dir" category:"experimental"`
+
+	PreferStreamingChunksFromStoreGateways        bool   `yaml:"prefer_streaming_chunks_from_store_gateways" category:"experimental"`
+	StreamingChunksPerStoreGatewaySeriesBatchSize uint64 `yaml:"streaming_chunks_per_store_gateway_series_batch_size" category:"experimental"`
+
+	IngesterDownsamplingEnabled           bool `yaml:"ingester_downsampling_enabled" category:"experimental"`
+	IngesterDownsamplingMinRangeSteps     int  `yaml:"ingester_downsampling_min_range_steps" category:"experimental"`
+	IngesterDownsamplingAvgEnabled        bool `yaml:"ingester_downsampling_avg_enabled" category:"experimental"`
+	IngesterDownsamplingCountersEnabled   bool `yaml:"ingester_downsampling_counters_enabled" category:"experimental"`
+	IngesterDownsamplingHistogramsEnabled bool `yaml:"ingester_downsampling_histograms_enabled" category:"experimental"`
+
+	QueryJournalFilepath   string `yaml:"query_journal_filepath" category:"experimental"`
+	QueryJournalMaxEntries int    `yaml:"query_journal_max_entries" category:"experimental"`
+
+	SelectorSplitMaxFetches int `yaml:"selector_split_max_fetches" category:"experimental"`
+
 	// PromQL engine config.
 	EngineConfig engine.Config `yaml:",inline"`
 }
@@ -62,17 +84,34 @@ const (
 var (
 	errBadLookbackConfigs = fmt.Errorf("the -%s setting must be greater than -%s otherwise queries might return partial results", queryIngestersWithinFlag, queryStoreAfterFlag)
 	errEmptyTimeRange     = errors.New("empty time range")
+	errBadStreamingChunks = errors.New("the -querier.streaming-chunks-per-store-gateway-series-batch-size setting must be greater than 0 when -querier.prefer-streaming-chunks-from-store-gateways is enabled")
+	errBadDownsampling    = errors.New("the -querier.ingester-downsampling-min-range-steps setting must be greater than 1 when -querier.ingester-downsampling-enabled is enabled")
+	errBadQueryJournal    = errors.New("the -querier.query-journal-max-entries setting must be greater than 0 when -querier.query-journal-filepath is set")
 )
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.StoreGatewayClient.RegisterFlagsWithPrefix("querier.store-gateway-client", f)
+	f.StringVar(&cfg.StoreGatewayPreferredZone, "querier.store-gateway-preferred-zone", "", "Availability zone of the store-gateways preferred to query the blocks replicated across zones, usually the zone of the querier. The store-gateways of the other zones are queried when the preferred ones are much slower or failing, according to the recent latency and errors of each store-gateway. If empty, the store-gateway querying each block is selected randomly among its replicas.")
 	f.BoolVar(&cfg.Iterators, "querier.iterators", false, "Use iterators to execute query, as opposed to fully materialising the series in memory.")
 	f.BoolVar(&cfg.BatchIterators, "querier.batch-iterators", true, "Use batch iterators to execute query, as opposed to fully materialising the series in memory.  Takes precedent over the -querier.iterators flag.")
 	f.DurationVar(&cfg.QueryIngestersWithin, queryIngestersWithinFlag, 13*time.Hour, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
 	f.DurationVar(&cfg.MaxQueryIntoFuture, "querier.max-query-into-future", 10*time.Minute, "Maximum duration into the future you can query. 0 to disable.")
 	f.DurationVar(&cfg.QueryStoreAfter, queryStoreAfterFlag, 12*time.Hour, "The time after which a metric should be queried from storage and not just ingesters. 0 means all queries are sent to store. If this option is enabled, the time range of the query sent to the store-gateway will be manipulated to ensure the query end is not more recent than 'now - query-store-after'.")
 	f.BoolVar(&cfg.ShuffleShardingIngestersEnabled, "querier.shuffle-sharding-ingesters-enabled", true, fmt.Sprintf("Fetch in-memory series from the minimum set of required ingesters, selecting only ingesters which may have received series since -%s. If this setting is false or -%s is '0', queriers always query all ingesters (ingesters shuffle sharding on read path is disabled).", queryIngestersWithinFlag, queryIngestersWithinFlag))
+	f.BoolVar(&cfg.EmbeddedStoreEnabled, "querier.embedded-store-enabled", false, fmt.Sprintf("Enable the embedded store, used to query the blocks of the tenants with at most -%s blocks directly from the long-term storage, bypassing the store-gateways.", validation.QuerierEmbeddedStoreMaxBlocksFlag))
+	f.StringVar(&cfg.EmbeddedStoreSyncDir, "querier.embedded-store-sync-dir", "./tsdb-sync-querier/", "Directory to store the index-headers of the blocks loaded by the embedded store. This directory must not be shared with the store-gateway.")
+	f.BoolVar(&cfg.PreferStreamingChunksFromStoreGateways, "querier.prefer-streaming-chunks-from-store-gateways", false, "Request the store-gateways to stream the chunks of the series in batches after the series labels, so that the querier reads and decodes the chunks incrementally while the query is evaluated instead of buffering all of them in memory. The store-gateways not supporting it send the series with their chunks.")
+	f.Uint64Var(&cfg.StreamingChunksPerStoreGatewaySeriesBatchSize, "querier.streaming-chunks-per-store-gateway-series-batch-size", 256, "Number of series per batch of chunks streamed by each store-gateway, when -querier.prefer-streaming-chunks-from-store-gateways is enabled.")
+	f.BoolVar(&cfg.IngesterDownsamplingEnabled, "querier.ingester-downsampling-enabled", false, "Request the ingesters to downsample the samples of the range selectors much larger than the query step, reducing the samples between each pair of consecutive multiples of the step to a single one. Only the min_over_time() and max_over_time() functions, whose results are not affected, are downsampled unless enabled by the other -querier.ingester-downsampling-* flags. The selectors whose time range and range are not multiples of the query step are not downsampled.")
+	f.IntVar(&cfg.IngesterDownsamplingMinRangeSteps, "querier.ingester-downsampling-min-range-steps", 10, "Minimum number of query steps covered by the range of a range selector for its samples to be downsampled by the ingesters.")
+	f.BoolVar(&cfg.IngesterDownsamplingAvgEnabled, "querier.ingester-downsampling-avg-enabled", false, "Downsample the samples of avg_over_time() to their average in each query step. The result is approximate, because each step has the same weight regardless of its number of samples.")
+	f.BoolVar(&cfg.IngesterDownsamplingCountersEnabled, "querier.ingester-downsampling-counters-enabled", false, "Downsample the samples of rate() and increase() to the last sample in each query step. The result is approximate, because the extrapolation at the boundaries of the range uses the downsampled samples.")
+	f.BoolVar(&cfg.IngesterDownsamplingHistogramsEnabled, "querier.ingester-downsampling-histograms-enabled", false, "Downsample the native histograms of avg_over_time() and rate() and increase() to the last histogram in each query step, when their downsampling is enabled. The result is approximate.")
+
+	f.StringVar(&cfg.QueryJournalFilepath, "querier.query-journal-filepath", "", "File where the queries running in the querier are recorded until completed, so that the queries running when the querier crashed are logged once it restarts. If empty, the query journal is disabled.")
+	f.IntVar(&cfg.QueryJournalMaxEntries, "querier.query-journal-max-entries", 1024, "Maximum number of concurrent queries recorded in the query journal. Used to size the file in advance. Additional queries are not recorded.")
+	f.IntVar(&cfg.SelectorSplitMaxFetches, "querier.selector-split-max-fetches", 0, "Split the selectors matching a union of metric names or label values, like {__name__=~\"a|b|c\"}, into a fetch for each name or value, run in parallel and merged in the querier, if the union has at most this number of values. The selectors joined by the or operator are always fetched in parallel. 0 to disable.")
 
 	cfg.EngineConfig.RegisterFlags(f)
 }
@@ -86,6 +125,18 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	if cfg.PreferStreamingChunksFromStoreGateways && cfg.StreamingChunksPerStoreGatewaySeriesBatchSize == 0 {
+		return errBadStreamingChunks
+	}
+
+	if cfg.IngesterDownsamplingEnabled && cfg.IngesterDownsamplingMinRangeSteps <= 1 {
+		return errBadDownsampling
+	}
+
+	if cfg.QueryJournalFilepath != "" && cfg.QueryJournalMaxEntries <= 0 {
+		return errBadQueryJournal
+	}
+
 	return nil
 }
 
@@ -102,16 +153,17 @@ func getChunksIteratorFunction(cfg Config) chunkIteratorFunc {
 func New(cfg Config, limits *validation.Overrides, distributor Distributor, stores []QueryableWithFilter, reg prometheus.Registerer, logger log.Logger, tracker *activitytracker.ActivityTracker) (storage.SampleAndChunkQueryable, storage.ExemplarQueryable, *promql.Engine) {
 	iteratorFunc := getChunksIteratorFunction(cfg)
 
-	distributorQueryable := newDistributorQueryable(distributor, iteratorFunc, cfg.QueryIngestersWithin, logger)
+	router := newTimeRangeRouter(cfg.QueryIngestersWithin, cfg.QueryStoreAfter, limits, newTimeRangeRoutingCosts(reg))
+	distributorQueryable := newDistributorQueryable(distributor, iteratorFunc, router, newIngesterDownsamplingConfig(cfg), logger)
 
 	ns := make([]QueryableWithFilter, len(stores))
 	for ix, s := range stores {
 		ns[ix] = storeQueryable{
 			QueryableWithFilter: s,
-			QueryStoreAfter:     cfg.QueryStoreAfter,
+			router:              router,
 		}
 	}
-	queryable := NewQueryable(distributorQueryable, ns, iteratorFunc, cfg, limits, logger)
+	queryable := NewQueryable(distributorQueryable, ns, iteratorFunc, cfg, limits, logger, reg)
 	exemplarQueryable := newDistributorExemplarQueryable(distributor, logger)
 
 	lazyQueryable := storage.QueryableFunc(func(ctx context.Context, mint int64, maxt int64) (storage.Querier, error) {
@@ -155,13 +207,26 @@ func (q *chunkQuerier) Select(sortSeries bool, hints *storage.SelectHints, match
 type QueryableWithFilter interface {
 	storage.Queryable
 
-	// UseQueryable returns true if this queryable should be used to satisfy the query for given time range.
+	// UseQueryable returns true if this queryable should be used to satisfy the query of the tenant for given time range.
 	// Query min and max time are in milliseconds since epoch.
-	UseQueryable(now time.Time, queryMinT, queryMaxT int64) bool
+	UseQueryable(now time.Time, userID string, queryMinT, queryMaxT int64) bool
 }
 
 // NewQueryable creates a new Queryable for mimir.
-func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter, chunkIterFn chunkIteratorFunc, cfg Config, limits *validation.Overrides, logger log.Logger) storage.Queryable {
+func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter, chunkIterFn chunkIteratorFunc, cfg Config, limits *validation.Overrides, logger log.Logger, reg prometheus.Registerer) storage.Queryable {
+	skippedComponents := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_querier_time_range_routing_skipped_components_total",
+		Help: "Total number of queries for which ingesters or store-gateways have been skipped because of the queried time range.",
+	}, []string{"component"})
+	skippedIngesters := skippedComponents.WithLabelValues(timeRangeRoutingIngesters)
+	skippedStoreGateways := skippedComponents.WithLabelValues(timeRangeRoutingStoreGateways)
+
+	splitFetchesPerQuery := promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+		Name:    "cortex_querier_selector_split_fetches_per_query",
+		Help:    "Number of parallel fetches the selectors of a query have been split into, for the queries with at least one split selector.",
+		Buckets: prometheus.ExponentialBuckets(2, 2, 8),
+	})
+
 	return storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
 		now := time.Now()
 
@@ -170,7 +235,16 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			return nil, err
 		}
 
-		ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(limits.MaxFetchedSeriesPerQuery(userID), limits.MaxFetchedChunkBytesPerQuery(userID), limits.MaxChunksPerQuery(userID)))
+		// The time range routing between ingesters and store-gateways is decided on the time the query started at.
+		ctx = contextWithQueryStartTime(ctx, now)
+		ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(limits.MaxFetchedSeriesPerQuery(userID), limits.MaxFetchedChunkBytesPerQuery(userID), limits.MaxChunksPerQuery(userID), limits.MaxEstimatedChunksPerQuery(userID), limits.MaxEstimatedChunkBytesPerQuery(userID), stats.FromContext(ctx)))
+		ctx = limiter.AddSelectorSeriesLimiterToContext(ctx, limiter.NewSelectorSeriesLimiter(limits.MaxFetchedSeriesPerSelector(userID), stats.FromContext(ctx)))
+
+		// The memory consumption tracker may have already been added by the PromQL engine, to track the memory
+		// consumed by the whole query, across all the queriers.
+		if _, ok := limiter.MemoryConsumptionTrackerFromContext(ctx); !ok {
+			ctx = limiter.AddMemoryConsumptionTrackerToContext(ctx, limiter.NewMemoryConsumptionTracker(uint64(limits.MaxEstimatedMemoryPerQuery(userID)), stats.FromContext(ctx)))
+		}
 
 		mint, maxt, err = validateQueryTimeRange(ctx, userID, mint, maxt, limits, cfg.MaxQueryIntoFuture, logger)
 		if errors.Is(err, errEmptyTimeRange) {
@@ -189,16 +263,20 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			logger:             logger,
 		}
 
-		if distributor.UseQueryable(now, mint, maxt) {
+		if distributor.UseQueryable(now, userID, mint, maxt) {
 			dqr, err := distributor.Querier(ctx, mint, maxt)
 			if err != nil {
 				return nil, err
 			}
 			q.queriers = append(q.queriers, dqr)
+		} else {
+			skippedIngesters.Inc()
 		}
 
+		skippedStores := false
 		for _, s := range stores {
-			if !s.UseQueryable(now, mint, maxt) {
+			if !s.UseQueryable(now, userID, mint, maxt) {
+				skippedStores = true
 				continue
 			}
 
@@ -209,8 +287,21 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 
 			q.queriers = append(q.queriers, cqr)
 		}
+		if skippedStores {
+			skippedStoreGateways.Inc()
+		}
 
-		return q, nil
+		var result storage.Querier = q
+		if maxSchema, maxBuckets := limits.QueryNativeHistogramsMaxSchema(userID), limits.QueryNativeHistogramsMaxBuckets(userID); maxSchema < maxNativeHistogramSchema || maxBuckets > 0 {
+			result = newHistogramResolutionQuerier(result, maxSchema, maxBuckets, histogramResolutionReducedFromContext(ctx))
+		}
+		if replicaLabel := limits.QueryDeduplicationReplicaLabel(userID); replicaLabel != "" {
+			result = newReplicaDeduplicationQuerier(result, replicaLabel)
+		}
+		if cfg.SelectorSplitMaxFetches > 1 {
+			result = newSelectorSplitQuerier(ctx, result, cfg.SelectorSplitMaxFetches, splitFetchesPerQuery)
+		}
+		return result, nil
 	})
 }
 
@@ -299,6 +390,10 @@ func (q querier) Select(_ bool, sp *storage.SelectHints, matchers ...*labels.Mat
 	// we have all the sets from different sources (chunk from store, chunks from ingesters,
 	// time series from store and time series from ingesters).
 	// mergeSeriesSets will return sorted set.
+	start := time.Now()
+	defer func() {
+		stats.FromContext(ctx).AddMergeTime(time.Since(start))
+	}()
 	return q.mergeSeriesSets(result)
 }
 
@@ -340,7 +435,17 @@ func (q querier) LabelValues(name string, matchers ...*labels.Matcher) ([]string
 		return nil, nil, err
 	}
 
-	return util.MergeSlices(sets...), warnings, nil
+	userID, err := tenant.TenantID(q.ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := pagination.FromContext(q.ctx).Apply(util.MergeSlices(sets...))
+	if err := validation.CheckLabelValuesResultsSizeBytes(values, q.limits.LabelValuesResultsMaxSizeBytes(userID)); err != nil {
+		return nil, nil, err
+	}
+
+	return values, warnings, nil
 }
 
 func (q querier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
@@ -380,11 +485,16 @@ func (q querier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warn
 		return nil, nil, err
 	}
 
-	return util.MergeSlices(sets...), warnings, nil
+	return pagination.FromContext(q.ctx).Apply(util.MergeSlices(sets...)), warnings, nil
 }
 
-func (querier) Close() error {
-	return nil
+// Close closes the underlying queriers, releasing the resources retained by their series sets.
+func (q querier) Close() error {
+	var errs multierror.MultiError
+	for _, querier := range q.queriers {
+		errs.Add(querier.Close())
+	}
+	return errs.Err()
 }
 
 func (q querier) mergeSeriesSets(sets []storage.SeriesSet) storage.SeriesSet {
@@ -457,22 +567,30 @@ func (s *sliceSeriesSet) Warnings() storage.Warnings {
 
 type storeQueryable struct {
 	QueryableWithFilter
-	QueryStoreAfter time.Duration
+	router timeRangeRouter
 }
 
-func (s storeQueryable) UseQueryable(now time.Time, queryMinT, queryMaxT int64) bool {
-	// Include this store only if mint is within QueryStoreAfter w.r.t current time.
-	if s.QueryStoreAfter != 0 && queryMinT > util.TimeToMillis(now.Add(-s.QueryStoreAfter)) {
+func (s storeQueryable) UseQueryable(now time.Time, userID string, queryMinT, queryMaxT int64) bool {
+	// Include this store only if mint is within the tenant's query-store-after w.r.t current time.
+	if !s.router.forTenant(now, userID).useStoreGateways(now, queryMinT, queryMaxT) {
 		return false
 	}
-	return s.QueryableWithFilter.UseQueryable(now, queryMinT, queryMaxT)
+	return s.QueryableWithFilter.UseQueryable(now, userID, queryMinT, queryMaxT)
+}
+
+func (s storeQueryable) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+	q, err := s.QueryableWithFilter.Querier(ctx, mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return s.router.trackCosts(q, timeRangeRoutingStoreGateways, mint, maxt), nil
 }
 
 type alwaysTrueFilterQueryable struct {
 	storage.Queryable
 }
 
-func (alwaysTrueFilterQueryable) UseQueryable(_ time.Time, _, _ int64) bool {
+func (alwaysTrueFilterQueryable) UseQueryable(_ time.Time, _ string, _, _ int64) bool {
 	return true
 }
 
@@ -486,7 +604,7 @@ type useBeforeTimestampQueryable struct {
 	ts int64 // Timestamp in milliseconds
 }
 
-func (u useBeforeTimestampQueryable) UseQueryable(_ time.Time, queryMinT, _ int64) bool {
+func (u useBeforeTimestampQueryable) UseQueryable(_ time.Time, _ string, queryMinT, _ int64) bool {
 	if u.ts == 0 {
 		return true
 	}
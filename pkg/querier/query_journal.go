@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/util/activitytracker"
+)
+
+// QueryJournal records the queries running in the querier in a file, removing them once completed, so that
+// the queries running when the querier crashed, for example because of an OOM, survive in the file and are
+// logged once the querier restarts. A nil QueryJournal ignores all the calls.
+type QueryJournal struct {
+	tracker *activitytracker.ActivityTracker
+}
+
+// NewQueryJournal logs the queries left in the journal file by the previous run of the querier, and starts
+// a new journal in the same file. Returns nil if the file path is empty.
+func NewQueryJournal(filepath string, maxEntries int, logger log.Logger, reg prometheus.Registerer) (*QueryJournal, error) {
+	if filepath == "" {
+		return nil, nil
+	}
+
+	entries, err := activitytracker.LoadUnfinishedEntries(filepath)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to fully read the query journal of the previous run", "file", filepath, "err", err)
+	}
+	if len(entries) > 0 {
+		level.Warn(logger).Log("msg", "found queries not completed by the previous run in the query journal", "count", len(entries))
+	}
+	for _, e := range entries {
+		level.Warn(logger).Log("msg", "query not completed by the previous run", "start", e.Timestamp.UTC().Format(time.RFC3339Nano), "query", e.Activity)
+	}
+
+	promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_querier_query_journal_leftover_queries",
+		Help: "Number of queries found in the query journal at startup, not completed by the previous run of the querier.",
+	}).Set(float64(len(entries)))
+
+	// The metrics of the tracker aren't registered, because they would clash with the ones of the activity tracker.
+	tracker, err := activitytracker.NewActivityTracker(activitytracker.Config{Filepath: filepath, MaxEntries: maxEntries}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryJournal{tracker: tracker}, nil
+}
+
+// Insert records the query with the input parameters, run by the tenant in the context, and returns the
+// index to remove it once completed.
+func (j *QueryJournal) Insert(ctx context.Context, query, params string) int {
+	if j == nil {
+		return -1
+	}
+
+	return j.tracker.Insert(func() string {
+		return queryJournalEntry(ctx, query, params)
+	})
+}
+
+// Delete removes the query with the input index, returned by Insert.
+func (j *QueryJournal) Delete(index int) {
+	if j == nil {
+		return
+	}
+	j.tracker.Delete(index)
+}
+
+// Close closes the journal file.
+func (j *QueryJournal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.tracker.Close()
+}
+
+// queryJournalEntry returns the journal entry of the query. The entry starts with the fingerprint of the query,
+// so that the executions of the same query can be matched even if the query is trimmed to fit in the entry.
+func queryJournalEntry(ctx context.Context, query, params string) string {
+	tenantID := ""
+	if tenantIDs, err := tenant.TenantIDs(ctx); err == nil {
+		tenantID = tenant.JoinTenantIDs(tenantIDs)
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(tenantID))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(params))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(query))
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "fingerprint=%016x tenant=%s ", h.Sum64(), tenantID)
+	if params != "" {
+		b.WriteString(params)
+		b.WriteString(" ")
+	}
+	b.WriteString("query=")
+	b.WriteString(query)
+	return b.String()
+}
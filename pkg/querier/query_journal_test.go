@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util/activitytracker"
+)
+
+func TestQueryJournal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "query-journal.log")
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	journal, err := NewQueryJournal(file, 10, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	completed := journal.Insert(ctx, `sum(rate(completed[5m]))`, "time=1000")
+	journal.Insert(ctx, `sum(rate(running[5m]))`, "start=1000 end=2000 step=10")
+	journal.Delete(completed)
+
+	// The querier crashes while running the second query, which is found in the journal once restarted.
+	logs := &bytes.Buffer{}
+	reg := prometheus.NewPedanticRegistry()
+	restarted, err := NewQueryJournal(file, 10, log.NewLogfmtLogger(logs), reg)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, restarted.Close()) })
+
+	expectedEntry := queryJournalEntry(ctx, `sum(rate(running[5m]))`, "start=1000 end=2000 step=10")
+	assert.Regexp(t, `^fingerprint=[0-9a-f]{16} tenant=user-1 start=1000 end=2000 step=10 query=sum\(rate\(running\[5m\]\)\)$`, expectedEntry)
+	assert.Contains(t, logs.String(), "count=1")
+	assert.Contains(t, logs.String(), expectedEntry)
+	assert.NotContains(t, logs.String(), "sum(rate(completed[5m]))")
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_query_journal_leftover_queries Number of queries found in the query journal at startup, not completed by the previous run of the querier.
+		# TYPE cortex_querier_query_journal_leftover_queries gauge
+		cortex_querier_query_journal_leftover_queries 1
+	`)))
+
+	// The journal starts empty once restarted.
+	entries, err := activitytracker.LoadUnfinishedEntries(file)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestQueryJournalEntry_Fingerprint(t *testing.T) {
+	fingerprint := func(tenantID, query, params string) string {
+		entry := queryJournalEntry(user.InjectOrgID(context.Background(), tenantID), query, params)
+		return strings.Fields(entry)[0]
+	}
+
+	assert.Equal(t, fingerprint("user-1", "up", "time=1"), fingerprint("user-1", "up", "time=1"))
+	assert.NotEqual(t, fingerprint("user-1", "up", "time=1"), fingerprint("user-2", "up", "time=1"))
+	assert.NotEqual(t, fingerprint("user-1", "up", "time=1"), fingerprint("user-1", "up", "time=2"))
+	assert.NotEqual(t, fingerprint("user-1", "up", "time=1"), fingerprint("user-1", "down", "time=1"))
+}
+
+func TestQueryJournal_Disabled(t *testing.T) {
+	journal, err := NewQueryJournal("", 10, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.Nil(t, journal)
+
+	// A nil journal ignores all the calls.
+	journal.Delete(journal.Insert(context.Background(), "up", ""))
+	require.NoError(t, journal.Close())
+}
@@ -0,0 +1,276 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"math"
+	"sort"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+// replicaDeduplicationInitialPenalty is the penalty, in milliseconds, applied to the replicas not picked
+// for the first sample of a deduplicated series, when the scrape interval isn't known yet.
+const replicaDeduplicationInitialPenalty = 5000
+
+// replicaDeduplicationQuerier is a storage.Querier deduplicating at query time the series which only differ
+// by the replica label, for the tenants ingesting the series of all their Prometheus HA replicas.
+type replicaDeduplicationQuerier struct {
+	storage.Querier
+
+	replicaLabel string
+}
+
+func newReplicaDeduplicationQuerier(q storage.Querier, replicaLabel string) storage.Querier {
+	return &replicaDeduplicationQuerier{Querier: q, replicaLabel: replicaLabel}
+}
+
+// Select implements storage.Querier. The returned series are always sorted.
+func (q *replicaDeduplicationQuerier) Select(sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	return newReplicaDeduplicationSeriesSet(q.Querier.Select(sortSeries, sp, matchers...), q.replicaLabel)
+}
+
+// LabelValues implements storage.Querier. The replica label has no values, since it's removed from the series.
+func (q *replicaDeduplicationQuerier) LabelValues(name string, matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	if name == q.replicaLabel {
+		return nil, nil, nil
+	}
+	return q.Querier.LabelValues(name, matchers...)
+}
+
+// LabelNames implements storage.Querier. The replica label is removed from the label names.
+func (q *replicaDeduplicationQuerier) LabelNames(matchers ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	names, warnings, err := q.Querier.LabelNames(matchers...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filtered := names[:0]
+	for _, name := range names {
+		if name != q.replicaLabel {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered, warnings, nil
+}
+
+// newReplicaDeduplicationSeriesSet removes the replica label from the series of the input set, and merges the
+// series which only differ by the replica label into a single series. The input set is fully consumed, since
+// removing the replica label changes the order of the series.
+func newReplicaDeduplicationSeriesSet(set storage.SeriesSet, replicaLabel string) storage.SeriesSet {
+	var (
+		deduplicated []*replicaDeduplicationSeries
+		byLabels     = map[string]*replicaDeduplicationSeries{}
+		builder      = labels.NewBuilder(labels.EmptyLabels())
+		buf          []byte
+	)
+
+	for set.Next() {
+		s := set.At()
+
+		builder.Reset(s.Labels())
+		lbls := builder.Del(replicaLabel).Labels(labels.EmptyLabels())
+
+		buf = lbls.Bytes(buf)
+		d, ok := byLabels[string(buf)]
+		if !ok {
+			d = &replicaDeduplicationSeries{labels: lbls}
+			byLabels[string(buf)] = d
+			deduplicated = append(deduplicated, d)
+		}
+		d.replicas = append(d.replicas, s)
+	}
+
+	if err := set.Err(); err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+
+	sort.Slice(deduplicated, func(i, j int) bool {
+		return labels.Compare(deduplicated[i].labels, deduplicated[j].labels) < 0
+	})
+
+	return &replicaDeduplicationSeriesSet{series: deduplicated, ix: -1, warnings: set.Warnings()}
+}
+
+type replicaDeduplicationSeriesSet struct {
+	series   []*replicaDeduplicationSeries
+	ix       int
+	warnings storage.Warnings
+}
+
+func (s *replicaDeduplicationSeriesSet) Next() bool {
+	s.ix++
+	return s.ix < len(s.series)
+}
+
+func (s *replicaDeduplicationSeriesSet) At() storage.Series {
+	if s.ix < 0 || s.ix >= len(s.series) {
+		return nil
+	}
+	return s.series[s.ix]
+}
+
+func (s *replicaDeduplicationSeriesSet) Err() error {
+	return nil
+}
+
+func (s *replicaDeduplicationSeriesSet) Warnings() storage.Warnings {
+	return s.warnings
+}
+
+// replicaDeduplicationSeries is a series merged from the series of multiple replicas, without the replica label.
+type replicaDeduplicationSeries struct {
+	labels   labels.Labels
+	replicas []storage.Series
+}
+
+func (s *replicaDeduplicationSeries) Labels() labels.Labels {
+	return s.labels
+}
+
+func (s *replicaDeduplicationSeries) Iterator(chunkenc.Iterator) chunkenc.Iterator {
+	it := s.replicas[0].Iterator(nil)
+	for _, r := range s.replicas[1:] {
+		it = newReplicaDeduplicationIterator(it, r.Iterator(nil))
+	}
+	return it
+}
+
+// replicaDeduplicationIterator merges the samples of two replicas of the same series. The samples are
+// picked from a single replica at a time: the replica not picked is only used once the picked one has
+// a gap in its samples, so that the merged series doesn't have a higher sampling frequency than the
+// replicas, even if their samples have slightly different timestamps.
+type replicaDeduplicationIterator struct {
+	a, b       chunkenc.Iterator
+	aVal, bVal chunkenc.ValueType
+
+	// The penalties, in milliseconds, added to the timestamp of the last sample when seeking each replica.
+	aPenalty, bPenalty int64
+
+	lastT int64
+	useA  bool
+	val   chunkenc.ValueType
+}
+
+func newReplicaDeduplicationIterator(a, b chunkenc.Iterator) *replicaDeduplicationIterator {
+	return &replicaDeduplicationIterator{
+		a:     a,
+		b:     b,
+		aVal:  a.Next(),
+		bVal:  b.Next(),
+		lastT: math.MinInt64,
+	}
+}
+
+func (it *replicaDeduplicationIterator) Next() chunkenc.ValueType {
+	// Advance both replicas past the last sample, plus their penalty. The replica without penalty is advanced
+	// first: if it has no more samples, the penalty of the other replica is reduced, to not skip its samples.
+	if it.aPenalty > 0 {
+		it.bVal = seekReplica(it.b, it.bVal, it.lastT+1)
+		it.aVal = seekReplica(it.a, it.aVal, it.lastT+1+penaltyIfAvailable(it.aPenalty, it.bVal))
+	} else {
+		it.aVal = seekReplica(it.a, it.aVal, it.lastT+1)
+		it.bVal = seekReplica(it.b, it.bVal, it.lastT+1+penaltyIfAvailable(it.bPenalty, it.aVal))
+	}
+
+	switch {
+	case it.aVal == chunkenc.ValNone && it.bVal == chunkenc.ValNone:
+		it.val = chunkenc.ValNone
+		return it.val
+	case it.aVal == chunkenc.ValNone:
+		it.useA = false
+	case it.bVal == chunkenc.ValNone:
+		it.useA = true
+	default:
+		// Pick the replica with the earliest sample, and penalize the other one with twice the interval
+		// since the last sample, to not pick a sample of the other replica too close to the picked one.
+		aT, bT := it.a.AtT(), it.b.AtT()
+		it.useA = aT <= bT
+
+		penalty := int64(replicaDeduplicationInitialPenalty)
+		if it.lastT != math.MinInt64 {
+			penalty = 2 * (util_math.Min(aT, bT) - it.lastT)
+		}
+		if it.useA {
+			it.bPenalty = penalty
+		} else {
+			it.aPenalty = penalty
+		}
+	}
+
+	if it.useA {
+		it.aPenalty = 0
+		it.lastT = it.a.AtT()
+		it.val = it.aVal
+	} else {
+		it.bPenalty = 0
+		it.lastT = it.b.AtT()
+		it.val = it.bVal
+	}
+	return it.val
+}
+
+func seekReplica(it chunkenc.Iterator, val chunkenc.ValueType, t int64) chunkenc.ValueType {
+	if val == chunkenc.ValNone {
+		return val
+	}
+	return it.Seek(t)
+}
+
+// penaltyIfAvailable returns the penalty of a replica. If the other replica has no more samples, the penalty
+// is reduced to half the interval between the last two samples, to only skip the samples of the replica too
+// close to the last sample.
+func penaltyIfAvailable(penalty int64, otherVal chunkenc.ValueType) int64 {
+	if otherVal == chunkenc.ValNone {
+		return penalty / 4
+	}
+	return penalty
+}
+
+func (it *replicaDeduplicationIterator) Seek(t int64) chunkenc.ValueType {
+	// Don't seek the replicas directly, to not miss the gaps of the picked replica.
+	if it.val != chunkenc.ValNone && it.lastT >= t {
+		return it.val
+	}
+	for it.Next() != chunkenc.ValNone {
+		if it.lastT >= t {
+			return it.val
+		}
+	}
+	return chunkenc.ValNone
+}
+
+func (it *replicaDeduplicationIterator) current() chunkenc.Iterator {
+	if it.useA {
+		return it.a
+	}
+	return it.b
+}
+
+func (it *replicaDeduplicationIterator) At() (int64, float64) {
+	return it.current().At()
+}
+
+func (it *replicaDeduplicationIterator) AtHistogram() (int64, *histogram.Histogram) {
+	return it.current().AtHistogram()
+}
+
+func (it *replicaDeduplicationIterator) AtFloatHistogram() (int64, *histogram.FloatHistogram) {
+	return it.current().AtFloatHistogram()
+}
+
+func (it *replicaDeduplicationIterator) AtT() int64 {
+	return it.current().AtT()
+}
+
+func (it *replicaDeduplicationIterator) Err() error {
+	if err := it.a.Err(); err != nil {
+		return err
+	}
+	return it.b.Err()
+}
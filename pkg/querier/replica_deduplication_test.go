@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/series"
+)
+
+func TestReplicaDeduplicationIterator(t *testing.T) {
+	tests := map[string]struct {
+		a, b              []model.Time
+		expectedTimestamp []int64
+	}{
+		"should pick the samples of a single replica when both replicas have all the samples": {
+			a:                 []model.Time{0, 15000, 30000},
+			b:                 []model.Time{1000, 16000, 31000},
+			expectedTimestamp: []int64{0, 15000, 30000},
+		},
+		"should pick the samples of the other replica when the picked one has a gap": {
+			a:                 []model.Time{0, 15000, 30000, 90000, 105000},
+			b:                 []model.Time{1000, 16000, 31000, 46000, 61000, 76000, 91000, 106000},
+			expectedTimestamp: []int64{0, 15000, 30000, 61000, 76000, 91000, 106000},
+		},
+		"should pick the samples of the other replica when the first one has no samples": {
+			b:                 []model.Time{1000, 16000},
+			expectedTimestamp: []int64{1000, 16000},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			it := newReplicaDeduplicationIterator(concreteSeriesIterator(testData.a), concreteSeriesIterator(testData.b))
+
+			var actual []int64
+			for it.Next() == chunkenc.ValFloat {
+				ts, v := it.At()
+				assert.Equal(t, float64(ts), v)
+				actual = append(actual, ts)
+			}
+			require.NoError(t, it.Err())
+			assert.Equal(t, testData.expectedTimestamp, actual)
+		})
+	}
+}
+
+func TestReplicaDeduplicationIterator_Seek(t *testing.T) {
+	it := newReplicaDeduplicationIterator(
+		concreteSeriesIterator([]model.Time{0, 15000, 30000, 90000}),
+		concreteSeriesIterator([]model.Time{1000, 16000, 31000, 61000, 76000}),
+	)
+
+	require.Equal(t, chunkenc.ValFloat, it.Seek(20000))
+	assert.Equal(t, int64(30000), it.AtT())
+
+	// Seeking a timestamp before the current sample has no effect.
+	require.Equal(t, chunkenc.ValFloat, it.Seek(0))
+	assert.Equal(t, int64(30000), it.AtT())
+
+	// The gap of the picked replica is filled with the samples of the other replica.
+	require.Equal(t, chunkenc.ValFloat, it.Seek(50000))
+	assert.Equal(t, int64(61000), it.AtT())
+
+	require.Equal(t, chunkenc.ValNone, it.Seek(200000))
+}
+
+func TestReplicaDeduplicationSeriesSet(t *testing.T) {
+	set := series.NewConcreteSeriesSet([]storage.Series{
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "__replica__", "a", "pod", "x"), samplePairs(0, 15000), nil),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "__replica__", "a", "pod", "y"), samplePairs(0, 15000), nil),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "__replica__", "b", "pod", "x"), samplePairs(1000, 16000, 31000), nil),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "pod", "z"), samplePairs(0), nil),
+	})
+
+	actual := map[string][]int64{}
+	var actualLabels []labels.Labels
+	deduplicated := newReplicaDeduplicationSeriesSet(set, "__replica__")
+	for deduplicated.Next() {
+		s := deduplicated.At()
+		actualLabels = append(actualLabels, s.Labels())
+
+		it := s.Iterator(nil)
+		for it.Next() != chunkenc.ValNone {
+			actual[s.Labels().String()] = append(actual[s.Labels().String()], it.AtT())
+		}
+		require.NoError(t, it.Err())
+	}
+	require.NoError(t, deduplicated.Err())
+
+	// The series are sorted once the replica label is removed.
+	assert.Equal(t, []labels.Labels{
+		labels.FromStrings(labels.MetricName, "up", "pod", "x"),
+		labels.FromStrings(labels.MetricName, "up", "pod", "y"),
+		labels.FromStrings(labels.MetricName, "up", "pod", "z"),
+	}, actualLabels)
+	assert.Equal(t, map[string][]int64{
+		`{__name__="up", pod="x"}`: {0, 15000, 31000},
+		`{__name__="up", pod="y"}`: {0, 15000},
+		`{__name__="up", pod="z"}`: {0},
+	}, actual)
+}
+
+func TestReplicaDeduplicationQuerier_LabelValues(t *testing.T) {
+	q := newReplicaDeduplicationQuerier(&mockBlocksStorageQuerier{}, "__replica__")
+
+	values, _, err := q.LabelValues("__replica__")
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func samplePairs(timestamps ...model.Time) []model.SamplePair {
+	samples := make([]model.SamplePair, 0, len(timestamps))
+	for _, ts := range timestamps {
+		samples = append(samples, model.SamplePair{Timestamp: ts, Value: model.SampleValue(ts)})
+	}
+	return samples
+}
+
+func concreteSeriesIterator(timestamps []model.Time) chunkenc.Iterator {
+	return series.NewConcreteSeries(labels.EmptyLabels(), samplePairs(timestamps...), nil).Iterator(nil)
+}
@@ -0,0 +1,298 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/series"
+)
+
+type selectorMemoizationContextKey int
+
+const selectorMemoizationKey selectorMemoizationContextKey = 0
+
+// selectorMemoization memoizes the series selected by the identical selectors of a single query, which are
+// common in the queries of the generated dashboards, so that they're only fetched once. Only the selectors
+// whose label matchers appear more than once in the query are memoized, up to a max size per query.
+type selectorMemoization struct {
+	memoizedEntries
+
+	hits prometheus.Counter
+
+	// The label matchers of the selectors appearing more than once in the query.
+	duplicated map[string]struct{}
+}
+
+// memoizedEntries holds the series memoized for a single query, up to a max size.
+type memoizedEntries struct {
+	maxBytes uint64
+
+	mtx     sync.Mutex
+	bytes   uint64
+	entries map[string]*memoizedSelect
+}
+
+type memoizedSelect struct {
+	series   []storage.Series
+	warnings storage.Warnings
+}
+
+// newSelectorMemoization returns the memoization of the selectors of the input query, or nil if no
+// selector appears more than once in the query.
+func newSelectorMemoization(query parser.Node, maxBytes uint64, hits prometheus.Counter) *selectorMemoization {
+	selectors := map[string]int{}
+	duplicated := map[string]struct{}{}
+
+	parser.Inspect(query, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			key := matchersKey(vs.LabelMatchers)
+			selectors[key]++
+			if selectors[key] > 1 {
+				duplicated[key] = struct{}{}
+			}
+		}
+		return nil
+	})
+
+	if len(duplicated) == 0 {
+		return nil
+	}
+
+	return &selectorMemoization{
+		memoizedEntries: memoizedEntries{maxBytes: maxBytes, entries: map[string]*memoizedSelect{}},
+		hits:            hits,
+		duplicated:      duplicated,
+	}
+}
+
+func contextWithSelectorMemoization(ctx context.Context, m *selectorMemoization) context.Context {
+	return context.WithValue(ctx, selectorMemoizationKey, m)
+}
+
+func selectorMemoizationFromContext(ctx context.Context) *selectorMemoization {
+	m, _ := ctx.Value(selectorMemoizationKey).(*selectorMemoization)
+	return m
+}
+
+// get returns the memoized series of the key, or nil if they have not been memoized.
+func (m *memoizedEntries) get(key string) *memoizedSelect {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.entries[key]
+}
+
+// reserve reserves the input bytes of the memoization, and returns false if there's not enough space left.
+func (m *memoizedEntries) reserve(bytes uint64) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.bytes+bytes > m.maxBytes {
+		return false
+	}
+	m.bytes += bytes
+	return true
+}
+
+func (m *memoizedEntries) release(bytes uint64) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.bytes -= bytes
+}
+
+// put memoizes the input entry under the input key, whose bytes must have already been reserved.
+func (m *memoizedEntries) put(key string, entry *memoizedSelect) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.entries[key] = entry
+}
+
+// memoize consumes the input series set and memoizes its series under the input key. If the series don't fit
+// in the space left, the series are returned without being memoized.
+func (m *selectorMemoization) memoize(key string, set storage.SeriesSet) storage.SeriesSet {
+	var (
+		memoized []storage.Series
+		reserved uint64
+	)
+
+	for set.Next() {
+		s, size, err := materializeSeries(set.At())
+		if err != nil {
+			m.release(reserved)
+			return storage.ErrSeriesSet(err)
+		}
+
+		memoized = append(memoized, s)
+		if !m.reserve(size) {
+			// The series not consumed yet are returned as they are, after the ones already consumed.
+			m.release(reserved)
+			return &prefixedSeriesSet{prefix: memoized, ix: -1, SeriesSet: set}
+		}
+		reserved += size
+	}
+
+	if err := set.Err(); err != nil {
+		m.release(reserved)
+		return storage.ErrSeriesSet(err)
+	}
+
+	entry := &memoizedSelect{series: memoized, warnings: set.Warnings()}
+	m.put(key, entry)
+	return entry.seriesSet()
+}
+
+// seriesSet returns a new set of the memoized series. The memoized series can be iterated concurrently.
+func (e *memoizedSelect) seriesSet() storage.SeriesSet {
+	return series.NewSeriesSetWithWarnings(series.NewConcreteSeriesSet(append([]storage.Series(nil), e.series...)), e.warnings)
+}
+
+// materializeSeries returns an in-memory copy of the input series, and its estimated size in bytes.
+func materializeSeries(s storage.Series) (*series.ConcreteSeries, uint64, error) {
+	var (
+		samples    []model.SamplePair
+		histograms []mimirpb.Histogram
+		size       uint64
+	)
+
+	lbls := s.Labels()
+	lbls.Range(func(l labels.Label) {
+		size += uint64(len(l.Name) + len(l.Value))
+	})
+
+	it := s.Iterator(nil)
+	for valType := it.Next(); valType != chunkenc.ValNone; valType = it.Next() {
+		switch valType {
+		case chunkenc.ValFloat:
+			t, v := it.At()
+			samples = append(samples, model.SamplePair{Timestamp: model.Time(t), Value: model.SampleValue(v)})
+			size += 16
+		case chunkenc.ValHistogram:
+			t, h := it.AtHistogram()
+			histograms = append(histograms, mimirpb.FromHistogramToHistogramProto(t, h))
+			size += uint64(histograms[len(histograms)-1].Size())
+		case chunkenc.ValFloatHistogram:
+			t, h := it.AtFloatHistogram()
+			histograms = append(histograms, mimirpb.FromFloatHistogramToHistogramProto(t, h))
+			size += uint64(histograms[len(histograms)-1].Size())
+		default:
+			return nil, 0, fmt.Errorf("unsupported value type %v", valType)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return series.NewConcreteSeries(lbls, samples, histograms), size, nil
+}
+
+// prefixedSeriesSet returns the prefix series, followed by the series of the wrapped set.
+type prefixedSeriesSet struct {
+	storage.SeriesSet
+
+	prefix []storage.Series
+	ix     int
+}
+
+func (s *prefixedSeriesSet) Next() bool {
+	if s.ix+1 < len(s.prefix) {
+		s.ix++
+		return true
+	}
+	s.ix = len(s.prefix)
+	return s.SeriesSet.Next()
+}
+
+func (s *prefixedSeriesSet) At() storage.Series {
+	if s.ix < len(s.prefix) {
+		return s.prefix[s.ix]
+	}
+	return s.SeriesSet.At()
+}
+
+// newMemoizingQueryable returns a queryable memoizing the series selected by the identical selectors and the
+// results of the identical subexpressions of the query, if the context of the query has a memoization.
+func newMemoizingQueryable(q storage.Queryable) storage.Queryable {
+	return storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+		querier, err := q.Querier(ctx, mint, maxt)
+		if err != nil {
+			return nil, err
+		}
+
+		m := selectorMemoizationFromContext(ctx)
+		sm := subexpressionMemoizationFromContext(ctx)
+		if m == nil && sm == nil {
+			return querier, nil
+		}
+		return &memoizingQuerier{Querier: querier, ctx: ctx, memoization: m, subexpressions: sm, mint: mint, maxt: maxt}, nil
+	})
+}
+
+type memoizingQuerier struct {
+	storage.Querier
+
+	ctx            context.Context
+	memoization    *selectorMemoization
+	subexpressions *subexpressionMemoization
+	mint, maxt     int64
+}
+
+// Select implements storage.Querier.
+func (q *memoizingQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	// The placeholders of the memoized subexpressions are never selected from the storage.
+	if id, ok := memoizedSubexpressionID(matchers); ok {
+		if q.subexpressions == nil {
+			return storage.ErrSeriesSet(fmt.Errorf("no memoization for the subexpression %s", id))
+		}
+		return q.subexpressions.selectSubexpression(q.ctx, id)
+	}
+
+	if q.memoization == nil {
+		return q.Querier.Select(sortSeries, hints, matchers...)
+	}
+
+	key := matchersKey(matchers)
+	if _, ok := q.memoization.duplicated[key]; !ok {
+		return q.Querier.Select(sortSeries, hints, matchers...)
+	}
+
+	// The series are only reused by the selectors with the same time range and hints.
+	key = fmt.Sprintf("%d:%d:%t:%s:%s", q.mint, q.maxt, sortSeries, hintsKey(hints), key)
+	if entry := q.memoization.get(key); entry != nil {
+		q.memoization.hits.Inc()
+		return entry.seriesSet()
+	}
+
+	return q.memoization.memoize(key, q.Querier.Select(sortSeries, hints, matchers...))
+}
+
+// matchersKey returns a key identifying the input label matchers, regardless of their order.
+func matchersKey(matchers []*labels.Matcher) string {
+	keys := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		keys = append(keys, m.String())
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func hintsKey(hints *storage.SelectHints) string {
+	if hints == nil {
+		return ""
+	}
+	return fmt.Sprintf("%+v", *hints)
+}
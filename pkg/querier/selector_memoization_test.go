@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/querier/engine"
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestPerTenantEngine_SelectorMemoization(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			some_metric{idx="1", group="a"} 0+1x10
+			some_metric{idx="2", group="a"} 0+2x10
+			other_metric{idx="1"} 0+1x10
+	`)
+	require.NoError(t, err)
+	t.Cleanup(test.Close)
+	require.NoError(t, test.Run())
+
+	overrides, err := validation.NewOverrides(defaultLimitsConfig(), validation.NewMockTenantLimits(map[string]*validation.Limits{
+		"streaming": func() *validation.Limits {
+			l := defaultLimitsConfig()
+			l.QueryEngine = validation.QueryEngineStreaming
+			return &l
+		}(),
+	}))
+	require.NoError(t, err)
+
+	const query = `sum(rate(some_metric[5m])) + sum(rate(some_metric[5m])) + sum(other_metric)`
+	start, end, step := time.Unix(0, 0), time.Unix(0, 0).Add(10*time.Minute), time.Minute
+
+	tests := map[string]struct {
+		tenantID         string
+		maxMemoizedBytes int
+		expectedSelects  int64
+		expectedHits     float64
+	}{
+		"disabled": {
+			tenantID:        "prometheus",
+			expectedSelects: 3,
+		},
+		"Prometheus engine": {
+			tenantID:         "prometheus",
+			maxMemoizedBytes: 1024 * 1024,
+			expectedSelects:  2,
+			expectedHits:     1,
+		},
+		"streaming engine": {
+			tenantID:         "streaming",
+			maxMemoizedBytes: 1024 * 1024,
+			expectedSelects:  2,
+			expectedHits:     1,
+		},
+		"series not fitting in the max size": {
+			tenantID:         "prometheus",
+			maxMemoizedBytes: 10,
+			expectedSelects:  3,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			engineCfg := engine.Config{}
+			flagext.DefaultValues(&engineCfg)
+			engineCfg.MaxMemoizedSelectorSeriesBytesPerQuery = testData.maxMemoizedBytes
+
+			prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+			e := NewPerTenantEngine(engineCfg, prometheusEngine, overrides, nil, nil, log.NewNopLogger(), nil)
+			ctx := user.InjectOrgID(context.Background(), testData.tenantID)
+
+			expected, err := prometheusEngine.NewRangeQuery(test.Queryable(), nil, query, start, end, step)
+			require.NoError(t, err)
+			expectedRes := expected.Exec(ctx)
+			require.NoError(t, expectedRes.Err)
+
+			selects := atomic.NewInt64(0)
+			queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+				q, err := test.Queryable().Querier(ctx, mint, maxt)
+				if err != nil {
+					return nil, err
+				}
+				return &selectCountingQuerier{Querier: q, selects: selects}, nil
+			})
+
+			q, err := e.NewRangeQuery(queryable, nil, query, start, end, step)
+			require.NoError(t, err)
+			res := q.Exec(ctx)
+			require.NoError(t, res.Err)
+			require.Equal(t, expectedRes.Value, res.Value)
+			q.Close()
+
+			assert.Equal(t, testData.expectedSelects, selects.Load())
+			assert.Equal(t, testData.expectedHits, testutil.ToFloat64(e.selectorMemoizationHits))
+		})
+	}
+}
+
+func TestSelectorMemoization_Memoize(t *testing.T) {
+	expr, err := parser.ParseExpr(`some_metric + some_metric`)
+	require.NoError(t, err)
+
+	newSet := func() storage.SeriesSet {
+		return series.NewConcreteSeriesSet([]storage.Series{
+			series.NewConcreteSeries(labels.FromStrings("idx", "1"), samplePairs(0, 15000), nil),
+			series.NewConcreteSeries(labels.FromStrings("idx", "2"), samplePairs(0, 15000), nil),
+		})
+	}
+
+	// The first series fits in the max size, the second doesn't.
+	m := newSelectorMemoization(expr, 40, prometheus.NewCounter(prometheus.CounterOpts{}))
+	require.NotNil(t, m)
+
+	set := m.memoize("key", newSet())
+	assert.Equal(t, []labels.Labels{labels.FromStrings("idx", "1"), labels.FromStrings("idx", "2")}, seriesSetLabels(t, set))
+	assert.Nil(t, m.get("key"))
+	assert.Equal(t, uint64(0), m.bytes)
+
+	// Both series fit in the max size once it's increased.
+	m.maxBytes = 100
+	set = m.memoize("key", newSet())
+	assert.Equal(t, []labels.Labels{labels.FromStrings("idx", "1"), labels.FromStrings("idx", "2")}, seriesSetLabels(t, set))
+	require.NotNil(t, m.get("key"))
+	assert.Equal(t, uint64(72), m.bytes)
+
+	// The memoized series can be iterated again.
+	assert.Equal(t, []labels.Labels{labels.FromStrings("idx", "1"), labels.FromStrings("idx", "2")}, seriesSetLabels(t, m.get("key").seriesSet()))
+}
+
+func TestNewSelectorMemoization(t *testing.T) {
+	for query, expectedDuplicated := range map[string][]string{
+		`sum(rate(some_metric[5m]))`:                               nil,
+		`some_metric{a="1", b="2"} / some_metric{b="2", a="1"}`:    {`__name__="some_metric",a="1",b="2"`},
+		`rate(some_metric[5m]) / rate(some_metric[5m] offset 1h)`:  {`__name__="some_metric"`},
+		`max_over_time(rate(some_metric[5m])[1h:]) / some_metric`:  {`__name__="some_metric"`},
+		`some_metric / other_metric`:                               nil,
+		`some_metric{a="1"} / some_metric{a="2"} or some_metric{}`: nil,
+	} {
+		t.Run(query, func(t *testing.T) {
+			expr, err := parser.ParseExpr(query)
+			require.NoError(t, err)
+
+			m := newSelectorMemoization(expr, 100, nil)
+			if expectedDuplicated == nil {
+				require.Nil(t, m)
+				return
+			}
+
+			require.NotNil(t, m)
+			var actual []string
+			for key := range m.duplicated {
+				actual = append(actual, key)
+			}
+			assert.Equal(t, expectedDuplicated, actual)
+		})
+	}
+}
+
+type selectCountingQuerier struct {
+	storage.Querier
+	selects *atomic.Int64
+}
+
+func (q *selectCountingQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	q.selects.Inc()
+	return q.Querier.Select(sortSeries, hints, matchers...)
+}
+
+func seriesSetLabels(t *testing.T, set storage.SeriesSet) []labels.Labels {
+	var actual []labels.Labels
+	for set.Next() {
+		s := set.At()
+		actual = append(actual, s.Labels())
+
+		it := s.Iterator(nil)
+		for it.Next() != chunkenc.ValNone {
+		}
+		require.NoError(t, it.Err())
+	}
+	require.NoError(t, set.Err())
+	return actual
+}
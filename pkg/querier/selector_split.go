@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/util/limiter"
+)
+
+// splitSelector plans the split of the selector of the input matchers into a fetch for each value of a union of
+// values, like {__name__=~"a|b|c"}, so that the fetches can run in parallel. The union of metric names is split
+// first, otherwise the first union of values of another label. A union including the empty value isn't split,
+// since it also matches the series without the label. It returns nil if the selector can't be split in at least
+// 2 and at most maxFetches fetches.
+func splitSelector(matchers []*labels.Matcher, maxFetches int) [][]*labels.Matcher {
+	splitIdx := -1
+	var values []string
+	for i, m := range matchers {
+		if m.Type != labels.MatchRegexp {
+			continue
+		}
+		set := m.SetMatches()
+		if len(set) < 2 || len(set) > maxFetches || containsEmptyValue(set) {
+			continue
+		}
+		if splitIdx < 0 || m.Name == labels.MetricName {
+			splitIdx, values = i, set
+		}
+		if m.Name == labels.MetricName {
+			break
+		}
+	}
+	if splitIdx < 0 {
+		return nil
+	}
+
+	fetches := make([][]*labels.Matcher, 0, len(values))
+	for _, value := range values {
+		fetch := make([]*labels.Matcher, len(matchers))
+		copy(fetch, matchers)
+		fetch[splitIdx] = labels.MustNewMatcher(labels.MatchEqual, matchers[splitIdx].Name, value)
+		fetches = append(fetches, fetch)
+	}
+	return fetches
+}
+
+func containsEmptyValue(values []string) bool {
+	for _, v := range values {
+		if v == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorSplitQuerier is a storage.Querier which splits the selectors matching a union of values into parallel
+// fetches, one for each value, and merges their series. The series of the fetches are disjoint, since each of
+// them has a different value of the split label.
+type selectorSplitQuerier struct {
+	storage.Querier
+
+	ctx        context.Context
+	maxFetches int
+
+	// fetchesPerQuery is observed, once the querier is closed, with the number of fetches the selectors of the
+	// query have been split into, if any.
+	fetchesPerQuery prometheus.Observer
+
+	mtx     sync.Mutex
+	fetches int
+}
+
+func newSelectorSplitQuerier(ctx context.Context, next storage.Querier, maxFetches int, fetchesPerQuery prometheus.Observer) *selectorSplitQuerier {
+	return &selectorSplitQuerier{
+		Querier:         next,
+		ctx:             ctx,
+		maxFetches:      maxFetches,
+		fetchesPerQuery: fetchesPerQuery,
+	}
+}
+
+// Select implements storage.Querier.
+func (q *selectorSplitQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	fetches := splitSelector(matchers, q.maxFetches)
+	if fetches == nil {
+		return q.Querier.Select(sortSeries, hints, matchers...)
+	}
+
+	q.mtx.Lock()
+	q.fetches += len(fetches)
+	q.mtx.Unlock()
+
+	// The series of the fetches are counted for the split selector by the max series per selector limit.
+	selectorLimiter := limiter.SelectorSeriesLimiterFromContextWithFallback(q.ctx)
+	selector := limiter.Selector(matchers)
+	for _, fetch := range fetches {
+		selectorLimiter.AddAlias(limiter.Selector(fetch), selector)
+	}
+
+	sets := make(chan storage.SeriesSet, len(fetches))
+	for _, fetch := range fetches {
+		go func(fetch []*labels.Matcher) {
+			// Each fetch gets its own copy of the hints, since the queriers may modify them.
+			var fetchHints *storage.SelectHints
+			if hints != nil {
+				h := *hints
+				fetchHints = &h
+			}
+			// The series must be sorted to be merged.
+			sets <- q.Querier.Select(true, fetchHints, fetch...)
+		}(fetch)
+	}
+
+	result := make([]storage.SeriesSet, 0, len(fetches))
+	for range fetches {
+		select {
+		case set := <-sets:
+			result = append(result, set)
+		case <-q.ctx.Done():
+			return storage.ErrSeriesSet(q.ctx.Err())
+		}
+	}
+	return storage.NewMergeSeriesSet(result, storage.ChainedSeriesMerge)
+}
+
+// Close implements storage.Querier.
+func (q *selectorSplitQuerier) Close() error {
+	q.mtx.Lock()
+	if q.fetches > 0 {
+		q.fetchesPerQuery.Observe(float64(q.fetches))
+	}
+	q.mtx.Unlock()
+
+	return q.Querier.Close()
+}
@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestSplitSelector(t *testing.T) {
+	tests := map[string]struct {
+		selector string
+		expected []string
+	}{
+		"should split the union of metric names": {
+			selector: `{__name__=~"a|b",job="x"}`,
+			expected: []string{`{__name__="a",job="x"}`, `{__name__="b",job="x"}`},
+		},
+		"should split the union of metric names before the union of label values": {
+			selector: `{job=~"x|y",__name__=~"a|b|c"}`,
+			expected: []string{`{job=~"x|y",__name__="a"}`, `{job=~"x|y",__name__="b"}`, `{job=~"x|y",__name__="c"}`},
+		},
+		"should split the union of label values": {
+			selector: `up{job=~"x|y"}`,
+			expected: []string{`{job="x",__name__="up"}`, `{job="y",__name__="up"}`},
+		},
+		"should not split the union of more values than the max fetches": {
+			selector: `{__name__=~"a|b|c|d|e"}`,
+		},
+		"should not split the union including the empty value": {
+			selector: `up{job=~"x|"}`,
+		},
+		"should not split the regexp which is not a union of values": {
+			selector: `{__name__=~"a.*"}`,
+		},
+		"should not split the negated union": {
+			selector: `up{job!~"x|y"}`,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			matchers, err := parser.ParseMetricSelector(testData.selector)
+			require.NoError(t, err)
+
+			var actual []string
+			for _, fetch := range splitSelector(matchers, 4) {
+				actual = append(actual, util.LabelMatchersToString(fetch))
+			}
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+// recordingQuerier is a storage.Querier returning the series matching the selectors, and recording them.
+type recordingQuerier struct {
+	storage.Querier
+	series []labels.Labels
+
+	mtx       sync.Mutex
+	selectors []string
+}
+
+func (q *recordingQuerier) Select(_ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	q.mtx.Lock()
+	q.selectors = append(q.selectors, util.LabelMatchersToString(matchers))
+	q.mtx.Unlock()
+
+	var result []storage.Series
+	for _, s := range q.series {
+		if matchesAll(s, matchers) {
+			result = append(result, series.NewConcreteSeries(s, samplePairs(0), nil))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return labels.Compare(result[i].Labels(), result[j].Labels()) < 0 })
+	return series.NewConcreteSeriesSet(result)
+}
+
+func (q *recordingQuerier) Close() error {
+	return nil
+}
+
+func matchesAll(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSelectorSplitQuerier(t *testing.T) {
+	next := &recordingQuerier{series: []labels.Labels{
+		labels.FromStrings(labels.MetricName, "a", "job", "x"),
+		labels.FromStrings(labels.MetricName, "b", "job", "x"),
+		labels.FromStrings(labels.MetricName, "b", "job", "y"),
+		labels.FromStrings(labels.MetricName, "c", "job", "x"),
+	}}
+
+	reg := prometheus.NewPedanticRegistry()
+	fetchesPerQuery := promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_fetches_per_query",
+		Help:    "Test.",
+		Buckets: []float64{2, 4},
+	})
+	q := newSelectorSplitQuerier(context.Background(), next, 4, fetchesPerQuery)
+
+	set := q.Select(false, &storage.SelectHints{Start: 0, End: 10}, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "a|b"))
+	var actual []labels.Labels
+	for set.Next() {
+		actual = append(actual, set.At().Labels())
+	}
+	require.NoError(t, set.Err())
+	assert.Equal(t, []labels.Labels{
+		labels.FromStrings(labels.MetricName, "a", "job", "x"),
+		labels.FromStrings(labels.MetricName, "b", "job", "x"),
+		labels.FromStrings(labels.MetricName, "b", "job", "y"),
+	}, actual)
+	assert.ElementsMatch(t, []string{`{__name__="a"}`, `{__name__="b"}`}, next.selectors)
+
+	// The selectors which can't be split are fetched as they are.
+	set = q.Select(false, nil, labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "a.*"))
+	for set.Next() {
+	}
+	require.NoError(t, set.Err())
+	assert.Contains(t, next.selectors, `{__name__=~"a.*"}`)
+
+	require.NoError(t, q.Close())
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP test_fetches_per_query Test.
+		# TYPE test_fetches_per_query histogram
+		test_fetches_per_query_bucket{le="2"} 1
+		test_fetches_per_query_bucket{le="4"} 1
+		test_fetches_per_query_bucket{le="+Inf"} 1
+		test_fetches_per_query_sum 2
+		test_fetches_per_query_count 1
+	`)))
+}
@@ -7,6 +7,7 @@ package stats
 
 import (
 	"context"
+	"sync"
 	"sync/atomic" //lint:ignore faillint we can't use go.uber.org/atomic with a protobuf struct without wrapping it.
 	"time"
 
@@ -15,7 +16,14 @@ import (
 
 type contextKey int
 
-var ctxKey = contextKey(0)
+var (
+	ctxKey                     = contextKey(0)
+	estimatedSeriesCountCtxKey = contextKey(1)
+
+	// rejectionMtx protects the Rejection of all the Stats, which can't hold a lock of their own because
+	// they're generated from the protobuf definition. It's only contended when the queries are rejected.
+	rejectionMtx sync.RWMutex
+)
 
 // ContextWithEmptyStats returns a context with empty stats.
 func ContextWithEmptyStats(ctx context.Context) (*Stats, context.Context) {
@@ -41,6 +49,19 @@ func IsEnabled(ctx context.Context) bool {
 	return FromContext(ctx) != nil
 }
 
+// ContextWithEstimatedSeriesCount returns a context carrying the estimated number of series
+// the query run with it touches.
+func ContextWithEstimatedSeriesCount(ctx context.Context, count uint64) context.Context {
+	return context.WithValue(ctx, estimatedSeriesCountCtxKey, count)
+}
+
+// EstimatedSeriesCountFromContext returns the estimated number of series the query run with
+// the context touches, or 0 if unknown.
+func EstimatedSeriesCountFromContext(ctx context.Context) uint64 {
+	count, _ := ctx.Value(estimatedSeriesCountCtxKey).(uint64)
+	return count
+}
+
 // AddWallTime adds some time to the counter.
 func (s *Stats) AddWallTime(t time.Duration) {
 	if s == nil {
@@ -171,6 +192,105 @@ func (s *Stats) LoadEstimatedSeriesCount() uint64 {
 	return atomic.LoadUint64(&s.EstimatedSeriesCount)
 }
 
+// AddIngesterFetchTime adds some time spent fetching the series from ingesters to the counter.
+func (s *Stats) AddIngesterFetchTime(t time.Duration) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64((*int64)(&s.IngesterFetchTime), int64(t))
+}
+
+// LoadIngesterFetchTime returns the current time spent fetching the series from ingesters.
+func (s *Stats) LoadIngesterFetchTime() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64((*int64)(&s.IngesterFetchTime)))
+}
+
+// AddStoreGatewayFetchTime adds some time spent fetching the series from store-gateways to the counter.
+func (s *Stats) AddStoreGatewayFetchTime(t time.Duration) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64((*int64)(&s.StoreGatewayFetchTime), int64(t))
+}
+
+// LoadStoreGatewayFetchTime returns the current time spent fetching the series from store-gateways.
+func (s *Stats) LoadStoreGatewayFetchTime() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64((*int64)(&s.StoreGatewayFetchTime)))
+}
+
+// AddMergeTime adds some time spent merging the series fetched from ingesters and store-gateways to the counter.
+func (s *Stats) AddMergeTime(t time.Duration) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64((*int64)(&s.MergeTime), int64(t))
+}
+
+// LoadMergeTime returns the current time spent merging the series fetched from ingesters and store-gateways.
+func (s *Stats) LoadMergeTime() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64((*int64)(&s.MergeTime)))
+}
+
+// AddEvalTime adds some time spent evaluating the PromQL expression to the counter.
+func (s *Stats) AddEvalTime(t time.Duration) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64((*int64)(&s.EvalTime), int64(t))
+}
+
+// LoadEvalTime returns the current time spent evaluating the PromQL expression.
+func (s *Stats) LoadEvalTime() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64((*int64)(&s.EvalTime)))
+}
+
+// RecordRejection records the limit which rejected the query. Only the first rejection is kept,
+// because it's the one which caused the query to fail.
+func (s *Stats) RecordRejection(r *Rejection) {
+	if s == nil || r == nil {
+		return
+	}
+
+	rejectionMtx.Lock()
+	defer rejectionMtx.Unlock()
+
+	if s.Rejection == nil {
+		s.Rejection = r
+	}
+}
+
+// LoadRejection returns the limit which rejected the query, or nil if the query hasn't been rejected.
+func (s *Stats) LoadRejection() *Rejection {
+	if s == nil {
+		return nil
+	}
+
+	rejectionMtx.RLock()
+	defer rejectionMtx.RUnlock()
+
+	return s.Rejection
+}
+
 // Merge the provided Stats into this one.
 func (s *Stats) Merge(other *Stats) {
 	if s == nil || other == nil {
@@ -185,6 +305,11 @@ func (s *Stats) Merge(other *Stats) {
 	s.AddSplitQueries(other.LoadSplitQueries())
 	s.AddFetchedIndexBytes(other.LoadFetchedIndexBytes())
 	s.AddEstimatedSeriesCount(other.LoadEstimatedSeriesCount())
+	s.AddIngesterFetchTime(other.LoadIngesterFetchTime())
+	s.AddStoreGatewayFetchTime(other.LoadStoreGatewayFetchTime())
+	s.AddMergeTime(other.LoadMergeTime())
+	s.AddEvalTime(other.LoadEvalTime())
+	s.RecordRejection(other.LoadRejection())
 }
 
 func ShouldTrackHTTPGRPCResponse(r *httpgrpc.HTTPResponse) bool {
@@ -4,6 +4,7 @@
 package stats
 
 import (
+	encoding_binary "encoding/binary"
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
@@ -46,6 +47,16 @@ type Stats struct {
 	FetchedIndexBytes uint64 `protobuf:"varint,7,opt,name=fetched_index_bytes,json=fetchedIndexBytes,proto3" json:"fetched_index_bytes,omitempty"`
 	// The estimated number of series to be fetched for the query
 	EstimatedSeriesCount uint64 `protobuf:"varint,8,opt,name=estimated_series_count,json=estimatedSeriesCount,proto3" json:"estimated_series_count,omitempty"`
+	// The limit which rejected the query, if any.
+	Rejection *Rejection `protobuf:"bytes,9,opt,name=rejection,proto3" json:"rejection,omitempty"`
+	// The sum of the time spent in the querier fetching the series from ingesters.
+	IngesterFetchTime time.Duration `protobuf:"bytes,10,opt,name=ingester_fetch_time,json=ingesterFetchTime,proto3,stdduration" json:"ingester_fetch_time"`
+	// The sum of the time spent in the querier fetching the series from store-gateways.
+	StoreGatewayFetchTime time.Duration `protobuf:"bytes,11,opt,name=store_gateway_fetch_time,json=storeGatewayFetchTime,proto3,stdduration" json:"store_gateway_fetch_time"`
+	// The sum of the time spent in the querier merging the series fetched from ingesters and store-gateways.
+	MergeTime time.Duration `protobuf:"bytes,12,opt,name=merge_time,json=mergeTime,proto3,stdduration" json:"merge_time"`
+	// The sum of the time spent in the querier evaluating the PromQL expression, excluding the time spent fetching the series.
+	EvalTime time.Duration `protobuf:"bytes,13,opt,name=eval_time,json=evalTime,proto3,stdduration" json:"eval_time"`
 }
 
 func (m *Stats) Reset()      { *m = Stats{} }
@@ -136,37 +147,156 @@ func (m *Stats) GetEstimatedSeriesCount() uint64 {
 	return 0
 }
 
+func (m *Stats) GetRejection() *Rejection {
+	if m != nil {
+		return m.Rejection
+	}
+	return nil
+}
+
+func (m *Stats) GetIngesterFetchTime() time.Duration {
+	if m != nil {
+		return m.IngesterFetchTime
+	}
+	return 0
+}
+
+func (m *Stats) GetStoreGatewayFetchTime() time.Duration {
+	if m != nil {
+		return m.StoreGatewayFetchTime
+	}
+	return 0
+}
+
+func (m *Stats) GetMergeTime() time.Duration {
+	if m != nil {
+		return m.MergeTime
+	}
+	return 0
+}
+
+func (m *Stats) GetEvalTime() time.Duration {
+	if m != nil {
+		return m.EvalTime
+	}
+	return 0
+}
+
+// Rejection describes the limit which rejected a query.
+type Rejection struct {
+	// The ID of the limit, as in the error returned to the client (eg. "max-series-per-query").
+	Limit string `protobuf:"bytes,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// The component which enforced the limit.
+	Component string `protobuf:"bytes,2,opt,name=component,proto3" json:"component,omitempty"`
+	// The value measured when the limit was hit.
+	MeasuredValue float64 `protobuf:"fixed64,3,opt,name=measured_value,json=measuredValue,proto3" json:"measured_value,omitempty"`
+	// The configured limit.
+	ConfiguredLimit float64 `protobuf:"fixed64,4,opt,name=configured_limit,json=configuredLimit,proto3" json:"configured_limit,omitempty"`
+}
+
+func (m *Rejection) Reset()      { *m = Rejection{} }
+func (*Rejection) ProtoMessage() {}
+func (*Rejection) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b4756a0aec8b9d44, []int{1}
+}
+func (m *Rejection) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Rejection) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Rejection.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Rejection) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Rejection.Merge(m, src)
+}
+func (m *Rejection) XXX_Size() int {
+	return m.Size()
+}
+func (m *Rejection) XXX_DiscardUnknown() {
+	xxx_messageInfo_Rejection.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Rejection proto.InternalMessageInfo
+
+func (m *Rejection) GetLimit() string {
+	if m != nil {
+		return m.Limit
+	}
+	return ""
+}
+
+func (m *Rejection) GetComponent() string {
+	if m != nil {
+		return m.Component
+	}
+	return ""
+}
+
+func (m *Rejection) GetMeasuredValue() float64 {
+	if m != nil {
+		return m.MeasuredValue
+	}
+	return 0
+}
+
+func (m *Rejection) GetConfiguredLimit() float64 {
+	if m != nil {
+		return m.ConfiguredLimit
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Stats)(nil), "stats.Stats")
+	proto.RegisterType((*Rejection)(nil), "stats.Rejection")
 }
 
 func init() { proto.RegisterFile("stats.proto", fileDescriptor_b4756a0aec8b9d44) }
 
 var fileDescriptor_b4756a0aec8b9d44 = []byte{
-	// 362 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x92, 0x3f, 0x4f, 0xc2, 0x40,
-	0x18, 0xc6, 0x7b, 0xf2, 0x47, 0x3c, 0x44, 0x63, 0x25, 0xa6, 0x32, 0xbc, 0x10, 0x1d, 0x64, 0x2a,
-	0x46, 0xdd, 0x5c, 0x0c, 0xb8, 0x38, 0x0a, 0x4e, 0x2e, 0x4d, 0xa1, 0x47, 0x69, 0x2c, 0x3d, 0xec,
-	0x5d, 0xa3, 0x6e, 0x7e, 0x04, 0xdd, 0xfc, 0x08, 0x7e, 0x14, 0x46, 0x46, 0x26, 0x95, 0x63, 0x71,
-	0xe4, 0x23, 0x98, 0xbb, 0xb6, 0x04, 0xdc, 0x7a, 0xef, 0xef, 0xfd, 0xe5, 0x79, 0x72, 0x3d, 0x5c,
-	0x64, 0xdc, 0xe6, 0xcc, 0x1c, 0x85, 0x94, 0x53, 0x3d, 0xa7, 0x0e, 0x95, 0xb2, 0x4b, 0x5d, 0xaa,
-	0x26, 0x0d, 0xf9, 0x15, 0xc3, 0x0a, 0xb8, 0x94, 0xba, 0x3e, 0x69, 0xa8, 0x53, 0x37, 0xea, 0x37,
-	0x9c, 0x28, 0xb4, 0xb9, 0x47, 0x83, 0x98, 0x1f, 0xbd, 0x67, 0x70, 0xae, 0x23, 0x7d, 0xfd, 0x0a,
-	0x6f, 0x3d, 0xd9, 0xbe, 0x6f, 0x71, 0x6f, 0x48, 0x0c, 0x54, 0x43, 0xf5, 0xe2, 0xd9, 0xa1, 0x19,
-	0xdb, 0x66, 0x6a, 0x9b, 0xd7, 0x89, 0xdd, 0x2c, 0x8c, 0xbf, 0xaa, 0xda, 0xc7, 0x77, 0x15, 0xb5,
-	0x0b, 0xd2, 0xba, 0xf3, 0x86, 0x44, 0x3f, 0xc5, 0xe5, 0x3e, 0xe1, 0xbd, 0x01, 0x71, 0x2c, 0x46,
-	0x42, 0x8f, 0x30, 0xab, 0x47, 0xa3, 0x80, 0x1b, 0x1b, 0x35, 0x54, 0xcf, 0xb6, 0xf5, 0x84, 0x75,
-	0x14, 0x6a, 0x49, 0xa2, 0x9b, 0x78, 0x3f, 0x35, 0x7a, 0x83, 0x28, 0x78, 0xb0, 0xba, 0x2f, 0x9c,
-	0x30, 0x23, 0xa3, 0x84, 0xbd, 0x04, 0xb5, 0x24, 0x69, 0x4a, 0xb0, 0x9a, 0xa0, 0xf6, 0xd3, 0x84,
-	0xec, 0x5a, 0x82, 0x12, 0x92, 0x84, 0x13, 0xbc, 0xcb, 0x06, 0x76, 0xe8, 0x10, 0xc7, 0x7a, 0x8c,
-	0x54, 0xb2, 0x91, 0xab, 0xa1, 0x7a, 0xa9, 0xbd, 0x93, 0x8c, 0x6f, 0xe3, 0xa9, 0x7e, 0x8c, 0x4b,
-	0x6c, 0xe4, 0x7b, 0x7c, 0xb9, 0x96, 0x57, 0x6b, 0xdb, 0x6a, 0x98, 0x2e, 0xad, 0xf4, 0xf5, 0x02,
-	0x87, 0x3c, 0x27, 0x7d, 0x37, 0xd7, 0xfa, 0xde, 0x48, 0x12, 0xf7, 0xbd, 0xc0, 0x07, 0x84, 0x71,
-	0x6f, 0x68, 0xf3, 0xff, 0x77, 0x52, 0x50, 0x4a, 0x79, 0x49, 0x57, 0x6e, 0xa5, 0x79, 0x39, 0x99,
-	0x81, 0x36, 0x9d, 0x81, 0xb6, 0x98, 0x01, 0x7a, 0x15, 0x80, 0x3e, 0x05, 0xa0, 0xb1, 0x00, 0x34,
-	0x11, 0x80, 0x7e, 0x04, 0xa0, 0x5f, 0x01, 0xda, 0x42, 0x00, 0x7a, 0x9b, 0x83, 0x36, 0x99, 0x83,
-	0x36, 0x9d, 0x83, 0x76, 0x1f, 0x3f, 0x83, 0x6e, 0x5e, 0xfd, 0xab, 0xf3, 0xbf, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0xba, 0xe5, 0x86, 0x2e, 0x23, 0x02, 0x00, 0x00,
+	// 541 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xbd, 0x6e, 0xd3, 0x5e,
+	0x14, 0xf7, 0xfd, 0xff, 0x9b, 0x12, 0xdf, 0x34, 0xfd, 0x70, 0x03, 0x32, 0x15, 0xba, 0x8d, 0x8a,
+	0x10, 0x61, 0x71, 0x10, 0xb0, 0xb1, 0x54, 0x29, 0x02, 0x21, 0xb1, 0xe0, 0x20, 0x06, 0x84, 0x64,
+	0x39, 0xf6, 0x89, 0x63, 0xb0, 0x7d, 0x83, 0x7d, 0xdd, 0xd2, 0x8d, 0x47, 0x80, 0x8d, 0x47, 0xe0,
+	0x51, 0x3a, 0x66, 0xec, 0x04, 0xc4, 0x59, 0x18, 0x3b, 0x33, 0xa1, 0x7b, 0xae, 0x9d, 0x0f, 0xa6,
+	0xb0, 0xe5, 0xfe, 0xbe, 0xce, 0xc9, 0x39, 0x47, 0xa6, 0x8d, 0x4c, 0xb8, 0x22, 0xb3, 0xc6, 0x29,
+	0x17, 0xdc, 0xa8, 0xe1, 0xe3, 0xa0, 0x15, 0xf0, 0x80, 0x23, 0xd2, 0x95, 0xbf, 0x14, 0x79, 0xc0,
+	0x02, 0xce, 0x83, 0x08, 0xba, 0xf8, 0x1a, 0xe4, 0xc3, 0xae, 0x9f, 0xa7, 0xae, 0x08, 0x79, 0xa2,
+	0xf8, 0xa3, 0xdf, 0x35, 0x5a, 0xeb, 0x4b, 0xbf, 0x71, 0x4c, 0xf5, 0x33, 0x37, 0x8a, 0x1c, 0x11,
+	0xc6, 0x60, 0x92, 0x36, 0xe9, 0x34, 0x1e, 0xdc, 0xb4, 0x94, 0xdb, 0xaa, 0xdc, 0xd6, 0x93, 0xd2,
+	0xdd, 0xab, 0x5f, 0x7c, 0x3f, 0xd4, 0xbe, 0xfe, 0x38, 0x24, 0x76, 0x5d, 0xba, 0x5e, 0x85, 0x31,
+	0x18, 0xf7, 0x69, 0x6b, 0x08, 0xc2, 0x1b, 0x81, 0xef, 0x64, 0x90, 0x86, 0x90, 0x39, 0x1e, 0xcf,
+	0x13, 0x61, 0xfe, 0xd7, 0x26, 0x9d, 0x0d, 0xdb, 0x28, 0xb9, 0x3e, 0x52, 0x27, 0x92, 0x31, 0x2c,
+	0xba, 0x5f, 0x39, 0xbc, 0x51, 0x9e, 0xbc, 0x77, 0x06, 0xe7, 0x02, 0x32, 0xf3, 0x7f, 0x34, 0xec,
+	0x95, 0xd4, 0x89, 0x64, 0x7a, 0x92, 0x58, 0xae, 0x80, 0xfa, 0xaa, 0xc2, 0xc6, 0x4a, 0x05, 0x34,
+	0x94, 0x15, 0xee, 0xd2, 0x9d, 0x6c, 0xe4, 0xa6, 0x3e, 0xf8, 0xce, 0x87, 0x1c, 0x2b, 0x9b, 0xb5,
+	0x36, 0xe9, 0x34, 0xed, 0xed, 0x12, 0x7e, 0xa9, 0x50, 0xe3, 0x36, 0x6d, 0x66, 0xe3, 0x28, 0x14,
+	0x73, 0xd9, 0x26, 0xca, 0xb6, 0x10, 0xac, 0x44, 0x4b, 0xfd, 0x86, 0x89, 0x0f, 0x1f, 0xcb, 0x7e,
+	0xaf, 0xad, 0xf4, 0xfb, 0x5c, 0x32, 0xaa, 0xdf, 0x47, 0xf4, 0x06, 0x64, 0x22, 0x8c, 0x5d, 0xf1,
+	0xf7, 0x4c, 0xea, 0x68, 0x69, 0xcd, 0xd9, 0xd5, 0xa9, 0xe8, 0x29, 0xbc, 0x03, 0x4f, 0x0e, 0xda,
+	0xd4, 0x71, 0x13, 0xbb, 0x96, 0xda, 0xb8, 0x5d, 0xe1, 0xf6, 0x42, 0x62, 0xf4, 0xe9, 0x7e, 0x98,
+	0x04, 0x90, 0x09, 0x48, 0x1d, 0xec, 0x41, 0xed, 0x90, 0xae, 0xbf, 0xc3, 0xbd, 0xca, 0xff, 0x54,
+	0xda, 0x71, 0x99, 0x6f, 0xa9, 0x99, 0x09, 0x9e, 0x82, 0x13, 0xb8, 0x02, 0xce, 0xdc, 0xf3, 0xe5,
+	0xe4, 0xc6, 0xfa, 0xc9, 0xd7, 0x31, 0xe4, 0x99, 0xca, 0x58, 0xa4, 0xf7, 0x28, 0x8d, 0x21, 0x0d,
+	0x40, 0xe5, 0x6d, 0xad, 0x9f, 0xa7, 0xa3, 0x0d, 0x33, 0x8e, 0xa9, 0x0e, 0xa7, 0x6e, 0x79, 0xb0,
+	0xcd, 0x7f, 0x38, 0x58, 0xe9, 0x92, 0x09, 0x47, 0x5f, 0x08, 0xd5, 0xe7, 0x13, 0x35, 0x5a, 0xb4,
+	0x16, 0x85, 0x71, 0x28, 0xf0, 0xf8, 0x75, 0x5b, 0x3d, 0x8c, 0x5b, 0x54, 0xf7, 0x78, 0x3c, 0xe6,
+	0x09, 0x94, 0x97, 0xac, 0xdb, 0x0b, 0xc0, 0xb8, 0x43, 0xb7, 0x63, 0x70, 0xb3, 0x3c, 0x05, 0xdf,
+	0x39, 0x75, 0xa3, 0x1c, 0xf0, 0x76, 0x89, 0xdd, 0xac, 0xd0, 0xd7, 0x12, 0x34, 0xee, 0xd1, 0x5d,
+	0x8f, 0x27, 0xc3, 0x30, 0x40, 0xa1, 0xaa, 0xb2, 0x81, 0xc2, 0x9d, 0x05, 0xfe, 0x42, 0xc2, 0xbd,
+	0xc7, 0x93, 0x29, 0xd3, 0x2e, 0xa7, 0x4c, 0xbb, 0x9a, 0x32, 0xf2, 0xa9, 0x60, 0xe4, 0x5b, 0xc1,
+	0xc8, 0x45, 0xc1, 0xc8, 0xa4, 0x60, 0xe4, 0x67, 0xc1, 0xc8, 0xaf, 0x82, 0x69, 0x57, 0x05, 0x23,
+	0x9f, 0x67, 0x4c, 0x9b, 0xcc, 0x98, 0x76, 0x39, 0x63, 0xda, 0x1b, 0xf5, 0x0d, 0x18, 0x6c, 0xe2,
+	0xff, 0x7e, 0xf8, 0x67, 0x00, 0x5c, 0x49, 0xa5, 0x0e, 0x20, 0x04, 0x00, 0x00,
 }
 
 func (this *Stats) Equal(that interface{}) bool {
@@ -212,13 +342,61 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.EstimatedSeriesCount != that1.EstimatedSeriesCount {
 		return false
 	}
+	if !this.Rejection.Equal(that1.Rejection) {
+		return false
+	}
+	if this.IngesterFetchTime != that1.IngesterFetchTime {
+		return false
+	}
+	if this.StoreGatewayFetchTime != that1.StoreGatewayFetchTime {
+		return false
+	}
+	if this.MergeTime != that1.MergeTime {
+		return false
+	}
+	if this.EvalTime != that1.EvalTime {
+		return false
+	}
+	return true
+}
+func (this *Rejection) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Rejection)
+	if !ok {
+		that2, ok := that.(Rejection)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if this.Component != that1.Component {
+		return false
+	}
+	if this.MeasuredValue != that1.MeasuredValue {
+		return false
+	}
+	if this.ConfiguredLimit != that1.ConfiguredLimit {
+		return false
+	}
 	return true
 }
 func (this *Stats) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 12)
+	s := make([]string, 0, 17)
 	s = append(s, "&stats.Stats{")
 	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
 	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
@@ -228,6 +406,26 @@ func (this *Stats) GoString() string {
 	s = append(s, "SplitQueries: "+fmt.Sprintf("%#v", this.SplitQueries)+",\n")
 	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
 	s = append(s, "EstimatedSeriesCount: "+fmt.Sprintf("%#v", this.EstimatedSeriesCount)+",\n")
+	if this.Rejection != nil {
+		s = append(s, "Rejection: "+fmt.Sprintf("%#v", this.Rejection)+",\n")
+	}
+	s = append(s, "IngesterFetchTime: "+fmt.Sprintf("%#v", this.IngesterFetchTime)+",\n")
+	s = append(s, "StoreGatewayFetchTime: "+fmt.Sprintf("%#v", this.StoreGatewayFetchTime)+",\n")
+	s = append(s, "MergeTime: "+fmt.Sprintf("%#v", this.MergeTime)+",\n")
+	s = append(s, "EvalTime: "+fmt.Sprintf("%#v", this.EvalTime)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Rejection) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&stats.Rejection{")
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "Component: "+fmt.Sprintf("%#v", this.Component)+",\n")
+	s = append(s, "MeasuredValue: "+fmt.Sprintf("%#v", this.MeasuredValue)+",\n")
+	s = append(s, "ConfiguredLimit: "+fmt.Sprintf("%#v", this.ConfiguredLimit)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -259,6 +457,50 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvalTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvalTime):])
+	if err1 != nil {
+		return 0, err1
+	}
+	i -= n1
+	i = encodeVarintStats(dAtA, i, uint64(n1))
+	i--
+	dAtA[i] = 0x6a
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.MergeTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.MergeTime):])
+	if err2 != nil {
+		return 0, err2
+	}
+	i -= n2
+	i = encodeVarintStats(dAtA, i, uint64(n2))
+	i--
+	dAtA[i] = 0x62
+	n3, err3 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.StoreGatewayFetchTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.StoreGatewayFetchTime):])
+	if err3 != nil {
+		return 0, err3
+	}
+	i -= n3
+	i = encodeVarintStats(dAtA, i, uint64(n3))
+	i--
+	dAtA[i] = 0x5a
+	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.IngesterFetchTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.IngesterFetchTime):])
+	if err4 != nil {
+		return 0, err4
+	}
+	i -= n4
+	i = encodeVarintStats(dAtA, i, uint64(n4))
+	i--
+	dAtA[i] = 0x52
+	if m.Rejection != nil {
+		{
+			size, err := m.Rejection.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintStats(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4a
+	}
 	if m.EstimatedSeriesCount != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.EstimatedSeriesCount))
 		i--
@@ -294,17 +536,66 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x10
 	}
-	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.WallTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.WallTime):])
-	if err1 != nil {
-		return 0, err1
+	n6, err6 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.WallTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.WallTime):])
+	if err6 != nil {
+		return 0, err6
 	}
-	i -= n1
-	i = encodeVarintStats(dAtA, i, uint64(n1))
+	i -= n6
+	i = encodeVarintStats(dAtA, i, uint64(n6))
 	i--
 	dAtA[i] = 0xa
 	return len(dAtA) - i, nil
 }
 
+func (m *Rejection) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Rejection) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Rejection) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.ConfiguredLimit != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ConfiguredLimit))))
+		i--
+		dAtA[i] = 0x21
+	}
+	if m.MeasuredValue != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.MeasuredValue))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if len(m.Component) > 0 {
+		i -= len(m.Component)
+		copy(dAtA[i:], m.Component)
+		i = encodeVarintStats(dAtA, i, uint64(len(m.Component)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Limit) > 0 {
+		i -= len(m.Limit)
+		copy(dAtA[i:], m.Limit)
+		i = encodeVarintStats(dAtA, i, uint64(len(m.Limit)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintStats(dAtA []byte, offset int, v uint64) int {
 	offset -= sovStats(v)
 	base := offset
@@ -345,6 +636,41 @@ func (m *Stats) Size() (n int) {
 	if m.EstimatedSeriesCount != 0 {
 		n += 1 + sovStats(uint64(m.EstimatedSeriesCount))
 	}
+	if m.Rejection != nil {
+		l = m.Rejection.Size()
+		n += 1 + l + sovStats(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.IngesterFetchTime)
+	n += 1 + l + sovStats(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.StoreGatewayFetchTime)
+	n += 1 + l + sovStats(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.MergeTime)
+	n += 1 + l + sovStats(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvalTime)
+	n += 1 + l + sovStats(uint64(l))
+	return n
+}
+
+func (m *Rejection) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Limit)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
+	l = len(m.Component)
+	if l > 0 {
+		n += 1 + l + sovStats(uint64(l))
+	}
+	if m.MeasuredValue != 0 {
+		n += 9
+	}
+	if m.ConfiguredLimit != 0 {
+		n += 9
+	}
 	return n
 }
 
@@ -367,6 +693,24 @@ func (this *Stats) String() string {
 		`SplitQueries:` + fmt.Sprintf("%v", this.SplitQueries) + `,`,
 		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
 		`EstimatedSeriesCount:` + fmt.Sprintf("%v", this.EstimatedSeriesCount) + `,`,
+		`Rejection:` + strings.Replace(this.Rejection.String(), "Rejection", "Rejection", 1) + `,`,
+		`IngesterFetchTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.IngesterFetchTime), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`StoreGatewayFetchTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StoreGatewayFetchTime), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`MergeTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.MergeTime), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`EvalTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvalTime), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Rejection) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Rejection{`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`Component:` + fmt.Sprintf("%v", this.Component) + `,`,
+		`MeasuredValue:` + fmt.Sprintf("%v", this.MeasuredValue) + `,`,
+		`ConfiguredLimit:` + fmt.Sprintf("%v", this.ConfiguredLimit) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -574,6 +918,313 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rejection", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Rejection == nil {
+				m.Rejection = &Rejection{}
+			}
+			if err := m.Rejection.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IngesterFetchTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.IngesterFetchTime, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreGatewayFetchTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.StoreGatewayFetchTime, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MergeTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.MergeTime, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EvalTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.EvalTime, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Rejection) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Rejection: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Rejection: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Limit = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Component", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Component = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MeasuredValue", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.MeasuredValue = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConfiguredLimit", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ConfiguredLimit = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -601,6 +1252,7 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 func skipStats(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
+	depth := 0
 	for iNdEx < l {
 		var wire uint64
 		for shift := uint(0); ; shift += 7 {
@@ -632,10 +1284,8 @@ func skipStats(dAtA []byte) (n int, err error) {
 					break
 				}
 			}
-			return iNdEx, nil
 		case 1:
 			iNdEx += 8
-			return iNdEx, nil
 		case 2:
 			var length int
 			for shift := uint(0); ; shift += 7 {
@@ -656,55 +1306,30 @@ func skipStats(dAtA []byte) (n int, err error) {
 				return 0, ErrInvalidLengthStats
 			}
 			iNdEx += length
-			if iNdEx < 0 {
-				return 0, ErrInvalidLengthStats
-			}
-			return iNdEx, nil
 		case 3:
-			for {
-				var innerWire uint64
-				var start int = iNdEx
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return 0, ErrIntOverflowStats
-					}
-					if iNdEx >= l {
-						return 0, io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					innerWire |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				innerWireType := int(innerWire & 0x7)
-				if innerWireType == 4 {
-					break
-				}
-				next, err := skipStats(dAtA[start:])
-				if err != nil {
-					return 0, err
-				}
-				iNdEx = start + next
-				if iNdEx < 0 {
-					return 0, ErrInvalidLengthStats
-				}
-			}
-			return iNdEx, nil
+			depth++
 		case 4:
-			return iNdEx, nil
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupStats
+			}
+			depth--
 		case 5:
 			iNdEx += 4
-			return iNdEx, nil
 		default:
 			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
 		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthStats
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
 	}
-	panic("unreachable")
+	return 0, io.ErrUnexpectedEOF
 }
 
 var (
-	ErrInvalidLengthStats = fmt.Errorf("proto: negative length found during unmarshaling")
-	ErrIntOverflowStats   = fmt.Errorf("proto: integer overflow")
+	ErrInvalidLengthStats        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowStats          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupStats = fmt.Errorf("proto: unexpected end of group")
 )
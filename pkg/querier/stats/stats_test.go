@@ -7,6 +7,7 @@ package stats
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -115,6 +116,72 @@ func TestStats_AddSplitQueries(t *testing.T) {
 	})
 }
 
+func TestStats_StageTimes(t *testing.T) {
+	t.Run("add and load stage times", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddIngesterFetchTime(time.Second)
+		stats.AddIngesterFetchTime(time.Second)
+		stats.AddStoreGatewayFetchTime(3 * time.Second)
+		stats.AddMergeTime(4 * time.Second)
+		stats.AddEvalTime(5 * time.Second)
+
+		assert.Equal(t, 2*time.Second, stats.LoadIngesterFetchTime())
+		assert.Equal(t, 3*time.Second, stats.LoadStoreGatewayFetchTime())
+		assert.Equal(t, 4*time.Second, stats.LoadMergeTime())
+		assert.Equal(t, 5*time.Second, stats.LoadEvalTime())
+	})
+
+	t.Run("add and load stage times nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.AddIngesterFetchTime(time.Second)
+		stats.AddStoreGatewayFetchTime(time.Second)
+		stats.AddMergeTime(time.Second)
+		stats.AddEvalTime(time.Second)
+
+		assert.Equal(t, time.Duration(0), stats.LoadIngesterFetchTime())
+		assert.Equal(t, time.Duration(0), stats.LoadStoreGatewayFetchTime())
+		assert.Equal(t, time.Duration(0), stats.LoadMergeTime())
+		assert.Equal(t, time.Duration(0), stats.LoadEvalTime())
+	})
+}
+
+func TestStats_RecordRejection(t *testing.T) {
+	t.Run("record and load the first rejection", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		assert.Nil(t, stats.LoadRejection())
+
+		first := &Rejection{Limit: "max-series-per-query", Component: "querier", MeasuredValue: 11, ConfiguredLimit: 10}
+		stats.RecordRejection(first)
+		stats.RecordRejection(&Rejection{Limit: "max-chunks-per-query", Component: "querier", MeasuredValue: 101, ConfiguredLimit: 100})
+
+		assert.Equal(t, first, stats.LoadRejection())
+	})
+
+	t.Run("record and load the rejections concurrently", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				stats.RecordRejection(&Rejection{Limit: "max-series-per-query", MeasuredValue: float64(i)})
+				assert.NotNil(t, stats.LoadRejection())
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, "max-series-per-query", stats.LoadRejection().Limit)
+	})
+
+	t.Run("record and load the rejection nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.RecordRejection(&Rejection{Limit: "max-series-per-query"})
+
+		assert.Nil(t, stats.LoadRejection())
+	})
+}
+
 func TestStats_Merge(t *testing.T) {
 	t.Run("merge two stats objects", func(t *testing.T) {
 		stats1 := &Stats{}
@@ -124,14 +191,20 @@ func TestStats_Merge(t *testing.T) {
 		stats1.AddFetchedChunks(10)
 		stats1.AddShardedQueries(20)
 		stats1.AddSplitQueries(10)
+		stats1.AddIngesterFetchTime(time.Millisecond)
+		stats1.AddEvalTime(time.Millisecond)
 
 		stats2 := &Stats{}
+		stats2.RecordRejection(&Rejection{Limit: "max-series-per-query", Component: "querier", MeasuredValue: 11, ConfiguredLimit: 10})
 		stats2.AddWallTime(time.Second)
 		stats2.AddFetchedSeries(60)
 		stats2.AddFetchedChunkBytes(100)
 		stats2.AddFetchedChunks(11)
 		stats2.AddShardedQueries(21)
 		stats2.AddSplitQueries(11)
+		stats2.AddIngesterFetchTime(time.Second)
+		stats2.AddStoreGatewayFetchTime(time.Second)
+		stats2.AddMergeTime(time.Second)
 
 		stats1.Merge(stats2)
 
@@ -141,6 +214,11 @@ func TestStats_Merge(t *testing.T) {
 		assert.Equal(t, uint64(21), stats1.LoadFetchedChunks())
 		assert.Equal(t, uint32(41), stats1.LoadShardedQueries())
 		assert.Equal(t, uint32(21), stats1.LoadSplitQueries())
+		assert.Equal(t, 1001*time.Millisecond, stats1.LoadIngesterFetchTime())
+		assert.Equal(t, time.Second, stats1.LoadStoreGatewayFetchTime())
+		assert.Equal(t, time.Second, stats1.LoadMergeTime())
+		assert.Equal(t, time.Millisecond, stats1.LoadEvalTime())
+		assert.Equal(t, &Rejection{Limit: "max-series-per-query", Component: "querier", MeasuredValue: 11, ConfiguredLimit: 10}, stats1.LoadRejection())
 	})
 
 	t.Run("merge two nil stats objects", func(t *testing.T) {
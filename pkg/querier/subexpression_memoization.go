@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/series"
+)
+
+type subexpressionMemoizationContextKey int
+
+const subexpressionMemoizationKey subexpressionMemoizationContextKey = 0
+
+// memoizedSubexpressionLabel is the label of the selectors replacing the memoized subexpressions in the query,
+// whose value is the ID of the subexpression.
+const memoizedSubexpressionLabel = "__memoized_subexpression__"
+
+// subexpressionMemoization memoizes the results of the identical subexpressions of a single query, like
+// the aggregations, function calls and binary operations over the same selectors, so that they're only
+// evaluated once. The identical subexpressions are replaced in the query by placeholder selectors, whose
+// series are the results of the subexpressions evaluated on their own, up to a max size per query.
+// The subexpressions within subqueries aren't memoized, because they're evaluated at different steps.
+type subexpressionMemoization struct {
+	memoizedEntries
+
+	queryable storage.Queryable
+	engine    *promql.Engine
+	opts      *promql.QueryOpts
+	hits      prometheus.Counter
+
+	// The time range of the query, with a 0 interval for instant queries.
+	start, end time.Time
+	interval   time.Duration
+
+	// The subexpressions replaced by the placeholder selectors, by ID.
+	subexpressions map[string]string
+}
+
+// newSubexpressionMemoization returns the memoization of the identical subexpressions of the input query, and
+// the query with the subexpressions replaced by the placeholder selectors. It returns nil and the input query
+// if no subexpression appears more than once in the query.
+func newSubexpressionMemoization(qs string, maxBytes uint64, hits prometheus.Counter) (*subexpressionMemoization, string) {
+	// The query is parsed again, so that the statement of the query isn't modified.
+	expr, err := parser.ParseExpr(qs)
+	if err != nil {
+		return nil, qs
+	}
+
+	counts := map[string]int{}
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		if isMemoizableSubexpression(node, path) {
+			counts[node.String()]++
+		}
+		return nil
+	})
+
+	m := &subexpressionMemoization{
+		memoizedEntries: memoizedEntries{maxBytes: maxBytes, entries: map[string]*memoizedSelect{}},
+		hits:            hits,
+		subexpressions:  map[string]string{},
+	}
+	ids := map[string]string{}
+
+	var rewrite func(expr parser.Expr) parser.Expr
+	rewrite = func(expr parser.Expr) parser.Expr {
+		// Only the outermost identical subexpressions are replaced.
+		if s := expr.String(); counts[s] > 1 {
+			id, ok := ids[s]
+			if !ok {
+				id = strconv.Itoa(len(ids))
+				ids[s] = id
+				m.subexpressions[id] = s
+			}
+			return &parser.VectorSelector{
+				LabelMatchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, memoizedSubexpressionLabel, id)},
+				PosRange:      expr.PositionRange(),
+			}
+		}
+
+		switch n := expr.(type) {
+		case *parser.AggregateExpr:
+			n.Expr = rewrite(n.Expr)
+			if n.Param != nil {
+				n.Param = rewrite(n.Param)
+			}
+		case *parser.BinaryExpr:
+			n.LHS = rewrite(n.LHS)
+			n.RHS = rewrite(n.RHS)
+		case *parser.Call:
+			for i, arg := range n.Args {
+				n.Args[i] = rewrite(arg)
+			}
+		case *parser.ParenExpr:
+			n.Expr = rewrite(n.Expr)
+		case *parser.UnaryExpr:
+			n.Expr = rewrite(n.Expr)
+		}
+		return expr
+	}
+
+	rewritten := rewrite(expr)
+	if len(ids) == 0 {
+		return nil, qs
+	}
+	return m, rewritten.String()
+}
+
+// isMemoizableSubexpression returns whether the input node is a subexpression returning an instant vector
+// whose evaluation can be memoized.
+func isMemoizableSubexpression(node parser.Node, path []parser.Node) bool {
+	switch n := node.(type) {
+	case *parser.AggregateExpr, *parser.BinaryExpr, *parser.Call:
+		if n.(parser.Expr).Type() != parser.ValueTypeVector {
+			return false
+		}
+	default:
+		return false
+	}
+
+	for _, p := range path {
+		if _, ok := p.(*parser.SubqueryExpr); ok {
+			return false
+		}
+	}
+
+	// The subexpressions not selecting any series are cheap to evaluate again.
+	hasSelector := false
+	parser.Inspect(node, func(node parser.Node, _ []parser.Node) error {
+		switch node.(type) {
+		case *parser.VectorSelector, *parser.MatrixSelector:
+			hasSelector = true
+		}
+		return nil
+	})
+	return hasSelector
+}
+
+func contextWithSubexpressionMemoization(ctx context.Context, m *subexpressionMemoization) context.Context {
+	return context.WithValue(ctx, subexpressionMemoizationKey, m)
+}
+
+func subexpressionMemoizationFromContext(ctx context.Context) *subexpressionMemoization {
+	m, _ := ctx.Value(subexpressionMemoizationKey).(*subexpressionMemoization)
+	return m
+}
+
+// memoizedSubexpressionID returns the ID of the memoized subexpression, if the input matchers are the
+// ones of a placeholder selector.
+func memoizedSubexpressionID(matchers []*labels.Matcher) (string, bool) {
+	if len(matchers) != 1 || matchers[0].Name != memoizedSubexpressionLabel || matchers[0].Type != labels.MatchEqual {
+		return "", false
+	}
+	return matchers[0].Value, true
+}
+
+// selectSubexpression returns the result of the subexpression with the input ID as a series set, evaluating
+// the subexpression with the Prometheus engine unless it has been memoized.
+func (m *subexpressionMemoization) selectSubexpression(ctx context.Context, id string) storage.SeriesSet {
+	if entry := m.get(id); entry != nil {
+		m.hits.Inc()
+		return entry.seriesSet()
+	}
+
+	qs, ok := m.subexpressions[id]
+	if !ok {
+		return storage.ErrSeriesSet(fmt.Errorf("unknown memoized subexpression %s", id))
+	}
+
+	var (
+		query promql.Query
+		err   error
+	)
+	if m.interval == 0 {
+		query, err = m.engine.NewInstantQuery(m.queryable, m.opts, qs, m.start)
+	} else {
+		query, err = m.engine.NewRangeQuery(m.queryable, m.opts, qs, m.start, m.end, m.interval)
+	}
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	defer query.Close()
+
+	res := trackPrometheusEngineMemoryConsumption(ctx, query, query.Exec(ctx))
+	if res.Err != nil {
+		return storage.ErrSeriesSet(res.Err)
+	}
+
+	var matrix promql.Matrix
+	switch v := res.Value.(type) {
+	case promql.Matrix:
+		matrix = v
+	case promql.Vector:
+		for _, s := range v {
+			matrix = append(matrix, promql.Series{Metric: s.Metric, Points: []promql.Point{s.Point}})
+		}
+	default:
+		return storage.ErrSeriesSet(fmt.Errorf("unexpected result type %s of the memoized subexpression %s", res.Value.Type(), qs))
+	}
+
+	memoized, size := subexpressionSeries(matrix, m.interval.Milliseconds(), timestamp.FromTime(m.end))
+	entry := &memoizedSelect{series: memoized, warnings: res.Warnings}
+
+	// The result is returned without being memoized if it doesn't fit in the space left.
+	if m.reserve(size) {
+		m.put(id, entry)
+	}
+	return entry.seriesSet()
+}
+
+// subexpressionSeries returns the series of the input subexpression result, and their estimated size in bytes.
+// A stale marker is added at the first step missing after each point of a range query result, so that
+// the points aren't looked back at from the steps which have no value in the result.
+func subexpressionSeries(matrix promql.Matrix, interval, end int64) ([]storage.Series, uint64) {
+	var size uint64
+	result := make([]storage.Series, 0, len(matrix))
+
+	for _, s := range matrix {
+		var (
+			samples    []model.SamplePair
+			histograms []mimirpb.Histogram
+		)
+
+		s.Metric.Range(func(l labels.Label) {
+			size += uint64(len(l.Name) + len(l.Value))
+		})
+
+		for i, p := range s.Points {
+			if p.H != nil {
+				histograms = append(histograms, mimirpb.FromFloatHistogramToHistogramProto(p.T, p.H))
+				size += uint64(histograms[len(histograms)-1].Size())
+			} else {
+				samples = append(samples, model.SamplePair{Timestamp: model.Time(p.T), Value: model.SampleValue(p.V)})
+				size += 16
+			}
+
+			if interval == 0 {
+				continue
+			}
+			if next := p.T + interval; next <= end && (i == len(s.Points)-1 || s.Points[i+1].T > next) {
+				samples = append(samples, model.SamplePair{Timestamp: model.Time(next), Value: model.SampleValue(math.Float64frombits(value.StaleNaN))})
+				size += 16
+			}
+		}
+
+		result = append(result, series.NewConcreteSeries(s.Metric, samples, histograms))
+	}
+
+	return result, size
+}
@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/querier/engine"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestPerTenantEngine_SubexpressionMemoization(t *testing.T) {
+	test, err := promql.NewTest(t, `
+		load 1m
+			some_metric{idx="1", group="a"} 0+1x10
+			some_metric{idx="2", group="a"} 0+2x10
+			gappy_metric{idx="1"} 1 2 _ _ _ _ _ _ 3 4 5
+	`)
+	require.NoError(t, err)
+	t.Cleanup(test.Close)
+	require.NoError(t, test.Run())
+
+	overrides, err := validation.NewOverrides(defaultLimitsConfig(), validation.NewMockTenantLimits(map[string]*validation.Limits{
+		"streaming": func() *validation.Limits {
+			l := defaultLimitsConfig()
+			l.QueryEngine = validation.QueryEngineStreaming
+			return &l
+		}(),
+	}))
+	require.NoError(t, err)
+
+	// The result of sum(gappy_metric) has no value in the middle of the range, which must not be looked back at.
+	const query = `sum(rate(some_metric[5m])) + sum(rate(some_metric[5m])) + sum(gappy_metric) / sum(gappy_metric)`
+	start, end, step := time.Unix(0, 0), time.Unix(0, 0).Add(10*time.Minute), time.Minute
+
+	tests := map[string]struct {
+		tenantID         string
+		instant          bool
+		maxMemoizedBytes int
+		expectedSelects  int64
+		expectedHits     float64
+	}{
+		"disabled": {
+			tenantID:        "prometheus",
+			expectedSelects: 4,
+		},
+		"Prometheus engine": {
+			tenantID:         "prometheus",
+			maxMemoizedBytes: 1024 * 1024,
+			expectedSelects:  2,
+			expectedHits:     2,
+		},
+		"streaming engine": {
+			tenantID:         "streaming",
+			maxMemoizedBytes: 1024 * 1024,
+			expectedSelects:  2,
+			expectedHits:     2,
+		},
+		"instant query": {
+			tenantID:         "prometheus",
+			instant:          true,
+			maxMemoizedBytes: 1024 * 1024,
+			expectedSelects:  2,
+			expectedHits:     2,
+		},
+		"results not fitting in the max size": {
+			tenantID:         "prometheus",
+			maxMemoizedBytes: 10,
+			expectedSelects:  4,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			engineCfg := engine.Config{}
+			flagext.DefaultValues(&engineCfg)
+			engineCfg.MaxMemoizedSubexpressionBytesPerQuery = testData.maxMemoizedBytes
+
+			prometheusEngine := promql.NewEngine(engine.NewPromQLEngineOptions(engineCfg, nil, log.NewNopLogger(), nil))
+			e := NewPerTenantEngine(engineCfg, prometheusEngine, overrides, nil, nil, log.NewNopLogger(), nil)
+			ctx := user.InjectOrgID(context.Background(), testData.tenantID)
+
+			newQuery := func(queryable storage.Queryable, newInstantQuery func(storage.Queryable, *promql.QueryOpts, string, time.Time) (promql.Query, error), newRangeQuery func(storage.Queryable, *promql.QueryOpts, string, time.Time, time.Time, time.Duration) (promql.Query, error)) promql.Query {
+				var (
+					q   promql.Query
+					err error
+				)
+				if testData.instant {
+					q, err = newInstantQuery(queryable, nil, query, end)
+				} else {
+					q, err = newRangeQuery(queryable, nil, query, start, end, step)
+				}
+				require.NoError(t, err)
+				return q
+			}
+
+			expected := newQuery(test.Queryable(), prometheusEngine.NewInstantQuery, prometheusEngine.NewRangeQuery)
+			expectedRes := expected.Exec(ctx)
+			require.NoError(t, expectedRes.Err)
+
+			selects := atomic.NewInt64(0)
+			queryable := storage.QueryableFunc(func(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
+				q, err := test.Queryable().Querier(ctx, mint, maxt)
+				if err != nil {
+					return nil, err
+				}
+				return &selectCountingQuerier{Querier: q, selects: selects}, nil
+			})
+
+			q := newQuery(queryable, e.NewInstantQuery, e.NewRangeQuery)
+			res := q.Exec(ctx)
+			require.NoError(t, res.Err)
+			require.Equal(t, expectedRes.Value, res.Value)
+			assert.Equal(t, query, q.String())
+			assert.Equal(t, expected.Statement().String(), q.Statement().String())
+			q.Close()
+
+			assert.Equal(t, testData.expectedSelects, selects.Load())
+			assert.Equal(t, testData.expectedHits, testutil.ToFloat64(e.subexpressionMemoizationHits))
+		})
+	}
+}
+
+func TestNewSubexpressionMemoization(t *testing.T) {
+	for query, expected := range map[string]struct {
+		rewritten      string
+		subexpressions map[string]string
+	}{
+		`sum(rate(some_metric[5m]))`: {},
+		`sum(rate(some_metric[5m])) / sum(rate(some_metric[5m]))`: {
+			rewritten:      `{__memoized_subexpression__="0"} / {__memoized_subexpression__="0"}`,
+			subexpressions: map[string]string{"0": `sum(rate(some_metric[5m]))`},
+		},
+		`rate(some_metric[5m]) / rate(some_metric[5m] offset 1h)`: {},
+		`(sum(some_metric) + 1) / (sum(some_metric) + 1) > bool sum(some_metric)`: {
+			rewritten:      `({__memoized_subexpression__="0"}) / ({__memoized_subexpression__="0"}) > bool {__memoized_subexpression__="1"}`,
+			subexpressions: map[string]string{"0": `sum(some_metric) + 1`, "1": `sum(some_metric)`},
+		},
+		`max_over_time(sum(some_metric)[1h:]) / max_over_time(sum(some_metric)[1h:])`: {
+			rewritten:      `{__memoized_subexpression__="0"} / {__memoized_subexpression__="0"}`,
+			subexpressions: map[string]string{"0": `max_over_time(sum(some_metric)[1h:])`},
+		},
+		`max_over_time(sum(some_metric)[1h:]) / sum(some_metric)`: {},
+		`vector(1) + vector(1)`:                                   {},
+		`scalar(sum(some_metric)) * scalar(sum(some_metric))`: {
+			rewritten:      `scalar({__memoized_subexpression__="0"}) * scalar({__memoized_subexpression__="0"})`,
+			subexpressions: map[string]string{"0": `sum(some_metric)`},
+		},
+	} {
+		t.Run(query, func(t *testing.T) {
+			m, rewritten := newSubexpressionMemoization(query, 100, nil)
+			if expected.rewritten == "" {
+				require.Nil(t, m)
+				assert.Equal(t, query, rewritten)
+				return
+			}
+
+			require.NotNil(t, m)
+			assert.Equal(t, expected.rewritten, rewritten)
+			assert.Equal(t, expected.subexpressions, m.subexpressions)
+		})
+	}
+}
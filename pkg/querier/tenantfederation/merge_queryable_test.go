@@ -88,7 +88,7 @@ func (m *mockTenantQueryableWithFilter) Querier(ctx context.Context, _, _ int64)
 
 // UseQueryable implements the querier.QueryableWithFilter interface.
 // It ensures the mockTenantQueryableWithFilter storage.Queryable is always used.
-func (m *mockTenantQueryableWithFilter) UseQueryable(_ time.Time, _, _ int64) bool {
+func (m *mockTenantQueryableWithFilter) UseQueryable(_ time.Time, _ string, _, _ int64) bool {
 	return true
 }
 
@@ -0,0 +1,340 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/util"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+const (
+	timeRangeRoutingIngesters     = "ingesters"
+	timeRangeRoutingStoreGateways = "store-gateways"
+
+	// timeRangeRoutingCostDecisionInterval is how often the cost-based time range routing re-evaluates
+	// which component the overlapping time range is queried from.
+	timeRangeRoutingCostDecisionInterval = time.Minute
+
+	// timeRangeRoutingCostDecisionDelay is the delay after which a new decision of the cost-based time range
+	// routing applies to the queries: the queries started before keep using the previous decision, so that
+	// the ingesters and the store-gateways routing of each query is decided on the same decision.
+	timeRangeRoutingCostDecisionDelay = 10 * time.Second
+
+	// timeRangeRoutingCostHysteresis is how much cheaper a component must be estimated, relative to
+	// the currently preferred one, to query the overlapping time range from it.
+	timeRangeRoutingCostHysteresis = 0.1
+
+	// timeRangeRoutingCostEWMAWeight is the weight of each query in the moving average of the cost.
+	timeRangeRoutingCostEWMAWeight = 0.05
+)
+
+// TimeRangeRoutingLimits is the interface that should be implemented by the limits provider of the
+// time range routing between ingesters and store-gateways.
+type TimeRangeRoutingLimits interface {
+	QueryIngestersWithin(userID string) time.Duration
+	QueryStoreAfter(userID string) time.Duration
+	StrictTimeRangeRoutingEnabled(userID string) bool
+	CostBasedTimeRangeRoutingEnabled(userID string) bool
+	TimeRangeRoutingOverlapMargin(userID string) time.Duration
+}
+
+// timeRangeRouter resolves the time range routing between ingesters and store-gateways of each tenant,
+// using the querier's boundaries unless they're overridden for the tenant.
+type timeRangeRouter struct {
+	queryIngestersWithin time.Duration
+	queryStoreAfter      time.Duration
+
+	// Optional: if nil, the querier's boundaries are used for all tenants.
+	limits TimeRangeRoutingLimits
+
+	// Optional: if nil, the cost-based routing is disabled for all tenants.
+	costs *timeRangeRoutingCosts
+}
+
+func newTimeRangeRouter(queryIngestersWithin, queryStoreAfter time.Duration, limits TimeRangeRoutingLimits, costs *timeRangeRoutingCosts) timeRangeRouter {
+	return timeRangeRouter{
+		queryIngestersWithin: queryIngestersWithin,
+		queryStoreAfter:      queryStoreAfter,
+		limits:               limits,
+		costs:                costs,
+	}
+}
+
+// forTenant returns the time range routing of the tenant for the query started at the input time.
+func (r timeRangeRouter) forTenant(now time.Time, userID string) timeRangeRouting {
+	routing := timeRangeRouting{
+		queryIngestersWithin: r.queryIngestersWithin,
+		queryStoreAfter:      r.queryStoreAfter,
+	}
+	if r.limits == nil {
+		return routing
+	}
+
+	if d := r.limits.QueryIngestersWithin(userID); d > 0 {
+		routing.queryIngestersWithin = d
+	}
+	if d := r.limits.QueryStoreAfter(userID); d > 0 {
+		routing.queryStoreAfter = d
+	}
+
+	// The strict routing requires both boundaries, and the ingesters to hold the time range
+	// between them, otherwise queries might return partial results.
+	overlap := routing.queryIngestersWithin > 0 && routing.queryStoreAfter > 0 &&
+		routing.queryStoreAfter < routing.queryIngestersWithin
+
+	if overlap && r.costs != nil && r.limits.CostBasedTimeRangeRoutingEnabled(userID) {
+		routing.costBased = true
+		routing.preferIngesters = r.costs.preferIngesters(now)
+		routing.overlapMargin = util_math.Min(util_math.Max(r.limits.TimeRangeRoutingOverlapMargin(userID), 0), routing.queryIngestersWithin-routing.queryStoreAfter)
+		return routing
+	}
+
+	routing.strict = r.limits.StrictTimeRangeRoutingEnabled(userID) && overlap
+
+	return routing
+}
+
+// trackOverlapRouting tracks the component the time range held by both the ingesters and the store-gateways
+// has been routed to, if the input query time range overlaps it.
+func (r timeRangeRouter) trackOverlapRouting(routing timeRangeRouting, now time.Time, queryMinT, queryMaxT int64) {
+	if !routing.costBased ||
+		queryMaxT < util.TimeToMillis(now.Add(-routing.queryIngestersWithin)) ||
+		queryMinT > util.TimeToMillis(now.Add(-routing.queryStoreAfter)) {
+		return
+	}
+
+	component := timeRangeRoutingStoreGateways
+	if routing.preferIngesters {
+		component = timeRangeRoutingIngesters
+	}
+	r.costs.overlapRouted.WithLabelValues(component).Inc()
+}
+
+// trackCosts returns the input querier of the component, tracking the cost of its queries if the
+// cost-based routing is enabled.
+func (r timeRangeRouter) trackCosts(q storage.Querier, component string, mint, maxt int64) storage.Querier {
+	if r.costs == nil {
+		return q
+	}
+	return &costTrackingQuerier{Querier: q, costs: r.costs, component: component, mint: mint, maxt: maxt}
+}
+
+// timeRangeRouting is the time range routing between ingesters and store-gateways of a tenant.
+//
+// By default, the ingesters are queried for the time range more recent than "now - queryIngestersWithin",
+// and the store-gateways for the time range older than "now - queryStoreAfter", so that the time range
+// between the two boundaries is queried from both.
+//
+// In strict mode, each part of the queried time range is routed to a single component: the ingesters are
+// skipped for the queries ending before "now - queryStoreAfter", the store-gateways are skipped for the
+// queries starting after "now - queryIngestersWithin", and when both are queried the ingesters are only
+// queried for the time range more recent than "now - queryStoreAfter".
+//
+// In cost-based mode, the time range between the two boundaries is queried from the component estimated
+// to be cheaper, and the time range within the overlap margin of the boundary between the two components
+// is queried from both: if the ingesters are preferred, the store-gateways are queried for the time range
+// older than "now - queryIngestersWithin + overlapMargin", otherwise the ingesters are queried for the time
+// range more recent than "now - queryStoreAfter - overlapMargin".
+type timeRangeRouting struct {
+	queryIngestersWithin time.Duration
+	queryStoreAfter      time.Duration
+	strict               bool
+
+	costBased       bool
+	preferIngesters bool
+	overlapMargin   time.Duration
+}
+
+// useIngesters returns whether the ingesters should be queried for the input time range.
+func (r timeRangeRouting) useIngesters(now time.Time, queryMaxT int64) bool {
+	if r.costBased && !r.preferIngesters {
+		return queryMaxT >= util.TimeToMillis(now.Add(-r.queryStoreAfter-r.overlapMargin))
+	}
+	if r.strict {
+		return queryMaxT >= util.TimeToMillis(now.Add(-r.queryStoreAfter))
+	}
+
+	return r.queryIngestersWithin == 0 || queryMaxT >= util.TimeToMillis(now.Add(-r.queryIngestersWithin))
+}
+
+// useStoreGateways returns whether the store-gateways should be queried for the input time range.
+func (r timeRangeRouting) useStoreGateways(now time.Time, queryMinT, queryMaxT int64) bool {
+	if r.costBased && r.preferIngesters {
+		return queryMinT <= util.TimeToMillis(now.Add(-r.queryIngestersWithin+r.overlapMargin))
+	}
+	if r.strict && queryMinT >= util.TimeToMillis(now.Add(-r.queryIngestersWithin)) && r.useIngesters(now, queryMaxT) {
+		// The ingesters hold the whole queried time range.
+		return false
+	}
+
+	return r.queryStoreAfter == 0 || queryMinT <= util.TimeToMillis(now.Add(-r.queryStoreAfter))
+}
+
+// ingestersMinT returns the min time the ingesters should be queried from, for a querier whose time range is
+// [queryMinT, queryMaxT], or 0 if the ingesters' time range shouldn't be manipulated. The boundary must be
+// decided on the same time range the store-gateways routing is decided on, otherwise part of the queried
+// time range could be queried from none of them.
+func (r timeRangeRouting) ingestersMinT(now time.Time, queryMinT, queryMaxT int64) int64 {
+	if r.costBased && !r.preferIngesters {
+		return util.TimeToMillis(now.Add(-r.queryStoreAfter - r.overlapMargin))
+	}
+	if r.strict {
+		if !r.useStoreGateways(now, queryMinT, queryMaxT) {
+			return 0
+		}
+		// The store-gateways are queried up until "now - queryStoreAfter" included.
+		return util.TimeToMillis(now.Add(-r.queryStoreAfter)) + 1
+	}
+
+	if r.queryIngestersWithin == 0 {
+		return 0
+	}
+	return util.TimeToMillis(now.Add(-r.queryIngestersWithin))
+}
+
+// timeRangeRoutingCosts estimates the cost of querying the ingesters and the store-gateways, as the moving
+// average of the time taken by the querier to query them for up to an hour of data, and decides which of
+// them the time range held by both is queried from by the cost-based routing.
+type timeRangeRoutingCosts struct {
+	mtx   sync.Mutex
+	costs map[string]float64
+
+	// The current decision applies to the queries started from currentFrom, the previous one to the
+	// queries started before.
+	decidedAt   time.Time
+	current     bool
+	currentFrom time.Time
+	previous    bool
+
+	decision      prometheus.Gauge
+	estimatedCost *prometheus.GaugeVec
+	overlapRouted *prometheus.CounterVec
+}
+
+func newTimeRangeRoutingCosts(reg prometheus.Registerer) *timeRangeRoutingCosts {
+	return &timeRangeRoutingCosts{
+		costs: map[string]float64{},
+		decision: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_querier_time_range_routing_prefer_ingesters",
+			Help: "1 if the cost-based time range routing queries the time range held by both the ingesters and the store-gateways from the ingesters, 0 if from the store-gateways.",
+		}),
+		estimatedCost: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_querier_time_range_routing_estimated_cost_seconds",
+			Help: "Estimated time taken to query up to an hour of data from ingesters or store-gateways, used by the cost-based time range routing.",
+		}, []string{"component"}),
+		overlapRouted: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_time_range_routing_overlap_queries_total",
+			Help: "Total number of queries whose time range held by both the ingesters and the store-gateways has been routed to ingesters or store-gateways by the cost-based time range routing.",
+		}, []string{"component"}),
+	}
+}
+
+// observe tracks the time taken to query the component for the input time range.
+func (c *timeRangeRoutingCosts) observe(component string, mint, maxt int64, took time.Duration) {
+	// The cost of a query isn't proportional to its time range, so the queries of up to an hour are
+	// considered as costly as the queries of an hour.
+	cost := took.Seconds() / util_math.Max(float64(maxt-mint)/float64(time.Hour.Milliseconds()), 1)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if prev, ok := c.costs[component]; ok {
+		cost = prev + timeRangeRoutingCostEWMAWeight*(cost-prev)
+	}
+	c.costs[component] = cost
+	c.estimatedCost.WithLabelValues(component).Set(cost)
+}
+
+// preferIngesters returns whether the time range held by both the ingesters and the store-gateways
+// should be queried from the ingesters, for the query started at the input time. The store-gateways
+// are preferred until the cost of both components has been estimated.
+func (c *timeRangeRoutingCosts) preferIngesters(now time.Time) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if decideAt := time.Now(); decideAt.Sub(c.decidedAt) >= timeRangeRoutingCostDecisionInterval {
+		c.decidedAt = decideAt
+		if decision := c.decideLocked(); decision != c.current {
+			c.previous = c.current
+			c.current = decision
+			c.currentFrom = decideAt.Add(timeRangeRoutingCostDecisionDelay)
+		}
+	}
+
+	if now.Before(c.currentFrom) {
+		return c.previous
+	}
+	return c.current
+}
+
+func (c *timeRangeRoutingCosts) decideLocked() bool {
+	ingesters, okIngesters := c.costs[timeRangeRoutingIngesters]
+	storeGateways, okStoreGateways := c.costs[timeRangeRoutingStoreGateways]
+	if !okIngesters || !okStoreGateways {
+		return c.current
+	}
+
+	decision := c.current
+	if c.current && storeGateways < ingesters*(1-timeRangeRoutingCostHysteresis) {
+		decision = false
+	} else if !c.current && ingesters < storeGateways*(1-timeRangeRoutingCostHysteresis) {
+		decision = true
+	}
+
+	if decision {
+		c.decision.Set(1)
+	} else {
+		c.decision.Set(0)
+	}
+	return decision
+}
+
+// costTrackingQuerier is a storage.Querier tracking the time taken by the series queries of a component.
+type costTrackingQuerier struct {
+	storage.Querier
+
+	costs      *timeRangeRoutingCosts
+	component  string
+	mint, maxt int64
+}
+
+func (q *costTrackingQuerier) Select(sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	mint, maxt := q.mint, q.maxt
+	if hints != nil {
+		mint, maxt = hints.Start, hints.End
+	}
+
+	start := time.Now()
+	set := q.Querier.Select(sortSeries, hints, matchers...)
+	if set.Err() == nil {
+		q.costs.observe(q.component, mint, maxt, time.Since(start))
+	}
+	return set
+}
+
+type timeRangeRoutingContextKey int
+
+const queryStartTimeCtxKey = timeRangeRoutingContextKey(0)
+
+// contextWithQueryStartTime returns a context with the time the query was started at, which the time range
+// routing of the query is decided on.
+func contextWithQueryStartTime(ctx context.Context, now time.Time) context.Context {
+	return context.WithValue(ctx, queryStartTimeCtxKey, now)
+}
+
+// queryStartTimeFromContext returns the time the query was started at, or the current time if unknown.
+func queryStartTimeFromContext(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(queryStartTimeCtxKey).(time.Time); ok {
+		return now
+	}
+	return time.Now()
+}
@@ -0,0 +1,475 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestTimeRangeRouter_ForTenant(t *testing.T) {
+	tests := map[string]struct {
+		limits   TimeRangeRoutingLimits
+		expected timeRangeRouting
+	}{
+		"no limits": {
+			expected: timeRangeRouting{queryIngestersWithin: 13 * time.Hour, queryStoreAfter: 12 * time.Hour},
+		},
+		"no overrides": {
+			limits:   &timeRangeRoutingLimitsMock{},
+			expected: timeRangeRouting{queryIngestersWithin: 13 * time.Hour, queryStoreAfter: 12 * time.Hour},
+		},
+		"overridden boundaries": {
+			limits:   &timeRangeRoutingLimitsMock{queryIngestersWithin: 2 * time.Hour, queryStoreAfter: time.Hour},
+			expected: timeRangeRouting{queryIngestersWithin: 2 * time.Hour, queryStoreAfter: time.Hour},
+		},
+		"strict routing": {
+			limits:   &timeRangeRoutingLimitsMock{strict: true},
+			expected: timeRangeRouting{queryIngestersWithin: 13 * time.Hour, queryStoreAfter: 12 * time.Hour, strict: true},
+		},
+		"strict routing is disabled if the query-store-after boundary is not lower than the query-ingesters-within one": {
+			limits:   &timeRangeRoutingLimitsMock{queryIngestersWithin: time.Hour, strict: true},
+			expected: timeRangeRouting{queryIngestersWithin: time.Hour, queryStoreAfter: 12 * time.Hour},
+		},
+		"cost-based routing": {
+			limits:   &timeRangeRoutingLimitsMock{costBased: true, overlapMargin: 10 * time.Minute},
+			expected: timeRangeRouting{queryIngestersWithin: 13 * time.Hour, queryStoreAfter: 12 * time.Hour, costBased: true, overlapMargin: 10 * time.Minute},
+		},
+		"cost-based routing takes precedence over the strict routing": {
+			limits:   &timeRangeRoutingLimitsMock{costBased: true, strict: true, overlapMargin: 10 * time.Minute},
+			expected: timeRangeRouting{queryIngestersWithin: 13 * time.Hour, queryStoreAfter: 12 * time.Hour, costBased: true, overlapMargin: 10 * time.Minute},
+		},
+		"cost-based routing overlap margin is capped to the time range between the boundaries": {
+			limits:   &timeRangeRoutingLimitsMock{costBased: true, overlapMargin: 2 * time.Hour},
+			expected: timeRangeRouting{queryIngestersWithin: 13 * time.Hour, queryStoreAfter: 12 * time.Hour, costBased: true, overlapMargin: time.Hour},
+		},
+		"cost-based routing is disabled if the query-store-after boundary is not lower than the query-ingesters-within one": {
+			limits:   &timeRangeRoutingLimitsMock{queryIngestersWithin: time.Hour, costBased: true},
+			expected: timeRangeRouting{queryIngestersWithin: time.Hour, queryStoreAfter: 12 * time.Hour},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			router := newTimeRangeRouter(13*time.Hour, 12*time.Hour, testData.limits, newTimeRangeRoutingCosts(nil))
+			assert.Equal(t, testData.expected, router.forTenant(time.Now(), "user-1"))
+		})
+	}
+}
+
+func TestTimeRangeRouting(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		strict                   bool
+		costBased                bool
+		preferIngesters          bool
+		queryMinT, queryMaxT     time.Time
+		expectedUseIngesters     bool
+		expectedUseStoreGateways bool
+		expectedIngestersMinT    time.Time
+	}{
+		"recent query": {
+			queryMinT:                now.Add(-30 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: false,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"query within both boundaries": {
+			queryMinT:                now.Add(-100 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"long query": {
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"old query ending between the boundaries": {
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now.Add(-90 * time.Minute),
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"old query": {
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now.Add(-3 * time.Hour),
+			expectedUseIngesters:     false,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"strict: recent query": {
+			strict:                   true,
+			queryMinT:                now.Add(-30 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: false,
+		},
+		"strict: query within both boundaries": {
+			strict:                   true,
+			queryMinT:                now.Add(-100 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: false,
+		},
+		"strict: long query": {
+			strict:                   true,
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-time.Hour).Add(time.Millisecond),
+		},
+		"strict: old query ending between the boundaries": {
+			strict:                   true,
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now.Add(-90 * time.Minute),
+			expectedUseIngesters:     false,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-time.Hour).Add(time.Millisecond),
+		},
+		"strict: query between the boundaries": {
+			strict:                   true,
+			queryMinT:                now.Add(-100 * time.Minute),
+			queryMaxT:                now.Add(-90 * time.Minute),
+			expectedUseIngesters:     false,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-time.Hour).Add(time.Millisecond),
+		},
+		"strict: old query": {
+			strict:                   true,
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now.Add(-3 * time.Hour),
+			expectedUseIngesters:     false,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-time.Hour).Add(time.Millisecond),
+		},
+		"cost-based, store-gateways preferred: query within both boundaries": {
+			costBased:                true,
+			queryMinT:                now.Add(-100 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-70 * time.Minute),
+		},
+		"cost-based, store-gateways preferred: query between the boundaries": {
+			costBased:                true,
+			queryMinT:                now.Add(-100 * time.Minute),
+			queryMaxT:                now.Add(-80 * time.Minute),
+			expectedUseIngesters:     false,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-70 * time.Minute),
+		},
+		"cost-based, store-gateways preferred: query ending within the overlap margin": {
+			costBased:                true,
+			queryMinT:                now.Add(-100 * time.Minute),
+			queryMaxT:                now.Add(-65 * time.Minute),
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-70 * time.Minute),
+		},
+		"cost-based, store-gateways preferred: recent query": {
+			costBased:                true,
+			queryMinT:                now.Add(-30 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: false,
+			expectedIngestersMinT:    now.Add(-70 * time.Minute),
+		},
+		"cost-based, ingesters preferred: query within both boundaries": {
+			costBased:                true,
+			preferIngesters:          true,
+			queryMinT:                now.Add(-100 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: false,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"cost-based, ingesters preferred: query starting within the overlap margin": {
+			costBased:                true,
+			preferIngesters:          true,
+			queryMinT:                now.Add(-115 * time.Minute),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"cost-based, ingesters preferred: long query": {
+			costBased:                true,
+			preferIngesters:          true,
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now,
+			expectedUseIngesters:     true,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+		"cost-based, ingesters preferred: old query": {
+			costBased:                true,
+			preferIngesters:          true,
+			queryMinT:                now.Add(-90 * 24 * time.Hour),
+			queryMaxT:                now.Add(-3 * time.Hour),
+			expectedUseIngesters:     false,
+			expectedUseStoreGateways: true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			r := timeRangeRouting{queryIngestersWithin: 2 * time.Hour, queryStoreAfter: time.Hour, strict: testData.strict, costBased: testData.costBased, preferIngesters: testData.preferIngesters}
+			if r.costBased {
+				r.overlapMargin = 10 * time.Minute
+			}
+			queryMinT, queryMaxT := util.TimeToMillis(testData.queryMinT), util.TimeToMillis(testData.queryMaxT)
+
+			assert.Equal(t, testData.expectedUseIngesters, r.useIngesters(now, queryMaxT))
+			assert.Equal(t, testData.expectedUseStoreGateways, r.useStoreGateways(now, queryMinT, queryMaxT))
+
+			expectedIngestersMinT := int64(0)
+			if !testData.expectedIngestersMinT.IsZero() {
+				expectedIngestersMinT = util.TimeToMillis(testData.expectedIngestersMinT)
+			}
+			assert.Equal(t, expectedIngestersMinT, r.ingestersMinT(now, queryMinT, queryMaxT))
+		})
+	}
+}
+
+func TestQuerier_TimeRangeRoutingPerTenant(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		tenantLimits             func(limits *validation.Limits)
+		queryMinT, queryMaxT     time.Time
+		expectedHitIngesters     bool
+		expectedIngestersMinT    time.Time
+		expectedHitStoreGateways bool
+		expectedSkipped          string
+	}{
+		"querier boundaries": {
+			queryMinT:                now.Add(-14 * time.Hour),
+			queryMaxT:                now,
+			expectedHitIngesters:     true,
+			expectedIngestersMinT:    now.Add(-13 * time.Hour),
+			expectedHitStoreGateways: true,
+		},
+		"overridden boundaries": {
+			tenantLimits: func(limits *validation.Limits) {
+				limits.QueryIngestersWithin = model.Duration(2 * time.Hour)
+				limits.QueryStoreAfter = model.Duration(time.Hour)
+			},
+			queryMinT:                now.Add(-14 * time.Hour),
+			queryMaxT:                now,
+			expectedHitIngesters:     true,
+			expectedIngestersMinT:    now.Add(-2 * time.Hour),
+			expectedHitStoreGateways: true,
+		},
+		"overridden boundaries, old query": {
+			tenantLimits: func(limits *validation.Limits) {
+				limits.QueryIngestersWithin = model.Duration(2 * time.Hour)
+				limits.QueryStoreAfter = model.Duration(time.Hour)
+			},
+			queryMinT:                now.Add(-14 * time.Hour),
+			queryMaxT:                now.Add(-3 * time.Hour),
+			expectedHitStoreGateways: true,
+			expectedSkipped:          "ingesters",
+		},
+		"strict routing, long query": {
+			tenantLimits: func(limits *validation.Limits) {
+				limits.StrictTimeRangeRoutingEnabled = true
+			},
+			queryMinT:                now.Add(-14 * time.Hour),
+			queryMaxT:                now,
+			expectedHitIngesters:     true,
+			expectedIngestersMinT:    now.Add(-12 * time.Hour),
+			expectedHitStoreGateways: true,
+		},
+		"strict routing, recent query": {
+			tenantLimits: func(limits *validation.Limits) {
+				limits.StrictTimeRangeRoutingEnabled = true
+			},
+			queryMinT:             now.Add(-12*time.Hour - 30*time.Minute),
+			queryMaxT:             now,
+			expectedHitIngesters:  true,
+			expectedIngestersMinT: now.Add(-12*time.Hour - 30*time.Minute),
+			expectedSkipped:       "store-gateways",
+		},
+		"cost-based routing, long query": {
+			tenantLimits: func(limits *validation.Limits) {
+				limits.CostBasedTimeRangeRoutingEnabled = true
+			},
+			queryMinT:                now.Add(-14 * time.Hour),
+			queryMaxT:                now,
+			expectedHitIngesters:     true,
+			expectedIngestersMinT:    now.Add(-12*time.Hour - 10*time.Minute),
+			expectedHitStoreGateways: true,
+		},
+		"cost-based routing, query between the boundaries": {
+			tenantLimits: func(limits *validation.Limits) {
+				limits.CostBasedTimeRangeRoutingEnabled = true
+			},
+			queryMinT:                now.Add(-12*time.Hour - 50*time.Minute),
+			queryMaxT:                now.Add(-12*time.Hour - 30*time.Minute),
+			expectedHitStoreGateways: true,
+			expectedSkipped:          "ingesters",
+		},
+		"strict routing, old query": {
+			tenantLimits: func(limits *validation.Limits) {
+				limits.StrictTimeRangeRoutingEnabled = true
+			},
+			queryMinT:                now.Add(-14 * time.Hour),
+			queryMaxT:                now.Add(-12*time.Hour - 30*time.Minute),
+			expectedHitStoreGateways: true,
+			expectedSkipped:          "ingesters",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+
+			tenantLimits := defaultLimitsConfig()
+			if testData.tenantLimits != nil {
+				testData.tenantLimits(&tenantLimits)
+			}
+			overrides, err := validation.NewOverrides(defaultLimitsConfig(), validation.NewMockTenantLimits(map[string]*validation.Limits{"user-1": &tenantLimits}))
+			require.NoError(t, err)
+
+			distributor := &mockDistributor{}
+			distributor.On("QueryStream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&client.QueryStreamResponse{}, nil)
+
+			storeQuerier := &mockBlocksStorageQuerier{}
+			storeQuerier.On("Select", true, mock.Anything, mock.Anything).Return(storage.EmptySeriesSet())
+
+			reg := prometheus.NewPedanticRegistry()
+			queryable, _, _ := New(cfg, overrides, distributor, []QueryableWithFilter{UseAlwaysQueryable(newMockBlocksStorageQueryable(storeQuerier))}, reg, log.NewNopLogger(), nil)
+
+			queryMinT, queryMaxT := util.TimeToMillis(testData.queryMinT), util.TimeToMillis(testData.queryMaxT)
+			q, err := queryable.Querier(user.InjectOrgID(context.Background(), "user-1"), queryMinT, queryMaxT)
+			require.NoError(t, err)
+
+			set := q.Select(true, &storage.SelectHints{Start: queryMinT, End: queryMaxT}, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "metric"))
+			require.NoError(t, set.Err())
+
+			if testData.expectedHitIngesters {
+				require.Len(t, distributor.Calls, 1)
+				assert.InDelta(t, util.TimeToMillis(testData.expectedIngestersMinT), int64(distributor.Calls[0].Arguments.Get(1).(model.Time)), float64(5*time.Second.Milliseconds()))
+				assert.Equal(t, queryMaxT, int64(distributor.Calls[0].Arguments.Get(2).(model.Time)))
+			} else {
+				assert.Len(t, distributor.Calls, 0)
+			}
+
+			if testData.expectedHitStoreGateways {
+				storeQuerier.AssertCalled(t, "Select", true, mock.Anything, mock.Anything)
+			} else {
+				storeQuerier.AssertNotCalled(t, "Select", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			expectedMetrics := `
+				# HELP cortex_querier_time_range_routing_skipped_components_total Total number of queries for which ingesters or store-gateways have been skipped because of the queried time range.
+				# TYPE cortex_querier_time_range_routing_skipped_components_total counter
+			`
+			for _, component := range []string{"ingesters", "store-gateways"} {
+				skipped := 0
+				if component == testData.expectedSkipped {
+					skipped = 1
+				}
+				expectedMetrics += fmt.Sprintf("cortex_querier_time_range_routing_skipped_components_total{component=%q} %d\n", component, skipped)
+			}
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_querier_time_range_routing_skipped_components_total"))
+		})
+	}
+}
+
+func TestTimeRangeRoutingCosts(t *testing.T) {
+	costs := newTimeRangeRoutingCosts(prometheus.NewPedanticRegistry())
+	hour := time.Hour.Milliseconds()
+
+	// The store-gateways are preferred until the cost of both components has been estimated.
+	costs.observe(timeRangeRoutingIngesters, 0, hour, time.Second)
+	require.False(t, costs.preferIngesters(time.Now()))
+
+	// The cost of the queries of up to an hour is the time taken by the query, and it's proportional
+	// to the time range for the longer queries.
+	costs.observe(timeRangeRoutingStoreGateways, 0, 10*hour, 20*time.Second)
+	assert.Equal(t, map[string]float64{timeRangeRoutingIngesters: 1, timeRangeRoutingStoreGateways: 2}, costs.costs)
+
+	// The decision is re-evaluated at most once per interval.
+	require.False(t, costs.preferIngesters(time.Now()))
+	costs.decidedAt = time.Time{}
+
+	// The new decision only applies to the queries started after the delay.
+	now := time.Now()
+	require.False(t, costs.preferIngesters(now))
+	require.False(t, costs.preferIngesters(now.Add(time.Second)))
+	require.True(t, costs.preferIngesters(now.Add(timeRangeRoutingCostDecisionDelay+time.Second)))
+	require.True(t, costs.preferIngesters(time.Now().Add(timeRangeRoutingCostDecisionDelay+time.Second)))
+
+	// The ingesters are still preferred as long as the store-gateways aren't cheaper enough.
+	costs.costs[timeRangeRoutingStoreGateways] = 0.95
+	costs.decidedAt = time.Time{}
+	require.True(t, costs.preferIngesters(time.Now().Add(timeRangeRoutingCostDecisionDelay+time.Second)))
+
+	costs.costs[timeRangeRoutingStoreGateways] = 0.5
+	costs.decidedAt = time.Time{}
+	require.False(t, costs.preferIngesters(time.Now().Add(timeRangeRoutingCostDecisionDelay+time.Second)))
+
+	assert.NoError(t, testutil.CollectAndCompare(costs.decision, strings.NewReader(`
+		# HELP cortex_querier_time_range_routing_prefer_ingesters 1 if the cost-based time range routing queries the time range held by both the ingesters and the store-gateways from the ingesters, 0 if from the store-gateways.
+		# TYPE cortex_querier_time_range_routing_prefer_ingesters gauge
+		cortex_querier_time_range_routing_prefer_ingesters 0
+	`)))
+}
+
+type timeRangeRoutingLimitsMock struct {
+	queryIngestersWithin time.Duration
+	queryStoreAfter      time.Duration
+	strict               bool
+	costBased            bool
+	overlapMargin        time.Duration
+}
+
+func (m *timeRangeRoutingLimitsMock) QueryIngestersWithin(string) time.Duration {
+	return m.queryIngestersWithin
+}
+
+func (m *timeRangeRoutingLimitsMock) QueryStoreAfter(string) time.Duration {
+	return m.queryStoreAfter
+}
+
+func (m *timeRangeRoutingLimitsMock) StrictTimeRangeRoutingEnabled(string) bool {
+	return m.strict
+}
+
+func (m *timeRangeRoutingLimitsMock) CostBasedTimeRangeRoutingEnabled(string) bool {
+	return m.costBased
+}
+
+func (m *timeRangeRoutingLimitsMock) TimeRangeRoutingOverlapMargin(string) time.Duration {
+	return m.overlapMargin
+}
@@ -7,9 +7,14 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"runtime/debug"
+	"runtime/metrics"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -33,18 +38,33 @@ import (
 	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
 	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+	"github.com/grafana/mimir/pkg/util/globalerror"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
-func newSchedulerProcessor(cfg Config, handler RequestHandler, log log.Logger, reg prometheus.Registerer) (*schedulerProcessor, []services.Service) {
+var errMaxQueryExecutionTime = globalerror.MaxQueryExecutionTime.MessageWithPerTenantLimitConfig(
+	"the query has been canceled because it exceeded the max execution time allowed for the tenant",
+	validation.MaxQueryExecutionTimeFlag,
+)
+
+func newSchedulerProcessor(cfg Config, handler RequestHandler, limits Limits, log log.Logger, reg prometheus.Registerer) (*schedulerProcessor, []services.Service) {
 	p := &schedulerProcessor{
 		log:            log,
 		handler:        handler,
+		tenantLimiter:  newTenantConcurrencyLimiter(limits, reg),
 		maxMessageSize: cfg.GRPCClientConfig.MaxSendMsgSize,
 		querierID:      cfg.QuerierID,
+		querierPool:    cfg.QuerierPool,
 		grpcConfig:     cfg.GRPCClientConfig,
 
+		gracefulDrainEnabled: cfg.GracefulDrainEnabled,
+		gracefulDrainTimeout: cfg.GracefulDrainTimeout,
+
+		inflightQueries: atomic.NewUint32(0),
+		memoryHeadroom:  memoryHeadroomBytes,
+
 		schedulerClientFactory: func(conn *grpc.ClientConn) schedulerpb.SchedulerForQuerierClient {
 			return schedulerpb.NewSchedulerForQuerierClient(conn)
 		},
@@ -75,9 +95,21 @@ func newSchedulerProcessor(cfg Config, handler RequestHandler, log log.Logger, r
 type schedulerProcessor struct {
 	log            log.Logger
 	handler        RequestHandler
+	tenantLimiter  *tenantConcurrencyLimiter
 	grpcConfig     grpcclient.Config
 	maxMessageSize int
 	querierID      string
+	querierPool    string
+
+	// Whether the querier waits until the query-scheduler confirms no more queries will be dispatched
+	// on a stream before closing it, and the max time it waits for it.
+	gracefulDrainEnabled bool
+	gracefulDrainTimeout time.Duration
+
+	// Number of queries executing, across all query-schedulers. Reported to query-schedulers along with
+	// the memory headroom, so that they can take into account the querier capacity when dispatching queries.
+	inflightQueries *atomic.Uint32
+	memoryHeadroom  func() uint64
 
 	frontendPool                  *client.Pool
 	frontendClientRequestDuration *prometheus.HistogramVec
@@ -100,50 +132,105 @@ func (sp *schedulerProcessor) processQueriesOnSingleStream(workerCtx context.Con
 	schedulerClient := sp.schedulerClientFactory(conn)
 
 	// Run the querier loop (and so all the queries) in a dedicated context that we call the "execution context".
-	// The execution context is cancelled once the workerCtx is cancelled AND there's no inflight query executing.
-	execCtx, execCancel, inflightQuery := newExecutionContext(workerCtx, sp.log)
+	// The execution context is cancelled once the workerCtx is cancelled AND there's no inflight query executing
+	// or, when the graceful drain is enabled, once the query-scheduler confirmed the stream has been drained.
+	var (
+		execCtx       context.Context
+		execCancel    context.CancelFunc
+		inflightQuery = atomic.NewBool(false)
+	)
+	if sp.gracefulDrainEnabled {
+		execCtx, execCancel = newDrainingExecutionContext(workerCtx, sp.gracefulDrainTimeout, sp.log)
+	} else {
+		execCtx, execCancel, inflightQuery = newExecutionContext(workerCtx, sp.log)
+	}
 	defer execCancel()
 
 	backoff := backoff.New(execCtx, processorBackoffConfig)
 	for backoff.Ongoing() {
 		c, err := schedulerClient.QuerierLoop(execCtx)
 		if err == nil {
-			err = c.Send(&schedulerpb.QuerierToScheduler{QuerierID: sp.querierID})
+			err = c.Send(&schedulerpb.QuerierToScheduler{QuerierID: sp.querierID, QuerierPool: sp.querierPool, Capacity: sp.capacity()})
 		}
 
 		if err != nil {
 			level.Warn(sp.log).Log("msg", "error contacting scheduler", "err", err, "addr", address)
+
+			// Do not reconnect to the query-scheduler if the querier is shutting down.
+			if sp.gracefulDrainEnabled && workerCtx.Err() != nil {
+				return
+			}
+
 			backoff.Wait()
 			continue
 		}
 
-		if err := sp.querierLoop(c, address, inflightQuery); err != nil {
+		if err := sp.querierLoop(workerCtx, c, address, inflightQuery); err != nil {
 			// Do not log an error is the query-scheduler is shutting down.
 			if s, ok := status.FromError(err); !ok || !strings.Contains(s.Message(), schedulerpb.ErrSchedulerIsNotRunning.Error()) {
 				level.Error(sp.log).Log("msg", "error processing requests from scheduler", "err", err, "addr", address)
 			}
 
+			// Do not reconnect to the query-scheduler if the querier is shutting down.
+			if sp.gracefulDrainEnabled && workerCtx.Err() != nil {
+				return
+			}
+
 			backoff.Wait()
 			continue
 		}
 
+		if sp.gracefulDrainEnabled {
+			// The query-scheduler confirmed the stream has been drained.
+			level.Debug(sp.log).Log("msg", "query-scheduler confirmed the stream has been drained", "addr", address)
+			return
+		}
+
 		backoff.Reset()
 	}
 }
 
-// process loops processing requests on an established stream.
-func (sp *schedulerProcessor) querierLoop(c schedulerpb.SchedulerForQuerier_QuerierLoopClient, address string, inflightQuery *atomic.Bool) error {
+// process loops processing requests on an established stream. When the graceful drain is enabled, it returns
+// nil once the query-scheduler confirmed the stream has been drained after the worker context has been canceled.
+func (sp *schedulerProcessor) querierLoop(workerCtx context.Context, c schedulerpb.SchedulerForQuerier_QuerierLoopClient, address string, inflightQuery *atomic.Bool) error {
 	// Build a child context so we can cancel a query when the stream is closed.
 	ctx, cancel := context.WithCancel(c.Context())
 	defer cancel()
 
+	// The DRAINING message may be sent while a query is executing, so sending on the stream must be serialized.
+	sendMx := sync.Mutex{}
+	send := func(msg *schedulerpb.QuerierToScheduler) error {
+		sendMx.Lock()
+		defer sendMx.Unlock()
+		return c.Send(msg)
+	}
+
+	if sp.gracefulDrainEnabled {
+		go func() {
+			select {
+			case <-workerCtx.Done():
+				// Tell the query-scheduler to stop dispatching queries on this stream. The queries received
+				// until it confirms the stream has been drained are still executed.
+				if err := send(&schedulerpb.QuerierToScheduler{Type: schedulerpb.DRAINING}); err != nil {
+					level.Warn(sp.log).Log("msg", "error notifying scheduler about querier draining", "err", err, "addr", address)
+				}
+			case <-ctx.Done():
+			}
+		}()
+	}
+
 	for {
 		request, err := c.Recv()
 		if err != nil {
 			return err
 		}
 
+		if request.GetType() == schedulerpb.DRAINED {
+			return nil
+		}
+
 		inflightQuery.Store(true)
+		sp.inflightQueries.Inc()
 
 		// Handle the request on a "background" goroutine, so we go back to
 		// blocking on c.Recv().  This allows us to detect the stream closing
@@ -151,8 +238,6 @@ func (sp *schedulerProcessor) querierLoop(c schedulerpb.SchedulerForQuerier_Quer
 		// here, as we're running in lock step with the server - each Recv is
 		// paired with a Send.
 		go func() {
-			defer inflightQuery.Store(false)
-
 			// We need to inject user into context for sending response back.
 			ctx := user.InjectOrgID(ctx, request.UserID)
 
@@ -167,23 +252,112 @@ func (sp *schedulerProcessor) querierLoop(c schedulerpb.SchedulerForQuerier_Quer
 			}
 			logger := util_log.WithContext(ctx, sp.log)
 
-			sp.runRequest(ctx, logger, request.QueryID, request.FrontendAddress, request.StatsEnabled, request.HttpRequest)
+			targets := append([]*schedulerpb.QueryTarget{{QueryID: request.QueryID, FrontendAddress: request.FrontendAddress, Nonce: request.Nonce}}, request.AdditionalTargets...)
+
+			if !sp.tenantLimiter.tryAcquire(request.UserID) {
+				// The tenant reached the max number of queries executing concurrently in the querier: the stream
+				// is released, so that the query-scheduler can dispatch the queries of other tenants to it, and
+				// the query waits in the querier for one of the executing queries of the tenant to complete.
+				inflightQuery.Store(false)
+				sp.inflightQueries.Dec()
+				if err := send(&schedulerpb.QuerierToScheduler{Capacity: sp.capacity()}); err != nil {
+					level.Error(logger).Log("msg", "error notifying scheduler about waiting query", "err", err, "addr", address)
+				}
+
+				sp.runWaitingRequest(ctx, logger, targets, request.StatsEnabled, request.Deadline, request.HttpRequest)
+				return
+			}
+
+			sp.runRequest(ctx, logger, targets, request.StatsEnabled, request.Deadline, request.HttpRequest)
+			sp.tenantLimiter.release(request.UserID)
+			sp.inflightQueries.Dec()
 
 			// Report back to scheduler that processing of the query has finished.
-			if err := c.Send(&schedulerpb.QuerierToScheduler{}); err != nil {
+			if err := send(&schedulerpb.QuerierToScheduler{Capacity: sp.capacity()}); err != nil {
 				level.Error(logger).Log("msg", "error notifying scheduler about finished query", "err", err, "addr", address)
 			}
+			inflightQuery.Store(false)
 		}()
 	}
 }
 
-func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger, queryID uint64, frontendAddress string, statsEnabled bool, request *httpgrpc.HTTPRequest) {
+// runWaitingRequest waits until the tenant of the request is below its max number of concurrent queries,
+// and then runs the request. The query-scheduler has already been notified the query is finished, so the
+// request isn't canceled when the stream is closed, and the waiting time counts towards the deadline.
+func (sp *schedulerProcessor) runWaitingRequest(streamCtx context.Context, logger log.Logger, targets []*schedulerpb.QueryTarget, statsEnabled bool, deadline int64, request *httpgrpc.HTTPRequest) {
+	userID, _ := user.ExtractOrgID(streamCtx)
+	ctx := opentracing.ContextWithSpan(user.InjectOrgID(context.Background(), userID), opentracing.SpanFromContext(streamCtx))
+
+	waitCtx := ctx
+	if deadline > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithDeadline(ctx, time.Unix(0, deadline))
+		defer cancel()
+	}
+
+	if err := sp.tenantLimiter.acquire(waitCtx, userID); err != nil {
+		level.Warn(logger).Log("msg", "query exceeded the max execution time while waiting for the tenant to be below its max concurrent queries", "deadline", time.Unix(0, deadline))
+		sp.sendResponse(ctx, logger, targets, nil, &httpgrpc.HTTPResponse{
+			Code: http.StatusUnprocessableEntity,
+			Body: []byte(errMaxQueryExecutionTime),
+		})
+		return
+	}
+	defer sp.tenantLimiter.release(userID)
+
+	sp.inflightQueries.Inc()
+	defer sp.inflightQueries.Dec()
+
+	sp.runRequest(ctx, logger, targets, statsEnabled, deadline, request)
+}
+
+// capacity returns the current capacity of the querier, to be reported to the query-scheduler.
+func (sp *schedulerProcessor) capacity() *schedulerpb.QuerierCapacity {
+	return &schedulerpb.QuerierCapacity{
+		InflightQueries:     sp.inflightQueries.Load(),
+		MemoryHeadroomBytes: sp.memoryHeadroom(),
+	}
+}
+
+// memoryHeadroomBytes returns how much memory the process can still allocate before reaching
+// the Go memory limit, using the same accounting as the Go runtime.
+func memoryHeadroomBytes() uint64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 {
+		// No memory limit configured.
+		return math.MaxUint64
+	}
+
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+
+	inUse := samples[0].Value.Uint64() - samples[1].Value.Uint64()
+	if inUse >= uint64(limit) {
+		return 0
+	}
+	return uint64(limit) - inUse
+}
+
+// runRequest runs the request and sends the response to the frontends of all targets. The request is canceled once
+// the deadline, as Unix nanoseconds, is reached, unless it's 0.
+func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger, targets []*schedulerpb.QueryTarget, statsEnabled bool, deadline int64, request *httpgrpc.HTTPRequest) {
 	var stats *querier_stats.Stats
 	if statsEnabled {
 		stats, ctx = querier_stats.ContextWithEmptyStats(ctx)
 	}
 
-	response, err := sp.handler.Handle(ctx, request)
+	// The deadline only applies to the request execution, not to sending the response to the frontends.
+	handlerCtx := ctx
+	if deadline > 0 {
+		var cancel context.CancelFunc
+		handlerCtx, cancel = context.WithDeadline(ctx, time.Unix(0, deadline))
+		defer cancel()
+	}
+
+	response, err := sp.handler.Handle(handlerCtx, request)
 	if err != nil {
 		var ok bool
 		response, ok = httpgrpc.HTTPResponseFromError(err)
@@ -195,6 +369,16 @@ func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger,
 		}
 	}
 
+	// The request failed because of the deadline: the error is reported as a limit error, so that the request
+	// isn't retried by the frontend.
+	if response.Code/100 != 2 && ctx.Err() == nil && errors.Is(handlerCtx.Err(), context.DeadlineExceeded) {
+		level.Warn(logger).Log("msg", "query exceeded the max execution time", "deadline", time.Unix(0, deadline))
+		response = &httpgrpc.HTTPResponse{
+			Code: http.StatusUnprocessableEntity,
+			Body: []byte(errMaxQueryExecutionTime),
+		}
+	}
+
 	// Ensure responses that are too big are not retried.
 	if len(response.Body) >= sp.maxMessageSize {
 		level.Error(logger).Log("msg", "response larger than max message size", "size", len(response.Body), "maxMessageSize", sp.maxMessageSize)
@@ -206,17 +390,26 @@ func (sp *schedulerProcessor) runRequest(ctx context.Context, logger log.Logger,
 		}
 	}
 
-	c, err := sp.frontendPool.GetClientFor(frontendAddress)
-	if err == nil {
-		// Response is empty and uninteresting.
-		_, err = c.(frontendv2pb.FrontendForQuerierClient).QueryResult(ctx, &frontendv2pb.QueryResultRequest{
-			QueryID:      queryID,
-			HttpResponse: response,
-			Stats:        stats,
-		})
-	}
-	if err != nil {
-		level.Error(logger).Log("msg", "error notifying frontend about finished query", "err", err, "frontend", frontendAddress)
+	sp.sendResponse(ctx, logger, targets, stats, response)
+}
+
+// sendResponse sends the response to the frontends of all targets.
+func (sp *schedulerProcessor) sendResponse(ctx context.Context, logger log.Logger, targets []*schedulerpb.QueryTarget, stats *querier_stats.Stats, response *httpgrpc.HTTPResponse) {
+	// The same query may have been enqueued by multiple frontends, and deduplicated by the scheduler.
+	for _, target := range targets {
+		c, err := sp.frontendPool.GetClientFor(target.FrontendAddress)
+		if err == nil {
+			// Response is empty and uninteresting.
+			_, err = c.(frontendv2pb.FrontendForQuerierClient).QueryResult(ctx, &frontendv2pb.QueryResultRequest{
+				QueryID:      target.QueryID,
+				Nonce:        target.Nonce,
+				HttpResponse: response,
+				Stats:        stats,
+			})
+		}
+		if err != nil {
+			level.Error(logger).Log("msg", "error notifying frontend about finished query", "err", err, "frontend", target.FrontendAddress)
+		}
 	}
 }
 
@@ -4,12 +4,17 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/gogo/status"
 	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/ring/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -17,8 +22,10 @@ import (
 	"go.uber.org/atomic"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
 )
 
@@ -46,7 +53,28 @@ func TestSchedulerProcessor_processQueriesOnSingleStream(t *testing.T) {
 
 		// We expect Send() has been called only once, to send the querier ID to scheduler.
 		loopClient.AssertNumberOfCalls(t, "Send", 1)
-		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{QuerierID: "test-querier-id"})
+		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{QuerierID: "test-querier-id", Capacity: &schedulerpb.QuerierCapacity{MemoryHeadroomBytes: 1024}})
+	})
+
+	t.Run("should advertise the querier pool when connecting to the scheduler", func(t *testing.T) {
+		sp, loopClient, requestHandler := prepareSchedulerProcessor()
+		sp.querierPool = "reserved"
+
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+
+		loopClient.On("Recv").Return(func() (*schedulerpb.SchedulerToQuerier, error) {
+			workerCancel()
+
+			<-loopClient.Context().Done()
+			return nil, loopClient.Context().Err()
+		})
+
+		requestHandler.On("Handle", mock.Anything, mock.Anything).Return(&httpgrpc.HTTPResponse{}, nil)
+
+		sp.processQueriesOnSingleStream(workerCtx, nil, "127.0.0.1")
+
+		loopClient.AssertNumberOfCalls(t, "Send", 1)
+		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{QuerierID: "test-querier-id", QuerierPool: "reserved", Capacity: &schedulerpb.QuerierCapacity{MemoryHeadroomBytes: 1024}})
 	})
 
 	t.Run("should wait until inflight query execution is completed before returning when worker context is canceled", func(t *testing.T) {
@@ -93,7 +121,283 @@ func TestSchedulerProcessor_processQueriesOnSingleStream(t *testing.T) {
 		// We expect Send() to be called twice: first to send the querier ID to scheduler
 		// and then to send the query result.
 		loopClient.AssertNumberOfCalls(t, "Send", 2)
-		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{QuerierID: "test-querier-id"})
+		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{QuerierID: "test-querier-id", Capacity: &schedulerpb.QuerierCapacity{MemoryHeadroomBytes: 1024}})
+	})
+
+	t.Run("should wait until the query-scheduler confirms the stream has been drained before returning when worker context is canceled and graceful drain is enabled", func(t *testing.T) {
+		sp, loopClient, requestHandler := prepareSchedulerProcessor()
+		sp.gracefulDrainEnabled = true
+		sp.gracefulDrainTimeout = time.Minute
+
+		// Keep track of the messages sent to the query-scheduler.
+		sent := make(chan *schedulerpb.QuerierToScheduler, 10)
+		for _, call := range loopClient.ExpectedCalls {
+			if call.Method == "Send" {
+				call.Run(func(args mock.Arguments) {
+					sent <- args.Get(0).(*schedulerpb.QuerierToScheduler)
+				})
+			}
+		}
+
+		recvCount := atomic.NewInt64(0)
+
+		loopClient.On("Recv").Return(func() (*schedulerpb.SchedulerToQuerier, error) {
+			switch recvCount.Inc() {
+			case 1:
+				return &schedulerpb.SchedulerToQuerier{
+					QueryID:         1,
+					HttpRequest:     nil,
+					FrontendAddress: "127.0.0.2",
+					UserID:          "user-1",
+				}, nil
+			case 2:
+				// Confirm the stream has been drained once the querier asked for it and completed the query.
+				var draining, ready bool
+				for !draining || !ready {
+					switch msg := <-sent; {
+					case msg.Type == schedulerpb.DRAINING:
+						draining = true
+					case msg.QuerierID == "":
+						ready = true
+					}
+				}
+
+				// Ensure the execution context hasn't been canceled yet.
+				require.Nil(t, loopClient.Context().Err())
+
+				return &schedulerpb.SchedulerToQuerier{Type: schedulerpb.DRAINED}, nil
+			default:
+				// The stream shouldn't be used anymore, so waiting until terminated.
+				<-loopClient.Context().Done()
+				return nil, loopClient.Context().Err()
+			}
+		})
+
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+
+		requestHandler.On("Handle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			// Cancel the worker context while the query execution is in progress.
+			workerCancel()
+		}).Return(&httpgrpc.HTTPResponse{}, nil)
+
+		sp.processQueriesOnSingleStream(workerCtx, nil, "127.0.0.1")
+
+		// We expect at this point, the execution context has been canceled too.
+		require.Error(t, loopClient.Context().Err())
+
+		// We expect Send() to be called three times: to send the querier ID to scheduler, to ask
+		// the scheduler to drain the stream, and to send the query result.
+		loopClient.AssertNumberOfCalls(t, "Send", 3)
+		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{Type: schedulerpb.DRAINING})
+		loopClient.AssertNumberOfCalls(t, "Recv", 2)
+	})
+
+	t.Run("should report the querier capacity to the query-scheduler", func(t *testing.T) {
+		sp, loopClient, requestHandler := prepareSchedulerProcessor()
+
+		// Simulate queries running on other streams.
+		sp.inflightQueries.Store(2)
+
+		recvCount := atomic.NewInt64(0)
+
+		loopClient.On("Recv").Return(func() (*schedulerpb.SchedulerToQuerier, error) {
+			switch recvCount.Inc() {
+			case 1:
+				return &schedulerpb.SchedulerToQuerier{
+					QueryID:         1,
+					HttpRequest:     nil,
+					FrontendAddress: "127.0.0.2",
+					UserID:          "user-1",
+				}, nil
+			default:
+				// No more messages to process, so waiting until terminated.
+				<-loopClient.Context().Done()
+				return nil, loopClient.Context().Err()
+			}
+		})
+
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+
+		requestHandler.On("Handle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			// The query being executed is counted as in-flight.
+			assert.Equal(t, uint32(3), sp.inflightQueries.Load())
+
+			workerCancel()
+		}).Return(&httpgrpc.HTTPResponse{}, nil)
+
+		sp.processQueriesOnSingleStream(workerCtx, nil, "127.0.0.1")
+
+		// The capacity is reported both when connecting and once the query has been executed.
+		loopClient.AssertNumberOfCalls(t, "Send", 2)
+		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{QuerierID: "test-querier-id", Capacity: &schedulerpb.QuerierCapacity{InflightQueries: 2, MemoryHeadroomBytes: 1024}})
+		loopClient.AssertCalled(t, "Send", &schedulerpb.QuerierToScheduler{Capacity: &schedulerpb.QuerierCapacity{InflightQueries: 2, MemoryHeadroomBytes: 1024}})
+	})
+
+	t.Run("should send the query result to all the query-frontends the query has been enqueued by", func(t *testing.T) {
+		sp, loopClient, requestHandler := prepareSchedulerProcessor()
+		sp.maxMessageSize = 1024
+
+		frontends := map[string]*frontendForQuerierClientMock{}
+		sp.frontendPool = client.NewPool("frontend", client.PoolConfig{}, nil, func(addr string) (client.PoolClient, error) {
+			frontends[addr] = &frontendForQuerierClientMock{}
+			frontends[addr].On("QueryResult", mock.Anything, mock.Anything).Return(&frontendv2pb.QueryResultResponse{}, nil)
+			return frontends[addr], nil
+		}, prometheus.NewGauge(prometheus.GaugeOpts{}), log.NewNopLogger())
+
+		recvCount := atomic.NewInt64(0)
+
+		loopClient.On("Recv").Return(func() (*schedulerpb.SchedulerToQuerier, error) {
+			switch recvCount.Inc() {
+			case 1:
+				return &schedulerpb.SchedulerToQuerier{
+					QueryID:         1,
+					Nonce:           10,
+					HttpRequest:     nil,
+					FrontendAddress: "127.0.0.2",
+					UserID:          "user-1",
+					AdditionalTargets: []*schedulerpb.QueryTarget{
+						{QueryID: 2, Nonce: 20, FrontendAddress: "127.0.0.3"},
+					},
+				}, nil
+			default:
+				// No more messages to process, so waiting until terminated.
+				<-loopClient.Context().Done()
+				return nil, loopClient.Context().Err()
+			}
+		})
+
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+
+		response := &httpgrpc.HTTPResponse{Code: 200, Body: []byte("result")}
+		requestHandler.On("Handle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			workerCancel()
+		}).Return(response, nil)
+
+		sp.processQueriesOnSingleStream(workerCtx, nil, "127.0.0.1")
+
+		require.Len(t, frontends, 2)
+		frontends["127.0.0.2"].AssertCalled(t, "QueryResult", mock.Anything, &frontendv2pb.QueryResultRequest{QueryID: 1, Nonce: 10, HttpResponse: response})
+		frontends["127.0.0.3"].AssertCalled(t, "QueryResult", mock.Anything, &frontendv2pb.QueryResultRequest{QueryID: 2, Nonce: 20, HttpResponse: response})
+	})
+
+	t.Run("should cancel the query and return a limit error once the deadline set by the query-scheduler is reached", func(t *testing.T) {
+		sp, loopClient, requestHandler := prepareSchedulerProcessor()
+		sp.maxMessageSize = 1024
+
+		frontend := &frontendForQuerierClientMock{}
+		frontend.On("QueryResult", mock.Anything, mock.Anything).Return(&frontendv2pb.QueryResultResponse{}, nil)
+		sp.frontendPool = client.NewPool("frontend", client.PoolConfig{}, nil, func(addr string) (client.PoolClient, error) {
+			return frontend, nil
+		}, prometheus.NewGauge(prometheus.GaugeOpts{}), log.NewNopLogger())
+
+		recvCount := atomic.NewInt64(0)
+
+		loopClient.On("Recv").Return(func() (*schedulerpb.SchedulerToQuerier, error) {
+			switch recvCount.Inc() {
+			case 1:
+				return &schedulerpb.SchedulerToQuerier{
+					QueryID:         1,
+					Nonce:           10,
+					HttpRequest:     nil,
+					FrontendAddress: "127.0.0.2",
+					UserID:          "user-1",
+					Deadline:        time.Now().Add(100 * time.Millisecond).UnixNano(),
+				}, nil
+			default:
+				// No more messages to process, so waiting until terminated.
+				<-loopClient.Context().Done()
+				return nil, loopClient.Context().Err()
+			}
+		})
+
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+
+		requestHandler.On("Handle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			// Simulate a query running until it gets canceled.
+			<-args.Get(0).(context.Context).Done()
+			workerCancel()
+		}).Return((*httpgrpc.HTTPResponse)(nil), context.DeadlineExceeded)
+
+		sp.processQueriesOnSingleStream(workerCtx, nil, "127.0.0.1")
+
+		frontend.AssertCalled(t, "QueryResult", mock.Anything, &frontendv2pb.QueryResultRequest{
+			QueryID:      1,
+			Nonce:        10,
+			HttpResponse: &httpgrpc.HTTPResponse{Code: http.StatusUnprocessableEntity, Body: []byte(errMaxQueryExecutionTime)},
+		})
+	})
+
+	t.Run("should release the stream while the query waits for the tenant to be below its max concurrent queries", func(t *testing.T) {
+		sp, loopClient, requestHandler := prepareSchedulerProcessor()
+		sp.maxMessageSize = 1024
+		sp.tenantLimiter = newTenantConcurrencyLimiter(mockLimits{maxQuerierConcurrentQueries: 1}, nil)
+
+		// Simulate a query of the tenant running on another stream.
+		require.True(t, sp.tenantLimiter.tryAcquire("user-1"))
+
+		queryResult := make(chan *frontendv2pb.QueryResultRequest, 1)
+		frontend := &frontendForQuerierClientMock{}
+		frontend.On("QueryResult", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			queryResult <- args.Get(1).(*frontendv2pb.QueryResultRequest)
+		}).Return(&frontendv2pb.QueryResultResponse{}, nil)
+		sp.frontendPool = client.NewPool("frontend", client.PoolConfig{}, nil, func(addr string) (client.PoolClient, error) {
+			return frontend, nil
+		}, prometheus.NewGauge(prometheus.GaugeOpts{}), log.NewNopLogger())
+
+		recvCount := atomic.NewInt64(0)
+
+		loopClient.On("Recv").Return(func() (*schedulerpb.SchedulerToQuerier, error) {
+			switch recvCount.Inc() {
+			case 1:
+				return &schedulerpb.SchedulerToQuerier{
+					QueryID:         1,
+					Nonce:           10,
+					HttpRequest:     nil,
+					FrontendAddress: "127.0.0.2",
+					UserID:          "user-1",
+				}, nil
+			default:
+				// No more messages to process, so waiting until terminated.
+				<-loopClient.Context().Done()
+				return nil, loopClient.Context().Err()
+			}
+		})
+
+		response := &httpgrpc.HTTPResponse{Code: 200, Body: []byte("result")}
+		requestHandler.On("Handle", mock.Anything, mock.Anything).Return(response, nil)
+
+		workerCtx, workerCancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			sp.processQueriesOnSingleStream(workerCtx, nil, "127.0.0.1")
+			close(done)
+		}()
+
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(sp.tenantLimiter.waitingQueries) == 1
+		}, time.Second, time.Millisecond)
+		requestHandler.AssertNotCalled(t, "Handle", mock.Anything, mock.Anything)
+
+		// The stream has been released, so the worker returns once canceled even if the query is still waiting.
+		workerCancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			require.Fail(t, "the worker is expected to return")
+		}
+
+		// The capacity is reported both when connecting and once the query starts waiting.
+		loopClient.AssertNumberOfCalls(t, "Send", 2)
+
+		// The query is executed once the other query of the tenant completes.
+		sp.tenantLimiter.release("user-1")
+		select {
+		case res := <-queryResult:
+			assert.Equal(t, &frontendv2pb.QueryResultRequest{QueryID: 1, Nonce: 10, HttpResponse: response}, res)
+		case <-time.After(time.Second):
+			require.Fail(t, "the query result is expected to be sent to the query-frontend")
+		}
 	})
 
 	t.Run("should not log an error when the query-scheduler is terminates while waiting for the next query to run", func(t *testing.T) {
@@ -139,7 +443,8 @@ func prepareSchedulerProcessor() (*schedulerProcessor, *querierLoopClientMock, *
 
 	requestHandler := &requestHandlerMock{}
 
-	sp, _ := newSchedulerProcessor(Config{QuerierID: "test-querier-id"}, requestHandler, log.NewNopLogger(), nil)
+	sp, _ := newSchedulerProcessor(Config{QuerierID: "test-querier-id"}, requestHandler, nil, log.NewNopLogger(), nil)
+	sp.memoryHeadroom = func() uint64 { return 1024 }
 	sp.schedulerClientFactory = func(_ *grpc.ClientConn) schedulerpb.SchedulerForQuerierClient {
 		return schedulerClient
 	}
@@ -217,6 +522,27 @@ func (m *querierLoopClientMock) RecvMsg(msg interface{}) error {
 	return args.Error(0)
 }
 
+type frontendForQuerierClientMock struct {
+	mock.Mock
+}
+
+func (m *frontendForQuerierClientMock) QueryResult(ctx context.Context, in *frontendv2pb.QueryResultRequest, _ ...grpc.CallOption) (*frontendv2pb.QueryResultResponse, error) {
+	args := m.Called(ctx, in)
+	return args.Get(0).(*frontendv2pb.QueryResultResponse), args.Error(1)
+}
+
+func (m *frontendForQuerierClientMock) Check(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (*grpc_health_v1.HealthCheckResponse, error) {
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (m *frontendForQuerierClientMock) Watch(context.Context, *grpc_health_v1.HealthCheckRequest, ...grpc.CallOption) (grpc_health_v1.Health_WatchClient, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *frontendForQuerierClientMock) Close() error {
+	return nil
+}
+
 type requestHandlerMock struct {
 	mock.Mock
 }
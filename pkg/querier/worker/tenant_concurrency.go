@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Limits is the interface of the per-tenant limits used by the querier worker.
+type Limits interface {
+	// MaxQuerierConcurrentQueries returns the max number of queries of a tenant executing
+	// concurrently in a single querier. 0 means unlimited.
+	MaxQuerierConcurrentQueries(userID string) int
+}
+
+// tenantConcurrencyLimiter limits the number of queries of each tenant executing concurrently in the querier,
+// independently of how many queries of the tenant are dispatched to the querier. The queries exceeding the
+// limit wait in a per-tenant FIFO queue until one of the executing queries of the tenant completes.
+type tenantConcurrencyLimiter struct {
+	// Nil if the limit is disabled for all tenants.
+	limits Limits
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantQueries
+
+	waitingQueries prometheus.Gauge
+	waitDuration   prometheus.Histogram
+}
+
+type tenantQueries struct {
+	executing int
+	// Queries waiting for a slot, in arrival order. The channel is closed once the slot is assigned to the query.
+	waiting []chan struct{}
+}
+
+func newTenantConcurrencyLimiter(limits Limits, reg prometheus.Registerer) *tenantConcurrencyLimiter {
+	return &tenantConcurrencyLimiter{
+		limits:  limits,
+		tenants: map[string]*tenantQueries{},
+		waitingQueries: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_querier_tenant_concurrency_waiting_queries",
+			Help: "Number of queries waiting in the querier because their tenant reached the max number of concurrent queries per querier.",
+		}),
+		waitDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_querier_tenant_concurrency_wait_duration_seconds",
+			Help:    "Time spent by the queries waiting in the querier because their tenant reached the max number of concurrent queries per querier.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}),
+	}
+}
+
+func (l *tenantConcurrencyLimiter) maxConcurrent(userID string) int {
+	if l.limits == nil {
+		return 0
+	}
+	return l.limits.MaxQuerierConcurrentQueries(userID)
+}
+
+// tryAcquire acquires a slot for a query of the tenant if the tenant is below its limit and no other
+// query of the tenant is waiting, and returns whether the slot has been acquired.
+func (l *tenantConcurrencyLimiter) tryAcquire(userID string) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.tryAcquireLocked(userID)
+}
+
+func (l *tenantConcurrencyLimiter) tryAcquireLocked(userID string) bool {
+	t := l.tenants[userID]
+	if t == nil {
+		t = &tenantQueries{}
+		l.tenants[userID] = t
+	}
+
+	if max := l.maxConcurrent(userID); max > 0 && (t.executing >= max || len(t.waiting) > 0) {
+		return false
+	}
+	t.executing++
+	return true
+}
+
+// acquire waits until a slot is available for a query of the tenant, or the context is done.
+func (l *tenantConcurrencyLimiter) acquire(ctx context.Context, userID string) error {
+	l.mtx.Lock()
+	if l.tryAcquireLocked(userID) {
+		l.mtx.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	t := l.tenants[userID]
+	t.waiting = append(t.waiting, ready)
+	l.mtx.Unlock()
+
+	start := time.Now()
+	l.waitingQueries.Inc()
+	defer func() {
+		l.waitingQueries.Dec()
+		l.waitDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	for i, ch := range t.waiting {
+		if ch == ready {
+			t.waiting = append(t.waiting[:i], t.waiting[i+1:]...)
+			l.cleanupLocked(userID, t)
+			return ctx.Err()
+		}
+	}
+
+	// The slot has been assigned to the query while the context was done: give it to the next query.
+	l.releaseLocked(userID, t)
+	return ctx.Err()
+}
+
+// release releases the slot of a query of the tenant, assigning it to the first waiting query of the tenant, if any.
+func (l *tenantConcurrencyLimiter) release(userID string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if t := l.tenants[userID]; t != nil {
+		l.releaseLocked(userID, t)
+	}
+}
+
+func (l *tenantConcurrencyLimiter) releaseLocked(userID string, t *tenantQueries) {
+	t.executing--
+
+	// The limit may have changed since the queries started waiting, so more than one query may be woken up.
+	max := l.maxConcurrent(userID)
+	for len(t.waiting) > 0 && (max <= 0 || t.executing < max) {
+		close(t.waiting[0])
+		t.waiting = t.waiting[1:]
+		t.executing++
+	}
+
+	l.cleanupLocked(userID, t)
+}
+
+func (l *tenantConcurrencyLimiter) cleanupLocked(userID string, t *tenantQueries) {
+	if t.executing <= 0 && len(t.waiting) == 0 {
+		delete(l.tenants, userID)
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantConcurrencyLimiter(t *testing.T) {
+	l := newTenantConcurrencyLimiter(mockLimits{maxQuerierConcurrentQueries: 2}, nil)
+
+	require.True(t, l.tryAcquire("user-1"))
+	require.True(t, l.tryAcquire("user-1"))
+	require.False(t, l.tryAcquire("user-1"))
+
+	// The other tenants are not affected.
+	require.True(t, l.tryAcquire("user-2"))
+	l.release("user-2")
+
+	// The waiting queries get the slots in arrival order.
+	acquired := make(chan int, 2)
+	for i := 1; i <= 2; i++ {
+		i := i
+		go func() {
+			require.NoError(t, l.acquire(context.Background(), "user-1"))
+			acquired <- i
+		}()
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(l.waitingQueries) == float64(i)
+		}, time.Second, time.Millisecond)
+	}
+
+	// A query doesn't skip the waiting ones.
+	require.False(t, l.tryAcquire("user-1"))
+
+	l.release("user-1")
+	assert.Equal(t, 1, <-acquired)
+	l.release("user-1")
+	assert.Equal(t, 2, <-acquired)
+	assert.Equal(t, float64(0), testutil.ToFloat64(l.waitingQueries))
+
+	l.release("user-1")
+	l.release("user-1")
+	assert.Empty(t, l.tenants)
+}
+
+func TestTenantConcurrencyLimiter_AcquireContextDone(t *testing.T) {
+	l := newTenantConcurrencyLimiter(mockLimits{maxQuerierConcurrentQueries: 1}, nil)
+	require.True(t, l.tryAcquire("user-1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.acquire(ctx, "user-1"), context.DeadlineExceeded)
+
+	// The query which gave up waiting doesn't hold a slot.
+	l.release("user-1")
+	assert.Empty(t, l.tenants)
+	require.True(t, l.tryAcquire("user-1"))
+}
+
+func TestTenantConcurrencyLimiter_Disabled(t *testing.T) {
+	for _, limits := range []Limits{nil, mockLimits{}} {
+		l := newTenantConcurrencyLimiter(limits, nil)
+		for i := 0; i < 10; i++ {
+			require.True(t, l.tryAcquire("user-1"))
+		}
+	}
+}
+
+type mockLimits struct {
+	maxQuerierConcurrentQueries int
+}
+
+func (m mockLimits) MaxQuerierConcurrentQueries(string) int {
+	return m.maxQuerierConcurrentQueries
+}
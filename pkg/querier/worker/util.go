@@ -56,3 +56,37 @@ func newExecutionContext(workerCtx context.Context, logger log.Logger) (execCtx
 
 	return
 }
+
+// newDrainingExecutionContext returns a new execution context (execCtx) that wraps the input workerCtx and
+// is used, in place of the one returned by newExecutionContext, when the querier gracefully drains the
+// streams to the query-scheduler before exiting.
+//
+// The caller must call execCancel() once done.
+//
+// Unlike newExecutionContext, the execution context is not canceled once the worker context gets cancelled
+// and there's no inflight query, because a query may have already been dispatched by the query-scheduler
+// and not received yet. The caller is expected to cancel the execution context once the query-scheduler
+// confirmed no more queries will be dispatched. In case it never does, the execution context is canceled
+// once drainTimeout has elapsed since the worker context has been cancelled.
+func newDrainingExecutionContext(workerCtx context.Context, drainTimeout time.Duration, logger log.Logger) (execCtx context.Context, execCancel context.CancelFunc) {
+	execCtx, execCancel = context.WithCancel(context.Background())
+
+	go func() {
+		select {
+		case <-workerCtx.Done():
+			level.Debug(logger).Log("msg", "querier worker context has been canceled, waiting until the query-scheduler confirms the stream has been drained", "timeout", drainTimeout)
+
+			select {
+			case <-execCtx.Done():
+				// The execution context has been explicitly canceled.
+			case <-time.After(drainTimeout):
+				level.Warn(logger).Log("msg", "timed out waiting for the query-scheduler to confirm the stream has been drained, canceling the execution context")
+				execCancel()
+			}
+		case <-execCtx.Done():
+			// Nothing to do. The execution context has been explicitly canceled.
+		}
+	}()
+
+	return
+}
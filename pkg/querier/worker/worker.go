@@ -27,11 +27,14 @@ import (
 )
 
 type Config struct {
-	FrontendAddress  string            `yaml:"frontend_address"`
-	SchedulerAddress string            `yaml:"scheduler_address"`
-	DNSLookupPeriod  time.Duration     `yaml:"dns_lookup_duration" category:"advanced"`
-	QuerierID        string            `yaml:"id" category:"advanced"`
-	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the queriers and the query-frontends / query-schedulers."`
+	FrontendAddress      string            `yaml:"frontend_address"`
+	SchedulerAddress     string            `yaml:"scheduler_address"`
+	DNSLookupPeriod      time.Duration     `yaml:"dns_lookup_duration" category:"advanced"`
+	QuerierID            string            `yaml:"id" category:"advanced"`
+	QuerierPool          string            `yaml:"pool" category:"experimental"`
+	GracefulDrainEnabled bool              `yaml:"graceful_drain_enabled" category:"experimental"`
+	GracefulDrainTimeout time.Duration     `yaml:"graceful_drain_timeout" category:"experimental"`
+	GRPCClientConfig     grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the queriers and the query-frontends / query-schedulers."`
 
 	// This configuration is injected internally.
 	MaxConcurrentRequests   int                       `yaml:"-"` // Must be same as passed to PromQL Engine.
@@ -43,6 +46,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.FrontendAddress, "querier.frontend-address", "", "Address of the query-frontend component, in host:port format. If multiple query-frontends are running, the host should be a DNS resolving to all query-frontend instances. This option should be set only when query-scheduler component is not in use.")
 	f.DurationVar(&cfg.DNSLookupPeriod, "querier.dns-lookup-period", 10*time.Second, "How often to query DNS for query-frontend or query-scheduler address.")
 	f.StringVar(&cfg.QuerierID, "querier.id", "", "Querier ID, sent to the query-frontend to identify requests from the same querier. Defaults to hostname.")
+	f.StringVar(&cfg.QuerierPool, "querier.pool", "", "Querier pool advertised to the query-schedulers. Queriers in a pool only run the queries of the tenants assigned to it via -query-scheduler.querier-pool. Empty to run the queries of the tenants assigned to no pool. This option is supported only when the query-scheduler component is in use.")
+	f.BoolVar(&cfg.GracefulDrainEnabled, "querier.graceful-drain-enabled", false, "When enabled, the querier waits until each query-scheduler confirms no more queries will be dispatched to it before closing the connection on shutdown, so that no query is lost. This option is supported only when the query-scheduler component is in use, and query-schedulers must be upgraded before queriers when enabling it.")
+	f.DurationVar(&cfg.GracefulDrainTimeout, "querier.graceful-drain-timeout", 2*time.Minute, "Maximum time the querier waits on shutdown for a query-scheduler to confirm no more queries will be dispatched to it, when -querier.graceful-drain-enabled is true.")
 
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("querier.frontend-client", f)
 }
@@ -103,7 +109,8 @@ type querierWorker struct {
 	instances map[string]servicediscovery.Instance
 }
 
-func NewQuerierWorker(cfg Config, handler RequestHandler, log log.Logger, reg prometheus.Registerer) (services.Service, error) {
+// NewQuerierWorker makes a new querier worker. The limits can be nil if the per-tenant limits are not enforced.
+func NewQuerierWorker(cfg Config, handler RequestHandler, limits Limits, log log.Logger, reg prometheus.Registerer) (services.Service, error) {
 	if cfg.QuerierID == "" {
 		hostname, err := os.Hostname()
 		if err != nil {
@@ -124,7 +131,7 @@ func NewQuerierWorker(cfg Config, handler RequestHandler, log log.Logger, reg pr
 			return schedulerdiscovery.New(cfg.QuerySchedulerDiscovery, cfg.SchedulerAddress, cfg.DNSLookupPeriod, "querier", receiver, log, reg)
 		}
 
-		processor, servs = newSchedulerProcessor(cfg, handler, log, reg)
+		processor, servs = newSchedulerProcessor(cfg, handler, limits, log, reg)
 
 	case cfg.FrontendAddress != "":
 		level.Info(log).Log("msg", "Starting querier worker connected to query-frontend", "frontend", cfg.FrontendAddress)
@@ -6,6 +6,7 @@
 package ruler
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -21,7 +22,6 @@ import (
 	"github.com/pkg/errors"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/prometheus/model/labels"
-	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/weaveworks/common/user"
 	"gopkg.in/yaml.v3"
 
@@ -71,11 +71,15 @@ type RuleGroup struct {
 	// In order to preserve rule ordering, while exposing type (alerting or recording)
 	// specific properties, both alerting and recording rules are exposed in the
 	// same array.
-	Rules          []rule    `json:"rules"`
-	Interval       float64   `json:"interval"`
-	LastEvaluation time.Time `json:"lastEvaluation"`
-	EvaluationTime float64   `json:"evaluationTime"`
-	SourceTenants  []string  `json:"sourceTenants"`
+	Rules    []rule  `json:"rules"`
+	Interval float64 `json:"interval"`
+	// The evaluation interval configured for the group. It's longer than the interval, which is the effective one,
+	// when the configured interval has been rewritten to the tenant's minimum rule evaluation interval.
+	ConfiguredInterval float64   `json:"configuredInterval"`
+	LastEvaluation     time.Time `json:"lastEvaluation"`
+	EvaluationTime     float64   `json:"evaluationTime"`
+	SourceTenants      []string  `json:"sourceTenants"`
+	DestinationTenant  string    `json:"destinationTenant,omitempty"`
 }
 
 type rule interface{}
@@ -166,13 +170,20 @@ func (a *API) PrometheusRules(w http.ResponseWriter, req *http.Request) {
 
 	for _, g := range rgs {
 		grp := RuleGroup{
-			Name:           g.Group.Name,
-			File:           g.Group.Namespace,
-			Rules:          make([]rule, len(g.ActiveRules)),
-			Interval:       g.Group.Interval.Seconds(),
-			LastEvaluation: g.GetEvaluationTimestamp(),
-			EvaluationTime: g.GetEvaluationDuration().Seconds(),
-			SourceTenants:  g.Group.GetSourceTenants(),
+			Name:               g.Group.Name,
+			File:               g.Group.Namespace,
+			Rules:              make([]rule, len(g.ActiveRules)),
+			Interval:           g.Group.Interval.Seconds(),
+			ConfiguredInterval: g.Group.Interval.Seconds(),
+			LastEvaluation:     g.GetEvaluationTimestamp(),
+			EvaluationTime:     g.GetEvaluationDuration().Seconds(),
+			SourceTenants:      g.Group.GetSourceTenants(),
+			DestinationTenant:  g.Group.GetDestinationTenant(),
+		}
+
+		// Rulers running an older version don't return the configured interval.
+		if g.ConfiguredInterval > 0 {
+			grp.ConfiguredInterval = g.ConfiguredInterval.Seconds()
 		}
 
 		for i, rl := range g.ActiveRules {
@@ -413,10 +424,87 @@ func (a *API) ListRules(w http.ResponseWriter, req *http.Request) {
 
 	level.Debug(logger).Log("msg", "retrieved rule groups from rule store", "userID", userID, "num_namespaces", len(rgs))
 
-	formatted := rgs.Formatted()
+	formatted := rgs.FormattedRuleGroups()
 	marshalAndSend(formatted, w, logger)
 }
 
+// ListDuplicateRules reports the duplicate rule groups and rules across the tenant's namespaces, with the
+// suggested consolidations and the estimated evaluations they would save.
+func (a *API) ListDuplicateRules(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+
+	userID, _, _, err := parseRequest(req, false, false)
+	if err != nil {
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	rgs, err := a.loadAllRuleGroups(req.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := findDuplicateRules(rgs, func(interval time.Duration) time.Duration {
+		return a.ruler.tenantRuleGroupInterval(userID, interval)
+	})
+
+	level.Debug(logger).Log("msg", "analysed rule groups for duplicates", "userID", userID, "rule_groups", len(rgs), "duplicate_groups", len(report.DuplicateGroups), "duplicate_rules", len(report.DuplicateRules))
+	marshalAndSend(report, w, logger)
+}
+
+// GetRuleDependencies returns the graph of the dependencies between the tenant's recording rules and the rules
+// selecting the series recorded by them. If the "record" parameter is set, the graph only includes the recording
+// rules recording it and the rules depending on them, recursively.
+func (a *API) GetRuleDependencies(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+
+	userID, _, _, err := parseRequest(req, false, false)
+	if err != nil {
+		respondError(logger, w, err.Error())
+		return
+	}
+
+	rgs, err := a.loadAllRuleGroups(req.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	graph := buildRuleDependencyGraph(rgs, req.URL.Query().Get("record"))
+	level.Debug(logger).Log("msg", "built rule dependency graph", "userID", userID, "rule_groups", len(rgs), "nodes", len(graph.Nodes), "edges", len(graph.Edges))
+
+	b, err := json.Marshal(&response{
+		Status: "success",
+		Data:   graph,
+	})
+	if err != nil {
+		level.Error(logger).Log("msg", "error marshaling json response", "err", err)
+		respondError(logger, w, "unable to marshal the requested data")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if n, err := w.Write(b); err != nil {
+		level.Error(logger).Log("msg", "error writing response", "bytesWritten", n, "err", err)
+	}
+}
+
+// loadAllRuleGroups returns all the rule groups of the tenant, with their rules.
+func (a *API) loadAllRuleGroups(ctx context.Context, userID string) (rulespb.RuleGroupList, error) {
+	rgs, err := a.store.ListRuleGroupsForUserAndNamespace(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rgs) > 0 {
+		if err := a.store.LoadRuleGroups(ctx, map[string]rulespb.RuleGroupList{userID: rgs}); err != nil {
+			return nil, err
+		}
+	}
+	return rgs, nil
+}
+
 func (a *API) GetRuleGroup(w http.ResponseWriter, req *http.Request) {
 	logger := util_log.WithContext(req.Context(), a.logger)
 	userID, namespace, groupName, err := parseRequest(req, true, true)
@@ -435,7 +523,7 @@ func (a *API) GetRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	formatted := rulespb.FromProto(rg)
+	formatted := rulespb.RuleGroupFromProto(rg)
 	marshalAndSend(formatted, w, logger)
 }
 
@@ -456,7 +544,7 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 
 	level.Debug(logger).Log("msg", "attempting to unmarshal rulegroup", "userID", userID, "group", string(payload))
 
-	rg := rulefmt.RuleGroup{}
+	rg := rulespb.RuleGroup{}
 	err = yaml.Unmarshal(payload, &rg)
 	if err != nil {
 		level.Error(logger).Log("msg", "unable to unmarshal rule group payload", "err", err.Error())
@@ -464,7 +552,7 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	errs := a.ruler.manager.ValidateRuleGroup(rg)
+	errs := a.ruler.manager.ValidateRuleGroup(rg.RuleGroup)
 	if len(errs) > 0 {
 		e := []string{}
 		for _, err := range errs {
@@ -482,6 +570,18 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if err := a.ruler.AssertMinRuleEvaluationInterval(userID, time.Duration(rg.Interval)); err != nil {
+		level.Error(logger).Log("msg", "limit validation failure", "err", err.Error(), "user", userID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.ruler.AssertDestinationTenant(userID, rg); err != nil {
+		level.Error(logger).Log("msg", "limit validation failure", "err", err.Error(), "user", userID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	rgs, err := a.store.ListRuleGroupsForUserAndNamespace(req.Context(), userID, "")
 	if err != nil {
 		level.Error(logger).Log("msg", "unable to fetch current rule groups for validation", "err", err.Error(), "user", userID)
@@ -495,7 +595,7 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	rgProto := rulespb.ToProto(userID, namespace, rg)
+	rgProto := rulespb.RuleGroupToProto(userID, namespace, rg)
 
 	level.Debug(logger).Log("msg", "attempting to store rulegroup", "userID", userID, "group", rgProto.String())
 	err = a.store.SetRuleGroup(req.Context(), userID, namespace, rgProto)
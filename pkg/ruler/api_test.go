@@ -20,6 +20,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/grafana/dskit/services"
 	"github.com/grafana/dskit/test"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
@@ -70,7 +71,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:           60,
+					ConfiguredInterval: 60,
 				},
 			},
 		},
@@ -101,7 +103,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Type:   "recording",
 						},
 					},
-					Interval: 60,
+					Interval:           60,
+					ConfiguredInterval: 60,
 				},
 			},
 		},
@@ -134,7 +137,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:           60,
+					ConfiguredInterval: 60,
 				},
 			},
 		},
@@ -186,7 +190,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:           60,
+					ConfiguredInterval: 60,
 				},
 			},
 		},
@@ -223,7 +228,8 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts: []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:           60,
+					ConfiguredInterval: 60,
 				},
 			},
 		},
@@ -259,7 +265,40 @@ func TestRuler_PrometheusRules(t *testing.T) {
 							Alerts:        []*Alert{},
 						},
 					},
-					Interval: 60,
+					Interval:           60,
+					ConfiguredInterval: 60,
+				},
+			},
+		},
+		"should evaluate the rule groups at the min rule evaluation interval if the interval rewrite is enabled": {
+			configuredRules: rulespb.RuleGroupList{
+				&rulespb.RuleGroupDesc{
+					Name:      "group1",
+					Namespace: "namespace1",
+					User:      userID,
+					Rules:     []*rulespb.RuleDesc{mockRecordingRuleDesc("UP_RULE", "up")},
+					Interval:  interval,
+				},
+			},
+			limits: validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+				tenantLimits[userID] = validation.MockDefaultLimits()
+				tenantLimits[userID].RulerMinRuleEvaluationInterval = model.Duration(2 * interval)
+				tenantLimits[userID].RulerMinRuleEvaluationIntervalRewrite = true
+			}),
+			expectedRules: []*RuleGroup{
+				{
+					Name: "group1",
+					File: "namespace1",
+					Rules: []rule{
+						&recordingRule{
+							Name:   "UP_RULE",
+							Query:  "up",
+							Health: "unknown",
+							Type:   "recording",
+						},
+					},
+					Interval:           120,
+					ConfiguredInterval: 60,
 				},
 			},
 		},
@@ -662,6 +701,261 @@ rules:
 	}
 }
 
+func TestRuler_MinRuleEvaluationInterval(t *testing.T) {
+	const input = `
+name: test
+interval: 15s
+rules:
+- record: up_rule
+  expr: up{}
+`
+
+	tc := map[string]struct {
+		rewriteEnabled bool
+		status         int
+		output         string
+	}{
+		"should reject the rule group if the interval is shorter than the min evaluation interval": {
+			rewriteEnabled: false,
+			status:         400,
+			output:         "per-user min rule evaluation interval (limit: 1m0s actual: 15s) not reached\n",
+		},
+		"should accept the rule group if the interval is shorter than the min evaluation interval and the rewrite is enabled": {
+			rewriteEnabled: true,
+			status:         202,
+			output:         "{\"status\":\"success\",\"data\":null,\"errorType\":\"\",\"error\":\"\"}",
+		},
+	}
+
+	for name, tt := range tc {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultRulerConfig(t)
+
+			r := prepareRuler(t, cfg, newMockRuleStore(make(map[string]rulespb.RuleGroupList)), withStart(), withLimits(validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+				defaults.RulerMaxRuleGroupsPerTenant = 1
+				defaults.RulerMaxRulesPerRuleGroup = 1
+				defaults.RulerMinRuleEvaluationInterval = model.Duration(time.Minute)
+				defaults.RulerMinRuleEvaluationIntervalRewrite = tt.rewriteEnabled
+			})))
+
+			a := NewAPI(r, r.store, log.NewNopLogger())
+
+			router := mux.NewRouter()
+			router.Path("/prometheus/config/v1/rules/{namespace}").Methods("POST").HandlerFunc(a.CreateRuleGroup)
+			req := requestFor(t, http.MethodPost, "https://localhost:8080/prometheus/config/v1/rules/namespace", strings.NewReader(input), "user1")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, tt.output, w.Body.String())
+		})
+	}
+}
+
+func TestRuler_DestinationTenant(t *testing.T) {
+	tc := map[string]struct {
+		input  string
+		status int
+		output string
+	}{
+		"should accept the rule group if the destination tenant is allowed": {
+			input: `
+name: test
+destination_tenant: platform
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			status: 202,
+			output: "{\"status\":\"success\",\"data\":null,\"errorType\":\"\",\"error\":\"\"}",
+		},
+		"should accept the rule group if the destination tenant is the tenant itself": {
+			input: `
+name: test
+destination_tenant: user1
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			status: 202,
+			output: "{\"status\":\"success\",\"data\":null,\"errorType\":\"\",\"error\":\"\"}",
+		},
+		"should reject the rule group if the destination tenant is not allowed": {
+			input: `
+name: test
+destination_tenant: another
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			status: 400,
+			output: "per-user allowed destination tenants don't include the rule group destination tenant another\n",
+		},
+		"should reject the rule group if it has a destination tenant and alerting rules": {
+			input: `
+name: test
+destination_tenant: platform
+rules:
+- alert: up_alert
+  expr: up{} < 1
+`,
+			status: 400,
+			output: "a rule group with a destination tenant can only contain recording rules\n",
+		},
+	}
+
+	for name, tt := range tc {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaultRulerConfig(t)
+
+			r := prepareRuler(t, cfg, newMockRuleStore(make(map[string]rulespb.RuleGroupList)), withStart(), withLimits(validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+				defaults.RulerAllowedDestinationTenants = []string{"platform"}
+			})))
+
+			a := NewAPI(r, r.store, log.NewNopLogger())
+
+			router := mux.NewRouter()
+			router.Path("/prometheus/config/v1/rules/{namespace}").Methods("POST").HandlerFunc(a.CreateRuleGroup)
+			req := requestFor(t, http.MethodPost, "https://localhost:8080/prometheus/config/v1/rules/namespace", strings.NewReader(tt.input), "user1")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, tt.output, w.Body.String())
+		})
+	}
+}
+
+func TestRuler_ListDuplicateRules(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+
+	mockRulesNamespaces := map[string]rulespb.RuleGroupList{
+		"user1": {
+			&rulespb.RuleGroupDesc{
+				Name:      "group1",
+				Namespace: "namespace1",
+				User:      "user1",
+				Rules:     []*rulespb.RuleDesc{mockRecordingRuleDesc("UP_RULE", "up"), mockAlertingRuleDesc("UP_ALERT", "up < 1")},
+				Interval:  interval,
+			},
+			&rulespb.RuleGroupDesc{
+				Name:      "group2",
+				Namespace: "namespace2",
+				User:      "user1",
+				Rules:     []*rulespb.RuleDesc{mockRecordingRuleDesc("UP2_RULE", "up{}")},
+			},
+		},
+	}
+
+	r := prepareRuler(t, cfg, newMockRuleStore(mockRulesNamespaces), withStart(), withLimits(validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+		defaults.RulerMinRuleEvaluationInterval = model.Duration(2 * time.Minute)
+		defaults.RulerMinRuleEvaluationIntervalRewrite = true
+	})))
+	a := NewAPI(r, r.store, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/prometheus/config/v1/analysis/duplicate_rules").Methods(http.MethodGet).HandlerFunc(a.ListDuplicateRules)
+
+	for userID, expected := range map[string]string{
+		"user1": `duplicate_groups: []
+duplicate_rules:
+    - type: recording
+      expr: up
+      rules:
+        - namespace: namespace1
+          group: group1
+          name: UP_RULE
+          expr: up
+          interval: 2m
+        - namespace: namespace2
+          group: group2
+          name: UP2_RULE
+          expr: up{}
+          interval: 2m
+      identical: false
+      same_definition: false
+      estimated_evaluations_saved_per_minute: 0.5
+      suggestion: keep the recording rule "UP_RULE" in the rule group "group1" of the namespace "namespace1" and re-point the consumers of the other 1 rules to it before deleting them, because the rules evaluate the same expression but differ by name, labels, for or keep_firing_for
+estimated_evaluations_saved_per_minute: 0.5
+`,
+		"user2": `duplicate_groups: []
+duplicate_rules: []
+estimated_evaluations_saved_per_minute: 0
+`,
+	} {
+		req := requestFor(t, http.MethodGet, "https://localhost:8080/prometheus/config/v1/analysis/duplicate_rules", nil, userID)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, expected, w.Body.String())
+	}
+}
+
+func TestRuler_GetRuleDependencies(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+
+	mockRulesNamespaces := map[string]rulespb.RuleGroupList{
+		"user1": {
+			&rulespb.RuleGroupDesc{
+				Name:      "group1",
+				Namespace: "namespace1",
+				User:      "user1",
+				Rules:     []*rulespb.RuleDesc{mockRecordingRuleDesc("UP_RULE", "up"), mockAlertingRuleDesc("UP_ALERT", "UP_RULE < 1")},
+				Interval:  interval,
+			},
+			&rulespb.RuleGroupDesc{
+				Name:      "group2",
+				Namespace: "namespace2",
+				User:      "user1",
+				Rules:     []*rulespb.RuleDesc{mockRecordingRuleDesc("DOWN_RULE", "1 - up")},
+			},
+		},
+	}
+
+	r := prepareRuler(t, cfg, newMockRuleStore(mockRulesNamespaces), withStart())
+	a := NewAPI(r, r.store, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/prometheus/config/v1/analysis/rule_dependencies").Methods(http.MethodGet).HandlerFunc(a.GetRuleDependencies)
+
+	for url, expected := range map[string]string{
+		"https://localhost:8080/prometheus/config/v1/analysis/rule_dependencies": `{
+			"status": "success",
+			"data": {
+				"nodes": [
+					{"id": 0, "type": "recording", "namespace": "namespace1", "group": "group1", "name": "UP_RULE", "expr": "up", "dependents": 1, "transitive_dependents": 1},
+					{"id": 1, "type": "alerting", "namespace": "namespace1", "group": "group1", "name": "UP_ALERT", "expr": "UP_RULE < 1", "dependents": 0, "transitive_dependents": 0},
+					{"id": 2, "type": "recording", "namespace": "namespace2", "group": "group2", "name": "DOWN_RULE", "expr": "1 - up", "dependents": 0, "transitive_dependents": 0}
+				],
+				"edges": [
+					{"from": 0, "to": 1, "metric": "UP_RULE"}
+				]
+			},
+			"errorType": "",
+			"error": ""
+		}`,
+		"https://localhost:8080/prometheus/config/v1/analysis/rule_dependencies?record=DOWN_RULE": `{
+			"status": "success",
+			"data": {
+				"nodes": [
+					{"id": 2, "type": "recording", "namespace": "namespace2", "group": "group2", "name": "DOWN_RULE", "expr": "1 - up", "dependents": 0, "transitive_dependents": 0}
+				],
+				"edges": []
+			},
+			"errorType": "",
+			"error": ""
+		}`,
+	} {
+		req := requestFor(t, http.MethodGet, url, nil, "user1")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, expected, w.Body.String())
+	}
+}
+
 func TestAlertStateDescToPrometheusAlert(t *testing.T) {
 	t.Run("should not export KeepFiringSince if it's the zero value", func(t *testing.T) {
 		actual := alertStateDescToPrometheusAlert(&AlertStateDesc{})
@@ -8,6 +8,7 @@ package ruler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/go-kit/log"
@@ -21,6 +22,7 @@ import (
 	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/notifier"
 	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/weaveworks/common/httpgrpc"
@@ -28,6 +30,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier"
+	"github.com/grafana/mimir/pkg/querier/engine"
 	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 )
@@ -41,6 +44,13 @@ type PusherAppender struct {
 	failedWrites prometheus.Counter
 	totalWrites  prometheus.Counter
 
+	// Optional: the writes to a destination tenant other than the rule group's tenant.
+	crossTenantFailedWrites prometheus.Counter
+	crossTenantWrites       prometheus.Counter
+
+	// Optional: the error returned on commit, without writing, when the writes aren't allowed.
+	err error
+
 	ctx             context.Context
 	pusher          Pusher
 	labels          []labels.Labels
@@ -80,7 +90,15 @@ func (a *PusherAppender) AppendHistogram(_ storage.SeriesRef, l labels.Labels, t
 }
 
 func (a *PusherAppender) Commit() error {
+	if a.err != nil {
+		_ = a.Rollback()
+		return a.err
+	}
+
 	a.totalWrites.Inc()
+	if a.crossTenantWrites != nil {
+		a.crossTenantWrites.Inc()
+	}
 
 	// Since a.pusher is distributor, client.ReuseSlice will be called in a.pusher.Push.
 	// We shouldn't call client.ReuseSlice here.
@@ -92,6 +110,9 @@ func (a *PusherAppender) Commit() error {
 		// Don't report errors that ended with 4xx HTTP status code (series limits, duplicate samples, out of order, etc.)
 		if resp, ok := httpgrpc.HTTPResponseFromError(err); !ok || resp.Code/100 != 4 {
 			a.failedWrites.Inc()
+			if a.crossTenantFailedWrites != nil {
+				a.crossTenantFailedWrites.Inc()
+			}
 		}
 	}
 
@@ -110,23 +131,30 @@ func (a *PusherAppender) Rollback() error {
 type PusherAppendable struct {
 	pusher Pusher
 	userID string
+	limits RulesLimits
 
 	totalWrites  prometheus.Counter
 	failedWrites prometheus.Counter
+
+	crossTenantWrites       *prometheus.CounterVec
+	crossTenantFailedWrites *prometheus.CounterVec
 }
 
-func NewPusherAppendable(pusher Pusher, userID string, limits RulesLimits, totalWrites, failedWrites prometheus.Counter) *PusherAppendable {
+func NewPusherAppendable(pusher Pusher, userID string, limits RulesLimits, totalWrites, failedWrites prometheus.Counter, crossTenantWrites, crossTenantFailedWrites *prometheus.CounterVec) *PusherAppendable {
 	return &PusherAppendable{
-		pusher:       pusher,
-		userID:       userID,
-		totalWrites:  totalWrites,
-		failedWrites: failedWrites,
+		pusher:                  pusher,
+		userID:                  userID,
+		limits:                  limits,
+		totalWrites:             totalWrites,
+		failedWrites:            failedWrites,
+		crossTenantWrites:       crossTenantWrites,
+		crossTenantFailedWrites: crossTenantFailedWrites,
 	}
 }
 
 // Appender returns a storage.Appender
 func (t *PusherAppendable) Appender(ctx context.Context) storage.Appender {
-	return &PusherAppender{
+	a := &PusherAppender{
 		failedWrites: t.failedWrites,
 		totalWrites:  t.totalWrites,
 
@@ -134,6 +162,18 @@ func (t *PusherAppendable) Appender(ctx context.Context) storage.Appender {
 		pusher: t.pusher,
 		userID: t.userID,
 	}
+
+	// The results of the rule groups with a destination tenant are written to the destination tenant,
+	// as long as the tenant is still allowed to write to it.
+	if destination := destinationTenantFromContext(ctx); destination != "" && destination != t.userID {
+		if !isDestinationTenantAllowed(t.limits, t.userID, destination) {
+			a.err = fmt.Errorf(errDestinationTenantNotAllowed, destination)
+		}
+		a.userID = destination
+		a.crossTenantWrites = t.crossTenantWrites.WithLabelValues(t.userID, destination)
+		a.crossTenantFailedWrites = t.crossTenantFailedWrites.WithLabelValues(t.userID, destination)
+	}
+	return a
 }
 
 // RulesLimits defines limits used by Ruler.
@@ -144,6 +184,10 @@ type RulesLimits interface {
 	RulerMaxRulesPerRuleGroup(userID string) int
 	RulerRecordingRulesEvaluationEnabled(userID string) bool
 	RulerAlertingRulesEvaluationEnabled(userID string) bool
+	RulerMinRuleEvaluationInterval(userID string) time.Duration
+	RulerMinRuleEvaluationIntervalRewrite(userID string) bool
+	RulerAllowedDestinationTenants(userID string) []string
+	EnabledPromQLExperimentalFunctions(userID string) []string
 }
 
 func MetricsQueryFunc(qf rules.QueryFunc, queries, failedQueries prometheus.Counter) rules.QueryFunc {
@@ -184,6 +228,23 @@ func MetricsQueryFunc(qf rules.QueryFunc, queries, failedQueries prometheus.Coun
 	}
 }
 
+// ExperimentalFunctionsQueryFunc fails the queries using experimental PromQL functions not enabled for the tenant.
+func ExperimentalFunctionsQueryFunc(qf rules.QueryFunc, userID string, overrides RulesLimits) rules.QueryFunc {
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		// The queries which can't be parsed are left to fail when run.
+		if expr, err := parser.ParseExpr(qs); err == nil {
+			enabled := overrides.EnabledPromQLExperimentalFunctions(userID)
+			for _, f := range engine.FindExperimentalFunctions(expr) {
+				if !engine.IsExperimentalFunctionEnabled(f, enabled) {
+					return nil, fmt.Errorf("function %q is experimental and not enabled for the tenant %s", f, userID)
+				}
+			}
+		}
+
+		return qf(ctx, qs, t)
+	}
+}
+
 func RecordAndReportRuleQueryMetrics(qf rules.QueryFunc, queryTime prometheus.Counter, logger log.Logger) rules.QueryFunc {
 	if queryTime == nil {
 		return qf
@@ -261,6 +322,14 @@ func DefaultTenantManagerFactory(
 		Name: "cortex_ruler_write_requests_failed_total",
 		Help: "Number of failed write requests to ingesters.",
 	})
+	crossTenantWrites := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_ruler_cross_tenant_write_requests_total",
+		Help: "Number of write requests to ingesters of the rule groups writing their results to a destination tenant other than their own.",
+	}, []string{"user", "destination_user"})
+	crossTenantFailedWrites := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_ruler_cross_tenant_write_requests_failed_total",
+		Help: "Number of failed write requests to ingesters of the rule groups writing their results to a destination tenant other than their own.",
+	}, []string{"user", "destination_user"})
 
 	totalQueries := promauto.With(reg).NewCounter(prometheus.CounterOpts{
 		Name: "cortex_ruler_queries_total",
@@ -270,6 +339,14 @@ func DefaultTenantManagerFactory(
 		Name: "cortex_ruler_queries_failed_total",
 		Help: "Number of failed queries by ruler.",
 	})
+	evaluationResultsCacheRequests := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_ruler_evaluation_results_cache_requests_total",
+		Help: "Total number of rule queries looked up in the evaluation results cache.",
+	})
+	evaluationResultsCacheHits := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_ruler_evaluation_results_cache_hits_total",
+		Help: "Total number of rule queries whose results have been reused from the evaluation results cache.",
+	})
 	var rulerQuerySeconds *prometheus.CounterVec
 	if cfg.EnableQueryStats {
 		rulerQuerySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
@@ -284,15 +361,17 @@ func DefaultTenantManagerFactory(
 		}
 		var wrappedQueryFunc rules.QueryFunc
 
-		wrappedQueryFunc = MetricsQueryFunc(queryFunc, totalQueries, failedQueries)
+		wrappedQueryFunc = ExperimentalFunctionsQueryFunc(queryFunc, userID, overrides)
+		wrappedQueryFunc = MetricsQueryFunc(wrappedQueryFunc, totalQueries, failedQueries)
 		wrappedQueryFunc = RecordAndReportRuleQueryMetrics(wrappedQueryFunc, queryTime, logger)
+		wrappedQueryFunc = EvaluationResultsCacheQueryFunc(wrappedQueryFunc, cfg.EvaluationResultsCacheTTL, evaluationResultsCacheRequests, evaluationResultsCacheHits)
 
 		return rules.NewManager(&rules.ManagerOptions{
-			Appendable:                 NewPusherAppendable(p, userID, overrides, totalWrites, failedWrites),
+			Appendable:                 NewPusherAppendable(p, userID, overrides, totalWrites, failedWrites, crossTenantWrites, crossTenantFailedWrites),
 			Queryable:                  embeddedQueryable,
 			QueryFunc:                  wrappedQueryFunc,
 			Context:                    user.InjectOrgID(ctx, userID),
-			GroupEvaluationContextFunc: FederatedGroupContextFunc,
+			GroupEvaluationContextFunc: GroupEvaluationContextFunc,
 			ExternalURL:                cfg.ExternalURL.URL,
 			NotifyFunc:                 rules.SendAlerts(notifier, cfg.ExternalURL.String()),
 			Logger:                     log.With(logger, "user", userID),
@@ -8,12 +8,14 @@ package ruler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"net/http"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -29,6 +31,7 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/ruler/rulespb"
@@ -38,18 +41,20 @@ import (
 
 type fakePusher struct {
 	request  *mimirpb.WriteRequest
+	userID   string
 	response *mimirpb.WriteResponse
 	err      error
 }
 
 func (p *fakePusher) Push(ctx context.Context, r *mimirpb.WriteRequest) (*mimirpb.WriteResponse, error) {
 	p.request = r
+	p.userID, _ = tenant.TenantID(ctx)
 	return p.response, p.err
 }
 
 func TestPusherAppendable(t *testing.T) {
 	pusher := &fakePusher{}
-	pa := NewPusherAppendable(pusher, "user-1", nil, promauto.With(nil).NewCounter(prometheus.CounterOpts{}), promauto.With(nil).NewCounter(prometheus.CounterOpts{}))
+	pa := NewPusherAppendable(pusher, "user-1", nil, promauto.With(nil).NewCounter(prometheus.CounterOpts{}), promauto.With(nil).NewCounter(prometheus.CounterOpts{}), nil, nil)
 
 	type sample struct {
 		series         string
@@ -246,7 +251,7 @@ func TestPusherErrors(t *testing.T) {
 				defaults.RulerEvaluationDelay = 0
 			})
 
-			pa := NewPusherAppendable(pusher, "user-1", limits, writes, failures)
+			pa := NewPusherAppendable(pusher, "user-1", limits, writes, failures, nil, nil)
 
 			lbls, err := parser.ParseMetric("foo_bar")
 			require.NoError(t, err)
@@ -263,6 +268,81 @@ func TestPusherErrors(t *testing.T) {
 	}
 }
 
+func TestPusherAppendable_DestinationTenant(t *testing.T) {
+	for name, tc := range map[string]struct {
+		destinationTenant    string
+		allowedTenants       []string
+		pushErr              error
+		expectedErr          error
+		expectedUserID       string
+		expectedCrossWrites  int
+		expectedCrossFailure int
+	}{
+		"no destination tenant": {
+			expectedUserID: "user-1",
+		},
+		"destination tenant equal to the rule group tenant": {
+			destinationTenant: "user-1",
+			expectedUserID:    "user-1",
+		},
+		"allowed destination tenant": {
+			destinationTenant:   "platform",
+			allowedTenants:      []string{"platform"},
+			expectedUserID:      "platform",
+			expectedCrossWrites: 1,
+		},
+		"allowed destination tenant, failed write": {
+			destinationTenant:    "platform",
+			allowedTenants:       []string{"platform"},
+			pushErr:              httpgrpc.Errorf(http.StatusInternalServerError, "test error"),
+			expectedErr:          httpgrpc.Errorf(http.StatusInternalServerError, "test error"),
+			expectedUserID:       "platform",
+			expectedCrossWrites:  1,
+			expectedCrossFailure: 1,
+		},
+		"destination tenant not allowed": {
+			destinationTenant: "platform",
+			allowedTenants:    []string{"another"},
+			expectedErr:       fmt.Errorf(errDestinationTenantNotAllowed, "platform"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pusher := &fakePusher{err: tc.pushErr, response: &mimirpb.WriteResponse{}}
+			limits := validation.MockOverrides(func(defaults *validation.Limits, _ map[string]*validation.Limits) {
+				defaults.RulerAllowedDestinationTenants = tc.allowedTenants
+			})
+
+			reg := prometheus.NewPedanticRegistry()
+			crossTenantWrites := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{Name: "writes"}, []string{"user", "destination_user"})
+			crossTenantFailures := promauto.With(reg).NewCounterVec(prometheus.CounterOpts{Name: "failures"}, []string{"user", "destination_user"})
+			pa := NewPusherAppendable(pusher, "user-1", limits, promauto.With(nil).NewCounter(prometheus.CounterOpts{}), promauto.With(nil).NewCounter(prometheus.CounterOpts{}), crossTenantWrites, crossTenantFailures)
+
+			tenants := newDestinationTenants()
+			tenants.set(rulespb.RuleGroupList{{Name: "group", Namespace: "namespace", User: "user-1", DestinationTenant: tc.destinationTenant}})
+
+			group := rules.NewGroup(rules.GroupOptions{
+				Name: "group",
+				File: "/rules/user-1/namespace",
+				Opts: &rules.ManagerOptions{},
+			})
+			ctx := withDestinationTenants(user.InjectOrgID(context.Background(), "user-1"), tenants)
+			ctx = GroupEvaluationContextFunc(ctx, group)
+
+			a := pa.Appender(ctx)
+			_, err := a.Append(0, labels.FromStrings(labels.MetricName, "foo_bar"), 120_000, 1)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedErr, a.Commit())
+
+			require.Equal(t, tc.expectedUserID, pusher.userID)
+			if tc.expectedUserID == "" {
+				require.Nil(t, pusher.request)
+			}
+			require.Equal(t, tc.expectedCrossWrites, int(testutil.ToFloat64(crossTenantWrites.WithLabelValues("user-1", "platform"))))
+			require.Equal(t, tc.expectedCrossFailure, int(testutil.ToFloat64(crossTenantFailures.WithLabelValues("user-1", "platform"))))
+		})
+	}
+}
+
 func TestMetricsQueryFuncErrors(t *testing.T) {
 	for name, tc := range map[string]struct {
 		returnedError         error
@@ -349,6 +429,37 @@ func TestMetricsQueryFuncErrors(t *testing.T) {
 	}
 }
 
+func TestExperimentalFunctionsQueryFunc(t *testing.T) {
+	overrides := validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+		tenantLimits["enabled"] = validation.MockDefaultLimits()
+		tenantLimits["enabled"].EnabledPromQLExperimentalFunctions = []string{"mad_over_time"}
+	})
+
+	mockFunc := func(ctx context.Context, q string, t time.Time) (promql.Vector, error) {
+		return promql.Vector{}, nil
+	}
+
+	for _, tc := range []struct {
+		userID      string
+		query       string
+		expectedErr string
+	}{
+		{userID: "enabled", query: "mad_over_time(up[5m])"},
+		{userID: "enabled", query: `sort_by_label(up, "job")`, expectedErr: `function "sort_by_label" is experimental and not enabled for the tenant enabled`},
+		{userID: "disabled", query: "mad_over_time(up[5m])", expectedErr: `function "mad_over_time" is experimental and not enabled for the tenant disabled`},
+		{userID: "disabled", query: "sum(up)"},
+	} {
+		qf := ExperimentalFunctionsQueryFunc(mockFunc, tc.userID, overrides)
+
+		_, err := qf(context.Background(), tc.query, time.Now())
+		if tc.expectedErr != "" {
+			require.EqualError(t, err, tc.expectedErr)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
 func TestRecordAndReportRuleQueryMetrics(t *testing.T) {
 	queryTime := promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"})
 
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"sort"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+// RuleDependencyGraph is the graph of the dependencies between a tenant's rules: each edge goes from a recording
+// rule to a rule whose expression selects the series recorded by it.
+type RuleDependencyGraph struct {
+	Nodes []RuleDependencyNode `json:"nodes"`
+	Edges []RuleDependencyEdge `json:"edges"`
+}
+
+// RuleDependencyNode is a rule of a RuleDependencyGraph.
+type RuleDependencyNode struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Group     string `json:"group"`
+	Name      string `json:"name"`
+	Expr      string `json:"expr"`
+
+	// Dependents is the number of rules selecting the series recorded by the rule, while TransitiveDependents
+	// includes the rules depending on them too, recursively. They're the rules affected by changing or deleting
+	// the rule.
+	Dependents           int `json:"dependents"`
+	TransitiveDependents int `json:"transitive_dependents"`
+
+	// InvalidExpr is true if the expression of the rule can't be parsed, and so its dependencies are unknown.
+	InvalidExpr bool `json:"invalid_expr,omitempty"`
+}
+
+// RuleDependencyEdge is a dependency of a RuleDependencyGraph: the rule To selects the series Metric recorded
+// by the recording rule From.
+type RuleDependencyEdge struct {
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Metric string `json:"metric"`
+}
+
+// buildRuleDependencyGraph builds the graph of the dependencies between the rules of the input rule groups. A rule
+// depends on a recording rule if any of its selectors matches the name of the recorded series. The selectors
+// not matching the metric name are not taken into account, because the series they select can't be known in
+// advance, and neither are the other labels of the selectors.
+//
+// If record is not empty, the graph only includes the recording rules recording it, and the rules depending on
+// them, recursively.
+func buildRuleDependencyGraph(groups rulespb.RuleGroupList, record string) RuleDependencyGraph {
+	graph := RuleDependencyGraph{
+		Nodes: []RuleDependencyNode{},
+		Edges: []RuleDependencyEdge{},
+	}
+
+	// The IDs of the recording rules, by recorded metric name.
+	recordingRules := map[string][]int{}
+	selectors := map[int][][]*labels.Matcher{}
+
+	for _, group := range groups {
+		for _, rule := range group.Rules {
+			node := RuleDependencyNode{
+				ID:        len(graph.Nodes),
+				Type:      ruleTypeRecording,
+				Namespace: group.Namespace,
+				Group:     group.Name,
+				Name:      rule.Record,
+				Expr:      rule.Expr,
+			}
+			if rule.Alert != "" {
+				node.Type, node.Name = ruleTypeAlerting, rule.Alert
+			} else {
+				recordingRules[rule.Record] = append(recordingRules[rule.Record], node.ID)
+			}
+
+			ms, err := metricNameMatchers(rule.Expr)
+			if err != nil {
+				node.InvalidExpr = true
+			}
+			selectors[node.ID] = ms
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+
+	// Sort the metric names, so that the edges are deterministic.
+	metrics := make([]string, 0, len(recordingRules))
+	for metric := range recordingRules {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	dependents := map[int][]int{}
+	for _, node := range graph.Nodes {
+		for _, metric := range metrics {
+			if !anySelectorMatches(selectors[node.ID], metric) {
+				continue
+			}
+			for _, from := range recordingRules[metric] {
+				graph.Edges = append(graph.Edges, RuleDependencyEdge{From: from, To: node.ID, Metric: metric})
+				dependents[from] = append(dependents[from], node.ID)
+			}
+		}
+	}
+
+	for i := range graph.Nodes {
+		graph.Nodes[i].Dependents = len(dependents[i])
+		graph.Nodes[i].TransitiveDependents = len(reachableRules(dependents, []int{i})) - 1
+	}
+
+	if record != "" {
+		graph = graph.subgraph(reachableRules(dependents, recordingRules[record]))
+	}
+
+	sort.SliceStable(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	return graph
+}
+
+// subgraph returns the graph restricted to the input rules. The IDs of the rules are preserved.
+func (g RuleDependencyGraph) subgraph(ids map[int]struct{}) RuleDependencyGraph {
+	sub := RuleDependencyGraph{
+		Nodes: []RuleDependencyNode{},
+		Edges: []RuleDependencyEdge{},
+	}
+	for _, node := range g.Nodes {
+		if _, ok := ids[node.ID]; ok {
+			sub.Nodes = append(sub.Nodes, node)
+		}
+	}
+	for _, edge := range g.Edges {
+		_, fromOK := ids[edge.From]
+		_, toOK := ids[edge.To]
+		if fromOK && toOK {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+	return sub
+}
+
+// reachableRules returns the input rules, and the rules depending on them, recursively.
+func reachableRules(dependents map[int][]int, from []int) map[int]struct{} {
+	visited := map[int]struct{}{}
+	queue := append([]int(nil), from...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[id]; ok {
+			continue
+		}
+		visited[id] = struct{}{}
+		queue = append(queue, dependents[id]...)
+	}
+	return visited
+}
+
+// metricNameMatchers returns the metric name matchers of each selector of the input expression. The selectors
+// without metric name matchers are skipped.
+func metricNameMatchers(expr string) ([][]*labels.Matcher, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var selectors [][]*labels.Matcher
+	parser.Inspect(parsed, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+
+		var ms []*labels.Matcher
+		for _, m := range vs.LabelMatchers {
+			if m.Name == labels.MetricName {
+				ms = append(ms, m)
+			}
+		}
+		if len(ms) > 0 {
+			selectors = append(selectors, ms)
+		}
+		return nil
+	})
+	return selectors, nil
+}
+
+// anySelectorMatches returns whether all the matchers of any of the input selectors match the metric name.
+func anySelectorMatches(selectors [][]*labels.Matcher, metric string) bool {
+	for _, ms := range selectors {
+		matches := true
+		for _, m := range ms {
+			matches = matches && m.Matches(metric)
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+func TestBuildRuleDependencyGraph(t *testing.T) {
+	groups := rulespb.RuleGroupList{
+		{Namespace: "ns1", Name: "group1", Rules: []*rulespb.RuleDesc{
+			mockRecordingRuleDesc("job:requests:rate5m", `sum by (job) (rate(requests_total[5m]))`),                 // 0
+			mockRecordingRuleDesc("job:errors:rate5m", `sum by (job) (rate(errors_total[5m]))`),                     // 1
+			mockRecordingRuleDesc("job:error_ratio:rate5m", `job:errors:rate5m / on (job) job:requests:rate5m`),     // 2
+			mockAlertingRuleDesc("HighErrorRatio", `job:error_ratio:rate5m > 0.1`),                                  // 3
+			mockAlertingRuleDesc("NoRequests", `absent({__name__="job:requests:rate5m"})`),                          // 4
+			mockAlertingRuleDesc("AnyRatioHigh", `{__name__=~"job:.*_ratio:rate5m", job!="test"} > 0.5`),            // 5
+			mockAlertingRuleDesc("Unrelated", `up == 0`),                                                            // 6
+			mockAlertingRuleDesc("Invalid", `sum(`),                                                                 // 7
+			mockAlertingRuleDesc("NoMetricName", `{job="api"} > 0`),                                                 // 8
+			mockRecordingRuleDesc("job:requests:rate5m", `sum by (job) (rate(requests_total{env="prod"}[5m]))`),     // 9
+			mockAlertingRuleDesc("ErrorsWithoutRequests", `job:errors:rate5m > 0 unless job:requests:rate5m > 0`),   // 10
+			mockRecordingRuleDesc("job:error_ratio:rate1h", `avg_over_time(job:error_ratio:rate5m[1h])`),            // 11
+			mockAlertingRuleDesc("SustainedErrorRatio", `job:error_ratio:rate1h > 0.05 and job:error_ratio:rate5m`), // 12
+			mockAlertingRuleDesc("NotARatio", `{__name__=~"job:.*_ratio:rate5m", __name__!~".*error.*"} > 0`),       // 13
+			mockAlertingRuleDesc("SubqueryRatio", `max_over_time(job:error_ratio:rate1h[1d:1h]) > 0.01`),            // 14
+			mockRecordingRuleDesc("instance:up", `up`),                                                              // 15
+			mockAlertingRuleDesc("InstanceDown", `instance:up == 0`),                                                // 16
+		}},
+	}
+
+	t.Run("full graph", func(t *testing.T) {
+		graph := buildRuleDependencyGraph(groups, "")
+		require.Len(t, graph.Nodes, 17)
+
+		assert.Equal(t, RuleDependencyNode{
+			ID:                   2,
+			Type:                 ruleTypeRecording,
+			Namespace:            "ns1",
+			Group:                "group1",
+			Name:                 "job:error_ratio:rate5m",
+			Expr:                 `job:errors:rate5m / on (job) job:requests:rate5m`,
+			Dependents:           4,
+			TransitiveDependents: 5,
+		}, graph.Nodes[2])
+		assert.Equal(t, ruleTypeAlerting, graph.Nodes[7].Type)
+		assert.True(t, graph.Nodes[7].InvalidExpr)
+
+		assert.Equal(t, []RuleDependencyEdge{
+			{From: 0, To: 2, Metric: "job:requests:rate5m"},
+			{From: 0, To: 4, Metric: "job:requests:rate5m"},
+			{From: 0, To: 10, Metric: "job:requests:rate5m"},
+			{From: 1, To: 2, Metric: "job:errors:rate5m"},
+			{From: 1, To: 10, Metric: "job:errors:rate5m"},
+			{From: 2, To: 3, Metric: "job:error_ratio:rate5m"},
+			{From: 2, To: 5, Metric: "job:error_ratio:rate5m"},
+			{From: 2, To: 11, Metric: "job:error_ratio:rate5m"},
+			{From: 2, To: 12, Metric: "job:error_ratio:rate5m"},
+			{From: 9, To: 2, Metric: "job:requests:rate5m"},
+			{From: 9, To: 4, Metric: "job:requests:rate5m"},
+			{From: 9, To: 10, Metric: "job:requests:rate5m"},
+			{From: 11, To: 12, Metric: "job:error_ratio:rate1h"},
+			{From: 11, To: 14, Metric: "job:error_ratio:rate1h"},
+			{From: 15, To: 16, Metric: "instance:up"},
+		}, graph.Edges)
+
+		// The rules depending on job:requests:rate5m are reached through job:error_ratio:rate5m too.
+		assert.Equal(t, 3, graph.Nodes[0].Dependents)
+		assert.Equal(t, 8, graph.Nodes[0].TransitiveDependents)
+		assert.Equal(t, 0, graph.Nodes[16].Dependents)
+	})
+
+	t.Run("graph of a recorded metric", func(t *testing.T) {
+		graph := buildRuleDependencyGraph(groups, "job:error_ratio:rate1h")
+
+		ids := make([]int, 0, len(graph.Nodes))
+		for _, node := range graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		assert.Equal(t, []int{11, 12, 14}, ids)
+		assert.Equal(t, []RuleDependencyEdge{
+			{From: 11, To: 12, Metric: "job:error_ratio:rate1h"},
+			{From: 11, To: 14, Metric: "job:error_ratio:rate1h"},
+		}, graph.Edges)
+	})
+
+	t.Run("graph of a metric not recorded", func(t *testing.T) {
+		graph := buildRuleDependencyGraph(groups, "up")
+		assert.Empty(t, graph.Nodes)
+		assert.Empty(t, graph.Edges)
+	})
+
+	t.Run("cyclic dependencies", func(t *testing.T) {
+		graph := buildRuleDependencyGraph(rulespb.RuleGroupList{
+			{Namespace: "ns1", Name: "group1", Rules: []*rulespb.RuleDesc{
+				mockRecordingRuleDesc("a", `b`),
+				mockRecordingRuleDesc("b", `a`),
+			}},
+		}, "")
+
+		assert.Equal(t, 1, graph.Nodes[0].Dependents)
+		assert.Equal(t, 1, graph.Nodes[0].TransitiveDependents)
+		assert.Equal(t, 1, graph.Nodes[1].TransitiveDependents)
+	})
+}
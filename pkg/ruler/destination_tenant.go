@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"sync"
+
+	"github.com/prometheus/prometheus/rules"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+const (
+	groupDestinationTenant   contextKey = 2
+	tenantDestinationTenants contextKey = 3
+)
+
+type destinationTenantKey struct {
+	namespace string
+	name      string
+}
+
+// destinationTenants holds the destination tenants of the rule groups of a tenant. The destination tenant
+// isn't part of the Prometheus rule group format, so it's tracked by the ruler next to the rule files and
+// updated on every sync: a change of the destination tenant applies without restarting the rule group.
+type destinationTenants struct {
+	mtx    sync.RWMutex
+	groups map[destinationTenantKey]string
+}
+
+func newDestinationTenants() *destinationTenants {
+	return &destinationTenants{groups: map[destinationTenantKey]string{}}
+}
+
+// set replaces the tracked destination tenants with the ones of the input rule groups.
+func (d *destinationTenants) set(groups rulespb.RuleGroupList) {
+	tracked := make(map[destinationTenantKey]string, len(groups))
+	for _, g := range groups {
+		if g.GetDestinationTenant() != "" {
+			tracked[destinationTenantKey{namespace: g.GetNamespace(), name: g.GetName()}] = g.GetDestinationTenant()
+		}
+	}
+
+	d.mtx.Lock()
+	d.groups = tracked
+	d.mtx.Unlock()
+}
+
+// get returns the destination tenant of the input rule group, or an empty string if it has none.
+func (d *destinationTenants) get(namespace, name string) string {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	return d.groups[destinationTenantKey{namespace: namespace, name: name}]
+}
+
+// withDestinationTenants injects the destination tenants of the rule groups of a tenant in to the context
+// of its rules manager, to be used by DestinationTenantGroupContextFunc.
+func withDestinationTenants(ctx context.Context, d *destinationTenants) context.Context {
+	return context.WithValue(ctx, tenantDestinationTenants, d)
+}
+
+// DestinationTenantGroupContextFunc prepares the context for the rule groups writing their results to another tenant.
+// It injects a lookup of the destination tenant of g in to the context to be used by the PusherAppendable.
+func DestinationTenantGroupContextFunc(ctx context.Context, g *rules.Group) context.Context {
+	tenants, ok := ctx.Value(tenantDestinationTenants).(*destinationTenants)
+	if !ok {
+		return ctx
+	}
+
+	// The mapped filename is url path escaped encoded to make handling `/` characters easier
+	namespace, err := url.PathUnescape(filepath.Base(g.File()))
+	if err != nil {
+		return ctx
+	}
+
+	name := g.Name()
+	return context.WithValue(ctx, groupDestinationTenant, func() string {
+		return tenants.get(namespace, name)
+	})
+}
+
+// GroupEvaluationContextFunc prepares the context for the evaluation of a rule group, injecting both its
+// source tenants and its destination tenant.
+func GroupEvaluationContextFunc(ctx context.Context, g *rules.Group) context.Context {
+	return DestinationTenantGroupContextFunc(FederatedGroupContextFunc(ctx, g), g)
+}
+
+// destinationTenantFromContext returns the destination tenant of the rule group being evaluated, if any.
+func destinationTenantFromContext(ctx context.Context) string {
+	destination, ok := ctx.Value(groupDestinationTenant).(func() string)
+	if !ok {
+		return ""
+	}
+	return destination()
+}
+
+// isDestinationTenantAllowed returns whether the rule groups of the input user are allowed to write the
+// results of their recording rules to the input destination tenant. A user is always allowed to write to itself.
+func isDestinationTenantAllowed(limits RulesLimits, userID, destination string) bool {
+	return destination == "" || destination == userID || slices.Contains(limits.RulerAllowedDestinationTenants(userID), destination)
+}
@@ -0,0 +1,301 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+const (
+	ruleTypeRecording = "recording"
+	ruleTypeAlerting  = "alerting"
+)
+
+// DuplicateRulesReport is the result of the analysis of a tenant's rule groups looking for duplicated rules.
+type DuplicateRulesReport struct {
+	// DuplicateGroups are the sets of rule groups with the same rules.
+	DuplicateGroups []DuplicateRuleGroups `yaml:"duplicate_groups"`
+
+	// DuplicateRules are the sets of rules of the same type evaluating the same expression.
+	DuplicateRules []DuplicateRules `yaml:"duplicate_rules"`
+
+	// EstimatedEvaluationsSavedPerMinute is the number of rule evaluations per minute saved by consolidating
+	// all the duplicate rules. It includes the rules of the duplicate groups.
+	EstimatedEvaluationsSavedPerMinute float64 `yaml:"estimated_evaluations_saved_per_minute"`
+}
+
+// DuplicateRuleGroups is a set of rule groups with the same rules.
+type DuplicateRuleGroups struct {
+	Groups []DuplicateRuleGroupRef `yaml:"groups"`
+
+	// Identical is true if the rules of the groups have the same expressions verbatim, false if the
+	// expressions are equivalent after normalization (e.g. formatting or matchers order).
+	Identical bool `yaml:"identical"`
+
+	EstimatedEvaluationsSavedPerMinute float64 `yaml:"estimated_evaluations_saved_per_minute"`
+	Suggestion                         string  `yaml:"suggestion"`
+}
+
+// DuplicateRuleGroupRef references a rule group of a DuplicateRuleGroups.
+type DuplicateRuleGroupRef struct {
+	Namespace string         `yaml:"namespace"`
+	Group     string         `yaml:"group"`
+	Interval  model.Duration `yaml:"interval"`
+}
+
+// DuplicateRules is a set of rules of the same type evaluating the same expression.
+type DuplicateRules struct {
+	Type string `yaml:"type"`
+	// Expr is the normalized expression evaluated by the rules.
+	Expr  string             `yaml:"expr"`
+	Rules []DuplicateRuleRef `yaml:"rules"`
+
+	// Identical is true if the rules have the same expression verbatim, false if the expressions are
+	// equivalent after normalization (e.g. formatting or matchers order).
+	Identical bool `yaml:"identical"`
+
+	// SameDefinition is true if the rules also have the same name, labels, for and keep_firing_for, so that
+	// all but one can be deleted. Otherwise the rules record different series or fire different alerts, and the
+	// consumers of the other rules need to be re-pointed to the kept one.
+	SameDefinition bool `yaml:"same_definition"`
+
+	EstimatedEvaluationsSavedPerMinute float64 `yaml:"estimated_evaluations_saved_per_minute"`
+	Suggestion                         string  `yaml:"suggestion"`
+}
+
+// DuplicateRuleRef references a rule of a DuplicateRules.
+type DuplicateRuleRef struct {
+	Namespace string         `yaml:"namespace"`
+	Group     string         `yaml:"group"`
+	Name      string         `yaml:"name"`
+	Expr      string         `yaml:"expr"`
+	Interval  model.Duration `yaml:"interval"`
+}
+
+// findDuplicateRules analyses the input rule groups looking for duplicate rule groups and rules. The interval
+// function returns the effective evaluation interval of a rule group given its configured one.
+//
+// Two rules are duplicates if they have the same type and evaluate the same expression once normalized, regardless
+// of the name of the recorded series or alert. Two rule groups are duplicates if they have the same rules, in the
+// same order, including their names and labels. The consolidation of each set of duplicates keeps the rule or group
+// evaluated more frequently. The other rules are only suggested for deletion if they have the same definition as the
+// kept one, otherwise their consumers have to be re-pointed to it.
+func findDuplicateRules(groups rulespb.RuleGroupList, interval func(time.Duration) time.Duration) DuplicateRulesReport {
+	var (
+		rulesByKey  = map[string][]DuplicateRuleRef{}
+		ruleDefs    = map[string]map[string]struct{}{}
+		groupsByKey = map[string][]DuplicateRuleGroupRef{}
+		groupSizes  = map[string]int{}
+		groupExprs  = map[string]map[string]struct{}{}
+	)
+
+	for _, group := range groups {
+		groupInterval := model.Duration(interval(group.Interval))
+		groupKey := strings.Builder{}
+
+		for _, rule := range group.Rules {
+			ruleType, name := ruleTypeRecording, rule.Record
+			if rule.Alert != "" {
+				ruleType, name = ruleTypeAlerting, rule.Alert
+			}
+
+			expr := normalizeRuleExpr(rule.Expr)
+			ruleKey := ruleType + "\x00" + expr
+			rulesByKey[ruleKey] = append(rulesByKey[ruleKey], DuplicateRuleRef{
+				Namespace: group.Namespace,
+				Group:     group.Name,
+				Name:      name,
+				Expr:      rule.Expr,
+				Interval:  groupInterval,
+			})
+
+			ruleDef := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%s", ruleType, name, expr, rule.For, rule.KeepFiringFor, labelsKey(rule.Labels))
+			if ruleDefs[ruleKey] == nil {
+				ruleDefs[ruleKey] = map[string]struct{}{}
+			}
+			ruleDefs[ruleKey][ruleDef] = struct{}{}
+
+			groupKey.WriteString(ruleDef + "\x01")
+		}
+
+		if len(group.Rules) == 0 {
+			continue
+		}
+
+		key := groupKey.String()
+		groupsByKey[key] = append(groupsByKey[key], DuplicateRuleGroupRef{
+			Namespace: group.Namespace,
+			Group:     group.Name,
+			Interval:  groupInterval,
+		})
+		groupSizes[key] = len(group.Rules)
+
+		if groupExprs[key] == nil {
+			groupExprs[key] = map[string]struct{}{}
+		}
+		groupExprs[key][rawRuleExprsKey(group.Rules)] = struct{}{}
+	}
+
+	report := DuplicateRulesReport{
+		DuplicateGroups: []DuplicateRuleGroups{},
+		DuplicateRules:  []DuplicateRules{},
+	}
+
+	for key, refs := range groupsByKey {
+		if len(refs) < 2 {
+			continue
+		}
+
+		sort.SliceStable(refs, func(i, j int) bool {
+			return lessDuplicateRef(refs[i].Interval, refs[j].Interval, refs[i].Namespace, refs[j].Namespace, refs[i].Group, refs[j].Group)
+		})
+
+		saved := 0.0
+		for _, ref := range refs[1:] {
+			saved += float64(groupSizes[key]) * evaluationsPerMinute(ref.Interval)
+		}
+
+		report.DuplicateGroups = append(report.DuplicateGroups, DuplicateRuleGroups{
+			Groups:                             refs,
+			Identical:                          len(groupExprs[key]) == 1,
+			EstimatedEvaluationsSavedPerMinute: saved,
+			Suggestion:                         fmt.Sprintf("keep the rule group %q in the namespace %q and delete the other %d rule groups", refs[0].Group, refs[0].Namespace, len(refs)-1),
+		})
+	}
+
+	for key, refs := range rulesByKey {
+		if len(refs) < 2 {
+			continue
+		}
+
+		sort.SliceStable(refs, func(i, j int) bool {
+			return lessDuplicateRef(refs[i].Interval, refs[j].Interval, refs[i].Namespace, refs[j].Namespace, refs[i].Group, refs[j].Group)
+		})
+
+		identical := true
+		saved := 0.0
+		for _, ref := range refs[1:] {
+			identical = identical && ref.Expr == refs[0].Expr
+			saved += evaluationsPerMinute(ref.Interval)
+		}
+
+		ruleType, expr, _ := strings.Cut(key, "\x00")
+		sameDefinition := len(ruleDefs[key]) == 1
+		suggestion := fmt.Sprintf("keep the %s rule %q in the rule group %q of the namespace %q and delete the other %d rules", ruleType, refs[0].Name, refs[0].Group, refs[0].Namespace, len(refs)-1)
+		if !sameDefinition {
+			suggestion = fmt.Sprintf("keep the %s rule %q in the rule group %q of the namespace %q and re-point the consumers of the other %d rules to it before deleting them, because the rules evaluate the same expression but differ by name, labels, for or keep_firing_for", ruleType, refs[0].Name, refs[0].Group, refs[0].Namespace, len(refs)-1)
+		}
+		report.DuplicateRules = append(report.DuplicateRules, DuplicateRules{
+			Type:                               ruleType,
+			Expr:                               expr,
+			Rules:                              refs,
+			Identical:                          identical,
+			SameDefinition:                     sameDefinition,
+			EstimatedEvaluationsSavedPerMinute: saved,
+			Suggestion:                         suggestion,
+		})
+		report.EstimatedEvaluationsSavedPerMinute += saved
+	}
+
+	// Sort the duplicates by savings, so that the most effective consolidations are reported first.
+	sort.Slice(report.DuplicateGroups, func(i, j int) bool {
+		a, b := report.DuplicateGroups[i], report.DuplicateGroups[j]
+		if a.EstimatedEvaluationsSavedPerMinute != b.EstimatedEvaluationsSavedPerMinute {
+			return a.EstimatedEvaluationsSavedPerMinute > b.EstimatedEvaluationsSavedPerMinute
+		}
+		return a.Groups[0].Namespace+"/"+a.Groups[0].Group < b.Groups[0].Namespace+"/"+b.Groups[0].Group
+	})
+	sort.Slice(report.DuplicateRules, func(i, j int) bool {
+		a, b := report.DuplicateRules[i], report.DuplicateRules[j]
+		if a.EstimatedEvaluationsSavedPerMinute != b.EstimatedEvaluationsSavedPerMinute {
+			return a.EstimatedEvaluationsSavedPerMinute > b.EstimatedEvaluationsSavedPerMinute
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Expr < b.Expr
+	})
+
+	return report
+}
+
+// normalizeRuleExpr returns the canonical form of the input PromQL expression, so that equivalent expressions
+// differing only by formatting, label matchers order or grouping labels order have the same form. Expressions
+// failing to parse are returned trimmed.
+func normalizeRuleExpr(expr string) string {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return strings.TrimSpace(expr)
+	}
+
+	parser.Inspect(parsed, func(node parser.Node, _ []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.VectorSelector:
+			sort.Slice(n.LabelMatchers, func(i, j int) bool {
+				return lessMatcher(n.LabelMatchers[i], n.LabelMatchers[j])
+			})
+		case *parser.AggregateExpr:
+			slices.Sort(n.Grouping)
+		case *parser.BinaryExpr:
+			if n.VectorMatching != nil {
+				slices.Sort(n.VectorMatching.MatchingLabels)
+				slices.Sort(n.VectorMatching.Include)
+			}
+		}
+		return nil
+	})
+
+	return parsed.String()
+}
+
+func lessMatcher(a, b *labels.Matcher) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	return a.Value < b.Value
+}
+
+// lessDuplicateRef sorts the rules and rule groups by evaluation interval, and then by namespace and group
+// name, so that the first one is the one kept by the consolidation.
+func lessDuplicateRef(intervalA, intervalB model.Duration, namespaceA, namespaceB, groupA, groupB string) bool {
+	if intervalA != intervalB {
+		return intervalA < intervalB
+	}
+	if namespaceA != namespaceB {
+		return namespaceA < namespaceB
+	}
+	return groupA < groupB
+}
+
+func evaluationsPerMinute(interval model.Duration) float64 {
+	if interval <= 0 {
+		return 0
+	}
+	return float64(time.Minute) / float64(interval)
+}
+
+func labelsKey(lbls []mimirpb.LabelAdapter) string {
+	return mimirpb.FromLabelAdaptersToLabels(lbls).String()
+}
+
+func rawRuleExprsKey(rules []*rulespb.RuleDesc) string {
+	exprs := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		exprs = append(exprs, rule.Expr)
+	}
+	return strings.Join(exprs, "\x00")
+}
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+)
+
+func TestNormalizeRuleExpr(t *testing.T) {
+	tests := map[string]struct {
+		a, b       string
+		equivalent bool
+	}{
+		"same expression": {
+			a:          `sum(rate(http_requests_total{job="api"}[5m]))`,
+			b:          `sum(rate(http_requests_total{job="api"}[5m]))`,
+			equivalent: true,
+		},
+		"different formatting": {
+			a:          `sum(rate(http_requests_total{job="api"}[5m]))`,
+			b:          "sum (\n  rate( http_requests_total{ job = \"api\" } [5m] )\n)",
+			equivalent: true,
+		},
+		"different matchers order": {
+			a:          `up{job="api", instance="a"}`,
+			b:          `up{instance="a", job="api"}`,
+			equivalent: true,
+		},
+		"different grouping labels order": {
+			a:          `sum by (job, instance) (up)`,
+			b:          `sum by (instance, job) (up)`,
+			equivalent: true,
+		},
+		"different vector matching labels order": {
+			a:          `a * on (job, instance) group_left (pod, node) b`,
+			b:          `a * on (instance, job) group_left (node, pod) b`,
+			equivalent: true,
+		},
+		"different matchers": {
+			a:          `up{job="api"}`,
+			b:          `up{job="db"}`,
+			equivalent: false,
+		},
+		"different range": {
+			a:          `rate(http_requests_total[5m])`,
+			b:          `rate(http_requests_total[1m])`,
+			equivalent: false,
+		},
+		"invalid expressions": {
+			a:          ` up{ `,
+			b:          `up{`,
+			equivalent: true,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			if testData.equivalent {
+				assert.Equal(t, normalizeRuleExpr(testData.a), normalizeRuleExpr(testData.b))
+			} else {
+				assert.NotEqual(t, normalizeRuleExpr(testData.a), normalizeRuleExpr(testData.b))
+			}
+		})
+	}
+}
+
+func TestFindDuplicateRules(t *testing.T) {
+	defaultInterval := func(interval time.Duration) time.Duration {
+		if interval <= 0 {
+			return time.Minute
+		}
+		return interval
+	}
+
+	t.Run("should report no duplicates if there are none", func(t *testing.T) {
+		report := findDuplicateRules(rulespb.RuleGroupList{
+			{Namespace: "ns1", Name: "group1", Rules: []*rulespb.RuleDesc{mockRecordingRuleDesc("job:up:sum", `sum by (job) (up)`)}},
+			{Namespace: "ns2", Name: "group1", Rules: []*rulespb.RuleDesc{mockAlertingRuleDesc("JobDown", `sum by (job) (up) == 0`)}},
+		}, defaultInterval)
+
+		assert.Empty(t, report.DuplicateGroups)
+		assert.Empty(t, report.DuplicateRules)
+		assert.Zero(t, report.EstimatedEvaluationsSavedPerMinute)
+	})
+
+	t.Run("should report the duplicate rules across namespaces", func(t *testing.T) {
+		report := findDuplicateRules(rulespb.RuleGroupList{
+			{Namespace: "ns1", Name: "group1", Interval: time.Minute, Rules: []*rulespb.RuleDesc{
+				mockRecordingRuleDesc("job:up:sum", `sum by (job) (up)`),
+				mockAlertingRuleDesc("JobDown", `sum by (job) (up) == 0`),
+			}},
+			{Namespace: "ns2", Name: "group2", Interval: 30 * time.Second, Rules: []*rulespb.RuleDesc{
+				mockRecordingRuleDesc("job:up:sum_copy", `sum(up) by (job)`),
+			}},
+			{Namespace: "ns3", Name: "group3", Interval: 2 * time.Minute, Rules: []*rulespb.RuleDesc{
+				// A recording rule and an alerting rule with the same expression aren't duplicates.
+				mockAlertingRuleDesc("JobUp", `sum by (job) (up)`),
+			}},
+		}, defaultInterval)
+
+		assert.Empty(t, report.DuplicateGroups)
+		require.Len(t, report.DuplicateRules, 1)
+
+		actual := report.DuplicateRules[0]
+		assert.Equal(t, ruleTypeRecording, actual.Type)
+		assert.Equal(t, `sum by (job) (up)`, actual.Expr)
+		assert.False(t, actual.Identical)
+		assert.Equal(t, []DuplicateRuleRef{
+			// The rule evaluated more frequently is the one to keep.
+			{Namespace: "ns2", Group: "group2", Name: "job:up:sum_copy", Expr: `sum(up) by (job)`, Interval: model.Duration(30 * time.Second)},
+			{Namespace: "ns1", Group: "group1", Name: "job:up:sum", Expr: `sum by (job) (up)`, Interval: model.Duration(time.Minute)},
+		}, actual.Rules)
+		assert.Equal(t, 1.0, actual.EstimatedEvaluationsSavedPerMinute)
+		// The rules record different series, so their consumers have to be re-pointed.
+		assert.False(t, actual.SameDefinition)
+		assert.Equal(t, `keep the recording rule "job:up:sum_copy" in the rule group "group2" of the namespace "ns2" and re-point the consumers of the other 1 rules to it before deleting them, because the rules evaluate the same expression but differ by name, labels, for or keep_firing_for`, actual.Suggestion)
+		assert.Equal(t, 1.0, report.EstimatedEvaluationsSavedPerMinute)
+	})
+
+	t.Run("should only suggest to delete the duplicate rules with the same definition", func(t *testing.T) {
+		alert := func(name string, labels map[string]string, forDuration time.Duration) *rulespb.RuleDesc {
+			rule := mockAlertingRuleDesc(name, `sum by (job) (up) == 0`)
+			rule.For = forDuration
+			for n, v := range labels {
+				rule.Labels = append(rule.Labels, mimirpb.LabelAdapter{Name: n, Value: v})
+			}
+			return rule
+		}
+
+		for name, tc := range map[string]struct {
+			other                  *rulespb.RuleDesc
+			expectedSameDefinition bool
+		}{
+			"same definition": {
+				other:                  alert("JobDown", map[string]string{"severity": "critical"}, 5*time.Minute),
+				expectedSameDefinition: true,
+			},
+			"different name": {
+				other: alert("JobIsDown", map[string]string{"severity": "critical"}, 5*time.Minute),
+			},
+			"different labels": {
+				other: alert("JobDown", map[string]string{"severity": "warning"}, 5*time.Minute),
+			},
+			"different for": {
+				other: alert("JobDown", map[string]string{"severity": "critical"}, 10*time.Minute),
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				report := findDuplicateRules(rulespb.RuleGroupList{
+					{Namespace: "ns1", Name: "group1", Rules: []*rulespb.RuleDesc{alert("JobDown", map[string]string{"severity": "critical"}, 5*time.Minute)}},
+					// The group is not a duplicate of the first one because of the rule evaluation interval.
+					{Namespace: "ns2", Name: "group2", Interval: 2 * time.Minute, Rules: []*rulespb.RuleDesc{tc.other}},
+				}, defaultInterval)
+
+				require.Len(t, report.DuplicateRules, 1)
+				actual := report.DuplicateRules[0]
+				assert.Equal(t, tc.expectedSameDefinition, actual.SameDefinition)
+				if tc.expectedSameDefinition {
+					assert.Equal(t, `keep the alerting rule "JobDown" in the rule group "group1" of the namespace "ns1" and delete the other 1 rules`, actual.Suggestion)
+				} else {
+					assert.Contains(t, actual.Suggestion, "re-point the consumers of the other 1 rules")
+				}
+			})
+		}
+	})
+
+	t.Run("should report the duplicate rule groups", func(t *testing.T) {
+		rules := func(expr string) []*rulespb.RuleDesc {
+			return []*rulespb.RuleDesc{
+				mockRecordingRuleDesc("job:up:sum", expr),
+				mockAlertingRuleDesc("JobDown", `job:up:sum == 0`),
+			}
+		}
+
+		report := findDuplicateRules(rulespb.RuleGroupList{
+			{Namespace: "ns1", Name: "group", Rules: rules(`sum by (job) (up)`)},
+			{Namespace: "ns2", Name: "group", Rules: rules(`sum by (job) (up)`)},
+			{Namespace: "ns3", Name: "group", Interval: 15 * time.Second, Rules: rules(`sum by (job) (up)`)},
+			// Same rules with a different alert name.
+			{Namespace: "ns4", Name: "group", Rules: []*rulespb.RuleDesc{
+				mockRecordingRuleDesc("job:up:sum", `sum by (job) (up)`),
+				mockAlertingRuleDesc("JobIsDown", `job:up:sum == 0`),
+			}},
+		}, defaultInterval)
+
+		require.Len(t, report.DuplicateGroups, 1)
+
+		actual := report.DuplicateGroups[0]
+		assert.True(t, actual.Identical)
+		assert.Equal(t, []DuplicateRuleGroupRef{
+			{Namespace: "ns3", Group: "group", Interval: model.Duration(15 * time.Second)},
+			{Namespace: "ns1", Group: "group", Interval: model.Duration(time.Minute)},
+			{Namespace: "ns2", Group: "group", Interval: model.Duration(time.Minute)},
+		}, actual.Groups)
+		assert.Equal(t, 4.0, actual.EstimatedEvaluationsSavedPerMinute)
+		assert.Equal(t, `keep the rule group "group" in the namespace "ns3" and delete the other 2 rule groups`, actual.Suggestion)
+
+		// The rules of all groups are duplicates, including the ones of the group with a different alert name.
+		require.Len(t, report.DuplicateRules, 2)
+		for _, duplicates := range report.DuplicateRules {
+			assert.Len(t, duplicates.Rules, 4)
+			assert.True(t, duplicates.Identical)
+			assert.Equal(t, 3.0, duplicates.EstimatedEvaluationsSavedPerMinute)
+			// Only the alerting rules differ by name.
+			assert.Equal(t, duplicates.Type == ruleTypeRecording, duplicates.SameDefinition)
+		}
+		assert.Equal(t, 6.0, report.EstimatedEvaluationsSavedPerMinute)
+	})
+
+	t.Run("should report the duplicate rule groups with equivalent expressions as not identical", func(t *testing.T) {
+		report := findDuplicateRules(rulespb.RuleGroupList{
+			{Namespace: "ns1", Name: "group", Rules: []*rulespb.RuleDesc{mockRecordingRuleDesc("job:up:sum", `sum by (job) (up)`)}},
+			{Namespace: "ns2", Name: "group", Rules: []*rulespb.RuleDesc{mockRecordingRuleDesc("job:up:sum", `sum(up) by (job)`)}},
+		}, defaultInterval)
+
+		require.Len(t, report.DuplicateGroups, 1)
+		assert.False(t, report.DuplicateGroups[0].Identical)
+		require.Len(t, report.DuplicateRules, 1)
+		assert.False(t, report.DuplicateRules[0].Identical)
+	})
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/weaveworks/common/user"
+)
+
+// evaluationResultsCache is a short-lived cache of the results of the rule queries of a tenant, keyed by
+// expression and evaluation timestamp, so that the identical expressions evaluated by multiple rules or rule
+// groups at the same timestamp are only run once.
+type evaluationResultsCache struct {
+	ttl time.Duration
+
+	mtx     sync.Mutex
+	entries map[evaluationResultsCacheKey]*evaluationResultsCacheEntry
+	// The keys of the entries, in insertion order, to evict the expired entries.
+	keys []evaluationResultsCacheKey
+}
+
+type evaluationResultsCacheKey struct {
+	// The tenant the query is run for, which differs from the tenant owning the rules for federated rule groups.
+	tenantID  string
+	expr      string
+	timestamp int64
+}
+
+type evaluationResultsCacheEntry struct {
+	created time.Time
+
+	// done is closed once the query has been run.
+	done   chan struct{}
+	result promql.Vector
+	err    error
+}
+
+func newEvaluationResultsCache(ttl time.Duration) *evaluationResultsCache {
+	return &evaluationResultsCache{
+		ttl:     ttl,
+		entries: map[evaluationResultsCacheKey]*evaluationResultsCacheEntry{},
+	}
+}
+
+// get returns the entry of the key, and whether it has been found. If not found, a new entry is added for
+// the key, and the caller is responsible for running the query and completing the entry.
+func (c *evaluationResultsCache) get(now time.Time, key evaluationResultsCacheKey) (*evaluationResultsCacheEntry, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.evict(now)
+
+	if entry, ok := c.entries[key]; ok {
+		return entry, true
+	}
+
+	entry := &evaluationResultsCacheEntry{created: now, done: make(chan struct{})}
+	c.entries[key] = entry
+	c.keys = append(c.keys, key)
+	return entry, false
+}
+
+// remove removes the entry of the key, if it's still the input one.
+func (c *evaluationResultsCache) remove(key evaluationResultsCacheKey, entry *evaluationResultsCacheEntry) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+}
+
+// evict removes the expired entries. Must be called with the lock held.
+func (c *evaluationResultsCache) evict(now time.Time) {
+	for len(c.keys) > 0 {
+		entry, ok := c.entries[c.keys[0]]
+		if ok && now.Sub(entry.created) <= c.ttl {
+			return
+		}
+
+		// The queries still running when expired complete anyway for the callers waiting for them.
+		if ok {
+			delete(c.entries, c.keys[0])
+		}
+		c.keys = c.keys[1:]
+	}
+}
+
+// EvaluationResultsCacheQueryFunc reuses the results of the identical expressions evaluated at the same timestamp
+// by the rules of the tenant, within the input TTL. The identical expressions run concurrently wait for the first
+// one to complete. The errors aren't cached.
+func EvaluationResultsCacheQueryFunc(qf rules.QueryFunc, ttl time.Duration, requests, hits prometheus.Counter) rules.QueryFunc {
+	if ttl <= 0 {
+		return qf
+	}
+
+	cache := newEvaluationResultsCache(ttl)
+
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		tenantID, err := user.ExtractOrgID(ctx)
+		if err != nil {
+			return qf(ctx, qs, t)
+		}
+
+		// The expression is normalized, so that the expressions only differing by their formatting are
+		// considered identical. The queries which can't be parsed are left to fail when run.
+		expr, err := parser.ParseExpr(qs)
+		if err != nil {
+			return qf(ctx, qs, t)
+		}
+
+		requests.Inc()
+		key := evaluationResultsCacheKey{tenantID: tenantID, expr: expr.String(), timestamp: t.UnixMilli()}
+		entry, found := cache.get(time.Now(), key)
+		if !found {
+			entry.result, entry.err = qf(ctx, qs, t)
+			close(entry.done)
+			if entry.err != nil {
+				cache.remove(key, entry)
+			}
+			return copyVector(entry.result), entry.err
+		}
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The query may have failed because of the context of the rule which ran it, so it's run again.
+		if entry.err != nil {
+			return qf(ctx, qs, t)
+		}
+
+		hits.Inc()
+		return copyVector(entry.result), nil
+	}
+}
+
+// copyVector returns a copy of the input vector, since the rules modify the labels of the samples of the
+// vector returned by the query.
+func copyVector(v promql.Vector) promql.Vector {
+	if v == nil {
+		return nil
+	}
+	return append(make(promql.Vector, 0, len(v)), v...)
+}
@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+)
+
+func TestEvaluationResultsCacheQueryFunc(t *testing.T) {
+	var (
+		calls    = atomic.NewInt64(0)
+		failing  = atomic.NewBool(false)
+		requests = prometheus.NewCounter(prometheus.CounterOpts{})
+		hits     = prometheus.NewCounter(prometheus.CounterOpts{})
+		now      = time.Now()
+	)
+
+	qf := EvaluationResultsCacheQueryFunc(func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		calls.Inc()
+		if failing.Load() {
+			return nil, errors.New("query failed")
+		}
+		return promql.Vector{{Metric: labels.FromStrings(labels.MetricName, "up"), Point: promql.Point{T: t.UnixMilli(), V: 1}}}, nil
+	}, time.Minute, requests, hits)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	res, err := qf(ctx, `sum(up)`, now)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, int64(1), calls.Load())
+
+	// The rules modify the labels of the returned samples, which must not affect the cached results.
+	res[0].Metric = labels.FromStrings(labels.MetricName, "modified")
+
+	// The identical expressions only differing by their formatting are evaluated once.
+	res, err = qf(ctx, `sum (up)`, now)
+	require.NoError(t, err)
+	assert.Equal(t, labels.FromStrings(labels.MetricName, "up"), res[0].Metric)
+	assert.Equal(t, int64(1), calls.Load())
+
+	// The expressions evaluated at another timestamp or for another tenant are run.
+	_, err = qf(ctx, `sum(up)`, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), calls.Load())
+
+	_, err = qf(user.InjectOrgID(context.Background(), "user-2"), `sum(up)`, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), calls.Load())
+
+	// The errors aren't cached.
+	failing.Store(true)
+	_, err = qf(ctx, `sum(down)`, now)
+	require.Error(t, err)
+	_, err = qf(ctx, `sum(down)`, now)
+	require.Error(t, err)
+	assert.Equal(t, int64(5), calls.Load())
+
+	assert.Equal(t, float64(6), testutil.ToFloat64(requests))
+	assert.Equal(t, float64(1), testutil.ToFloat64(hits))
+}
+
+func TestEvaluationResultsCacheQueryFunc_ConcurrentQueries(t *testing.T) {
+	var (
+		calls   = atomic.NewInt64(0)
+		started = make(chan struct{})
+		unblock = make(chan struct{})
+		hits    = prometheus.NewCounter(prometheus.CounterOpts{})
+	)
+
+	qf := EvaluationResultsCacheQueryFunc(func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		calls.Inc()
+		close(started)
+		<-unblock
+		return promql.Vector{{Point: promql.Point{T: t.UnixMilli(), V: 1}}}, nil
+	}, time.Minute, prometheus.NewCounter(prometheus.CounterOpts{}), hits)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	now := time.Now()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := qf(ctx, `sum(up)`, now)
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	// The identical query waits for the running one to complete.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		res, err := qf(ctx, `sum(up)`, now)
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+	}()
+
+	close(unblock)
+	<-done
+	wg.Wait()
+
+	assert.Equal(t, int64(1), calls.Load())
+	assert.Equal(t, float64(1), testutil.ToFloat64(hits))
+}
+
+func TestEvaluationResultsCache_Evict(t *testing.T) {
+	now := time.Now()
+	cache := newEvaluationResultsCache(time.Minute)
+
+	key1 := evaluationResultsCacheKey{tenantID: "user-1", expr: "up", timestamp: 1}
+	key2 := evaluationResultsCacheKey{tenantID: "user-1", expr: "up", timestamp: 2}
+
+	_, found := cache.get(now, key1)
+	require.False(t, found)
+	_, found = cache.get(now.Add(30*time.Second), key2)
+	require.False(t, found)
+
+	_, found = cache.get(now.Add(time.Minute), key1)
+	require.True(t, found)
+
+	// The expired entries are evicted.
+	_, found = cache.get(now.Add(61*time.Second), key2)
+	require.True(t, found)
+	assert.Len(t, cache.entries, 1)
+	_, found = cache.get(now.Add(2*time.Minute), key1)
+	require.False(t, found)
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -48,6 +49,14 @@ type DefaultMultiTenantManager struct {
 	notifiersMtx sync.Mutex
 	notifiers    map[string]*rulerNotifier
 
+	// Per-user time of the last successful sync of the rules.
+	lastSyncMtx sync.Mutex
+	lastSync    map[string]time.Time
+
+	// Per-user destination tenants of the rule groups.
+	destinationTenantsMtx sync.Mutex
+	destinationTenants    map[string]*destinationTenants
+
 	managersTotal                 prometheus.Gauge
 	lastReloadSuccessful          *prometheus.GaugeVec
 	lastReloadSuccessfulTimestamp *prometheus.GaugeVec
@@ -74,6 +83,8 @@ func NewDefaultMultiTenantManager(cfg Config, managerFactory ManagerFactory, reg
 		notifierCfg:        ncfg,
 		managerFactory:     managerFactory,
 		notifiers:          map[string]*rulerNotifier{},
+		lastSync:           map[string]time.Time{},
+		destinationTenants: map[string]*destinationTenants{},
 		mapper:             newMapper(cfg.RulePath, logger),
 		userManagers:       map[string]RulesManager{},
 		userManagerMetrics: userManagerMetrics,
@@ -143,6 +154,12 @@ func (r *DefaultMultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGrou
 			r.lastReloadSuccessfulTimestamp.DeleteLabelValues(userID)
 			r.configUpdatesTotal.DeleteLabelValues(userID)
 			r.userManagerMetrics.RemoveUserRegistry(userID)
+			r.destinationTenantsMtx.Lock()
+			delete(r.destinationTenants, userID)
+			r.destinationTenantsMtx.Unlock()
+			r.lastSyncMtx.Lock()
+			delete(r.lastSync, userID)
+			r.lastSyncMtx.Unlock()
 			level.Info(r.logger).Log("msg", "deleted rule manager and local rule files", "user", userID)
 		}
 	}
@@ -170,6 +187,9 @@ func (r *DefaultMultiTenantManager) Start() {
 // the user's Prometheus Rules Manager. Since this method writes to disk it is not safe to call
 // concurrently for the same user.
 func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user string, groups rulespb.RuleGroupList) {
+	// The destination tenants aren't part of the rule files, so they're updated even if the rules on disk haven't changed.
+	r.getOrCreateDestinationTenants(user).set(groups)
+
 	// Map the files to disk and return the file names to be passed to the users manager if they
 	// have been updated
 	update, files, err := r.mapper.MapRules(user, groups.Formatted())
@@ -189,6 +209,7 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 	// We need to update the manager only if it was just created or rules on disk have changed.
 	if !(created || update) {
 		level.Debug(r.logger).Log("msg", "rules have not changed, skipping rule manager update", "user", user)
+		r.setLastSync(user)
 		return
 	}
 
@@ -204,6 +225,37 @@ func (r *DefaultMultiTenantManager) syncRulesToManager(ctx context.Context, user
 
 	r.lastReloadSuccessful.WithLabelValues(user).Set(1)
 	r.lastReloadSuccessfulTimestamp.WithLabelValues(user).SetToCurrentTime()
+	r.setLastSync(user)
+}
+
+func (r *DefaultMultiTenantManager) getOrCreateDestinationTenants(user string) *destinationTenants {
+	r.destinationTenantsMtx.Lock()
+	defer r.destinationTenantsMtx.Unlock()
+
+	d, ok := r.destinationTenants[user]
+	if !ok {
+		d = newDestinationTenants()
+		r.destinationTenants[user] = d
+	}
+	return d
+}
+
+// GetDestinationTenant implements MultiTenantManager.
+func (r *DefaultMultiTenantManager) GetDestinationTenant(user, namespace, group string) string {
+	r.destinationTenantsMtx.Lock()
+	d, ok := r.destinationTenants[user]
+	r.destinationTenantsMtx.Unlock()
+
+	if !ok {
+		return ""
+	}
+	return d.get(namespace, group)
+}
+
+func (r *DefaultMultiTenantManager) setLastSync(user string) {
+	r.lastSyncMtx.Lock()
+	r.lastSync[user] = time.Now()
+	r.lastSyncMtx.Unlock()
 }
 
 // getOrCreateManager retrieves the user manager. If it doesn't exist, it will create and start it first.
@@ -258,6 +310,7 @@ func (r *DefaultMultiTenantManager) newManager(ctx context.Context, userID strin
 	reg := prometheus.NewRegistry()
 	r.userManagerMetrics.AddUserRegistry(userID, reg)
 
+	ctx = withDestinationTenants(ctx, r.getOrCreateDestinationTenants(userID))
 	return r.managerFactory(ctx, userID, notifier, r.logger, reg), nil
 }
 
@@ -314,6 +367,27 @@ func (r *DefaultMultiTenantManager) GetRules(userID string) []*promRules.Group {
 	return nil
 }
 
+// GetTenantStatus returns the status of the rules sync and the notifications of a tenant in this ruler.
+func (r *DefaultMultiTenantManager) GetTenantStatus(userID string) TenantStatus {
+	var status TenantStatus
+
+	r.lastSyncMtx.Lock()
+	if t, ok := r.lastSync[userID]; ok {
+		status.LastSuccessfulSync = &t
+	}
+	r.lastSyncMtx.Unlock()
+
+	r.notifiersMtx.Lock()
+	n, ok := r.notifiers[userID]
+	r.notifiersMtx.Unlock()
+
+	if ok {
+		notifierStatus := n.status()
+		status.Notifier = &notifierStatus
+	}
+	return status
+}
+
 func (r *DefaultMultiTenantManager) Stop() {
 	r.notifiersMtx.Lock()
 	for _, n := range r.notifiers {
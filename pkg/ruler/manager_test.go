@@ -7,6 +7,8 @@ package ruler
 
 import (
 	"context"
+	"net/url"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -147,6 +149,65 @@ func TestSyncRuleGroups(t *testing.T) {
 	})
 }
 
+func TestSyncRuleGroups_DestinationTenant(t *testing.T) {
+	const user = "user-1"
+
+	var managerCtx context.Context
+	captureFactory := func(ctx context.Context, userID string, notifier *notifier.Manager, logger log.Logger, reg prometheus.Registerer) RulesManager {
+		managerCtx = ctx
+		return factory(ctx, userID, notifier, logger, reg)
+	}
+
+	m, err := NewDefaultMultiTenantManager(Config{RulePath: t.TempDir()}, captureFactory, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(m.Stop)
+
+	userRules := func(destination string) map[string]rulespb.RuleGroupList {
+		return map[string]rulespb.RuleGroupList{
+			user: {
+				&rulespb.RuleGroupDesc{
+					Name:              "group",
+					Namespace:         "ns/1",
+					Interval:          time.Minute,
+					User:              user,
+					DestinationTenant: destination,
+				},
+			},
+		}
+	}
+
+	m.SyncRuleGroups(context.Background(), userRules("platform"))
+	require.Equal(t, "platform", m.GetDestinationTenant(user, "ns/1", "group"))
+	require.Equal(t, "", m.GetDestinationTenant(user, "ns/1", "another"))
+
+	// The destination tenant isn't written to the rule files loaded by the Prometheus rules manager.
+	content, err := os.ReadFile(filepath.Join(m.mapper.Path, user, url.PathEscape("ns/1")))
+	require.NoError(t, err)
+	require.Equal(t, "groups:\n    - name: group\n      interval: 1m\n      rules: []\n", string(content))
+
+	require.NotNil(t, managerCtx)
+	group := rules.NewGroup(rules.GroupOptions{
+		Name: "group",
+		File: filepath.Join(m.mapper.Path, user, url.PathEscape("ns/1")),
+		Opts: &rules.ManagerOptions{},
+	})
+	groupCtx := GroupEvaluationContextFunc(managerCtx, group)
+	require.Equal(t, "platform", destinationTenantFromContext(groupCtx))
+
+	// A change of the destination tenant applies to the running rule group.
+	m.SyncRuleGroups(context.Background(), userRules("another"))
+	require.Equal(t, "another", m.GetDestinationTenant(user, "ns/1", "group"))
+	require.Equal(t, "another", destinationTenantFromContext(groupCtx))
+
+	m.SyncRuleGroups(context.Background(), userRules(""))
+	require.Equal(t, "", destinationTenantFromContext(groupCtx))
+
+	// The destination tenants are removed with the tenant.
+	m.SyncRuleGroups(context.Background(), userRules("platform"))
+	m.SyncRuleGroups(context.Background(), nil)
+	require.Equal(t, "", m.GetDestinationTenant(user, "ns/1", "group"))
+}
+
 func getManager(m *DefaultMultiTenantManager, user string) RulesManager {
 	m.userManagerMtx.RLock()
 	defer m.userManagerMtx.RUnlock()
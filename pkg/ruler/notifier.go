@@ -16,6 +16,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/crypto/tls"
+	"github.com/prometheus/client_golang/prometheus"
 	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
@@ -46,15 +47,25 @@ type rulerNotifier struct {
 	sdManager *discovery.Manager
 	wg        sync.WaitGroup
 	logger    gklog.Logger
+
+	// metrics is a local registry of the notifier metrics, used to read the length
+	// of the notifications queue which isn't exposed by the notifier.Manager.
+	metrics       *prometheus.Registry
+	queueCapacity int
 }
 
 func newRulerNotifier(o *notifier.Options, l gklog.Logger) *rulerNotifier {
 	sdCtx, sdCancel := context.WithCancel(context.Background())
+	metrics := prometheus.NewRegistry()
+	o.Registerer = localRegisterer{Registerer: o.Registerer, local: metrics}
+
 	return &rulerNotifier{
-		notifier:  notifier.NewManager(o, l),
-		sdCancel:  sdCancel,
-		sdManager: discovery.NewManager(sdCtx, l),
-		logger:    l,
+		notifier:      notifier.NewManager(o, l),
+		sdCancel:      sdCancel,
+		sdManager:     discovery.NewManager(sdCtx, l),
+		logger:        l,
+		metrics:       metrics,
+		queueCapacity: o.QueueCapacity,
 	}
 }
 
@@ -91,6 +102,76 @@ func (rn *rulerNotifier) stop() {
 	rn.wg.Wait()
 }
 
+// NotifierStatus is the status of the notifications to the Alertmanager of a tenant.
+type NotifierStatus struct {
+	QueueLength             int      `json:"queue_length"`
+	QueueCapacity           int      `json:"queue_capacity"`
+	DiscoveredAlertmanagers []string `json:"discovered_alertmanagers"`
+	DroppedAlertmanagers    []string `json:"dropped_alertmanagers"`
+}
+
+func (rn *rulerNotifier) status() NotifierStatus {
+	status := NotifierStatus{
+		QueueLength:             rn.queueLength(),
+		QueueCapacity:           rn.queueCapacity,
+		DiscoveredAlertmanagers: []string{},
+		DroppedAlertmanagers:    []string{},
+	}
+	for _, u := range rn.notifier.Alertmanagers() {
+		status.DiscoveredAlertmanagers = append(status.DiscoveredAlertmanagers, u.String())
+	}
+	for _, u := range rn.notifier.DroppedAlertmanagers() {
+		status.DroppedAlertmanagers = append(status.DroppedAlertmanagers, u.String())
+	}
+	return status
+}
+
+func (rn *rulerNotifier) queueLength() int {
+	families, err := rn.metrics.Gather()
+	if err != nil {
+		level.Warn(rn.logger).Log("msg", "failed to gather the notifier metrics", "err", err)
+		return 0
+	}
+	for _, family := range families {
+		if family.GetName() == "prometheus_notifications_queue_length" && len(family.GetMetric()) == 1 {
+			return int(family.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+	return 0
+}
+
+// localRegisterer registers the collectors to both the wrapped Registerer, if any, and a local registry.
+type localRegisterer struct {
+	prometheus.Registerer
+	local *prometheus.Registry
+}
+
+func (r localRegisterer) Register(c prometheus.Collector) error {
+	if err := r.local.Register(c); err != nil {
+		return err
+	}
+	if r.Registerer == nil {
+		return nil
+	}
+	return r.Registerer.Register(c)
+}
+
+func (r localRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r localRegisterer) Unregister(c prometheus.Collector) bool {
+	r.local.Unregister(c)
+	if r.Registerer == nil {
+		return true
+	}
+	return r.Registerer.Unregister(c)
+}
+
 // Builds a Prometheus config.Config from a ruler.Config with just the required
 // options to configure notifications to Alertmanager.
 func buildNotifierConfig(rulerConfig *Config, resolver cache.AddressProvider) (*config.Config, error) {
@@ -43,7 +43,8 @@ import (
 )
 
 var (
-	errInvalidTenantShardSize = errors.New("invalid tenant shard size, the value must be greater or equal to 0")
+	errInvalidTenantShardSize           = errors.New("invalid tenant shard size, the value must be greater or equal to 0")
+	errInvalidEvaluationResultsCacheTTL = errors.New("invalid evaluation results cache TTL, the value must be greater or equal to 0")
 )
 
 const (
@@ -65,6 +66,9 @@ const (
 	// Limit errors
 	errMaxRuleGroupsPerUserLimitExceeded        = "per-user rule groups limit (limit: %d actual: %d) exceeded"
 	errMaxRulesPerRuleGroupPerUserLimitExceeded = "per-user rules per rule group limit (limit: %d actual: %d) exceeded"
+	errMinRuleEvaluationIntervalNotReached      = "per-user min rule evaluation interval (limit: %s actual: %s) not reached"
+	errDestinationTenantNotAllowed              = "per-user allowed destination tenants don't include the rule group destination tenant %s"
+	errDestinationTenantWithAlertingRules       = "a rule group with a destination tenant can only contain recording rules"
 
 	// errors
 	errListAllUser = "unable to list the ruler users"
@@ -113,6 +117,8 @@ type Config struct {
 
 	EnableQueryStats bool `yaml:"query_stats_enabled" category:"advanced"`
 
+	EvaluationResultsCacheTTL time.Duration `yaml:"evaluation_results_cache_ttl" category:"experimental"`
+
 	QueryFrontend QueryFrontendConfig `yaml:"query_frontend"`
 
 	TenantFederation TenantFederationConfig `yaml:"tenant_federation"`
@@ -124,6 +130,10 @@ func (cfg *Config) Validate(limits validation.Limits, log log.Logger) error {
 		return errInvalidTenantShardSize
 	}
 
+	if cfg.EvaluationResultsCacheTTL < 0 {
+		return errInvalidEvaluationResultsCacheTTL
+	}
+
 	if err := cfg.ClientTLSConfig.Validate(log); err != nil {
 		return errors.Wrap(err, "invalid ruler gRPC client config")
 	}
@@ -167,15 +177,17 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.Var(&cfg.DisabledTenants, "ruler.disabled-tenants", "Comma separated list of tenants whose rules this ruler cannot evaluate. If specified, a ruler that would normally pick the specified tenant(s) for processing will ignore them instead. Subject to sharding.")
 
 	f.BoolVar(&cfg.EnableQueryStats, "ruler.query-stats-enabled", false, "Report the wall time for ruler queries to complete as a per-tenant metric and as an info level log message.")
+	f.DurationVar(&cfg.EvaluationResultsCacheTTL, "ruler.evaluation-results-cache-ttl", 0, "How long the results of the rule queries are cached, to reuse them for the identical expressions evaluated at the same timestamp by other rules or rule groups of the same tenant. The cached results are kept in memory. 0 to disable.")
 
 	cfg.RingCheckPeriod = 5 * time.Second
 }
 
 type rulerMetrics struct {
-	listRules       prometheus.Histogram
-	loadRuleGroups  prometheus.Histogram
-	ringCheckErrors prometheus.Counter
-	rulerSync       *prometheus.CounterVec
+	listRules                   prometheus.Histogram
+	loadRuleGroups              prometheus.Histogram
+	ringCheckErrors             prometheus.Counter
+	rulerSync                   *prometheus.CounterVec
+	ruleGroupConfiguredInterval *prometheus.GaugeVec
 }
 
 func newRulerMetrics(reg prometheus.Registerer) *rulerMetrics {
@@ -198,6 +210,10 @@ func newRulerMetrics(reg prometheus.Registerer) *rulerMetrics {
 			Name: "cortex_ruler_sync_rules_total",
 			Help: "Total number of times the ruler sync operation triggered.",
 		}, []string{"reason"}),
+		ruleGroupConfiguredInterval: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ruler_rule_group_configured_interval_seconds",
+			Help: "The configured evaluation interval of the rule groups evaluated at the tenant's minimum rule evaluation interval, because their configured interval is shorter.",
+		}, []string{"user", "namespace", "rule_group"}),
 	}
 }
 
@@ -214,6 +230,18 @@ type MultiTenantManager interface {
 	ValidateRuleGroup(rulefmt.RuleGroup) []error
 	// Start evaluating rules.
 	Start()
+	// GetTenantStatus returns the status of the rules sync and the notifications of a tenant.
+	GetTenantStatus(userID string) TenantStatus
+	// GetDestinationTenant returns the destination tenant of a rule group of a tenant, or an empty string if it has none.
+	GetDestinationTenant(userID, namespace, group string) string
+}
+
+// TenantStatus is the status of the rules sync and the notifications of a tenant in a ruler.
+type TenantStatus struct {
+	// LastSuccessfulSync is nil if the rules of the tenant have never been synced successfully.
+	LastSuccessfulSync *time.Time
+	// Notifier is nil if the tenant has no notifier running.
+	Notifier *NotifierStatus
 }
 
 // Ruler evaluates rules.
@@ -263,6 +291,11 @@ type Ruler struct {
 
 	allowedTenants *util.AllowedTenants
 
+	// The configured evaluation interval of the rule groups whose interval has been rewritten
+	// to the tenant's minimum, by user and rule group.
+	rewrittenIntervalsMtx sync.RWMutex
+	rewrittenIntervals    map[string]map[namespacedGroupName]time.Duration
+
 	registry prometheus.Registerer
 	logger   log.Logger
 }
@@ -477,6 +510,9 @@ func (r *Ruler) syncRules(ctx context.Context, reason rulesSyncReason) {
 	// Filter out all rules for which their evaluation has been disabled for the given tenant.
 	configs = filterRuleGroupsByEnabled(configs, r.limits, r.logger)
 
+	// Enforce the min evaluation interval on the rule groups of the tenants having the interval rewrite enabled.
+	configs = r.rewriteRuleGroupsInterval(configs)
+
 	// This will also delete local group files for users that are no longer in 'configs' map.
 	r.manager.SyncRuleGroups(ctx, configs)
 }
@@ -699,13 +735,14 @@ func filterRuleGroupByEnabled(group *rulespb.RuleGroupDesc, recordingEnabled, al
 
 	// Create a copy of the group and remove some rules.
 	filtered = &rulespb.RuleGroupDesc{
-		Name:          group.Name,
-		Namespace:     group.Namespace,
-		Interval:      group.Interval,
-		Rules:         make([]*rulespb.RuleDesc, 0, len(group.Rules)-removedRules),
-		User:          group.User,
-		Options:       group.Options,
-		SourceTenants: group.SourceTenants,
+		Name:              group.Name,
+		Namespace:         group.Namespace,
+		Interval:          group.Interval,
+		Rules:             make([]*rulespb.RuleDesc, 0, len(group.Rules)-removedRules),
+		User:              group.User,
+		Options:           group.Options,
+		SourceTenants:     group.SourceTenants,
+		DestinationTenant: group.DestinationTenant,
 	}
 
 	for _, rule := range group.Rules {
@@ -722,6 +759,90 @@ func filterRuleGroupByEnabled(group *rulespb.RuleGroupDesc, recordingEnabled, al
 	return filtered, removedRules
 }
 
+type namespacedGroupName struct {
+	namespace, name string
+}
+
+// rewriteRuleGroupsInterval returns the input configs with the evaluation interval of the rule groups shorter than
+// the tenant's minimum rewritten to the minimum, for the tenants having the interval rewrite enabled. The configured
+// interval of the rewritten rule groups is tracked, to expose it via API and metrics.
+//
+// This function doesn't modify the input configs in place, like filterRuleGroupsByEnabled().
+func (r *Ruler) rewriteRuleGroupsInterval(configs map[string]rulespb.RuleGroupList) map[string]rulespb.RuleGroupList {
+	result := make(map[string]rulespb.RuleGroupList, len(configs))
+	rewrittenIntervals := map[string]map[namespacedGroupName]time.Duration{}
+
+	r.metrics.ruleGroupConfiguredInterval.Reset()
+
+	for userID, groups := range configs {
+		minInterval := r.limits.RulerMinRuleEvaluationInterval(userID)
+		if minInterval <= 0 || !r.limits.RulerMinRuleEvaluationIntervalRewrite(userID) {
+			result[userID] = groups
+			continue
+		}
+
+		result[userID] = make(rulespb.RuleGroupList, 0, len(groups))
+		for _, group := range groups {
+			configured := r.effectiveRuleGroupInterval(group.Interval)
+			if configured >= minInterval {
+				result[userID] = append(result[userID], group)
+				continue
+			}
+
+			rewritten := *group
+			rewritten.Interval = minInterval
+			result[userID] = append(result[userID], &rewritten)
+
+			if rewrittenIntervals[userID] == nil {
+				rewrittenIntervals[userID] = map[namespacedGroupName]time.Duration{}
+			}
+			rewrittenIntervals[userID][namespacedGroupName{namespace: group.Namespace, name: group.Name}] = configured
+			r.metrics.ruleGroupConfiguredInterval.WithLabelValues(userID, group.Namespace, group.Name).Set(configured.Seconds())
+		}
+
+		if n := len(rewrittenIntervals[userID]); n > 0 {
+			level.Debug(r.logger).Log("msg", "rewritten the evaluation interval of rule groups to the tenant's min rule evaluation interval", "user", userID, "rule_groups", n, "min_interval", minInterval)
+		}
+	}
+
+	r.rewrittenIntervalsMtx.Lock()
+	r.rewrittenIntervals = rewrittenIntervals
+	r.rewrittenIntervalsMtx.Unlock()
+
+	return result
+}
+
+// configuredRuleGroupInterval returns the configured evaluation interval of the input rule group, given its
+// effective interval.
+func (r *Ruler) configuredRuleGroupInterval(userID, namespace, name string, interval time.Duration) time.Duration {
+	r.rewrittenIntervalsMtx.RLock()
+	defer r.rewrittenIntervalsMtx.RUnlock()
+
+	if configured, ok := r.rewrittenIntervals[userID][namespacedGroupName{namespace: namespace, name: name}]; ok {
+		return configured
+	}
+	return interval
+}
+
+// effectiveRuleGroupInterval returns the interval a rule group is evaluated at, given its configured interval.
+func (r *Ruler) effectiveRuleGroupInterval(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return r.cfg.EvaluationInterval
+	}
+	return interval
+}
+
+// tenantRuleGroupInterval returns the interval a rule group of the input tenant is evaluated at, given its
+// configured interval, taking into account the tenant's min rule evaluation interval rewrite.
+func (r *Ruler) tenantRuleGroupInterval(userID string, interval time.Duration) time.Duration {
+	interval = r.effectiveRuleGroupInterval(interval)
+
+	if minInterval := r.limits.RulerMinRuleEvaluationInterval(userID); minInterval > 0 && interval < minInterval && r.limits.RulerMinRuleEvaluationIntervalRewrite(userID) {
+		return minInterval
+	}
+	return interval
+}
+
 // GetRules retrieves the running rules from this ruler and all running rulers in the ring.
 func (r *Ruler) GetRules(ctx context.Context) ([]*GroupStateDesc, error) {
 	userID, err := tenant.TenantID(ctx)
@@ -808,15 +929,17 @@ func (r *Ruler) getLocalRules(userID string) ([]*GroupStateDesc, error) {
 
 		groupDesc := &GroupStateDesc{
 			Group: &rulespb.RuleGroupDesc{
-				Name:          group.Name(),
-				Namespace:     decodedNamespace,
-				Interval:      interval,
-				User:          userID,
-				SourceTenants: group.SourceTenants(),
+				Name:              group.Name(),
+				Namespace:         decodedNamespace,
+				Interval:          interval,
+				User:              userID,
+				SourceTenants:     group.SourceTenants(),
+				DestinationTenant: r.manager.GetDestinationTenant(userID, decodedNamespace, group.Name()),
 			},
 
 			EvaluationTimestamp: group.GetLastEvaluation(),
 			EvaluationDuration:  group.GetEvaluationTime(),
+			ConfiguredInterval:  r.configuredRuleGroupInterval(userID, decodedNamespace, group.Name(), interval),
 		}
 		for _, r := range group.Rules() {
 			lastError := ""
@@ -912,6 +1035,46 @@ func (r *Ruler) AssertMaxRulesPerRuleGroup(userID string, rules int) error {
 	return fmt.Errorf(errMaxRulesPerRuleGroupPerUserLimitExceeded, limit, rules)
 }
 
+// AssertMinRuleEvaluationInterval checks the input rule group evaluation interval is not shorter than the
+// minimum evaluation interval and returns an error if so. Shorter intervals are accepted if the tenant has
+// the interval rewrite enabled, because the rule group will be evaluated at the minimum interval.
+func (r *Ruler) AssertMinRuleEvaluationInterval(userID string, interval time.Duration) error {
+	limit := r.limits.RulerMinRuleEvaluationInterval(userID)
+
+	if limit <= 0 || r.limits.RulerMinRuleEvaluationIntervalRewrite(userID) {
+		return nil
+	}
+
+	if interval = r.effectiveRuleGroupInterval(interval); interval >= limit {
+		return nil
+	}
+	return fmt.Errorf(errMinRuleEvaluationIntervalNotReached, limit, interval)
+}
+
+// AssertDestinationTenant checks the tenant is allowed to write the results of the input rule group to its
+// destination tenant, if any, and returns an error if not. The rule groups with a destination tenant can only
+// contain recording rules, because the state of the alerts can't be restored from another tenant.
+func (r *Ruler) AssertDestinationTenant(userID string, rg rulespb.RuleGroup) error {
+	if rg.DestinationTenant == "" || rg.DestinationTenant == userID {
+		return nil
+	}
+
+	if err := tenant.ValidTenantID(rg.DestinationTenant); err != nil {
+		return err
+	}
+
+	for _, rule := range rg.Rules {
+		if rule.Alert.Value != "" {
+			return errors.New(errDestinationTenantWithAlertingRules)
+		}
+	}
+
+	if !isDestinationTenantAllowed(r.limits, userID, rg.DestinationTenant) {
+		return fmt.Errorf(errDestinationTenantNotAllowed, rg.DestinationTenant)
+	}
+	return nil
+}
+
 func (r *Ruler) DeleteTenantConfiguration(w http.ResponseWriter, req *http.Request) {
 	logger := util_log.WithContext(req.Context(), r.logger)
 
@@ -960,7 +1123,7 @@ func (r *Ruler) ListAllRules(w http.ResponseWriter, req *http.Request) {
 		if err := r.store.LoadRuleGroups(ctx, userRules); err != nil {
 			return errors.Wrapf(err, "failed to load ruler config for user %s", userID)
 		}
-		data := map[string]map[string][]rulefmt.RuleGroup{userID: userRules[userID].Formatted()}
+		data := map[string]map[string][]rulespb.RuleGroup{userID: userRules[userID].FormattedRuleGroups()}
 
 		select {
 		case iter <- data:
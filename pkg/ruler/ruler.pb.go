@@ -122,6 +122,9 @@ type GroupStateDesc struct {
 	ActiveRules         []*RuleStateDesc       `protobuf:"bytes,2,rep,name=active_rules,json=activeRules,proto3" json:"active_rules,omitempty"`
 	EvaluationTimestamp time.Time              `protobuf:"bytes,3,opt,name=evaluationTimestamp,proto3,stdtime" json:"evaluationTimestamp"`
 	EvaluationDuration  time.Duration          `protobuf:"bytes,4,opt,name=evaluationDuration,proto3,stdduration" json:"evaluationDuration"`
+	// The evaluation interval configured for the group. It differs from the group interval, which is the
+	// effective one, when the configured interval has been rewritten to the tenant's minimum evaluation interval.
+	ConfiguredInterval time.Duration `protobuf:"bytes,5,opt,name=configuredInterval,proto3,stdduration" json:"configuredInterval"`
 }
 
 func (m *GroupStateDesc) Reset()      { *m = GroupStateDesc{} }
@@ -184,6 +187,13 @@ func (m *GroupStateDesc) GetEvaluationDuration() time.Duration {
 	return 0
 }
 
+func (m *GroupStateDesc) GetConfiguredInterval() time.Duration {
+	if m != nil {
+		return m.ConfiguredInterval
+	}
+	return 0
+}
+
 // RuleStateDesc is a proto representation of a Prometheus Rule
 type RuleStateDesc struct {
 	Rule                *rulespb.RuleDesc `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
@@ -388,52 +398,53 @@ func init() {
 func init() { proto.RegisterFile("ruler.proto", fileDescriptor_9ecbec0a4cfddea6) }
 
 var fileDescriptor_9ecbec0a4cfddea6 = []byte{
-	// 709 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x54, 0x3f, 0x6f, 0x13, 0x31,
-	0x14, 0x3f, 0xa7, 0xbd, 0x34, 0x71, 0x4a, 0x2b, 0xdc, 0x80, 0x8e, 0x08, 0x39, 0x55, 0x58, 0x2a,
-	0xa4, 0x5e, 0xa0, 0x54, 0x20, 0x06, 0x40, 0xa9, 0xda, 0xb2, 0x30, 0x54, 0x17, 0x60, 0x8d, 0x7c,
-	0x89, 0x73, 0x3d, 0xf5, 0x72, 0x3e, 0x6c, 0x5f, 0xc4, 0xc8, 0x47, 0xe8, 0xc8, 0xcc, 0xc4, 0xe7,
-	0x60, 0xea, 0xd8, 0xb1, 0x42, 0xa8, 0xd0, 0xeb, 0xc2, 0xd8, 0x8f, 0x80, 0x6c, 0x5f, 0x48, 0x02,
-	0x05, 0x11, 0xa1, 0x2e, 0x89, 0xdf, 0x9f, 0xdf, 0xef, 0xbd, 0xf7, 0x7b, 0x67, 0xc3, 0x0a, 0x4f,
-	0x23, 0xca, 0xdd, 0x84, 0x33, 0xc9, 0x90, 0xad, 0x8d, 0xda, 0x7a, 0x10, 0xca, 0xfd, 0xd4, 0x77,
-	0xbb, 0x6c, 0xd0, 0x0c, 0x58, 0xc0, 0x9a, 0x3a, 0xea, 0xa7, 0x7d, 0x6d, 0x69, 0x43, 0x9f, 0x0c,
-	0xaa, 0x86, 0x03, 0xc6, 0x82, 0x88, 0x8e, 0xb3, 0x7a, 0x29, 0x27, 0x32, 0x64, 0x71, 0x1e, 0xaf,
-	0xff, 0x1a, 0x97, 0xe1, 0x80, 0x0a, 0x49, 0x06, 0x49, 0x9e, 0x70, 0x6f, 0xb2, 0x1e, 0x27, 0x7d,
-	0x12, 0x93, 0xe6, 0x20, 0x1c, 0x84, 0xbc, 0x99, 0x1c, 0x04, 0xe6, 0x94, 0xf8, 0xe6, 0x3f, 0x47,
-	0x3c, 0xfc, 0x2b, 0x42, 0x4f, 0xa1, 0x7f, 0x45, 0xe2, 0x9b, 0x7f, 0x83, 0x6b, 0x2c, 0xc1, 0x45,
-	0x4f, 0x99, 0x1e, 0x7d, 0x93, 0x52, 0x21, 0x1b, 0x4f, 0xe1, 0xb5, 0xdc, 0x16, 0x09, 0x8b, 0x05,
-	0x45, 0xeb, 0xb0, 0x18, 0x70, 0x96, 0x26, 0xc2, 0x01, 0xab, 0x73, 0x6b, 0x95, 0x8d, 0x1b, 0xae,
-	0xd1, 0xe7, 0xb9, 0x72, 0xb6, 0x25, 0x91, 0x74, 0x9b, 0x8a, 0xae, 0x97, 0x27, 0x35, 0x3e, 0x14,
-	0xe0, 0xd2, 0x74, 0x08, 0xdd, 0x85, 0xb6, 0x0e, 0x3a, 0x60, 0x15, 0xac, 0x55, 0x36, 0xaa, 0xae,
-	0xa9, 0xaf, 0xca, 0xe8, 0x4c, 0x8d, 0x37, 0x29, 0xe8, 0x11, 0x5c, 0x24, 0x5d, 0x19, 0x0e, 0x69,
-	0x47, 0x27, 0x39, 0x05, 0x5d, 0xb3, 0x9a, 0xd7, 0x54, 0x90, 0x71, 0xc9, 0x8a, 0xc9, 0xd4, 0xed,
-	0xa2, 0xd7, 0x70, 0x85, 0x0e, 0x49, 0x94, 0x6a, 0x99, 0x5f, 0x8e, 0xe4, 0x74, 0xe6, 0x74, 0xc9,
-	0x9a, 0x6b, 0x04, 0x77, 0x47, 0x82, 0xbb, 0x3f, 0x33, 0xb6, 0x4a, 0x47, 0xa7, 0x75, 0xeb, 0xf0,
-	0x6b, 0x1d, 0x78, 0x97, 0x11, 0xa0, 0x36, 0x44, 0x63, 0xf7, 0x76, 0xbe, 0x46, 0x67, 0x5e, 0xd3,
-	0xde, 0xfa, 0x8d, 0x76, 0x94, 0x60, 0x58, 0xdf, 0x2b, 0xd6, 0x4b, 0xe0, 0x8d, 0x2f, 0x05, 0xa3,
-	0xf2, 0x58, 0xa3, 0x3b, 0x70, 0x5e, 0x8d, 0x98, 0x4b, 0xb4, 0x3c, 0x21, 0x91, 0x1e, 0x55, 0x07,
-	0x51, 0x15, 0xda, 0x42, 0x21, 0x9c, 0xc2, 0x2a, 0x58, 0x2b, 0x7b, 0xc6, 0x40, 0x37, 0x61, 0x71,
-	0x9f, 0x92, 0x48, 0xee, 0xeb, 0x61, 0xcb, 0x5e, 0x6e, 0xa1, 0xdb, 0xb0, 0x1c, 0x11, 0x21, 0x77,
-	0x38, 0x67, 0x5c, 0x37, 0x5c, 0xf6, 0xc6, 0x0e, 0xb5, 0x56, 0x12, 0x51, 0x2e, 0x85, 0x63, 0x4f,
-	0xad, 0xb5, 0xa5, 0x9c, 0x13, 0x6b, 0x35, 0x49, 0x7f, 0x92, 0xb7, 0x78, 0x35, 0xf2, 0x2e, 0xfc,
-	0x9f, 0xbc, 0x9f, 0x6c, 0xb8, 0x34, 0x3d, 0xc7, 0x58, 0x3a, 0x30, 0x29, 0x5d, 0x1f, 0x16, 0x23,
-	0xe2, 0xd3, 0x68, 0xf4, 0x9d, 0xad, 0xb8, 0x5d, 0xc6, 0x25, 0x7d, 0x9b, 0xf8, 0xee, 0x0b, 0xe5,
-	0xdf, 0x23, 0x21, 0xdf, 0x7a, 0xac, 0x6a, 0x7d, 0x3e, 0xad, 0xdf, 0xff, 0x97, 0x3b, 0x69, 0x70,
-	0xad, 0x1e, 0x49, 0x24, 0xe5, 0x5e, 0xce, 0x8e, 0x12, 0x58, 0x21, 0x71, 0xcc, 0xa4, 0x6e, 0x4f,
-	0x38, 0x73, 0x57, 0x52, 0x6c, 0xb2, 0x84, 0x9a, 0x57, 0xe9, 0x42, 0xf5, 0xe2, 0x81, 0x67, 0x0c,
-	0xd4, 0x82, 0xe5, 0xfc, 0x76, 0x11, 0xe9, 0xd8, 0x33, 0xec, 0xae, 0x64, 0x60, 0x2d, 0x89, 0x9e,
-	0xc1, 0x52, 0x3f, 0xe4, 0xb4, 0xa7, 0x18, 0x66, 0xd9, 0xfe, 0x82, 0x46, 0xb5, 0x24, 0xda, 0x81,
-	0x15, 0x4e, 0x05, 0x8b, 0x86, 0x86, 0x63, 0x61, 0x06, 0x0e, 0x38, 0x02, 0xb6, 0x24, 0xda, 0x85,
-	0x8b, 0xea, 0x63, 0xee, 0x08, 0x1a, 0x4b, 0xc5, 0x53, 0x9a, 0x85, 0x47, 0x21, 0xdb, 0x34, 0x96,
-	0xa6, 0x9d, 0x21, 0x89, 0xc2, 0x5e, 0x27, 0x8d, 0x65, 0x18, 0x39, 0xe5, 0x59, 0x68, 0x34, 0xf0,
-	0x95, 0xc2, 0xa1, 0x3d, 0x78, 0xfd, 0x80, 0xd2, 0xa4, 0xd3, 0x0f, 0x79, 0x18, 0x07, 0x1d, 0x11,
-	0xc6, 0x5d, 0xea, 0xc0, 0x19, 0xc8, 0x96, 0x15, 0x7c, 0x57, 0xa3, 0xdb, 0x0a, 0xbc, 0xf1, 0x04,
-	0xda, 0xea, 0xfa, 0x73, 0xb4, 0x69, 0x0e, 0x02, 0xad, 0x4c, 0xbc, 0x82, 0xa3, 0xf7, 0xba, 0x56,
-	0x9d, 0x76, 0x9a, 0x47, 0xbb, 0x61, 0x6d, 0x6d, 0x1e, 0x9f, 0x61, 0xeb, 0xe4, 0x0c, 0x5b, 0x17,
-	0x67, 0x18, 0xbc, 0xcb, 0x30, 0xf8, 0x98, 0x61, 0x70, 0x94, 0x61, 0x70, 0x9c, 0x61, 0xf0, 0x2d,
-	0xc3, 0xe0, 0x7b, 0x86, 0xad, 0x8b, 0x0c, 0x83, 0xc3, 0x73, 0x6c, 0x1d, 0x9f, 0x63, 0xeb, 0xe4,
-	0x1c, 0x5b, 0x7e, 0x51, 0xf7, 0xf8, 0xe0, 0x47, 0x00, 0x00, 0x00, 0xff, 0xff, 0x64, 0xb3, 0xf8,
-	0xf8, 0x04, 0x07, 0x00, 0x00,
+	// 733 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0x4d, 0x6f, 0x13, 0x3b,
+	0x14, 0x9d, 0x49, 0x3a, 0x69, 0xe2, 0xf4, 0x43, 0xcf, 0xed, 0x7b, 0x9a, 0x17, 0x21, 0x27, 0x0a,
+	0x9b, 0x0a, 0xa9, 0x13, 0x28, 0x15, 0x88, 0x05, 0xa0, 0x54, 0x6d, 0x11, 0x12, 0x8b, 0x6a, 0x02,
+	0x6c, 0x23, 0x27, 0x71, 0xa6, 0x56, 0x27, 0xe3, 0xc1, 0xf6, 0x44, 0x2c, 0xf9, 0x09, 0x5d, 0xf2,
+	0x13, 0xf8, 0x1d, 0xac, 0xba, 0xec, 0xb2, 0x42, 0xa8, 0xd0, 0x74, 0xc3, 0xb2, 0x3b, 0xb6, 0xc8,
+	0xf6, 0xa4, 0x49, 0x68, 0x41, 0x8c, 0x50, 0x37, 0x99, 0xb9, 0xf7, 0x9e, 0x73, 0xae, 0x7d, 0xae,
+	0x9d, 0x01, 0x65, 0x9e, 0x84, 0x84, 0x7b, 0x31, 0x67, 0x92, 0x41, 0x47, 0x07, 0x95, 0xf5, 0x80,
+	0xca, 0xfd, 0xa4, 0xe3, 0x75, 0xd9, 0xa0, 0x11, 0xb0, 0x80, 0x35, 0x74, 0xb5, 0x93, 0xf4, 0x75,
+	0xa4, 0x03, 0xfd, 0x66, 0x58, 0x15, 0x14, 0x30, 0x16, 0x84, 0x64, 0x82, 0xea, 0x25, 0x1c, 0x4b,
+	0xca, 0xa2, 0xb4, 0x5e, 0xfd, 0xb9, 0x2e, 0xe9, 0x80, 0x08, 0x89, 0x07, 0x71, 0x0a, 0xb8, 0x3b,
+	0xdd, 0x8f, 0xe3, 0x3e, 0x8e, 0x70, 0x63, 0x40, 0x07, 0x94, 0x37, 0xe2, 0x83, 0xc0, 0xbc, 0xc5,
+	0x1d, 0xf3, 0x4c, 0x19, 0x0f, 0x7e, 0xcb, 0xd0, 0xbb, 0xd0, 0xbf, 0x22, 0xee, 0x98, 0xa7, 0xe1,
+	0xd5, 0x97, 0xc0, 0x82, 0xaf, 0x42, 0x9f, 0xbc, 0x49, 0x88, 0x90, 0xf5, 0x27, 0x60, 0x31, 0x8d,
+	0x45, 0xcc, 0x22, 0x41, 0xe0, 0x3a, 0x28, 0x04, 0x9c, 0x25, 0xb1, 0x70, 0xed, 0x5a, 0x7e, 0xad,
+	0xbc, 0xf1, 0xaf, 0x67, 0xfc, 0x79, 0xa6, 0x92, 0x2d, 0x89, 0x25, 0xd9, 0x26, 0xa2, 0xeb, 0xa7,
+	0xa0, 0xfa, 0xf7, 0x1c, 0x58, 0x9a, 0x2d, 0xc1, 0x3b, 0xc0, 0xd1, 0x45, 0xd7, 0xae, 0xd9, 0x6b,
+	0xe5, 0x8d, 0x55, 0xcf, 0xf4, 0x57, 0x6d, 0x34, 0x52, 0xf3, 0x0d, 0x04, 0x3e, 0x04, 0x0b, 0xb8,
+	0x2b, 0xe9, 0x90, 0xb4, 0x35, 0xc8, 0xcd, 0xd5, 0xf2, 0x97, 0x14, 0xae, 0x29, 0x93, 0x96, 0x65,
+	0x83, 0xd4, 0xcb, 0x85, 0xaf, 0xc1, 0x0a, 0x19, 0xe2, 0x30, 0xd1, 0x36, 0xbf, 0x1c, 0xdb, 0xe9,
+	0xe6, 0x75, 0xcb, 0x8a, 0x67, 0x0c, 0xf7, 0xc6, 0x86, 0x7b, 0x97, 0x88, 0xad, 0xe2, 0xd1, 0x69,
+	0xd5, 0x3a, 0xfc, 0x52, 0xb5, 0xfd, 0xeb, 0x04, 0x60, 0x0b, 0xc0, 0x49, 0x7a, 0x3b, 0x1d, 0xa3,
+	0x3b, 0xa7, 0x65, 0xff, 0xbf, 0x22, 0x3b, 0x06, 0x18, 0xd5, 0xf7, 0x4a, 0xf5, 0x1a, 0xba, 0x12,
+	0xed, 0xb2, 0xa8, 0x4f, 0x83, 0x84, 0x93, 0xde, 0xf3, 0x48, 0x12, 0x3e, 0xc4, 0xa1, 0xeb, 0x64,
+	0x10, 0xbd, 0x4a, 0xaf, 0x7f, 0xce, 0x81, 0xc5, 0x19, 0x83, 0xe0, 0x6d, 0x30, 0xa7, 0x7c, 0x4b,
+	0x7d, 0x5f, 0x9e, 0xf2, 0x5d, 0xfb, 0xa7, 0x8b, 0x70, 0x15, 0x38, 0x42, 0x31, 0xdc, 0x5c, 0xcd,
+	0x5e, 0x2b, 0xf9, 0x26, 0x80, 0xff, 0x81, 0xc2, 0x3e, 0xc1, 0xa1, 0xdc, 0xd7, 0x0e, 0x96, 0xfc,
+	0x34, 0x82, 0xb7, 0x40, 0x29, 0xc4, 0x42, 0xee, 0x70, 0xce, 0xb8, 0x76, 0xa1, 0xe4, 0x4f, 0x12,
+	0xea, 0xac, 0xe0, 0x90, 0x70, 0x29, 0x5c, 0x67, 0xe6, 0xac, 0x34, 0x55, 0x72, 0xea, 0xac, 0x18,
+	0xd0, 0xaf, 0x66, 0x56, 0xb8, 0x99, 0x99, 0xcd, 0xff, 0xd5, 0xcc, 0xea, 0x1f, 0x1d, 0xb0, 0x34,
+	0xbb, 0x8f, 0x89, 0x75, 0xf6, 0xb4, 0x75, 0x7d, 0x50, 0x08, 0x71, 0x87, 0x84, 0xe3, 0xc3, 0xbb,
+	0xe2, 0x75, 0x19, 0x97, 0xe4, 0x6d, 0xdc, 0xf1, 0x5e, 0xa8, 0xfc, 0x1e, 0xa6, 0x7c, 0xeb, 0x91,
+	0xea, 0xf5, 0xe9, 0xb4, 0x7a, 0xef, 0x4f, 0x2e, 0xba, 0xe1, 0x35, 0x7b, 0x38, 0x96, 0x84, 0xfb,
+	0xa9, 0x3a, 0x8c, 0x41, 0x19, 0x47, 0x11, 0x93, 0x7a, 0x79, 0xc2, 0xcd, 0xdf, 0x48, 0xb3, 0xe9,
+	0x16, 0x6a, 0xbf, 0xca, 0x17, 0xa2, 0x07, 0x6f, 0xfb, 0x26, 0x80, 0x4d, 0x50, 0x4a, 0xaf, 0x2c,
+	0x96, 0xae, 0x93, 0x61, 0x76, 0x45, 0x43, 0x6b, 0x4a, 0xf8, 0x14, 0x14, 0xfb, 0x94, 0x93, 0x9e,
+	0x52, 0xc8, 0x32, 0xfd, 0x79, 0xcd, 0x6a, 0x4a, 0xb8, 0x03, 0xca, 0x9c, 0x08, 0x16, 0x0e, 0x8d,
+	0xc6, 0x7c, 0x06, 0x0d, 0x30, 0x26, 0x36, 0x25, 0xdc, 0x05, 0x0b, 0xea, 0x30, 0xb7, 0x05, 0x89,
+	0xa4, 0xd2, 0x29, 0x66, 0xd1, 0x51, 0xcc, 0x16, 0x89, 0xa4, 0x59, 0xce, 0x10, 0x87, 0xb4, 0xd7,
+	0x4e, 0x22, 0x49, 0x43, 0xb7, 0x94, 0x45, 0x46, 0x13, 0x5f, 0x29, 0x1e, 0xdc, 0x03, 0xff, 0x1c,
+	0x10, 0x12, 0xb7, 0xfb, 0x94, 0xd3, 0x28, 0x68, 0x0b, 0x1a, 0x75, 0x89, 0x0b, 0x32, 0x88, 0x2d,
+	0x2b, 0xfa, 0xae, 0x66, 0xb7, 0x14, 0x79, 0xe3, 0x31, 0x70, 0xd4, 0xf5, 0xe7, 0x70, 0xd3, 0xbc,
+	0x08, 0xb8, 0x32, 0xf5, 0xd7, 0x3a, 0xfe, 0x08, 0x54, 0x56, 0x67, 0x93, 0xe6, 0x4b, 0x50, 0xb7,
+	0xb6, 0x36, 0x8f, 0xcf, 0x90, 0x75, 0x72, 0x86, 0xac, 0x8b, 0x33, 0x64, 0xbf, 0x1b, 0x21, 0xfb,
+	0xc3, 0x08, 0xd9, 0x47, 0x23, 0x64, 0x1f, 0x8f, 0x90, 0xfd, 0x75, 0x84, 0xec, 0x6f, 0x23, 0x64,
+	0x5d, 0x8c, 0x90, 0x7d, 0x78, 0x8e, 0xac, 0xe3, 0x73, 0x64, 0x9d, 0x9c, 0x23, 0xab, 0x53, 0xd0,
+	0x6b, 0xbc, 0xff, 0x63, 0x00, 0x46, 0x8a, 0x1d, 0xa7, 0x59, 0x07, 0x00, 0x00,
 }
 
 func (this *RulesRequest) Equal(that interface{}) bool {
@@ -522,6 +533,9 @@ func (this *GroupStateDesc) Equal(that interface{}) bool {
 	if this.EvaluationDuration != that1.EvaluationDuration {
 		return false
 	}
+	if this.ConfiguredInterval != that1.ConfiguredInterval {
+		return false
+	}
 	return true
 }
 func (this *RuleStateDesc) Equal(that interface{}) bool {
@@ -657,7 +671,7 @@ func (this *GroupStateDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&ruler.GroupStateDesc{")
 	if this.Group != nil {
 		s = append(s, "Group: "+fmt.Sprintf("%#v", this.Group)+",\n")
@@ -667,6 +681,7 @@ func (this *GroupStateDesc) GoString() string {
 	}
 	s = append(s, "EvaluationTimestamp: "+fmt.Sprintf("%#v", this.EvaluationTimestamp)+",\n")
 	s = append(s, "EvaluationDuration: "+fmt.Sprintf("%#v", this.EvaluationDuration)+",\n")
+	s = append(s, "ConfiguredInterval: "+fmt.Sprintf("%#v", this.ConfiguredInterval)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -878,21 +893,29 @@ func (m *GroupStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.ConfiguredInterval, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.ConfiguredInterval):])
 	if err1 != nil {
 		return 0, err1
 	}
 	i -= n1
 	i = encodeVarintRuler(dAtA, i, uint64(n1))
 	i--
-	dAtA[i] = 0x22
-	n2, err2 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	dAtA[i] = 0x2a
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
 	if err2 != nil {
 		return 0, err2
 	}
 	i -= n2
 	i = encodeVarintRuler(dAtA, i, uint64(n2))
 	i--
+	dAtA[i] = 0x22
+	n3, err3 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	if err3 != nil {
+		return 0, err3
+	}
+	i -= n3
+	i = encodeVarintRuler(dAtA, i, uint64(n3))
+	i--
 	dAtA[i] = 0x1a
 	if len(m.ActiveRules) > 0 {
 		for iNdEx := len(m.ActiveRules) - 1; iNdEx >= 0; iNdEx-- {
@@ -943,21 +966,21 @@ func (m *RuleStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n4, err4 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
-	if err4 != nil {
-		return 0, err4
-	}
-	i -= n4
-	i = encodeVarintRuler(dAtA, i, uint64(n4))
-	i--
-	dAtA[i] = 0x3a
-	n5, err5 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	n5, err5 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.EvaluationDuration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration):])
 	if err5 != nil {
 		return 0, err5
 	}
 	i -= n5
 	i = encodeVarintRuler(dAtA, i, uint64(n5))
 	i--
+	dAtA[i] = 0x3a
+	n6, err6 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.EvaluationTimestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.EvaluationTimestamp):])
+	if err6 != nil {
+		return 0, err6
+	}
+	i -= n6
+	i = encodeVarintRuler(dAtA, i, uint64(n6))
+	i--
 	dAtA[i] = 0x32
 	if len(m.Alerts) > 0 {
 		for iNdEx := len(m.Alerts) - 1; iNdEx >= 0; iNdEx-- {
@@ -1029,53 +1052,53 @@ func (m *AlertStateDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n7, err7 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.KeepFiringSince, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.KeepFiringSince):])
-	if err7 != nil {
-		return 0, err7
-	}
-	i -= n7
-	i = encodeVarintRuler(dAtA, i, uint64(n7))
-	i--
-	dAtA[i] = 0x52
-	n8, err8 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ValidUntil, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ValidUntil):])
+	n8, err8 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.KeepFiringSince, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.KeepFiringSince):])
 	if err8 != nil {
 		return 0, err8
 	}
 	i -= n8
 	i = encodeVarintRuler(dAtA, i, uint64(n8))
 	i--
-	dAtA[i] = 0x4a
-	n9, err9 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.LastSentAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.LastSentAt):])
+	dAtA[i] = 0x52
+	n9, err9 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ValidUntil, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ValidUntil):])
 	if err9 != nil {
 		return 0, err9
 	}
 	i -= n9
 	i = encodeVarintRuler(dAtA, i, uint64(n9))
 	i--
-	dAtA[i] = 0x42
-	n10, err10 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ResolvedAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ResolvedAt):])
+	dAtA[i] = 0x4a
+	n10, err10 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.LastSentAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.LastSentAt):])
 	if err10 != nil {
 		return 0, err10
 	}
 	i -= n10
 	i = encodeVarintRuler(dAtA, i, uint64(n10))
 	i--
-	dAtA[i] = 0x3a
-	n11, err11 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.FiredAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.FiredAt):])
+	dAtA[i] = 0x42
+	n11, err11 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ResolvedAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ResolvedAt):])
 	if err11 != nil {
 		return 0, err11
 	}
 	i -= n11
 	i = encodeVarintRuler(dAtA, i, uint64(n11))
 	i--
-	dAtA[i] = 0x32
-	n12, err12 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ActiveAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ActiveAt):])
+	dAtA[i] = 0x3a
+	n12, err12 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.FiredAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.FiredAt):])
 	if err12 != nil {
 		return 0, err12
 	}
 	i -= n12
 	i = encodeVarintRuler(dAtA, i, uint64(n12))
 	i--
+	dAtA[i] = 0x32
+	n13, err13 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.ActiveAt, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.ActiveAt):])
+	if err13 != nil {
+		return 0, err13
+	}
+	i -= n13
+	i = encodeVarintRuler(dAtA, i, uint64(n13))
+	i--
 	dAtA[i] = 0x2a
 	if m.Value != 0 {
 		i -= 8
@@ -1176,6 +1199,8 @@ func (m *GroupStateDesc) Size() (n int) {
 	n += 1 + l + sovRuler(uint64(l))
 	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.EvaluationDuration)
 	n += 1 + l + sovRuler(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.ConfiguredInterval)
+	n += 1 + l + sovRuler(uint64(l))
 	return n
 }
 
@@ -1298,6 +1323,7 @@ func (this *GroupStateDesc) String() string {
 		`ActiveRules:` + repeatedStringForActiveRules + `,`,
 		`EvaluationTimestamp:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationTimestamp), "Timestamp", "timestamp.Timestamp", 1), `&`, ``, 1) + `,`,
 		`EvaluationDuration:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationDuration), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`ConfiguredInterval:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.ConfiguredInterval), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1655,6 +1681,39 @@ func (m *GroupStateDesc) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConfiguredInterval", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRuler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRuler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRuler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.ConfiguredInterval, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRuler(dAtA[iNdEx:])
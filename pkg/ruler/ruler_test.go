@@ -30,6 +30,7 @@ import (
 	"github.com/grafana/dskit/test"
 	"github.com/prometheus/client_golang/prometheus"
 	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/prometheus/prometheus/notifier"
@@ -1290,6 +1291,67 @@ func TestFilterRuleGroupsByEnabled(t *testing.T) {
 	}
 }
 
+func TestRuler_RewriteRuleGroupsInterval(t *testing.T) {
+	cfg := defaultRulerConfig(t)
+	cfg.EvaluationInterval = 30 * time.Second
+
+	r := prepareRuler(t, cfg, newMockRuleStore(nil), withLimits(validation.MockOverrides(func(defaults *validation.Limits, tenantLimits map[string]*validation.Limits) {
+		tenantLimits["user-1"] = validation.MockDefaultLimits()
+		tenantLimits["user-1"].RulerMinRuleEvaluationInterval = model.Duration(time.Minute)
+		tenantLimits["user-1"].RulerMinRuleEvaluationIntervalRewrite = true
+
+		// The rewrite is disabled for user-2, so its rule groups are not rewritten.
+		tenantLimits["user-2"] = validation.MockDefaultLimits()
+		tenantLimits["user-2"].RulerMinRuleEvaluationInterval = model.Duration(time.Minute)
+	})))
+
+	configs := map[string]rulespb.RuleGroupList{
+		"user-1": {
+			&rulespb.RuleGroupDesc{Name: "group-1", Namespace: "ns", User: "user-1", Interval: 15 * time.Second},
+			&rulespb.RuleGroupDesc{Name: "group-2", Namespace: "ns", User: "user-1", Interval: 2 * time.Minute},
+			&rulespb.RuleGroupDesc{Name: "group-3", Namespace: "ns", User: "user-1"}, // Default evaluation interval.
+		},
+		"user-2": {
+			&rulespb.RuleGroupDesc{Name: "group-1", Namespace: "ns", User: "user-2", Interval: 15 * time.Second},
+		},
+		"user-3": {
+			&rulespb.RuleGroupDesc{Name: "group-1", Namespace: "ns", User: "user-3", Interval: 15 * time.Second},
+		},
+	}
+
+	actual := r.rewriteRuleGroupsInterval(configs)
+
+	intervals := func(groups rulespb.RuleGroupList) (res []time.Duration) {
+		for _, g := range groups {
+			res = append(res, g.Interval)
+		}
+		return
+	}
+	assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, time.Minute}, intervals(actual["user-1"]))
+	assert.Equal(t, []time.Duration{15 * time.Second}, intervals(actual["user-2"]))
+	assert.Equal(t, []time.Duration{15 * time.Second}, intervals(actual["user-3"]))
+
+	// The input configs should not be modified.
+	assert.Equal(t, []time.Duration{15 * time.Second, 2 * time.Minute, 0}, intervals(configs["user-1"]))
+
+	// The configured interval of the rewritten rule groups should be tracked.
+	assert.Equal(t, 15*time.Second, r.configuredRuleGroupInterval("user-1", "ns", "group-1", time.Minute))
+	assert.Equal(t, 30*time.Second, r.configuredRuleGroupInterval("user-1", "ns", "group-3", time.Minute))
+	assert.Equal(t, 2*time.Minute, r.configuredRuleGroupInterval("user-1", "ns", "group-2", 2*time.Minute))
+
+	assert.NoError(t, prom_testutil.GatherAndCompare(r.registry.(*prometheus.Registry), strings.NewReader(`
+		# HELP cortex_ruler_rule_group_configured_interval_seconds The configured evaluation interval of the rule groups evaluated at the tenant's minimum rule evaluation interval, because their configured interval is shorter.
+		# TYPE cortex_ruler_rule_group_configured_interval_seconds gauge
+		cortex_ruler_rule_group_configured_interval_seconds{namespace="ns",rule_group="group-1",user="user-1"} 15
+		cortex_ruler_rule_group_configured_interval_seconds{namespace="ns",rule_group="group-3",user="user-1"} 30
+	`), "cortex_ruler_rule_group_configured_interval_seconds"))
+
+	// Rule groups no longer rewritten should not be tracked anymore.
+	r.rewriteRuleGroupsInterval(map[string]rulespb.RuleGroupList{"user-1": configs["user-1"][1:2]})
+	assert.Equal(t, time.Minute, r.configuredRuleGroupInterval("user-1", "ns", "group-1", time.Minute))
+	assert.NoError(t, prom_testutil.GatherAndCompare(r.registry.(*prometheus.Registry), strings.NewReader(""), "cortex_ruler_rule_group_configured_interval_seconds"))
+}
+
 func BenchmarkFilterRuleGroupsByEnabled(b *testing.B) {
 	const (
 		numTenants                    = 1000
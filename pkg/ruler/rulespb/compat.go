@@ -16,6 +16,31 @@ import (
 	"github.com/grafana/mimir/pkg/mimirpb" //lint:ignore faillint allowed to import other protobuf
 )
 
+// RuleGroup is a Prometheus rule group with the fields which are specific to Mimir, and therefore
+// not part of the rule files loaded by the Prometheus rules manager.
+type RuleGroup struct {
+	rulefmt.RuleGroup `yaml:",inline"`
+
+	// DestinationTenant is the tenant the results of the recording rules are written to,
+	// if other than the tenant owning the rule group.
+	DestinationTenant string `yaml:"destination_tenant,omitempty"`
+}
+
+// RuleGroupToProto transforms a formatted Mimir rulegroup to a rule group protobuf
+func RuleGroupToProto(user string, namespace string, rl RuleGroup) *RuleGroupDesc {
+	rg := ToProto(user, namespace, rl.RuleGroup)
+	rg.DestinationTenant = rl.DestinationTenant
+	return rg
+}
+
+// RuleGroupFromProto generates a Mimir RuleGroup
+func RuleGroupFromProto(rg *RuleGroupDesc) RuleGroup {
+	return RuleGroup{
+		RuleGroup:         FromProto(rg),
+		DestinationTenant: rg.GetDestinationTenant(),
+	}
+}
+
 // ToProto transforms a formatted prometheus rulegroup to a rule group protobuf
 func ToProto(user string, namespace string, rl rulefmt.RuleGroup) *RuleGroupDesc {
 	rg := RuleGroupDesc{
@@ -38,6 +38,16 @@ evaluation_delay: 3m
 source_tenants:
   - a
   - b
+rules:
+    - record: test_metric:sum:rate1m
+      expr: sum(rate(test_metric[1m]))
+`,
+		"with source tenants and destination tenant": `
+name: testrules
+source_tenants:
+  - a
+  - b
+destination_tenant: c
 rules:
     - record: test_metric:sum:rate1m
       expr: sum(rate(test_metric[1m]))
@@ -55,16 +65,22 @@ rules:
 `,
 	} {
 		t.Run(name, func(t *testing.T) {
-			rg := rulefmt.RuleGroup{}
+			rg := RuleGroup{}
 			require.NoError(t, yaml.Unmarshal([]byte(group), &rg))
 
-			desc := ToProto("user", "namespace", rg)
-			newRg := FromProto(desc)
+			desc := RuleGroupToProto("user", "namespace", rg)
+			newRg := RuleGroupFromProto(desc)
 
 			newYaml, err := yaml.Marshal(newRg)
 			require.NoError(t, err)
 
 			assert.YAMLEq(t, group, string(newYaml))
+
+			// The Prometheus rule group doesn't have the Mimir fields, so it can be loaded by the Prometheus rules manager.
+			ruleFile, err := yaml.Marshal(rulefmt.RuleGroups{Groups: []rulefmt.RuleGroup{FromProto(desc)}})
+			require.NoError(t, err)
+			_, errs := rulefmt.Parse(ruleFile)
+			require.Empty(t, errs)
 		})
 	}
 }
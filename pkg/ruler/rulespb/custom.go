@@ -24,3 +24,13 @@ func (l RuleGroupList) Formatted() map[string][]rulefmt.RuleGroup {
 	}
 	return ruleMap
 }
+
+// FormattedRuleGroups returns the rule group list as a set of formatted Mimir rule groups
+// mapped by namespace. Unlike Formatted, it includes the fields specific to Mimir.
+func (l RuleGroupList) FormattedRuleGroups() map[string][]RuleGroup {
+	ruleMap := map[string][]RuleGroup{}
+	for _, g := range l {
+		ruleMap[g.Namespace] = append(ruleMap[g.Namespace], RuleGroupFromProto(g))
+	}
+	return ruleMap
+}
@@ -47,6 +47,7 @@ type RuleGroupDesc struct {
 	SourceTenants                 []string      `protobuf:"bytes,10,rep,name=sourceTenants,proto3" json:"sourceTenants,omitempty"`
 	EvaluationDelay               time.Duration `protobuf:"bytes,11,opt,name=evaluationDelay,proto3,stdduration" json:"evaluationDelay"`
 	AlignEvaluationTimeOnInterval bool          `protobuf:"varint,12,opt,name=align_evaluation_time_on_interval,json=alignEvaluationTimeOnInterval,proto3" json:"align_evaluation_time_on_interval,omitempty"`
+	DestinationTenant             string        `protobuf:"bytes,13,opt,name=destinationTenant,proto3" json:"destinationTenant,omitempty"`
 }
 
 func (m *RuleGroupDesc) Reset()      { *m = RuleGroupDesc{} }
@@ -144,6 +145,13 @@ func (m *RuleGroupDesc) GetAlignEvaluationTimeOnInterval() bool {
 	return false
 }
 
+func (m *RuleGroupDesc) GetDestinationTenant() string {
+	if m != nil {
+		return m.DestinationTenant
+	}
+	return ""
+}
+
 // RuleDesc is a proto representation of a Prometheus Rule
 type RuleDesc struct {
 	Expr          string                                              `protobuf:"bytes,1,opt,name=expr,proto3" json:"expr,omitempty"`
@@ -230,44 +238,45 @@ func init() {
 func init() { proto.RegisterFile("rules.proto", fileDescriptor_8e722d3e922f0937) }
 
 var fileDescriptor_8e722d3e922f0937 = []byte{
-	// 589 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x53, 0x31, 0x6f, 0xd4, 0x30,
-	0x14, 0x8e, 0xb9, 0x5c, 0x9a, 0xf8, 0x38, 0xb5, 0x32, 0x15, 0x4a, 0x2b, 0x70, 0x8f, 0x0a, 0xa4,
-	0x5b, 0xc8, 0x41, 0x11, 0x03, 0x03, 0x42, 0xad, 0x4a, 0x81, 0x02, 0x02, 0x45, 0x9d, 0x58, 0x22,
-	0x27, 0xf5, 0x85, 0xa8, 0x89, 0x6d, 0x39, 0x49, 0xd5, 0x6e, 0xfc, 0x04, 0x46, 0x7e, 0x02, 0x23,
-	0xbf, 0x80, 0xb9, 0x63, 0xc7, 0x8a, 0xa1, 0xd0, 0x74, 0x61, 0xec, 0x4f, 0x40, 0xb6, 0x73, 0x6d,
-	0x29, 0xcb, 0x2d, 0x4c, 0x79, 0xcf, 0xdf, 0xfb, 0xde, 0xfb, 0xfc, 0x3d, 0x07, 0xf6, 0x64, 0x9d,
-	0xd3, 0x32, 0x10, 0x92, 0x57, 0x1c, 0x75, 0x75, 0xb2, 0x78, 0x3f, 0xcd, 0xaa, 0x8f, 0x75, 0x1c,
-	0x24, 0xbc, 0x18, 0xa5, 0x3c, 0xe5, 0x23, 0x8d, 0xc6, 0xf5, 0x58, 0x67, 0x3a, 0xd1, 0x91, 0x61,
-	0x2d, 0xe2, 0x94, 0xf3, 0x34, 0xa7, 0x17, 0x55, 0xdb, 0xb5, 0x24, 0x55, 0xc6, 0x59, 0x8b, 0x2f,
-	0x5c, 0xc5, 0x09, 0xdb, 0x6f, 0xa1, 0x07, 0x97, 0x27, 0x49, 0x32, 0x26, 0x8c, 0x8c, 0x8a, 0xac,
-	0xc8, 0xe4, 0x48, 0xec, 0xa4, 0x26, 0x12, 0xb1, 0xf9, 0x1a, 0xc6, 0xf2, 0xf7, 0x0e, 0xec, 0x87,
-	0x75, 0x4e, 0x5f, 0x48, 0x5e, 0x8b, 0x75, 0x5a, 0x26, 0x08, 0x41, 0x9b, 0x91, 0x82, 0xfa, 0x60,
-	0x00, 0x86, 0x5e, 0xa8, 0x63, 0x74, 0x0b, 0x7a, 0xea, 0x5b, 0x0a, 0x92, 0x50, 0xff, 0x9a, 0x06,
-	0x2e, 0x0e, 0xd0, 0x33, 0xe8, 0x66, 0xac, 0xa2, 0x72, 0x97, 0xe4, 0x7e, 0x67, 0x00, 0x86, 0xbd,
-	0x95, 0x85, 0xc0, 0x68, 0x0c, 0x26, 0x1a, 0x83, 0xf5, 0xf6, 0x0e, 0x6b, 0xee, 0xc1, 0xf1, 0x92,
-	0xf5, 0xe5, 0xe7, 0x12, 0x08, 0xcf, 0x49, 0xe8, 0x1e, 0x34, 0x4e, 0xf9, 0xf6, 0xa0, 0x33, 0xec,
-	0xad, 0xcc, 0x06, 0xc6, 0x44, 0xa5, 0x4b, 0x49, 0x0a, 0x0d, 0xaa, 0x94, 0xd5, 0x25, 0x95, 0xbe,
-	0x63, 0x94, 0xa9, 0x18, 0x05, 0x70, 0x86, 0x0b, 0xd5, 0xb8, 0xf4, 0x3d, 0x4d, 0x9e, 0xff, 0x67,
-	0xf4, 0x2a, 0xdb, 0x0f, 0x27, 0x45, 0xe8, 0x2e, 0xec, 0x97, 0xbc, 0x96, 0x09, 0xdd, 0xa2, 0x8c,
-	0xb0, 0xaa, 0xf4, 0xe1, 0xa0, 0x33, 0xf4, 0xc2, 0xbf, 0x0f, 0xd1, 0x5b, 0x38, 0x4b, 0x77, 0x49,
-	0x5e, 0x6b, 0xc9, 0xeb, 0x34, 0x27, 0xfb, 0x7e, 0x6f, 0xfa, 0x8b, 0x5d, 0xe5, 0xa2, 0x97, 0xf0,
-	0x0e, 0xc9, 0xb3, 0x94, 0x45, 0x17, 0x40, 0x54, 0x65, 0x05, 0x8d, 0x38, 0x8b, 0xce, 0x9d, 0xbb,
-	0x3e, 0x00, 0x43, 0x37, 0xbc, 0xad, 0x0b, 0x9f, 0x9f, 0xd7, 0x6d, 0x65, 0x05, 0x7d, 0xc7, 0x5e,
-	0xb5, 0x45, 0x9b, 0xb6, 0xdb, 0x9d, 0x73, 0x36, 0x6d, 0x77, 0x66, 0xce, 0xdd, 0xb4, 0x5d, 0x77,
-	0xce, 0x5b, 0xfe, 0xd6, 0x81, 0xee, 0xc4, 0x28, 0xe5, 0x10, 0xdd, 0x13, 0x72, 0xb2, 0x3b, 0x15,
-	0xa3, 0x9b, 0xd0, 0x91, 0x34, 0xe1, 0x72, 0xbb, 0x5d, 0x5c, 0x9b, 0xa1, 0x79, 0xd8, 0x25, 0x39,
-	0x95, 0x95, 0x5e, 0x99, 0x17, 0x9a, 0x04, 0x3d, 0x86, 0x9d, 0x31, 0x97, 0xbe, 0x3d, 0xfd, 0x6d,
-	0x55, 0x3d, 0x7a, 0x0d, 0x67, 0x77, 0x28, 0x15, 0xd1, 0x38, 0x93, 0x19, 0x4b, 0x23, 0xd5, 0xa2,
-	0x3f, 0x7d, 0x8b, 0xbe, 0xe2, 0x6e, 0x68, 0xea, 0x06, 0x97, 0x68, 0x0c, 0x9d, 0x9c, 0xc4, 0x34,
-	0x2f, 0xfd, 0xae, 0x5e, 0xe9, 0x8d, 0x20, 0xe1, 0xb2, 0xa2, 0x7b, 0x22, 0x0e, 0xde, 0xa8, 0xf3,
-	0xf7, 0x24, 0x93, 0x6b, 0x4f, 0x14, 0xfb, 0xc7, 0xf1, 0xd2, 0xc3, 0x69, 0x9e, 0xbc, 0xe1, 0xad,
-	0x6e, 0x13, 0x51, 0x51, 0x19, 0xb6, 0xdd, 0x91, 0x80, 0x3d, 0xc2, 0x18, 0xaf, 0x88, 0x79, 0x3f,
-	0xce, 0x7f, 0x19, 0x76, 0x79, 0x84, 0x5e, 0x5c, 0x7f, 0xed, 0xe9, 0xe1, 0x09, 0xb6, 0x8e, 0x4e,
-	0xb0, 0x75, 0x76, 0x82, 0xc1, 0xa7, 0x06, 0x83, 0xaf, 0x0d, 0x06, 0x07, 0x0d, 0x06, 0x87, 0x0d,
-	0x06, 0xbf, 0x1a, 0x0c, 0x7e, 0x37, 0xd8, 0x3a, 0x6b, 0x30, 0xf8, 0x7c, 0x8a, 0xad, 0xc3, 0x53,
-	0x6c, 0x1d, 0x9d, 0x62, 0xeb, 0xc3, 0x8c, 0xfe, 0x09, 0x44, 0x1c, 0x3b, 0xda, 0xca, 0x47, 0x7f,
-	0x02, 0x00, 0x00, 0xff, 0xff, 0x16, 0xa3, 0xc2, 0x1a, 0x6b, 0x04, 0x00, 0x00,
+	// 605 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x53, 0x3f, 0x6f, 0xd4, 0x3e,
+	0x18, 0x8e, 0x7f, 0xf7, 0xa7, 0x39, 0xdf, 0xef, 0xd4, 0x62, 0x2a, 0x94, 0x56, 0xe0, 0x1e, 0x15,
+	0x48, 0x37, 0x40, 0x0e, 0x8a, 0x18, 0x18, 0x10, 0x6a, 0x55, 0x0a, 0x14, 0x10, 0x28, 0xea, 0xc4,
+	0x12, 0x39, 0x39, 0x5f, 0xb0, 0x9a, 0xd8, 0x96, 0x93, 0x54, 0xed, 0xc6, 0x47, 0x60, 0xe4, 0x23,
+	0x30, 0xf2, 0x31, 0x3a, 0x76, 0xac, 0x18, 0x0a, 0x4d, 0x17, 0x06, 0x86, 0x7e, 0x04, 0x64, 0x3b,
+	0xd7, 0x96, 0x76, 0xb9, 0x85, 0x29, 0xef, 0xeb, 0xe7, 0x7d, 0xde, 0xf7, 0xc9, 0xf3, 0xda, 0xb0,
+	0xab, 0xca, 0x94, 0xe6, 0xbe, 0x54, 0xa2, 0x10, 0xa8, 0x65, 0x92, 0xc5, 0xfb, 0x09, 0x2b, 0x3e,
+	0x96, 0x91, 0x1f, 0x8b, 0x6c, 0x98, 0x88, 0x44, 0x0c, 0x0d, 0x1a, 0x95, 0x63, 0x93, 0x99, 0xc4,
+	0x44, 0x96, 0xb5, 0x88, 0x13, 0x21, 0x92, 0x94, 0x9e, 0x57, 0x8d, 0x4a, 0x45, 0x0a, 0x26, 0x78,
+	0x8d, 0x2f, 0x5c, 0xc6, 0x09, 0xdf, 0xab, 0xa1, 0x07, 0x17, 0x27, 0x29, 0x32, 0x26, 0x9c, 0x0c,
+	0x33, 0x96, 0x31, 0x35, 0x94, 0xdb, 0x89, 0x8d, 0x64, 0x64, 0xbf, 0x96, 0xb1, 0xfc, 0xbb, 0x01,
+	0x7b, 0x41, 0x99, 0xd2, 0x17, 0x4a, 0x94, 0x72, 0x9d, 0xe6, 0x31, 0x42, 0xb0, 0xc9, 0x49, 0x46,
+	0x3d, 0xd0, 0x07, 0x83, 0x4e, 0x60, 0x62, 0x74, 0x13, 0x76, 0xf4, 0x37, 0x97, 0x24, 0xa6, 0xde,
+	0x7f, 0x06, 0x38, 0x3f, 0x40, 0xcf, 0xa0, 0xcb, 0x78, 0x41, 0xd5, 0x0e, 0x49, 0xbd, 0x46, 0x1f,
+	0x0c, 0xba, 0x2b, 0x0b, 0xbe, 0xd5, 0xe8, 0x4f, 0x34, 0xfa, 0xeb, 0xf5, 0x3f, 0xac, 0xb9, 0xfb,
+	0x47, 0x4b, 0xce, 0x97, 0x1f, 0x4b, 0x20, 0x38, 0x23, 0xa1, 0xbb, 0xd0, 0x3a, 0xe5, 0x35, 0xfb,
+	0x8d, 0x41, 0x77, 0x65, 0xd6, 0x37, 0x99, 0xaf, 0x75, 0x69, 0x49, 0x81, 0x45, 0xb5, 0xb2, 0x32,
+	0xa7, 0xca, 0x6b, 0x5b, 0x65, 0x3a, 0x46, 0x3e, 0x9c, 0x11, 0x52, 0x37, 0xce, 0xbd, 0x8e, 0x21,
+	0xcf, 0x5f, 0x19, 0xbd, 0xca, 0xf7, 0x82, 0x49, 0x11, 0xba, 0x03, 0x7b, 0xb9, 0x28, 0x55, 0x4c,
+	0xb7, 0x28, 0x27, 0xbc, 0xc8, 0x3d, 0xd8, 0x6f, 0x0c, 0x3a, 0xc1, 0xdf, 0x87, 0xe8, 0x2d, 0x9c,
+	0xa5, 0x3b, 0x24, 0x2d, 0x8d, 0xe4, 0x75, 0x9a, 0x92, 0x3d, 0xaf, 0x3b, 0xfd, 0x8f, 0x5d, 0xe6,
+	0xa2, 0x97, 0xf0, 0x36, 0x49, 0x59, 0xc2, 0xc3, 0x73, 0x20, 0x2c, 0x58, 0x46, 0x43, 0xc1, 0xc3,
+	0x33, 0xe7, 0xfe, 0xef, 0x83, 0x81, 0x1b, 0xdc, 0x32, 0x85, 0xcf, 0xcf, 0xea, 0xb6, 0x58, 0x46,
+	0xdf, 0xf1, 0x57, 0x13, 0xa7, 0xee, 0xc1, 0x6b, 0x23, 0x9a, 0x17, 0x8c, 0x5b, 0xd0, 0xc8, 0xf5,
+	0x7a, 0xc6, 0x8f, 0xab, 0xc0, 0x66, 0xd3, 0x6d, 0xcd, 0xb5, 0x37, 0x9b, 0xee, 0xcc, 0x9c, 0xbb,
+	0xd9, 0x74, 0xdd, 0xb9, 0xce, 0xf2, 0xb7, 0x06, 0x74, 0x27, 0xb6, 0x6a, 0x3f, 0xe9, 0xae, 0x54,
+	0x93, 0x4d, 0xeb, 0x18, 0xdd, 0x80, 0x6d, 0x45, 0x63, 0xa1, 0x46, 0xf5, 0x9a, 0xeb, 0x0c, 0xcd,
+	0xc3, 0x16, 0x49, 0xa9, 0x2a, 0xcc, 0x82, 0x3b, 0x81, 0x4d, 0xd0, 0x63, 0xd8, 0x18, 0x0b, 0xe5,
+	0x35, 0xa7, 0xf7, 0x46, 0xd7, 0xa3, 0xd7, 0x70, 0x76, 0x9b, 0x52, 0x19, 0x8e, 0x99, 0x62, 0x3c,
+	0x09, 0x75, 0x8b, 0xde, 0xf4, 0x2d, 0x7a, 0x9a, 0xbb, 0x61, 0xa8, 0x1b, 0x42, 0xa1, 0x31, 0x6c,
+	0xa7, 0x24, 0xa2, 0x69, 0xee, 0xb5, 0xcc, 0x05, 0xb8, 0xee, 0xc7, 0x42, 0x15, 0x74, 0x57, 0x46,
+	0xfe, 0x1b, 0x7d, 0xfe, 0x9e, 0x30, 0xb5, 0xf6, 0x44, 0xb3, 0xbf, 0x1f, 0x2d, 0x3d, 0x9c, 0xe6,
+	0x81, 0x58, 0xde, 0xea, 0x88, 0xc8, 0x82, 0xaa, 0xa0, 0xee, 0x8e, 0x24, 0xec, 0x12, 0xce, 0x45,
+	0x41, 0xec, 0x6d, 0x6b, 0xff, 0x93, 0x61, 0x17, 0x47, 0x98, 0xc5, 0xf5, 0xd6, 0x9e, 0x1e, 0x1c,
+	0x63, 0xe7, 0xf0, 0x18, 0x3b, 0xa7, 0xc7, 0x18, 0x7c, 0xaa, 0x30, 0xf8, 0x5a, 0x61, 0xb0, 0x5f,
+	0x61, 0x70, 0x50, 0x61, 0xf0, 0xb3, 0xc2, 0xe0, 0x57, 0x85, 0x9d, 0xd3, 0x0a, 0x83, 0xcf, 0x27,
+	0xd8, 0x39, 0x38, 0xc1, 0xce, 0xe1, 0x09, 0x76, 0x3e, 0xcc, 0x98, 0x27, 0x23, 0xa3, 0xa8, 0x6d,
+	0xac, 0x7c, 0xf4, 0x67, 0x00, 0x3e, 0xff, 0x60, 0xc4, 0x99, 0x04, 0x00, 0x00,
 }
 
 func (this *RuleGroupDesc) Equal(that interface{}) bool {
@@ -331,6 +340,9 @@ func (this *RuleGroupDesc) Equal(that interface{}) bool {
 	if this.AlignEvaluationTimeOnInterval != that1.AlignEvaluationTimeOnInterval {
 		return false
 	}
+	if this.DestinationTenant != that1.DestinationTenant {
+		return false
+	}
 	return true
 }
 func (this *RuleDesc) Equal(that interface{}) bool {
@@ -389,7 +401,7 @@ func (this *RuleGroupDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 14)
 	s = append(s, "&rulespb.RuleGroupDesc{")
 	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
 	s = append(s, "Namespace: "+fmt.Sprintf("%#v", this.Namespace)+",\n")
@@ -404,6 +416,7 @@ func (this *RuleGroupDesc) GoString() string {
 	s = append(s, "SourceTenants: "+fmt.Sprintf("%#v", this.SourceTenants)+",\n")
 	s = append(s, "EvaluationDelay: "+fmt.Sprintf("%#v", this.EvaluationDelay)+",\n")
 	s = append(s, "AlignEvaluationTimeOnInterval: "+fmt.Sprintf("%#v", this.AlignEvaluationTimeOnInterval)+",\n")
+	s = append(s, "DestinationTenant: "+fmt.Sprintf("%#v", this.DestinationTenant)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -451,6 +464,13 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.DestinationTenant) > 0 {
+		i -= len(m.DestinationTenant)
+		copy(dAtA[i:], m.DestinationTenant)
+		i = encodeVarintRules(dAtA, i, uint64(len(m.DestinationTenant)))
+		i--
+		dAtA[i] = 0x6a
+	}
 	if m.AlignEvaluationTimeOnInterval {
 		i--
 		if m.AlignEvaluationTimeOnInterval {
@@ -680,6 +700,10 @@ func (m *RuleGroupDesc) Size() (n int) {
 	if m.AlignEvaluationTimeOnInterval {
 		n += 2
 	}
+	l = len(m.DestinationTenant)
+	if l > 0 {
+		n += 1 + l + sovRules(uint64(l))
+	}
 	return n
 }
 
@@ -750,6 +774,7 @@ func (this *RuleGroupDesc) String() string {
 		`SourceTenants:` + fmt.Sprintf("%v", this.SourceTenants) + `,`,
 		`EvaluationDelay:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.EvaluationDelay), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`AlignEvaluationTimeOnInterval:` + fmt.Sprintf("%v", this.AlignEvaluationTimeOnInterval) + `,`,
+		`DestinationTenant:` + fmt.Sprintf("%v", this.DestinationTenant) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1089,6 +1114,38 @@ func (m *RuleGroupDesc) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.AlignEvaluationTimeOnInterval = bool(v != 0)
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DestinationTenant", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRules
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRules
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DestinationTenant = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRules(dAtA[iNdEx:])
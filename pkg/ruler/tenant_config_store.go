@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/ruler/rulestore"
+)
+
+// TenantRuleGroupsStore deletes the rule groups of the tenants when they're offboarded.
+type TenantRuleGroupsStore struct {
+	store rulestore.RuleStore
+}
+
+func NewTenantRuleGroupsStore(store rulestore.RuleStore) *TenantRuleGroupsStore {
+	return &TenantRuleGroupsStore{store: store}
+}
+
+// Name implements compactor.TenantConfigStore.
+func (s *TenantRuleGroupsStore) Name() string {
+	return "rule_groups"
+}
+
+// DeleteTenantConfig implements compactor.TenantConfigStore.
+func (s *TenantRuleGroupsStore) DeleteTenantConfig(ctx context.Context, userID string) error {
+	err := s.store.DeleteNamespace(ctx, userID, "") // Empty namespace = delete all rule groups.
+	if errors.Is(err, rulestore.ErrGroupNamespaceNotFound) {
+		return nil
+	}
+	return err
+}
+
+// TenantConfigDeleted implements compactor.TenantConfigStore.
+func (s *TenantRuleGroupsStore) TenantConfigDeleted(ctx context.Context, userID string) (bool, error) {
+	groups, err := s.store.ListRuleGroupsForUserAndNamespace(ctx, userID, "")
+	if err != nil {
+		return false, err
+	}
+	return len(groups) == 0, nil
+}
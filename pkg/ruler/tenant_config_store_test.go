@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/ruler/rulespb"
+	"github.com/grafana/mimir/pkg/ruler/rulestore/bucketclient"
+)
+
+func TestTenantRuleGroupsStore(t *testing.T) {
+	ctx := context.Background()
+	rs := bucketclient.NewBucketRuleStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	for _, userID := range []string{"user-1", "user-2"} {
+		for _, namespace := range []string{"namespace-1", "namespace-2"} {
+			require.NoError(t, rs.SetRuleGroup(ctx, userID, namespace, &rulespb.RuleGroupDesc{User: userID, Namespace: namespace, Name: "group"}))
+		}
+	}
+
+	store := NewTenantRuleGroupsStore(rs)
+
+	deleted, err := store.TenantConfigDeleted(ctx, "user-1")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	// All the namespaces of the tenant are deleted, and deleting them again is a no-op.
+	require.NoError(t, store.DeleteTenantConfig(ctx, "user-1"))
+	require.NoError(t, store.DeleteTenantConfig(ctx, "user-1"))
+
+	deleted, err = store.TenantConfigDeleted(ctx, "user-1")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = store.TenantConfigDeleted(ctx, "user-2")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
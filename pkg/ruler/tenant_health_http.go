@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/util"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// tenantHealth summarizes whether the rules of a tenant are synced, evaluated and their alerts notified.
+// The rule groups are counted across all the rulers of the tenant, while the sync and the notifications
+// status are the ones of the ruler serving the request.
+type tenantHealth struct {
+	Tenant             string          `json:"tenant"`
+	LastSuccessfulSync *time.Time      `json:"last_successful_sync"`
+	RuleGroups         int             `json:"rule_groups"`
+	FailingRuleGroups  int             `json:"failing_rule_groups"`
+	Notifier           *NotifierStatus `json:"notifier"`
+}
+
+// TenantHealthHandler returns the health of the sync, evaluation and notifications of the rules of a tenant.
+func (r *Ruler) TenantHealthHandler(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), r.logger)
+
+	tenantID := mux.Vars(req)["tenant"]
+	if tenantID == "" {
+		util.WriteTextResponse(w, "Tenant ID can't be empty")
+		return
+	}
+
+	groups, err := r.GetRules(user.InjectOrgID(req.Context(), tenantID))
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to get the rule groups of the tenant", "user", tenantID, "err", err)
+		http.Error(w, fmt.Sprintf("failed to get the rule groups of the tenant: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	status := r.manager.GetTenantStatus(tenantID)
+	health := tenantHealth{
+		Tenant:             tenantID,
+		LastSuccessfulSync: status.LastSuccessfulSync,
+		RuleGroups:         len(groups),
+		Notifier:           status.Notifier,
+	}
+	for _, g := range groups {
+		if isFailingGroup(g) {
+			health.FailingRuleGroups++
+		}
+	}
+
+	util.WriteJSONResponse(w, health)
+}
+
+// isFailingGroup returns whether the last evaluation of any rule of the group failed.
+func isFailingGroup(g *GroupStateDesc) bool {
+	for _, rule := range g.ActiveRules {
+		if rule.Health == string(promRules.HealthBad) {
+			return true
+		}
+	}
+	return false
+}
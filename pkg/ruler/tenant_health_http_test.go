@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package ruler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/test"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestRuler_TenantHealthHandler(t *testing.T) {
+	am := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer am.Close()
+
+	cfg := defaultRulerConfig(t)
+	cfg.AlertmanagerURL = am.URL
+
+	rulerAddrMap := map[string]*Ruler{}
+	r := prepareRuler(t, cfg, newMockRuleStore(mockRules), withRulerAddrMap(rulerAddrMap), withStart())
+	rulerAddrMap[cfg.Ring.Common.InstanceID] = r
+
+	// Rules are synchronized asynchronously, so we wait until they have been synced.
+	test.Poll(t, 5*time.Second, len(mockRules["user1"]), func() interface{} {
+		rls, _ := r.Rules(user.InjectOrgID(context.Background(), "user1"), &RulesRequest{})
+		return len(rls.Groups)
+	})
+
+	router := mux.NewRouter()
+	router.Path("/ruler/tenant/{tenant}/health").Methods(http.MethodGet).HandlerFunc(r.TenantHealthHandler)
+
+	getHealth := func(tenantID string) tenantHealth {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ruler/tenant/"+tenantID+"/health", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var health tenantHealth
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+		return health
+	}
+
+	t.Run("tenant with rules", func(t *testing.T) {
+		// Alertmanagers are discovered asynchronously too.
+		test.Poll(t, 5*time.Second, 1, func() interface{} {
+			health := getHealth("user1")
+			if health.Notifier == nil {
+				return 0
+			}
+			return len(health.Notifier.DiscoveredAlertmanagers)
+		})
+
+		health := getHealth("user1")
+		assert.Equal(t, "user1", health.Tenant)
+		assert.NotNil(t, health.LastSuccessfulSync)
+		assert.Equal(t, len(mockRules["user1"]), health.RuleGroups)
+		assert.Equal(t, 0, health.FailingRuleGroups)
+		require.NotNil(t, health.Notifier)
+		assert.Equal(t, 0, health.Notifier.QueueLength)
+		assert.Equal(t, cfg.NotificationQueueCapacity, health.Notifier.QueueCapacity)
+		assert.Equal(t, []string{am.URL + "/api/v2/alerts"}, health.Notifier.DiscoveredAlertmanagers)
+		assert.Empty(t, health.Notifier.DroppedAlertmanagers)
+	})
+
+	t.Run("tenant without rules", func(t *testing.T) {
+		health := getHealth("user-unknown")
+		assert.Equal(t, tenantHealth{Tenant: "user-unknown"}, health)
+	})
+}
+
+func TestIsFailingGroup(t *testing.T) {
+	tests := map[string]struct {
+		rules    []*RuleStateDesc
+		expected bool
+	}{
+		"no rules": {},
+		"all rules healthy or not evaluated yet": {
+			rules: []*RuleStateDesc{{Health: string(promRules.HealthGood)}, {Health: string(promRules.HealthUnknown)}},
+		},
+		"any rule failing": {
+			rules:    []*RuleStateDesc{{Health: string(promRules.HealthGood)}, {Health: string(promRules.HealthBad), LastError: "some error"}},
+			expected: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, isFailingGroup(&GroupStateDesc{ActiveRules: testData.rules}))
+		})
+	}
+}
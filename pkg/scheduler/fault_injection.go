@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"flag"
+	"math/rand"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	faultEnqueueDrop        = "enqueue_drop"
+	faultDispatchDelay      = "dispatch_delay"
+	faultQuerierStreamClose = "querier_stream_close"
+)
+
+var (
+	errInvalidFaultInjectionPercentage = errors.New("the fault injection percentages must be between 0 and 100")
+
+	errInjectedQuerierStreamClose = errors.New("querier stream closed by fault injection")
+)
+
+// FaultInjectionConfig configures the faults injected by the query-scheduler, to test the resilience of
+// the query-frontends and queriers. Faults are injected only if UnsafeEnabled is true.
+type FaultInjectionConfig struct {
+	UnsafeEnabled                bool          `yaml:"unsafe_enabled" category:"experimental"`
+	EnqueueDropPercentage        float64       `yaml:"enqueue_drop_percentage" category:"experimental"`
+	DispatchDelay                time.Duration `yaml:"dispatch_delay" category:"experimental"`
+	QuerierStreamClosePercentage float64       `yaml:"querier_stream_close_percentage" category:"experimental"`
+}
+
+func (cfg *FaultInjectionConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.UnsafeEnabled, "query-scheduler.fault-injection.unsafe-enabled", false, "Inject the configured faults in the query path, to test the resilience of query-frontends and queriers. Faults cause queries to fail or to be slower: this must never be enabled in production.")
+	f.Float64Var(&cfg.EnqueueDropPercentage, "query-scheduler.fault-injection.enqueue-drop-percentage", 0, "Percentage of the enqueued queries which are acknowledged to the query-frontend but never dispatched to a querier, as if they were lost. Applies only when -query-scheduler.fault-injection.unsafe-enabled is true.")
+	f.DurationVar(&cfg.DispatchDelay, "query-scheduler.fault-injection.dispatch-delay", 0, "Delay added before dispatching each query to a querier. Applies only when -query-scheduler.fault-injection.unsafe-enabled is true.")
+	f.Float64Var(&cfg.QuerierStreamClosePercentage, "query-scheduler.fault-injection.querier-stream-close-percentage", 0, "Percentage of the queries dispatched to a querier after which the query-scheduler closes the stream to the querier, without waiting for the query to complete. Applies only when -query-scheduler.fault-injection.unsafe-enabled is true.")
+}
+
+func (cfg *FaultInjectionConfig) Validate() error {
+	for _, p := range []float64{cfg.EnqueueDropPercentage, cfg.QuerierStreamClosePercentage} {
+		if p < 0 || p > 100 {
+			return errInvalidFaultInjectionPercentage
+		}
+	}
+	return nil
+}
+
+// faultInjector decides which faults to inject, according to the configuration.
+type faultInjector struct {
+	cfg FaultInjectionConfig
+
+	// Returns a random number in [0, 100). Replaceable in tests.
+	random func() float64
+
+	injectedFaults *prometheus.CounterVec
+}
+
+func newFaultInjector(cfg FaultInjectionConfig, logger log.Logger, reg prometheus.Registerer) *faultInjector {
+	if cfg.UnsafeEnabled {
+		level.Warn(logger).Log("msg", "query-scheduler fault injection is enabled, queries may fail or be slower", "enqueue_drop_percentage", cfg.EnqueueDropPercentage, "dispatch_delay", cfg.DispatchDelay, "querier_stream_close_percentage", cfg.QuerierStreamClosePercentage)
+	}
+
+	return &faultInjector{
+		cfg:    cfg,
+		random: func() float64 { return rand.Float64() * 100 },
+		injectedFaults: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_scheduler_injected_faults_total",
+			Help: "Total number of faults injected by the query-scheduler fault injection.",
+		}, []string{"fault"}),
+	}
+}
+
+// dropEnqueue returns whether the query being enqueued should be dropped.
+func (f *faultInjector) dropEnqueue() bool {
+	return f.inject(faultEnqueueDrop, f.cfg.EnqueueDropPercentage)
+}
+
+// closeQuerierStream returns whether the stream to the querier should be closed after dispatching a query.
+func (f *faultInjector) closeQuerierStream() bool {
+	return f.inject(faultQuerierStreamClose, f.cfg.QuerierStreamClosePercentage)
+}
+
+// delayDispatch waits for the configured dispatch delay, or until the context is done.
+func (f *faultInjector) delayDispatch(ctx context.Context) {
+	if !f.cfg.UnsafeEnabled || f.cfg.DispatchDelay <= 0 {
+		return
+	}
+
+	f.injectedFaults.WithLabelValues(faultDispatchDelay).Inc()
+
+	select {
+	case <-time.After(f.cfg.DispatchDelay):
+	case <-ctx.Done():
+	}
+}
+
+func (f *faultInjector) inject(fault string, percentage float64) bool {
+	if !f.cfg.UnsafeEnabled || percentage <= 0 || f.random() >= percentage {
+		return false
+	}
+
+	f.injectedFaults.WithLabelValues(fault).Inc()
+	return true
+}
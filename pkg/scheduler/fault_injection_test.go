@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+)
+
+func TestFaultInjectionConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg      FaultInjectionConfig
+		expected error
+	}{
+		"default config": {
+			cfg: FaultInjectionConfig{},
+		},
+		"valid percentages": {
+			cfg: FaultInjectionConfig{EnqueueDropPercentage: 100, QuerierStreamClosePercentage: 0.5},
+		},
+		"negative enqueue drop percentage": {
+			cfg:      FaultInjectionConfig{EnqueueDropPercentage: -1},
+			expected: errInvalidFaultInjectionPercentage,
+		},
+		"querier stream close percentage above 100": {
+			cfg:      FaultInjectionConfig{QuerierStreamClosePercentage: 101},
+			expected: errInvalidFaultInjectionPercentage,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testData.cfg.Validate())
+		})
+	}
+}
+
+func TestFaultInjector(t *testing.T) {
+	tests := map[string]struct {
+		cfg                        FaultInjectionConfig
+		random                     float64
+		expectedDropEnqueue        bool
+		expectedCloseQuerierStream bool
+	}{
+		"faults are not injected if not explicitly enabled": {
+			cfg:    FaultInjectionConfig{EnqueueDropPercentage: 100, QuerierStreamClosePercentage: 100},
+			random: 0,
+		},
+		"faults are injected if enabled": {
+			cfg:                        FaultInjectionConfig{UnsafeEnabled: true, EnqueueDropPercentage: 100, QuerierStreamClosePercentage: 100},
+			random:                     99.9,
+			expectedDropEnqueue:        true,
+			expectedCloseQuerierStream: true,
+		},
+		"faults are injected according to the configured percentages": {
+			cfg:                        FaultInjectionConfig{UnsafeEnabled: true, EnqueueDropPercentage: 10, QuerierStreamClosePercentage: 50},
+			random:                     20,
+			expectedDropEnqueue:        false,
+			expectedCloseQuerierStream: true,
+		},
+		"no fault is injected if the percentages are 0": {
+			cfg:    FaultInjectionConfig{UnsafeEnabled: true},
+			random: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			f := newFaultInjector(testData.cfg, log.NewNopLogger(), nil)
+			f.random = func() float64 { return testData.random }
+
+			assert.Equal(t, testData.expectedDropEnqueue, f.dropEnqueue())
+			assert.Equal(t, testData.expectedCloseQuerierStream, f.closeQuerierStream())
+		})
+	}
+}
+
+func TestFaultInjector_delayDispatch(t *testing.T) {
+	f := newFaultInjector(FaultInjectionConfig{UnsafeEnabled: true, DispatchDelay: time.Hour}, log.NewNopLogger(), nil)
+
+	// The delay is interrupted once the context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now()
+	f.delayDispatch(ctx)
+	assert.Less(t, time.Since(startTime), time.Hour)
+}
+
+func TestSchedulerFaultInjection(t *testing.T) {
+	enqueue := func(t *testing.T, frontendLoop schedulerpb.SchedulerForFrontend_FrontendLoopClient, queryID uint64) {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     queryID,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		})
+	}
+
+	t.Run("enqueued requests are dropped", func(t *testing.T) {
+		cfg := Config{}
+		flagext.DefaultValues(&cfg)
+		cfg.FaultInjection = FaultInjectionConfig{UnsafeEnabled: true, EnqueueDropPercentage: 100}
+
+		reg := prometheus.NewPedanticRegistry()
+		scheduler, frontendClient, querierClient := setupSchedulerWithConfig(t, reg, cfg, &limits{})
+
+		frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+		enqueue(t, frontendLoop, 1)
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		verifyQuerierDoesntReceiveRequest(t, querierLoop, 500*time.Millisecond)
+		verifyNoPendingRequestsLeft(t, scheduler)
+
+		require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_query_scheduler_injected_faults_total Total number of faults injected by the query-scheduler fault injection.
+			# TYPE cortex_query_scheduler_injected_faults_total counter
+			cortex_query_scheduler_injected_faults_total{fault="enqueue_drop"} 1
+		`), "cortex_query_scheduler_injected_faults_total"))
+	})
+
+	t.Run("querier stream is closed after dispatching a request", func(t *testing.T) {
+		cfg := Config{}
+		flagext.DefaultValues(&cfg)
+		cfg.FaultInjection = FaultInjectionConfig{UnsafeEnabled: true, QuerierStreamClosePercentage: 100}
+
+		scheduler, frontendClient, querierClient := setupSchedulerWithConfig(t, nil, cfg, &limits{})
+
+		frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+		enqueue(t, frontendLoop, 1)
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		msg, err := querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), msg.QueryID)
+
+		_, err = querierLoop.Recv()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errInjectedQuerierStreamClose.Error())
+
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Maximum time a querier connection waits for the querier to complete a high-cost query, after having
+// put a high-cost query back into the queue, before picking up the next query.
+const highCostQueryMaxDeferDelay = 100 * time.Millisecond
+
+// highCostQueryTracker keeps track of the high-cost queries running on each querier, so that the
+// query-scheduler can avoid dispatching multiple high-cost queries to the same querier at once.
+type highCostQueryTracker struct {
+	seriesThreshold uint64
+	maxPerQuerier   int
+
+	mtx      sync.Mutex
+	inflight map[string]int
+
+	// Closed, and replaced, each time a high-cost query completes.
+	released chan struct{}
+}
+
+func newHighCostQueryTracker(seriesThreshold uint64, maxPerQuerier int) *highCostQueryTracker {
+	return &highCostQueryTracker{
+		seriesThreshold: seriesThreshold,
+		maxPerQuerier:   maxPerQuerier,
+		inflight:        map[string]int{},
+		released:        make(chan struct{}),
+	}
+}
+
+// isHighCost returns whether the query is estimated to touch enough series to be a high-cost one.
+func (t *highCostQueryTracker) isHighCost(estimatedSeriesCount uint64) bool {
+	return t.seriesThreshold > 0 && estimatedSeriesCount >= t.seriesThreshold
+}
+
+// tryAcquire tracks a high-cost query running on the querier, unless the querier is already running
+// the max number of high-cost queries. Returns whether the query has been tracked.
+func (t *highCostQueryTracker) tryAcquire(querierID string) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.inflight[querierID] >= t.maxPerQuerier {
+		return false
+	}
+	t.inflight[querierID]++
+	return true
+}
+
+// acquire tracks a high-cost query running on the querier, regardless of the max number of high-cost queries.
+func (t *highCostQueryTracker) acquire(querierID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.inflight[querierID]++
+}
+
+// release stops tracking a high-cost query running on the querier, and wakes up the connections waiting for it.
+func (t *highCostQueryTracker) release(querierID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.inflight[querierID]--
+	if t.inflight[querierID] <= 0 {
+		delete(t.inflight, querierID)
+	}
+
+	close(t.released)
+	t.released = make(chan struct{})
+}
+
+// waitForRelease blocks until the querier can run another high-cost query, maxDelay has elapsed
+// or the context is done.
+func (t *highCostQueryTracker) waitForRelease(ctx context.Context, querierID string, maxDelay time.Duration) {
+	timer := time.NewTimer(maxDelay)
+	defer timer.Stop()
+
+	for {
+		t.mtx.Lock()
+		ok, released := t.inflight[querierID] < t.maxPerQuerier, t.released
+		t.mtx.Unlock()
+
+		if ok {
+			return
+		}
+
+		select {
+		case <-released:
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
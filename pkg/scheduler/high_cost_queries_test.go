@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighCostQueryTracker_isHighCost(t *testing.T) {
+	assert.False(t, newHighCostQueryTracker(0, 1).isHighCost(1000))
+	assert.False(t, newHighCostQueryTracker(1000, 1).isHighCost(999))
+	assert.True(t, newHighCostQueryTracker(1000, 1).isHighCost(1000))
+}
+
+func TestHighCostQueryTracker_acquireAndRelease(t *testing.T) {
+	tracker := newHighCostQueryTracker(1000, 2)
+
+	assert.True(t, tracker.tryAcquire("querier-1"))
+	assert.True(t, tracker.tryAcquire("querier-1"))
+	assert.False(t, tracker.tryAcquire("querier-1"))
+
+	// The limit applies to each querier.
+	assert.True(t, tracker.tryAcquire("querier-2"))
+
+	// Forcing the acquisition exceeds the limit.
+	tracker.acquire("querier-1")
+	assert.Equal(t, 3, tracker.inflight["querier-1"])
+
+	tracker.release("querier-1")
+	tracker.release("querier-1")
+	assert.True(t, tracker.tryAcquire("querier-1"))
+
+	tracker.release("querier-1")
+	tracker.release("querier-1")
+	tracker.release("querier-2")
+	assert.Empty(t, tracker.inflight)
+}
+
+func TestHighCostQueryTracker_waitForRelease(t *testing.T) {
+	t.Run("returns once a high-cost query completes", func(t *testing.T) {
+		tracker := newHighCostQueryTracker(1000, 1)
+		tracker.acquire("querier-1")
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			tracker.release("querier-1")
+		}()
+
+		start := time.Now()
+		tracker.waitForRelease(context.Background(), "querier-1", time.Minute)
+		assert.Less(t, time.Since(start), time.Minute)
+	})
+
+	t.Run("returns after the max delay", func(t *testing.T) {
+		tracker := newHighCostQueryTracker(1000, 1)
+		tracker.acquire("querier-1")
+
+		start := time.Now()
+		tracker.waitForRelease(context.Background(), "querier-1", 100*time.Millisecond)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("returns immediately if the querier can run another high-cost query", func(t *testing.T) {
+		tracker := newHighCostQueryTracker(1000, 1)
+
+		start := time.Now()
+		tracker.waitForRelease(context.Background(), "querier-1", time.Minute)
+		assert.Less(t, time.Since(start), time.Minute)
+	})
+}
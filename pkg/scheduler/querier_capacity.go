@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+)
+
+// querierCapacityTracker keeps track of the capacity last reported by each connected querier,
+// and allows querier connections to wait until their querier has capacity to run another query.
+type querierCapacityTracker struct {
+	maxInflightQueries     uint32
+	minMemoryHeadroomBytes uint64
+
+	mtx      sync.Mutex
+	queriers map[string]*querierCapacity
+}
+
+type querierCapacity struct {
+	connections int
+
+	// Nil until the querier reports its capacity. Queriers not reporting it are assumed to have capacity.
+	reported *schedulerpb.QuerierCapacity
+
+	// Closed, and replaced, each time the querier reports its capacity.
+	updated chan struct{}
+}
+
+func newQuerierCapacityTracker(maxInflightQueries int, minMemoryHeadroomBytes uint64) *querierCapacityTracker {
+	return &querierCapacityTracker{
+		maxInflightQueries:     uint32(maxInflightQueries),
+		minMemoryHeadroomBytes: minMemoryHeadroomBytes,
+		queriers:               map[string]*querierCapacity{},
+	}
+}
+
+func (t *querierCapacityTracker) registerConnection(querierID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	q := t.queriers[querierID]
+	if q == nil {
+		q = &querierCapacity{updated: make(chan struct{})}
+		t.queriers[querierID] = q
+	}
+	q.connections++
+}
+
+func (t *querierCapacityTracker) unregisterConnection(querierID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	q := t.queriers[querierID]
+	if q == nil {
+		return
+	}
+
+	q.connections--
+	if q.connections <= 0 {
+		delete(t.queriers, querierID)
+	}
+}
+
+// update stores the capacity reported by the querier and wakes up its connections waiting for capacity.
+func (t *querierCapacityTracker) update(querierID string, capacity *schedulerpb.QuerierCapacity) {
+	if capacity == nil {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	q := t.queriers[querierID]
+	if q == nil {
+		return
+	}
+
+	q.reported = capacity
+	close(q.updated)
+	q.updated = make(chan struct{})
+}
+
+// hasCapacity returns whether the querier, based on the last capacity it reported, can run another query.
+func (t *querierCapacityTracker) hasCapacity(querierID string) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	ok, _ := t.hasCapacityLocked(querierID)
+	return ok
+}
+
+func (t *querierCapacityTracker) hasCapacityLocked(querierID string) (bool, <-chan struct{}) {
+	q := t.queriers[querierID]
+	if q == nil || q.reported == nil {
+		return true, nil
+	}
+
+	if t.maxInflightQueries > 0 && q.reported.InflightQueries >= t.maxInflightQueries {
+		return false, q.updated
+	}
+	if t.minMemoryHeadroomBytes > 0 && q.reported.MemoryHeadroomBytes < t.minMemoryHeadroomBytes {
+		return false, q.updated
+	}
+	return true, q.updated
+}
+
+// waitForCapacity blocks until the querier reports it has capacity to run another query, maxDelay has
+// elapsed or the context is done.
+func (t *querierCapacityTracker) waitForCapacity(ctx context.Context, querierID string, maxDelay time.Duration) {
+	// Stop waiting after maxDelay anyway, because the querier reports its capacity only when it
+	// completes a query, so it may not have the chance to report again it's below the thresholds.
+	timer := time.NewTimer(maxDelay)
+	defer timer.Stop()
+
+	for {
+		t.mtx.Lock()
+		ok, updated := t.hasCapacityLocked(querierID)
+		t.mtx.Unlock()
+
+		if ok {
+			return
+		}
+
+		select {
+		case <-updated:
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
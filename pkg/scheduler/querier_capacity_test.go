@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+)
+
+func TestQuerierCapacityTracker_hasCapacity(t *testing.T) {
+	tests := map[string]struct {
+		maxInflightQueries     int
+		minMemoryHeadroomBytes uint64
+		reported               *schedulerpb.QuerierCapacity
+		expected               bool
+	}{
+		"backpressure disabled": {
+			reported: &schedulerpb.QuerierCapacity{InflightQueries: 100, MemoryHeadroomBytes: 0},
+			expected: true,
+		},
+		"capacity not reported": {
+			maxInflightQueries:     1,
+			minMemoryHeadroomBytes: 1024,
+			expected:               true,
+		},
+		"in-flight queries below the limit": {
+			maxInflightQueries: 2,
+			reported:           &schedulerpb.QuerierCapacity{InflightQueries: 1},
+			expected:           true,
+		},
+		"in-flight queries equal to the limit": {
+			maxInflightQueries: 2,
+			reported:           &schedulerpb.QuerierCapacity{InflightQueries: 2},
+			expected:           false,
+		},
+		"memory headroom above the min": {
+			minMemoryHeadroomBytes: 1024,
+			reported:               &schedulerpb.QuerierCapacity{MemoryHeadroomBytes: 1024},
+			expected:               true,
+		},
+		"memory headroom below the min": {
+			minMemoryHeadroomBytes: 1024,
+			reported:               &schedulerpb.QuerierCapacity{MemoryHeadroomBytes: 1023},
+			expected:               false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			tracker := newQuerierCapacityTracker(testData.maxInflightQueries, testData.minMemoryHeadroomBytes)
+			tracker.registerConnection("querier-1")
+			tracker.update("querier-1", testData.reported)
+
+			assert.Equal(t, testData.expected, tracker.hasCapacity("querier-1"))
+		})
+	}
+}
+
+func TestQuerierCapacityTracker_unregisterConnection(t *testing.T) {
+	tracker := newQuerierCapacityTracker(1, 0)
+	tracker.registerConnection("querier-1")
+	tracker.registerConnection("querier-1")
+	tracker.update("querier-1", &schedulerpb.QuerierCapacity{InflightQueries: 1})
+
+	// The reported capacity is kept until the last connection of the querier is closed.
+	tracker.unregisterConnection("querier-1")
+	assert.False(t, tracker.hasCapacity("querier-1"))
+
+	tracker.unregisterConnection("querier-1")
+	assert.True(t, tracker.hasCapacity("querier-1"))
+	assert.Empty(t, tracker.queriers)
+}
+
+func TestQuerierCapacityTracker_waitForCapacity(t *testing.T) {
+	t.Run("should return as soon as the querier reports it has capacity", func(t *testing.T) {
+		tracker := newQuerierCapacityTracker(1, 0)
+		tracker.registerConnection("querier-1")
+		tracker.update("querier-1", &schedulerpb.QuerierCapacity{InflightQueries: 1})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			tracker.waitForCapacity(context.Background(), "querier-1", time.Minute)
+		}()
+
+		// A capacity update still above the limit doesn't unblock the wait.
+		tracker.update("querier-1", &schedulerpb.QuerierCapacity{InflightQueries: 2})
+		select {
+		case <-done:
+			require.Fail(t, "expected to wait for capacity")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		tracker.update("querier-1", &schedulerpb.QuerierCapacity{InflightQueries: 0})
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			require.Fail(t, "expected to stop waiting once the querier reported capacity")
+		}
+	})
+
+	t.Run("should return once the max delay has elapsed", func(t *testing.T) {
+		tracker := newQuerierCapacityTracker(1, 0)
+		tracker.registerConnection("querier-1")
+		tracker.update("querier-1", &schedulerpb.QuerierCapacity{InflightQueries: 1})
+
+		startTime := time.Now()
+		tracker.waitForCapacity(context.Background(), "querier-1", 200*time.Millisecond)
+		assert.GreaterOrEqual(t, time.Since(startTime), 200*time.Millisecond)
+	})
+
+	t.Run("should return once the context is canceled", func(t *testing.T) {
+		tracker := newQuerierCapacityTracker(1, 0)
+		tracker.registerConnection("querier-1")
+		tracker.update("querier-1", &schedulerpb.QuerierCapacity{InflightQueries: 1})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		startTime := time.Now()
+		tracker.waitForCapacity(ctx, "querier-1", time.Minute)
+		assert.Less(t, time.Since(startTime), time.Minute)
+	})
+}
@@ -80,11 +80,11 @@ func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, que
 }
 
 // EnqueueRequest puts the request into the queue. MaxQueries is user-specific value that specifies how many queriers can
-// this user use (zero or negative = all queriers). It is passed to each EnqueueRequest, because it can change
-// between calls.
+// this user use (zero or negative = all queriers). QuerierPool is the user-specific querier pool the user is assigned to
+// (empty = no pool). They're passed to each EnqueueRequest, because they can change between calls.
 //
 // If request is successfully enqueued, successFn is called with the lock held, before any querier can receive the request.
-func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers int, successFn func()) error {
+func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers int, querierPool string, successFn func()) error {
 	q.mtx.Lock()
 	defer q.mtx.Unlock()
 
@@ -92,7 +92,7 @@ func (q *RequestQueue) EnqueueRequest(userID string, req Request, maxQueriers in
 		return ErrStopped
 	}
 
-	queue := q.queues.getOrAddQueue(userID, maxQueriers)
+	queue := q.queues.getOrAddQueue(userID, maxQueriers, querierPool)
 	if queue == nil {
 		// This can only happen if userID is "".
 		return errors.New("no queue found")
@@ -166,6 +166,108 @@ FindQueue:
 	goto FindQueue
 }
 
+// ReturnRequest puts back a request previously returned by GetNextRequestForQuerier at the front of the
+// user queue, so that it's the next request of the user to be dequeued. It fails if the queue is full.
+func (q *RequestQueue) ReturnRequest(userID string, req Request, maxQueriers int, querierPool string) error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if q.stopped {
+		return ErrStopped
+	}
+
+	queue := q.queues.getOrAddQueue(userID, maxQueriers, querierPool)
+	if queue == nil {
+		// This can only happen if userID is "".
+		return errors.New("no queue found")
+	}
+	if len(queue) == cap(queue) {
+		return ErrTooManyRequests
+	}
+
+	// Requests are picked from the queue and put back after the returned one. This is safe because
+	// requests are enqueued and dequeued only while holding the lock.
+	n := len(queue)
+	queue <- req
+	for i := 0; i < n; i++ {
+		queue <- <-queue
+	}
+
+	q.queueLength.WithLabelValues(userID).Inc()
+	q.cond.Broadcast()
+	return nil
+}
+
+// UserQueueLengths returns the number of requests in the queue of each user with a queue.
+func (q *RequestQueue) UserQueueLengths() map[string]int {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	lengths := make(map[string]int, q.queues.len())
+	for userID, uq := range q.queues.userQueues {
+		lengths[userID] = len(uq.ch)
+	}
+	return lengths
+}
+
+// GetUserRequests returns the requests in the user queue, in the order they will be dequeued,
+// without removing them from the queue.
+func (q *RequestQueue) GetUserRequests(userID string) []Request {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	uq := q.queues.userQueues[userID]
+	if uq == nil {
+		return nil
+	}
+
+	// Requests are picked from the queue and put back in the same order. This is safe because
+	// requests are enqueued and dequeued only while holding the lock.
+	requests := make([]Request, 0, len(uq.ch))
+	for i, n := 0, len(uq.ch); i < n; i++ {
+		req := <-uq.ch
+		requests = append(requests, req)
+		uq.ch <- req
+	}
+	return requests
+}
+
+// RemoveUserRequests removes from the user queue the requests for which shouldRemove returns true,
+// and returns them. The order of the requests left in the queue is preserved.
+func (q *RequestQueue) RemoveUserRequests(userID string, shouldRemove func(Request) bool) []Request {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	uq := q.queues.userQueues[userID]
+	if uq == nil {
+		return nil
+	}
+
+	var removed []Request
+	for i, n := 0, len(uq.ch); i < n; i++ {
+		req := <-uq.ch
+		if shouldRemove(req) {
+			removed = append(removed, req)
+			continue
+		}
+		uq.ch <- req
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	q.queueLength.WithLabelValues(userID).Sub(float64(len(removed)))
+	if len(uq.ch) == 0 {
+		q.queues.deleteQueue(userID)
+	}
+
+	// Tell stopping() we've removed requests.
+	q.cond.Broadcast()
+
+	return removed
+}
+
 func (q *RequestQueue) forgetDisconnectedQueriers(_ context.Context) error {
 	q.mtx.Lock()
 	defer q.mtx.Unlock()
@@ -196,12 +298,14 @@ func (q *RequestQueue) stopping(_ error) error {
 	return nil
 }
 
-func (q *RequestQueue) RegisterQuerierConnection(querier string) {
+// RegisterQuerierConnection registers a connection from the querier. QuerierPool is the pool the querier
+// belongs to, or empty if the querier belongs to no pool and can handle the requests of any user not assigned to a pool.
+func (q *RequestQueue) RegisterQuerierConnection(querier, querierPool string) {
 	q.connectedQuerierWorkers.Inc()
 
 	q.mtx.Lock()
 	defer q.mtx.Unlock()
-	q.queues.addQuerierConnection(querier)
+	q.queues.addQuerierConnection(querier, querierPool)
 }
 
 func (q *RequestQueue) UnregisterQuerierConnection(querier string) {
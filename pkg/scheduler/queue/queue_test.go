@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/dskit/services"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,14 +36,14 @@ func BenchmarkGetNextRequest(b *testing.B) {
 		queues = append(queues, queue)
 
 		for ix := 0; ix < queriers; ix++ {
-			queue.RegisterQuerierConnection(fmt.Sprintf("querier-%d", ix))
+			queue.RegisterQuerierConnection(fmt.Sprintf("querier-%d", ix), "")
 		}
 
 		for i := 0; i < maxOutstandingPerTenant; i++ {
 			for j := 0; j < numTenants; j++ {
 				userID := strconv.Itoa(j)
 
-				err := queue.EnqueueRequest(userID, "request", 0, nil)
+				err := queue.EnqueueRequest(userID, "request", 0, "", nil)
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -91,7 +92,7 @@ func BenchmarkQueueRequest(b *testing.B) {
 		)
 
 		for ix := 0; ix < queriers; ix++ {
-			q.RegisterQuerierConnection(fmt.Sprintf("querier-%d", ix))
+			q.RegisterQuerierConnection(fmt.Sprintf("querier-%d", ix), "")
 		}
 
 		queues = append(queues, q)
@@ -106,7 +107,7 @@ func BenchmarkQueueRequest(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		for i := 0; i < maxOutstandingPerTenant; i++ {
 			for j := 0; j < numTenants; j++ {
-				err := queues[n].EnqueueRequest(users[j], requests[j], 0, nil)
+				err := queues[n].EnqueueRequest(users[j], requests[j], 0, "", nil)
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -130,8 +131,8 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 	})
 
 	// Two queriers connect.
-	queue.RegisterQuerierConnection("querier-1")
-	queue.RegisterQuerierConnection("querier-2")
+	queue.RegisterQuerierConnection("querier-1", "")
+	queue.RegisterQuerierConnection("querier-2", "")
 
 	// Querier-2 waits for a new request.
 	querier2wg := sync.WaitGroup{}
@@ -147,7 +148,7 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 
 	// Enqueue a request from an user which would be assigned to querier-1.
 	// NOTE: "user-1" hash falls in the querier-1 shard.
-	require.NoError(t, queue.EnqueueRequest("user-1", "request", 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request", 1, "", nil))
 
 	startTime := time.Now()
 	querier2wg.Wait()
@@ -157,6 +158,67 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 	assert.GreaterOrEqual(t, waitTime.Milliseconds(), forgetDelay.Milliseconds())
 }
 
+func TestRequestQueue_GetAndRemoveUserRequests(t *testing.T) {
+	queueLength := promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"})
+	queue := NewRequestQueue(10, 0, queueLength, promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}))
+
+	for _, req := range []string{"request-1", "request-2", "request-3"} {
+		require.NoError(t, queue.EnqueueRequest("user-1", req, 0, "", nil))
+	}
+	require.NoError(t, queue.EnqueueRequest("user-2", "request-4", 0, "", nil))
+
+	assert.Equal(t, map[string]int{"user-1": 3, "user-2": 1}, queue.UserQueueLengths())
+
+	// Getting the requests doesn't remove them from the queue, nor change their order.
+	assert.Equal(t, []Request{"request-1", "request-2", "request-3"}, queue.GetUserRequests("user-1"))
+	assert.Equal(t, []Request{"request-1", "request-2", "request-3"}, queue.GetUserRequests("user-1"))
+	assert.Nil(t, queue.GetUserRequests("user-3"))
+
+	// Remove a specific request.
+	removed := queue.RemoveUserRequests("user-1", func(req Request) bool { return req == "request-2" })
+	assert.Equal(t, []Request{"request-2"}, removed)
+	assert.Equal(t, []Request{"request-1", "request-3"}, queue.GetUserRequests("user-1"))
+	assert.Equal(t, 2.0, promtest.ToFloat64(queueLength.WithLabelValues("user-1")))
+
+	// Removing no request is a no-op.
+	assert.Nil(t, queue.RemoveUserRequests("user-1", func(Request) bool { return false }))
+	assert.Nil(t, queue.RemoveUserRequests("user-3", func(Request) bool { return true }))
+
+	// Drain the whole queue.
+	removed = queue.RemoveUserRequests("user-1", func(Request) bool { return true })
+	assert.Equal(t, []Request{"request-1", "request-3"}, removed)
+	assert.Equal(t, map[string]int{"user-2": 1}, queue.UserQueueLengths())
+	assert.Equal(t, 0.0, promtest.ToFloat64(queueLength.WithLabelValues("user-1")))
+}
+
+func TestRequestQueue_ReturnRequest(t *testing.T) {
+	queueLength := promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"})
+	queue := NewRequestQueue(3, 0, queueLength, promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}))
+
+	for _, req := range []string{"request-1", "request-2", "request-3"} {
+		require.NoError(t, queue.EnqueueRequest("user-1", req, 0, "", nil))
+	}
+
+	queue.RegisterQuerierConnection("querier-1", "")
+	req, _, err := queue.GetNextRequestForQuerier(context.Background(), FirstUser(), "querier-1")
+	require.NoError(t, err)
+	require.Equal(t, "request-1", req)
+
+	// The returned request is put back at the front of the queue.
+	require.NoError(t, queue.ReturnRequest("user-1", req, 0, ""))
+	assert.Equal(t, []Request{"request-1", "request-2", "request-3"}, queue.GetUserRequests("user-1"))
+	assert.Equal(t, 3.0, promtest.ToFloat64(queueLength.WithLabelValues("user-1")))
+
+	// A request can't be returned if the queue is full.
+	assert.Equal(t, ErrTooManyRequests, queue.ReturnRequest("user-1", "request-4", 0, ""))
+
+	// The queue is created again if the returned request was the last one.
+	require.NoError(t, queue.EnqueueRequest("user-2", "request-5", 0, "", nil))
+	require.Len(t, queue.RemoveUserRequests("user-2", func(Request) bool { return true }), 1)
+	require.NoError(t, queue.ReturnRequest("user-2", "request-5", 0, ""))
+	assert.Equal(t, []Request{"request-5"}, queue.GetUserRequests("user-2"))
+}
+
 func TestContextCond(t *testing.T) {
 	t.Run("wait until broadcast", func(t *testing.T) {
 		t.Parallel()
@@ -25,6 +25,10 @@ type querier struct {
 
 	// When the last connection has been unregistered.
 	disconnectedAt time.Time
+
+	// Pool the querier belongs to, as advertised by its first connection. Queriers in a pool are reserved
+	// to the users assigned to it. Empty if the querier belongs to no pool, and so is shared by all other users.
+	pool string
 }
 
 // This struct holds user queues for pending requests. It also keeps track of connected queriers,
@@ -48,6 +52,9 @@ type queues struct {
 
 	// Sorted list of querier names, used when creating per-user shard.
 	sortedQueriers []string
+
+	// Number of queriers registered to the queue in each querier pool.
+	poolQueriers map[string]int
 }
 
 type userQueue struct {
@@ -58,6 +65,12 @@ type userQueue struct {
 	queriers    map[string]struct{}
 	maxQueriers int
 
+	// Querier pool the user is assigned to, and the pool of the queriers actually handling user requests.
+	// They differ when no querier of the assigned pool is registered, and so user requests are handled by
+	// the queriers belonging to no pool.
+	pool          string
+	effectivePool string
+
 	// Seed for shuffle sharding of queriers. This seed is based on userID only and is therefore consistent
 	// between different frontends.
 	seed int64
@@ -74,6 +87,7 @@ func newUserQueues(maxUserQueueSize int, forgetDelay time.Duration) *queues {
 		forgetDelay:      forgetDelay,
 		queriers:         map[string]*querier{},
 		sortedQueriers:   nil,
+		poolQueriers:     map[string]int{},
 	}
 }
 
@@ -98,9 +112,10 @@ func (q *queues) deleteQueue(userID string) {
 
 // Returns existing or new queue for user.
 // MaxQueriers is used to compute which queriers should handle requests for this user.
-// If maxQueriers is <= 0, all queriers can handle this user's requests.
-// If maxQueriers has changed since the last call, queriers for this are recomputed.
-func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
+// If maxQueriers is <= 0, all queriers of the user's querier pool can handle this user's requests.
+// Pool is the querier pool the user is assigned to, or empty if the user is assigned to no pool.
+// If maxQueriers or pool have changed since the last call, queriers for this are recomputed.
+func (q *queues) getOrAddQueue(userID string, maxQueriers int, pool string) chan Request {
 	// Empty user is not allowed, as that would break our users list ("" is used for free spot).
 	if userID == "" {
 		return nil
@@ -111,6 +126,7 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 	}
 
 	uq := q.userQueues[userID]
+	isNew := false
 
 	if uq == nil {
 		uq = &userQueue{
@@ -119,6 +135,7 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 			index: -1,
 		}
 		q.userQueues[userID] = uq
+		isNew = true
 
 		// Add user to the list of users... find first free spot, and put it there.
 		for ix, u := range q.users {
@@ -136,9 +153,10 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) chan Request {
 		}
 	}
 
-	if uq.maxQueriers != maxQueriers {
+	if isNew || uq.maxQueriers != maxQueriers || uq.pool != pool {
 		uq.maxQueriers = maxQueriers
-		uq.queriers = shuffleQueriersForUser(uq.seed, maxQueriers, q.sortedQueriers, nil)
+		uq.pool = pool
+		q.recomputeQueriersForUser(uq, nil)
 	}
 
 	return uq.ch
@@ -152,7 +170,8 @@ func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (ch
 
 	// Ensure the querier is not shutting down. If the querier is shutting down, we shouldn't forward
 	// any more queries to it.
-	if info := q.queriers[querierID]; info == nil || info.shuttingDown {
+	info := q.queriers[querierID]
+	if info == nil || info.shuttingDown {
 		return nil, "", uid
 	}
 
@@ -172,6 +191,11 @@ func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (ch
 
 		q := q.userQueues[u]
 
+		if q.effectivePool != info.pool {
+			// This querier is not in the querier pool handling the user.
+			continue
+		}
+
 		if q.queriers != nil {
 			if _, ok := q.queriers[querierID]; !ok {
 				// This querier is not handling the user.
@@ -184,7 +208,9 @@ func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (ch
 	return nil, "", uid
 }
 
-func (q *queues) addQuerierConnection(querierID string) {
+// addQuerierConnection registers a connection from the querier. The pool the querier belongs to is
+// taken from the first connection, and is empty if the querier belongs to no pool.
+func (q *queues) addQuerierConnection(querierID, pool string) {
 	info := q.queriers[querierID]
 	if info != nil {
 		info.connections++
@@ -197,9 +223,10 @@ func (q *queues) addQuerierConnection(querierID string) {
 	}
 
 	// First connection from this querier.
-	q.queriers[querierID] = &querier{connections: 1}
+	q.queriers[querierID] = &querier{connections: 1, pool: pool}
 	q.sortedQueriers = append(q.sortedQueriers, querierID)
 	slices.Sort(q.sortedQueriers)
+	q.poolQueriers[pool]++
 
 	q.recomputeUserQueriers()
 }
@@ -230,6 +257,11 @@ func (q *queues) removeQuerierConnection(querierID string, now time.Time) {
 }
 
 func (q *queues) removeQuerier(querierID string) {
+	if info := q.queriers[querierID]; info != nil {
+		if q.poolQueriers[info.pool]--; q.poolQueriers[info.pool] <= 0 {
+			delete(q.poolQueriers, info.pool)
+		}
+	}
 	delete(q.queriers, querierID)
 
 	ix := sort.SearchStrings(q.sortedQueriers, querierID)
@@ -287,10 +319,33 @@ func (q *queues) recomputeUserQueriers() {
 	scratchpad := make([]string, 0, len(q.sortedQueriers))
 
 	for _, uq := range q.userQueues {
-		uq.queriers = shuffleQueriersForUser(uq.seed, uq.maxQueriers, q.sortedQueriers, scratchpad)
+		q.recomputeQueriersForUser(uq, scratchpad)
 	}
 }
 
+// recomputeQueriersForUser selects the querier pool handling the user requests, and the queriers of that pool
+// the user is shuffle sharded to. Requests of users assigned to a pool with no registered queriers are handled
+// by the queriers belonging to no pool.
+func (q *queues) recomputeQueriersForUser(uq *userQueue, scratchpad []string) {
+	uq.effectivePool = uq.pool
+	if q.poolQueriers[uq.pool] == 0 {
+		uq.effectivePool = ""
+	}
+
+	poolQueriers := q.sortedQueriers
+	if len(q.poolQueriers) > 1 || q.poolQueriers[""] == 0 {
+		// Not all registered queriers belong to the same pool, so we need to filter them.
+		poolQueriers = make([]string, 0, q.poolQueriers[uq.effectivePool])
+		for _, querierID := range q.sortedQueriers {
+			if q.queriers[querierID].pool == uq.effectivePool {
+				poolQueriers = append(poolQueriers, querierID)
+			}
+		}
+	}
+
+	uq.queriers = shuffleQueriersForUser(uq.seed, uq.maxQueriers, poolQueriers, scratchpad)
+}
+
 // shuffleQueriersForUser returns nil if queriersToSelect is 0 or there are not enough queriers to select from.
 // In that case *all* queriers should be used.
 // Scratchpad is used for shuffling, to avoid new allocations. If nil, new slice is allocated.
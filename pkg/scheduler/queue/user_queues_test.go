@@ -23,8 +23,8 @@ func TestQueues(t *testing.T) {
 	assert.NotNil(t, uq)
 	assert.NoError(t, isConsistent(uq))
 
-	uq.addQuerierConnection("querier-1")
-	uq.addQuerierConnection("querier-2")
+	uq.addQuerierConnection("querier-1", "")
+	uq.addQuerierConnection("querier-2", "")
 
 	q, u, lastUserIndex := uq.getNextQueueForQuerier(-1, "querier-1")
 	assert.Nil(t, q)
@@ -81,8 +81,8 @@ func TestQueuesOnTerminatingQuerier(t *testing.T) {
 	assert.NotNil(t, uq)
 	assert.NoError(t, isConsistent(uq))
 
-	uq.addQuerierConnection("querier-1")
-	uq.addQuerierConnection("querier-2")
+	uq.addQuerierConnection("querier-1", "")
+	uq.addQuerierConnection("querier-2", "")
 
 	// Add queues: [one, two]
 	qOne := getOrAdd(t, uq, "one", 0)
@@ -106,6 +106,63 @@ func TestQueuesOnTerminatingQuerier(t *testing.T) {
 	assert.Equal(t, "", u)
 }
 
+func TestQueues_QuerierPools(t *testing.T) {
+	uq := newUserQueues(0, 0)
+
+	uq.addQuerierConnection("querier-1", "")
+	uq.addQuerierConnection("querier-2", "")
+
+	qShared := uq.getOrAddQueue("shared", 0, "")
+	qReserved := uq.getOrAddQueue("reserved", 0, "reserved-pool")
+	assert.NoError(t, isConsistent(uq))
+
+	// No querier in the reserved pool is connected, so the queriers belonging to no pool handle all users.
+	assert.ElementsMatch(t, []string{"shared", "reserved"}, getUsersByQuerier(uq, "querier-1"))
+	assert.ElementsMatch(t, []string{"shared", "reserved"}, getUsersByQuerier(uq, "querier-2"))
+
+	// Once queriers in the reserved pool connect, they're the only ones handling the users assigned to it,
+	// and they don't handle other users.
+	uq.addQuerierConnection("querier-3", "reserved-pool")
+	uq.addQuerierConnection("querier-4", "reserved-pool")
+	assert.NoError(t, isConsistent(uq))
+
+	assert.Equal(t, []string{"shared"}, getUsersByQuerier(uq, "querier-1"))
+	assert.Equal(t, []string{"shared"}, getUsersByQuerier(uq, "querier-2"))
+	assert.Equal(t, []string{"reserved"}, getUsersByQuerier(uq, "querier-3"))
+	assert.Equal(t, []string{"reserved"}, getUsersByQuerier(uq, "querier-4"))
+
+	confirmOrderForQuerier(t, uq, "querier-1", -1, qShared, qShared)
+	confirmOrderForQuerier(t, uq, "querier-3", -1, qReserved, qReserved)
+
+	// Shuffle sharding only selects queriers of the reserved pool.
+	uq.getOrAddQueue("reserved", 1, "reserved-pool")
+	assert.NoError(t, isConsistent(uq))
+	assert.Len(t, uq.userQueues["reserved"].queriers, 1)
+
+	// Users assigned to a pool with no queriers are handled by the queriers belonging to no pool.
+	qOther := uq.getOrAddQueue("other", 0, "other-pool")
+	assert.NoError(t, isConsistent(uq))
+	assert.ElementsMatch(t, []string{"shared", "other"}, getUsersByQuerier(uq, "querier-1"))
+	confirmOrderForQuerier(t, uq, "querier-3", -1, qReserved, qReserved)
+
+	// Once all queriers in the reserved pool disconnect, the users assigned to it fall back to the queriers belonging to no pool.
+	uq.removeQuerierConnection("querier-3", time.Now())
+	uq.removeQuerierConnection("querier-4", time.Now())
+	assert.NoError(t, isConsistent(uq))
+	assert.ElementsMatch(t, []string{"shared", "reserved", "other"}, getUsersByQuerier(uq, "querier-1"))
+
+	// Changing the pool the user is assigned to is honored.
+	uq.addQuerierConnection("querier-5", "other-pool")
+	assert.Equal(t, qOther, uq.getOrAddQueue("other", 0, "other-pool"))
+	assert.NoError(t, isConsistent(uq))
+	assert.Equal(t, []string{"other"}, getUsersByQuerier(uq, "querier-5"))
+
+	uq.getOrAddQueue("other", 0, "")
+	assert.NoError(t, isConsistent(uq))
+	assert.Empty(t, getUsersByQuerier(uq, "querier-5"))
+	assert.ElementsMatch(t, []string{"shared", "reserved", "other"}, getUsersByQuerier(uq, "querier-1"))
+}
+
 func TestQueuesWithQueriers(t *testing.T) {
 	uq := newUserQueues(0, 0)
 	assert.NotNil(t, uq)
@@ -118,7 +175,7 @@ func TestQueuesWithQueriers(t *testing.T) {
 	// Add some queriers.
 	for ix := 0; ix < queriers; ix++ {
 		qid := fmt.Sprintf("querier-%d", ix)
-		uq.addQuerierConnection(qid)
+		uq.addQuerierConnection(qid, "")
 
 		// No querier has any queues yet.
 		q, u, _ := uq.getNextQueueForQuerier(-1, qid)
@@ -197,7 +254,7 @@ func TestQueuesConsistency(t *testing.T) {
 			for i := 0; i < 10000; i++ {
 				switch r.Int() % 6 {
 				case 0:
-					assert.NotNil(t, uq.getOrAddQueue(generateTenant(r), 3))
+					assert.NotNil(t, uq.getOrAddQueue(generateTenant(r), 3, ""))
 				case 1:
 					qid := generateQuerier(r)
 					_, _, luid := uq.getNextQueueForQuerier(lastUserIndexes[qid], qid)
@@ -206,7 +263,7 @@ func TestQueuesConsistency(t *testing.T) {
 					uq.deleteQueue(generateTenant(r))
 				case 3:
 					q := generateQuerier(r)
-					uq.addQuerierConnection(q)
+					uq.addQuerierConnection(q, "")
 					conns[q]++
 				case 4:
 					q := generateQuerier(r)
@@ -239,8 +296,8 @@ func TestQueues_ForgetDelay(t *testing.T) {
 
 	// 3 queriers open 2 connections each.
 	for i := 1; i <= 3; i++ {
-		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i))
-		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i))
+		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i), "")
+		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i), "")
 	}
 
 	// Add user queues.
@@ -268,8 +325,8 @@ func TestQueues_ForgetDelay(t *testing.T) {
 	}
 
 	// Querier-1 reconnects.
-	uq.addQuerierConnection("querier-1")
-	uq.addQuerierConnection("querier-1")
+	uq.addQuerierConnection("querier-1", "")
+	uq.addQuerierConnection("querier-1", "")
 
 	// We expect the initial querier-1 users have got back to querier-1.
 	for _, userID := range querier1Users {
@@ -331,8 +388,8 @@ func TestQueues_ForgetDelay_ShouldCorrectlyHandleQuerierReconnectingBeforeForget
 
 	// 3 queriers open 2 connections each.
 	for i := 1; i <= 3; i++ {
-		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i))
-		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i))
+		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i), "")
+		uq.addQuerierConnection(fmt.Sprintf("querier-%d", i), "")
 	}
 
 	// Add user queues.
@@ -364,8 +421,8 @@ func TestQueues_ForgetDelay_ShouldCorrectlyHandleQuerierReconnectingBeforeForget
 	uq.forgetDisconnectedQueriers(now.Add(90 * time.Second))
 
 	// Querier-1 reconnects.
-	uq.addQuerierConnection("querier-1")
-	uq.addQuerierConnection("querier-1")
+	uq.addQuerierConnection("querier-1", "")
+	uq.addQuerierConnection("querier-1", "")
 
 	assert.Contains(t, uq.queriers, "querier-1")
 	assert.NoError(t, isConsistent(uq))
@@ -399,10 +456,10 @@ func generateQuerier(r *rand.Rand) string {
 }
 
 func getOrAdd(t *testing.T, uq *queues, tenant string, maxQueriers int) chan Request {
-	q := uq.getOrAddQueue(tenant, maxQueriers)
+	q := uq.getOrAddQueue(tenant, maxQueriers, "")
 	assert.NotNil(t, q)
 	assert.NoError(t, isConsistent(uq))
-	assert.Equal(t, q, uq.getOrAddQueue(tenant, maxQueriers))
+	assert.Equal(t, q, uq.getOrAddQueue(tenant, maxQueriers, ""))
 	return q
 }
 
@@ -444,11 +501,22 @@ func isConsistent(uq *queues) error {
 			return fmt.Errorf("user %s has queriers, but maxQueriers=0", u)
 		}
 
-		if q.maxQueriers > 0 && len(uq.sortedQueriers) <= q.maxQueriers && q.queriers != nil {
+		if q.pool != q.effectivePool && (q.effectivePool != "" || uq.poolQueriers[q.pool] > 0) {
+			return fmt.Errorf("user %s is handled by querier pool %s, but assigned to pool %s", u, q.effectivePool, q.pool)
+		}
+
+		poolQueriers := uq.poolQueriers[q.effectivePool]
+		for querierID := range q.queriers {
+			if uq.queriers[querierID].pool != q.effectivePool {
+				return fmt.Errorf("user %s has querier %s not belonging to querier pool %s", u, querierID, q.effectivePool)
+			}
+		}
+
+		if q.maxQueriers > 0 && poolQueriers <= q.maxQueriers && q.queriers != nil {
 			return fmt.Errorf("user %s has queriers set despite not enough queriers available", u)
 		}
 
-		if q.maxQueriers > 0 && len(uq.sortedQueriers) > q.maxQueriers && len(q.queriers) != q.maxQueriers {
+		if q.maxQueriers > 0 && poolQueriers > q.maxQueriers && len(q.queriers) != q.maxQueriers {
 			return fmt.Errorf("user %s has incorrect number of queriers, expected=%d, got=%d", u, len(q.queriers), q.maxQueriers)
 		}
 	}
@@ -464,6 +532,10 @@ func isConsistent(uq *queues) error {
 func getUsersByQuerier(queues *queues, querierID string) []string {
 	var userIDs []string
 	for userID, q := range queues.userQueues {
+		if info := queues.queriers[querierID]; info == nil || info.pool != q.effectivePool {
+			// The querier is not in the querier pool handling this user.
+			continue
+		}
 		if q.queriers == nil {
 			// If it's nil then all queriers can handle this user.
 			userIDs = append(userIDs, userID)
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+)
+
+const (
+	// QueueSnapshotsPrefix is the prefix of the queue snapshots in the bucket the snapshots are stored in.
+	QueueSnapshotsPrefix = "query-scheduler-queue-snapshots"
+
+	// queueSnapshotsDayLayout is the layout of the per-day directories the snapshots are grouped into, so that
+	// reading a time range and deleting the snapshots past the retention don't need to list all the snapshots.
+	queueSnapshotsDayLayout = "2006-01-02"
+
+	// queueSnapshotsCleanupInterval is how frequently the snapshots past the retention are deleted.
+	queueSnapshotsCleanupInterval = time.Hour
+
+	// queueSnapshotsReadConcurrency is the max number of snapshots read concurrently from the bucket.
+	queueSnapshotsReadConcurrency = 16
+)
+
+var errInvalidQueueSnapshotsRetention = errors.New("the queue snapshots retention must be greater than 0 when the queue snapshots are enabled")
+
+// QueueSnapshotsConfig configures the periodic snapshots of the per-tenant queues stored to object storage.
+type QueueSnapshotsConfig struct {
+	Interval  time.Duration `yaml:"interval" category:"experimental"`
+	Retention time.Duration `yaml:"retention" category:"experimental"`
+}
+
+func (cfg *QueueSnapshotsConfig) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.Interval, "query-scheduler.queue-snapshots.interval", 0, "How frequently the query-scheduler stores a snapshot of the length and the age of the requests of each tenant queue to the blocks storage bucket. The snapshots can be queried over time with the /query-scheduler/queue-snapshots endpoint. 0 to disable.")
+	f.DurationVar(&cfg.Retention, "query-scheduler.queue-snapshots.retention", 30*24*time.Hour, "How long the queue snapshots are kept in the blocks storage bucket before being deleted. This applies only when -query-scheduler.queue-snapshots.interval is set.")
+}
+
+func (cfg *QueueSnapshotsConfig) Validate() error {
+	if cfg.Enabled() && cfg.Retention <= 0 {
+		return errInvalidQueueSnapshotsRetention
+	}
+	return nil
+}
+
+// Enabled returns whether the queue snapshots are enabled.
+func (cfg *QueueSnapshotsConfig) Enabled() bool {
+	return cfg.Interval > 0
+}
+
+// queueSnapshot is the state of the tenant queues of a query-scheduler instance at a point in time.
+type queueSnapshot struct {
+	Timestamp time.Time             `json:"timestamp"`
+	Instance  string                `json:"instance"`
+	Tenants   []tenantQueueSnapshot `json:"tenants"`
+}
+
+// tenantQueueSnapshot is the state of the queue of a tenant at a point in time. Tenants with no request
+// waiting in the queue are not included in the snapshots.
+type tenantQueueSnapshot struct {
+	Tenant           string  `json:"tenant"`
+	QueueLength      int     `json:"queue_length"`
+	OldestAgeSeconds float64 `json:"oldest_age_seconds"`
+	P50AgeSeconds    float64 `json:"p50_age_seconds"`
+	P99AgeSeconds    float64 `json:"p99_age_seconds"`
+}
+
+// newTenantQueueSnapshots builds the snapshots of the tenant queues from the time each queued request has been
+// waiting for, by tenant. The snapshots are sorted by tenant.
+func newTenantQueueSnapshots(ages map[string][]time.Duration) []tenantQueueSnapshot {
+	tenants := make([]tenantQueueSnapshot, 0, len(ages))
+	for tenantID, tenantAges := range ages {
+		if len(tenantAges) == 0 {
+			continue
+		}
+		sort.Slice(tenantAges, func(i, j int) bool { return tenantAges[i] < tenantAges[j] })
+
+		tenants = append(tenants, tenantQueueSnapshot{
+			Tenant:           tenantID,
+			QueueLength:      len(tenantAges),
+			OldestAgeSeconds: tenantAges[len(tenantAges)-1].Seconds(),
+			P50AgeSeconds:    ageQuantile(tenantAges, 0.5).Seconds(),
+			P99AgeSeconds:    ageQuantile(tenantAges, 0.99).Seconds(),
+		})
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Tenant < tenants[j].Tenant })
+	return tenants
+}
+
+// ageQuantile returns the q-quantile of the sorted ages, using the nearest-rank method.
+func ageQuantile(sorted []time.Duration, q float64) time.Duration {
+	idx := int(q*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// queueSnapshotter periodically stores the snapshots of the tenant queues to the bucket, deletes the snapshots
+// past the retention, and reads back the snapshots within a time range.
+type queueSnapshotter struct {
+	services.Service
+
+	cfg        QueueSnapshotsConfig
+	bkt        objstore.Bucket
+	instanceID string
+	logger     log.Logger
+
+	// collect returns the snapshots of the tenant queues at the given time.
+	collect func(now time.Time) []tenantQueueSnapshot
+
+	// now is overridden in the tests.
+	now func() time.Time
+
+	lastCleanup time.Time
+
+	uploads        prometheus.Counter
+	uploadFailures prometheus.Counter
+}
+
+func newQueueSnapshotter(cfg QueueSnapshotsConfig, bkt objstore.Bucket, instanceID string, collect func(now time.Time) []tenantQueueSnapshot, logger log.Logger, reg prometheus.Registerer) *queueSnapshotter {
+	s := &queueSnapshotter{
+		cfg:        cfg,
+		bkt:        objstore.NewPrefixedBucket(bkt, QueueSnapshotsPrefix),
+		instanceID: instanceID,
+		logger:     logger,
+		collect:    collect,
+		now:        time.Now,
+		uploads: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_scheduler_queue_snapshots_uploads_total",
+			Help: "Total number of queue snapshots the query-scheduler attempted to store to the bucket.",
+		}),
+		uploadFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_scheduler_queue_snapshots_upload_failures_total",
+			Help: "Total number of queue snapshots the query-scheduler failed to store to the bucket.",
+		}),
+	}
+
+	s.Service = services.NewTimerService(cfg.Interval, nil, s.iteration, nil)
+	return s
+}
+
+func (s *queueSnapshotter) iteration(ctx context.Context) error {
+	now := s.now()
+
+	// Failures are not returned, to not stop the query-scheduler because the bucket is unavailable.
+	if err := s.upload(ctx, now); err != nil {
+		level.Warn(s.logger).Log("msg", "failed to store the queue snapshot", "err", err)
+	}
+
+	if now.Sub(s.lastCleanup) >= queueSnapshotsCleanupInterval {
+		if err := s.cleanup(ctx, now); err != nil {
+			level.Warn(s.logger).Log("msg", "failed to delete the queue snapshots past the retention", "err", err)
+		}
+	}
+	return nil
+}
+
+// upload stores the snapshot of the tenant queues at the given time to the bucket.
+func (s *queueSnapshotter) upload(ctx context.Context, now time.Time) error {
+	s.uploads.Inc()
+
+	data, err := json.Marshal(queueSnapshot{
+		Timestamp: now.UTC(),
+		Instance:  s.instanceID,
+		Tenants:   s.collect(now),
+	})
+	if err == nil {
+		err = s.bkt.Upload(ctx, queueSnapshotPath(now, s.instanceID), bytes.NewReader(data))
+	}
+	if err != nil {
+		s.uploadFailures.Inc()
+		return err
+	}
+	return nil
+}
+
+// cleanup deletes the snapshots stored before the retention.
+func (s *queueSnapshotter) cleanup(ctx context.Context, now time.Time) error {
+	// A day is deleted once all its snapshots are past the retention.
+	minDay := now.Add(-s.cfg.Retention).UTC().Truncate(24 * time.Hour)
+
+	var days []string
+	err := s.bkt.Iter(ctx, "", func(name string) error {
+		day := strings.TrimSuffix(name, objstore.DirDelim)
+		if t, err := time.Parse(queueSnapshotsDayLayout, day); err == nil && t.Before(minDay) {
+			days = append(days, day)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "list queue snapshots days")
+	}
+
+	for _, day := range days {
+		err := s.bkt.Iter(ctx, day+objstore.DirDelim, func(name string) error {
+			// Other query-scheduler replicas may delete the same snapshots at the same time.
+			if err := s.bkt.Delete(ctx, name); err != nil && !s.bkt.IsObjNotFoundErr(err) {
+				return errors.Wrapf(err, "delete queue snapshot %s", name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	s.lastCleanup = now
+	return nil
+}
+
+// read returns the snapshots stored by all the query-scheduler instances within [start, end], sorted by
+// timestamp. If tenantID is not empty, the snapshots only include the queue of the tenant.
+func (s *queueSnapshotter) read(ctx context.Context, tenantID string, start, end time.Time) ([]queueSnapshot, error) {
+	var names []string
+	for day := start.UTC().Truncate(24 * time.Hour); !day.After(end); day = day.Add(24 * time.Hour) {
+		err := s.bkt.Iter(ctx, day.Format(queueSnapshotsDayLayout)+objstore.DirDelim, func(name string) error {
+			ts, ok := parseQueueSnapshotPath(name)
+			if ok && !ts.Before(start) && !ts.After(end) {
+				names = append(names, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "list queue snapshots")
+		}
+	}
+
+	snapshots := make([]queueSnapshot, len(names))
+	err := concurrency.ForEachJob(ctx, len(names), queueSnapshotsReadConcurrency, func(ctx context.Context, idx int) error {
+		r, err := s.bkt.Get(ctx, names[idx])
+		if err != nil {
+			// The snapshot may have been deleted by the retention in the meanwhile.
+			if s.bkt.IsObjNotFoundErr(err) {
+				return nil
+			}
+			return errors.Wrapf(err, "read queue snapshot %s", names[idx])
+		}
+		defer r.Close()
+
+		if err := json.NewDecoder(r).Decode(&snapshots[idx]); err != nil {
+			return errors.Wrapf(err, "decode queue snapshot %s", names[idx])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := snapshots[:0]
+	for _, snapshot := range snapshots {
+		if snapshot.Timestamp.IsZero() {
+			continue
+		}
+		if tenantID != "" {
+			tenants := make([]tenantQueueSnapshot, 0, 1)
+			for _, tenant := range snapshot.Tenants {
+				if tenant.Tenant == tenantID {
+					tenants = append(tenants, tenant)
+				}
+			}
+			snapshot.Tenants = tenants
+		}
+		result = append(result, snapshot)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+	return result, nil
+}
+
+// queueSnapshotPath returns the path of the snapshot stored by the instance at the given time.
+func queueSnapshotPath(ts time.Time, instanceID string) string {
+	ts = ts.UTC()
+	return path.Join(ts.Format(queueSnapshotsDayLayout), fmt.Sprintf("%d-%s.json", ts.UnixMilli(), instanceID))
+}
+
+// parseQueueSnapshotPath returns the time of the snapshot stored at the given path.
+func parseQueueSnapshotPath(name string) (time.Time, bool) {
+	ms, _, ok := strings.Cut(path.Base(name), "-")
+	if !ok {
+		return time.Time{}, false
+	}
+	v, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(v), true
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestNewTenantQueueSnapshots(t *testing.T) {
+	ages := map[string][]time.Duration{
+		"user-2": {time.Second},
+		"user-1": {},
+		"user-3": {},
+	}
+	for i := 100; i > 0; i-- {
+		ages["user-3"] = append(ages["user-3"], time.Duration(i)*time.Second)
+	}
+
+	assert.Equal(t, []tenantQueueSnapshot{
+		{Tenant: "user-2", QueueLength: 1, OldestAgeSeconds: 1, P50AgeSeconds: 1, P99AgeSeconds: 1},
+		{Tenant: "user-3", QueueLength: 100, OldestAgeSeconds: 100, P50AgeSeconds: 50, P99AgeSeconds: 99},
+	}, newTenantQueueSnapshots(ages))
+}
+
+func TestQueueSnapshotter(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	reg := prometheus.NewPedanticRegistry()
+
+	tenants := []tenantQueueSnapshot{
+		{Tenant: "user-1", QueueLength: 2, OldestAgeSeconds: 3, P50AgeSeconds: 1, P99AgeSeconds: 3},
+		{Tenant: "user-2", QueueLength: 1, OldestAgeSeconds: 1, P50AgeSeconds: 1, P99AgeSeconds: 1},
+	}
+	cfg := QueueSnapshotsConfig{Interval: time.Minute, Retention: 48 * time.Hour}
+	collect := func(time.Time) []tenantQueueSnapshot { return tenants }
+	s1 := newQueueSnapshotter(cfg, bkt, "scheduler-1", collect, log.NewNopLogger(), reg)
+	s2 := newQueueSnapshotter(cfg, bkt, "scheduler-2", collect, log.NewNopLogger(), nil)
+
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, ts := range []time.Time{now.Add(-72 * time.Hour), now.Add(-25 * time.Hour), now.Add(-time.Minute), now} {
+		require.NoError(t, s1.upload(ctx, ts))
+	}
+	require.NoError(t, s2.upload(ctx, now.Add(-30*time.Second)))
+
+	assert.Equal(t, float64(4), promtest.ToFloat64(s1.uploads))
+	assert.Equal(t, float64(0), promtest.ToFloat64(s1.uploadFailures))
+
+	t.Run("read all tenants", func(t *testing.T) {
+		snapshots, err := s1.read(ctx, "", now.Add(-26*time.Hour), now)
+		require.NoError(t, err)
+		assert.Equal(t, []queueSnapshot{
+			{Timestamp: now.Add(-25 * time.Hour), Instance: "scheduler-1", Tenants: tenants},
+			{Timestamp: now.Add(-time.Minute), Instance: "scheduler-1", Tenants: tenants},
+			{Timestamp: now.Add(-30 * time.Second), Instance: "scheduler-2", Tenants: tenants},
+			{Timestamp: now, Instance: "scheduler-1", Tenants: tenants},
+		}, snapshots)
+	})
+
+	t.Run("read a single tenant", func(t *testing.T) {
+		snapshots, err := s2.read(ctx, "user-2", now.Add(-time.Hour), now.Add(-time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, []queueSnapshot{
+			{Timestamp: now.Add(-time.Minute), Instance: "scheduler-1", Tenants: tenants[1:]},
+			{Timestamp: now.Add(-30 * time.Second), Instance: "scheduler-2", Tenants: tenants[1:]},
+		}, snapshots)
+	})
+
+	t.Run("cleanup the snapshots past the retention", func(t *testing.T) {
+		require.NoError(t, s1.cleanup(ctx, now))
+
+		snapshots, err := s1.read(ctx, "", now.Add(-100*time.Hour), now)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 4)
+		assert.Equal(t, now.Add(-25*time.Hour), snapshots[0].Timestamp)
+	})
+}
+
+func TestQueueSnapshotsHandler(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		s := &Scheduler{log: log.NewNopLogger()}
+		rec := httptest.NewRecorder()
+		s.QueueSnapshotsHandler(rec, httptest.NewRequest(http.MethodGet, "/query-scheduler/queue-snapshots", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		bkt := objstore.NewInMemBucket()
+		cfg := QueueSnapshotsConfig{Interval: time.Minute, Retention: time.Hour}
+		collect := func(time.Time) []tenantQueueSnapshot {
+			return []tenantQueueSnapshot{{Tenant: "user-1", QueueLength: 1, OldestAgeSeconds: 1, P50AgeSeconds: 1, P99AgeSeconds: 1}}
+		}
+		s := &Scheduler{log: log.NewNopLogger(), queueSnapshots: newQueueSnapshotter(cfg, bkt, "scheduler-1", collect, log.NewNopLogger(), nil)}
+
+		now := time.Now().Truncate(time.Millisecond).UTC()
+		require.NoError(t, s.queueSnapshots.upload(context.Background(), now.Add(-25*time.Hour)))
+		require.NoError(t, s.queueSnapshots.upload(context.Background(), now.Add(-time.Hour)))
+
+		// The last 24 hours are returned by default.
+		rec := httptest.NewRecorder()
+		s.QueueSnapshotsHandler(rec, httptest.NewRequest(http.MethodGet, "/query-scheduler/queue-snapshots", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp queueSnapshotsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Snapshots, 1)
+		assert.True(t, now.Add(-time.Hour).Equal(resp.Snapshots[0].Timestamp))
+
+		rec = httptest.NewRecorder()
+		start := strconv.FormatInt(now.Add(-26*time.Hour).Unix(), 10)
+		s.QueueSnapshotsHandler(rec, httptest.NewRequest(http.MethodGet, "/query-scheduler/queue-snapshots?tenant=user-2&start="+start, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Snapshots, 2)
+		assert.Empty(t, resp.Snapshots[0].Tenants)
+		assert.Empty(t, resp.Snapshots[1].Tenants)
+
+		rec = httptest.NewRecorder()
+		s.QueueSnapshotsHandler(rec, httptest.NewRequest(http.MethodGet, "/query-scheduler/queue-snapshots?start=invalid", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -7,15 +7,18 @@ package scheduler
 
 import (
 	"context"
+	"crypto/sha256"
 	"flag"
 	"io"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/limiter"
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
 	otgrpc "github.com/opentracing-contrib/go-grpc"
@@ -23,6 +26,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
 	"github.com/weaveworks/common/user"
@@ -54,13 +58,35 @@ type Scheduler struct {
 	requestQueue *queue.RequestQueue
 	activeUsers  *util.ActiveUsersCleanupService
 
+	querierCapacity *querierCapacityTracker
+	highCostQueries *highCostQueryTracker
+	tenantQueryRate *tenantQueryRateTracker
+	faults          *faultInjector
+
+	// Stores the periodic snapshots of the tenant queues. Nil if the queue snapshots are disabled.
+	queueSnapshots *queueSnapshotter
+
 	pendingRequestsMu sync.Mutex
 	pendingRequests   map[requestKey]*schedulerRequest // Request is kept in this map even after being dispatched to querier. It can still be canceled at that time.
 
+	// Number of pending requests per tenant, including the ones being enqueued. Guarded by pendingRequestsMu.
+	pendingRequestsPerUser map[string]int
+
+	// Queued requests other identical requests can be deduplicated into, by deduplication key.
+	// Guarded by pendingRequestsMu.
+	deduplicationLeaders map[string]*schedulerRequest
+
+	// Per-tenant rate limiter of the enqueued queries.
+	queryRateLimiter *limiter.RateLimiter
+
 	// The ring is used to let other components discover query-scheduler replicas.
 	// The ring is optional.
 	schedulerLifecycler *ring.BasicLifecycler
 
+	// The ring client is used to find out the number of query-scheduler replicas the per-tenant
+	// query limits are split between. It's set only if the ring-based service discovery mode is used.
+	schedulerRingClient *ring.Ring
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -69,9 +95,19 @@ type Scheduler struct {
 	queueLength              *prometheus.GaugeVec
 	discardedRequests        *prometheus.CounterVec
 	cancelledRequests        *prometheus.CounterVec
+	expiredRequests          *prometheus.CounterVec
+	droppedRequests          *prometheus.CounterVec
+	rejectedRequests         *prometheus.CounterVec
+	deduplicatedRequests     *prometheus.CounterVec
+	querierShardSize         *prometheus.GaugeVec
+	querierBackpressureWaits prometheus.Counter
+	deferredHighCostRequests *prometheus.CounterVec
 	connectedQuerierClients  prometheus.GaugeFunc
 	connectedFrontendClients prometheus.GaugeFunc
 	queueDuration            prometheus.Histogram
+	tenantQueueDuration      *prometheus.HistogramVec // Nil if the per-tenant queue duration histogram is disabled.
+	oldestQueuedRequestAge   prometheus.Gauge
+	tenantOldestRequestAge   *prometheus.GaugeVec // Nil if the per-tenant queue duration histogram is disabled.
 	inflightRequests         prometheus.Summary
 }
 
@@ -89,26 +125,66 @@ type connectedFrontend struct {
 	cancel context.CancelFunc
 }
 
+var (
+	errQueryRateLimited     = errors.New("the tenant exceeded the query rate limit")
+	errMaxConcurrentQueries = errors.New("the tenant exceeded the max number of concurrent queries")
+
+	errInvalidQuerierMaxInflightQueries    = errors.New("the querier max in-flight queries must be greater than or equal to 0")
+	errInvalidMaxHighCostQueriesPerQuerier = errors.New("the max high-cost queries per querier must be greater than 0")
+	errQueueSnapshotsBucketRequired        = errors.New("a bucket is required when the queue snapshots are enabled")
+)
+
 type Config struct {
-	MaxOutstandingPerTenant int                       `yaml:"max_outstanding_requests_per_tenant"`
-	QuerierForgetDelay      time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
-	GRPCClientConfig        grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
-	ServiceDiscovery        schedulerdiscovery.Config `yaml:",inline"`
+	MaxOutstandingPerTenant             int                       `yaml:"max_outstanding_requests_per_tenant"`
+	QuerierForgetDelay                  time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
+	QuerierMaxInflightQueries           int                       `yaml:"querier_max_inflight_queries" category:"experimental"`
+	QuerierMinMemoryHeadroomBytes       uint64                    `yaml:"querier_min_memory_headroom_bytes" category:"experimental"`
+	QuerierBackpressureMaxDelay         time.Duration             `yaml:"querier_backpressure_max_delay" category:"experimental"`
+	HighCostQuerySeriesThreshold        uint64                    `yaml:"high_cost_query_series_threshold" category:"experimental"`
+	MaxHighCostQueriesPerQuerier        int                       `yaml:"max_high_cost_queries_per_querier" category:"experimental"`
+	QueryDeduplicationEnabled           bool                      `yaml:"query_deduplication_enabled" category:"experimental"`
+	TenantQueueDurationHistogramEnabled bool                      `yaml:"tenant_queue_duration_histogram_enabled" category:"experimental"`
+	FaultInjection                      FaultInjectionConfig      `yaml:"fault_injection"`
+	QueueSnapshots                      QueueSnapshotsConfig      `yaml:"queue_snapshots"`
+	GRPCClientConfig                    grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
+	ServiceDiscovery                    schedulerdiscovery.Config `yaml:",inline"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.IntVar(&cfg.MaxOutstandingPerTenant, "query-scheduler.max-outstanding-requests-per-tenant", 100, "Maximum number of outstanding requests per tenant per query-scheduler. In-flight requests above this limit will fail with HTTP response status code 429.")
 	f.DurationVar(&cfg.QuerierForgetDelay, "query-scheduler.querier-forget-delay", 0, "If a querier disconnects without sending notification about graceful shutdown, the query-scheduler will keep the querier in the tenant's shard until the forget delay has passed. This feature is useful to reduce the blast radius when shuffle-sharding is enabled.")
+	f.IntVar(&cfg.QuerierMaxInflightQueries, "query-scheduler.querier-max-inflight-queries", 0, "The query-scheduler doesn't dispatch queries to a querier reporting this number of in-flight queries or more, across all query-schedulers, until the querier reports a lower number or the backpressure max delay has passed. 0 to disable.")
+	f.Uint64Var(&cfg.QuerierMinMemoryHeadroomBytes, "query-scheduler.querier-min-memory-headroom-bytes", 0, "The query-scheduler doesn't dispatch queries to a querier reporting less memory headroom than this, until the querier reports a higher headroom or the backpressure max delay has passed. The memory headroom is computed against the querier Go memory limit (GOMEMLIMIT). 0 to disable.")
+	f.DurationVar(&cfg.QuerierBackpressureMaxDelay, "query-scheduler.querier-backpressure-max-delay", time.Second, "Maximum time the query-scheduler holds back the dispatching of a query to a querier without capacity. This applies only when -query-scheduler.querier-max-inflight-queries or -query-scheduler.querier-min-memory-headroom-bytes is set.")
+	f.Uint64Var(&cfg.HighCostQuerySeriesThreshold, "query-scheduler.high-cost-query-series-threshold", 0, "Queries estimated by the query-frontend to touch this number of series or more are high-cost queries. The query-scheduler doesn't dispatch more than -query-scheduler.max-high-cost-queries-per-querier high-cost queries to the same querier at once, to smooth querier memory peaks. The estimate is available only when the query-frontend cardinality estimation is enabled. 0 to disable.")
+	f.IntVar(&cfg.MaxHighCostQueriesPerQuerier, "query-scheduler.max-high-cost-queries-per-querier", 1, "Maximum number of high-cost queries the query-scheduler dispatches to the same querier at once. This applies only when -query-scheduler.high-cost-query-series-threshold is set.")
+	f.BoolVar(&cfg.QueryDeduplicationEnabled, "query-scheduler.query-deduplication-enabled", false, "When enabled, a query enqueued while an identical query of the same tenant is waiting in the queue is not enqueued, but the result of the queued query is sent to both query-frontends once a querier runs it. Queries are identical if they have the same HTTP method, URL and body.")
+	f.BoolVar(&cfg.TenantQueueDurationHistogramEnabled, "query-scheduler.tenant-queue-duration-histogram-enabled", false, "Track the time requests spend in the queue with a histogram per tenant, and the age of the oldest request waiting in the queue per tenant, in addition to the ones across all tenants. Enabling this option increases the number of series exported by the query-scheduler proportionally to the number of tenants.")
+	cfg.FaultInjection.RegisterFlags(f)
+	cfg.QueueSnapshots.RegisterFlags(f)
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
 	cfg.ServiceDiscovery.RegisterFlags(f, logger)
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.QuerierMaxInflightQueries < 0 {
+		return errInvalidQuerierMaxInflightQueries
+	}
+	if cfg.HighCostQuerySeriesThreshold > 0 && cfg.MaxHighCostQueriesPerQuerier <= 0 {
+		return errInvalidMaxHighCostQueriesPerQuerier
+	}
+	if err := cfg.FaultInjection.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.QueueSnapshots.Validate(); err != nil {
+		return err
+	}
 	return cfg.ServiceDiscovery.Validate()
 }
 
-// NewScheduler creates a new Scheduler.
-func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer prometheus.Registerer) (*Scheduler, error) {
+// NewScheduler creates a new Scheduler. The bucket is used to store the queue snapshots, and can be nil if
+// the queue snapshots are disabled.
+func NewScheduler(cfg Config, limits Limits, bkt objstore.Bucket, log log.Logger, registerer prometheus.Registerer) (*Scheduler, error) {
 	var err error
 
 	s := &Scheduler{
@@ -116,9 +192,14 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		log:    log,
 		limits: limits,
 
-		pendingRequests:    map[requestKey]*schedulerRequest{},
-		connectedFrontends: map[string]*connectedFrontend{},
-		subservicesWatcher: services.NewFailureWatcher(),
+		pendingRequests:        map[requestKey]*schedulerRequest{},
+		pendingRequestsPerUser: map[string]int{},
+		deduplicationLeaders:   map[string]*schedulerRequest{},
+		connectedFrontends:     map[string]*connectedFrontend{},
+		querierCapacity:        newQuerierCapacityTracker(cfg.QuerierMaxInflightQueries, cfg.QuerierMinMemoryHeadroomBytes),
+		highCostQueries:        newHighCostQueryTracker(cfg.HighCostQuerySeriesThreshold, cfg.MaxHighCostQueriesPerQuerier),
+		tenantQueryRate:        newTenantQueryRateTracker(),
+		subservicesWatcher:     services.NewFailureWatcher(),
 	}
 
 	s.queueLength = promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
@@ -134,6 +215,35 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Name: "cortex_query_scheduler_discarded_requests_total",
 		Help: "Total number of query requests discarded.",
 	}, []string{"user"})
+	s.expiredRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_expired_requests_total",
+		Help: "Total number of query requests removed from the queue because they waited longer than the max queue wait time.",
+	}, []string{"user"})
+	s.droppedRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_dropped_requests_total",
+		Help: "Total number of query requests dropped from the queue by an operator.",
+	}, []string{"user"})
+	s.rejectedRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_rejected_requests_total",
+		Help: "Total number of query requests rejected because the tenant exceeded the query rate limit or the max concurrent queries.",
+	}, []string{"user", "reason"})
+	s.deduplicatedRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_deduplicated_requests_total",
+		Help: "Total number of query requests not enqueued because an identical request was already waiting in the queue.",
+	}, []string{"user"})
+	s.querierShardSize = promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_querier_shard_size",
+		Help: "Max number of queriers that can handle the queries of the tenant, as computed on the last enqueued query. Only tracked for the tenants with dynamic querier shard size.",
+	}, []string{"user"})
+	s.querierBackpressureWaits = promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_querier_backpressure_waits_total",
+		Help: "Total number of times the query-scheduler held back the dispatching of a query because the querier reported it had no capacity.",
+	})
+	s.deferredHighCostRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_deferred_high_cost_requests_total",
+		Help: "Total number of high-cost query requests put back into the queue because the querier was already running the max number of high-cost queries.",
+	}, []string{"user"})
+	s.faults = newFaultInjector(cfg.FaultInjection, log, registerer)
 	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, s.queueLength, s.discardedRequests)
 
 	s.queueDuration = promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
@@ -141,6 +251,21 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Help:    "Time spend by requests in queue before getting picked up by a querier.",
 		Buckets: prometheus.DefBuckets,
 	})
+	if cfg.TenantQueueDurationHistogramEnabled {
+		s.tenantQueueDuration = promauto.With(registerer).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_query_scheduler_tenant_queue_duration_seconds",
+			Help:    "Time spend by requests of the tenant in queue before getting picked up by a querier.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"user"})
+		s.tenantOldestRequestAge = promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_query_scheduler_tenant_oldest_queued_request_age_seconds",
+			Help: "Time the oldest request of the tenant still waiting in the queue has been waiting for. 0 if the tenant has no request waiting in the queue.",
+		}, []string{"user"})
+	}
+	s.oldestQueuedRequestAge = promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_oldest_queued_request_age_seconds",
+		Help: "Time the oldest request still waiting in the queue has been waiting for. 0 if no request is waiting in the queue.",
+	})
 	s.connectedQuerierClients = promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
 		Name: "cortex_query_scheduler_connected_querier_clients",
 		Help: "Number of querier worker clients currently connected to the query-scheduler.",
@@ -161,6 +286,14 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 	s.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(s.cleanupMetricsForInactiveUser)
 	subservices := []services.Service{s.requestQueue, s.activeUsers}
 
+	if cfg.QueueSnapshots.Enabled() {
+		if bkt == nil {
+			return nil, errQueueSnapshotsBucketRequired
+		}
+		s.queueSnapshots = newQueueSnapshotter(cfg.QueueSnapshots, bkt, cfg.ServiceDiscovery.SchedulerRing.InstanceID, s.collectQueueSnapshots, log, registerer)
+		subservices = append(subservices, s.queueSnapshots)
+	}
+
 	// Init the ring only if the ring-based service discovery mode is used.
 	if cfg.ServiceDiscovery.Mode == schedulerdiscovery.ModeRing {
 		s.schedulerLifecycler, err = schedulerdiscovery.NewRingLifecycler(cfg.ServiceDiscovery.SchedulerRing, log, registerer)
@@ -168,9 +301,16 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 			return nil, err
 		}
 
-		subservices = append(subservices, s.schedulerLifecycler)
+		s.schedulerRingClient, err = schedulerdiscovery.NewRingClient(cfg.ServiceDiscovery.SchedulerRing, "query-scheduler-query-scheduler-client", log, registerer)
+		if err != nil {
+			return nil, err
+		}
+
+		subservices = append(subservices, s.schedulerLifecycler, s.schedulerRingClient)
 	}
 
+	s.queryRateLimiter = limiter.NewRateLimiter(newQueryRateStrategy(limits, s.usedInstancesCount), 10*time.Second)
+
 	s.subservices, err = services.NewManager(subservices...)
 	if err != nil {
 		return nil, err
@@ -184,6 +324,31 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 type Limits interface {
 	// MaxQueriersPerUser returns max queriers to use per tenant, or 0 if shuffle sharding is disabled.
 	MaxQueriersPerUser(user string) int
+
+	// MaxQueueWaitTime returns the max time a request can wait in the queue, or 0 if there's no limit.
+	MaxQueueWaitTime(user string) time.Duration
+
+	// MaxQueryExecutionTime returns the max time a request can run in the querier once dispatched, or 0 if there's no limit.
+	MaxQueryExecutionTime(user string) time.Duration
+
+	// QuerierPool returns the querier pool the tenant is assigned to, or empty if it's assigned to no pool.
+	QuerierPool(user string) string
+
+	// MinQueriersPerUser returns the min queriers to use per tenant when the number of queriers is dynamic.
+	MinQueriersPerUser(user string) int
+
+	// TargetQueriesPerSecondPerQuerier returns the target rate of queries per second of a tenant that each querier
+	// should handle, or 0 if the number of queriers per tenant is not dynamic.
+	TargetQueriesPerSecondPerQuerier(user string) float64
+
+	// QueryRateLimit returns the max rate of queries per second a tenant can enqueue, or 0 if there's no limit.
+	QueryRateLimit(user string) float64
+
+	// QueryBurstSize returns the allowed burst of queries a tenant can enqueue, or 0 to use the query rate limit.
+	QueryBurstSize(user string) int
+
+	// MaxConcurrentQueries returns the max number of queries, either queued or running, of a tenant, or 0 if there's no limit.
+	MaxConcurrentQueries(user string) int
 }
 
 type schedulerRequest struct {
@@ -192,8 +357,33 @@ type schedulerRequest struct {
 	queryID         uint64
 	request         *httpgrpc.HTTPRequest
 	statsEnabled    bool
+	nonce           uint64
+
+	// Estimated number of series touched by the query, or 0 if unknown.
+	estimatedSeriesCount uint64
+
+	// The max queriers and querier pool of the tenant, used to put the request back into the queue.
+	maxQueriers int
+	querierPool string
 
-	enqueueTime time.Time
+	// The frontend stream the request has been received from. Used to notify the frontend
+	// when the request has waited in the queue longer than maxQueueWaitTime or has been dropped.
+	frontend         *frontendStream
+	enqueueTime      time.Time
+	maxQueueWaitTime time.Duration
+
+	// Max time the request can run in the querier once dispatched, or 0 if there's no limit.
+	maxExecutionTime time.Duration
+
+	// Key used to deduplicate identical requests, or empty if deduplication is disabled.
+	deduplicationKey string
+
+	// Requests deduplicated into this one, which get the result of this request. Guarded by pendingRequestsMu.
+	followers []*schedulerRequest
+
+	// Guarded by pendingRequestsMu. A request can either be dispatched to a querier or expire, but not both.
+	dispatched bool
+	expired    bool
 
 	ctx       context.Context
 	ctxCancel context.CancelFunc
@@ -203,8 +393,40 @@ type schedulerRequest struct {
 	parentSpanContext opentracing.SpanContext
 }
 
+// frontendStream wraps the stream of a frontend loop, so that messages can be sent to the frontend
+// from outside the FrontendLoop goroutine too.
+type frontendStream struct {
+	schedulerpb.SchedulerForFrontend_FrontendLoopServer
+
+	sendMu sync.Mutex
+	closed bool
+}
+
+func (f *frontendStream) Send(msg *schedulerpb.SchedulerToFrontend) error {
+	f.sendMu.Lock()
+	defer f.sendMu.Unlock()
+
+	// Sending on a stream is not allowed once the FrontendLoop has returned.
+	if f.closed {
+		return errFrontendStreamClosed
+	}
+	return f.SchedulerForFrontend_FrontendLoopServer.Send(msg)
+}
+
+func (f *frontendStream) close() {
+	f.sendMu.Lock()
+	defer f.sendMu.Unlock()
+
+	f.closed = true
+}
+
+var errFrontendStreamClosed = errors.New("frontend stream is closed")
+
 // FrontendLoop handles connection from frontend.
-func (s *Scheduler) FrontendLoop(frontend schedulerpb.SchedulerForFrontend_FrontendLoopServer) error {
+func (s *Scheduler) FrontendLoop(stream schedulerpb.SchedulerForFrontend_FrontendLoopServer) error {
+	frontend := &frontendStream{SchedulerForFrontend_FrontendLoopServer: stream}
+	defer frontend.close()
+
 	frontendAddress, frontendCtx, err := s.frontendConnected(frontend)
 	if err != nil {
 		return err
@@ -238,11 +460,17 @@ func (s *Scheduler) FrontendLoop(frontend schedulerpb.SchedulerForFrontend_Front
 
 		switch msg.GetType() {
 		case schedulerpb.ENQUEUE:
-			err = s.enqueueRequest(frontendCtx, frontendAddress, msg)
+			if s.faults.dropEnqueue() {
+				// The request is acknowledged but never enqueued, as if it was lost.
+				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+				break
+			}
+
+			err = s.enqueueRequest(frontendCtx, frontendAddress, frontend, msg)
 			switch {
 			case err == nil:
 				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
-			case errors.Is(err, queue.ErrTooManyRequests):
+			case errors.Is(err, queue.ErrTooManyRequests), errors.Is(err, errQueryRateLimited), errors.Is(err, errMaxConcurrentQueries):
 				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.TOO_MANY_REQUESTS_PER_TENANT}
 			default:
 				resp = &schedulerpb.SchedulerToFrontend{Status: schedulerpb.ERROR, Error: err.Error()}
@@ -305,7 +533,7 @@ func (s *Scheduler) frontendDisconnected(frontendAddress string) {
 	}
 }
 
-func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr string, msg *schedulerpb.FrontendToScheduler) error {
+func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr string, frontend *frontendStream, msg *schedulerpb.FrontendToScheduler) error {
 	// Create new context for this request, to support cancellation.
 	ctx, cancel := context.WithCancel(frontendContext)
 	shouldCancel := true
@@ -331,6 +559,10 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 		queryID:         msg.QueryID,
 		request:         msg.HttpRequest,
 		statsEnabled:    msg.StatsEnabled,
+		nonce:           msg.Nonce,
+		frontend:        frontend,
+
+		estimatedSeriesCount: msg.EstimatedSeriesCount,
 	}
 
 	now := time.Now()
@@ -345,16 +577,119 @@ func (s *Scheduler) enqueueRequest(frontendContext context.Context, frontendAddr
 	if err != nil {
 		return err
 	}
-	maxQueriers := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MaxQueriersPerUser)
+	maxQueriers := s.maxQueriersForTenants(userID, tenantIDs)
+	req.maxQueueWaitTime = validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.MaxQueueWaitTime)
+	req.maxExecutionTime = validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, s.limits.MaxQueryExecutionTime)
+
+	querierPool := querierPoolForTenants(tenantIDs, s.limits.QuerierPool)
+	req.maxQueriers, req.querierPool = maxQueriers, querierPool
 
 	s.activeUsers.UpdateUserTimestamp(userID, now)
-	return s.requestQueue.EnqueueRequest(userID, req, maxQueriers, func() {
+
+	if !s.queryRateLimiter.AllowN(now, userID, 1) {
+		s.rejectedRequests.WithLabelValues(userID, rejectReasonRateLimited).Inc()
+		return errQueryRateLimited
+	}
+	if !s.reservePendingRequest(userID, tenantIDs) {
+		s.rejectedRequests.WithLabelValues(userID, rejectReasonMaxConcurrentQueries).Inc()
+		return errMaxConcurrentQueries
+	}
+
+	s.tenantQueryRate.inc(userID)
+
+	if s.cfg.QueryDeduplicationEnabled {
+		req.deduplicationKey = requestDeduplicationKey(userID, msg)
+		if s.deduplicateRequest(req) {
+			shouldCancel = false
+			s.deduplicatedRequests.WithLabelValues(userID).Inc()
+			return nil
+		}
+	}
+
+	err = s.requestQueue.EnqueueRequest(userID, req, maxQueriers, querierPool, func() {
 		shouldCancel = false
 
 		s.pendingRequestsMu.Lock()
 		s.pendingRequests[requestKey{frontendAddr: frontendAddr, queryID: msg.QueryID}] = req
+		if req.deduplicationKey != "" {
+			s.deduplicationLeaders[req.deduplicationKey] = req
+		}
 		s.pendingRequestsMu.Unlock()
 	})
+	if err != nil {
+		s.pendingRequestsMu.Lock()
+		s.releasePendingRequest(userID)
+		s.pendingRequestsMu.Unlock()
+	}
+	return err
+}
+
+// deduplicateRequest adds the request to the followers of an identical request waiting in the queue, if any.
+// Returns true if the request has been deduplicated, and so it must not be enqueued.
+func (s *Scheduler) deduplicateRequest(req *schedulerRequest) bool {
+	s.pendingRequestsMu.Lock()
+	defer s.pendingRequestsMu.Unlock()
+
+	leader := s.deduplicationLeaders[req.deduplicationKey]
+	if leader == nil {
+		return false
+	}
+
+	leader.followers = append(leader.followers, req)
+	s.pendingRequests[requestKey{frontendAddr: req.frontendAddress, queryID: req.queryID}] = req
+	return true
+}
+
+// reservePendingRequest reserves a pending request slot for the user, unless the user has already reached
+// the max number of concurrent queries. Returns false if the slot couldn't be reserved.
+func (s *Scheduler) reservePendingRequest(userID string, tenantIDs []string) bool {
+	s.pendingRequestsMu.Lock()
+	defer s.pendingRequestsMu.Unlock()
+
+	if limit := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MaxConcurrentQueries); limit > 0 {
+		if s.pendingRequestsPerUser[userID] >= perInstanceConcurrencyLimit(limit, s.usedInstancesCount()) {
+			return false
+		}
+	}
+
+	s.pendingRequestsPerUser[userID]++
+	return true
+}
+
+// releasePendingRequest releases a pending request slot of the user. Must be called with pendingRequestsMu held.
+func (s *Scheduler) releasePendingRequest(userID string) {
+	if s.pendingRequestsPerUser[userID] <= 1 {
+		delete(s.pendingRequestsPerUser, userID)
+		return
+	}
+	s.pendingRequestsPerUser[userID]--
+}
+
+// removePendingRequest removes the request from the pending requests and releases its slot.
+// Must be called with pendingRequestsMu held.
+func (s *Scheduler) removePendingRequest(key requestKey) {
+	if req := s.pendingRequests[key]; req != nil {
+		delete(s.pendingRequests, key)
+		s.releasePendingRequest(req.userID)
+	}
+}
+
+// usedInstancesCount returns the number of query-scheduler replicas the query-frontends enqueue queries to,
+// as seen from the query-schedulers ring. Returns 1 if the ring-based service discovery mode is not used.
+func (s *Scheduler) usedInstancesCount() int {
+	if s.schedulerRingClient == nil {
+		return 1
+	}
+
+	set, err := s.schedulerRingClient.GetAllHealthy(activeSchedulersRingOp)
+	if err != nil || len(set.Instances) == 0 {
+		return 1
+	}
+
+	if maxUsed := s.cfg.ServiceDiscovery.MaxUsedInstances; maxUsed > 0 && len(set.Instances) > maxUsed {
+		return maxUsed
+	}
+	return len(set.Instances)
 }
 
 // This method doesn't do removal from the queue.
@@ -368,7 +703,144 @@ func (s *Scheduler) cancelRequestAndRemoveFromPending(frontendAddr string, query
 		req.ctxCancel()
 	}
 
-	delete(s.pendingRequests, key)
+	s.removePendingRequest(key)
+}
+
+// markRequestDispatched marks the request dequeued from the queue, and the requests deduplicated into it, as
+// dispatched to a querier, so that they can't expire anymore. Returns the requests to dispatch, which excludes
+// the ones which have been canceled or have expired while waiting in the queue.
+func (s *Scheduler) markRequestDispatched(req *schedulerRequest) []*schedulerRequest {
+	s.pendingRequestsMu.Lock()
+	defer s.pendingRequestsMu.Unlock()
+
+	// No more requests can be deduplicated into this one.
+	if req.deduplicationKey != "" && s.deduplicationLeaders[req.deduplicationKey] == req {
+		delete(s.deduplicationLeaders, req.deduplicationKey)
+	}
+
+	var dispatched []*schedulerRequest
+	for _, r := range append([]*schedulerRequest{req}, req.followers...) {
+		key := requestKey{frontendAddr: r.frontendAddress, queryID: r.queryID}
+		if r.expired || r.ctx.Err() != nil || s.pendingRequests[key] != r {
+			continue
+		}
+
+		r.dispatched = true
+		dispatched = append(dispatched, r)
+	}
+	return dispatched
+}
+
+// expireRequestsTooLongInQueue removes from the pending requests the ones which have been waiting in the queue
+// longer than their max queue wait time, and notifies the frontends about it. Expired requests are left in the
+// queue, and discarded once a querier picks them up, the same way canceled requests are.
+func (s *Scheduler) expireRequestsTooLongInQueue(now time.Time) {
+	var expired []*schedulerRequest
+
+	s.pendingRequestsMu.Lock()
+	for key, req := range s.pendingRequests {
+		if req.dispatched || req.maxQueueWaitTime <= 0 || now.Sub(req.enqueueTime) <= req.maxQueueWaitTime {
+			continue
+		}
+
+		req.expired = true
+		req.ctxCancel()
+		s.removePendingRequest(key)
+		expired = append(expired, req)
+	}
+	s.pendingRequestsMu.Unlock()
+
+	for _, req := range expired {
+		s.expiredRequests.WithLabelValues(req.userID).Inc()
+
+		err := req.frontend.Send(&schedulerpb.SchedulerToFrontend{
+			Status:  schedulerpb.TOO_LONG_IN_QUEUE,
+			QueryID: req.queryID,
+		})
+		if err != nil {
+			level.Warn(s.log).Log("msg", "failed to notify frontend about request waiting too long in queue", "frontend", req.frontendAddress, "queryID", req.queryID, "err", err)
+		}
+	}
+}
+
+// dropQueuedRequests removes from the user queue the requests for which shouldDrop returns true, removes them
+// from the pending requests and notifies the frontends about it. Returns the number of dropped requests.
+func (s *Scheduler) dropQueuedRequests(userID string, shouldDrop func(*schedulerRequest) bool) int {
+	removed := s.requestQueue.RemoveUserRequests(userID, func(r queue.Request) bool {
+		return shouldDrop(r.(*schedulerRequest))
+	})
+
+	var dropped []*schedulerRequest
+
+	s.pendingRequestsMu.Lock()
+	for _, r := range removed {
+		leader := r.(*schedulerRequest)
+		leader.queueSpan.Finish()
+
+		if leader.deduplicationKey != "" && s.deduplicationLeaders[leader.deduplicationKey] == leader {
+			delete(s.deduplicationLeaders, leader.deduplicationKey)
+		}
+
+		// The requests deduplicated into the removed one are dropped too.
+		for _, req := range append([]*schedulerRequest{leader}, leader.followers...) {
+			// Requests which have been canceled or have expired are not pending anymore, and
+			// their frontend doesn't wait for any response.
+			key := requestKey{frontendAddr: req.frontendAddress, queryID: req.queryID}
+			if req.expired || s.pendingRequests[key] != req {
+				continue
+			}
+
+			req.expired = true
+			req.ctxCancel()
+			s.removePendingRequest(key)
+			dropped = append(dropped, req)
+		}
+	}
+	s.pendingRequestsMu.Unlock()
+
+	for _, req := range dropped {
+		s.droppedRequests.WithLabelValues(req.userID).Inc()
+
+		err := req.frontend.Send(&schedulerpb.SchedulerToFrontend{
+			Status:  schedulerpb.DROPPED_FROM_QUEUE,
+			QueryID: req.queryID,
+		})
+		if err != nil {
+			level.Warn(s.log).Log("msg", "failed to notify frontend about request dropped from queue", "frontend", req.frontendAddress, "queryID", req.queryID, "err", err)
+		}
+	}
+
+	return len(removed)
+}
+
+// maxQueriersForTenants returns the max number of queriers that can handle the requests of the input tenants,
+// enqueued in the queue of userID. If the dynamic querier shard size is enabled, the number of queriers is computed
+// from the recent query rate of the queue.
+func (s *Scheduler) maxQueriersForTenants(userID string, tenantIDs []string) int {
+	maxQueriers := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MaxQueriersPerUser)
+	targetRate := validation.SmallestPositiveNonZeroFloat64PerTenant(tenantIDs, s.limits.TargetQueriesPerSecondPerQuerier)
+	if targetRate <= 0 {
+		return maxQueriers
+	}
+
+	minQueriers := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MinQueriersPerUser)
+	shardSize := dynamicMaxQueriers(s.tenantQueryRate.rate(userID), targetRate, minQueriers, maxQueriers)
+	s.querierShardSize.WithLabelValues(userID).Set(float64(shardSize))
+	return shardSize
+}
+
+// querierPoolForTenants returns the querier pool the input tenants are assigned to. Queries spanning
+// tenants assigned to different pools are run by the queriers belonging to no pool.
+func querierPoolForTenants(tenantIDs []string, f func(string) string) string {
+	pool := ""
+	for ix, tenantID := range tenantIDs {
+		tenantPool := f(tenantID)
+		if ix > 0 && tenantPool != pool {
+			return ""
+		}
+		pool = tenantPool
+	}
+	return pool
 }
 
 // QuerierLoop is started by querier to receive queries from scheduler.
@@ -380,15 +852,44 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 
 	querierID := resp.GetQuerierID()
 
-	s.requestQueue.RegisterQuerierConnection(querierID)
+	s.requestQueue.RegisterQuerierConnection(querierID, resp.GetQuerierPool())
 	defer s.requestQueue.UnregisterQuerierConnection(querierID)
 
+	s.querierCapacity.registerConnection(querierID)
+	defer s.querierCapacity.unregisterConnection(querierID)
+	s.querierCapacity.update(querierID, resp.GetCapacity())
+
+	// The drain context is canceled once the querier asks to drain the stream, to stop waiting for the next
+	// request to dispatch. The messages sent by the querier are received by a dedicated goroutine, so that
+	// the DRAINING message is received while waiting.
+	drainCtx, drainCancel := context.WithCancel(querier.Context())
+	defer drainCancel()
+	receiver := newQuerierStreamReceiver(querier, drainCancel)
+
+	isDraining := func() bool {
+		return drainCtx.Err() != nil && querier.Context().Err() == nil
+	}
+
 	lastUserIndex := queue.FirstUser()
 
 	// In stopping state scheduler is not accepting new queries, but still dispatching queries in the queues.
 	for s.isRunningOrStopping() {
-		req, idx, err := s.requestQueue.GetNextRequestForQuerier(querier.Context(), lastUserIndex, querierID)
+		if isDraining() {
+			return s.confirmQuerierDrained(querier, querierID)
+		}
+
+		// Give queriers with capacity the chance to pick up queries before this one, if it reported to have no capacity.
+		if !s.querierCapacity.hasCapacity(querierID) {
+			s.querierBackpressureWaits.Inc()
+			s.querierCapacity.waitForCapacity(drainCtx, querierID, s.cfg.QuerierBackpressureMaxDelay)
+		}
+
+		req, idx, err := s.requestQueue.GetNextRequestForQuerier(drainCtx, lastUserIndex, querierID)
 		if err != nil {
+			if isDraining() {
+				return s.confirmQuerierDrained(querier, querierID)
+			}
+
 			// Return a more clear error if the queue is stopped because the query-scheduler is not running.
 			if errors.Is(err, queue.ErrStopped) && !s.isRunning() {
 				return schedulerpb.ErrSchedulerIsNotRunning
@@ -400,7 +901,24 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 
 		r := req.(*schedulerRequest)
 
-		s.queueDuration.Observe(time.Since(r.enqueueTime).Seconds())
+		// Avoid running multiple high-cost queries on the same querier at once. The request is put back at the front of
+		// the queue, so that queriers not running high-cost queries can pick it up in the meanwhile. If the queue is full,
+		// the request is dispatched anyway.
+		highCost := s.highCostQueries.isHighCost(r.estimatedSeriesCount)
+		if highCost && !s.highCostQueries.tryAcquire(querierID) {
+			if err := s.requestQueue.ReturnRequest(r.userID, r, r.maxQueriers, r.querierPool); err == nil {
+				s.deferredHighCostRequests.WithLabelValues(r.userID).Inc()
+				s.highCostQueries.waitForRelease(drainCtx, querierID, highCostQueryMaxDeferDelay)
+				continue
+			}
+			s.highCostQueries.acquire(querierID)
+		}
+
+		queueDuration := time.Since(r.enqueueTime).Seconds()
+		s.queueDuration.Observe(queueDuration)
+		if s.tenantQueueDuration != nil {
+			s.tenantQueueDuration.WithLabelValues(r.userID).Observe(queueDuration)
+		}
 		r.queueSpan.Finish()
 
 		/*
@@ -415,15 +933,29 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 		  it's possible that it's own queue would perpetually contain only expired requests.
 		*/
 
-		if r.ctx.Err() != nil {
+		reqs := s.markRequestDispatched(r)
+		if len(reqs) == 0 {
 			// Remove from pending requests.
 			s.cancelRequestAndRemoveFromPending(r.frontendAddress, r.queryID)
+			for _, f := range r.followers {
+				s.cancelRequestAndRemoveFromPending(f.frontendAddress, f.queryID)
+			}
+
+			if highCost {
+				s.highCostQueries.release(querierID)
+			}
 
 			lastUserIndex = lastUserIndex.ReuseLastUser()
 			continue
 		}
 
-		if err := s.forwardRequestToQuerier(querier, r); err != nil {
+		s.faults.delayDispatch(querier.Context())
+
+		err = s.forwardRequestToQuerier(querier, receiver, querierID, reqs)
+		if highCost {
+			s.highCostQueries.release(querierID)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -431,6 +963,66 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 	return schedulerpb.ErrSchedulerIsNotRunning
 }
 
+// confirmQuerierDrained tells the querier no more requests will be dispatched on the stream, so it can close it.
+func (s *Scheduler) confirmQuerierDrained(querier schedulerpb.SchedulerForQuerier_QuerierLoopServer, querierID string) error {
+	level.Debug(s.log).Log("msg", "querier stream has been drained", "querier", querierID)
+	return querier.Send(&schedulerpb.SchedulerToQuerier{Type: schedulerpb.DRAINED})
+}
+
+// querierStreamReceiver receives the messages sent by a querier on the QuerierLoop stream.
+type querierStreamReceiver struct {
+	ready chan *schedulerpb.QuerierToScheduler
+
+	// done is closed once receiving from the stream failed, and err is the reason why.
+	done chan struct{}
+	err  error
+}
+
+// newQuerierStreamReceiver starts receiving the messages sent by the querier on the stream, until it's closed.
+// The onDraining function is called when the querier asks to drain the stream.
+func newQuerierStreamReceiver(querier schedulerpb.SchedulerForQuerier_QuerierLoopServer, onDraining func()) *querierStreamReceiver {
+	r := &querierStreamReceiver{
+		ready: make(chan *schedulerpb.QuerierToScheduler, 1),
+		done:  make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.done)
+
+		for {
+			msg, err := querier.Recv()
+			if err != nil {
+				r.err = err
+				return
+			}
+
+			if msg.GetType() == schedulerpb.DRAINING {
+				onDraining()
+				continue
+			}
+
+			select {
+			case r.ready <- msg:
+			case <-querier.Context().Done():
+				r.err = querier.Context().Err()
+				return
+			}
+		}
+	}()
+
+	return r
+}
+
+// next returns the next message sent by the querier to signal it's ready to accept another request.
+func (r *querierStreamReceiver) next() (*schedulerpb.QuerierToScheduler, error) {
+	select {
+	case msg := <-r.ready:
+		return msg, nil
+	case <-r.done:
+		return nil, r.err
+	}
+}
+
 func (s *Scheduler) NotifyQuerierShutdown(_ context.Context, req *schedulerpb.NotifyQuerierShutdownRequest) (*schedulerpb.NotifyQuerierShutdownResponse, error) {
 	level.Info(s.log).Log("msg", "received shutdown notification from querier", "querier", req.GetQuerierID())
 	s.requestQueue.NotifyQuerierShutdown(req.GetQuerierID())
@@ -438,33 +1030,64 @@ func (s *Scheduler) NotifyQuerierShutdown(_ context.Context, req *schedulerpb.No
 	return &schedulerpb.NotifyQuerierShutdownResponse{}, nil
 }
 
-func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuerier_QuerierLoopServer, req *schedulerRequest) error {
-	// Make sure to cancel request at the end to cleanup resources.
-	defer s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
+// forwardRequestToQuerier runs the input requests, which are identical, on the querier. The querier sends
+// the result to the frontends of all requests.
+func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuerier_QuerierLoopServer, receiver *querierStreamReceiver, querierID string, reqs []*schedulerRequest) error {
+	req := reqs[0]
+
+	// Make sure to cancel requests at the end to cleanup resources.
+	defer func() {
+		for _, r := range reqs {
+			s.cancelRequestAndRemoveFromPending(r.frontendAddress, r.queryID)
+		}
+	}()
+
+	msg := &schedulerpb.SchedulerToQuerier{
+		UserID:          req.userID,
+		QueryID:         req.queryID,
+		FrontendAddress: req.frontendAddress,
+		HttpRequest:     req.request,
+		StatsEnabled:    req.statsEnabled,
+		Nonce:           req.nonce,
+	}
+	if req.maxExecutionTime > 0 {
+		msg.Deadline = time.Now().Add(req.maxExecutionTime).UnixNano()
+	}
+	ctxs := []context.Context{req.ctx}
+	for _, r := range reqs[1:] {
+		msg.AdditionalTargets = append(msg.AdditionalTargets, &schedulerpb.QueryTarget{
+			QueryID:         r.queryID,
+			FrontendAddress: r.frontendAddress,
+			Nonce:           r.nonce,
+		})
+		ctxs = append(ctxs, r.ctx)
+	}
 
 	// Handle the stream sending & receiving on a goroutine so we can
 	// monitoring the contexts in a select and cancel things appropriately.
 	errCh := make(chan error, 1)
 	go func() {
-		err := querier.Send(&schedulerpb.SchedulerToQuerier{
-			UserID:          req.userID,
-			QueryID:         req.queryID,
-			FrontendAddress: req.frontendAddress,
-			HttpRequest:     req.request,
-			StatsEnabled:    req.statsEnabled,
-		})
+		err := querier.Send(msg)
 		if err != nil {
 			errCh <- err
 			return
 		}
 
-		_, err = querier.Recv()
+		if s.faults.closeQuerierStream() {
+			errCh <- errInjectedQuerierStreamClose
+			return
+		}
+
+		resp, err := receiver.next()
+		if err == nil {
+			s.querierCapacity.update(querierID, resp.GetCapacity())
+		}
 		errCh <- err
 	}()
 
 	select {
-	case <-req.ctx.Done():
-		// If the upstream request is cancelled (eg. frontend issued CANCEL or closed connection),
+	case <-allContextsDone(ctxs):
+		// If all upstream requests are cancelled (eg. frontend issued CANCEL or closed connection),
 		// we need to cancel the downstream req. Only way we can do that is to close the stream (by returning error here).
 		// Querier is expecting this semantics.
 		s.cancelledRequests.WithLabelValues(req.userID).Inc()
@@ -475,12 +1098,30 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 		// then error out this upstream request _and_ stream.
 
 		if err != nil {
-			s.forwardErrorToFrontend(req.ctx, req, err)
+			for _, r := range reqs {
+				s.forwardErrorToFrontend(r.ctx, r, err)
+			}
 		}
 		return err
 	}
 }
 
+// allContextsDone returns a channel which is closed once all input contexts are done.
+func allContextsDone(ctxs []context.Context) <-chan struct{} {
+	if len(ctxs) == 1 {
+		return ctxs[0].Done()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for _, ctx := range ctxs {
+			<-ctx.Done()
+		}
+		close(done)
+	}()
+	return done
+}
+
 func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRequest, requestErr error) {
 	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
 		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
@@ -506,6 +1147,7 @@ func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRe
 	userCtx := user.InjectOrgID(ctx, req.userID)
 	_, err = client.QueryResult(userCtx, &frontendv2pb.QueryResultRequest{
 		QueryID: req.queryID,
+		Nonce:   req.nonce,
 		HttpResponse: &httpgrpc.HTTPResponse{
 			Code: http.StatusInternalServerError,
 			Body: []byte(requestErr.Error()),
@@ -546,6 +1188,17 @@ func (s *Scheduler) running(ctx context.Context) error {
 	inflightRequestsTicker := time.NewTicker(250 * time.Millisecond)
 	defer inflightRequestsTicker.Stop()
 
+	// Requests waiting in the queue longer than the max queue wait time are checked with the
+	// same frequency, which is the precision the max queue wait time is enforced with.
+	expireRequestsTicker := time.NewTicker(250 * time.Millisecond)
+	defer expireRequestsTicker.Stop()
+
+	tenantQueryRateTicker := time.NewTicker(tenantQueryRateTickInterval)
+	defer tenantQueryRateTicker.Stop()
+
+	// Users whose oldest queued request age has been last reported as non-zero.
+	queuedUsers := map[string]struct{}{}
+
 	for {
 		select {
 		case <-inflightRequestsTicker.C:
@@ -554,6 +1207,11 @@ func (s *Scheduler) running(ctx context.Context) error {
 			s.pendingRequestsMu.Unlock()
 
 			s.inflightRequests.Observe(float64(inflight))
+		case now := <-expireRequestsTicker.C:
+			s.expireRequestsTooLongInQueue(now)
+			queuedUsers = s.updateOldestQueuedRequestAge(now, queuedUsers)
+		case <-tenantQueryRateTicker.C:
+			s.tenantQueryRate.tick()
 		case <-ctx.Done():
 			return nil
 		case err := <-s.subservicesWatcher.Chan():
@@ -562,6 +1220,67 @@ func (s *Scheduler) running(ctx context.Context) error {
 	}
 }
 
+// updateOldestQueuedRequestAge updates the age of the oldest request waiting in the queue, and the one
+// of each user if the per-tenant queue duration histogram is enabled. The age of the users in
+// previouslyQueuedUsers who have no request waiting in the queue anymore is reset to 0. Returns the
+// users who have requests waiting in the queue.
+func (s *Scheduler) updateOldestQueuedRequestAge(now time.Time, previouslyQueuedUsers map[string]struct{}) map[string]struct{} {
+	var oldestOverall time.Time
+	oldest := map[string]time.Time{}
+
+	s.pendingRequestsMu.Lock()
+	for _, req := range s.pendingRequests {
+		if req.dispatched {
+			continue
+		}
+		if oldestOverall.IsZero() || req.enqueueTime.Before(oldestOverall) {
+			oldestOverall = req.enqueueTime
+		}
+		if t, ok := oldest[req.userID]; !ok || req.enqueueTime.Before(t) {
+			oldest[req.userID] = req.enqueueTime
+		}
+	}
+	s.pendingRequestsMu.Unlock()
+
+	if oldestOverall.IsZero() {
+		s.oldestQueuedRequestAge.Set(0)
+	} else {
+		s.oldestQueuedRequestAge.Set(now.Sub(oldestOverall).Seconds())
+	}
+
+	if s.tenantOldestRequestAge == nil {
+		return nil
+	}
+
+	queuedUsers := make(map[string]struct{}, len(oldest))
+	for userID, enqueueTime := range oldest {
+		s.tenantOldestRequestAge.WithLabelValues(userID).Set(now.Sub(enqueueTime).Seconds())
+		queuedUsers[userID] = struct{}{}
+	}
+	for userID := range previouslyQueuedUsers {
+		if _, ok := queuedUsers[userID]; !ok {
+			s.tenantOldestRequestAge.WithLabelValues(userID).Set(0)
+		}
+	}
+	return queuedUsers
+}
+
+// collectQueueSnapshots returns the snapshots of the tenant queues, built from the requests waiting in the queue.
+func (s *Scheduler) collectQueueSnapshots(now time.Time) []tenantQueueSnapshot {
+	ages := map[string][]time.Duration{}
+
+	s.pendingRequestsMu.Lock()
+	for _, req := range s.pendingRequests {
+		if req.dispatched {
+			continue
+		}
+		ages[req.userID] = append(ages[req.userID], now.Sub(req.enqueueTime))
+	}
+	s.pendingRequestsMu.Unlock()
+
+	return newTenantQueueSnapshots(ages)
+}
+
 // Close the Scheduler.
 func (s *Scheduler) stopping(_ error) error {
 	// This will also stop the requests queue, which stop accepting new requests and errors out any pending requests.
@@ -572,6 +1291,17 @@ func (s *Scheduler) cleanupMetricsForInactiveUser(user string) {
 	s.queueLength.DeleteLabelValues(user)
 	s.discardedRequests.DeleteLabelValues(user)
 	s.cancelledRequests.DeleteLabelValues(user)
+	s.expiredRequests.DeleteLabelValues(user)
+	s.droppedRequests.DeleteLabelValues(user)
+	s.rejectedRequests.DeletePartialMatch(prometheus.Labels{"user": user})
+	s.deduplicatedRequests.DeleteLabelValues(user)
+	s.deferredHighCostRequests.DeleteLabelValues(user)
+	if s.tenantQueueDuration != nil {
+		s.tenantQueueDuration.DeleteLabelValues(user)
+		s.tenantOldestRequestAge.DeleteLabelValues(user)
+	}
+	s.querierShardSize.DeleteLabelValues(user)
+	s.tenantQueryRate.remove(user)
 }
 
 func (s *Scheduler) getConnectedFrontendClientsMetric() float64 {
@@ -606,3 +1336,17 @@ func (s *Scheduler) RingHandler(w http.ResponseWriter, req *http.Request) {
 		</html>`
 	util.WriteHTMLResponse(w, ringDisabledPage)
 }
+
+// requestDeduplicationKey returns the key identifying the identical requests of the same user, which can
+// be deduplicated. Requests are identical if they have the same HTTP method, URL and body.
+func requestDeduplicationKey(userID string, msg *schedulerpb.FrontendToScheduler) string {
+	h := sha256.New()
+	for _, v := range []string{userID, strconv.FormatBool(msg.StatsEnabled), msg.HttpRequest.GetMethod(), msg.HttpRequest.GetUrl()} {
+		// Write the length of each value, to not mix up values with different boundaries.
+		_, _ = h.Write([]byte(strconv.Itoa(len(v))))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(v))
+	}
+	_, _ = h.Write(msg.HttpRequest.GetBody())
+	return string(h.Sum(nil))
+}
@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	_ "embed" // Used to embed html template
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+//go:embed tenants.gohtml
+var tenantsPageHTML string
+var tenantsTemplate = template.Must(template.New("webpage").Parse(tenantsPageHTML))
+
+//go:embed tenant_queue.gohtml
+var tenantQueuePageHTML string
+var tenantQueueTemplate = template.Must(template.New("webpage").Parse(tenantQueuePageHTML))
+
+type tenantsPageContents struct {
+	Now     time.Time     `json:"now"`
+	Tenants []tenantQueue `json:"tenants"`
+}
+
+type tenantQueue struct {
+	Tenant      string `json:"tenant"`
+	QueueLength int    `json:"queue_length"`
+}
+
+type tenantQueuePageContents struct {
+	Now      time.Time       `json:"now"`
+	Tenant   string          `json:"tenant"`
+	Requests []queuedRequest `json:"requests"`
+}
+
+type queuedRequest struct {
+	FrontendAddress string        `json:"frontend_address"`
+	QueryID         uint64        `json:"query_id"`
+	EnqueueTime     time.Time     `json:"enqueue_time"`
+	TimeInQueue     time.Duration `json:"time_in_queue"`
+	Method          string        `json:"method"`
+	URL             string        `json:"url"`
+	StatsEnabled    bool          `json:"stats_enabled"`
+	Canceled        bool          `json:"canceled"`
+}
+
+// TenantsHandler lists the tenants with queued requests, and the length of their queue.
+func (s *Scheduler) TenantsHandler(w http.ResponseWriter, req *http.Request) {
+	lengths := s.requestQueue.UserQueueLengths()
+
+	tenants := make([]tenantQueue, 0, len(lengths))
+	for tenantID, length := range lengths {
+		tenants = append(tenants, tenantQueue{Tenant: tenantID, QueueLength: length})
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Tenant < tenants[j].Tenant })
+
+	util.RenderHTTPResponse(w, tenantsPageContents{
+		Now:     time.Now(),
+		Tenants: tenants,
+	}, tenantsTemplate, req)
+}
+
+// TenantQueueHandler lists the requests in the queue of a tenant, in the order they will be dispatched to queriers.
+func (s *Scheduler) TenantQueueHandler(w http.ResponseWriter, req *http.Request) {
+	tenantID := mux.Vars(req)["tenant"]
+	if tenantID == "" {
+		util.WriteTextResponse(w, "Tenant ID can't be empty")
+		return
+	}
+
+	now := time.Now()
+	queued := s.requestQueue.GetUserRequests(tenantID)
+
+	requests := make([]queuedRequest, 0, len(queued))
+	for _, r := range queued {
+		sr := r.(*schedulerRequest)
+		requests = append(requests, queuedRequest{
+			FrontendAddress: sr.frontendAddress,
+			QueryID:         sr.queryID,
+			EnqueueTime:     sr.enqueueTime,
+			TimeInQueue:     now.Sub(sr.enqueueTime),
+			Method:          sr.request.GetMethod(),
+			URL:             sr.request.GetUrl(),
+			StatsEnabled:    sr.statsEnabled,
+			Canceled:        sr.ctx.Err() != nil,
+		})
+	}
+
+	util.RenderHTTPResponse(w, tenantQueuePageContents{
+		Now:      now,
+		Tenant:   tenantID,
+		Requests: requests,
+	}, tenantQueueTemplate, req)
+}
+
+// DropQueuedRequestHandler drops a single request, identified by the frontend address and query ID,
+// from the queue of a tenant. The frontend which enqueued the request is notified about it.
+func (s *Scheduler) DropQueuedRequestHandler(w http.ResponseWriter, req *http.Request) {
+	tenantID := mux.Vars(req)["tenant"]
+	if tenantID == "" {
+		http.Error(w, "tenant ID can't be empty", http.StatusBadRequest)
+		return
+	}
+
+	frontendAddr := req.FormValue("frontend")
+	queryID, err := strconv.ParseUint(req.FormValue("query_id"), 10, 64)
+	if frontendAddr == "" || err != nil {
+		http.Error(w, "frontend and query_id parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	dropped := s.dropQueuedRequests(tenantID, func(r *schedulerRequest) bool {
+		return r.frontendAddress == frontendAddr && r.queryID == queryID
+	})
+	if dropped == 0 {
+		http.Error(w, "request not found in the tenant queue", http.StatusNotFound)
+		return
+	}
+
+	level.Info(s.log).Log("msg", "dropped request from the queue", "user", tenantID, "frontend", frontendAddr, "queryID", queryID)
+	util.WriteTextResponse(w, fmt.Sprintf("Dropped request %d from frontend %s from the queue of tenant %s", queryID, frontendAddr, tenantID))
+}
+
+// DrainTenantQueueHandler drops all requests from the queue of a tenant. The frontends which enqueued
+// the requests are notified about it.
+func (s *Scheduler) DrainTenantQueueHandler(w http.ResponseWriter, req *http.Request) {
+	tenantID := mux.Vars(req)["tenant"]
+	if tenantID == "" {
+		http.Error(w, "tenant ID can't be empty", http.StatusBadRequest)
+		return
+	}
+
+	dropped := s.dropQueuedRequests(tenantID, func(*schedulerRequest) bool { return true })
+
+	level.Info(s.log).Log("msg", "drained tenant queue", "user", tenantID, "dropped", dropped)
+	util.WriteTextResponse(w, fmt.Sprintf("Dropped %d requests from the queue of tenant %s", dropped, tenantID))
+}
+
+type queueSnapshotsResponse struct {
+	Start     time.Time       `json:"start"`
+	End       time.Time       `json:"end"`
+	Snapshots []queueSnapshot `json:"snapshots"`
+}
+
+// QueueSnapshotsHandler returns the snapshots of the tenant queues stored by all the query-scheduler instances
+// between the start and end parameters, which default to the last 24 hours. If the tenant parameter is set, the
+// snapshots only include the queue of the tenant.
+func (s *Scheduler) QueueSnapshotsHandler(w http.ResponseWriter, req *http.Request) {
+	if s.queueSnapshots == nil {
+		http.Error(w, "queue snapshots are disabled", http.StatusNotFound)
+		return
+	}
+
+	end := time.Now()
+	if v := req.FormValue("end"); v != "" {
+		ms, err := util.ParseTime(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid end parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+		end = util.TimeFromMillis(ms)
+	}
+	start := end.Add(-24 * time.Hour)
+	if v := req.FormValue("start"); v != "" {
+		ms, err := util.ParseTime(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid start parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+		start = util.TimeFromMillis(ms)
+	}
+	if end.Before(start) {
+		http.Error(w, "end parameter must not be before start parameter", http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := s.queueSnapshots.read(req.Context(), req.FormValue("tenant"), start, end)
+	if err != nil {
+		level.Warn(s.log).Log("msg", "failed to read the queue snapshots", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, queueSnapshotsResponse{
+		Start:     start,
+		End:       end,
+		Snapshots: snapshots,
+	})
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+)
+
+func TestSchedulerQueueHTTPHandlers(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	scheduler, frontendClient, querierClient := setupScheduler(t, reg)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	for _, req := range []*schedulerpb.FrontendToScheduler{
+		{Type: schedulerpb.ENQUEUE, QueryID: 1, UserID: "user-1", HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/first"}},
+		{Type: schedulerpb.ENQUEUE, QueryID: 2, UserID: "user-1", HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/second"}},
+		{Type: schedulerpb.ENQUEUE, QueryID: 3, UserID: "user-1", HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/third"}},
+		{Type: schedulerpb.ENQUEUE, QueryID: 4, UserID: "user-2", HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/fourth"}},
+	} {
+		frontendToScheduler(t, frontendLoop, req)
+	}
+
+	t.Run("list tenants", func(t *testing.T) {
+		rec := serveQueueHandler(scheduler.TenantsHandler, http.MethodGet, "/query-scheduler/tenants", nil, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var page tenantsPageContents
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Equal(t, []tenantQueue{{Tenant: "user-1", QueueLength: 3}, {Tenant: "user-2", QueueLength: 1}}, page.Tenants)
+	})
+
+	t.Run("list tenant queue", func(t *testing.T) {
+		rec := serveQueueHandler(scheduler.TenantQueueHandler, http.MethodGet, "/query-scheduler/tenant/user-1/queue", map[string]string{"tenant": "user-1"}, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var page tenantQueuePageContents
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Equal(t, "user-1", page.Tenant)
+
+		var urls []string
+		for _, r := range page.Requests {
+			require.Equal(t, "frontend-12345", r.FrontendAddress)
+			require.Equal(t, http.MethodGet, r.Method)
+			require.False(t, r.Canceled)
+			urls = append(urls, r.URL)
+		}
+		require.Equal(t, []string{"/first", "/second", "/third"}, urls)
+	})
+
+	t.Run("drop a request which is not queued", func(t *testing.T) {
+		form := url.Values{"frontend": {"frontend-12345"}, "query_id": {"4"}}
+		rec := serveQueueHandler(scheduler.DropQueuedRequestHandler, http.MethodPost, "/query-scheduler/tenant/user-1/queue/drop", map[string]string{"tenant": "user-1"}, form)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("drop a request with missing parameters", func(t *testing.T) {
+		form := url.Values{"frontend": {"frontend-12345"}}
+		rec := serveQueueHandler(scheduler.DropQueuedRequestHandler, http.MethodPost, "/query-scheduler/tenant/user-1/queue/drop", map[string]string{"tenant": "user-1"}, form)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("drop a queued request", func(t *testing.T) {
+		form := url.Values{"frontend": {"frontend-12345"}, "query_id": {"2"}}
+		rec := serveQueueHandler(scheduler.DropQueuedRequestHandler, http.MethodPost, "/query-scheduler/tenant/user-1/queue/drop", map[string]string{"tenant": "user-1"}, form)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		msg, err := frontendLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, schedulerpb.DROPPED_FROM_QUEUE, msg.Status)
+		require.Equal(t, uint64(2), msg.QueryID)
+
+		require.Equal(t, map[string]int{"user-1": 2, "user-2": 1}, scheduler.requestQueue.UserQueueLengths())
+	})
+
+	t.Run("drain a tenant queue", func(t *testing.T) {
+		rec := serveQueueHandler(scheduler.DrainTenantQueueHandler, http.MethodPost, "/query-scheduler/tenant/user-1/queue/drain", map[string]string{"tenant": "user-1"}, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		for _, expectedQueryID := range []uint64{1, 3} {
+			msg, err := frontendLoop.Recv()
+			require.NoError(t, err)
+			require.Equal(t, schedulerpb.DROPPED_FROM_QUEUE, msg.Status)
+			require.Equal(t, expectedQueryID, msg.QueryID)
+		}
+
+		require.Equal(t, map[string]int{"user-2": 1}, scheduler.requestQueue.UserQueueLengths())
+	})
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_dropped_requests_total Total number of query requests dropped from the queue by an operator.
+		# TYPE cortex_query_scheduler_dropped_requests_total counter
+		cortex_query_scheduler_dropped_requests_total{user="user-1"} 3
+	`), "cortex_query_scheduler_dropped_requests_total"))
+
+	// Only the request of the other tenant is left, and it's dispatched to the querier.
+	querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+	msg, err := querierLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), msg.QueryID)
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+	verifyQuerierDoesntReceiveRequest(t, querierLoop, 500*time.Millisecond)
+}
+
+func serveQueueHandler(handler http.HandlerFunc, method, target string, vars map[string]string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Accept", "application/json")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if vars != nil {
+		req = mux.SetURLVars(req, vars)
+	}
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
@@ -8,6 +8,7 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
@@ -22,6 +23,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-client-go/config"
 	"github.com/weaveworks/common/httpgrpc"
@@ -36,11 +38,19 @@ import (
 const testMaxOutstandingPerTenant = 5
 
 func setupScheduler(t *testing.T, reg prometheus.Registerer) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
+	return setupSchedulerWithLimits(t, reg, &limits{queriers: 2})
+}
+
+func setupSchedulerWithLimits(t *testing.T, reg prometheus.Registerer, limits Limits) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
 	cfg := Config{}
 	flagext.DefaultValues(&cfg)
 	cfg.MaxOutstandingPerTenant = testMaxOutstandingPerTenant
 
-	s, err := NewScheduler(cfg, &limits{queriers: 2}, log.NewNopLogger(), reg)
+	return setupSchedulerWithConfig(t, reg, cfg, limits)
+}
+
+func setupSchedulerWithConfig(t *testing.T, reg prometheus.Registerer, cfg Config, limits Limits) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
+	s, err := NewScheduler(cfg, limits, nil, log.NewNopLogger(), reg)
 	require.NoError(t, err)
 
 	server := grpc.NewServer()
@@ -82,6 +92,7 @@ func TestSchedulerBasicEnqueue(t *testing.T) {
 		QueryID:     1,
 		UserID:      "test",
 		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		Nonce:       1234,
 	})
 
 	{
@@ -95,12 +106,231 @@ func TestSchedulerBasicEnqueue(t *testing.T) {
 		require.Equal(t, "frontend-12345", msg2.FrontendAddress)
 		require.Equal(t, "GET", msg2.HttpRequest.Method)
 		require.Equal(t, "/hello", msg2.HttpRequest.Url)
+		require.Equal(t, uint64(1234), msg2.Nonce)
 		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
 	}
 
 	verifyNoPendingRequestsLeft(t, scheduler)
 }
 
+func TestSchedulerQueryDeduplication(t *testing.T) {
+	enqueue := func(t *testing.T, frontendLoop schedulerpb.SchedulerForFrontend_FrontendLoopClient, queryID uint64, url string) {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     queryID,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: url},
+			Nonce:       queryID * 10,
+		})
+	}
+
+	t.Run("identical queued requests are run once", func(t *testing.T) {
+		cfg := Config{}
+		flagext.DefaultValues(&cfg)
+		cfg.QueryDeduplicationEnabled = true
+
+		reg := prometheus.NewPedanticRegistry()
+		scheduler, frontendClient, querierClient := setupSchedulerWithConfig(t, reg, cfg, &limits{})
+
+		frontendLoop1 := initFrontendLoop(t, frontendClient, "frontend-1")
+		frontendLoop2 := initFrontendLoop(t, frontendClient, "frontend-2")
+		enqueue(t, frontendLoop1, 1, "/hello")
+		enqueue(t, frontendLoop2, 2, "/hello")
+		enqueue(t, frontendLoop2, 3, "/world")
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		msg, err := querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), msg.QueryID)
+		require.Equal(t, "frontend-1", msg.FrontendAddress)
+		require.Equal(t, uint64(10), msg.Nonce)
+		require.Equal(t, []*schedulerpb.QueryTarget{{QueryID: 2, FrontendAddress: "frontend-2", Nonce: 20}}, msg.AdditionalTargets)
+		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+		msg, err = querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), msg.QueryID)
+		require.Empty(t, msg.AdditionalTargets)
+		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+		verifyNoPendingRequestsLeft(t, scheduler)
+
+		require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_query_scheduler_deduplicated_requests_total Total number of query requests not enqueued because an identical request was already waiting in the queue.
+			# TYPE cortex_query_scheduler_deduplicated_requests_total counter
+			cortex_query_scheduler_deduplicated_requests_total{user="test"} 1
+		`), "cortex_query_scheduler_deduplicated_requests_total"))
+	})
+
+	t.Run("deduplicated request is run even if the queued request is canceled", func(t *testing.T) {
+		cfg := Config{}
+		flagext.DefaultValues(&cfg)
+		cfg.QueryDeduplicationEnabled = true
+
+		scheduler, frontendClient, querierClient := setupSchedulerWithConfig(t, nil, cfg, &limits{})
+
+		frontendLoop1 := initFrontendLoop(t, frontendClient, "frontend-1")
+		frontendLoop2 := initFrontendLoop(t, frontendClient, "frontend-2")
+		enqueue(t, frontendLoop1, 1, "/hello")
+		enqueue(t, frontendLoop2, 2, "/hello")
+		frontendToScheduler(t, frontendLoop1, &schedulerpb.FrontendToScheduler{Type: schedulerpb.CANCEL, QueryID: 1})
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		msg, err := querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), msg.QueryID)
+		require.Equal(t, "frontend-2", msg.FrontendAddress)
+		require.Empty(t, msg.AdditionalTargets)
+		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+
+	t.Run("identical requests are not deduplicated if deduplication is disabled", func(t *testing.T) {
+		scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+		frontendLoop1 := initFrontendLoop(t, frontendClient, "frontend-1")
+		frontendLoop2 := initFrontendLoop(t, frontendClient, "frontend-2")
+		enqueue(t, frontendLoop1, 1, "/hello")
+		enqueue(t, frontendLoop2, 2, "/hello")
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		for _, queryID := range []uint64{1, 2} {
+			msg, err := querierLoop.Recv()
+			require.NoError(t, err)
+			require.Equal(t, queryID, msg.QueryID)
+			require.Empty(t, msg.AdditionalTargets)
+			require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+		}
+
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+}
+
+func TestSchedulerTenantQueueDurationHistogram(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.TenantQueueDurationHistogramEnabled = enabled
+
+			reg := prometheus.NewPedanticRegistry()
+			scheduler, frontendClient, querierClient := setupSchedulerWithConfig(t, reg, cfg, &limits{})
+
+			frontendLoop := initFrontendLoop(t, frontendClient, "frontend-1")
+			for queryID, userID := range []string{"user-1", "user-2", "user-1"} {
+				frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+					Type:        schedulerpb.ENQUEUE,
+					QueryID:     uint64(queryID),
+					UserID:      userID,
+					HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+				})
+			}
+
+			querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+			for i := 0; i < 3; i++ {
+				_, err := querierLoop.Recv()
+				require.NoError(t, err)
+				require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+			}
+			verifyNoPendingRequestsLeft(t, scheduler)
+
+			families, err := reg.Gather()
+			require.NoError(t, err)
+
+			counts := map[string]uint64{}
+			for _, family := range families {
+				if family.GetName() != "cortex_query_scheduler_tenant_queue_duration_seconds" {
+					continue
+				}
+				for _, m := range family.GetMetric() {
+					counts[m.GetLabel()[0].GetValue()] = m.GetHistogram().GetSampleCount()
+				}
+			}
+
+			if enabled {
+				assert.Equal(t, map[string]uint64{"user-1": 2, "user-2": 1}, counts)
+			} else {
+				assert.Empty(t, counts)
+			}
+		})
+	}
+}
+
+func TestScheduler_UpdateOldestQueuedRequestAge(t *testing.T) {
+	for _, tenantMetricsEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("tenant metrics enabled=%t", tenantMetricsEnabled), func(t *testing.T) {
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.TenantQueueDurationHistogramEnabled = tenantMetricsEnabled
+
+			reg := prometheus.NewPedanticRegistry()
+			scheduler, err := NewScheduler(cfg, &limits{}, nil, log.NewNopLogger(), reg)
+			require.NoError(t, err)
+
+			metricNames := []string{"cortex_query_scheduler_oldest_queued_request_age_seconds", "cortex_query_scheduler_tenant_oldest_queued_request_age_seconds"}
+			expectedMetrics := func(oldest string, tenants string) string {
+				expected := `
+					# HELP cortex_query_scheduler_oldest_queued_request_age_seconds Time the oldest request still waiting in the queue has been waiting for. 0 if no request is waiting in the queue.
+					# TYPE cortex_query_scheduler_oldest_queued_request_age_seconds gauge
+					cortex_query_scheduler_oldest_queued_request_age_seconds ` + oldest + `
+				`
+				if tenantMetricsEnabled {
+					expected += `
+					# HELP cortex_query_scheduler_tenant_oldest_queued_request_age_seconds Time the oldest request of the tenant still waiting in the queue has been waiting for. 0 if the tenant has no request waiting in the queue.
+					# TYPE cortex_query_scheduler_tenant_oldest_queued_request_age_seconds gauge
+					` + tenants
+				}
+				return expected
+			}
+
+			now := time.Now()
+			scheduler.pendingRequests = map[requestKey]*schedulerRequest{
+				{frontendAddr: "frontend-1", queryID: 1}: {userID: "user-1", enqueueTime: now.Add(-10 * time.Second)},
+				{frontendAddr: "frontend-1", queryID: 2}: {userID: "user-1", enqueueTime: now.Add(-5 * time.Second)},
+				{frontendAddr: "frontend-1", queryID: 3}: {userID: "user-2", enqueueTime: now.Add(-20 * time.Second), dispatched: true},
+				{frontendAddr: "frontend-2", queryID: 1}: {userID: "user-2", enqueueTime: now.Add(-2 * time.Second)},
+			}
+
+			queuedUsers := scheduler.updateOldestQueuedRequestAge(now, nil)
+			if tenantMetricsEnabled {
+				assert.Equal(t, map[string]struct{}{"user-1": {}, "user-2": {}}, queuedUsers)
+			} else {
+				assert.Empty(t, queuedUsers)
+			}
+			require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(expectedMetrics("10", `
+					cortex_query_scheduler_tenant_oldest_queued_request_age_seconds{user="user-1"} 10
+					cortex_query_scheduler_tenant_oldest_queued_request_age_seconds{user="user-2"} 2
+			`)), metricNames...))
+
+			// The age of the tenant is reset once it has no more requests waiting in the queue.
+			delete(scheduler.pendingRequests, requestKey{frontendAddr: "frontend-2", queryID: 1})
+
+			queuedUsers = scheduler.updateOldestQueuedRequestAge(now, queuedUsers)
+			if tenantMetricsEnabled {
+				assert.Equal(t, map[string]struct{}{"user-1": {}}, queuedUsers)
+			}
+			require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(expectedMetrics("10", `
+					cortex_query_scheduler_tenant_oldest_queued_request_age_seconds{user="user-1"} 10
+					cortex_query_scheduler_tenant_oldest_queued_request_age_seconds{user="user-2"} 0
+			`)), metricNames...))
+
+			// The age is reset once no request is waiting in the queue.
+			scheduler.pendingRequests = map[requestKey]*schedulerRequest{}
+
+			queuedUsers = scheduler.updateOldestQueuedRequestAge(now, queuedUsers)
+			assert.Empty(t, queuedUsers)
+			require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(expectedMetrics("0", `
+					cortex_query_scheduler_tenant_oldest_queued_request_age_seconds{user="user-1"} 0
+					cortex_query_scheduler_tenant_oldest_queued_request_age_seconds{user="user-2"} 0
+			`)), metricNames...))
+		})
+	}
+}
+
 func TestSchedulerEnqueueWithCancel(t *testing.T) {
 	scheduler, frontendClient, querierClient := setupScheduler(t, nil)
 
@@ -319,6 +549,78 @@ func TestSchedulerShutdown_QuerierLoop(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestSchedulerQuerierDraining(t *testing.T) {
+	t.Run("confirms the stream has been drained while waiting for the next request", func(t *testing.T) {
+		scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{Type: schedulerpb.DRAINING}))
+
+		msg, err := querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, schedulerpb.DRAINED, msg.Type)
+
+		// Requests enqueued after the stream has been drained are not dispatched to it.
+		frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     1,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		})
+
+		_, err = querierLoop.Recv()
+		require.Equal(t, io.EOF, err)
+
+		verifyQuerierReceivesRequest(t, initQuerierLoop(t, querierClient, "querier-2"), 1)
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+
+	t.Run("confirms the stream has been drained once the inflight request has completed", func(t *testing.T) {
+		scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+		frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     1,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		})
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     2,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/world"},
+		})
+
+		querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+		msg, err := querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), msg.QueryID)
+
+		// The querier asks to drain the stream while running the request.
+		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{Type: schedulerpb.DRAINING}))
+		require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+		msg, err = querierLoop.Recv()
+		require.NoError(t, err)
+		require.Equal(t, schedulerpb.DRAINED, msg.Type)
+
+		// The other request is left to other queriers.
+		verifyQuerierReceivesRequest(t, initQuerierLoop(t, querierClient, "querier-2"), 2)
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+}
+
+func verifyQuerierReceivesRequest(t *testing.T, querierLoop schedulerpb.SchedulerForQuerier_QuerierLoopClient, queryID uint64) {
+	msg, err := querierLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, schedulerpb.QUERY, msg.Type)
+	require.Equal(t, queryID, msg.QueryID)
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+}
+
 func TestSchedulerMaxOutstandingRequests(t *testing.T) {
 	_, frontendClient, _ := setupScheduler(t, nil)
 
@@ -351,6 +653,294 @@ func TestSchedulerMaxOutstandingRequests(t *testing.T) {
 	require.Equal(t, schedulerpb.TOO_MANY_REQUESTS_PER_TENANT, msg.Status)
 }
 
+func TestSchedulerQueryRateLimit(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	_, frontendClient, _ := setupSchedulerWithLimits(t, reg, &limits{queryRateLimit: 0.001, queryBurstSize: 2})
+
+	// The burst is allowed, regardless of the frontend the queries come from.
+	require.Equal(t, schedulerpb.OK, enqueueAndGetStatus(t, initFrontendLoop(t, frontendClient, "frontend-1"), "user-1", 1))
+	require.Equal(t, schedulerpb.OK, enqueueAndGetStatus(t, initFrontendLoop(t, frontendClient, "frontend-2"), "user-1", 2))
+
+	// Queries above the limit are rejected.
+	require.Equal(t, schedulerpb.TOO_MANY_REQUESTS_PER_TENANT, enqueueAndGetStatus(t, initFrontendLoop(t, frontendClient, "frontend-3"), "user-1", 3))
+
+	// Other tenants are not affected.
+	require.Equal(t, schedulerpb.OK, enqueueAndGetStatus(t, initFrontendLoop(t, frontendClient, "frontend-3"), "user-2", 4))
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_rejected_requests_total Total number of query requests rejected because the tenant exceeded the query rate limit or the max concurrent queries.
+		# TYPE cortex_query_scheduler_rejected_requests_total counter
+		cortex_query_scheduler_rejected_requests_total{reason="rate_limited",user="user-1"} 1
+	`), "cortex_query_scheduler_rejected_requests_total"))
+}
+
+func TestSchedulerMaxConcurrentQueries(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	scheduler, frontendClient, _ := setupSchedulerWithLimits(t, reg, &limits{maxConcurrentQueries: 2})
+
+	fl1 := initFrontendLoop(t, frontendClient, "frontend-1")
+	fl2 := initFrontendLoop(t, frontendClient, "frontend-2")
+	require.Equal(t, schedulerpb.OK, enqueueAndGetStatus(t, fl1, "user-1", 1))
+	require.Equal(t, schedulerpb.OK, enqueueAndGetStatus(t, fl2, "user-1", 2))
+
+	// Queries above the limit are rejected, regardless of the frontend they come from.
+	require.Equal(t, schedulerpb.TOO_MANY_REQUESTS_PER_TENANT, enqueueAndGetStatus(t, fl1, "user-1", 3))
+	require.Equal(t, schedulerpb.TOO_MANY_REQUESTS_PER_TENANT, enqueueAndGetStatus(t, fl2, "user-1", 4))
+
+	// Other tenants are not affected.
+	require.Equal(t, schedulerpb.OK, enqueueAndGetStatus(t, fl1, "user-2", 5))
+
+	// Once a query is canceled, a new one can be enqueued.
+	require.NoError(t, fl1.Send(&schedulerpb.FrontendToScheduler{Type: schedulerpb.CANCEL, QueryID: 1}))
+	msg, err := fl1.Recv()
+	require.NoError(t, err)
+	require.Equal(t, schedulerpb.OK, msg.Status)
+
+	require.Equal(t, schedulerpb.OK, enqueueAndGetStatus(t, fl1, "user-1", 6))
+
+	scheduler.pendingRequestsMu.Lock()
+	require.Equal(t, map[string]int{"user-1": 2, "user-2": 1}, scheduler.pendingRequestsPerUser)
+	scheduler.pendingRequestsMu.Unlock()
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_rejected_requests_total Total number of query requests rejected because the tenant exceeded the query rate limit or the max concurrent queries.
+		# TYPE cortex_query_scheduler_rejected_requests_total counter
+		cortex_query_scheduler_rejected_requests_total{reason="max_concurrent_queries",user="user-1"} 2
+	`), "cortex_query_scheduler_rejected_requests_total"))
+}
+
+func enqueueAndGetStatus(t *testing.T, fl schedulerpb.SchedulerForFrontend_FrontendLoopClient, userID string, queryID uint64) schedulerpb.SchedulerToFrontendStatus {
+	require.NoError(t, fl.Send(&schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     queryID,
+		UserID:      userID,
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+	}))
+
+	msg, err := fl.Recv()
+	require.NoError(t, err)
+	return msg.Status
+}
+
+func TestSchedulerMaxQueueWaitTime(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	scheduler, frontendClient, querierClient := setupSchedulerWithLimits(t, reg, &limits{queriers: 2, maxQueueWaitTime: 100 * time.Millisecond})
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     1,
+		UserID:      "test",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+	})
+
+	// No querier is connected, so the request stays in the queue until the scheduler notifies the frontend.
+	msg, err := frontendLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, schedulerpb.TOO_LONG_IN_QUEUE, msg.Status)
+	require.Equal(t, uint64(1), msg.QueryID)
+
+	verifyNoPendingRequestsLeft(t, scheduler)
+
+	// The expired request must not be dispatched to a querier.
+	querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+	verifyQuerierDoesntReceiveRequest(t, querierLoop, 500*time.Millisecond)
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_expired_requests_total Total number of query requests removed from the queue because they waited longer than the max queue wait time.
+		# TYPE cortex_query_scheduler_expired_requests_total counter
+		cortex_query_scheduler_expired_requests_total{user="test"} 1
+	`), "cortex_query_scheduler_expired_requests_total"))
+}
+
+func TestSchedulerMaxQueryExecutionTime(t *testing.T) {
+	for name, maxExecutionTime := range map[string]time.Duration{"disabled": 0, "enabled": time.Minute} {
+		t.Run(name, func(t *testing.T) {
+			scheduler, frontendClient, querierClient := setupSchedulerWithLimits(t, nil, &limits{queriers: 2, maxExecutionTime: maxExecutionTime})
+
+			frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+			frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+				Type:        schedulerpb.ENQUEUE,
+				QueryID:     1,
+				UserID:      "test",
+				HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+			})
+
+			beforeDispatch := time.Now()
+			querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+			msg, err := querierLoop.Recv()
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), msg.QueryID)
+
+			// The deadline is computed from the time the request has been dispatched to the querier.
+			if maxExecutionTime == 0 {
+				require.Zero(t, msg.Deadline)
+			} else {
+				deadline := time.Unix(0, msg.Deadline)
+				require.False(t, deadline.Before(beforeDispatch.Add(maxExecutionTime)))
+				require.False(t, deadline.After(time.Now().Add(maxExecutionTime)))
+			}
+			require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+			verifyNoPendingRequestsLeft(t, scheduler)
+		})
+	}
+}
+
+func TestSchedulerQuerierBackpressure(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.MaxOutstandingPerTenant = testMaxOutstandingPerTenant
+	cfg.QuerierMaxInflightQueries = 2
+	cfg.QuerierBackpressureMaxDelay = time.Minute
+
+	reg := prometheus.NewPedanticRegistry()
+	scheduler, frontendClient, querierClient := setupSchedulerWithConfig(t, reg, cfg, &limits{queriers: 2})
+
+	// The first querier reports it has no capacity left.
+	busyQuerierLoop, err := querierClient.QuerierLoop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, busyQuerierLoop.Send(&schedulerpb.QuerierToScheduler{QuerierID: "querier-1", Capacity: &schedulerpb.QuerierCapacity{InflightQueries: 2}}))
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     1,
+		UserID:      "test",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+	})
+
+	verifyQuerierDoesntReceiveRequest(t, busyQuerierLoop, 500*time.Millisecond)
+
+	// The second querier has capacity, so the query is dispatched to it.
+	querierLoop, err := querierClient.QuerierLoop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{QuerierID: "querier-2", Capacity: &schedulerpb.QuerierCapacity{InflightQueries: 1}}))
+
+	msg, err := querierLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), msg.QueryID)
+	require.NoError(t, querierLoop.Send(&schedulerpb.QuerierToScheduler{Capacity: &schedulerpb.QuerierCapacity{InflightQueries: 1}}))
+
+	verifyNoPendingRequestsLeft(t, scheduler)
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_querier_backpressure_waits_total Total number of times the query-scheduler held back the dispatching of a query because the querier reported it had no capacity.
+		# TYPE cortex_query_scheduler_querier_backpressure_waits_total counter
+		cortex_query_scheduler_querier_backpressure_waits_total 1
+	`), "cortex_query_scheduler_querier_backpressure_waits_total"))
+}
+
+func TestSchedulerHighCostQueries(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.MaxOutstandingPerTenant = testMaxOutstandingPerTenant
+	cfg.HighCostQuerySeriesThreshold = 1000
+	cfg.MaxHighCostQueriesPerQuerier = 1
+
+	scheduler, frontendClient, querierClient := setupSchedulerWithConfig(t, nil, cfg, &limits{queriers: 2})
+
+	firstQuerierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	for queryID := uint64(1); queryID <= 2; queryID++ {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:                 schedulerpb.ENQUEUE,
+			QueryID:              queryID,
+			UserID:               "test",
+			HttpRequest:          &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+			EstimatedSeriesCount: 5000,
+		})
+	}
+
+	// The querier runs only one high-cost query at once.
+	msg, err := firstQuerierLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), msg.QueryID)
+
+	secondQuerierLoop := initQuerierLoop(t, querierClient, "querier-1")
+	verifyQuerierDoesntReceiveRequest(t, secondQuerierLoop, 500*time.Millisecond)
+
+	// Another querier picks up the second high-cost query in the meanwhile.
+	otherQuerierLoop := initQuerierLoop(t, querierClient, "querier-2")
+
+	msg, err = otherQuerierLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), msg.QueryID)
+
+	require.NoError(t, firstQuerierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+	require.NoError(t, otherQuerierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+	verifyNoPendingRequestsLeft(t, scheduler)
+	assert.GreaterOrEqual(t, promtest.ToFloat64(scheduler.deferredHighCostRequests.WithLabelValues("test")), 1.0)
+}
+
+func TestSchedulerQuerierPools(t *testing.T) {
+	scheduler, frontendClient, querierClient := setupSchedulerWithLimits(t, nil, &limits{querierPools: map[string]string{"noisy": "reserved"}})
+
+	sharedQuerierLoop := initQuerierLoop(t, querierClient, "querier-1")
+
+	reservedQuerierLoop, err := querierClient.QuerierLoop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, reservedQuerierLoop.Send(&schedulerpb.QuerierToScheduler{QuerierID: "querier-2", QuerierPool: "reserved"}))
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:        schedulerpb.ENQUEUE,
+		QueryID:     1,
+		UserID:      "noisy",
+		HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+	})
+
+	// The query of the tenant assigned to the reserved pool is dispatched only to the querier in that pool.
+	verifyQuerierDoesntReceiveRequest(t, sharedQuerierLoop, 500*time.Millisecond)
+
+	msg, err := reservedQuerierLoop.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), msg.QueryID)
+	require.NoError(t, reservedQuerierLoop.Send(&schedulerpb.QuerierToScheduler{}))
+
+	verifyNoPendingRequestsLeft(t, scheduler)
+}
+
+func TestQuerierPoolForTenants(t *testing.T) {
+	pools := map[string]string{"tenant-a": "pool-1", "tenant-b": "pool-1", "tenant-c": "pool-2"}
+	poolFn := func(tenantID string) string { return pools[tenantID] }
+
+	tests := map[string]struct {
+		tenantIDs []string
+		expected  string
+	}{
+		"single tenant assigned to no pool": {
+			tenantIDs: []string{"tenant-d"},
+			expected:  "",
+		},
+		"single tenant assigned to a pool": {
+			tenantIDs: []string{"tenant-a"},
+			expected:  "pool-1",
+		},
+		"multiple tenants assigned to the same pool": {
+			tenantIDs: []string{"tenant-a", "tenant-b"},
+			expected:  "pool-1",
+		},
+		"multiple tenants assigned to different pools": {
+			tenantIDs: []string{"tenant-a", "tenant-c"},
+			expected:  "",
+		},
+		"multiple tenants, some assigned to no pool": {
+			tenantIDs: []string{"tenant-a", "tenant-d"},
+			expected:  "",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			require.Equal(t, testData.expected, querierPoolForTenants(testData.tenantIDs, poolFn))
+		})
+	}
+}
+
 func TestSchedulerForwardsErrorToFrontend(t *testing.T) {
 	_, frontendClient, querierClient := setupScheduler(t, nil)
 
@@ -500,13 +1090,54 @@ func verifyNoPendingRequestsLeft(t *testing.T, scheduler *Scheduler) {
 }
 
 type limits struct {
-	queriers int
+	queriers         int
+	minQueriers      int
+	targetQueryRate  float64
+	maxQueueWaitTime time.Duration
+	maxExecutionTime time.Duration
+	querierPools     map[string]string
+
+	queryRateLimit       float64
+	queryBurstSize       int
+	maxConcurrentQueries int
 }
 
 func (l limits) MaxQueriersPerUser(_ string) int {
 	return l.queriers
 }
 
+func (l limits) MaxQueueWaitTime(_ string) time.Duration {
+	return l.maxQueueWaitTime
+}
+
+func (l limits) MaxQueryExecutionTime(_ string) time.Duration {
+	return l.maxExecutionTime
+}
+
+func (l limits) QuerierPool(user string) string {
+	return l.querierPools[user]
+}
+
+func (l limits) MinQueriersPerUser(_ string) int {
+	return l.minQueriers
+}
+
+func (l limits) TargetQueriesPerSecondPerQuerier(_ string) float64 {
+	return l.targetQueryRate
+}
+
+func (l limits) QueryRateLimit(_ string) float64 {
+	return l.queryRateLimit
+}
+
+func (l limits) QueryBurstSize(_ string) int {
+	return l.queryBurstSize
+}
+
+func (l limits) MaxConcurrentQueries(_ string) int {
+	return l.maxConcurrentQueries
+}
+
 type frontendMock struct {
 	mu   sync.Mutex
 	resp map[uint64]*httpgrpc.HTTPResponse
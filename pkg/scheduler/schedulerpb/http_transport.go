@@ -0,0 +1,339 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schedulerpb
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// FrontendLoopHTTPPath is the path of the FrontendLoop stream, when the query-frontend connects to the
+	// query-scheduler over plain HTTP/2 instead of gRPC.
+	FrontendLoopHTTPPath = "/schedulerpb.SchedulerForFrontend/FrontendLoop"
+
+	frontendLoopHTTPContentType = "application/x-protobuf-stream"
+
+	// frontendLoopHTTPErrorTrailer is the trailer carrying the error the FrontendLoop stream has been closed with.
+	frontendLoopHTTPErrorTrailer = "Frontend-Loop-Error"
+
+	// maxFrontendLoopHTTPMessageSize is the max size of a message sent over the FrontendLoop stream, matching the
+	// default max size of the messages received by the gRPC server.
+	maxFrontendLoopHTTPMessageSize = 100 << 20
+
+	// http2PriorKnowledgePreface is the remainder of the HTTP/2 connection preface, once its first line has been
+	// parsed as a HTTP/1 request by the HTTP server.
+	http2PriorKnowledgePreface = "SM\r\n\r\n"
+)
+
+// The messages are sent over the HTTP/2 stream as protobuf messages prefixed by their size, as 4 bytes big endian.
+func writeFrame(w io.Writer, msg proto.Marshaler) error {
+	data, err := msg.Marshal()
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+
+	_, err = w.Write(buf)
+	return err
+}
+
+func readFrame(r io.Reader, msg proto.Unmarshaler) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrontendLoopHTTPMessageSize {
+		return fmt.Errorf("received message larger than max (%d vs. %d)", n, maxFrontendLoopHTTPMessageSize)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return msg.Unmarshal(data)
+}
+
+// NewFrontendLoopH2CHandler returns a handler serving the FrontendLoop stream of the input server over the HTTP/2
+// connections opened with prior knowledge (h2c), for the query-frontends which can't connect to the query-scheduler
+// over gRPC. All the other requests are passed to the next handler.
+func NewFrontendLoopH2CHandler(next http.Handler, srv SchedulerForFrontendServer) http.Handler {
+	h2Server := &http2.Server{}
+
+	mux := http.NewServeMux()
+	mux.Handle(FrontendLoopHTTPPath, frontendLoopHTTPHandler(srv))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first line of the HTTP/2 connection preface is parsed by the HTTP server as a PRI request.
+		if r.Method != "PRI" || r.URL.Path != "*" || r.Proto != "HTTP/2.0" || len(r.Header) != 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		conn, err := hijackH2CConn(w)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer conn.Close()
+
+		// The connection is long-lived, so the deadlines set by the HTTP server are removed.
+		_ = conn.SetDeadline(time.Time{})
+
+		h2Server.ServeConn(conn, &http2.ServeConnOpts{
+			Context:          r.Context(),
+			Handler:          mux,
+			SawClientPreface: true,
+		})
+	})
+}
+
+func hijackH2CConn(w http.ResponseWriter) (net.Conn, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("h2c: the connection can't be hijacked")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	preface := make([]byte, len(http2PriorKnowledgePreface))
+	if _, err := io.ReadFull(rw, preface); err != nil || string(preface) != http2PriorKnowledgePreface {
+		conn.Close()
+		return nil, errors.New("h2c: invalid client preface")
+	}
+
+	// The data already buffered by the HTTP server is read before the connection.
+	return &bufferedConn{Conn: conn, r: rw.Reader}, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func frontendLoopHTTPHandler(srv SchedulerForFrontendServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.ProtoMajor != 2 {
+			http.Error(w, "the FrontendLoop stream requires a HTTP/2 POST request", http.StatusBadRequest)
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "the FrontendLoop stream requires a flushable response", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", frontendLoopHTTPContentType)
+		w.Header().Set("Trailer", frontendLoopHTTPErrorTrailer)
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		err := srv.FrontendLoop(&frontendLoopHTTPServer{ctx: r.Context(), body: r.Body, w: w, flusher: flusher})
+		if err != nil {
+			w.Header().Set(frontendLoopHTTPErrorTrailer, err.Error())
+		}
+	})
+}
+
+// frontendLoopHTTPServer implements SchedulerForFrontend_FrontendLoopServer over a HTTP/2 stream.
+type frontendLoopHTTPServer struct {
+	ctx     context.Context
+	body    io.Reader
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (s *frontendLoopHTTPServer) Send(msg *SchedulerToFrontend) error {
+	if err := writeFrame(s.w, msg); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *frontendLoopHTTPServer) Recv() (*FrontendToScheduler, error) {
+	msg := &FrontendToScheduler{}
+	if err := readFrame(s.body, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (s *frontendLoopHTTPServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *frontendLoopHTTPServer) SendMsg(m interface{}) error {
+	msg, ok := m.(*SchedulerToFrontend)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+	return s.Send(msg)
+}
+
+func (s *frontendLoopHTTPServer) RecvMsg(m interface{}) error {
+	msg, ok := m.(*FrontendToScheduler)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+	return readFrame(s.body, msg)
+}
+
+func (s *frontendLoopHTTPServer) SetHeader(metadata.MD) error  { return nil }
+func (s *frontendLoopHTTPServer) SendHeader(metadata.MD) error { return nil }
+func (s *frontendLoopHTTPServer) SetTrailer(metadata.MD)       {}
+
+// SchedulerForFrontendHTTPClient is a SchedulerForFrontendClient connecting to a query-scheduler over plain
+// HTTP/2 (h2c), for the environments where gRPC is blocked between the query-frontends and the query-schedulers.
+// The messages sent over the FrontendLoop stream are identical to the ones sent over gRPC.
+type SchedulerForFrontendHTTPClient struct {
+	address   string
+	transport *http2.Transport
+}
+
+// NewSchedulerForFrontendHTTPClient returns a client connecting to the HTTP server of the query-scheduler at the
+// input address, in host:port format.
+func NewSchedulerForFrontendHTTPClient(address string) *SchedulerForFrontendHTTPClient {
+	return &SchedulerForFrontendHTTPClient{
+		address: address,
+		transport: &http2.Transport{
+			// Allow the http scheme, and dial plain TCP connections instead of TLS ones.
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+}
+
+// FrontendLoop implements SchedulerForFrontendClient. The stream is closed once the input context is canceled.
+func (c *SchedulerForFrontendHTTPClient) FrontendLoop(ctx context.Context, _ ...grpc.CallOption) (SchedulerForFrontend_FrontendLoopClient, error) {
+	body, bodyWriter := io.Pipe()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+c.address+FrontendLoopHTTPPath, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", frontendLoopHTTPContentType)
+
+	// The response headers are sent by the query-scheduler as soon as the stream is opened.
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		_ = bodyWriter.CloseWithError(err)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		_ = bodyWriter.Close()
+		return nil, fmt.Errorf("unexpected status code %d opening the FrontendLoop stream: %s", resp.StatusCode, msg)
+	}
+
+	return &frontendLoopHTTPClient{ctx: ctx, resp: resp, bodyWriter: bodyWriter}, nil
+}
+
+// Close closes the idle connections to the query-scheduler.
+func (c *SchedulerForFrontendHTTPClient) Close() error {
+	c.transport.CloseIdleConnections()
+	return nil
+}
+
+// frontendLoopHTTPClient implements SchedulerForFrontend_FrontendLoopClient over a HTTP/2 stream.
+type frontendLoopHTTPClient struct {
+	ctx        context.Context
+	resp       *http.Response
+	bodyWriter *io.PipeWriter
+
+	// Send may be called concurrently with CloseSend.
+	sendMtx sync.Mutex
+}
+
+func (c *frontendLoopHTTPClient) Send(msg *FrontendToScheduler) error {
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	return writeFrame(c.bodyWriter, msg)
+}
+
+func (c *frontendLoopHTTPClient) Recv() (*SchedulerToFrontend, error) {
+	msg := &SchedulerToFrontend{}
+	if err := c.RecvMsg(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (c *frontendLoopHTTPClient) CloseSend() error {
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+
+	return c.bodyWriter.Close()
+}
+
+func (c *frontendLoopHTTPClient) Context() context.Context {
+	return c.ctx
+}
+
+func (c *frontendLoopHTTPClient) SendMsg(m interface{}) error {
+	msg, ok := m.(*FrontendToScheduler)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+	return c.Send(msg)
+}
+
+func (c *frontendLoopHTTPClient) RecvMsg(m interface{}) error {
+	msg, ok := m.(*SchedulerToFrontend)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+
+	err := readFrame(c.resp.Body, msg)
+	if errors.Is(err, io.EOF) {
+		// The trailers are only available once the response body has been fully read.
+		_ = c.resp.Body.Close()
+		if trailerErr := c.resp.Trailer.Get(frontendLoopHTTPErrorTrailer); trailerErr != "" {
+			return errors.New(trailerErr)
+		}
+	}
+	return err
+}
+
+func (c *frontendLoopHTTPClient) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+func (c *frontendLoopHTTPClient) Trailer() metadata.MD {
+	return metadata.MD{}
+}
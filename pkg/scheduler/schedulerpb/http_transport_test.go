@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package schedulerpb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrontendLoopH2CHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("next"))
+	})
+	server := httptest.NewServer(NewFrontendLoopH2CHandler(next, frontendLoopFunc(func(frontend SchedulerForFrontend_FrontendLoopServer) error {
+		msg, err := frontend.Recv()
+		if err != nil {
+			return err
+		}
+		if err := frontend.Send(&SchedulerToFrontend{Status: OK, QueryID: msg.QueryID}); err != nil {
+			return err
+		}
+		return errors.New("scheduler is shutting down")
+	})))
+	t.Cleanup(server.Close)
+
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	// The requests not opening an HTTP/2 connection with prior knowledge are passed to the next handler.
+	resp, err := http.Get(server.URL + FrontendLoopHTTPPath)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	client := NewSchedulerForFrontendHTTPClient(address)
+	t.Cleanup(func() { _ = client.Close() })
+
+	loop, err := client.FrontendLoop(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, loop.Send(&FrontendToScheduler{Type: ENQUEUE, QueryID: 1}))
+	msg, err := loop.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, &SchedulerToFrontend{Status: OK, QueryID: 1}, msg)
+
+	// The error the stream has been closed with by the query-scheduler is returned to the query-frontend.
+	_, err = loop.Recv()
+	require.EqualError(t, err, "scheduler is shutting down")
+	require.NoError(t, loop.CloseSend())
+}
+
+type frontendLoopFunc func(SchedulerForFrontend_FrontendLoopServer) error
+
+func (f frontendLoopFunc) FrontendLoop(frontend SchedulerForFrontend_FrontendLoopServer) error {
+	return f(frontend)
+}
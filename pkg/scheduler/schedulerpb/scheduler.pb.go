@@ -31,6 +31,53 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+type QuerierToSchedulerType int32
+
+const (
+	READY QuerierToSchedulerType = 0
+	// Sent by the querier, without waiting for the next request, when it wants to close the stream (eg. because it's
+	// shutting down). The scheduler stops dispatching requests on the stream and, once the request running on it (if any)
+	// has completed, replies with a DRAINED message. The querier keeps running the requests received until then.
+	DRAINING QuerierToSchedulerType = 1
+)
+
+var QuerierToSchedulerType_name = map[int32]string{
+	0: "READY",
+	1: "DRAINING",
+}
+
+var QuerierToSchedulerType_value = map[string]int32{
+	"READY":    0,
+	"DRAINING": 1,
+}
+
+func (QuerierToSchedulerType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{0}
+}
+
+type SchedulerToQuerierType int32
+
+const (
+	QUERY SchedulerToQuerierType = 0
+	// Sent by the scheduler in reply to a DRAINING message, once no more requests will be dispatched on the stream.
+	// The querier can safely close the stream after receiving it.
+	DRAINED SchedulerToQuerierType = 1
+)
+
+var SchedulerToQuerierType_name = map[int32]string{
+	0: "QUERY",
+	1: "DRAINED",
+}
+
+var SchedulerToQuerierType_value = map[string]int32{
+	"QUERY":   0,
+	"DRAINED": 1,
+}
+
+func (SchedulerToQuerierType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{1}
+}
+
 type FrontendToSchedulerType int32
 
 const (
@@ -52,7 +99,7 @@ var FrontendToSchedulerType_value = map[string]int32{
 }
 
 func (FrontendToSchedulerType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_2b3fc28395a6d9c5, []int{0}
+	return fileDescriptor_2b3fc28395a6d9c5, []int{2}
 }
 
 type SchedulerToFrontendStatus int32
@@ -62,6 +109,12 @@ const (
 	TOO_MANY_REQUESTS_PER_TENANT SchedulerToFrontendStatus = 1
 	ERROR                        SchedulerToFrontendStatus = 2
 	SHUTTING_DOWN                SchedulerToFrontendStatus = 3
+	// Sent by the scheduler without a preceding request from the frontend, when an enqueued
+	// request has been waiting in the queue longer than the tenant's max queue wait time.
+	TOO_LONG_IN_QUEUE SchedulerToFrontendStatus = 4
+	// Sent by the scheduler without a preceding request from the frontend, when an enqueued
+	// request has been dropped from the queue by an operator.
+	DROPPED_FROM_QUEUE SchedulerToFrontendStatus = 5
 )
 
 var SchedulerToFrontendStatus_name = map[int32]string{
@@ -69,6 +122,8 @@ var SchedulerToFrontendStatus_name = map[int32]string{
 	1: "TOO_MANY_REQUESTS_PER_TENANT",
 	2: "ERROR",
 	3: "SHUTTING_DOWN",
+	4: "TOO_LONG_IN_QUEUE",
+	5: "DROPPED_FROM_QUEUE",
 }
 
 var SchedulerToFrontendStatus_value = map[string]int32{
@@ -76,16 +131,27 @@ var SchedulerToFrontendStatus_value = map[string]int32{
 	"TOO_MANY_REQUESTS_PER_TENANT": 1,
 	"ERROR":                        2,
 	"SHUTTING_DOWN":                3,
+	"TOO_LONG_IN_QUEUE":            4,
+	"DROPPED_FROM_QUEUE":           5,
 }
 
 func (SchedulerToFrontendStatus) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_2b3fc28395a6d9c5, []int{1}
+	return fileDescriptor_2b3fc28395a6d9c5, []int{3}
 }
 
 // Querier reports its own clientID when it connects, so that scheduler knows how many *different* queriers are connected.
-// To signal that querier is ready to accept another request, querier sends empty message.
+// To signal that querier is ready to accept another request, querier sends a message with no querierID.
 type QuerierToScheduler struct {
 	QuerierID string `protobuf:"bytes,1,opt,name=querierID,proto3" json:"querierID,omitempty"`
+	// Capacity of the querier at the time the message was sent. It's sent both when the querier connects and each
+	// time it signals it's ready to accept another request. Queriers not reporting it are assumed to have capacity.
+	Capacity *QuerierCapacity `protobuf:"bytes,2,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// Pool the querier belongs to, sent when the querier connects. Queriers in a pool only run the queries of the
+	// tenants assigned to it. Empty if the querier belongs to no pool.
+	QuerierPool string `protobuf:"bytes,3,opt,name=querierPool,proto3" json:"querierPool,omitempty"`
+	// Type of the message. Messages sent when the querier connects and when it's ready to accept another request
+	// are of type READY.
+	Type QuerierToSchedulerType `protobuf:"varint,4,opt,name=type,proto3,enum=schedulerpb.QuerierToSchedulerType" json:"type,omitempty"`
 }
 
 func (m *QuerierToScheduler) Reset()      { *m = QuerierToScheduler{} }
@@ -127,6 +193,81 @@ func (m *QuerierToScheduler) GetQuerierID() string {
 	return ""
 }
 
+func (m *QuerierToScheduler) GetCapacity() *QuerierCapacity {
+	if m != nil {
+		return m.Capacity
+	}
+	return nil
+}
+
+func (m *QuerierToScheduler) GetQuerierPool() string {
+	if m != nil {
+		return m.QuerierPool
+	}
+	return ""
+}
+
+func (m *QuerierToScheduler) GetType() QuerierToSchedulerType {
+	if m != nil {
+		return m.Type
+	}
+	return READY
+}
+
+type QuerierCapacity struct {
+	// Number of queries currently executing in the querier, across all query-schedulers it's connected to.
+	InflightQueries uint32 `protobuf:"varint,1,opt,name=inflightQueries,proto3" json:"inflightQueries,omitempty"`
+	// Memory the querier can still allocate before reaching its memory limit. When the querier has no memory limit,
+	// the headroom is the max uint64 value.
+	MemoryHeadroomBytes uint64 `protobuf:"varint,2,opt,name=memoryHeadroomBytes,proto3" json:"memoryHeadroomBytes,omitempty"`
+}
+
+func (m *QuerierCapacity) Reset()      { *m = QuerierCapacity{} }
+func (*QuerierCapacity) ProtoMessage() {}
+func (*QuerierCapacity) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{1}
+}
+func (m *QuerierCapacity) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuerierCapacity) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuerierCapacity.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QuerierCapacity) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuerierCapacity.Merge(m, src)
+}
+func (m *QuerierCapacity) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuerierCapacity) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuerierCapacity.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuerierCapacity proto.InternalMessageInfo
+
+func (m *QuerierCapacity) GetInflightQueries() uint32 {
+	if m != nil {
+		return m.InflightQueries
+	}
+	return 0
+}
+
+func (m *QuerierCapacity) GetMemoryHeadroomBytes() uint64 {
+	if m != nil {
+		return m.MemoryHeadroomBytes
+	}
+	return 0
+}
+
 type SchedulerToQuerier struct {
 	// Query ID as reported by frontend. When querier sends the response back to frontend (using frontendAddress),
 	// it identifies the query by using this ID.
@@ -139,12 +280,23 @@ type SchedulerToQuerier struct {
 	// Whether query statistics tracking should be enabled. The response will include
 	// statistics only when this option is enabled.
 	StatsEnabled bool `protobuf:"varint,5,opt,name=statsEnabled,proto3" json:"statsEnabled,omitempty"`
+	// Random nonce generated by the frontend for the query. Querier must send it back to the frontend
+	// together with the query result.
+	Nonce uint64 `protobuf:"varint,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// Identical queries of the same user, enqueued by frontends while this query was waiting in the queue,
+	// which have been deduplicated into this one. Querier must send the query result to each of them too.
+	AdditionalTargets []*QueryTarget `protobuf:"bytes,7,rep,name=additionalTargets,proto3" json:"additionalTargets,omitempty"`
+	// Type of the message. Only messages of type QUERY carry a request.
+	Type SchedulerToQuerierType `protobuf:"varint,8,opt,name=type,proto3,enum=schedulerpb.SchedulerToQuerierType" json:"type,omitempty"`
+	// Deadline of the query execution in the querier, as Unix nanoseconds, or 0 if there's no deadline.
+	// Set by the scheduler from the max query execution time of the tenant.
+	Deadline int64 `protobuf:"varint,9,opt,name=deadline,proto3" json:"deadline,omitempty"`
 }
 
 func (m *SchedulerToQuerier) Reset()      { *m = SchedulerToQuerier{} }
 func (*SchedulerToQuerier) ProtoMessage() {}
 func (*SchedulerToQuerier) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2b3fc28395a6d9c5, []int{1}
+	return fileDescriptor_2b3fc28395a6d9c5, []int{2}
 }
 func (m *SchedulerToQuerier) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -208,6 +360,94 @@ func (m *SchedulerToQuerier) GetStatsEnabled() bool {
 	return false
 }
 
+func (m *SchedulerToQuerier) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *SchedulerToQuerier) GetAdditionalTargets() []*QueryTarget {
+	if m != nil {
+		return m.AdditionalTargets
+	}
+	return nil
+}
+
+func (m *SchedulerToQuerier) GetType() SchedulerToQuerierType {
+	if m != nil {
+		return m.Type
+	}
+	return QUERY
+}
+
+func (m *SchedulerToQuerier) GetDeadline() int64 {
+	if m != nil {
+		return m.Deadline
+	}
+	return 0
+}
+
+// QueryTarget identifies a query enqueued by a frontend, the query result should be sent to.
+type QueryTarget struct {
+	QueryID         uint64 `protobuf:"varint,1,opt,name=queryID,proto3" json:"queryID,omitempty"`
+	FrontendAddress string `protobuf:"bytes,2,opt,name=frontendAddress,proto3" json:"frontendAddress,omitempty"`
+	Nonce           uint64 `protobuf:"varint,3,opt,name=nonce,proto3" json:"nonce,omitempty"`
+}
+
+func (m *QueryTarget) Reset()      { *m = QueryTarget{} }
+func (*QueryTarget) ProtoMessage() {}
+func (*QueryTarget) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2b3fc28395a6d9c5, []int{3}
+}
+func (m *QueryTarget) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryTarget) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryTarget.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryTarget) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryTarget.Merge(m, src)
+}
+func (m *QueryTarget) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryTarget) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryTarget.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryTarget proto.InternalMessageInfo
+
+func (m *QueryTarget) GetQueryID() uint64 {
+	if m != nil {
+		return m.QueryID
+	}
+	return 0
+}
+
+func (m *QueryTarget) GetFrontendAddress() string {
+	if m != nil {
+		return m.FrontendAddress
+	}
+	return ""
+}
+
+func (m *QueryTarget) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
 type FrontendToScheduler struct {
 	Type FrontendToSchedulerType `protobuf:"varint,1,opt,name=type,proto3,enum=schedulerpb.FrontendToSchedulerType" json:"type,omitempty"`
 	// Used by INIT message. Will be put into all requests passed to querier.
@@ -219,12 +459,18 @@ type FrontendToScheduler struct {
 	UserID       string                `protobuf:"bytes,4,opt,name=userID,proto3" json:"userID,omitempty"`
 	HttpRequest  *httpgrpc.HTTPRequest `protobuf:"bytes,5,opt,name=httpRequest,proto3" json:"httpRequest,omitempty"`
 	StatsEnabled bool                  `protobuf:"varint,6,opt,name=statsEnabled,proto3" json:"statsEnabled,omitempty"`
+	// Random nonce generated by the frontend for the query, which the querier must send back together
+	// with the query result. Used to reject results for queries which weren't dispatched to the querier.
+	Nonce uint64 `protobuf:"varint,7,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// Estimated number of series the query touches, or 0 if unknown. Used by the scheduler to
+	// avoid dispatching multiple high-cost queries to the same querier at once.
+	EstimatedSeriesCount uint64 `protobuf:"varint,8,opt,name=estimatedSeriesCount,proto3" json:"estimatedSeriesCount,omitempty"`
 }
 
 func (m *FrontendToScheduler) Reset()      { *m = FrontendToScheduler{} }
 func (*FrontendToScheduler) ProtoMessage() {}
 func (*FrontendToScheduler) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2b3fc28395a6d9c5, []int{2}
+	return fileDescriptor_2b3fc28395a6d9c5, []int{4}
 }
 func (m *FrontendToScheduler) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -295,15 +541,31 @@ func (m *FrontendToScheduler) GetStatsEnabled() bool {
 	return false
 }
 
+func (m *FrontendToScheduler) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *FrontendToScheduler) GetEstimatedSeriesCount() uint64 {
+	if m != nil {
+		return m.EstimatedSeriesCount
+	}
+	return 0
+}
+
 type SchedulerToFrontend struct {
 	Status SchedulerToFrontendStatus `protobuf:"varint,1,opt,name=status,proto3,enum=schedulerpb.SchedulerToFrontendStatus" json:"status,omitempty"`
 	Error  string                    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// Used by TOO_LONG_IN_QUEUE and DROPPED_FROM_QUEUE only. Identifies the expired or dropped request.
+	QueryID uint64 `protobuf:"varint,3,opt,name=queryID,proto3" json:"queryID,omitempty"`
 }
 
 func (m *SchedulerToFrontend) Reset()      { *m = SchedulerToFrontend{} }
 func (*SchedulerToFrontend) ProtoMessage() {}
 func (*SchedulerToFrontend) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2b3fc28395a6d9c5, []int{3}
+	return fileDescriptor_2b3fc28395a6d9c5, []int{5}
 }
 func (m *SchedulerToFrontend) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -346,6 +608,13 @@ func (m *SchedulerToFrontend) GetError() string {
 	return ""
 }
 
+func (m *SchedulerToFrontend) GetQueryID() uint64 {
+	if m != nil {
+		return m.QueryID
+	}
+	return 0
+}
+
 type NotifyQuerierShutdownRequest struct {
 	QuerierID string `protobuf:"bytes,1,opt,name=querierID,proto3" json:"querierID,omitempty"`
 }
@@ -353,7 +622,7 @@ type NotifyQuerierShutdownRequest struct {
 func (m *NotifyQuerierShutdownRequest) Reset()      { *m = NotifyQuerierShutdownRequest{} }
 func (*NotifyQuerierShutdownRequest) ProtoMessage() {}
 func (*NotifyQuerierShutdownRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2b3fc28395a6d9c5, []int{4}
+	return fileDescriptor_2b3fc28395a6d9c5, []int{6}
 }
 func (m *NotifyQuerierShutdownRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -395,7 +664,7 @@ type NotifyQuerierShutdownResponse struct {
 func (m *NotifyQuerierShutdownResponse) Reset()      { *m = NotifyQuerierShutdownResponse{} }
 func (*NotifyQuerierShutdownResponse) ProtoMessage() {}
 func (*NotifyQuerierShutdownResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2b3fc28395a6d9c5, []int{5}
+	return fileDescriptor_2b3fc28395a6d9c5, []int{7}
 }
 func (m *NotifyQuerierShutdownResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -425,10 +694,14 @@ func (m *NotifyQuerierShutdownResponse) XXX_DiscardUnknown() {
 var xxx_messageInfo_NotifyQuerierShutdownResponse proto.InternalMessageInfo
 
 func init() {
+	proto.RegisterEnum("schedulerpb.QuerierToSchedulerType", QuerierToSchedulerType_name, QuerierToSchedulerType_value)
+	proto.RegisterEnum("schedulerpb.SchedulerToQuerierType", SchedulerToQuerierType_name, SchedulerToQuerierType_value)
 	proto.RegisterEnum("schedulerpb.FrontendToSchedulerType", FrontendToSchedulerType_name, FrontendToSchedulerType_value)
 	proto.RegisterEnum("schedulerpb.SchedulerToFrontendStatus", SchedulerToFrontendStatus_name, SchedulerToFrontendStatus_value)
 	proto.RegisterType((*QuerierToScheduler)(nil), "schedulerpb.QuerierToScheduler")
+	proto.RegisterType((*QuerierCapacity)(nil), "schedulerpb.QuerierCapacity")
 	proto.RegisterType((*SchedulerToQuerier)(nil), "schedulerpb.SchedulerToQuerier")
+	proto.RegisterType((*QueryTarget)(nil), "schedulerpb.QueryTarget")
 	proto.RegisterType((*FrontendToScheduler)(nil), "schedulerpb.FrontendToScheduler")
 	proto.RegisterType((*SchedulerToFrontend)(nil), "schedulerpb.SchedulerToFrontend")
 	proto.RegisterType((*NotifyQuerierShutdownRequest)(nil), "schedulerpb.NotifyQuerierShutdownRequest")
@@ -438,50 +711,82 @@ func init() {
 func init() { proto.RegisterFile("scheduler.proto", fileDescriptor_2b3fc28395a6d9c5) }
 
 var fileDescriptor_2b3fc28395a6d9c5 = []byte{
-	// 644 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x4f, 0x4f, 0xdb, 0x4e,
-	0x10, 0xf5, 0x86, 0x24, 0xc0, 0x84, 0xdf, 0x0f, 0x77, 0x81, 0x36, 0x8d, 0xe8, 0x12, 0x45, 0x55,
-	0x95, 0x72, 0x48, 0xaa, 0xb4, 0x52, 0x7b, 0x40, 0x95, 0x52, 0x30, 0x25, 0x2a, 0x75, 0x60, 0xb3,
-	0x51, 0xff, 0x5c, 0x22, 0x92, 0x2c, 0x09, 0x02, 0xbc, 0x66, 0x6d, 0x17, 0xe5, 0xd6, 0x63, 0x8f,
-	0xfd, 0x18, 0xfd, 0x28, 0xbd, 0x54, 0xe2, 0xc8, 0xa1, 0x87, 0x62, 0x2e, 0x3d, 0xf2, 0x11, 0xaa,
-	0x38, 0x76, 0xea, 0xa4, 0x0e, 0x70, 0x9b, 0x1d, 0xbf, 0xe7, 0x9d, 0xf7, 0x66, 0x66, 0x61, 0xde,
-	0x6a, 0x75, 0x79, 0xdb, 0x39, 0xe2, 0xb2, 0x60, 0x4a, 0x61, 0x0b, 0x9c, 0x1a, 0x26, 0xcc, 0x66,
-	0x66, 0xb1, 0x23, 0x3a, 0xc2, 0xcb, 0x17, 0xfb, 0xd1, 0x00, 0x92, 0x79, 0xd6, 0x39, 0xb0, 0xbb,
-	0x4e, 0xb3, 0xd0, 0x12, 0xc7, 0xc5, 0x53, 0xbe, 0xf7, 0x89, 0x9f, 0x0a, 0x79, 0x68, 0x15, 0x5b,
-	0xe2, 0xf8, 0x58, 0x18, 0xc5, 0xae, 0x6d, 0x9b, 0x1d, 0x69, 0xb6, 0x86, 0xc1, 0x80, 0x95, 0x2b,
-	0x01, 0xde, 0x75, 0xb8, 0x3c, 0xe0, 0x92, 0x89, 0x5a, 0x70, 0x07, 0x5e, 0x86, 0xd9, 0x93, 0x41,
-	0xb6, 0xb2, 0x91, 0x46, 0x59, 0x94, 0x9f, 0xa5, 0x7f, 0x13, 0xb9, 0x1f, 0x08, 0xf0, 0x10, 0xcb,
-	0x84, 0xcf, 0xc7, 0x69, 0x98, 0xee, 0x63, 0x7a, 0x3e, 0x25, 0x4e, 0x83, 0x23, 0x7e, 0x0e, 0xa9,
-	0xfe, 0xb5, 0x94, 0x9f, 0x38, 0xdc, 0xb2, 0xd3, 0xb1, 0x2c, 0xca, 0xa7, 0x4a, 0x4b, 0x85, 0x61,
-	0x29, 0x5b, 0x8c, 0xed, 0xf8, 0x1f, 0x69, 0x18, 0x89, 0xf3, 0x30, 0xbf, 0x2f, 0x85, 0x61, 0x73,
-	0xa3, 0x5d, 0x6e, 0xb7, 0x25, 0xb7, 0xac, 0xf4, 0x94, 0x57, 0xcd, 0x78, 0x1a, 0xdf, 0x85, 0xa4,
-	0x63, 0x79, 0xe5, 0xc6, 0x3d, 0x80, 0x7f, 0xc2, 0x39, 0x98, 0xb3, 0xec, 0x3d, 0xdb, 0xd2, 0x8c,
-	0xbd, 0xe6, 0x11, 0x6f, 0xa7, 0x13, 0x59, 0x94, 0x9f, 0xa1, 0x23, 0xb9, 0xdc, 0x97, 0x18, 0x2c,
-	0x6c, 0xfa, 0xff, 0x0b, 0xbb, 0xf0, 0x02, 0xe2, 0x76, 0xcf, 0xe4, 0x9e, 0x9a, 0xff, 0x4b, 0x0f,
-	0x0b, 0xa1, 0x1e, 0x14, 0x22, 0xf0, 0xac, 0x67, 0x72, 0xea, 0x31, 0xa2, 0xea, 0x8e, 0x45, 0xd7,
-	0x1d, 0x32, 0x6d, 0x6a, 0xd4, 0xb4, 0x49, 0x8a, 0xc6, 0xcc, 0x4c, 0xdc, 0xda, 0xcc, 0x71, 0x2b,
-	0x92, 0x11, 0x56, 0x1c, 0xc2, 0x42, 0xa8, 0xb3, 0x81, 0x48, 0xfc, 0x12, 0x92, 0x7d, 0x98, 0x63,
-	0xf9, 0x5e, 0x3c, 0x1a, 0xf1, 0x22, 0x82, 0x51, 0xf3, 0xd0, 0xd4, 0x67, 0xe1, 0x45, 0x48, 0x70,
-	0x29, 0x85, 0xf4, 0x5d, 0x18, 0x1c, 0x72, 0x6b, 0xb0, 0xac, 0x0b, 0xfb, 0x60, 0xbf, 0xe7, 0x4f,
-	0x50, 0xad, 0xeb, 0xd8, 0x6d, 0x71, 0x6a, 0x04, 0x05, 0x5f, 0x3f, 0x85, 0x2b, 0xf0, 0x60, 0x02,
-	0xdb, 0x32, 0x85, 0x61, 0xf1, 0xd5, 0x35, 0xb8, 0x37, 0xa1, 0x4b, 0x78, 0x06, 0xe2, 0x15, 0xbd,
-	0xc2, 0x54, 0x05, 0xa7, 0x60, 0x5a, 0xd3, 0x77, 0xeb, 0x5a, 0x5d, 0x53, 0x11, 0x06, 0x48, 0xae,
-	0x97, 0xf5, 0x75, 0x6d, 0x5b, 0x8d, 0xad, 0xb6, 0xe0, 0xfe, 0x44, 0x5d, 0x38, 0x09, 0xb1, 0xea,
-	0x1b, 0x55, 0xc1, 0x59, 0x58, 0x66, 0xd5, 0x6a, 0xe3, 0x6d, 0x59, 0xff, 0xd0, 0xa0, 0xda, 0x6e,
-	0x5d, 0xab, 0xb1, 0x5a, 0x63, 0x47, 0xa3, 0x0d, 0xa6, 0xe9, 0x65, 0x9d, 0xa9, 0x08, 0xcf, 0x42,
-	0x42, 0xa3, 0xb4, 0x4a, 0xd5, 0x18, 0xbe, 0x03, 0xff, 0xd5, 0xb6, 0xea, 0x8c, 0x55, 0xf4, 0xd7,
-	0x8d, 0x8d, 0xea, 0x3b, 0x5d, 0x9d, 0x2a, 0xfd, 0x44, 0x21, 0xbf, 0x37, 0x85, 0x0c, 0x56, 0xa9,
-	0x0e, 0x29, 0x3f, 0xdc, 0x16, 0xc2, 0xc4, 0x2b, 0x23, 0x76, 0xff, 0xbb, 0xaf, 0x99, 0x95, 0x49,
-	0xfd, 0xf0, 0xb1, 0x39, 0x25, 0x8f, 0x9e, 0x20, 0x6c, 0xc0, 0x52, 0xa4, 0x65, 0xf8, 0xf1, 0x08,
-	0xff, 0xba, 0xa6, 0x64, 0x56, 0x6f, 0x03, 0x1d, 0x74, 0xa0, 0x64, 0xc2, 0x62, 0x58, 0xdd, 0x70,
-	0x9c, 0xde, 0xc3, 0x5c, 0x10, 0x7b, 0xfa, 0xb2, 0x37, 0xad, 0x56, 0x26, 0x7b, 0xd3, 0xc0, 0x0d,
-	0x14, 0xbe, 0x2a, 0x9f, 0x5d, 0x10, 0xe5, 0xfc, 0x82, 0x28, 0x57, 0x17, 0x04, 0x7d, 0x76, 0x09,
-	0xfa, 0xe6, 0x12, 0xf4, 0xdd, 0x25, 0xe8, 0xcc, 0x25, 0xe8, 0x97, 0x4b, 0xd0, 0x6f, 0x97, 0x28,
-	0x57, 0x2e, 0x41, 0x5f, 0x2f, 0x89, 0x72, 0x76, 0x49, 0x94, 0xf3, 0x4b, 0xa2, 0x7c, 0x0c, 0xbf,
-	0xae, 0xcd, 0xa4, 0xf7, 0x30, 0x3e, 0xfd, 0x13, 0x00, 0x00, 0xff, 0xff, 0x88, 0x0c, 0xfe, 0x56,
-	0x84, 0x05, 0x00, 0x00,
+	// 933 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x56, 0x4d, 0x6f, 0xe2, 0x46,
+	0x18, 0x66, 0xf8, 0x0a, 0x79, 0xc9, 0x36, 0x64, 0xf2, 0x51, 0x17, 0xa5, 0x8e, 0xe5, 0x56, 0x15,
+	0xe5, 0x40, 0x52, 0x5a, 0x69, 0xf7, 0xb0, 0xaa, 0xc4, 0x06, 0x27, 0x41, 0xcd, 0x1a, 0x32, 0x18,
+	0xb5, 0xdb, 0x0b, 0x72, 0xf0, 0x04, 0xac, 0x82, 0xc7, 0x6b, 0x0f, 0x8d, 0xb8, 0xed, 0xa5, 0xf7,
+	0xaa, 0xbf, 0xa2, 0xff, 0xa4, 0x3d, 0x46, 0x3d, 0xed, 0xa1, 0x87, 0x86, 0x5c, 0x7a, 0xdc, 0x9f,
+	0xb0, 0xf2, 0x07, 0xc4, 0x21, 0x26, 0xc9, 0x6d, 0xe6, 0x9d, 0xf7, 0x99, 0x79, 0x9f, 0xe7, 0x7d,
+	0x66, 0x6c, 0x58, 0x77, 0x7b, 0x03, 0x6a, 0x8c, 0x87, 0xd4, 0xa9, 0xd8, 0x0e, 0xe3, 0x0c, 0xe7,
+	0xe7, 0x01, 0xfb, 0xbc, 0xb8, 0xd5, 0x67, 0x7d, 0xe6, 0xc7, 0xf7, 0xbd, 0x51, 0x90, 0x52, 0xfc,
+	0xae, 0x6f, 0xf2, 0xc1, 0xf8, 0xbc, 0xd2, 0x63, 0xa3, 0xfd, 0x4b, 0xaa, 0xff, 0x4a, 0x2f, 0x99,
+	0xf3, 0x8b, 0xbb, 0xdf, 0x63, 0xa3, 0x11, 0xb3, 0xf6, 0x07, 0x9c, 0xdb, 0x7d, 0xc7, 0xee, 0xcd,
+	0x07, 0x01, 0x4a, 0xfe, 0x0b, 0x01, 0x3e, 0x1b, 0x53, 0xc7, 0xa4, 0x8e, 0xc6, 0xda, 0xb3, 0x43,
+	0xf0, 0x2e, 0xac, 0xbe, 0x0d, 0xa2, 0x8d, 0xba, 0x80, 0x24, 0x54, 0x5a, 0x25, 0xb7, 0x01, 0xfc,
+	0x02, 0x72, 0x3d, 0xdd, 0xd6, 0x7b, 0x26, 0x9f, 0x08, 0x49, 0x09, 0x95, 0xf2, 0xd5, 0xdd, 0x4a,
+	0xa4, 0xc0, 0x4a, 0xb8, 0xe1, 0x61, 0x98, 0x43, 0xe6, 0xd9, 0x58, 0x82, 0x7c, 0xb8, 0x4d, 0x8b,
+	0xb1, 0xa1, 0x90, 0xf2, 0x77, 0x8e, 0x86, 0xf0, 0x73, 0x48, 0xf3, 0x89, 0x4d, 0x85, 0xb4, 0x84,
+	0x4a, 0x9f, 0x54, 0xbf, 0x88, 0xdb, 0x37, 0x52, 0xa8, 0x36, 0xb1, 0x29, 0xf1, 0x01, 0xf2, 0x08,
+	0xd6, 0x17, 0xce, 0xc5, 0x25, 0x58, 0x37, 0xad, 0x8b, 0xa1, 0xd9, 0x1f, 0xf0, 0x60, 0xc9, 0xf5,
+	0xb9, 0x3c, 0x23, 0x8b, 0x61, 0x7c, 0x00, 0x9b, 0x23, 0x3a, 0x62, 0xce, 0xe4, 0x84, 0xea, 0x86,
+	0xc3, 0xd8, 0xe8, 0xd5, 0x84, 0x53, 0xd7, 0x27, 0x97, 0x26, 0x71, 0x4b, 0xf2, 0xbb, 0x14, 0xe0,
+	0xdb, 0x32, 0x58, 0x78, 0x34, 0x16, 0x60, 0xc5, 0x63, 0x33, 0x09, 0x65, 0x4b, 0x93, 0xd9, 0x14,
+	0x3f, 0x87, 0xbc, 0xa7, 0x3d, 0xa1, 0x6f, 0xc7, 0xd4, 0xe5, 0xa1, 0x6e, 0xdb, 0x95, 0x79, 0x3f,
+	0x4e, 0x34, 0xad, 0x15, 0x2e, 0x92, 0x68, 0xa6, 0xc7, 0xe2, 0xc2, 0x61, 0x16, 0xa7, 0x96, 0x51,
+	0x33, 0x0c, 0x87, 0xba, 0x6e, 0xa8, 0xdb, 0x62, 0x18, 0xef, 0x40, 0x76, 0xec, 0xfa, 0x2d, 0x4b,
+	0xfb, 0x09, 0xe1, 0x0c, 0xcb, 0xb0, 0xe6, 0x72, 0x9d, 0xbb, 0x8a, 0xa5, 0x9f, 0x0f, 0xa9, 0x21,
+	0x64, 0x24, 0x54, 0xca, 0x91, 0x3b, 0x31, 0xbc, 0x05, 0x19, 0x8b, 0x59, 0x3d, 0x2a, 0x64, 0xfd,
+	0xb2, 0x83, 0x09, 0x3e, 0x82, 0x0d, 0xdd, 0x30, 0x4c, 0x6e, 0x32, 0x4b, 0x1f, 0x6a, 0xba, 0xd3,
+	0xa7, 0xdc, 0x15, 0x56, 0xa4, 0x54, 0x29, 0x5f, 0x15, 0xee, 0xb5, 0x66, 0x12, 0x24, 0x90, 0xfb,
+	0x90, 0x79, 0x57, 0x73, 0x31, 0x5d, 0xbd, 0xaf, 0xe2, 0x6d, 0x57, 0x71, 0x11, 0x72, 0x06, 0xd5,
+	0x8d, 0xa1, 0x69, 0x51, 0x61, 0x55, 0x42, 0xa5, 0x14, 0x99, 0xcf, 0xe5, 0x3e, 0xe4, 0x23, 0xc7,
+	0x3e, 0x20, 0x7d, 0x8c, 0x82, 0xc9, 0x78, 0x05, 0xe7, 0x2a, 0xa4, 0x22, 0x2a, 0xc8, 0xff, 0x24,
+	0x61, 0xf3, 0x28, 0xcc, 0x8c, 0xde, 0x92, 0x17, 0x21, 0x2b, 0xe4, 0xb3, 0xfa, 0xf2, 0x0e, 0xab,
+	0x98, 0xfc, 0x08, 0xad, 0xa7, 0x57, 0x14, 0x61, 0x95, 0xba, 0xcb, 0x6a, 0x59, 0xb7, 0x17, 0x8c,
+	0x96, 0x79, 0xb2, 0xd1, 0x16, 0x6d, 0x92, 0x7d, 0xc8, 0x26, 0x2b, 0x51, 0x9b, 0x54, 0x61, 0x8b,
+	0xba, 0xdc, 0x1c, 0xe9, 0x9c, 0x1a, 0x6d, 0xff, 0x46, 0x1d, 0xb2, 0xb1, 0xc5, 0xfd, 0x76, 0xa7,
+	0x49, 0xec, 0x9a, 0xfc, 0x1b, 0x82, 0xcd, 0x48, 0xeb, 0x67, 0x7a, 0xe1, 0xef, 0x21, 0xeb, 0x9d,
+	0x38, 0x76, 0x43, 0x59, 0xbf, 0x5a, 0x66, 0x96, 0x19, 0xa2, 0xed, 0x67, 0x93, 0x10, 0xe5, 0x55,
+	0x48, 0x1d, 0x87, 0x39, 0xa1, 0xa0, 0xc1, 0x64, 0xb9, 0x8c, 0xf2, 0x4b, 0xd8, 0x55, 0x19, 0x37,
+	0x2f, 0x26, 0xa1, 0xf9, 0xda, 0x83, 0x31, 0x37, 0xd8, 0xa5, 0x35, 0x53, 0xe5, 0xc1, 0xa7, 0x50,
+	0xde, 0x83, 0xcf, 0x97, 0xa0, 0x5d, 0x9b, 0x59, 0x2e, 0x2d, 0x7f, 0x03, 0x3b, 0xf1, 0xcf, 0x16,
+	0x5e, 0x85, 0x0c, 0x51, 0x6a, 0xf5, 0x37, 0x85, 0x04, 0x5e, 0x83, 0x5c, 0x9d, 0xd4, 0x1a, 0x6a,
+	0x43, 0x3d, 0x2e, 0xa0, 0xf2, 0x01, 0xec, 0xc4, 0xdf, 0x09, 0x0f, 0x72, 0xd6, 0x51, 0x88, 0x07,
+	0xc9, 0xc3, 0x8a, 0x0f, 0x51, 0xea, 0x05, 0x54, 0x7e, 0x09, 0x9f, 0x2e, 0xf1, 0x1b, 0xce, 0x41,
+	0xba, 0xa1, 0x36, 0xb4, 0x00, 0xa1, 0xa8, 0x67, 0x1d, 0xa5, 0xa3, 0x14, 0x10, 0x06, 0xc8, 0x1e,
+	0xd6, 0xd4, 0x43, 0xe5, 0xb4, 0x90, 0x2c, 0xff, 0x81, 0xe0, 0xb3, 0xa5, 0xba, 0xe2, 0x2c, 0x24,
+	0x9b, 0x3f, 0x14, 0x12, 0x58, 0x82, 0x5d, 0xad, 0xd9, 0xec, 0xbe, 0xae, 0xa9, 0x6f, 0xba, 0x44,
+	0x39, 0xeb, 0x28, 0x6d, 0xad, 0xdd, 0x6d, 0x29, 0xa4, 0xab, 0x29, 0x6a, 0x4d, 0xd5, 0x0a, 0xc8,
+	0xab, 0x4e, 0x21, 0xa4, 0x49, 0x0a, 0x49, 0xbc, 0x01, 0xcf, 0xda, 0x27, 0x1d, 0x4d, 0x6b, 0xa8,
+	0xc7, 0xdd, 0x7a, 0xf3, 0x47, 0xb5, 0x90, 0xc2, 0xdb, 0xb0, 0xe1, 0xe1, 0x4f, 0x9b, 0xea, 0x71,
+	0xb7, 0xa1, 0x76, 0x83, 0x42, 0xd2, 0x78, 0x07, 0x70, 0x9d, 0x34, 0x5b, 0x2d, 0xa5, 0xde, 0x3d,
+	0x22, 0xcd, 0xd7, 0x61, 0x3c, 0x53, 0xfd, 0x37, 0x6a, 0x8f, 0x23, 0xe6, 0xcc, 0x1e, 0xd8, 0x4e,
+	0x70, 0xe9, 0x4d, 0xea, 0x9c, 0x32, 0x66, 0xe3, 0xbd, 0x47, 0x3e, 0x10, 0xc5, 0xbd, 0x47, 0xde,
+	0x1a, 0x39, 0x51, 0x42, 0x07, 0x08, 0x5b, 0xb0, 0x1d, 0xdb, 0x47, 0xfc, 0xf5, 0x1d, 0xfc, 0x43,
+	0x4e, 0x29, 0x96, 0x9f, 0x92, 0x1a, 0xd8, 0xa2, 0x6a, 0xc3, 0x56, 0x94, 0xdd, 0xdc, 0xfd, 0x3f,
+	0xc1, 0xda, 0x6c, 0xec, 0xf3, 0x93, 0x1e, 0x7b, 0x54, 0x8a, 0xd2, 0x63, 0xf7, 0x23, 0x60, 0xf8,
+	0xaa, 0x76, 0x75, 0x2d, 0x26, 0xde, 0x5f, 0x8b, 0x89, 0x0f, 0xd7, 0x22, 0x7a, 0x37, 0x15, 0xd1,
+	0x9f, 0x53, 0x11, 0xfd, 0x3d, 0x15, 0xd1, 0xd5, 0x54, 0x44, 0xff, 0x4d, 0x45, 0xf4, 0xff, 0x54,
+	0x4c, 0x7c, 0x98, 0x8a, 0xe8, 0xf7, 0x1b, 0x31, 0x71, 0x75, 0x23, 0x26, 0xde, 0xdf, 0x88, 0x89,
+	0x9f, 0xa3, 0x3f, 0x1e, 0xe7, 0x59, 0xff, 0x9f, 0xe1, 0xdb, 0x8f, 0x03, 0x00, 0xca, 0xa8, 0x90,
+	0x3b, 0x9f, 0x08, 0x00, 0x00,
+}
+
+func (x QuerierToSchedulerType) String() string {
+	s, ok := QuerierToSchedulerType_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
+func (x SchedulerToQuerierType) String() string {
+	s, ok := SchedulerToQuerierType_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
 }
-
 func (x FrontendToSchedulerType) String() string {
 	s, ok := FrontendToSchedulerType_name[int32(x)]
 	if ok {
@@ -518,6 +823,42 @@ func (this *QuerierToScheduler) Equal(that interface{}) bool {
 	if this.QuerierID != that1.QuerierID {
 		return false
 	}
+	if !this.Capacity.Equal(that1.Capacity) {
+		return false
+	}
+	if this.QuerierPool != that1.QuerierPool {
+		return false
+	}
+	if this.Type != that1.Type {
+		return false
+	}
+	return true
+}
+func (this *QuerierCapacity) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QuerierCapacity)
+	if !ok {
+		that2, ok := that.(QuerierCapacity)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.InflightQueries != that1.InflightQueries {
+		return false
+	}
+	if this.MemoryHeadroomBytes != that1.MemoryHeadroomBytes {
+		return false
+	}
 	return true
 }
 func (this *SchedulerToQuerier) Equal(that interface{}) bool {
@@ -554,6 +895,53 @@ func (this *SchedulerToQuerier) Equal(that interface{}) bool {
 	if this.StatsEnabled != that1.StatsEnabled {
 		return false
 	}
+	if this.Nonce != that1.Nonce {
+		return false
+	}
+	if len(this.AdditionalTargets) != len(that1.AdditionalTargets) {
+		return false
+	}
+	for i := range this.AdditionalTargets {
+		if !this.AdditionalTargets[i].Equal(that1.AdditionalTargets[i]) {
+			return false
+		}
+	}
+	if this.Type != that1.Type {
+		return false
+	}
+	if this.Deadline != that1.Deadline {
+		return false
+	}
+	return true
+}
+func (this *QueryTarget) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryTarget)
+	if !ok {
+		that2, ok := that.(QueryTarget)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.QueryID != that1.QueryID {
+		return false
+	}
+	if this.FrontendAddress != that1.FrontendAddress {
+		return false
+	}
+	if this.Nonce != that1.Nonce {
+		return false
+	}
 	return true
 }
 func (this *FrontendToScheduler) Equal(that interface{}) bool {
@@ -593,6 +981,12 @@ func (this *FrontendToScheduler) Equal(that interface{}) bool {
 	if this.StatsEnabled != that1.StatsEnabled {
 		return false
 	}
+	if this.Nonce != that1.Nonce {
+		return false
+	}
+	if this.EstimatedSeriesCount != that1.EstimatedSeriesCount {
+		return false
+	}
 	return true
 }
 func (this *SchedulerToFrontend) Equal(that interface{}) bool {
@@ -620,6 +1014,9 @@ func (this *SchedulerToFrontend) Equal(that interface{}) bool {
 	if this.Error != that1.Error {
 		return false
 	}
+	if this.QueryID != that1.QueryID {
+		return false
+	}
 	return true
 }
 func (this *NotifyQuerierShutdownRequest) Equal(that interface{}) bool {
@@ -671,9 +1068,25 @@ func (this *QuerierToScheduler) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
+	s := make([]string, 0, 8)
 	s = append(s, "&schedulerpb.QuerierToScheduler{")
 	s = append(s, "QuerierID: "+fmt.Sprintf("%#v", this.QuerierID)+",\n")
+	if this.Capacity != nil {
+		s = append(s, "Capacity: "+fmt.Sprintf("%#v", this.Capacity)+",\n")
+	}
+	s = append(s, "QuerierPool: "+fmt.Sprintf("%#v", this.QuerierPool)+",\n")
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QuerierCapacity) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&schedulerpb.QuerierCapacity{")
+	s = append(s, "InflightQueries: "+fmt.Sprintf("%#v", this.InflightQueries)+",\n")
+	s = append(s, "MemoryHeadroomBytes: "+fmt.Sprintf("%#v", this.MemoryHeadroomBytes)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -681,7 +1094,7 @@ func (this *SchedulerToQuerier) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 13)
 	s = append(s, "&schedulerpb.SchedulerToQuerier{")
 	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
 	if this.HttpRequest != nil {
@@ -690,15 +1103,33 @@ func (this *SchedulerToQuerier) GoString() string {
 	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
 	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "Nonce: "+fmt.Sprintf("%#v", this.Nonce)+",\n")
+	if this.AdditionalTargets != nil {
+		s = append(s, "AdditionalTargets: "+fmt.Sprintf("%#v", this.AdditionalTargets)+",\n")
+	}
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	s = append(s, "Deadline: "+fmt.Sprintf("%#v", this.Deadline)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func (this *FrontendToScheduler) GoString() string {
+func (this *QueryTarget) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 10)
-	s = append(s, "&schedulerpb.FrontendToScheduler{")
+	s := make([]string, 0, 7)
+	s = append(s, "&schedulerpb.QueryTarget{")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
+	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
+	s = append(s, "Nonce: "+fmt.Sprintf("%#v", this.Nonce)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *FrontendToScheduler) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 12)
+	s = append(s, "&schedulerpb.FrontendToScheduler{")
 	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
 	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
 	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
@@ -707,6 +1138,8 @@ func (this *FrontendToScheduler) GoString() string {
 		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
 	}
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "Nonce: "+fmt.Sprintf("%#v", this.Nonce)+",\n")
+	s = append(s, "EstimatedSeriesCount: "+fmt.Sprintf("%#v", this.EstimatedSeriesCount)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -714,10 +1147,11 @@ func (this *SchedulerToFrontend) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
+	s := make([]string, 0, 7)
 	s = append(s, "&schedulerpb.SchedulerToFrontend{")
 	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
 	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1048,6 +1482,30 @@ func (m *QuerierToScheduler) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Type != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.QuerierPool) > 0 {
+		i -= len(m.QuerierPool)
+		copy(dAtA[i:], m.QuerierPool)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.QuerierPool)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Capacity != nil {
+		{
+			size, err := m.Capacity.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintScheduler(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
 	if len(m.QuerierID) > 0 {
 		i -= len(m.QuerierID)
 		copy(dAtA[i:], m.QuerierID)
@@ -1058,6 +1516,39 @@ func (m *QuerierToScheduler) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *QuerierCapacity) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuerierCapacity) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuerierCapacity) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MemoryHeadroomBytes != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.MemoryHeadroomBytes))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.InflightQueries != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.InflightQueries))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *SchedulerToQuerier) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1078,6 +1569,35 @@ func (m *SchedulerToQuerier) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Deadline != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Deadline))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.Type != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.AdditionalTargets) > 0 {
+		for iNdEx := len(m.AdditionalTargets) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.AdditionalTargets[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintScheduler(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if m.Nonce != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Nonce))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.StatsEnabled {
 		i--
 		if m.StatsEnabled {
@@ -1122,6 +1642,46 @@ func (m *SchedulerToQuerier) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *QueryTarget) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryTarget) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryTarget) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Nonce != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Nonce))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.FrontendAddress) > 0 {
+		i -= len(m.FrontendAddress)
+		copy(dAtA[i:], m.FrontendAddress)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.FrontendAddress)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.QueryID != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.QueryID))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *FrontendToScheduler) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1142,6 +1702,16 @@ func (m *FrontendToScheduler) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.EstimatedSeriesCount != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.EstimatedSeriesCount))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.Nonce != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Nonce))
+		i--
+		dAtA[i] = 0x38
+	}
 	if m.StatsEnabled {
 		i--
 		if m.StatsEnabled {
@@ -1211,6 +1781,11 @@ func (m *SchedulerToFrontend) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.QueryID != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.QueryID))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.Error) > 0 {
 		i -= len(m.Error)
 		copy(dAtA[i:], m.Error)
@@ -1300,6 +1875,32 @@ func (m *QuerierToScheduler) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovScheduler(uint64(l))
 	}
+	if m.Capacity != nil {
+		l = m.Capacity.Size()
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	l = len(m.QuerierPool)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.Type != 0 {
+		n += 1 + sovScheduler(uint64(m.Type))
+	}
+	return n
+}
+
+func (m *QuerierCapacity) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.InflightQueries != 0 {
+		n += 1 + sovScheduler(uint64(m.InflightQueries))
+	}
+	if m.MemoryHeadroomBytes != 0 {
+		n += 1 + sovScheduler(uint64(m.MemoryHeadroomBytes))
+	}
 	return n
 }
 
@@ -1327,6 +1928,40 @@ func (m *SchedulerToQuerier) Size() (n int) {
 	if m.StatsEnabled {
 		n += 2
 	}
+	if m.Nonce != 0 {
+		n += 1 + sovScheduler(uint64(m.Nonce))
+	}
+	if len(m.AdditionalTargets) > 0 {
+		for _, e := range m.AdditionalTargets {
+			l = e.Size()
+			n += 1 + l + sovScheduler(uint64(l))
+		}
+	}
+	if m.Type != 0 {
+		n += 1 + sovScheduler(uint64(m.Type))
+	}
+	if m.Deadline != 0 {
+		n += 1 + sovScheduler(uint64(m.Deadline))
+	}
+	return n
+}
+
+func (m *QueryTarget) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.QueryID != 0 {
+		n += 1 + sovScheduler(uint64(m.QueryID))
+	}
+	l = len(m.FrontendAddress)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.Nonce != 0 {
+		n += 1 + sovScheduler(uint64(m.Nonce))
+	}
 	return n
 }
 
@@ -1357,6 +1992,12 @@ func (m *FrontendToScheduler) Size() (n int) {
 	if m.StatsEnabled {
 		n += 2
 	}
+	if m.Nonce != 0 {
+		n += 1 + sovScheduler(uint64(m.Nonce))
+	}
+	if m.EstimatedSeriesCount != 0 {
+		n += 1 + sovScheduler(uint64(m.EstimatedSeriesCount))
+	}
 	return n
 }
 
@@ -1373,6 +2014,9 @@ func (m *SchedulerToFrontend) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovScheduler(uint64(l))
 	}
+	if m.QueryID != 0 {
+		n += 1 + sovScheduler(uint64(m.QueryID))
+	}
 	return n
 }
 
@@ -1410,6 +2054,20 @@ func (this *QuerierToScheduler) String() string {
 	}
 	s := strings.Join([]string{`&QuerierToScheduler{`,
 		`QuerierID:` + fmt.Sprintf("%v", this.QuerierID) + `,`,
+		`Capacity:` + strings.Replace(this.Capacity.String(), "QuerierCapacity", "QuerierCapacity", 1) + `,`,
+		`QuerierPool:` + fmt.Sprintf("%v", this.QuerierPool) + `,`,
+		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QuerierCapacity) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QuerierCapacity{`,
+		`InflightQueries:` + fmt.Sprintf("%v", this.InflightQueries) + `,`,
+		`MemoryHeadroomBytes:` + fmt.Sprintf("%v", this.MemoryHeadroomBytes) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1418,12 +2076,33 @@ func (this *SchedulerToQuerier) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForAdditionalTargets := "[]*QueryTarget{"
+	for _, f := range this.AdditionalTargets {
+		repeatedStringForAdditionalTargets += strings.Replace(f.String(), "QueryTarget", "QueryTarget", 1) + ","
+	}
+	repeatedStringForAdditionalTargets += "}"
 	s := strings.Join([]string{`&SchedulerToQuerier{`,
 		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
 		`HttpRequest:` + strings.Replace(fmt.Sprintf("%v", this.HttpRequest), "HTTPRequest", "httpgrpc.HTTPRequest", 1) + `,`,
 		`FrontendAddress:` + fmt.Sprintf("%v", this.FrontendAddress) + `,`,
 		`UserID:` + fmt.Sprintf("%v", this.UserID) + `,`,
 		`StatsEnabled:` + fmt.Sprintf("%v", this.StatsEnabled) + `,`,
+		`Nonce:` + fmt.Sprintf("%v", this.Nonce) + `,`,
+		`AdditionalTargets:` + repeatedStringForAdditionalTargets + `,`,
+		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
+		`Deadline:` + fmt.Sprintf("%v", this.Deadline) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryTarget) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QueryTarget{`,
+		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
+		`FrontendAddress:` + fmt.Sprintf("%v", this.FrontendAddress) + `,`,
+		`Nonce:` + fmt.Sprintf("%v", this.Nonce) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1439,6 +2118,8 @@ func (this *FrontendToScheduler) String() string {
 		`UserID:` + fmt.Sprintf("%v", this.UserID) + `,`,
 		`HttpRequest:` + strings.Replace(fmt.Sprintf("%v", this.HttpRequest), "HTTPRequest", "httpgrpc.HTTPRequest", 1) + `,`,
 		`StatsEnabled:` + fmt.Sprintf("%v", this.StatsEnabled) + `,`,
+		`Nonce:` + fmt.Sprintf("%v", this.Nonce) + `,`,
+		`EstimatedSeriesCount:` + fmt.Sprintf("%v", this.EstimatedSeriesCount) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1450,6 +2131,7 @@ func (this *SchedulerToFrontend) String() string {
 	s := strings.Join([]string{`&SchedulerToFrontend{`,
 		`Status:` + fmt.Sprintf("%v", this.Status) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1542,81 +2224,9 @@ func (m *QuerierToScheduler) Unmarshal(dAtA []byte) error {
 			}
 			m.QuerierID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipScheduler(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthScheduler
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthScheduler
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowScheduler
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: SchedulerToQuerier: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SchedulerToQuerier: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
-			}
-			m.QueryID = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowScheduler
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.QueryID |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HttpRequest", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1643,16 +2253,266 @@ func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.HttpRequest == nil {
-				m.HttpRequest = &httpgrpc.HTTPRequest{}
+			if m.Capacity == nil {
+				m.Capacity = &QuerierCapacity{}
 			}
-			if err := m.HttpRequest.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Capacity.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FrontendAddress", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field QuerierPool", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QuerierPool = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= QuerierToSchedulerType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QuerierCapacity) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuerierCapacity: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuerierCapacity: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InflightQueries", wireType)
+			}
+			m.InflightQueries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.InflightQueries |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MemoryHeadroomBytes", wireType)
+			}
+			m.MemoryHeadroomBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MemoryHeadroomBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SchedulerToQuerier: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SchedulerToQuerier: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
+			}
+			m.QueryID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HttpRequest", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.HttpRequest == nil {
+				m.HttpRequest = &httpgrpc.HTTPRequest{}
+			}
+			if err := m.HttpRequest.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FrontendAddress", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1734,6 +2594,220 @@ func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.StatsEnabled = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonce", wireType)
+			}
+			m.Nonce = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Nonce |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AdditionalTargets", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AdditionalTargets = append(m.AdditionalTargets, &QueryTarget{})
+			if err := m.AdditionalTargets[len(m.AdditionalTargets)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= SchedulerToQuerierType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Deadline", wireType)
+			}
+			m.Deadline = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Deadline |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipScheduler(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryTarget) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowScheduler
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryTarget: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryTarget: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
+			}
+			m.QueryID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FrontendAddress", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FrontendAddress = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonce", wireType)
+			}
+			m.Nonce = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Nonce |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -1945,6 +3019,44 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.StatsEnabled = bool(v != 0)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Nonce", wireType)
+			}
+			m.Nonce = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Nonce |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EstimatedSeriesCount", wireType)
+			}
+			m.EstimatedSeriesCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EstimatedSeriesCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -2049,6 +3161,25 @@ func (m *SchedulerToFrontend) Unmarshal(dAtA []byte) error {
 			}
 			m.Error = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryID", wireType)
+			}
+			m.QueryID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
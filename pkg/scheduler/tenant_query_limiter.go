@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"math"
+
+	"github.com/grafana/dskit/limiter"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/tenant"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	rejectReasonRateLimited          = "rate_limited"
+	rejectReasonMaxConcurrentQueries = "max_concurrent_queries"
+)
+
+var activeSchedulersRingOp = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
+
+// queryRateStrategy is the rate limiter strategy of the queries enqueued by each tenant. The tenant's query
+// rate limit is evenly split between the query-scheduler replicas in use.
+type queryRateStrategy struct {
+	limits    Limits
+	instances func() int
+}
+
+func newQueryRateStrategy(limits Limits, instances func() int) limiter.RateLimiterStrategy {
+	return &queryRateStrategy{
+		limits:    limits,
+		instances: instances,
+	}
+}
+
+func (s *queryRateStrategy) Limit(userID string) float64 {
+	limit := s.tenantsLimit(userID)
+	if limit <= 0 {
+		return float64(rate.Inf)
+	}
+
+	if n := s.instances(); n > 1 {
+		return limit / float64(n)
+	}
+	return limit
+}
+
+func (s *queryRateStrategy) Burst(userID string) int {
+	limit := s.tenantsLimit(userID)
+	if limit <= 0 {
+		// Burst is ignored when limit = rate.Inf
+		return 0
+	}
+
+	tenantIDs, err := tenant.TenantIDsFromOrgID(userID)
+	if err != nil {
+		return 0
+	}
+	if burst := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.QueryBurstSize); burst > 0 {
+		return burst
+	}
+	return int(math.Ceil(limit))
+}
+
+// tenantsLimit returns the query rate limit of the given user, which may be a multi-tenant user ID.
+func (s *queryRateStrategy) tenantsLimit(userID string) float64 {
+	tenantIDs, err := tenant.TenantIDsFromOrgID(userID)
+	if err != nil {
+		return 0
+	}
+	return validation.SmallestPositiveNonZeroFloat64PerTenant(tenantIDs, s.limits.QueryRateLimit)
+}
+
+// perInstanceConcurrencyLimit returns the share of the concurrency limit enforced by each of the given number
+// of query-scheduler replicas in use.
+func perInstanceConcurrencyLimit(limit, instances int) int {
+	if limit <= 0 || instances <= 1 {
+		return limit
+	}
+	return int(math.Ceil(float64(limit) / float64(instances)))
+}
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestQueryRateStrategy(t *testing.T) {
+	tests := map[string]struct {
+		limits        limits
+		instances     int
+		userID        string
+		expectedLimit float64
+		expectedBurst int
+	}{
+		"rate limit disabled": {
+			instances:     1,
+			userID:        "user-1",
+			expectedLimit: float64(rate.Inf),
+			expectedBurst: 0,
+		},
+		"burst defaults to the rate limit rounded up": {
+			limits:        limits{queryRateLimit: 2.5},
+			instances:     1,
+			userID:        "user-1",
+			expectedLimit: 2.5,
+			expectedBurst: 3,
+		},
+		"burst explicitly set": {
+			limits:        limits{queryRateLimit: 2.5, queryBurstSize: 10},
+			instances:     1,
+			userID:        "user-1",
+			expectedLimit: 2.5,
+			expectedBurst: 10,
+		},
+		"rate limit split between query-schedulers": {
+			limits:        limits{queryRateLimit: 10, queryBurstSize: 10},
+			instances:     4,
+			userID:        "user-1",
+			expectedLimit: 2.5,
+			expectedBurst: 10,
+		},
+		"multi-tenant query": {
+			limits:        limits{queryRateLimit: 10},
+			instances:     1,
+			userID:        "user-1|user-2",
+			expectedLimit: 10,
+			expectedBurst: 10,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			strategy := newQueryRateStrategy(testData.limits, func() int { return testData.instances })
+			assert.Equal(t, testData.expectedLimit, strategy.Limit(testData.userID))
+			assert.Equal(t, testData.expectedBurst, strategy.Burst(testData.userID))
+		})
+	}
+}
+
+func TestPerInstanceConcurrencyLimit(t *testing.T) {
+	assert.Equal(t, 0, perInstanceConcurrencyLimit(0, 3))
+	assert.Equal(t, 10, perInstanceConcurrencyLimit(10, 0))
+	assert.Equal(t, 10, perInstanceConcurrencyLimit(10, 1))
+	assert.Equal(t, 4, perInstanceConcurrencyLimit(10, 3))
+	assert.Equal(t, 1, perInstanceConcurrencyLimit(1, 3))
+}
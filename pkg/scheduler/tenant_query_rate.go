@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+const (
+	// tenantQueryRateTickInterval is the interval the tenants query rate is updated at.
+	tenantQueryRateTickInterval = time.Second
+
+	// tenantQueryRateAlpha is the smoothing factor of the tenants query rate. With a tick interval
+	// of 1s, the query rate reacts to changes in the order of tens of seconds.
+	tenantQueryRateAlpha = 0.1
+)
+
+// tenantQueryRateTracker keeps track of the recent rate of queries enqueued by each tenant.
+type tenantQueryRateTracker struct {
+	mtx   sync.RWMutex
+	rates map[string]*util_math.EwmaRate
+}
+
+func newTenantQueryRateTracker() *tenantQueryRateTracker {
+	return &tenantQueryRateTracker{
+		rates: map[string]*util_math.EwmaRate{},
+	}
+}
+
+// inc counts a query enqueued by the tenant.
+func (t *tenantQueryRateTracker) inc(userID string) {
+	t.mtx.RLock()
+	r := t.rates[userID]
+	t.mtx.RUnlock()
+
+	if r == nil {
+		t.mtx.Lock()
+		if r = t.rates[userID]; r == nil {
+			r = util_math.NewEWMARate(tenantQueryRateAlpha, tenantQueryRateTickInterval)
+			t.rates[userID] = r
+		}
+		t.mtx.Unlock()
+	}
+
+	r.Inc()
+}
+
+// rate returns the recent per-second rate of queries enqueued by the tenant.
+func (t *tenantQueryRateTracker) rate(userID string) float64 {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	if r := t.rates[userID]; r != nil {
+		return r.Rate()
+	}
+	return 0
+}
+
+// tick updates the rate of all tenants. It's expected to be called every tenantQueryRateTickInterval.
+func (t *tenantQueryRateTracker) tick() {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	for _, r := range t.rates {
+		r.Tick()
+	}
+}
+
+func (t *tenantQueryRateTracker) remove(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.rates, userID)
+}
+
+// dynamicMaxQueriers returns the number of queriers that can handle the requests of a tenant, given the tenant's
+// recent query rate and the target query rate per querier. The returned value is bounded by minQueriers and
+// maxQueriers (if greater than 0). If targetRatePerQuerier is 0, the dynamic shard size is disabled and maxQueriers
+// is returned as is.
+func dynamicMaxQueriers(queryRate, targetRatePerQuerier float64, minQueriers, maxQueriers int) int {
+	if targetRatePerQuerier <= 0 {
+		return maxQueriers
+	}
+
+	queriers := int(math.Ceil(queryRate / targetRatePerQuerier))
+	if queriers < minQueriers {
+		queriers = minQueriers
+	}
+	if queriers < 1 {
+		queriers = 1
+	}
+	if maxQueriers > 0 && queriers > maxQueriers {
+		queriers = maxQueriers
+	}
+	return queriers
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantQueryRateTracker(t *testing.T) {
+	tracker := newTenantQueryRateTracker()
+	assert.Equal(t, 0.0, tracker.rate("user-1"))
+
+	for i := 0; i < 10; i++ {
+		tracker.inc("user-1")
+	}
+	tracker.inc("user-2")
+
+	// The rate is updated only on tick.
+	assert.Equal(t, 0.0, tracker.rate("user-1"))
+
+	tracker.tick()
+	assert.Equal(t, 10.0, tracker.rate("user-1"))
+	assert.Equal(t, 1.0, tracker.rate("user-2"))
+
+	// With no new queries, the rate decreases over time.
+	tracker.tick()
+	assert.InDelta(t, 9.0, tracker.rate("user-1"), 0.001)
+	assert.InDelta(t, 0.9, tracker.rate("user-2"), 0.001)
+
+	tracker.remove("user-1")
+	assert.Equal(t, 0.0, tracker.rate("user-1"))
+	assert.InDelta(t, 0.9, tracker.rate("user-2"), 0.001)
+}
+
+func TestDynamicMaxQueriers(t *testing.T) {
+	tests := map[string]struct {
+		queryRate   float64
+		targetRate  float64
+		minQueriers int
+		maxQueriers int
+		expected    int
+	}{
+		"dynamic shard size disabled": {
+			queryRate:   100,
+			maxQueriers: 5,
+			expected:    5,
+		},
+		"dynamic shard size disabled and shuffle sharding disabled": {
+			queryRate: 100,
+			expected:  0,
+		},
+		"shard size computed from the query rate": {
+			queryRate:   25,
+			targetRate:  10,
+			maxQueriers: 10,
+			expected:    3,
+		},
+		"shard size bounded by the max queriers": {
+			queryRate:   250,
+			targetRate:  10,
+			maxQueriers: 10,
+			expected:    10,
+		},
+		"shard size not bounded if max queriers is 0": {
+			queryRate:  250,
+			targetRate: 10,
+			expected:   25,
+		},
+		"shard size bounded by the min queriers": {
+			queryRate:   5,
+			targetRate:  10,
+			minQueriers: 3,
+			maxQueriers: 10,
+			expected:    3,
+		},
+		"shard size is at least 1 with no queries": {
+			queryRate:   0,
+			targetRate:  10,
+			maxQueriers: 10,
+			expected:    1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, dynamicMaxQueriers(testData.queryRate, testData.targetRate, testData.minQueriers, testData.maxQueriers))
+		})
+	}
+}
+
+func TestScheduler_MaxQueriersForTenants(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+
+	// The scheduler is not started, so that the query rate is only updated by the test.
+	scheduler, err := NewScheduler(cfg, &limits{queriers: 10, minQueriers: 2, targetQueryRate: 5}, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	// With no queries, the min number of queriers is used.
+	require.Equal(t, 2, scheduler.maxQueriersForTenants("user-1", []string{"user-1"}))
+
+	for i := 0; i < 22; i++ {
+		scheduler.tenantQueryRate.inc("user-1")
+	}
+	scheduler.tenantQueryRate.tick()
+
+	// The shard size grows with the query rate.
+	require.Equal(t, 5, scheduler.maxQueriersForTenants("user-1", []string{"user-1"}))
+	require.Equal(t, 2, scheduler.maxQueriersForTenants("user-2", []string{"user-2"}))
+	assert.Equal(t, 2, testutil.CollectAndCount(scheduler.querierShardSize))
+}
+
+func TestScheduler_MaxQueriersForTenants_DynamicShardSizeDisabled(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+
+	scheduler, err := NewScheduler(cfg, &limits{queriers: 10, minQueriers: 2}, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	// The max number of queriers is used, and the shard size isn't tracked.
+	require.Equal(t, 10, scheduler.maxQueriersForTenants("user-1", []string{"user-1"}))
+	assert.Equal(t, 0, testutil.CollectAndCount(scheduler.querierShardSize))
+}
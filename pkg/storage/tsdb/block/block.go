@@ -37,6 +37,9 @@ const (
 	IndexHeaderFilename = "index-header"
 	// ChunksDirname is the known dir name for chunks with compressed samples.
 	ChunksDirname = "chunks"
+	// CardinalitySummaryFilename is the name of the optional file summarizing the label names and values of the
+	// series in a block. The file is written by the compactor, see the cardinalityindex package.
+	CardinalitySummaryFilename = "cardinality-summary.json.gz"
 
 	// DebugMetas is a directory for debug meta files that happen in the past. Useful for debugging.
 	DebugMetas = "debug/metas"
@@ -122,6 +125,20 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, blockDi
 		return cleanUp(logger, bkt, id, errors.Wrap(err, "upload index"))
 	}
 
+	// The exemplars file is optional.
+	if _, err := os.Stat(filepath.Join(blockDir, ExemplarsFilename)); err == nil {
+		if err := objstore.UploadFile(ctx, logger, bkt, filepath.Join(blockDir, ExemplarsFilename), path.Join(id.String(), ExemplarsFilename)); err != nil {
+			return cleanUp(logger, bkt, id, errors.Wrap(err, "upload exemplars"))
+		}
+	}
+
+	// The cardinality summary file is optional.
+	if _, err := os.Stat(filepath.Join(blockDir, CardinalitySummaryFilename)); err == nil {
+		if err := objstore.UploadFile(ctx, logger, bkt, filepath.Join(blockDir, CardinalitySummaryFilename), path.Join(id.String(), CardinalitySummaryFilename)); err != nil {
+			return cleanUp(logger, bkt, id, errors.Wrap(err, "upload cardinality summary"))
+		}
+	}
+
 	// Meta.json always need to be uploaded as a last item. This will allow to assume block directories without meta file to be pending uploads.
 	if err := bkt.Upload(ctx, path.Join(id.String(), MetaFilename), strings.NewReader(metaEncoded.String())); err != nil {
 		// Don't call cleanUp here. Despite getting error, meta.json may have been uploaded in certain cases,
@@ -314,6 +331,28 @@ func GatherFileStats(blockDir string) (res []metadata.File, _ error) {
 	}
 	res = append(res, mf)
 
+	// The exemplars file is optional.
+	exemplarsFile, err := os.Stat(filepath.Join(blockDir, ExemplarsFilename))
+	if err == nil {
+		res = append(res, metadata.File{
+			RelPath:   exemplarsFile.Name(),
+			SizeBytes: exemplarsFile.Size(),
+		})
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, ExemplarsFilename))
+	}
+
+	// The cardinality summary file is optional.
+	summaryFile, err := os.Stat(filepath.Join(blockDir, CardinalitySummaryFilename))
+	if err == nil {
+		res = append(res, metadata.File{
+			RelPath:   summaryFile.Name(),
+			SizeBytes: summaryFile.Size(),
+		})
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, CardinalitySummaryFilename))
+	}
+
 	metaFile, err := os.Stat(filepath.Join(blockDir, MetaFilename))
 	if err != nil {
 		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, MetaFilename))
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+// ExemplarsFilename is the name of the optional file storing the exemplars of the series in a block.
+// The file is a snappy-compressed storepb.BlockExemplars protobuf message.
+const ExemplarsFilename = "exemplars"
+
+// WriteExemplarsFile writes the exemplars of the input series into <dir>/exemplars.
+func WriteExemplarsFile(dir string, series []storepb.SeriesExemplars) error {
+	data, err := (&storepb.BlockExemplars{Series: series}).Marshal()
+	if err != nil {
+		return errors.Wrap(err, "marshal exemplars")
+	}
+
+	// Make any changes to the file appear atomic.
+	path := filepath.Join(dir, ExemplarsFilename)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, snappy.Encode(nil, data), 0o666); err != nil {
+		return errors.Wrap(err, "write exemplars")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "rename exemplars")
+}
+
+// ReadExemplarsFile reads the exemplars from <dir>/exemplars. Returns no exemplars
+// if the block has no exemplars file.
+func ReadExemplarsFile(dir string) ([]storepb.SeriesExemplars, error) {
+	f, err := os.Open(filepath.Join(dir, ExemplarsFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	return DecodeExemplars(f)
+}
+
+// DecodeExemplars decodes the exemplars from the content of an exemplars file.
+func DecodeExemplars(r io.Reader) ([]storepb.SeriesExemplars, error) {
+	compressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read exemplars")
+	}
+
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress exemplars")
+	}
+
+	var res storepb.BlockExemplars
+	if err := res.Unmarshal(data); err != nil {
+		return nil, errors.Wrap(err, "unmarshal exemplars")
+	}
+	return res.Series, nil
+}
+
+// MergeSeriesExemplars merges the input sets of series exemplars. The returned series are sorted by labels,
+// and the exemplars of each series are sorted by timestamp, with duplicated exemplars removed.
+func MergeSeriesExemplars(sets ...[]storepb.SeriesExemplars) []storepb.SeriesExemplars {
+	bySeries := map[string]*storepb.SeriesExemplars{}
+	for _, set := range sets {
+		for _, s := range set {
+			key := mimirpb.FromLabelAdaptersToLabels(s.Labels).String()
+			if merged, ok := bySeries[key]; ok {
+				merged.Exemplars = append(merged.Exemplars, s.Exemplars...)
+				continue
+			}
+			bySeries[key] = &storepb.SeriesExemplars{
+				Labels:    s.Labels,
+				Exemplars: append([]mimirpb.Exemplar(nil), s.Exemplars...),
+			}
+		}
+	}
+
+	res := make([]storepb.SeriesExemplars, 0, len(bySeries))
+	for _, s := range bySeries {
+		s.Exemplars = sortAndDeduplicateExemplars(s.Exemplars)
+		res = append(res, *s)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return labels.Compare(mimirpb.FromLabelAdaptersToLabels(res[i].Labels), mimirpb.FromLabelAdaptersToLabels(res[j].Labels)) < 0
+	})
+	return res
+}
+
+func sortAndDeduplicateExemplars(exemplars []mimirpb.Exemplar) []mimirpb.Exemplar {
+	sort.SliceStable(exemplars, func(i, j int) bool {
+		return exemplars[i].TimestampMs < exemplars[j].TimestampMs
+	})
+
+	res := exemplars[:0]
+	for _, e := range exemplars {
+		if len(res) > 0 && isSameExemplar(res[len(res)-1], e) {
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+func isSameExemplar(a, b mimirpb.Exemplar) bool {
+	return a.TimestampMs == b.TimestampMs && a.Value == b.Value &&
+		labels.Equal(mimirpb.FromLabelAdaptersToLabels(a.Labels), mimirpb.FromLabelAdaptersToLabels(b.Labels))
+}
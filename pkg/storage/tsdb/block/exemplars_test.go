@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestWriteReadExemplarsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("should return no exemplars if the file doesn't exist", func(t *testing.T) {
+		actual, err := ReadExemplarsFile(dir)
+		require.NoError(t, err)
+		assert.Empty(t, actual)
+	})
+
+	t.Run("should read back the written exemplars", func(t *testing.T) {
+		series := []storepb.SeriesExemplars{{
+			Labels: []mimirpb.LabelAdapter{{Name: "__name__", Value: "series_1"}},
+			Exemplars: []mimirpb.Exemplar{
+				{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "1"}}, TimestampMs: 10, Value: 1},
+				{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: "2"}}, TimestampMs: 20, Value: 2},
+			},
+		}}
+
+		require.NoError(t, WriteExemplarsFile(dir, series))
+
+		// The temporary file should have been removed.
+		_, err := os.Stat(filepath.Join(dir, ExemplarsFilename+".tmp"))
+		require.True(t, os.IsNotExist(err))
+
+		actual, err := ReadExemplarsFile(dir)
+		require.NoError(t, err)
+		assert.Equal(t, series, actual)
+	})
+
+	t.Run("should fail on a corrupted file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ExemplarsFilename), []byte("corrupted"), 0o666))
+
+		_, err := ReadExemplarsFile(dir)
+		require.Error(t, err)
+	})
+}
+
+func TestMergeSeriesExemplars(t *testing.T) {
+	series1 := []mimirpb.LabelAdapter{{Name: "__name__", Value: "series_1"}}
+	series2 := []mimirpb.LabelAdapter{{Name: "__name__", Value: "series_2"}}
+	exemplar := func(traceID string, ts int64) mimirpb.Exemplar {
+		return mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: traceID}}, TimestampMs: ts, Value: float64(ts)}
+	}
+
+	actual := MergeSeriesExemplars(
+		[]storepb.SeriesExemplars{
+			{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("a", 30)}},
+			{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("b", 20), exemplar("c", 10)}},
+		},
+		[]storepb.SeriesExemplars{
+			{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("b", 20), exemplar("d", 15)}},
+		},
+	)
+
+	assert.Equal(t, []storepb.SeriesExemplars{
+		{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("c", 10), exemplar("d", 15), exemplar("b", 20)}},
+		{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("a", 30)}},
+	}, actual)
+}
@@ -25,6 +25,7 @@ const (
 	IndexCompressedFilename = IndexFilename + ".gz"
 	IndexVersion1           = 1
 	IndexVersion2           = 2 // Added CompactorShardID field.
+	IndexVersion3           = 3 // Added SizeBytes field.
 	SegmentsFormatUnknown   = ""
 
 	// SegmentsFormat1Based6Digits defined segments numbered with 6 digits numbers in a sequence starting from number 1
@@ -91,6 +92,9 @@ type Block struct {
 
 	// Block's compactor shard ID, copied from tsdb.CompactorShardIDExternalLabel label.
 	CompactorShardID string `json:"compactor_shard_id,omitempty"`
+
+	// SizeBytes is the total size of the block files listed in the meta.json, 0 if unknown.
+	SizeBytes int64 `json:"size_bytes,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -154,7 +158,15 @@ func BlockFromThanosMeta(meta metadata.Meta) *Block {
 		SegmentsFormat:   segmentsFormat,
 		SegmentsNum:      segmentsNum,
 		CompactorShardID: meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel],
+		SizeBytes:        blockSizeBytes(meta),
+	}
+}
+
+func blockSizeBytes(meta metadata.Meta) (size int64) {
+	for _, f := range meta.Thanos.Files {
+		size += f.SizeBytes
 	}
+	return size
 }
 
 func detectBlockSegmentsFormat(meta metadata.Meta) (string, int) {
@@ -257,6 +269,14 @@ func (s Blocks) GetULIDs() []ulid.ULID {
 	return ids
 }
 
+// SizeBytes returns the total size of the blocks. The blocks whose size is unknown are not counted.
+func (s Blocks) SizeBytes() (size int64) {
+	for _, m := range s {
+		size += m.SizeBytes
+	}
+	return size
+}
+
 func (s Blocks) String() string {
 	b := strings.Builder{}
 
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/go-kit/log"
 	"github.com/golang/snappy"
 	"github.com/grafana/dskit/cache"
@@ -26,6 +27,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketcache"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/diskcache"
 )
 
 // subrangeSize is the size of each subrange that bucket objects are split into for better caching
@@ -33,14 +35,25 @@ const subrangeSize int64 = 16000
 
 var supportedCacheBackends = []string{cache.BackendMemcached, cache.BackendRedis}
 
-type ChunksCacheConfig struct {
-	cache.BackendConfig `yaml:",inline"`
+// diskCacheBackfillTTL is the TTL of the items stored on disk after being fetched from the cache backend.
+// The cached items of the blocks are immutable, so they're evicted from the disk because of its size anyway.
+const diskCacheBackfillTTL = 24 * time.Hour
+
+var (
+	errMissingDiskCacheDirectory = errors.New("the disk cache directory is required")
+	errInvalidDiskCacheMaxSize   = errors.New("the disk cache max size must be greater than 0")
+)
 
-	MaxGetRangeRequests             int           `yaml:"max_get_range_requests" category:"advanced"`
-	AttributesTTL                   time.Duration `yaml:"attributes_ttl" category:"advanced"`
-	AttributesInMemoryMaxItems      int           `yaml:"attributes_in_memory_max_items" category:"advanced"`
-	SubrangeTTL                     time.Duration `yaml:"subrange_ttl" category:"advanced"`
-	FineGrainedChunksCachingEnabled bool          `yaml:"fine_grained_chunks_caching_enabled" category:"experimental"`
+type ChunksCacheConfig struct {
+	cache.BackendConfig    `yaml:",inline"`
+	RedisPipeliningEnabled bool `yaml:"redis_pipelining_enabled" category:"experimental"`
+
+	MaxGetRangeRequests             int             `yaml:"max_get_range_requests" category:"advanced"`
+	AttributesTTL                   time.Duration   `yaml:"attributes_ttl" category:"advanced"`
+	AttributesInMemoryMaxItems      int             `yaml:"attributes_in_memory_max_items" category:"advanced"`
+	SubrangeTTL                     time.Duration   `yaml:"subrange_ttl" category:"advanced"`
+	FineGrainedChunksCachingEnabled bool            `yaml:"fine_grained_chunks_caching_enabled" category:"experimental"`
+	Disk                            DiskCacheConfig `yaml:"disk"`
 }
 
 func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string, logger log.Logger) {
@@ -48,6 +61,7 @@ func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 
 	cfg.Memcached.RegisterFlagsWithPrefix(prefix+"memcached.", f)
 	cfg.Redis.RegisterFlagsWithPrefix(prefix+"redis.", f)
+	f.BoolVar(&cfg.RedisPipeliningEnabled, prefix+"redis-pipelining-enabled", false, "Send the lookups of each batch of keys to Redis as a pipeline of GET commands, instead of a MGET command. Enable it when running Redis Cluster, which rejects the MGET commands looking up keys belonging to different hash slots.")
 
 	// TODO: Deprecated in Mimir 2.7, remove in Mimir 2.9
 	flagext.DeprecatedFlag(f, prefix+"subrange-size", fmt.Sprintf("Deprecated, %d bytes is now always used. Size of each subrange that bucket object is split into for better caching.", subrangeSize), logger)
@@ -56,14 +70,59 @@ func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 	f.IntVar(&cfg.AttributesInMemoryMaxItems, prefix+"attributes-in-memory-max-items", 50000, "Maximum number of object attribute items to keep in a first level in-memory LRU cache. Metadata will be stored and fetched in-memory before hitting the cache backend. 0 to disable the in-memory cache.")
 	f.DurationVar(&cfg.SubrangeTTL, prefix+"subrange-ttl", 24*time.Hour, "TTL for caching individual chunks subranges.")
 	f.BoolVar(&cfg.FineGrainedChunksCachingEnabled, prefix+"fine-grained-chunks-caching-enabled", false, "Enable fine-grained caching of chunks in the store-gateway. This reduces the required bandwidth and memory utilization.")
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "./chunks-cache/")
 }
 
 func (cfg *ChunksCacheConfig) Validate() error {
-	return cfg.BackendConfig.Validate()
+	if err := cfg.BackendConfig.Validate(); err != nil {
+		return err
+	}
+	return cfg.Disk.Validate()
+}
+
+// DiskCacheConfig holds the config of the cache tier on the local disk, used in addition to the cache backend.
+type DiskCacheConfig struct {
+	Enabled      bool   `yaml:"enabled" category:"experimental"`
+	Directory    string `yaml:"directory" category:"experimental"`
+	MaxSizeBytes uint64 `yaml:"max_size_bytes" category:"experimental"`
+}
+
+func (cfg *DiskCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix, defaultDirectory string) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable the cache tier on the local disk, in addition to the cache backend. The disk is looked up after the in-memory cache and before the remote caches. The items stored on disk are reused after a restart.")
+	f.StringVar(&cfg.Directory, prefix+"directory", defaultDirectory, "Directory to store the cached items in. The directory should be on a local SSD, and not shared with other caches.")
+	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"max-size-bytes", uint64(10*units.Gibibyte), "Maximum size in bytes of the items stored on disk. The least recently used items are evicted when the limit is exceeded.")
+}
+
+func (cfg *DiskCacheConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Directory == "" {
+		return errMissingDiskCacheDirectory
+	}
+	if cfg.MaxSizeBytes == 0 {
+		return errInvalidDiskCacheMaxSize
+	}
+	return nil
+}
+
+// WrapWithDiskCache wraps the input cache, which may be nil, with a cache tier on the local disk, if enabled.
+func WrapWithDiskCache(name string, c cache.Cache, cfg DiskCacheConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
+	if !cfg.Enabled {
+		return c, nil
+	}
+
+	disk, err := diskcache.New(name, cfg.Directory, cfg.MaxSizeBytes, logger, reg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "create %s disk cache", name)
+	}
+
+	return diskcache.NewTieredCache(disk, c, diskCacheBackfillTTL), nil
 }
 
 type MetadataCacheConfig struct {
-	cache.BackendConfig `yaml:",inline"`
+	cache.BackendConfig    `yaml:",inline"`
+	RedisPipeliningEnabled bool `yaml:"redis_pipelining_enabled" category:"experimental"`
 
 	TenantsListTTL          time.Duration `yaml:"tenants_list_ttl" category:"advanced"`
 	TenantBlocksListTTL     time.Duration `yaml:"tenant_blocks_list_ttl" category:"advanced"`
@@ -83,6 +142,7 @@ func (cfg *MetadataCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix
 
 	cfg.Memcached.RegisterFlagsWithPrefix(prefix+"memcached.", f)
 	cfg.Redis.RegisterFlagsWithPrefix(prefix+"redis.", f)
+	f.BoolVar(&cfg.RedisPipeliningEnabled, prefix+"redis-pipelining-enabled", false, "Send the lookups of each batch of keys to Redis as a pipeline of GET commands, instead of a MGET command. Enable it when running Redis Cluster, which rejects the MGET commands looking up keys belonging to different hash slots.")
 
 	f.DurationVar(&cfg.TenantsListTTL, prefix+"tenants-list-ttl", 15*time.Minute, "How long to cache list of tenants in the bucket.")
 	f.DurationVar(&cfg.TenantBlocksListTTL, prefix+"tenant-blocks-list-ttl", 5*time.Minute, "How long to cache list of blocks for each tenant.")
@@ -105,7 +165,7 @@ func CreateCachingBucket(chunksCache cache.Cache, chunksConfig ChunksCacheConfig
 	cfg := bucketcache.NewCachingBucketConfig()
 	cachingConfigured := false
 
-	metadataCache, err := cache.CreateClient("metadata-cache", metadataConfig.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
+	metadataCache, err := CreateCacheClient("metadata-cache", metadataConfig.BackendConfig, metadataConfig.RedisPipeliningEnabled, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
 	if err != nil {
 		return nil, errors.Wrapf(err, "metadata-cache")
 	}
@@ -6,9 +6,12 @@
 package tsdb
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/flagext"
 	"github.com/oklog/ulid"
 	"github.com/stretchr/testify/assert"
 )
@@ -39,3 +42,109 @@ func TestIsBlockIndexFile(t *testing.T) {
 	assert.True(t, isBlockIndexFile(fmt.Sprintf("%s/index", blockID.String())))
 	assert.True(t, isBlockIndexFile(fmt.Sprintf("/%s/index", blockID.String())))
 }
+
+func TestChunksCacheConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg      ChunksCacheConfig
+		expected error
+	}{
+		"no backend should pass": {
+			cfg: ChunksCacheConfig{},
+		},
+		"no memcached addresses should fail": {
+			cfg: ChunksCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: cache.BackendMemcached,
+				},
+			},
+			expected: cache.ErrNoMemcachedAddresses,
+		},
+		"no redis endpoint should fail": {
+			cfg: ChunksCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: cache.BackendRedis,
+					Redis: cache.RedisClientConfig{
+						MaxAsyncConcurrency: 1,
+					},
+				},
+			},
+			expected: cache.ErrRedisConfigNoEndpoint,
+		},
+		"redis cluster endpoints should pass": {
+			cfg: ChunksCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: cache.BackendRedis,
+					Redis: cache.RedisClientConfig{
+						Endpoint:            flagext.StringSliceCSV{"redis-1:6379", "redis-2:6379", "redis-3:6379"},
+						MaxAsyncConcurrency: 1,
+					},
+				},
+			},
+		},
+		"unsupported backend should fail": {
+			cfg: ChunksCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: "unknown",
+				},
+			},
+			expected: errors.New("unsupported cache backend: unknown"),
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testData.cfg.Validate())
+		})
+	}
+}
+
+func TestMetadataCacheConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg      MetadataCacheConfig
+		expected error
+	}{
+		"no backend should pass": {
+			cfg: MetadataCacheConfig{},
+		},
+		"no redis endpoint should fail": {
+			cfg: MetadataCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: cache.BackendRedis,
+					Redis: cache.RedisClientConfig{
+						MaxAsyncConcurrency: 1,
+					},
+				},
+			},
+			expected: cache.ErrRedisConfigNoEndpoint,
+		},
+		"redis sentinel endpoints should pass": {
+			cfg: MetadataCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: cache.BackendRedis,
+					Redis: cache.RedisClientConfig{
+						Endpoint:            flagext.StringSliceCSV{"sentinel-1:26379", "sentinel-2:26379"},
+						MasterName:          "mymaster",
+						MaxAsyncConcurrency: 1,
+					},
+				},
+			},
+		},
+		"non positive redis max async concurrency should fail": {
+			cfg: MetadataCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: cache.BackendRedis,
+					Redis: cache.RedisClientConfig{
+						Endpoint: flagext.StringSliceCSV{"redis:6379"},
+					},
+				},
+			},
+			expected: cache.ErrRedisMaxAsyncConcurrencyNotPositive,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, testData.cfg.Validate())
+		})
+	}
+}
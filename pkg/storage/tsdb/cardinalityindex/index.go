@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package cardinalityindex summarizes the label names and values of the series of the blocks. The compactor writes
+// the summary of each block it compacts next to the block's meta.json, and the queriers aggregate the summaries
+// of the blocks they query to look up the label names and values without querying the store-gateways.
+package cardinalityindex
+
+import (
+	"sort"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// Block holds the summary of the series of a block.
+type Block struct {
+	// Block ID.
+	ID ulid.ULID
+
+	// MinTime and MaxTime specify the time range all samples in the block are in (millis precision).
+	MinTime int64
+	MaxTime int64
+
+	// Metrics holds the summary of the series of each metric, keyed by metric name.
+	Metrics map[string]*Metric
+}
+
+// Metric holds the summary of the series of a metric in a block.
+type Metric struct {
+	// Number of series of the metric.
+	Series uint64
+
+	// Number of series of the metric by label name and value. The metric name label isn't included.
+	Labels map[string]map[string]uint64
+}
+
+// Blocks holds a set of block summaries.
+type Blocks []*Block
+
+// LabelNames returns the sorted label names of the series of the input metric, or of all the series
+// if the metric name is empty.
+func (s Blocks) LabelNames(metricName string) []string {
+	names := map[string]struct{}{}
+	s.forEachMetric(metricName, func(_ string, m *Metric) {
+		names[labels.MetricName] = struct{}{}
+		for name := range m.Labels {
+			names[name] = struct{}{}
+		}
+	})
+	return sortedKeys(names)
+}
+
+// LabelValues returns the sorted values of the input label of the series of the input metric,
+// or of all the series if the metric name is empty.
+func (s Blocks) LabelValues(labelName, metricName string) []string {
+	values := map[string]struct{}{}
+	s.forEachMetric(metricName, func(name string, m *Metric) {
+		if labelName == labels.MetricName {
+			values[name] = struct{}{}
+			return
+		}
+		for value := range m.Labels[labelName] {
+			values[value] = struct{}{}
+		}
+	})
+	return sortedKeys(values)
+}
+
+// SeriesCountByLabelValue returns the estimated number of series of the input metric (or of all the series
+// if the metric name is empty) by value of the input label. The series of the blocks covering the same time
+// range are added up, and the highest count of all the time ranges is returned, so that a series present in
+// blocks covering different time ranges is counted once. The estimate is higher than the actual number of
+// series if the blocks covering the same time range have series in common, like the non-compacted blocks do.
+func (s Blocks) SeriesCountByLabelValue(labelName, metricName string) map[string]uint64 {
+	type timeRange struct{ minTime, maxTime int64 }
+
+	byTimeRange := map[timeRange]map[string]uint64{}
+	for _, b := range s {
+		tr := timeRange{minTime: b.MinTime, maxTime: b.MaxTime}
+		counts, ok := byTimeRange[tr]
+		if !ok {
+			counts = map[string]uint64{}
+			byTimeRange[tr] = counts
+		}
+
+		Blocks{b}.forEachMetric(metricName, func(name string, m *Metric) {
+			if labelName == labels.MetricName {
+				counts[name] += m.Series
+				return
+			}
+			for value, series := range m.Labels[labelName] {
+				counts[value] += series
+			}
+		})
+	}
+
+	result := map[string]uint64{}
+	for _, counts := range byTimeRange {
+		for value, series := range counts {
+			if series > result[value] {
+				result[value] = series
+			}
+		}
+	}
+	return result
+}
+
+// forEachMetric calls f for each metric with the input name in the blocks, or for all the metrics
+// if the name is empty.
+func (s Blocks) forEachMetric(metricName string, f func(name string, m *Metric)) {
+	for _, b := range s {
+		if metricName != "" {
+			if m, ok := b.Metrics[metricName]; ok {
+				f(metricName, m)
+			}
+			continue
+		}
+		for name, m := range b.Metrics {
+			f(name, m)
+		}
+	}
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
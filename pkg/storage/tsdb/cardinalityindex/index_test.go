@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cardinalityindex
+
+import (
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlocks(t *testing.T) {
+	blocks := Blocks{
+		{
+			ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 10,
+			Metrics: map[string]*Metric{
+				"up":   {Series: 3, Labels: map[string]map[string]uint64{"job": {"a": 2, "b": 1}, "instance": {"1": 1, "2": 1, "3": 1}}},
+				"cpu":  {Series: 2, Labels: map[string]map[string]uint64{"job": {"a": 2}, "mode": {"user": 1, "system": 1}}},
+				"temp": {Series: 1, Labels: map[string]map[string]uint64{}},
+			},
+		},
+		{
+			// Covers the same time range as the first block, like a split compacted block.
+			ID: ulid.MustNew(2, nil), MinTime: 0, MaxTime: 10,
+			Metrics: map[string]*Metric{
+				"up": {Series: 1, Labels: map[string]map[string]uint64{"job": {"c": 1}, "instance": {"4": 1}}},
+			},
+		},
+		{
+			ID: ulid.MustNew(3, nil), MinTime: 10, MaxTime: 20,
+			Metrics: map[string]*Metric{
+				"up": {Series: 2, Labels: map[string]map[string]uint64{"job": {"a": 1, "d": 1}, "instance": {"1": 1, "5": 1}}},
+			},
+		},
+	}
+
+	t.Run("LabelNames", func(t *testing.T) {
+		assert.Equal(t, []string{"__name__", "instance", "job", "mode"}, blocks.LabelNames(""))
+		assert.Equal(t, []string{"__name__", "instance", "job"}, blocks.LabelNames("up"))
+		assert.Equal(t, []string{"__name__"}, blocks.LabelNames("temp"))
+		assert.Empty(t, blocks.LabelNames("unknown"))
+	})
+
+	t.Run("LabelValues", func(t *testing.T) {
+		assert.Equal(t, []string{"cpu", "temp", "up"}, blocks.LabelValues("__name__", ""))
+		assert.Equal(t, []string{"up"}, blocks.LabelValues("__name__", "up"))
+		assert.Equal(t, []string{"a", "b", "c", "d"}, blocks.LabelValues("job", ""))
+		assert.Equal(t, []string{"a"}, blocks.LabelValues("job", "cpu"))
+		assert.Empty(t, blocks.LabelValues("mode", "up"))
+		assert.Empty(t, blocks.LabelValues("job", "unknown"))
+	})
+
+	t.Run("SeriesCountByLabelValue", func(t *testing.T) {
+		assert.Equal(t, map[string]uint64{"cpu": 2, "temp": 1, "up": 4}, blocks.SeriesCountByLabelValue("__name__", ""))
+		assert.Equal(t, map[string]uint64{"a": 4, "b": 1, "c": 1, "d": 1}, blocks.SeriesCountByLabelValue("job", ""))
+		assert.Equal(t, map[string]uint64{"a": 2, "b": 1, "c": 1, "d": 1}, blocks.SeriesCountByLabelValue("job", "up"))
+		assert.Empty(t, blocks.SeriesCountByLabelValue("job", "unknown"))
+	})
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cardinalityindex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+)
+
+const (
+	// readSummaryTimeout is the maximum allowed time when reading a single cardinality summary
+	// from the storage. It's hard-coded to a reasonably high value.
+	readSummaryTimeout = 15 * time.Second
+
+	// loadConcurrency is the maximum number of cardinality summaries concurrently read from the storage
+	// for a single lookup.
+	loadConcurrency = 16
+
+	// maxLoadedSummaries is the maximum number of entries kept by the Loader, including the ones caching
+	// a missing summary.
+	maxLoadedSummaries = 100000
+
+	// DefaultMaxLoadedSymbols is the default maximum number of symbols of the summaries kept in memory by the Loader.
+	DefaultMaxLoadedSymbols = 8 * MaxSummarySymbols
+)
+
+// ErrLookupTooLarge is returned when the summaries of the blocks to look up don't fit in the Loader.
+var ErrLookupTooLarge = errors.New("cardinality summaries of the blocks exceed the loader capacity")
+
+// Loader lazy loads the cardinality summaries of the blocks, and keeps the most recently used ones in memory,
+// up to a maximum number of symbols in total. The blocks without a summary, and the summaries which failed to
+// be loaded, are cached for the TTL, to avoid hammering the object store.
+type Loader struct {
+	bkt         objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	ttl         time.Duration
+	maxSymbols  int
+	logger      log.Logger
+
+	mtx     sync.Mutex
+	lru     *lru.LRU
+	symbols int
+
+	// Metrics.
+	loadAttempts  prometheus.Counter
+	loadFailures  prometheus.Counter
+	loadedSymbols prometheus.Gauge
+}
+
+type summaryKey struct {
+	userID  string
+	blockID ulid.ULID
+}
+
+type cachedSummary struct {
+	block    *Block
+	symbols  int
+	err      error
+	loadedAt time.Time
+}
+
+// NewLoader makes a new Loader keeping up to maxSymbols symbols in memory.
+func NewLoader(ttl time.Duration, maxSymbols int, bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) (*Loader, error) {
+	l := &Loader{
+		bkt:         bkt,
+		cfgProvider: cfgProvider,
+		ttl:         ttl,
+		maxSymbols:  maxSymbols,
+		logger:      logger,
+
+		loadAttempts: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_cardinality_summary_loads_total",
+			Help: "Total number of block cardinality summary loading attempts.",
+		}),
+		loadFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_cardinality_summary_load_failures_total",
+			Help: "Total number of block cardinality summary loading failures.",
+		}),
+		loadedSymbols: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_cardinality_summary_loaded_symbols",
+			Help: "Number of symbols of the block cardinality summaries kept in memory.",
+		}),
+	}
+
+	var err error
+	l.lru, err = lru.NewLRU(maxLoadedSummaries, l.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GetBlocks returns the summaries of the input blocks of the user, loading the ones which aren't in memory from
+// the storage. It returns ErrSummaryNotFound if any of the blocks has no summary, and ErrLookupTooLarge if their
+// summaries don't fit in the Loader, in which case the blocks must be queried through the store-gateways.
+func (l *Loader) GetBlocks(ctx context.Context, userID string, blocks bucketindex.Blocks) (Blocks, error) {
+	userBkt := bucket.NewUserBucketClient(userID, l.bkt, l.cfgProvider)
+
+	var (
+		symbolsMx sync.Mutex
+		symbols   int
+	)
+
+	result := make(Blocks, len(blocks))
+	err := concurrency.ForEachJob(ctx, len(blocks), loadConcurrency, func(ctx context.Context, idx int) error {
+		b, blockSymbols, err := l.getBlock(ctx, userBkt, userID, blocks[idx])
+		if err != nil {
+			return err
+		}
+
+		symbolsMx.Lock()
+		defer symbolsMx.Unlock()
+
+		// The summaries are referenced until the lookup is done, even if they're evicted in the meanwhile.
+		symbols += blockSymbols
+		if symbols > l.maxSymbols {
+			return ErrLookupTooLarge
+		}
+		result[idx] = b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (l *Loader) getBlock(ctx context.Context, userBkt objstore.InstrumentedBucketReader, userID string, meta *bucketindex.Block) (*Block, int, error) {
+	key := summaryKey{userID: userID, blockID: meta.ID}
+	now := time.Now()
+
+	l.mtx.Lock()
+	if v, ok := l.lru.Get(key); ok {
+		entry := v.(*cachedSummary)
+
+		// Blocks are immutable, so a loaded summary never expires.
+		if entry.err == nil || now.Sub(entry.loadedAt) < l.ttl {
+			l.mtx.Unlock()
+			return entry.block, entry.symbols, entry.err
+		}
+	}
+	l.mtx.Unlock()
+
+	readCtx, cancel := context.WithTimeout(ctx, readSummaryTimeout)
+	defer cancel()
+
+	l.loadAttempts.Inc()
+	entry := &cachedSummary{loadedAt: now}
+	s, err := ReadSummary(readCtx, userBkt, meta.ID, l.logger)
+	if err == nil {
+		entry.block, err = s.Block(meta.ID, meta.MinTime, meta.MaxTime)
+		entry.symbols = len(s.Symbols)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			// The lookup has been canceled, so the summary may be there.
+			return nil, 0, err
+		}
+		if !errors.Is(err, ErrSummaryNotFound) {
+			l.loadFailures.Inc()
+			level.Warn(l.logger).Log("msg", "unable to load cardinality summary", "user", userID, "block", meta.ID.String(), "err", err)
+		}
+		entry.block, entry.symbols, entry.err = nil, 0, err
+	}
+
+	l.add(key, entry)
+	return entry.block, entry.symbols, entry.err
+}
+
+// add adds the input entry to the LRU, evicting the least recently used entries to make room for it.
+// The summaries larger than the Loader aren't kept.
+func (l *Loader) add(key summaryKey, entry *cachedSummary) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if entry.symbols > l.maxSymbols {
+		return
+	}
+
+	// Replacing an entry doesn't call onEvict, so the old one is removed first.
+	l.lru.Remove(key)
+	for l.symbols+entry.symbols > l.maxSymbols {
+		l.lru.RemoveOldest()
+	}
+
+	l.lru.Add(key, entry)
+	l.symbols += entry.symbols
+	l.loadedSymbols.Set(float64(l.symbols))
+}
+
+// onEvict is called with the lock held.
+func (l *Loader) onEvict(_, val interface{}) {
+	l.symbols -= val.(*cachedSummary).symbols
+	l.loadedSymbols.Set(float64(l.symbols))
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cardinalityindex
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestLoader_GetBlocks_ShouldCacheTheSummariesAndErrors(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	block1 := &bucketindex.Block{ID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 10}
+	block2 := &bucketindex.Block{ID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 20}
+	uploadTestSummary(t, userBkt, block1.ID, "up")
+
+	loader, err := NewLoader(time.Hour, DefaultMaxLoadedSymbols, bkt, nil, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	// The second block has no summary yet.
+	_, err = loader.GetBlocks(ctx, userID, bucketindex.Blocks{block1, block2})
+	require.Equal(t, ErrSummaryNotFound, err)
+
+	// Upload the missing summary. The not found error is cached.
+	uploadTestSummary(t, userBkt, block2.ID, "cpu")
+	_, err = loader.GetBlocks(ctx, userID, bucketindex.Blocks{block2})
+	require.Equal(t, ErrSummaryNotFound, err)
+
+	// The loaded summaries are cached, even if they're deleted from the storage.
+	require.NoError(t, userBkt.Delete(ctx, path.Join(block1.ID.String(), block.CardinalitySummaryFilename)))
+	for i := 0; i < 2; i++ {
+		blocks, err := loader.GetBlocks(ctx, userID, bucketindex.Blocks{block1})
+		require.NoError(t, err)
+		assert.Equal(t, Blocks{{ID: block1.ID, MinTime: 0, MaxTime: 10, Metrics: map[string]*Metric{"up": {Series: 1, Labels: map[string]map[string]uint64{}}}}}, blocks)
+	}
+
+	// A lookup of no blocks doesn't load anything.
+	blocks, err := loader.GetBlocks(ctx, userID, nil)
+	require.NoError(t, err)
+	assert.Empty(t, blocks)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_cardinality_summary_load_failures_total Total number of block cardinality summary loading failures.
+		# TYPE cortex_cardinality_summary_load_failures_total counter
+		cortex_cardinality_summary_load_failures_total 0
+		# HELP cortex_cardinality_summary_loaded_symbols Number of symbols of the block cardinality summaries kept in memory.
+		# TYPE cortex_cardinality_summary_loaded_symbols gauge
+		cortex_cardinality_summary_loaded_symbols 1
+		# HELP cortex_cardinality_summary_loads_total Total number of block cardinality summary loading attempts.
+		# TYPE cortex_cardinality_summary_loads_total counter
+		cortex_cardinality_summary_loads_total 2
+	`)))
+}
+
+func TestLoader_GetBlocks_ShouldBoundTheLoadedSymbols(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	var blocks bucketindex.Blocks
+	for i, metricName := range []string{"up", "cpu", "temp"} {
+		b := &bucketindex.Block{ID: ulid.MustNew(uint64(i), nil)}
+		uploadTestSummary(t, userBkt, b.ID, metricName)
+		blocks = append(blocks, b)
+	}
+
+	loader, err := NewLoader(time.Hour, 2, bkt, nil, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	// The summaries of the three blocks don't fit in the loader.
+	_, err = loader.GetBlocks(ctx, userID, blocks)
+	require.Equal(t, ErrLookupTooLarge, err)
+
+	// The least recently used summaries are evicted to make room for the new ones.
+	for _, b := range blocks {
+		_, err = loader.GetBlocks(ctx, userID, bucketindex.Blocks{b})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, loader.lru.Len())
+	assert.False(t, loader.lru.Contains(summaryKey{userID: userID, blockID: blocks[0].ID}))
+	assert.Equal(t, 2.0, testutil.ToFloat64(loader.loadedSymbols))
+}
+
+// uploadTestSummary uploads the summary of a block with a single series of the input metric.
+func uploadTestSummary(t *testing.T, userBkt objstore.Bucket, blockID ulid.ULID, metricName string) {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), block.CardinalitySummaryFilename)
+	require.NoError(t, writeSummary(filename, &Summary{
+		Version: SummaryVersion1,
+		Symbols: []string{metricName},
+		Metrics: []SummaryMetric{{Name: 0, Series: 1, Labels: []SummaryLabel{}}},
+	}))
+
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, userBkt.Upload(context.Background(), path.Join(blockID.String(), block.CardinalitySummaryFilename), f))
+}
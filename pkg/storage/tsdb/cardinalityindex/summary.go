@@ -0,0 +1,285 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cardinalityindex
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const (
+	SummaryVersion1 = 1
+
+	// MaxSummarySymbols is the maximum number of distinct metric names, label names and label values of the
+	// series of a block which can be summarized. The blocks with more don't get a summary, and are always
+	// queried through the store-gateways.
+	MaxSummarySymbols = 1 << 20
+)
+
+var (
+	ErrSummaryNotFound  = errors.New("cardinality summary not found")
+	ErrSummaryCorrupted = errors.New("cardinality summary corrupted")
+	ErrSummaryTooLarge  = errors.New("cardinality summary too large")
+)
+
+// Summary is the content of the cardinality summary file of a block. Each metric name, label name and label
+// value is stored once in the symbols table, and referenced by its position in it.
+type Summary struct {
+	// Version of the summary format.
+	Version int `json:"version"`
+
+	// Symbols referenced by the metrics.
+	Symbols []string `json:"symbols"`
+
+	// Metrics holds the summary of the series of each metric.
+	Metrics []SummaryMetric `json:"metrics"`
+}
+
+// SummaryMetric holds the summary of the series of a metric.
+type SummaryMetric struct {
+	// Symbol of the metric name.
+	Name uint32 `json:"name"`
+
+	// Number of series of the metric.
+	Series uint64 `json:"series"`
+
+	// Number of series of the metric by label name and value. The metric name label isn't included.
+	Labels []SummaryLabel `json:"labels"`
+}
+
+// SummaryLabel holds the number of series of a metric by value of a label.
+type SummaryLabel struct {
+	// Symbol of the label name.
+	Name uint32 `json:"name"`
+
+	// Symbols of the label values, and their number of series in the same order.
+	Values []uint32 `json:"values"`
+	Series []uint64 `json:"series"`
+}
+
+// Block returns the summary of the series of the input block, decoded from s.
+func (s *Summary) Block(id ulid.ULID, minTime, maxTime int64) (*Block, error) {
+	if s.Version != SummaryVersion1 {
+		return nil, errors.Wrapf(ErrSummaryCorrupted, "unsupported version %d", s.Version)
+	}
+
+	symbol := func(ref uint32) (string, bool) {
+		if int(ref) >= len(s.Symbols) {
+			return "", false
+		}
+		return s.Symbols[ref], true
+	}
+
+	b := &Block{ID: id, MinTime: minTime, MaxTime: maxTime, Metrics: make(map[string]*Metric, len(s.Metrics))}
+	for _, sm := range s.Metrics {
+		metricName, ok := symbol(sm.Name)
+		if !ok {
+			return nil, ErrSummaryCorrupted
+		}
+
+		m := &Metric{Series: sm.Series, Labels: make(map[string]map[string]uint64, len(sm.Labels))}
+		for _, sl := range sm.Labels {
+			labelName, ok := symbol(sl.Name)
+			if !ok || len(sl.Values) != len(sl.Series) {
+				return nil, ErrSummaryCorrupted
+			}
+
+			values := make(map[string]uint64, len(sl.Values))
+			for i, ref := range sl.Values {
+				value, ok := symbol(ref)
+				if !ok {
+					return nil, ErrSummaryCorrupted
+				}
+				values[value] = sl.Series[i]
+			}
+			m.Labels[labelName] = values
+		}
+		b.Metrics[metricName] = m
+	}
+	return b, nil
+}
+
+// WriteSummaryFile summarizes the series of the block in the input directory, and writes the summary into
+// <dir>/cardinality-summary.json.gz, to be uploaded with the block. It returns ErrSummaryTooLarge if the
+// block has more than MaxSummarySymbols symbols, in which case no file is written.
+func WriteSummaryFile(dir string) error {
+	r, err := index.NewFileReader(filepath.Join(dir, block.IndexFilename))
+	if err != nil {
+		return errors.Wrap(err, "open block index file")
+	}
+	defer r.Close()
+
+	s, err := summarizeIndex(r, MaxSummarySymbols)
+	if err != nil {
+		return err
+	}
+
+	// Make any changes to the file appear atomic.
+	filename := filepath.Join(dir, block.CardinalitySummaryFilename)
+	tmp := filename + ".tmp"
+
+	if err := writeSummary(tmp, s); err != nil {
+		return err
+	}
+	return errors.Wrap(os.Rename(tmp, filename), "rename cardinality summary")
+}
+
+func writeSummary(filename string, s *Summary) (returnErr error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "create cardinality summary")
+	}
+	defer func() {
+		if err := f.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close cardinality summary")
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(f)
+	if err := json.NewEncoder(gzipWriter).Encode(s); err != nil {
+		return errors.Wrap(err, "encode cardinality summary")
+	}
+	return errors.Wrap(gzipWriter.Close(), "gzip cardinality summary")
+}
+
+// ReadSummary reads and parses the cardinality summary of the input block from the tenant's bucket.
+func ReadSummary(ctx context.Context, bkt objstore.InstrumentedBucketReader, id ulid.ULID, logger log.Logger) (*Summary, error) {
+	reader, err := bkt.ReaderWithExpectedErrs(bkt.IsObjNotFoundErr).Get(ctx, path.Join(id.String(), block.CardinalitySummaryFilename))
+	if err != nil {
+		if bkt.IsObjNotFoundErr(err) {
+			return nil, ErrSummaryNotFound
+		}
+		return nil, errors.Wrap(err, "read cardinality summary")
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close cardinality summary reader")
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, ErrSummaryCorrupted
+	}
+	defer runutil.CloseWithLogOnErr(logger, gzipReader, "close cardinality summary gzip reader")
+
+	s := &Summary{}
+	if err := json.NewDecoder(gzipReader).Decode(s); err != nil {
+		return nil, ErrSummaryCorrupted
+	}
+	return s, nil
+}
+
+// summarizeIndex returns the summary of the series of each metric of the input block index. It returns
+// ErrSummaryTooLarge if the series have more than maxSymbols distinct metric names, label names and values.
+func summarizeIndex(r *index.Reader, maxSymbols int) (*Summary, error) {
+	metricNames, err := r.SortedLabelValues(labels.MetricName)
+	if err != nil {
+		return nil, errors.Wrap(err, "read metric names")
+	}
+
+	s := &Summary{Version: SummaryVersion1, Metrics: make([]SummaryMetric, 0, len(metricNames))}
+	refs := map[string]uint32{}
+	symbol := func(value string) (uint32, error) {
+		if ref, ok := refs[value]; ok {
+			return ref, nil
+		}
+		if len(s.Symbols) >= maxSymbols {
+			return 0, ErrSummaryTooLarge
+		}
+
+		// The strings read from the index reference the index file, which is unmapped once the reader is closed.
+		value = strings.Clone(value)
+		ref := uint32(len(s.Symbols))
+		refs[value] = ref
+		s.Symbols = append(s.Symbols, value)
+		return ref, nil
+	}
+
+	var builder labels.ScratchBuilder
+	for _, metricName := range metricNames {
+		nameRef, err := symbol(metricName)
+		if err != nil {
+			return nil, err
+		}
+
+		postings, err := r.Postings(labels.MetricName, metricName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read postings of metric %s", metricName)
+		}
+
+		m := SummaryMetric{Name: nameRef}
+		series := map[uint32]map[uint32]uint64{}
+		for postings.Next() {
+			if err := r.Series(postings.At(), &builder, nil); err != nil {
+				return nil, errors.Wrapf(err, "read series of metric %s", metricName)
+			}
+
+			m.Series++
+			var symbolErr error
+			builder.Labels().Range(func(l labels.Label) {
+				if l.Name == labels.MetricName || symbolErr != nil {
+					return
+				}
+				labelRef, err := symbol(l.Name)
+				if err != nil {
+					symbolErr = err
+					return
+				}
+				valueRef, err := symbol(l.Value)
+				if err != nil {
+					symbolErr = err
+					return
+				}
+
+				values, ok := series[labelRef]
+				if !ok {
+					values = map[uint32]uint64{}
+					series[labelRef] = values
+				}
+				values[valueRef]++
+			})
+			if symbolErr != nil {
+				return nil, symbolErr
+			}
+		}
+		if err := postings.Err(); err != nil {
+			return nil, errors.Wrapf(err, "iterate postings of metric %s", metricName)
+		}
+
+		m.Labels = make([]SummaryLabel, 0, len(series))
+		for labelRef, values := range series {
+			l := SummaryLabel{Name: labelRef, Values: make([]uint32, 0, len(values)), Series: make([]uint64, 0, len(values))}
+			for _, valueRef := range sortedRefs(values) {
+				l.Values = append(l.Values, valueRef)
+				l.Series = append(l.Series, values[valueRef])
+			}
+			m.Labels = append(m.Labels, l)
+		}
+		sort.Slice(m.Labels, func(i, j int) bool { return m.Labels[i].Name < m.Labels[j].Name })
+
+		s.Metrics = append(s.Metrics, m)
+	}
+	return s, nil
+}
+
+func sortedRefs(m map[uint32]uint64) []uint32 {
+	refs := make([]uint32, 0, len(m))
+	for ref := range m {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+	return refs
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cardinalityindex
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+	"github.com/grafana/mimir/pkg/storegateway/testhelper"
+)
+
+var testSeries = []labels.Labels{
+	labels.FromStrings("__name__", "up", "job", "a", "instance", "1"),
+	labels.FromStrings("__name__", "up", "job", "a", "instance", "2"),
+	labels.FromStrings("__name__", "up", "job", "b", "instance", "1"),
+	labels.FromStrings("__name__", "cpu", "job", "a"),
+}
+
+func TestWriteSummaryFile_ShouldBeUploadedWithTheBlockAndReadBack(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	blocksDir := t.TempDir()
+	blockID, err := testhelper.CreateBlock(ctx, blocksDir, testSeries, 10, 0, 1000, labels.FromStrings("ext1", "val1"))
+	require.NoError(t, err)
+
+	blockDir := filepath.Join(blocksDir, blockID.String())
+	require.NoError(t, WriteSummaryFile(blockDir))
+	require.NoError(t, block.Upload(ctx, logger, userBkt, blockDir, nil))
+
+	s, err := ReadSummary(ctx, userBkt, blockID, logger)
+	require.NoError(t, err)
+	assert.Equal(t, SummaryVersion1, s.Version)
+	assert.ElementsMatch(t, []string{"cpu", "job", "a", "up", "instance", "1", "2", "b"}, s.Symbols)
+
+	b, err := s.Block(blockID, 0, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, &Block{
+		ID:      blockID,
+		MinTime: 0,
+		MaxTime: 1000,
+		Metrics: map[string]*Metric{
+			"up":  {Series: 3, Labels: map[string]map[string]uint64{"job": {"a": 2, "b": 1}, "instance": {"1": 2, "2": 1}}},
+			"cpu": {Series: 1, Labels: map[string]map[string]uint64{"job": {"a": 1}}},
+		},
+	}, b)
+}
+
+func TestWriteSummaryFile_ShouldNotWriteTooLargeSummaries(t *testing.T) {
+	blocksDir := t.TempDir()
+	blockID, err := testhelper.CreateBlock(context.Background(), blocksDir, testSeries, 10, 0, 1000, labels.EmptyLabels())
+	require.NoError(t, err)
+
+	r, err := index.NewFileReader(filepath.Join(blocksDir, blockID.String(), block.IndexFilename))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, r.Close()) })
+
+	_, err = summarizeIndex(r, 8)
+	require.NoError(t, err)
+	_, err = summarizeIndex(r, 7)
+	require.Equal(t, ErrSummaryTooLarge, err)
+}
+
+func TestReadSummary_ShouldReturnErrorIfSummaryDoesNotExist(t *testing.T) {
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	s, err := ReadSummary(context.Background(), bucket.NewUserBucketClient("user-1", bkt, nil), ulid.MustNew(1, nil), log.NewNopLogger())
+	require.Equal(t, ErrSummaryNotFound, err)
+	require.Nil(t, s)
+}
+
+func TestReadSummary_ShouldReturnErrorIfSummaryIsCorrupted(t *testing.T) {
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+	blockID := ulid.MustNew(1, nil)
+
+	// Write a corrupted summary.
+	require.NoError(t, userBkt.Upload(ctx, path.Join(blockID.String(), block.CardinalitySummaryFilename), strings.NewReader("invalid!}")))
+
+	s, err := ReadSummary(ctx, userBkt, blockID, log.NewNopLogger())
+	require.Equal(t, ErrSummaryCorrupted, err)
+	require.Nil(t, s)
+}
+
+func TestSummary_Block_ShouldReturnErrorIfSymbolsAreMissing(t *testing.T) {
+	s := &Summary{
+		Version: SummaryVersion1,
+		Symbols: []string{"up", "job"},
+		Metrics: []SummaryMetric{{Name: 0, Series: 1, Labels: []SummaryLabel{{Name: 1, Values: []uint32{2}, Series: []uint64{1}}}}},
+	}
+
+	_, err := s.Block(ulid.MustNew(1, nil), 0, 1000)
+	require.ErrorIs(t, err, ErrSummaryCorrupted)
+
+	s.Version = 2
+	_, err = s.Block(ulid.MustNew(1, nil), 0, 1000)
+	require.ErrorIs(t, err, ErrSummaryCorrupted)
+}
@@ -7,6 +7,7 @@ package tsdb
 
 import (
 	"flag"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/wlog"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	"github.com/grafana/mimir/pkg/storegateway/indexheader"
@@ -86,15 +88,23 @@ const (
 
 // Validation errors
 var (
-	errInvalidShipConcurrency       = errors.New("invalid TSDB ship concurrency")
-	errInvalidOpeningConcurrency    = errors.New("invalid TSDB opening concurrency")
-	errInvalidCompactionInterval    = errors.New("invalid TSDB compaction interval")
-	errInvalidCompactionConcurrency = errors.New("invalid TSDB compaction concurrency")
-	errInvalidWALSegmentSizeBytes   = errors.New("invalid TSDB WAL segment size bytes")
-	errInvalidWALReplayConcurrency  = errors.New("invalid TSDB WAL replay concurrency")
-	errInvalidStripeSize            = errors.New("invalid TSDB stripe size")
-	errInvalidStreamingBatchSize    = errors.New("invalid store-gateway streaming batch size")
-	errEmptyBlockranges             = errors.New("empty block ranges for TSDB")
+	errInvalidShipConcurrency           = errors.New("invalid TSDB ship concurrency")
+	errInvalidOpeningConcurrency        = errors.New("invalid TSDB opening concurrency")
+	errInvalidCompactionInterval        = errors.New("invalid TSDB compaction interval")
+	errInvalidCompactionConcurrency     = errors.New("invalid TSDB compaction concurrency")
+	errInvalidWALSegmentSizeBytes       = errors.New("invalid TSDB WAL segment size bytes")
+	errInvalidWALReplayConcurrency      = errors.New("invalid TSDB WAL replay concurrency")
+	errInvalidStripeSize                = errors.New("invalid TSDB stripe size")
+	errInvalidStreamingBatchSize        = errors.New("invalid store-gateway streaming batch size")
+	errInvalidHotSeriesSetsConfig       = errors.New("invalid store-gateway hot series sets config: the min queries and the tracking period must be greater than 0")
+	errInvalidMaxConcurrentDownloads    = errors.New("invalid store-gateway max concurrent downloads, the value must be greater than or equal to 0")
+	errInvalidIndexHeaderEvictionPolicy = errors.New("invalid index-header lazy loading eviction policy")
+	errInvalidHedgedRequestsConfig      = errors.New("invalid store-gateway hedged requests config: the latency percentile must be between 0 and 100, the min delay must be greater than or equal to 0 and the max hedged requests per second must be greater than 0")
+	errInvalidCoresPerPartition         = errors.New("invalid store-gateway cores per partition, must be greater than or equal to 0")
+	errInvalidSeriesDecodingWorkers     = errors.New("invalid store-gateway series decoding workers per partition, must be greater than 0")
+	errInvalidTenantQueryPool           = errors.New("invalid store-gateway per-tenant query concurrency, the max concurrent and max queued queries must be greater than or equal to 0")
+	errSameDiskCacheDirectory           = errors.New("the index cache and the chunks cache can't use the same disk cache directory")
+	errEmptyBlockranges                 = errors.New("empty block ranges for TSDB")
 )
 
 // BlocksStorageConfig holds the config information for the blocks storage.
@@ -335,16 +345,26 @@ type BucketStoreConfig struct {
 	ChunkPoolMinBucketSizeBytes int    `yaml:"chunk_pool_min_bucket_size_bytes" category:"advanced"`
 	ChunkPoolMaxBucketSizeBytes int    `yaml:"chunk_pool_max_bucket_size_bytes" category:"advanced"`
 
+	// Partitioning of the chunks pool and of the series decoding workers per group of CPU cores.
+	CoresPerPartition                 int `yaml:"cores_per_partition" category:"experimental"`
+	SeriesDecodingWorkersPerPartition int `yaml:"series_decoding_workers_per_partition" category:"experimental"`
+
 	// Series hash cache.
-	SeriesHashCacheMaxBytes uint64 `yaml:"series_hash_cache_max_size_bytes" category:"advanced"`
+	SeriesHashCacheMaxBytes           uint64 `yaml:"series_hash_cache_max_size_bytes" category:"advanced"`
+	SeriesHashCachePersistenceEnabled bool   `yaml:"series_hash_cache_persistence_enabled" category:"experimental"`
 
 	// Controls whether index-header lazy loading is enabled.
-	IndexHeaderLazyLoadingEnabled     bool          `yaml:"index_header_lazy_loading_enabled" category:"advanced"`
-	IndexHeaderLazyLoadingIdleTimeout time.Duration `yaml:"index_header_lazy_loading_idle_timeout" category:"advanced"`
+	IndexHeaderLazyLoadingEnabled        bool          `yaml:"index_header_lazy_loading_enabled" category:"advanced"`
+	IndexHeaderLazyLoadingIdleTimeout    time.Duration `yaml:"index_header_lazy_loading_idle_timeout" category:"advanced"`
+	IndexHeaderLazyLoadingEvictionPolicy string        `yaml:"index_header_lazy_loading_eviction_policy" category:"experimental"`
 
 	// Controls the partitioner, used to aggregate multiple GET object API requests.
 	PartitionerMaxGapBytes uint64 `yaml:"partitioner_max_gap_bytes" category:"advanced"`
 
+	// Controls the readahead of the chunks, used to aggregate the GET object requests of consecutive batches.
+	ChunksReadaheadMaxBytes           uint64 `yaml:"chunks_readahead_max_bytes" category:"experimental"`
+	ChunksReadaheadMaxBytesPerRequest uint64 `yaml:"chunks_readahead_max_bytes_per_request" category:"experimental"`
+
 	// Controls what is the ratio of postings offsets store will hold in memory.
 	// Larger value will keep less offsets, which will increase CPU cycles needed for query touching those postings.
 	// It's meant for setups that want low baseline memory pressure and where less traffic is expected.
@@ -355,8 +375,28 @@ type BucketStoreConfig struct {
 	// Controls experimental options for index-header file reading.
 	IndexHeader indexheader.Config `yaml:"index_header" category:"experimental"`
 
-	StreamingBatchSize   int `yaml:"streaming_series_batch_size" category:"advanced"`
-	ChunkRangesPerSeries int `yaml:"fine_grained_chunks_caching_ranges_per_series" category:"experimental"`
+	StreamingBatchSize              int    `yaml:"streaming_series_batch_size" category:"advanced"`
+	StreamingMaxBufferedChunksBytes uint64 `yaml:"streaming_series_max_buffered_chunks_bytes" category:"experimental"`
+	ChunkRangesPerSeries            int    `yaml:"fine_grained_chunks_caching_ranges_per_series" category:"experimental"`
+
+	// Hot series sets.
+	HotSeriesSetsMaxBytesPerTenant uint64        `yaml:"hot_series_sets_max_size_bytes_per_tenant" category:"experimental"`
+	HotSeriesSetsMinQueries        int           `yaml:"hot_series_sets_min_queries" category:"experimental"`
+	HotSeriesSetsTrackingPeriod    time.Duration `yaml:"hot_series_sets_tracking_period" category:"experimental"`
+
+	// Blocks downloads throttling.
+	MaxConcurrentDownloads    int    `yaml:"max_concurrent_downloads" category:"experimental"`
+	MaxDownloadBandwidthBytes uint64 `yaml:"max_download_bandwidth_bytes" category:"experimental"`
+
+	// Hedged requests to the long-term storage.
+	HedgedRequests HedgedRequestsConfig `yaml:"hedged_requests"`
+
+	// Per-tenant query concurrency.
+	MaxConcurrentPerTenant int `yaml:"max_concurrent_per_tenant" category:"experimental"`
+	MaxQueuedPerTenant     int `yaml:"max_queued_per_tenant" category:"experimental"`
+
+	// Local replica of the blocks.
+	LocalReplicaDir string `yaml:"local_replica_dir" category:"experimental"`
 }
 
 // RegisterFlags registers the BucketStore flags
@@ -366,13 +406,18 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger)
 	cfg.MetadataCache.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.metadata-cache.")
 	cfg.BucketIndex.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.bucket-index.")
 	cfg.IndexHeader.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.index-header.")
+	cfg.HedgedRequests.RegisterFlagsWithPrefix(f, "blocks-storage.bucket-store.hedged-requests.")
 
 	f.StringVar(&cfg.SyncDir, "blocks-storage.bucket-store.sync-dir", "./tsdb-sync/", "Directory to store synchronized TSDB index headers. This directory is not required to be persisted between restarts, but it's highly recommended in order to improve the store-gateway startup time.")
 	f.DurationVar(&cfg.SyncInterval, "blocks-storage.bucket-store.sync-interval", 15*time.Minute, "How frequently to scan the bucket, or to refresh the bucket index (if enabled), in order to look for changes (new blocks shipped by ingesters and blocks deleted by retention or compaction).")
 	f.Uint64Var(&cfg.MaxChunkPoolBytes, "blocks-storage.bucket-store.max-chunk-pool-bytes", uint64(2*units.Gibibyte), "Max size - in bytes - of a chunks pool, used to reduce memory allocations. The pool is shared across all tenants. 0 to disable the limit.")
 	f.IntVar(&cfg.ChunkPoolMinBucketSizeBytes, "blocks-storage.bucket-store.chunk-pool-min-bucket-size-bytes", ChunkPoolDefaultMinBucketSize, "Size - in bytes - of the smallest chunks pool bucket.")
 	f.IntVar(&cfg.ChunkPoolMaxBucketSizeBytes, "blocks-storage.bucket-store.chunk-pool-max-bucket-size-bytes", ChunkPoolDefaultMaxBucketSize, "Size - in bytes - of the largest chunks pool bucket.")
+	f.IntVar(&cfg.CoresPerPartition, "blocks-storage.bucket-store.cores-per-partition", 0, "If greater than 0, the chunks of the series are loaded and decoded by workers partitioned per group of this number of CPU cores, as given by GOMAXPROCS. Each partition has its own workers and its own shard of the chunks pool, and the shards share -blocks-storage.bucket-store.max-chunk-pool-bytes, to reduce the lock contention on store-gateways with many CPU cores. Each query runs on a single partition. 0 to disable.")
+	f.IntVar(&cfg.SeriesDecodingWorkersPerPartition, "blocks-storage.bucket-store.series-decoding-workers-per-partition", 32, "Max number of workers of each partition loading and decoding the chunks of the series, when -blocks-storage.bucket-store.cores-per-partition is enabled. The workers read the chunks from the long-term storage too, so they should outnumber the CPU cores of a partition.")
 	f.Uint64Var(&cfg.SeriesHashCacheMaxBytes, "blocks-storage.bucket-store.series-hash-cache-max-size-bytes", uint64(1*units.Gibibyte), "Max size - in bytes - of the in-memory series hash cache. The cache is shared across all tenants and it's used only when query sharding is enabled.")
+	f.StringVar(&cfg.LocalReplicaDir, "blocks-storage.bucket-store.local-replica-dir", "", "Read-only directory containing a replica of the blocks of the long-term storage, with the same layout as the bucket, like a local or NFS mount. The store-gateway reads the files of the blocks of the tenants with -store-gateway.local-replica-enabled from this directory, and falls back to the long-term storage for the files not found in it. The blocks are still discovered from the long-term storage. Empty to disable.")
+	f.BoolVar(&cfg.SeriesHashCachePersistenceEnabled, "blocks-storage.bucket-store.series-hash-cache-persistence-enabled", false, "If enabled, the series hashes computed by the sharded queries are persisted to a file in the local directory of each block, and loaded into the series hash cache the first time the block is queried by a sharded query, like after a restart.")
 	f.IntVar(&cfg.MaxConcurrent, "blocks-storage.bucket-store.max-concurrent", 100, "Max number of concurrent queries to execute against the long-term storage. The limit is shared across all tenants.")
 	f.IntVar(&cfg.TenantSyncConcurrency, "blocks-storage.bucket-store.tenant-sync-concurrency", 10, "Maximum number of concurrent tenants synching blocks.")
 	f.IntVar(&cfg.BlockSyncConcurrency, "blocks-storage.bucket-store.block-sync-concurrency", 20, "Maximum number of concurrent blocks synching per tenant.")
@@ -384,9 +429,20 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet, logger log.Logger)
 	f.IntVar(&cfg.PostingOffsetsInMemSampling, "blocks-storage.bucket-store.posting-offsets-in-mem-sampling", DefaultPostingOffsetInMemorySampling, "Controls what is the ratio of postings offsets that the store will hold in memory.")
 	f.BoolVar(&cfg.IndexHeaderLazyLoadingEnabled, "blocks-storage.bucket-store.index-header-lazy-loading-enabled", true, "If enabled, store-gateway will lazy load an index-header only once required by a query.")
 	f.DurationVar(&cfg.IndexHeaderLazyLoadingIdleTimeout, "blocks-storage.bucket-store.index-header-lazy-loading-idle-timeout", 60*time.Minute, "If index-header lazy loading is enabled and this setting is > 0, the store-gateway will offload unused index-headers after 'idle timeout' inactivity.")
+	f.StringVar(&cfg.IndexHeaderLazyLoadingEvictionPolicy, "blocks-storage.bucket-store.index-header-lazy-loading-eviction-policy", indexheader.EvictionPolicyLRU, fmt.Sprintf("Policy selecting the index-headers offloaded first when the lazy loaded index-headers of a tenant exceed -store-gateway.max-resident-index-header-bytes. Supported values are: %s. lru offloads the least recently queried index-headers first, size offloads the largest index-headers first.", strings.Join(indexheader.EvictionPolicies, ", ")))
 	f.Uint64Var(&cfg.PartitionerMaxGapBytes, "blocks-storage.bucket-store.partitioner-max-gap-bytes", DefaultPartitionerMaxGapSize, "Max size - in bytes - of a gap for which the partitioner aggregates together two bucket GET object requests.")
+	f.Uint64Var(&cfg.ChunksReadaheadMaxBytes, "blocks-storage.bucket-store.chunks-readahead-max-bytes", 0, "Max size - in bytes - of the range of a segment file read ahead of the next batch of series of a query, when the batches read the segment file sequentially. The readahead starts with the size of the batch and doubles at each batch reading the segment file sequentially, so that the next batches get their chunks from memory instead of issuing many small GET object requests. The bytes read past the end of the last chunk of a batch, because its length is estimated, are kept too. The bytes kept are taken from the chunks pool. 0 to disable.")
+	f.Uint64Var(&cfg.ChunksReadaheadMaxBytesPerRequest, "blocks-storage.bucket-store.chunks-readahead-max-bytes-per-request", uint64(64*units.Mebibyte), "Max size - in bytes - of the ranges of the segment files read ahead by a single query. Once reached, the next batches of series are loaded without readahead. 0 to disable the limit.")
 	f.IntVar(&cfg.StreamingBatchSize, "blocks-storage.bucket-store.batch-series-size", 5000, "This option controls how many series to fetch per batch. The batch size must be greater than 0.")
+	f.Uint64Var(&cfg.StreamingMaxBufferedChunksBytes, "blocks-storage.bucket-store.batch-series-max-buffered-chunks-bytes", 0, "Max size - in bytes - of the chunks loaded by a Series() request and not sent to the querier yet. The loading of the next batches of series is paused until the chunks fit in this memory budget. A batch is always loaded if no chunks are buffered, even if bigger than the budget. 0 to disable.")
 	f.IntVar(&cfg.ChunkRangesPerSeries, "blocks-storage.bucket-store.fine-grained-chunks-caching-ranges-per-series", 1, "This option controls into how many ranges the chunks of each series from each block are split. This value is effectively the number of chunks cache items per series per block when -blocks-storage.bucket-store.chunks-cache.fine-grained-chunks-caching-enabled is enabled.")
+	f.Uint64Var(&cfg.HotSeriesSetsMaxBytesPerTenant, "blocks-storage.bucket-store.hot-series-sets-max-size-bytes-per-tenant", 0, "Max size - in bytes - of the expanded postings kept in memory, per tenant, for the selectors most frequently queried, so that their queries skip the postings expansion. The expanded postings are refreshed when blocks are loaded or dropped. 0 to disable.")
+	f.IntVar(&cfg.HotSeriesSetsMinQueries, "blocks-storage.bucket-store.hot-series-sets-min-queries", 10, "Minimum number of queries of a selector within a tracking period for its expanded postings to be kept in memory.")
+	f.DurationVar(&cfg.HotSeriesSetsTrackingPeriod, "blocks-storage.bucket-store.hot-series-sets-tracking-period", 10*time.Minute, "Period over which the queries of each selector are counted to identify the most frequently queried selectors.")
+	f.IntVar(&cfg.MaxConcurrentDownloads, "blocks-storage.bucket-store.max-concurrent-downloads", 0, "Max number of concurrent downloads from the long-term storage, shared across all tenants. When the limit is reached, the downloads triggered by queries are started before the ones triggered by the background blocks synchronization. 0 to disable the limit.")
+	f.Uint64Var(&cfg.MaxDownloadBandwidthBytes, "blocks-storage.bucket-store.max-download-bandwidth-bytes", 0, "Max bandwidth - in bytes per second - of the downloads from the long-term storage, shared across all tenants. The downloads triggered by queries are never delayed, but their bandwidth is deducted from the one available to the downloads triggered by the background blocks synchronization, such as the index-headers building. 0 to disable the limit.")
+	f.IntVar(&cfg.MaxConcurrentPerTenant, "blocks-storage.bucket-store.max-concurrent-per-tenant", 0, "Max number of concurrent queries of a single tenant to execute against the long-term storage. The queries of a tenant wait for one of these slots before waiting for one of the -blocks-storage.bucket-store.max-concurrent ones, so that a tenant can't take all the slots shared across all tenants. 0 to disable the limit.")
+	f.IntVar(&cfg.MaxQueuedPerTenant, "blocks-storage.bucket-store.max-queued-per-tenant", 0, "Max number of queries of a single tenant waiting because of -blocks-storage.bucket-store.max-concurrent-per-tenant. The queries received once the limit is reached are rejected. 0 to disable the limit.")
 }
 
 // Validate the config.
@@ -394,6 +450,27 @@ func (cfg *BucketStoreConfig) Validate(logger log.Logger) error {
 	if cfg.StreamingBatchSize <= 0 {
 		return errInvalidStreamingBatchSize
 	}
+	if cfg.HotSeriesSetsMaxBytesPerTenant > 0 && (cfg.HotSeriesSetsMinQueries <= 0 || cfg.HotSeriesSetsTrackingPeriod <= 0) {
+		return errInvalidHotSeriesSetsConfig
+	}
+	if cfg.MaxConcurrentDownloads < 0 {
+		return errInvalidMaxConcurrentDownloads
+	}
+	if cfg.MaxConcurrentPerTenant < 0 || cfg.MaxQueuedPerTenant < 0 {
+		return errInvalidTenantQueryPool
+	}
+	if cfg.CoresPerPartition < 0 {
+		return errInvalidCoresPerPartition
+	}
+	if cfg.SeriesDecodingWorkersPerPartition < 1 {
+		return errInvalidSeriesDecodingWorkers
+	}
+	if err := cfg.HedgedRequests.Validate(); err != nil {
+		return err
+	}
+	if !slices.Contains(indexheader.EvictionPolicies, cfg.IndexHeaderLazyLoadingEvictionPolicy) {
+		return errInvalidIndexHeaderEvictionPolicy
+	}
 	if err := cfg.IndexCache.Validate(); err != nil {
 		return errors.Wrap(err, "index-cache configuration")
 	}
@@ -403,17 +480,45 @@ func (cfg *BucketStoreConfig) Validate(logger log.Logger) error {
 	if err := cfg.MetadataCache.Validate(); err != nil {
 		return errors.Wrap(err, "metadata-cache configuration")
 	}
+	if cfg.IndexCache.Disk.Enabled && cfg.ChunksCache.Disk.Enabled && filepath.Clean(cfg.IndexCache.Disk.Directory) == filepath.Clean(cfg.ChunksCache.Disk.Directory) {
+		return errSameDiskCacheDirectory
+	}
 	if cfg.DeprecatedConsistencyDelay > 0 {
 		util.WarnDeprecatedConfig(consistencyDelayFlag, logger)
 	}
 	return nil
 }
 
+type HedgedRequestsConfig struct {
+	Enabled           bool          `yaml:"enabled" category:"experimental"`
+	LatencyPercentile float64       `yaml:"latency_percentile" category:"experimental"`
+	MinDelay          time.Duration `yaml:"min_delay" category:"experimental"`
+	MaxPerSecond      float64       `yaml:"max_per_second" category:"experimental"`
+}
+
+func (cfg *HedgedRequestsConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "If enabled, the store-gateway sends a second GET object request for the chunks and index reads of the queries whose first request to the long-term storage is slower than usual, and uses the response of whichever request completes first.")
+	f.Float64Var(&cfg.LatencyPercentile, prefix+"latency-percentile", 95, "Percentile of the recent latencies of the GET object requests after which a request is hedged. No request is hedged until enough latencies have been observed.")
+	f.DurationVar(&cfg.MinDelay, prefix+"min-delay", 50*time.Millisecond, "Minimum time to wait for the first request before sending the hedged one, regardless of the latency percentile.")
+	f.Float64Var(&cfg.MaxPerSecond, prefix+"max-per-second", 10, "Maximum number of hedged requests per second, shared across all tenants. The requests which would be hedged above this budget are not.")
+}
+
+func (cfg *HedgedRequestsConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.LatencyPercentile <= 0 || cfg.LatencyPercentile >= 100 || cfg.MinDelay < 0 || cfg.MaxPerSecond <= 0 {
+		return errInvalidHedgedRequestsConfig
+	}
+	return nil
+}
+
 type BucketIndexConfig struct {
 	Enabled               bool          `yaml:"enabled"`
 	UpdateOnErrorInterval time.Duration `yaml:"update_on_error_interval" category:"advanced"`
 	IdleTimeout           time.Duration `yaml:"idle_timeout" category:"advanced"`
 	MaxStalePeriod        time.Duration `yaml:"max_stale_period" category:"advanced"`
+	StaleFallbackEnabled  bool          `yaml:"stale_fallback_enabled" category:"experimental"`
 }
 
 func (cfg *BucketIndexConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
@@ -421,4 +526,5 @@ func (cfg *BucketIndexConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 	f.DurationVar(&cfg.UpdateOnErrorInterval, prefix+"update-on-error-interval", time.Minute, "How frequently a bucket index, which previously failed to load, should be tried to load again. This option is used only by querier.")
 	f.DurationVar(&cfg.IdleTimeout, prefix+"idle-timeout", time.Hour, "How long a unused bucket index should be cached. Once this timeout expires, the unused bucket index is removed from the in-memory cache. This option is used only by querier.")
 	f.DurationVar(&cfg.MaxStalePeriod, prefix+"max-stale-period", time.Hour, "The maximum allowed age of a bucket index (last updated) before queries start failing because the bucket index is too old. The bucket index is periodically updated by the compactor, and this check is enforced in the querier (at query time).")
+	f.BoolVar(&cfg.StaleFallbackEnabled, prefix+"stale-fallback-enabled", false, "If enabled, queriers and store-gateways discover the blocks of a tenant whose bucket index is older than the max stale period by scanning the tenant's blocks in the bucket, instead of failing the queries of the tenant (querier) or using the stale bucket index (store-gateway).")
 }
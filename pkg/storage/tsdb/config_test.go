@@ -127,6 +127,48 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: errInvalidStreamingBatchSize,
 		},
+		"should fail on negative store-gateway cores per partition": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.CoresPerPartition = -1
+			},
+			expectedErr: errInvalidCoresPerPartition,
+		},
+		"should fail on invalid store-gateway series decoding workers per partition": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.SeriesDecodingWorkersPerPartition = 0
+			},
+			expectedErr: errInvalidSeriesDecodingWorkers,
+		},
+		"should fail on invalid store-gateway hot series sets min queries": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.HotSeriesSetsMaxBytesPerTenant = 1024
+				cfg.BucketStore.HotSeriesSetsMinQueries = 0
+			},
+			expectedErr: errInvalidHotSeriesSetsConfig,
+		},
+		"should fail on invalid store-gateway hedged requests latency percentile": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.HedgedRequests.Enabled = true
+				cfg.BucketStore.HedgedRequests.LatencyPercentile = 100
+			},
+			expectedErr: errInvalidHedgedRequestsConfig,
+		},
+		"should fail on invalid store-gateway hedged requests max per second": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.HedgedRequests.Enabled = true
+				cfg.BucketStore.HedgedRequests.MaxPerSecond = 0
+			},
+			expectedErr: errInvalidHedgedRequestsConfig,
+		},
+		"should fail if the index cache and the chunks cache use the same disk cache directory": {
+			setup: func(cfg *BlocksStorageConfig) {
+				cfg.BucketStore.IndexCache.Disk.Enabled = true
+				cfg.BucketStore.IndexCache.Disk.Directory = "./cache/"
+				cfg.BucketStore.ChunksCache.Disk.Enabled = true
+				cfg.BucketStore.ChunksCache.Disk.Directory = "./cache"
+			},
+			expectedErr: errSameDiskCacheDirectory,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -17,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/grafana/mimir/pkg/storegateway/diskcache"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/util"
 )
@@ -44,8 +45,10 @@ var (
 )
 
 type IndexCacheConfig struct {
-	cache.BackendConfig `yaml:",inline"`
-	InMemory            InMemoryIndexCacheConfig `yaml:"inmemory"`
+	cache.BackendConfig    `yaml:",inline"`
+	RedisPipeliningEnabled bool                     `yaml:"redis_pipelining_enabled" category:"experimental"`
+	InMemory               InMemoryIndexCacheConfig `yaml:"inmemory"`
+	Disk                   DiskCacheConfig          `yaml:"disk"`
 }
 
 func (cfg *IndexCacheConfig) RegisterFlags(f *flag.FlagSet) {
@@ -58,6 +61,8 @@ func (cfg *IndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix str
 	cfg.InMemory.RegisterFlagsWithPrefix(prefix+"inmemory.", f)
 	cfg.Memcached.RegisterFlagsWithPrefix(prefix+"memcached.", f)
 	cfg.Redis.RegisterFlagsWithPrefix(prefix+"redis.", f)
+	f.BoolVar(&cfg.RedisPipeliningEnabled, prefix+"redis-pipelining-enabled", false, "Send the lookups of each batch of keys to Redis as a pipeline of GET commands, instead of a MGET command. Enable it when running Redis Cluster, which rejects the MGET commands looking up keys belonging to different hash slots.")
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "./index-cache/")
 }
 
 // Validate the config.
@@ -73,7 +78,7 @@ func (cfg *IndexCacheConfig) Validate() error {
 		}
 	}
 
-	return nil
+	return cfg.Disk.Validate()
 }
 
 type InMemoryIndexCacheConfig struct {
@@ -86,16 +91,46 @@ func (cfg *InMemoryIndexCacheConfig) RegisterFlagsWithPrefix(prefix string, f *f
 
 // NewIndexCache creates a new index cache based on the input configuration.
 func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+	var (
+		c   indexcache.IndexCache
+		err error
+	)
+
 	switch cfg.Backend {
 	case IndexCacheBackendInMemory:
-		return newInMemoryIndexCache(cfg.InMemory, logger, registerer)
+		c, err = newInMemoryIndexCache(cfg.InMemory, logger, registerer)
 	case IndexCacheBackendMemcached:
-		return newMemcachedIndexCache(cfg.Memcached, logger, registerer)
+		c, err = newMemcachedIndexCache(cfg.Memcached, logger, registerer)
 	case IndexCacheBackendRedis:
-		return newRedisIndexCache(cfg.Redis, logger, registerer)
+		c, err = newRedisIndexCache(cfg.Redis, cfg.RedisPipeliningEnabled, logger, registerer)
 	default:
 		return nil, errUnsupportedIndexCacheBackend
 	}
+	if err != nil || !cfg.Disk.Enabled {
+		return c, err
+	}
+
+	return newDiskTieredIndexCache(c, cfg.Backend, cfg.Disk, logger, registerer)
+}
+
+// newDiskTieredIndexCache adds a cache tier on the local disk to the input index cache. The disk is
+// looked up after the in-memory cache, but before the remote ones.
+func newDiskTieredIndexCache(c indexcache.IndexCache, backend string, cfg DiskCacheConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+	disk, err := diskcache.New("index-cache", cfg.Directory, cfg.MaxSizeBytes, logger, registerer)
+	if err != nil {
+		return nil, errors.Wrap(err, "create index cache disk cache")
+	}
+
+	// The disk cache tracks its own metrics, so the ones of the index cache are not registered.
+	diskIndexCache, err := indexcache.NewRemoteIndexCache(logger, disk, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "create disk-based index cache")
+	}
+
+	if backend == IndexCacheBackendInMemory {
+		return indexcache.NewTieredIndexCache(c, diskIndexCache), nil
+	}
+	return indexcache.NewTieredIndexCache(diskIndexCache, c), nil
 }
 
 func newInMemoryIndexCache(cfg InMemoryIndexCacheConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
@@ -127,8 +162,8 @@ func newMemcachedIndexCache(cfg cache.MemcachedClientConfig, logger log.Logger,
 	return indexcache.NewTracingIndexCache(c, logger), nil
 }
 
-func newRedisIndexCache(cfg cache.RedisClientConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
-	client, err := cache.NewRedisClient(logger, "index-cache", cfg, prometheus.WrapRegistererWithPrefix("thanos_", registerer))
+func newRedisIndexCache(cfg cache.RedisClientConfig, pipelining bool, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+	client, err := newRedisClient("index-cache", cfg, pipelining, logger, prometheus.WrapRegistererWithPrefix("thanos_", registerer))
 	if err != nil {
 		return nil, errors.Wrap(err, "create index cache redis client")
 	}
@@ -8,9 +8,14 @@ package tsdb
 import (
 	"testing"
 
+	"github.com/go-kit/log"
 	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 )
 
 func TestIndexCacheConfig_Validate(t *testing.T) {
@@ -78,6 +83,30 @@ func TestIndexCacheConfig_Validate(t *testing.T) {
 				},
 			},
 		},
+		"disk cache without directory should fail": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendInMemory,
+				},
+				Disk: DiskCacheConfig{
+					Enabled:      true,
+					MaxSizeBytes: 1024,
+				},
+			},
+			expected: errMissingDiskCacheDirectory,
+		},
+		"disk cache with zero max size should fail": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendInMemory,
+				},
+				Disk: DiskCacheConfig{
+					Enabled:   true,
+					Directory: "./index-cache/",
+				},
+			},
+			expected: errInvalidDiskCacheMaxSize,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -86,3 +115,18 @@ func TestIndexCacheConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestNewIndexCache_WithDiskCache(t *testing.T) {
+	cfg := IndexCacheConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.Disk.Enabled = true
+	cfg.Disk.Directory = t.TempDir()
+
+	c, err := NewIndexCache(cfg, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	assert.IsType(t, &indexcache.TieredIndexCache{}, c)
+
+	// The in-memory cache supports the invalidation of the blocks.
+	_, ok := c.(indexcache.BlockInvalidator)
+	assert.True(t, ok)
+}
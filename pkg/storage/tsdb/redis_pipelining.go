@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-redis/redis/v8"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CreateCacheClient creates the cache client of the input backend config like cache.CreateClient does. When the
+// backend is Redis and the pipelining is enabled, the lookups of each batch of keys are sent in a single pipeline.
+func CreateCacheClient(cacheName string, cfg cache.BackendConfig, redisPipelining bool, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
+	if cfg.Backend != cache.BackendRedis || !redisPipelining {
+		return cache.CreateClient(cacheName, cfg, logger, reg)
+	}
+
+	client, err := newRedisClient(cacheName, cfg.Redis, redisPipelining, logger, reg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create redis client")
+	}
+	return cache.NewRedisCache(cacheName, logger, client, reg), nil
+}
+
+// newRedisClient creates a Redis client, sending the lookups of each batch of keys in a single pipeline if enabled.
+func newRedisClient(cacheName string, cfg cache.RedisClientConfig, pipelining bool, logger log.Logger, reg prometheus.Registerer) (cache.RemoteCacheClient, error) {
+	client, err := cache.NewRedisClient(logger, cacheName, cfg, reg)
+	if err != nil || !pipelining {
+		return client, err
+	}
+
+	// The dskit client embeds the go-redis client, which is used to send the pipelines.
+	redisClient, ok := client.(redis.UniversalClient)
+	if !ok {
+		return nil, errors.New("the redis client doesn't support pipelining")
+	}
+
+	return &pipeliningRedisClient{
+		RemoteCacheClient: client,
+		redis:             redisClient,
+		batchSize:         cfg.MaxGetMultiBatchSize,
+		concurrency:       cfg.MaxGetMultiConcurrency,
+		logger:            log.With(logger, "name", cacheName),
+	}, nil
+}
+
+// pipeliningRedisClient is a Redis client sending the lookups of each batch of keys as a pipeline of GET
+// commands, instead of a MGET command. Unlike MGET, the pipelines can look up keys belonging to different
+// hash slots when running Redis Cluster, because the commands are split by node.
+type pipeliningRedisClient struct {
+	cache.RemoteCacheClient
+
+	redis       redis.UniversalClient
+	batchSize   int
+	concurrency int
+	logger      log.Logger
+}
+
+// GetMulti implements cache.RemoteCacheClient.
+func (c *pipeliningRedisClient) GetMulti(ctx context.Context, keys []string, _ ...cache.Option) map[string][]byte {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	batchSize := c.batchSize
+	if batchSize <= 0 {
+		batchSize = len(keys)
+	}
+	batches := (len(keys) + batchSize - 1) / batchSize
+
+	maxConcurrency := c.concurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = batches
+	}
+
+	var mtx sync.Mutex
+	results := make(map[string][]byte, len(keys))
+
+	err := concurrency.ForEachJob(ctx, batches, maxConcurrency, func(ctx context.Context, idx int) error {
+		batch := keys[idx*batchSize:]
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+
+		pipe := c.redis.Pipeline()
+		cmds := make([]*redis.StringCmd, 0, len(batch))
+		for _, key := range batch {
+			cmds = append(cmds, pipe.Get(ctx, key))
+		}
+
+		// The misses are returned as redis.Nil errors of the single commands.
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			level.Warn(c.logger).Log("msg", "failed to get items from redis in a pipeline", "err", err, "items", len(batch))
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		for i, cmd := range cmds {
+			if value, err := cmd.Bytes(); err == nil {
+				results[batch[i]] = value
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to get items from redis in a pipeline", "err", err, "items", len(keys))
+		return nil
+	}
+	return results
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tsdb
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeliningRedisClient_GetMulti(t *testing.T) {
+	server := newFakeRedisServer(t, map[string]string{"key-1": "value-1", "key-3": "value-3", "key-4": "value-4"})
+
+	for name, pipelining := range map[string]bool{"pipelining disabled": false, "pipelining enabled": true} {
+		t.Run(name, func(t *testing.T) {
+			server.resetCommands()
+
+			cfg := cache.RedisClientConfig{}
+			cfg.RegisterFlagsWithPrefix("", flag.NewFlagSet("", flag.PanicOnError))
+			cfg.Endpoint = []string{server.addr}
+			cfg.MaxGetMultiBatchSize = 2
+
+			client, err := newRedisClient("test", cfg, pipelining, log.NewNopLogger(), nil)
+			require.NoError(t, err)
+			t.Cleanup(client.Stop)
+
+			assert.Equal(t, map[string][]byte{
+				"key-1": []byte("value-1"),
+				"key-3": []byte("value-3"),
+				"key-4": []byte("value-4"),
+			}, client.GetMulti(context.Background(), []string{"key-1", "key-2", "key-3", "key-4", "key-5"}))
+
+			if pipelining {
+				assert.Equal(t, map[string]int{"get": 5}, server.getCommands())
+			} else {
+				assert.Equal(t, map[string]int{"mget": 3}, server.getCommands())
+			}
+		})
+	}
+}
+
+// fakeRedisServer is a Redis server answering the GET and MGET commands with fixed items, and
+// counting the commands received.
+type fakeRedisServer struct {
+	addr  string
+	items map[string]string
+
+	mtx      sync.Mutex
+	commands map[string]int
+}
+
+func newFakeRedisServer(t *testing.T, items map[string]string) *fakeRedisServer {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &fakeRedisServer{addr: listener.Addr().String(), items: items, commands: map[string]int{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedisServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRedisCommand(r)
+		if err != nil {
+			return
+		}
+
+		name := strings.ToLower(args[0])
+		s.mtx.Lock()
+		s.commands[name]++
+		s.mtx.Unlock()
+
+		var reply string
+		switch name {
+		case "get":
+			reply = s.bulkString(args[1])
+		case "mget":
+			reply = fmt.Sprintf("*%d\r\n", len(args)-1)
+			for _, key := range args[1:] {
+				reply += s.bulkString(key)
+			}
+		default:
+			reply = "+OK\r\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedisServer) bulkString(key string) string {
+	value, ok := s.items[key]
+	if !ok {
+		return "$-1\r\n"
+	}
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func (s *fakeRedisServer) getCommands() map[string]int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	commands := map[string]int{}
+	for name, count := range s.commands {
+		commands[name] = count
+	}
+	return commands
+}
+
+func (s *fakeRedisServer) resetCommands() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.commands = map[string]int{}
+}
+
+// readRedisCommand reads a command sent by a client as an array of bulk strings.
+func readRedisCommand(r *bufio.Reader) ([]string, error) {
+	readLine := func() (string, error) {
+		line, err := r.ReadString('\n')
+		return strings.TrimSuffix(line, "\r\n"), err
+	}
+
+	line, err := readLine()
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimPrefix(line, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		if _, err := readLine(); err != nil {
+			return nil, err
+		}
+		arg, err := readLine()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
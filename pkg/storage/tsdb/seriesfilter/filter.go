@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package seriesfilter
+
+import (
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+const (
+	// bitsPerPair and numHashes give a false positive rate of about 1%.
+	bitsPerPair = 10
+	numHashes   = 7
+
+	// minFilterBits is the min size of a filter, to keep a low false positive rate for the blocks with few pairs.
+	minFilterBits = 64
+)
+
+// Filter is a bloom filter of the label name and value pairs of the series of a block. It can tell that a block
+// has no series matching a set of matchers, while it may report false positives.
+type Filter struct {
+	// Bits of the bloom filter.
+	Bits []byte `json:"bits"`
+
+	// Number of hash functions.
+	Hashes int `json:"hashes"`
+}
+
+// NewFilter returns an empty filter sized for the input number of label name and value pairs.
+func NewFilter(pairs int) *Filter {
+	numBits := pairs * bitsPerPair
+	if numBits < minFilterBits {
+		numBits = minFilterBits
+	}
+	return &Filter{
+		Bits:   make([]byte, (numBits+7)/8),
+		Hashes: numHashes,
+	}
+}
+
+// Add adds the label name and value pair to the filter.
+func (f *Filter) Add(name, value string) {
+	h1, h2 := pairHashes(name, value)
+	numBits := uint32(len(f.Bits) * 8)
+	for i := 0; i < f.Hashes; i++ {
+		bit := (h1 + uint32(i)*h2) % numBits
+		f.Bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// MayContain returns false if the label name and value pair hasn't been added to the filter.
+func (f *Filter) MayContain(name, value string) bool {
+	numBits := uint32(len(f.Bits) * 8)
+	if numBits == 0 {
+		return true
+	}
+
+	h1, h2 := pairHashes(name, value)
+	for i := 0; i < f.Hashes; i++ {
+		bit := (h1 + uint32(i)*h2) % numBits
+		if f.Bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MayMatch returns false if no series added to the filter can match all the input matchers. Only the equality
+// matchers, and the regexp matchers of a set of values, are checked against the filter, while the other
+// matchers are assumed to match.
+func (f *Filter) MayMatch(matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		var values []string
+		switch m.Type {
+		case labels.MatchEqual:
+			values = []string{m.Value}
+		case labels.MatchRegexp:
+			values = m.SetMatches()
+		}
+
+		if len(values) > 0 && !f.mayContainAny(m.Name, values) {
+			return false
+		}
+	}
+	return true
+}
+
+// mayContainAny returns false if none of the pairs of the label name and the input values have been added to
+// the filter. An empty value matches the series without the label, so the filter can't rule it out.
+func (f *Filter) mayContainAny(name string, values []string) bool {
+	for _, value := range values {
+		if value == "" || f.MayContain(name, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// pairHashes returns the two hashes of the label name and value pair, combined to compute the bits of the
+// filter with the double hashing technique.
+func pairHashes(name, value string) (uint32, uint32) {
+	d := xxhash.New()
+	_, _ = d.WriteString(name)
+	_, _ = d.Write([]byte{0xff})
+	_, _ = d.WriteString(value)
+	h := d.Sum64()
+	return uint32(h), uint32(h>>32) | 1
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package seriesfilter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter(t *testing.T) {
+	const pairs = 1000
+
+	f := NewFilter(pairs)
+	for i := 0; i < pairs; i++ {
+		f.Add("pod", fmt.Sprintf("pod-%d", i))
+	}
+
+	// The added pairs are always reported.
+	for i := 0; i < pairs; i++ {
+		require.True(t, f.MayContain("pod", fmt.Sprintf("pod-%d", i)))
+	}
+
+	// The false positive rate of the pairs not added is low.
+	falsePositives := 0
+	for i := pairs; i < 10*pairs; i++ {
+		if f.MayContain("pod", fmt.Sprintf("pod-%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 9*pairs/50)
+
+	// The label name is part of the pair.
+	assert.False(t, f.MayContain("instance", "pod-0"))
+}
+
+func TestFilter_MayMatch(t *testing.T) {
+	f := NewFilter(3)
+	f.Add(labels.MetricName, "up")
+	f.Add("job", "a")
+	f.Add("job", "b")
+
+	tests := map[string]struct {
+		matchers []*labels.Matcher
+		expected bool
+	}{
+		"no matchers": {
+			expected: true,
+		},
+		"equal matchers of existing pairs": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"), labels.MustNewMatcher(labels.MatchEqual, "job", "a")},
+			expected: true,
+		},
+		"equal matcher of a non-existing pair": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"), labels.MustNewMatcher(labels.MatchEqual, "job", "c")},
+			expected: false,
+		},
+		"equal matcher of an empty value": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "instance", "")},
+			expected: true,
+		},
+		"regexp matcher of a set of values including an existing pair": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "job", "c|b")},
+			expected: true,
+		},
+		"regexp matcher of a set of non-existing pairs": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "job", "c|d")},
+			expected: false,
+		},
+		"regexp matcher which isn't a set of values": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "job", "c.*")},
+			expected: true,
+		},
+		"not equal matcher": {
+			matchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchNotEqual, "job", "c")},
+			expected: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, f.MayMatch(tc.matchers))
+		})
+	}
+}
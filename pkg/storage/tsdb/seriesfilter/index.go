@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package seriesfilter
+
+import (
+	"time"
+
+	"github.com/oklog/ulid"
+)
+
+const (
+	IndexFilename           = "series-filter.json"
+	IndexCompressedFilename = IndexFilename + ".gz"
+	IndexVersion1           = 1
+)
+
+// Index is the series filter index of a tenant. It holds a filter of the series of each block of the tenant,
+// so that the blocks which can't contain the series matching a query can be skipped without reading them.
+type Index struct {
+	// Version of the index format.
+	Version int `json:"version"`
+
+	// List of the filtered blocks.
+	Blocks []*Block `json:"blocks"`
+
+	// UpdatedAt is a unix timestamp (seconds precision) of when the index has been updated
+	// (written in the storage) the last time.
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+func (idx *Index) GetUpdatedAt() time.Time {
+	return time.Unix(idx.UpdatedAt, 0)
+}
+
+// Filters returns the filters of the blocks in the index, by block ID.
+func (idx *Index) Filters() map[ulid.ULID]*Filter {
+	filters := make(map[ulid.ULID]*Filter, len(idx.Blocks))
+	for _, b := range idx.Blocks {
+		filters[b.ID] = b.Filter
+	}
+	return filters
+}
+
+// Block holds the filter of the series of a block.
+type Block struct {
+	// Block ID.
+	ID ulid.ULID `json:"block_id"`
+
+	// Filter of the label name and value pairs of the series of the block.
+	Filter *Filter `json:"filter"`
+}
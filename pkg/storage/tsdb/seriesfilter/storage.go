@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package seriesfilter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+var (
+	ErrIndexNotFound  = errors.New("series filter index not found")
+	ErrIndexCorrupted = errors.New("series filter index corrupted")
+)
+
+// ReadIndex reads, parses and returns a series filter index from the bucket.
+func ReadIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) (*Index, error) {
+	userBkt := bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	// Get the series filter index.
+	reader, err := userBkt.WithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, IndexCompressedFilename)
+	if err != nil {
+		if userBkt.IsObjNotFoundErr(err) {
+			return nil, ErrIndexNotFound
+		}
+		return nil, errors.Wrap(err, "read series filter index")
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close series filter index reader")
+
+	// Read all the content.
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, ErrIndexCorrupted
+	}
+	defer runutil.CloseWithLogOnErr(logger, gzipReader, "close series filter index gzip reader")
+
+	// Deserialize it.
+	index := &Index{}
+	d := json.NewDecoder(gzipReader)
+	if err := d.Decode(index); err != nil {
+		return nil, ErrIndexCorrupted
+	}
+
+	return index, nil
+}
+
+// WriteIndex uploads the provided index to the storage.
+func WriteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, idx *Index) error {
+	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	// Marshal the index.
+	content, err := json.Marshal(idx)
+	if err != nil {
+		return errors.Wrap(err, "marshal series filter index")
+	}
+
+	// Compress it.
+	var gzipContent bytes.Buffer
+	gzip := gzip.NewWriter(&gzipContent)
+	gzip.Name = IndexFilename
+
+	if _, err := gzip.Write(content); err != nil {
+		return errors.Wrap(err, "gzip series filter index")
+	}
+	if err := gzip.Close(); err != nil {
+		return errors.Wrap(err, "close gzip series filter index")
+	}
+
+	// Upload the index to the storage.
+	if err := bkt.Upload(ctx, IndexCompressedFilename, &gzipContent); err != nil {
+		return errors.Wrap(err, "upload series filter index")
+	}
+
+	return nil
+}
+
+// DeleteIndex deletes the series filter index from the storage. No error is returned if the index
+// does not exist.
+func DeleteIndex(ctx context.Context, bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider) error {
+	bkt = bucket.NewUserBucketClient(userID, bkt, cfgProvider)
+
+	err := bkt.Delete(ctx, IndexCompressedFilename)
+	if err != nil && !bkt.IsObjNotFoundErr(err) {
+		return errors.Wrap(err, "delete series filter index")
+	}
+	return nil
+}
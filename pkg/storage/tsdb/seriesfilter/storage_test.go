@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package seriesfilter
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestReadIndex_ShouldReturnErrorIfIndexDoesNotExist(t *testing.T) {
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	idx, err := ReadIndex(context.Background(), bkt, "user-1", nil, log.NewNopLogger())
+	require.Equal(t, ErrIndexNotFound, err)
+	require.Nil(t, idx)
+}
+
+func TestReadIndex_ShouldReturnErrorIfIndexIsCorrupted(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	// Write a corrupted index.
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, IndexCompressedFilename), strings.NewReader("invalid!}")))
+
+	idx, err := ReadIndex(ctx, bkt, userID, nil, log.NewNopLogger())
+	require.Equal(t, ErrIndexCorrupted, err)
+	require.Nil(t, idx)
+}
+
+func TestWriteIndex_ShouldBeReadBackAndDeleted(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+
+	expectedIdx := &Index{
+		Version: IndexVersion1,
+		Blocks: []*Block{{
+			ID:     ulid.MustNew(1, nil),
+			Filter: &Filter{Bits: []byte{1, 2, 3, 4, 5, 6, 7, 8}, Hashes: 7},
+		}},
+		UpdatedAt: 100,
+	}
+	require.NoError(t, WriteIndex(ctx, bkt, userID, nil, expectedIdx))
+
+	actualIdx, err := ReadIndex(ctx, bkt, userID, nil, logger)
+	require.NoError(t, err)
+	assert.Equal(t, expectedIdx, actualIdx)
+
+	require.NoError(t, DeleteIndex(ctx, bkt, userID, nil))
+	_, err = ReadIndex(ctx, bkt, userID, nil, logger)
+	require.Equal(t, ErrIndexNotFound, err)
+
+	// Deleting a non-existing index is not an error.
+	require.NoError(t, DeleteIndex(ctx, bkt, userID, nil))
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package seriesfilter
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+// Updater is responsible to generate an updated in-memory series filter index.
+type Updater struct {
+	bkt    objstore.InstrumentedBucket
+	dir    string
+	logger log.Logger
+}
+
+// NewUpdater makes a new Updater. The index of the blocks to filter is temporarily downloaded to the input directory.
+func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, dir string, logger log.Logger) *Updater {
+	return &Updater{
+		bkt:    bucket.NewUserBucketClient(userID, bkt, cfgProvider),
+		dir:    filepath.Join(dir, userID),
+		logger: util_log.WithUserID(userID, logger),
+	}
+}
+
+// UpdateIndex generates the series filter index of the input blocks and returns it, without storing it to the storage.
+// Since blocks are immutable, the filters of the blocks in the old index are reused, and only the filters of the new
+// blocks are built. The blocks whose filter failed to be built are left out of the index, and returned with their error.
+func (w *Updater) UpdateIndex(ctx context.Context, old *Index, blocks bucketindex.Blocks) (*Index, map[ulid.ULID]error, error) {
+	oldFilters := map[ulid.ULID]*Filter{}
+	if old != nil && old.Version == IndexVersion1 {
+		oldFilters = old.Filters()
+	}
+
+	filtered := make([]*Block, 0, len(blocks))
+	failed := map[ulid.ULID]error{}
+	for _, b := range blocks {
+		if f, ok := oldFilters[b.ID]; ok {
+			filtered = append(filtered, &Block{ID: b.ID, Filter: f})
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		f, err := w.buildBlockFilter(ctx, b.ID)
+		if err != nil {
+			failed[b.ID] = err
+			level.Warn(w.logger).Log("msg", "skipped block whose filter failed to be built when updating series filter index", "block", b.ID.String(), "err", err)
+			continue
+		}
+		filtered = append(filtered, &Block{ID: b.ID, Filter: f})
+	}
+
+	return &Index{
+		Version:   IndexVersion1,
+		Blocks:    filtered,
+		UpdatedAt: time.Now().Unix(),
+	}, failed, nil
+}
+
+// buildBlockFilter downloads the index of the input block, and returns the filter of its series.
+func (w *Updater) buildBlockFilter(ctx context.Context, id ulid.ULID) (_ *Filter, returnErr error) {
+	if err := os.MkdirAll(w.dir, os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, "create series filter index directory")
+	}
+
+	indexFile := filepath.Join(w.dir, id.String())
+	defer func() {
+		if err := os.Remove(indexFile); err != nil && !os.IsNotExist(err) && returnErr == nil {
+			returnErr = errors.Wrap(err, "remove block index file")
+		}
+	}()
+
+	if err := objstore.DownloadFile(ctx, w.logger, w.bkt, path.Join(id.String(), block.IndexFilename), indexFile); err != nil {
+		return nil, errors.Wrap(err, "download block index file")
+	}
+
+	r, err := index.NewFileReader(indexFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "open block index file")
+	}
+	defer r.Close()
+
+	return buildIndexFilter(r)
+}
+
+// buildIndexFilter returns the filter of the label name and value pairs of the input block index.
+func buildIndexFilter(r *index.Reader) (*Filter, error) {
+	names, err := r.LabelNames()
+	if err != nil {
+		return nil, errors.Wrap(err, "read label names")
+	}
+
+	valuesByName := make([][]string, 0, len(names))
+	pairs := 0
+	for _, name := range names {
+		values, err := r.SortedLabelValues(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read values of label %s", name)
+		}
+		valuesByName = append(valuesByName, values)
+		pairs += len(values)
+	}
+
+	f := NewFilter(pairs)
+	for i, name := range names {
+		for _, value := range valuesByName[i] {
+			f.Add(name, value)
+		}
+	}
+	return f, nil
+}
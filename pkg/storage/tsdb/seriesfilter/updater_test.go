@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package seriesfilter
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+	"github.com/grafana/mimir/pkg/storegateway/testhelper"
+)
+
+func TestUpdater_UpdateIndex(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+
+	// Create and upload a block.
+	blocksDir := t.TempDir()
+	block1ID, err := testhelper.CreateBlock(ctx, blocksDir, []labels.Labels{
+		labels.FromStrings("__name__", "up", "job", "a", "instance", "1"),
+		labels.FromStrings("__name__", "up", "job", "b", "instance", "2"),
+		labels.FromStrings("__name__", "cpu", "job", "a"),
+	}, 10, 0, 1000, labels.FromStrings("ext1", "val1"))
+	require.NoError(t, err)
+	require.NoError(t, block.Upload(ctx, logger, userBkt, path.Join(blocksDir, block1ID.String()), nil))
+
+	// The second block doesn't exist in the storage.
+	block2ID := ulid.MustNew(2, nil)
+
+	w := NewUpdater(bkt, userID, nil, t.TempDir(), logger)
+	idx, failed, err := w.UpdateIndex(ctx, nil, bucketindex.Blocks{
+		{ID: block1ID, MinTime: 0, MaxTime: 1000},
+		{ID: block2ID, MinTime: 1000, MaxTime: 2000},
+	})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	require.Contains(t, failed, block2ID)
+
+	assert.Equal(t, IndexVersion1, idx.Version)
+	require.Len(t, idx.Blocks, 1)
+	assert.Equal(t, block1ID, idx.Blocks[0].ID)
+
+	filter := idx.Blocks[0].Filter
+	for _, pair := range [][2]string{{"__name__", "up"}, {"__name__", "cpu"}, {"job", "a"}, {"job", "b"}, {"instance", "1"}, {"instance", "2"}} {
+		assert.True(t, filter.MayContain(pair[0], pair[1]), pair)
+	}
+	assert.False(t, filter.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "c")}))
+
+	// Delete the block from the storage, to check that its filter is reused from the old index.
+	require.NoError(t, block.Delete(ctx, logger, userBkt, block1ID))
+
+	updated, failed, err := w.UpdateIndex(ctx, idx, bucketindex.Blocks{{ID: block1ID, MinTime: 0, MaxTime: 1000}})
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, idx.Blocks, updated.Blocks)
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -34,6 +35,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/objstore/tracing"
+	"github.com/weaveworks/common/httpgrpc"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
@@ -45,6 +47,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketcache"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storage/tsdb/seriesfilter"
 	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
@@ -54,6 +57,7 @@ import (
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
@@ -98,6 +102,9 @@ type BucketStore struct {
 	chunkPool       pool.Bytes
 	seriesHashCache *hashcache.SeriesHashCache
 
+	// seriesDecodingWorkers load the chunks, each request on one of their partitions, if not nil.
+	seriesDecodingWorkers *seriesDecodingWorkers
+
 	// Sets of blocks that have the same labels. They are indexed by a hash over their label set.
 	blocksMx sync.RWMutex
 	blocks   map[ulid.ULID]*bucketBlock
@@ -110,6 +117,10 @@ type BucketStore struct {
 	// This value must be greater than zero.
 	maxSeriesPerBatch int
 
+	// maxBufferedChunksBytesPerRequest is the memory budget of the chunks loaded by a Series() request and
+	// not sent yet. The loading of the next batches waits until they fit in it. Zero means no limit.
+	maxBufferedChunksBytesPerRequest uint64
+
 	// numChunksRangesPerSeries controls into how many ranges the chunks of each series from each block are split.
 	// This value is effectively the number of chunks cache items per series per block.
 	numChunksRangesPerSeries int
@@ -118,6 +129,26 @@ type BucketStore struct {
 	// or rely on the transparent caching bucket.
 	fineGrainedChunksCachingEnabled bool
 
+	// chunksCacheMinBlockAge and chunksCacheMaxBlockAge return the range of block ages for which
+	// chunks are cached. Zero means no limit.
+	chunksCacheMinBlockAge func() time.Duration
+	chunksCacheMaxBlockAge func() time.Duration
+
+	// partialResultsEnabled returns whether the Series() requests return the series of the blocks successfully
+	// queried when some blocks fail to be queried.
+	partialResultsEnabled func() bool
+
+	// indexHeaderEviction configures when the lazy loaded index-headers are unloaded, in addition to the idle timeout.
+	indexHeaderEviction indexheader.EvictionConfig
+
+	// loadSeriesFilters returns the series filter index of the tenant, or nil if not available. Nil if disabled.
+	loadSeriesFilters func(ctx context.Context) (*seriesfilter.Index, error)
+
+	// seriesFilters holds the series filters of the loaded blocks, by block ID. The blocks without a filter
+	// are never skipped.
+	seriesFiltersMx sync.RWMutex
+	seriesFilters   map[ulid.ULID]*seriesfilter.Filter
+
 	// Query gate which limits the maximum amount of concurrent queries.
 	queryGate gate.Gate
 
@@ -128,11 +159,34 @@ type BucketStore struct {
 	seriesLimiterFactory SeriesLimiterFactory
 	partitioners         blockPartitioners
 
+	// postingsBytesLimiterFactory, seriesBytesLimiterFactory and chunksBytesLimiterFactory create new limiters used
+	// to limit the size of the postings, series and chunks touched by each Series() call.
+	postingsBytesLimiterFactory BytesLimiterFactory
+	seriesBytesLimiterFactory   BytesLimiterFactory
+	chunksBytesLimiterFactory   BytesLimiterFactory
+
+	// tenantPostingsBytesLimiter, tenantSeriesBytesLimiter and tenantChunksBytesLimiter limit the size of the postings,
+	// series and chunks touched by all the in-flight Series() calls of the tenant.
+	tenantPostingsBytesLimiter *InflightBytesLimiter
+	tenantSeriesBytesLimiter   *InflightBytesLimiter
+	tenantChunksBytesLimiter   *InflightBytesLimiter
+
 	// Every how many posting offset entry we pool in heap memory. Default in Prometheus is 32.
 	postingOffsetsInMemSampling int
 
 	// Additional configuration for experimental indexheader.BinaryReader behaviour.
 	indexHeaderCfg indexheader.Config
+
+	// hotSeriesSets keeps the expanded postings of the most frequently queried selectors. Nil if disabled.
+	hotSeriesSets *hotSeriesSets
+
+	// seriesHashesPersistenceEnabled controls whether the series hashes of each block are persisted to disk.
+	seriesHashesPersistenceEnabled bool
+
+	// chunksReadaheadMaxBytes is the max size of each readahead of the chunks, zero if disabled, and
+	// chunksReadaheadMaxBytesPerRequest is the budget of the bytes read ahead by a Series() request.
+	chunksReadaheadMaxBytes           uint64
+	chunksReadaheadMaxBytesPerRequest uint64
 }
 
 type noopCache struct{}
@@ -210,12 +264,109 @@ func WithChunkPool(chunkPool pool.Bytes) BucketStoreOption {
 	}
 }
 
+// WithSeriesDecodingWorkers sets the workers loading the chunks of the series. The chunks bytes are got from the
+// chunks pools of their partitions instead of the one set by WithChunkPool.
+func WithSeriesDecodingWorkers(workers *seriesDecodingWorkers) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.seriesDecodingWorkers = workers
+	}
+}
+
 func WithFineGrainedChunksCaching(enabled bool) BucketStoreOption {
 	return func(s *BucketStore) {
 		s.fineGrainedChunksCachingEnabled = enabled
 	}
 }
 
+// WithChunksCacheBlockAgeLimits sets the functions returning the min and max age of the blocks whose chunks
+// are fetched from and stored to the chunks cache. A returned value of zero means no limit.
+func WithChunksCacheBlockAgeLimits(minAge, maxAge func() time.Duration) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksCacheMinBlockAge = minAge
+		s.chunksCacheMaxBlockAge = maxAge
+	}
+}
+
+// WithPartialResults sets the function returning whether the Series() requests return the series of the blocks
+// successfully queried, along with a warning, when some blocks fail to be queried.
+func WithPartialResults(enabled func() bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.partialResultsEnabled = enabled
+	}
+}
+
+// WithIndexHeaderEviction sets the eviction policy of the lazy loaded index-headers exceeding the max resident
+// bytes returned by maxResidentBytes, and the function returning whether the index-headers are never unloaded.
+func WithIndexHeaderEviction(policy string, maxResidentBytes func() int64, pinned func() bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.indexHeaderEviction.Policy = policy
+		s.indexHeaderEviction.MaxResidentBytes = maxResidentBytes
+		s.indexHeaderEviction.Pinned = pinned
+	}
+}
+
+// WithSeriesFilters sets the function returning the series filter index of the tenant, used to skip the blocks
+// which can't contain the series matching a request. The function returns nil if the index is not available.
+func WithSeriesFilters(load func(ctx context.Context) (*seriesfilter.Index, error)) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.loadSeriesFilters = load
+	}
+}
+
+// WithHotSeriesSets enables keeping in memory, up to maxBytes, the expanded postings of the selectors queried
+// at least minQueries times within a tracking period. A maxBytes of zero disables it.
+func WithHotSeriesSets(maxBytes uint64, minQueries int, trackingPeriod time.Duration) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.hotSeriesSets = newHotSeriesSets(maxBytes, minQueries, trackingPeriod, s.metrics)
+	}
+}
+
+// WithSeriesHashesPersistence enables persisting the series hashes computed by the sharded queries to a file
+// in the local directory of each block, which is loaded into the series hash cache when the block is queried.
+func WithSeriesHashesPersistence(enabled bool) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.seriesHashesPersistenceEnabled = enabled
+	}
+}
+
+// WithChunksReadahead enables reading ahead, up to maxBytes, the ranges of the segment files following the ones
+// read by a batch of a Series() request, when the batches read a segment file sequentially. The bytes read ahead
+// by each request are limited to maxBytesPerRequest, zero meaning no limit. A maxBytes of zero disables it.
+func WithChunksReadahead(maxBytes, maxBytesPerRequest uint64) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunksReadaheadMaxBytes = maxBytes
+		s.chunksReadaheadMaxBytesPerRequest = maxBytesPerRequest
+	}
+}
+
+// WithMaxBufferedChunksBytesPerRequest sets the memory budget of the chunks loaded by each Series() call
+// and not sent yet. A value of zero means no limit.
+func WithMaxBufferedChunksBytesPerRequest(maxBytes uint64) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.maxBufferedChunksBytesPerRequest = maxBytes
+	}
+}
+
+// WithRequestBytesLimiters sets the factories of the limiters of the size of the postings, series and chunks
+// touched by each Series() call.
+func WithRequestBytesLimiters(postings, series, chunks BytesLimiterFactory) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.postingsBytesLimiterFactory = postings
+		s.seriesBytesLimiterFactory = series
+		s.chunksBytesLimiterFactory = chunks
+	}
+}
+
+// WithTenantBytesLimiters sets the limiters of the size of the postings, series and chunks touched by all
+// the in-flight Series() calls of the tenant.
+func WithTenantBytesLimiters(postings, series, chunks *InflightBytesLimiter) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.tenantPostingsBytesLimiter = postings
+		s.tenantSeriesBytesLimiter = series
+		s.tenantChunksBytesLimiter = chunks
+	}
+}
+
 // NewBucketStore creates a new bucket backed store that implements the store API against
 // an object store bucket. It is optimized to work against high latency backends.
 func NewBucketStore(
@@ -244,6 +395,9 @@ func NewBucketStore(
 		dir:                         dir,
 		indexCache:                  noopCache{},
 		chunksCache:                 chunkscache.NoopCache{},
+		chunksCacheMinBlockAge:      func() time.Duration { return 0 },
+		chunksCacheMaxBlockAge:      func() time.Duration { return 0 },
+		partialResultsEnabled:       func() bool { return false },
 		chunkPool:                   pool.NoopBytes{},
 		blocks:                      map[ulid.ULID]*bucketBlock{},
 		blockSet:                    newBucketBlockSet(),
@@ -252,6 +406,12 @@ func NewBucketStore(
 		chunksLimiterFactory:        chunksLimiterFactory,
 		seriesLimiterFactory:        seriesLimiterFactory,
 		partitioners:                partitioners,
+		postingsBytesLimiterFactory: noBytesLimiterFactory,
+		seriesBytesLimiterFactory:   noBytesLimiterFactory,
+		chunksBytesLimiterFactory:   noBytesLimiterFactory,
+		tenantPostingsBytesLimiter:  NewInflightBytesLimiter(noBytesLimit),
+		tenantSeriesBytesLimiter:    NewInflightBytesLimiter(noBytesLimit),
+		tenantChunksBytesLimiter:    NewInflightBytesLimiter(noBytesLimit),
 		postingOffsetsInMemSampling: postingOffsetsInMemSampling,
 		indexHeaderCfg:              indexHeaderCfg,
 		seriesHashCache:             seriesHashCache,
@@ -266,7 +426,8 @@ func NewBucketStore(
 	}
 
 	// Depend on the options
-	s.indexReaderPool = indexheader.NewReaderPool(s.logger, lazyIndexReaderEnabled, lazyIndexReaderIdleTimeout, metrics.indexHeaderReaderMetrics)
+	s.indexHeaderEviction.IdleTimeout = lazyIndexReaderIdleTimeout
+	s.indexReaderPool = indexheader.NewReaderPool(s.logger, lazyIndexReaderEnabled, s.indexHeaderEviction, metrics.indexHeaderReaderMetrics)
 
 	if err := os.MkdirAll(dir, 0750); err != nil {
 		return nil, errors.Wrap(err, "create dir")
@@ -278,6 +439,7 @@ func NewBucketStore(
 // RemoveBlocksAndClose remove all blocks from local disk and releases all resources associated with the BucketStore.
 func (s *BucketStore) RemoveBlocksAndClose() error {
 	err := s.removeAllBlocks()
+	s.hotSeriesSets.close()
 
 	// Release other resources even if it failed to close some blocks.
 	s.indexReaderPool.Close()
@@ -305,8 +467,12 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 		return metaFetchErr
 	}
 
-	var wg sync.WaitGroup
-	blockc := make(chan *metadata.Meta)
+	var (
+		wg          sync.WaitGroup
+		blockc      = make(chan *metadata.Meta)
+		addedMx     sync.Mutex
+		addedBlocks []ulid.ULID
+	)
 
 	for i := 0; i < s.blockSyncConcurrency; i++ {
 		wg.Add(1)
@@ -315,6 +481,9 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 				if err := s.addBlock(ctx, meta); err != nil {
 					continue
 				}
+				addedMx.Lock()
+				addedBlocks = append(addedBlocks, meta.ULID)
+				addedMx.Unlock()
 			}
 			wg.Done()
 		}()
@@ -333,11 +502,14 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 	close(blockc)
 	wg.Wait()
 
+	s.expandHotSeriesSets(ctx, addedBlocks)
+
 	if metaFetchErr != nil {
 		return metaFetchErr
 	}
 
 	// Drop all blocks that are no longer present in the bucket.
+	var droppedBlocks []ulid.ULID
 	for id := range s.blocks {
 		if _, ok := metas[id]; ok {
 			continue
@@ -346,11 +518,164 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 			level.Warn(s.logger).Log("msg", "drop of outdated block failed", "block", id, "err", err)
 		}
 		level.Info(s.logger).Log("msg", "dropped outdated block", "block", id)
+		droppedBlocks = append(droppedBlocks, id)
 	}
+	s.purgeCachedEntries(droppedBlocks)
+	s.syncSeriesFilters(ctx)
 
 	return nil
 }
 
+// syncSeriesFilters loads the series filters of the loaded blocks. The filters previously loaded are kept if
+// the series filter index fails to be read, since it's just an optimization.
+func (s *BucketStore) syncSeriesFilters(ctx context.Context) {
+	if s.loadSeriesFilters == nil {
+		return
+	}
+
+	idx, err := s.loadSeriesFilters(ctx)
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "failed to load the series filter index", "err", err)
+		return
+	}
+
+	// Only keep the filters of the blocks loaded by this store-gateway.
+	var filters map[ulid.ULID]*seriesfilter.Filter
+	if idx != nil {
+		filters = map[ulid.ULID]*seriesfilter.Filter{}
+		s.blocksMx.RLock()
+		for _, b := range idx.Blocks {
+			if _, ok := s.blocks[b.ID]; ok && b.Filter != nil {
+				filters[b.ID] = b.Filter
+			}
+		}
+		s.blocksMx.RUnlock()
+	}
+
+	s.seriesFiltersMx.Lock()
+	s.seriesFilters = filters
+	s.seriesFiltersMx.Unlock()
+}
+
+// skipBlockBySeriesFilter returns whether the series filter of the block rules out the series matching the
+// input matchers, so that the block can be skipped without reading its index-header.
+func (s *BucketStore) skipBlockBySeriesFilter(id ulid.ULID, matchers []*labels.Matcher) bool {
+	if len(matchers) == 0 {
+		return false
+	}
+
+	s.seriesFiltersMx.RLock()
+	filter := s.seriesFilters[id]
+	s.seriesFiltersMx.RUnlock()
+
+	if filter == nil || filter.MayMatch(matchers) {
+		return false
+	}
+	s.metrics.seriesFilterSkipped.Inc()
+	return true
+}
+
+// purgeCachedEntries removes the index cache entries of the dropped blocks, if supported by the index cache, so
+// that the entries which would never be fetched again, like the expanded postings of the queried matchers, don't
+// take the cache space of the loaded blocks. It must be called once the blocks are closed, so that no query can
+// cache new entries of the blocks afterwards.
+func (s *BucketStore) purgeCachedEntries(blockIDs []ulid.ULID) {
+	invalidator, ok := s.indexCache.(indexcache.BlockInvalidator)
+	if !ok || len(blockIDs) == 0 {
+		return
+	}
+
+	purged := invalidator.InvalidateBlocks(s.userID, blockIDs)
+	s.metrics.blockDropsPurged.Add(float64(purged))
+	level.Debug(s.logger).Log("msg", "purged cached entries of dropped blocks", "blocks", len(blockIDs), "purged_entries", purged)
+}
+
+// expandHotSeriesSets expands the postings of the hot selectors for the input blocks, so that the queries of the
+// hot selectors skip the postings expansion of the blocks loaded since they have been identified.
+func (s *BucketStore) expandHotSeriesSets(ctx context.Context, blockIDs []ulid.ULID) {
+	selectors := s.hotSeriesSets.hotSelectors()
+	if len(selectors) == 0 {
+		return
+	}
+
+	for _, id := range blockIDs {
+		b := s.getBlock(id)
+		if b == nil {
+			continue
+		}
+
+		indexr := b.indexReader()
+		for _, ms := range selectors {
+			if _, _, err := indexr.ExpandedPostings(ctx, ms, newSafeQueryStats()); err != nil {
+				level.Warn(s.logger).Log("msg", "failed to expand the postings of a hot selector", "block", id, "matchers", storepb.PromMatchersToString(ms...), "err", err)
+			}
+		}
+		runutil.CloseWithLogOnErr(s.logger, indexr, "close block index reader")
+	}
+}
+
+// loadedBlockIDs returns the IDs of the blocks loaded by the store, sorted by ULID.
+func (s *BucketStore) loadedBlockIDs() []ulid.ULID {
+	s.blocksMx.RLock()
+	ids := make([]ulid.ULID, 0, len(s.blocks))
+	for id := range s.blocks {
+		ids = append(ids, id)
+	}
+	s.blocksMx.RUnlock()
+
+	slices.SortFunc(ids, func(a, b ulid.ULID) bool {
+		return a.Compare(b) < 0
+	})
+	return ids
+}
+
+// loadedBlocks returns the blocks loaded by the store, sorted by ID.
+func (s *BucketStore) loadedBlocks() []*bucketBlock {
+	s.blocksMx.RLock()
+	blocks := make([]*bucketBlock, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		blocks = append(blocks, b)
+	}
+	s.blocksMx.RUnlock()
+
+	slices.SortFunc(blocks, func(a, b *bucketBlock) bool {
+		return a.meta.ULID.Compare(b.meta.ULID) < 0
+	})
+	return blocks
+}
+
+// flushSeriesHashes persists the pending series hashes of the loaded blocks.
+func (s *BucketStore) flushSeriesHashes() {
+	for _, b := range s.loadedBlocks() {
+		if b.seriesHashes != nil {
+			b.seriesHashes.flush()
+		}
+	}
+}
+
+// warmUpBlock loads the index-header of the block, if lazy loaded, so that the next query doesn't wait for it.
+// The index-header is unloaded again once idle, like after being used by a query. If hotPostings is true, the
+// postings of the hot selectors of the tenant are expanded for the block too.
+func (s *BucketStore) warmUpBlock(ctx context.Context, id ulid.ULID, hotPostings bool) error {
+	b := s.getBlock(id)
+	if b == nil {
+		return errBlockNotLoaded
+	}
+
+	indexr := b.indexReader()
+	defer runutil.CloseWithLogOnErr(s.logger, indexr, "close block index reader")
+
+	// Any call to the index-header reader loads it.
+	if _, err := b.indexHeaderReader.IndexVersion(); err != nil {
+		return errors.Wrap(err, "load index-header")
+	}
+
+	if hotPostings {
+		s.expandHotSeriesSets(ctx, []ulid.ULID{id})
+	}
+	return nil
+}
+
 // InitialSync perform blocking sync with extra step at the end to delete locally saved blocks that are no longer
 // present in the bucket. The mismatch of these can only happen between restarts, so we can do that only once per startup.
 func (s *BucketStore) InitialSync(ctx context.Context) error {
@@ -443,6 +768,10 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *metadata.Meta) (err er
 	if err != nil {
 		return errors.Wrap(err, "new bucket block")
 	}
+	b.hotSeriesSets = s.hotSeriesSets
+	if s.seriesHashesPersistenceEnabled {
+		b.seriesHashes = newPersistedSeriesHashes(dir, meta.Stats.NumSeries, b.logger)
+	}
 	defer func() {
 		if err != nil {
 			runutil.CloseWithErrCapture(&err, b, "index-header")
@@ -482,6 +811,7 @@ func (s *BucketStore) removeBlock(id ulid.ULID) (returnErr error) {
 	// The block has already been removed from BucketStore, so we track it as removed
 	// even if releasing its resources could fail below.
 	s.metrics.blockDrops.Inc()
+	s.hotSeriesSets.dropBlock(id)
 
 	if err := b.Close(); err != nil {
 		return errors.Wrap(err, "close block")
@@ -509,6 +839,7 @@ func (s *BucketStore) removeAllBlocks() error {
 			errs.Add(errors.Wrap(err, fmt.Sprintf("block: %s", id.String())))
 		}
 	}
+	s.purgeCachedEntries(blockIDs)
 
 	return errs.Err()
 }
@@ -574,6 +905,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	if err != nil {
 		return status.Error(codes.InvalidArgument, errors.Wrap(err, "parse query sharding label").Error())
 	}
+	s.hotSeriesSets.recordQuery(matchers)
 
 	spanLogger := spanlogger.FromContext(srv.Context(), s.logger)
 	level.Debug(spanLogger).Log(
@@ -590,8 +922,10 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		reqBlockMatchers []*labels.Matcher
 		chunksLimiter    = s.chunksLimiterFactory(s.metrics.queriesDropped.WithLabelValues("chunks"))
 		seriesLimiter    = s.seriesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("series"))
+		bytesLimiters    = s.newSeriesBytesLimiters()
 	)
 	defer s.recordSeriesCallResult(stats)
+	defer bytesLimiters.release()
 
 	if req.Hints != nil {
 		reqHints := &hintspb.SeriesRequestHints{}
@@ -623,10 +957,27 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		readers = newChunkReaders(chunkReaders)
 	}
 
-	seriesSet, resHints, err := s.streamingSeriesSetForBlocks(ctx, req, blocks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, stats)
+	// The blocks failed to be queried are only tolerated if the partial results are enabled.
+	var partial *partialResults
+	if s.partialResultsEnabled != nil && s.partialResultsEnabled() {
+		partial = newPartialResults()
+	}
+
+	if req.StreamingChunksBatchSize > 0 && !req.SkipChunks {
+		err = s.sendStreamingSeriesAndChunks(ctx, req, srv, blocks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, bytesLimiters, stats, partial)
+		if err != nil {
+			return err
+		}
+		return s.sendStats(srv, stats)
+	}
+
+	seriesSet, resHints, err := s.streamingSeriesSetForBlocks(ctx, req, blocks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, bytesLimiters, stats, partial)
 	if err != nil {
 		return err
 	}
+	if err := s.sendPartialResultsWarning(srv, partial, len(blocks)); err != nil {
+		return err
+	}
 
 	// Merge the sub-results from each selected block.
 	tracing.DoWithSpan(ctx, "bucket_store_merge_all", func(ctx context.Context, _ tracing.Span) {
@@ -712,13 +1063,188 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		return
 	}
 
+	return s.sendStats(srv, stats)
+}
+
+// sendStreamingSeriesAndChunks sends the response hints, followed by the labels of all the series in batches, and then
+// by the chunks of the series in batches of req.StreamingChunksBatchSize series. The series set is iterated twice: the
+// first time without loading the chunks, and the second time loading them, so that the store-gateway only keeps in memory
+// the chunks of a batch.
+func (s *BucketStore) sendStreamingSeriesAndChunks(
+	ctx context.Context,
+	req *storepb.SeriesRequest,
+	srv storepb.Store_SeriesServer,
+	blocks []*bucketBlock,
+	indexReaders map[ulid.ULID]*bucketIndexReader,
+	chunkReaders *bucketChunkReaders,
+	shardSelector *sharding.ShardSelector,
+	matchers []*labels.Matcher,
+	chunksLimiter ChunksLimiter,
+	seriesLimiter SeriesLimiter,
+	bytesLimiters *seriesBytesLimiters,
+	stats *safeQueryStats,
+	partial *partialResults,
+) (err error) {
+	var (
+		iterationBegin = time.Now()
+		encodeDuration = time.Duration(0)
+		sendDuration   = time.Duration(0)
+		seriesCount    int
+		chunksCount    int
+	)
+
+	// Once the iteration is done we will update the stats.
+	defer stats.update(func(stats *queryStats) {
+		stats.mergedSeriesCount += seriesCount
+		stats.mergedChunksCount += chunksCount
+
+		stats.streamingSeriesFetchSeriesAndChunksDuration += stats.streamingSeriesWaitBatchLoadedDuration
+		stats.streamingSeriesEncodeResponseDuration += encodeDuration
+		stats.streamingSeriesSendResponseDuration += sendDuration
+		stats.streamingSeriesOtherDuration += time.Duration(util_math.Max(0, int64(time.Since(iterationBegin)-
+			stats.streamingSeriesFetchSeriesAndChunksDuration-encodeDuration-sendDuration)))
+	})
+
+	send := func(resp *storepb.SeriesResponse, what string) error {
+		encodeBegin := time.Now()
+		msg := &grpc.PreparedMsg{}
+		if err := msg.Encode(srv, resp); err != nil {
+			return status.Error(codes.Internal, errors.Wrapf(err, "encode %s response", what).Error())
+		}
+		encodeDuration += time.Since(encodeBegin)
+
+		sendBegin := time.Now()
+		if err := srv.SendMsg(msg); err != nil {
+			return status.Error(codes.Unknown, errors.Wrapf(err, "send %s response", what).Error())
+		}
+		sendDuration += time.Since(sendBegin)
+		return nil
+	}
+
+	// The first iteration doesn't load the chunks, so the chunks limit is only applied to the second one,
+	// and the series limit only to the first one.
+	seriesSet, resHints, err := s.streamingSeriesSetForBlocks(ctx, req, blocks, indexReaders, nil, shardSelector, matchers, NewLimiter(0, nil), seriesLimiter, bytesLimiters, stats, partial)
+	if err != nil {
+		return err
+	}
+	if err := s.sendPartialResultsWarning(srv, partial, len(blocks)); err != nil {
+		return err
+	}
+
+	// The hints are sent first, so that the querier can check the queried blocks before consuming the chunks.
+	anyHints, err := types.MarshalAny(resHints)
+	if err != nil {
+		return status.Error(codes.Unknown, errors.Wrap(err, "marshal series response hints").Error())
+	}
+	if err := srv.Send(storepb.NewHintsSeriesResponse(anyHints)); err != nil {
+		return status.Error(codes.Unknown, errors.Wrap(err, "send series response hints").Error())
+	}
+
+	// The chunks of each batch are estimated from the chunk refs, so that the querier can reject the query
+	// before the chunks are streamed.
+	estimator, _ := seriesSet.(chunksEstimatingSeriesSet)
+
+	seriesBatch := &storepb.StreamingSeriesBatch{Series: make([]*storepb.StreamingSeries, 0, req.StreamingChunksBatchSize)}
+	for seriesSet.Next() {
+		// The labels are copied because the memory returned by seriesSet.At() may be released by the next call to Next().
+		lset, _ := seriesSet.At()
+		seriesBatch.Series = append(seriesBatch.Series, &storepb.StreamingSeries{Labels: mimirpb.FromLabelsToLabelAdapters(lset.Copy())})
+		seriesCount++
+
+		if estimator != nil {
+			numChunks, numBytes := estimator.atChunksEstimate()
+			seriesBatch.EstimatedChunksCount += numChunks
+			seriesBatch.EstimatedChunksBytes += numBytes
+		}
+
+		if uint64(len(seriesBatch.Series)) == req.StreamingChunksBatchSize {
+			if err := send(storepb.NewStreamingSeriesResponse(seriesBatch), "streaming series"); err != nil {
+				return err
+			}
+			seriesBatch.Series = seriesBatch.Series[:0]
+			seriesBatch.EstimatedChunksCount = 0
+			seriesBatch.EstimatedChunksBytes = 0
+		}
+	}
+	if seriesSet.Err() != nil {
+		return errors.Wrap(seriesSet.Err(), "expand series set")
+	}
+
+	// The last batch is sent even if empty, to signal the end of the series stream.
+	seriesBatch.IsEndOfSeriesStream = true
+	if err := send(storepb.NewStreamingSeriesResponse(seriesBatch), "streaming series"); err != nil {
+		return err
+	}
+
+	// The blocks failed to be queried by the first iteration are skipped, so that the chunks match the series
+	// already sent. Any failure of the other blocks fails the request, because their series have been sent.
+	blocks = partial.withoutFailedBlocks(blocks)
+	seriesSet, _, err = s.streamingSeriesSetForBlocks(ctx, req, blocks, indexReaders, chunkReaders, shardSelector, matchers, chunksLimiter, NewLimiter(0, nil), bytesLimiters, stats, nil)
+	if err != nil {
+		return err
+	}
+
+	chunksBatch := &storepb.StreamingChunksBatch{Series: make([]*storepb.StreamingChunks, 0, req.StreamingChunksBatchSize)}
+	for seriesIdx := uint64(0); seriesSet.Next(); seriesIdx++ {
+		// The chunks are copied because the memory returned by seriesSet.At() may be released by the next call to Next().
+		_, chks := seriesSet.At()
+		chunksBatch.Series = append(chunksBatch.Series, &storepb.StreamingChunks{SeriesIndex: seriesIdx, Chunks: copyChunks(chks)})
+
+		chunksCount += len(chks)
+		s.metrics.chunkSizeBytes.Observe(float64(chunksSize(chks)))
+
+		if uint64(len(chunksBatch.Series)) == req.StreamingChunksBatchSize {
+			if err := send(storepb.NewStreamingChunksResponse(chunksBatch), "streaming chunks"); err != nil {
+				return err
+			}
+			chunksBatch.Series = chunksBatch.Series[:0]
+		}
+	}
+	if seriesSet.Err() != nil {
+		return errors.Wrap(seriesSet.Err(), "expand series set")
+	}
+
+	if len(chunksBatch.Series) > 0 {
+		if err := send(storepb.NewStreamingChunksResponse(chunksBatch), "streaming chunks"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendPartialResultsWarning sends the warning listing the blocks failed to be queried, if any, out of the numBlocks
+// queried by the request.
+func (s *BucketStore) sendPartialResultsWarning(srv storepb.Store_SeriesServer, partial *partialResults, numBlocks int) error {
+	warning := partial.warning(numBlocks)
+	if warning == "" {
+		return nil
+	}
+
+	s.metrics.seriesBlocksFailed.Add(float64(len(partial.failedBlocks())))
+	if err := srv.Send(storepb.NewWarnSeriesResponse(warning)); err != nil {
+		return status.Error(codes.Unknown, errors.Wrap(err, "send series response warning").Error())
+	}
+	return nil
+}
+
+func (s *BucketStore) sendStats(srv storepb.Store_SeriesServer, stats *safeQueryStats) error {
 	unsafeStats := stats.export()
-	if err = srv.Send(storepb.NewStatsResponse(unsafeStats.postingsTouchedSizeSum + unsafeStats.seriesTouchedSizeSum)); err != nil {
-		err = status.Error(codes.Unknown, errors.Wrap(err, "sends series response stats").Error())
-		return
+	if err := srv.Send(storepb.NewStatsResponse(unsafeStats.postingsTouchedSizeSum + unsafeStats.seriesTouchedSizeSum)); err != nil {
+		return status.Error(codes.Unknown, errors.Wrap(err, "sends series response stats").Error())
 	}
+	return nil
+}
 
-	return err
+func copyChunks(chks []storepb.AggrChunk) []storepb.AggrChunk {
+	copied := make([]storepb.AggrChunk, 0, len(chks))
+	for _, chk := range chks {
+		if chk.Raw != nil {
+			chk.Raw = &storepb.Chunk{Type: chk.Raw.Type, Data: append([]byte(nil), chk.Raw.Data...)}
+		}
+		copied = append(copied, chk)
+	}
+	return copied
 }
 
 func chunksSize(chks []storepb.AggrChunk) (size int) {
@@ -728,6 +1254,12 @@ func chunksSize(chks []storepb.AggrChunk) (size int) {
 	return size
 }
 
+// chunksEstimatingSeriesSet is implemented by the series sets which can estimate the chunks of the current
+// series without loading them.
+type chunksEstimatingSeriesSet interface {
+	atChunksEstimate() (numChunks, numBytes uint64)
+}
+
 func (s *BucketStore) streamingSeriesSetForBlocks(
 	ctx context.Context,
 	req *storepb.SeriesRequest,
@@ -738,7 +1270,9 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 	matchers []*labels.Matcher,
 	chunksLimiter ChunksLimiter, // Rate limiter for loading chunks.
 	seriesLimiter SeriesLimiter, // Rate limiter for loading series.
+	bytesLimiters *seriesBytesLimiters, // Limiters of the postings, series and chunks bytes touched.
 	stats *safeQueryStats,
+	partial *partialResults, // Tracker of the blocks failed to be queried, if their failures are tolerated.
 ) (storepb.SeriesSet, *hintspb.SeriesResponseHints, error) {
 	var (
 		resHints = &hintspb.SeriesResponseHints{}
@@ -753,6 +1287,11 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 
 		// Keep track of queried blocks.
 		resHints.AddQueriedBlock(b.meta.ULID)
+
+		// The blocks skipped by the series filter are still reported as queried, since they have no series to return.
+		if s.skipBlockBySeriesFilter(b.meta.ULID, matchers) {
+			continue
+		}
 		indexr := indexReaders[b.meta.ULID]
 
 		// If query sharding is enabled we have to get the block-specific series hash cache
@@ -761,12 +1300,20 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 		if shardSelector != nil {
 			blockSeriesHashCache = s.seriesHashCache.GetBlockCache(b.meta.ULID.String())
 		}
+		var hasher seriesHasher = cachedSeriesHasher{blockSeriesHashCache}
+		if shardSelector != nil && b.seriesHashes != nil {
+			hasher = persistingSeriesHasher{cachedSeriesHasher{blockSeriesHashCache}, b.seriesHashes}
+		}
 		g.Go(func() error {
 			var (
 				part seriesChunkRefsSetIterator
 				err  error
 			)
 
+			if shardSelector != nil && b.seriesHashes != nil {
+				b.seriesHashes.loadInto(blockSeriesHashCache)
+			}
+
 			part, err = openBlockSeriesChunkRefsSetsIterator(
 				ctx,
 				s.maxSeriesPerBatch,
@@ -776,7 +1323,7 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 				b.meta,
 				matchers,
 				shardSelector,
-				cachedSeriesHasher{blockSeriesHashCache},
+				hasher,
 				req.SkipChunks,
 				req.MinTime, req.MaxTime,
 				s.numChunksRangesPerSeries,
@@ -784,6 +1331,11 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 				s.logger,
 			)
 			if err != nil {
+				// The failed block is still reported as queried, so that the querier doesn't retry it.
+				if partial.tolerate(ctx, b.meta.ULID, err) {
+					level.Warn(s.logger).Log("msg", "failed to fetch series for block, returning partial results", "block", b.meta.ULID, "err", err)
+					return nil
+				}
 				return errors.Wrapf(err, "fetch series for block %s", b.meta.ULID)
 			}
 
@@ -811,14 +1363,17 @@ func (s *BucketStore) streamingSeriesSetForBlocks(
 	// Apply limits after the merging, so that if the same series is part of multiple blocks it just gets
 	// counted once towards the limit.
 	mergedIterator = newLimitingSeriesChunkRefsSetIterator(mergedIterator, chunksLimiter, seriesLimiter)
+	mergedIterator = newBytesLimitingSetIterator[seriesChunkRefsSet](mergedIterator, func() error {
+		return bytesLimiters.reserveIndexBytes(stats)
+	})
 
 	var set storepb.SeriesSet
-	if !req.SkipChunks {
+	if chunkReaders != nil {
 		var cache chunkscache.Cache
 		if s.fineGrainedChunksCachingEnabled {
-			cache = s.chunksCache
+			cache = newBlockAgeChunksCache(s.chunksCache, blocks, time.Now(), s.chunksCacheMinBlockAge(), s.chunksCacheMaxBlockAge(), s.metrics)
 		}
-		set = newSeriesSetWithChunks(ctx, s.logger, s.userID, cache, *chunkReaders, mergedIterator, s.maxSeriesPerBatch, stats, req.MinTime, req.MaxTime)
+		set = newSeriesSetWithChunks(ctx, s.logger, s.userID, cache, *chunkReaders, mergedIterator, s.maxSeriesPerBatch, bytesLimiters, stats, req.MinTime, req.MaxTime)
 	} else {
 		set = newSeriesSetWithoutChunks(ctx, mergedIterator, stats)
 	}
@@ -875,6 +1430,9 @@ func (s *BucketStore) recordSeriesCallResult(safeStats *safeQueryStats) {
 		s.metrics.streamingSeriesBatchPreloadingLoadDuration.Observe(stats.streamingSeriesBatchLoadDuration.Seconds())
 		s.metrics.streamingSeriesBatchPreloadingWaitDuration.Observe(stats.streamingSeriesWaitBatchLoadedDuration.Seconds())
 	}
+	if s.maxBufferedChunksBytesPerRequest > 0 {
+		s.metrics.streamingSeriesMemoryBudgetWaitDuration.Observe(stats.streamingSeriesWaitMemoryBudgetDuration.Seconds())
+	}
 
 	s.metrics.streamingSeriesRefsFetchDuration.Observe(stats.streamingSeriesFetchRefsDuration.Seconds())
 
@@ -900,9 +1458,18 @@ func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool,
 		return blocks, indexReaders, nil
 	}
 
+	// The readahead budget is shared by the chunk readers of all the blocks.
+	var readaheadBudget *chunksReadaheadBudget
+	if s.chunksReadaheadMaxBytes > 0 {
+		readaheadBudget = newChunksReadaheadBudget(s.chunksReadaheadMaxBytes, s.chunksReadaheadMaxBytesPerRequest)
+	}
+
+	// The chunks of all the blocks are loaded by the same partition of the series decoding workers.
+	partition := s.seriesDecodingWorkers.partition()
+
 	chunkReaders := make(map[ulid.ULID]chunkReader, len(blocks))
 	for _, b := range blocks {
-		chunkReaders[b.meta.ULID] = b.chunkReader(ctx)
+		chunkReaders[b.meta.ULID] = b.chunkReader(ctx, readaheadBudget, partition)
 	}
 
 	return blocks, indexReaders, chunkReaders
@@ -950,6 +1517,10 @@ func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesReq
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		if s.skipBlockBySeriesFilter(b.meta.ULID, reqSeriesMatchers) {
+			continue
+		}
+
 		indexr := b.indexReader()
 
 		g.Go(func() error {
@@ -986,7 +1557,7 @@ func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesReq
 	}
 
 	return &storepb.LabelNamesResponse{
-		Names: util.MergeSlices(sets...),
+		Names: util.PaginateSortedSlice(util.MergeSlices(sets...), req.After, int(req.Limit)),
 		Hints: anyHints,
 	}, nil
 }
@@ -1131,6 +1702,10 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		if s.skipBlockBySeriesFilter(b.meta.ULID, reqSeriesMatchers) {
+			continue
+		}
+
 		indexr := b.indexReader()
 
 		g.Go(func() error {
@@ -1166,8 +1741,15 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 		return nil, status.Error(codes.Unknown, errors.Wrap(err, "marshal label values response hints").Error())
 	}
 
+	values := util.PaginateSortedSlice(util.MergeSlices(sets...), req.After, int(req.Limit))
+
+	// Fail the request instead of sending back label values which would be rejected by the querier anyway.
+	if err := validation.CheckLabelValuesResultsSizeBytes(values, int(req.MaxSizeBytes)); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusUnprocessableEntity, err.Error())
+	}
+
 	return &storepb.LabelValuesResponse{
-		Values: util.MergeSlices(sets...),
+		Values: values,
 		Hints:  anyHints,
 	}, nil
 }
@@ -1376,6 +1958,12 @@ type bucketBlock struct {
 	blockLabels labels.Labels
 
 	expandedPostingsPromises sync.Map
+
+	// hotSeriesSets keeps the expanded postings of the hot selectors of the tenant. Nil if disabled.
+	hotSeriesSets *hotSeriesSets
+
+	// seriesHashes persists the series hashes of the block computed by the sharded queries. Nil if disabled.
+	seriesHashes *persistedSeriesHashes
 }
 
 func newBucketBlock(
@@ -1448,7 +2036,7 @@ func (b *bucketBlock) readIndexRange(ctx context.Context, off, length int64) ([]
 	return buf.Bytes(), nil
 }
 
-func (b *bucketBlock) readChunkRange(ctx context.Context, seq int, off, length int64, chunkRanges byteRanges) (*[]byte, error) {
+func (b *bucketBlock) readChunkRange(ctx context.Context, seq int, off, length int64, chunkRanges byteRanges, chunkPool pool.Bytes) (*[]byte, error) {
 	if seq < 0 || seq >= len(b.chunkObjs) {
 		return nil, errors.Errorf("unknown segment file for index %d", seq)
 	}
@@ -1461,7 +2049,7 @@ func (b *bucketBlock) readChunkRange(ctx context.Context, seq int, off, length i
 	defer runutil.CloseWithLogOnErr(b.logger, reader, "readChunkRange close range reader")
 
 	// Get a buffer from the pool.
-	chunkBuffer, err := b.chunkPool.Get(chunkRanges.size())
+	chunkBuffer, err := chunkPool.Get(chunkRanges.size())
 	if err != nil {
 		return nil, errors.Wrap(err, "allocate chunk bytes")
 	}
@@ -1489,9 +2077,9 @@ func (b *bucketBlock) indexReader() *bucketIndexReader {
 	return newBucketIndexReader(b, selectAllStrategy{})
 }
 
-func (b *bucketBlock) chunkReader(ctx context.Context) *bucketChunkReader {
+func (b *bucketBlock) chunkReader(ctx context.Context, readaheadBudget *chunksReadaheadBudget, partition *seriesDecodingPartition) *bucketChunkReader {
 	b.pendingReaders.Add(1)
-	return newBucketChunkReader(ctx, b)
+	return newBucketChunkReader(ctx, b, readaheadBudget, partition)
 }
 
 // matchLabels verifies whether the block matches the given matchers.
@@ -1514,6 +2102,9 @@ func (b *bucketBlock) overlapsClosedInterval(mint, maxt int64) bool {
 // Close waits for all pending readers to finish and then closes all underlying resources.
 func (b *bucketBlock) Close() error {
 	b.pendingReaders.Wait()
+	if b.seriesHashes != nil {
+		b.seriesHashes.flush()
+	}
 	return b.indexHeaderReader.Close()
 }
 
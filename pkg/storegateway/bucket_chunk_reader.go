@@ -7,12 +7,14 @@ package storegateway
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
 	"hash/crc32"
 	"io"
 	"sort"
+	"sync"
 
 	"github.com/grafana/dskit/runutil"
 	"github.com/oklog/ulid"
@@ -30,18 +32,49 @@ type bucketChunkReader struct {
 	ctx   context.Context
 	block *bucketBlock
 
+	// partition runs the loading of the chunks, if not nil. The chunks bytes are got from the chunks pool of the
+	// partition, or from the one of the block if there's no partition.
+	partition *seriesDecodingPartition
+	chunkPool pool.Bytes
+
 	toLoad [][]loadIdx
+
+	// readahead is the readahead state of each segment file, guarded by readaheadMtx since a batch may still be
+	// loading when the reader is closed. Nil if the readahead is disabled.
+	readaheadMtx    sync.Mutex
+	readahead       []chunksReadahead
+	readaheadBudget *chunksReadaheadBudget
+	closed          bool
 }
 
-func newBucketChunkReader(ctx context.Context, block *bucketBlock) *bucketChunkReader {
-	return &bucketChunkReader{
-		ctx:    ctx,
-		block:  block,
-		toLoad: make([][]loadIdx, len(block.chunkObjs)),
+// newBucketChunkReader returns a chunk reader of the block. The readahead of the chunks is disabled if
+// readaheadBudget is nil. The chunks are loaded by the workers of partition, unless it's nil.
+func newBucketChunkReader(ctx context.Context, block *bucketBlock, readaheadBudget *chunksReadaheadBudget, partition *seriesDecodingPartition) *bucketChunkReader {
+	r := &bucketChunkReader{
+		ctx:       ctx,
+		block:     block,
+		partition: partition,
+		chunkPool: block.chunkPool,
+		toLoad:    make([][]loadIdx, len(block.chunkObjs)),
+	}
+	if partition != nil {
+		r.chunkPool = partition.chunkPool
 	}
+	if readaheadBudget != nil {
+		r.readahead = make([]chunksReadahead, len(block.chunkObjs))
+		r.readaheadBudget = readaheadBudget
+	}
+	return r
 }
 
 func (r *bucketChunkReader) Close() error {
+	r.readaheadMtx.Lock()
+	r.closed = true
+	for i := range r.readahead {
+		r.readahead[i].reset(r.chunkPool)
+	}
+	r.readaheadMtx.Unlock()
+
 	r.block.pendingReaders.Done()
 	return nil
 }
@@ -81,7 +114,12 @@ func chunkRef(segmentFile, offset uint32) chunks.ChunkRef {
 
 // load all added chunks and saves resulting chunks to res.
 func (r *bucketChunkReader) load(res []seriesEntry, chunksPool *pool.SafeSlabPool[byte], stats *safeQueryStats) error {
-	g, ctx := errgroup.WithContext(r.ctx)
+	if r.readahead != nil {
+		r.readaheadMtx.Lock()
+		defer r.readaheadMtx.Unlock()
+	}
+
+	g, ctx := newSeriesDecodingGroup(r.ctx, r.partition)
 
 	for seq, pIdxs := range r.toLoad {
 		sort.Slice(pIdxs, func(i, j int) bool {
@@ -91,28 +129,84 @@ func (r *bucketChunkReader) load(res []seriesEntry, chunksPool *pool.SafeSlabPoo
 			return uint64(pIdxs[i].offset), uint64(pIdxs[i].offset) + uint64(pIdxs[i].length)
 		})
 
-		for _, p := range parts {
+		var readahead *chunksReadahead
+		if r.readahead != nil {
+			readahead = &r.readahead[seq]
+			if len(parts) == 0 {
+				readahead.reset(r.chunkPool)
+				continue
+			}
+			readahead.prepare(parts, r.readaheadBudget)
+		}
+
+		for i, p := range parts {
 			seq := seq
 			p := p
 			indices := pIdxs[p.ElemRng[0]:p.ElemRng[1]]
+
+			var (
+				prefetched    []byte
+				prefetchedEOF bool
+				next          *chunksReadaheadRead
+			)
+			if readahead != nil {
+				prefetched, prefetchedEOF = readahead.prefetched(p)
+				if i == len(parts)-1 {
+					next = readahead.next
+				}
+			}
 			g.Go(func() error {
-				return r.loadChunks(ctx, res, seq, p, indices, chunksPool, stats)
+				return r.loadChunks(ctx, res, seq, p, prefetched, prefetchedEOF, next, indices, chunksPool, stats)
 			})
 		}
 	}
-	return g.Wait()
+	err := g.Wait()
+
+	for seq := range r.readahead {
+		if len(r.toLoad[seq]) > 0 {
+			r.readahead[seq].done(err == nil && !r.closed, r.readaheadBudget, r.chunkPool, r.block.metrics)
+		}
+	}
+	return err
+}
+
+// chunkRangeReader returns a reader of the part of the segment file with sequence number seq, followed by
+// readaheadLen bytes. The beginning of the part kept by the previous batch is read from prefetched, which
+// reaches the end of the segment file if prefetchedEOF. The returned bool is whether a request has been issued:
+// none is if prefetched holds the whole part, in which case nothing is read ahead.
+func (r *bucketChunkReader) chunkRangeReader(ctx context.Context, seq int, part Part, prefetched []byte, prefetchedEOF bool, readaheadLen int) (io.ReadCloser, bool, error) {
+	start, end := part.Start+uint64(len(prefetched)), part.End+uint64(readaheadLen)
+	if start >= part.End || prefetchedEOF {
+		return io.NopCloser(bytes.NewReader(prefetched)), false, nil
+	}
+
+	reader, err := r.block.chunkRangeReader(ctx, seq, int64(start), int64(end-start))
+	if err != nil || len(prefetched) == 0 {
+		return reader, true, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefetched), reader), reader}, true, nil
 }
 
 // loadChunks will read range [start, end] from the segment file with sequence number seq.
-// This data range covers chunks starting at supplied offsets.
+// This data range covers chunks starting at supplied offsets. The beginning of the range may
+// have been kept by the previous batch in prefetched, reaching the end of the segment file if
+// prefetchedEOF, and the bytes following the last chunk are kept in readahead, if not nil.
 //
 // This function is called concurrently and the same instance of res, part of pIdx is
 // passed to multiple concurrent invocations. However, this shouldn't require a mutex
 // because part and pIdxs is only read, and different calls are expected to write to
 // different chunks in the res.
-func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, seq int, part Part, pIdxs []loadIdx, chunksPool *pool.SafeSlabPool[byte], stats *safeQueryStats) error {
+func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, seq int, part Part, prefetched []byte, prefetchedEOF bool, readahead *chunksReadaheadRead, pIdxs []loadIdx, chunksPool *pool.SafeSlabPool[byte], stats *safeQueryStats) error {
+	readaheadLen := 0
+	if readahead != nil {
+		readaheadLen = readahead.window
+	}
+
 	// Get a reader for the required range.
-	reader, err := r.block.chunkRangeReader(ctx, seq, int64(part.Start), int64(part.End-part.Start))
+	reader, requested, err := r.chunkRangeReader(ctx, seq, part, prefetched, prefetchedEOF, readaheadLen)
 	if err != nil {
 		return errors.Wrap(err, "get range reader")
 	}
@@ -184,7 +278,7 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, s
 
 		// Read entire chunk into new buffer.
 		// TODO: readChunkRange call could be avoided for any chunk but last in this particular part.
-		nb, err := r.block.readChunkRange(ctx, seq, int64(pIdx.offset), int64(chunkLen), []byteRange{{offset: 0, length: chunkLen}})
+		nb, err := r.block.readChunkRange(ctx, seq, int64(pIdx.offset), int64(chunkLen), []byteRange{{offset: 0, length: chunkLen}}, r.chunkPool)
 		if err != nil {
 			return errors.Wrapf(err, "preloaded chunk too small, expecting %d, and failed to fetch full chunk", chunkLen)
 		}
@@ -196,17 +290,56 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, res []seriesEntry, s
 		localStats.chunksRefetchedSizeSum += len(*nb)
 		err = populateChunk(&(res[pIdx.seriesEntry].chks[pIdx.chunk]), rawChunk((*nb)[n:]), chunksPool)
 		if err != nil {
-			r.block.chunkPool.Put(nb)
+			r.chunkPool.Put(nb)
 			return errors.Wrap(err, "populate chunk")
 		}
 		localStats.chunksTouched++
 		localStats.chunksTouchedSizeSum += chunkLen + crc32.Size
 
-		r.block.chunkPool.Put(nb)
+		r.chunkPool.Put(nb)
+	}
+
+	if readahead != nil {
+		// The bytes read past the end of the last chunk, since its length is estimated.
+		var excess []byte
+		last := int(pIdxs[len(pIdxs)-1].offset)
+		if end := last + chunkLen + crc32.Size; end < readOffset {
+			excess = buf[end-last : readOffset-last]
+		}
+		readahead.start = uint64(readOffset - len(excess))
+
+		// The bytes following the last chunk are already kept if no request has been issued.
+		if requested {
+			r.readAhead(bufReader, part, excess, readOffset, readahead)
+		}
 	}
 	return nil
 }
 
+// readAhead keeps the bytes following the last chunk of the part in readahead: the excess ones read past the end
+// of the last chunk, and the ones left in the reader, up to the end of the readahead window. The readahead is best
+// effort, so the bytes which can't be read, like past the end of the segment file, are just not kept.
+func (r *bucketChunkReader) readAhead(reader io.Reader, part Part, excess []byte, readOffset int, readahead *chunksReadaheadRead) {
+	remaining := int(part.End) + readahead.window - readOffset
+	if len(excess)+remaining <= 0 {
+		return
+	}
+	buf, err := r.chunkPool.Get(len(excess) + remaining)
+	if err != nil {
+		// The bytes are not kept if the chunks pool is exhausted.
+		return
+	}
+	*buf = append((*buf)[:0], excess...)
+	n, err := io.ReadFull(reader, (*buf)[len(excess):len(excess)+remaining])
+	*buf = (*buf)[:len(excess)+n]
+
+	readahead.buf = buf
+	readahead.eof = errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	if windowRead := n - (int(part.End) - readOffset); windowRead > 0 {
+		readahead.windowRead = windowRead
+	}
+}
+
 func populateChunk(out *storepb.AggrChunk, in chunkenc.Chunk, chunksPool *pool.SafeSlabPool[byte]) error {
 	var enc storepb.Chunk_Encoding
 	switch in.Encoding() {
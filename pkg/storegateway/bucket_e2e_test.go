@@ -19,6 +19,7 @@ import (
 	"github.com/gogo/status"
 	dskit_metrics "github.com/grafana/dskit/metrics"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/timestamp"
@@ -112,6 +113,8 @@ type prepareStoreConfig struct {
 	tempDir              string
 	manyParts            bool
 	maxSeriesPerBatch    int
+	maxBufferedChunks    uint64
+	chunksReadahead      uint64
 	chunksLimiterFactory ChunksLimiterFactory
 	seriesLimiterFactory SeriesLimiterFactory
 	series               []labels.Labels
@@ -161,6 +164,18 @@ func withManyParts() prepareStoreConfigOption {
 	}
 }
 
+func withMaxBufferedChunks(maxBytes uint64) prepareStoreConfigOption {
+	return func(config *prepareStoreConfig) {
+		config.maxBufferedChunks = maxBytes
+	}
+}
+
+func withChunksReadahead(maxBytes uint64) prepareStoreConfigOption {
+	return func(config *prepareStoreConfig) {
+		config.chunksReadahead = maxBytes
+	}
+}
+
 func prepareStoreWithTestBlocks(t testing.TB, bkt objstore.Bucket, cfg *prepareStoreConfig) *storeSuite {
 	extLset := labels.FromStrings("ext1", "value1")
 
@@ -178,7 +193,7 @@ func prepareStoreWithTestBlocks(t testing.TB, bkt objstore.Bucket, cfg *prepareS
 	assert.NoError(t, err)
 
 	// Have our options in the beginning so tests can override logger and index cache if they need to
-	storeOpts := []BucketStoreOption{WithLogger(s.logger), WithIndexCache(s.cache), WithChunksCache(s.cache)}
+	storeOpts := []BucketStoreOption{WithLogger(s.logger), WithIndexCache(s.cache), WithChunksCache(s.cache), WithMaxBufferedChunksBytesPerRequest(cfg.maxBufferedChunks), WithChunksReadahead(cfg.chunksReadahead, 0)}
 
 	store, err := NewBucketStore(
 		"tenant",
@@ -416,19 +431,24 @@ func testBucketStore_e2e(t *testing.T, ctx context.Context, s *storeSuite) {
 			},
 		},
 	} {
-		if ok := t.Run(fmt.Sprint(i), func(t *testing.T) {
-			seriesSet, _, _, err := srv.Series(context.Background(), tcase.req)
-			require.NoError(t, err)
-
-			assert.Equal(t, len(tcase.expected), len(seriesSet))
-
-			for i, s := range seriesSet {
-				assert.Equal(t, tcase.expected[i], s.Labels)
-				assert.Equal(t, tcase.expectedChunkLen, len(s.Chunks))
+		for _, streamingChunksBatchSize := range []uint64{0, 1, 3} {
+			if ok := t.Run(fmt.Sprintf("%d,streamingChunksBatchSize=%d", i, streamingChunksBatchSize), func(t *testing.T) {
+				req := *tcase.req
+				req.StreamingChunksBatchSize = streamingChunksBatchSize
+
+				seriesSet, _, _, err := srv.Series(context.Background(), &req)
+				require.NoError(t, err)
+
+				assert.Equal(t, len(tcase.expected), len(seriesSet))
+
+				for i, s := range seriesSet {
+					assert.Equal(t, tcase.expected[i], s.Labels)
+					assert.Equal(t, tcase.expectedChunkLen, len(s.Chunks))
+				}
+				assertQueryStatsMetricsRecorded(t, len(tcase.expected), tcase.expectedChunkLen, s.metricsRegistry)
+			}); !ok {
+				return
 			}
-			assertQueryStatsMetricsRecorded(t, len(tcase.expected), tcase.expectedChunkLen, s.metricsRegistry)
-		}); !ok {
-			return
 		}
 	}
 }
@@ -569,6 +589,34 @@ func TestBucketStore_ManyParts_e2e(t *testing.T) {
 	})
 }
 
+// A memory budget smaller than any batch forces each batch to be loaded only once the previous one has been sent.
+func TestBucketStore_MaxBufferedChunks_e2e(t *testing.T) {
+	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := newSuite(withMaxBufferedChunks(1))
+		testBucketStore_e2e(t, ctx, s)
+	})
+}
+
+// Loading a single series per batch makes the batches read the segment files sequentially.
+func TestBucketStore_ChunksReadahead_e2e(t *testing.T) {
+	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := newSuite(withChunksReadahead(1024), func(config *prepareStoreConfig) {
+			config.maxSeriesPerBatch = 1
+		})
+		testBucketStore_e2e(t, ctx, s)
+
+		assert.Greater(t, testutil.ToFloat64(s.store.metrics.chunksReadaheadBytes), 0.0)
+		assert.Greater(t, testutil.ToFloat64(s.store.metrics.chunksReadaheadUsedBytes), 0.0)
+		assert.Greater(t, testutil.ToFloat64(s.store.metrics.chunksReadaheadSavedRequests), 0.0)
+	})
+}
+
 func TestBucketStore_Series_ChunksLimiter_e2e(t *testing.T) {
 	// The query will fetch 2 series from 6 blocks, so we do expect to hit a total of 12 chunks.
 	expectedChunks := uint64(2 * 6)
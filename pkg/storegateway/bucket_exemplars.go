@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"path"
+	"sync"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/grafana/dskit/runutil"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+// Exemplars returns the exemplars persisted in the blocks, for the series matching any of the
+// requested matcher sets, within the requested time range.
+func (s *BucketStore) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	matcherSets := make([][]*labels.Matcher, 0, len(req.Matchers))
+	for _, m := range req.Matchers {
+		matchers, err := storepb.MatchersToPromMatchers(m.Matchers...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request labels matchers").Error())
+		}
+		matcherSets = append(matcherSets, matchers)
+	}
+
+	resHints := &hintspb.ExemplarsResponseHints{}
+
+	var reqBlockMatchers []*labels.Matcher
+	if req.Hints != nil {
+		reqHints := &hintspb.ExemplarsRequestHints{}
+		err := types.UnmarshalAny(req.Hints, reqHints)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "unmarshal exemplars request hints").Error())
+		}
+
+		reqBlockMatchers, err = storepb.MatchersToPromMatchers(reqHints.BlockMatchers...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request hints labels matchers").Error())
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	s.blocksMx.RLock()
+
+	var mtx sync.Mutex
+	var sets [][]storepb.SeriesExemplars
+
+	for _, b := range s.blocks {
+		b := b
+		if !b.overlapsClosedInterval(req.Start, req.End) {
+			continue
+		}
+		if len(reqBlockMatchers) > 0 && !b.matchLabels(reqBlockMatchers) {
+			continue
+		}
+
+		resHints.AddQueriedBlock(b.meta.ULID)
+
+		g.Go(func() error {
+			series, err := b.readExemplars(gctx)
+			if err != nil {
+				return errors.Wrapf(err, "block %s", b.meta.ULID)
+			}
+
+			result := filterSeriesExemplars(series, req.Start, req.End, matcherSets)
+			if len(result) > 0 {
+				mtx.Lock()
+				sets = append(sets, result)
+				mtx.Unlock()
+			}
+
+			return nil
+		})
+	}
+
+	s.blocksMx.RUnlock()
+
+	if err := g.Wait(); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, status.Error(codes.Canceled, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	anyHints, err := types.MarshalAny(resHints)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, errors.Wrap(err, "marshal exemplars response hints").Error())
+	}
+
+	return &storepb.ExemplarsResponse{
+		Series: block.MergeSeriesExemplars(sets...),
+		Hints:  anyHints,
+	}, nil
+}
+
+// readExemplars reads the exemplars persisted in the block. Returns no exemplars if the block has no exemplars file.
+func (b *bucketBlock) readExemplars(ctx context.Context) ([]storepb.SeriesExemplars, error) {
+	r, err := b.bkt.Get(ctx, path.Join(b.meta.ULID.String(), block.ExemplarsFilename))
+	if b.bkt.IsObjNotFoundErr(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get exemplars file")
+	}
+	defer runutil.CloseWithLogOnErr(b.logger, r, "close exemplars file")
+
+	return block.DecodeExemplars(r)
+}
+
+// filterSeriesExemplars returns the exemplars within the time range [start, end] of the series matching any of the
+// matcher sets.
+func filterSeriesExemplars(series []storepb.SeriesExemplars, start, end int64, matcherSets [][]*labels.Matcher) []storepb.SeriesExemplars {
+	var res []storepb.SeriesExemplars
+
+	for _, s := range series {
+		if !seriesMatchesAnySet(mimirpb.FromLabelAdaptersToLabels(s.Labels), matcherSets) {
+			continue
+		}
+
+		var exemplars []mimirpb.Exemplar
+		for _, e := range s.Exemplars {
+			if e.TimestampMs >= start && e.TimestampMs <= end {
+				exemplars = append(exemplars, e)
+			}
+		}
+		if len(exemplars) > 0 {
+			res = append(res, storepb.SeriesExemplars{Labels: s.Labels, Exemplars: exemplars})
+		}
+	}
+
+	return res
+}
+
+func seriesMatchesAnySet(lset labels.Labels, matcherSets [][]*labels.Matcher) bool {
+	for _, matchers := range matcherSets {
+		matches := true
+		for _, m := range matchers {
+			if !m.Matches(lset.Get(m.Name)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/types"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestBucketStore_Exemplars(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		bkt     = objstore.NewInMemBucket()
+		block1  = ulid.MustNew(1, nil)
+		block2  = ulid.MustNew(2, nil)
+		block3  = ulid.MustNew(3, nil)
+		series1 = []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: "series_1"}}
+		series2 = []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: "series_2"}}
+	)
+
+	exemplar := func(traceID string, ts int64) mimirpb.Exemplar {
+		return mimirpb.Exemplar{Labels: []mimirpb.LabelAdapter{{Name: "trace_id", Value: traceID}}, TimestampMs: ts, Value: float64(ts)}
+	}
+
+	// Block 1 and 2 have exemplars, while block 3 has no exemplars file.
+	uploadExemplars(t, bkt, block1, []storepb.SeriesExemplars{
+		{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("a", 10), exemplar("b", 20)}},
+		{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("c", 15)}},
+	})
+	uploadExemplars(t, bkt, block2, []storepb.SeriesExemplars{
+		{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("d", 110)}},
+	})
+
+	newBlock := func(id ulid.ULID, minT, maxT int64) *bucketBlock {
+		return &bucketBlock{
+			logger: log.NewNopLogger(),
+			bkt:    bkt,
+			meta:   &metadata.Meta{BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: minT, MaxTime: maxT}},
+		}
+	}
+
+	store := &BucketStore{blocks: map[ulid.ULID]*bucketBlock{
+		block1: newBlock(block1, 0, 100),
+		block2: newBlock(block2, 100, 200),
+		block3: newBlock(block3, 0, 200),
+	}}
+
+	tests := map[string]struct {
+		start, end      int64
+		matchers        []storepb.ExemplarMatchers
+		expectedSeries  []storepb.SeriesExemplars
+		expectedQueried []ulid.ULID
+	}{
+		"should return exemplars from all blocks overlapping the time range": {
+			start:    0,
+			end:      200,
+			matchers: []storepb.ExemplarMatchers{{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: ".+"}}}},
+			expectedSeries: []storepb.SeriesExemplars{
+				{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("a", 10), exemplar("b", 20), exemplar("d", 110)}},
+				{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("c", 15)}},
+			},
+			expectedQueried: []ulid.ULID{block1, block2, block3},
+		},
+		"should filter exemplars by time range": {
+			start:    12,
+			end:      50,
+			matchers: []storepb.ExemplarMatchers{{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: ".+"}}}},
+			expectedSeries: []storepb.SeriesExemplars{
+				{Labels: series1, Exemplars: []mimirpb.Exemplar{exemplar("b", 20)}},
+				{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("c", 15)}},
+			},
+			expectedQueried: []ulid.ULID{block1, block3},
+		},
+		"should filter series by matchers": {
+			start: 0,
+			end:   200,
+			matchers: []storepb.ExemplarMatchers{
+				{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: "series_2"}}},
+				{Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: "unknown"}}},
+			},
+			expectedSeries: []storepb.SeriesExemplars{
+				{Labels: series2, Exemplars: []mimirpb.Exemplar{exemplar("c", 15)}},
+			},
+			expectedQueried: []ulid.ULID{block1, block2, block3},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			res, err := store.Exemplars(ctx, &storepb.ExemplarsRequest{
+				Start:    testData.start,
+				End:      testData.end,
+				Matchers: testData.matchers,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedSeries, res.Series)
+
+			hints := &hintspb.ExemplarsResponseHints{}
+			require.NoError(t, types.UnmarshalAny(res.Hints, hints))
+
+			var queried []ulid.ULID
+			for _, b := range hints.QueriedBlocks {
+				queried = append(queried, ulid.MustParse(b.Id))
+			}
+			assert.ElementsMatch(t, testData.expectedQueried, queried)
+		})
+	}
+}
+
+func uploadExemplars(t *testing.T, bkt objstore.Bucket, blockID ulid.ULID, series []storepb.SeriesExemplars) {
+	dir := t.TempDir()
+	require.NoError(t, block.WriteExemplarsFile(dir, series))
+
+	data, err := os.ReadFile(filepath.Join(dir, block.ExemplarsFilename))
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(context.Background(), path.Join(blockID.String(), block.ExemplarsFilename), bytes.NewReader(data)))
+}
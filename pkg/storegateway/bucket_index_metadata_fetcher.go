@@ -25,6 +25,7 @@ import (
 const (
 	corruptedBucketIndex = "corrupted-bucket-index"
 	noBucketIndex        = "no-bucket-index"
+	staleBucketIndex     = "stale-bucket-index"
 )
 
 // BucketIndexMetadataFetcher is a Thanos MetadataFetcher implementation leveraging on the Mimir bucket index.
@@ -35,6 +36,10 @@ type BucketIndexMetadataFetcher struct {
 	logger      log.Logger
 	filters     []block.MetadataFilter
 	metrics     *block.FetcherMetrics
+
+	// staleFallbackPeriod is the max age of the bucket index after which the blocks are discovered by scanning
+	// the bucket instead. Disabled if 0.
+	staleFallbackPeriod time.Duration
 }
 
 func NewBucketIndexMetadataFetcher(
@@ -44,14 +49,16 @@ func NewBucketIndexMetadataFetcher(
 	logger log.Logger,
 	reg prometheus.Registerer,
 	filters []block.MetadataFilter,
+	staleFallbackPeriod time.Duration,
 ) *BucketIndexMetadataFetcher {
 	return &BucketIndexMetadataFetcher{
-		userID:      userID,
-		bkt:         bkt,
-		cfgProvider: cfgProvider,
-		logger:      logger,
-		filters:     filters,
-		metrics:     block.NewFetcherMetrics(reg, [][]string{{corruptedBucketIndex}, {noBucketIndex}, {minTimeExcludedMeta}}, nil),
+		userID:              userID,
+		bkt:                 bkt,
+		cfgProvider:         cfgProvider,
+		logger:              logger,
+		filters:             filters,
+		metrics:             block.NewFetcherMetrics(reg, [][]string{{corruptedBucketIndex}, {noBucketIndex}, {staleBucketIndex}, {minTimeExcludedMeta}}, nil),
+		staleFallbackPeriod: staleFallbackPeriod,
 	}
 }
 
@@ -95,6 +102,21 @@ func (f *BucketIndexMetadataFetcher) Fetch(ctx context.Context) (metas map[ulid.
 		return nil, nil, errors.Wrapf(err, "read bucket index")
 	}
 
+	// If the bucket index is too old, because the compactor isn't updating it, the blocks are discovered by
+	// scanning the bucket. The stale index is updated, so that only the new blocks' metas are fetched.
+	if f.staleFallbackPeriod > 0 && time.Since(idx.GetUpdatedAt()) > f.staleFallbackPeriod {
+		level.Warn(f.logger).Log("msg", "bucket index is too old, scanning the bucket to discover the blocks", "user", f.userID, "updated_at", idx.GetUpdatedAt().UTC().Format(time.RFC3339Nano))
+		f.metrics.Synced.WithLabelValues(staleBucketIndex).Set(1)
+
+		idx, _, err = bucketindex.NewUpdater(f.bkt, f.userID, f.cfgProvider, f.logger).UpdateIndex(ctx, idx)
+		if err != nil {
+			f.metrics.Synced.WithLabelValues(block.FailedMeta).Set(1)
+			f.metrics.Submit()
+
+			return nil, nil, errors.Wrapf(err, "scan bucket to update stale bucket index")
+		}
+	}
+
 	// Build block metas out of the index.
 	metas = make(map[ulid.ULID]*metadata.Meta, len(idx.Blocks))
 	for _, b := range idx.Blocks {
@@ -61,7 +61,7 @@ func TestBucketIndexMetadataFetcher_Fetch(t *testing.T) {
 		newMinTimeMetaFilter(1 * time.Hour),
 	}
 
-	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, filters)
+	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, filters, 0)
 	metas, partials, err := fetcher.Fetch(ctx)
 	require.NoError(t, err)
 	assert.Equal(t, map[ulid.ULID]*metadata.Meta{
@@ -92,6 +92,7 @@ func TestBucketIndexMetadataFetcher_Fetch(t *testing.T) {
 		blocks_meta_synced{state="marked-for-no-compact"} 0
 		blocks_meta_synced{state="no-bucket-index"} 0
 		blocks_meta_synced{state="no-meta-json"} 0
+		blocks_meta_synced{state="stale-bucket-index"} 0
 		blocks_meta_synced{state="time-excluded"} 0
 		blocks_meta_synced{state="min-time-excluded"} 1
 		blocks_meta_synced{state="too-fresh"} 0
@@ -116,7 +117,7 @@ func TestBucketIndexMetadataFetcher_Fetch_NoBucketIndex(t *testing.T) {
 	logs := &concurrency.SyncBuffer{}
 	logger := log.NewLogfmtLogger(logs)
 
-	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil)
+	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil, 0)
 	metas, partials, err := fetcher.Fetch(ctx)
 	require.NoError(t, err)
 	assert.Empty(t, metas)
@@ -144,6 +145,7 @@ func TestBucketIndexMetadataFetcher_Fetch_NoBucketIndex(t *testing.T) {
 		blocks_meta_synced{state="marked-for-no-compact"} 0
 		blocks_meta_synced{state="no-bucket-index"} 1
 		blocks_meta_synced{state="no-meta-json"} 0
+		blocks_meta_synced{state="stale-bucket-index"} 0
 		blocks_meta_synced{state="time-excluded"} 0
 		blocks_meta_synced{state="min-time-excluded"} 0
 		blocks_meta_synced{state="too-fresh"} 0
@@ -171,7 +173,7 @@ func TestBucketIndexMetadataFetcher_Fetch_CorruptedBucketIndex(t *testing.T) {
 	// Upload a corrupted bucket index.
 	require.NoError(t, bkt.Upload(ctx, path.Join(userID, bucketindex.IndexCompressedFilename), strings.NewReader("invalid}!")))
 
-	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil)
+	fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, logger, reg, nil, 0)
 	metas, partials, err := fetcher.Fetch(ctx)
 	require.NoError(t, err)
 	assert.Empty(t, metas)
@@ -199,6 +201,7 @@ func TestBucketIndexMetadataFetcher_Fetch_CorruptedBucketIndex(t *testing.T) {
 		blocks_meta_synced{state="marked-for-no-compact"} 0
 		blocks_meta_synced{state="no-bucket-index"} 0
 		blocks_meta_synced{state="no-meta-json"} 0
+		blocks_meta_synced{state="stale-bucket-index"} 0
 		blocks_meta_synced{state="time-excluded"} 0
 		blocks_meta_synced{state="min-time-excluded"} 0
 		blocks_meta_synced{state="too-fresh"} 0
@@ -214,6 +217,58 @@ func TestBucketIndexMetadataFetcher_Fetch_CorruptedBucketIndex(t *testing.T) {
 	))
 }
 
+func TestBucketIndexMetadataFetcher_Fetch_StaleBucketIndex(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	ctx := context.Background()
+
+	// Create a stale bucket index, which doesn't include the last uploaded block.
+	block1 := mimir_testutil.MockStorageBlock(t, bkt, userID, 10, 20)
+	block2 := mimir_testutil.MockStorageBlock(t, bkt, userID, 20, 30)
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, userID, nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion3,
+		Blocks:    bucketindex.Blocks{{ID: block1.ULID, MinTime: block1.MinTime, MaxTime: block1.MaxTime}},
+		UpdatedAt: time.Now().Add(-2 * time.Hour).Unix(),
+	}))
+
+	t.Run("stale fallback disabled", func(t *testing.T) {
+		fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, log.NewNopLogger(), nil, nil, 0)
+		metas, _, err := fetcher.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Len(t, metas, 1)
+		assert.Contains(t, metas, block1.ULID)
+	})
+
+	t.Run("stale fallback enabled", func(t *testing.T) {
+		reg := prometheus.NewPedanticRegistry()
+		logs := &concurrency.SyncBuffer{}
+
+		fetcher := NewBucketIndexMetadataFetcher(userID, bkt, nil, log.NewLogfmtLogger(logs), reg, nil, time.Hour)
+		metas, _, err := fetcher.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Len(t, metas, 2)
+		assert.Contains(t, metas, block1.ULID)
+		assert.Contains(t, metas, block2.ULID)
+		assert.Regexp(t, "bucket index is too old", logs)
+
+		metrics, err := reg.Gather()
+		require.NoError(t, err)
+		synced := map[string]float64{}
+		for _, m := range metrics {
+			if m.GetName() != "blocks_meta_synced" {
+				continue
+			}
+			for _, s := range m.GetMetric() {
+				synced[s.GetLabel()[0].GetValue()] = s.GetGauge().GetValue()
+			}
+		}
+		assert.Equal(t, float64(1), synced[staleBucketIndex])
+		assert.Equal(t, float64(2), synced[block.LoadedMeta])
+	})
+}
+
 // noShardingStrategy is a no-op strategy. When this strategy is used, no tenant/block is filtered out.
 type noShardingStrategy struct{}
 
@@ -225,6 +280,6 @@ func (s *noShardingStrategy) FilterUsers(_ context.Context, userIDs []string) ([
 	return userIDs, nil
 }
 
-func (s *noShardingStrategy) FilterBlocks(_ context.Context, _ string, _ map[ulid.ULID]*metadata.Meta, _ map[ulid.ULID]struct{}, _ block.GaugeVec) error {
+func (s *noShardingStrategy) FilterBlocks(_ context.Context, _ string, _ map[ulid.ULID]*metadata.Meta, _ int64, _ map[ulid.ULID]struct{}, _ block.GaugeVec) error {
 	return nil
 }
@@ -144,14 +144,22 @@ func (r *bucketIndexReader) expandedPostingsPromise(ctx context.Context, ms []*l
 	defer close(done)
 	defer r.block.expandedPostingsPromises.Delete(key)
 
+	refs, pendingMatchers, cached = r.block.hotSeriesSets.fetch(r.block.meta.ULID, key, r.postingsStrategy.name())
+	if cached {
+		return promise, false
+	}
+
+	start := time.Now()
 	refs, pendingMatchers, cached = r.fetchCachedExpandedPostings(ctx, r.block.userID, key, stats)
 	if cached {
+		r.block.hotSeriesSets.store(r.block.meta.ULID, key, r.postingsStrategy.name(), refs, pendingMatchers, time.Since(start))
 		return promise, false
 	}
 	refs, pendingMatchers, err = r.expandedPostings(ctx, ms, stats)
 	if err != nil {
 		return promise, false
 	}
+	r.block.hotSeriesSets.store(r.block.meta.ULID, key, r.postingsStrategy.name(), refs, pendingMatchers, time.Since(start))
 	r.cacheExpandedPostings(r.block.userID, key, refs, pendingMatchers)
 	return promise, false
 }
@@ -24,11 +24,14 @@ type BucketStoreMetrics struct {
 	blockLoadFailures     prometheus.Counter
 	blockDrops            prometheus.Counter
 	blockDropFailures     prometheus.Counter
+	blockDropsPurged      prometheus.Counter
+	seriesFilterSkipped   prometheus.Counter
 	seriesDataTouched     *prometheus.SummaryVec
 	seriesDataFetched     *prometheus.SummaryVec
 	seriesDataSizeTouched *prometheus.SummaryVec
 	seriesDataSizeFetched *prometheus.SummaryVec
 	seriesBlocksQueried   prometheus.Summary
+	seriesBlocksFailed    prometheus.Counter
 	resultSeriesCount     prometheus.Summary
 	chunkSizeBytes        prometheus.Histogram
 	queriesDropped        *prometheus.CounterVec
@@ -38,6 +41,7 @@ type BucketStoreMetrics struct {
 	streamingSeriesRequestDurationByStage      *prometheus.HistogramVec
 	streamingSeriesBatchPreloadingLoadDuration prometheus.Histogram
 	streamingSeriesBatchPreloadingWaitDuration prometheus.Histogram
+	streamingSeriesMemoryBudgetWaitDuration    prometheus.Histogram
 	streamingSeriesRefsFetchDuration           prometheus.Histogram
 
 	cachedPostingsCompressions           *prometheus.CounterVec
@@ -49,6 +53,19 @@ type BucketStoreMetrics struct {
 	seriesHashCacheRequests prometheus.Counter
 	seriesHashCacheHits     prometheus.Counter
 
+	chunksCacheBlockAgeRequests *prometheus.CounterVec
+	chunksCacheBlockAgeHits     *prometheus.CounterVec
+	chunksCacheBlockAgeSkipped  *prometheus.CounterVec
+
+	hotSeriesSetsSelectors    prometheus.Gauge
+	hotSeriesSetsBytes        prometheus.Gauge
+	hotSeriesSetsHits         prometheus.Counter
+	hotSeriesSetsSavedSeconds prometheus.Counter
+
+	chunksReadaheadBytes         prometheus.Counter
+	chunksReadaheadUsedBytes     prometheus.Counter
+	chunksReadaheadSavedRequests prometheus.Counter
+
 	seriesFetchDuration   prometheus.Histogram
 	postingsFetchDuration prometheus.Histogram
 
@@ -75,6 +92,14 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Name: "cortex_bucket_store_block_drop_failures_total",
 		Help: "Total number of local blocks that failed to be dropped.",
 	})
+	m.blockDropsPurged = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_block_drops_purged_cache_entries_total",
+		Help: "Total number of index cache entries, like the expanded postings of the queried matchers, purged because their blocks were dropped.",
+	})
+	m.seriesFilterSkipped = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_series_filter_skipped_blocks_total",
+		Help: "Total number of blocks skipped by the Series(), LabelNames() and LabelValues() calls because their series filter rules out the series matching the request.",
+	})
 	m.seriesDataTouched = promauto.With(reg).NewSummaryVec(prometheus.SummaryOpts{
 		Name: "cortex_bucket_store_series_data_touched",
 		Help: "How many items of a data type in a block were touched for a single series request.",
@@ -97,6 +122,10 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Name: "cortex_bucket_store_series_blocks_queried",
 		Help: "Number of blocks in a bucket store that were touched to satisfy a query.",
 	})
+	m.seriesBlocksFailed = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_series_blocks_failed_total",
+		Help: "Total number of blocks failed to be queried by the series requests returning partial results.",
+	})
 	m.seriesRefetches = promauto.With(reg).NewCounter(prometheus.CounterOpts{
 		Name: "cortex_bucket_store_series_refetches_total",
 		Help: "Total number of cases where the built-in max series size was not enough to fetch series from index, resulting in refetch.",
@@ -160,6 +189,49 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Help: "Total number of fetch hits to the in-memory series hash cache.",
 	})
 
+	m.chunksCacheBlockAgeRequests = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunks_cache_block_age_requests_total",
+		Help: "Total number of chunk ranges requested from the chunks cache, partitioned by the age of the block they belong to.",
+	}, []string{"block_age"})
+	m.chunksCacheBlockAgeHits = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunks_cache_block_age_hits_total",
+		Help: "Total number of chunk ranges retrieved from the chunks cache, partitioned by the age of the block they belong to.",
+	}, []string{"block_age"})
+	m.chunksCacheBlockAgeSkipped = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunks_cache_block_age_skipped_total",
+		Help: "Total number of chunk ranges not looked up in the chunks cache because the age of the block they belong to is outside the configured range, partitioned by block age.",
+	}, []string{"block_age"})
+
+	m.hotSeriesSetsSelectors = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_bucket_store_hot_series_sets_selectors",
+		Help: "Number of selectors currently identified as hot, across all tenants.",
+	})
+	m.hotSeriesSetsBytes = promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_bucket_store_hot_series_sets_size_bytes",
+		Help: "Size - in bytes - of the expanded postings kept in memory for the hot selectors, across all tenants.",
+	})
+	m.hotSeriesSetsHits = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_hot_series_sets_hits_total",
+		Help: "Total number of postings expansions skipped because the expanded postings of a hot selector were kept in memory.",
+	})
+	m.hotSeriesSetsSavedSeconds = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_hot_series_sets_saved_seconds_total",
+		Help: "Total time spent expanding the postings of the hot selectors, which has been saved by keeping them in memory.",
+	})
+
+	m.chunksReadaheadBytes = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunks_readahead_bytes_total",
+		Help: "Total number of bytes of the segment files following the last chunk read by a batch of a series request, kept in memory for the next batches.",
+	})
+	m.chunksReadaheadUsedBytes = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunks_readahead_used_bytes_total",
+		Help: "Total number of bytes kept in memory which have been used by the next batches of a series request.",
+	})
+	m.chunksReadaheadSavedRequests = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunks_readahead_saved_requests_total",
+		Help: "Total number of chunk ranges fully served by the bytes kept in memory, instead of being fetched from the storage.",
+	})
+
 	m.chunkSizeBytes = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name: "cortex_bucket_store_sent_chunk_size_bytes",
 		Help: "Size in bytes of the chunks for the single series, which is adequate to the gRPC message size sent to querier.",
@@ -185,6 +257,11 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Help:    "Time spent by store-gateway waiting until the next batch is loaded, once the store-gateway is ready to send it. This metric is tracked only if the request is split into 2+ batches.",
 		Buckets: durationBuckets,
 	})
+	m.streamingSeriesMemoryBudgetWaitDuration = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+		Name:    "cortex_bucket_store_series_memory_budget_wait_duration_seconds",
+		Help:    "Time spent by store-gateway waiting until the chunks of the next batch fit in the memory budget of the request. This metric is tracked only if the memory budget is enabled.",
+		Buckets: durationBuckets,
+	})
 	m.streamingSeriesRefsFetchDuration = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_bucket_store_series_refs_fetch_duration_seconds",
 		Help:    "Time spent by store-gateway to fetch series labels and chunk references for a single request.",
@@ -9,6 +9,7 @@ import (
 	"context"
 	"io"
 	"net"
+	"strings"
 	"testing"
 
 	"github.com/gogo/protobuf/types"
@@ -18,6 +19,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
@@ -92,12 +94,18 @@ func newStoreGatewayTestServer(t testing.TB, store storegatewaypb.StoreGatewaySe
 }
 
 // Series calls the store server's Series() endpoint via gRPC and returns the responses collected
-// via the gRPC stream.
+// via the gRPC stream. The series streamed with their chunks in separate messages, if the request
+// asks for streaming chunks, are reassembled into the returned series.
 func (s *storeTestServer) Series(ctx context.Context, req *storepb.SeriesRequest) (seriesSet []*storepb.Series, warnings storage.Warnings, hints hintspb.SeriesResponseHints, err error) {
 	var (
-		conn   *grpc.ClientConn
-		stream storepb.Store_SeriesClient
-		res    *storepb.SeriesResponse
+		conn              *grpc.ClientConn
+		stream            storepb.Store_SeriesClient
+		res               *storepb.SeriesResponse
+		streamingSeries   []*storepb.Series
+		endOfSeriesStream bool
+
+		// The number of chunks estimated by the store-gateway before streaming them.
+		estimatedChunks uint64
 	)
 
 	// Create a gRPC connection to the server.
@@ -163,6 +171,66 @@ func (s *storeTestServer) Series(ctx context.Context, req *storepb.SeriesRequest
 
 			seriesSet = append(seriesSet, copiedSeries)
 		}
+
+		if recvSeries := res.GetStreamingSeries(); recvSeries != nil {
+			if endOfSeriesStream {
+				err = errors.New("received streaming series after the end of the series stream")
+				return
+			}
+
+			for _, s := range recvSeries.Series {
+				copiedLabels := make([]mimirpb.LabelAdapter, 0, len(s.Labels))
+				for _, l := range s.Labels {
+					copiedLabels = append(copiedLabels, mimirpb.LabelAdapter{Name: strings.Clone(l.Name), Value: strings.Clone(l.Value)})
+				}
+				streamingSeries = append(streamingSeries, &storepb.Series{Labels: copiedLabels})
+			}
+			endOfSeriesStream = recvSeries.IsEndOfSeriesStream
+			estimatedChunks += recvSeries.EstimatedChunksCount
+		}
+
+		if recvChunks := res.GetStreamingChunks(); recvChunks != nil {
+			if !endOfSeriesStream {
+				err = errors.New("received streaming chunks before the end of the series stream")
+				return
+			}
+
+			for _, s := range recvChunks.Series {
+				if s.SeriesIndex >= uint64(len(streamingSeries)) {
+					err = errors.Errorf("received chunks for the unknown series index %d", s.SeriesIndex)
+					return
+				}
+
+				copiedChunks := &storepb.Series{}
+				var data []byte
+				if data, err = (&storepb.Series{Chunks: s.Chunks}).Marshal(); err != nil {
+					err = errors.Wrap(err, "marshal received chunks")
+					return
+				}
+				if err = copiedChunks.Unmarshal(data); err != nil {
+					err = errors.Wrap(err, "unmarshal received chunks")
+					return
+				}
+				streamingSeries[s.SeriesIndex].Chunks = append(streamingSeries[s.SeriesIndex].Chunks, copiedChunks.Chunks...)
+			}
+		}
+	}
+
+	if req.StreamingChunksBatchSize > 0 && !req.SkipChunks {
+		if !endOfSeriesStream {
+			err = errors.New("the end of the series stream has not been received")
+			return
+		}
+
+		var streamedChunks uint64
+		for _, s := range streamingSeries {
+			streamedChunks += uint64(len(s.Chunks))
+		}
+		if estimatedChunks != streamedChunks {
+			err = errors.Errorf("the estimated number of chunks (%d) doesn't match the number of streamed chunks (%d)", estimatedChunks, streamedChunks)
+			return
+		}
+		seriesSet = append(seriesSet, streamingSeries...)
 	}
 
 	return
@@ -10,14 +10,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/backoff"
-	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/concurrency"
 	"github.com/grafana/dskit/gate"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -27,12 +30,15 @@ import (
 	"google.golang.org/grpc/metadata"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/seriesfilter"
 	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/pool"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -58,17 +64,29 @@ type BucketStores struct {
 
 	chunksCache chunkscache.Cache
 
+	// Deleter of the entries of the chunks cache, nil if the chunks cache doesn't support deleting its entries.
+	chunksCacheDeleter indexcache.MatchingKeysDeleter
+
 	// Series hash cache shared across all tenants.
 	seriesHashCache *hashcache.SeriesHashCache
 
 	// Chunks bytes pool shared across all tenants.
 	chunksPool pool.Bytes
 
+	// Series decoding workers shared across all tenants, nil if they're not partitioned.
+	seriesDecodingWorkers *seriesDecodingWorkers
+
 	// partitioners shared across all tenants.
 	partitioners blockPartitioners
 
-	// Gate used to limit query concurrency across all tenants.
-	queryGate gate.Gate
+	// Gates used to limit query concurrency across all tenants, isolating the tenants from each other.
+	queryGates *tenantQueryGates
+
+	// Throttler of the downloads from the long-term storage, shared across all tenants.
+	downloadThrottler *downloadThrottler
+
+	// Metrics of the reads from the local replica of the blocks, nil if the local replica is disabled.
+	localReplicaMetrics *localReplicaBucketMetrics
 
 	// Keeps a bucket store for each tenant.
 	storesMu sync.RWMutex
@@ -84,11 +102,26 @@ type BucketStores struct {
 
 // NewBucketStores makes a new BucketStores.
 func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStrategy, bucketClient objstore.Bucket, limits *validation.Overrides, logger log.Logger, reg prometheus.Registerer) (*BucketStores, error) {
-	chunksCacheClient, err := cache.CreateClient("chunks-cache", cfg.BucketStore.ChunksCache.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
+	chunksCacheClient, err := tsdb.CreateCacheClient("chunks-cache", cfg.BucketStore.ChunksCache.BackendConfig, cfg.BucketStore.ChunksCache.RedisPipeliningEnabled, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
 	if err != nil {
 		return nil, errors.Wrapf(err, "chunks-cache")
 	}
 
+	// The disk cache tier is used both for the chunks subranges and the fine-grained chunks ranges.
+	chunksCacheClient, err = tsdb.WrapWithDiskCache("chunks-cache", chunksCacheClient, cfg.BucketStore.ChunksCache.Disk, logger, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The requests are hedged below the throttling, so that a hedged request doesn't wait for a download slot.
+	if cfg.BucketStore.HedgedRequests.Enabled {
+		bucketClient = newHedgedBucket(bucketClient, cfg.BucketStore.HedgedRequests, reg)
+	}
+
+	// The downloads are throttled below the caching bucket, so that the objects fetched from the caches are not.
+	downloadThrottler := newDownloadThrottler(cfg.BucketStore.MaxConcurrentDownloads, cfg.BucketStore.MaxDownloadBandwidthBytes, reg)
+	bucketClient = newThrottledBucket(bucketClient, downloadThrottler)
+
 	cachingBucket, err := tsdb.CreateCachingBucket(chunksCacheClient, cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, bucketClient, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "create caching bucket")
@@ -98,6 +131,7 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 	queryGateReg := prometheus.WrapRegistererWithPrefix("cortex_bucket_stores_", reg)
 	queryGate := gate.NewBlocking(cfg.BucketStore.MaxConcurrent)
 	queryGate = gate.NewInstrumented(queryGateReg, cfg.BucketStore.MaxConcurrent, queryGate)
+	queryGates := newTenantQueryGates(queryGate, cfg.BucketStore.MaxConcurrentPerTenant, cfg.BucketStore.MaxQueuedPerTenant, reg)
 
 	u := &BucketStores{
 		logger:             logger,
@@ -108,7 +142,8 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		stores:             map[string]*BucketStore{},
 		bucketStoreMetrics: NewBucketStoreMetrics(reg),
 		metaFetcherMetrics: NewMetadataFetcherMetrics(),
-		queryGate:          queryGate,
+		queryGates:         queryGates,
+		downloadThrottler:  downloadThrottler,
 		partitioners:       newGapBasedPartitioners(cfg.BucketStore.PartitionerMaxGapBytes, reg),
 		seriesHashCache:    hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
 		syncBackoffConfig: backoff.Config{
@@ -140,6 +175,9 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		Name: "cortex_bucket_store_blocks_loaded",
 		Help: "Number of currently loaded blocks.",
 	}, u.getBlocksLoadedMetric)
+	if cfg.BucketStore.LocalReplicaDir != "" {
+		u.localReplicaMetrics = newLocalReplicaBucketMetrics(reg)
+	}
 
 	// Init the index cache.
 	if u.indexCache, err = tsdb.NewIndexCache(cfg.BucketStore.IndexCache, logger, reg); err != nil {
@@ -151,9 +189,21 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		return nil, errors.Wrap(err, "create chunks cache")
 	}
 	u.chunksCache = chunkscache.NewTracingCache(chunksCache, logger)
+	if deleter, ok := chunksCacheClient.(indexcache.MatchingKeysDeleter); ok {
+		u.chunksCacheDeleter = deleter
+	}
 
-	// Init the chunks bytes pool.
-	if u.chunksPool, err = newChunkBytesPool(cfg.BucketStore.ChunkPoolMinBucketSizeBytes, cfg.BucketStore.ChunkPoolMaxBucketSizeBytes, cfg.BucketStore.MaxChunkPoolBytes, reg); err != nil {
+	// Init the chunks bytes pool, partitioned with the series decoding workers per group of CPU cores if enabled.
+	if cfg.BucketStore.CoresPerPartition > 0 {
+		partitions := util_math.Max(1, runtime.GOMAXPROCS(0)/cfg.BucketStore.CoresPerPartition)
+		chunksPools, err := newShardedChunkBytesPool(cfg.BucketStore.ChunkPoolMinBucketSizeBytes, cfg.BucketStore.ChunkPoolMaxBucketSizeBytes, cfg.BucketStore.MaxChunkPoolBytes, partitions, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "create chunks bytes pool")
+		}
+		u.seriesDecodingWorkers = newSeriesDecodingWorkers(chunksPools, cfg.BucketStore.SeriesDecodingWorkersPerPartition, reg)
+		// The chunks bytes are got from the partitions' pools: this one is never used.
+		u.chunksPool = pool.NoopBytes{}
+	} else if u.chunksPool, err = newChunkBytesPool(cfg.BucketStore.ChunkPoolMinBucketSizeBytes, cfg.BucketStore.ChunkPoolMaxBucketSizeBytes, cfg.BucketStore.MaxChunkPoolBytes, reg); err != nil {
 		return nil, errors.Wrap(err, "create chunks bytes pool")
 	}
 
@@ -186,6 +236,25 @@ func (u *BucketStores) SyncBlocks(ctx context.Context) error {
 	})
 }
 
+// SyncTenantsBlocks synchronizes the stores state with the Bucket store for the given users, skipping
+// the ones not owned by this instance. Unlike SyncBlocks, it doesn't close the stores of the users which
+// are not owned anymore, because it doesn't have the full picture of the tenants in the bucket.
+func (u *BucketStores) SyncTenantsBlocks(ctx context.Context, userIDs []string) error {
+	ownedUserIDs, err := u.shardingStrategy.FilterUsers(ctx, userIDs)
+	if err != nil {
+		return errors.Wrap(err, "unable to check tenants owned by this store-gateway instance")
+	}
+
+	return concurrency.ForEachUser(ctx, ownedUserIDs, u.cfg.BucketStore.TenantSyncConcurrency, func(ctx context.Context, userID string) error {
+		bs, err := u.getOrCreateStore(userID)
+		if err != nil {
+			return err
+		}
+
+		return errors.Wrapf(bs.SyncBlocks(ctx), "failed to synchronize TSDB blocks for user %s", userID)
+	})
+}
+
 func (u *BucketStores) syncUsersBlocksWithRetries(ctx context.Context, f func(context.Context, *BucketStore) error) error {
 	retries := backoff.New(ctx, u.syncBackoffConfig)
 
@@ -315,7 +384,7 @@ func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_Seri
 
 	return store.Series(req, spanSeriesServer{
 		Store_SeriesServer: srv,
-		ctx:                spanCtx,
+		ctx:                contextWithDownloadPriority(spanCtx, downloadPriorityQuery),
 	})
 }
 
@@ -334,7 +403,7 @@ func (u *BucketStores) LabelNames(ctx context.Context, req *storepb.LabelNamesRe
 		return &storepb.LabelNamesResponse{}, nil
 	}
 
-	return store.LabelNames(ctx, req)
+	return store.LabelNames(contextWithDownloadPriority(ctx, downloadPriorityQuery), req)
 }
 
 // LabelValues implements the storepb.StoreServer interface.
@@ -352,7 +421,57 @@ func (u *BucketStores) LabelValues(ctx context.Context, req *storepb.LabelValues
 		return &storepb.LabelValuesResponse{}, nil
 	}
 
-	return store.LabelValues(ctx, req)
+	return store.LabelValues(contextWithDownloadPriority(ctx, downloadPriorityQuery), req)
+}
+
+// Exemplars returns the exemplars persisted in the blocks of the tenant.
+func (u *BucketStores) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	spanLog, spanCtx := spanlogger.NewWithLogger(ctx, u.logger, "BucketStores.Exemplars")
+	defer spanLog.Span.Finish()
+
+	userID := getUserIDFromGRPCContext(spanCtx)
+	if userID == "" {
+		return nil, fmt.Errorf("no userID")
+	}
+
+	store := u.getStore(userID)
+	if store == nil {
+		return &storepb.ExemplarsResponse{}, nil
+	}
+
+	return store.Exemplars(contextWithDownloadPriority(ctx, downloadPriorityQuery), req)
+}
+
+// InvalidateBlockCaches removes the cached entries of the input blocks from the index and chunks caches
+// supporting it, and returns the number of removed entries.
+func (u *BucketStores) InvalidateBlockCaches(userID string, blockIDs []ulid.ULID) int {
+	removed := 0
+	if invalidator, ok := u.indexCache.(indexcache.BlockInvalidator); ok {
+		removed += invalidator.InvalidateBlocks(userID, blockIDs)
+	}
+	if u.chunksCacheDeleter != nil {
+		removed += u.chunksCacheDeleter.DeleteMatching(blockChunksCacheKeysMatcher(userID, blockIDs))
+	}
+	return removed
+}
+
+// blockChunksCacheKeysMatcher returns a function matching the keys of the chunks cache entries of the input blocks:
+// both the keys of the chunks ranges, formatted as "C:<user>:<block>:...", and the ones of the caching bucket,
+// containing the name of the block objects, formatted as "<user>/<block>/...".
+func blockChunksCacheKeysMatcher(userID string, blockIDs []ulid.ULID) func(key string) bool {
+	patterns := make([]string, 0, 2*len(blockIDs))
+	for _, blockID := range blockIDs {
+		patterns = append(patterns, ":"+userID+":"+blockID.String()+":", userID+"/"+blockID.String()+"/")
+	}
+
+	return func(key string) bool {
+		for _, pattern := range patterns {
+			if strings.Contains(key, pattern) {
+				return true
+			}
+		}
+		return false
+	}
 }
 
 // scanUsers in the bucket and return the list of found users. If an error occurs while
@@ -394,6 +513,8 @@ func (u *BucketStores) closeBucketStore(userID string) error {
 	u.storesMu.Unlock()
 
 	u.metaFetcherMetrics.RemoveUserRegistry(userID)
+	u.downloadThrottler.removeTenant(userID)
+	u.queryGates.removeTenant(userID)
 	return bs.RemoveBlocksAndClose()
 }
 
@@ -422,6 +543,13 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 	level.Info(userLogger).Log("msg", "creating user bucket store")
 
 	userBkt := bucket.NewUserBucketClient(userID, u.bucket, u.limits)
+	if u.cfg.BucketStore.LocalReplicaDir != "" {
+		replicaBkt, err := filesystem.NewBucketClient(filesystem.Config{Directory: filepath.Join(u.cfg.BucketStore.LocalReplicaDir, userID)})
+		if err != nil {
+			return nil, errors.Wrap(err, "create local replica bucket client")
+		}
+		userBkt = newLocalReplicaBucket(userBkt, replicaBkt, func() bool { return u.limits.StoreGatewayLocalReplicaEnabled(userID) }, u.localReplicaMetrics)
+	}
 	fetcherReg := prometheus.NewRegistry()
 
 	// The sharding strategy filter MUST be before the ones we create here (order matters).
@@ -447,6 +575,7 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 			u.logger,
 			fetcherReg,
 			filters,
+			u.bucketIndexStaleFallbackPeriod(),
 		)
 	} else {
 		var err error
@@ -467,9 +596,60 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 		WithLogger(userLogger),
 		WithIndexCache(u.indexCache),
 		WithChunksCache(u.chunksCache),
-		WithQueryGate(u.queryGate),
+		WithQueryGate(u.queryGates.forTenant(userID)),
 		WithChunkPool(u.chunksPool),
+		WithSeriesDecodingWorkers(u.seriesDecodingWorkers),
 		WithFineGrainedChunksCaching(u.cfg.BucketStore.ChunksCache.FineGrainedChunksCachingEnabled),
+		WithChunksCacheBlockAgeLimits(
+			func() time.Duration { return u.limits.StoreGatewayChunksCacheMinBlockAge(userID) },
+			func() time.Duration { return u.limits.StoreGatewayChunksCacheMaxBlockAge(userID) },
+		),
+		WithPartialResults(func() bool { return u.limits.StoreGatewayPartialResultsEnabled(userID) }),
+		WithIndexHeaderEviction(
+			u.cfg.BucketStore.IndexHeaderLazyLoadingEvictionPolicy,
+			func() int64 { return int64(u.limits.StoreGatewayMaxResidentIndexHeaderBytes(userID)) },
+			func() bool { return u.limits.StoreGatewayIndexHeadersPinned(userID) },
+		),
+		WithSeriesFilters(func(ctx context.Context) (*seriesfilter.Index, error) {
+			if !u.limits.CompactorSeriesFilterEnabled(userID) {
+				return nil, nil
+			}
+			idx, err := seriesfilter.ReadIndex(ctx, u.bucket, userID, u.limits, userLogger)
+			if errors.Is(err, seriesfilter.ErrIndexNotFound) {
+				return nil, nil
+			}
+			return idx, err
+		}),
+		WithHotSeriesSets(
+			u.cfg.BucketStore.HotSeriesSetsMaxBytesPerTenant,
+			u.cfg.BucketStore.HotSeriesSetsMinQueries,
+			u.cfg.BucketStore.HotSeriesSetsTrackingPeriod,
+		),
+		WithMaxBufferedChunksBytesPerRequest(u.cfg.BucketStore.StreamingMaxBufferedChunksBytes),
+		WithSeriesHashesPersistence(u.cfg.BucketStore.SeriesHashCachePersistenceEnabled),
+		WithChunksReadahead(u.cfg.BucketStore.ChunksReadaheadMaxBytes, u.cfg.BucketStore.ChunksReadaheadMaxBytesPerRequest),
+		WithRequestBytesLimiters(
+			NewBytesLimiterFactory(func() uint64 {
+				return uint64(u.limits.StoreGatewayMaxTouchedPostingsBytesPerRequest(userID))
+			}),
+			NewBytesLimiterFactory(func() uint64 {
+				return uint64(u.limits.StoreGatewayMaxTouchedSeriesBytesPerRequest(userID))
+			}),
+			NewBytesLimiterFactory(func() uint64 {
+				return uint64(u.limits.StoreGatewayMaxTouchedChunksBytesPerRequest(userID))
+			}),
+		),
+		WithTenantBytesLimiters(
+			NewInflightBytesLimiter(func() uint64 {
+				return uint64(u.limits.StoreGatewayMaxTouchedPostingsBytesPerTenant(userID))
+			}),
+			NewInflightBytesLimiter(func() uint64 {
+				return uint64(u.limits.StoreGatewayMaxTouchedSeriesBytesPerTenant(userID))
+			}),
+			NewInflightBytesLimiter(func() uint64 {
+				return uint64(u.limits.StoreGatewayMaxTouchedChunksBytesPerTenant(userID))
+			}),
+		),
 	}
 
 	bs, err := NewBucketStore(
@@ -544,6 +724,25 @@ func (u *BucketStores) closeBucketStoreAndDeleteLocalFilesForExcludedTenants(inc
 	}
 }
 
+// getStores returns the stores of the tenants loaded by the store-gateway, by tenant.
+func (u *BucketStores) getStores() map[string]*BucketStore {
+	u.storesMu.RLock()
+	defer u.storesMu.RUnlock()
+
+	stores := make(map[string]*BucketStore, len(u.stores))
+	for userID, store := range u.stores {
+		stores[userID] = store
+	}
+	return stores
+}
+
+// flushSeriesHashes persists the pending series hashes of the blocks of all the tenants.
+func (u *BucketStores) flushSeriesHashes() {
+	for _, store := range u.getStores() {
+		store.flushSeriesHashes()
+	}
+}
+
 // getBlocksLoadedMetric returns the number of blocks currently loaded across all bucket stores.
 func (u *BucketStores) getBlocksLoadedMetric() float64 {
 	count := 0
@@ -580,3 +779,12 @@ type spanSeriesServer struct {
 func (s spanSeriesServer) Context() context.Context {
 	return s.ctx
 }
+
+// bucketIndexStaleFallbackPeriod returns the max age of the bucket index after which the blocks are discovered by
+// scanning the bucket, or 0 if the fallback is disabled.
+func (u *BucketStores) bucketIndexStaleFallbackPeriod() time.Duration {
+	if !u.cfg.BucketStore.BucketIndex.StaleFallbackEnabled {
+		return 0
+	}
+	return u.cfg.BucketStore.BucketIndex.MaxStalePeriod
+}
@@ -12,7 +12,9 @@ import (
 	"io"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -20,6 +22,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/flagext"
+	dstest "github.com/grafana/dskit/test"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -46,6 +49,8 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+	"github.com/grafana/mimir/pkg/storegateway/diskcache"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
@@ -266,6 +271,41 @@ func TestBucketStores_SyncBlocks(t *testing.T) {
 	assert.Greater(t, testutil.ToFloat64(stores.syncLastSuccess), float64(0))
 }
 
+func TestBucketStores_SyncTenantsBlocks(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	const metricName = "series_1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+
+	storageDir := t.TempDir()
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, defaultLimitsOverrides(t), log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	// Run an initial sync to discover 1 block for each user.
+	generateStorageBlock(t, storageDir, "user-1", metricName, 10, 100, 15)
+	generateStorageBlock(t, storageDir, "user-2", metricName, 10, 100, 15)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// Generate another block for each user, but only sync the blocks of user-1 and a new user.
+	generateStorageBlock(t, storageDir, "user-1", metricName, 100, 200, 15)
+	generateStorageBlock(t, storageDir, "user-2", metricName, 100, 200, 15)
+	generateStorageBlock(t, storageDir, "user-3", metricName, 100, 200, 15)
+	require.NoError(t, stores.SyncTenantsBlocks(ctx, []string{"user-1", "user-3"}))
+
+	for userID, expectedSeries := range map[string]int{"user-1": 1, "user-2": 0, "user-3": 1} {
+		seriesSet, warnings, err := querySeries(t, stores, userID, metricName, 150, 180)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		assert.Len(t, seriesSet, expectedSeries, userID)
+	}
+}
+
 func TestBucketStores_syncUsersBlocks(t *testing.T) {
 	test.VerifyNoLeak(t)
 
@@ -635,6 +675,217 @@ func setUserIDToGRPCContext(ctx context.Context, userID string) context.Context
 	return grpc_metadata.AppendToOutgoingContext(ctx, GrpcContextMetadataTenantID, userID)
 }
 
+func TestBucketStores_ShouldPurgeTheCachedEntriesOfTheDroppedBlocks(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+
+	storageDir := t.TempDir()
+	generateStorageBlock(t, storageDir, userID, "series_1", 10, 100, 15)
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, defaultLimitsOverrides(t), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	blockIDs := stores.getStore(userID).loadedBlockIDs()
+	require.Len(t, blockIDs, 1)
+
+	// The query caches the expanded postings of its matchers, among the others.
+	seriesSet, _, err := querySeries(t, stores, userID, "series_1", 0, 100)
+	require.NoError(t, err)
+	require.Len(t, seriesSet, 1)
+	stores.indexCache.StoreExpandedPostings(userID, blockIDs[0], "matchers", "strategy", []byte{1})
+
+	// The block is deleted from the storage, and so dropped by the next sync.
+	require.NoError(t, os.RemoveAll(filepath.Join(storageDir, userID, blockIDs[0].String())))
+	require.NoError(t, stores.SyncBlocks(ctx))
+
+	_, ok := stores.indexCache.FetchExpandedPostings(ctx, userID, blockIDs[0], "matchers", "strategy")
+	assert.False(t, ok)
+
+	metrics, err := reg.Gather()
+	require.NoError(t, err)
+	purged := float64(0)
+	for _, m := range metrics {
+		if m.GetName() == "cortex_bucket_store_block_drops_purged_cache_entries_total" {
+			purged = m.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	assert.Greater(t, purged, float64(1))
+}
+
+func TestBucketStores_InvalidateBlockCaches_ShouldPurgeTheChunksDiskCache(t *testing.T) {
+	ctx := context.Background()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.ChunksCache.Disk = mimir_tsdb.DiskCacheConfig{Enabled: true, Directory: t.TempDir(), MaxSizeBytes: 1024 * 1024}
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, defaultLimitsOverrides(t), log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NotNil(t, stores.chunksCacheDeleter)
+
+	// Store both the chunks ranges and the subranges of the chunks objects of the blocks.
+	keys := []string{
+		"C:user-1:" + block1.String() + ":0:10",
+		"subrange:user-1/" + block1.String() + "/chunks/000001:0:16000",
+		"C:user-1:" + block2.String() + ":0:10",
+		"subrange:user-1/" + block2.String() + "/chunks/000001:0:16000",
+		"C:user-2:" + block1.String() + ":0:10",
+	}
+	chunksCache := stores.chunksCacheDeleter.(*diskcache.TieredCache)
+	t.Cleanup(chunksCache.Stop)
+
+	for _, key := range keys {
+		chunksCache.StoreAsync(map[string][]byte{key: {1}}, time.Hour)
+	}
+	dstest.Poll(t, time.Second, len(keys), func() interface{} {
+		return len(chunksCache.Fetch(ctx, keys))
+	})
+
+	// Only the entries of the first block of the tenant are purged.
+	assert.Equal(t, 2, stores.InvalidateBlockCaches("user-1", []ulid.ULID{block1}))
+	assert.Equal(t, map[string][]byte{keys[2]: {1}, keys[3]: {1}, keys[4]: {1}}, chunksCache.Fetch(ctx, keys))
+}
+
+func TestBucketStores_Series_ShouldReturnPartialResultsIfEnabled(t *testing.T) {
+	const userID = "user-1"
+
+	for _, partialResultsEnabled := range []bool{false, true} {
+		for _, streamingBatchSize := range []uint64{0, 10} {
+			t.Run(fmt.Sprintf("partial results enabled: %t, streaming batch size: %d", partialResultsEnabled, streamingBatchSize), func(t *testing.T) {
+				ctx := context.Background()
+				cfg := prepareStorageConfig(t)
+
+				storageDir := t.TempDir()
+				generateStorageBlock(t, storageDir, userID, "series_1", 10, 100, 15)
+				generateStorageBlock(t, storageDir, userID, "series_1", 200, 300, 15)
+
+				fsBucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+				require.NoError(t, err)
+				bucket := &failGetRangeBucket{Bucket: fsBucket}
+
+				limitsCfg := defaultLimitsConfig()
+				limitsCfg.StoreGatewayPartialResultsEnabled = partialResultsEnabled
+				overrides, err := validation.NewOverrides(limitsCfg, nil)
+				require.NoError(t, err)
+
+				reg := prometheus.NewPedanticRegistry()
+				stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, overrides, log.NewNopLogger(), reg)
+				require.NoError(t, err)
+				require.NoError(t, stores.InitialSync(ctx))
+
+				blockIDs := stores.getStore(userID).loadedBlockIDs()
+				require.Len(t, blockIDs, 2)
+				sort.Slice(blockIDs, func(i, j int) bool { return blockIDs[i].Compare(blockIDs[j]) < 0 })
+
+				// The postings of the first block can't be fetched from the storage.
+				failedBlock := blockIDs[0]
+				bucket.failName.Store(path.Join(userID, failedBlock.String(), block.IndexFilename))
+
+				req := &storepb.SeriesRequest{
+					MinTime:                  0,
+					MaxTime:                  300,
+					Matchers:                 []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: "series_1"}},
+					StreamingChunksBatchSize: streamingBatchSize,
+				}
+				srv := newBucketStoreTestServer(t, stores)
+				seriesSet, warnings, hints, err := srv.Series(setUserIDToGRPCContext(ctx, userID), req)
+
+				if !partialResultsEnabled {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), "GetRange() request mocked error")
+					return
+				}
+
+				require.NoError(t, err)
+				require.Len(t, warnings, 1)
+				assert.True(t, strings.HasPrefix(warnings[0].Error(), partialResultsWarningPrefix+": failed to query 1 of 2 blocks"))
+				assert.Contains(t, warnings[0].Error(), failedBlock.String())
+
+				// The failed block is reported as queried, so that the querier doesn't retry it.
+				assert.ElementsMatch(t, []hintspb.Block{{Id: blockIDs[0].String()}, {Id: blockIDs[1].String()}}, hints.QueriedBlocks)
+
+				// Only the samples of the second block are returned.
+				require.Len(t, seriesSet, 1)
+				samples, err := readSamplesFromChunks(seriesSet[0].Chunks)
+				require.NoError(t, err)
+				require.NotEmpty(t, samples)
+				assert.GreaterOrEqual(t, samples[0].t, int64(200))
+
+				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+					# HELP cortex_bucket_store_series_blocks_failed_total Total number of blocks failed to be queried by the series requests returning partial results.
+					# TYPE cortex_bucket_store_series_blocks_failed_total counter
+					cortex_bucket_store_series_blocks_failed_total 1
+				`), "cortex_bucket_store_series_blocks_failed_total"))
+			})
+		}
+	}
+}
+
+func TestBucketStores_Series_ShouldReadTheBlocksFromTheLocalReplica(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.LocalReplicaDir = t.TempDir()
+
+	// The blocks are in the local replica, while the object storage only has their meta.json, to be discovered.
+	storageDir := t.TempDir()
+	generateStorageBlock(t, cfg.BucketStore.LocalReplicaDir, userID, "series_1", 10, 100, 15)
+
+	entries, err := os.ReadDir(filepath.Join(cfg.BucketStore.LocalReplicaDir, userID))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	blockID := entries[0].Name()
+
+	meta, err := os.ReadFile(filepath.Join(cfg.BucketStore.LocalReplicaDir, userID, blockID, block.MetaFilename))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(storageDir, userID, blockID), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(storageDir, userID, blockID, block.MetaFilename), meta, 0o644))
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	for _, localReplicaEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("local replica enabled: %t", localReplicaEnabled), func(t *testing.T) {
+			cfg.BucketStore.SyncDir = t.TempDir()
+
+			limitsCfg := defaultLimitsConfig()
+			limitsCfg.StoreGatewayLocalReplicaEnabled = localReplicaEnabled
+			overrides, err := validation.NewOverrides(limitsCfg, nil)
+			require.NoError(t, err)
+
+			reg := prometheus.NewPedanticRegistry()
+			stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, overrides, log.NewNopLogger(), reg)
+			require.NoError(t, err)
+			require.NoError(t, stores.InitialSync(ctx))
+
+			// The block can't be loaded from the object storage alone.
+			seriesSet, _, err := querySeries(t, stores, userID, "series_1", 20, 40)
+			require.NoError(t, err)
+			if !localReplicaEnabled {
+				assert.Empty(t, seriesSet)
+				return
+			}
+
+			require.Len(t, seriesSet, 1)
+			assert.Equal(t, []mimirpb.LabelAdapter{{Name: labels.MetricName, Value: "series_1"}}, seriesSet[0].Labels)
+			assert.Greater(t, testutil.ToFloat64(stores.localReplicaMetrics.reads.WithLabelValues(localReplicaSource)), float64(0))
+			assert.Equal(t, float64(0), testutil.ToFloat64(stores.localReplicaMetrics.reads.WithLabelValues(objectStorageSource)))
+		})
+	}
+}
+
 func TestBucketStores_deleteLocalFilesForExcludedTenants(t *testing.T) {
 	test.VerifyNoLeak(t)
 
@@ -759,7 +1010,7 @@ func (u *userShardingStrategy) FilterUsers(ctx context.Context, userIDs []string
 	return u.users, nil
 }
 
-func (u *userShardingStrategy) FilterBlocks(ctx context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error {
+func (u *userShardingStrategy) FilterBlocks(ctx context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, blocksSize int64, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error {
 	if util.StringsContain(u.users, userID) {
 		return nil
 	}
@@ -785,6 +1036,21 @@ func (f *failFirstGetBucket) Get(ctx context.Context, name string) (io.ReadClose
 	return f.Bucket.Get(ctx, name)
 }
 
+// failGetRangeBucket is an objstore.Bucket wrapper which fails the GetRange() requests of the object failName with a mocked error.
+type failGetRangeBucket struct {
+	objstore.Bucket
+
+	failName atomic.String
+}
+
+func (f *failGetRangeBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if name == f.failName.Load() {
+		return nil, errors.New("GetRange() request mocked error")
+	}
+
+	return f.Bucket.GetRange(ctx, name, off, length)
+}
+
 func BenchmarkBucketStoreLabelValues(tb *testing.B) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
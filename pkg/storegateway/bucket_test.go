@@ -58,6 +58,7 @@ import (
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storage/tsdb/seriesfilter"
 	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
@@ -1636,7 +1637,7 @@ func TestBucketStore_Series_OneBlock_InMemIndexCacheSegfault(t *testing.T) {
 		logger:          logger,
 		indexCache:      indexCache,
 		chunksCache:     chunkscache.NoopCache{},
-		indexReaderPool: indexheader.NewReaderPool(log.NewNopLogger(), false, 0, indexheader.NewReaderPoolMetrics(nil)),
+		indexReaderPool: indexheader.NewReaderPool(log.NewNopLogger(), false, indexheader.EvictionConfig{}, indexheader.NewReaderPoolMetrics(nil)),
 		metrics:         NewBucketStoreMetrics(nil),
 		blockSet:        &bucketBlockSet{blocks: []*bucketBlock{b1, b2}},
 		blocks: map[ulid.ULID]*bucketBlock{
@@ -1649,6 +1650,13 @@ func TestBucketStore_Series_OneBlock_InMemIndexCacheSegfault(t *testing.T) {
 		maxSeriesPerBatch:        65536,
 		numChunksRangesPerSeries: 1,
 		chunkPool:                chunkPool,
+
+		postingsBytesLimiterFactory: newStaticBytesLimiterFactory(0),
+		seriesBytesLimiterFactory:   newStaticBytesLimiterFactory(0),
+		chunksBytesLimiterFactory:   newStaticBytesLimiterFactory(0),
+		tenantPostingsBytesLimiter:  NewInflightBytesLimiter(noBytesLimit),
+		tenantSeriesBytesLimiter:    NewInflightBytesLimiter(noBytesLimit),
+		tenantChunksBytesLimiter:    NewInflightBytesLimiter(noBytesLimit),
 	}
 
 	srv := newBucketStoreTestServer(t, store)
@@ -1768,6 +1776,52 @@ func TestBucketStore_Series_RequestAndResponseHints(t *testing.T) {
 	runTestServerSeries(tb, store, newTestCases(seriesSet1, seriesSet2, block1, block2)...)
 }
 
+func TestBucketStore_Series_ShouldSkipBlocksBySeriesFilter(t *testing.T) {
+	var filterIdx *seriesfilter.Index
+	loadSeriesFilters := func(context.Context) (*seriesfilter.Index, error) { return filterIdx, nil }
+
+	tb, store, seriesSet1, _, block1, block2, close := setupStoreForHintsTest(t, 5000, WithSeriesFilters(loadSeriesFilters))
+	tb.Cleanup(close)
+
+	// The filter of the first block has the pairs of its series, while the filter of the second block is empty.
+	filter1 := seriesfilter.NewFilter(0)
+	for _, s := range seriesSet1 {
+		for _, l := range s.Labels {
+			filter1.Add(l.Name, l.Value)
+		}
+	}
+	filterIdx = &seriesfilter.Index{Version: seriesfilter.IndexVersion1, Blocks: []*seriesfilter.Block{
+		{ID: block1, Filter: filter1},
+		{ID: block2, Filter: seriesfilter.NewFilter(0)},
+	}}
+	require.NoError(t, store.SyncBlocks(context.Background()))
+
+	// The skipped block is still reported as queried.
+	runTestServerSeries(tb, store, &seriesCase{
+		Name: "querying a range containing multiple blocks should skip the blocks ruled out by the series filter",
+		Req: &storepb.SeriesRequest{
+			MinTime: 0,
+			MaxTime: 3,
+			Matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_EQ, Name: "foo", Value: "bar"},
+			},
+		},
+		ExpectedSeries: seriesSet1,
+		ExpectedHints: hintspb.SeriesResponseHints{
+			QueriedBlocks: []hintspb.Block{
+				{Id: block1.String()},
+				{Id: block2.String()},
+			},
+		},
+	})
+	assert.Equal(t, float64(1), promtest.ToFloat64(store.metrics.seriesFilterSkipped))
+
+	// The blocks are not skipped anymore once the series filter index is not available.
+	filterIdx = nil
+	require.NoError(t, store.SyncBlocks(context.Background()))
+	assert.False(t, store.skipBlockBySeriesFilter(block2, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "foo", "bar")}))
+}
+
 func TestBucketStore_Series_ErrorUnmarshallingRequestHints(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -2140,11 +2194,17 @@ func TestBucketStore_Series_Limits(t *testing.T) {
 	assert.NoError(t, err)
 
 	tests := map[string]struct {
-		reqMatchers    []storepb.LabelMatcher
-		seriesLimit    uint64
-		chunksLimit    uint64
-		expectedErr    string
-		expectedSeries int
+		reqMatchers              []storepb.LabelMatcher
+		seriesLimit              uint64
+		chunksLimit              uint64
+		postingsBytesLimit       uint64
+		seriesBytesLimit         uint64
+		chunksBytesLimit         uint64
+		tenantPostingsBytesLimit uint64
+		tenantSeriesBytesLimit   uint64
+		tenantChunksBytesLimit   uint64
+		expectedErr              string
+		expectedSeries           int
 	}{
 		"should fail if the number of unique series queried is greater than the configured series limit": {
 			reqMatchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
@@ -2166,6 +2226,46 @@ func TestBucketStore_Series_Limits(t *testing.T) {
 			chunksLimit:    6,
 			expectedSeries: 3,
 		},
+		"should fail if the size of the postings touched is greater than the configured postings bytes limit": {
+			reqMatchers:        []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			postingsBytesLimit: 1,
+			expectedErr:        ErrPostingsBytesLimitMessage,
+		},
+		"should fail if the size of the series touched is greater than the configured series bytes limit": {
+			reqMatchers:      []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			seriesBytesLimit: 1,
+			expectedErr:      ErrSeriesBytesLimitMessage,
+		},
+		"should fail if the size of the chunks touched is greater than the configured chunks bytes limit": {
+			reqMatchers:      []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			chunksBytesLimit: 1,
+			expectedErr:      ErrChunksBytesLimitMessage,
+		},
+		"should fail if the size of the postings touched is greater than the configured tenant postings bytes limit": {
+			reqMatchers:              []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			tenantPostingsBytesLimit: 1,
+			expectedErr:              ErrTenantPostingsBytesLimitMessage,
+		},
+		"should fail if the size of the series touched is greater than the configured tenant series bytes limit": {
+			reqMatchers:            []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			tenantSeriesBytesLimit: 1,
+			expectedErr:            ErrTenantSeriesBytesLimitMessage,
+		},
+		"should fail if the size of the chunks touched is greater than the configured tenant chunks bytes limit": {
+			reqMatchers:            []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			tenantChunksBytesLimit: 1,
+			expectedErr:            ErrTenantChunksBytesLimitMessage,
+		},
+		"should pass if the size of the data touched is less than the configured bytes limits": {
+			reqMatchers:              []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			postingsBytesLimit:       1024 * 1024,
+			seriesBytesLimit:         1024 * 1024,
+			chunksBytesLimit:         1024 * 1024,
+			tenantPostingsBytesLimit: 1024 * 1024,
+			tenantSeriesBytesLimit:   1024 * 1024,
+			tenantChunksBytesLimit:   1024 * 1024,
+			expectedSeries:           3,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -2189,10 +2289,27 @@ func TestBucketStore_Series_Limits(t *testing.T) {
 						0,
 						hashcache.NewSeriesHashCache(1024*1024),
 						NewBucketStoreMetrics(nil),
+						WithRequestBytesLimiters(
+							newStaticBytesLimiterFactory(testData.postingsBytesLimit),
+							newStaticBytesLimiterFactory(testData.seriesBytesLimit),
+							newStaticBytesLimiterFactory(testData.chunksBytesLimit),
+						),
+						WithTenantBytesLimiters(
+							NewInflightBytesLimiter(func() uint64 { return testData.tenantPostingsBytesLimit }),
+							NewInflightBytesLimiter(func() uint64 { return testData.tenantSeriesBytesLimit }),
+							NewInflightBytesLimiter(func() uint64 { return testData.tenantChunksBytesLimit }),
+						),
 					)
 					assert.NoError(t, err)
 					assert.NoError(t, store.SyncBlocks(ctx))
 
+					// The bytes reserved out of the tenant limits are released once the request has completed.
+					t.Cleanup(func() {
+						assert.Zero(t, store.tenantPostingsBytesLimiter.Reserved())
+						assert.Zero(t, store.tenantSeriesBytesLimiter.Reserved())
+						assert.Zero(t, store.tenantChunksBytesLimiter.Reserved())
+					})
+
 					req := &storepb.SeriesRequest{
 						MinTime:  minTime,
 						MaxTime:  maxTime,
@@ -2590,7 +2707,7 @@ func BenchmarkBucketBlock_readChunkRange(b *testing.B) {
 		offset := int64(0)
 		length := readLengths[n%len(readLengths)]
 
-		_, err := blk.readChunkRange(ctx, 0, offset, length, byteRanges{{offset: 0, length: int(length)}})
+		_, err := blk.readChunkRange(ctx, 0, offset, length, byteRanges{{offset: 0, length: int(length)}}, blk.chunkPool)
 		if err != nil {
 			b.Fatal(err.Error())
 		}
@@ -0,0 +1,513 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-redis/redis/v8"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/nats-io/nats.go"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	// CacheInvalidationBackendRedisStreams is the value for the Redis Streams cache invalidation backend.
+	CacheInvalidationBackendRedisStreams = "redis-streams"
+	// CacheInvalidationBackendNATSJetStream is the value for the NATS JetStream cache invalidation backend.
+	CacheInvalidationBackendNATSJetStream = "nats-jetstream"
+
+	// BlockEventDeleted is the type of the events of blocks deleted from the storage.
+	BlockEventDeleted = "deleted"
+	// BlockEventCompacted is the type of the events of blocks compacted into other blocks.
+	BlockEventCompacted = "compacted"
+
+	// cacheInvalidationReceiveBackoff is the time waited before receiving the next event after a failure,
+	// to not hammer the backend while it's unavailable.
+	cacheInvalidationReceiveBackoff = time.Second
+
+	// redisStreamBlockTimeout is the max time a read from the Redis stream blocks waiting for new events.
+	redisStreamBlockTimeout = 10 * time.Second
+	// redisStreamReadCount is the max number of events read from the Redis stream at once.
+	redisStreamReadCount = 100
+	// redisStreamEventField is the field of the Redis stream entries holding the event.
+	redisStreamEventField = "event"
+
+	// natsFetchTimeout is the max time a fetch from the NATS JetStream consumer waits for new events.
+	natsFetchTimeout = 10 * time.Second
+	// natsFetchCount is the max number of events fetched from the NATS JetStream consumer at once.
+	natsFetchCount = 100
+
+	eventResultProcessed = "processed"
+	eventResultInvalid   = "invalid"
+)
+
+var (
+	supportedCacheInvalidationBackends = []string{CacheInvalidationBackendRedisStreams, CacheInvalidationBackendNATSJetStream}
+
+	errInvalidCacheInvalidationBackend  = fmt.Errorf("invalid cache invalidation backend, supported values: %s", strings.Join(supportedCacheInvalidationBackends, ", "))
+	errMissingCacheInvalidationEndpoint = errors.New("the cache invalidation redis endpoint is required")
+	errMissingCacheInvalidationStream   = errors.New("the cache invalidation redis stream is required")
+	errMissingCacheInvalidationNATSURL  = errors.New("the cache invalidation nats url is required")
+	errMissingCacheInvalidationSubject  = errors.New("the cache invalidation nats subject is required")
+)
+
+// CacheInvalidationConfig holds the config of the cache invalidation bus the store-gateway subscribes to.
+type CacheInvalidationConfig struct {
+	Enabled bool                         `yaml:"enabled" category:"experimental"`
+	Backend string                       `yaml:"backend" category:"experimental"`
+	Redis   CacheInvalidationRedisConfig `yaml:"redis"`
+	NATS    CacheInvalidationNATSConfig  `yaml:"nats"`
+}
+
+// RegisterFlagsWithPrefix registers the CacheInvalidationConfig flags with the given prefix.
+func (cfg *CacheInvalidationConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Subscribe to the blocks deletion and compaction events published on the cache invalidation bus, and purge the cached entries of the obsolete blocks instead of waiting for them to expire. The entries are purged from the in-memory index cache and from the disk tiers of the index and chunks caches. The entries stored in Memcached or Redis expire with their TTL. The tenant's blocks are synchronized too, without waiting for the next periodic sync.")
+	f.StringVar(&cfg.Backend, prefix+"backend", CacheInvalidationBackendRedisStreams, fmt.Sprintf("The cache invalidation bus backend. Supported values: %s.", strings.Join(supportedCacheInvalidationBackends, ", ")))
+	cfg.Redis.RegisterFlagsWithPrefix(f, prefix+"redis.")
+	cfg.NATS.RegisterFlagsWithPrefix(f, prefix+"nats.")
+}
+
+// Validate the CacheInvalidationConfig.
+func (cfg *CacheInvalidationConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	switch cfg.Backend {
+	case CacheInvalidationBackendRedisStreams:
+		return cfg.Redis.Validate()
+	case CacheInvalidationBackendNATSJetStream:
+		return cfg.NATS.Validate()
+	default:
+		return errInvalidCacheInvalidationBackend
+	}
+}
+
+// CacheInvalidationRedisConfig holds the config of the Redis Streams cache invalidation backend.
+type CacheInvalidationRedisConfig struct {
+	Endpoint string         `yaml:"endpoint" category:"experimental"`
+	Username string         `yaml:"username" category:"experimental"`
+	Password flagext.Secret `yaml:"password" category:"experimental"`
+	DB       int            `yaml:"db" category:"experimental"`
+	Stream   string         `yaml:"stream" category:"experimental"`
+}
+
+// RegisterFlagsWithPrefix registers the CacheInvalidationRedisConfig flags with the given prefix.
+func (cfg *CacheInvalidationRedisConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.StringVar(&cfg.Endpoint, prefix+"endpoint", "", "Redis server endpoint to read the stream from, in the host:port format.")
+	f.StringVar(&cfg.Username, prefix+"username", "", "Username to use when connecting to Redis.")
+	f.Var(&cfg.Password, prefix+"password", "Password to use when connecting to Redis.")
+	f.IntVar(&cfg.DB, prefix+"db", 0, "Database index.")
+	f.StringVar(&cfg.Stream, prefix+"stream", "mimir-block-events", "Redis stream the blocks events are appended to, in the \""+redisStreamEventField+"\" field of the entries. Each store-gateway reads the stream with its own consumer group, named after its instance ID, and acknowledges the events once processed, so the events appended while a store-gateway is unavailable are processed once it's back.")
+}
+
+// Validate the CacheInvalidationRedisConfig.
+func (cfg *CacheInvalidationRedisConfig) Validate() error {
+	if cfg.Endpoint == "" {
+		return errMissingCacheInvalidationEndpoint
+	}
+	if cfg.Stream == "" {
+		return errMissingCacheInvalidationStream
+	}
+	return nil
+}
+
+// CacheInvalidationNATSConfig holds the config of the NATS JetStream cache invalidation backend.
+type CacheInvalidationNATSConfig struct {
+	URL      string         `yaml:"url" category:"experimental"`
+	Username string         `yaml:"username" category:"experimental"`
+	Password flagext.Secret `yaml:"password" category:"experimental"`
+	Subject  string         `yaml:"subject" category:"experimental"`
+}
+
+// RegisterFlagsWithPrefix registers the CacheInvalidationNATSConfig flags with the given prefix.
+func (cfg *CacheInvalidationNATSConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	f.StringVar(&cfg.URL, prefix+"url", "", "NATS server URL to connect to, in the nats://host:port format. Multiple comma-separated URLs can be specified.")
+	f.StringVar(&cfg.Username, prefix+"username", "", "Username to use when connecting to NATS.")
+	f.Var(&cfg.Password, prefix+"password", "Password to use when connecting to NATS.")
+	f.StringVar(&cfg.Subject, prefix+"subject", "mimir.block-events", "NATS subject the blocks events are published to. The subject must be captured by a JetStream stream. Each store-gateway reads the stream with its own durable pull consumer, named after its instance ID, and acknowledges the events once processed, so the events published while a store-gateway is unavailable are processed once it's back.")
+}
+
+// Validate the CacheInvalidationNATSConfig.
+func (cfg *CacheInvalidationNATSConfig) Validate() error {
+	if cfg.URL == "" {
+		return errMissingCacheInvalidationNATSURL
+	}
+	if cfg.Subject == "" {
+		return errMissingCacheInvalidationSubject
+	}
+	return nil
+}
+
+// BlockEvent is the message published on the cache invalidation bus, encoded in JSON, when blocks have been
+// deleted from the storage, or compacted into other blocks. The blocks of the event are obsolete.
+type BlockEvent struct {
+	Type     string      `json:"type"`
+	TenantID string      `json:"tenant_id"`
+	BlockIDs []ulid.ULID `json:"block_ids"`
+}
+
+func (e BlockEvent) validate() error {
+	if e.Type != BlockEventDeleted && e.Type != BlockEventCompacted {
+		return fmt.Errorf("unsupported event type %q", e.Type)
+	}
+	if e.TenantID == "" {
+		return errors.New("missing tenant ID")
+	}
+	return nil
+}
+
+// blockEventsSubscriber receives the messages published on the cache invalidation bus.
+type blockEventsSubscriber interface {
+	// Receive blocks until a message is received, and returns its payload. The message is received
+	// again, even after a restart, until it's acknowledged with ack.
+	Receive(ctx context.Context) (payload []byte, ack func() error, _ error)
+	Close() error
+}
+
+// blocksInvalidator is implemented by the components the obsolete blocks are invalidated from.
+type blocksInvalidator interface {
+	// invalidateBlocks invalidates the input obsolete blocks of the tenant, and returns the
+	// number of cached entries removed.
+	invalidateBlocks(userID string, blockIDs []ulid.ULID) int
+}
+
+// cacheInvalidator is a service which subscribes to the cache invalidation bus, and invalidates
+// the blocks of the received events.
+type cacheInvalidator struct {
+	services.Service
+
+	newSubscriber func(ctx context.Context) blockEventsSubscriber
+	subscriber    blockEventsSubscriber
+	invalidator   blocksInvalidator
+	logger        log.Logger
+
+	events          *prometheus.CounterVec
+	receiveFailures prometheus.Counter
+	purgedEntries   prometheus.Counter
+}
+
+// newCacheInvalidator returns a cacheInvalidator receiving the blocks events as the consumer of the input instance.
+func newCacheInvalidator(cfg CacheInvalidationConfig, instanceID string, invalidator blocksInvalidator, logger log.Logger, reg prometheus.Registerer) *cacheInvalidator {
+	return newCacheInvalidatorWithSubscriber(func(context.Context) blockEventsSubscriber {
+		if cfg.Backend == CacheInvalidationBackendNATSJetStream {
+			return newNATSBlockEventsSubscriber(cfg.NATS, instanceID)
+		}
+		return newRedisBlockEventsSubscriber(cfg.Redis, instanceID)
+	}, invalidator, logger, reg)
+}
+
+func newCacheInvalidatorWithSubscriber(newSubscriber func(ctx context.Context) blockEventsSubscriber, invalidator blocksInvalidator, logger log.Logger, reg prometheus.Registerer) *cacheInvalidator {
+	c := &cacheInvalidator{
+		newSubscriber: newSubscriber,
+		invalidator:   invalidator,
+		logger:        logger,
+
+		events: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_storegateway_cache_invalidation_events_total",
+			Help: "Total number of blocks events received from the cache invalidation bus, by result.",
+		}, []string{"result"}),
+		receiveFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_storegateway_cache_invalidation_receive_failures_total",
+			Help: "Total number of failures receiving the blocks events from the cache invalidation bus.",
+		}),
+		purgedEntries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_storegateway_cache_invalidation_purged_entries_total",
+			Help: "Total number of cached entries of obsolete blocks purged after an event received from the cache invalidation bus.",
+		}),
+	}
+
+	// Init metrics.
+	c.events.WithLabelValues(eventResultProcessed)
+	c.events.WithLabelValues(eventResultInvalid)
+
+	c.Service = services.NewBasicService(c.starting, c.running, c.stopping)
+	return c
+}
+
+func (c *cacheInvalidator) starting(ctx context.Context) error {
+	c.subscriber = c.newSubscriber(ctx)
+	return nil
+}
+
+func (c *cacheInvalidator) running(ctx context.Context) error {
+	for {
+		payload, ack, err := c.subscriber.Receive(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			c.receiveFailures.Inc()
+			level.Warn(c.logger).Log("msg", "failed to receive event from the cache invalidation bus", "err", err)
+
+			select {
+			case <-time.After(cacheInvalidationReceiveBackoff):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		// The invalid events are acknowledged too, because they would be invalid when received again anyway.
+		c.handleEvent(payload)
+		if err := ack(); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to acknowledge event from the cache invalidation bus", "err", err)
+		}
+	}
+}
+
+func (c *cacheInvalidator) stopping(_ error) error {
+	if c.subscriber == nil {
+		return nil
+	}
+	return c.subscriber.Close()
+}
+
+func (c *cacheInvalidator) handleEvent(payload []byte) {
+	var event BlockEvent
+	err := json.Unmarshal(payload, &event)
+	if err == nil {
+		err = event.validate()
+	}
+	if err != nil {
+		c.events.WithLabelValues(eventResultInvalid).Inc()
+		level.Warn(c.logger).Log("msg", "received invalid event from the cache invalidation bus", "err", err)
+		return
+	}
+
+	purged := c.invalidator.invalidateBlocks(event.TenantID, event.BlockIDs)
+	c.purgedEntries.Add(float64(purged))
+	c.events.WithLabelValues(eventResultProcessed).Inc()
+
+	level.Debug(util_log.WithUserID(event.TenantID, c.logger)).Log("msg", "invalidated blocks from the cache invalidation bus event", "type", event.Type, "blocks", len(event.BlockIDs), "purged_entries", purged)
+}
+
+// redisBlockEventsSubscriber receives the blocks events from a Redis stream, as a consumer group with a single
+// consumer: each store-gateway gets all the events, and the events not acknowledged yet are received again after
+// a restart. The connection is re-established by the Redis client in case of failure.
+type redisBlockEventsSubscriber struct {
+	client   *redis.Client
+	stream   string
+	group    string
+	consumer string
+
+	// groupCreated is whether the consumer group has been created, if it didn't exist.
+	groupCreated bool
+	// pending is whether the events received but not acknowledged before a restart are still to be received.
+	pending bool
+	// buffered are the events read from the stream but not returned by Receive yet.
+	buffered []redis.XMessage
+}
+
+func newRedisBlockEventsSubscriber(cfg CacheInvalidationRedisConfig, instanceID string) *redisBlockEventsSubscriber {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Endpoint,
+		Username: cfg.Username,
+		Password: cfg.Password.String(),
+		DB:       cfg.DB,
+
+		// The reads block for up to redisStreamBlockTimeout, so they shouldn't time out before.
+		ReadTimeout: redisStreamBlockTimeout + 5*time.Second,
+	})
+
+	// The consumer group is created on the first Receive, so that the store-gateway can start even
+	// if Redis is unavailable.
+	return &redisBlockEventsSubscriber{
+		client:   client,
+		stream:   cfg.Stream,
+		group:    instanceID,
+		consumer: instanceID,
+		pending:  true,
+	}
+}
+
+func (s *redisBlockEventsSubscriber) Receive(ctx context.Context) ([]byte, func() error, error) {
+	for len(s.buffered) == 0 {
+		if err := s.createGroup(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		// The ID "0" reads the pending events of this consumer, while ">" reads the events never delivered
+		// to the consumer group.
+		id := ">"
+		if s.pending {
+			id = "0"
+		}
+
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.group,
+			Consumer: s.consumer,
+			Streams:  []string{s.stream, id},
+			Count:    redisStreamReadCount,
+			Block:    redisStreamBlockTimeout,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			// No new events.
+			continue
+		}
+		if err != nil {
+			// The consumer group is created again if it has been deleted in the meanwhile.
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				s.groupCreated = false
+			}
+			return nil, nil, err
+		}
+
+		for _, stream := range streams {
+			s.buffered = append(s.buffered, stream.Messages...)
+		}
+		if s.pending && len(s.buffered) == 0 {
+			s.pending = false
+		}
+	}
+
+	msg := s.buffered[0]
+	s.buffered = s.buffered[1:]
+
+	ack := func() error {
+		return s.client.XAck(context.Background(), s.stream, s.group, msg.ID).Err()
+	}
+
+	// An entry without the event field is received as an invalid event.
+	payload, _ := msg.Values[redisStreamEventField].(string)
+	return []byte(payload), ack, nil
+}
+
+// createGroup creates the consumer group reading the events appended to the stream from now on, and the stream
+// itself, if they don't exist yet.
+func (s *redisBlockEventsSubscriber) createGroup(ctx context.Context) error {
+	if s.groupCreated {
+		return nil
+	}
+
+	err := s.client.XGroupCreateMkStream(ctx, s.stream, s.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return errors.Wrap(err, "create consumer group")
+	}
+
+	s.groupCreated = true
+	return nil
+}
+
+func (s *redisBlockEventsSubscriber) Close() error {
+	return s.client.Close()
+}
+
+// natsBlockEventsSubscriber receives the blocks events from a NATS JetStream stream, with a durable pull consumer:
+// each store-gateway gets all the events, and the events not acknowledged are received again, after the ack wait
+// of the consumer or after a restart. The connection is re-established by the NATS client in case of failure.
+type natsBlockEventsSubscriber struct {
+	cfg      CacheInvalidationNATSConfig
+	consumer string
+
+	// The connection and the subscription are created on the first Receive, so that the store-gateway can start
+	// even if NATS is unavailable.
+	conn *nats.Conn
+	sub  *nats.Subscription
+
+	// buffered are the events fetched from the consumer but not returned by Receive yet.
+	buffered []*nats.Msg
+}
+
+func newNATSBlockEventsSubscriber(cfg CacheInvalidationNATSConfig, instanceID string) *natsBlockEventsSubscriber {
+	return &natsBlockEventsSubscriber{
+		cfg:      cfg,
+		consumer: natsConsumerName(instanceID),
+	}
+}
+
+// natsConsumerName returns the name of the durable consumer of the input instance, which can't contain the
+// characters reserved for the NATS subjects.
+func natsConsumerName(instanceID string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '*', '>', ' ', '\t':
+			return '_'
+		}
+		return r
+	}, instanceID)
+}
+
+func (s *natsBlockEventsSubscriber) Receive(ctx context.Context) ([]byte, func() error, error) {
+	for len(s.buffered) == 0 {
+		if err := s.subscribe(); err != nil {
+			return nil, nil, err
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, natsFetchTimeout)
+		msgs, err := s.sub.Fetch(natsFetchCount, nats.Context(fetchCtx))
+		cancel()
+		if (errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			// No new events.
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		s.buffered = append(s.buffered, msgs...)
+	}
+
+	msg := s.buffered[0]
+	s.buffered = s.buffered[1:]
+
+	return msg.Data, func() error { return msg.Ack() }, nil
+}
+
+// subscribe connects to NATS and creates the durable pull consumer reading the events published from now on,
+// if they don't exist yet.
+func (s *natsBlockEventsSubscriber) subscribe() error {
+	if s.sub != nil {
+		return nil
+	}
+
+	if s.conn == nil {
+		opts := []nats.Option{nats.Name(s.consumer), nats.MaxReconnects(-1)}
+		if s.cfg.Username != "" {
+			opts = append(opts, nats.UserInfo(s.cfg.Username, s.cfg.Password.String()))
+		}
+
+		conn, err := nats.Connect(s.cfg.URL, opts...)
+		if err != nil {
+			return errors.Wrap(err, "connect to nats")
+		}
+		s.conn = conn
+	}
+
+	js, err := s.conn.JetStream()
+	if err != nil {
+		return errors.Wrap(err, "get jetstream context")
+	}
+
+	sub, err := js.PullSubscribe(s.cfg.Subject, s.consumer, nats.DeliverNew(), nats.AckExplicit())
+	if err != nil {
+		return errors.Wrap(err, "create pull consumer")
+	}
+
+	s.sub = sub
+	return nil
+}
+
+func (s *natsBlockEventsSubscriber) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	// The subscription isn't unsubscribed, because it would delete the durable consumer and its pending events.
+	s.conn.Close()
+	return nil
+}
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	dstest "github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestCacheInvalidationConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		setup    func(cfg *CacheInvalidationConfig)
+		expected error
+	}{
+		"should pass if disabled": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Backend = "unknown"
+			},
+		},
+		"should pass with the redis endpoint": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Enabled = true
+				cfg.Redis.Endpoint = "localhost:6379"
+			},
+		},
+		"should fail on unsupported backend": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Enabled = true
+				cfg.Backend = "unknown"
+				cfg.Redis.Endpoint = "localhost:6379"
+			},
+			expected: errInvalidCacheInvalidationBackend,
+		},
+		"should fail on missing redis endpoint": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Enabled = true
+			},
+			expected: errMissingCacheInvalidationEndpoint,
+		},
+		"should fail on missing redis stream": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Enabled = true
+				cfg.Redis.Endpoint = "localhost:6379"
+				cfg.Redis.Stream = ""
+			},
+			expected: errMissingCacheInvalidationStream,
+		},
+		"should pass with the nats url": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Enabled = true
+				cfg.Backend = CacheInvalidationBackendNATSJetStream
+				cfg.NATS.URL = "nats://localhost:4222"
+			},
+		},
+		"should fail on missing nats url": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Enabled = true
+				cfg.Backend = CacheInvalidationBackendNATSJetStream
+				cfg.Redis.Endpoint = "localhost:6379"
+			},
+			expected: errMissingCacheInvalidationNATSURL,
+		},
+		"should fail on missing nats subject": {
+			setup: func(cfg *CacheInvalidationConfig) {
+				cfg.Enabled = true
+				cfg.Backend = CacheInvalidationBackendNATSJetStream
+				cfg.NATS.URL = "nats://localhost:4222"
+				cfg.NATS.Subject = ""
+			},
+			expected: errMissingCacheInvalidationSubject,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := CacheInvalidationConfig{}
+			cfg.RegisterFlagsWithPrefix(flag.NewFlagSet("", flag.PanicOnError), "")
+			testData.setup(&cfg)
+
+			assert.Equal(t, testData.expected, cfg.Validate())
+		})
+	}
+}
+
+func TestCacheInvalidator(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	subscriber := newMockBlockEventsSubscriber()
+	invalidator := &mockBlocksInvalidator{purged: 3}
+	c := newCacheInvalidatorWithSubscriber(func(context.Context) blockEventsSubscriber {
+		return subscriber
+	}, invalidator, log.NewNopLogger(), reg)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, c))
+
+	subscriber.events <- []byte(`{"type":"compacted","tenant_id":"user-1","block_ids":["` + block1.String() + `","` + block2.String() + `"]}`)
+	subscriber.events <- []byte(`invalid`)
+	subscriber.events <- []byte(`{"type":"unknown","tenant_id":"user-1"}`)
+	subscriber.events <- []byte(`{"type":"deleted","block_ids":["` + block1.String() + `"]}`)
+	subscriber.failures <- errors.New("connection refused")
+	subscriber.events <- []byte(`{"type":"deleted","tenant_id":"user-2","block_ids":["` + block2.String() + `"]}`)
+
+	dstest.Poll(t, 5*time.Second, 2, func() interface{} {
+		return len(invalidator.getCalls())
+	})
+
+	// All the received events have been acknowledged, including the invalid ones.
+	dstest.Poll(t, 5*time.Second, 5, func() interface{} {
+		return int(subscriber.acked.Load())
+	})
+
+	require.NoError(t, services.StopAndAwaitTerminated(ctx, c))
+	assert.True(t, subscriber.closed)
+
+	assert.Equal(t, []mockInvalidateBlocksCall{
+		{userID: "user-1", blockIDs: []ulid.ULID{block1, block2}},
+		{userID: "user-2", blockIDs: []ulid.ULID{block2}},
+	}, invalidator.getCalls())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_storegateway_cache_invalidation_events_total Total number of blocks events received from the cache invalidation bus, by result.
+		# TYPE cortex_storegateway_cache_invalidation_events_total counter
+		cortex_storegateway_cache_invalidation_events_total{result="invalid"} 3
+		cortex_storegateway_cache_invalidation_events_total{result="processed"} 2
+		# HELP cortex_storegateway_cache_invalidation_purged_entries_total Total number of cached entries of obsolete blocks purged after an event received from the cache invalidation bus.
+		# TYPE cortex_storegateway_cache_invalidation_purged_entries_total counter
+		cortex_storegateway_cache_invalidation_purged_entries_total 6
+		# HELP cortex_storegateway_cache_invalidation_receive_failures_total Total number of failures receiving the blocks events from the cache invalidation bus.
+		# TYPE cortex_storegateway_cache_invalidation_receive_failures_total counter
+		cortex_storegateway_cache_invalidation_receive_failures_total 1
+	`)))
+}
+
+type mockBlockEventsSubscriber struct {
+	events   chan []byte
+	failures chan error
+	acked    atomic.Int64
+	closed   bool
+}
+
+func newMockBlockEventsSubscriber() *mockBlockEventsSubscriber {
+	return &mockBlockEventsSubscriber{
+		events:   make(chan []byte),
+		failures: make(chan error),
+	}
+}
+
+func (m *mockBlockEventsSubscriber) Receive(ctx context.Context) ([]byte, func() error, error) {
+	select {
+	case payload := <-m.events:
+		return payload, func() error {
+			m.acked.Inc()
+			return nil
+		}, nil
+	case err := <-m.failures:
+		return nil, nil, err
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (m *mockBlockEventsSubscriber) Close() error {
+	m.closed = true
+	return nil
+}
+
+type mockInvalidateBlocksCall struct {
+	userID   string
+	blockIDs []ulid.ULID
+}
+
+type mockBlocksInvalidator struct {
+	purged int
+
+	mtx   sync.Mutex
+	calls []mockInvalidateBlocksCall
+}
+
+func (m *mockBlocksInvalidator) invalidateBlocks(userID string, blockIDs []ulid.ULID) int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.calls = append(m.calls, mockInvalidateBlocksCall{userID: userID, blockIDs: blockIDs})
+	return m.purged
+}
+
+func (m *mockBlocksInvalidator) getCalls() []mockInvalidateBlocksCall {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return append([]mockInvalidateBlocksCall(nil), m.calls...)
+}
+
+func TestNATSConsumerName(t *testing.T) {
+	assert.Equal(t, "store-gateway-1", natsConsumerName("store-gateway-1"))
+	assert.Equal(t, "store-gateway_zone-a_1", natsConsumerName("store-gateway.zone-a 1"))
+	assert.Equal(t, "a_b_c", natsConsumerName("a*b>c"))
+}
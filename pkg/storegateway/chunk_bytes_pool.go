@@ -13,7 +13,7 @@ import (
 )
 
 type chunkBytesPool struct {
-	pool *pool.BucketedBytes
+	pool pool.Bytes
 
 	// Metrics.
 	requestedBytes prometheus.Counter
@@ -26,19 +26,53 @@ func newChunkBytesPool(minBucketSize, maxBucketSize int, maxChunkPoolBytes uint6
 		return nil, err
 	}
 
+	requestedBytes, returnedBytes := newChunkBytesPoolMetrics(reg, upstream.Contentions)
 	return &chunkBytesPool{
-		pool: upstream,
-		requestedBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
-			Name: "cortex_bucket_store_chunk_pool_requested_bytes_total",
-			Help: "Total bytes requested to chunk bytes pool.",
-		}),
-		returnedBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
-			Name: "cortex_bucket_store_chunk_pool_returned_bytes_total",
-			Help: "Total bytes returned by the chunk bytes pool.",
-		}),
+		pool:           upstream,
+		requestedBytes: requestedBytes,
+		returnedBytes:  returnedBytes,
 	}, nil
 }
 
+// newShardedChunkBytesPool returns a chunkBytesPool for each shard of a pool split in the given number of shards,
+// sharing the maximum number of used bytes and the metrics. The byte slices got from a shard must be returned to it.
+func newShardedChunkBytesPool(minBucketSize, maxBucketSize int, maxChunkPoolBytes uint64, shards int, reg prometheus.Registerer) ([]pool.Bytes, error) {
+	upstream, err := pool.NewShardedBucketedBytes(minBucketSize, maxBucketSize, 2, maxChunkPoolBytes, shards)
+	if err != nil {
+		return nil, err
+	}
+
+	requestedBytes, returnedBytes := newChunkBytesPoolMetrics(reg, upstream.Contentions)
+	pools := make([]pool.Bytes, upstream.Shards())
+	for i := range pools {
+		pools[i] = &chunkBytesPool{
+			pool:           upstream.Shard(i),
+			requestedBytes: requestedBytes,
+			returnedBytes:  returnedBytes,
+		}
+	}
+	return pools, nil
+}
+
+func newChunkBytesPoolMetrics(reg prometheus.Registerer, contentions func() uint64) (requestedBytes, returnedBytes prometheus.Counter) {
+	promauto.With(reg).NewCounterFunc(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunk_pool_contentions_total",
+		Help: "Total number of times the chunk bytes pool waited for another goroutine to update the used bytes.",
+	}, func() float64 {
+		return float64(contentions())
+	})
+
+	requestedBytes = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunk_pool_requested_bytes_total",
+		Help: "Total bytes requested to chunk bytes pool.",
+	})
+	returnedBytes = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_chunk_pool_returned_bytes_total",
+		Help: "Total bytes returned by the chunk bytes pool.",
+	})
+	return requestedBytes, returnedBytes
+}
+
 func (p *chunkBytesPool) Get(sz int) (*[]byte, error) {
 	buffer, err := p.pool.Get(sz)
 	if err != nil {
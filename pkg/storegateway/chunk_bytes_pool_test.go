@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/util/pool"
 )
 
 func TestChunkBytesPool_Get(t *testing.T) {
@@ -30,6 +31,10 @@ func TestChunkBytesPool_Get(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(fmt.Sprintf(`
+		# HELP cortex_bucket_store_chunk_pool_contentions_total Total number of times the chunk bytes pool waited for another goroutine to update the used bytes.
+		# TYPE cortex_bucket_store_chunk_pool_contentions_total counter
+		cortex_bucket_store_chunk_pool_contentions_total 0
+
 		# HELP cortex_bucket_store_chunk_pool_requested_bytes_total Total bytes requested to chunk bytes pool.
 		# TYPE cortex_bucket_store_chunk_pool_requested_bytes_total counter
 		cortex_bucket_store_chunk_pool_requested_bytes_total %d
@@ -39,3 +44,38 @@ func TestChunkBytesPool_Get(t *testing.T) {
 		cortex_bucket_store_chunk_pool_returned_bytes_total %d
 	`, mimir_tsdb.EstimatedMaxChunkSize*2, mimir_tsdb.EstimatedMaxChunkSize*3))))
 }
+
+func TestNewShardedChunkBytesPool(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	pools, err := newShardedChunkBytesPool(mimir_tsdb.ChunkPoolDefaultMinBucketSize, mimir_tsdb.ChunkPoolDefaultMaxBucketSize, 3*mimir_tsdb.EstimatedMaxChunkSize, 4, reg)
+	require.NoError(t, err)
+	require.Len(t, pools, 4)
+
+	// The max pool bytes are shared by the shards: a single shard can use all of them.
+	var buffers []*[]byte
+	for i := 0; i < 3; i++ {
+		b, err := pools[0].Get(mimir_tsdb.EstimatedMaxChunkSize)
+		require.NoError(t, err)
+		buffers = append(buffers, b)
+	}
+	for _, p := range pools {
+		_, err = p.Get(mimir_tsdb.EstimatedMaxChunkSize)
+		require.ErrorIs(t, err, pool.ErrPoolExhausted)
+	}
+
+	// The bytes returned to a shard can be got by the other shards.
+	pools[0].Put(buffers[0])
+	_, err = pools[3].Get(mimir_tsdb.EstimatedMaxChunkSize)
+	require.NoError(t, err)
+
+	// The metrics are shared by the shards.
+	assert.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(fmt.Sprintf(`
+		# HELP cortex_bucket_store_chunk_pool_requested_bytes_total Total bytes requested to chunk bytes pool.
+		# TYPE cortex_bucket_store_chunk_pool_requested_bytes_total counter
+		cortex_bucket_store_chunk_pool_requested_bytes_total %d
+
+		# HELP cortex_bucket_store_chunk_pool_returned_bytes_total Total bytes returned by the chunk bytes pool.
+		# TYPE cortex_bucket_store_chunk_pool_returned_bytes_total counter
+		cortex_bucket_store_chunk_pool_returned_bytes_total %d
+	`, mimir_tsdb.EstimatedMaxChunkSize*4, mimir_tsdb.EstimatedMaxChunkSize*4)), "cortex_bucket_store_chunk_pool_requested_bytes_total", "cortex_bucket_store_chunk_pool_returned_bytes_total"))
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/oklog/ulid"
+
+	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
+	"github.com/grafana/mimir/pkg/util/pool"
+)
+
+// chunksCacheBlockAgeBuckets are the upper bounds of the block age buckets the chunks cache metrics are partitioned by.
+var chunksCacheBlockAgeBuckets = []struct {
+	maxAge time.Duration
+	label  string
+}{
+	{maxAge: 2 * time.Hour, label: "2h"},
+	{maxAge: 12 * time.Hour, label: "12h"},
+	{maxAge: 24 * time.Hour, label: "24h"},
+	{maxAge: 7 * 24 * time.Hour, label: "7d"},
+}
+
+const chunksCacheBlockAgeOlderLabel = "older"
+
+// blockAgeBucketLabel returns the label of the block age bucket the input age belongs to.
+func blockAgeBucketLabel(age time.Duration) string {
+	for _, b := range chunksCacheBlockAgeBuckets {
+		if age <= b.maxAge {
+			return b.label
+		}
+	}
+	return chunksCacheBlockAgeOlderLabel
+}
+
+// blockAgeChunksCache wraps a chunkscache.Cache to only fetch and store the chunks of blocks whose age is
+// within [minAge, maxAge], and tracks cache requests and hits partitioned by block age. The age of a block
+// is the time elapsed since the block max time. The cache is meant to be used for a single request.
+type blockAgeChunksCache struct {
+	cache   chunkscache.Cache
+	metrics *BucketStoreMetrics
+
+	// Block age bucket label of each block. Blocks for which caching is disabled are tracked in skipped.
+	ageLabels map[ulid.ULID]string
+	skipped   map[ulid.ULID]bool
+}
+
+func newBlockAgeChunksCache(cache chunkscache.Cache, blocks []*bucketBlock, now time.Time, minAge, maxAge time.Duration, metrics *BucketStoreMetrics) *blockAgeChunksCache {
+	c := &blockAgeChunksCache{
+		cache:     cache,
+		metrics:   metrics,
+		ageLabels: make(map[ulid.ULID]string, len(blocks)),
+		skipped:   map[ulid.ULID]bool{},
+	}
+
+	for _, b := range blocks {
+		age := now.Sub(time.UnixMilli(b.meta.MaxTime))
+		c.ageLabels[b.meta.ULID] = blockAgeBucketLabel(age)
+
+		if (minAge > 0 && age < minAge) || (maxAge > 0 && age > maxAge) {
+			c.skipped[b.meta.ULID] = true
+		}
+	}
+
+	return c
+}
+
+func (c *blockAgeChunksCache) FetchMultiChunks(ctx context.Context, userID string, ranges []chunkscache.Range, chunksPool *pool.SafeSlabPool[byte]) map[chunkscache.Range][]byte {
+	toFetch := ranges
+	if len(c.skipped) > 0 {
+		toFetch = make([]chunkscache.Range, 0, len(ranges))
+		for _, r := range ranges {
+			if c.skipped[r.BlockID] {
+				c.metrics.chunksCacheBlockAgeSkipped.WithLabelValues(c.ageLabels[r.BlockID]).Inc()
+				continue
+			}
+			toFetch = append(toFetch, r)
+		}
+	}
+
+	if len(toFetch) == 0 {
+		return nil
+	}
+
+	hits := c.cache.FetchMultiChunks(ctx, userID, toFetch, chunksPool)
+
+	for _, r := range toFetch {
+		c.metrics.chunksCacheBlockAgeRequests.WithLabelValues(c.ageLabels[r.BlockID]).Inc()
+	}
+	for r := range hits {
+		c.metrics.chunksCacheBlockAgeHits.WithLabelValues(c.ageLabels[r.BlockID]).Inc()
+	}
+
+	return hits
+}
+
+func (c *blockAgeChunksCache) StoreChunks(userID string, ranges map[chunkscache.Range][]byte) {
+	if len(c.skipped) > 0 {
+		for r := range ranges {
+			if c.skipped[r.BlockID] {
+				delete(ranges, r)
+			}
+		}
+	}
+
+	if len(ranges) == 0 {
+		return
+	}
+
+	c.cache.StoreChunks(userID, ranges)
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
+	"github.com/grafana/mimir/pkg/storegateway/chunkscache"
+)
+
+func TestBlockAgeBucketLabel(t *testing.T) {
+	assert.Equal(t, "2h", blockAgeBucketLabel(0))
+	assert.Equal(t, "2h", blockAgeBucketLabel(2*time.Hour))
+	assert.Equal(t, "12h", blockAgeBucketLabel(3*time.Hour))
+	assert.Equal(t, "24h", blockAgeBucketLabel(13*time.Hour))
+	assert.Equal(t, "7d", blockAgeBucketLabel(7*24*time.Hour))
+	assert.Equal(t, "older", blockAgeBucketLabel(30*24*time.Hour))
+}
+
+func TestBlockAgeChunksCache(t *testing.T) {
+	const userID = "user-1"
+
+	now := time.Now()
+	recentBlock := newBlockWithMaxTime(ulid.MustNew(1, nil), now.Add(-time.Hour))
+	oldBlock := newBlockWithMaxTime(ulid.MustNew(2, nil), now.Add(-10*24*time.Hour))
+
+	recentRange := chunkscache.Range{BlockID: recentBlock.meta.ULID, Start: chunks.ChunkRef(10), NumChunks: 1}
+	oldRange := chunkscache.Range{BlockID: oldBlock.meta.ULID, Start: chunks.ChunkRef(10), NumChunks: 1}
+
+	testCases := map[string]struct {
+		minAge, maxAge   time.Duration
+		expectedHits     []chunkscache.Range
+		expectedCached   []chunkscache.Range
+		expectedRequests string
+		expectedSkipped  string
+	}{
+		"no limits": {
+			expectedHits:   []chunkscache.Range{recentRange, oldRange},
+			expectedCached: []chunkscache.Range{recentRange, oldRange},
+			expectedRequests: `
+				cortex_bucket_store_chunks_cache_block_age_requests_total{block_age="2h"} 1
+				cortex_bucket_store_chunks_cache_block_age_requests_total{block_age="older"} 1
+			`,
+		},
+		"min block age": {
+			minAge:         24 * time.Hour,
+			expectedHits:   []chunkscache.Range{oldRange},
+			expectedCached: []chunkscache.Range{oldRange},
+			expectedRequests: `
+				cortex_bucket_store_chunks_cache_block_age_requests_total{block_age="older"} 1
+			`,
+			expectedSkipped: `
+				cortex_bucket_store_chunks_cache_block_age_skipped_total{block_age="2h"} 1
+			`,
+		},
+		"max block age": {
+			maxAge:         24 * time.Hour,
+			expectedHits:   []chunkscache.Range{recentRange},
+			expectedCached: []chunkscache.Range{recentRange},
+			expectedRequests: `
+				cortex_bucket_store_chunks_cache_block_age_requests_total{block_age="2h"} 1
+			`,
+			expectedSkipped: `
+				cortex_bucket_store_chunks_cache_block_age_skipped_total{block_age="older"} 1
+			`,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			inner := newInMemoryChunksCache().(*inMemoryChunksCache)
+			c := newBlockAgeChunksCache(inner, []*bucketBlock{recentBlock, oldBlock}, now, testCase.minAge, testCase.maxAge, NewBucketStoreMetrics(reg))
+
+			c.StoreChunks(userID, map[chunkscache.Range][]byte{recentRange: {1}, oldRange: {2}})
+			assert.Len(t, inner.cached[userID], len(testCase.expectedCached))
+			for _, r := range testCase.expectedCached {
+				assert.Contains(t, inner.cached[userID], r)
+			}
+
+			// Populate the inner cache with all ranges, to check which ones are actually looked up.
+			inner.StoreChunks(userID, map[chunkscache.Range][]byte{recentRange: {1}, oldRange: {2}})
+			hits := c.FetchMultiChunks(context.Background(), userID, []chunkscache.Range{recentRange, oldRange}, nil)
+			assert.Len(t, hits, len(testCase.expectedHits))
+			for _, r := range testCase.expectedHits {
+				assert.Contains(t, hits, r)
+			}
+
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+				# HELP cortex_bucket_store_chunks_cache_block_age_requests_total Total number of chunk ranges requested from the chunks cache, partitioned by the age of the block they belong to.
+				# TYPE cortex_bucket_store_chunks_cache_block_age_requests_total counter
+			`+testCase.expectedRequests+`
+				# HELP cortex_bucket_store_chunks_cache_block_age_hits_total Total number of chunk ranges retrieved from the chunks cache, partitioned by the age of the block they belong to.
+				# TYPE cortex_bucket_store_chunks_cache_block_age_hits_total counter
+			`+strings.ReplaceAll(testCase.expectedRequests, "requests_total", "hits_total")+`
+				# HELP cortex_bucket_store_chunks_cache_block_age_skipped_total Total number of chunk ranges not looked up in the chunks cache because the age of the block they belong to is outside the configured range, partitioned by block age.
+				# TYPE cortex_bucket_store_chunks_cache_block_age_skipped_total counter
+			`+testCase.expectedSkipped),
+				"cortex_bucket_store_chunks_cache_block_age_requests_total",
+				"cortex_bucket_store_chunks_cache_block_age_hits_total",
+				"cortex_bucket_store_chunks_cache_block_age_skipped_total",
+			))
+		})
+	}
+}
+
+func newBlockWithMaxTime(id ulid.ULID, maxTime time.Time) *bucketBlock {
+	return &bucketBlock{
+		meta: &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{
+				ULID:    id,
+				MaxTime: maxTime.UnixMilli(),
+			},
+		},
+	}
+}
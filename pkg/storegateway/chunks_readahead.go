@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"math"
+
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/util/pool"
+)
+
+// chunksReadaheadBudget is the budget of the bytes read ahead by the chunk readers of a single Series() request.
+type chunksReadaheadBudget struct {
+	// maxBytes is the max size of each readahead.
+	maxBytes  uint64
+	remaining atomic.Int64
+}
+
+// newChunksReadaheadBudget returns the budget of a request. A maxBytesPerRequest of zero means no limit.
+func newChunksReadaheadBudget(maxBytes, maxBytesPerRequest uint64) *chunksReadaheadBudget {
+	b := &chunksReadaheadBudget{maxBytes: maxBytes}
+	if maxBytesPerRequest == 0 || maxBytesPerRequest > math.MaxInt64 {
+		b.remaining.Store(math.MaxInt64)
+	} else {
+		b.remaining.Store(int64(maxBytesPerRequest))
+	}
+	return b
+}
+
+// reserve reserves up to n bytes of the budget, and returns the number of bytes reserved.
+func (b *chunksReadaheadBudget) reserve(n uint64) uint64 {
+	for {
+		remaining := b.remaining.Load()
+		if remaining <= 0 {
+			return 0
+		}
+		if n > uint64(remaining) {
+			n = uint64(remaining)
+		}
+		if b.remaining.CompareAndSwap(remaining, remaining-int64(n)) {
+			return n
+		}
+	}
+}
+
+// release gives back n bytes reserved but not read.
+func (b *chunksReadaheadBudget) release(n uint64) {
+	b.remaining.Add(int64(n))
+}
+
+// chunksReadahead is the readahead state of a segment file of a block, across the batches of a Series() request.
+//
+// The bytes following the last chunk read by a batch are kept in memory, so that the first ranges of the next
+// batch are served from memory instead of issuing a GetRange call each, when the batches read the segment file
+// sequentially. These are the bytes read in excess, because the length of the last chunk is estimated, and the
+// ones read ahead: the last range read by the batch is extended by a window which starts from the size of the
+// batch, doubles at each batch reading the segment file sequentially, up to the max size, and is reset as soon
+// as a batch doesn't.
+type chunksReadahead struct {
+	// buf holds the bytes kept by the previous batch, starting from the offset start of the segment file, and
+	// eof is whether they reach the end of the segment file.
+	buf   *[]byte
+	start uint64
+	eof   bool
+
+	// end is the end of the last chunk read by the previous batch, or zero if none.
+	end    uint64
+	window uint64
+
+	// next is filled by the current batch.
+	next *chunksReadaheadRead
+
+	// Stats of the current batch.
+	usedBytes     uint64
+	savedRequests int
+}
+
+// chunksReadaheadRead is the readahead of the last range read by a batch.
+type chunksReadaheadRead struct {
+	// window is the number of bytes to read past the end of the range, reserved from the budget.
+	window int
+
+	// buf holds the bytes following the last chunk of the range, starting from the offset start, and
+	// windowRead is the number of them read past the end of the range. The buf is nil if no bytes are kept.
+	buf        *[]byte
+	start      uint64
+	windowRead int
+	eof        bool
+}
+
+// prepare plans the readahead of the current batch, which reads the given parts of the segment file.
+func (ra *chunksReadahead) prepare(parts []Part, budget *chunksReadaheadBudget) {
+	first, last := parts[0], parts[len(parts)-1]
+	sequential := ra.end > 0 && first.Start >= ra.end && first.Start-ra.end <= budget.maxBytes
+
+	switch {
+	case !sequential:
+		ra.window = 0
+	case ra.window == 0:
+		ra.window = last.End - first.Start
+	default:
+		ra.window *= 2
+	}
+	if ra.window > budget.maxBytes {
+		ra.window = budget.maxBytes
+	}
+
+	ra.next = &chunksReadaheadRead{window: int(budget.reserve(ra.window))}
+}
+
+// prefetched returns the bytes of the part which have been kept by the previous batch, if any, and whether
+// they reach the end of the segment file. The returned bytes are always the beginning of the part.
+func (ra *chunksReadahead) prefetched(p Part) ([]byte, bool) {
+	if ra.buf == nil || p.Start < ra.start || p.Start >= ra.start+uint64(len(*ra.buf)) {
+		return nil, false
+	}
+	end := ra.start + uint64(len(*ra.buf))
+	if p.End < end {
+		end = p.End
+	}
+	ra.usedBytes += end - p.Start
+
+	// There's nothing to read past the end of the segment file.
+	eof := ra.eof && end == ra.start+uint64(len(*ra.buf))
+	if end == p.End || eof {
+		ra.savedRequests++
+	}
+	return (*ra.buf)[p.Start-ra.start : end-ra.start], eof
+}
+
+// done keeps the bytes following the last chunk read by the current batch, if it succeeded, in place of the ones
+// kept by the previous batch. The latter are left in place if the current batch hasn't kept any, since they may
+// still hold the following bytes, like when the current batch has been entirely served from them.
+func (ra *chunksReadahead) done(succeeded bool, budget *chunksReadaheadBudget, chunkPool pool.Bytes, metrics *BucketStoreMetrics) {
+	metrics.chunksReadaheadUsedBytes.Add(float64(ra.usedBytes))
+	metrics.chunksReadaheadSavedRequests.Add(float64(ra.savedRequests))
+	if !succeeded {
+		ra.reset(chunkPool)
+		return
+	}
+
+	next := ra.next
+	ra.next, ra.end = nil, next.start
+	ra.usedBytes, ra.savedRequests = 0, 0
+
+	// The bytes of the window not read, like past the end of the segment file, are given back to the budget.
+	budget.release(uint64(next.window - next.windowRead))
+	if next.buf == nil {
+		return
+	}
+	if len(*next.buf) == 0 {
+		chunkPool.Put(next.buf)
+		return
+	}
+	if ra.buf != nil {
+		chunkPool.Put(ra.buf)
+	}
+	metrics.chunksReadaheadBytes.Add(float64(len(*next.buf)))
+	ra.buf, ra.start, ra.eof = next.buf, next.start, next.eof
+}
+
+// reset releases the bytes kept, if any, and resets the readahead, like when a batch doesn't read the segment file.
+func (ra *chunksReadahead) reset(chunkPool pool.Bytes) {
+	if ra.buf != nil {
+		chunkPool.Put(ra.buf)
+	}
+	if ra.next != nil && ra.next.buf != nil {
+		chunkPool.Put(ra.next.buf)
+	}
+	*ra = chunksReadahead{}
+}
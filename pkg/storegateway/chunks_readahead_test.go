@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/pool"
+)
+
+func TestChunksReadaheadBudget(t *testing.T) {
+	b := newChunksReadaheadBudget(100, 250)
+	assert.Equal(t, uint64(100), b.reserve(100))
+	assert.Equal(t, uint64(100), b.reserve(100))
+	assert.Equal(t, uint64(50), b.reserve(100))
+	assert.Equal(t, uint64(0), b.reserve(100))
+
+	b.release(30)
+	assert.Equal(t, uint64(30), b.reserve(100))
+
+	unlimited := newChunksReadaheadBudget(100, 0)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, uint64(1<<40), unlimited.reserve(1<<40))
+	}
+}
+
+func TestBucketChunkReader_Readahead(t *testing.T) {
+	const numChunks = 200
+
+	// Build a segment file made of chunks of different sizes.
+	segment := make([]byte, chunks.SegmentHeaderSize)
+	offsets := make([]uint32, 0, numChunks)
+	expected := make([][]byte, 0, numChunks)
+	for i := 0; i < numChunks; i++ {
+		chk := chunkenc.NewXORChunk()
+		app, err := chk.Appender()
+		require.NoError(t, err)
+		for ts := 0; ts < 1+i%20; ts++ {
+			app.Append(int64(ts), float64(i*ts))
+		}
+
+		offsets = append(offsets, uint32(len(segment)))
+		expected = append(expected, chk.Bytes())
+
+		var lenBuf [binary.MaxVarintLen32]byte
+		start := len(segment)
+		segment = append(segment, lenBuf[:binary.PutUvarint(lenBuf[:], uint64(len(chk.Bytes())))]...)
+		segment = append(segment, byte(chunkenc.EncXOR))
+		segment = append(segment, chk.Bytes()...)
+		var crcBuf [crc32.Size]byte
+		binary.BigEndian.PutUint32(crcBuf[:], crc32.Checksum(segment[start:], castagnoliTable))
+		segment = append(segment, crcBuf[:]...)
+	}
+
+	// loadSequentially loads the chunks one per batch, like a query loading a series per batch, and returns the
+	// number of GetRange requests issued. If partitioned, the chunks are loaded by the series decoding workers.
+	loadSequentially := func(t *testing.T, readaheadBudget *chunksReadaheadBudget, estimatedLength uint32, partitioned bool) (*bucketBlock, int) {
+		bkt := &getRangeCountingBucket{Bucket: objstore.NewInMemBucket()}
+		require.NoError(t, bkt.Upload(context.Background(), "segment", bytes.NewReader(segment)))
+
+		chunkPool := &trackedBytesPool{parent: pool.NoopBytes{}}
+		blockChunkPool := chunkPool
+		var partition *seriesDecodingPartition
+		if partitioned {
+			// The chunks bytes are got from the pool of the partition only.
+			blockChunkPool = &trackedBytesPool{parent: pool.NoopBytes{}}
+			partition = newSeriesDecodingWorkers([]pool.Bytes{chunkPool}, 2, nil).partition()
+		}
+
+		block := &bucketBlock{
+			logger:       log.NewNopLogger(),
+			metrics:      NewBucketStoreMetrics(nil),
+			bkt:          bkt,
+			chunkObjs:    []string{"segment"},
+			chunkPool:    blockChunkPool,
+			partitioners: newGapBasedPartitioners(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+		}
+
+		r := block.chunkReader(context.Background(), readaheadBudget, partition)
+		for i, offset := range offsets {
+			r.reset()
+			require.NoError(t, r.addLoad(chunkRef(0, offset), 0, 0, estimatedLength))
+
+			res := []seriesEntry{{chks: make([]storepb.AggrChunk, 1)}}
+			chunksPool := pool.NewSafeSlabPool[byte](chunkBytesSlicePool, chunkBytesSlabSize)
+			require.NoError(t, r.load(res, chunksPool, newSafeQueryStats()))
+			require.Equal(t, expected[i], []byte(res[0].chks[0].Raw.Data), "chunk %d", i)
+			chunksPool.Release()
+		}
+		require.NoError(t, r.Close())
+
+		// All the bytes kept for the next batches have been released.
+		assert.Equal(t, uint64(0), chunkPool.balance.Load())
+		if partitioned {
+			assert.Greater(t, chunkPool.gets.Load(), uint64(0))
+			assert.Equal(t, uint64(0), blockChunkPool.gets.Load())
+		}
+		return block, int(bkt.getRangeRequests.Load())
+	}
+
+	// The length of the chunks is overestimated, but less than the whole segment file.
+	const estimatedLength = 64
+
+	_, requests := loadSequentially(t, nil, estimatedLength, false)
+	assert.Equal(t, numChunks, requests)
+
+	// The chunks are mostly served from the bytes kept by the previous batches.
+	block, requests := loadSequentially(t, newChunksReadaheadBudget(4096, 0), estimatedLength, false)
+	assert.Less(t, requests, numChunks/2)
+	assert.Greater(t, testutil.ToFloat64(block.metrics.chunksReadaheadSavedRequests), float64(numChunks/2))
+	assert.Greater(t, testutil.ToFloat64(block.metrics.chunksReadaheadBytes), 0.0)
+	assert.Greater(t, testutil.ToFloat64(block.metrics.chunksReadaheadUsedBytes), 0.0)
+
+	// Once the budget of the request is exhausted, only the bytes read in excess are kept.
+	_, limitedRequests := loadSequentially(t, newChunksReadaheadBudget(4096, 1024), estimatedLength, false)
+	assert.Greater(t, limitedRequests, requests)
+
+	// The first range reads the whole segment file when the length of the last chunk is estimated to the max.
+	_, requests = loadSequentially(t, newChunksReadaheadBudget(4096, 0), mimir_tsdb.EstimatedMaxChunkSize, false)
+	assert.Equal(t, 1, requests)
+
+	// The chunks whose length is underestimated are refetched.
+	_, _ = loadSequentially(t, newChunksReadaheadBudget(4096, 0), 8, false)
+
+	// The chunks loaded by the series decoding workers, and the bytes kept for the next batches, are got from
+	// and returned to the chunks pool of their partition.
+	_, requests = loadSequentially(t, newChunksReadaheadBudget(4096, 0), estimatedLength, false)
+	_, partitionedRequests := loadSequentially(t, newChunksReadaheadBudget(4096, 0), estimatedLength, true)
+	assert.Equal(t, requests, partitionedRequests)
+	_, _ = loadSequentially(t, newChunksReadaheadBudget(4096, 0), 8, true)
+}
+
+func TestChunksReadahead_ShouldResetWhenNotSequential(t *testing.T) {
+	budget := newChunksReadaheadBudget(1000, 0)
+	chunkPool := &trackedBytesPool{parent: pool.NoopBytes{}}
+	metrics := NewBucketStoreMetrics(nil)
+	ra := chunksReadahead{}
+
+	// The first batch doesn't read ahead.
+	ra.prepare([]Part{{Start: 100, End: 200}}, budget)
+	assert.Equal(t, 0, ra.next.window)
+	ra.next.start = 150
+	ra.done(true, budget, chunkPool, metrics)
+
+	// The window starts from the size of the batch, and doubles while the batches are sequential.
+	ra.prepare([]Part{{Start: 160, End: 260}}, budget)
+	assert.Equal(t, 100, ra.next.window)
+	ra.next.start = 250
+	ra.done(true, budget, chunkPool, metrics)
+
+	ra.prepare([]Part{{Start: 250, End: 300}}, budget)
+	assert.Equal(t, 200, ra.next.window)
+	ra.next.start = 300
+	ra.done(true, budget, chunkPool, metrics)
+
+	// A batch reading backwards, or too far, resets it.
+	ra.prepare([]Part{{Start: 100, End: 200}}, budget)
+	assert.Equal(t, 0, ra.next.window)
+	ra.next.start = 200
+	ra.done(true, budget, chunkPool, metrics)
+
+	ra.prepare([]Part{{Start: 5000, End: 5100}}, budget)
+	assert.Equal(t, 0, ra.next.window)
+	ra.done(false, budget, chunkPool, metrics)
+	assert.Equal(t, chunksReadahead{}, ra)
+}
+
+// getRangeCountingBucket is an objstore.Bucket wrapper which counts the GetRange() requests.
+type getRangeCountingBucket struct {
+	objstore.Bucket
+
+	getRangeRequests atomic.Int64
+}
+
+func (b *getRangeCountingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	b.getRangeRequests.Inc()
+	return b.Bucket.GetRange(ctx, name, off, length)
+}
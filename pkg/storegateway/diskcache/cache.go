@@ -0,0 +1,501 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package diskcache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// headerSize is the size of the header of each cache file: the expiration timestamp, in
+	// milliseconds, followed by the length of the key.
+	headerSize = 8 + 4
+
+	tmpFileSuffix = ".tmp"
+
+	writeQueueSize   = 10000
+	writeConcurrency = 4
+)
+
+var (
+	_ cache.Cache             = (*Cache)(nil)
+	_ cache.RemoteCacheClient = (*Cache)(nil)
+
+	errCorruptedFile = errors.New("corrupted cache file")
+	errExpired       = errors.New("expired cache file")
+)
+
+// Cache is a cache storing each item in a file on the local disk. The total size of the files is limited,
+// and the least recently used items are evicted when the limit is exceeded. The items already stored in the
+// directory are reused on startup.
+type Cache struct {
+	name         string
+	dir          string
+	maxSizeBytes uint64
+	logger       log.Logger
+
+	mtx     sync.Mutex
+	lru     *simplelru.LRU // File name -> *cacheItem.
+	curSize uint64
+
+	writes chan diskWrite
+	stop   chan struct{}
+	wg     sync.WaitGroup
+
+	requests      prometheus.Counter
+	hits          prometheus.Counter
+	evicted       prometheus.Counter
+	droppedWrites prometheus.Counter
+}
+
+// cacheItem is an item stored on disk. Its key is kept in memory, so that the items can be deleted by key.
+type cacheItem struct {
+	key  string
+	size uint64
+}
+
+type diskWrite struct {
+	key   string
+	value []byte
+	ttl   time.Duration
+}
+
+// New makes a new Cache storing the items in the input directory, and loads the items already stored in it.
+func New(name, dir string, maxSizeBytes uint64, logger log.Logger, reg prometheus.Registerer) (*Cache, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, errors.Wrap(err, "create disk cache directory")
+	}
+
+	c := &Cache{
+		name:         name,
+		dir:          dir,
+		maxSizeBytes: maxSizeBytes,
+		logger:       log.With(logger, "name", name),
+		writes:       make(chan diskWrite, writeQueueSize),
+		stop:         make(chan struct{}),
+
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_bucket_store_disk_cache_requests_total",
+			Help:        "Total number of items requested from the disk cache.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_bucket_store_disk_cache_hits_total",
+			Help:        "Total number of items requested from the disk cache that were a hit.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+		evicted: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_bucket_store_disk_cache_evicted_total",
+			Help:        "Total number of items evicted from the disk cache because it was full.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+		droppedWrites: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name:        "cortex_bucket_store_disk_cache_dropped_writes_total",
+			Help:        "Total number of items not stored in the disk cache because the write queue was full.",
+			ConstLabels: map[string]string{"name": name},
+		}),
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "cortex_bucket_store_disk_cache_items",
+		Help:        "Number of items currently stored in the disk cache.",
+		ConstLabels: map[string]string{"name": name},
+	}, func() float64 {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		return float64(c.lru.Len())
+	})
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "cortex_bucket_store_disk_cache_size_bytes",
+		Help:        "Size in bytes of the items currently stored in the disk cache.",
+		ConstLabels: map[string]string{"name": name},
+	}, func() float64 {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		return float64(c.curSize)
+	})
+
+	// The size of the LRU is limited by the size of the files, not by the number of items.
+	l, err := simplelru.NewLRU(math.MaxInt, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+
+	if err := c.load(); err != nil {
+		return nil, errors.Wrap(err, "load disk cache")
+	}
+
+	c.wg.Add(writeConcurrency)
+	for i := 0; i < writeConcurrency; i++ {
+		go c.writeLoop()
+	}
+
+	level.Info(c.logger).Log("msg", "created disk cache", "dir", dir, "items", c.lru.Len(), "size_bytes", c.curSize)
+	return c, nil
+}
+
+// load adds the files already stored in the cache directory to the LRU, from the least recently modified
+// to the most recently modified one, and evicts the oldest ones if they don't fit in the cache anymore.
+func (c *Cache) load() error {
+	type cacheFile struct {
+		name    string
+		key     string
+		size    uint64
+		modTime time.Time
+	}
+
+	var files []cacheFile
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		// Leftovers of writes interrupted by a shutdown.
+		if strings.HasSuffix(path, tmpFileSuffix) {
+			return os.Remove(path)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(c.dir, path)
+		if err != nil {
+			return err
+		}
+
+		key, err := readKey(path, info.Size())
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "removing unreadable item from the disk cache", "file", name, "err", err)
+			return os.Remove(path)
+		}
+
+		files = append(files, cacheFile{name: name, key: key, size: uint64(info.Size()), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, f := range files {
+		c.lru.Add(f.name, &cacheItem{key: f.key, size: f.size})
+		c.curSize += f.size
+	}
+	c.evictOldest()
+	return nil
+}
+
+// Stop the asynchronous writes. The writes still enqueued are dropped.
+func (c *Cache) Stop() {
+	close(c.stop)
+	c.wg.Wait()
+}
+
+func (c *Cache) Name() string {
+	return c.name
+}
+
+// StoreAsync enqueues the items to be written to the disk. The items are dropped if the write queue is full.
+func (c *Cache) StoreAsync(data map[string][]byte, ttl time.Duration) {
+	for key, value := range data {
+		c.enqueue(diskWrite{key: key, value: value, ttl: ttl})
+	}
+}
+
+// SetAsync implements cache.RemoteCacheClient.
+func (c *Cache) SetAsync(key string, value []byte, ttl time.Duration) error {
+	c.enqueue(diskWrite{key: key, value: value, ttl: ttl})
+	return nil
+}
+
+func (c *Cache) enqueue(w diskWrite) {
+	select {
+	case <-c.stop:
+		return
+	default:
+	}
+
+	select {
+	case c.writes <- w:
+	default:
+		c.droppedWrites.Inc()
+	}
+}
+
+func (c *Cache) writeLoop() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case w := <-c.writes:
+			if err := c.store(w.key, w.value, w.ttl); err != nil {
+				level.Warn(c.logger).Log("msg", "failed to store item in the disk cache", "err", err)
+			}
+		}
+	}
+}
+
+// store writes the item to the disk, and evicts the least recently used items if the cache is full.
+func (c *Cache) store(key string, value []byte, ttl time.Duration) error {
+	size := uint64(headerSize + len(key) + len(value))
+	if size > c.maxSizeBytes {
+		return nil
+	}
+
+	name := fileName(key)
+	path := filepath.Join(c.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a partially written file is never read.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*"+tmpFileSuffix)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixMilli()
+	}
+
+	header := make([]byte, headerSize, headerSize+len(key))
+	binary.BigEndian.PutUint64(header, uint64(expiresAt))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(key)))
+
+	_, err = f.Write(append(header, key...))
+	if err == nil {
+		_, err = f.Write(value)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if prev, ok := c.lru.Peek(name); ok {
+		c.curSize -= prev.(*cacheItem).size
+	}
+	c.lru.Add(name, &cacheItem{key: key, size: size})
+	c.curSize += size
+	c.evictOldest()
+	return nil
+}
+
+// evictOldest evicts the least recently used items until the cache size is within the limit.
+// Must be called with the lock held.
+func (c *Cache) evictOldest() {
+	for c.curSize > c.maxSizeBytes {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			return
+		}
+		c.evicted.Inc()
+	}
+}
+
+// onEvict removes the file of the item removed from the LRU. It's called with the lock held.
+func (c *Cache) onEvict(name, item interface{}) {
+	c.curSize -= item.(*cacheItem).size
+
+	if err := os.Remove(filepath.Join(c.dir, name.(string))); err != nil && !os.IsNotExist(err) {
+		level.Warn(c.logger).Log("msg", "failed to remove evicted item from the disk cache", "err", err)
+	}
+}
+
+// Fetch reads the items from the disk. Each value is allocated with the allocator from the input options, if any.
+func (c *Cache) Fetch(ctx context.Context, keys []string, opts ...cache.Option) map[string][]byte {
+	options := &cache.Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	c.requests.Add(float64(len(keys)))
+
+	var hits map[string][]byte
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+
+		name := fileName(key)
+
+		c.mtx.Lock()
+		_, ok := c.lru.Get(name)
+		c.mtx.Unlock()
+		if !ok {
+			continue
+		}
+
+		value, err := c.read(name, key, options.Alloc)
+		if err != nil {
+			if !os.IsNotExist(err) && !errors.Is(err, errExpired) {
+				level.Warn(c.logger).Log("msg", "failed to read item from the disk cache", "err", err)
+			}
+
+			c.mtx.Lock()
+			c.lru.Remove(name)
+			c.mtx.Unlock()
+			continue
+		}
+
+		if hits == nil {
+			hits = make(map[string][]byte, len(keys))
+		}
+		hits[key] = value
+	}
+
+	c.hits.Add(float64(len(hits)))
+	return hits
+}
+
+// GetMulti implements cache.RemoteCacheClient.
+func (c *Cache) GetMulti(ctx context.Context, keys []string, opts ...cache.Option) map[string][]byte {
+	return c.Fetch(ctx, keys, opts...)
+}
+
+func (c *Cache) read(name, key string, alloc cache.Allocator) (_ []byte, returnErr error) {
+	f, err := os.Open(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(info.Size())
+	if size < headerSize+len(key) {
+		return nil, errCorruptedFile
+	}
+
+	var buf []byte
+	if alloc != nil {
+		b := alloc.Get(size)
+		defer func() {
+			if returnErr != nil {
+				alloc.Put(b)
+			}
+		}()
+		buf = (*b)[:size]
+	} else {
+		buf = make([]byte, size)
+	}
+
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return nil, err
+	}
+
+	expiresAt := int64(binary.BigEndian.Uint64(buf))
+	if expiresAt > 0 && time.Now().UnixMilli() > expiresAt {
+		return nil, errExpired
+	}
+
+	// Guard against hash collisions.
+	keyLen := int(binary.BigEndian.Uint32(buf[8:]))
+	if keyLen != len(key) || string(buf[headerSize:headerSize+keyLen]) != key {
+		return nil, errCorruptedFile
+	}
+
+	return buf[headerSize+keyLen:], nil
+}
+
+// Delete the item from the disk.
+func (c *Cache) Delete(_ context.Context, key string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.lru.Remove(fileName(key))
+	return nil
+}
+
+// DeleteMatching deletes the items whose key matches, and returns the number of deleted items.
+func (c *Cache) DeleteMatching(match func(key string) bool) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	deleted := 0
+	for _, name := range c.lru.Keys() {
+		item, ok := c.lru.Peek(name)
+		if ok && match(item.(*cacheItem).key) {
+			c.lru.Remove(name)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// readKey returns the key of the item stored in the input file of the input size.
+func readKey(path string, size int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return "", errCorruptedFile
+	}
+
+	keyLen := int64(binary.BigEndian.Uint32(header[8:]))
+	if headerSize+keyLen > size {
+		return "", errCorruptedFile
+	}
+
+	key := make([]byte, keyLen)
+	if _, err := io.ReadFull(f, key); err != nil {
+		return "", errCorruptedFile
+	}
+	return string(key), nil
+}
+
+// fileName returns the name of the file of the key, relative to the cache directory. The files are spread
+// across sub-directories, to not have too many files in a single directory.
+func fileName(key string) string {
+	hash := blake2b.Sum256([]byte(key))
+	name := hex.EncodeToString(hash[:])
+	return filepath.Join(name[:2], name)
+}
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package diskcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/test"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_StoreAndFetch(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+
+	c, err := New("test", t.TempDir(), 1024, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	require.NoError(t, c.store("key-1", []byte("value-1"), time.Hour))
+	require.NoError(t, c.store("key-2", []byte("value-2"), 0))
+	require.NoError(t, c.store("key-3", []byte("value-3"), time.Millisecond))
+
+	// Overwriting an item doesn't count its size twice.
+	require.NoError(t, c.store("key-1", []byte("value-1"), time.Hour))
+
+	// Wait until the third item has expired.
+	time.Sleep(10 * time.Millisecond)
+
+	hits := c.Fetch(ctx, []string{"key-1", "key-2", "key-3", "key-4"})
+	assert.Equal(t, map[string][]byte{"key-1": []byte("value-1"), "key-2": []byte("value-2")}, hits)
+
+	require.NoError(t, c.Delete(ctx, "key-2"))
+	assert.Empty(t, c.Fetch(ctx, []string{"key-2"}))
+
+	// The expired and deleted items have been removed from the disk.
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_disk_cache_hits_total Total number of items requested from the disk cache that were a hit.
+		# TYPE cortex_bucket_store_disk_cache_hits_total counter
+		cortex_bucket_store_disk_cache_hits_total{name="test"} 2
+		# HELP cortex_bucket_store_disk_cache_items Number of items currently stored in the disk cache.
+		# TYPE cortex_bucket_store_disk_cache_items gauge
+		cortex_bucket_store_disk_cache_items{name="test"} 1
+		# HELP cortex_bucket_store_disk_cache_requests_total Total number of items requested from the disk cache.
+		# TYPE cortex_bucket_store_disk_cache_requests_total counter
+		cortex_bucket_store_disk_cache_requests_total{name="test"} 5
+		# HELP cortex_bucket_store_disk_cache_size_bytes Size in bytes of the items currently stored in the disk cache.
+		# TYPE cortex_bucket_store_disk_cache_size_bytes gauge
+		cortex_bucket_store_disk_cache_size_bytes{name="test"} 24
+	`), "cortex_bucket_store_disk_cache_hits_total", "cortex_bucket_store_disk_cache_items", "cortex_bucket_store_disk_cache_requests_total", "cortex_bucket_store_disk_cache_size_bytes"))
+}
+
+func TestCache_StoreAsync(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := New("test", t.TempDir(), 1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	c.StoreAsync(map[string][]byte{"key-1": []byte("value-1")}, time.Hour)
+	require.NoError(t, c.SetAsync("key-2", []byte("value-2"), time.Hour))
+
+	test.Poll(t, time.Second, 2, func() interface{} {
+		return len(c.GetMulti(ctx, []string{"key-1", "key-2"}))
+	})
+}
+
+func TestCache_ShouldEvictTheLeastRecentlyUsedItems(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+
+	// Each item takes 12 bytes of header, 5 bytes of key and 10 bytes of value.
+	c, err := New("test", t.TempDir(), 3*27, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	require.NoError(t, c.store("key-1", []byte("0123456789"), 0))
+	require.NoError(t, c.store("key-2", []byte("0123456789"), 0))
+	require.NoError(t, c.store("key-3", []byte("0123456789"), 0))
+
+	// Use the first item, so that the second one is the least recently used.
+	require.Len(t, c.Fetch(ctx, []string{"key-1"}), 1)
+
+	require.NoError(t, c.store("key-4", []byte("0123456789"), 0))
+
+	// Items bigger than the cache are not stored.
+	require.NoError(t, c.store("key-5", make([]byte, 100), 0))
+
+	hits := c.Fetch(ctx, []string{"key-1", "key-2", "key-3", "key-4", "key-5"})
+	assert.Len(t, hits, 3)
+	assert.NotContains(t, hits, "key-2")
+	assert.NoFileExists(t, filepath.Join(c.dir, fileName("key-2")))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.evicted))
+}
+
+func TestCache_ShouldReuseTheItemsOnStartup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c, err := New("test", dir, 1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c.store("key-1", []byte("value-1"), time.Hour))
+	require.NoError(t, c.store("key-2", []byte("value-2"), time.Hour))
+	c.Stop()
+
+	// Write a leftover of an interrupted write.
+	tmpFile := filepath.Join(dir, fileName("key-3")+"-123"+tmpFileSuffix)
+	require.NoError(t, os.MkdirAll(filepath.Dir(tmpFile), os.ModePerm))
+	require.NoError(t, os.WriteFile(tmpFile, []byte("partial"), 0o600))
+
+	// Make the first item the oldest one, so that it's evicted first.
+	oldTime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, fileName("key-1")), oldTime, oldTime))
+
+	// The new cache can only fit one item.
+	c, err = New("test", dir, 30, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	hits := c.Fetch(ctx, []string{"key-1", "key-2"})
+	assert.Equal(t, map[string][]byte{"key-2": []byte("value-2")}, hits)
+	assert.NoFileExists(t, tmpFile)
+}
+
+func TestCache_DeleteMatching(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c, err := New("test", dir, 1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c.store("block-1:key-1", []byte("value-1"), time.Hour))
+	require.NoError(t, c.store("block-1:key-2", []byte("value-2"), time.Hour))
+	require.NoError(t, c.store("block-2:key-1", []byte("value-3"), time.Hour))
+
+	isBlock1 := func(key string) bool { return strings.HasPrefix(key, "block-1:") }
+	assert.Equal(t, 2, c.DeleteMatching(isBlock1))
+	assert.Equal(t, 0, c.DeleteMatching(isBlock1))
+	assert.NoFileExists(t, filepath.Join(dir, fileName("block-1:key-1")))
+
+	hits := c.Fetch(ctx, []string{"block-1:key-1", "block-1:key-2", "block-2:key-1"})
+	assert.Equal(t, map[string][]byte{"block-2:key-1": []byte("value-3")}, hits)
+	c.Stop()
+
+	// The keys of the items reused on startup are read from their files, so they can be deleted too.
+	c, err = New("test", dir, 1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	assert.Equal(t, 1, c.DeleteMatching(func(key string) bool { return key == "block-2:key-1" }))
+	assert.Empty(t, c.Fetch(ctx, []string{"block-2:key-1"}))
+}
+
+func TestCache_FetchWithAllocator(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := New("test", t.TempDir(), 1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+
+	require.NoError(t, c.store("key-1", []byte("value-1"), time.Hour))
+
+	alloc := &mockAllocator{}
+	hits := c.Fetch(ctx, []string{"key-1"}, cache.WithAllocator(alloc))
+	assert.Equal(t, map[string][]byte{"key-1": []byte("value-1")}, hits)
+	assert.Equal(t, 1, alloc.allocated)
+}
+
+type mockAllocator struct {
+	allocated int
+}
+
+func (a *mockAllocator) Get(sz int) *[]byte {
+	a.allocated++
+	b := make([]byte, 0, sz)
+	return &b
+}
+
+func (a *mockAllocator) Put(*[]byte) {
+	a.allocated--
+}
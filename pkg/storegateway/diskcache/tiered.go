@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package diskcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/dskit/cache"
+)
+
+var _ cache.Cache = (*TieredCache)(nil)
+
+// TieredCache is a cache.Cache which fetches the items from the disk cache first, and then the
+// misses from the next cache tier, if any. The items found in the next tier are stored on disk too.
+type TieredCache struct {
+	disk        *Cache
+	next        cache.Cache
+	backfillTTL time.Duration
+}
+
+// NewTieredCache makes a new TieredCache. The next cache tier may be nil. The items found in the
+// next cache tier are stored on disk with the input backfillTTL, because their TTL is unknown.
+func NewTieredCache(disk *Cache, next cache.Cache, backfillTTL time.Duration) *TieredCache {
+	return &TieredCache{
+		disk:        disk,
+		next:        next,
+		backfillTTL: backfillTTL,
+	}
+}
+
+func (c *TieredCache) StoreAsync(data map[string][]byte, ttl time.Duration) {
+	c.disk.StoreAsync(data, ttl)
+	if c.next != nil {
+		c.next.StoreAsync(data, ttl)
+	}
+}
+
+func (c *TieredCache) Fetch(ctx context.Context, keys []string, opts ...cache.Option) map[string][]byte {
+	hits := c.disk.Fetch(ctx, keys, opts...)
+	if c.next == nil || len(hits) == len(keys) {
+		return hits
+	}
+
+	misses := make([]string, 0, len(keys)-len(hits))
+	for _, key := range keys {
+		if _, ok := hits[key]; !ok {
+			misses = append(misses, key)
+		}
+	}
+
+	nextHits := c.next.Fetch(ctx, misses, opts...)
+	if len(nextHits) == 0 {
+		return hits
+	}
+
+	// The values may be allocated from a pool which is released at the end of the request,
+	// while they're written to the disk asynchronously, so we store a copy.
+	backfill := make(map[string][]byte, len(nextHits))
+	if hits == nil {
+		hits = make(map[string][]byte, len(nextHits))
+	}
+	for key, value := range nextHits {
+		hits[key] = value
+		backfill[key] = append([]byte(nil), value...)
+	}
+	c.disk.StoreAsync(backfill, c.backfillTTL)
+
+	return hits
+}
+
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	err := c.disk.Delete(ctx, key)
+	if c.next != nil {
+		if nextErr := c.next.Delete(ctx, key); err == nil {
+			err = nextErr
+		}
+	}
+	return err
+}
+
+// DeleteMatching deletes the items whose key matches from the disk, and returns the number of deleted items.
+// The items can't be deleted by key from the next cache tier, so they're left to expire.
+func (c *TieredCache) DeleteMatching(match func(key string) bool) int {
+	return c.disk.DeleteMatching(match)
+}
+
+// Stop stops the disk cache. The next cache tier is left to its owner.
+func (c *TieredCache) Stop() {
+	c.disk.Stop()
+}
+
+func (c *TieredCache) Name() string {
+	if c.next != nil {
+		return c.next.Name()
+	}
+	return c.disk.Name()
+}
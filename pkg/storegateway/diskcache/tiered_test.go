@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package diskcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+
+	disk, err := New("test", t.TempDir(), 1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(disk.Stop)
+
+	next := cache.NewMockCache()
+	c := NewTieredCache(disk, next, time.Hour)
+
+	// Items are stored in both tiers.
+	c.StoreAsync(map[string][]byte{"key-1": []byte("value-1")}, time.Hour)
+	assert.Contains(t, next.GetItems(), "key-1")
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return len(disk.Fetch(ctx, []string{"key-1"}))
+	})
+
+	// Items only found in the next tier are stored on disk too.
+	next.StoreAsync(map[string][]byte{"key-2": []byte("value-2")}, time.Hour)
+
+	hits := c.Fetch(ctx, []string{"key-1", "key-2", "key-3"})
+	assert.Equal(t, map[string][]byte{"key-1": []byte("value-1"), "key-2": []byte("value-2")}, hits)
+	test.Poll(t, time.Second, 2, func() interface{} {
+		return len(disk.Fetch(ctx, []string{"key-1", "key-2"}))
+	})
+
+	// Items are deleted from both tiers.
+	require.NoError(t, c.Delete(ctx, "key-2"))
+	assert.Empty(t, c.Fetch(ctx, []string{"key-2"}))
+}
+
+func TestTieredCache_WithoutNextTier(t *testing.T) {
+	ctx := context.Background()
+
+	disk, err := New("test", t.TempDir(), 1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(disk.Stop)
+
+	c := NewTieredCache(disk, nil, time.Hour)
+	assert.Equal(t, "test", c.Name())
+
+	c.StoreAsync(map[string][]byte{"key-1": []byte("value-1")}, time.Hour)
+	test.Poll(t, time.Second, 1, func() interface{} {
+		return len(c.Fetch(ctx, []string{"key-1", "key-2"}))
+	})
+}
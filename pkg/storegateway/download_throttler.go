@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/time/rate"
+)
+
+// downloadPriority is the priority of a download from the long-term storage.
+type downloadPriority int
+
+const (
+	// downloadPriorityBackground is the priority of the downloads not triggered by a query, like the ones
+	// building the index-headers of the blocks being synchronized. It's the default one.
+	downloadPriorityBackground downloadPriority = iota
+
+	// downloadPriorityQuery is the priority of the downloads triggered by a query.
+	downloadPriorityQuery
+
+	numDownloadPriorities
+)
+
+func (p downloadPriority) String() string {
+	if p == downloadPriorityQuery {
+		return "query"
+	}
+	return "background"
+}
+
+type downloadPriorityContextKey int
+
+const downloadPriorityCtxKey = downloadPriorityContextKey(0)
+
+// contextWithDownloadPriority returns a context whose downloads from the long-term storage have the input priority.
+func contextWithDownloadPriority(ctx context.Context, priority downloadPriority) context.Context {
+	return context.WithValue(ctx, downloadPriorityCtxKey, priority)
+}
+
+// downloadPriorityFromContext returns the priority of the downloads run with the context.
+func downloadPriorityFromContext(ctx context.Context) downloadPriority {
+	if priority, ok := ctx.Value(downloadPriorityCtxKey).(downloadPriority); ok {
+		return priority
+	}
+	return downloadPriorityBackground
+}
+
+// downloadThrottler limits the concurrency and the bandwidth of the downloads from the long-term storage,
+// giving priority to the downloads triggered by queries over the background ones.
+type downloadThrottler struct {
+	maxConcurrent int
+
+	// bandwidth limits the bandwidth of the background downloads, while the query downloads consume it without
+	// waiting. Nil if the bandwidth is not limited.
+	bandwidth *rate.Limiter
+
+	mtx      sync.Mutex
+	inflight int
+	waiting  [numDownloadPriorities][]chan struct{}
+
+	downloadedBytes *prometheus.CounterVec
+	waitDuration    *prometheus.HistogramVec
+}
+
+func newDownloadThrottler(maxConcurrent int, maxBandwidthBytes uint64, reg prometheus.Registerer) *downloadThrottler {
+	t := &downloadThrottler{
+		maxConcurrent: maxConcurrent,
+		downloadedBytes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_downloaded_bytes_total",
+			Help: "Total number of bytes downloaded from the long-term storage, by tenant and priority of the downloads.",
+		}, []string{"user", "priority"}),
+		waitDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_bucket_store_download_wait_duration_seconds",
+			Help:    "Time spent by the downloads from the long-term storage waiting because of the concurrency and bandwidth limits, by priority of the downloads.",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 5, 10, 30},
+		}, []string{"priority"}),
+	}
+	if maxBandwidthBytes > 0 {
+		burst := int(maxBandwidthBytes)
+		if burst <= 0 {
+			// Overflow.
+			burst = int(^uint(0) >> 1)
+		}
+		t.bandwidth = rate.NewLimiter(rate.Limit(maxBandwidthBytes), burst)
+	}
+	return t
+}
+
+// acquire waits until a download with the input priority can be started. The downloads with the highest
+// priority are started first, and the ones with the same priority in FIFO order.
+func (t *downloadThrottler) acquire(ctx context.Context, priority downloadPriority) error {
+	if t.maxConcurrent <= 0 {
+		return nil
+	}
+
+	t.mtx.Lock()
+	if t.inflight < t.maxConcurrent && !t.hasWaitingLocked(priority) {
+		t.inflight++
+		t.mtx.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	t.waiting[priority] = append(t.waiting[priority], ready)
+	t.mtx.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		t.mtx.Lock()
+		removed := t.removeWaitingLocked(priority, ready)
+		t.mtx.Unlock()
+
+		if !removed {
+			// The download has been started in the meantime, so the slot must be handed over.
+			t.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// release hands over the slot of a finished download to the next waiting download, if any.
+func (t *downloadThrottler) release() {
+	if t.maxConcurrent <= 0 {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for priority := numDownloadPriorities - 1; priority >= 0; priority-- {
+		if len(t.waiting[priority]) > 0 {
+			ready := t.waiting[priority][0]
+			t.waiting[priority] = t.waiting[priority][1:]
+			close(ready)
+			return
+		}
+	}
+	t.inflight--
+}
+
+// hasWaitingLocked returns whether there are waiting downloads with the same or a higher priority.
+func (t *downloadThrottler) hasWaitingLocked(priority downloadPriority) bool {
+	for p := priority; p < numDownloadPriorities; p++ {
+		if len(t.waiting[p]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *downloadThrottler) removeWaitingLocked(priority downloadPriority, ready chan struct{}) bool {
+	for i, w := range t.waiting[priority] {
+		if w == ready {
+			t.waiting[priority] = append(t.waiting[priority][:i], t.waiting[priority][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// consumeBandwidth consumes the bandwidth of n downloaded bytes. The background downloads wait until the bytes
+// fit in the bandwidth, while the query downloads consume it without waiting.
+func (t *downloadThrottler) consumeBandwidth(ctx context.Context, priority downloadPriority, n int) error {
+	if t.bandwidth == nil {
+		return nil
+	}
+
+	// The bytes consumed at once can't exceed the burst.
+	for n > 0 {
+		num := n
+		if burst := t.bandwidth.Burst(); num > burst {
+			num = burst
+		}
+		n -= num
+
+		if priority == downloadPriorityQuery {
+			t.bandwidth.ReserveN(time.Now(), num)
+			continue
+		}
+		if err := t.bandwidth.WaitN(ctx, num); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeTenant removes the metrics of the tenant.
+func (t *downloadThrottler) removeTenant(userID string) {
+	for priority := downloadPriority(0); priority < numDownloadPriorities; priority++ {
+		t.downloadedBytes.DeleteLabelValues(userID, priority.String())
+	}
+}
+
+// throttledBucket is an objstore.Bucket whose downloads are throttled by a downloadThrottler. The tenant
+// of each download is the first directory of the downloaded object.
+type throttledBucket struct {
+	objstore.Bucket
+	throttler *downloadThrottler
+}
+
+func newThrottledBucket(bkt objstore.Bucket, throttler *downloadThrottler) *throttledBucket {
+	return &throttledBucket{Bucket: bkt, throttler: throttler}
+}
+
+// Get implements objstore.Bucket.
+func (b *throttledBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.throttle(ctx, name, func() (io.ReadCloser, error) {
+		return b.Bucket.Get(ctx, name)
+	})
+}
+
+// GetRange implements objstore.Bucket.
+func (b *throttledBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.throttle(ctx, name, func() (io.ReadCloser, error) {
+		return b.Bucket.GetRange(ctx, name, off, length)
+	})
+}
+
+func (b *throttledBucket) throttle(ctx context.Context, name string, get func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	priority := downloadPriorityFromContext(ctx)
+
+	start := time.Now()
+	if err := b.throttler.acquire(ctx, priority); err != nil {
+		return nil, err
+	}
+
+	r, err := get()
+	if err != nil {
+		b.throttler.release()
+		return nil, err
+	}
+
+	userID, _, _ := strings.Cut(name, "/")
+	return &throttledReader{
+		ReadCloser: r,
+		ctx:        ctx,
+		throttler:  b.throttler,
+		priority:   priority,
+		downloaded: b.throttler.downloadedBytes.WithLabelValues(userID, priority.String()),
+		waited:     time.Since(start),
+	}, nil
+}
+
+// ReaderWithExpectedErrs implements objstore.InstrumentedBucketReader.
+func (b *throttledBucket) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs implements objstore.InstrumentedBucket.
+func (b *throttledBucket) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	if ib, ok := b.Bucket.(objstore.InstrumentedBucket); ok {
+		return newThrottledBucket(ib.WithExpectedErrs(fn), b.throttler)
+	}
+	return b
+}
+
+// throttledReader consumes the bandwidth of the downloaded bytes, and releases the download slot once closed.
+type throttledReader struct {
+	io.ReadCloser
+
+	ctx        context.Context
+	throttler  *downloadThrottler
+	priority   downloadPriority
+	downloaded prometheus.Counter
+
+	waited    time.Duration
+	closeOnce sync.Once
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.downloaded.Add(float64(n))
+
+		start := time.Now()
+		if waitErr := r.throttler.consumeBandwidth(r.ctx, r.priority, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+		r.waited += time.Since(start)
+	}
+	return n, err
+}
+
+func (r *throttledReader) Close() error {
+	r.closeOnce.Do(func() {
+		r.throttler.release()
+		r.throttler.waitDuration.WithLabelValues(r.priority.String()).Observe(r.waited.Seconds())
+	})
+	return r.ReadCloser.Close()
+}
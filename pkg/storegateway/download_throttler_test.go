@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestDownloadThrottler_ShouldStartQueryDownloadsFirst(t *testing.T) {
+	ctx := context.Background()
+	throttler := newDownloadThrottler(1, 0, nil)
+
+	// The first download gets the only slot.
+	require.NoError(t, throttler.acquire(ctx, downloadPriorityBackground))
+
+	started := make(chan downloadPriority, 2)
+	waitFor := func(priority downloadPriority) {
+		go func() {
+			assert.NoError(t, throttler.acquire(ctx, priority))
+			started <- priority
+		}()
+
+		// Wait until the download is waiting.
+		require.Eventually(t, func() bool {
+			throttler.mtx.Lock()
+			defer throttler.mtx.Unlock()
+			return len(throttler.waiting[priority]) == 1
+		}, time.Second, time.Millisecond)
+	}
+
+	waitFor(downloadPriorityBackground)
+	waitFor(downloadPriorityQuery)
+
+	// The query download is started first, even if it's been waiting for less time.
+	throttler.release()
+	assert.Equal(t, downloadPriorityQuery, <-started)
+
+	throttler.release()
+	assert.Equal(t, downloadPriorityBackground, <-started)
+
+	throttler.release()
+	throttler.mtx.Lock()
+	assert.Equal(t, 0, throttler.inflight)
+	throttler.mtx.Unlock()
+}
+
+func TestDownloadThrottler_ShouldStopWaitingOnContextCancellation(t *testing.T) {
+	throttler := newDownloadThrottler(1, 0, nil)
+	require.NoError(t, throttler.acquire(context.Background(), downloadPriorityQuery))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, throttler.acquire(ctx, downloadPriorityBackground), context.DeadlineExceeded)
+
+	// The cancelled download doesn't take the slot once released.
+	throttler.release()
+	require.NoError(t, throttler.acquire(context.Background(), downloadPriorityBackground))
+}
+
+func TestDownloadThrottler_ShouldNotDelayQueryDownloadsBecauseOfTheBandwidth(t *testing.T) {
+	throttler := newDownloadThrottler(0, 1000, nil)
+
+	// The query downloads consume the bandwidth without waiting, even beyond it.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.NoError(t, throttler.consumeBandwidth(ctx, downloadPriorityQuery, 2000))
+
+	// The background downloads have to wait for the bandwidth consumed by the query ones.
+	assert.Error(t, throttler.consumeBandwidth(ctx, downloadPriorityBackground, 100))
+}
+
+func TestThrottledBucket(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	throttler := newDownloadThrottler(1, 0, reg)
+
+	inmem := objstore.NewInMemBucket()
+	require.NoError(t, inmem.Upload(ctx, "user-1/block/index", bytes.NewReader(make([]byte, 100))))
+	require.NoError(t, inmem.Upload(ctx, "user-2/block/index", bytes.NewReader(make([]byte, 100))))
+	bkt := newThrottledBucket(inmem, throttler)
+
+	read := func(ctx context.Context, get func(context.Context) (io.ReadCloser, error)) {
+		r, err := get(ctx)
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		// Closing twice doesn't release the slot twice.
+		require.NoError(t, r.Close())
+	}
+
+	read(ctx, func(ctx context.Context) (io.ReadCloser, error) {
+		return bkt.Get(ctx, "user-1/block/index")
+	})
+	read(contextWithDownloadPriority(ctx, downloadPriorityQuery), func(ctx context.Context) (io.ReadCloser, error) {
+		return bkt.GetRange(ctx, "user-1/block/index", 10, 20)
+	})
+	read(contextWithDownloadPriority(ctx, downloadPriorityQuery), func(ctx context.Context) (io.ReadCloser, error) {
+		return bkt.Get(ctx, "user-2/block/index")
+	})
+
+	// The slot of a failed download is released.
+	_, err := bkt.Get(ctx, "user-3/block/index")
+	require.True(t, bkt.IsObjNotFoundErr(err))
+
+	throttler.mtx.Lock()
+	assert.Equal(t, 0, throttler.inflight)
+	throttler.mtx.Unlock()
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_downloaded_bytes_total Total number of bytes downloaded from the long-term storage, by tenant and priority of the downloads.
+		# TYPE cortex_bucket_store_downloaded_bytes_total counter
+		cortex_bucket_store_downloaded_bytes_total{priority="background",user="user-1"} 100
+		cortex_bucket_store_downloaded_bytes_total{priority="query",user="user-1"} 20
+		cortex_bucket_store_downloaded_bytes_total{priority="query",user="user-2"} 100
+	`), "cortex_bucket_store_downloaded_bytes_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(throttler.waitDuration))
+
+	// The metrics of a removed tenant are removed.
+	throttler.removeTenant("user-1")
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_downloaded_bytes_total Total number of bytes downloaded from the long-term storage, by tenant and priority of the downloads.
+		# TYPE cortex_bucket_store_downloaded_bytes_total counter
+		cortex_bucket_store_downloaded_bytes_total{priority="query",user="user-2"} 100
+	`), "cortex_bucket_store_downloaded_bytes_total"))
+}
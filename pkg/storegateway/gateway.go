@@ -9,6 +9,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -16,6 +17,7 @@ import (
 	"github.com/grafana/dskit/kv"
 	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -35,6 +37,7 @@ const (
 	syncReasonInitial    = "initial"
 	syncReasonPeriodic   = "periodic"
 	syncReasonRingChange = "ring-change"
+	syncReasonTenants    = "tenants-notification"
 
 	// ringAutoForgetUnhealthyPeriods is how many consecutive timeout periods an unhealthy instance
 	// in the ring will be automatically removed.
@@ -43,17 +46,22 @@ const (
 
 var (
 	// Validation errors.
-	errInvalidTenantShardSize = errors.New("invalid tenant shard size, the value must be greater or equal to 0")
+	errInvalidTenantShardSize         = errors.New("invalid tenant shard size, the value must be greater or equal to 0")
+	errInvalidTenantReplicationFactor = errors.New("invalid tenant replication factor, the value must be greater or equal to 0")
+	errInvalidLargeTenantThreshold    = errors.New("invalid large tenant threshold, the value must be greater or equal to 0")
+	errInvalidLargeTenantShardSize    = errors.New("invalid large tenant shard size, the value must be greater or equal to 0")
 )
 
 // Config holds the store gateway config.
 type Config struct {
-	ShardingRing RingConfig `yaml:"sharding_ring" doc:"description=The hash ring configuration."`
+	ShardingRing      RingConfig              `yaml:"sharding_ring" doc:"description=The hash ring configuration."`
+	CacheInvalidation CacheInvalidationConfig `yaml:"cache_invalidation"`
 }
 
 // RegisterFlags registers the Config flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	cfg.ShardingRing.RegisterFlags(f, logger)
+	cfg.CacheInvalidation.RegisterFlagsWithPrefix(f, "store-gateway.cache-invalidation.")
 }
 
 // Validate the Config.
@@ -61,8 +69,17 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 	if limits.StoreGatewayTenantShardSize < 0 {
 		return errInvalidTenantShardSize
 	}
+	if limits.StoreGatewayTenantReplicationFactor < 0 {
+		return errInvalidTenantReplicationFactor
+	}
+	if limits.StoreGatewayLargeTenantThreshold < 0 {
+		return errInvalidLargeTenantThreshold
+	}
+	if limits.StoreGatewayLargeTenantShardSize < 0 {
+		return errInvalidLargeTenantShardSize
+	}
 
-	return nil
+	return cfg.CacheInvalidation.Validate()
 }
 
 // StoreGateway is the Mimir service responsible to expose an API over the bucket
@@ -75,6 +92,7 @@ type StoreGateway struct {
 	storageCfg mimir_tsdb.BlocksStorageConfig
 	logger     log.Logger
 	stores     *BucketStores
+	limits     ShardingLimits
 	tracker    *activitytracker.ActivityTracker
 
 	// Ring used for sharding blocks.
@@ -85,6 +103,18 @@ type StoreGateway struct {
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
 
+	// Tenants whose blocks have been notified as changed, and have to be synced
+	// without waiting for the next periodic sync.
+	tenantsToSyncMx sync.Mutex
+	tenantsToSync   map[string]struct{}
+	tenantsToSyncCh chan struct{}
+
+	// Optional: invalidates the blocks of the events received from the cache invalidation bus.
+	cacheInvalidator *cacheInvalidator
+
+	// Warm-ups of the blocks requested via the HTTP API.
+	warmUps *warmUps
+
 	bucketSync *prometheus.CounterVec
 }
 
@@ -116,7 +146,14 @@ func newStoreGateway(gatewayCfg Config, storageCfg mimir_tsdb.BlocksStorageConfi
 		gatewayCfg: gatewayCfg,
 		storageCfg: storageCfg,
 		logger:     logger,
+		limits:     limits,
 		tracker:    tracker,
+
+		tenantsToSync:   map[string]struct{}{},
+		tenantsToSyncCh: make(chan struct{}, 1),
+
+		warmUps: newWarmUps(logger),
+
 		bucketSync: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_storegateway_bucket_sync_total",
 			Help: "Total number of times the bucket sync operation triggered.",
@@ -127,6 +164,7 @@ func newStoreGateway(gatewayCfg Config, storageCfg mimir_tsdb.BlocksStorageConfi
 	g.bucketSync.WithLabelValues(syncReasonInitial)
 	g.bucketSync.WithLabelValues(syncReasonPeriodic)
 	g.bucketSync.WithLabelValues(syncReasonRingChange)
+	g.bucketSync.WithLabelValues(syncReasonTenants)
 
 	// Init sharding strategy.
 	var shardingStrategy ShardingStrategy
@@ -161,6 +199,10 @@ func newStoreGateway(gatewayCfg Config, storageCfg mimir_tsdb.BlocksStorageConfi
 		return nil, errors.Wrap(err, "create bucket stores")
 	}
 
+	if gatewayCfg.CacheInvalidation.Enabled {
+		g.cacheInvalidator = newCacheInvalidator(gatewayCfg.CacheInvalidation, lifecyclerCfg.ID, g, logger, reg)
+	}
+
 	g.Service = services.NewBasicService(g.starting, g.running, g.stopping)
 
 	return g, nil
@@ -182,7 +224,12 @@ func (g *StoreGateway) starting(ctx context.Context) (err error) {
 
 	// First of all we register the instance in the ring and wait
 	// until the lifecycler successfully started.
-	if g.subservices, err = services.NewManager(g.ringLifecycler, g.ring); err != nil {
+	subservices := []services.Service{g.ringLifecycler, g.ring}
+	if g.cacheInvalidator != nil {
+		subservices = append(subservices, g.cacheInvalidator)
+	}
+
+	if g.subservices, err = services.NewManager(subservices...); err != nil {
 		return errors.Wrap(err, "unable to start store-gateway dependencies")
 	}
 
@@ -267,6 +314,8 @@ func (g *StoreGateway) running(ctx context.Context) error {
 				ringLastState = currRingState
 				g.syncStores(ctx, syncReasonRingChange)
 			}
+		case <-g.tenantsToSyncCh:
+			g.syncTenantsStores(ctx)
 		case <-ctx.Done():
 			return nil
 		case err := <-g.subservicesWatcher.Chan():
@@ -276,6 +325,9 @@ func (g *StoreGateway) running(ctx context.Context) error {
 }
 
 func (g *StoreGateway) stopping(_ error) error {
+	g.warmUps.stop()
+	defer g.stores.flushSeriesHashes()
+
 	if g.subservices != nil {
 		return services.StopManagerAndAwaitStopped(context.Background(), g.subservices)
 	}
@@ -293,6 +345,65 @@ func (g *StoreGateway) syncStores(ctx context.Context, reason string) {
 	}
 }
 
+func (g *StoreGateway) syncTenantsStores(ctx context.Context) {
+	g.tenantsToSyncMx.Lock()
+	userIDs := make([]string, 0, len(g.tenantsToSync))
+	for userID := range g.tenantsToSync {
+		userIDs = append(userIDs, userID)
+	}
+	g.tenantsToSync = map[string]struct{}{}
+	g.tenantsToSyncMx.Unlock()
+
+	if len(userIDs) == 0 {
+		return
+	}
+
+	level.Info(g.logger).Log("msg", "synchronizing TSDB blocks for notified users", "reason", syncReasonTenants, "users", len(userIDs))
+	g.bucketSync.WithLabelValues(syncReasonTenants).Inc()
+
+	if err := g.stores.SyncTenantsBlocks(ctx, userIDs); err != nil {
+		level.Warn(g.logger).Log("msg", "failed to synchronize TSDB blocks for notified users", "reason", syncReasonTenants, "err", err)
+	} else {
+		level.Info(g.logger).Log("msg", "successfully synchronized TSDB blocks for notified users", "reason", syncReasonTenants)
+	}
+}
+
+// SyncTenants implements the storegatewaypb.StoreGatewayServer interface. The sync is asynchronous:
+// the tenants are enqueued and synced by the running loop, so that concurrent notifications get merged.
+func (g *StoreGateway) SyncTenants(_ context.Context, req *storegatewaypb.SyncTenantsRequest) (*storegatewaypb.SyncTenantsResponse, error) {
+	g.enqueueTenantsSync(req.TenantIds)
+
+	return &storegatewaypb.SyncTenantsResponse{}, nil
+}
+
+// enqueueTenantsSync enqueues the tenants to be synced by the running loop.
+func (g *StoreGateway) enqueueTenantsSync(userIDs []string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	g.tenantsToSyncMx.Lock()
+	for _, userID := range userIDs {
+		g.tenantsToSync[userID] = struct{}{}
+	}
+	g.tenantsToSyncMx.Unlock()
+
+	select {
+	case g.tenantsToSyncCh <- struct{}{}:
+	default:
+		// A sync is already pending, and it will pick up the tenants too.
+	}
+}
+
+// invalidateBlocks implements blocksInvalidator. It purges the cached entries of the obsolete blocks,
+// and enqueues the sync of the tenant, so that the blocks are unloaded without waiting for the next periodic sync.
+func (g *StoreGateway) invalidateBlocks(userID string, blockIDs []ulid.ULID) int {
+	purged := g.stores.InvalidateBlockCaches(userID, blockIDs)
+
+	g.enqueueTenantsSync([]string{userID})
+	return purged
+}
+
 // Series implements the storegatewaypb.StoreGatewayServer interface.
 func (g *StoreGateway) Series(req *storepb.SeriesRequest, srv storegatewaypb.StoreGateway_SeriesServer) error {
 	ix := g.tracker.Insert(func() string {
@@ -323,6 +434,16 @@ func (g *StoreGateway) LabelValues(ctx context.Context, req *storepb.LabelValues
 	return g.stores.LabelValues(ctx, req)
 }
 
+// Exemplars implements the storegatewaypb.StoreGatewayServer interface.
+func (g *StoreGateway) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	ix := g.tracker.Insert(func() string {
+		return requestActivity(ctx, "StoreGateway/Exemplars", req)
+	})
+	defer g.tracker.Delete(ix)
+
+	return g.stores.Exemplars(ctx, req)
+}
+
 func requestActivity(ctx context.Context, name string, req interface{}) string {
 	user := getUserIDFromGRPCContext(ctx)
 	traceID, _ := tracing.ExtractSampledTraceID(ctx)
@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/grafana/mimir/pkg/storegateway/indexheader"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/listblocks"
+)
+
+type loadedTenant struct {
+	Tenant             string `json:"tenant"`
+	Blocks             int    `json:"blocks"`
+	SizeBytes          uint64 `json:"size_bytes"`
+	IndexHeadersLoaded int    `json:"index_headers_loaded"`
+}
+
+type loadedBlocksResponse struct {
+	Tenant string        `json:"tenant"`
+	Blocks []loadedBlock `json:"blocks"`
+}
+
+type loadedBlock struct {
+	ID                string    `json:"id"`
+	MinTime           time.Time `json:"min_time"`
+	MaxTime           time.Time `json:"max_time"`
+	CompactionLevel   int       `json:"compaction_level"`
+	SizeBytes         uint64    `json:"size_bytes"`
+	IndexHeaderLazy   bool      `json:"index_header_lazy"`
+	IndexHeaderLoaded bool      `json:"index_header_loaded"`
+}
+
+func newLoadedBlock(b *bucketBlock) loadedBlock {
+	lazy, loaded := indexheader.LoadState(b.indexHeaderReader)
+	return loadedBlock{
+		ID:                b.meta.ULID.String(),
+		MinTime:           util.TimeFromMillis(b.meta.MinTime).UTC(),
+		MaxTime:           util.TimeFromMillis(b.meta.MaxTime).UTC(),
+		CompactionLevel:   b.meta.Compaction.Level,
+		SizeBytes:         listblocks.GetBlockSizeBytes(b.meta),
+		IndexHeaderLazy:   lazy,
+		IndexHeaderLoaded: loaded,
+	}
+}
+
+// LoadedTenantsHandler lists the tenants loaded by this store-gateway, along with the number and the size of their
+// loaded blocks, and the number of their index-headers currently loaded.
+func (g *StoreGateway) LoadedTenantsHandler(w http.ResponseWriter, _ *http.Request) {
+	stores := g.stores.getStores()
+
+	tenants := make([]loadedTenant, 0, len(stores))
+	for tenantID, store := range stores {
+		tenant := loadedTenant{Tenant: tenantID}
+		for _, b := range store.loadedBlocks() {
+			block := newLoadedBlock(b)
+			tenant.Blocks++
+			tenant.SizeBytes += block.SizeBytes
+			if block.IndexHeaderLoaded {
+				tenant.IndexHeadersLoaded++
+			}
+		}
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Tenant < tenants[j].Tenant })
+
+	util.WriteJSONResponse(w, tenants)
+}
+
+// LoadedBlocksHandler lists the blocks of a tenant loaded by this store-gateway. If the "start" or "end"
+// parameters are given, only the blocks which a query of the time range would touch are listed.
+func (g *StoreGateway) LoadedBlocksHandler(w http.ResponseWriter, req *http.Request) {
+	tenantID := mux.Vars(req)["tenant"]
+	if tenantID == "" {
+		http.Error(w, "Tenant ID can't be empty", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("Can't parse form: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	start, err := parseTimeParam(req, "start", math.MinInt64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end, err := parseTimeParam(req, "end", math.MaxInt64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	store := g.stores.getStore(tenantID)
+	if store == nil {
+		http.Error(w, errTenantNotLoaded.Error(), http.StatusNotFound)
+		return
+	}
+
+	blocks := store.loadedBlocks()
+	if req.Form.Has("start") || req.Form.Has("end") {
+		// Select the blocks the same way the queries do.
+		blocks = store.blockSet.getFor(start, end, nil)
+	}
+
+	resp := loadedBlocksResponse{
+		Tenant: tenantID,
+		Blocks: make([]loadedBlock, 0, len(blocks)),
+	}
+	for _, b := range blocks {
+		resp.Blocks = append(resp.Blocks, newLoadedBlock(b))
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
+
+// parseTimeParam returns the time of the request parameter, in milliseconds, or the default if it's not given.
+func parseTimeParam(req *http.Request, name string, defaultValue int64) (int64, error) {
+	value := req.Form.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	t, err := util.ParseTime(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter: %w", name, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
+	"github.com/grafana/mimir/pkg/util/listblocks"
+)
+
+func TestStoreGateway_LoadedHandlers(t *testing.T) {
+	ctx := context.Background()
+	gatewayCfg := mockGatewayConfig()
+	storageCfg := mockStorageConfig(t)
+	storageCfg.BucketStore.IndexHeaderLazyLoadingEnabled = true
+	storageCfg.BucketStore.IndexHeaderLazyLoadingIdleTimeout = time.Hour
+	storageCfg.BucketStore.SyncInterval = time.Hour // Do not trigger the periodic sync in this test.
+
+	now := time.Now()
+	storageDir := t.TempDir()
+	mockTSDB(t, filepath.Join(storageDir, "user-1"), 6, 3, now.Add(-6*time.Hour).UnixMilli(), now.UnixMilli())
+
+	bucketClient, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	g, err := newStoreGateway(gatewayCfg, storageCfg, bucketClient, ringStore, defaultLimitsOverrides(t), log.NewNopLogger(), prometheus.NewPedanticRegistry(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, g))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(ctx, g)) })
+
+	store := g.stores.getStore("user-1")
+	blocks := store.loadedBlocks()
+	require.Len(t, blocks, 3)
+
+	var expectedSize uint64
+	for _, b := range blocks {
+		expectedSize += listblocks.GetBlockSizeBytes(b.meta)
+	}
+
+	// Load the index-header of a block.
+	require.NoError(t, store.warmUpBlock(ctx, blocks[0].meta.ULID, false))
+
+	router := mux.NewRouter()
+	router.Path("/store-gateway/loaded_tenants").HandlerFunc(g.LoadedTenantsHandler)
+	router.Path("/store-gateway/tenant/{tenant}/loaded_blocks").HandlerFunc(g.LoadedBlocksHandler)
+
+	call := func(url string, resp interface{}) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code == http.StatusOK {
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), resp))
+		}
+		return rec.Code
+	}
+
+	t.Run("loaded tenants", func(t *testing.T) {
+		var tenants []loadedTenant
+		require.Equal(t, http.StatusOK, call("/store-gateway/loaded_tenants", &tenants))
+		assert.Equal(t, []loadedTenant{{Tenant: "user-1", Blocks: 3, SizeBytes: expectedSize, IndexHeadersLoaded: 1}}, tenants)
+	})
+
+	t.Run("loaded blocks", func(t *testing.T) {
+		var resp loadedBlocksResponse
+		require.Equal(t, http.StatusOK, call("/store-gateway/tenant/user-1/loaded_blocks", &resp))
+		assert.Equal(t, "user-1", resp.Tenant)
+		require.Len(t, resp.Blocks, 3)
+
+		for i, b := range blocks {
+			assert.Equal(t, b.meta.ULID.String(), resp.Blocks[i].ID)
+			assert.Equal(t, b.meta.MinTime, resp.Blocks[i].MinTime.UnixMilli())
+			assert.Equal(t, b.meta.MaxTime, resp.Blocks[i].MaxTime.UnixMilli())
+			assert.True(t, resp.Blocks[i].IndexHeaderLazy)
+			assert.Equal(t, i == 0, resp.Blocks[i].IndexHeaderLoaded)
+		}
+	})
+
+	t.Run("loaded blocks touched by a time range", func(t *testing.T) {
+		// Blocks are half-open intervals, so the range ending right before the end of the latest block
+		// doesn't touch the block before it.
+		latest := slices.Clone(blocks)
+		slices.SortFunc(latest, func(a, b *bucketBlock) bool { return a.meta.MinTime > b.meta.MinTime })
+		start := time.UnixMilli(latest[0].meta.MinTime).UTC().Format(time.RFC3339Nano)
+		end := time.UnixMilli(latest[0].meta.MaxTime - 1).UTC().Format(time.RFC3339Nano)
+
+		var resp loadedBlocksResponse
+		require.Equal(t, http.StatusOK, call("/store-gateway/tenant/user-1/loaded_blocks?start="+start+"&end="+end, &resp))
+		require.Len(t, resp.Blocks, 1)
+		assert.Equal(t, latest[0].meta.ULID.String(), resp.Blocks[0].ID)
+
+		// Only the start of the range is given.
+		resp = loadedBlocksResponse{}
+		require.Equal(t, http.StatusOK, call("/store-gateway/tenant/user-1/loaded_blocks?start="+start, &resp))
+		require.Len(t, resp.Blocks, 1)
+		assert.Equal(t, latest[0].meta.ULID.String(), resp.Blocks[0].ID)
+	})
+
+	t.Run("invalid time range", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, call("/store-gateway/tenant/user-1/loaded_blocks?start=invalid", nil))
+	})
+
+	t.Run("tenant not loaded", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, call("/store-gateway/tenant/user-2/loaded_blocks", nil))
+	})
+}
@@ -59,6 +59,11 @@ var (
 		// store-gateway keeps their previously owned blocks until new owners are ACTIVE).
 		return s != ring.ACTIVE
 	})
+
+	// blocksOwnerAnyState is the operation used to get the instances owning a block in the ring
+	// order, regardless of their state. The state of the instances is checked afterwards, when
+	// the block is replicated to fewer instances than the ring replication factor.
+	blocksOwnerAnyState = ring.NewOp([]ring.InstanceState{ring.PENDING, ring.JOINING, ring.ACTIVE, ring.LEAVING}, nil)
 )
 
 // RingConfig masks the ring lifecycler config which contains
@@ -17,10 +17,18 @@ var tenantsPageHTML string
 var tenantsTemplate = template.Must(template.New("webpage").Parse(tenantsPageHTML))
 
 type tenantsPageContents struct {
-	Now     time.Time `json:"now"`
-	Tenants []string  `json:"tenants,omitempty"`
+	Now     time.Time      `json:"now"`
+	Tenants []bucketTenant `json:"tenants,omitempty"`
 }
 
+type bucketTenant struct {
+	Tenant            string `json:"tenant"`
+	ShardSize         int    `json:"shard_size"`
+	ReplicationFactor int    `json:"replication_factor"`
+}
+
+// TenantsHandler lists the tenants with blocks in the bucket, along with their store-gateway shard size and
+// effective replication factor.
 func (s *StoreGateway) TenantsHandler(w http.ResponseWriter, req *http.Request) {
 	tenantIDs, err := s.stores.scanUsers(req.Context())
 	if err != nil {
@@ -28,8 +36,17 @@ func (s *StoreGateway) TenantsHandler(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	tenants := make([]bucketTenant, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		tenants = append(tenants, bucketTenant{
+			Tenant:            tenantID,
+			ShardSize:         s.limits.StoreGatewayTenantShardSize(tenantID),
+			ReplicationFactor: GetTenantReplicationFactor(GetShuffleShardingSubring(s.ring, tenantID, s.limits), tenantID, s.limits),
+		})
+	}
+
 	util.RenderHTTPResponse(w, tenantsPageContents{
 		Now:     time.Now(),
-		Tenants: tenantIDs,
+		Tenants: tenants,
 	}, tenantsTemplate, req)
 }
@@ -52,6 +52,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/test"
@@ -73,12 +74,24 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expected: errInvalidTenantShardSize,
 		},
+		"should fail if replication factor is negative": {
+			setup: func(cfg *Config, limits *validation.Limits) {
+				limits.StoreGatewayTenantReplicationFactor = -1
+			},
+			expected: errInvalidTenantReplicationFactor,
+		},
 		"should pass if shard size has been set": {
 			setup: func(cfg *Config, limits *validation.Limits) {
 				limits.StoreGatewayTenantShardSize = 3
 			},
 			expected: nil,
 		},
+		"should fail if cache invalidation is enabled without the redis endpoint": {
+			setup: func(cfg *Config, limits *validation.Limits) {
+				cfg.CacheInvalidation.Enabled = true
+			},
+			expected: errMissingCacheInvalidationEndpoint,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -755,6 +768,72 @@ func TestStoreGateway_SyncOnRingTopologyChanged(t *testing.T) {
 	}
 }
 
+func TestStoreGateway_SyncTenants(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	ctx := context.Background()
+	gatewayCfg := mockGatewayConfig()
+	storageCfg := mockStorageConfig(t)
+	storageCfg.BucketStore.SyncInterval = time.Hour // Do not trigger the periodic sync in this test.
+
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	bucketClient, err := filesystem.NewBucketClient(filesystem.Config{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	g, err := newStoreGateway(gatewayCfg, storageCfg, bucketClient, ringStore, defaultLimitsOverrides(t), log.NewNopLogger(), nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, g))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(ctx, g)) })
+
+	// An empty notification doesn't trigger any sync.
+	_, err = g.SyncTenants(ctx, &storegatewaypb.SyncTenantsRequest{})
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, float64(0), testutil.ToFloat64(g.bucketSync.WithLabelValues(syncReasonTenants)))
+
+	_, err = g.SyncTenants(ctx, &storegatewaypb.SyncTenantsRequest{TenantIds: []string{"user-1", "user-2"}})
+	require.NoError(t, err)
+
+	dstest.Poll(t, time.Second, float64(1), func() interface{} {
+		return testutil.ToFloat64(g.bucketSync.WithLabelValues(syncReasonTenants))
+	})
+
+	g.tenantsToSyncMx.Lock()
+	assert.Empty(t, g.tenantsToSync)
+	g.tenantsToSyncMx.Unlock()
+}
+
+func TestStoreGateway_InvalidateBlocksShouldSyncTheTenant(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	ctx := context.Background()
+	gatewayCfg := mockGatewayConfig()
+	storageCfg := mockStorageConfig(t)
+	storageCfg.BucketStore.SyncInterval = time.Hour // Do not trigger the periodic sync in this test.
+
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	bucketClient, err := filesystem.NewBucketClient(filesystem.Config{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	g, err := newStoreGateway(gatewayCfg, storageCfg, bucketClient, ringStore, defaultLimitsOverrides(t), log.NewNopLogger(), nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, g))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(ctx, g)) })
+
+	// The tenant has no blocks loaded, so there's nothing to purge.
+	assert.Equal(t, 0, g.invalidateBlocks("user-1", []ulid.ULID{ulid.MustNew(1, nil)}))
+
+	dstest.Poll(t, time.Second, float64(1), func() interface{} {
+		return testutil.ToFloat64(g.bucketSync.WithLabelValues(syncReasonTenants))
+	})
+}
+
 func TestStoreGateway_SyncShouldKeepPreviousBlocksIfInstanceIsUnhealthyInTheRing(t *testing.T) {
 	test.VerifyNoLeak(t)
 
@@ -1663,8 +1742,8 @@ func (m *mockShardingStrategy) FilterUsers(ctx context.Context, userIDs []string
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *mockShardingStrategy) FilterBlocks(ctx context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error {
-	args := m.Called(ctx, userID, metas, loaded, synced)
+func (m *mockShardingStrategy) FilterBlocks(ctx context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, blocksSize int64, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error {
+	args := m.Called(ctx, userID, metas, blocksSize, loaded, synced)
 	return args.Error(0)
 }
 
@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+)
+
+const maxWarmUpErrors = 10
+
+var (
+	errBlockNotLoaded  = errors.New("the block is not loaded by this store-gateway")
+	errTenantNotLoaded = errors.New("the tenant is not loaded by this store-gateway")
+)
+
+// warmUps keeps track of the warm-ups of the blocks of each tenant requested via the HTTP API. At most one
+// warm-up runs for each tenant, and the progress of the last one of each tenant is kept until the next one.
+type warmUps struct {
+	logger log.Logger
+
+	// ctx is canceled when the store-gateway stops, stopping the running warm-ups.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mtx     sync.Mutex
+	tenants map[string]*warmUp
+}
+
+func newWarmUps(logger log.Logger) *warmUps {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &warmUps{
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		tenants: map[string]*warmUp{},
+	}
+}
+
+// warmUp is the progress of the warm-up of the blocks of a tenant.
+type warmUp struct {
+	mtx sync.Mutex
+
+	Tenant       string     `json:"tenant"`
+	HotPostings  bool       `json:"hot_postings"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	TotalBlocks  int        `json:"total_blocks"`
+	WarmedBlocks int        `json:"warmed_blocks"`
+	FailedBlocks int        `json:"failed_blocks"`
+	Errors       []string   `json:"errors,omitempty"`
+}
+
+func (w *warmUp) finished() bool {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.FinishedAt != nil
+}
+
+func (w *warmUp) blockDone(id ulid.ULID, err error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if err == nil {
+		w.WarmedBlocks++
+		return
+	}
+
+	w.FailedBlocks++
+	if len(w.Errors) < maxWarmUpErrors {
+		w.Errors = append(w.Errors, fmt.Sprintf("block %s: %s", id, err))
+	}
+}
+
+func (w *warmUp) finish() {
+	now := time.Now()
+
+	w.mtx.Lock()
+	w.FinishedAt = &now
+	w.mtx.Unlock()
+}
+
+// snapshot returns a copy of the progress, safe to be read while the warm-up runs.
+func (w *warmUp) snapshot() *warmUp {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	return &warmUp{
+		Tenant:       w.Tenant,
+		HotPostings:  w.HotPostings,
+		StartedAt:    w.StartedAt,
+		FinishedAt:   w.FinishedAt,
+		TotalBlocks:  w.TotalBlocks,
+		WarmedBlocks: w.WarmedBlocks,
+		FailedBlocks: w.FailedBlocks,
+		Errors:       append([]string(nil), w.Errors...),
+	}
+}
+
+// start starts the warm-up of the input blocks of the tenant's store, or of all its loaded blocks if none is
+// given. Returns false if a warm-up of the tenant is already running.
+func (w *warmUps) start(store *BucketStore, userID string, blockIDs []ulid.ULID, hotPostings bool) (*warmUp, bool) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if running, ok := w.tenants[userID]; ok && !running.finished() {
+		return running, false
+	}
+
+	if len(blockIDs) == 0 {
+		blockIDs = store.loadedBlockIDs()
+	}
+
+	progress := &warmUp{
+		Tenant:      userID,
+		HotPostings: hotPostings,
+		StartedAt:   time.Now(),
+		TotalBlocks: len(blockIDs),
+	}
+	w.tenants[userID] = progress
+
+	go func() {
+		defer progress.finish()
+
+		level.Info(w.logger).Log("msg", "warming up blocks", "user", userID, "blocks", len(blockIDs), "hot_postings", hotPostings)
+		for _, id := range blockIDs {
+			if w.ctx.Err() != nil {
+				progress.blockDone(id, w.ctx.Err())
+				continue
+			}
+			progress.blockDone(id, store.warmUpBlock(w.ctx, id, hotPostings))
+		}
+
+		p := progress.snapshot()
+		level.Info(w.logger).Log("msg", "warmed up blocks", "user", userID, "warmed", p.WarmedBlocks, "failed", p.FailedBlocks, "elapsed", time.Since(p.StartedAt))
+	}()
+
+	return progress, true
+}
+
+// get returns the progress of the last warm-up of the tenant, or nil if none.
+func (w *warmUps) get(userID string) *warmUp {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.tenants[userID]
+}
+
+// stop stops the running warm-ups.
+func (w *warmUps) stop() {
+	w.cancel()
+}
+
+// WarmUpHandler starts the warm-up of the blocks of a tenant on POST requests, and returns the progress of the last
+// warm-up of the tenant on GET requests. The blocks to warm up are given by the "block" parameters, or all the
+// blocks of the tenant loaded by the store-gateway are warmed up if none is given. The postings of the hot
+// selectors of the tenant are expanded for the blocks too if the "hot_postings" parameter is true.
+func (g *StoreGateway) WarmUpHandler(w http.ResponseWriter, req *http.Request) {
+	tenantID := mux.Vars(req)["tenant"]
+	if tenantID == "" {
+		http.Error(w, "Tenant ID can't be empty", http.StatusBadRequest)
+		return
+	}
+
+	if req.Method == http.MethodGet {
+		progress := g.warmUps.get(tenantID)
+		if progress == nil {
+			http.Error(w, "No warm-up has been run for the tenant", http.StatusNotFound)
+			return
+		}
+		writeWarmUpResponse(w, http.StatusOK, progress)
+		return
+	}
+
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("Can't parse form: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var blockIDs []ulid.ULID
+	for _, b := range req.Form["block"] {
+		id, err := ulid.Parse(b)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid block ID %q: %s", b, err), http.StatusBadRequest)
+			return
+		}
+		blockIDs = append(blockIDs, id)
+	}
+
+	hotPostings := false
+	if hp := req.Form.Get("hot_postings"); hp != "" {
+		var err error
+		if hotPostings, err = strconv.ParseBool(hp); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid hot_postings parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	store := g.stores.getStore(tenantID)
+	if store == nil {
+		http.Error(w, errTenantNotLoaded.Error(), http.StatusNotFound)
+		return
+	}
+
+	progress, started := g.warmUps.start(store, tenantID, blockIDs, hotPostings)
+	if !started {
+		writeWarmUpResponse(w, http.StatusConflict, progress)
+		return
+	}
+	writeWarmUpResponse(w, http.StatusAccepted, progress)
+}
+
+func writeWarmUpResponse(w http.ResponseWriter, statusCode int, progress *warmUp) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(progress.snapshot())
+}
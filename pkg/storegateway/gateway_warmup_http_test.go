@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket/filesystem"
+)
+
+func TestStoreGateway_WarmUpHandler(t *testing.T) {
+	ctx := context.Background()
+	gatewayCfg := mockGatewayConfig()
+	storageCfg := mockStorageConfig(t)
+	storageCfg.BucketStore.IndexHeaderLazyLoadingEnabled = true
+	storageCfg.BucketStore.IndexHeaderLazyLoadingIdleTimeout = time.Hour
+	storageCfg.BucketStore.SyncInterval = time.Hour // Do not trigger the periodic sync in this test.
+
+	now := time.Now()
+	storageDir := t.TempDir()
+	mockTSDB(t, filepath.Join(storageDir, "user-1"), 6, 3, now.Add(-6*time.Hour).UnixMilli(), now.UnixMilli())
+
+	bucketClient, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	reg := prometheus.NewPedanticRegistry()
+	g, err := newStoreGateway(gatewayCfg, storageCfg, bucketClient, ringStore, defaultLimitsOverrides(t), log.NewNopLogger(), reg, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, services.StartAndAwaitRunning(ctx, g))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(ctx, g)) })
+
+	blockIDs := g.stores.getStore("user-1").loadedBlockIDs()
+	require.Len(t, blockIDs, 3)
+
+	router := mux.NewRouter()
+	router.Path("/store-gateway/tenant/{tenant}/warmup").HandlerFunc(g.WarmUpHandler)
+
+	call := func(method, url string) (int, *warmUp) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+
+		progress := &warmUp{}
+		if rec.Code != http.StatusOK && rec.Code != http.StatusAccepted && rec.Code != http.StatusConflict {
+			return rec.Code, progress
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), progress))
+		return rec.Code, progress
+	}
+	waitFinished := func(tenant string) *warmUp {
+		var progress *warmUp
+		require.Eventually(t, func() bool {
+			_, progress = call(http.MethodGet, "/store-gateway/tenant/"+tenant+"/warmup")
+			return progress.FinishedAt != nil
+		}, 5*time.Second, 10*time.Millisecond)
+		return progress
+	}
+	lazyLoads := func() float64 {
+		metrics, err := reg.Gather()
+		require.NoError(t, err)
+		for _, m := range metrics {
+			if m.GetName() == "cortex_bucket_store_indexheader_lazy_load_total" {
+				return m.GetMetric()[0].GetCounter().GetValue()
+			}
+		}
+		return 0
+	}
+
+	// No warm-up has been run yet.
+	code, _ := call(http.MethodGet, "/store-gateway/tenant/user-1/warmup")
+	assert.Equal(t, http.StatusNotFound, code)
+	require.Equal(t, float64(0), lazyLoads())
+
+	// The tenant must be loaded by the store-gateway.
+	code, _ = call(http.MethodPost, "/store-gateway/tenant/user-2/warmup")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// Invalid parameters are rejected.
+	code, _ = call(http.MethodPost, "/store-gateway/tenant/user-1/warmup?block=invalid")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = call(http.MethodPost, "/store-gateway/tenant/user-1/warmup?hot_postings=invalid")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Warm up a single block, along with an unknown one.
+	code, progress := call(http.MethodPost, "/store-gateway/tenant/user-1/warmup?block="+blockIDs[0].String()+"&block=01GZZZZZZZZZZZZZZZZZZZZZZZ&hot_postings=true")
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "user-1", progress.Tenant)
+	assert.True(t, progress.HotPostings)
+	assert.Equal(t, 2, progress.TotalBlocks)
+
+	progress = waitFinished("user-1")
+	assert.Equal(t, 1, progress.WarmedBlocks)
+	assert.Equal(t, 1, progress.FailedBlocks)
+	require.Len(t, progress.Errors, 1)
+	assert.True(t, strings.HasSuffix(progress.Errors[0], errBlockNotLoaded.Error()))
+	assert.Equal(t, float64(1), lazyLoads())
+
+	// Warm up all the blocks of the tenant. The already loaded index-header isn't loaded again.
+	code, progress = call(http.MethodPost, "/store-gateway/tenant/user-1/warmup")
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, 3, progress.TotalBlocks)
+
+	progress = waitFinished("user-1")
+	assert.Equal(t, 3, progress.WarmedBlocks)
+	assert.Equal(t, 0, progress.FailedBlocks)
+	assert.Equal(t, float64(3), lazyLoads())
+}
+
+func TestWarmUps_ShouldRunAtMostOneWarmUpPerTenant(t *testing.T) {
+	w := newWarmUps(log.NewNopLogger())
+
+	// A running warm-up.
+	w.tenants["user-1"] = &warmUp{Tenant: "user-1"}
+
+	running, started := w.start(nil, "user-1", nil, false)
+	assert.False(t, started)
+	assert.Same(t, w.tenants["user-1"], running)
+
+	// Once finished, a new warm-up can be started.
+	running.finish()
+	store := &BucketStore{}
+	_, started = w.start(store, "user-1", nil, false)
+	assert.True(t, started)
+	w.stop()
+}
+
+func TestWarmUp_ShouldLimitTheReportedErrors(t *testing.T) {
+	progress := &warmUp{}
+	for i := 0; i < maxWarmUpErrors+5; i++ {
+		progress.blockDone([16]byte{byte(i)}, errBlockNotLoaded)
+	}
+
+	snapshot := progress.snapshot()
+	assert.Equal(t, maxWarmUpErrors+5, snapshot.FailedBlocks)
+	assert.Len(t, snapshot.Errors, maxWarmUpErrors)
+}
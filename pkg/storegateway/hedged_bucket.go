@@ -0,0 +1,286 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+const (
+	// hedgedLatencyWindowSize is the number of the most recent latencies the hedging delay is computed from.
+	hedgedLatencyWindowSize = 1000
+
+	// hedgedLatencyUpdateInterval is the number of observed latencies after which the hedging delay is updated.
+	// No request is hedged until this number of latencies has been observed.
+	hedgedLatencyUpdateInterval = 100
+
+	opGet      = "get"
+	opGetRange = "get_range"
+)
+
+// latencyTracker tracks the latencies of the most recent requests and computes a percentile of them.
+type latencyTracker struct {
+	percentile float64
+
+	mtx         sync.Mutex
+	window      []time.Duration
+	next        int
+	sinceUpdate int
+
+	// value is the last computed percentile, 0 if not computed yet.
+	value atomic.Duration
+}
+
+func newLatencyTracker(percentile float64) *latencyTracker {
+	return &latencyTracker{
+		percentile: percentile,
+		window:     make([]time.Duration, 0, hedgedLatencyWindowSize),
+	}
+}
+
+func (t *latencyTracker) observe(d time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if len(t.window) < hedgedLatencyWindowSize {
+		t.window = append(t.window, d)
+	} else {
+		t.window[t.next] = d
+		t.next = (t.next + 1) % hedgedLatencyWindowSize
+	}
+
+	t.sinceUpdate++
+	if t.sinceUpdate < hedgedLatencyUpdateInterval {
+		return
+	}
+	t.sinceUpdate = 0
+
+	sorted := make([]time.Duration, len(t.window))
+	copy(sorted, t.window)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(t.percentile/100*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	t.value.Store(sorted[idx])
+}
+
+// get returns the last computed percentile, or 0 if not enough latencies have been observed yet.
+func (t *latencyTracker) get() time.Duration {
+	return t.value.Load()
+}
+
+type hedgedBucketMetrics struct {
+	hedged          *prometheus.CounterVec
+	hedgedWon       *prometheus.CounterVec
+	budgetExhausted *prometheus.CounterVec
+	delay           *prometheus.GaugeVec
+}
+
+func newHedgedBucketMetrics(reg prometheus.Registerer) *hedgedBucketMetrics {
+	return &hedgedBucketMetrics{
+		hedged: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_hedged_requests_total",
+			Help: "Total number of hedged requests sent to the long-term storage, by operation.",
+		}, []string{"operation"}),
+		hedgedWon: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_hedged_requests_won_total",
+			Help: "Total number of hedged requests to the long-term storage which completed before the request they hedged, by operation.",
+		}, []string{"operation"}),
+		budgetExhausted: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_hedged_requests_budget_exhausted_total",
+			Help: "Total number of requests to the long-term storage which haven't been hedged because the max hedged requests per second has been reached, by operation.",
+		}, []string{"operation"}),
+		delay: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_store_hedged_requests_delay_seconds",
+			Help: "Current time after which a request to the long-term storage is hedged, by operation. 0 if not enough latencies have been observed yet.",
+		}, []string{"operation"}),
+	}
+}
+
+// hedgedBucket is an objstore.Bucket which hedges the GET object requests triggered by queries: if the first
+// request doesn't complete within a percentile of the recent latencies, a second one is sent, and the response
+// of whichever completes first is used. The number of hedged requests per second is limited.
+type hedgedBucket struct {
+	objstore.Bucket
+
+	cfg     tsdb.HedgedRequestsConfig
+	budget  *rate.Limiter
+	metrics *hedgedBucketMetrics
+
+	// Latencies of the first requests, by operation.
+	latencies map[string]*latencyTracker
+}
+
+func newHedgedBucket(bkt objstore.Bucket, cfg tsdb.HedgedRequestsConfig, reg prometheus.Registerer) *hedgedBucket {
+	return newHedgedBucketWithMetrics(bkt, cfg, rate.NewLimiter(rate.Limit(cfg.MaxPerSecond), int(math.Max(1, cfg.MaxPerSecond))), newHedgedBucketMetrics(reg))
+}
+
+func newHedgedBucketWithMetrics(bkt objstore.Bucket, cfg tsdb.HedgedRequestsConfig, budget *rate.Limiter, metrics *hedgedBucketMetrics) *hedgedBucket {
+	return &hedgedBucket{
+		Bucket:  bkt,
+		cfg:     cfg,
+		budget:  budget,
+		metrics: metrics,
+		latencies: map[string]*latencyTracker{
+			opGet:      newLatencyTracker(cfg.LatencyPercentile),
+			opGetRange: newLatencyTracker(cfg.LatencyPercentile),
+		},
+	}
+}
+
+// Get implements objstore.Bucket.
+func (b *hedgedBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.hedge(ctx, opGet, func(ctx context.Context) (io.ReadCloser, error) {
+		return b.Bucket.Get(ctx, name)
+	})
+}
+
+// GetRange implements objstore.Bucket.
+func (b *hedgedBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.hedge(ctx, opGetRange, func(ctx context.Context) (io.ReadCloser, error) {
+		return b.Bucket.GetRange(ctx, name, off, length)
+	})
+}
+
+// ReaderWithExpectedErrs implements objstore.InstrumentedBucketReader.
+func (b *hedgedBucket) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs implements objstore.InstrumentedBucket.
+func (b *hedgedBucket) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	if ib, ok := b.Bucket.(objstore.InstrumentedBucket); ok {
+		return &hedgedBucket{
+			Bucket:    ib.WithExpectedErrs(fn),
+			cfg:       b.cfg,
+			budget:    b.budget,
+			metrics:   b.metrics,
+			latencies: b.latencies,
+		}
+	}
+	return b
+}
+
+type hedgedResult struct {
+	r       io.ReadCloser
+	err     error
+	hedged  bool
+	elapsed time.Duration
+	cancel  context.CancelFunc
+}
+
+func (b *hedgedBucket) hedge(ctx context.Context, op string, get func(context.Context) (io.ReadCloser, error)) (io.ReadCloser, error) {
+	// Only the downloads triggered by queries are hedged, since the background ones are not latency sensitive.
+	if downloadPriorityFromContext(ctx) != downloadPriorityQuery {
+		return get(ctx)
+	}
+
+	latencies := b.latencies[op]
+	delay := latencies.get()
+	if delay > 0 && delay < b.cfg.MinDelay {
+		delay = b.cfg.MinDelay
+	}
+	b.metrics.delay.WithLabelValues(op).Set(delay.Seconds())
+
+	// Each request has its own context, canceled once its response is closed or discarded.
+	results := make(chan hedgedResult, 2)
+	cancels := map[bool]context.CancelFunc{}
+	send := func(hedged bool) {
+		reqCtx, cancel := context.WithCancel(ctx)
+		cancels[hedged] = cancel
+		go func() {
+			start := time.Now()
+			r, err := get(reqCtx)
+			results <- hedgedResult{r: r, err: err, hedged: hedged, elapsed: time.Since(start), cancel: cancel}
+		}()
+	}
+	send(false)
+	inflight := 1
+
+	var timer <-chan time.Time
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var firstErr error
+	for inflight > 0 {
+		select {
+		case res := <-results:
+			inflight--
+			if !res.hedged {
+				latencies.observe(res.elapsed)
+			}
+
+			if res.err == nil {
+				if inflight > 0 {
+					// The other request is canceled, and its response discarded.
+					cancels[!res.hedged]()
+					go b.discard(results, latencies)
+				}
+				if res.hedged {
+					b.metrics.hedgedWon.WithLabelValues(op).Inc()
+				}
+				return &hedgedReader{ReadCloser: res.r, cancel: res.cancel}, nil
+			}
+
+			res.cancel()
+			// The error of the first request is returned if both fail.
+			if !res.hedged || firstErr == nil {
+				firstErr = res.err
+			}
+			// The request is not hedged anymore once the first one failed.
+			timer = nil
+
+		case <-timer:
+			timer = nil
+			if !b.budget.Allow() {
+				b.metrics.budgetExhausted.WithLabelValues(op).Inc()
+				continue
+			}
+			b.metrics.hedged.WithLabelValues(op).Inc()
+			send(true)
+			inflight++
+		}
+	}
+	return nil, firstErr
+}
+
+// discard waits for the response of the request which completed last, or has been canceled, and discards it.
+func (b *hedgedBucket) discard(results <-chan hedgedResult, latencies *latencyTracker) {
+	res := <-results
+	if !res.hedged {
+		latencies.observe(res.elapsed)
+	}
+	if res.err == nil {
+		_ = res.r.Close()
+	}
+	res.cancel()
+}
+
+// hedgedReader cancels the context of its request once closed.
+type hedgedReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *hedgedReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	tracker := newLatencyTracker(90)
+
+	// The percentile is not computed until enough latencies have been observed.
+	for i := 1; i < hedgedLatencyUpdateInterval; i++ {
+		tracker.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, time.Duration(0), tracker.get())
+
+	tracker.observe(hedgedLatencyUpdateInterval * time.Millisecond)
+	assert.Equal(t, 90*time.Millisecond, tracker.get())
+
+	// The oldest latencies are discarded once out of the window.
+	for i := 0; i < hedgedLatencyWindowSize; i++ {
+		tracker.observe(time.Second)
+	}
+	assert.Equal(t, time.Second, tracker.get())
+}
+
+// slowFirstRequestBucket is an objstore.Bucket whose first GetRange request completes only once its context is
+// canceled, or fails with firstErr if set.
+type slowFirstRequestBucket struct {
+	objstore.Bucket
+	firstErr error
+
+	mtx      sync.Mutex
+	requests int
+	canceled chan struct{}
+}
+
+func (b *slowFirstRequestBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	b.mtx.Lock()
+	b.requests++
+	first := b.requests == 1
+	b.mtx.Unlock()
+
+	if first {
+		if b.firstErr != nil {
+			return nil, b.firstErr
+		}
+		<-ctx.Done()
+		close(b.canceled)
+		return nil, ctx.Err()
+	}
+	return b.Bucket.GetRange(ctx, name, off, length)
+}
+
+func (b *slowFirstRequestBucket) numRequests() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.requests
+}
+
+func TestHedgedBucket(t *testing.T) {
+	const name = "user-1/block/chunks/000001"
+	cfg := tsdb.HedgedRequestsConfig{Enabled: true, LatencyPercentile: 90, MinDelay: 10 * time.Millisecond, MaxPerSecond: 10}
+	queryCtx := contextWithDownloadPriority(context.Background(), downloadPriorityQuery)
+
+	setup := func(t *testing.T, budget *rate.Limiter, firstErr error) (*hedgedBucket, *slowFirstRequestBucket, *prometheus.Registry) {
+		inmem := objstore.NewInMemBucket()
+		require.NoError(t, inmem.Upload(context.Background(), name, strings.NewReader("0123456789")))
+
+		bkt := &slowFirstRequestBucket{Bucket: inmem, firstErr: firstErr, canceled: make(chan struct{})}
+		reg := prometheus.NewPedanticRegistry()
+		hedged := newHedgedBucketWithMetrics(bkt, cfg, budget, newHedgedBucketMetrics(reg))
+
+		// Observe enough latencies for the requests to be hedged.
+		for i := 0; i < hedgedLatencyUpdateInterval; i++ {
+			hedged.latencies[opGetRange].observe(time.Millisecond)
+		}
+		return hedged, bkt, reg
+	}
+
+	readAll := func(t *testing.T, r io.ReadCloser) string {
+		var buf bytes.Buffer
+		_, err := io.Copy(&buf, r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		return buf.String()
+	}
+
+	t.Run("should hedge the slow request and use the response of the hedged one", func(t *testing.T) {
+		hedged, bkt, reg := setup(t, rate.NewLimiter(10, 10), nil)
+
+		r, err := hedged.GetRange(queryCtx, name, 2, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "234", readAll(t, r))
+		assert.Equal(t, 2, bkt.numRequests())
+
+		// The slow request is canceled.
+		select {
+		case <-bkt.canceled:
+		case <-time.After(time.Second):
+			t.Fatal("the slow request has not been canceled")
+		}
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_hedged_requests_total Total number of hedged requests sent to the long-term storage, by operation.
+			# TYPE cortex_bucket_store_hedged_requests_total counter
+			cortex_bucket_store_hedged_requests_total{operation="get_range"} 1
+			# HELP cortex_bucket_store_hedged_requests_won_total Total number of hedged requests to the long-term storage which completed before the request they hedged, by operation.
+			# TYPE cortex_bucket_store_hedged_requests_won_total counter
+			cortex_bucket_store_hedged_requests_won_total{operation="get_range"} 1
+		`), "cortex_bucket_store_hedged_requests_total", "cortex_bucket_store_hedged_requests_won_total", "cortex_bucket_store_hedged_requests_budget_exhausted_total"))
+	})
+
+	t.Run("should not hedge the request once the budget is exhausted", func(t *testing.T) {
+		hedged, bkt, reg := setup(t, rate.NewLimiter(0, 0), nil)
+
+		ctx, cancel := context.WithTimeout(queryCtx, 100*time.Millisecond)
+		defer cancel()
+
+		_, err := hedged.GetRange(ctx, name, 2, 3)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, bkt.numRequests())
+
+		assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_hedged_requests_budget_exhausted_total Total number of requests to the long-term storage which haven't been hedged because the max hedged requests per second has been reached, by operation.
+			# TYPE cortex_bucket_store_hedged_requests_budget_exhausted_total counter
+			cortex_bucket_store_hedged_requests_budget_exhausted_total{operation="get_range"} 1
+		`), "cortex_bucket_store_hedged_requests_total", "cortex_bucket_store_hedged_requests_won_total", "cortex_bucket_store_hedged_requests_budget_exhausted_total"))
+	})
+
+	t.Run("should not hedge the request whose first attempt failed", func(t *testing.T) {
+		firstErr := errors.New("failed")
+		hedged, bkt, _ := setup(t, rate.NewLimiter(10, 10), firstErr)
+
+		_, err := hedged.GetRange(queryCtx, name, 2, 3)
+		require.ErrorIs(t, err, firstErr)
+		assert.Equal(t, 1, bkt.numRequests())
+	})
+
+	t.Run("should not hedge the background downloads", func(t *testing.T) {
+		hedged, bkt, _ := setup(t, rate.NewLimiter(10, 10), nil)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := hedged.GetRange(ctx, name, 2, 3)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, bkt.numRequests())
+	})
+}
@@ -0,0 +1,238 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+)
+
+// hotSeriesSets tracks the selectors most frequently queried by a tenant, and keeps in memory the expanded
+// postings of each block for them, so that the queries of the hottest dashboard panels skip the postings
+// expansion entirely. A selector is hot once it has been queried at least minQueries times within the
+// current or the previous tracking period.
+type hotSeriesSets struct {
+	maxBytes       uint64
+	minQueries     int
+	trackingPeriod time.Duration
+	metrics        *BucketStoreMetrics
+	now            func() time.Time
+
+	mtx         sync.Mutex
+	periodStart time.Time
+	queries     map[indexcache.LabelMatchersKey]int
+	hot         map[indexcache.LabelMatchersKey][]*labels.Matcher
+	bytes       uint64
+	entries     map[hotSeriesSetKey]*hotSeriesSet
+}
+
+type hotSeriesSetKey struct {
+	blockID  ulid.ULID
+	matchers indexcache.LabelMatchersKey
+	strategy string
+}
+
+type hotSeriesSet struct {
+	refs            []storage.SeriesRef
+	pendingMatchers []*labels.Matcher
+	size            uint64
+
+	// expansionDuration is the time it took to expand the postings, which is saved by each hit.
+	expansionDuration time.Duration
+}
+
+// newHotSeriesSets returns the hot series sets of a tenant, or nil if maxBytes is 0. All methods can be
+// called on a nil *hotSeriesSets.
+func newHotSeriesSets(maxBytes uint64, minQueries int, trackingPeriod time.Duration, metrics *BucketStoreMetrics) *hotSeriesSets {
+	if maxBytes == 0 {
+		return nil
+	}
+
+	return &hotSeriesSets{
+		maxBytes:       maxBytes,
+		minQueries:     minQueries,
+		trackingPeriod: trackingPeriod,
+		metrics:        metrics,
+		now:            time.Now,
+		periodStart:    time.Now(),
+		queries:        map[indexcache.LabelMatchersKey]int{},
+		hot:            map[indexcache.LabelMatchersKey][]*labels.Matcher{},
+		entries:        map[hotSeriesSetKey]*hotSeriesSet{},
+	}
+}
+
+// recordQuery records a query of the input selector.
+func (h *hotSeriesSets) recordQuery(ms []*labels.Matcher) {
+	if h == nil {
+		return
+	}
+
+	key := indexcache.CanonicalLabelMatchersKey(ms)
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.maybeRotateLocked()
+
+	h.queries[key]++
+	if _, ok := h.hot[key]; !ok && h.queries[key] >= h.minQueries {
+		h.hot[key] = ms
+		h.metrics.hotSeriesSetsSelectors.Inc()
+	}
+}
+
+// maybeRotateLocked starts a new tracking period if the current one is over. The selectors which haven't been
+// queried enough in the period which is over aren't hot anymore, and their series sets are dropped.
+func (h *hotSeriesSets) maybeRotateLocked() {
+	now := h.now()
+	elapsed := now.Sub(h.periodStart)
+	if elapsed < h.trackingPeriod {
+		return
+	}
+
+	// If no query has been recorded for a whole period, no selector is hot anymore.
+	lastPeriodQueries := h.queries
+	if elapsed >= 2*h.trackingPeriod {
+		lastPeriodQueries = nil
+	}
+
+	for key := range h.hot {
+		if lastPeriodQueries[key] >= h.minQueries {
+			continue
+		}
+		delete(h.hot, key)
+		h.metrics.hotSeriesSetsSelectors.Dec()
+	}
+
+	for key, entry := range h.entries {
+		if _, ok := h.hot[key.matchers]; !ok {
+			h.deleteEntryLocked(key, entry)
+		}
+	}
+
+	h.queries = map[indexcache.LabelMatchersKey]int{}
+	h.periodStart = now
+}
+
+// hotSelectors returns the matchers of the hot selectors.
+func (h *hotSeriesSets) hotSelectors() [][]*labels.Matcher {
+	if h == nil {
+		return nil
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	selectors := make([][]*labels.Matcher, 0, len(h.hot))
+	for _, ms := range h.hot {
+		selectors = append(selectors, ms)
+	}
+	return selectors
+}
+
+// fetch returns the expanded postings of the input block and selector, if the selector is hot and they have
+// already been expanded. The returned refs must not be modified.
+func (h *hotSeriesSets) fetch(blockID ulid.ULID, key indexcache.LabelMatchersKey, strategy string) ([]storage.SeriesRef, []*labels.Matcher, bool) {
+	if h == nil {
+		return nil, nil, false
+	}
+
+	h.mtx.Lock()
+	entry, ok := h.entries[hotSeriesSetKey{blockID: blockID, matchers: key, strategy: strategy}]
+	h.mtx.Unlock()
+
+	if !ok {
+		return nil, nil, false
+	}
+
+	h.metrics.hotSeriesSetsHits.Inc()
+	h.metrics.hotSeriesSetsSavedSeconds.Add(entry.expansionDuration.Seconds())
+	return entry.refs, entry.pendingMatchers, true
+}
+
+// store keeps the expanded postings of the input block and selector, if the selector is hot and they fit in the
+// max size. The input refs must not be modified after the call.
+func (h *hotSeriesSets) store(blockID ulid.ULID, key indexcache.LabelMatchersKey, strategy string, refs []storage.SeriesRef, pendingMatchers []*labels.Matcher, expansionDuration time.Duration) {
+	if h == nil {
+		return
+	}
+
+	entryKey := hotSeriesSetKey{blockID: blockID, matchers: key, strategy: strategy}
+	entry := &hotSeriesSet{
+		refs:              refs,
+		pendingMatchers:   pendingMatchers,
+		size:              hotSeriesSetSize(key, refs, pendingMatchers),
+		expansionDuration: expansionDuration,
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if _, ok := h.hot[key]; !ok {
+		return
+	}
+	if _, ok := h.entries[entryKey]; ok {
+		return
+	}
+	if h.bytes+entry.size > h.maxBytes {
+		return
+	}
+
+	h.entries[entryKey] = entry
+	h.bytes += entry.size
+	h.metrics.hotSeriesSetsBytes.Add(float64(entry.size))
+}
+
+// dropBlock drops the series sets of the input block.
+func (h *hotSeriesSets) dropBlock(blockID ulid.ULID) {
+	if h == nil {
+		return
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	for key, entry := range h.entries {
+		if key.blockID == blockID {
+			h.deleteEntryLocked(key, entry)
+		}
+	}
+}
+
+// close drops all the series sets and stops tracking the hot selectors.
+func (h *hotSeriesSets) close() {
+	if h == nil {
+		return
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	for key, entry := range h.entries {
+		h.deleteEntryLocked(key, entry)
+	}
+	h.metrics.hotSeriesSetsSelectors.Sub(float64(len(h.hot)))
+	h.hot = map[indexcache.LabelMatchersKey][]*labels.Matcher{}
+	h.queries = map[indexcache.LabelMatchersKey]int{}
+}
+
+func (h *hotSeriesSets) deleteEntryLocked(key hotSeriesSetKey, entry *hotSeriesSet) {
+	delete(h.entries, key)
+	h.bytes -= entry.size
+	h.metrics.hotSeriesSetsBytes.Sub(float64(entry.size))
+}
+
+// hotSeriesSetSize returns the estimated size in bytes of a series set kept in memory.
+func hotSeriesSetSize(key indexcache.LabelMatchersKey, refs []storage.SeriesRef, pendingMatchers []*labels.Matcher) uint64 {
+	size := uint64(len(key)) + uint64(len(refs))*8
+	for _, m := range pendingMatchers {
+		size += uint64(len(m.Name) + len(m.Value))
+	}
+	return size
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+	"github.com/grafana/mimir/pkg/util/test"
+)
+
+func TestHotSeriesSets(t *testing.T) {
+	var (
+		hotMatchers  = []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "hot")}
+		coldMatchers = []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "cold")}
+		hotKey       = indexcache.CanonicalLabelMatchersKey(hotMatchers)
+		coldKey      = indexcache.CanonicalLabelMatchersKey(coldMatchers)
+		block1       = ulid.MustNew(1, nil)
+		block2       = ulid.MustNew(2, nil)
+		refs         = []storage.SeriesRef{1, 2, 3}
+		now          = time.Now()
+	)
+
+	metrics := NewBucketStoreMetrics(nil)
+	h := newHotSeriesSets(1024, 2, time.Minute, metrics)
+	h.now = func() time.Time { return now }
+	h.periodStart = now
+
+	// Selectors queried less than the min queries aren't hot.
+	h.recordQuery(hotMatchers)
+	h.recordQuery(coldMatchers)
+	h.store(block1, hotKey, "all", refs, nil, time.Second)
+	_, _, ok := h.fetch(block1, hotKey, "all")
+	assert.False(t, ok)
+
+	h.recordQuery(hotMatchers)
+	assert.Equal(t, [][]*labels.Matcher{hotMatchers}, h.hotSelectors())
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.hotSeriesSetsSelectors))
+
+	h.store(block1, hotKey, "all", refs, nil, time.Second)
+	h.store(block2, hotKey, "all", refs, nil, time.Second)
+	h.store(block1, coldKey, "all", refs, nil, time.Second)
+
+	actualRefs, _, ok := h.fetch(block1, hotKey, "all")
+	require.True(t, ok)
+	assert.Equal(t, refs, actualRefs)
+	_, _, ok = h.fetch(block1, hotKey, "other")
+	assert.False(t, ok)
+	_, _, ok = h.fetch(block1, coldKey, "all")
+	assert.False(t, ok)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.hotSeriesSetsHits))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.hotSeriesSetsSavedSeconds))
+	assert.Equal(t, float64(2*hotSeriesSetSize(hotKey, refs, nil)), testutil.ToFloat64(metrics.hotSeriesSetsBytes))
+
+	// The series sets of a dropped block are dropped.
+	h.dropBlock(block1)
+	_, _, ok = h.fetch(block1, hotKey, "all")
+	assert.False(t, ok)
+	_, _, ok = h.fetch(block2, hotKey, "all")
+	assert.True(t, ok)
+	assert.Equal(t, float64(hotSeriesSetSize(hotKey, refs, nil)), testutil.ToFloat64(metrics.hotSeriesSetsBytes))
+
+	// The selector is still hot in the next period, because it has been queried enough in the previous one.
+	now = now.Add(time.Minute)
+	h.recordQuery(coldMatchers)
+	_, _, ok = h.fetch(block2, hotKey, "all")
+	assert.True(t, ok)
+
+	// The selector isn't hot anymore once it hasn't been queried enough in the previous period.
+	now = now.Add(time.Minute)
+	h.recordQuery(coldMatchers)
+	_, _, ok = h.fetch(block2, hotKey, "all")
+	assert.False(t, ok)
+	assert.Empty(t, h.hotSelectors())
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.hotSeriesSetsSelectors))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.hotSeriesSetsBytes))
+
+	// The series sets not fitting in the max size aren't kept.
+	h.recordQuery(hotMatchers)
+	h.recordQuery(hotMatchers)
+	h.store(block1, hotKey, "all", make([]storage.SeriesRef, 1024), nil, time.Second)
+	_, _, ok = h.fetch(block1, hotKey, "all")
+	assert.False(t, ok)
+
+	h.store(block1, hotKey, "all", refs, nil, time.Second)
+	h.close()
+	_, _, ok = h.fetch(block1, hotKey, "all")
+	assert.False(t, ok)
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.hotSeriesSetsSelectors))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.hotSeriesSetsBytes))
+}
+
+func TestHotSeriesSets_Disabled(t *testing.T) {
+	h := newHotSeriesSets(0, 1, time.Minute, NewBucketStoreMetrics(nil))
+	require.Nil(t, h)
+
+	matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "__name__", "hot")}
+	h.recordQuery(matchers)
+	h.store(ulid.MustNew(1, nil), indexcache.CanonicalLabelMatchersKey(matchers), "all", []storage.SeriesRef{1}, nil, time.Second)
+	_, _, ok := h.fetch(ulid.MustNew(1, nil), indexcache.CanonicalLabelMatchersKey(matchers), "all")
+	assert.False(t, ok)
+	assert.Empty(t, h.hotSelectors())
+}
+
+func TestBucketIndexReader_ExpandedPostings_HotSeriesSets(t *testing.T) {
+	const series = 500
+
+	b := prepareTestBlockWithBinaryReader(test.NewTB(t), appendTestSeries(series))()
+	b.hotSeriesSets = newHotSeriesSets(1024*1024, 1, time.Hour, b.metrics)
+
+	matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "i", "^.+$")}
+	b.hotSeriesSets.recordQuery(matchers)
+
+	refs, _, err := b.indexReader().ExpandedPostings(context.Background(), matchers, newSafeQueryStats())
+	require.NoError(t, err)
+	require.Len(t, refs, series)
+
+	// The postings of the hot selector are no longer expanded from the index.
+	b.indexHeaderReader = &interceptedIndexReader{
+		Reader: b.indexHeaderReader,
+		onLabelValuesOffsetsCalled: func(string) error {
+			return errors.New("the postings have been expanded")
+		},
+	}
+
+	refs, _, err = b.indexReader().ExpandedPostings(context.Background(), matchers, newSafeQueryStats())
+	require.NoError(t, err)
+	require.Len(t, refs, series)
+	assert.Equal(t, float64(1), testutil.ToFloat64(b.metrics.hotSeriesSetsHits))
+
+	// The postings of the other selectors are still expanded from the index.
+	_, _, err = b.indexReader().ExpandedPostings(context.Background(), []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "n", "^0_.*$")}, newSafeQueryStats())
+	require.Error(t, err)
+}
@@ -42,6 +42,14 @@ var (
 	}
 )
 
+// BlockInvalidator is implemented by the index cache backends which can purge the entries of a block,
+// like the in-memory one and the disk tier. The entries of the remote caches can't be listed, so they expire
+// with their TTL.
+type BlockInvalidator interface {
+	// InvalidateBlocks removes all the cached entries of the blocks, and returns the number of removed entries.
+	InvalidateBlocks(userID string, blockIDs []ulid.ULID) int
+}
+
 // IndexCache is the interface exported by index cache backends.
 type IndexCache interface {
 	// StorePostings stores postings for a single series.
@@ -375,6 +375,35 @@ func (c *InMemoryIndexCache) FetchLabelValues(_ context.Context, userID string,
 	return c.get(cacheKeyLabelValues{userID, blockID, labelName, matchersKey})
 }
 
+// InvalidateBlocks removes all the cached entries of the blocks, and returns the number of removed entries.
+// The entries of all the blocks are removed with a single scan of the cache.
+func (c *InMemoryIndexCache) InvalidateBlocks(userID string, blockIDs []ulid.ULID) int {
+	if len(blockIDs) == 0 {
+		return 0
+	}
+
+	invalidated := make(map[ulid.ULID]struct{}, len(blockIDs))
+	for _, blockID := range blockIDs {
+		invalidated[blockID] = struct{}{}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	removed := 0
+	for _, key := range c.lru.Keys() {
+		keyUserID, keyBlockID := key.(cacheKey).blockRef()
+		if keyUserID != userID {
+			continue
+		}
+		if _, ok := invalidated[keyBlockID]; ok {
+			c.lru.Remove(key)
+			removed++
+		}
+	}
+	return removed
+}
+
 // cacheKey is used by in-memory representation to store cached data.
 // The implementations of cacheKey should be hashable, as they will be used as keys for *lru.LRU cache
 type cacheKey interface {
@@ -382,6 +411,8 @@ type cacheKey interface {
 	typ() string
 	// size is used to keep track of the cache size, it represents the footprint of the cache key in memory.
 	size() uint64
+	// blockRef returns the tenant and block the cache entry belongs to.
+	blockRef() (string, ulid.ULID)
 }
 
 // cacheKeyPostings implements cacheKey and is used to reference a postings cache entry in the inmemory cache.
@@ -391,6 +422,8 @@ type cacheKeyPostings struct {
 	label  labels.Label
 }
 
+func (c cacheKeyPostings) blockRef() (string, ulid.ULID) { return c.userID, c.block }
+
 func (c cacheKeyPostings) typ() string { return cacheTypePostings }
 
 func (c cacheKeyPostings) size() uint64 {
@@ -404,6 +437,8 @@ type cacheKeySeriesForRef struct {
 	ref    storage.SeriesRef
 }
 
+func (c cacheKeySeriesForRef) blockRef() (string, ulid.ULID) { return c.userID, c.block }
+
 func (c cacheKeySeriesForRef) typ() string { return cacheTypeSeriesForRef }
 
 func (c cacheKeySeriesForRef) size() uint64 {
@@ -418,6 +453,8 @@ type cacheKeyExpandedPostings struct {
 	postingsSelectionStrategy string
 }
 
+func (c cacheKeyExpandedPostings) blockRef() (string, ulid.ULID) { return c.userID, c.block }
+
 func (c cacheKeyExpandedPostings) typ() string { return cacheTypeExpandedPostings }
 
 func (c cacheKeyExpandedPostings) size() uint64 {
@@ -431,6 +468,8 @@ type cacheKeySeriesForPostings struct {
 	postingsKey PostingsKey
 }
 
+func (c cacheKeySeriesForPostings) blockRef() (string, ulid.ULID) { return c.userID, c.block }
+
 func (c cacheKeySeriesForPostings) typ() string {
 	return cacheTypeSeriesForPostings
 }
@@ -445,6 +484,8 @@ type cacheKeyLabelNames struct {
 	matchersKey LabelMatchersKey
 }
 
+func (c cacheKeyLabelNames) blockRef() (string, ulid.ULID) { return c.userID, c.block }
+
 func (c cacheKeyLabelNames) typ() string {
 	return cacheTypeLabelNames
 }
@@ -460,6 +501,8 @@ type cacheKeyLabelValues struct {
 	matchersKey LabelMatchersKey
 }
 
+func (c cacheKeyLabelValues) blockRef() (string, ulid.ULID) { return c.userID, c.block }
+
 func (c cacheKeyLabelValues) typ() string {
 	return cacheTypeLabelValues
 }
@@ -276,6 +276,57 @@ func TestInMemoryIndexCache_MaxNumberOfItemsHit(t *testing.T) {
 	}
 }
 
+func TestInMemoryIndexCache_InvalidateBlocks(t *testing.T) {
+	const user = "tenant"
+
+	ctx := context.Background()
+	cache, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), prometheus.NewRegistry(), DefaultInMemoryIndexCacheConfig)
+	assert.NoError(t, err)
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	lbl := labels.Label{Name: "test", Value: "123"}
+	matchersKey := LabelMatchersKey("matchers")
+
+	for _, blockID := range []ulid.ULID{block1, block2} {
+		cache.StorePostings(user, blockID, lbl, []byte{1})
+		cache.StoreSeriesForRef(user, blockID, 1, []byte{2})
+		cache.StoreExpandedPostings(user, blockID, matchersKey, "strategy", []byte{3})
+		cache.StoreSeriesForPostings(user, blockID, nil, PostingsKey("postings"), []byte{4})
+		cache.StoreLabelNames(user, blockID, matchersKey, []byte{5})
+		cache.StoreLabelValues(user, blockID, "name", matchersKey, []byte{6})
+	}
+
+	// The same block of another tenant is not invalidated.
+	cache.StorePostings("another", block1, lbl, []byte{1})
+
+	assert.Equal(t, 6, cache.InvalidateBlocks(user, []ulid.ULID{block1}))
+	assert.Equal(t, 0, cache.InvalidateBlocks(user, []ulid.ULID{block1}))
+
+	hits, _ := cache.FetchMultiPostings(ctx, user, block1, []labels.Label{lbl})
+	assert.Empty(t, hits)
+	_, ok := cache.FetchLabelNames(ctx, user, block1, matchersKey)
+	assert.False(t, ok)
+	_, ok = cache.FetchLabelValues(ctx, user, block1, "name", matchersKey)
+	assert.False(t, ok)
+
+	hits, _ = cache.FetchMultiPostings(ctx, user, block2, []labels.Label{lbl})
+	assert.Len(t, hits, 1)
+	_, ok = cache.FetchLabelNames(ctx, user, block2, matchersKey)
+	assert.True(t, ok)
+	hits, _ = cache.FetchMultiPostings(ctx, "another", block1, []labels.Label{lbl})
+	assert.Len(t, hits, 1)
+
+	assert.Equal(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypePostings)))
+	assert.Equal(t, float64(1), promtest.ToFloat64(cache.evicted.WithLabelValues(cacheTypeLabelNames)))
+
+	// The entries of multiple blocks are removed at once.
+	assert.Equal(t, 6, cache.InvalidateBlocks(user, []ulid.ULID{block1, block2}))
+	hits, _ = cache.FetchMultiPostings(ctx, user, block2, []labels.Label{lbl})
+	assert.Empty(t, hits)
+	assert.Equal(t, 0, cache.InvalidateBlocks(user, nil))
+}
+
 func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	user := "tenant"
 	metrics := prometheus.NewRegistry()
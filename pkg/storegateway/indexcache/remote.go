@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/base64"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log"
@@ -270,3 +271,34 @@ func labelValuesCacheKey(userID string, blockID ulid.ULID, labelName string, mat
 	hash := blake2b.Sum256([]byte(matchersKey))
 	return "LV:" + userID + ":" + blockID.String() + ":" + labelName + ":" + base64.RawURLEncoding.EncodeToString(hash[0:])
 }
+
+// MatchingKeysDeleter is implemented by the remote cache clients which can delete the items whose key matches,
+// like the disk cache tier.
+type MatchingKeysDeleter interface {
+	// DeleteMatching deletes the items whose key matches, and returns the number of deleted items.
+	DeleteMatching(match func(key string) bool) int
+}
+
+// InvalidateBlocks implements BlockInvalidator. The entries of the blocks are purged only if the remote cache
+// client is a MatchingKeysDeleter, otherwise they expire with their TTL.
+func (c *RemoteIndexCache) InvalidateBlocks(userID string, blockIDs []ulid.ULID) int {
+	deleter, ok := c.remote.(MatchingKeysDeleter)
+	if !ok {
+		return 0
+	}
+
+	// All the keys are formatted as "<type>:<user>:<block>:<key>".
+	prefixes := make([]string, 0, len(blockIDs))
+	for _, blockID := range blockIDs {
+		prefixes = append(prefixes, ":"+userID+":"+blockID.String()+":")
+	}
+
+	return deleter.DeleteMatching(func(key string) bool {
+		for _, prefix := range prefixes {
+			if strings.Contains(key, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexcache
+
+import (
+	"context"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/storage/sharding"
+)
+
+// TieredIndexCache is an IndexCache which fetches the items from the first cache tier, and then the
+// misses from the second one. The items found in the second tier are stored in the first one too.
+// The items are always stored in both tiers.
+type TieredIndexCache struct {
+	first  IndexCache
+	second IndexCache
+}
+
+// NewTieredIndexCache makes a new TieredIndexCache.
+func NewTieredIndexCache(first, second IndexCache) *TieredIndexCache {
+	return &TieredIndexCache{
+		first:  first,
+		second: second,
+	}
+}
+
+func (c *TieredIndexCache) StorePostings(userID string, blockID ulid.ULID, l labels.Label, v []byte) {
+	c.first.StorePostings(userID, blockID, l, v)
+	c.second.StorePostings(userID, blockID, l, v)
+}
+
+func (c *TieredIndexCache) FetchMultiPostings(ctx context.Context, userID string, blockID ulid.ULID, keys []labels.Label) (hits map[labels.Label][]byte, misses []labels.Label) {
+	hits, misses = c.first.FetchMultiPostings(ctx, userID, blockID, keys)
+	if len(misses) == 0 {
+		return hits, misses
+	}
+
+	secondHits, misses := c.second.FetchMultiPostings(ctx, userID, blockID, misses)
+	if len(secondHits) > 0 && hits == nil {
+		hits = make(map[labels.Label][]byte, len(secondHits))
+	}
+	for l, v := range secondHits {
+		c.first.StorePostings(userID, blockID, l, v)
+		hits[l] = v
+	}
+	return hits, misses
+}
+
+func (c *TieredIndexCache) StoreSeriesForRef(userID string, blockID ulid.ULID, id storage.SeriesRef, v []byte) {
+	c.first.StoreSeriesForRef(userID, blockID, id, v)
+	c.second.StoreSeriesForRef(userID, blockID, id, v)
+}
+
+func (c *TieredIndexCache) FetchMultiSeriesForRefs(ctx context.Context, userID string, blockID ulid.ULID, ids []storage.SeriesRef) (hits map[storage.SeriesRef][]byte, misses []storage.SeriesRef) {
+	hits, misses = c.first.FetchMultiSeriesForRefs(ctx, userID, blockID, ids)
+	if len(misses) == 0 {
+		return hits, misses
+	}
+
+	secondHits, misses := c.second.FetchMultiSeriesForRefs(ctx, userID, blockID, misses)
+	if len(secondHits) > 0 && hits == nil {
+		hits = make(map[storage.SeriesRef][]byte, len(secondHits))
+	}
+	for id, v := range secondHits {
+		c.first.StoreSeriesForRef(userID, blockID, id, v)
+		hits[id] = v
+	}
+	return hits, misses
+}
+
+func (c *TieredIndexCache) StoreExpandedPostings(userID string, blockID ulid.ULID, key LabelMatchersKey, postingsSelectionStrategy string, v []byte) {
+	c.first.StoreExpandedPostings(userID, blockID, key, postingsSelectionStrategy, v)
+	c.second.StoreExpandedPostings(userID, blockID, key, postingsSelectionStrategy, v)
+}
+
+func (c *TieredIndexCache) FetchExpandedPostings(ctx context.Context, userID string, blockID ulid.ULID, key LabelMatchersKey, postingsSelectionStrategy string) ([]byte, bool) {
+	if v, ok := c.first.FetchExpandedPostings(ctx, userID, blockID, key, postingsSelectionStrategy); ok {
+		return v, true
+	}
+
+	v, ok := c.second.FetchExpandedPostings(ctx, userID, blockID, key, postingsSelectionStrategy)
+	if ok {
+		c.first.StoreExpandedPostings(userID, blockID, key, postingsSelectionStrategy, v)
+	}
+	return v, ok
+}
+
+func (c *TieredIndexCache) StoreSeriesForPostings(userID string, blockID ulid.ULID, shard *sharding.ShardSelector, postingsKey PostingsKey, v []byte) {
+	c.first.StoreSeriesForPostings(userID, blockID, shard, postingsKey, v)
+	c.second.StoreSeriesForPostings(userID, blockID, shard, postingsKey, v)
+}
+
+func (c *TieredIndexCache) FetchSeriesForPostings(ctx context.Context, userID string, blockID ulid.ULID, shard *sharding.ShardSelector, postingsKey PostingsKey) ([]byte, bool) {
+	if v, ok := c.first.FetchSeriesForPostings(ctx, userID, blockID, shard, postingsKey); ok {
+		return v, true
+	}
+
+	v, ok := c.second.FetchSeriesForPostings(ctx, userID, blockID, shard, postingsKey)
+	if ok {
+		c.first.StoreSeriesForPostings(userID, blockID, shard, postingsKey, v)
+	}
+	return v, ok
+}
+
+func (c *TieredIndexCache) StoreLabelNames(userID string, blockID ulid.ULID, matchersKey LabelMatchersKey, v []byte) {
+	c.first.StoreLabelNames(userID, blockID, matchersKey, v)
+	c.second.StoreLabelNames(userID, blockID, matchersKey, v)
+}
+
+func (c *TieredIndexCache) FetchLabelNames(ctx context.Context, userID string, blockID ulid.ULID, matchersKey LabelMatchersKey) ([]byte, bool) {
+	if v, ok := c.first.FetchLabelNames(ctx, userID, blockID, matchersKey); ok {
+		return v, true
+	}
+
+	v, ok := c.second.FetchLabelNames(ctx, userID, blockID, matchersKey)
+	if ok {
+		c.first.StoreLabelNames(userID, blockID, matchersKey, v)
+	}
+	return v, ok
+}
+
+func (c *TieredIndexCache) StoreLabelValues(userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey, v []byte) {
+	c.first.StoreLabelValues(userID, blockID, labelName, matchersKey, v)
+	c.second.StoreLabelValues(userID, blockID, labelName, matchersKey, v)
+}
+
+func (c *TieredIndexCache) FetchLabelValues(ctx context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) ([]byte, bool) {
+	if v, ok := c.first.FetchLabelValues(ctx, userID, blockID, labelName, matchersKey); ok {
+		return v, true
+	}
+
+	v, ok := c.second.FetchLabelValues(ctx, userID, blockID, labelName, matchersKey)
+	if ok {
+		c.first.StoreLabelValues(userID, blockID, labelName, matchersKey, v)
+	}
+	return v, ok
+}
+
+// InvalidateBlocks implements BlockInvalidator, purging the entries of the blocks from the tiers which support it.
+func (c *TieredIndexCache) InvalidateBlocks(userID string, blockIDs []ulid.ULID) int {
+	purged := 0
+	for _, tier := range []IndexCache{c.first, c.second} {
+		if invalidator, ok := tier.(BlockInvalidator); ok {
+			purged += invalidator.InvalidateBlocks(userID, blockIDs)
+		}
+	}
+	return purged
+}
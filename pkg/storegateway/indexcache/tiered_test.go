@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package indexcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/test"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storegateway/diskcache"
+)
+
+func TestTieredIndexCache(t *testing.T) {
+	const user = "tenant"
+
+	ctx := context.Background()
+	blockID := ulid.MustNew(1, nil)
+	matchersKey := LabelMatchersKey("matchers")
+
+	first, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), nil, DefaultInMemoryIndexCacheConfig)
+	require.NoError(t, err)
+	second, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), nil, DefaultInMemoryIndexCacheConfig)
+	require.NoError(t, err)
+	c := NewTieredIndexCache(first, second)
+
+	lbl1 := labels.Label{Name: "a", Value: "1"}
+	lbl2 := labels.Label{Name: "a", Value: "2"}
+	lbl3 := labels.Label{Name: "a", Value: "3"}
+
+	// Items are stored in both tiers.
+	c.StorePostings(user, blockID, lbl1, []byte{1})
+	hits, _ := second.FetchMultiPostings(ctx, user, blockID, []labels.Label{lbl1})
+	assert.Len(t, hits, 1)
+
+	// Items only found in the second tier are stored in the first one too.
+	second.StorePostings(user, blockID, lbl2, []byte{2})
+	second.StoreSeriesForRef(user, blockID, 1, []byte{3})
+	second.StoreLabelNames(user, blockID, matchersKey, []byte{4})
+
+	hits, misses := c.FetchMultiPostings(ctx, user, blockID, []labels.Label{lbl1, lbl2, lbl3})
+	assert.Equal(t, map[labels.Label][]byte{lbl1: {1}, lbl2: {2}}, hits)
+	assert.Equal(t, []labels.Label{lbl3}, misses)
+
+	seriesHits, seriesMisses := c.FetchMultiSeriesForRefs(ctx, user, blockID, []storage.SeriesRef{1, 2})
+	assert.Equal(t, map[storage.SeriesRef][]byte{1: {3}}, seriesHits)
+	assert.Equal(t, []storage.SeriesRef{2}, seriesMisses)
+
+	v, ok := c.FetchLabelNames(ctx, user, blockID, matchersKey)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{4}, v)
+
+	_, ok = c.FetchLabelValues(ctx, user, blockID, "a", matchersKey)
+	assert.False(t, ok)
+
+	hits, _ = first.FetchMultiPostings(ctx, user, blockID, []labels.Label{lbl2})
+	assert.Len(t, hits, 1)
+	seriesHits, _ = first.FetchMultiSeriesForRefs(ctx, user, blockID, []storage.SeriesRef{1})
+	assert.Len(t, seriesHits, 1)
+	_, ok = first.FetchLabelNames(ctx, user, blockID, matchersKey)
+	assert.True(t, ok)
+
+	// The block is invalidated from both tiers.
+	assert.Equal(t, 8, c.InvalidateBlocks(user, []ulid.ULID{blockID}))
+}
+
+func TestTieredIndexCache_InvalidateBlocksFromTheDiskTier(t *testing.T) {
+	const user = "tenant"
+
+	ctx := context.Background()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	lbl := labels.Label{Name: "a", Value: "1"}
+
+	first, err := NewInMemoryIndexCacheWithConfig(log.NewNopLogger(), nil, DefaultInMemoryIndexCacheConfig)
+	require.NoError(t, err)
+	disk, err := diskcache.New("index-cache", t.TempDir(), 1024*1024, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(disk.Stop)
+	second, err := NewRemoteIndexCache(log.NewNopLogger(), disk, nil)
+	require.NoError(t, err)
+	c := NewTieredIndexCache(first, second)
+
+	for _, blockID := range []ulid.ULID{block1, block2} {
+		c.StorePostings(user, blockID, lbl, []byte{1})
+		c.StoreSeriesForRef(user, blockID, 1, []byte{2})
+	}
+
+	// The disk tier stores the items asynchronously.
+	test.Poll(t, time.Second, 4, func() interface{} {
+		hits := 0
+		for _, blockID := range []ulid.ULID{block1, block2} {
+			postingsHits, _ := second.FetchMultiPostings(ctx, user, blockID, []labels.Label{lbl})
+			seriesHits, _ := second.FetchMultiSeriesForRefs(ctx, user, blockID, []storage.SeriesRef{1})
+			hits += len(postingsHits) + len(seriesHits)
+		}
+		return hits
+	})
+
+	// The entries of the first block are removed from both tiers.
+	assert.Equal(t, 4, c.InvalidateBlocks(user, []ulid.ULID{block1}))
+
+	hits, _ := second.FetchMultiPostings(ctx, user, block1, []labels.Label{lbl})
+	assert.Empty(t, hits)
+	hits, _ = second.FetchMultiPostings(ctx, user, block2, []labels.Label{lbl})
+	assert.Len(t, hits, 1)
+
+	// The entries of the other tenants are kept.
+	assert.Equal(t, 0, c.InvalidateBlocks("other", []ulid.ULID{block2}))
+}
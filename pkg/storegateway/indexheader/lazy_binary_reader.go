@@ -82,6 +82,9 @@ type LazyBinaryReader struct {
 
 	// Keep track of the last time it was used.
 	usedAt *atomic.Int64
+
+	// Size of the index-header file, in bytes.
+	size int64
 }
 
 // NewLazyBinaryReader makes a new LazyBinaryReader. If the index-header does not exist
@@ -116,6 +119,11 @@ func NewLazyBinaryReader(
 		level.Debug(logger).Log("msg", "built index-header file", "path", path, "elapsed", time.Since(start))
 	}
 
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read index header")
+	}
+
 	return &LazyBinaryReader{
 		logger:        logger,
 		filepath:      path,
@@ -123,6 +131,7 @@ func NewLazyBinaryReader(
 		usedAt:        atomic.NewInt64(time.Now().UnixNano()),
 		onClosed:      onClosed,
 		readerFactory: readerFactory,
+		size:          info.Size(),
 	}, nil
 }
 
@@ -295,6 +304,31 @@ func (r *LazyBinaryReader) unloadIfIdleSince(ts int64) error {
 	return nil
 }
 
+// isLoaded returns true if the index-header is loaded.
+func (r *LazyBinaryReader) isLoaded() bool {
+	r.readerMx.RLock()
+	defer r.readerMx.RUnlock()
+
+	return r.reader != nil
+}
+
+// LoadState returns whether the index-header of the reader is lazy loaded, and whether it's currently loaded.
+// The index-header of a reader which isn't lazy loaded is always loaded.
+func LoadState(r Reader) (lazy, loaded bool) {
+	if lazyReader, ok := r.(*LazyBinaryReader); ok {
+		return true, lazyReader.isLoaded()
+	}
+	return false, true
+}
+
+// residentBytes returns the size of the index-header if loaded, or 0 otherwise.
+func (r *LazyBinaryReader) residentBytes() int64 {
+	if !r.isLoaded() {
+		return 0
+	}
+	return r.size
+}
+
 // isIdleSince returns true if the reader is idle since given time (as unix nano).
 func (r *LazyBinaryReader) isIdleSince(ts int64) bool {
 	if r.usedAt.Load() > ts {
@@ -7,6 +7,7 @@ package indexheader
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -15,14 +16,49 @@ import (
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 
 	"github.com/thanos-io/objstore"
 )
 
+const (
+	// EvictionPolicyLRU unloads the least recently used index-headers first.
+	EvictionPolicyLRU = "lru"
+
+	// EvictionPolicySize unloads the largest index-headers first, so that the fewest index-headers
+	// are unloaded to get back under the max resident bytes.
+	EvictionPolicySize = "size"
+
+	// maxResidentBytesCheckInterval is the max interval between two checks of the resident bytes.
+	maxResidentBytesCheckInterval = 10 * time.Second
+)
+
+// EvictionPolicies is the list of the supported eviction policies.
+var EvictionPolicies = []string{EvictionPolicyLRU, EvictionPolicySize}
+
+// EvictionConfig configures when the lazy readers tracked by the ReaderPool are unloaded.
+type EvictionConfig struct {
+	// IdleTimeout is the time after which an unused reader is unloaded. 0 to disable.
+	IdleTimeout time.Duration
+
+	// Policy selects the readers unloaded first when the resident bytes exceed MaxResidentBytes.
+	Policy string
+
+	// MaxResidentBytes returns the max size of the loaded index-headers. The readers are unloaded,
+	// according to the Policy, until the loaded index-headers fit. Nil or 0 to disable.
+	MaxResidentBytes func() int64
+
+	// Pinned returns whether the readers are never unloaded, neither when idle nor when the
+	// resident bytes exceed the max. Nil means not pinned.
+	Pinned func() bool
+}
+
 // ReaderPoolMetrics holds metrics tracked by ReaderPool.
 type ReaderPoolMetrics struct {
 	lazyReader   *LazyBinaryReaderMetrics
 	streamReader *StreamBinaryReaderMetrics
+
+	maxResidentBytesEvictions prometheus.Counter
 }
 
 // NewReaderPoolMetrics makes new ReaderPoolMetrics.
@@ -30,18 +66,23 @@ func NewReaderPoolMetrics(reg prometheus.Registerer) *ReaderPoolMetrics {
 	return &ReaderPoolMetrics{
 		lazyReader:   NewLazyBinaryReaderMetrics(reg),
 		streamReader: NewStreamBinaryReaderMetrics(reg),
+		maxResidentBytesEvictions: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_lazy_max_resident_bytes_evictions_total",
+			Help: "Total number of index-headers unloaded because the loaded index-headers of the tenant exceeded the max resident bytes.",
+		}),
 	}
 }
 
 // ReaderPool is used to istantiate new index-header readers and keep track of them.
 // When the lazy reader is enabled, the pool keeps track of all instantiated readers
-// and automatically close them once the idle timeout is reached. A closed lazy reader
-// will be automatically re-opened upon next usage.
+// and automatically close them once the idle timeout is reached or the loaded
+// index-headers exceed the max resident bytes. A closed lazy reader will be
+// automatically re-opened upon next usage.
 type ReaderPool struct {
-	lazyReaderEnabled     bool
-	lazyReaderIdleTimeout time.Duration
-	logger                log.Logger
-	metrics               *ReaderPoolMetrics
+	lazyReaderEnabled bool
+	eviction          EvictionConfig
+	logger            log.Logger
+	metrics           *ReaderPoolMetrics
 
 	// Channel used to signal once the pool is closing.
 	close chan struct{}
@@ -52,12 +93,15 @@ type ReaderPool struct {
 }
 
 // NewReaderPool makes a new ReaderPool and starts a background task for unloading idle Readers if enabled.
-func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, metrics *ReaderPoolMetrics) *ReaderPool {
-	p := newReaderPool(logger, lazyReaderEnabled, lazyReaderIdleTimeout, metrics)
+func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, eviction EvictionConfig, metrics *ReaderPoolMetrics) *ReaderPool {
+	p := newReaderPool(logger, lazyReaderEnabled, eviction, metrics)
 
 	// Start a goroutine to close idle readers (only if required).
-	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
-		checkFreq := p.lazyReaderIdleTimeout / 10
+	if p.tracksLazyReaders() {
+		checkFreq := p.eviction.IdleTimeout / 10
+		if p.eviction.MaxResidentBytes != nil && (checkFreq <= 0 || checkFreq > maxResidentBytesCheckInterval) {
+			checkFreq = maxResidentBytesCheckInterval
+		}
 
 		go func() {
 			for {
@@ -65,7 +109,7 @@ func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTime
 				case <-p.close:
 					return
 				case <-time.After(checkFreq):
-					p.closeIdleReaders()
+					p.evictReaders()
 				}
 			}
 		}()
@@ -75,20 +119,26 @@ func NewReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTime
 }
 
 // newReaderPool makes a new ReaderPool.
-func newReaderPool(logger log.Logger, lazyReaderEnabled bool, lazyReaderIdleTimeout time.Duration, metrics *ReaderPoolMetrics) *ReaderPool {
+func newReaderPool(logger log.Logger, lazyReaderEnabled bool, eviction EvictionConfig, metrics *ReaderPoolMetrics) *ReaderPool {
 	return &ReaderPool{
-		logger:                logger,
-		metrics:               metrics,
-		lazyReaderEnabled:     lazyReaderEnabled,
-		lazyReaderIdleTimeout: lazyReaderIdleTimeout,
-		lazyReaders:           make(map[*LazyBinaryReader]struct{}),
-		close:                 make(chan struct{}),
+		logger:            logger,
+		metrics:           metrics,
+		lazyReaderEnabled: lazyReaderEnabled,
+		eviction:          eviction,
+		lazyReaders:       make(map[*LazyBinaryReader]struct{}),
+		close:             make(chan struct{}),
 	}
 }
 
+// tracksLazyReaders returns whether the pool needs to keep track of the lazy readers to unload them.
+func (p *ReaderPool) tracksLazyReaders() bool {
+	return p.lazyReaderEnabled && (p.eviction.IdleTimeout > 0 || p.eviction.MaxResidentBytes != nil)
+}
+
 // NewBinaryReader creates and returns a new binary reader. If the pool has been configured
 // with lazy reader enabled, this function will return a lazy reader. The returned lazy reader
-// is tracked by the pool and automatically closed once the idle timeout expires.
+// is tracked by the pool and automatically closed once the idle timeout expires or the
+// loaded index-headers exceed the max resident bytes.
 func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, dir string, id ulid.ULID, postingOffsetsInMemSampling int, cfg Config) (Reader, error) {
 	var readerFactory func() (Reader, error)
 	var reader Reader
@@ -109,7 +159,7 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 	}
 
 	// Keep track of lazy readers only if required.
-	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
+	if p.tracksLazyReaders() {
 		p.lazyReadersMx.Lock()
 		p.lazyReaders[reader.(*LazyBinaryReader)] = struct{}{}
 		p.lazyReadersMx.Unlock()
@@ -124,8 +174,24 @@ func (p *ReaderPool) Close() {
 	close(p.close)
 }
 
+// evictReaders unloads the idle readers and the readers exceeding the max resident bytes, unless pinned.
+func (p *ReaderPool) evictReaders() {
+	if p.eviction.Pinned != nil && p.eviction.Pinned() {
+		return
+	}
+
+	if p.eviction.IdleTimeout > 0 {
+		p.closeIdleReaders()
+	}
+	if p.eviction.MaxResidentBytes != nil {
+		if maxBytes := p.eviction.MaxResidentBytes(); maxBytes > 0 {
+			p.closeReadersOverMaxResidentBytes(maxBytes)
+		}
+	}
+}
+
 func (p *ReaderPool) closeIdleReaders() {
-	idleTimeoutAgo := time.Now().Add(-p.lazyReaderIdleTimeout).UnixNano()
+	idleTimeoutAgo := time.Now().Add(-p.eviction.IdleTimeout).UnixNano()
 
 	for _, r := range p.getIdleReadersSince(idleTimeoutAgo) {
 		if err := r.unloadIfIdleSince(idleTimeoutAgo); err != nil && !errors.Is(err, errNotIdle) {
@@ -134,6 +200,67 @@ func (p *ReaderPool) closeIdleReaders() {
 	}
 }
 
+// closeReadersOverMaxResidentBytes unloads the loaded readers, in the order of the eviction policy,
+// until the size of the loaded index-headers is not greater than maxBytes. The readers used while
+// being selected for eviction are not unloaded.
+func (p *ReaderPool) closeReadersOverMaxResidentBytes(maxBytes int64) {
+	type candidate struct {
+		reader *LazyBinaryReader
+		usedAt int64
+		bytes  int64
+	}
+
+	var (
+		candidates []candidate
+		total      int64
+	)
+	for _, r := range p.getLoadedReaders() {
+		c := candidate{reader: r, usedAt: r.usedAt.Load(), bytes: r.residentBytes()}
+		candidates = append(candidates, c)
+		total += c.bytes
+	}
+	if total <= maxBytes {
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if p.eviction.Policy == EvictionPolicySize && candidates[i].bytes != candidates[j].bytes {
+			return candidates[i].bytes > candidates[j].bytes
+		}
+		return candidates[i].usedAt < candidates[j].usedAt
+	})
+
+	for _, c := range candidates {
+		if total <= maxBytes {
+			return
+		}
+
+		if err := c.reader.unloadIfIdleSince(c.usedAt); err != nil {
+			if !errors.Is(err, errNotIdle) {
+				level.Warn(p.logger).Log("msg", "failed to close index-header reader exceeding the max resident bytes", "err", err)
+			}
+			continue
+		}
+		p.metrics.maxResidentBytesEvictions.Inc()
+		total -= c.bytes
+	}
+}
+
+// getLoadedReaders returns the tracked readers whose index-header is loaded.
+func (p *ReaderPool) getLoadedReaders() []*LazyBinaryReader {
+	p.lazyReadersMx.Lock()
+	defer p.lazyReadersMx.Unlock()
+
+	var loaded []*LazyBinaryReader
+	for r := range p.lazyReaders {
+		if r.isLoaded() {
+			loaded = append(loaded, r)
+		}
+	}
+
+	return loaded
+}
+
 func (p *ReaderPool) getIdleReadersSince(ts int64) []*LazyBinaryReader {
 	p.lazyReadersMx.Lock()
 	defer p.lazyReadersMx.Unlock()
@@ -9,10 +9,12 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
 	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
@@ -62,7 +64,7 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			pool := NewReaderPool(log.NewNopLogger(), testData.lazyReaderEnabled, testData.lazyReaderIdleTimeout, NewReaderPoolMetrics(nil))
+			pool := NewReaderPool(log.NewNopLogger(), testData.lazyReaderEnabled, EvictionConfig{IdleTimeout: testData.lazyReaderIdleTimeout}, NewReaderPoolMetrics(nil))
 			defer pool.Close()
 
 			r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
@@ -102,7 +104,7 @@ func TestReaderPool_ShouldCloseIdleLazyReaders(t *testing.T) {
 	metrics := NewReaderPoolMetrics(nil)
 	// Note that we are creating a ReaderPool that doesn't run a background cleanup task for idle
 	// Reader instances. We'll manually invoke the cleanup task when we need it as part of this test.
-	pool := newReaderPool(log.NewNopLogger(), true, idleTimeout, metrics)
+	pool := newReaderPool(log.NewNopLogger(), true, EvictionConfig{IdleTimeout: idleTimeout}, metrics)
 	defer pool.Close()
 
 	r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, Config{})
@@ -139,3 +141,93 @@ func TestReaderPool_ShouldCloseIdleLazyReaders(t *testing.T) {
 	require.Equal(t, float64(2), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
 	require.Equal(t, float64(2), promtestutil.ToFloat64(metrics.lazyReader.unloadCount))
 }
+
+func TestReaderPool_ShouldCloseReadersOverMaxResidentBytes(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir := t.TempDir()
+	bkt, err := filesystem.NewBucket(filepath.Join(tmpDir, "bkt"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, bkt.Close()) })
+
+	// Create blocks of increasing size.
+	var blockIDs []ulid.ULID
+	for _, numSeries := range []int{10, 100, 1000} {
+		series := make([]labels.Labels, 0, numSeries)
+		for i := 0; i < numSeries; i++ {
+			series = append(series, labels.FromStrings("a", strconv.Itoa(i)))
+		}
+
+		blockID, err := testhelper.CreateBlock(ctx, tmpDir, series, 10, 0, 1000, labels.FromStrings("ext1", "1"))
+		require.NoError(t, err)
+		require.NoError(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(tmpDir, blockID.String()), nil))
+		blockIDs = append(blockIDs, blockID)
+	}
+
+	tests := map[string]struct {
+		policy           string
+		pinned           bool
+		expectedUnloaded []int
+	}{
+		"lru policy unloads the least recently used index-headers first": {
+			policy:           EvictionPolicyLRU,
+			expectedUnloaded: []int{0, 1},
+		},
+		"size policy unloads the largest index-headers first": {
+			policy:           EvictionPolicySize,
+			expectedUnloaded: []int{2},
+		},
+		"pinned index-headers are never unloaded": {
+			policy: EvictionPolicySize,
+			pinned: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var maxResidentBytes int64
+			metrics := NewReaderPoolMetrics(nil)
+			// Note that we are creating a ReaderPool that doesn't run a background eviction task.
+			pool := newReaderPool(log.NewNopLogger(), true, EvictionConfig{
+				Policy:           testData.policy,
+				MaxResidentBytes: func() int64 { return maxResidentBytes },
+				Pinned:           func() bool { return testData.pinned },
+			}, metrics)
+			defer pool.Close()
+
+			// Load the index-headers, from the smallest to the largest one, so that the smallest one is the least recently used.
+			readers := make([]*LazyBinaryReader, len(blockIDs))
+			for i := range blockIDs {
+				r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockIDs[i], 3, Config{})
+				require.NoError(t, err)
+				t.Cleanup(func() { require.NoError(t, r.Close()) })
+
+				_, err = r.LabelNames()
+				require.NoError(t, err)
+				readers[i] = r.(*LazyBinaryReader)
+				time.Sleep(time.Millisecond)
+			}
+
+			// Nothing is unloaded while the index-headers fit.
+			maxResidentBytes = readers[0].size + readers[1].size + readers[2].size
+			pool.evictReaders()
+			for _, r := range readers {
+				require.True(t, r.isLoaded())
+			}
+
+			// Allow only the largest index-header, or the two smallest ones, to be loaded.
+			require.Less(t, readers[0].size+readers[1].size, readers[2].size)
+			maxResidentBytes = readers[2].size
+			pool.evictReaders()
+
+			var unloaded []int
+			for i, r := range readers {
+				if !r.isLoaded() {
+					unloaded = append(unloaded, i)
+				}
+			}
+			require.Equal(t, testData.expectedUnloaded, unloaded)
+			require.Equal(t, float64(len(testData.expectedUnloaded)), promtestutil.ToFloat64(metrics.maxResidentBytesEvictions))
+		})
+	}
+}
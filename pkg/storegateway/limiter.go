@@ -6,12 +6,15 @@
 package storegateway
 
 import (
+	"context"
 	"net/http"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/weaveworks/common/httpgrpc"
 	"go.uber.org/atomic"
+
+	util_math "github.com/grafana/mimir/pkg/util/math"
 )
 
 type ChunksLimiter interface {
@@ -77,3 +80,141 @@ func NewSeriesLimiterFactory(limitsExtractor func() uint64) SeriesLimiterFactory
 		return NewLimiter(limitsExtractor(), failedCounter)
 	}
 }
+
+// BytesLimiter limits the number of bytes touched by a request.
+type BytesLimiter interface {
+	// Reserve num bytes out of the total number of bytes enforced by the limiter.
+	// Returns an error if the limit has been exceeded. This function must be
+	// goroutine safe.
+	Reserve(num uint64) error
+}
+
+// BytesLimiterFactory is used to create a new BytesLimiter for each request.
+type BytesLimiterFactory func(failedCounter prometheus.Counter) BytesLimiter
+
+// NewBytesLimiterFactory makes a new BytesLimiterFactory with a dynamic limit.
+func NewBytesLimiterFactory(limitsExtractor func() uint64) BytesLimiterFactory {
+	return func(failedCounter prometheus.Counter) BytesLimiter {
+		return NewLimiter(limitsExtractor(), failedCounter)
+	}
+}
+
+// InflightBytesLimiter limits the number of bytes reserved by all the in-flight requests sharing it,
+// for example all the requests of a tenant. The bytes reserved by a request are released once the
+// request has completed.
+type InflightBytesLimiter struct {
+	limit    func() uint64
+	reserved atomic.Uint64
+}
+
+// NewInflightBytesLimiter returns a new InflightBytesLimiter with a dynamic limit. 0 disables the limit.
+func NewInflightBytesLimiter(limitsExtractor func() uint64) *InflightBytesLimiter {
+	return &InflightBytesLimiter{limit: limitsExtractor}
+}
+
+// NewRequestLimiter returns the limiter of a single request, reserving bytes out of the ones shared by
+// the in-flight requests. The returned limiter must be released once the request has completed.
+func (l *InflightBytesLimiter) NewRequestLimiter(failedCounter prometheus.Counter) *InflightRequestBytesLimiter {
+	return &InflightRequestBytesLimiter{parent: l, limit: l.limit(), failedCounter: failedCounter}
+}
+
+// Reserved returns the number of bytes currently reserved by the in-flight requests.
+func (l *InflightBytesLimiter) Reserved() uint64 {
+	return l.reserved.Load()
+}
+
+// InflightRequestBytesLimiter is the BytesLimiter of a single request created by InflightBytesLimiter.
+type InflightRequestBytesLimiter struct {
+	parent   *InflightBytesLimiter
+	limit    uint64
+	reserved atomic.Uint64
+
+	// Counter metric which we will increase if limit is exceeded.
+	failedCounter prometheus.Counter
+	failedOnce    sync.Once
+}
+
+// Reserve implements BytesLimiter.
+func (l *InflightRequestBytesLimiter) Reserve(num uint64) error {
+	if l.limit == 0 {
+		return nil
+	}
+	l.reserved.Add(num)
+	if reserved := l.parent.reserved.Add(num); reserved > l.limit {
+		l.failedOnce.Do(l.failedCounter.Inc)
+		return httpgrpc.Errorf(http.StatusUnprocessableEntity, "limit %v exceeded by the in-flight requests", l.limit)
+	}
+	return nil
+}
+
+// Release releases the bytes reserved by the request. It's safe to call it multiple times.
+func (l *InflightRequestBytesLimiter) Release() {
+	l.parent.reserved.Sub(l.reserved.Swap(0))
+}
+
+// MemoryBudget bounds the memory buffered by a Series() call. Unlike the other limiters, it doesn't fail
+// once the budget has been exhausted, but blocks the reservation until enough memory has been released.
+type MemoryBudget struct {
+	limit uint64
+
+	mtx      sync.Mutex
+	reserved uint64
+	released chan struct{}
+}
+
+// NewMemoryBudget makes a new MemoryBudget. A limit of zero means no limit.
+func NewMemoryBudget(limit uint64) *MemoryBudget {
+	return &MemoryBudget{
+		limit:    limit,
+		released: make(chan struct{}, 1),
+	}
+}
+
+// Reserve num bytes out of the budget, waiting until enough bytes have been released if the budget
+// has been exhausted. A reservation is always granted if nothing is reserved, so that a single
+// reservation bigger than the budget doesn't block forever. Returns an error if the context is
+// canceled while waiting.
+func (b *MemoryBudget) Reserve(ctx context.Context, num uint64) error {
+	if b.limit == 0 {
+		return nil
+	}
+
+	for {
+		b.mtx.Lock()
+		if b.reserved == 0 || b.reserved+num <= b.limit {
+			b.reserved += num
+			b.mtx.Unlock()
+			return nil
+		}
+		b.mtx.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.released:
+		}
+	}
+}
+
+// Release num bytes previously reserved, unblocking a pending reservation if any.
+func (b *MemoryBudget) Release(num uint64) {
+	if b.limit == 0 {
+		return
+	}
+
+	b.mtx.Lock()
+	b.reserved -= util_math.Min(num, b.reserved)
+	b.mtx.Unlock()
+
+	select {
+	case b.released <- struct{}{}:
+	default:
+	}
+}
+
+// Reserved returns the number of bytes currently reserved.
+func (b *MemoryBudget) Reserved() uint64 {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.reserved
+}
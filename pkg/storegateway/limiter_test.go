@@ -6,13 +6,16 @@
 package storegateway
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	prom_testutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/status"
 )
 
@@ -37,6 +40,81 @@ func TestLimiter(t *testing.T) {
 	checkErrorStatusCode(t, err)
 }
 
+func TestInflightBytesLimiter(t *testing.T) {
+	c := promauto.With(nil).NewCounter(prometheus.CounterOpts{})
+	l := NewInflightBytesLimiter(func() uint64 { return 10 })
+
+	first := l.NewRequestLimiter(c)
+	second := l.NewRequestLimiter(c)
+
+	assert.NoError(t, first.Reserve(5))
+	assert.NoError(t, second.Reserve(5))
+	assert.Equal(t, uint64(10), l.Reserved())
+	assert.Equal(t, float64(0), prom_testutil.ToFloat64(c))
+
+	// The limit is shared by the in-flight requests.
+	err := second.Reserve(1)
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), prom_testutil.ToFloat64(c))
+	checkErrorStatusCode(t, err)
+
+	// The bytes of a request are released once it has completed.
+	second.Release()
+	second.Release()
+	assert.Equal(t, uint64(5), l.Reserved())
+
+	third := l.NewRequestLimiter(c)
+	assert.NoError(t, third.Reserve(5))
+	assert.Error(t, third.Reserve(1))
+
+	first.Release()
+	third.Release()
+	assert.Equal(t, uint64(0), l.Reserved())
+
+	// A limit of 0 disables the limiter.
+	l = NewInflightBytesLimiter(func() uint64 { return 0 })
+	unlimited := l.NewRequestLimiter(c)
+	assert.NoError(t, unlimited.Reserve(100))
+	assert.Equal(t, uint64(0), l.Reserved())
+}
+
+func TestMemoryBudget(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemoryBudget(10)
+
+	// A reservation bigger than the budget is granted if nothing is reserved.
+	require.NoError(t, b.Reserve(ctx, 15))
+	assert.Equal(t, uint64(15), b.Reserved())
+
+	reserved := make(chan error)
+	go func() {
+		reserved <- b.Reserve(ctx, 5)
+	}()
+
+	// The reservation waits until enough bytes have been released.
+	select {
+	case <-reserved:
+		require.FailNow(t, "the reservation has been granted while the budget was exhausted")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	b.Release(15)
+	require.NoError(t, <-reserved)
+	assert.Equal(t, uint64(5), b.Reserved())
+
+	// The reservation fails once the context is canceled.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, b.Reserve(canceledCtx, 10), context.Canceled)
+	assert.NoError(t, b.Reserve(canceledCtx, 5))
+	assert.Equal(t, uint64(10), b.Reserved())
+
+	// A zero limit means no limit.
+	unlimited := NewMemoryBudget(0)
+	assert.NoError(t, unlimited.Reserve(ctx, 100))
+	assert.Zero(t, unlimited.Reserved())
+}
+
 func checkErrorStatusCode(t *testing.T, err error) {
 	st, ok := status.FromError(err)
 	assert.True(t, ok)
@@ -56,3 +134,10 @@ func newStaticSeriesLimiterFactory(limit uint64) SeriesLimiterFactory {
 		return NewLimiter(limit, failedCounter)
 	}
 }
+
+// newStaticBytesLimiterFactory makes a new BytesLimiterFactory with a static limit.
+func newStaticBytesLimiterFactory(limit uint64) BytesLimiterFactory {
+	return func(failedCounter prometheus.Counter) BytesLimiter {
+		return NewLimiter(limit, failedCounter)
+	}
+}
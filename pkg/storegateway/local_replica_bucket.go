@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const (
+	localReplicaSource  = "local_replica"
+	objectStorageSource = "object_storage"
+)
+
+type localReplicaBucketMetrics struct {
+	reads *prometheus.CounterVec
+}
+
+func newLocalReplicaBucketMetrics(reg prometheus.Registerer) *localReplicaBucketMetrics {
+	return &localReplicaBucketMetrics{
+		reads: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_local_replica_reads_total",
+			Help: "Total number of reads of the block files of the tenants with the local replica enabled, by the source the file has been read from.",
+		}, []string{"source"}),
+	}
+}
+
+// localReplicaBucket is an objstore.Bucket which reads the files of the blocks from a read-only local replica of
+// the bucket, like a local or NFS mount, falling back to the wrapped bucket for the files not found in the replica.
+// Only the immutable files of the blocks are read from the replica, while the blocks are still discovered from the
+// wrapped bucket, and the markers are always read from it.
+type localReplicaBucket struct {
+	objstore.Bucket
+
+	replica objstore.BucketReader
+	enabled func() bool
+	metrics *localReplicaBucketMetrics
+}
+
+func newLocalReplicaBucket(bkt objstore.Bucket, replica objstore.BucketReader, enabled func() bool, metrics *localReplicaBucketMetrics) *localReplicaBucket {
+	return &localReplicaBucket{
+		Bucket:  bkt,
+		replica: replica,
+		enabled: enabled,
+		metrics: metrics,
+	}
+}
+
+// Get implements objstore.Bucket.
+func (b *localReplicaBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if b.readFromReplica(name) {
+		r, err := b.replica.Get(ctx, name)
+		if err == nil {
+			b.metrics.reads.WithLabelValues(localReplicaSource).Inc()
+			return r, nil
+		}
+		b.metrics.reads.WithLabelValues(objectStorageSource).Inc()
+	}
+	return b.Bucket.Get(ctx, name)
+}
+
+// GetRange implements objstore.Bucket.
+func (b *localReplicaBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if b.readFromReplica(name) {
+		r, err := b.replica.GetRange(ctx, name, off, length)
+		if err == nil {
+			b.metrics.reads.WithLabelValues(localReplicaSource).Inc()
+			return r, nil
+		}
+		b.metrics.reads.WithLabelValues(objectStorageSource).Inc()
+	}
+	return b.Bucket.GetRange(ctx, name, off, length)
+}
+
+// Iter implements objstore.Bucket. The chunk files of the blocks are listed from the replica, if any is found there.
+func (b *localReplicaBucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	if b.enabled() && isBlockChunksDir(dir) {
+		var names []string
+		err := b.replica.Iter(ctx, dir, func(name string) error {
+			names = append(names, name)
+			return nil
+		}, options...)
+		if err == nil && len(names) > 0 {
+			for _, name := range names {
+				if err := f(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return b.Bucket.Iter(ctx, dir, f, options...)
+}
+
+// Attributes implements objstore.Bucket.
+func (b *localReplicaBucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	if b.readFromReplica(name) {
+		if attrs, err := b.replica.Attributes(ctx, name); err == nil {
+			return attrs, nil
+		}
+	}
+	return b.Bucket.Attributes(ctx, name)
+}
+
+// ReaderWithExpectedErrs implements objstore.InstrumentedBucketReader.
+func (b *localReplicaBucket) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs implements objstore.InstrumentedBucket.
+func (b *localReplicaBucket) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	if ib, ok := b.Bucket.(objstore.InstrumentedBucket); ok {
+		return newLocalReplicaBucket(ib.WithExpectedErrs(fn), b.replica, b.enabled, b.metrics)
+	}
+	return b
+}
+
+// readFromReplica returns whether the object should be read from the local replica: the replica must be enabled,
+// and the object must be one of the immutable files of a block.
+func (b *localReplicaBucket) readFromReplica(name string) bool {
+	if !b.enabled() {
+		return false
+	}
+	return isImmutableBlockFile(name)
+}
+
+func isImmutableBlockFile(name string) bool {
+	dir, file := path.Split(name)
+	if file == block.MetaFilename || file == block.IndexFilename {
+		return isBlockID(strings.TrimSuffix(dir, "/"))
+	}
+	return isBlockChunksDir(dir)
+}
+
+// isBlockChunksDir returns whether dir is the chunks directory of a block, with or without the trailing slash.
+func isBlockChunksDir(dir string) bool {
+	blockID, chunksDir, ok := strings.Cut(strings.TrimSuffix(dir, "/"), "/")
+	return ok && chunksDir == block.ChunksDirname && isBlockID(blockID)
+}
+
+func isBlockID(s string) bool {
+	_, err := ulid.Parse(s)
+	return err == nil
+}
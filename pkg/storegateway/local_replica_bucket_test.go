@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestLocalReplicaBucket(t *testing.T) {
+	ctx := context.Background()
+	blockID := ulid.MustNew(1, nil).String()
+
+	remote := objstore.NewInMemBucket()
+	replica := objstore.NewInMemBucket()
+	for _, name := range []string{"meta.json", "index", "chunks/000001", "chunks/000002", "deletion-mark.json"} {
+		require.NoError(t, remote.Upload(ctx, blockID+"/"+name, strings.NewReader("remote")))
+	}
+	for _, name := range []string{"meta.json", "index", "chunks/000001", "deletion-mark.json"} {
+		require.NoError(t, replica.Upload(ctx, blockID+"/"+name, strings.NewReader("replica")))
+	}
+	require.NoError(t, replica.Upload(ctx, "bucket-index.json.gz", strings.NewReader("replica")))
+	require.NoError(t, remote.Upload(ctx, "bucket-index.json.gz", strings.NewReader("remote")))
+
+	enabled := true
+	reg := prometheus.NewPedanticRegistry()
+	bkt := newLocalReplicaBucket(remote, replica, func() bool { return enabled }, newLocalReplicaBucketMetrics(reg))
+
+	get := func(name string) string {
+		r, err := bkt.Get(ctx, name)
+		require.NoError(t, err)
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	// The immutable files of the blocks are read from the replica.
+	assert.Equal(t, "replica", get(blockID+"/meta.json"))
+	assert.Equal(t, "replica", get(blockID+"/index"))
+	assert.Equal(t, "replica", get(blockID+"/chunks/000001"))
+
+	r, err := bkt.GetRange(ctx, blockID+"/chunks/000001", 1, 3)
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "epl", string(b))
+
+	attrs, err := bkt.Attributes(ctx, blockID+"/index")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("replica")), attrs.Size)
+
+	// The chunks files are listed from the replica.
+	var names []string
+	require.NoError(t, bkt.Iter(ctx, blockID+"/chunks", func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	assert.Equal(t, []string{blockID + "/chunks/000001"}, names)
+
+	// The files not found in the replica are read from the object storage.
+	assert.Equal(t, "remote", get(blockID+"/chunks/000002"))
+
+	// The markers and the files outside the blocks are always read from the object storage.
+	assert.Equal(t, "remote", get(blockID+"/deletion-mark.json"))
+	assert.Equal(t, "remote", get("bucket-index.json.gz"))
+
+	// The objects not found anywhere are reported as such.
+	_, err = bkt.Get(ctx, blockID+"/chunks/000003")
+	require.Error(t, err)
+	assert.True(t, bkt.IsObjNotFoundErr(err))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_bucket_store_local_replica_reads_total Total number of reads of the block files of the tenants with the local replica enabled, by the source the file has been read from.
+		# TYPE cortex_bucket_store_local_replica_reads_total counter
+		cortex_bucket_store_local_replica_reads_total{source="local_replica"} 4
+		cortex_bucket_store_local_replica_reads_total{source="object_storage"} 2
+	`), "cortex_bucket_store_local_replica_reads_total"))
+
+	// Once disabled, everything is read from the object storage.
+	enabled = false
+	assert.Equal(t, "remote", get(blockID+"/index"))
+
+	names = nil
+	require.NoError(t, bkt.Iter(ctx, blockID+"/chunks", func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	assert.Equal(t, []string{blockID + "/chunks/000001", blockID + "/chunks/000002"}, names)
+}
+
+func TestIsImmutableBlockFile(t *testing.T) {
+	blockID := ulid.MustNew(1, nil).String()
+
+	tests := map[string]bool{
+		blockID + "/meta.json":                       true,
+		blockID + "/index":                           true,
+		blockID + "/chunks/000001":                   true,
+		blockID + "/deletion-mark.json":              false,
+		blockID + "/no-compact-mark.json":            false,
+		blockID + "/chunks":                          false,
+		"markers/" + blockID + "-deletion-mark.json": false,
+		"bucket-index.json.gz":                       false,
+		"not-a-block/index":                          false,
+		"not-a-block/chunks/000001":                  false,
+		blockID + "/chunks/000001/other":             false,
+	}
+
+	for name, expected := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, expected, isImmutableBlockFile(name))
+		})
+	}
+}
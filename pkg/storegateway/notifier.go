@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/grafana/dskit/crypto/tls"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/grafana/dskit/kv"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/ring/client"
+	"github.com/grafana/dskit/services"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+)
+
+const (
+	// RingNameForNotifier is the name of the ring used by the BlocksChangedNotifier.
+	RingNameForNotifier = "store-gateway-notifier"
+
+	// notifyTimeout is the max time spent notifying the store-gateways about a tenant.
+	notifyTimeout = 10 * time.Second
+)
+
+// BlocksChangedNotifier notifies the store-gateways owning a tenant when the tenant's blocks in the bucket
+// have changed, so that they can sync the tenant without waiting for the next periodic sync.
+type BlocksChangedNotifier struct {
+	services.Service
+
+	ring        *ring.Ring
+	clientsPool *client.Pool
+	limits      ShardingLimits
+	logger      log.Logger
+
+	// Subservices manager (ring, clients pool).
+	subservices        *services.Manager
+	subservicesWatcher *services.FailureWatcher
+
+	notificationsTotal  prometheus.Counter
+	notificationsFailed prometheus.Counter
+}
+
+// NewBlocksChangedNotifier makes a new BlocksChangedNotifier, which discovers the store-gateways through
+// the store-gateway ring.
+func NewBlocksChangedNotifier(ringCfg RingConfig, tlsEnabled bool, tlsCfg tls.ClientConfig, limits ShardingLimits, logger log.Logger, reg prometheus.Registerer) (*BlocksChangedNotifier, error) {
+	storesRingCfg := ringCfg.ToRingConfig()
+	storesRingBackend, err := kv.NewClient(
+		storesRingCfg.KVStore,
+		ring.GetCodec(),
+		kv.RegistererWithKVName(prometheus.WrapRegistererWithPrefix("cortex_", reg), "store-gateway-notifier"),
+		logger,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create store-gateway ring backend")
+	}
+
+	storesRing, err := ring.NewWithStoreClientAndStrategy(storesRingCfg, RingNameForNotifier, RingKey, storesRingBackend, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), prometheus.WrapRegistererWithPrefix("cortex_", reg), logger)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create store-gateway ring client")
+	}
+
+	n := &BlocksChangedNotifier{
+		ring:        storesRing,
+		clientsPool: newNotifierClientPool(client.NewRingServiceDiscovery(storesRing), tlsEnabled, tlsCfg, logger, reg),
+		limits:      limits,
+		logger:      logger,
+		notificationsTotal: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_storegateway_blocks_changed_notifications_total",
+			Help: "Total number of notifications sent to store-gateways about tenants whose blocks have changed.",
+		}),
+		notificationsFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_storegateway_blocks_changed_notifications_failed_total",
+			Help: "Total number of notifications sent to store-gateways about tenants whose blocks have changed, which have failed.",
+		}),
+	}
+
+	n.subservices, err = services.NewManager(n.ring, n.clientsPool)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Service = services.NewBasicService(n.starting, n.running, n.stopping)
+
+	return n, nil
+}
+
+func (n *BlocksChangedNotifier) starting(ctx context.Context) error {
+	n.subservicesWatcher = services.NewFailureWatcher()
+	n.subservicesWatcher.WatchManager(n.subservices)
+
+	return errors.Wrap(services.StartManagerAndAwaitHealthy(ctx, n.subservices), "unable to start store-gateway notifier subservices")
+}
+
+func (n *BlocksChangedNotifier) running(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-n.subservicesWatcher.Chan():
+		return errors.Wrap(err, "store-gateway notifier subservice failed")
+	}
+}
+
+func (n *BlocksChangedNotifier) stopping(_ error) error {
+	err := services.StopManagerAndAwaitStopped(context.Background(), n.subservices)
+
+	// The clients pool doesn't close the clients when stopped.
+	for _, addr := range n.clientsPool.RegisteredAddresses() {
+		n.clientsPool.RemoveClientFor(addr)
+	}
+
+	return err
+}
+
+// NotifyBlocksChanged notifies the store-gateways in the shard of the tenant that the tenant's blocks
+// have changed. Notifications are best effort: failures are logged and tracked, but not returned,
+// because the store-gateways will anyway discover the changes at the next periodic sync.
+func (n *BlocksChangedNotifier) NotifyBlocksChanged(ctx context.Context, userID string) {
+	logger := util_log.WithUserID(userID, n.logger)
+
+	rs, err := GetShuffleShardingSubring(n.ring, userID, n.limits).GetAllHealthy(BlocksOwnerSync)
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to get the store-gateways to notify about changed blocks", "err", err)
+		return
+	}
+
+	// The tenant ID is required by the gRPC client and server middlewares.
+	ctx, cancel := context.WithTimeout(user.InjectOrgID(ctx, userID), notifyTimeout)
+	defer cancel()
+
+	req := &storegatewaypb.SyncTenantsRequest{TenantIds: []string{userID}}
+
+	_ = concurrency.ForEachJob(ctx, len(rs.Instances), len(rs.Instances), func(ctx context.Context, idx int) error {
+		addr := rs.Instances[idx].Addr
+		n.notificationsTotal.Inc()
+
+		c, err := n.clientsPool.GetClientFor(addr)
+		if err == nil {
+			_, err = c.(storegatewaypb.StoreGatewayClient).SyncTenants(ctx, req)
+		}
+		if err != nil {
+			n.notificationsFailed.Inc()
+			level.Warn(logger).Log("msg", "failed to notify store-gateway about changed blocks", "addr", addr, "err", err)
+		}
+
+		// Never return an error, so that a failure doesn't cancel the other notifications.
+		return nil
+	})
+}
+
+func newNotifierClientPool(discovery client.PoolServiceDiscovery, tlsEnabled bool, tlsCfg tls.ClientConfig, logger log.Logger, reg prometheus.Registerer) *client.Pool {
+	// We use the same defaults of the querier's store-gateway clients.
+	clientCfg := grpcclient.Config{
+		MaxRecvMsgSize: 100 << 20,
+		MaxSendMsgSize: 16 << 20,
+		TLSEnabled:     tlsEnabled,
+		TLS:            tlsCfg,
+	}
+	poolCfg := client.PoolConfig{
+		CheckInterval:      10 * time.Second,
+		HealthCheckEnabled: true,
+		HealthCheckTimeout: 10 * time.Second,
+	}
+
+	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   "cortex",
+		Name:        "storegateway_client_request_duration_seconds",
+		Help:        "Time spent executing requests to the store-gateway.",
+		Buckets:     prometheus.ExponentialBuckets(0.008, 4, 7),
+		ConstLabels: prometheus.Labels{"client": "notifier"},
+	}, []string{"operation", "status_code"})
+
+	clientsCount := promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+		Namespace:   "cortex",
+		Name:        "storegateway_clients",
+		Help:        "The current number of store-gateway clients in the pool.",
+		ConstLabels: map[string]string{"client": "notifier"},
+	})
+
+	factory := func(addr string) (client.PoolClient, error) {
+		opts, err := clientCfg.DialOption(grpcclient.Instrument(requestDuration))
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := grpc.Dial(addr, opts...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to dial store-gateway %s", addr)
+		}
+
+		return &notifierClient{
+			StoreGatewayClient: storegatewaypb.NewStoreGatewayClient(conn),
+			HealthClient:       grpc_health_v1.NewHealthClient(conn),
+			conn:               conn,
+		}, nil
+	}
+
+	return client.NewPool("store-gateway", poolCfg, discovery, factory, clientsCount, logger)
+}
+
+type notifierClient struct {
+	storegatewaypb.StoreGatewayClient
+	grpc_health_v1.HealthClient
+	conn *grpc.ClientConn
+}
+
+func (c *notifierClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *notifierClient) String() string {
+	return c.conn.Target()
+}
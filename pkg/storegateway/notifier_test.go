@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/crypto/tls"
+	"github.com/grafana/dskit/kv/consul"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/util/test"
+)
+
+func TestBlocksChangedNotifier_NotifyBlocksChanged(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	ctx := context.Background()
+
+	// Start two store-gateways, and register a third one which isn't reachable.
+	srv1, addr1 := startSyncTenantsServer(t)
+	srv2, addr2 := startSyncTenantsServer(t)
+
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, ringStore.CAS(ctx, RingKey, func(in interface{}) (interface{}, bool, error) {
+		desc := ring.GetOrCreateRingDesc(in)
+		desc.AddIngester("instance-1", addr1, "", ring.Tokens{1, 2, 3}, ring.ACTIVE, time.Now())
+		desc.AddIngester("instance-2", addr2, "", ring.Tokens{4, 5, 6}, ring.ACTIVE, time.Now())
+		desc.AddIngester("instance-3", "127.0.0.1:1", "", ring.Tokens{7, 8, 9}, ring.ACTIVE, time.Now())
+		return desc, true, nil
+	}))
+
+	ringCfg := mockGatewayConfig().ShardingRing
+	ringCfg.KVStore.Mock = ringStore
+
+	reg := prometheus.NewPedanticRegistry()
+	n, err := NewBlocksChangedNotifier(ringCfg, false, tls.ClientConfig{}, &shardingLimitsMock{}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, n))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(ctx, n)) })
+
+	// Wait until the ring client has discovered the store-gateways.
+	require.Eventually(t, func() bool {
+		rs, err := n.ring.GetAllHealthy(BlocksOwnerSync)
+		return err == nil && len(rs.Instances) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	n.NotifyBlocksChanged(ctx, "user-1")
+
+	assert.Equal(t, []string{"user-1"}, srv1.getTenants())
+	assert.Equal(t, []string{"user-1"}, srv2.getTenants())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_storegateway_blocks_changed_notifications_total Total number of notifications sent to store-gateways about tenants whose blocks have changed.
+		# TYPE cortex_storegateway_blocks_changed_notifications_total counter
+		cortex_storegateway_blocks_changed_notifications_total 3
+		# HELP cortex_storegateway_blocks_changed_notifications_failed_total Total number of notifications sent to store-gateways about tenants whose blocks have changed, which have failed.
+		# TYPE cortex_storegateway_blocks_changed_notifications_failed_total counter
+		cortex_storegateway_blocks_changed_notifications_failed_total 1
+	`), "cortex_storegateway_blocks_changed_notifications_total", "cortex_storegateway_blocks_changed_notifications_failed_total"))
+}
+
+func startSyncTenantsServer(t *testing.T) (*syncTenantsServerMock, string) {
+	srv := &syncTenantsServerMock{}
+
+	grpcServer := grpc.NewServer()
+	storegatewaypb.RegisterStoreGatewayServer(grpcServer, srv)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	return srv, listener.Addr().String()
+}
+
+type syncTenantsServerMock struct {
+	storegatewaypb.UnimplementedStoreGatewayServer
+
+	mtx     sync.Mutex
+	tenants []string
+}
+
+func (m *syncTenantsServerMock) SyncTenants(_ context.Context, req *storegatewaypb.SyncTenantsRequest) (*storegatewaypb.SyncTenantsResponse, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.tenants = append(m.tenants, req.TenantIds...)
+	return &storegatewaypb.SyncTenantsResponse{}, nil
+}
+
+func (m *syncTenantsServerMock) getTenants() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return append([]string(nil), m.tenants...)
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/status"
+)
+
+// partialResultsWarningPrefix is the prefix of the warning sent by the Series() requests returning partial results,
+// so that the warning can be recognised by the clients.
+const partialResultsWarningPrefix = "partial data"
+
+// partialResults keeps track of the blocks failed to be queried by a Series() request returning partial results.
+// A nil *partialResults tolerates no failure.
+type partialResults struct {
+	mtx    sync.Mutex
+	failed map[ulid.ULID]error
+}
+
+func newPartialResults() *partialResults {
+	return &partialResults{failed: map[ulid.ULID]error{}}
+}
+
+// tolerate records the failure of the block and returns true if the error doesn't need to fail the request.
+// The errors caused by the request being canceled or exceeding a limit always fail the request, because
+// the other blocks would fail the same way.
+func (p *partialResults) tolerate(ctx context.Context, blockID ulid.ULID, err error) bool {
+	if p == nil || ctx.Err() != nil {
+		return false
+	}
+	if st, ok := status.FromError(errors.Cause(err)); ok && int(st.Code()) == http.StatusUnprocessableEntity {
+		return false
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.failed[blockID] = err
+	return true
+}
+
+// failedBlocks returns the IDs of the blocks failed to be queried, sorted.
+func (p *partialResults) failedBlocks() []ulid.ULID {
+	if p == nil {
+		return nil
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	ids := make([]ulid.ULID, 0, len(p.failed))
+	for id := range p.failed {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Compare(ids[j]) < 0 })
+	return ids
+}
+
+// withoutFailedBlocks returns the input blocks, except the ones failed to be queried.
+func (p *partialResults) withoutFailedBlocks(blocks []*bucketBlock) []*bucketBlock {
+	if p == nil {
+		return blocks
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if len(p.failed) == 0 {
+		return blocks
+	}
+	filtered := make([]*bucketBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if _, failed := p.failed[b.meta.ULID]; !failed {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
+
+// warning returns the warning listing the blocks failed to be queried out of the numBlocks queried, or an
+// empty string if no block failed.
+func (p *partialResults) warning(numBlocks int) string {
+	ids := p.failedBlocks()
+	if len(ids) == 0 {
+		return ""
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	failures := make([]string, 0, len(ids))
+	for _, id := range ids {
+		failures = append(failures, fmt.Sprintf("block %s: %s", id, p.failed[id]))
+	}
+	return fmt.Sprintf("%s: failed to query %d of %d blocks: %s", partialResultsWarningPrefix, len(ids), numBlocks, strings.Join(failures, "; "))
+}
@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var noBytesLimiterFactory = NewBytesLimiterFactory(noBytesLimit)
+
+func noBytesLimit() uint64 { return 0 }
+
+// touchedBytesLimiter reserves the bytes of a type of data touched by a Series() call, both out of the
+// limit of the request and out of the limit shared by the in-flight requests of the tenant.
+type touchedBytesLimiter struct {
+	request         BytesLimiter
+	tenant          *InflightRequestBytesLimiter
+	requestErrMsg   string
+	tenantErrMsg    string
+	reservedTouched int
+}
+
+// reserveTouched reserves the bytes touched since the previous call, given the total touched so far.
+func (l *touchedBytesLimiter) reserveTouched(touched int) error {
+	if touched <= l.reservedTouched {
+		return nil
+	}
+	num := uint64(touched - l.reservedTouched)
+	l.reservedTouched = touched
+
+	if err := l.request.Reserve(num); err != nil {
+		return errors.Wrap(err, l.requestErrMsg)
+	}
+	if err := l.tenant.Reserve(num); err != nil {
+		return errors.Wrap(err, l.tenantErrMsg)
+	}
+	return nil
+}
+
+// seriesBytesLimiters limits the size of the postings, series and chunks touched by a Series() call. The touched
+// bytes are tracked by the query stats, so each check reserves the bytes touched since the previous one.
+// It also bounds the memory of the chunks buffered by the call.
+type seriesBytesLimiters struct {
+	mtx      sync.Mutex
+	postings touchedBytesLimiter
+	series   touchedBytesLimiter
+	chunks   touchedBytesLimiter
+
+	bufferedChunks *MemoryBudget
+}
+
+func (s *BucketStore) newSeriesBytesLimiters() *seriesBytesLimiters {
+	dropped := s.metrics.queriesDropped
+	return &seriesBytesLimiters{
+		postings: touchedBytesLimiter{
+			request:       s.postingsBytesLimiterFactory(dropped.WithLabelValues("postings_bytes")),
+			tenant:        s.tenantPostingsBytesLimiter.NewRequestLimiter(dropped.WithLabelValues("tenant_postings_bytes")),
+			requestErrMsg: ErrPostingsBytesLimitMessage,
+			tenantErrMsg:  ErrTenantPostingsBytesLimitMessage,
+		},
+		series: touchedBytesLimiter{
+			request:       s.seriesBytesLimiterFactory(dropped.WithLabelValues("series_bytes")),
+			tenant:        s.tenantSeriesBytesLimiter.NewRequestLimiter(dropped.WithLabelValues("tenant_series_bytes")),
+			requestErrMsg: ErrSeriesBytesLimitMessage,
+			tenantErrMsg:  ErrTenantSeriesBytesLimitMessage,
+		},
+		chunks: touchedBytesLimiter{
+			request:       s.chunksBytesLimiterFactory(dropped.WithLabelValues("chunks_bytes")),
+			tenant:        s.tenantChunksBytesLimiter.NewRequestLimiter(dropped.WithLabelValues("tenant_chunks_bytes")),
+			requestErrMsg: ErrChunksBytesLimitMessage,
+			tenantErrMsg:  ErrTenantChunksBytesLimitMessage,
+		},
+		bufferedChunks: NewMemoryBudget(s.maxBufferedChunksBytesPerRequest),
+	}
+}
+
+// reserveIndexBytes reserves the postings and series bytes touched since the previous check.
+func (l *seriesBytesLimiters) reserveIndexBytes(stats *safeQueryStats) error {
+	touched := stats.export()
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if err := l.postings.reserveTouched(touched.postingsTouchedSizeSum); err != nil {
+		return err
+	}
+	return l.series.reserveTouched(touched.seriesTouchedSizeSum)
+}
+
+// reserveChunksBytes reserves the chunks bytes touched since the previous check. The processed chunks
+// are used, because they include the chunks fetched from the chunks cache too.
+func (l *seriesBytesLimiters) reserveChunksBytes(stats *safeQueryStats) error {
+	touched := stats.export()
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.chunks.reserveTouched(touched.chunksProcessedSizeSum)
+}
+
+// release releases the bytes reserved out of the limits shared by the in-flight requests of the tenant.
+func (l *seriesBytesLimiters) release() {
+	l.postings.tenant.Release()
+	l.series.tenant.Release()
+	l.chunks.tenant.Release()
+}
+
+// bytesLimitingSetIterator checks the bytes limits after each set returned by the wrapped iterator.
+type bytesLimitingSetIterator[Set any] struct {
+	from    genericIterator[Set]
+	reserve func() error
+
+	err error
+}
+
+func newBytesLimitingSetIterator[Set any](from genericIterator[Set], reserve func() error) *bytesLimitingSetIterator[Set] {
+	return &bytesLimitingSetIterator[Set]{
+		from:    from,
+		reserve: reserve,
+	}
+}
+
+func (l *bytesLimitingSetIterator[Set]) Next() bool {
+	if l.err != nil {
+		return false
+	}
+
+	if !l.from.Next() {
+		l.err = l.from.Err()
+		return false
+	}
+
+	l.err = l.reserve()
+	return l.err == nil
+}
+
+func (l *bytesLimitingSetIterator[Set]) At() Set {
+	return l.from.At()
+}
+
+func (l *bytesLimitingSetIterator[Set]) Err() error {
+	return l.err
+}
@@ -185,11 +185,15 @@ func newSeriesSetWithChunks(
 	chunkReaders bucketChunkReaders,
 	refsIterator seriesChunkRefsSetIterator,
 	refsIteratorBatchSize int,
+	bytesLimiters *seriesBytesLimiters,
 	stats *safeQueryStats,
 	minT, maxT int64,
 ) storepb.SeriesSet {
 	var iterator seriesChunksSetIterator
-	iterator = newLoadingSeriesChunksSetIterator(ctx, logger, userID, cache, chunkReaders, refsIterator, refsIteratorBatchSize, stats, minT, maxT)
+	iterator = newLoadingSeriesChunksSetIterator(ctx, logger, userID, cache, chunkReaders, refsIterator, refsIteratorBatchSize, bytesLimiters.bufferedChunks, stats, minT, maxT)
+	iterator = newBytesLimitingSetIterator[seriesChunksSet](iterator, func() error {
+		return bytesLimiters.reserveChunksBytes(stats)
+	})
 	iterator = newPreloadingAndStatsTrackingSetIterator[seriesChunksSet](ctx, 1, iterator, stats)
 	return newSeriesChunksSeriesSet(iterator)
 }
@@ -331,6 +335,7 @@ type loadingSeriesChunksSetIterator struct {
 	chunkReaders  bucketChunkReaders
 	from          seriesChunkRefsSetIterator
 	fromBatchSize int
+	memoryBudget  *MemoryBudget
 	stats         *safeQueryStats
 
 	current          seriesChunksSet
@@ -346,6 +351,7 @@ func newLoadingSeriesChunksSetIterator(
 	chunkReaders bucketChunkReaders,
 	from seriesChunkRefsSetIterator,
 	fromBatchSize int,
+	memoryBudget *MemoryBudget,
 	stats *safeQueryStats,
 	minT int64,
 	maxT int64,
@@ -358,6 +364,7 @@ func newLoadingSeriesChunksSetIterator(
 		chunkReaders:  chunkReaders,
 		from:          from,
 		fromBatchSize: fromBatchSize,
+		memoryBudget:  memoryBudget,
 		stats:         stats,
 		minTime:       minT,
 		maxTime:       maxT,
@@ -379,6 +386,19 @@ func (c *loadingSeriesChunksSetIterator) Next() (retHasNext bool) {
 	// This data structure doesn't retain the seriesChunkRefsSet so it can be released once done.
 	defer nextUnloaded.release()
 
+	// Wait until the chunks of the set fit in the memory budget of the request, so that the sets loaded
+	// ahead of the ones being sent don't exceed it. The reservation is released with the set.
+	reservedBytes := estimateChunksBytes(nextUnloaded.series)
+	if err := c.reserveMemory(reservedBytes); err != nil {
+		c.err = errors.Wrap(err, "waiting for the memory budget of the request")
+		return false
+	}
+	defer func() {
+		if !retHasNext && c.memoryBudget != nil {
+			c.memoryBudget.Release(reservedBytes)
+		}
+	}()
+
 	// Pre-allocate the series slice using the expected batchSize even if nextUnloaded has less elements,
 	// so that there's a higher chance the slice will be reused once released.
 	nextSet := newSeriesChunksSet(util_math.Max(c.fromBatchSize, nextUnloaded.len()), true)
@@ -458,10 +478,52 @@ func (c *loadingSeriesChunksSetIterator) Next() (retHasNext bool) {
 	c.recordReturnedChunks(nextSet.series)
 
 	nextSet.chunksReleaser = chunksPool
+	if c.memoryBudget != nil {
+		nextSet.chunksReleaser = budgetChunksReleaser{chunksReleaser: chunksPool, budget: c.memoryBudget, reserved: reservedBytes}
+	}
 	c.current = nextSet
 	return true
 }
 
+func (c *loadingSeriesChunksSetIterator) reserveMemory(num uint64) error {
+	if c.memoryBudget == nil {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		c.stats.update(func(stats *queryStats) {
+			stats.streamingSeriesWaitMemoryBudgetDuration += time.Since(start)
+		})
+	}()
+	return c.memoryBudget.Reserve(c.ctx, num)
+}
+
+// estimateChunksBytes returns the size of the chunks of the series estimated from their chunk refs.
+func estimateChunksBytes(series []seriesChunkRefs) (numBytes uint64) {
+	for _, s := range series {
+		for _, r := range s.chunksRanges {
+			for _, c := range r.refs {
+				numBytes += uint64(c.length)
+			}
+		}
+	}
+	return numBytes
+}
+
+// budgetChunksReleaser releases the memory reserved for the chunks out of the memory budget
+// of the request, together with the chunks.
+type budgetChunksReleaser struct {
+	chunksReleaser chunksReleaser
+	budget         *MemoryBudget
+	reserved       uint64
+}
+
+func (r budgetChunksReleaser) Release() {
+	r.chunksReleaser.Release()
+	r.budget.Release(r.reserved)
+}
+
 func initializeChunks(chunksRange seriesChunkRefsRange, chunks []storepb.AggrChunk) {
 	for cIdx := range chunks {
 		chunks[cIdx].MinTime = chunksRange.refs[cIdx].minTime
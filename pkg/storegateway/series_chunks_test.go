@@ -943,7 +943,7 @@ func TestLoadingSeriesChunksSetIterator(t *testing.T) {
 					}
 
 					// Run test
-					set := newLoadingSeriesChunksSetIterator(context.Background(), log.NewNopLogger(), "tenant", chunksCache, *readers, newSliceSeriesChunkRefsSetIterator(nil, testCase.setsToLoad...), 100, newSafeQueryStats(), minT, maxT)
+					set := newLoadingSeriesChunksSetIterator(context.Background(), log.NewNopLogger(), "tenant", chunksCache, *readers, newSliceSeriesChunkRefsSetIterator(nil, testCase.setsToLoad...), 100, nil, newSafeQueryStats(), minT, maxT)
 					loadedSets := readAllSeriesChunksSets(set)
 
 					// Assertions
@@ -980,6 +980,78 @@ func TestLoadingSeriesChunksSetIterator(t *testing.T) {
 	}
 }
 
+func TestLoadingSeriesChunksSetIterator_MemoryBudget(t *testing.T) {
+	block := testBlock{
+		ulid:   ulid.MustNew(1, nil),
+		series: generateSeriesEntries(t, 4),
+	}
+	readers := newChunkReaders(map[ulid.ULID]chunkReader{
+		block.ulid: newChunkReaderMockWithSeries(block.series, nil, nil),
+	})
+
+	// The length of the chunk refs is used to estimate the memory of the chunks.
+	toSeriesChunkRefs := func(seriesIdx int) seriesChunkRefs {
+		refs := block.toSeriesChunkRefs(seriesIdx)
+		for _, r := range refs.chunksRanges {
+			for cIdx := range r.refs {
+				r.refs[cIdx].length = 100
+			}
+		}
+		return refs
+	}
+	firstSet := seriesChunkRefsSet{series: []seriesChunkRefs{toSeriesChunkRefs(0), toSeriesChunkRefs(1)}}
+	secondSet := seriesChunkRefsSet{series: []seriesChunkRefs{toSeriesChunkRefs(2), toSeriesChunkRefs(3)}}
+	firstSetBytes := estimateChunksBytes(firstSet.series)
+	require.Greater(t, firstSetBytes, uint64(0))
+
+	t.Run("should wait until the loaded sets have been released", func(t *testing.T) {
+		budget := NewMemoryBudget(firstSetBytes)
+		stats := newSafeQueryStats()
+		it := newLoadingSeriesChunksSetIterator(context.Background(), log.NewNopLogger(), "tenant", nil, *readers, newSliceSeriesChunkRefsSetIterator(nil, firstSet, secondSet), 2, budget, stats, 0, 100000)
+
+		require.True(t, it.Next())
+		assert.Equal(t, firstSetBytes, budget.Reserved())
+
+		nextLoaded := make(chan bool)
+		go func() {
+			nextLoaded <- it.Next()
+		}()
+
+		select {
+		case <-nextLoaded:
+			require.FailNow(t, "the next set has been loaded while the budget was exhausted")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		set := it.At()
+		set.release()
+		require.True(t, <-nextLoaded)
+		assert.Equal(t, estimateChunksBytes(secondSet.series), budget.Reserved())
+		assert.Greater(t, stats.export().streamingSeriesWaitMemoryBudgetDuration, time.Duration(0))
+
+		set = it.At()
+		set.release()
+		assert.False(t, it.Next())
+		assert.NoError(t, it.Err())
+		assert.Zero(t, budget.Reserved())
+	})
+
+	t.Run("should stop waiting once the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		budget := NewMemoryBudget(firstSetBytes)
+		it := newLoadingSeriesChunksSetIterator(ctx, log.NewNopLogger(), "tenant", nil, *readers, newSliceSeriesChunkRefsSetIterator(nil, firstSet, secondSet), 2, budget, newSafeQueryStats(), 0, 100000)
+
+		require.True(t, it.Next())
+		set := it.At()
+		cancel()
+		assert.False(t, it.Next())
+		assert.ErrorIs(t, it.Err(), context.Canceled)
+
+		set.release()
+		assert.Zero(t, budget.Reserved())
+	})
+}
+
 func BenchmarkLoadingSeriesChunksSetIterator(b *testing.B) {
 	for batchSize := 10; batchSize <= 1000; batchSize *= 10 {
 		b.Run(fmt.Sprintf("batch size: %d", batchSize), func(b *testing.B) {
@@ -1024,7 +1096,7 @@ func BenchmarkLoadingSeriesChunksSetIterator(b *testing.B) {
 
 			for n := 0; n < b.N; n++ {
 				batchSize := numSeriesPerSet
-				it := newLoadingSeriesChunksSetIterator(context.Background(), log.NewNopLogger(), "tenant", newInMemoryChunksCache(), *chunkReaders, newSliceSeriesChunkRefsSetIterator(nil, sets...), batchSize, stats, 0, 10000)
+				it := newLoadingSeriesChunksSetIterator(context.Background(), log.NewNopLogger(), "tenant", newInMemoryChunksCache(), *chunkReaders, newSliceSeriesChunkRefsSetIterator(nil, sets...), batchSize, nil, stats, 0, 10000)
 
 				actualSeries := 0
 				actualChunks := 0
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/util/pool"
+)
+
+// seriesDecodingWorkers are the workers loading and decoding the chunks of the series of the requests, partitioned
+// per group of CPU cores. Each partition has its own workers and its own shard of the chunks pool, so the requests
+// running on different partitions don't contend on the same locks, and the chunks bytes are reused by the workers
+// of the partition which returned them.
+type seriesDecodingWorkers struct {
+	partitions []*seriesDecodingPartition
+	next       atomic.Uint64
+}
+
+// newSeriesDecodingWorkers returns the workers of a partition for each of the input chunks pools, which must be
+// the shards of the same pool, each one with up to workersPerPartition workers.
+func newSeriesDecodingWorkers(chunkPools []pool.Bytes, workersPerPartition int, reg prometheus.Registerer) *seriesDecodingWorkers {
+	contentions := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_series_decoding_workers_lock_contentions_total",
+		Help: "Total number of times the series decoding workers waited for the lock of their partition to be released by another goroutine.",
+	})
+	queued := promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_bucket_store_series_decoding_workers_queued_jobs",
+		Help: "Number of jobs queued for the series decoding workers of a partition, because all of them are busy.",
+	}, []string{"partition"})
+
+	w := &seriesDecodingWorkers{partitions: make([]*seriesDecodingPartition, len(chunkPools))}
+	for i, chunkPool := range chunkPools {
+		w.partitions[i] = &seriesDecodingPartition{
+			chunkPool:   chunkPool,
+			maxWorkers:  workersPerPartition,
+			contentions: contentions,
+			queued:      queued.WithLabelValues(strconv.Itoa(i)),
+		}
+	}
+	return w
+}
+
+// partition returns the partition running the jobs of a new request, or nil if w is nil. The partitions are
+// assigned to the requests in turn, so the requests are spread evenly across the partitions.
+func (w *seriesDecodingWorkers) partition() *seriesDecodingPartition {
+	if w == nil {
+		return nil
+	}
+	return w.partitions[w.next.Inc()%uint64(len(w.partitions))]
+}
+
+// seriesDecodingPartition is a partition of the seriesDecodingWorkers. The workers are started when the jobs
+// are submitted and exit when there are no more queued jobs, up to maxWorkers running at the same time.
+type seriesDecodingPartition struct {
+	chunkPool  pool.Bytes
+	maxWorkers int
+
+	mtx     sync.Mutex
+	workers int
+	jobs    []func()
+
+	contentions prometheus.Counter
+	queued      prometheus.Gauge
+}
+
+// run runs job on a worker of the partition, or queues it if all the workers are busy.
+func (p *seriesDecodingPartition) run(job func()) {
+	p.lock()
+	if p.workers < p.maxWorkers {
+		p.workers++
+		p.mtx.Unlock()
+
+		go p.work(job)
+		return
+	}
+
+	p.jobs = append(p.jobs, job)
+	p.queued.Inc()
+	p.mtx.Unlock()
+}
+
+// work runs job and then the queued jobs, until there are none.
+func (p *seriesDecodingPartition) work(job func()) {
+	for {
+		job()
+
+		p.lock()
+		if len(p.jobs) == 0 {
+			p.workers--
+			p.mtx.Unlock()
+			return
+		}
+
+		job = p.jobs[0]
+		p.jobs[0] = nil
+		p.jobs = p.jobs[1:]
+		p.queued.Dec()
+		p.mtx.Unlock()
+	}
+}
+
+func (p *seriesDecodingPartition) lock() {
+	if !p.mtx.TryLock() {
+		p.contentions.Inc()
+		p.mtx.Lock()
+	}
+}
+
+// seriesDecodingGroup runs functions and waits for them like an errgroup.Group with a context, on the workers
+// of a partition, or on their own goroutine if the partition is nil.
+type seriesDecodingGroup struct {
+	partition *seriesDecodingPartition
+	cancel    context.CancelFunc
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// newSeriesDecodingGroup returns a seriesDecodingGroup running its functions on partition, and a context
+// derived from ctx which is canceled the first time a function returns an error or Wait returns.
+func newSeriesDecodingGroup(ctx context.Context, partition *seriesDecodingPartition) (*seriesDecodingGroup, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &seriesDecodingGroup{partition: partition, cancel: cancel}, ctx
+}
+
+// Go runs f, and cancels the context of the group if it returns an error.
+func (g *seriesDecodingGroup) Go(f func() error) {
+	g.wg.Add(1)
+
+	job := func() {
+		defer g.wg.Done()
+
+		if err := f(); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}
+
+	if g.partition == nil {
+		go job()
+		return
+	}
+	g.partition.run(job)
+}
+
+// Wait blocks until all the functions have returned, and returns the first error returned by any of them.
+func (g *seriesDecodingGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
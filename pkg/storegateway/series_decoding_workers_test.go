@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/util/pool"
+)
+
+func TestSeriesDecodingWorkers_Partition(t *testing.T) {
+	var nilWorkers *seriesDecodingWorkers
+	assert.Nil(t, nilWorkers.partition())
+
+	chunkPools := []pool.Bytes{pool.NoopBytes{}, pool.NoopBytes{}, pool.NoopBytes{}}
+	w := newSeriesDecodingWorkers(chunkPools, 1, nil)
+
+	// The partitions are assigned to the requests in turn.
+	assigned := map[*seriesDecodingPartition]int{}
+	for i := 0; i < 3*len(chunkPools); i++ {
+		assigned[w.partition()]++
+	}
+	require.Len(t, assigned, len(chunkPools))
+	for _, p := range w.partitions {
+		assert.Equal(t, 3, assigned[p])
+	}
+}
+
+func TestSeriesDecodingPartition_ShouldRunUpToMaxWorkers(t *testing.T) {
+	const maxWorkers = 2
+
+	reg := prometheus.NewPedanticRegistry()
+	p := newSeriesDecodingWorkers([]pool.Bytes{pool.NoopBytes{}}, maxWorkers, reg).partition()
+
+	var running, maxRunning atomic.Int64
+	started := make(chan struct{})
+	release := make(chan struct{})
+	g, _ := newSeriesDecodingGroup(context.Background(), p)
+	for i := 0; i < 5; i++ {
+		g.Go(func() error {
+			r := running.Inc()
+			for m := maxRunning.Load(); r > m && !maxRunning.CAS(m, r); m = maxRunning.Load() {
+			}
+			started <- struct{}{}
+			<-release
+			running.Dec()
+			return nil
+		})
+	}
+
+	// The jobs exceeding the max workers are queued until a worker is done.
+	for i := 0; i < maxWorkers; i++ {
+		<-started
+	}
+	assert.Equal(t, 3.0, testutil.ToFloat64(p.queued))
+
+	close(release)
+	for i := maxWorkers; i < 5; i++ {
+		<-started
+	}
+	require.NoError(t, g.Wait())
+
+	assert.Equal(t, int64(maxWorkers), maxRunning.Load())
+	assert.Equal(t, 0.0, testutil.ToFloat64(p.queued))
+
+	// The workers exit once there are no more queued jobs.
+	p.mtx.Lock()
+	assert.Equal(t, 0, p.workers)
+	p.mtx.Unlock()
+}
+
+func TestSeriesDecodingGroup(t *testing.T) {
+	for name, partition := range map[string]*seriesDecodingPartition{
+		"without partition": nil,
+		"with partition":    newSeriesDecodingWorkers([]pool.Bytes{pool.NoopBytes{}}, 1, nil).partition(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("should wait for all the functions", func(t *testing.T) {
+				var calls atomic.Int64
+				g, ctx := newSeriesDecodingGroup(context.Background(), partition)
+				for i := 0; i < 10; i++ {
+					g.Go(func() error {
+						calls.Inc()
+						return nil
+					})
+				}
+				require.NoError(t, g.Wait())
+				assert.Equal(t, int64(10), calls.Load())
+
+				// The context is canceled once Wait returns.
+				assert.ErrorIs(t, ctx.Err(), context.Canceled)
+			})
+
+			t.Run("should return the first error and cancel the context", func(t *testing.T) {
+				expectedErr := errors.New("failed")
+				g, ctx := newSeriesDecodingGroup(context.Background(), partition)
+				g.Go(func() error {
+					return expectedErr
+				})
+				g.Go(func() error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+				assert.Equal(t, expectedErr, g.Wait())
+			})
+		})
+	}
+}
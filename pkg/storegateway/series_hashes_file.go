@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/hashcache"
+)
+
+const (
+	seriesHashesFilename = "series-hashes"
+
+	// seriesHashRecordSize is the size of each record of the file: the series ref, its hash and the CRC32 of both.
+	seriesHashRecordSize = 8 + 8 + 4
+
+	// seriesHashesFlushThreshold is the number of series hashes after which the pending ones are appended to the file.
+	seriesHashesFlushThreshold = 4096
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// persistedSeriesHashes persists the series hashes of a block computed by the sharded queries to a file in the
+// local directory of the block, so that they're loaded into the series hash cache the first time the block is
+// queried again, like after a restart. The series hashes of a block never change, so the file is only appended to.
+type persistedSeriesHashes struct {
+	path   string
+	logger log.Logger
+
+	// maxRecords is the number of series of the block. The hashes evicted from the cache after being loaded are
+	// appended again, so the file is capped to the size of the unique hashes of the block, and it's compacted
+	// when loaded.
+	maxRecords uint64
+
+	loadOnce sync.Once
+
+	mtx     sync.Mutex
+	records uint64
+	pending []byte
+}
+
+func newPersistedSeriesHashes(blockDir string, numSeries uint64, logger log.Logger) *persistedSeriesHashes {
+	return &persistedSeriesHashes{
+		path:       filepath.Join(blockDir, seriesHashesFilename),
+		logger:     logger,
+		maxRecords: numSeries,
+	}
+}
+
+// loadInto loads the persisted series hashes into the cache of the block. Only the first call loads them.
+func (p *persistedSeriesHashes) loadInto(cache *hashcache.BlockSeriesHashCache) {
+	p.loadOnce.Do(func() {
+		hashes, err := readSeriesHashes(p.path)
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			level.Warn(p.logger).Log("msg", "failed to read the persisted series hashes", "path", p.path, "err", err)
+		}
+
+		for id, hash := range hashes {
+			cache.Store(id, hash)
+		}
+
+		p.mtx.Lock()
+		defer p.mtx.Unlock()
+
+		p.records = uint64(len(hashes))
+		if err != nil || fileRecords(p.path) != p.records {
+			// Rewrite the file without the duplicated and the corrupted records.
+			if err := writeSeriesHashes(p.path, hashes); err != nil {
+				level.Warn(p.logger).Log("msg", "failed to rewrite the persisted series hashes", "path", p.path, "err", err)
+			}
+		}
+	})
+}
+
+// add records the hash of a series computed by a query. The hashes are appended to the file in batches.
+func (p *persistedSeriesHashes) add(id storage.SeriesRef, hash uint64) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.maxRecords > 0 && p.records >= p.maxRecords {
+		return
+	}
+	p.records++
+	p.pending = appendSeriesHashRecord(p.pending, id, hash)
+
+	if len(p.pending) >= seriesHashesFlushThreshold*seriesHashRecordSize {
+		p.flushLocked()
+	}
+}
+
+// flush appends the pending series hashes to the file.
+func (p *persistedSeriesHashes) flush() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.flushLocked()
+}
+
+func (p *persistedSeriesHashes) flushLocked() {
+	if len(p.pending) == 0 {
+		return
+	}
+
+	if err := appendToFile(p.path, p.pending); err != nil {
+		level.Warn(p.logger).Log("msg", "failed to persist the series hashes", "path", p.path, "err", err)
+	}
+	p.pending = p.pending[:0]
+}
+
+func appendSeriesHashRecord(b []byte, id storage.SeriesRef, hash uint64) []byte {
+	var record [seriesHashRecordSize]byte
+	binary.LittleEndian.PutUint64(record[0:], uint64(id))
+	binary.LittleEndian.PutUint64(record[8:], hash)
+	binary.LittleEndian.PutUint32(record[16:], crc32.Checksum(record[:16], castagnoliTable))
+	return append(b, record[:]...)
+}
+
+// readSeriesHashes reads the series hashes of the file. The reading stops at the first corrupted or truncated
+// record, returning the hashes read so far along with an error.
+func readSeriesHashes(path string) (map[storage.SeriesRef]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[storage.SeriesRef]uint64, len(data)/seriesHashRecordSize)
+	for ; len(data) >= seriesHashRecordSize; data = data[seriesHashRecordSize:] {
+		if crc32.Checksum(data[:16], castagnoliTable) != binary.LittleEndian.Uint32(data[16:seriesHashRecordSize]) {
+			return hashes, errors.New("corrupted series hash record")
+		}
+		hashes[storage.SeriesRef(binary.LittleEndian.Uint64(data))] = binary.LittleEndian.Uint64(data[8:])
+	}
+	if len(data) > 0 {
+		return hashes, errors.New("truncated series hash record")
+	}
+	return hashes, nil
+}
+
+func writeSeriesHashes(path string, hashes map[storage.SeriesRef]uint64) error {
+	data := make([]byte, 0, len(hashes)*seriesHashRecordSize)
+	for id, hash := range hashes {
+		data = appendSeriesHashRecord(data, id, hash)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func appendToFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fileRecords returns the number of complete records in the file, or 0 if it can't be read.
+func fileRecords(path string) uint64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return uint64(info.Size() / seriesHashRecordSize)
+}
+
+// persistingSeriesHasher is a cachedSeriesHasher which persists the series hashes it computes.
+type persistingSeriesHasher struct {
+	cachedSeriesHasher
+	persisted *persistedSeriesHashes
+}
+
+func (h persistingSeriesHasher) Hash(id storage.SeriesRef, lset labels.Labels, stats *queryStats) uint64 {
+	hash, ok := h.CachedHash(id, stats)
+	if !ok {
+		hash = labels.StableHash(lset)
+		h.cache.Store(id, hash)
+		h.persisted.add(id, hash)
+	}
+	return hash
+}
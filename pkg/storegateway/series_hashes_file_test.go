@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/hashcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistedSeriesHashes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, seriesHashesFilename)
+
+	// Nothing is loaded, and no file is created, if none has been persisted.
+	p := newPersistedSeriesHashes(dir, 10, log.NewNopLogger())
+	cache := hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache("block")
+	p.loadInto(cache)
+	assert.NoFileExists(t, path)
+
+	p.add(1, 100)
+	p.add(2, 200)
+	assert.NoFileExists(t, path, "the series hashes are appended in batches")
+	p.flush()
+	p.add(2, 200) // Duplicated, like after the hash has been evicted from the cache.
+	p.add(3, 300)
+	p.flush()
+	assert.Equal(t, uint64(4), fileRecords(path))
+
+	// The series hashes are loaded by the next process, and the duplicated ones are compacted.
+	p = newPersistedSeriesHashes(dir, 10, log.NewNopLogger())
+	cache = hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache("block")
+	p.loadInto(cache)
+	for id, expected := range map[storage.SeriesRef]uint64{1: 100, 2: 200, 3: 300} {
+		hash, ok := cache.Fetch(id)
+		require.True(t, ok)
+		assert.Equal(t, expected, hash)
+	}
+	assert.Equal(t, uint64(3), fileRecords(path))
+
+	// Only the first call loads them.
+	other := hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache("block")
+	p.loadInto(other)
+	_, ok := other.Fetch(1)
+	assert.False(t, ok)
+
+	// The file is capped to the number of series of the block.
+	for id := storage.SeriesRef(4); id <= 20; id++ {
+		p.add(id, uint64(id)*100)
+	}
+	p.flush()
+	assert.Equal(t, uint64(10), fileRecords(path))
+}
+
+func TestPersistedSeriesHashes_ShouldSkipCorruptedRecords(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, seriesHashesFilename)
+
+	p := newPersistedSeriesHashes(dir, 0, log.NewNopLogger())
+	p.loadInto(hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache("block"))
+	p.add(1, 100)
+	p.add(2, 200)
+	p.flush()
+
+	// Corrupt the second record, and append a truncated one.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[seriesHashRecordSize+8]++
+	require.NoError(t, os.WriteFile(path, append(data, 1, 2, 3), 0o644))
+
+	p = newPersistedSeriesHashes(dir, 0, log.NewNopLogger())
+	cache := hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache("block")
+	p.loadInto(cache)
+
+	hash, ok := cache.Fetch(1)
+	require.True(t, ok)
+	assert.Equal(t, uint64(100), hash)
+	_, ok = cache.Fetch(2)
+	assert.False(t, ok)
+
+	// The file has been rewritten without the corrupted records.
+	hashes, err := readSeriesHashes(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[storage.SeriesRef]uint64{1: 100}, hashes)
+}
+
+func TestPersistingSeriesHasher(t *testing.T) {
+	dir := t.TempDir()
+	cache := hashcache.NewSeriesHashCache(1024 * 1024).GetBlockCache("block")
+	persisted := newPersistedSeriesHashes(dir, 0, log.NewNopLogger())
+	persisted.loadInto(cache)
+
+	hasher := persistingSeriesHasher{cachedSeriesHasher{cache}, persisted}
+	lset := labels.FromStrings("__name__", "up")
+	stats := newSafeQueryStats()
+
+	// The hash is computed, and persisted, only the first time.
+	stats.update(func(stats *queryStats) {
+		assert.Equal(t, labels.StableHash(lset), hasher.Hash(1, lset, stats))
+		assert.Equal(t, labels.StableHash(lset), hasher.Hash(1, lset, stats))
+	})
+	persisted.flush()
+
+	hashes, err := readSeriesHashes(filepath.Join(dir, seriesHashesFilename))
+	require.NoError(t, err)
+	assert.Equal(t, map[storage.SeriesRef]uint64{1: labels.StableHash(lset)}, hashes)
+	assert.Equal(t, 1, stats.export().seriesHashCacheHits)
+}
@@ -44,6 +44,13 @@ var (
 const (
 	ErrSeriesLimitMessage = "exceeded series limit"
 	ErrChunksLimitMessage = "exceeded chunks limit"
+
+	ErrPostingsBytesLimitMessage       = "exceeded postings bytes limit"
+	ErrSeriesBytesLimitMessage         = "exceeded series bytes limit"
+	ErrChunksBytesLimitMessage         = "exceeded chunks bytes limit"
+	ErrTenantPostingsBytesLimitMessage = "exceeded tenant postings bytes limit"
+	ErrTenantSeriesBytesLimitMessage   = "exceeded tenant series bytes limit"
+	ErrTenantChunksBytesLimitMessage   = "exceeded tenant chunks bytes limit"
 )
 
 // seriesChunkRefsSetIterator is the interface implemented by an iterator returning a sequence of seriesChunkRefsSet.
@@ -534,6 +541,18 @@ func (s *seriesChunkRefsSeriesSet) At() (labels.Labels, []storepb.AggrChunk) {
 	return s.currentIterator.At().lset, nil
 }
 
+// atChunksEstimate returns the number of chunks of the current series, and their size in bytes estimated
+// from the chunk refs loaded from the index, without loading the chunks.
+func (s *seriesChunkRefsSeriesSet) atChunksEstimate() (numChunks, numBytes uint64) {
+	for _, r := range s.currentIterator.At().chunksRanges {
+		for _, c := range r.refs {
+			numChunks++
+			numBytes += uint64(c.length)
+		}
+	}
+	return numChunks, numBytes
+}
+
 func (s *seriesChunkRefsSeriesSet) Err() error {
 	return s.from.Err()
 }
@@ -7,6 +7,7 @@ package storegateway
 
 import (
 	"context"
+	"math"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -16,11 +17,13 @@ import (
 
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
 )
 
 const (
-	shardExcludedMeta = "shard-excluded"
+	// ShardExcludedMeta is the synced metadata label value of the blocks excluded by a sharding strategy.
+	ShardExcludedMeta = "shard-excluded"
 )
 
 var (
@@ -33,15 +36,19 @@ type ShardingStrategy interface {
 	FilterUsers(ctx context.Context, userIDs []string) ([]string, error)
 
 	// FilterBlocks filters metas in-place keeping only blocks that should be loaded by the store-gateway.
-	// The provided loaded map contains blocks which have been previously returned by this function and
-	// are now loaded or loading in the store-gateway.
-	FilterBlocks(ctx context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error
+	// The provided blocksSize is the total size of all the user's blocks, 0 if unknown. The provided
+	// loaded map contains blocks which have been previously returned by this function and are now loaded
+	// or loading in the store-gateway.
+	FilterBlocks(ctx context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, blocksSize int64, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error
 }
 
 // ShardingLimits is the interface that should be implemented by the limits provider,
 // limiting the scope of the limits to the ones required by sharding strategies.
 type ShardingLimits interface {
 	StoreGatewayTenantShardSize(userID string) int
+	StoreGatewayTenantReplicationFactor(userID string) int
+	StoreGatewayLargeTenantThreshold(userID string) int
+	StoreGatewayLargeTenantShardSize(userID string) int
 }
 
 // ShuffleShardingStrategy is a shuffle sharding strategy, based on the hash ring formed by store-gateways,
@@ -91,7 +98,7 @@ func (s *ShuffleShardingStrategy) FilterUsers(_ context.Context, userIDs []strin
 }
 
 // FilterBlocks implements ShardingStrategy.
-func (s *ShuffleShardingStrategy) FilterBlocks(_ context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error {
+func (s *ShuffleShardingStrategy) FilterBlocks(_ context.Context, userID string, metas map[ulid.ULID]*metadata.Meta, blocksSize int64, loaded map[ulid.ULID]struct{}, synced block.GaugeVec) error {
 	// As a protection, ensure the store-gateway instance is healthy in the ring. If it's unhealthy because it's failing
 	// to heartbeat or get updates from the ring, or even removed from the ring because of the auto-forget feature, then
 	// keep the previously loaded blocks.
@@ -103,7 +110,7 @@ func (s *ShuffleShardingStrategy) FilterBlocks(_ context.Context, userID string,
 				level.Warn(s.logger).Log("msg", "store-gateway is unhealthy in the ring and block has been excluded because was not previously loaded", "block", blockID.String(), "err", err)
 
 				// Skip the block.
-				synced.WithLabelValues(shardExcludedMeta).Inc()
+				synced.WithLabelValues(ShardExcludedMeta).Inc()
 				delete(metas, blockID)
 			}
 		}
@@ -111,14 +118,15 @@ func (s *ShuffleShardingStrategy) FilterBlocks(_ context.Context, userID string,
 		return nil
 	}
 
-	r := GetShuffleShardingSubring(s.r, userID, s.limits)
+	r := GetBlocksShuffleShardingSubring(s.r, userID, s.limits, blocksSize)
+	replicationFactor := GetTenantReplicationFactor(r, userID, s.limits)
 	bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
 
 	for blockID := range metas {
 		key := mimir_tsdb.HashBlockID(blockID)
 
 		// Check if the block is owned by the store-gateway
-		set, err := r.Get(key, BlocksOwnerSync, bufDescs, bufHosts, bufZones)
+		set, err := GetBlockReplicationSet(r, key, BlocksOwnerSync, replicationFactor, bufDescs, bufHosts, bufZones)
 
 		// If an error occurs while checking the ring, we keep the previously loaded blocks.
 		if err != nil {
@@ -128,7 +136,7 @@ func (s *ShuffleShardingStrategy) FilterBlocks(_ context.Context, userID string,
 				level.Warn(s.logger).Log("msg", "failed to check block owner and block has been excluded because was not previously loaded", "block", blockID.String(), "err", err)
 
 				// Skip the block.
-				synced.WithLabelValues(shardExcludedMeta).Inc()
+				synced.WithLabelValues(ShardExcludedMeta).Inc()
 				delete(metas, blockID)
 			}
 
@@ -145,7 +153,7 @@ func (s *ShuffleShardingStrategy) FilterBlocks(_ context.Context, userID string,
 		// for queries.
 		if _, ok := loaded[blockID]; ok {
 			// The ring Get() returns an error if there's no available instance.
-			if _, err := r.Get(key, BlocksOwnerRead, bufDescs, bufHosts, bufZones); err != nil {
+			if _, err := GetBlockReplicationSet(r, key, BlocksOwnerRead, replicationFactor, bufDescs, bufHosts, bufZones); err != nil {
 				// Keep the block.
 				continue
 			}
@@ -154,17 +162,25 @@ func (s *ShuffleShardingStrategy) FilterBlocks(_ context.Context, userID string,
 		// The block is not owned by the store-gateway and there's at least 1 available
 		// authoritative owner available for queries, so we can filter it out (and unload
 		// it if it was loaded).
-		synced.WithLabelValues(shardExcludedMeta).Inc()
+		synced.WithLabelValues(ShardExcludedMeta).Inc()
 		delete(metas, blockID)
 	}
 
 	return nil
 }
 
-// GetShuffleShardingSubring returns the subring to be used for a given user. This function
-// should be used both by store-gateway and querier in order to guarantee the same logic is used.
+// GetShuffleShardingSubring returns the subring of all the store-gateways which may own the blocks of a given
+// user, whatever the size of the user's blocks. This function should be used both by store-gateway and querier
+// in order to guarantee the same logic is used.
 func GetShuffleShardingSubring(ring *ring.Ring, userID string, limits ShardingLimits) ring.ReadRing {
-	shardSize := limits.StoreGatewayTenantShardSize(userID)
+	return GetBlocksShuffleShardingSubring(ring, userID, limits, math.MaxInt64)
+}
+
+// GetBlocksShuffleShardingSubring returns the subring the blocks of a given user are sharded across, given the
+// total size of the user's blocks. This function should be used both by store-gateway and querier in order to
+// guarantee the same logic is used.
+func GetBlocksShuffleShardingSubring(ring *ring.Ring, userID string, limits ShardingLimits, blocksSize int64) ring.ReadRing {
+	shardSize := getTenantShardSize(userID, limits, blocksSize)
 
 	// A shard size of 0 means shuffle sharding is disabled for this specific user,
 	// so we just return the full ring so that blocks will be sharded across all store-gateways.
@@ -175,6 +191,74 @@ func GetShuffleShardingSubring(ring *ring.Ring, userID string, limits ShardingLi
 	return ring.ShuffleShard(userID, shardSize)
 }
 
+// getTenantShardSize returns the shard size of a given user, given the total size of the user's blocks. The blocks
+// of a large tenant are sharded across the large tenant shard size, which always includes the tenant's shard, since
+// the shuffle shard of a bigger size includes the instances of the shuffle shard of a smaller size.
+func getTenantShardSize(userID string, limits ShardingLimits, blocksSize int64) int {
+	shardSize := limits.StoreGatewayTenantShardSize(userID)
+	if shardSize <= 0 {
+		return 0
+	}
+
+	threshold := limits.StoreGatewayLargeTenantThreshold(userID)
+	largeShardSize := limits.StoreGatewayLargeTenantShardSize(userID)
+	if threshold > 0 && largeShardSize > shardSize && blocksSize > int64(threshold) {
+		return largeShardSize
+	}
+
+	return shardSize
+}
+
+// GetTenantReplicationFactor returns the replication factor of the blocks of a given user in the input ring.
+// The per-tenant replication factor can only lower the ring replication factor, because the ring doesn't
+// return more replicas than its replication factor.
+func GetTenantReplicationFactor(r ring.ReadRing, userID string, limits ShardingLimits) int {
+	replicationFactor := limits.StoreGatewayTenantReplicationFactor(userID)
+	if replicationFactor <= 0 || replicationFactor > r.ReplicationFactor() {
+		return r.ReplicationFactor()
+	}
+
+	return replicationFactor
+}
+
+// GetBlockReplicationSet returns the replication set of the block with the input key, given the replication
+// factor of the tenant the block belongs to. This function should be used both by store-gateway and querier
+// in order to guarantee the same logic is used.
+func GetBlockReplicationSet(r ring.ReadRing, key uint32, op ring.Operation, replicationFactor int, bufDescs []ring.InstanceDesc, bufHosts, bufZones []string) (ring.ReplicationSet, error) {
+	if replicationFactor <= 0 || replicationFactor >= r.ReplicationFactor() {
+		return r.Get(key, op, bufDescs, bufHosts, bufZones)
+	}
+
+	// The ring always looks up as many instances as its replication factor, so we get the
+	// owners in the ring order and then apply the operation to the first replicationFactor
+	// ones, extending the replication set in the same way the ring does.
+	owners, err := r.Get(key, blocksOwnerAnyState, bufDescs, bufHosts, bufZones)
+	if err != nil {
+		return ring.ReplicationSet{}, err
+	}
+
+	instances := make([]ring.InstanceDesc, 0, replicationFactor)
+	for i, n := 0, replicationFactor; i < n && i < len(owners.Instances); i++ {
+		instance := owners.Instances[i]
+
+		if op.ShouldExtendReplicaSetOnState(instance.State) {
+			n++
+		}
+		if op.IsInstanceInStateHealthy(instance.State) {
+			instances = append(instances, instance)
+		}
+	}
+
+	if len(instances) == 0 {
+		return ring.ReplicationSet{}, ring.ErrTooManyUnhealthyInstances
+	}
+
+	return ring.ReplicationSet{
+		Instances: instances,
+		MaxErrors: len(instances) - 1,
+	}, nil
+}
+
 type shardingMetadataFilterAdapter struct {
 	userID   string
 	strategy ShardingStrategy
@@ -191,10 +275,29 @@ func NewShardingMetadataFilterAdapter(userID string, strategy ShardingStrategy)
 	}
 }
 
-// Filter implements block.MetadataFilter.
+// Filter implements block.MetadataFilter. The size of the blocks is the size of the files listed
+// in their meta.json, since the sharding filter is the first one and gets all the user's blocks.
 // This function is NOT safe for use by multiple goroutines concurrently.
 func (a *shardingMetadataFilterAdapter) Filter(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, synced block.GaugeVec, modified block.GaugeVec) error {
-	if err := a.strategy.FilterBlocks(ctx, a.userID, metas, a.lastBlocks, synced); err != nil {
+	var blocksSize int64
+	for _, m := range metas {
+		for _, f := range m.Thanos.Files {
+			blocksSize += f.SizeBytes
+		}
+	}
+
+	return a.filterBlocks(ctx, metas, blocksSize, synced)
+}
+
+// FilterWithBucketIndex implements MetadataFilterWithBucketIndex. The size of the blocks is
+// the size of all the blocks in the bucket index.
+// This function is NOT safe for use by multiple goroutines concurrently.
+func (a *shardingMetadataFilterAdapter) FilterWithBucketIndex(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, idx *bucketindex.Index, synced block.GaugeVec) error {
+	return a.filterBlocks(ctx, metas, idx.Blocks.SizeBytes(), synced)
+}
+
+func (a *shardingMetadataFilterAdapter) filterBlocks(ctx context.Context, metas map[ulid.ULID]*metadata.Meta, blocksSize int64, synced block.GaugeVec) error {
+	if err := a.strategy.FilterBlocks(ctx, a.userID, metas, blocksSize, a.lastBlocks, synced); err != nil {
 		return err
 	}
 
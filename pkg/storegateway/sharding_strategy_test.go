@@ -21,6 +21,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
 	"github.com/grafana/mimir/pkg/storage/tsdb/metadata"
 	"github.com/grafana/mimir/pkg/util/extprom"
 )
@@ -58,6 +60,7 @@ func TestShuffleShardingStrategy(t *testing.T) {
 	tests := map[string]struct {
 		replicationFactor int
 		limits            ShardingLimits
+		blocksSize        int64
 		setupRing         func(*ring.Desc)
 		prevLoadedBlocks  map[string]map[ulid.ULID]struct{}
 		expectedUsers     []usersExpectation
@@ -191,6 +194,66 @@ func TestShuffleShardingStrategy(t *testing.T) {
 				{instanceID: "instance-3", instanceAddr: "127.0.0.3", blocks: []ulid.ULID{block4 /* replicated: */, block3}},
 			},
 		},
+		"multiple ACTIVE instances in the ring with RF = 2, SS = 3 and tenant RF = 1 (should not replicate blocks)": {
+			replicationFactor: 2,
+			limits:            &shardingLimitsMock{storeGatewayTenantShardSize: 3, storeGatewayTenantReplicationFactor: 1},
+			setupRing: func(r *ring.Desc) {
+				r.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1, block3Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-3", "127.0.0.3", "", []uint32{block4Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			expectedUsers: []usersExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", users: []string{userID}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", users: []string{userID}},
+				{instanceID: "instance-3", instanceAddr: "127.0.0.3", users: []string{userID}},
+			},
+			expectedBlocks: []blocksExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", blocks: []ulid.ULID{block1, block3}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", blocks: []ulid.ULID{block2}},
+				{instanceID: "instance-3", instanceAddr: "127.0.0.3", blocks: []ulid.ULID{block4}},
+			},
+		},
+		"multiple ACTIVE instances in the ring with RF = 1, SS = 3 and tenant RF = 2 (should not replicate blocks beyond the ring RF)": {
+			replicationFactor: 1,
+			limits:            &shardingLimitsMock{storeGatewayTenantShardSize: 3, storeGatewayTenantReplicationFactor: 2},
+			setupRing: func(r *ring.Desc) {
+				r.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1, block3Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-3", "127.0.0.3", "", []uint32{block4Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			expectedUsers: []usersExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", users: []string{userID}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", users: []string{userID}},
+				{instanceID: "instance-3", instanceAddr: "127.0.0.3", users: []string{userID}},
+			},
+			expectedBlocks: []blocksExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", blocks: []ulid.ULID{block1, block3}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", blocks: []ulid.ULID{block2}},
+				{instanceID: "instance-3", instanceAddr: "127.0.0.3", blocks: []ulid.ULID{block4}},
+			},
+		},
+		"JOINING instance in the ring with RF = 2, SS = 3 and tenant RF = 1 should get its blocks, while the previous owner keeps them until the JOINING instance is ACTIVE": {
+			replicationFactor: 2,
+			limits:            &shardingLimitsMock{storeGatewayTenantShardSize: 3, storeGatewayTenantReplicationFactor: 1},
+			setupRing: func(r *ring.Desc) {
+				r.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1, block3Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-3", "127.0.0.3", "", []uint32{block4Hash + 1}, ring.JOINING, registeredAt)
+			},
+			prevLoadedBlocks: map[string]map[ulid.ULID]struct{}{
+				"instance-1": {block1: struct{}{}, block3: struct{}{}, block4: struct{}{}},
+			},
+			expectedUsers: []usersExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", users: []string{userID}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", users: []string{userID}},
+				{instanceID: "instance-3", instanceAddr: "127.0.0.3", users: []string{userID}},
+			},
+			expectedBlocks: []blocksExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", blocks: []ulid.ULID{block1, block3, block4 /* keeping the previously loaded block */}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", blocks: []ulid.ULID{block2}},
+				{instanceID: "instance-3", instanceAddr: "127.0.0.3", blocks: []ulid.ULID{block4}},
+			},
+		},
 		"one unhealthy instance in the ring with RF = 1, SS = 3 and NO previously loaded blocks": {
 			replicationFactor: 1,
 			limits:            &shardingLimitsMock{storeGatewayTenantShardSize: 3},
@@ -333,6 +396,41 @@ func TestShuffleShardingStrategy(t *testing.T) {
 				{instanceID: "instance-3", instanceAddr: "127.0.0.3", blocks: []ulid.ULID{block4}},
 			},
 		},
+		"two ACTIVE instances in the ring with RF = 1, SS = 1 and large tenant SS = 2 (should sync blocks on 2 instances because the tenant is large)": {
+			replicationFactor: 1,
+			limits:            &shardingLimitsMock{storeGatewayTenantShardSize: 1, storeGatewayLargeTenantThreshold: 1000, storeGatewayLargeTenantShardSize: 2},
+			blocksSize:        2000,
+			setupRing: func(r *ring.Desc) {
+				r.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1, block3Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1, block4Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			expectedUsers: []usersExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", users: []string{userID}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", users: []string{userID}},
+			},
+			expectedBlocks: []blocksExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", blocks: []ulid.ULID{block1, block3}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", blocks: []ulid.ULID{block2, block4}},
+			},
+		},
+		"two ACTIVE instances in the ring with RF = 1, SS = 1 and large tenant SS = 2 (should sync blocks on 1 instance because the tenant is not large)": {
+			replicationFactor: 1,
+			limits:            &shardingLimitsMock{storeGatewayTenantShardSize: 1, storeGatewayLargeTenantThreshold: 1000, storeGatewayLargeTenantShardSize: 2},
+			blocksSize:        500,
+			setupRing: func(r *ring.Desc) {
+				r.AddIngester("instance-1", "127.0.0.1", "", []uint32{block1Hash + 1, block3Hash + 1}, ring.ACTIVE, registeredAt)
+				r.AddIngester("instance-2", "127.0.0.2", "", []uint32{block2Hash + 1, block4Hash + 1}, ring.ACTIVE, registeredAt)
+			},
+			expectedUsers: []usersExpectation{
+				// The user is synced by all the instances of the large tenant shard, since its blocks size is unknown.
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", users: []string{userID}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", users: []string{userID}},
+			},
+			expectedBlocks: []blocksExpectation{
+				{instanceID: "instance-1", instanceAddr: "127.0.0.1", blocks: []ulid.ULID{block1, block2, block3, block4}},
+				{instanceID: "instance-2", instanceAddr: "127.0.0.2", blocks: []ulid.ULID{}},
+			},
+		},
 		"SS = 0 disables shuffle sharding": {
 			replicationFactor: 1,
 			limits:            &shardingLimitsMock{storeGatewayTenantShardSize: 0},
@@ -395,7 +493,7 @@ func TestShuffleShardingStrategy(t *testing.T) {
 			for _, expected := range testData.expectedBlocks {
 				filter := NewShuffleShardingStrategy(r, expected.instanceID, expected.instanceAddr, testData.limits, log.NewNopLogger())
 				synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{}, []string{"state"})
-				synced.WithLabelValues(shardExcludedMeta).Set(0)
+				synced.WithLabelValues(ShardExcludedMeta).Set(0)
 
 				metas := map[ulid.ULID]*metadata.Meta{
 					block1: {},
@@ -404,7 +502,7 @@ func TestShuffleShardingStrategy(t *testing.T) {
 					block4: {},
 				}
 
-				err = filter.FilterBlocks(ctx, userID, metas, testData.prevLoadedBlocks[expected.instanceID], synced)
+				err = filter.FilterBlocks(ctx, userID, metas, testData.blocksSize, testData.prevLoadedBlocks[expected.instanceID], synced)
 				require.NoError(t, err)
 
 				var actualBlocks []ulid.ULID
@@ -422,10 +520,68 @@ func TestShuffleShardingStrategy(t *testing.T) {
 	}
 }
 
+func TestShardingMetadataFilterAdapter_ShouldPassTheBlocksSize(t *testing.T) {
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	strategy := &blocksSizeRecordingStrategy{}
+	filter := NewShardingMetadataFilterAdapter("user-1", strategy)
+	synced := extprom.NewTxGaugeVec(nil, prometheus.GaugeOpts{}, []string{"state"})
+
+	t.Run("should sum the size of the files of the metas", func(t *testing.T) {
+		metas := map[ulid.ULID]*metadata.Meta{
+			block1: {Thanos: metadata.Thanos{Files: []metadata.File{{RelPath: "index", SizeBytes: 10}, {RelPath: "chunks/000001", SizeBytes: 20}}}},
+			block2: {Thanos: metadata.Thanos{Files: []metadata.File{{RelPath: "index", SizeBytes: 30}}}},
+		}
+		require.NoError(t, filter.Filter(context.Background(), metas, synced, nil))
+		assert.Equal(t, int64(60), strategy.blocksSize)
+	})
+
+	t.Run("should sum the size of all the blocks in the bucket index", func(t *testing.T) {
+		idx := &bucketindex.Index{Blocks: bucketindex.Blocks{
+			{ID: block1, SizeBytes: 100},
+			{ID: block2, SizeBytes: 200},
+		}}
+		// The blocks size includes the blocks already filtered out.
+		metas := map[ulid.ULID]*metadata.Meta{block1: idx.Blocks[0].ThanosMeta()}
+		require.NoError(t, filter.(MetadataFilterWithBucketIndex).FilterWithBucketIndex(context.Background(), metas, idx, synced))
+		assert.Equal(t, int64(300), strategy.blocksSize)
+	})
+}
+
+// blocksSizeRecordingStrategy is a ShardingStrategy which keeps all blocks and records the blocks size it's called with.
+type blocksSizeRecordingStrategy struct {
+	blocksSize int64
+}
+
+func (s *blocksSizeRecordingStrategy) FilterUsers(_ context.Context, userIDs []string) ([]string, error) {
+	return userIDs, nil
+}
+
+func (s *blocksSizeRecordingStrategy) FilterBlocks(_ context.Context, _ string, _ map[ulid.ULID]*metadata.Meta, blocksSize int64, _ map[ulid.ULID]struct{}, _ block.GaugeVec) error {
+	s.blocksSize = blocksSize
+	return nil
+}
+
 type shardingLimitsMock struct {
-	storeGatewayTenantShardSize int
+	storeGatewayTenantShardSize         int
+	storeGatewayTenantReplicationFactor int
+	storeGatewayLargeTenantThreshold    int
+	storeGatewayLargeTenantShardSize    int
 }
 
 func (m *shardingLimitsMock) StoreGatewayTenantShardSize(_ string) int {
 	return m.storeGatewayTenantShardSize
 }
+
+func (m *shardingLimitsMock) StoreGatewayTenantReplicationFactor(_ string) int {
+	return m.storeGatewayTenantReplicationFactor
+}
+
+func (m *shardingLimitsMock) StoreGatewayLargeTenantThreshold(_ string) int {
+	return m.storeGatewayLargeTenantThreshold
+}
+
+func (m *shardingLimitsMock) StoreGatewayLargeTenantShardSize(_ string) int {
+	return m.storeGatewayLargeTenantShardSize
+}
@@ -68,6 +68,9 @@ type queryStats struct {
 	// ready to send it to the client.
 	streamingSeriesWaitBatchLoadedDuration time.Duration
 
+	// The total time spent waiting until the chunks of the next batch fit in the memory budget of the request.
+	streamingSeriesWaitMemoryBudgetDuration time.Duration
+
 	// The Series() request timing breakdown.
 	streamingSeriesExpandPostingsDuration       time.Duration
 	streamingSeriesFetchSeriesAndChunksDuration time.Duration
@@ -124,6 +127,7 @@ func (s queryStats) merge(o *queryStats) *queryStats {
 	s.streamingSeriesBatchCount += o.streamingSeriesBatchCount
 	s.streamingSeriesBatchLoadDuration += o.streamingSeriesBatchLoadDuration
 	s.streamingSeriesWaitBatchLoadedDuration += o.streamingSeriesWaitBatchLoadedDuration
+	s.streamingSeriesWaitMemoryBudgetDuration += o.streamingSeriesWaitMemoryBudgetDuration
 	s.streamingSeriesExpandPostingsDuration += o.streamingSeriesExpandPostingsDuration
 	s.streamingSeriesFetchSeriesAndChunksDuration += o.streamingSeriesFetchSeriesAndChunksDuration
 	s.streamingSeriesEncodeResponseDuration += o.streamingSeriesEncodeResponseDuration
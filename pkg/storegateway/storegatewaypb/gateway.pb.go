@@ -11,7 +11,11 @@ import (
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	io "io"
 	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strings "strings"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -25,27 +29,193 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+type SyncTenantsRequest struct {
+	TenantIds []string `protobuf:"bytes,1,rep,name=tenant_ids,json=tenantIds,proto3" json:"tenant_ids,omitempty"`
+}
+
+func (m *SyncTenantsRequest) Reset()      { *m = SyncTenantsRequest{} }
+func (*SyncTenantsRequest) ProtoMessage() {}
+func (*SyncTenantsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f1a937782ebbded5, []int{0}
+}
+func (m *SyncTenantsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SyncTenantsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SyncTenantsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SyncTenantsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncTenantsRequest.Merge(m, src)
+}
+func (m *SyncTenantsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *SyncTenantsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncTenantsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncTenantsRequest proto.InternalMessageInfo
+
+func (m *SyncTenantsRequest) GetTenantIds() []string {
+	if m != nil {
+		return m.TenantIds
+	}
+	return nil
+}
+
+type SyncTenantsResponse struct {
+}
+
+func (m *SyncTenantsResponse) Reset()      { *m = SyncTenantsResponse{} }
+func (*SyncTenantsResponse) ProtoMessage() {}
+func (*SyncTenantsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f1a937782ebbded5, []int{1}
+}
+func (m *SyncTenantsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SyncTenantsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SyncTenantsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SyncTenantsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncTenantsResponse.Merge(m, src)
+}
+func (m *SyncTenantsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *SyncTenantsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncTenantsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncTenantsResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*SyncTenantsRequest)(nil), "gatewaypb.SyncTenantsRequest")
+	proto.RegisterType((*SyncTenantsResponse)(nil), "gatewaypb.SyncTenantsResponse")
+}
+
 func init() { proto.RegisterFile("gateway.proto", fileDescriptor_f1a937782ebbded5) }
 
 var fileDescriptor_f1a937782ebbded5 = []byte{
-	// 263 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x90, 0xbd, 0x4a, 0x04, 0x31,
-	0x14, 0x46, 0x93, 0x66, 0xc1, 0xf8, 0x53, 0x04, 0x14, 0x5c, 0xe1, 0x3e, 0xc2, 0x44, 0xb4, 0x12,
-	0x3b, 0x15, 0x6d, 0xc4, 0xc2, 0x05, 0x0b, 0xbb, 0x9b, 0xe5, 0x3a, 0x3b, 0xb8, 0x33, 0x89, 0x49,
-	0x06, 0xb1, 0xf3, 0x11, 0x7c, 0x0c, 0x1f, 0xc5, 0x72, 0x2a, 0xd9, 0xd2, 0xc9, 0x34, 0x96, 0xfb,
-	0x08, 0xe2, 0x66, 0x07, 0x7f, 0xd8, 0xf2, 0x3b, 0xf7, 0x70, 0x8a, 0x2b, 0x36, 0x73, 0x0c, 0xf4,
-	0x88, 0x4f, 0x99, 0x75, 0x26, 0x18, 0xb9, 0xb6, 0x9c, 0x56, 0x0f, 0x8f, 0xf3, 0x22, 0x4c, 0x6a,
-	0x9d, 0x8d, 0x4d, 0xa9, 0x72, 0x87, 0x77, 0x58, 0xa1, 0x2a, 0x8b, 0xb2, 0x70, 0xca, 0xde, 0xe7,
-	0xca, 0x07, 0xe3, 0x68, 0x29, 0xa7, 0x61, 0xb5, 0x72, 0x76, 0x9c, 0x3a, 0x07, 0xef, 0x5c, 0x6c,
-	0x8c, 0xbe, 0xe9, 0x45, 0x52, 0xe4, 0x91, 0x18, 0x8c, 0xc8, 0x15, 0xe4, 0xe5, 0x76, 0x16, 0x26,
-	0x58, 0x19, 0x9f, 0xa5, 0x7d, 0x4d, 0x0f, 0x35, 0xf9, 0x30, 0xdc, 0xf9, 0x8f, 0xbd, 0x35, 0x95,
-	0xa7, 0x7d, 0x2e, 0x4f, 0x85, 0xb8, 0x44, 0x4d, 0xd3, 0x2b, 0x2c, 0xc9, 0xcb, 0xdd, 0xde, 0xfb,
-	0x61, 0x7d, 0x62, 0xb8, 0xea, 0x94, 0x32, 0xf2, 0x5c, 0xac, 0x2f, 0xe8, 0x0d, 0x4e, 0x6b, 0xf2,
-	0xf2, 0xaf, 0x9a, 0x60, 0x9f, 0xd9, 0x5b, 0x79, 0x4b, 0x9d, 0x93, 0xb3, 0xa6, 0x05, 0x36, 0x6b,
-	0x81, 0xcd, 0x5b, 0xe0, 0xcf, 0x11, 0xf8, 0x6b, 0x04, 0xfe, 0x16, 0x81, 0x37, 0x11, 0xf8, 0x47,
-	0x04, 0xfe, 0x19, 0x81, 0xcd, 0x23, 0xf0, 0x97, 0x0e, 0x58, 0xd3, 0x01, 0x9b, 0x75, 0xc0, 0x6e,
-	0xb7, 0x7e, 0xbf, 0xcb, 0x6a, 0x3d, 0x58, 0x7c, 0xe9, 0xf0, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xb9,
-	0xbe, 0x15, 0x37, 0x7e, 0x01, 0x00, 0x00,
+	// 350 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x92, 0xcd, 0x4e, 0xf2, 0x40,
+	0x14, 0x86, 0x3b, 0xdf, 0x97, 0x90, 0x74, 0x50, 0x17, 0x63, 0x30, 0x50, 0xc3, 0x89, 0x61, 0xe5,
+	0xaa, 0x35, 0xb2, 0x32, 0x6e, 0x8c, 0xbf, 0x31, 0x21, 0x2e, 0xc0, 0xb8, 0x70, 0x63, 0xa6, 0x30,
+	0x96, 0x46, 0xda, 0x8e, 0x33, 0x43, 0x94, 0x9d, 0x97, 0xe0, 0x65, 0x78, 0x29, 0x2e, 0x59, 0xb2,
+	0x94, 0x21, 0x31, 0x2e, 0xb9, 0x04, 0x23, 0xd3, 0x22, 0x68, 0x5d, 0x9e, 0xe7, 0x7d, 0xfb, 0x9c,
+	0x64, 0x7a, 0xf0, 0x6a, 0x40, 0x15, 0x7b, 0xa0, 0x03, 0x97, 0x8b, 0x44, 0x25, 0xc4, 0x4e, 0x47,
+	0xee, 0x3b, 0xfb, 0x41, 0xa8, 0xba, 0x7d, 0xdf, 0x6d, 0x27, 0x91, 0x17, 0x08, 0x7a, 0x4b, 0x63,
+	0xea, 0x45, 0x61, 0x14, 0x0a, 0x8f, 0xdf, 0x05, 0x9e, 0x54, 0x89, 0x60, 0x69, 0xd9, 0x0c, 0xdc,
+	0xf7, 0x04, 0x6f, 0x1b, 0x4f, 0xad, 0x8e, 0x49, 0x6b, 0x10, 0xb7, 0x2f, 0x59, 0x4c, 0x63, 0x25,
+	0x9b, 0xec, 0xbe, 0xcf, 0xa4, 0x22, 0x55, 0x8c, 0xd5, 0x8c, 0xdc, 0x84, 0x1d, 0x59, 0x46, 0x5b,
+	0xff, 0xb7, 0xed, 0xa6, 0x6d, 0xc8, 0x79, 0x47, 0xd6, 0x4a, 0x78, 0x7d, 0xe9, 0x23, 0xc9, 0x93,
+	0x58, 0xb2, 0xdd, 0xf7, 0x7f, 0x78, 0xa5, 0xf5, 0xb5, 0xe1, 0xcc, 0xac, 0x23, 0x7b, 0xb8, 0xd0,
+	0x62, 0x22, 0x64, 0x92, 0x94, 0x5c, 0xd5, 0xa5, 0x71, 0x22, 0x5d, 0x33, 0xa7, 0x7b, 0x9c, 0x8d,
+	0x9f, 0xd8, 0x98, 0x76, 0x10, 0x39, 0xc2, 0xb8, 0x41, 0x7d, 0xd6, 0xbb, 0xa0, 0x11, 0x93, 0xa4,
+	0x92, 0xf5, 0xbe, 0x59, 0xa6, 0x70, 0xf2, 0x22, 0xa3, 0x21, 0xa7, 0xb8, 0x38, 0xa3, 0x57, 0xb4,
+	0xd7, 0x67, 0x92, 0x2c, 0x57, 0x0d, 0xcc, 0x34, 0x9b, 0xb9, 0x59, 0xea, 0x39, 0xc0, 0xf6, 0xc9,
+	0x23, 0x8b, 0x78, 0x8f, 0x0a, 0x49, 0xca, 0x59, 0x73, 0x8e, 0x32, 0x47, 0x25, 0x27, 0x49, 0x0d,
+	0x0d, 0x5c, 0x5c, 0x78, 0x31, 0x52, 0x75, 0xe7, 0xbf, 0xcf, 0xfd, 0xfd, 0xfc, 0x0e, 0xfc, 0x15,
+	0x1b, 0xdb, 0xe1, 0xf1, 0x70, 0x0c, 0xd6, 0x68, 0x0c, 0xd6, 0x74, 0x0c, 0xe8, 0x49, 0x03, 0x7a,
+	0xd1, 0x80, 0x5e, 0x35, 0xa0, 0xa1, 0x06, 0xf4, 0xa6, 0x01, 0x7d, 0x68, 0xb0, 0xa6, 0x1a, 0xd0,
+	0xf3, 0x04, 0xac, 0xe1, 0x04, 0xac, 0xd1, 0x04, 0xac, 0xeb, 0xb5, 0xc5, 0x53, 0xe0, 0xbe, 0x5f,
+	0x98, 0x5d, 0x40, 0xfd, 0x73, 0x00, 0x7e, 0xd1, 0x7c, 0x07, 0x5a, 0x02, 0x00, 0x00,
+}
+
+func (this *SyncTenantsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*SyncTenantsRequest)
+	if !ok {
+		that2, ok := that.(SyncTenantsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.TenantIds) != len(that1.TenantIds) {
+		return false
+	}
+	for i := range this.TenantIds {
+		if this.TenantIds[i] != that1.TenantIds[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *SyncTenantsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*SyncTenantsResponse)
+	if !ok {
+		that2, ok := that.(SyncTenantsResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *SyncTenantsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storegatewaypb.SyncTenantsRequest{")
+	s = append(s, "TenantIds: "+fmt.Sprintf("%#v", this.TenantIds)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *SyncTenantsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&storegatewaypb.SyncTenantsResponse{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringGateway(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -72,6 +242,11 @@ type StoreGatewayClient interface {
 	LabelNames(ctx context.Context, in *storepb.LabelNamesRequest, opts ...grpc.CallOption) (*storepb.LabelNamesResponse, error)
 	// LabelValues returns all label values for given label name.
 	LabelValues(ctx context.Context, in *storepb.LabelValuesRequest, opts ...grpc.CallOption) (*storepb.LabelValuesResponse, error)
+	// Exemplars returns the exemplars persisted in the blocks for the given label matchers and time range.
+	Exemplars(ctx context.Context, in *storepb.ExemplarsRequest, opts ...grpc.CallOption) (*storepb.ExemplarsResponse, error)
+	// SyncTenants asks the store-gateway to sync the blocks of the given tenants from the bucket, without waiting
+	// for the next periodic sync. It's used to discover the newly uploaded or deleted blocks faster.
+	SyncTenants(ctx context.Context, in *SyncTenantsRequest, opts ...grpc.CallOption) (*SyncTenantsResponse, error)
 }
 
 type storeGatewayClient struct {
@@ -132,6 +307,24 @@ func (c *storeGatewayClient) LabelValues(ctx context.Context, in *storepb.LabelV
 	return out, nil
 }
 
+func (c *storeGatewayClient) Exemplars(ctx context.Context, in *storepb.ExemplarsRequest, opts ...grpc.CallOption) (*storepb.ExemplarsResponse, error) {
+	out := new(storepb.ExemplarsResponse)
+	err := c.cc.Invoke(ctx, "/gatewaypb.StoreGateway/Exemplars", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeGatewayClient) SyncTenants(ctx context.Context, in *SyncTenantsRequest, opts ...grpc.CallOption) (*SyncTenantsResponse, error) {
+	out := new(SyncTenantsResponse)
+	err := c.cc.Invoke(ctx, "/gatewaypb.StoreGateway/SyncTenants", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StoreGatewayServer is the server API for StoreGateway service.
 type StoreGatewayServer interface {
 	// Series streams each Series for given label matchers and time range.
@@ -146,6 +339,11 @@ type StoreGatewayServer interface {
 	LabelNames(context.Context, *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error)
 	// LabelValues returns all label values for given label name.
 	LabelValues(context.Context, *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error)
+	// Exemplars returns the exemplars persisted in the blocks for the given label matchers and time range.
+	Exemplars(context.Context, *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error)
+	// SyncTenants asks the store-gateway to sync the blocks of the given tenants from the bucket, without waiting
+	// for the next periodic sync. It's used to discover the newly uploaded or deleted blocks faster.
+	SyncTenants(context.Context, *SyncTenantsRequest) (*SyncTenantsResponse, error)
 }
 
 // UnimplementedStoreGatewayServer can be embedded to have forward compatible implementations.
@@ -161,6 +359,12 @@ func (*UnimplementedStoreGatewayServer) LabelNames(ctx context.Context, req *sto
 func (*UnimplementedStoreGatewayServer) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
 }
+func (*UnimplementedStoreGatewayServer) Exemplars(ctx context.Context, req *storepb.ExemplarsRequest) (*storepb.ExemplarsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exemplars not implemented")
+}
+func (*UnimplementedStoreGatewayServer) SyncTenants(ctx context.Context, req *SyncTenantsRequest) (*SyncTenantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncTenants not implemented")
+}
 
 func RegisterStoreGatewayServer(s *grpc.Server, srv StoreGatewayServer) {
 	s.RegisterService(&_StoreGateway_serviceDesc, srv)
@@ -223,6 +427,42 @@ func _StoreGateway_LabelValues_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StoreGateway_Exemplars_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(storepb.ExemplarsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreGatewayServer).Exemplars(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gatewaypb.StoreGateway/Exemplars",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreGatewayServer).Exemplars(ctx, req.(*storepb.ExemplarsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreGateway_SyncTenants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncTenantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreGatewayServer).SyncTenants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gatewaypb.StoreGateway/SyncTenants",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreGatewayServer).SyncTenants(ctx, req.(*SyncTenantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StoreGateway_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "gatewaypb.StoreGateway",
 	HandlerType: (*StoreGatewayServer)(nil),
@@ -235,6 +475,14 @@ var _StoreGateway_serviceDesc = grpc.ServiceDesc{
 			MethodName: "LabelValues",
 			Handler:    _StoreGateway_LabelValues_Handler,
 		},
+		{
+			MethodName: "Exemplars",
+			Handler:    _StoreGateway_Exemplars_Handler,
+		},
+		{
+			MethodName: "SyncTenants",
+			Handler:    _StoreGateway_SyncTenants_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -245,3 +493,349 @@ var _StoreGateway_serviceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "gateway.proto",
 }
+
+func (m *SyncTenantsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SyncTenantsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SyncTenantsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.TenantIds) > 0 {
+		for iNdEx := len(m.TenantIds) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.TenantIds[iNdEx])
+			copy(dAtA[i:], m.TenantIds[iNdEx])
+			i = encodeVarintGateway(dAtA, i, uint64(len(m.TenantIds[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SyncTenantsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SyncTenantsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SyncTenantsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintGateway(dAtA []byte, offset int, v uint64) int {
+	offset -= sovGateway(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *SyncTenantsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.TenantIds) > 0 {
+		for _, s := range m.TenantIds {
+			l = len(s)
+			n += 1 + l + sovGateway(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *SyncTenantsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func sovGateway(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozGateway(x uint64) (n int) {
+	return sovGateway(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *SyncTenantsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SyncTenantsRequest{`,
+		`TenantIds:` + fmt.Sprintf("%v", this.TenantIds) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SyncTenantsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SyncTenantsResponse{`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringGateway(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *SyncTenantsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowGateway
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SyncTenantsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SyncTenantsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TenantIds", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGateway
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGateway
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGateway
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TenantIds = append(m.TenantIds, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipGateway(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthGateway
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthGateway
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SyncTenantsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowGateway
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SyncTenantsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SyncTenantsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipGateway(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthGateway
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthGateway
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipGateway(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowGateway
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowGateway
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowGateway
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthGateway
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupGateway
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthGateway
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthGateway        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowGateway          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupGateway = fmt.Errorf("proto: unexpected end of group")
+)
@@ -31,6 +31,14 @@ func NewHintsSeriesResponse(hints *types.Any) *SeriesResponse {
 	}
 }
 
+func NewWarnSeriesResponse(warning string) *SeriesResponse {
+	return &SeriesResponse{
+		Result: &SeriesResponse_Warning{
+			Warning: warning,
+		},
+	}
+}
+
 func NewStatsResponse(indexBytesFetched int) *SeriesResponse {
 	return &SeriesResponse{
 		Result: &SeriesResponse_Stats{
@@ -39,6 +47,22 @@ func NewStatsResponse(indexBytesFetched int) *SeriesResponse {
 	}
 }
 
+func NewStreamingSeriesResponse(series *StreamingSeriesBatch) *SeriesResponse {
+	return &SeriesResponse{
+		Result: &SeriesResponse_StreamingSeries{
+			StreamingSeries: series,
+		},
+	}
+}
+
+func NewStreamingChunksResponse(series *StreamingChunksBatch) *SeriesResponse {
+	return &SeriesResponse{
+		Result: &SeriesResponse_StreamingChunks{
+			StreamingChunks: series,
+		},
+	}
+}
+
 type emptySeriesSet struct{}
 
 func (emptySeriesSet) Next() bool                       { return false }
@@ -9,6 +9,8 @@ import (
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
 	types "github.com/gogo/protobuf/types"
+	_ "github.com/grafana/mimir/pkg/mimirpb"
+	github_com_grafana_mimir_pkg_mimirpb "github.com/grafana/mimir/pkg/mimirpb"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -40,6 +42,10 @@ type SeriesRequest struct {
 	// The content of this field and whether it's supported depends on the
 	// implementation of a specific store.
 	Hints *types.Any `protobuf:"bytes,9,opt,name=hints,proto3" json:"hints,omitempty"`
+	// If streaming_chunks_batch_size > 0, the series labels are streamed first in StreamingSeriesBatch
+	// messages, followed by the series chunks in StreamingChunksBatch messages of at most
+	// streaming_chunks_batch_size series each. If 0, the series are sent with their chunks.
+	StreamingChunksBatchSize uint64 `protobuf:"varint,100,opt,name=streaming_chunks_batch_size,json=streamingChunksBatchSize,proto3" json:"streaming_chunks_batch_size,omitempty"`
 }
 
 func (m *SeriesRequest) Reset()      { *m = SeriesRequest{} }
@@ -117,6 +123,8 @@ type SeriesResponse struct {
 	//	*SeriesResponse_Warning
 	//	*SeriesResponse_Hints
 	//	*SeriesResponse_Stats
+	//	*SeriesResponse_StreamingSeries
+	//	*SeriesResponse_StreamingChunks
 	Result isSeriesResponse_Result `protobuf_oneof:"result"`
 }
 
@@ -171,11 +179,19 @@ type SeriesResponse_Hints struct {
 type SeriesResponse_Stats struct {
 	Stats *Stats `protobuf:"bytes,4,opt,name=stats,proto3,oneof"`
 }
+type SeriesResponse_StreamingSeries struct {
+	StreamingSeries *StreamingSeriesBatch `protobuf:"bytes,5,opt,name=streaming_series,json=streamingSeries,proto3,oneof"`
+}
+type SeriesResponse_StreamingChunks struct {
+	StreamingChunks *StreamingChunksBatch `protobuf:"bytes,6,opt,name=streaming_chunks,json=streamingChunks,proto3,oneof"`
+}
 
-func (*SeriesResponse_Series) isSeriesResponse_Result()  {}
-func (*SeriesResponse_Warning) isSeriesResponse_Result() {}
-func (*SeriesResponse_Hints) isSeriesResponse_Result()   {}
-func (*SeriesResponse_Stats) isSeriesResponse_Result()   {}
+func (*SeriesResponse_Series) isSeriesResponse_Result()          {}
+func (*SeriesResponse_Warning) isSeriesResponse_Result()         {}
+func (*SeriesResponse_Hints) isSeriesResponse_Result()           {}
+func (*SeriesResponse_Stats) isSeriesResponse_Result()           {}
+func (*SeriesResponse_StreamingSeries) isSeriesResponse_Result() {}
+func (*SeriesResponse_StreamingChunks) isSeriesResponse_Result() {}
 
 func (m *SeriesResponse) GetResult() isSeriesResponse_Result {
 	if m != nil {
@@ -212,6 +228,20 @@ func (m *SeriesResponse) GetStats() *Stats {
 	return nil
 }
 
+func (m *SeriesResponse) GetStreamingSeries() *StreamingSeriesBatch {
+	if x, ok := m.GetResult().(*SeriesResponse_StreamingSeries); ok {
+		return x.StreamingSeries
+	}
+	return nil
+}
+
+func (m *SeriesResponse) GetStreamingChunks() *StreamingChunksBatch {
+	if x, ok := m.GetResult().(*SeriesResponse_StreamingChunks); ok {
+		return x.StreamingChunks
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*SeriesResponse) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -219,8 +249,164 @@ func (*SeriesResponse) XXX_OneofWrappers() []interface{} {
 		(*SeriesResponse_Warning)(nil),
 		(*SeriesResponse_Hints)(nil),
 		(*SeriesResponse_Stats)(nil),
+		(*SeriesResponse_StreamingSeries)(nil),
+		(*SeriesResponse_StreamingChunks)(nil),
+	}
+}
+
+// StreamingSeries is a series whose chunks are streamed separately in StreamingChunks messages.
+type StreamingSeries struct {
+	Labels []github_com_grafana_mimir_pkg_mimirpb.LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=github.com/grafana/mimir/pkg/mimirpb.LabelAdapter" json:"labels"`
+}
+
+func (m *StreamingSeries) Reset()      { *m = StreamingSeries{} }
+func (*StreamingSeries) ProtoMessage() {}
+func (*StreamingSeries) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{3}
+}
+func (m *StreamingSeries) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StreamingSeries) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StreamingSeries.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StreamingSeries) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamingSeries.Merge(m, src)
+}
+func (m *StreamingSeries) XXX_Size() int {
+	return m.Size()
+}
+func (m *StreamingSeries) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamingSeries.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamingSeries proto.InternalMessageInfo
+
+type StreamingSeriesBatch struct {
+	Series []*StreamingSeries `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+	// is_end_of_series_stream is true in the last batch of series, after which the chunks are streamed.
+	IsEndOfSeriesStream bool `protobuf:"varint,2,opt,name=is_end_of_series_stream,json=isEndOfSeriesStream,proto3" json:"is_end_of_series_stream,omitempty"`
+	// The estimated number of chunks of the series in the batch, and their size in bytes, computed from the
+	// index before the chunks are streamed.
+	EstimatedChunksCount uint64 `protobuf:"varint,3,opt,name=estimated_chunks_count,json=estimatedChunksCount,proto3" json:"estimated_chunks_count,omitempty"`
+	EstimatedChunksBytes uint64 `protobuf:"varint,4,opt,name=estimated_chunks_bytes,json=estimatedChunksBytes,proto3" json:"estimated_chunks_bytes,omitempty"`
+}
+
+func (m *StreamingSeriesBatch) Reset()      { *m = StreamingSeriesBatch{} }
+func (*StreamingSeriesBatch) ProtoMessage() {}
+func (*StreamingSeriesBatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{4}
+}
+func (m *StreamingSeriesBatch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StreamingSeriesBatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StreamingSeriesBatch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StreamingSeriesBatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamingSeriesBatch.Merge(m, src)
+}
+func (m *StreamingSeriesBatch) XXX_Size() int {
+	return m.Size()
+}
+func (m *StreamingSeriesBatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamingSeriesBatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamingSeriesBatch proto.InternalMessageInfo
+
+// StreamingChunks are the chunks of a series previously streamed in a StreamingSeriesBatch.
+type StreamingChunks struct {
+	// Index of the series in the order they've been streamed, starting from 0.
+	SeriesIndex uint64      `protobuf:"varint,1,opt,name=series_index,json=seriesIndex,proto3" json:"series_index,omitempty"`
+	Chunks      []AggrChunk `protobuf:"bytes,2,rep,name=chunks,proto3" json:"chunks"`
+}
+
+func (m *StreamingChunks) Reset()      { *m = StreamingChunks{} }
+func (*StreamingChunks) ProtoMessage() {}
+func (*StreamingChunks) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{5}
+}
+func (m *StreamingChunks) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StreamingChunks) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StreamingChunks.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StreamingChunks) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamingChunks.Merge(m, src)
+}
+func (m *StreamingChunks) XXX_Size() int {
+	return m.Size()
+}
+func (m *StreamingChunks) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamingChunks.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamingChunks proto.InternalMessageInfo
+
+type StreamingChunksBatch struct {
+	Series []*StreamingChunks `protobuf:"bytes,1,rep,name=series,proto3" json:"series,omitempty"`
+}
+
+func (m *StreamingChunksBatch) Reset()      { *m = StreamingChunksBatch{} }
+func (*StreamingChunksBatch) ProtoMessage() {}
+func (*StreamingChunksBatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{6}
+}
+func (m *StreamingChunksBatch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StreamingChunksBatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StreamingChunksBatch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
 }
+func (m *StreamingChunksBatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamingChunksBatch.Merge(m, src)
+}
+func (m *StreamingChunksBatch) XXX_Size() int {
+	return m.Size()
+}
+func (m *StreamingChunksBatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamingChunksBatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamingChunksBatch proto.InternalMessageInfo
 
 type LabelNamesRequest struct {
 	Start int64 `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
@@ -230,12 +416,14 @@ type LabelNamesRequest struct {
 	// implementation of a specific store.
 	Hints    *types.Any     `protobuf:"bytes,5,opt,name=hints,proto3" json:"hints,omitempty"`
 	Matchers []LabelMatcher `protobuf:"bytes,6,rep,name=matchers,proto3" json:"matchers"`
+	Limit    int64          `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`
+	After    string         `protobuf:"bytes,8,opt,name=after,proto3" json:"after,omitempty"`
 }
 
 func (m *LabelNamesRequest) Reset()      { *m = LabelNamesRequest{} }
 func (*LabelNamesRequest) ProtoMessage() {}
 func (*LabelNamesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{3}
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
 }
 func (m *LabelNamesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -276,7 +464,7 @@ type LabelNamesResponse struct {
 func (m *LabelNamesResponse) Reset()      { *m = LabelNamesResponse{} }
 func (*LabelNamesResponse) ProtoMessage() {}
 func (*LabelNamesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{4}
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
 }
 func (m *LabelNamesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -312,14 +500,17 @@ type LabelValuesRequest struct {
 	// hints is an opaque data structure that can be used to carry additional information.
 	// The content of this field and whether it's supported depends on the
 	// implementation of a specific store.
-	Hints    *types.Any     `protobuf:"bytes,6,opt,name=hints,proto3" json:"hints,omitempty"`
-	Matchers []LabelMatcher `protobuf:"bytes,7,rep,name=matchers,proto3" json:"matchers"`
+	Hints        *types.Any     `protobuf:"bytes,6,opt,name=hints,proto3" json:"hints,omitempty"`
+	Matchers     []LabelMatcher `protobuf:"bytes,7,rep,name=matchers,proto3" json:"matchers"`
+	Limit        int64          `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"`
+	After        string         `protobuf:"bytes,9,opt,name=after,proto3" json:"after,omitempty"`
+	MaxSizeBytes int64          `protobuf:"varint,10,opt,name=max_size_bytes,json=maxSizeBytes,proto3" json:"max_size_bytes,omitempty"`
 }
 
 func (m *LabelValuesRequest) Reset()      { *m = LabelValuesRequest{} }
 func (*LabelValuesRequest) ProtoMessage() {}
 func (*LabelValuesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{5}
+	return fileDescriptor_77a6da22d6a3feb1, []int{9}
 }
 func (m *LabelValuesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -360,7 +551,7 @@ type LabelValuesResponse struct {
 func (m *LabelValuesResponse) Reset()      { *m = LabelValuesResponse{} }
 func (*LabelValuesResponse) ProtoMessage() {}
 func (*LabelValuesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{6}
+	return fileDescriptor_77a6da22d6a3feb1, []int{10}
 }
 func (m *LabelValuesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -389,64 +580,216 @@ func (m *LabelValuesResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_LabelValuesResponse proto.InternalMessageInfo
 
+type ExemplarsRequest struct {
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// Exemplars of the series matching any of the matcher sets are returned.
+	Matchers []ExemplarMatchers `protobuf:"bytes,3,rep,name=matchers,proto3" json:"matchers"`
+	// hints is an opaque data structure that can be used to carry additional information.
+	// The content of this field and whether it's supported depends on the
+	// implementation of a specific store.
+	Hints *types.Any `protobuf:"bytes,4,opt,name=hints,proto3" json:"hints,omitempty"`
+}
+
+func (m *ExemplarsRequest) Reset()      { *m = ExemplarsRequest{} }
+func (*ExemplarsRequest) ProtoMessage() {}
+func (*ExemplarsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{11}
+}
+func (m *ExemplarsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsRequest.Merge(m, src)
+}
+func (m *ExemplarsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsRequest proto.InternalMessageInfo
+
+type ExemplarMatchers struct {
+	Matchers []LabelMatcher `protobuf:"bytes,1,rep,name=matchers,proto3" json:"matchers"`
+}
+
+func (m *ExemplarMatchers) Reset()      { *m = ExemplarMatchers{} }
+func (*ExemplarMatchers) ProtoMessage() {}
+func (*ExemplarMatchers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{12}
+}
+func (m *ExemplarMatchers) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarMatchers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarMatchers.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarMatchers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarMatchers.Merge(m, src)
+}
+func (m *ExemplarMatchers) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarMatchers) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarMatchers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarMatchers proto.InternalMessageInfo
+
+type ExemplarsResponse struct {
+	Series   []SeriesExemplars `protobuf:"bytes,1,rep,name=series,proto3" json:"series"`
+	Warnings []string          `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	/// hints is an opaque data structure that can be used to carry additional information from
+	/// the store. The content of this field and whether it's supported depends on the
+	/// implementation of a specific store.
+	Hints *types.Any `protobuf:"bytes,3,opt,name=hints,proto3" json:"hints,omitempty"`
+}
+
+func (m *ExemplarsResponse) Reset()      { *m = ExemplarsResponse{} }
+func (*ExemplarsResponse) ProtoMessage() {}
+func (*ExemplarsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{13}
+}
+func (m *ExemplarsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarsResponse.Merge(m, src)
+}
+func (m *ExemplarsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarsResponse proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*SeriesRequest)(nil), "thanos.SeriesRequest")
 	proto.RegisterType((*Stats)(nil), "thanos.Stats")
 	proto.RegisterType((*SeriesResponse)(nil), "thanos.SeriesResponse")
+	proto.RegisterType((*StreamingSeries)(nil), "thanos.StreamingSeries")
+	proto.RegisterType((*StreamingSeriesBatch)(nil), "thanos.StreamingSeriesBatch")
+	proto.RegisterType((*StreamingChunks)(nil), "thanos.StreamingChunks")
+	proto.RegisterType((*StreamingChunksBatch)(nil), "thanos.StreamingChunksBatch")
 	proto.RegisterType((*LabelNamesRequest)(nil), "thanos.LabelNamesRequest")
 	proto.RegisterType((*LabelNamesResponse)(nil), "thanos.LabelNamesResponse")
 	proto.RegisterType((*LabelValuesRequest)(nil), "thanos.LabelValuesRequest")
 	proto.RegisterType((*LabelValuesResponse)(nil), "thanos.LabelValuesResponse")
+	proto.RegisterType((*ExemplarsRequest)(nil), "thanos.ExemplarsRequest")
+	proto.RegisterType((*ExemplarMatchers)(nil), "thanos.ExemplarMatchers")
+	proto.RegisterType((*ExemplarsResponse)(nil), "thanos.ExemplarsResponse")
 }
 
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 692 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x93, 0x31, 0x6f, 0xd3, 0x40,
-	0x14, 0xc7, 0x7d, 0xf1, 0xd9, 0xb9, 0x5c, 0x9a, 0xca, 0xbd, 0x96, 0xca, 0x35, 0xd2, 0x35, 0xb2,
-	0x84, 0x14, 0x21, 0x70, 0x51, 0x91, 0x40, 0x8c, 0x4d, 0x25, 0x54, 0x2c, 0x60, 0x70, 0x11, 0x03,
-	0x4b, 0xe4, 0xa4, 0xd7, 0xc4, 0x6a, 0x62, 0x07, 0x9f, 0x0d, 0xcd, 0xc6, 0x47, 0xe0, 0x63, 0x20,
-	0x31, 0xf3, 0x05, 0x98, 0xba, 0xd1, 0xb1, 0x13, 0x22, 0xee, 0xc2, 0xd8, 0x99, 0x09, 0xf9, 0xce,
-	0x69, 0x6a, 0x14, 0x54, 0x2a, 0xb1, 0xe5, 0xfd, 0xff, 0x2f, 0x77, 0xef, 0xff, 0x7b, 0x67, 0x5c,
-	0x8b, 0xc7, 0x3d, 0x67, 0x1c, 0x47, 0x49, 0x44, 0xf4, 0x64, 0xe0, 0x87, 0x11, 0xb7, 0xea, 0xc9,
-	0x64, 0xcc, 0xb8, 0x14, 0xad, 0xfb, 0xfd, 0x20, 0x19, 0xa4, 0x5d, 0xa7, 0x17, 0x8d, 0xb6, 0xfa,
-	0x51, 0x3f, 0xda, 0x12, 0x72, 0x37, 0x3d, 0x14, 0x95, 0x28, 0xc4, 0xaf, 0xa2, 0x7d, 0xa3, 0x1f,
-	0x45, 0xfd, 0x21, 0x9b, 0x77, 0xf9, 0xe1, 0x44, 0x5a, 0xf6, 0x2f, 0x80, 0x1b, 0xfb, 0x2c, 0x0e,
-	0x18, 0xf7, 0xd8, 0xdb, 0x94, 0xf1, 0x84, 0x6c, 0x60, 0x34, 0x0a, 0xc2, 0x4e, 0x12, 0x8c, 0x98,
-	0x09, 0x9a, 0xa0, 0xa5, 0x7a, 0xd5, 0x51, 0x10, 0xbe, 0x0a, 0x46, 0x4c, 0x58, 0xfe, 0xb1, 0xb4,
-	0x2a, 0x85, 0xe5, 0x1f, 0x0b, 0xeb, 0x51, 0x6e, 0x25, 0xbd, 0x01, 0x8b, 0xb9, 0xa9, 0x36, 0xd5,
-	0x56, 0x7d, 0x7b, 0xcd, 0x91, 0x93, 0x3b, 0xcf, 0xfd, 0x2e, 0x1b, 0xbe, 0x90, 0x66, 0x1b, 0x9e,
-	0x7c, 0xdf, 0x54, 0xbc, 0xcb, 0x5e, 0xb2, 0x89, 0xeb, 0xfc, 0x28, 0x18, 0x77, 0x7a, 0x83, 0x34,
-	0x3c, 0xe2, 0x26, 0x6a, 0x82, 0x16, 0xf2, 0x70, 0x2e, 0xed, 0x0a, 0x85, 0xdc, 0xc5, 0xda, 0x20,
-	0x08, 0x13, 0x6e, 0xd6, 0x9a, 0x40, 0x9c, 0x2a, 0xb3, 0x38, 0xb3, 0x2c, 0xce, 0x4e, 0x38, 0xf1,
-	0x64, 0x8b, 0x0b, 0x11, 0x34, 0x34, 0x17, 0x22, 0xcd, 0xd0, 0x5d, 0x88, 0x74, 0xa3, 0xea, 0x42,
-	0x54, 0x35, 0x90, 0x0b, 0x11, 0x36, 0xea, 0x2e, 0x44, 0x75, 0x63, 0xc9, 0x85, 0x68, 0xc9, 0x68,
-	0xb8, 0x10, 0x35, 0x8c, 0x65, 0xfb, 0x31, 0xd6, 0xf6, 0x13, 0x3f, 0xe1, 0xc4, 0xc1, 0xab, 0x87,
-	0x2c, 0x9f, 0xe8, 0xa0, 0x13, 0x84, 0x07, 0xec, 0xb8, 0xd3, 0x9d, 0x24, 0x8c, 0x8b, 0xf8, 0xd0,
-	0x5b, 0x29, 0xac, 0x67, 0xb9, 0xd3, 0xce, 0x0d, 0xfb, 0x0b, 0xc0, 0xcb, 0x33, 0x6a, 0x7c, 0x1c,
-	0x85, 0x9c, 0x91, 0x16, 0xd6, 0xb9, 0x50, 0xc4, 0xbf, 0xea, 0xdb, 0xcb, 0xb3, 0xf8, 0xb2, 0x6f,
-	0x4f, 0xf1, 0x0a, 0x9f, 0x58, 0xb8, 0xfa, 0xde, 0x8f, 0xc3, 0x20, 0xec, 0x0b, 0x88, 0xb5, 0x3d,
-	0xc5, 0x9b, 0x09, 0xe4, 0xde, 0x2c, 0xad, 0xfa, 0xf7, 0xb4, 0x7b, 0x4a, 0x91, 0x97, 0xdc, 0xc1,
-	0x1a, 0xcf, 0xe7, 0x37, 0xa1, 0xe8, 0x6e, 0x5c, 0x5e, 0x99, 0x8b, 0x79, 0x9b, 0x70, 0xdb, 0x08,
-	0xeb, 0x31, 0xe3, 0xe9, 0x30, 0xb1, 0x3f, 0x03, 0xbc, 0x22, 0xd6, 0xf1, 0xd2, 0x1f, 0xcd, 0x37,
-	0xbe, 0x26, 0x8e, 0x89, 0x13, 0x71, 0xa9, 0xea, 0xc9, 0x82, 0x18, 0x58, 0x65, 0xe1, 0x81, 0x38,
-	0x5a, 0xf5, 0xf2, 0x9f, 0xf3, 0x55, 0x68, 0xd7, 0xae, 0xa2, 0xf4, 0x1e, 0xf4, 0x7f, 0x7f, 0x0f,
-	0x2e, 0x44, 0xc0, 0xa8, 0xb8, 0x10, 0x55, 0x0c, 0xd5, 0x8e, 0x31, 0xb9, 0x3a, 0x6c, 0x01, 0x7a,
-	0x0d, 0x6b, 0x61, 0x2e, 0x98, 0xa0, 0xa9, 0xb6, 0x6a, 0x9e, 0x2c, 0x88, 0x85, 0x51, 0xc1, 0x90,
-	0x9b, 0x15, 0x61, 0x5c, 0xd6, 0xf3, 0xb9, 0xd5, 0x6b, 0xe7, 0xb6, 0xbf, 0x82, 0xe2, 0xd2, 0xd7,
-	0xfe, 0x30, 0x2d, 0x21, 0x1a, 0xe6, 0xaa, 0x58, 0x6e, 0xcd, 0x93, 0xc5, 0x1c, 0x1c, 0x5c, 0x00,
-	0x4e, 0x5b, 0x00, 0x4e, 0xbf, 0x19, 0xb8, 0xea, 0x8d, 0xc0, 0x55, 0x0c, 0xd5, 0x85, 0x48, 0x35,
-	0xa0, 0x9d, 0xe2, 0xd5, 0x52, 0x86, 0x82, 0xdc, 0x3a, 0xd6, 0xdf, 0x09, 0xa5, 0x40, 0x57, 0x54,
-	0xff, 0x8b, 0xdd, 0xf6, 0x37, 0x90, 0x7f, 0x4f, 0x51, 0xcc, 0xc8, 0x13, 0xac, 0xcb, 0x67, 0x4f,
-	0x6e, 0x95, 0x3f, 0x83, 0x82, 0xa7, 0xb5, 0xfe, 0xa7, 0x2c, 0x47, 0x7c, 0x00, 0xc8, 0x2e, 0xc6,
-	0xf3, 0xa5, 0x93, 0x8d, 0x52, 0xf6, 0xab, 0xaf, 0xd6, 0xb2, 0x16, 0x59, 0x45, 0xd2, 0xa7, 0xb8,
-	0x7e, 0x05, 0x00, 0x29, 0xb7, 0x96, 0x36, 0x6b, 0xdd, 0x5e, 0xe8, 0xc9, 0x73, 0xda, 0x3b, 0x27,
-	0x53, 0xaa, 0x9c, 0x4e, 0xa9, 0x72, 0x36, 0xa5, 0xca, 0xc5, 0x94, 0x82, 0x0f, 0x19, 0x05, 0x9f,
-	0x32, 0x0a, 0x4e, 0x32, 0x0a, 0x4e, 0x33, 0x0a, 0x7e, 0x64, 0x14, 0xfc, 0xcc, 0xa8, 0x72, 0x91,
-	0x51, 0xf0, 0xf1, 0x9c, 0x2a, 0xa7, 0xe7, 0x54, 0x39, 0x3b, 0xa7, 0xca, 0x9b, 0x2a, 0xcf, 0x41,
-	0x8c, 0xbb, 0x5d, 0x5d, 0x90, 0x7a, 0xf8, 0x3b, 0x00, 0x00, 0xff, 0xff, 0xe8, 0x55, 0x20, 0x90,
-	0xd3, 0x05, 0x00, 0x00,
+	// 1104 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x96, 0x4d, 0x6e, 0x23, 0x45,
+	0x14, 0x80, 0xbb, 0xec, 0x72, 0xbb, 0x5d, 0x4e, 0x32, 0x9d, 0x8a, 0xc9, 0x38, 0x1e, 0xd4, 0x09,
+	0x2d, 0x90, 0x22, 0x04, 0xce, 0x10, 0x06, 0xd0, 0x20, 0xb1, 0x48, 0xa2, 0x81, 0x4c, 0x8b, 0x3f,
+	0x75, 0x10, 0x0b, 0x36, 0x56, 0xdb, 0x2e, 0x3b, 0xa5, 0xb8, 0x7f, 0xe8, 0x2a, 0x83, 0x3d, 0x2b,
+	0x8e, 0x80, 0x10, 0x27, 0x60, 0xc5, 0x15, 0xb8, 0x41, 0x24, 0x16, 0x64, 0xc7, 0x88, 0xc5, 0x88,
+	0x38, 0x1b, 0xd8, 0xcd, 0x11, 0x50, 0xfd, 0xb4, 0xdd, 0x4e, 0x1c, 0x25, 0x83, 0x66, 0xe5, 0xae,
+	0xf7, 0x57, 0xf5, 0xbe, 0xf7, 0xea, 0x95, 0x51, 0x25, 0x4d, 0x3a, 0xcd, 0x24, 0x8d, 0x79, 0x8c,
+	0x4d, 0x7e, 0x1c, 0x44, 0x31, 0x6b, 0x54, 0xf9, 0x38, 0x21, 0x4c, 0x09, 0x1b, 0xf7, 0xfb, 0x94,
+	0x1f, 0x0f, 0xdb, 0xcd, 0x4e, 0x1c, 0xee, 0xf4, 0xd3, 0xa0, 0x17, 0x44, 0xc1, 0x4e, 0x48, 0x43,
+	0x9a, 0xee, 0x24, 0x27, 0x7d, 0xf5, 0x95, 0xb4, 0xd5, 0xaf, 0xf6, 0x78, 0x3b, 0xef, 0x11, 0xf7,
+	0xe3, 0x1d, 0x29, 0x6e, 0x0f, 0x7b, 0x72, 0x25, 0x17, 0xf2, 0x4b, 0x9b, 0x6f, 0xf4, 0xe3, 0xb8,
+	0x3f, 0x20, 0x33, 0xab, 0x20, 0x1a, 0x2b, 0x95, 0xfb, 0x5b, 0x01, 0x2d, 0x1f, 0x91, 0x94, 0x12,
+	0xe6, 0x93, 0x6f, 0x87, 0x84, 0x71, 0xbc, 0x81, 0xac, 0x90, 0x46, 0x2d, 0x4e, 0x43, 0x52, 0x07,
+	0x5b, 0x60, 0xbb, 0xe8, 0x97, 0x43, 0x1a, 0x7d, 0x45, 0x43, 0x22, 0x55, 0xc1, 0x48, 0xa9, 0x0a,
+	0x5a, 0x15, 0x8c, 0xa4, 0xea, 0x7d, 0xa1, 0xe2, 0x9d, 0x63, 0x92, 0xb2, 0x7a, 0x71, 0xab, 0xb8,
+	0x5d, 0xdd, 0xad, 0x35, 0x55, 0xae, 0xcd, 0x4f, 0x83, 0x36, 0x19, 0x7c, 0xa6, 0x94, 0xfb, 0xf0,
+	0xf4, 0xd9, 0xa6, 0xe1, 0x4f, 0x6d, 0xf1, 0x26, 0xaa, 0xb2, 0x13, 0x9a, 0xb4, 0x3a, 0xc7, 0xc3,
+	0xe8, 0x84, 0xd5, 0xad, 0x2d, 0xb0, 0x6d, 0xf9, 0x48, 0x88, 0x0e, 0xa4, 0x04, 0xbf, 0x89, 0x4a,
+	0xc7, 0x34, 0xe2, 0xac, 0x5e, 0xd9, 0x02, 0x32, 0xaa, 0xca, 0xa5, 0x99, 0xe5, 0xd2, 0xdc, 0x8b,
+	0xc6, 0xbe, 0x32, 0xc1, 0x1f, 0xa1, 0x7b, 0x8c, 0xa7, 0x24, 0x08, 0x69, 0xd4, 0xd7, 0x11, 0x5b,
+	0x6d, 0xb1, 0x53, 0x8b, 0xd1, 0x27, 0xa4, 0xde, 0xdd, 0x02, 0xdb, 0xd0, 0xaf, 0x4f, 0x4d, 0xd4,
+	0x0e, 0xfb, 0xc2, 0xe0, 0x88, 0x3e, 0x21, 0x1e, 0xb4, 0xa0, 0x5d, 0xf2, 0xa0, 0x55, 0xb2, 0x4d,
+	0x0f, 0x5a, 0xa6, 0x5d, 0xf6, 0xa0, 0x55, 0xb6, 0x2d, 0x0f, 0x5a, 0xc8, 0xae, 0x7a, 0xd0, 0xaa,
+	0xda, 0x4b, 0x1e, 0xb4, 0x96, 0xec, 0x65, 0x0f, 0x5a, 0xcb, 0xf6, 0x8a, 0xfb, 0x01, 0x2a, 0x1d,
+	0xf1, 0x80, 0x33, 0xdc, 0x44, 0x6b, 0x3d, 0x22, 0x12, 0xea, 0xb6, 0x68, 0xd4, 0x25, 0xa3, 0x56,
+	0x7b, 0xcc, 0x09, 0x93, 0xf4, 0xa0, 0xbf, 0xaa, 0x55, 0x8f, 0x85, 0x66, 0x5f, 0x28, 0xdc, 0xdf,
+	0x0b, 0x68, 0x25, 0x83, 0xce, 0x92, 0x38, 0x62, 0x04, 0x6f, 0x23, 0x93, 0x49, 0x89, 0xf4, 0xaa,
+	0xee, 0xae, 0x64, 0xf4, 0x94, 0xdd, 0xa1, 0xe1, 0x6b, 0x3d, 0x6e, 0xa0, 0xf2, 0xf7, 0x41, 0x1a,
+	0xd1, 0xa8, 0x2f, 0x6b, 0x50, 0x39, 0x34, 0xfc, 0x4c, 0x80, 0xdf, 0xca, 0x60, 0x15, 0xaf, 0x87,
+	0x75, 0x68, 0x64, 0xb8, 0xde, 0x40, 0x25, 0x26, 0xce, 0x5f, 0x87, 0xd2, 0x7a, 0x79, 0xba, 0xa5,
+	0x10, 0x0a, 0x33, 0xa9, 0xc5, 0x8f, 0x91, 0x3d, 0xa3, 0xaa, 0x0f, 0x59, 0x92, 0x1e, 0xaf, 0xce,
+	0x3c, 0xb4, 0x5e, 0x9d, 0x56, 0x22, 0x3d, 0x34, 0xfc, 0x3b, 0x6c, 0x5e, 0x3e, 0x1f, 0x4a, 0x97,
+	0xdc, 0xbc, 0x26, 0x54, 0xae, 0x3a, 0x73, 0xa1, 0xb4, 0xdc, 0x42, 0x66, 0x4a, 0xd8, 0x70, 0xc0,
+	0xdd, 0x31, 0xba, 0x73, 0x69, 0x7f, 0xdc, 0x43, 0xe6, 0x40, 0x74, 0x9d, 0xa0, 0x29, 0x7a, 0x71,
+	0xad, 0xd9, 0x89, 0x53, 0x4e, 0x46, 0x49, 0x5b, 0x75, 0xe3, 0x97, 0x01, 0x4d, 0xf7, 0x1f, 0x8a,
+	0x56, 0xfc, 0xeb, 0xd9, 0xe6, 0x3b, 0xb7, 0xb9, 0x7e, 0xca, 0x6f, 0xaf, 0x1b, 0x24, 0x9c, 0xa4,
+	0xbe, 0x8e, 0xee, 0xfe, 0x0b, 0x50, 0x6d, 0x51, 0xee, 0x78, 0x27, 0x57, 0x4e, 0x71, 0x80, 0xbb,
+	0xd7, 0x90, 0x9a, 0x56, 0xf5, 0x01, 0xba, 0x4b, 0x59, 0x8b, 0x44, 0xdd, 0x56, 0xdc, 0xd3, 0x90,
+	0x5b, 0x2a, 0x65, 0x59, 0x65, 0xcb, 0x5f, 0xa3, 0xec, 0x51, 0xd4, 0xfd, 0xa2, 0xa7, 0xfc, 0x54,
+	0x18, 0xfc, 0x00, 0xad, 0x13, 0xc6, 0x69, 0x18, 0x70, 0xd2, 0xcd, 0x1a, 0xbe, 0x13, 0x0f, 0x23,
+	0x2e, 0x1b, 0x00, 0xfa, 0xb5, 0xa9, 0x56, 0x51, 0x3b, 0x10, 0xba, 0x85, 0x5e, 0xaa, 0x63, 0xe1,
+	0x42, 0x2f, 0xd5, 0xb4, 0x24, 0x87, 0x59, 0xdf, 0xcd, 0xd7, 0xd0, 0x92, 0x3e, 0xaa, 0x6c, 0x7b,
+	0xdd, 0xf0, 0x55, 0x25, 0x93, 0xfd, 0x2e, 0x40, 0xe8, 0x3a, 0x17, 0x24, 0x88, 0xd5, 0x0c, 0xc4,
+	0x5e, 0xbf, 0x9f, 0xca, 0x30, 0x7a, 0x24, 0x68, 0x33, 0xf7, 0x93, 0x1c, 0xd1, 0x5c, 0x0b, 0xdc,
+	0x82, 0xa8, 0xb2, 0xce, 0x88, 0xba, 0x7f, 0x02, 0xb4, 0x2a, 0x8b, 0xf6, 0x79, 0x10, 0xce, 0xa6,
+	0x5b, 0x4d, 0xf6, 0x7c, 0xaa, 0x00, 0x15, 0x7d, 0xb5, 0xc0, 0x36, 0x2a, 0x92, 0xa8, 0x2b, 0xd3,
+	0x2f, 0xfa, 0xe2, 0x73, 0x36, 0x76, 0x4a, 0x37, 0x8f, 0x9d, 0xfc, 0xec, 0x33, 0x5f, 0x60, 0xf6,
+	0xd5, 0x50, 0x69, 0x40, 0x43, 0xca, 0xeb, 0x65, 0x75, 0x16, 0xb9, 0x10, 0xd2, 0xa0, 0xc7, 0x49,
+	0x2a, 0x67, 0x61, 0xc5, 0x57, 0x0b, 0x0f, 0x5a, 0xc0, 0x2e, 0x78, 0xd0, 0x2a, 0xd8, 0x45, 0x37,
+	0x45, 0x38, 0x9f, 0x98, 0x9e, 0x20, 0x35, 0x54, 0x8a, 0x82, 0x50, 0xf3, 0xa9, 0xf8, 0x6a, 0x81,
+	0x1b, 0xc8, 0xd2, 0xc3, 0x41, 0x55, 0xa0, 0xe2, 0x4f, 0xd7, 0xb3, 0x1c, 0x8b, 0x37, 0xe6, 0xe8,
+	0xfe, 0x5c, 0xd0, 0x9b, 0x7e, 0x1d, 0x0c, 0x86, 0x73, 0x38, 0xe5, 0x55, 0x90, 0xa5, 0xaf, 0xf8,
+	0x6a, 0x31, 0x83, 0x0c, 0x17, 0x40, 0x2e, 0x2d, 0x80, 0x6c, 0xbe, 0x18, 0xe4, 0xf2, 0xff, 0x81,
+	0x6c, 0x2d, 0x84, 0x5c, 0xc9, 0x41, 0xc6, 0xaf, 0xa3, 0x15, 0xf1, 0xbe, 0x89, 0xc7, 0x42, 0x5f,
+	0x08, 0x24, 0x9d, 0x96, 0xc2, 0x60, 0x24, 0x5e, 0x08, 0x79, 0x11, 0x54, 0x11, 0x3c, 0x68, 0x15,
+	0x6d, 0xe8, 0x0e, 0xd1, 0xda, 0x1c, 0x15, 0x5d, 0x8b, 0x75, 0x64, 0x7e, 0x27, 0x25, 0xba, 0x18,
+	0x7a, 0xf5, 0xd2, 0xaa, 0xf1, 0x0b, 0x40, 0xf6, 0xa3, 0x11, 0x09, 0x93, 0x41, 0x90, 0x5e, 0x6d,
+	0x6d, 0xb0, 0x80, 0x7a, 0x61, 0x46, 0xfd, 0xc3, 0x2b, 0x4f, 0x75, 0x3d, 0x23, 0x99, 0xc5, 0xd4,
+	0x30, 0xd9, 0x15, 0x9a, 0xd3, 0x43, 0xc2, 0x9b, 0x0f, 0xe9, 0x21, 0xfb, 0x72, 0xbc, 0xb9, 0x2a,
+	0x82, 0xdb, 0x57, 0xd1, 0xfd, 0x09, 0xa0, 0xd5, 0x5c, 0xc2, 0x1a, 0xf3, 0x7b, 0xd7, 0xce, 0x04,
+	0x29, 0x9d, 0x3a, 0x64, 0x23, 0x66, 0xfa, 0x82, 0xbe, 0x94, 0x2a, 0xec, 0xfe, 0x01, 0xc4, 0x1f,
+	0x80, 0x38, 0x25, 0xf8, 0x21, 0x32, 0xf5, 0xcb, 0xf3, 0xca, 0xfc, 0x11, 0x74, 0x6d, 0x1a, 0xeb,
+	0x97, 0xc5, 0x2a, 0x83, 0xfb, 0x00, 0x1f, 0x20, 0x34, 0xbb, 0xcc, 0x78, 0x63, 0x8e, 0x46, 0x7e,
+	0x72, 0x35, 0x1a, 0x8b, 0x54, 0x1a, 0xc4, 0xc7, 0xa8, 0x9a, 0x6b, 0x43, 0x3c, 0x6f, 0x3a, 0x77,
+	0x63, 0x1b, 0xf7, 0x16, 0xea, 0x54, 0x9c, 0xfd, 0xbd, 0xd3, 0x73, 0xc7, 0x38, 0x3b, 0x77, 0x8c,
+	0xa7, 0xe7, 0x8e, 0xf1, 0xfc, 0xdc, 0x01, 0x3f, 0x4c, 0x1c, 0xf0, 0xeb, 0xc4, 0x01, 0xa7, 0x13,
+	0x07, 0x9c, 0x4d, 0x1c, 0xf0, 0xf7, 0xc4, 0x01, 0xff, 0x4c, 0x1c, 0xe3, 0xf9, 0xc4, 0x01, 0x3f,
+	0x5e, 0x38, 0xc6, 0xd9, 0x85, 0x63, 0x3c, 0xbd, 0x70, 0x8c, 0x6f, 0xca, 0x4c, 0x80, 0x48, 0xda,
+	0x6d, 0x53, 0x92, 0x7a, 0xf7, 0xbf, 0x01, 0x00, 0x7e, 0xe3, 0xe0, 0xb5, 0xf5, 0x0a, 0x00, 0x00,
 }
 
 func (this *SeriesRequest) Equal(that interface{}) bool {
@@ -488,6 +831,9 @@ func (this *SeriesRequest) Equal(that interface{}) bool {
 	if !this.Hints.Equal(that1.Hints) {
 		return false
 	}
+	if this.StreamingChunksBatchSize != that1.StreamingChunksBatchSize {
+		return false
+	}
 	return true
 }
 func (this *Stats) Equal(that interface{}) bool {
@@ -640,14 +986,14 @@ func (this *SeriesResponse_Stats) Equal(that interface{}) bool {
 	}
 	return true
 }
-func (this *LabelNamesRequest) Equal(that interface{}) bool {
+func (this *SeriesResponse_StreamingSeries) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesRequest)
+	that1, ok := that.(*SeriesResponse_StreamingSeries)
 	if !ok {
-		that2, ok := that.(LabelNamesRequest)
+		that2, ok := that.(SeriesResponse_StreamingSeries)
 		if ok {
 			that1 = &that2
 		} else {
@@ -659,33 +1005,72 @@ func (this *LabelNamesRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.Start != that1.Start {
+	if !this.StreamingSeries.Equal(that1.StreamingSeries) {
 		return false
 	}
-	if this.End != that1.End {
+	return true
+}
+func (this *SeriesResponse_StreamingChunks) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*SeriesResponse_StreamingChunks)
+	if !ok {
+		that2, ok := that.(SeriesResponse_StreamingChunks)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
 		return false
 	}
-	if !this.Hints.Equal(that1.Hints) {
+	if !this.StreamingChunks.Equal(that1.StreamingChunks) {
 		return false
 	}
-	if len(this.Matchers) != len(that1.Matchers) {
+	return true
+}
+func (this *StreamingSeries) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*StreamingSeries)
+	if !ok {
+		that2, ok := that.(StreamingSeries)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
 		return false
 	}
-	for i := range this.Matchers {
-		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LabelNamesResponse) Equal(that interface{}) bool {
+func (this *StreamingSeriesBatch) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelNamesResponse)
+	that1, ok := that.(*StreamingSeriesBatch)
 	if !ok {
-		that2, ok := that.(LabelNamesResponse)
+		that2, ok := that.(StreamingSeriesBatch)
 		if ok {
 			that1 = &that2
 		} else {
@@ -697,35 +1082,33 @@ func (this *LabelNamesResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Names) != len(that1.Names) {
+	if len(this.Series) != len(that1.Series) {
 		return false
 	}
-	for i := range this.Names {
-		if this.Names[i] != that1.Names[i] {
+	for i := range this.Series {
+		if !this.Series[i].Equal(that1.Series[i]) {
 			return false
 		}
 	}
-	if len(this.Warnings) != len(that1.Warnings) {
+	if this.IsEndOfSeriesStream != that1.IsEndOfSeriesStream {
 		return false
 	}
-	for i := range this.Warnings {
-		if this.Warnings[i] != that1.Warnings[i] {
-			return false
-		}
+	if this.EstimatedChunksCount != that1.EstimatedChunksCount {
+		return false
 	}
-	if !this.Hints.Equal(that1.Hints) {
+	if this.EstimatedChunksBytes != that1.EstimatedChunksBytes {
 		return false
 	}
 	return true
 }
-func (this *LabelValuesRequest) Equal(that interface{}) bool {
+func (this *StreamingChunks) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesRequest)
+	that1, ok := that.(*StreamingChunks)
 	if !ok {
-		that2, ok := that.(LabelValuesRequest)
+		that2, ok := that.(StreamingChunks)
 		if ok {
 			that1 = &that2
 		} else {
@@ -737,36 +1120,27 @@ func (this *LabelValuesRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.Label != that1.Label {
-		return false
-	}
-	if this.Start != that1.Start {
-		return false
-	}
-	if this.End != that1.End {
-		return false
-	}
-	if !this.Hints.Equal(that1.Hints) {
+	if this.SeriesIndex != that1.SeriesIndex {
 		return false
 	}
-	if len(this.Matchers) != len(that1.Matchers) {
+	if len(this.Chunks) != len(that1.Chunks) {
 		return false
 	}
-	for i := range this.Matchers {
-		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+	for i := range this.Chunks {
+		if !this.Chunks[i].Equal(&that1.Chunks[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *LabelValuesResponse) Equal(that interface{}) bool {
+func (this *StreamingChunksBatch) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelValuesResponse)
+	that1, ok := that.(*StreamingChunksBatch)
 	if !ok {
-		that2, ok := that.(LabelValuesResponse)
+		that2, ok := that.(StreamingChunksBatch)
 		if ok {
 			that1 = &that2
 		} else {
@@ -778,766 +1152,789 @@ func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Values) != len(that1.Values) {
+	if len(this.Series) != len(that1.Series) {
 		return false
 	}
-	for i := range this.Values {
-		if this.Values[i] != that1.Values[i] {
+	for i := range this.Series {
+		if !this.Series[i].Equal(that1.Series[i]) {
 			return false
 		}
 	}
-	if len(this.Warnings) != len(that1.Warnings) {
-		return false
-	}
-	for i := range this.Warnings {
-		if this.Warnings[i] != that1.Warnings[i] {
+	return true
+}
+func (this *LabelNamesRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelNamesRequest)
+	if !ok {
+		that2, ok := that.(LabelNamesRequest)
+		if ok {
+			that1 = &that2
+		} else {
 			return false
 		}
 	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Start != that1.Start {
+		return false
+	}
+	if this.End != that1.End {
+		return false
+	}
 	if !this.Hints.Equal(that1.Hints) {
 		return false
 	}
-	return true
-}
-func (this *SeriesRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
 	}
-	s := make([]string, 0, 9)
-	s = append(s, "&storepb.SeriesRequest{")
-	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
-	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
-	if this.Matchers != nil {
-		vs := make([]*LabelMatcher, len(this.Matchers))
-		for i := range vs {
-			vs[i] = &this.Matchers[i]
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
 		}
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	s = append(s, "SkipChunks: "+fmt.Sprintf("%#v", this.SkipChunks)+",\n")
-	if this.Hints != nil {
-		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	if this.Limit != that1.Limit {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *Stats) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.After != that1.After {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&storepb.Stats{")
-	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *SeriesResponse) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *LabelNamesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 8)
-	s = append(s, "&storepb.SeriesResponse{")
-	if this.Result != nil {
-		s = append(s, "Result: "+fmt.Sprintf("%#v", this.Result)+",\n")
+
+	that1, ok := that.(*LabelNamesResponse)
+	if !ok {
+		that2, ok := that.(LabelNamesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *SeriesResponse_Series) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := strings.Join([]string{`&storepb.SeriesResponse_Series{` +
-		`Series:` + fmt.Sprintf("%#v", this.Series) + `}`}, ", ")
-	return s
-}
-func (this *SeriesResponse_Warning) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.Names) != len(that1.Names) {
+		return false
 	}
-	s := strings.Join([]string{`&storepb.SeriesResponse_Warning{` +
-		`Warning:` + fmt.Sprintf("%#v", this.Warning) + `}`}, ", ")
-	return s
-}
-func (this *SeriesResponse_Hints) GoString() string {
-	if this == nil {
-		return "nil"
+	for i := range this.Names {
+		if this.Names[i] != that1.Names[i] {
+			return false
+		}
 	}
-	s := strings.Join([]string{`&storepb.SeriesResponse_Hints{` +
-		`Hints:` + fmt.Sprintf("%#v", this.Hints) + `}`}, ", ")
-	return s
-}
-func (this *SeriesResponse_Stats) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
 	}
-	s := strings.Join([]string{`&storepb.SeriesResponse_Stats{` +
-		`Stats:` + fmt.Sprintf("%#v", this.Stats) + `}`}, ", ")
-	return s
-}
-func (this *LabelNamesRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
 	}
-	s := make([]string, 0, 8)
-	s = append(s, "&storepb.LabelNamesRequest{")
-	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
-	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
-	if this.Hints != nil {
-		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	if !this.Hints.Equal(that1.Hints) {
+		return false
 	}
-	if this.Matchers != nil {
-		vs := make([]*LabelMatcher, len(this.Matchers))
-		for i := range vs {
-			vs[i] = &this.Matchers[i]
+	return true
+}
+func (this *LabelValuesRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelValuesRequest)
+	if !ok {
+		that2, ok := that.(LabelValuesRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelNamesResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&storepb.LabelNamesResponse{")
-	s = append(s, "Names: "+fmt.Sprintf("%#v", this.Names)+",\n")
-	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
-	if this.Hints != nil {
-		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	if this.Label != that1.Label {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelValuesRequest) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Start != that1.Start {
+		return false
 	}
-	s := make([]string, 0, 9)
-	s = append(s, "&storepb.LabelValuesRequest{")
-	s = append(s, "Label: "+fmt.Sprintf("%#v", this.Label)+",\n")
-	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
-	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
-	if this.Hints != nil {
-		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	if this.End != that1.End {
+		return false
 	}
-	if this.Matchers != nil {
-		vs := make([]*LabelMatcher, len(this.Matchers))
-		for i := range vs {
-			vs[i] = &this.Matchers[i]
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
 		}
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *LabelValuesResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Limit != that1.Limit {
+		return false
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&storepb.LabelValuesResponse{")
-	s = append(s, "Values: "+fmt.Sprintf("%#v", this.Values)+",\n")
-	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
-	if this.Hints != nil {
-		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	if this.After != that1.After {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func valueToGoStringRpc(v interface{}, typ string) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+	if this.MaxSizeBytes != that1.MaxSizeBytes {
+		return false
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+	return true
 }
+func (this *LabelValuesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// StoreClient is the client API for Store service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type StoreClient interface {
-	/// Series streams each Series (Labels and chunk/downsampling chunk) for given label matchers and time range.
-	///
-	/// Series should strictly stream full series after series, optionally split by time. This means that a single frame can contain
-	/// partition of the single series, but once a new series is started to be streamed it means that no more data will
-	/// be sent for previous one.
-	/// Series has to be sorted.
-	///
-	/// There is no requirements on chunk sorting, however it is recommended to have chunk sorted by chunk min time.
-	/// This heavily optimizes the resource usage on Querier / Federated Queries.
-	Series(ctx context.Context, in *SeriesRequest, opts ...grpc.CallOption) (Store_SeriesClient, error)
-	/// LabelNames returns all label names constrained by the given matchers.
-	LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error)
-	/// LabelValues returns all label values for given label name.
-	LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error)
-}
-
-type storeClient struct {
-	cc *grpc.ClientConn
-}
-
-func NewStoreClient(cc *grpc.ClientConn) StoreClient {
-	return &storeClient{cc}
-}
-
-func (c *storeClient) Series(ctx context.Context, in *SeriesRequest, opts ...grpc.CallOption) (Store_SeriesClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Store_serviceDesc.Streams[0], "/thanos.Store/Series", opts...)
-	if err != nil {
-		return nil, err
+	that1, ok := that.(*LabelValuesResponse)
+	if !ok {
+		that2, ok := that.(LabelValuesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	x := &storeSeriesClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	if len(this.Values) != len(that1.Values) {
+		return false
 	}
-	return x, nil
-}
-
-type Store_SeriesClient interface {
-	Recv() (*SeriesResponse, error)
-	grpc.ClientStream
-}
-
-type storeSeriesClient struct {
-	grpc.ClientStream
-}
-
-func (x *storeSeriesClient) Recv() (*SeriesResponse, error) {
-	m := new(SeriesResponse)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+	for i := range this.Values {
+		if this.Values[i] != that1.Values[i] {
+			return false
+		}
 	}
-	return m, nil
-}
-
-func (c *storeClient) LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error) {
-	out := new(LabelNamesResponse)
-	err := c.cc.Invoke(ctx, "/thanos.Store/LabelNames", in, out, opts...)
-	if err != nil {
-		return nil, err
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
 	}
-	return out, nil
-}
-
-func (c *storeClient) LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error) {
-	out := new(LabelValuesResponse)
-	err := c.cc.Invoke(ctx, "/thanos.Store/LabelValues", in, out, opts...)
-	if err != nil {
-		return nil, err
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
 	}
-	return out, nil
-}
-
-// StoreServer is the server API for Store service.
-type StoreServer interface {
-	/// Series streams each Series (Labels and chunk/downsampling chunk) for given label matchers and time range.
-	///
-	/// Series should strictly stream full series after series, optionally split by time. This means that a single frame can contain
-	/// partition of the single series, but once a new series is started to be streamed it means that no more data will
-	/// be sent for previous one.
-	/// Series has to be sorted.
-	///
-	/// There is no requirements on chunk sorting, however it is recommended to have chunk sorted by chunk min time.
-	/// This heavily optimizes the resource usage on Querier / Federated Queries.
-	Series(*SeriesRequest, Store_SeriesServer) error
-	/// LabelNames returns all label names constrained by the given matchers.
-	LabelNames(context.Context, *LabelNamesRequest) (*LabelNamesResponse, error)
-	/// LabelValues returns all label values for given label name.
-	LabelValues(context.Context, *LabelValuesRequest) (*LabelValuesResponse, error)
-}
-
-// UnimplementedStoreServer can be embedded to have forward compatible implementations.
-type UnimplementedStoreServer struct {
-}
-
-func (*UnimplementedStoreServer) Series(req *SeriesRequest, srv Store_SeriesServer) error {
-	return status.Errorf(codes.Unimplemented, "method Series not implemented")
-}
-func (*UnimplementedStoreServer) LabelNames(ctx context.Context, req *LabelNamesRequest) (*LabelNamesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method LabelNames not implemented")
-}
-func (*UnimplementedStoreServer) LabelValues(ctx context.Context, req *LabelValuesRequest) (*LabelValuesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
-}
-
-func RegisterStoreServer(s *grpc.Server, srv StoreServer) {
-	s.RegisterService(&_Store_serviceDesc, srv)
-}
-
-func _Store_Series_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(SeriesRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+	if !this.Hints.Equal(that1.Hints) {
+		return false
 	}
-	return srv.(StoreServer).Series(m, &storeSeriesServer{stream})
-}
-
-type Store_SeriesServer interface {
-	Send(*SeriesResponse) error
-	grpc.ServerStream
-}
-
-type storeSeriesServer struct {
-	grpc.ServerStream
-}
-
-func (x *storeSeriesServer) Send(m *SeriesResponse) error {
-	return x.ServerStream.SendMsg(m)
+	return true
 }
+func (this *ExemplarsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func _Store_LabelNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LabelNamesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+	that1, ok := that.(*ExemplarsRequest)
+	if !ok {
+		that2, ok := that.(ExemplarsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if interceptor == nil {
-		return srv.(StoreServer).LabelNames(ctx, in)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/thanos.Store/LabelNames",
+	if this.Start != that1.Start {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StoreServer).LabelNames(ctx, req.(*LabelNamesRequest))
+	if this.End != that1.End {
+		return false
 	}
-	return interceptor(ctx, in, info, handler)
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
+		}
+	}
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	return true
 }
+func (this *ExemplarMatchers) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func _Store_LabelValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(LabelValuesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
+	that1, ok := that.(*ExemplarMatchers)
+	if !ok {
+		that2, ok := that.(ExemplarMatchers)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if interceptor == nil {
-		return srv.(StoreServer).LabelValues(ctx, in)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/thanos.Store/LabelValues",
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StoreServer).LabelValues(ctx, req.(*LabelValuesRequest))
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
+		}
 	}
-	return interceptor(ctx, in, info, handler)
-}
-
-var _Store_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "thanos.Store",
-	HandlerType: (*StoreServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "LabelNames",
-			Handler:    _Store_LabelNames_Handler,
-		},
-		{
-			MethodName: "LabelValues",
-			Handler:    _Store_LabelValues_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "Series",
-			Handler:       _Store_Series_Handler,
-			ServerStreams: true,
-		},
-	},
-	Metadata: "rpc.proto",
+	return true
 }
-
-func (m *SeriesRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *ExemplarsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return dAtA[:n], nil
-}
-
-func (m *SeriesRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
 
-func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Hints != nil {
-		{
-			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintRpc(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x4a
-	}
-	if m.SkipChunks {
-		i--
-		if m.SkipChunks {
-			dAtA[i] = 1
+	that1, ok := that.(*ExemplarsResponse)
+	if !ok {
+		that2, ok := that.(ExemplarsResponse)
+		if ok {
+			that1 = &that2
 		} else {
-			dAtA[i] = 0
+			return false
 		}
-		i--
-		dAtA[i] = 0x40
 	}
-	if len(m.Matchers) > 0 {
-		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintRpc(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Series) != len(that1.Series) {
+		return false
+	}
+	for i := range this.Series {
+		if !this.Series[i].Equal(&that1.Series[i]) {
+			return false
 		}
 	}
-	if m.MaxTime != 0 {
-		i = encodeVarintRpc(dAtA, i, uint64(m.MaxTime))
-		i--
-		dAtA[i] = 0x10
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
 	}
-	if m.MinTime != 0 {
-		i = encodeVarintRpc(dAtA, i, uint64(m.MinTime))
-		i--
-		dAtA[i] = 0x8
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
 	}
-	return len(dAtA) - i, nil
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	return true
 }
-
-func (m *Stats) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *SeriesRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := make([]string, 0, 10)
+	s = append(s, "&storepb.SeriesRequest{")
+	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	if this.Matchers != nil {
+		vs := make([]*LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
+		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "SkipChunks: "+fmt.Sprintf("%#v", this.SkipChunks)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "StreamingChunksBatchSize: "+fmt.Sprintf("%#v", this.StreamingChunksBatchSize)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *Stats) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *Stats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.Stats{")
+	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.FetchedIndexBytes != 0 {
-		i = encodeVarintRpc(dAtA, i, uint64(m.FetchedIndexBytes))
-		i--
-		dAtA[i] = 0x8
+func (this *SeriesResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := make([]string, 0, 10)
+	s = append(s, "&storepb.SeriesResponse{")
+	if this.Result != nil {
+		s = append(s, "Result: "+fmt.Sprintf("%#v", this.Result)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *SeriesResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *SeriesResponse_Series) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	s := strings.Join([]string{`&storepb.SeriesResponse_Series{` +
+		`Series:` + fmt.Sprintf("%#v", this.Series) + `}`}, ", ")
+	return s
 }
-
-func (m *SeriesResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (this *SeriesResponse_Warning) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&storepb.SeriesResponse_Warning{` +
+		`Warning:` + fmt.Sprintf("%#v", this.Warning) + `}`}, ", ")
+	return s
 }
-
-func (m *SeriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Result != nil {
-		{
-			size := m.Result.Size()
-			i -= size
-			if _, err := m.Result.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
-		}
+func (this *SeriesResponse_Hints) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := strings.Join([]string{`&storepb.SeriesResponse_Hints{` +
+		`Hints:` + fmt.Sprintf("%#v", this.Hints) + `}`}, ", ")
+	return s
 }
-
-func (m *SeriesResponse_Series) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+func (this *SeriesResponse_Stats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&storepb.SeriesResponse_Stats{` +
+		`Stats:` + fmt.Sprintf("%#v", this.Stats) + `}`}, ", ")
+	return s
 }
-
-func (m *SeriesResponse_Series) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.Series != nil {
-		{
-			size, err := m.Series.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintRpc(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+func (this *SeriesResponse_StreamingSeries) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return len(dAtA) - i, nil
+	s := strings.Join([]string{`&storepb.SeriesResponse_StreamingSeries{` +
+		`StreamingSeries:` + fmt.Sprintf("%#v", this.StreamingSeries) + `}`}, ", ")
+	return s
 }
-func (m *SeriesResponse_Warning) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+func (this *SeriesResponse_StreamingChunks) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&storepb.SeriesResponse_StreamingChunks{` +
+		`StreamingChunks:` + fmt.Sprintf("%#v", this.StreamingChunks) + `}`}, ", ")
+	return s
 }
-
-func (m *SeriesResponse_Warning) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	i -= len(m.Warning)
-	copy(dAtA[i:], m.Warning)
-	i = encodeVarintRpc(dAtA, i, uint64(len(m.Warning)))
-	i--
-	dAtA[i] = 0x12
-	return len(dAtA) - i, nil
+func (this *StreamingSeries) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.StreamingSeries{")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-func (m *SeriesResponse_Hints) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+func (this *StreamingSeriesBatch) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&storepb.StreamingSeriesBatch{")
+	if this.Series != nil {
+		s = append(s, "Series: "+fmt.Sprintf("%#v", this.Series)+",\n")
+	}
+	s = append(s, "IsEndOfSeriesStream: "+fmt.Sprintf("%#v", this.IsEndOfSeriesStream)+",\n")
+	s = append(s, "EstimatedChunksCount: "+fmt.Sprintf("%#v", this.EstimatedChunksCount)+",\n")
+	s = append(s, "EstimatedChunksBytes: "+fmt.Sprintf("%#v", this.EstimatedChunksBytes)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *SeriesResponse_Hints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.Hints != nil {
-		{
-			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintRpc(dAtA, i, uint64(size))
+func (this *StreamingChunks) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&storepb.StreamingChunks{")
+	s = append(s, "SeriesIndex: "+fmt.Sprintf("%#v", this.SeriesIndex)+",\n")
+	if this.Chunks != nil {
+		vs := make([]AggrChunk, len(this.Chunks))
+		for i := range vs {
+			vs[i] = this.Chunks[i]
 		}
-		i--
-		dAtA[i] = 0x1a
+		s = append(s, "Chunks: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return len(dAtA) - i, nil
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-func (m *SeriesResponse_Stats) MarshalTo(dAtA []byte) (int, error) {
-	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+func (this *StreamingChunksBatch) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.StreamingChunksBatch{")
+	if this.Series != nil {
+		s = append(s, "Series: "+fmt.Sprintf("%#v", this.Series)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *SeriesResponse_Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.Stats != nil {
-		{
-			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintRpc(dAtA, i, uint64(size))
+func (this *LabelNamesRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 10)
+	s = append(s, "&storepb.LabelNamesRequest{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	if this.Matchers != nil {
+		vs := make([]*LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
 		}
-		i--
-		dAtA[i] = 0x22
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return len(dAtA) - i, nil
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "After: "+fmt.Sprintf("%#v", this.After)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-func (m *LabelNamesRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *LabelNamesResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LabelNamesRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	s := make([]string, 0, 7)
+	s = append(s, "&storepb.LabelNamesResponse{")
+	s = append(s, "Names: "+fmt.Sprintf("%#v", this.Names)+",\n")
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelNamesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Matchers) > 0 {
-		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintRpc(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x32
-		}
+func (this *LabelValuesRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if m.Hints != nil {
-		{
-			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintRpc(dAtA, i, uint64(size))
+	s := make([]string, 0, 12)
+	s = append(s, "&storepb.LabelValuesRequest{")
+	s = append(s, "Label: "+fmt.Sprintf("%#v", this.Label)+",\n")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	if this.Matchers != nil {
+		vs := make([]*LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
 		}
-		i--
-		dAtA[i] = 0x2a
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	if m.End != 0 {
-		i = encodeVarintRpc(dAtA, i, uint64(m.End))
-		i--
-		dAtA[i] = 0x20
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "After: "+fmt.Sprintf("%#v", this.After)+",\n")
+	s = append(s, "MaxSizeBytes: "+fmt.Sprintf("%#v", this.MaxSizeBytes)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelValuesResponse) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	if m.Start != 0 {
-		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
-		i--
-		dAtA[i] = 0x18
+	s := make([]string, 0, 7)
+	s = append(s, "&storepb.LabelValuesResponse{")
+	s = append(s, "Values: "+fmt.Sprintf("%#v", this.Values)+",\n")
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
 	}
-	return len(dAtA) - i, nil
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelNamesResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *ExemplarsRequest) GoString() string {
+	if this == nil {
+		return "nil"
 	}
-	return dAtA[:n], nil
-}
-
-func (m *LabelNamesResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *LabelNamesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Hints != nil {
-		{
-			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintRpc(dAtA, i, uint64(size))
+	s := make([]string, 0, 8)
+	s = append(s, "&storepb.ExemplarsRequest{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	if this.Matchers != nil {
+		vs := make([]*ExemplarMatchers, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
 		}
-		i--
-		dAtA[i] = 0x1a
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	if len(m.Warnings) > 0 {
-		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.Warnings[iNdEx])
-			copy(dAtA[i:], m.Warnings[iNdEx])
-			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
-			i--
-			dAtA[i] = 0x12
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExemplarMatchers) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.ExemplarMatchers{")
+	if this.Matchers != nil {
+		vs := make([]*LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
 		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	if len(m.Names) > 0 {
-		for iNdEx := len(m.Names) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.Names[iNdEx])
-			copy(dAtA[i:], m.Names[iNdEx])
-			i = encodeVarintRpc(dAtA, i, uint64(len(m.Names[iNdEx])))
-			i--
-			dAtA[i] = 0xa
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExemplarsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&storepb.ExemplarsResponse{")
+	if this.Series != nil {
+		vs := make([]*SeriesExemplars, len(this.Series))
+		for i := range vs {
+			vs[i] = &this.Series[i]
 		}
+		s = append(s, "Series: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	return len(dAtA) - i, nil
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-
-func (m *LabelValuesRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func valueToGoStringRpc(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
 	}
-	return dAtA[:n], nil
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
 }
 
-func (m *LabelValuesRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// StoreClient is the client API for Store service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StoreClient interface {
+	/// Series streams each Series (Labels and chunk/downsampling chunk) for given label matchers and time range.
+	///
+	/// Series should strictly stream full series after series, optionally split by time. This means that a single frame can contain
+	/// partition of the single series, but once a new series is started to be streamed it means that no more data will
+	/// be sent for previous one.
+	/// Series has to be sorted.
+	///
+	/// There is no requirements on chunk sorting, however it is recommended to have chunk sorted by chunk min time.
+	/// This heavily optimizes the resource usage on Querier / Federated Queries.
+	Series(ctx context.Context, in *SeriesRequest, opts ...grpc.CallOption) (Store_SeriesClient, error)
+	/// LabelNames returns all label names constrained by the given matchers.
+	LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error)
+	/// LabelValues returns all label values for given label name.
+	LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error)
 }
 
-func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Matchers) > 0 {
-		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintRpc(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x3a
-		}
+type storeClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStoreClient(cc *grpc.ClientConn) StoreClient {
+	return &storeClient{cc}
+}
+
+func (c *storeClient) Series(ctx context.Context, in *SeriesRequest, opts ...grpc.CallOption) (Store_SeriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Store_serviceDesc.Streams[0], "/thanos.Store/Series", opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.Hints != nil {
-		{
-			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintRpc(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x32
+	x := &storeSeriesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
 	}
-	if m.End != 0 {
-		i = encodeVarintRpc(dAtA, i, uint64(m.End))
-		i--
-		dAtA[i] = 0x28
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	if m.Start != 0 {
-		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
-		i--
-		dAtA[i] = 0x20
+	return x, nil
+}
+
+type Store_SeriesClient interface {
+	Recv() (*SeriesResponse, error)
+	grpc.ClientStream
+}
+
+type storeSeriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *storeSeriesClient) Recv() (*SeriesResponse, error) {
+	m := new(SeriesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	if len(m.Label) > 0 {
-		i -= len(m.Label)
-		copy(dAtA[i:], m.Label)
-		i = encodeVarintRpc(dAtA, i, uint64(len(m.Label)))
-		i--
-		dAtA[i] = 0xa
+	return m, nil
+}
+
+func (c *storeClient) LabelNames(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (*LabelNamesResponse, error) {
+	out := new(LabelNamesResponse)
+	err := c.cc.Invoke(ctx, "/thanos.Store/LabelNames", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *LabelValuesResponse) Marshal() (dAtA []byte, err error) {
+func (c *storeClient) LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error) {
+	out := new(LabelValuesResponse)
+	err := c.cc.Invoke(ctx, "/thanos.Store/LabelValues", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StoreServer is the server API for Store service.
+type StoreServer interface {
+	/// Series streams each Series (Labels and chunk/downsampling chunk) for given label matchers and time range.
+	///
+	/// Series should strictly stream full series after series, optionally split by time. This means that a single frame can contain
+	/// partition of the single series, but once a new series is started to be streamed it means that no more data will
+	/// be sent for previous one.
+	/// Series has to be sorted.
+	///
+	/// There is no requirements on chunk sorting, however it is recommended to have chunk sorted by chunk min time.
+	/// This heavily optimizes the resource usage on Querier / Federated Queries.
+	Series(*SeriesRequest, Store_SeriesServer) error
+	/// LabelNames returns all label names constrained by the given matchers.
+	LabelNames(context.Context, *LabelNamesRequest) (*LabelNamesResponse, error)
+	/// LabelValues returns all label values for given label name.
+	LabelValues(context.Context, *LabelValuesRequest) (*LabelValuesResponse, error)
+}
+
+// UnimplementedStoreServer can be embedded to have forward compatible implementations.
+type UnimplementedStoreServer struct {
+}
+
+func (*UnimplementedStoreServer) Series(req *SeriesRequest, srv Store_SeriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method Series not implemented")
+}
+func (*UnimplementedStoreServer) LabelNames(ctx context.Context, req *LabelNamesRequest) (*LabelNamesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelNames not implemented")
+}
+func (*UnimplementedStoreServer) LabelValues(ctx context.Context, req *LabelValuesRequest) (*LabelValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
+}
+
+func RegisterStoreServer(s *grpc.Server, srv StoreServer) {
+	s.RegisterService(&_Store_serviceDesc, srv)
+}
+
+func _Store_Series_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SeriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StoreServer).Series(m, &storeSeriesServer{stream})
+}
+
+type Store_SeriesServer interface {
+	Send(*SeriesResponse) error
+	grpc.ServerStream
+}
+
+type storeSeriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *storeSeriesServer) Send(m *SeriesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Store_LabelNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelNamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServer).LabelNames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/thanos.Store/LabelNames",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServer).LabelNames(ctx, req.(*LabelNamesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Store_LabelValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreServer).LabelValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/thanos.Store/LabelValues",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreServer).LabelValues(ctx, req.(*LabelValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Store_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "thanos.Store",
+	HandlerType: (*StoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LabelNames",
+			Handler:    _Store_LabelNames_Handler,
+		},
+		{
+			MethodName: "LabelValues",
+			Handler:    _Store_LabelValues_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Series",
+			Handler:       _Store_Series_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rpc.proto",
+}
+
+func (m *SeriesRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1547,16 +1944,23 @@ func (m *LabelValuesResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *LabelValuesResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *SeriesRequest) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
+	if m.StreamingChunksBatchSize != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.StreamingChunksBatchSize))
+		i--
+		dAtA[i] = 0x6
+		i--
+		dAtA[i] = 0xa0
+	}
 	if m.Hints != nil {
 		{
 			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
@@ -1567,397 +1971,2110 @@ func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			i = encodeVarintRpc(dAtA, i, uint64(size))
 		}
 		i--
-		dAtA[i] = 0x1a
+		dAtA[i] = 0x4a
 	}
-	if len(m.Warnings) > 0 {
-		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.Warnings[iNdEx])
-			copy(dAtA[i:], m.Warnings[iNdEx])
-			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
-			i--
-			dAtA[i] = 0x12
+	if m.SkipChunks {
+		i--
+		if m.SkipChunks {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i--
+		dAtA[i] = 0x40
 	}
-	if len(m.Values) > 0 {
-		for iNdEx := len(m.Values) - 1; iNdEx >= 0; iNdEx-- {
-			i -= len(m.Values[iNdEx])
-			copy(dAtA[i:], m.Values[iNdEx])
-			i = encodeVarintRpc(dAtA, i, uint64(len(m.Values[iNdEx])))
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
 			i--
-			dAtA[i] = 0xa
+			dAtA[i] = 0x1a
 		}
 	}
+	if m.MaxTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.MinTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MinTime))
+		i--
+		dAtA[i] = 0x8
+	}
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
-	offset -= sovRpc(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *Stats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *SeriesRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.MinTime != 0 {
-		n += 1 + sovRpc(uint64(m.MinTime))
-	}
-	if m.MaxTime != 0 {
-		n += 1 + sovRpc(uint64(m.MaxTime))
-	}
-	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovRpc(uint64(l))
-		}
-	}
-	if m.SkipChunks {
-		n += 2
-	}
-	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
-	}
-	return n
+
+func (m *Stats) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *Stats) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
 	if m.FetchedIndexBytes != 0 {
-		n += 1 + sovRpc(uint64(m.FetchedIndexBytes))
+		i = encodeVarintRpc(dAtA, i, uint64(m.FetchedIndexBytes))
+		i--
+		dAtA[i] = 0x8
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *SeriesResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *SeriesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *SeriesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SeriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
 	if m.Result != nil {
-		n += m.Result.Size()
+		{
+			size := m.Result.Size()
+			i -= size
+			if _, err := m.Result.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *SeriesResponse_Series) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
+func (m *SeriesResponse_Series) MarshalTo(dAtA []byte) (int, error) {
+	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
+}
+
+func (m *SeriesResponse_Series) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
 	if m.Series != nil {
-		l = m.Series.Size()
-		n += 1 + l + sovRpc(uint64(l))
+		{
+			size, err := m.Series.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
 	}
-	return n
+	return len(dAtA) - i, nil
 }
-func (m *SeriesResponse_Warning) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.Warning)
-	n += 1 + l + sovRpc(uint64(l))
-	return n
+func (m *SeriesResponse_Warning) MarshalTo(dAtA []byte) (int, error) {
+	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
 }
-func (m *SeriesResponse_Hints) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
-	}
-	return n
+
+func (m *SeriesResponse_Warning) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i -= len(m.Warning)
+	copy(dAtA[i:], m.Warning)
+	i = encodeVarintRpc(dAtA, i, uint64(len(m.Warning)))
+	i--
+	dAtA[i] = 0x12
+	return len(dAtA) - i, nil
 }
-func (m *SeriesResponse_Stats) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Stats != nil {
-		l = m.Stats.Size()
-		n += 1 + l + sovRpc(uint64(l))
-	}
-	return n
+func (m *SeriesResponse_Hints) MarshalTo(dAtA []byte) (int, error) {
+	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
 }
-func (m *LabelNamesRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Start != 0 {
-		n += 1 + sovRpc(uint64(m.Start))
-	}
-	if m.End != 0 {
-		n += 1 + sovRpc(uint64(m.End))
-	}
+
+func (m *SeriesResponse_Hints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
 	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
-	}
-	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovRpc(uint64(l))
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
 		}
+		i--
+		dAtA[i] = 0x1a
 	}
-	return n
+	return len(dAtA) - i, nil
+}
+func (m *SeriesResponse_Stats) MarshalTo(dAtA []byte) (int, error) {
+	return m.MarshalToSizedBuffer(dAtA[:m.Size()])
 }
 
-func (m *LabelNamesResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Names) > 0 {
-		for _, s := range m.Names {
-			l = len(s)
-			n += 1 + l + sovRpc(uint64(l))
+func (m *SeriesResponse_Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Stats != nil {
+		{
+			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
 		}
+		i--
+		dAtA[i] = 0x22
 	}
-	if len(m.Warnings) > 0 {
-		for _, s := range m.Warnings {
-			l = len(s)
-			n += 1 + l + sovRpc(uint64(l))
+	return len(dAtA) - i, nil
+}
+func (m *SeriesResponse_StreamingSeries) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SeriesResponse_StreamingSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.StreamingSeries != nil {
+		{
+			size, err := m.StreamingSeries.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
 		}
+		i--
+		dAtA[i] = 0x2a
 	}
-	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
-	}
-	return n
+	return len(dAtA) - i, nil
+}
+func (m *SeriesResponse_StreamingChunks) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *LabelValuesRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *SeriesResponse_StreamingChunks) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.StreamingChunks != nil {
+		{
+			size, err := m.StreamingChunks.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
 	}
+	return len(dAtA) - i, nil
+}
+func (m *StreamingSeries) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StreamingSeries) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *StreamingSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Label)
-	if l > 0 {
-		n += 1 + l + sovRpc(uint64(l))
-	}
-	if m.Start != 0 {
-		n += 1 + sovRpc(uint64(m.Start))
-	}
-	if m.End != 0 {
-		n += 1 + sovRpc(uint64(m.End))
-	}
-	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
-	}
-	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovRpc(uint64(l))
+	if len(m.Labels) > 0 {
+		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Labels[iNdEx].Size()
+				i -= size
+				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
 		}
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *LabelValuesResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *StreamingSeriesBatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *StreamingSeriesBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *StreamingSeriesBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Values) > 0 {
-		for _, s := range m.Values {
-			l = len(s)
-			n += 1 + l + sovRpc(uint64(l))
-		}
+	if m.EstimatedChunksBytes != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.EstimatedChunksBytes))
+		i--
+		dAtA[i] = 0x20
 	}
-	if len(m.Warnings) > 0 {
-		for _, s := range m.Warnings {
-			l = len(s)
-			n += 1 + l + sovRpc(uint64(l))
+	if m.EstimatedChunksCount != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.EstimatedChunksCount))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.IsEndOfSeriesStream {
+		i--
+		if m.IsEndOfSeriesStream {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i--
+		dAtA[i] = 0x10
 	}
-	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if len(m.Series) > 0 {
+		for iNdEx := len(m.Series) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Series[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func sovRpc(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
+func (m *StreamingChunks) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
 }
-func sozRpc(x uint64) (n int) {
-	return sovRpc(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+
+func (m *StreamingChunks) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
-func (this *SeriesRequest) String() string {
-	if this == nil {
-		return "nil"
-	}
-	repeatedStringForMatchers := "[]LabelMatcher{"
-	for _, f := range this.Matchers {
-		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+
+func (m *StreamingChunks) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Chunks) > 0 {
+		for iNdEx := len(m.Chunks) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Chunks[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
 	}
-	repeatedStringForMatchers += "}"
-	s := strings.Join([]string{`&SeriesRequest{`,
-		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
-		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
-		`Matchers:` + repeatedStringForMatchers + `,`,
-		`SkipChunks:` + fmt.Sprintf("%v", this.SkipChunks) + `,`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *Stats) String() string {
-	if this == nil {
-		return "nil"
+	if m.SeriesIndex != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesIndex))
+		i--
+		dAtA[i] = 0x8
 	}
-	s := strings.Join([]string{`&Stats{`,
-		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *SeriesResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *StreamingChunksBatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	s := strings.Join([]string{`&SeriesResponse{`,
-		`Result:` + fmt.Sprintf("%v", this.Result) + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *SeriesResponse_Series) String() string {
-	if this == nil {
-		return "nil"
-	}
-	s := strings.Join([]string{`&SeriesResponse_Series{`,
-		`Series:` + strings.Replace(fmt.Sprintf("%v", this.Series), "Series", "Series", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+
+func (m *StreamingChunksBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
-func (this *SeriesResponse_Warning) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *StreamingChunksBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for iNdEx := len(m.Series) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Series[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	s := strings.Join([]string{`&SeriesResponse_Warning{`,
-		`Warning:` + fmt.Sprintf("%v", this.Warning) + `,`,
-		`}`,
-	}, "")
-	return s
+	return len(dAtA) - i, nil
 }
-func (this *SeriesResponse_Hints) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	s := strings.Join([]string{`&SeriesResponse_Hints{`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *SeriesResponse_Stats) String() string {
-	if this == nil {
-		return "nil"
-	}
-	s := strings.Join([]string{`&SeriesResponse_Stats{`,
-		`Stats:` + strings.Replace(fmt.Sprintf("%v", this.Stats), "Stats", "Stats", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+
+func (m *LabelNamesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
-func (this *LabelNamesRequest) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.After) > 0 {
+		i -= len(m.After)
+		copy(dAtA[i:], m.After)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.After)))
+		i--
+		dAtA[i] = 0x42
 	}
-	repeatedStringForMatchers := "[]LabelMatcher{"
-	for _, f := range this.Matchers {
-		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	if m.Limit != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x38
 	}
-	repeatedStringForMatchers += "}"
-	s := strings.Join([]string{`&LabelNamesRequest{`,
-		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
-		`End:` + fmt.Sprintf("%v", this.End) + `,`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`Matchers:` + repeatedStringForMatchers + `,`,
-		`}`,
-	}, "")
-	return s
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.End != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Start != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x18
+	}
+	return len(dAtA) - i, nil
 }
-func (this *LabelNamesResponse) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	s := strings.Join([]string{`&LabelNamesResponse{`,
-		`Names:` + fmt.Sprintf("%v", this.Names) + `,`,
-		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+	return dAtA[:n], nil
 }
-func (this *LabelValuesRequest) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelNamesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Names) > 0 {
+		for iNdEx := len(m.Names) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Names[iNdEx])
+			copy(dAtA[i:], m.Names[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Names[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelValuesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MaxSizeBytes != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MaxSizeBytes))
+		i--
+		dAtA[i] = 0x50
+	}
+	if len(m.After) > 0 {
+		i -= len(m.After)
+		copy(dAtA[i:], m.After)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.After)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if m.Limit != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.End != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Start != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Label) > 0 {
+		i -= len(m.Label)
+		copy(dAtA[i:], m.Label)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.Label)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelValuesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Values) > 0 {
+		for iNdEx := len(m.Values) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Values[iNdEx])
+			copy(dAtA[i:], m.Values[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Values[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExemplarsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.End != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Start != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExemplarMatchers) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarMatchers) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarMatchers) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExemplarsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExemplarsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExemplarsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Series) > 0 {
+		for iNdEx := len(m.Series) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Series[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
+	offset -= sovRpc(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *SeriesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MinTime != 0 {
+		n += 1 + sovRpc(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovRpc(uint64(m.MaxTime))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.SkipChunks {
+		n += 2
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if m.StreamingChunksBatchSize != 0 {
+		n += 2 + sovRpc(uint64(m.StreamingChunksBatchSize))
+	}
+	return n
+}
+
+func (m *Stats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FetchedIndexBytes != 0 {
+		n += 1 + sovRpc(uint64(m.FetchedIndexBytes))
+	}
+	return n
+}
+
+func (m *SeriesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Result != nil {
+		n += m.Result.Size()
+	}
+	return n
+}
+
+func (m *SeriesResponse_Series) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Series != nil {
+		l = m.Series.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_Warning) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Warning)
+	n += 1 + l + sovRpc(uint64(l))
+	return n
+}
+func (m *SeriesResponse_Hints) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_Stats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_StreamingSeries) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StreamingSeries != nil {
+		l = m.StreamingSeries.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_StreamingChunks) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StreamingChunks != nil {
+		l = m.StreamingChunks.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *StreamingSeries) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *StreamingSeriesBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for _, e := range m.Series {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.IsEndOfSeriesStream {
+		n += 2
+	}
+	if m.EstimatedChunksCount != 0 {
+		n += 1 + sovRpc(uint64(m.EstimatedChunksCount))
+	}
+	if m.EstimatedChunksBytes != 0 {
+		n += 1 + sovRpc(uint64(m.EstimatedChunksBytes))
+	}
+	return n
+}
+
+func (m *StreamingChunks) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.SeriesIndex != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesIndex))
+	}
+	if len(m.Chunks) > 0 {
+		for _, e := range m.Chunks {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *StreamingChunksBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for _, e := range m.Series {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelNamesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Limit != 0 {
+		n += 1 + sovRpc(uint64(m.Limit))
+	}
+	l = len(m.After)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func (m *LabelNamesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Names) > 0 {
+		for _, s := range m.Names {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func (m *LabelValuesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Label)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Limit != 0 {
+		n += 1 + sovRpc(uint64(m.Limit))
+	}
+	l = len(m.After)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if m.MaxSizeBytes != 0 {
+		n += 1 + sovRpc(uint64(m.MaxSizeBytes))
+	}
+	return n
+}
+
+func (m *LabelValuesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for _, s := range m.Values {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func (m *ExemplarsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func (m *ExemplarMatchers) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExemplarsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for _, e := range m.Series {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func sovRpc(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozRpc(x uint64) (n int) {
+	return sovRpc(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *SeriesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&SeriesRequest{`,
+		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`SkipChunks:` + fmt.Sprintf("%v", this.SkipChunks) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`StreamingChunksBatchSize:` + fmt.Sprintf("%v", this.StreamingChunksBatchSize) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Stats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Stats{`,
+		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse{`,
+		`Result:` + fmt.Sprintf("%v", this.Result) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Series) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Series{`,
+		`Series:` + strings.Replace(fmt.Sprintf("%v", this.Series), "Series", "Series", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Warning) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Warning{`,
+		`Warning:` + fmt.Sprintf("%v", this.Warning) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Hints) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Hints{`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Stats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Stats{`,
+		`Stats:` + strings.Replace(fmt.Sprintf("%v", this.Stats), "Stats", "Stats", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_StreamingSeries) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_StreamingSeries{`,
+		`StreamingSeries:` + strings.Replace(fmt.Sprintf("%v", this.StreamingSeries), "StreamingSeriesBatch", "StreamingSeriesBatch", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_StreamingChunks) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_StreamingChunks{`,
+		`StreamingChunks:` + strings.Replace(fmt.Sprintf("%v", this.StreamingChunks), "StreamingChunksBatch", "StreamingChunksBatch", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *StreamingSeries) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&StreamingSeries{`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *StreamingSeriesBatch) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSeries := "[]*StreamingSeries{"
+	for _, f := range this.Series {
+		repeatedStringForSeries += strings.Replace(f.String(), "StreamingSeries", "StreamingSeries", 1) + ","
+	}
+	repeatedStringForSeries += "}"
+	s := strings.Join([]string{`&StreamingSeriesBatch{`,
+		`Series:` + repeatedStringForSeries + `,`,
+		`IsEndOfSeriesStream:` + fmt.Sprintf("%v", this.IsEndOfSeriesStream) + `,`,
+		`EstimatedChunksCount:` + fmt.Sprintf("%v", this.EstimatedChunksCount) + `,`,
+		`EstimatedChunksBytes:` + fmt.Sprintf("%v", this.EstimatedChunksBytes) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *StreamingChunks) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForChunks := "[]AggrChunk{"
+	for _, f := range this.Chunks {
+		repeatedStringForChunks += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForChunks += "}"
+	s := strings.Join([]string{`&StreamingChunks{`,
+		`SeriesIndex:` + fmt.Sprintf("%v", this.SeriesIndex) + `,`,
+		`Chunks:` + repeatedStringForChunks + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *StreamingChunksBatch) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSeries := "[]*StreamingChunks{"
+	for _, f := range this.Series {
+		repeatedStringForSeries += strings.Replace(f.String(), "StreamingChunks", "StreamingChunks", 1) + ","
+	}
+	repeatedStringForSeries += "}"
+	s := strings.Join([]string{`&StreamingChunksBatch{`,
+		`Series:` + repeatedStringForSeries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelNamesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&LabelNamesRequest{`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`After:` + fmt.Sprintf("%v", this.After) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelNamesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelNamesResponse{`,
+		`Names:` + fmt.Sprintf("%v", this.Names) + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&LabelValuesRequest{`,
+		`Label:` + fmt.Sprintf("%v", this.Label) + `,`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`After:` + fmt.Sprintf("%v", this.After) + `,`,
+		`MaxSizeBytes:` + fmt.Sprintf("%v", this.MaxSizeBytes) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelValuesResponse{`,
+		`Values:` + fmt.Sprintf("%v", this.Values) + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]ExemplarMatchers{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += strings.Replace(strings.Replace(f.String(), "ExemplarMatchers", "ExemplarMatchers", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ExemplarsRequest{`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarMatchers) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&ExemplarMatchers{`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSeries := "[]SeriesExemplars{"
+	for _, f := range this.Series {
+		repeatedStringForSeries += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForSeries += "}"
+	s := strings.Join([]string{`&ExemplarsResponse{`,
+		`Series:` + repeatedStringForSeries + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringRpc(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			}
+			m.MinTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SkipChunks", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SkipChunks = bool(v != 0)
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 100:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunksBatchSize", wireType)
+			}
+			m.StreamingChunksBatchSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StreamingChunksBatchSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Stats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Stats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Stats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedIndexBytes", wireType)
+			}
+			m.FetchedIndexBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedIndexBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Series{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Series{v}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warning", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Result = &SeriesResponse_Warning{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &types.Any{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Hints{v}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Stats{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Stats{v}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingSeries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &StreamingSeriesBatch{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_StreamingSeries{v}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunks", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &StreamingChunksBatch{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_StreamingChunks{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	repeatedStringForMatchers := "[]LabelMatcher{"
-	for _, f := range this.Matchers {
-		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	repeatedStringForMatchers += "}"
-	s := strings.Join([]string{`&LabelValuesRequest{`,
-		`Label:` + fmt.Sprintf("%v", this.Label) + `,`,
-		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
-		`End:` + fmt.Sprintf("%v", this.End) + `,`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`Matchers:` + repeatedStringForMatchers + `,`,
-		`}`,
-	}, "")
-	return s
+	return nil
 }
-func (this *LabelValuesResponse) String() string {
-	if this == nil {
-		return "nil"
+func (m *StreamingSeries) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StreamingSeries: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StreamingSeries: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, github_com_grafana_mimir_pkg_mimirpb.LabelAdapter{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	s := strings.Join([]string{`&LabelValuesResponse{`,
-		`Values:` + fmt.Sprintf("%v", this.Values) + `,`,
-		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func valueToStringRpc(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	return nil
 }
-func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
+func (m *StreamingSeriesBatch) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1980,17 +4097,17 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SeriesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: StreamingSeriesBatch: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SeriesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StreamingSeriesBatch: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
 			}
-			m.MinTime = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2000,16 +4117,31 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MinTime |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Series = append(m.Series, &StreamingSeries{})
+			if err := m.Series[len(m.Series)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field IsEndOfSeriesStream", wireType)
 			}
-			m.MaxTime = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2019,16 +4151,17 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MaxTime |= int64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.IsEndOfSeriesStream = bool(v != 0)
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EstimatedChunksCount", wireType)
 			}
-			var msglen int
+			m.EstimatedChunksCount = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2038,31 +4171,88 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.EstimatedChunksCount |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EstimatedChunksBytes", wireType)
+			}
+			m.EstimatedChunksBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EstimatedChunksBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthRpc
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (iNdEx + skippy) < 0 {
 				return ErrInvalidLengthRpc
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Matchers = append(m.Matchers, LabelMatcher{})
-			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StreamingChunks) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
 			}
-			iNdEx = postIndex
-		case 8:
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StreamingChunks: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StreamingChunks: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SkipChunks", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesIndex", wireType)
 			}
-			var v int
+			m.SeriesIndex = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2072,15 +4262,14 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.SeriesIndex |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.SkipChunks = bool(v != 0)
-		case 9:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunks", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2107,10 +4296,8 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Hints == nil {
-				m.Hints = &types.Any{}
-			}
-			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Chunks = append(m.Chunks, AggrChunk{})
+			if err := m.Chunks[len(m.Chunks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -2138,7 +4325,7 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Stats) Unmarshal(dAtA []byte) error {
+func (m *StreamingChunksBatch) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2161,17 +4348,17 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Stats: wiretype end group for non-group")
+			return fmt.Errorf("proto: StreamingChunksBatch: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Stats: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StreamingChunksBatch: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FetchedIndexBytes", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
 			}
-			m.FetchedIndexBytes = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2181,11 +4368,26 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.FetchedIndexBytes |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Series = append(m.Series, &StreamingChunks{})
+			if err := m.Series[len(m.Series)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -2210,7 +4412,7 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2233,15 +4435,53 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SeriesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SeriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2268,15 +4508,69 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &Series{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Result = &SeriesResponse_Series{v}
 			iNdEx = postIndex
-		case 2:
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Warning", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field After", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2304,13 +4598,66 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Result = &SeriesResponse_Warning{string(dAtA[iNdEx:postIndex])}
+			m.After = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 3:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Names", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2320,30 +4667,59 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthRpc
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &types.Any{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Names = append(m.Names, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
 			}
-			m.Result = &SeriesResponse_Hints{v}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 4:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2370,11 +4746,12 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &Stats{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Result = &SeriesResponse_Stats{v}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -2400,7 +4777,7 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2423,13 +4800,45 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 3:
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Label", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Label = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
@@ -2448,7 +4857,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 4:
+		case 5:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
@@ -2467,7 +4876,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 5:
+		case 6:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
 			}
@@ -2503,7 +4912,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 6:
+		case 7:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
 			}
@@ -2537,6 +4946,76 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field After", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.After = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSizeBytes", wireType)
+			}
+			m.MaxSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -2561,7 +5040,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2584,15 +5063,15 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Names", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2620,7 +5099,7 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Names = append(m.Names, string(dAtA[iNdEx:postIndex]))
+			m.Values = append(m.Values, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
@@ -2714,7 +5193,7 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
+func (m *ExemplarsRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2737,17 +5216,17 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExemplarsRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExemplarsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Label", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			var stringLen uint64
+			m.Start = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2757,29 +5236,16 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.Start |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthRpc
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Label = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 4:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
-			m.Start = 0
+			m.End = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2789,16 +5255,16 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Start |= int64(b&0x7F) << shift
+				m.End |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
 			}
-			m.End = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2808,12 +5274,27 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.End |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, ExemplarMatchers{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
 			}
@@ -2849,7 +5330,60 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 7:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarMatchers) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarMatchers: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarMatchers: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
 			}
@@ -2907,7 +5441,7 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
+func (m *ExemplarsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2930,17 +5464,17 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExemplarsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExemplarsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2950,23 +5484,25 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthRpc
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Values = append(m.Values, string(dAtA[iNdEx:postIndex]))
+			m.Series = append(m.Series, SeriesExemplars{})
+			if err := m.Series[len(m.Series)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
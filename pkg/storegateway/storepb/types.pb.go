@@ -7,8 +7,8 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
-	_ "github.com/grafana/mimir/pkg/mimirpb"
 	github_com_grafana_mimir_pkg_mimirpb "github.com/grafana/mimir/pkg/mimirpb"
+	mimirpb "github.com/grafana/mimir/pkg/mimirpb"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -76,7 +76,7 @@ var LabelMatcher_Type_value = map[string]int32{
 }
 
 func (LabelMatcher_Type) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_d938547f84707355, []int{3, 0}
+	return fileDescriptor_d938547f84707355, []int{5, 0}
 }
 
 type Chunk struct {
@@ -153,6 +153,81 @@ func (m *Series) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Series proto.InternalMessageInfo
 
+// SeriesExemplars holds the exemplars of a single series.
+type SeriesExemplars struct {
+	Labels    []github_com_grafana_mimir_pkg_mimirpb.LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=github.com/grafana/mimir/pkg/mimirpb.LabelAdapter" json:"labels"`
+	Exemplars []mimirpb.Exemplar                                  `protobuf:"bytes,2,rep,name=exemplars,proto3" json:"exemplars"`
+}
+
+func (m *SeriesExemplars) Reset()      { *m = SeriesExemplars{} }
+func (*SeriesExemplars) ProtoMessage() {}
+func (*SeriesExemplars) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{2}
+}
+func (m *SeriesExemplars) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SeriesExemplars) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SeriesExemplars.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SeriesExemplars) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SeriesExemplars.Merge(m, src)
+}
+func (m *SeriesExemplars) XXX_Size() int {
+	return m.Size()
+}
+func (m *SeriesExemplars) XXX_DiscardUnknown() {
+	xxx_messageInfo_SeriesExemplars.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SeriesExemplars proto.InternalMessageInfo
+
+// BlockExemplars is the content of the exemplars file persisted in a block.
+type BlockExemplars struct {
+	Series []SeriesExemplars `protobuf:"bytes,1,rep,name=series,proto3" json:"series"`
+}
+
+func (m *BlockExemplars) Reset()      { *m = BlockExemplars{} }
+func (*BlockExemplars) ProtoMessage() {}
+func (*BlockExemplars) Descriptor() ([]byte, []int) {
+	return fileDescriptor_d938547f84707355, []int{3}
+}
+func (m *BlockExemplars) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BlockExemplars) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BlockExemplars.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BlockExemplars) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockExemplars.Merge(m, src)
+}
+func (m *BlockExemplars) XXX_Size() int {
+	return m.Size()
+}
+func (m *BlockExemplars) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockExemplars.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockExemplars proto.InternalMessageInfo
+
 type AggrChunk struct {
 	MinTime int64  `protobuf:"varint,1,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
 	MaxTime int64  `protobuf:"varint,2,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
@@ -162,7 +237,7 @@ type AggrChunk struct {
 func (m *AggrChunk) Reset()      { *m = AggrChunk{} }
 func (*AggrChunk) ProtoMessage() {}
 func (*AggrChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d938547f84707355, []int{2}
+	return fileDescriptor_d938547f84707355, []int{4}
 }
 func (m *AggrChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -201,7 +276,7 @@ type LabelMatcher struct {
 func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
 func (*LabelMatcher) ProtoMessage() {}
 func (*LabelMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_d938547f84707355, []int{3}
+	return fileDescriptor_d938547f84707355, []int{5}
 }
 func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -235,6 +310,8 @@ func init() {
 	proto.RegisterEnum("thanos.LabelMatcher_Type", LabelMatcher_Type_name, LabelMatcher_Type_value)
 	proto.RegisterType((*Chunk)(nil), "thanos.Chunk")
 	proto.RegisterType((*Series)(nil), "thanos.Series")
+	proto.RegisterType((*SeriesExemplars)(nil), "thanos.SeriesExemplars")
+	proto.RegisterType((*BlockExemplars)(nil), "thanos.BlockExemplars")
 	proto.RegisterType((*AggrChunk)(nil), "thanos.AggrChunk")
 	proto.RegisterType((*LabelMatcher)(nil), "thanos.LabelMatcher")
 }
@@ -242,43 +319,47 @@ func init() {
 func init() { proto.RegisterFile("types.proto", fileDescriptor_d938547f84707355) }
 
 var fileDescriptor_d938547f84707355 = []byte{
-	// 567 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xcd, 0x6e, 0xd3, 0x40,
-	0x10, 0xf6, 0x26, 0x8e, 0xe3, 0x6c, 0x5b, 0x58, 0xb6, 0x15, 0x72, 0x7b, 0xd8, 0x46, 0x3e, 0x45,
-	0x48, 0x75, 0xa0, 0x70, 0x41, 0xe2, 0xd2, 0xa0, 0xa0, 0x2a, 0xe2, 0xa7, 0x75, 0x8b, 0x84, 0x10,
-	0x52, 0xb5, 0x76, 0x37, 0xce, 0xaa, 0xf1, 0x8f, 0xd6, 0x1b, 0x68, 0x6f, 0x7d, 0x04, 0x5e, 0x81,
-	0x1b, 0x2f, 0x82, 0x94, 0x1b, 0x39, 0x56, 0x1c, 0x2a, 0xe2, 0x5c, 0x38, 0xf6, 0x11, 0x90, 0xd7,
-	0x0e, 0x24, 0xea, 0xa5, 0x27, 0xcf, 0xcc, 0xf7, 0xcd, 0x7c, 0x9f, 0x67, 0x6c, 0xb8, 0x22, 0x2f,
-	0x12, 0x96, 0x3a, 0x89, 0x88, 0x65, 0x8c, 0x0d, 0x39, 0xa0, 0x51, 0x9c, 0x6e, 0xed, 0x04, 0x5c,
-	0x0e, 0x46, 0x9e, 0xe3, 0xc7, 0x61, 0x3b, 0x88, 0x83, 0xb8, 0xad, 0x60, 0x6f, 0xd4, 0x57, 0x99,
-	0x4a, 0x54, 0x54, 0xb4, 0x6d, 0x3d, 0x5e, 0xa4, 0x0b, 0xda, 0xa7, 0x11, 0x6d, 0x87, 0x3c, 0xe4,
-	0xa2, 0x9d, 0x9c, 0x05, 0x45, 0x94, 0x78, 0xc5, 0xb3, 0xe8, 0xb0, 0x7f, 0x02, 0x58, 0x7b, 0x39,
-	0x18, 0x45, 0x67, 0xf8, 0x11, 0xd4, 0x73, 0x07, 0x16, 0x68, 0x82, 0xd6, 0xbd, 0xdd, 0x87, 0x4e,
-	0xe1, 0xc0, 0x51, 0xa0, 0xd3, 0x8d, 0xfc, 0xf8, 0x94, 0x47, 0x81, 0xab, 0x38, 0xf8, 0x00, 0xea,
-	0xa7, 0x54, 0x52, 0xab, 0xd2, 0x04, 0xad, 0xd5, 0xce, 0x8b, 0xf1, 0xf5, 0xb6, 0xf6, 0xeb, 0x7a,
-	0xfb, 0xd9, 0x5d, 0xd4, 0x9d, 0xf7, 0x51, 0x4a, 0xfb, 0xac, 0x73, 0x21, 0xd9, 0xd1, 0x90, 0xfb,
-	0xcc, 0x55, 0x93, 0xec, 0x7d, 0x68, 0xce, 0x35, 0xf0, 0x1a, 0x6c, 0x28, 0xd5, 0x93, 0x0f, 0xef,
-	0x5c, 0xa4, 0xe1, 0x75, 0x78, 0xbf, 0x48, 0xf7, 0x79, 0x2a, 0xe3, 0x40, 0xd0, 0x10, 0x01, 0x6c,
-	0xc1, 0x8d, 0xa2, 0xf8, 0x6a, 0x18, 0x53, 0xf9, 0x1f, 0xa9, 0xd8, 0xdf, 0x00, 0x34, 0x8e, 0x98,
-	0xe0, 0x2c, 0xc5, 0x7d, 0x68, 0x0c, 0xa9, 0xc7, 0x86, 0xa9, 0x05, 0x9a, 0xd5, 0xd6, 0xca, 0xee,
-	0xba, 0xe3, 0xc7, 0x42, 0xb2, 0xf3, 0xc4, 0x73, 0x5e, 0xe7, 0xf5, 0x03, 0xca, 0x45, 0xe7, 0x79,
-	0xe9, 0xfe, 0xc9, 0x9d, 0xdc, 0xab, 0xbe, 0xbd, 0x53, 0x9a, 0x48, 0x26, 0xdc, 0x72, 0x3a, 0x6e,
-	0x43, 0xc3, 0xcf, 0xcd, 0xa4, 0x56, 0x45, 0xe9, 0x3c, 0x98, 0x2f, 0x6f, 0x2f, 0x08, 0x84, 0xb2,
-	0xd9, 0xd1, 0x73, 0x15, 0xb7, 0xa4, 0xd9, 0x97, 0x00, 0x36, 0xfe, 0x61, 0x78, 0x13, 0x9a, 0x21,
-	0x8f, 0x4e, 0x24, 0x0f, 0x8b, 0xed, 0x57, 0xdd, 0x7a, 0xc8, 0xa3, 0x63, 0x1e, 0x32, 0x05, 0xd1,
-	0xf3, 0x02, 0xaa, 0x94, 0x10, 0x3d, 0x57, 0xd0, 0x36, 0xac, 0x0a, 0xfa, 0xc5, 0xaa, 0x36, 0x41,
-	0x6b, 0x65, 0x77, 0x6d, 0xe9, 0x5c, 0x6e, 0x8e, 0xf4, 0x74, 0x53, 0x47, 0xb5, 0x9e, 0x6e, 0xd6,
-	0x90, 0xd1, 0xd3, 0x4d, 0x03, 0xd5, 0x7b, 0xba, 0x59, 0x47, 0x66, 0x4f, 0x37, 0x4d, 0xd4, 0xb0,
-	0x7f, 0x00, 0xb8, 0xaa, 0x5e, 0xe6, 0x0d, 0x95, 0xfe, 0x80, 0x09, 0xbc, 0xb3, 0x74, 0xff, 0xcd,
-	0xf9, 0xc0, 0x45, 0x8e, 0x73, 0x7c, 0x91, 0xb0, 0xf2, 0x13, 0xc0, 0x50, 0x8f, 0x68, 0xe9, 0xaa,
-	0xe1, 0xaa, 0x18, 0x6f, 0xc0, 0xda, 0x67, 0x3a, 0x1c, 0x31, 0x65, 0xaa, 0xe1, 0x16, 0x89, 0xfd,
-	0x09, 0xea, 0x79, 0x5f, 0x7e, 0xc7, 0xc5, 0x61, 0x27, 0xdd, 0x43, 0xa4, 0xe1, 0x0d, 0x88, 0x96,
-	0x8a, 0x6f, 0xbb, 0x87, 0x08, 0xdc, 0xa2, 0xba, 0x5d, 0x54, 0xb9, 0x4d, 0x75, 0xbb, 0xa8, 0xda,
-	0xd9, 0x1b, 0x4f, 0x89, 0x36, 0x99, 0x12, 0xed, 0x6a, 0x4a, 0xb4, 0x9b, 0x29, 0x01, 0x97, 0x19,
-	0x01, 0xdf, 0x33, 0x02, 0xc6, 0x19, 0x01, 0x93, 0x8c, 0x80, 0xdf, 0x19, 0x01, 0x7f, 0x32, 0xa2,
-	0xdd, 0x64, 0x04, 0x7c, 0x9d, 0x11, 0x6d, 0x32, 0x23, 0xda, 0xd5, 0x8c, 0x68, 0x1f, 0xeb, 0xa9,
-	0x8c, 0x05, 0x4b, 0x3c, 0xcf, 0x50, 0xbf, 0xc2, 0xd3, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x08,
-	0x77, 0xfb, 0x5f, 0x82, 0x03, 0x00, 0x00,
+	// 625 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x94, 0xcb, 0x6e, 0xd3, 0x40,
+	0x14, 0x86, 0x3d, 0x89, 0xe3, 0x38, 0xd3, 0x9b, 0x99, 0x56, 0x90, 0x76, 0x31, 0x8d, 0xbc, 0x8a,
+	0x90, 0xea, 0x40, 0xb9, 0x48, 0x48, 0x6c, 0x1a, 0x14, 0xa8, 0x22, 0x2e, 0xad, 0x5b, 0x24, 0x84,
+	0x90, 0xaa, 0xb1, 0x3b, 0x71, 0xac, 0xfa, 0xa6, 0xf1, 0x04, 0xd2, 0x5d, 0x1f, 0x81, 0x57, 0x60,
+	0xc7, 0x96, 0x87, 0x40, 0xea, 0x8e, 0x2e, 0x2b, 0x16, 0x15, 0x71, 0x37, 0x2c, 0xfb, 0x08, 0xc8,
+	0x33, 0x36, 0x69, 0xe9, 0xa6, 0x2b, 0x56, 0x9e, 0x33, 0xff, 0x7f, 0xce, 0xf9, 0xce, 0xe8, 0xc8,
+	0x70, 0x86, 0x1f, 0x26, 0x34, 0xb5, 0x12, 0x16, 0xf3, 0x18, 0x69, 0x7c, 0x48, 0xa2, 0x38, 0x5d,
+	0x59, 0xf3, 0x7c, 0x3e, 0x1c, 0x39, 0x96, 0x1b, 0x87, 0x1d, 0x2f, 0xf6, 0xe2, 0x8e, 0x90, 0x9d,
+	0xd1, 0x40, 0x44, 0x22, 0x10, 0x27, 0x99, 0xb6, 0x72, 0xef, 0xb2, 0x9d, 0x91, 0x01, 0x89, 0x48,
+	0x27, 0xf4, 0x43, 0x9f, 0x75, 0x92, 0x03, 0x4f, 0x9e, 0x12, 0x47, 0x7e, 0x65, 0x86, 0xf9, 0x03,
+	0xc0, 0xda, 0xb3, 0xe1, 0x28, 0x3a, 0x40, 0x77, 0xa1, 0x9a, 0x13, 0x34, 0x41, 0x0b, 0xb4, 0xe7,
+	0xd7, 0x6f, 0x5b, 0x92, 0xc0, 0x12, 0xa2, 0xd5, 0x8b, 0xdc, 0x78, 0xdf, 0x8f, 0x3c, 0x5b, 0x78,
+	0xd0, 0x16, 0x54, 0xf7, 0x09, 0x27, 0xcd, 0x4a, 0x0b, 0xb4, 0x67, 0xbb, 0x4f, 0x8f, 0xcf, 0x56,
+	0x95, 0x9f, 0x67, 0xab, 0x0f, 0x6f, 0xd2, 0xdd, 0x7a, 0x1b, 0xa5, 0x64, 0x40, 0xbb, 0x87, 0x9c,
+	0xee, 0x04, 0xbe, 0x4b, 0x6d, 0x51, 0xc9, 0xdc, 0x84, 0x7a, 0xd9, 0x03, 0xcd, 0xc1, 0x86, 0xe8,
+	0xba, 0xf7, 0xee, 0x8d, 0x6d, 0x28, 0x68, 0x11, 0x2e, 0xc8, 0x70, 0xd3, 0x4f, 0x79, 0xec, 0x31,
+	0x12, 0x1a, 0x00, 0x35, 0xe1, 0x92, 0xbc, 0x7c, 0x1e, 0xc4, 0x84, 0x4f, 0x95, 0x8a, 0xf9, 0x05,
+	0x40, 0x6d, 0x87, 0x32, 0x9f, 0xa6, 0x68, 0x00, 0xb5, 0x80, 0x38, 0x34, 0x48, 0x9b, 0xa0, 0x55,
+	0x6d, 0xcf, 0xac, 0x2f, 0x5a, 0x6e, 0xcc, 0x38, 0x1d, 0x27, 0x8e, 0xf5, 0x32, 0xbf, 0xdf, 0x22,
+	0x3e, 0xeb, 0x3e, 0x29, 0xe8, 0xef, 0xdf, 0x88, 0x5e, 0xe4, 0x6d, 0xec, 0x93, 0x84, 0x53, 0x66,
+	0x17, 0xd5, 0x51, 0x07, 0x6a, 0x6e, 0x0e, 0x93, 0x36, 0x2b, 0xa2, 0xcf, 0xad, 0xf2, 0xf1, 0x36,
+	0x3c, 0x8f, 0x09, 0xcc, 0xae, 0x9a, 0x77, 0xb1, 0x0b, 0x9b, 0xf9, 0x0d, 0xc0, 0x05, 0xc9, 0xd8,
+	0x1b, 0xd3, 0x30, 0x09, 0x08, 0xfb, 0x7f, 0xb0, 0x8f, 0x61, 0x83, 0x96, 0x4d, 0x0b, 0x5e, 0x34,
+	0x6d, 0x55, 0xf2, 0x14, 0xc0, 0x53, 0xab, 0xf9, 0x02, 0xce, 0x77, 0x83, 0xd8, 0x3d, 0x98, 0x12,
+	0x3f, 0x82, 0x5a, 0x2a, 0x86, 0x28, 0x88, 0xef, 0x94, 0x63, 0xff, 0x33, 0x5a, 0x39, 0xbc, 0x34,
+	0x9b, 0x47, 0x00, 0x36, 0xfe, 0x3e, 0x0c, 0x5a, 0x86, 0x7a, 0xe8, 0x47, 0x7b, 0xdc, 0x0f, 0xe5,
+	0xea, 0x55, 0xed, 0x7a, 0xe8, 0x47, 0xbb, 0x7e, 0x48, 0x85, 0x44, 0xc6, 0x52, 0xaa, 0x14, 0x12,
+	0x19, 0x0b, 0x69, 0x15, 0x56, 0x19, 0xf9, 0xd4, 0xac, 0xb6, 0x40, 0x7b, 0x66, 0x7d, 0xee, 0xca,
+	0xae, 0xda, 0xb9, 0xd2, 0x57, 0x75, 0xd5, 0xa8, 0xf5, 0x55, 0xbd, 0x66, 0x68, 0x7d, 0x55, 0xd7,
+	0x8c, 0x7a, 0x5f, 0xd5, 0xeb, 0x86, 0xde, 0x57, 0x75, 0xdd, 0x68, 0x98, 0xdf, 0x01, 0x9c, 0x15,
+	0x8f, 0xf3, 0x8a, 0x70, 0x77, 0x48, 0x19, 0x5a, 0xbb, 0xb2, 0xfc, 0xcb, 0x65, 0xc1, 0xcb, 0x1e,
+	0x6b, 0xf7, 0x30, 0xa1, 0xc5, 0xfe, 0x23, 0xa8, 0x46, 0xa4, 0xa0, 0x6a, 0xd8, 0xe2, 0x8c, 0x96,
+	0x60, 0xed, 0x23, 0x09, 0x46, 0x54, 0x40, 0x35, 0x6c, 0x19, 0x98, 0x1f, 0xa0, 0x9a, 0xe7, 0xe5,
+	0x4b, 0x7c, 0xb9, 0xd8, 0x5e, 0x6f, 0xdb, 0x50, 0xd0, 0x12, 0x34, 0xae, 0x5c, 0xbe, 0xee, 0x6d,
+	0x1b, 0xe0, 0x9a, 0xd5, 0xee, 0x19, 0x95, 0xeb, 0x56, 0xbb, 0x67, 0x54, 0xbb, 0x1b, 0xc7, 0x13,
+	0xac, 0x9c, 0x4c, 0xb0, 0x72, 0x3a, 0xc1, 0xca, 0xc5, 0x04, 0x83, 0xa3, 0x0c, 0x83, 0xaf, 0x19,
+	0x06, 0xc7, 0x19, 0x06, 0x27, 0x19, 0x06, 0xbf, 0x32, 0x0c, 0x7e, 0x67, 0x58, 0xb9, 0xc8, 0x30,
+	0xf8, 0x7c, 0x8e, 0x95, 0x93, 0x73, 0xac, 0x9c, 0x9e, 0x63, 0xe5, 0x7d, 0x3d, 0xe5, 0x31, 0xa3,
+	0x89, 0xe3, 0x68, 0xe2, 0x3f, 0xf0, 0xe0, 0xcf, 0x00, 0x15, 0x96, 0xda, 0x81, 0x7f, 0x04, 0x00,
+	0x00,
 }
 
 func (x Chunk_Encoding) String() string {
@@ -359,6 +440,72 @@ func (this *Series) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *SeriesExemplars) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*SeriesExemplars)
+	if !ok {
+		that2, ok := that.(SeriesExemplars)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
+			return false
+		}
+	}
+	if len(this.Exemplars) != len(that1.Exemplars) {
+		return false
+	}
+	for i := range this.Exemplars {
+		if !this.Exemplars[i].Equal(&that1.Exemplars[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *BlockExemplars) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*BlockExemplars)
+	if !ok {
+		that2, ok := that.(BlockExemplars)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Series) != len(that1.Series) {
+		return false
+	}
+	for i := range this.Series {
+		if !this.Series[i].Equal(&that1.Series[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *AggrChunk) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -447,6 +594,39 @@ func (this *Series) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *SeriesExemplars) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&storepb.SeriesExemplars{")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
+	if this.Exemplars != nil {
+		vs := make([]*mimirpb.Exemplar, len(this.Exemplars))
+		for i := range vs {
+			vs[i] = &this.Exemplars[i]
+		}
+		s = append(s, "Exemplars: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *BlockExemplars) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.BlockExemplars{")
+	if this.Series != nil {
+		vs := make([]*SeriesExemplars, len(this.Series))
+		for i := range vs {
+			vs[i] = &this.Series[i]
+		}
+		s = append(s, "Series: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *AggrChunk) GoString() string {
 	if this == nil {
 		return "nil"
@@ -570,6 +750,94 @@ func (m *Series) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *SeriesExemplars) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SeriesExemplars) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SeriesExemplars) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Exemplars) > 0 {
+		for iNdEx := len(m.Exemplars) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Exemplars[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTypes(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Labels) > 0 {
+		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Labels[iNdEx].Size()
+				i -= size
+				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintTypes(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BlockExemplars) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BlockExemplars) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BlockExemplars) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for iNdEx := len(m.Series) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Series[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTypes(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *AggrChunk) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -703,6 +971,42 @@ func (m *Series) Size() (n int) {
 	return n
 }
 
+func (m *SeriesExemplars) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		for _, e := range m.Labels {
+			l = e.Size()
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
+	if len(m.Exemplars) > 0 {
+		for _, e := range m.Exemplars {
+			l = e.Size()
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *BlockExemplars) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Series) > 0 {
+		for _, e := range m.Series {
+			l = e.Size()
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
+	return n
+}
+
 func (m *AggrChunk) Size() (n int) {
 	if m == nil {
 		return 0
@@ -775,6 +1079,37 @@ func (this *Series) String() string {
 	}, "")
 	return s
 }
+func (this *SeriesExemplars) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForExemplars := "[]Exemplar{"
+	for _, f := range this.Exemplars {
+		repeatedStringForExemplars += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForExemplars += "}"
+	s := strings.Join([]string{`&SeriesExemplars{`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
+		`Exemplars:` + repeatedStringForExemplars + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *BlockExemplars) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSeries := "[]SeriesExemplars{"
+	for _, f := range this.Series {
+		repeatedStringForSeries += strings.Replace(strings.Replace(f.String(), "SeriesExemplars", "SeriesExemplars", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSeries += "}"
+	s := strings.Join([]string{`&BlockExemplars{`,
+		`Series:` + repeatedStringForSeries + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *AggrChunk) String() string {
 	if this == nil {
 		return "nil"
@@ -1033,6 +1368,214 @@ func (m *Series) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *SeriesExemplars) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesExemplars: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesExemplars: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, github_com_grafana_mimir_pkg_mimirpb.LabelAdapter{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exemplars", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Exemplars = append(m.Exemplars, mimirpb.Exemplar{})
+			if err := m.Exemplars[len(m.Exemplars)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BlockExemplars) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BlockExemplars: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BlockExemplars: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Series = append(m.Series, SeriesExemplars{})
+			if err := m.Series[len(m.Series)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *AggrChunk) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/dskit/gate"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tenantQueryGates isolates the tenants sharing the store-gateway query gate: the queries of each tenant wait for
+// a slot of the tenant's own pool before waiting for the shared gate, so that a tenant running many concurrent
+// queries can't take all the slots of the shared gate.
+type tenantQueryGates struct {
+	shared gate.Gate
+
+	// maxConcurrent is the max number of queries of each tenant running or waiting for the shared gate. The
+	// tenants' pools are disabled if 0.
+	maxConcurrent int
+
+	// maxQueued is the max number of queries of each tenant waiting for a slot of the tenant's pool. The
+	// queries exceeding it are rejected. Unlimited if 0.
+	maxQueued int
+
+	mtx   sync.Mutex
+	pools map[string]*tenantQueryGate
+
+	queued   *prometheus.GaugeVec
+	inflight *prometheus.GaugeVec
+	rejected *prometheus.CounterVec
+}
+
+func newTenantQueryGates(shared gate.Gate, maxConcurrent, maxQueued int, reg prometheus.Registerer) *tenantQueryGates {
+	return &tenantQueryGates{
+		shared:        shared,
+		maxConcurrent: maxConcurrent,
+		maxQueued:     maxQueued,
+		pools:         map[string]*tenantQueryGate{},
+		queued: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_store_tenant_queries_queued",
+			Help: "Number of queries waiting for a slot of the tenant's query concurrency pool.",
+		}, []string{"user"}),
+		inflight: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_store_tenant_queries_in_flight",
+			Help: "Number of queries holding a slot of the tenant's query concurrency pool, either running or waiting for the query gate shared across all tenants.",
+		}, []string{"user"}),
+		rejected: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_tenant_queries_rejected_total",
+			Help: "Total number of queries rejected because the max number of queries waiting for a slot of the tenant's query concurrency pool was reached.",
+		}, []string{"user"}),
+	}
+}
+
+// forTenant returns the gate to be used by the queries of the tenant, which is the shared one if the tenants'
+// pools are disabled.
+func (g *tenantQueryGates) forTenant(userID string) gate.Gate {
+	if g.maxConcurrent <= 0 {
+		return g.shared
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if pool, ok := g.pools[userID]; ok {
+		return pool
+	}
+
+	pool := &tenantQueryGate{
+		shared:    g.shared,
+		slots:     make(chan struct{}, g.maxConcurrent),
+		maxQueued: g.maxQueued,
+		queued:    g.queued.WithLabelValues(userID),
+		inflight:  g.inflight.WithLabelValues(userID),
+		rejected:  g.rejected.WithLabelValues(userID),
+	}
+	g.pools[userID] = pool
+	return pool
+}
+
+// removeTenant removes the pool and the metrics of the tenant.
+func (g *tenantQueryGates) removeTenant(userID string) {
+	g.mtx.Lock()
+	delete(g.pools, userID)
+	g.mtx.Unlock()
+
+	g.queued.DeleteLabelValues(userID)
+	g.inflight.DeleteLabelValues(userID)
+	g.rejected.DeleteLabelValues(userID)
+}
+
+// tenantQueryGate is a gate.Gate admitting the queries of a tenant to the shared gate through the tenant's pool.
+type tenantQueryGate struct {
+	shared    gate.Gate
+	slots     chan struct{}
+	maxQueued int
+
+	mtx         sync.Mutex
+	queuedCount int
+	queued      prometheus.Gauge
+	inflight    prometheus.Gauge
+	rejected    prometheus.Counter
+}
+
+func (g *tenantQueryGate) Start(ctx context.Context) error {
+	if err := g.acquireSlot(ctx); err != nil {
+		return err
+	}
+
+	if err := g.shared.Start(ctx); err != nil {
+		g.releaseSlot()
+		return err
+	}
+	return nil
+}
+
+func (g *tenantQueryGate) Done() {
+	g.shared.Done()
+	g.releaseSlot()
+}
+
+func (g *tenantQueryGate) acquireSlot(ctx context.Context) error {
+	// Fast path: a slot is free.
+	select {
+	case g.slots <- struct{}{}:
+		g.inflight.Inc()
+		return nil
+	default:
+	}
+
+	g.mtx.Lock()
+	if g.maxQueued > 0 && g.queuedCount >= g.maxQueued {
+		g.mtx.Unlock()
+		g.rejected.Inc()
+		return status.Errorf(codes.ResourceExhausted, "the max number of queued queries of the tenant has been reached (limit: %d)", g.maxQueued)
+	}
+	g.queuedCount++
+	g.mtx.Unlock()
+	g.queued.Inc()
+
+	defer func() {
+		g.mtx.Lock()
+		g.queuedCount--
+		g.mtx.Unlock()
+		g.queued.Dec()
+	}()
+
+	select {
+	case g.slots <- struct{}{}:
+		g.inflight.Inc()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *tenantQueryGate) releaseSlot() {
+	g.inflight.Dec()
+	<-g.slots
+}
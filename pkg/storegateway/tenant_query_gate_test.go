@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/gate"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantQueryGates_Disabled(t *testing.T) {
+	shared := gate.NewBlocking(1)
+	gates := newTenantQueryGates(shared, 0, 0, nil)
+
+	assert.Same(t, shared, gates.forTenant("user-1"))
+}
+
+func TestTenantQueryGates_ShouldIsolateTheTenants(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	gates := newTenantQueryGates(gate.NewBlocking(3), 2, 1, reg)
+
+	user1 := gates.forTenant("user-1")
+	user2 := gates.forTenant("user-2")
+	assert.Same(t, user1, gates.forTenant("user-1"))
+
+	// The first tenant takes all the slots of its pool, leaving a slot of the shared gate to the second one.
+	require.NoError(t, user1.Start(ctx))
+	require.NoError(t, user1.Start(ctx))
+
+	// A query of the first tenant is queued.
+	queued := make(chan error)
+	go func() {
+		queued <- user1.Start(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(gates.queued.WithLabelValues("user-1")) == 1
+	}, time.Second, time.Millisecond)
+
+	// The queue of the first tenant is full.
+	err := user1.Start(ctx)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The second tenant gets the last slot of the shared gate.
+	require.NoError(t, user2.Start(ctx))
+
+	// The slot of the tenant's pool is released if the query is canceled while waiting for the shared gate.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, user2.Start(canceledCtx), context.Canceled)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_tenant_queries_in_flight Number of queries holding a slot of the tenant's query concurrency pool, either running or waiting for the query gate shared across all tenants.
+		# TYPE cortex_bucket_store_tenant_queries_in_flight gauge
+		cortex_bucket_store_tenant_queries_in_flight{user="user-1"} 2
+		cortex_bucket_store_tenant_queries_in_flight{user="user-2"} 1
+		# HELP cortex_bucket_store_tenant_queries_queued Number of queries waiting for a slot of the tenant's query concurrency pool.
+		# TYPE cortex_bucket_store_tenant_queries_queued gauge
+		cortex_bucket_store_tenant_queries_queued{user="user-1"} 1
+		cortex_bucket_store_tenant_queries_queued{user="user-2"} 0
+		# HELP cortex_bucket_store_tenant_queries_rejected_total Total number of queries rejected because the max number of queries waiting for a slot of the tenant's query concurrency pool was reached.
+		# TYPE cortex_bucket_store_tenant_queries_rejected_total counter
+		cortex_bucket_store_tenant_queries_rejected_total{user="user-1"} 1
+		cortex_bucket_store_tenant_queries_rejected_total{user="user-2"} 0
+	`)))
+
+	// The queued query is started once a query of the tenant is done.
+	user1.Done()
+	require.NoError(t, <-queued)
+	assert.Equal(t, float64(2), testutil.ToFloat64(gates.inflight.WithLabelValues("user-1")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(gates.queued.WithLabelValues("user-1")))
+
+	// The metrics of the tenant are removed along with its pool.
+	gates.removeTenant("user-1")
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_store_tenant_queries_rejected_total Total number of queries rejected because the max number of queries waiting for a slot of the tenant's query concurrency pool was reached.
+		# TYPE cortex_bucket_store_tenant_queries_rejected_total counter
+		cortex_bucket_store_tenant_queries_rejected_total{user="user-2"} 0
+	`), "cortex_bucket_store_tenant_queries_rejected_total"))
+	assert.NotSame(t, user1, gates.forTenant("user-1"))
+}
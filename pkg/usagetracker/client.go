@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package usagetracker
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/grpcclient"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/usagetracker/usagetrackerpb"
+)
+
+// ClientConfig configures the client used to track the series in the usage-tracker.
+type ClientConfig struct {
+	Address          string            `yaml:"address" category:"experimental"`
+	RemoteTimeout    time.Duration     `yaml:"remote_timeout" category:"experimental"`
+	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate with the usage-tracker."`
+}
+
+func (cfg *ClientConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Address, prefix+".address", "", "Address of the usage-tracker, in the format host:port. When set, the series of each write request are tracked in the usage-tracker, and the series exceeding the per-tenant active series limit are rejected.")
+	f.DurationVar(&cfg.RemoteTimeout, prefix+".remote-timeout", 2*time.Second, "Timeout for the requests to the usage-tracker.")
+
+	cfg.GRPCClientConfig.RegisterFlagsWithPrefix(prefix, f)
+}
+
+func (cfg *ClientConfig) Validate(logger log.Logger) error {
+	if cfg.Address == "" {
+		return nil
+	}
+	return cfg.GRPCClientConfig.Validate(logger)
+}
+
+// Client is the client of the usage-tracker.
+type Client interface {
+	// TrackSeries tracks the input series for the tenant in the context, and returns the series which
+	// have been rejected because they exceed the tenant active series limit.
+	TrackSeries(ctx context.Context, seriesHashes []uint64) (rejected []uint64, err error)
+
+	Close() error
+}
+
+type client struct {
+	cfg    ClientConfig
+	client usagetrackerpb.UsageTrackerClient
+	conn   *grpc.ClientConn
+}
+
+// NewClient makes a new usage-tracker Client.
+func NewClient(cfg ClientConfig, reg prometheus.Registerer) (Client, error) {
+	requestDuration := promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cortex_usage_tracker_client_request_duration_seconds",
+		Help:    "Time spent doing usage-tracker requests.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 6),
+	}, []string{"operation", "status_code"})
+
+	opts, err := cfg.GRPCClientConfig.DialOption(grpcclient.Instrument(requestDuration))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(cfg.Address, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial usage-tracker %s", cfg.Address)
+	}
+
+	return &client{
+		cfg:    cfg,
+		client: usagetrackerpb.NewUsageTrackerClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+func (c *client) TrackSeries(ctx context.Context, seriesHashes []uint64) ([]uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.RemoteTimeout)
+	defer cancel()
+
+	res, err := c.client.TrackSeries(ctx, &usagetrackerpb.TrackSeriesRequest{SeriesHashes: seriesHashes})
+	if err != nil {
+		return nil, err
+	}
+	return res.RejectedSeriesHashes, nil
+}
+
+func (c *client) Close() error {
+	return c.conn.Close()
+}
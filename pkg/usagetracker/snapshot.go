@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package usagetracker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/usagetracker/usagetrackerpb"
+)
+
+// snapshotFileExtension is the extension of the files storing the snapshot of a tenant.
+// Each file is a snappy-compressed usagetrackerpb.TenantSnapshot protobuf message.
+const snapshotFileExtension = ".snapshot"
+
+// writeSnapshots stores the snapshot of each tenant currently tracked into <dir>/<tenant>.snapshot,
+// and removes the snapshots of the tenants not tracked anymore.
+func (t *UsageTracker) writeSnapshots() error {
+	tracked := map[string]struct{}{}
+
+	for _, userID := range t.tenantIDs() {
+		series := t.getTenant(userID)
+		if series == nil {
+			continue
+		}
+
+		if err := writeTenantSnapshot(t.snapshotPath(userID), series); err != nil {
+			return errors.Wrapf(err, "write snapshot of tenant %s", userID)
+		}
+		tracked[userID] = struct{}{}
+	}
+
+	userIDs, err := t.listSnapshots()
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if _, ok := tracked[userID]; ok {
+			continue
+		}
+		if err := os.Remove(t.snapshotPath(userID)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "remove snapshot of tenant %s", userID)
+		}
+	}
+
+	return nil
+}
+
+// loadSnapshots restores the series of each tenant from the snapshots, skipping the series which
+// are not active anymore at the given time.
+func (t *UsageTracker) loadSnapshots(now time.Time) error {
+	userIDs, err := t.listSnapshots()
+	if err != nil {
+		return err
+	}
+
+	deadlineMinute := toMinutes(now.Add(-t.cfg.IdleTimeout))
+
+	for _, userID := range userIDs {
+		snapshot, err := readTenantSnapshot(t.snapshotPath(userID))
+		if err != nil {
+			// A corrupted snapshot shouldn't prevent the usage-tracker from starting: the series will be
+			// tracked again on the next write requests.
+			level.Warn(t.logger).Log("msg", "failed to read the usage-tracker snapshot of the tenant", "user", userID, "err", err)
+			continue
+		}
+
+		if len(snapshot.SeriesHashes) != len(snapshot.LastSeenMinutes) {
+			level.Warn(t.logger).Log("msg", "skipped inconsistent usage-tracker snapshot of the tenant", "user", userID)
+			continue
+		}
+
+		series := t.getOrCreateTenant(userID)
+		for i, hash := range snapshot.SeriesHashes {
+			if snapshot.LastSeenMinutes[i] < deadlineMinute {
+				continue
+			}
+			series.load(hash, snapshot.LastSeenMinutes[i])
+		}
+
+		t.activeSeries.WithLabelValues(userID).Set(float64(series.activeSeries()))
+		level.Info(t.logger).Log("msg", "loaded usage-tracker snapshot of the tenant", "user", userID, "active_series", series.activeSeries())
+	}
+
+	return nil
+}
+
+// listSnapshots returns the IDs of the tenants having a snapshot stored.
+func (t *UsageTracker) listSnapshots() ([]string, error) {
+	entries, err := os.ReadDir(t.cfg.SnapshotDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "list snapshots")
+	}
+
+	var userIDs []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), snapshotFileExtension) {
+			continue
+		}
+		userIDs = append(userIDs, strings.TrimSuffix(entry.Name(), snapshotFileExtension))
+	}
+	return userIDs, nil
+}
+
+func (t *UsageTracker) snapshotPath(userID string) string {
+	return filepath.Join(t.cfg.SnapshotDir, userID+snapshotFileExtension)
+}
+
+func writeTenantSnapshot(path string, series *tenantSeries) error {
+	snapshot := usagetrackerpb.TenantSnapshot{}
+	series.forEach(func(hash uint64, lastSeenMinute uint32) {
+		snapshot.SeriesHashes = append(snapshot.SeriesHashes, hash)
+		snapshot.LastSeenMinutes = append(snapshot.LastSeenMinutes, lastSeenMinute)
+	})
+
+	data, err := snapshot.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "marshal snapshot")
+	}
+
+	// Make any changes to the file appear atomic.
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, snappy.Encode(nil, data), 0o666); err != nil {
+		return errors.Wrap(err, "write snapshot")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "rename snapshot")
+}
+
+func readTenantSnapshot(path string) (*usagetrackerpb.TenantSnapshot, error) {
+	compressed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read snapshot")
+	}
+
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress snapshot")
+	}
+
+	snapshot := &usagetrackerpb.TenantSnapshot{}
+	if err := snapshot.Unmarshal(data); err != nil {
+		return nil, errors.Wrap(err, "unmarshal snapshot")
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package usagetracker
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// tenantSeriesShards is the number of shards the series of a tenant are split into, to reduce the lock contention.
+const tenantSeriesShards = 128
+
+// tenantSeries tracks the active series of a tenant. To keep the memory footprint low, a series is tracked
+// by the hash of its labels, along with the last time it has been tracked with a minute precision.
+// Two series whose labels have the same hash are tracked as a single series.
+type tenantSeries struct {
+	shards [tenantSeriesShards]tenantSeriesShard
+
+	// Number of series currently tracked, across all shards.
+	active atomic.Int64
+}
+
+type tenantSeriesShard struct {
+	mtx sync.Mutex
+
+	// Last time each series has been tracked, in minutes since the Unix epoch, keyed by the series hash.
+	series map[uint64]uint32
+}
+
+func newTenantSeries() *tenantSeries {
+	s := &tenantSeries{}
+	for i := range s.shards {
+		s.shards[i].series = map[uint64]uint32{}
+	}
+	return s
+}
+
+// track tracks the input series at the given time, and returns the series which have been rejected because
+// tracking them would exceed the limit. The series already tracked are never rejected. A limit <= 0 means
+// no limit.
+func (s *tenantSeries) track(hashes []uint64, now time.Time, limit int) (rejected []uint64) {
+	minute := toMinutes(now)
+
+	for _, hash := range hashes {
+		shard := &s.shards[hash%tenantSeriesShards]
+
+		shard.mtx.Lock()
+		if _, ok := shard.series[hash]; ok {
+			shard.series[hash] = minute
+			shard.mtx.Unlock()
+			continue
+		}
+
+		if active := s.active.Inc(); limit > 0 && active > int64(limit) {
+			s.active.Dec()
+			shard.mtx.Unlock()
+
+			rejected = append(rejected, hash)
+			continue
+		}
+
+		shard.series[hash] = minute
+		shard.mtx.Unlock()
+	}
+
+	return rejected
+}
+
+// purge removes the series which haven't been tracked since the given deadline.
+func (s *tenantSeries) purge(deadline time.Time) {
+	deadlineMinute := toMinutes(deadline)
+
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.mtx.Lock()
+		for hash, minute := range shard.series {
+			if minute < deadlineMinute {
+				delete(shard.series, hash)
+				s.active.Dec()
+			}
+		}
+		shard.mtx.Unlock()
+	}
+}
+
+// activeSeries returns the number of series currently tracked.
+func (s *tenantSeries) activeSeries() int {
+	return int(s.active.Load())
+}
+
+// forEach calls the input function for each series currently tracked, with the minute it has been tracked last.
+func (s *tenantSeries) forEach(fn func(hash uint64, lastSeenMinute uint32)) {
+	for i := range s.shards {
+		shard := &s.shards[i]
+
+		shard.mtx.Lock()
+		for hash, minute := range shard.series {
+			fn(hash, minute)
+		}
+		shard.mtx.Unlock()
+	}
+}
+
+// load adds the input series, regardless of the limit. It's used to restore the series from a snapshot.
+func (s *tenantSeries) load(hash uint64, lastSeenMinute uint32) {
+	shard := &s.shards[hash%tenantSeriesShards]
+
+	shard.mtx.Lock()
+	defer shard.mtx.Unlock()
+
+	if prev, ok := shard.series[hash]; ok {
+		if lastSeenMinute > prev {
+			shard.series[hash] = lastSeenMinute
+		}
+		return
+	}
+
+	shard.series[hash] = lastSeenMinute
+	s.active.Inc()
+}
+
+func toMinutes(t time.Time) uint32 {
+	return uint32(t.Unix() / 60)
+}
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package usagetracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantSeries_track(t *testing.T) {
+	now := time.Now()
+	s := newTenantSeries()
+
+	// Series within the limit are accepted.
+	assert.Empty(t, s.track([]uint64{1, 2, 3}, now, 4))
+	assert.Equal(t, 3, s.activeSeries())
+
+	// New series exceeding the limit are rejected, while the series already tracked are accepted.
+	assert.Equal(t, []uint64{5, 6}, s.track([]uint64{1, 4, 5, 6}, now, 4))
+	assert.Equal(t, 4, s.activeSeries())
+
+	// Series are never rejected if the limit is disabled.
+	assert.Empty(t, s.track([]uint64{5, 6}, now, 0))
+	assert.Equal(t, 6, s.activeSeries())
+}
+
+func TestTenantSeries_purge(t *testing.T) {
+	now := time.Now()
+	s := newTenantSeries()
+
+	assert.Empty(t, s.track([]uint64{1, 2}, now.Add(-time.Hour), 0))
+	assert.Empty(t, s.track([]uint64{2, 3}, now, 0))
+	assert.Equal(t, 3, s.activeSeries())
+
+	s.purge(now.Add(-time.Minute))
+	assert.Equal(t, 2, s.activeSeries())
+
+	tracked := map[uint64]uint32{}
+	s.forEach(func(hash uint64, lastSeenMinute uint32) {
+		tracked[hash] = lastSeenMinute
+	})
+	assert.Equal(t, map[uint64]uint32{2: toMinutes(now), 3: toMinutes(now)}, tracked)
+
+	// The purged series can be tracked again, within the limit.
+	assert.Equal(t, []uint64{4}, s.track([]uint64{1, 4}, now, 3))
+}
+
+func TestTenantSeries_load(t *testing.T) {
+	s := newTenantSeries()
+
+	s.load(1, 10)
+	s.load(1, 5)
+	s.load(2, 20)
+	assert.Equal(t, 2, s.activeSeries())
+
+	tracked := map[uint64]uint32{}
+	s.forEach(func(hash uint64, lastSeenMinute uint32) {
+		tracked[hash] = lastSeenMinute
+	})
+	assert.Equal(t, map[uint64]uint32{1: 10, 2: 20}, tracked)
+}
@@ -0,0 +1,256 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package usagetracker
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/mimir/pkg/usagetracker/usagetrackerpb"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// How frequently the series which are not active anymore are purged.
+	purgeInterval = time.Minute
+)
+
+var errInvalidIdleTimeout = errors.New("the usage-tracker idle timeout must be greater than 0")
+
+// Config configures the usage-tracker.
+type Config struct {
+	IdleTimeout      time.Duration `yaml:"idle_timeout" category:"experimental"`
+	SnapshotDir      string        `yaml:"snapshot_dir" category:"experimental"`
+	SnapshotInterval time.Duration `yaml:"snapshot_interval" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.IdleTimeout, "usage-tracker.idle-timeout", 20*time.Minute, "Time after which a series which hasn't been pushed is not considered active anymore, and doesn't count towards the tenant active series limit.")
+	f.StringVar(&cfg.SnapshotDir, "usage-tracker.snapshot-dir", "./usage-tracker/", "Directory where the usage-tracker periodically stores the snapshots of the tracked series, and loads them from on startup.")
+	f.DurationVar(&cfg.SnapshotInterval, "usage-tracker.snapshot-interval", time.Minute, "How frequently the usage-tracker stores the snapshots of the tracked series. 0 to disable the snapshots.")
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.IdleTimeout <= 0 {
+		return errInvalidIdleTimeout
+	}
+	return nil
+}
+
+// Limits is the interface of the limits required by the usage-tracker.
+type Limits interface {
+	MaxActiveSeriesPerUser(userID string) int
+}
+
+// UsageTracker tracks the active series of each tenant across the whole cluster, and rejects the series exceeding
+// the per-tenant active series limit. Distributors track the series of each write request in the usage-tracker,
+// instead of relying on the ingesters to enforce a per-ingester limit extrapolated from the global one.
+type UsageTracker struct {
+	services.Service
+
+	cfg    Config
+	limits Limits
+	logger log.Logger
+
+	mtx     sync.RWMutex
+	tenants map[string]*tenantSeries
+
+	activeSeries      *prometheus.GaugeVec
+	rejectedSeries    *prometheus.CounterVec
+	snapshotFailures  prometheus.Counter
+	snapshotsDuration prometheus.Histogram
+}
+
+// New makes a new UsageTracker.
+func New(cfg Config, limits Limits, logger log.Logger, reg prometheus.Registerer) *UsageTracker {
+	t := &UsageTracker{
+		cfg:     cfg,
+		limits:  limits,
+		logger:  logger,
+		tenants: map[string]*tenantSeries{},
+
+		activeSeries: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_usage_tracker_active_series",
+			Help: "Number of active series tracked for each tenant.",
+		}, []string{"user"}),
+		rejectedSeries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_usage_tracker_rejected_series_total",
+			Help: "Total number of series rejected because the tenant has reached its active series limit.",
+		}, []string{"user"}),
+		snapshotFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_usage_tracker_snapshot_failures_total",
+			Help: "Total number of failures while storing the snapshots of the tracked series.",
+		}),
+		snapshotsDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "cortex_usage_tracker_snapshots_duration_seconds",
+			Help:    "Time taken to store the snapshots of the tracked series of all tenants.",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+
+	t.Service = services.NewBasicService(t.starting, t.running, t.stopping)
+	return t
+}
+
+func (t *UsageTracker) starting(_ context.Context) error {
+	if err := os.MkdirAll(t.cfg.SnapshotDir, 0o750); err != nil {
+		return errors.Wrap(err, "create usage-tracker snapshot directory")
+	}
+
+	return errors.Wrap(t.loadSnapshots(time.Now()), "load usage-tracker snapshots")
+}
+
+func (t *UsageTracker) running(ctx context.Context) error {
+	purgeTicker := time.NewTicker(purgeInterval)
+	defer purgeTicker.Stop()
+
+	var snapshotTickerChan <-chan time.Time
+	if t.cfg.SnapshotInterval > 0 {
+		snapshotTicker := time.NewTicker(t.cfg.SnapshotInterval)
+		defer snapshotTicker.Stop()
+		snapshotTickerChan = snapshotTicker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-purgeTicker.C:
+			t.purge(time.Now())
+		case <-snapshotTickerChan:
+			t.storeSnapshots()
+		}
+	}
+}
+
+func (t *UsageTracker) stopping(_ error) error {
+	if t.cfg.SnapshotInterval > 0 {
+		t.storeSnapshots()
+	}
+	return nil
+}
+
+// TrackSeries implements usagetrackerpb.UsageTrackerServer.
+func (t *UsageTracker) TrackSeries(ctx context.Context, req *usagetrackerpb.TrackSeriesRequest) (*usagetrackerpb.TrackSeriesResponse, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected := t.getOrCreateTenant(userID).track(req.SeriesHashes, time.Now(), t.limits.MaxActiveSeriesPerUser(userID))
+	if len(rejected) > 0 {
+		t.rejectedSeries.WithLabelValues(userID).Add(float64(len(rejected)))
+	}
+
+	return &usagetrackerpb.TrackSeriesResponse{RejectedSeriesHashes: rejected}, nil
+}
+
+type usageResponse struct {
+	ActiveSeries      int `json:"active_series"`
+	ActiveSeriesLimit int `json:"active_series_limit"`
+}
+
+// UsageHandler returns the current usage of the tenant.
+func (t *UsageTracker) UsageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	res := usageResponse{ActiveSeriesLimit: t.limits.MaxActiveSeriesPerUser(userID)}
+	if series := t.getTenant(userID); series != nil {
+		res.ActiveSeries = series.activeSeries()
+	}
+
+	util.WriteJSONResponse(w, res)
+}
+
+func (t *UsageTracker) getTenant(userID string) *tenantSeries {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return t.tenants[userID]
+}
+
+func (t *UsageTracker) getOrCreateTenant(userID string) *tenantSeries {
+	if series := t.getTenant(userID); series != nil {
+		return series
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	// Check again, because it may have been created in the meanwhile.
+	series := t.tenants[userID]
+	if series == nil {
+		series = newTenantSeries()
+		t.tenants[userID] = series
+	}
+	return series
+}
+
+// tenantIDs returns the IDs of the tenants currently tracked.
+func (t *UsageTracker) tenantIDs() []string {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	userIDs := make([]string, 0, len(t.tenants))
+	for userID := range t.tenants {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+// purge removes the series which are not active anymore, and the tenants without active series.
+func (t *UsageTracker) purge(now time.Time) {
+	deadline := now.Add(-t.cfg.IdleTimeout)
+
+	for _, userID := range t.tenantIDs() {
+		series := t.getTenant(userID)
+		if series == nil {
+			continue
+		}
+
+		series.purge(deadline)
+		t.activeSeries.WithLabelValues(userID).Set(float64(series.activeSeries()))
+
+		if series.activeSeries() > 0 {
+			continue
+		}
+
+		// A series tracked while the tenant is being removed may be lost, but it will be tracked again
+		// on the next write request.
+		t.mtx.Lock()
+		if series.activeSeries() == 0 {
+			delete(t.tenants, userID)
+			t.activeSeries.DeleteLabelValues(userID)
+			t.rejectedSeries.DeleteLabelValues(userID)
+		}
+		t.mtx.Unlock()
+	}
+}
+
+func (t *UsageTracker) storeSnapshots() {
+	start := time.Now()
+
+	if err := t.writeSnapshots(); err != nil {
+		t.snapshotFailures.Inc()
+		level.Warn(t.logger).Log("msg", "failed to store the usage-tracker snapshots", "err", err)
+		return
+	}
+
+	t.snapshotsDuration.Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package usagetracker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/mimir/pkg/usagetracker/usagetrackerpb"
+)
+
+type mockLimits map[string]int
+
+func (l mockLimits) MaxActiveSeriesPerUser(userID string) int {
+	return l[userID]
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&Config{IdleTimeout: time.Minute}).Validate())
+	assert.Equal(t, errInvalidIdleTimeout, (&Config{}).Validate())
+}
+
+func TestUsageTracker_TrackSeries(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	tracker := New(Config{IdleTimeout: time.Minute, SnapshotDir: t.TempDir()}, mockLimits{"user-1": 2}, log.NewNopLogger(), reg)
+
+	// Series exceeding the tenant limit are rejected.
+	res, err := tracker.TrackSeries(user.InjectOrgID(context.Background(), "user-1"), &usagetrackerpb.TrackSeriesRequest{SeriesHashes: []uint64{1, 2, 3}})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3}, res.RejectedSeriesHashes)
+
+	// Tenants without a limit are not limited.
+	res, err = tracker.TrackSeries(user.InjectOrgID(context.Background(), "user-2"), &usagetrackerpb.TrackSeriesRequest{SeriesHashes: []uint64{1, 2, 3}})
+	require.NoError(t, err)
+	assert.Empty(t, res.RejectedSeriesHashes)
+
+	// Requests without a tenant are refused.
+	_, err = tracker.TrackSeries(context.Background(), &usagetrackerpb.TrackSeriesRequest{SeriesHashes: []uint64{1}})
+	require.Error(t, err)
+
+	tracker.purge(time.Now())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_usage_tracker_active_series Number of active series tracked for each tenant.
+		# TYPE cortex_usage_tracker_active_series gauge
+		cortex_usage_tracker_active_series{user="user-1"} 2
+		cortex_usage_tracker_active_series{user="user-2"} 3
+
+		# HELP cortex_usage_tracker_rejected_series_total Total number of series rejected because the tenant has reached its active series limit.
+		# TYPE cortex_usage_tracker_rejected_series_total counter
+		cortex_usage_tracker_rejected_series_total{user="user-1"} 1
+	`), "cortex_usage_tracker_active_series", "cortex_usage_tracker_rejected_series_total"))
+
+	// Tenants without active series are removed once their series are purged.
+	tracker.purge(time.Now().Add(time.Hour))
+	assert.Empty(t, tracker.tenantIDs())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(""), "cortex_usage_tracker_active_series", "cortex_usage_tracker_rejected_series_total"))
+}
+
+func TestUsageTracker_Snapshots(t *testing.T) {
+	cfg := Config{IdleTimeout: 10 * time.Minute, SnapshotDir: t.TempDir(), SnapshotInterval: time.Hour}
+	limits := mockLimits{}
+
+	// Track some series and store the snapshots on shutdown.
+	tracker := New(cfg, limits, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), tracker))
+
+	_, err := tracker.TrackSeries(user.InjectOrgID(context.Background(), "user-1"), &usagetrackerpb.TrackSeriesRequest{SeriesHashes: []uint64{1, 2, 3}})
+	require.NoError(t, err)
+	_, err = tracker.TrackSeries(user.InjectOrgID(context.Background(), "user-2"), &usagetrackerpb.TrackSeriesRequest{SeriesHashes: []uint64{4}})
+	require.NoError(t, err)
+
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), tracker))
+	assert.FileExists(t, filepath.Join(cfg.SnapshotDir, "user-1"+snapshotFileExtension))
+	assert.FileExists(t, filepath.Join(cfg.SnapshotDir, "user-2"+snapshotFileExtension))
+
+	// A corrupted snapshot doesn't prevent the usage-tracker from starting.
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.SnapshotDir, "user-3"+snapshotFileExtension), []byte("corrupted"), 0o666))
+
+	// The series are restored on startup.
+	tracker = New(cfg, limits, log.NewNopLogger(), nil)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), tracker))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), tracker))
+	})
+
+	assert.Equal(t, 3, tracker.getTenant("user-1").activeSeries())
+	assert.Equal(t, 1, tracker.getTenant("user-2").activeSeries())
+	assert.Nil(t, tracker.getTenant("user-3"))
+
+	// The snapshots of the tenants not tracked anymore are removed.
+	tracker.purge(time.Now().Add(time.Hour))
+	require.NoError(t, tracker.writeSnapshots())
+
+	userIDs, err := tracker.listSnapshots()
+	require.NoError(t, err)
+	assert.Empty(t, userIDs)
+}
+
+func TestUsageTracker_loadSnapshots_SkipsIdleSeries(t *testing.T) {
+	cfg := Config{IdleTimeout: 10 * time.Minute, SnapshotDir: t.TempDir()}
+	now := time.Now()
+
+	series := newTenantSeries()
+	series.load(1, toMinutes(now.Add(-time.Hour)))
+	series.load(2, toMinutes(now))
+	require.NoError(t, writeTenantSnapshot(filepath.Join(cfg.SnapshotDir, "user-1"+snapshotFileExtension), series))
+
+	tracker := New(cfg, mockLimits{}, log.NewNopLogger(), nil)
+	require.NoError(t, tracker.loadSnapshots(now))
+	assert.Equal(t, 1, tracker.getTenant("user-1").activeSeries())
+}
+
+func TestUsageTracker_UsageHandler(t *testing.T) {
+	tracker := New(Config{IdleTimeout: time.Minute, SnapshotDir: t.TempDir()}, mockLimits{"user-1": 10}, log.NewNopLogger(), nil)
+
+	_, err := tracker.TrackSeries(user.InjectOrgID(context.Background(), "user-1"), &usagetrackerpb.TrackSeriesRequest{SeriesHashes: []uint64{1, 2, 3}})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		userID           string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		"tenant with active series": {
+			userID:           "user-1",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"active_series":3,"active_series_limit":10}`,
+		},
+		"tenant without active series": {
+			userID:           "user-2",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: `{"active_series":0,"active_series_limit":0}`,
+		},
+		"no tenant": {
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/usage-tracker/usage", nil)
+			if testData.userID != "" {
+				req = req.WithContext(user.InjectOrgID(req.Context(), testData.userID))
+			}
+
+			rec := httptest.NewRecorder()
+			tracker.UsageHandler(rec, req)
+
+			assert.Equal(t, testData.expectedStatus, rec.Code)
+			if testData.expectedResponse != "" {
+				assert.JSONEq(t, testData.expectedResponse, rec.Body.String())
+			}
+		})
+	}
+}
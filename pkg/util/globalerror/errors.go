@@ -27,9 +27,21 @@ const (
 	MaxMetadataPerMetric          ID = "max-metadata-per-metric"
 	MaxSeriesPerUser              ID = "max-series-per-user"
 	MaxMetadataPerUser            ID = "max-metadata-per-user"
+	MaxActiveSeriesPerUser        ID = "max-active-series-per-user"
 	MaxChunksPerQuery             ID = "max-chunks-per-query"
 	MaxSeriesPerQuery             ID = "max-series-per-query"
+	MaxSeriesPerSelector          ID = "max-series-per-selector"
 	MaxChunkBytesPerQuery         ID = "max-chunks-bytes-per-query"
+	MaxExemplarsPerQuery          ID = "max-exemplars-per-query"
+
+	MaxEstimatedChunksPerQuery     ID = "max-estimated-chunks-per-query"
+	MaxEstimatedChunkBytesPerQuery ID = "max-estimated-chunks-bytes-per-query"
+
+	MaxLabelValuesResultsSizeBytes ID = "max-label-values-results-size-bytes"
+
+	MaxEstimatedMemoryConsumptionPerQuery ID = "max-estimated-memory-consumption-per-query"
+	MaxCPUTimePerQuery                    ID = "max-cpu-time-per-query"
+	MaxQueryExecutionTime                 ID = "max-query-execution-time"
 
 	DistributorMaxIngestionRate             ID = "distributor-max-ingestion-rate"
 	DistributorMaxInflightPushRequests      ID = "distributor-max-inflight-push-requests"
@@ -65,6 +77,8 @@ const (
 	BucketIndexTooOld           ID = "bucket-index-too-old"
 
 	DistributorMaxWriteMessageSize ID = "distributor-max-write-message-size"
+
+	QueryFrontendMaxConcurrentRequestsPerTenant ID = "query-frontend-max-concurrent-requests-per-tenant"
 )
 
 // Message returns the provided msg, appending the error id.
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limiter
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+var MaxCPUTimePerQueryHitMsgFormat = globalerror.MaxCPUTimePerQuery.MessageWithPerTenantLimitConfig(
+	"the query exceeded the maximum CPU time a single query can consume (limit: %s)",
+	validation.MaxCPUTimePerQueryFlag,
+)
+
+// CPUTimeTracker tracks the CPU time consumed by the goroutine evaluating a single query, and fails
+// when the query exceeds the max CPU time. The goroutine is locked to its OS thread while it's tracked,
+// so that the CPU time can be read from the thread's CPU clock. The CPU time is only tracked on Linux,
+// on other platforms it's always 0. It's safe for concurrent use.
+type CPUTimeTracker struct {
+	maxCPUTime time.Duration
+
+	// Optional: the stats the rejection of the query is recorded in.
+	stats *stats.Stats
+
+	mtx      sync.Mutex
+	clock    threadCPUClock
+	started  bool
+	stopped  bool
+	startCPU time.Duration
+	consumed time.Duration
+}
+
+// NewCPUTimeTracker makes a new CPUTimeTracker. 0 means no limit. If the input stats aren't nil,
+// the limit rejecting the query is recorded in them.
+func NewCPUTimeTracker(maxCPUTime time.Duration, stats *stats.Stats) *CPUTimeTracker {
+	return &CPUTimeTracker{maxCPUTime: maxCPUTime, stats: stats}
+}
+
+// Start locks the calling goroutine to its OS thread and starts tracking the CPU time it consumes.
+// Stop must be called by the same goroutine once the query has been evaluated.
+func (t *CPUTimeTracker) Start() {
+	runtime.LockOSThread()
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.clock = currentThreadCPUClock()
+	t.startCPU = t.clock.cpuTime()
+	t.started = true
+}
+
+// Stop stops tracking the CPU time and unlocks the calling goroutine from its OS thread.
+func (t *CPUTimeTracker) Stop() {
+	t.mtx.Lock()
+	t.consumed = t.cpuTime()
+	t.stopped = true
+	t.mtx.Unlock()
+
+	runtime.UnlockOSThread()
+}
+
+// CPUTime returns the CPU time consumed by the query so far.
+func (t *CPUTimeTracker) CPUTime() time.Duration {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.cpuTime()
+}
+
+// cpuTime must be called with the lock held.
+func (t *CPUTimeTracker) cpuTime() time.Duration {
+	if t.stopped {
+		return t.consumed
+	}
+	if !t.started {
+		return 0
+	}
+
+	// The thread's CPU clock can't go backwards, unless it failed to be read.
+	if consumed := t.clock.cpuTime() - t.startCPU; consumed > 0 {
+		return consumed
+	}
+	return 0
+}
+
+// CheckCPUTime returns a validation.LimitError if the query exceeded the max CPU time.
+func (t *CPUTimeTracker) CheckCPUTime() error {
+	if t.maxCPUTime <= 0 {
+		return nil
+	}
+
+	if consumed := t.CPUTime(); consumed > t.maxCPUTime {
+		recordRejection(t.stats, globalerror.MaxCPUTimePerQuery, consumed.Seconds(), t.maxCPUTime.Seconds())
+		return validation.LimitError(fmt.Sprintf(MaxCPUTimePerQueryHitMsgFormat, t.maxCPUTime))
+	}
+	return nil
+}
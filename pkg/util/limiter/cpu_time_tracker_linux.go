@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+//go:build linux
+
+package limiter
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// CPUTimeTrackingSupported is whether the CPU time is tracked on this platform.
+const CPUTimeTrackingSupported = true
+
+// threadCPUClock reads the CPU time consumed by an OS thread.
+type threadCPUClock struct {
+	id int32
+}
+
+// currentThreadCPUClock returns the CPU clock of the calling thread, like pthread_getcpuclockid() does.
+// See MAKE_THREAD_CPUCLOCK() in the Linux kernel.
+func currentThreadCPUClock() threadCPUClock {
+	return threadCPUClock{id: int32(^unix.Gettid()<<3 | 6)}
+}
+
+func (c threadCPUClock) cpuTime() time.Duration {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(c.id, &ts); err != nil {
+		return 0
+	}
+	return time.Duration(ts.Nano())
+}
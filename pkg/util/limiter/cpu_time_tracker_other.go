@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+//go:build !linux
+
+package limiter
+
+import "time"
+
+// CPUTimeTrackingSupported is whether the CPU time is tracked on this platform.
+const CPUTimeTrackingSupported = false
+
+// threadCPUClock is a no-op on the platforms not supporting the CPU time tracking.
+type threadCPUClock struct{}
+
+func currentThreadCPUClock() threadCPUClock {
+	return threadCPUClock{}
+}
+
+func (c threadCPUClock) cpuTime() time.Duration {
+	return 0
+}
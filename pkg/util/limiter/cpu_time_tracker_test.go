@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limiter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestCPUTimeTracker(t *testing.T) {
+	if !CPUTimeTrackingSupported {
+		t.Skip("the CPU time isn't tracked on this platform")
+	}
+
+	queryStats := &stats.Stats{}
+	tracker := NewCPUTimeTracker(time.Millisecond, queryStats)
+	assert.Equal(t, time.Duration(0), tracker.CPUTime())
+	require.NoError(t, tracker.CheckCPUTime())
+
+	tracker.Start()
+	for tracker.CPUTime() <= time.Millisecond {
+		// Burn some CPU time.
+	}
+
+	err := tracker.CheckCPUTime()
+	require.Error(t, err)
+	assert.Equal(t, validation.LimitError(fmt.Sprintf(MaxCPUTimePerQueryHitMsgFormat, time.Millisecond)), err)
+	require.NotNil(t, queryStats.LoadRejection())
+	assert.Equal(t, "max-cpu-time-per-query", queryStats.LoadRejection().Limit)
+	assert.Greater(t, queryStats.LoadRejection().MeasuredValue, 0.001)
+	assert.Equal(t, 0.001, queryStats.LoadRejection().ConfiguredLimit)
+
+	// Once stopped, the CPU time consumed by the thread doesn't count towards the query.
+	tracker.Stop()
+	consumed := tracker.CPUTime()
+	for start := time.Now(); time.Since(start) < 10*time.Millisecond; {
+	}
+	assert.Equal(t, consumed, tracker.CPUTime())
+}
+
+func TestCPUTimeTracker_ShouldNotFailWithoutLimit(t *testing.T) {
+	tracker := NewCPUTimeTracker(0, nil)
+
+	tracker.Start()
+	for start := time.Now(); time.Since(start) < 10*time.Millisecond; {
+	}
+	tracker.Stop()
+
+	require.NoError(t, tracker.CheckCPUTime())
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+type memoryConsumptionTrackerCtxKey struct{}
+
+var (
+	memoryConsumptionTrackerKey = &memoryConsumptionTrackerCtxKey{}
+
+	MaxEstimatedMemoryPerQueryHitMsgFormat = globalerror.MaxEstimatedMemoryConsumptionPerQuery.MessageWithPerTenantLimitConfig(
+		"the query exceeded the maximum estimated memory a single query can consume (limit: %d bytes)",
+		validation.MaxEstimatedMemoryPerQueryFlag,
+	)
+)
+
+// MemoryConsumptionTracker tracks the estimated memory consumed by a single query, and fails
+// when the query exceeds the max estimated memory consumption. It's safe for concurrent use.
+type MemoryConsumptionTracker struct {
+	maxBytes uint64
+
+	// Optional: the stats the rejection of the query is recorded in.
+	stats *stats.Stats
+
+	mtx          sync.Mutex
+	currentBytes uint64
+	peakBytes    uint64
+}
+
+// NewMemoryConsumptionTracker makes a new MemoryConsumptionTracker. 0 means no limit. If the input
+// stats aren't nil, the limit rejecting the query is recorded in them.
+func NewMemoryConsumptionTracker(maxBytes uint64, stats *stats.Stats) *MemoryConsumptionTracker {
+	return &MemoryConsumptionTracker{maxBytes: maxBytes, stats: stats}
+}
+
+func AddMemoryConsumptionTrackerToContext(ctx context.Context, tracker *MemoryConsumptionTracker) context.Context {
+	return context.WithValue(ctx, memoryConsumptionTrackerKey, tracker)
+}
+
+// MemoryConsumptionTrackerFromContext returns the MemoryConsumptionTracker from the context, if any.
+func MemoryConsumptionTrackerFromContext(ctx context.Context) (*MemoryConsumptionTracker, bool) {
+	tracker, ok := ctx.Value(memoryConsumptionTrackerKey).(*MemoryConsumptionTracker)
+	return tracker, ok
+}
+
+// MemoryConsumptionTrackerFromContextWithFallback returns the MemoryConsumptionTracker from the context.
+// If there's no MemoryConsumptionTracker in the context, it returns a new tracker with no limit.
+func MemoryConsumptionTrackerFromContextWithFallback(ctx context.Context) *MemoryConsumptionTracker {
+	tracker, ok := MemoryConsumptionTrackerFromContext(ctx)
+	if !ok {
+		tracker = NewMemoryConsumptionTracker(0, nil)
+	}
+	return tracker
+}
+
+// IncreaseMemoryConsumption tracks the input bytes as consumed by the query. It returns a validation.LimitError,
+// without tracking the bytes, if the query would exceed the max estimated memory consumption.
+func (t *MemoryConsumptionTracker) IncreaseMemoryConsumption(bytes uint64) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.maxBytes > 0 && t.currentBytes+bytes > t.maxBytes {
+		recordRejection(t.stats, globalerror.MaxEstimatedMemoryConsumptionPerQuery, float64(t.currentBytes+bytes), float64(t.maxBytes))
+		return validation.LimitError(fmt.Sprintf(MaxEstimatedMemoryPerQueryHitMsgFormat, t.maxBytes))
+	}
+
+	t.currentBytes += bytes
+	if t.currentBytes > t.peakBytes {
+		t.peakBytes = t.currentBytes
+	}
+	return nil
+}
+
+// DecreaseMemoryConsumption tracks the input bytes as released by the query.
+func (t *MemoryConsumptionTracker) DecreaseMemoryConsumption(bytes uint64) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if bytes > t.currentBytes {
+		// Should never happen, but we don't want the tracking to wrap around.
+		t.currentBytes = 0
+		return
+	}
+	t.currentBytes -= bytes
+}
+
+// CurrentEstimatedMemoryConsumptionBytes returns the estimated memory currently consumed by the query.
+func (t *MemoryConsumptionTracker) CurrentEstimatedMemoryConsumptionBytes() uint64 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.currentBytes
+}
+
+// PeakEstimatedMemoryConsumptionBytes returns the peak estimated memory consumed by the query.
+func (t *MemoryConsumptionTracker) PeakEstimatedMemoryConsumptionBytes() uint64 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.peakBytes
+}
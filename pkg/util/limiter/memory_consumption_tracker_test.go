@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestMemoryConsumptionTracker_ShouldTrackCurrentAndPeakConsumption(t *testing.T) {
+	tracker := NewMemoryConsumptionTracker(0, nil)
+
+	require.NoError(t, tracker.IncreaseMemoryConsumption(100))
+	require.NoError(t, tracker.IncreaseMemoryConsumption(50))
+	assert.Equal(t, uint64(150), tracker.CurrentEstimatedMemoryConsumptionBytes())
+	assert.Equal(t, uint64(150), tracker.PeakEstimatedMemoryConsumptionBytes())
+
+	tracker.DecreaseMemoryConsumption(120)
+	assert.Equal(t, uint64(30), tracker.CurrentEstimatedMemoryConsumptionBytes())
+	assert.Equal(t, uint64(150), tracker.PeakEstimatedMemoryConsumptionBytes())
+
+	require.NoError(t, tracker.IncreaseMemoryConsumption(20))
+	assert.Equal(t, uint64(50), tracker.CurrentEstimatedMemoryConsumptionBytes())
+	assert.Equal(t, uint64(150), tracker.PeakEstimatedMemoryConsumptionBytes())
+
+	// Releasing more than what's tracked shouldn't wrap around.
+	tracker.DecreaseMemoryConsumption(1000)
+	assert.Equal(t, uint64(0), tracker.CurrentEstimatedMemoryConsumptionBytes())
+}
+
+func TestMemoryConsumptionTracker_ShouldReturnErrorOnLimitExceeded(t *testing.T) {
+	queryStats := &stats.Stats{}
+	tracker := NewMemoryConsumptionTracker(100, queryStats)
+
+	require.NoError(t, tracker.IncreaseMemoryConsumption(60))
+	require.NoError(t, tracker.IncreaseMemoryConsumption(40))
+
+	err := tracker.IncreaseMemoryConsumption(1)
+	require.Error(t, err)
+	assert.Equal(t, validation.LimitError(fmt.Sprintf(MaxEstimatedMemoryPerQueryHitMsgFormat, 100)), err)
+	assert.Equal(t, uint64(100), tracker.CurrentEstimatedMemoryConsumptionBytes())
+	assert.Equal(t, &stats.Rejection{Limit: "max-estimated-memory-consumption-per-query", Component: "querier", MeasuredValue: 101, ConfiguredLimit: 100}, queryStats.LoadRejection())
+
+	// Once some memory has been released, the query can consume it again.
+	tracker.DecreaseMemoryConsumption(10)
+	require.NoError(t, tracker.IncreaseMemoryConsumption(10))
+}
+
+func TestMemoryConsumptionTrackerFromContextWithFallback(t *testing.T) {
+	// Without a tracker in the context, a tracker with no limit is returned.
+	tracker := MemoryConsumptionTrackerFromContextWithFallback(context.Background())
+	require.NoError(t, tracker.IncreaseMemoryConsumption(1<<40))
+
+	expected := NewMemoryConsumptionTracker(10, nil)
+	ctx := AddMemoryConsumptionTrackerToContext(context.Background(), expected)
+	assert.Same(t, expected, MemoryConsumptionTrackerFromContextWithFallback(ctx))
+}
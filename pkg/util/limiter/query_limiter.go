@@ -13,6 +13,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util/globalerror"
 	"github.com/grafana/mimir/pkg/util/validation"
 )
@@ -33,6 +34,14 @@ var (
 		"the query exceeded the maximum number of chunks (limit: %d chunks)",
 		validation.MaxChunksPerQueryFlag,
 	)
+	MaxEstimatedChunksPerQueryLimitMsgFormat = globalerror.MaxEstimatedChunksPerQuery.MessageWithPerTenantLimitConfig(
+		"the query is estimated to fetch more than the maximum number of chunks (limit: %d chunks)",
+		validation.MaxEstimatedChunksPerQueryFlag,
+	)
+	MaxEstimatedChunkBytesHitMsgFormat = globalerror.MaxEstimatedChunkBytesPerQuery.MessageWithPerTenantLimitConfig(
+		"the query is estimated to fetch more than the aggregated chunks size limit (limit: %d bytes)",
+		validation.MaxEstimatedChunkBytesPerQueryFlag,
+	)
 )
 
 type QueryLimiter struct {
@@ -45,11 +54,23 @@ type QueryLimiter struct {
 	maxSeriesPerQuery     int
 	maxChunkBytesPerQuery int
 	maxChunksPerQuery     int
+
+	// The estimates of the chunks a query is going to fetch, reported by the ingesters and store-gateways
+	// from their index before sending the chunks.
+	estimatedChunkCount      atomic.Int64
+	estimatedChunkBytesCount atomic.Int64
+
+	maxEstimatedChunksPerQuery     int
+	maxEstimatedChunkBytesPerQuery int
+
+	// Optional: the stats the rejection of the query is recorded in.
+	stats *stats.Stats
 }
 
 // NewQueryLimiter makes a new per-query limiter. Each query limiter
-// is configured using the `maxSeriesPerQuery` limit.
-func NewQueryLimiter(maxSeriesPerQuery, maxChunkBytesPerQuery int, maxChunksPerQuery int) *QueryLimiter {
+// is configured using the `maxSeriesPerQuery` limit. If the input stats
+// aren't nil, the limit rejecting the query is recorded in them.
+func NewQueryLimiter(maxSeriesPerQuery, maxChunkBytesPerQuery int, maxChunksPerQuery int, maxEstimatedChunksPerQuery, maxEstimatedChunkBytesPerQuery int, stats *stats.Stats) *QueryLimiter {
 	return &QueryLimiter{
 		uniqueSeriesMx: sync.Mutex{},
 		uniqueSeries:   map[uint64]struct{}{},
@@ -57,6 +78,11 @@ func NewQueryLimiter(maxSeriesPerQuery, maxChunkBytesPerQuery int, maxChunksPerQ
 		maxSeriesPerQuery:     maxSeriesPerQuery,
 		maxChunkBytesPerQuery: maxChunkBytesPerQuery,
 		maxChunksPerQuery:     maxChunksPerQuery,
+
+		maxEstimatedChunksPerQuery:     maxEstimatedChunksPerQuery,
+		maxEstimatedChunkBytesPerQuery: maxEstimatedChunkBytesPerQuery,
+
+		stats: stats,
 	}
 }
 
@@ -70,7 +96,7 @@ func QueryLimiterFromContextWithFallback(ctx context.Context) *QueryLimiter {
 	ql, ok := ctx.Value(ctxKey).(*QueryLimiter)
 	if !ok {
 		// If there's no limiter return a new unlimited limiter as a fallback
-		ql = NewQueryLimiter(0, 0, 0)
+		ql = NewQueryLimiter(0, 0, 0, 0, 0, nil)
 	}
 	return ql
 }
@@ -88,6 +114,7 @@ func (ql *QueryLimiter) AddSeries(seriesLabels []mimirpb.LabelAdapter) error {
 
 	ql.uniqueSeries[fingerprint] = struct{}{}
 	if len(ql.uniqueSeries) > ql.maxSeriesPerQuery {
+		recordRejection(ql.stats, globalerror.MaxSeriesPerQuery, float64(len(ql.uniqueSeries)), float64(ql.maxSeriesPerQuery))
 		// Format error with max limit
 		return fmt.Errorf(MaxSeriesHitMsgFormat, ql.maxSeriesPerQuery)
 	}
@@ -106,7 +133,8 @@ func (ql *QueryLimiter) AddChunkBytes(chunkSizeInBytes int) error {
 	if ql.maxChunkBytesPerQuery == 0 {
 		return nil
 	}
-	if ql.chunkBytesCount.Add(int64(chunkSizeInBytes)) > int64(ql.maxChunkBytesPerQuery) {
+	if total := ql.chunkBytesCount.Add(int64(chunkSizeInBytes)); total > int64(ql.maxChunkBytesPerQuery) {
+		recordRejection(ql.stats, globalerror.MaxChunkBytesPerQuery, float64(total), float64(ql.maxChunkBytesPerQuery))
 		return fmt.Errorf(MaxChunkBytesHitMsgFormat, ql.maxChunkBytesPerQuery)
 	}
 	return nil
@@ -117,8 +145,34 @@ func (ql *QueryLimiter) AddChunks(count int) error {
 		return nil
 	}
 
-	if ql.chunkCount.Add(int64(count)) > int64(ql.maxChunksPerQuery) {
+	if total := ql.chunkCount.Add(int64(count)); total > int64(ql.maxChunksPerQuery) {
+		recordRejection(ql.stats, globalerror.MaxChunksPerQuery, float64(total), float64(ql.maxChunksPerQuery))
 		return fmt.Errorf(MaxChunksPerQueryLimitMsgFormat, ql.maxChunksPerQuery)
 	}
 	return nil
 }
+
+// EstimatedChunksLimited returns whether the query is limited on the estimates of the chunks it's going to fetch.
+func (ql *QueryLimiter) EstimatedChunksLimited() bool {
+	return ql.maxEstimatedChunksPerQuery > 0 || ql.maxEstimatedChunkBytesPerQuery > 0
+}
+
+// AddEstimatedChunks adds the estimated number of chunks and their size in bytes the query is going to fetch,
+// and returns an error if any of the limits is reached. The estimates are added before the chunks are fetched,
+// so that the query is rejected before transferring them.
+func (ql *QueryLimiter) AddEstimatedChunks(count, sizeInBytes int) error {
+	if ql.maxEstimatedChunksPerQuery > 0 {
+		if total := ql.estimatedChunkCount.Add(int64(count)); total > int64(ql.maxEstimatedChunksPerQuery) {
+			recordRejection(ql.stats, globalerror.MaxEstimatedChunksPerQuery, float64(total), float64(ql.maxEstimatedChunksPerQuery))
+			return fmt.Errorf(MaxEstimatedChunksPerQueryLimitMsgFormat, ql.maxEstimatedChunksPerQuery)
+		}
+	}
+
+	if ql.maxEstimatedChunkBytesPerQuery > 0 {
+		if total := ql.estimatedChunkBytesCount.Add(int64(sizeInBytes)); total > int64(ql.maxEstimatedChunkBytesPerQuery) {
+			recordRejection(ql.stats, globalerror.MaxEstimatedChunkBytesPerQuery, float64(total), float64(ql.maxEstimatedChunkBytesPerQuery))
+			return fmt.Errorf(MaxEstimatedChunkBytesHitMsgFormat, ql.maxEstimatedChunkBytesPerQuery)
+		}
+	}
+	return nil
+}
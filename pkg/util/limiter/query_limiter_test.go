@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
 )
 
 func TestQueryLimiter_AddSeries_ShouldReturnNoErrorOnLimitNotExceeded(t *testing.T) {
@@ -30,7 +31,7 @@ func TestQueryLimiter_AddSeries_ShouldReturnNoErrorOnLimitNotExceeded(t *testing
 			labels.MetricName: metricName + "_2",
 			"series2":         "1",
 		})
-		limiter = NewQueryLimiter(100, 0, 0)
+		limiter = NewQueryLimiter(100, 0, 0, 0, 0, nil)
 	)
 	err := limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series1))
 	assert.NoError(t, err)
@@ -58,21 +59,72 @@ func TestQueryLimiter_AddSeriers_ShouldReturnErrorOnLimitExceeded(t *testing.T)
 			labels.MetricName: metricName + "_2",
 			"series2":         "1",
 		})
-		limiter = NewQueryLimiter(1, 0, 0)
+		queryStats = &stats.Stats{}
+		limiter    = NewQueryLimiter(1, 0, 0, 0, 0, queryStats)
 	)
 	err := limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series1))
 	require.NoError(t, err)
+	assert.Nil(t, queryStats.LoadRejection())
 	err = limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(series2))
 	require.Error(t, err)
+	assert.Equal(t, &stats.Rejection{Limit: "max-series-per-query", Component: "querier", MeasuredValue: 2, ConfiguredLimit: 1}, queryStats.LoadRejection())
 }
 
 func TestQueryLimiter_AddChunkBytes(t *testing.T) {
-	var limiter = NewQueryLimiter(0, 100, 0)
+	var (
+		queryStats = &stats.Stats{}
+		limiter    = NewQueryLimiter(0, 100, 0, 0, 0, queryStats)
+	)
 
 	err := limiter.AddChunkBytes(100)
 	require.NoError(t, err)
 	err = limiter.AddChunkBytes(1)
 	require.Error(t, err)
+	assert.Equal(t, &stats.Rejection{Limit: "max-chunks-bytes-per-query", Component: "querier", MeasuredValue: 101, ConfiguredLimit: 100}, queryStats.LoadRejection())
+}
+
+func TestQueryLimiter_AddChunks(t *testing.T) {
+	var (
+		queryStats = &stats.Stats{}
+		limiter    = NewQueryLimiter(0, 0, 10, 0, 0, queryStats)
+	)
+
+	require.NoError(t, limiter.AddChunks(10))
+	require.Error(t, limiter.AddChunks(2))
+	assert.Equal(t, &stats.Rejection{Limit: "max-chunks-per-query", Component: "querier", MeasuredValue: 12, ConfiguredLimit: 10}, queryStats.LoadRejection())
+}
+
+func TestQueryLimiter_AddEstimatedChunks(t *testing.T) {
+	t.Run("chunks limit", func(t *testing.T) {
+		var (
+			queryStats = &stats.Stats{}
+			limiter    = NewQueryLimiter(0, 0, 0, 10, 0, queryStats)
+		)
+
+		require.True(t, limiter.EstimatedChunksLimited())
+		require.NoError(t, limiter.AddEstimatedChunks(10, 1000))
+		require.Error(t, limiter.AddEstimatedChunks(2, 0))
+		assert.Equal(t, &stats.Rejection{Limit: "max-estimated-chunks-per-query", Component: "querier", MeasuredValue: 12, ConfiguredLimit: 10}, queryStats.LoadRejection())
+	})
+
+	t.Run("chunk bytes limit", func(t *testing.T) {
+		var (
+			queryStats = &stats.Stats{}
+			limiter    = NewQueryLimiter(0, 0, 0, 0, 100, queryStats)
+		)
+
+		require.True(t, limiter.EstimatedChunksLimited())
+		require.NoError(t, limiter.AddEstimatedChunks(10, 100))
+		require.Error(t, limiter.AddEstimatedChunks(0, 1))
+		assert.Equal(t, &stats.Rejection{Limit: "max-estimated-chunks-bytes-per-query", Component: "querier", MeasuredValue: 101, ConfiguredLimit: 100}, queryStats.LoadRejection())
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		limiter := NewQueryLimiter(0, 0, 0, 0, 0, nil)
+
+		require.False(t, limiter.EstimatedChunksLimited())
+		require.NoError(t, limiter.AddEstimatedChunks(1e6, 1e9))
+	})
 }
 
 func BenchmarkQueryLimiter_AddSeries(b *testing.B) {
@@ -89,7 +141,7 @@ func BenchmarkQueryLimiter_AddSeries(b *testing.B) {
 	}
 	b.ResetTimer()
 
-	limiter := NewQueryLimiter(b.N+1, 0, 0)
+	limiter := NewQueryLimiter(b.N+1, 0, 0, 0, 0, nil)
 	for _, s := range series {
 		err := limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(s))
 		assert.NoError(b, err)
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limiter
+
+import (
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+)
+
+// rejectionComponent is the component enforcing the limits of this package.
+const rejectionComponent = "querier"
+
+// recordRejection records the limit which rejected the query in the input query stats, if any, so that
+// the rejection is propagated to the query-frontend along with the other query stats.
+func recordRejection(s *stats.Stats, limit globalerror.ID, measured, configured float64) {
+	s.RecordRejection(&stats.Rejection{
+		Limit:           string(limit),
+		Component:       rejectionComponent,
+		MeasuredValue:   measured,
+		ConfiguredLimit: configured,
+	})
+}
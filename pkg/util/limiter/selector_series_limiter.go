@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/globalerror"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+type selectorSeriesLimiterCtxKey struct{}
+
+var (
+	selectorSeriesLimiterKey = &selectorSeriesLimiterCtxKey{}
+
+	MaxSeriesPerSelectorHitMsgFormat = globalerror.MaxSeriesPerSelector.MessageWithPerTenantLimitConfig(
+		"the query selector %s exceeded the maximum number of series (limit: %d series)",
+		validation.MaxSeriesPerSelectorFlag,
+	)
+)
+
+// SelectorSeriesLimiter limits the number of unique series each selector of a single query can fetch.
+// The series fetched for the same selector from the ingesters and the store-gateways are counted together.
+// It's safe for concurrent use.
+type SelectorSeriesLimiter struct {
+	maxSeriesPerSelector int
+
+	// Optional: the stats the rejection of the query is recorded in.
+	stats *stats.Stats
+
+	mtx sync.Mutex
+	// The unique series fetched by each selector, keyed by the selector.
+	series map[string]map[uint64]struct{}
+	// The selectors the series of other selectors are counted for, keyed by the selector whose series are
+	// counted for another one.
+	aliases map[string]string
+}
+
+// NewSelectorSeriesLimiter makes a new SelectorSeriesLimiter. 0 means no limit. If the input stats
+// aren't nil, the limit rejecting the query is recorded in them.
+func NewSelectorSeriesLimiter(maxSeriesPerSelector int, stats *stats.Stats) *SelectorSeriesLimiter {
+	return &SelectorSeriesLimiter{
+		maxSeriesPerSelector: maxSeriesPerSelector,
+		stats:                stats,
+		series:               map[string]map[uint64]struct{}{},
+		aliases:              map[string]string{},
+	}
+}
+
+func AddSelectorSeriesLimiterToContext(ctx context.Context, limiter *SelectorSeriesLimiter) context.Context {
+	return context.WithValue(ctx, selectorSeriesLimiterKey, limiter)
+}
+
+// SelectorSeriesLimiterFromContextWithFallback returns the SelectorSeriesLimiter from the context.
+// If there's no SelectorSeriesLimiter in the context, it returns a new limiter with no limit.
+func SelectorSeriesLimiterFromContextWithFallback(ctx context.Context) *SelectorSeriesLimiter {
+	l, ok := ctx.Value(selectorSeriesLimiterKey).(*SelectorSeriesLimiter)
+	if !ok {
+		l = NewSelectorSeriesLimiter(0, nil)
+	}
+	return l
+}
+
+// Selector returns the selector of the input matchers, as used to count the series of the selector.
+func Selector(matchers []*labels.Matcher) string {
+	return util.LabelMatchersToString(matchers)
+}
+
+// AddSeries adds the input series, fetched by the input selector, and returns an error naming the selector
+// if the limit is reached.
+func (l *SelectorSeriesLimiter) AddSeries(selector string, seriesLabels []mimirpb.LabelAdapter) error {
+	if l.maxSeriesPerSelector == 0 {
+		return nil
+	}
+	fingerprint := mimirpb.FromLabelAdaptersToLabels(seriesLabels).Hash()
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if alias, ok := l.aliases[selector]; ok {
+		selector = alias
+	}
+	series, ok := l.series[selector]
+	if !ok {
+		series = map[uint64]struct{}{}
+		l.series[selector] = series
+	}
+	series[fingerprint] = struct{}{}
+	if len(series) > l.maxSeriesPerSelector {
+		recordRejection(l.stats, globalerror.MaxSeriesPerSelector, float64(len(series)), float64(l.maxSeriesPerSelector))
+		return fmt.Errorf(MaxSeriesPerSelectorHitMsgFormat, selector, l.maxSeriesPerSelector)
+	}
+	return nil
+}
+
+// AddAlias counts the series fetched by the input selector for the alias one, like the series fetched by
+// each part of a selector split into multiple fetches, which are counted for the original selector.
+func (l *SelectorSeriesLimiter) AddAlias(selector, alias string) {
+	if l.maxSeriesPerSelector == 0 {
+		return
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.aliases[selector] = alias
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
+)
+
+func TestSelectorSeriesLimiter_ShouldReturnErrorNamingTheSelectorOnLimitExceeded(t *testing.T) {
+	var (
+		queryStats = &stats.Stats{}
+		limiter    = NewSelectorSeriesLimiter(2, queryStats)
+		selector   = Selector([]*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")})
+		other      = Selector([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up")})
+		series1    = mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_1"))
+		series2    = mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_2"))
+		series3    = mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_3"))
+	)
+
+	require.NoError(t, limiter.AddSeries(selector, series1))
+	require.NoError(t, limiter.AddSeries(selector, series2))
+
+	// The same series fetched again, for example from another ingester or from the store-gateways, isn't counted twice.
+	require.NoError(t, limiter.AddSeries(selector, series1))
+
+	// The series of each selector are counted separately.
+	require.NoError(t, limiter.AddSeries(other, series1))
+	require.NoError(t, limiter.AddSeries(other, series3))
+
+	err := limiter.AddSeries(selector, series3)
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf(MaxSeriesPerSelectorHitMsgFormat, `{__name__=~".+"}`, 2), err.Error())
+	assert.Equal(t, &stats.Rejection{Limit: "max-series-per-selector", Component: "querier", MeasuredValue: 3, ConfiguredLimit: 2}, queryStats.LoadRejection())
+}
+
+func TestSelectorSeriesLimiterFromContextWithFallback(t *testing.T) {
+	selector := Selector([]*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, ".+")})
+	series1 := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_1"))
+	series2 := mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_2"))
+
+	// Without a limiter in the context, a limiter with no limit is returned.
+	limiter := SelectorSeriesLimiterFromContextWithFallback(context.Background())
+	require.NoError(t, limiter.AddSeries(selector, series1))
+	require.NoError(t, limiter.AddSeries(selector, series2))
+
+	ctx := AddSelectorSeriesLimiterToContext(context.Background(), NewSelectorSeriesLimiter(1, nil))
+	limiter = SelectorSeriesLimiterFromContextWithFallback(ctx)
+	require.NoError(t, limiter.AddSeries(selector, series1))
+	require.Error(t, limiter.AddSeries(selector, series2))
+}
+
+func TestSelectorSeriesLimiter_ShouldCountTheSeriesOfTheAliasedSelectorsTogether(t *testing.T) {
+	selector := Selector([]*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, "series_1|series_2")})
+	part1 := Selector([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "series_1")})
+	part2 := Selector([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "series_2")})
+
+	limiter := NewSelectorSeriesLimiter(1, nil)
+	limiter.AddAlias(part1, selector)
+	limiter.AddAlias(part2, selector)
+
+	require.NoError(t, limiter.AddSeries(part1, mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_1"))))
+	err := limiter.AddSeries(part2, mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "series_2")))
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf(MaxSeriesPerSelectorHitMsgFormat, `{__name__=~"series_1|series_2"}`, 1), err.Error())
+}
@@ -7,6 +7,8 @@
 
 package util
 
+import "sort"
+
 // MergeSlices merges a set of sorted string slices into a single ones
 // while removing all duplicates.
 func MergeSlices(a ...[]string) []string {
@@ -44,3 +46,16 @@ func mergeTwoStringSlices(a, b []string) []string {
 	res = append(res, b...)
 	return res
 }
+
+// PaginateSortedSlice returns the values of the input sorted slice sorting after the input value,
+// up to limit values. An empty after returns the values from the first one, and a limit <= 0 returns
+// all the values.
+func PaginateSortedSlice(values []string, after string, limit int) []string {
+	if after != "" {
+		values = values[sort.Search(len(values), func(i int) bool { return values[i] > after }):]
+	}
+	if limit > 0 && len(values) > limit {
+		values = values[:limit]
+	}
+	return values
+}
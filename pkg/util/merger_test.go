@@ -59,3 +59,14 @@ func TestMergeSlices(t *testing.T) {
 		})
 	}
 }
+
+func TestPaginateSortedSlice(t *testing.T) {
+	values := []string{"a", "b", "c", "d"}
+
+	require.Equal(t, values, PaginateSortedSlice(values, "", 0))
+	require.Equal(t, []string{"a", "b"}, PaginateSortedSlice(values, "", 2))
+	require.Equal(t, []string{"c", "d"}, PaginateSortedSlice(values, "b", 0))
+	require.Equal(t, []string{"c"}, PaginateSortedSlice(values, "bb", 1))
+	require.Empty(t, PaginateSortedSlice(values, "d", 2))
+	require.Empty(t, PaginateSortedSlice(nil, "a", 2))
+}
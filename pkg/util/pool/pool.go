@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/pkg/errors"
+	"go.uber.org/atomic"
 )
 
 // Interface defines the same functions of sync.Pool.
@@ -47,6 +48,9 @@ type BucketedBytes struct {
 	usedTotal uint64
 	mtx       sync.Mutex
 
+	// contentions is the number of times the lock was already held when acquired.
+	contentions atomic.Uint64
+
 	new func(s int) *[]byte
 }
 
@@ -86,13 +90,61 @@ var ErrPoolExhausted = errors.New("pool exhausted")
 
 // Get returns a new byte slice that fits the given size.
 func (p *BucketedBytes) Get(sz int) (*[]byte, error) {
-	p.mtx.Lock()
+	p.lock()
 	defer p.mtx.Unlock()
 
 	if p.maxTotal > 0 && p.usedTotal+uint64(sz) > p.maxTotal {
 		return nil, ErrPoolExhausted
 	}
 
+	b := p.getFromBucket(sz)
+	p.usedTotal += uint64(cap(*b))
+	return b, nil
+}
+
+// Put returns a byte slice to the right bucket in the pool.
+func (p *BucketedBytes) Put(b *[]byte) {
+	if b == nil {
+		return
+	}
+
+	sz := cap(*b)
+	p.putToBucket(b)
+
+	p.lock()
+	defer p.mtx.Unlock()
+	// We could assume here that our users will not make the slices larger
+	// but lets be on the safe side to avoid an underflow of p.usedTotal.
+	if uint64(sz) >= p.usedTotal {
+		p.usedTotal = 0
+	} else {
+		p.usedTotal -= uint64(sz)
+	}
+}
+
+// Contentions returns the number of times a Get() or Put() waited for another one to complete.
+func (p *BucketedBytes) Contentions() uint64 {
+	return p.contentions.Load()
+}
+
+func (p *BucketedBytes) lock() {
+	if !p.mtx.TryLock() {
+		p.contentions.Inc()
+		p.mtx.Lock()
+	}
+}
+
+// bucketSize returns the capacity of the byte slices got for the given size.
+func (p *BucketedBytes) bucketSize(sz int) int {
+	for _, bktSize := range p.sizes {
+		if sz <= bktSize {
+			return bktSize
+		}
+	}
+	return sz
+}
+
+func (p *BucketedBytes) getFromBucket(sz int) *[]byte {
 	for i, bktSize := range p.sizes {
 		if sz > bktSize {
 			continue
@@ -101,22 +153,14 @@ func (p *BucketedBytes) Get(sz int) (*[]byte, error) {
 		if !ok {
 			b = p.new(bktSize)
 		}
-
-		p.usedTotal += uint64(cap(*b))
-		return b, nil
+		return b
 	}
 
 	// The requested size exceeds that of our highest bucket, allocate it directly.
-	p.usedTotal += uint64(sz)
-	return p.new(sz), nil
+	return p.new(sz)
 }
 
-// Put returns a byte slice to the right bucket in the pool.
-func (p *BucketedBytes) Put(b *[]byte) {
-	if b == nil {
-		return
-	}
-
+func (p *BucketedBytes) putToBucket(b *[]byte) {
 	sz := cap(*b)
 	for i, bktSize := range p.sizes {
 		if sz > bktSize {
@@ -126,16 +170,119 @@ func (p *BucketedBytes) Put(b *[]byte) {
 		p.buckets[i].Put(b)
 		break
 	}
+}
 
-	p.mtx.Lock()
-	defer p.mtx.Unlock()
-	// We could assume here that our users will not make the slices larger
-	// but lets be on the safe side to avoid an underflow of p.usedTotal.
-	if uint64(sz) >= p.usedTotal {
-		p.usedTotal = 0
-	} else {
-		p.usedTotal -= uint64(sz)
+// ShardedBucketedBytes is a bucketed pool for variably sized byte slices split in shards, each one to be used by a
+// different group of CPU cores, to reduce the contention when the pool is used by many goroutines running in parallel.
+// Each shard pools its own byte slices, so they're reused by the goroutines of the group of CPU cores which returned
+// them. The shards share a maximum number of used bytes, tracked without a lock.
+// Every byte slice obtained from a shard must be returned to the same shard.
+type ShardedBucketedBytes struct {
+	shards    []*BucketedBytes
+	maxTotal  uint64
+	usedTotal atomic.Uint64
+
+	// contentions is the number of times the used bytes were updated by another goroutine while being updated.
+	contentions atomic.Uint64
+}
+
+// NewShardedBucketedBytes returns a new ShardedBucketedBytes with the given number of shards, each one with
+// size buckets for minSize to maxSize increasing by the given factor. No more than maxTotal bytes can be used
+// at any given time by all the shards together unless maxTotal is set to 0.
+func NewShardedBucketedBytes(minSize, maxSize int, factor float64, maxTotal uint64, shards int) (*ShardedBucketedBytes, error) {
+	if shards < 1 {
+		return nil, errors.New("invalid number of shards")
+	}
+
+	p := &ShardedBucketedBytes{
+		shards:   make([]*BucketedBytes, shards),
+		maxTotal: maxTotal,
+	}
+	for i := range p.shards {
+		var err error
+		// The used bytes are tracked by the sharded pool, not by each shard.
+		if p.shards[i], err = NewBucketedBytes(minSize, maxSize, factor, 0); err != nil {
+			return nil, err
+		}
 	}
+	return p, nil
+}
+
+// Shards returns the number of shards of the pool.
+func (p *ShardedBucketedBytes) Shards() int {
+	return len(p.shards)
+}
+
+// Shard returns the i-th shard of the pool, to get byte slices from and return them to.
+func (p *ShardedBucketedBytes) Shard(i int) Bytes {
+	return &bytesShard{pool: p, buckets: p.shards[i]}
+}
+
+// Contentions returns the number of times a Get() or Put() on any shard retried to update the used bytes,
+// because they were updated by another one at the same time.
+func (p *ShardedBucketedBytes) Contentions() uint64 {
+	return p.contentions.Load()
+}
+
+// reserve increases the used bytes by sz, and returns false if it would exceed the maximum number of used bytes.
+func (p *ShardedBucketedBytes) reserve(sz uint64) bool {
+	for {
+		used := p.usedTotal.Load()
+		if p.maxTotal > 0 && used+sz > p.maxTotal {
+			return false
+		}
+		if p.usedTotal.CompareAndSwap(used, used+sz) {
+			return true
+		}
+		p.contentions.Inc()
+	}
+}
+
+// release decreases the used bytes by sz.
+func (p *ShardedBucketedBytes) release(sz uint64) {
+	for {
+		used := p.usedTotal.Load()
+		// Avoid an underflow of the used bytes if the byte slices have been made larger.
+		next := uint64(0)
+		if sz < used {
+			next = used - sz
+		}
+		if p.usedTotal.CompareAndSwap(used, next) {
+			return
+		}
+		p.contentions.Inc()
+	}
+}
+
+type bytesShard struct {
+	pool    *ShardedBucketedBytes
+	buckets *BucketedBytes
+}
+
+// Get returns a new byte slice that fits the given size.
+func (s *bytesShard) Get(sz int) (*[]byte, error) {
+	// The byte slice is at most as large as its bucket, so its bucket size is reserved before getting it.
+	reserved := uint64(s.buckets.bucketSize(sz))
+	if !s.pool.reserve(reserved) {
+		return nil, ErrPoolExhausted
+	}
+
+	b := s.buckets.getFromBucket(sz)
+	if used := uint64(cap(*b)); used < reserved {
+		s.pool.release(reserved - used)
+	}
+	return b, nil
+}
+
+// Put returns a byte slice to the right bucket in the shard.
+func (s *bytesShard) Put(b *[]byte) {
+	if b == nil {
+		return
+	}
+
+	sz := uint64(cap(*b))
+	s.buckets.putToBucket(b)
+	s.pool.release(sz)
 }
 
 // SlabPool wraps Interface and adds support to get a sub-slice of the data type T
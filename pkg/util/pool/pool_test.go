@@ -7,6 +7,7 @@ package pool
 
 import (
 	"math/rand"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 	"go.uber.org/goleak"
 )
 
@@ -67,10 +69,70 @@ func TestBytesPool(t *testing.T) {
 	require.Equal(t, uint64(0), chunkPool.usedTotal)
 }
 
-func TestRacePutGet(t *testing.T) {
-	chunkPool, err := NewBucketedBytes(3, 100, 2, 5000)
+func TestShardedBytesPool(t *testing.T) {
+	_, err := NewShardedBucketedBytes(10, 100, 2, 1000, 0)
+	require.Error(t, err)
+
+	chunkPool, err := NewShardedBucketedBytes(10, 100, 2, 1000, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, chunkPool.Shards())
+
+	// The max used bytes are shared by the shards, so a single shard can use all of them.
+	b1, err := chunkPool.Shard(0).Get(900)
+	require.NoError(t, err)
+	require.Equal(t, 900, cap(*b1))
+	require.Equal(t, uint64(900), chunkPool.usedTotal.Load())
+
+	_, err = chunkPool.Shard(1).Get(200)
+	require.Equal(t, ErrPoolExhausted, err)
+	require.Equal(t, uint64(900), chunkPool.usedTotal.Load())
+
+	// The bucket size is used, like the not sharded pool.
+	b2, err := chunkPool.Shard(1).Get(15)
 	require.NoError(t, err)
+	require.Equal(t, 20, cap(*b2))
+	require.Equal(t, uint64(920), chunkPool.usedTotal.Load())
+
+	// Returning the byte slices releases the used bytes.
+	chunkPool.Shard(0).Put(b1)
+	chunkPool.Shard(1).Put(b2)
+	require.Equal(t, uint64(0), chunkPool.usedTotal.Load())
+
+	// The byte slices are reused by the shard they're returned to, and only by it.
+	*b2 = append(*b2, "reused"...)
+	chunkPool.Shard(1).Put(b2)
+	for i := 0; i < 10; i++ {
+		b, err := chunkPool.Shard(2).Get(15)
+		require.NoError(t, err)
+		require.NotSame(t, b2, b)
+	}
+
+	// A pool without limit never gets exhausted.
+	chunkPool, err = NewShardedBucketedBytes(10, 100, 2, 0, 3)
+	require.NoError(t, err)
+	b, err := chunkPool.Shard(2).Get(1000)
+	require.NoError(t, err)
+	chunkPool.Shard(2).Put(b)
+}
 
+func TestRacePutGet(t *testing.T) {
+	t.Run("not sharded", func(t *testing.T) {
+		chunkPool, err := NewBucketedBytes(3, 100, 2, 5000)
+		require.NoError(t, err)
+		testRacePutGet(t, func(int) Bytes { return chunkPool })
+	})
+
+	t.Run("sharded", func(t *testing.T) {
+		chunkPool, err := NewShardedBucketedBytes(3, 100, 2, 5000, 4)
+		require.NoError(t, err)
+		testRacePutGet(t, func(i int) Bytes { return chunkPool.Shard(i % chunkPool.Shards()) })
+		require.Equal(t, uint64(0), chunkPool.usedTotal.Load())
+	})
+}
+
+// testRacePutGet runs goroutines getting and putting byte slices in parallel, each one using the pool returned by
+// poolFor for its index.
+func testRacePutGet(t *testing.T, poolFor func(i int) Bytes) {
 	s := sync.WaitGroup{}
 
 	const goroutines = 100
@@ -81,7 +143,7 @@ func TestRacePutGet(t *testing.T) {
 	errs := make(chan error, goroutines)
 	stop := make(chan struct{})
 
-	f := func(txt string, grow bool) {
+	f := func(chunkPool Bytes, txt string, grow bool) {
 		defer s.Done()
 		for {
 			select {
@@ -119,7 +181,7 @@ func TestRacePutGet(t *testing.T) {
 		s := strings.Repeat(string(byte(i)), i%10)
 		// some of the goroutines will append more elements to the provided slice
 		grow := i%2 == 0
-		go f(s, grow)
+		go f(poolFor(i), s, grow)
 	}
 
 	time.Sleep(1 * time.Second)
@@ -132,6 +194,47 @@ func TestRacePutGet(t *testing.T) {
 	}
 }
 
+func BenchmarkBytesPool_Parallel(b *testing.B) {
+	// The pools return the pool to be used by each of the parallel goroutines.
+	pools := map[string]func() (func() Bytes, interface{ Contentions() uint64 }, error){
+		"not sharded": func() (func() Bytes, interface{ Contentions() uint64 }, error) {
+			p, err := NewBucketedBytes(1000, 50000, 2, 100<<20)
+			return func() Bytes { return p }, p, err
+		},
+		"sharded per core": func() (func() Bytes, interface{ Contentions() uint64 }, error) {
+			p, err := NewShardedBucketedBytes(1000, 50000, 2, 100<<20, runtime.GOMAXPROCS(0))
+			if err != nil {
+				return nil, nil, err
+			}
+			next := atomic.NewUint64(0)
+			return func() Bytes { return p.Shard(int(next.Inc() % uint64(p.Shards()))) }, p, nil
+		},
+	}
+
+	for name, newPool := range pools {
+		b.Run(name, func(b *testing.B) {
+			poolFor, contentions, err := newPool()
+			require.NoError(b, err)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				p := poolFor()
+				for pb.Next() {
+					buf, err := p.Get(16000)
+					if err != nil {
+						b.Fatal(err)
+					}
+					p.Put(buf)
+				}
+			})
+			b.StopTimer()
+
+			b.ReportMetric(float64(contentions.Contentions())/float64(b.N), "contentions/op")
+		})
+	}
+}
+
 func TestSlabPool(t *testing.T) {
 	t.Run("byte slices do not overlap when fit on the same slab", func(t *testing.T) {
 		delegatePool := &TrackedPool{Parent: &sync.Pool{}}
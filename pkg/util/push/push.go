@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"sync"
 
 	"github.com/go-kit/log/level"
@@ -38,6 +39,13 @@ var bufferPool = sync.Pool{
 }
 
 const SkipLabelNameValidationHeader = "X-Mimir-SkipLabelNameValidation"
+
+// Response headers summarizing the estimated ingestion cost of the write request, set when the push cost estimation is enabled.
+const (
+	AcceptedSamplesHeader = "X-Mimir-Accepted-Samples"
+	CreatedSeriesHeader   = "X-Mimir-Created-Series"
+	IngestionCostHeader   = "X-Mimir-Ingestion-Cost"
+)
 const statusClientClosedRequest = 499
 
 // Handler is a http.Handler which accepts WriteRequests.
@@ -115,21 +123,32 @@ func handler(maxRecvMsgSize int,
 			return &req.WriteRequest, cleanup, nil
 		}
 		req := newRequest(supplier)
-		if _, err := push(ctx, req); err != nil {
+		resp, err := push(ctx, req)
+		if resp != nil && resp.Cost != nil {
+			// Set the cost headers on failed requests too, because some samples may have been accepted anyway.
+			setCostHeaders(w.Header(), resp.Cost)
+		}
+		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				http.Error(w, err.Error(), statusClientClosedRequest)
 				level.Warn(logger).Log("msg", "push request canceled", "err", err)
 				return
 			}
-			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			errResp, ok := httpgrpc.HTTPResponseFromError(err)
 			if !ok {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			if resp.GetCode() != 202 {
+			if errResp.GetCode() != 202 {
 				level.Error(logger).Log("msg", "push error", "err", err)
 			}
-			http.Error(w, string(resp.Body), int(resp.Code))
+			http.Error(w, string(errResp.Body), int(errResp.Code))
 		}
 	})
 }
+
+func setCostHeaders(h http.Header, cost *mimirpb.WriteCost) {
+	h.Set(AcceptedSamplesHeader, strconv.FormatUint(cost.AcceptedSamples, 10))
+	h.Set(CreatedSeriesHeader, strconv.FormatUint(cost.CreatedSeries, 10))
+	h.Set(IngestionCostHeader, strconv.FormatFloat(cost.Units, 'f', -1, 64))
+}
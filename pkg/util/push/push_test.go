@@ -326,6 +326,46 @@ func TestHandler_mimirWriteRequest(t *testing.T) {
 	assert.Equal(t, 200, resp.Code)
 }
 
+func TestHandler_costHeaders(t *testing.T) {
+	cost := &mimirpb.WriteCost{AcceptedSamples: 2, CreatedSeries: 1, Units: 102.5}
+
+	t.Run("should not set the cost headers if the cost has not been estimated", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, nil, false, verifyWritePushFunc(t, mimirpb.API))
+		handler.ServeHTTP(resp, createRequest(t, createPrometheusRemoteWriteProtobuf(t)))
+		assert.Equal(t, 200, resp.Code)
+		assert.Empty(t, resp.Header().Get(AcceptedSamplesHeader))
+		assert.Empty(t, resp.Header().Get(CreatedSeriesHeader))
+		assert.Empty(t, resp.Header().Get(IngestionCostHeader))
+	})
+
+	t.Run("should set the cost headers on successful pushes", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, nil, false, func(_ context.Context, req *Request) (*mimirpb.WriteResponse, error) {
+			defer req.CleanUp()
+			return &mimirpb.WriteResponse{Cost: cost}, nil
+		})
+		handler.ServeHTTP(resp, createRequest(t, createPrometheusRemoteWriteProtobuf(t)))
+		assert.Equal(t, 200, resp.Code)
+		assert.Equal(t, "2", resp.Header().Get(AcceptedSamplesHeader))
+		assert.Equal(t, "1", resp.Header().Get(CreatedSeriesHeader))
+		assert.Equal(t, "102.5", resp.Header().Get(IngestionCostHeader))
+	})
+
+	t.Run("should set the cost headers on partially failed pushes", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, nil, false, func(_ context.Context, req *Request) (*mimirpb.WriteResponse, error) {
+			defer req.CleanUp()
+			return &mimirpb.WriteResponse{Cost: cost}, httpgrpc.Errorf(http.StatusBadRequest, "some samples have been rejected")
+		})
+		handler.ServeHTTP(resp, createRequest(t, createPrometheusRemoteWriteProtobuf(t)))
+		assert.Equal(t, 400, resp.Code)
+		assert.Equal(t, "2", resp.Header().Get(AcceptedSamplesHeader))
+		assert.Equal(t, "1", resp.Header().Get(CreatedSeriesHeader))
+		assert.Equal(t, "102.5", resp.Header().Get(IngestionCostHeader))
+	})
+}
+
 func TestHandler_contextCanceledRequest(t *testing.T) {
 	req := createRequest(t, createMimirWriteRequestProtobuf(t, false))
 	resp := httptest.NewRecorder()
@@ -264,6 +264,35 @@ func NewMaxQueryExpressionSizeBytesError(actualSizeBytes, maxQuerySizeBytes int)
 		maxQueryExpressionSizeBytesFlag))
 }
 
+func NewMaxExemplarsPerQueryError(maxExemplarsPerQuery int) LimitError {
+	return LimitError(globalerror.MaxExemplarsPerQuery.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the query exceeded the maximum number of exemplars (limit: %d exemplars)", maxExemplarsPerQuery),
+		MaxExemplarsPerQueryFlag))
+}
+
+func NewMaxLabelValuesResultsSizeBytesError(maxSizeBytes int) LimitError {
+	return LimitError(globalerror.MaxLabelValuesResultsSizeBytes.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the label values query exceeded the maximum size of the label values (limit: %d bytes)", maxSizeBytes),
+		MaxLabelValuesResultsSizeBytesFlag))
+}
+
+// CheckLabelValuesResultsSizeBytes returns an error if the size in bytes of the input label values
+// exceeds the input limit. A limit <= 0 disables the check.
+func CheckLabelValuesResultsSizeBytes(values []string, maxSizeBytes int) error {
+	if maxSizeBytes <= 0 {
+		return nil
+	}
+
+	sizeBytes := 0
+	for _, v := range values {
+		sizeBytes += len(v)
+		if sizeBytes > maxSizeBytes {
+			return NewMaxLabelValuesResultsSizeBytesError(maxSizeBytes)
+		}
+	}
+	return nil
+}
+
 func NewRequestRateLimitedError(limit float64, burst int) LimitError {
 	return LimitError(globalerror.RequestRateLimited.MessageWithPerTenantLimitConfig(
 		fmt.Sprintf("the request has been rejected because the tenant exceeded the request rate limit, set to %v requests/s across all distributors with a maximum allowed burst of %d", limit, burst),
@@ -36,6 +36,17 @@ func TestNewTotalMaxQueryLengthError(t *testing.T) {
 	assert.Equal(t, "the total query time range exceeds the limit (query length: 1h0m0s, limit: 1m0s) (err-mimir-max-total-query-length). To adjust the related per-tenant limit, configure -query-frontend.max-total-query-length, or contact your service administrator.", err.Error())
 }
 
+func TestCheckLabelValuesResultsSizeBytes(t *testing.T) {
+	values := []string{"200", "500"}
+
+	assert.NoError(t, CheckLabelValuesResultsSizeBytes(values, 0))
+	assert.NoError(t, CheckLabelValuesResultsSizeBytes(values, 6))
+
+	err := CheckLabelValuesResultsSizeBytes(values, 5)
+	assert.Equal(t, NewMaxLabelValuesResultsSizeBytesError(5), err)
+	assert.Equal(t, "the label values query exceeded the maximum size of the label values (limit: 5 bytes) (err-mimir-max-label-values-results-size-bytes). To adjust the related per-tenant limit, configure -querier.label-values-results-max-size-bytes, or contact your service administrator.", err.Error())
+}
+
 func TestNewRequestRateLimitedError(t *testing.T) {
 	err := NewRequestRateLimitedError(10, 5)
 	assert.Equal(t, "the request has been rejected because the tenant exceeded the request rate limit, set to 10 requests/s across all distributors with a maximum allowed burst of 5 (err-mimir-tenant-max-request-rate). To adjust the related per-tenant limits, configure -distributor.request-rate-limit and -distributor.request-burst-size, or contact your service administrator.", err.Error())
@@ -22,6 +22,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 )
 
@@ -32,7 +33,17 @@ const (
 	MaxMetadataPerUserFlag                 = "ingester.max-global-metadata-per-user"
 	MaxChunksPerQueryFlag                  = "querier.max-fetched-chunks-per-query"
 	MaxChunkBytesPerQueryFlag              = "querier.max-fetched-chunk-bytes-per-query"
+	MaxEstimatedChunksPerQueryFlag         = "querier.max-estimated-fetched-chunks-per-query"
+	MaxEstimatedChunkBytesPerQueryFlag     = "querier.max-estimated-fetched-chunk-bytes-per-query"
 	MaxSeriesPerQueryFlag                  = "querier.max-fetched-series-per-query"
+	MaxSeriesPerSelectorFlag               = "querier.max-fetched-series-per-selector"
+	MaxExemplarsPerQueryFlag               = "querier.max-fetched-exemplars-per-query"
+	MaxEstimatedMemoryPerQueryFlag         = "querier.max-estimated-memory-consumption-per-query"
+	MaxQueryExecutionTimeFlag              = "query-scheduler.max-query-execution-time"
+	MaxCPUTimePerQueryFlag                 = "querier.max-cpu-time-per-query"
+	MaxLabelValuesResultsSizeBytesFlag     = "querier.label-values-results-max-size-bytes"
+	QuerierEmbeddedStoreMaxBlocksFlag      = "querier.embedded-store-max-blocks"
+	MaxActiveSeriesPerUserFlag             = "usage-tracker.max-active-series-per-user"
 	maxLabelNamesPerSeriesFlag             = "validation.max-label-names-per-series"
 	maxLabelNameLengthFlag                 = "validation.max-length-label-name"
 	maxLabelValueLengthFlag                = "validation.max-length-label-value"
@@ -52,6 +63,19 @@ const (
 
 	// MinCompactorPartialBlockDeletionDelay is the minimum partial blocks deletion delay that can be configured in Mimir.
 	MinCompactorPartialBlockDeletionDelay = 4 * time.Hour
+
+	// PromQL engines the queries can be run with.
+	QueryEnginePrometheus = "prometheus"
+	QueryEngineStreaming  = "streaming"
+
+	// Policies applied by the distributor to the received staleness markers.
+	StalenessMarkersPolicyIngest  = "ingest"
+	StalenessMarkersPolicyDrop    = "drop"
+	StalenessMarkersPolicyConvert = "convert"
+
+	// Chunk encodings the ingesters can store the native histograms with.
+	HistogramChunkEncodingHistogram      = "histogram"
+	HistogramChunkEncodingFloatHistogram = "float-histogram"
 )
 
 // LimitError are errors that do not comply with the limits specified.
@@ -90,74 +114,146 @@ type Limits struct {
 	EnforceMetadataMetricName bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name" category:"advanced"`
 	IngestionTenantShardSize  int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
 	MetricRelabelConfigs      []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs." category:"experimental"`
+	StalenessMarkersPolicy    string              `yaml:"staleness_markers_policy" json:"staleness_markers_policy" category:"experimental"`
+
+	IngestionDeadbandMetrics        flagext.StringSliceCSV `yaml:"ingestion_deadband_metrics" json:"ingestion_deadband_metrics" category:"experimental"`
+	IngestionDeadbandWindow         model.Duration         `yaml:"ingestion_deadband_window" json:"ingestion_deadband_window" category:"experimental"`
+	IngestionDeadbandStrictCounters bool                   `yaml:"ingestion_deadband_strict_counters" json:"ingestion_deadband_strict_counters" category:"experimental"`
 
 	// Ingester enforced limits.
 	// Series
 	MaxGlobalSeriesPerUser   int `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
 	MaxGlobalSeriesPerMetric int `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
+	// Usage-tracker enforced limits.
+	MaxActiveSeriesPerUser int `yaml:"max_active_series_per_user" json:"max_active_series_per_user" category:"experimental"`
 	// Metadata
 	MaxGlobalMetricsWithMetadataPerUser int `yaml:"max_global_metadata_per_user" json:"max_global_metadata_per_user"`
 	MaxGlobalMetadataPerMetric          int `yaml:"max_global_metadata_per_metric" json:"max_global_metadata_per_metric"`
 	// Exemplars
-	MaxGlobalExemplarsPerUser int `yaml:"max_global_exemplars_per_user" json:"max_global_exemplars_per_user" category:"experimental"`
+	MaxGlobalExemplarsPerUser   int  `yaml:"max_global_exemplars_per_user" json:"max_global_exemplars_per_user" category:"experimental"`
+	ExemplarsPersistenceEnabled bool `yaml:"exemplars_persistence_enabled" json:"exemplars_persistence_enabled" category:"experimental"`
+	MaxFetchedExemplarsPerQuery int  `yaml:"max_fetched_exemplars_per_query" json:"max_fetched_exemplars_per_query" category:"experimental"`
 	// Native histograms
-	NativeHistogramsIngestionEnabled bool `yaml:"native_histograms_ingestion_enabled" json:"native_histograms_ingestion_enabled" category:"experimental"`
+	NativeHistogramsIngestionEnabled bool   `yaml:"native_histograms_ingestion_enabled" json:"native_histograms_ingestion_enabled" category:"experimental"`
+	HistogramChunkEncoding           string `yaml:"histogram_chunk_encoding" json:"histogram_chunk_encoding" category:"experimental"`
 	// Active series custom trackers
 	ActiveSeriesCustomTrackersConfig activeseries.CustomTrackersConfig `yaml:"active_series_custom_trackers" json:"active_series_custom_trackers" doc:"description=Additional custom trackers for active metrics. If there are active series matching a provided matcher (map value), the count will be exposed in the custom trackers metric labeled using the tracker name (map key). Zero valued counts are not exposed (and removed when they go back to zero)." category:"advanced"`
 	// Max allowed time window for out-of-order samples.
 	OutOfOrderTimeWindow                 model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window" category:"experimental"`
 	OutOfOrderBlocksExternalLabelEnabled bool           `yaml:"out_of_order_blocks_external_label_enabled" json:"out_of_order_blocks_external_label_enabled" category:"experimental"`
+	// Postings for matchers cache in the TSDB head.
+	HeadPostingsForMatchersCacheSize int `yaml:"head_postings_for_matchers_cache_size" json:"head_postings_for_matchers_cache_size" category:"experimental"`
 
 	// User defined label to give the option of subdividing specific metrics by another label
 	SeparateMetricsGroupLabel string `yaml:"separate_metrics_group_label" json:"separate_metrics_group_label" category:"experimental"`
 
 	// Querier enforced limits.
-	MaxChunksPerQuery               int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
-	MaxFetchedSeriesPerQuery        int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
-	MaxFetchedChunkBytesPerQuery    int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
-	MaxQueryLookback                model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
-	MaxQueryLength                  model.Duration `yaml:"max_query_length" json:"max_query_length" doc:"hidden"` // TODO: deprecated, remove in 2.8
-	MaxPartialQueryLength           model.Duration `yaml:"max_partial_query_length" json:"max_partial_query_length"`
-	MaxQueryParallelism             int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
-	MaxLabelsQueryLength            model.Duration `yaml:"max_labels_query_length" json:"max_labels_query_length"`
-	MaxCacheFreshness               model.Duration `yaml:"max_cache_freshness" json:"max_cache_freshness" category:"advanced"`
-	MaxQueriersPerTenant            int            `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
-	QueryShardingTotalShards        int            `yaml:"query_sharding_total_shards" json:"query_sharding_total_shards"`
-	QueryShardingMaxShardedQueries  int            `yaml:"query_sharding_max_sharded_queries" json:"query_sharding_max_sharded_queries"`
-	QueryShardingMaxRegexpSizeBytes int            `yaml:"query_sharding_max_regexp_size_bytes" json:"query_sharding_max_regexp_size_bytes" category:"experimental"`
-	SplitInstantQueriesByInterval   model.Duration `yaml:"split_instant_queries_by_interval" json:"split_instant_queries_by_interval" category:"experimental"`
+	MaxChunksPerQuery                  int                    `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
+	MaxFetchedSeriesPerQuery           int                    `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
+	MaxFetchedSeriesPerSelector        int                    `yaml:"max_fetched_series_per_selector" json:"max_fetched_series_per_selector" category:"experimental"`
+	MaxFetchedChunkBytesPerQuery       int                    `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
+	MaxEstimatedChunksPerQuery         int                    `yaml:"max_estimated_fetched_chunks_per_query" json:"max_estimated_fetched_chunks_per_query" category:"experimental"`
+	MaxEstimatedChunkBytesPerQuery     int                    `yaml:"max_estimated_fetched_chunk_bytes_per_query" json:"max_estimated_fetched_chunk_bytes_per_query" category:"experimental"`
+	MaxEstimatedMemoryPerQuery         int                    `yaml:"max_estimated_memory_consumption_per_query" json:"max_estimated_memory_consumption_per_query" category:"experimental"`
+	MaxCPUTimePerQuery                 model.Duration         `yaml:"max_cpu_time_per_query" json:"max_cpu_time_per_query" category:"experimental"`
+	MaxQueryLookback                   model.Duration         `yaml:"max_query_lookback" json:"max_query_lookback"`
+	MaxQueryLength                     model.Duration         `yaml:"max_query_length" json:"max_query_length" doc:"hidden"` // TODO: deprecated, remove in 2.8
+	MaxPartialQueryLength              model.Duration         `yaml:"max_partial_query_length" json:"max_partial_query_length"`
+	MaxQueryParallelism                int                    `yaml:"max_query_parallelism" json:"max_query_parallelism"`
+	MaxLabelsQueryLength               model.Duration         `yaml:"max_labels_query_length" json:"max_labels_query_length"`
+	QuerierEmbeddedStoreMaxBlocks      int                    `yaml:"querier_embedded_store_max_blocks" json:"querier_embedded_store_max_blocks" category:"experimental"`
+	QueryEngine                        string                 `yaml:"query_engine" json:"query_engine" category:"experimental"`
+	EnabledPromQLExperimentalFunctions flagext.StringSliceCSV `yaml:"enabled_promql_experimental_functions" json:"enabled_promql_experimental_functions" category:"experimental"`
+	PromQLAtModifierEnabled            bool                   `yaml:"promql_at_modifier_enabled" json:"promql_at_modifier_enabled" category:"experimental"`
+	LookbackDelta                      model.Duration         `yaml:"lookback_delta" json:"lookback_delta" category:"experimental"`
+	QueryIngestersWithin               model.Duration         `yaml:"query_ingesters_within" json:"query_ingesters_within" category:"experimental"`
+	QueryStoreAfter                    model.Duration         `yaml:"query_store_after" json:"query_store_after" category:"experimental"`
+	StrictTimeRangeRoutingEnabled      bool                   `yaml:"strict_time_range_routing_enabled" json:"strict_time_range_routing_enabled" category:"experimental"`
+	CostBasedTimeRangeRoutingEnabled   bool                   `yaml:"cost_based_time_range_routing_enabled" json:"cost_based_time_range_routing_enabled" category:"experimental"`
+	TimeRangeRoutingOverlapMargin      model.Duration         `yaml:"time_range_routing_overlap_margin" json:"time_range_routing_overlap_margin" category:"experimental"`
+	QueryDeduplicationReplicaLabel     string                 `yaml:"query_deduplication_replica_label" json:"query_deduplication_replica_label" category:"experimental"`
+	QueryNativeHistogramsMaxSchema     int                    `yaml:"query_native_histograms_max_schema" json:"query_native_histograms_max_schema" category:"experimental"`
+	QueryNativeHistogramsMaxBuckets    int                    `yaml:"query_native_histograms_max_buckets" json:"query_native_histograms_max_buckets" category:"experimental"`
+	MaxQuerierConcurrentQueries        int                    `yaml:"max_querier_concurrent_queries" json:"max_querier_concurrent_queries" category:"experimental"`
+	MaxCacheFreshness                  model.Duration         `yaml:"max_cache_freshness" json:"max_cache_freshness" category:"advanced"`
+	MaxQueriersPerTenant               int                    `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
+	QueryShardingTotalShards           int                    `yaml:"query_sharding_total_shards" json:"query_sharding_total_shards"`
+	QueryShardingMaxShardedQueries     int                    `yaml:"query_sharding_max_sharded_queries" json:"query_sharding_max_sharded_queries"`
+	QueryShardingMaxRegexpSizeBytes    int                    `yaml:"query_sharding_max_regexp_size_bytes" json:"query_sharding_max_regexp_size_bytes" category:"experimental"`
+	SplitInstantQueriesByInterval      model.Duration         `yaml:"split_instant_queries_by_interval" json:"split_instant_queries_by_interval" category:"experimental"`
+	MinQueryStep                       model.Duration         `yaml:"min_query_step" json:"min_query_step" category:"experimental"`
 
 	// Query-frontend limits.
-	MaxTotalQueryLength                    model.Duration `yaml:"max_total_query_length" json:"max_total_query_length"`
-	ResultsCacheTTL                        model.Duration `yaml:"results_cache_ttl" json:"results_cache_ttl" category:"experimental"`
-	ResultsCacheTTLForOutOfOrderTimeWindow model.Duration `yaml:"results_cache_ttl_for_out_of_order_time_window" json:"results_cache_ttl_for_out_of_order_time_window" category:"experimental"`
-	MaxQueryExpressionSizeBytes            int            `yaml:"max_query_expression_size_bytes" json:"max_query_expression_size_bytes" category:"experimental"`
+	MaxTotalQueryLength                    model.Duration    `yaml:"max_total_query_length" json:"max_total_query_length"`
+	ResultsCacheTTL                        model.Duration    `yaml:"results_cache_ttl" json:"results_cache_ttl" category:"experimental"`
+	ResultsCacheTTLForOutOfOrderTimeWindow model.Duration    `yaml:"results_cache_ttl_for_out_of_order_time_window" json:"results_cache_ttl_for_out_of_order_time_window" category:"experimental"`
+	MaxQueryExpressionSizeBytes            int               `yaml:"max_query_expression_size_bytes" json:"max_query_expression_size_bytes" category:"experimental"`
+	QueryResultRelabelConfigs              []*relabel.Config `yaml:"query_result_relabel_configs,omitempty" json:"query_result_relabel_configs,omitempty" doc:"nocli|description=List of relabel configurations applied by the query-frontend to the series of the query results. It can be used to rewrite or drop the labels of the series returned to the tenant, or to drop the series altogether." category:"experimental"`
+	QueryResultRoundValuesDecimalPlaces    int               `yaml:"query_result_round_values_decimal_places" json:"query_result_round_values_decimal_places" category:"experimental"`
+	EnqueueRateLimit                       float64           `yaml:"enqueue_rate_limit" json:"enqueue_rate_limit" category:"experimental"`
+	EnqueueBurstSize                       int               `yaml:"enqueue_burst_size" json:"enqueue_burst_size" category:"experimental"`
+
+	// Query-scheduler limits.
+	MaxQueueWaitTime      model.Duration `yaml:"max_queue_wait_time" json:"max_queue_wait_time" category:"experimental"`
+	MaxQueryExecutionTime model.Duration `yaml:"max_query_execution_time" json:"max_query_execution_time" category:"experimental"`
+	QuerierPool           string         `yaml:"querier_pool" json:"querier_pool" category:"experimental"`
+
+	MinQueriersPerTenant             int     `yaml:"min_queriers_per_tenant" json:"min_queriers_per_tenant" category:"experimental"`
+	TargetQueriesPerSecondPerQuerier float64 `yaml:"target_queries_per_second_per_querier" json:"target_queries_per_second_per_querier" category:"experimental"`
+
+	QueryRateLimit       float64 `yaml:"query_rate_limit" json:"query_rate_limit" category:"experimental"`
+	QueryBurstSize       int     `yaml:"query_burst_size" json:"query_burst_size" category:"experimental"`
+	MaxConcurrentQueries int     `yaml:"max_concurrent_queries" json:"max_concurrent_queries" category:"experimental"`
 
 	// Cardinality
 	CardinalityAnalysisEnabled                    bool `yaml:"cardinality_analysis_enabled" json:"cardinality_analysis_enabled"`
 	LabelNamesAndValuesResultsMaxSizeBytes        int  `yaml:"label_names_and_values_results_max_size_bytes" json:"label_names_and_values_results_max_size_bytes"`
+	LabelValuesResultsMaxSizeBytes                int  `yaml:"label_values_results_max_size_bytes" json:"label_values_results_max_size_bytes" category:"experimental"`
 	LabelValuesMaxCardinalityLabelNamesPerRequest int  `yaml:"label_values_max_cardinality_label_names_per_request" json:"label_values_max_cardinality_label_names_per_request"`
 
 	// Ruler defaults and limits.
-	RulerEvaluationDelay                 model.Duration `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
-	RulerTenantShardSize                 int            `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
-	RulerMaxRulesPerRuleGroup            int            `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
-	RulerMaxRuleGroupsPerTenant          int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
-	RulerRecordingRulesEvaluationEnabled bool           `yaml:"ruler_recording_rules_evaluation_enabled" json:"ruler_recording_rules_evaluation_enabled" category:"experimental"`
-	RulerAlertingRulesEvaluationEnabled  bool           `yaml:"ruler_alerting_rules_evaluation_enabled" json:"ruler_alerting_rules_evaluation_enabled" category:"experimental"`
+	RulerEvaluationDelay                  model.Duration         `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
+	RulerTenantShardSize                  int                    `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
+	RulerMaxRulesPerRuleGroup             int                    `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
+	RulerMaxRuleGroupsPerTenant           int                    `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
+	RulerRecordingRulesEvaluationEnabled  bool                   `yaml:"ruler_recording_rules_evaluation_enabled" json:"ruler_recording_rules_evaluation_enabled" category:"experimental"`
+	RulerAlertingRulesEvaluationEnabled   bool                   `yaml:"ruler_alerting_rules_evaluation_enabled" json:"ruler_alerting_rules_evaluation_enabled" category:"experimental"`
+	RulerMinRuleEvaluationInterval        model.Duration         `yaml:"ruler_min_rule_evaluation_interval" json:"ruler_min_rule_evaluation_interval" category:"experimental"`
+	RulerMinRuleEvaluationIntervalRewrite bool                   `yaml:"ruler_min_rule_evaluation_interval_rewrite_enabled" json:"ruler_min_rule_evaluation_interval_rewrite_enabled" category:"experimental"`
+	RulerAllowedDestinationTenants        flagext.StringSliceCSV `yaml:"ruler_allowed_destination_tenants" json:"ruler_allowed_destination_tenants" category:"experimental"`
 
 	// Store-gateway.
-	StoreGatewayTenantShardSize int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayTenantShardSize         int            `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayTenantReplicationFactor int            `yaml:"store_gateway_tenant_replication_factor" json:"store_gateway_tenant_replication_factor" category:"experimental"`
+	StoreGatewayLargeTenantThreshold    int            `yaml:"store_gateway_large_tenant_threshold_bytes" json:"store_gateway_large_tenant_threshold_bytes" category:"experimental"`
+	StoreGatewayLargeTenantShardSize    int            `yaml:"store_gateway_large_tenant_shard_size" json:"store_gateway_large_tenant_shard_size" category:"experimental"`
+	StoreGatewayChunksCacheMinBlockAge  model.Duration `yaml:"store_gateway_chunks_cache_min_block_age" json:"store_gateway_chunks_cache_min_block_age" category:"experimental"`
+	StoreGatewayChunksCacheMaxBlockAge  model.Duration `yaml:"store_gateway_chunks_cache_max_block_age" json:"store_gateway_chunks_cache_max_block_age" category:"experimental"`
+	StoreGatewayPartialResultsEnabled   bool           `yaml:"store_gateway_partial_results_enabled" json:"store_gateway_partial_results_enabled" category:"experimental"`
+
+	StoreGatewayMaxTouchedPostingsBytesPerRequest int  `yaml:"store_gateway_max_touched_postings_bytes_per_request" json:"store_gateway_max_touched_postings_bytes_per_request" category:"experimental"`
+	StoreGatewayMaxTouchedSeriesBytesPerRequest   int  `yaml:"store_gateway_max_touched_series_bytes_per_request" json:"store_gateway_max_touched_series_bytes_per_request" category:"experimental"`
+	StoreGatewayMaxTouchedChunksBytesPerRequest   int  `yaml:"store_gateway_max_touched_chunks_bytes_per_request" json:"store_gateway_max_touched_chunks_bytes_per_request" category:"experimental"`
+	StoreGatewayMaxTouchedPostingsBytesPerTenant  int  `yaml:"store_gateway_max_touched_postings_bytes_per_tenant" json:"store_gateway_max_touched_postings_bytes_per_tenant" category:"experimental"`
+	StoreGatewayMaxTouchedSeriesBytesPerTenant    int  `yaml:"store_gateway_max_touched_series_bytes_per_tenant" json:"store_gateway_max_touched_series_bytes_per_tenant" category:"experimental"`
+	StoreGatewayMaxTouchedChunksBytesPerTenant    int  `yaml:"store_gateway_max_touched_chunks_bytes_per_tenant" json:"store_gateway_max_touched_chunks_bytes_per_tenant" category:"experimental"`
+	StoreGatewayMaxResidentIndexHeaderBytes       int  `yaml:"store_gateway_max_resident_index_header_bytes" json:"store_gateway_max_resident_index_header_bytes" category:"experimental"`
+	StoreGatewayIndexHeadersPinned                bool `yaml:"store_gateway_index_headers_pinned" json:"store_gateway_index_headers_pinned" category:"experimental"`
+	StoreGatewayLocalReplicaEnabled               bool `yaml:"store_gateway_local_replica_enabled" json:"store_gateway_local_replica_enabled" category:"experimental"`
 
 	// Compactor.
-	CompactorBlocksRetentionPeriod        model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
-	CompactorSplitAndMergeShards          int            `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
-	CompactorSplitGroups                  int            `yaml:"compactor_split_groups" json:"compactor_split_groups"`
-	CompactorTenantShardSize              int            `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
-	CompactorPartialBlockDeletionDelay    model.Duration `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
-	CompactorBlockUploadEnabled           bool           `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
-	CompactorBlockUploadValidationEnabled bool           `yaml:"compactor_block_upload_validation_enabled" json:"compactor_block_upload_validation_enabled"`
-	CompactorBlockUploadVerifyChunks      bool           `yaml:"compactor_block_upload_verify_chunks" json:"compactor_block_upload_verify_chunks"`
+	CompactorBlocksRetentionPeriod        model.Duration          `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
+	CompactorSplitAndMergeShards          int                     `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
+	CompactorSplitGroups                  int                     `yaml:"compactor_split_groups" json:"compactor_split_groups"`
+	CompactorTenantShardSize              int                     `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
+	CompactorPartialBlockDeletionDelay    model.Duration          `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
+	CompactorBlockUploadEnabled           bool                    `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
+	CompactorBlockUploadValidationEnabled bool                    `yaml:"compactor_block_upload_validation_enabled" json:"compactor_block_upload_validation_enabled"`
+	CompactorBlockUploadVerifyChunks      bool                    `yaml:"compactor_block_upload_verify_chunks" json:"compactor_block_upload_verify_chunks"`
+	CompactorCardinalityIndexEnabled      bool                    `yaml:"compactor_cardinality_index_enabled" json:"compactor_cardinality_index_enabled" category:"experimental"`
+	CompactorSeriesFilterEnabled          bool                    `yaml:"compactor_series_filter_enabled" json:"compactor_series_filter_enabled" category:"experimental"`
+	CompactorBlockRanges                  mimir_tsdb.DurationList `yaml:"compactor_block_ranges" json:"compactor_block_ranges" category:"experimental"`
+	CompactorMaxExemplarsBytesPerJob      int                     `yaml:"compactor_max_exemplars_bytes_per_job" json:"compactor_max_exemplars_bytes_per_job" category:"experimental"`
 
 	// This config doesn't have a CLI flag registered here because they're registered in
 	// their own original config struct.
@@ -172,12 +268,13 @@ type Limits struct {
 	NotificationRateLimit               float64                  `yaml:"alertmanager_notification_rate_limit" json:"alertmanager_notification_rate_limit"`
 	NotificationRateLimitPerIntegration NotificationRateLimitMap `yaml:"alertmanager_notification_rate_limit_per_integration" json:"alertmanager_notification_rate_limit_per_integration"`
 
-	AlertmanagerMaxConfigSizeBytes             int `yaml:"alertmanager_max_config_size_bytes" json:"alertmanager_max_config_size_bytes"`
-	AlertmanagerMaxTemplatesCount              int `yaml:"alertmanager_max_templates_count" json:"alertmanager_max_templates_count"`
-	AlertmanagerMaxTemplateSizeBytes           int `yaml:"alertmanager_max_template_size_bytes" json:"alertmanager_max_template_size_bytes"`
-	AlertmanagerMaxDispatcherAggregationGroups int `yaml:"alertmanager_max_dispatcher_aggregation_groups" json:"alertmanager_max_dispatcher_aggregation_groups"`
-	AlertmanagerMaxAlertsCount                 int `yaml:"alertmanager_max_alerts_count" json:"alertmanager_max_alerts_count"`
-	AlertmanagerMaxAlertsSizeBytes             int `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
+	AlertmanagerMaxConfigSizeBytes             int    `yaml:"alertmanager_max_config_size_bytes" json:"alertmanager_max_config_size_bytes"`
+	AlertmanagerMaxTemplatesCount              int    `yaml:"alertmanager_max_templates_count" json:"alertmanager_max_templates_count"`
+	AlertmanagerMaxTemplateSizeBytes           int    `yaml:"alertmanager_max_template_size_bytes" json:"alertmanager_max_template_size_bytes"`
+	AlertmanagerMaxDispatcherAggregationGroups int    `yaml:"alertmanager_max_dispatcher_aggregation_groups" json:"alertmanager_max_dispatcher_aggregation_groups"`
+	AlertmanagerMaxAlertsCount                 int    `yaml:"alertmanager_max_alerts_count" json:"alertmanager_max_alerts_count"`
+	AlertmanagerMaxAlertsSizeBytes             int    `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
+	AlertmanagerAlertEnrichmentURL             string `yaml:"alertmanager_alert_enrichment_url" json:"alertmanager_alert_enrichment_url" category:"experimental"`
 
 	ForwardingEndpoint      string          `yaml:"forwarding_endpoint" json:"forwarding_endpoint" doc:"nocli|description=Remote-write endpoint where metrics specified in forwarding_rules are forwarded to. If set, takes precedence over endpoints specified in forwarding rules."`
 	ForwardingDropOlderThan model.Duration  `yaml:"forwarding_drop_older_than" json:"forwarding_drop_older_than" doc:"nocli|description=If set, forwarding drops samples that are older than this duration. If unset or 0, no samples get dropped."`
@@ -197,6 +294,10 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&l.HAClusterLabel, "distributor.ha-tracker.cluster", "cluster", "Prometheus label to look for in samples to identify a Prometheus HA cluster.")
 	f.StringVar(&l.HAReplicaLabel, "distributor.ha-tracker.replica", "__replica__", "Prometheus label to look for in samples to identify a Prometheus HA replica.")
 	f.IntVar(&l.HAMaxClusters, HATrackerMaxClustersFlag, 100, "Maximum number of clusters that HA tracker will keep track of for a single tenant. 0 to disable the limit.")
+	f.StringVar(&l.StalenessMarkersPolicy, "distributor.staleness-markers-policy", StalenessMarkersPolicyIngest, fmt.Sprintf("Policy applied to the Prometheus staleness markers received by the distributor. Supported values are: %s, %s, %s. With %s the staleness markers are ingested as they are, with %s they are removed before ingestion, and with %s they are ingested as regular NaN samples, which the queries don't consider as staleness markers.", StalenessMarkersPolicyIngest, StalenessMarkersPolicyDrop, StalenessMarkersPolicyConvert, StalenessMarkersPolicyIngest, StalenessMarkersPolicyDrop, StalenessMarkersPolicyConvert))
+	f.Var(&l.IngestionDeadbandMetrics, "distributor.ingestion-deadband-metrics", "Comma-separated list of gauge metric names whose consecutive samples with an unchanged value are dropped by the distributor, as long as the last ingested sample of the series is more recent than the ingestion deadband window. The samples are filtered independently by each distributor, so fewer samples are dropped when the series of a tenant are pushed to multiple distributors.")
+	f.Var(&l.IngestionDeadbandWindow, "distributor.ingestion-deadband-window", "Max time an unchanged value of a series of the ingestion deadband metrics isn't ingested for. The first sample after the window is ingested even if its value is unchanged. It should be lower than the query lookback delta, otherwise the series would disappear from query results. 0 to disable the ingestion deadband.")
+	f.BoolVar(&l.IngestionDeadbandStrictCounters, "distributor.ingestion-deadband-strict-counters", true, "Never drop the samples of the ingestion deadband metrics which look like counters: the metrics whose name ends with _total, _count, _sum or _bucket, or whose metadata in the same request has the counter, histogram or summary type.")
 	f.Var(&l.DropLabels, "distributor.drop-label", "This flag can be used to specify label names that to drop during sample ingestion within the distributor and can be repeated in order to drop multiple labels.")
 	f.IntVar(&l.MaxLabelNameLength, maxLabelNameLengthFlag, 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, maxLabelValueLengthFlag, 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
@@ -208,27 +309,53 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 
 	f.IntVar(&l.MaxGlobalSeriesPerUser, MaxSeriesPerUserFlag, 150000, "The maximum number of in-memory series per tenant, across the cluster before replication. 0 to disable.")
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, MaxSeriesPerMetricFlag, 0, "The maximum number of in-memory series per metric name, across the cluster before replication. 0 to disable.")
+	f.IntVar(&l.MaxActiveSeriesPerUser, MaxActiveSeriesPerUserFlag, 0, "The maximum number of active series per tenant, across the cluster, tracked by the usage-tracker. Distributors reject the series exceeding this limit. This limit only applies when -distributor.usage-tracker-client.address is set. 0 to disable.")
 
 	f.IntVar(&l.MaxGlobalMetricsWithMetadataPerUser, MaxMetadataPerUserFlag, 0, "The maximum number of in-memory metrics with metadata per tenant, across the cluster. 0 to disable.")
 	f.IntVar(&l.MaxGlobalMetadataPerMetric, MaxMetadataPerMetricFlag, 0, "The maximum number of metadata per metric, across the cluster. 0 to disable.")
 	f.IntVar(&l.MaxGlobalExemplarsPerUser, "ingester.max-global-exemplars-per-user", 0, "The maximum number of exemplars in memory, across the cluster. 0 to disable exemplars ingestion.")
+	f.BoolVar(&l.ExemplarsPersistenceEnabled, "ingester.exemplars-persistence-enabled", false, "Persist the exemplars of each block into the long-term storage, when the block is shipped by the ingester, and query them through the store-gateways. Exemplars are persisted on a best-effort basis: only the exemplars which are still in the ingester's memory when the block is shipped are persisted.")
+	f.IntVar(&l.MaxEstimatedMemoryPerQuery, MaxEstimatedMemoryPerQueryFlag, 0, "The maximum estimated memory a single query can consume in the querier, in bytes. The estimate includes the series fetched from ingesters and long-term storage, and the samples loaded in memory by the PromQL engine. The samples loaded by the Prometheus engine are only checked against the limit once the query has been evaluated, so a query run with the Prometheus engine can exceed the limit while being evaluated. When the limit is reached, the query fails. This limit is enforced in the querier. 0 to disable.")
+	f.Var(&l.MaxCPUTimePerQuery, MaxCPUTimePerQueryFlag, "The maximum CPU time the evaluation of a single query can consume in the querier. The CPU time is measured on the goroutine evaluating the query, and it's only tracked when the querier runs on Linux. When the limit is reached, the query is canceled and fails. 0 to disable.")
+	f.IntVar(&l.MaxFetchedExemplarsPerQuery, MaxExemplarsPerQueryFlag, 0, "The maximum number of exemplars that a single exemplar query can fetch from ingesters and long-term storage. This limit is enforced in the querier. 0 to disable.")
 	f.Var(&l.ActiveSeriesCustomTrackersConfig, "ingester.active-series-custom-trackers", "Additional active series metrics, matching the provided matchers. Matchers should be in form <name>:<matcher>, like 'foobar:{foo=\"bar\"}'. Multiple matchers can be provided either providing the flag multiple times or providing multiple semicolon-separated values to a single flag.")
 	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", fmt.Sprintf("Non-zero value enables out-of-order support for most recent samples that are within the time window in relation to the TSDB's maximum time, i.e., within [db.maxTime-timeWindow, db.maxTime]). The ingester will need more memory as a factor of rate of out-of-order samples being ingested and the number of series that are getting out-of-order samples. If query falls into this window, cached results will use value from -%s option to specify TTL for resulting cache entry.", resultsCacheTTLForOutOfOrderWindowFlag))
 	f.BoolVar(&l.NativeHistogramsIngestionEnabled, "ingester.native-histograms-ingestion-enabled", false, "Enable ingestion of native histogram samples. If false, native histogram samples are ignored without an error. To query native histograms with query-sharding enabled make sure to set -query-frontend.query-result-response-format to 'protobuf'.")
+	f.StringVar(&l.HistogramChunkEncoding, "ingester.histogram-chunk-encoding", HistogramChunkEncodingHistogram, fmt.Sprintf("Chunk encoding the ingester stores the integer native histograms with. Supported values are: %s, %s. With %s the integer histograms are stored in integer histogram chunks, while with %s they are converted to float histograms and stored in float histogram chunks, trading a bigger storage size for not having to cut a new chunk when a series mixes integer and float histograms. The float histograms are always stored in float histogram chunks. A sample which, once converted, is a duplicate of a sample stored as an integer histogram, like a retried one, is appended with its original encoding.", HistogramChunkEncodingHistogram, HistogramChunkEncodingFloatHistogram, HistogramChunkEncodingHistogram, HistogramChunkEncodingFloatHistogram))
 	f.BoolVar(&l.OutOfOrderBlocksExternalLabelEnabled, "ingester.out-of-order-blocks-external-label-enabled", false, "Whether the shipper should label out-of-order blocks with an external label before uploading them. Setting this label will compact out-of-order blocks separately from non-out-of-order blocks")
+	f.IntVar(&l.HeadPostingsForMatchersCacheSize, "ingester.head-postings-for-matchers-cache-size", 0, "Maximum number of entries in the cache for postings for matchers in the tenant's Head and OOOHead. Allows to give tenants with many repeated queries on recent data, like dashboards, a bigger cache. The change is applied when the tenant's TSDB is opened. 0 to use -blocks-storage.tsdb.head-postings-for-matchers-cache-size.")
 
 	f.StringVar(&l.SeparateMetricsGroupLabel, "validation.separate-metrics-group-label", "", "Label used to define the group label for metrics separation. For each write request, the group is obtained from the first non-empty group label from the first timeseries in the incoming list of timeseries. Specific distributor and ingester metrics will be further separated adding a 'group' label with group label's value. Currently applies to the following metrics: cortex_discarded_samples_total")
 
 	f.IntVar(&l.MaxChunksPerQuery, MaxChunksPerQueryFlag, 2e6, "Maximum number of chunks that can be fetched in a single query from ingesters and long-term storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.IntVar(&l.MaxFetchedSeriesPerQuery, MaxSeriesPerQueryFlag, 0, "The maximum number of unique series for which a query can fetch samples from each ingesters and storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable")
+	f.IntVar(&l.MaxFetchedSeriesPerSelector, MaxSeriesPerSelectorFlag, 0, "The maximum number of unique series a single selector of a query can fetch from ingesters and long-term storage. When the limit is reached, the query fails with an error naming the selector. This limit is enforced in the querier and ruler. 0 to disable.")
 	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, MaxChunkBytesPerQueryFlag, 0, "The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler. 0 to disable.")
+	f.IntVar(&l.MaxEstimatedChunksPerQuery, MaxEstimatedChunksPerQueryFlag, 0, "Maximum number of chunks a single query is estimated to fetch from ingesters and long-term storage. The estimate is computed by the ingesters and store-gateways from their index before sending the chunks, so that the query is rejected before fetching them. When -querier.prefer-streaming-chunks-from-store-gateways is disabled, the store-gateways don't estimate the chunks, and the limit is enforced on the chunks fetched from them. This limit is enforced in the querier and ruler. 0 to disable.")
+	f.IntVar(&l.MaxEstimatedChunkBytesPerQuery, MaxEstimatedChunkBytesPerQueryFlag, 0, "Maximum size in bytes of all the chunks a single query is estimated to fetch from ingesters and long-term storage. The estimate is computed by the ingesters and store-gateways from their index before sending the chunks, so that the query is rejected before fetching them. When -querier.prefer-streaming-chunks-from-store-gateways is disabled, the store-gateways don't estimate the chunks, and the limit is enforced on the chunks fetched from them. This limit is enforced in the querier and ruler. 0 to disable.")
 	// TODO: Deprecated in Mimir 2.6, remove in Mimir 2.8
 	f.Var(&l.MaxQueryLength, maxQueryLengthFlag, fmt.Sprintf("Deprecated: Limit the query time range (end - start time). This limit is enforced in the querier (on the query possibly split by the query-frontend) and ruler. 0 to disable. This option is deprecated, use -%s or -%s instead.", maxPartialQueryLengthFlag, maxTotalQueryLengthFlag))
 	f.Var(&l.MaxPartialQueryLength, maxPartialQueryLengthFlag, fmt.Sprintf("Limit the time range for partial queries at the querier level. Defaults to the value of -%s if set to 0.", maxQueryLengthFlag))
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split (by time) or partial (by shard) queries that will be scheduled in parallel by the query-frontend for a single input query. This limit is introduced to have a fairer query scheduling and avoid a single query over a large time range saturating all available queriers.")
 	f.Var(&l.MaxLabelsQueryLength, "store.max-labels-query-length", "Limit the time range (end - start time) of series, label names and values queries. This limit is enforced in the querier. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
+	f.IntVar(&l.QuerierEmbeddedStoreMaxBlocks, QuerierEmbeddedStoreMaxBlocksFlag, 0, "Maximum number of blocks a tenant can have in the long-term storage to be queried by the queriers directly from the long-term storage, through the embedded store, bypassing the store-gateways. This limit only applies when -querier.embedded-store-enabled is true. 0 to disable.")
+	f.StringVar(&l.QueryEngine, "querier.query-engine", QueryEnginePrometheus, fmt.Sprintf("PromQL engine the tenant's queries are run with in the querier. Supported values are: %s, %s. The %s engine evaluates the queries one series at a time, to reduce the memory utilization, and supports a subset of PromQL: the queries it doesn't support are run with the %s engine.", QueryEnginePrometheus, QueryEngineStreaming, QueryEngineStreaming, QueryEnginePrometheus))
+	f.Var(&l.EnabledPromQLExperimentalFunctions, "querier.enabled-promql-experimental-functions", "Comma-separated list of the experimental PromQL functions enabled for the tenant, or all to enable all of them. The queries using experimental functions not enabled for the tenant are rejected.")
+	f.BoolVar(&l.PromQLAtModifierEnabled, "querier.promql-at-modifier-enabled", true, "True to enable the @ modifier in the tenant's queries. If disabled, the queries using the @ modifier are rejected.")
+	f.Var(&l.LookbackDelta, "querier.tenant-lookback-delta", "Time since the last sample after which a time series of the tenant is considered stale and ignored by expression evaluations. Useful for tenants scraping their targets less frequently than the lookback delta. 0 to use -querier.lookback-delta.")
+	f.Var(&l.QueryIngestersWithin, "querier.tenant-query-ingesters-within", "Maximum lookback beyond which the tenant's queries are not sent to ingesters. When ingesters shuffle sharding on the read path is enabled, it should not be greater than -querier.query-ingesters-within. 0 to use -querier.query-ingesters-within.")
+	f.Var(&l.QueryStoreAfter, "querier.tenant-query-store-after", "The time after which the tenant's metrics should be queried from the store-gateways and not just ingesters. 0 to use -querier.query-store-after.")
+	f.BoolVar(&l.StrictTimeRangeRoutingEnabled, "querier.strict-time-range-routing-enabled", false, "Route each part of the tenant's queries time range to a single component: the store-gateways are skipped when the ingesters hold the whole queried time range, and the ingesters are skipped when the store-gateways hold it. When both are queried, the ingesters are only queried for the time range more recent than the query-store-after boundary. This setting only applies when both the query-ingesters-within and query-store-after boundaries are set.")
+	f.BoolVar(&l.CostBasedTimeRangeRoutingEnabled, "querier.cost-based-time-range-routing-enabled", false, "Query the time range held by both the ingesters and the store-gateways, between the query-ingesters-within and query-store-after boundaries, from the component estimated to be cheaper, based on the recent latency of the queries run by the querier against each component. The time range within the overlap margin of the boundary between the two components is queried from both of them. This setting takes precedence over the strict time range routing, and only applies when both the query-ingesters-within and query-store-after boundaries are set.")
+	_ = l.TimeRangeRoutingOverlapMargin.Set("10m")
+	f.Var(&l.TimeRangeRoutingOverlapMargin, "querier.time-range-routing-overlap-margin", "Time range around the boundary between the ingesters and the store-gateways which is queried from both when the cost-based time range routing is enabled, to not return partial results because of the clock skew or of the blocks upload delay. It's capped to the time range between the query-ingesters-within and query-store-after boundaries.")
+	f.StringVar(&l.QueryDeduplicationReplicaLabel, "querier.deduplication-replica-label", "", "Label identifying the Prometheus HA replica of the tenant's series, to deduplicate at query time the series which only differ by this label. The label is removed from the queried series, and the samples of the deduplicated series are picked from a single replica at a time. Useful for tenants ingesting the series of all their Prometheus HA replicas, without the distributor HA tracker. Empty to disable.")
+	f.IntVar(&l.QueryNativeHistogramsMaxSchema, "querier.native-histograms-max-schema", 8, "Maximum schema of the native histograms returned by the tenant's queries. The resolution of the native histograms with a higher schema is reduced to this schema, and a warning is returned. Supported values are between -4 and 8.")
+	f.IntVar(&l.QueryNativeHistogramsMaxBuckets, "querier.native-histograms-max-buckets", 0, "Maximum number of buckets of each native histogram returned by the tenant's queries. The resolution of the native histograms with more buckets is reduced until they fit the limit or reach the lowest resolution, and a warning is returned. 0 to disable.")
+	f.IntVar(&l.MaxQuerierConcurrentQueries, "querier.max-concurrent-queries-per-tenant", 0, "Maximum number of the tenant's queries executing concurrently in a single querier. The tenant's queries dispatched to a querier above this limit wait in the querier until one of the tenant's executing queries completes, without holding the querier worker, so that the queries of other tenants can still be dispatched to the querier. It should be lower than -querier.max-concurrent. This option is supported only when the query-scheduler component is in use. 0 to disable.")
 	f.IntVar(&l.LabelNamesAndValuesResultsMaxSizeBytes, "querier.label-names-and-values-results-max-size-bytes", 400*1024*1024, "Maximum size in bytes of distinct label names and values. When querier receives response from ingester, it merges the response with responses from other ingesters. This maximum size limit is applied to the merged(distinct) results. If the limit is reached, an error is returned.")
+	f.IntVar(&l.LabelValuesResultsMaxSizeBytes, MaxLabelValuesResultsSizeBytesFlag, 0, "Maximum size in bytes of the label values returned by a single label values query. The limit is pushed down to ingesters and store-gateways, which fail the request as soon as the label values they would return exceed it, and is applied again by the querier to the merged label values. 0 to disable.")
 	f.BoolVar(&l.CardinalityAnalysisEnabled, "querier.cardinality-analysis-enabled", false, "Enables endpoints used for cardinality analysis.")
 	f.IntVar(&l.LabelValuesMaxCardinalityLabelNamesPerRequest, "querier.label-values-max-cardinality-label-names-per-request", 100, "Maximum number of label names allowed to be queried in a single /api/v1/cardinality/label_values API call.")
 	_ = l.MaxCacheFreshness.Set("1m")
@@ -239,6 +366,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.QueryShardingMaxShardedQueries, "query-frontend.query-sharding-max-sharded-queries", 128, "The max number of sharded queries that can be run for a given received query. 0 to disable limit.")
 	f.IntVar(&l.QueryShardingMaxRegexpSizeBytes, "query-frontend.query-sharding-max-regexp-size-bytes", 0, "Disable query sharding for any query containing a regular expression matcher longer than the configured number of bytes. 0 to disable the limit.")
 	f.Var(&l.SplitInstantQueriesByInterval, "query-frontend.split-instant-queries-by-interval", "Split instant queries by an interval and execute in parallel. 0 to disable it.")
+	f.Var(&l.MinQueryStep, "query-frontend.min-query-step", "Minimum step of the tenant's range queries. Range queries with a lower step are run with this step. 0 to disable it.")
 
 	_ = l.RulerEvaluationDelay.Set("1m")
 	f.Var(&l.RulerEvaluationDelay, "ruler.evaluation-delay-duration", "Duration to delay the evaluation of rules to ensure the underlying metrics have been pushed.")
@@ -247,6 +375,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.RulerMaxRuleGroupsPerTenant, "ruler.max-rule-groups-per-tenant", 70, "Maximum number of rule groups per-tenant. 0 to disable.")
 	f.BoolVar(&l.RulerRecordingRulesEvaluationEnabled, "ruler.recording-rules-evaluation-enabled", true, "Controls whether recording rules evaluation is enabled. This configuration option can be used to forcefully disable recording rules evaluation on a per-tenant basis.")
 	f.BoolVar(&l.RulerAlertingRulesEvaluationEnabled, "ruler.alerting-rules-evaluation-enabled", true, "Controls whether alerting rules evaluation is enabled. This configuration option can be used to forcefully disable alerting rules evaluation on a per-tenant basis.")
+	f.Var(&l.RulerMinRuleEvaluationInterval, "ruler.min-rule-evaluation-interval", "Minimum evaluation interval of the tenant's rule groups. Rule groups with a shorter evaluation interval are rejected by the ruler configuration API, unless -ruler.min-rule-evaluation-interval-rewrite-enabled is set. 0 to disable.")
+	f.BoolVar(&l.RulerMinRuleEvaluationIntervalRewrite, "ruler.min-rule-evaluation-interval-rewrite-enabled", false, "Instead of rejecting rule groups with an evaluation interval shorter than -ruler.min-rule-evaluation-interval, accept them and evaluate them at the minimum evaluation interval. This applies to the already stored rule groups too.")
+	f.Var(&l.RulerAllowedDestinationTenants, "ruler.allowed-destination-tenants", "Comma-separated list of tenants the tenant's rule groups are allowed to write the results of their recording rules to, through the destination_tenant field of the rule group. Rule groups with a destination tenant not in the list are rejected by the ruler configuration API, and fail to be evaluated.")
 
 	f.Var(&l.CompactorBlocksRetentionPeriod, "compactor.blocks-retention-period", "Delete blocks containing samples older than the specified retention period. Also used by query-frontend to avoid querying beyond the retention period. 0 to disable.")
 	f.IntVar(&l.CompactorSplitAndMergeShards, "compactor.split-and-merge-shards", 0, "The number of shards to use when splitting blocks. 0 to disable splitting.")
@@ -256,6 +387,10 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&l.CompactorBlockUploadEnabled, "compactor.block-upload-enabled", false, "Enable block upload API for the tenant.")
 	f.BoolVar(&l.CompactorBlockUploadValidationEnabled, "compactor.block-upload-validation-enabled", true, "Enable block upload validation for the tenant.")
 	f.BoolVar(&l.CompactorBlockUploadVerifyChunks, "compactor.block-upload-verify-chunks", true, "Verify chunks when uploading blocks via the upload API for the tenant.")
+	f.BoolVar(&l.CompactorCardinalityIndexEnabled, "compactor.cardinality-index-enabled", false, "Enable the tenant's cardinality index. The compactor writes a summary of the label names and values, and the number of series, of each block it compacts. The queriers use the summaries of the queried blocks to serve the label names and values queries without a selector, or with a metric name selector only, and the cardinality analysis of the blocks. The blocks without a summary, like the ones not compacted yet, are queried through the store-gateways.")
+	f.BoolVar(&l.CompactorSeriesFilterEnabled, "compactor.series-filter-enabled", false, "Enable the tenant's series filter index, built by the compactor next to the bucket index. The index holds a bloom filter of the label name and value pairs of the series of each of the tenant's blocks. The store-gateways use it to skip the blocks which can't contain the series matching the equality matchers of a query, without reading their index-header.")
+	f.Var(&l.CompactorBlockRanges, "compactor.tenant-block-ranges", "Comma separated list of compaction time ranges of the tenant, overriding the ones configured by -compactor.block-ranges. Each range must be greater than, and divisible by, the previous one. Empty to use the ranges configured by -compactor.block-ranges.")
+	f.IntVar(&l.CompactorMaxExemplarsBytesPerJob, "compactor.max-exemplars-bytes-per-job", 64*1024*1024, "Maximum total size in bytes of the exemplars files of the source blocks read by a compaction job, to carry over their exemplars to the compacted blocks. The exemplars files exceeding the limit are skipped, and their exemplars aren't carried over. 0 to disable the limit.")
 
 	// Query-frontend.
 	f.Var(&l.MaxTotalQueryLength, maxTotalQueryLengthFlag, fmt.Sprintf("Limit the total query time range (end - start time). This limit is enforced in the query-frontend on the received query. Defaults to the value of -%s if set to 0.", maxQueryLengthFlag))
@@ -264,9 +399,37 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.ResultsCacheTTLForOutOfOrderTimeWindow.Set("10m")
 	f.Var(&l.ResultsCacheTTLForOutOfOrderTimeWindow, resultsCacheTTLForOutOfOrderWindowFlag, fmt.Sprintf("Time to live duration for cached query results if query falls into out-of-order time window. This is lower than -%s so that incoming out-of-order samples are returned in the query results sooner.", resultsCacheTTLFlag))
 	f.IntVar(&l.MaxQueryExpressionSizeBytes, maxQueryExpressionSizeBytesFlag, 0, "Max size of the raw query, in bytes. 0 to not apply a limit to the size of the query.")
+	f.IntVar(&l.QueryResultRoundValuesDecimalPlaces, "query-frontend.query-result-round-values-decimal-places", -1, "Number of decimal places the float values of the query results are rounded to by the query-frontend. A negative value disables the rounding.")
+	f.Float64Var(&l.EnqueueRateLimit, "query-frontend.enqueue-rate-limit", 0, "Per-tenant rate limit of queries enqueued by each query-frontend to the query-schedulers, in queries per second. The limit is enforced by each query-frontend replica before the queries are sent to the query-schedulers, protecting the query-scheduler queues from clients issuing many queries per second. Queries above this limit fail with HTTP response status code 429. 0 to disable.")
+	f.IntVar(&l.EnqueueBurstSize, "query-frontend.enqueue-burst-size", 0, "Per-tenant allowed burst of queries enqueued by each query-frontend to the query-schedulers. This option only applies when -query-frontend.enqueue-rate-limit is set. 0 to use the enqueue rate limit, rounded up, as burst size.")
+
+	// Query-scheduler.
+	f.Var(&l.MaxQueueWaitTime, "query-scheduler.max-queue-wait-time", "Maximum time a query request can wait in the query-scheduler queue before being picked up by a querier. When exceeded, the query-scheduler removes the request from the queue and notifies the query-frontend, which fails the request. 0 to disable.")
+	f.Var(&l.MaxQueryExecutionTime, MaxQueryExecutionTimeFlag, "Maximum time a query request can run in the querier, once dispatched by the query-scheduler. The query-scheduler attaches the deadline to the request sent to the querier, which cancels the query execution when the deadline is reached and fails the request. 0 to disable.")
+	f.StringVar(&l.QuerierPool, "query-scheduler.querier-pool", "", "Querier pool the tenant's queries are reserved to. Queries are run only by the queriers advertising this pool via -querier.pool, and queriers in a pool run only the queries of the tenants assigned to it. If no querier in the pool is connected, queries are run by the queriers advertising no pool. Empty to run queries on the queriers advertising no pool.")
+	f.IntVar(&l.MinQueriersPerTenant, "query-scheduler.min-queriers-per-tenant", 0, "Minimum number of queriers that can handle requests for a single tenant, when the number of queriers is dynamically computed from the tenant's query rate. This option only applies when -query-scheduler.target-queries-per-second-per-querier is set.")
+	f.Float64Var(&l.TargetQueriesPerSecondPerQuerier, "query-scheduler.target-queries-per-second-per-querier", 0, "Target rate of queries per second of a single tenant that each querier should handle. When set, the number of queriers that can handle requests for a single tenant is dynamically computed by the query-scheduler as the tenant's recent query rate divided by this value, bounded by -query-scheduler.min-queriers-per-tenant and -query-frontend.max-queriers-per-tenant (if not 0). 0 to disable and use a fixed number of queriers configured by -query-frontend.max-queriers-per-tenant.")
+	f.Float64Var(&l.QueryRateLimit, "query-scheduler.query-rate-limit", 0, "Per-tenant rate limit of queries enqueued to the query-scheduler, in queries per second. The limit is global across all query-frontends. When query-scheduler ring-based service discovery is enabled, the limit is also global across all query-schedulers, otherwise it's enforced by each query-scheduler replica. Queries above this limit fail with HTTP response status code 429. 0 to disable.")
+	f.IntVar(&l.QueryBurstSize, "query-scheduler.query-burst-size", 0, "Per-tenant allowed burst of queries enqueued to the query-scheduler. This option only applies when -query-scheduler.query-rate-limit is set. 0 to use the query rate limit, rounded up, as burst size.")
+	f.IntVar(&l.MaxConcurrentQueries, "query-scheduler.max-concurrent-queries", 0, "Per-tenant maximum number of concurrent queries, either queued or running, in the query-scheduler. The limit is global across all query-frontends. When query-scheduler ring-based service discovery is enabled, the limit is also global across all query-schedulers, otherwise it's enforced by each query-scheduler replica. Queries above this limit fail with HTTP response status code 429. 0 to disable.")
 
 	// Store-gateway.
 	f.IntVar(&l.StoreGatewayTenantShardSize, "store-gateway.tenant-shard-size", 0, "The tenant's shard size, used when store-gateway sharding is enabled. Value of 0 disables shuffle sharding for the tenant, that is all tenant blocks are sharded across all store-gateway replicas.")
+	f.IntVar(&l.StoreGatewayTenantReplicationFactor, "store-gateway.tenant-replication-factor", 0, "The tenant's replication factor of the blocks in the store-gateways. It can only lower the replication factor configured for the store-gateway ring. Value of 0 uses the store-gateway ring replication factor.")
+	f.IntVar(&l.StoreGatewayLargeTenantThreshold, "store-gateway.large-tenant-threshold-bytes", 0, "Total size of the tenant's blocks, as listed in the bucket index, above which the tenant's blocks are sharded across -store-gateway.large-tenant-shard-size store-gateway replicas instead of the tenant's shard size. Requires the bucket index. 0 to disable.")
+	f.IntVar(&l.StoreGatewayLargeTenantShardSize, "store-gateway.large-tenant-shard-size", 0, "The tenant's shard size once the total size of the tenant's blocks exceeds -store-gateway.large-tenant-threshold-bytes. The shard always includes the store-gateway replicas of the tenant's shard. It only applies if greater than the tenant's shard size, and shuffle sharding is enabled for the tenant.")
+	f.Var(&l.StoreGatewayChunksCacheMinBlockAge, "store-gateway.chunks-cache-min-block-age", "Only fetch from and store to the chunks cache the chunks of blocks older than this age. The age of a block is the time elapsed since the block max time. Applies only when fine-grained chunks caching is enabled. 0 to disable.")
+	f.Var(&l.StoreGatewayChunksCacheMaxBlockAge, "store-gateway.chunks-cache-max-block-age", "Only fetch from and store to the chunks cache the chunks of blocks not older than this age. The age of a block is the time elapsed since the block max time. Applies only when fine-grained chunks caching is enabled. 0 to disable.")
+	f.BoolVar(&l.StoreGatewayPartialResultsEnabled, "store-gateway.partial-results-enabled", false, "If true, the store-gateway returns the series of the blocks successfully queried, along with a warning listing the blocks failed to be queried, instead of failing the whole request when some blocks can't be queried, for example because their index is corrupted or can't be fetched from the object storage. The failed blocks are not retried on other store-gateways.")
+	f.IntVar(&l.StoreGatewayMaxTouchedPostingsBytesPerRequest, "store-gateway.max-touched-postings-bytes-per-request", 0, "Maximum size in bytes of the postings touched by a single Series() request to a store-gateway, including the postings fetched from the index cache. 0 to disable.")
+	f.IntVar(&l.StoreGatewayMaxTouchedSeriesBytesPerRequest, "store-gateway.max-touched-series-bytes-per-request", 0, "Maximum size in bytes of the series touched by a single Series() request to a store-gateway, including the series fetched from the index cache. 0 to disable.")
+	f.IntVar(&l.StoreGatewayMaxTouchedChunksBytesPerRequest, "store-gateway.max-touched-chunks-bytes-per-request", 0, "Maximum size in bytes of the chunks touched by a single Series() request to a store-gateway, including the chunks fetched from the chunks cache. 0 to disable.")
+	f.IntVar(&l.StoreGatewayMaxTouchedPostingsBytesPerTenant, "store-gateway.max-touched-postings-bytes-per-tenant", 0, "Maximum size in bytes of the postings touched by all the in-flight Series() requests of the tenant to a store-gateway. 0 to disable.")
+	f.IntVar(&l.StoreGatewayMaxTouchedSeriesBytesPerTenant, "store-gateway.max-touched-series-bytes-per-tenant", 0, "Maximum size in bytes of the series touched by all the in-flight Series() requests of the tenant to a store-gateway. 0 to disable.")
+	f.IntVar(&l.StoreGatewayMaxResidentIndexHeaderBytes, "store-gateway.max-resident-index-header-bytes", 0, "Maximum size in bytes of the lazy loaded index-headers of the tenant kept loaded by a store-gateway. When exceeded, the index-headers are unloaded according to -blocks-storage.bucket-store.index-header-lazy-loading-eviction-policy, regardless of the idle timeout. The index-headers used within the last check are not unloaded, so the size can temporarily exceed the limit. 0 to disable.")
+	f.BoolVar(&l.StoreGatewayIndexHeadersPinned, "store-gateway.index-headers-pinned", false, "If true, the lazy loaded index-headers of the tenant are never unloaded by the store-gateway, neither because of the idle timeout nor because of the max resident index-header bytes.")
+	f.BoolVar(&l.StoreGatewayLocalReplicaEnabled, "store-gateway.local-replica-enabled", false, "If true, the store-gateway reads the files of the tenant's blocks from the local replica directory configured with -blocks-storage.bucket-store.local-replica-dir, when found there, instead of the long-term storage.")
+	f.IntVar(&l.StoreGatewayMaxTouchedChunksBytesPerTenant, "store-gateway.max-touched-chunks-bytes-per-tenant", 0, "Maximum size in bytes of the chunks touched by all the in-flight Series() requests of the tenant to a store-gateway. 0 to disable.")
 
 	// Alertmanager.
 	f.Var(&l.AlertmanagerReceiversBlockCIDRNetworks, "alertmanager.receivers-firewall-block-cidr-networks", "Comma-separated list of network CIDRs to block in Alertmanager receiver integrations.")
@@ -283,6 +446,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.AlertmanagerMaxTemplateSizeBytes, "alertmanager.max-template-size-bytes", 0, "Maximum size of single template in tenant's Alertmanager configuration uploaded via Alertmanager API. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxDispatcherAggregationGroups, "alertmanager.max-dispatcher-aggregation-groups", 0, "Maximum number of aggregation groups in Alertmanager's dispatcher that a tenant can have. Each active aggregation group uses single goroutine. When the limit is reached, dispatcher will not dispatch alerts that belong to additional aggregation groups, but existing groups will keep working properly. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxAlertsCount, "alertmanager.max-alerts-count", 0, "Maximum number of alerts that a single tenant can have. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
+	f.StringVar(&l.AlertmanagerAlertEnrichmentURL, "alertmanager.alert-enrichment-url", "", "URL of an HTTP service the Alertmanager sends the labels of the tenant's alerts to before notifying them. The annotations returned by the service are added to the alerts, unless the alerts already have them. The service is subject to the receivers firewall. Empty to disable.")
 	f.IntVar(&l.AlertmanagerMaxAlertsSizeBytes, "alertmanager.max-alerts-size-bytes", 0, "Maximum total size of alerts that a single tenant can have, alert size is the sum of the bytes of its labels, annotations and generatorURL. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
 }
 
@@ -330,6 +494,47 @@ func (l *Limits) validate() error {
 		}
 	}
 
+	for _, cfg := range l.QueryResultRelabelConfigs {
+		if cfg == nil {
+			return fmt.Errorf("invalid query_result_relabel_configs")
+		}
+	}
+
+	switch l.QueryEngine {
+	case "", QueryEnginePrometheus, QueryEngineStreaming:
+	default:
+		return fmt.Errorf("unsupported query_engine %q, supported values are: %s, %s", l.QueryEngine, QueryEnginePrometheus, QueryEngineStreaming)
+	}
+
+	if l.QueryIngestersWithin > 0 && l.QueryStoreAfter > 0 && l.QueryStoreAfter >= l.QueryIngestersWithin {
+		return fmt.Errorf("query_store_after must be lower than query_ingesters_within otherwise queries might return partial results")
+	}
+
+	if l.QueryNativeHistogramsMaxSchema < -4 || l.QueryNativeHistogramsMaxSchema > 8 {
+		return fmt.Errorf("unsupported query_native_histograms_max_schema %d, supported values are between -4 and 8", l.QueryNativeHistogramsMaxSchema)
+	}
+
+	if len(l.CompactorBlockRanges) > 0 && l.CompactorBlockRanges[0] <= 0 {
+		return fmt.Errorf("invalid compactor_block_ranges: the ranges must be positive")
+	}
+	for i := 1; i < len(l.CompactorBlockRanges); i++ {
+		if l.CompactorBlockRanges[i] <= l.CompactorBlockRanges[i-1] || l.CompactorBlockRanges[i]%l.CompactorBlockRanges[i-1] != 0 {
+			return fmt.Errorf("invalid compactor_block_ranges: each range must be greater than, and divisible by, the previous one, but %s is not", l.CompactorBlockRanges[i])
+		}
+	}
+
+	switch l.StalenessMarkersPolicy {
+	case "", StalenessMarkersPolicyIngest, StalenessMarkersPolicyDrop, StalenessMarkersPolicyConvert:
+	default:
+		return fmt.Errorf("unsupported staleness_markers_policy %q, supported values are: %s, %s, %s", l.StalenessMarkersPolicy, StalenessMarkersPolicyIngest, StalenessMarkersPolicyDrop, StalenessMarkersPolicyConvert)
+	}
+
+	switch l.HistogramChunkEncoding {
+	case "", HistogramChunkEncodingHistogram, HistogramChunkEncodingFloatHistogram:
+	default:
+		return fmt.Errorf("unsupported histogram_chunk_encoding %q, supported values are: %s, %s", l.HistogramChunkEncoding, HistogramChunkEncodingHistogram, HistogramChunkEncodingFloatHistogram)
+	}
+
 	return nil
 }
 
@@ -397,6 +602,11 @@ func (o *Overrides) LabelNamesAndValuesResultsMaxSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).LabelNamesAndValuesResultsMaxSizeBytes
 }
 
+// LabelValuesResultsMaxSizeBytes returns the maximum size in bytes of the label values returned by a single label values query.
+func (o *Overrides) LabelValuesResultsMaxSizeBytes(userID string) int {
+	return o.getOverridesForUser(userID).LabelValuesResultsMaxSizeBytes
+}
+
 func (o *Overrides) CardinalityAnalysisEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).CardinalityAnalysisEnabled
 }
@@ -479,12 +689,40 @@ func (o *Overrides) MaxFetchedSeriesPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxFetchedSeriesPerQuery
 }
 
+// MaxFetchedSeriesPerSelector returns the maximum number of series a single selector of a query is allowed
+// to fetch from ingesters and blocks storage.
+func (o *Overrides) MaxFetchedSeriesPerSelector(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedSeriesPerSelector
+}
+
 // MaxFetchedChunkBytesPerQuery returns the maximum number of bytes for chunks allowed per query when fetching
 // chunks from ingesters and blocks storage.
 func (o *Overrides) MaxFetchedChunkBytesPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxFetchedChunkBytesPerQuery
 }
 
+// MaxEstimatedChunksPerQuery returns the maximum number of chunks a single query is estimated to fetch from
+// ingesters and blocks storage.
+func (o *Overrides) MaxEstimatedChunksPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxEstimatedChunksPerQuery
+}
+
+// MaxEstimatedChunkBytesPerQuery returns the maximum size in bytes of the chunks a single query is estimated
+// to fetch from ingesters and blocks storage.
+func (o *Overrides) MaxEstimatedChunkBytesPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxEstimatedChunkBytesPerQuery
+}
+
+// MaxEstimatedMemoryPerQuery returns the maximum estimated memory a single query can consume in the querier.
+func (o *Overrides) MaxEstimatedMemoryPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxEstimatedMemoryPerQuery
+}
+
+// MaxCPUTimePerQuery returns the maximum CPU time the evaluation of a single query can consume in the querier.
+func (o *Overrides) MaxCPUTimePerQuery(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MaxCPUTimePerQuery)
+}
+
 // MaxQueryLookback returns the max lookback period of queries.
 func (o *Overrides) MaxQueryLookback(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryLookback)
@@ -518,6 +756,28 @@ func (o *Overrides) MaxQueryExpressionSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).MaxQueryExpressionSizeBytes
 }
 
+// QueryResultRelabelConfigs returns the relabel configs applied to the series of the query results of a given user.
+func (o *Overrides) QueryResultRelabelConfigs(userID string) []*relabel.Config {
+	return o.getOverridesForUser(userID).QueryResultRelabelConfigs
+}
+
+// QueryResultRoundValuesDecimalPlaces returns the number of decimal places the float values of the query results
+// of a given user are rounded to. A negative value means the values aren't rounded.
+func (o *Overrides) QueryResultRoundValuesDecimalPlaces(userID string) int {
+	return o.getOverridesForUser(userID).QueryResultRoundValuesDecimalPlaces
+}
+
+// EnqueueRateLimit returns the limit on the rate of queries each query-frontend enqueues to the query-schedulers
+// (queries per second).
+func (o *Overrides) EnqueueRateLimit(userID string) float64 {
+	return o.getOverridesForUser(userID).EnqueueRateLimit
+}
+
+// EnqueueBurstSize returns the burst size for the rate of queries each query-frontend enqueues to the query-schedulers.
+func (o *Overrides) EnqueueBurstSize(userID string) int {
+	return o.getOverridesForUser(userID).EnqueueBurstSize
+}
+
 // MaxLabelsQueryLength returns the limit of the length (in time) of a label names or values request.
 func (o *Overrides) MaxLabelsQueryLength(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxLabelsQueryLength)
@@ -529,11 +789,54 @@ func (o *Overrides) MaxCacheFreshness(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxCacheFreshness)
 }
 
+// MaxQueueWaitTime returns the maximum time a query request can wait in the query-scheduler queue.
+func (o *Overrides) MaxQueueWaitTime(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MaxQueueWaitTime)
+}
+
+// MaxQueryExecutionTime returns the maximum time a query request can run in the querier, once dispatched
+// by the query-scheduler.
+func (o *Overrides) MaxQueryExecutionTime(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MaxQueryExecutionTime)
+}
+
+// QuerierPool returns the querier pool the tenant's queries are reserved to, or empty if the tenant is assigned to no pool.
+func (o *Overrides) QuerierPool(userID string) string {
+	return o.getOverridesForUser(userID).QuerierPool
+}
+
 // MaxQueriersPerUser returns the maximum number of queriers that can handle requests for this user.
 func (o *Overrides) MaxQueriersPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxQueriersPerTenant
 }
 
+// MinQueriersPerUser returns the minimum number of queriers that can handle requests for this user,
+// when the number of queriers is dynamically computed from the user's query rate.
+func (o *Overrides) MinQueriersPerUser(userID string) int {
+	return o.getOverridesForUser(userID).MinQueriersPerTenant
+}
+
+// TargetQueriesPerSecondPerQuerier returns the target rate of queries per second of this user that each
+// querier should handle, or 0 if the number of queriers handling requests for this user is not dynamic.
+func (o *Overrides) TargetQueriesPerSecondPerQuerier(userID string) float64 {
+	return o.getOverridesForUser(userID).TargetQueriesPerSecondPerQuerier
+}
+
+// QueryRateLimit returns the limit on the rate of queries enqueued to the query-scheduler (queries per second).
+func (o *Overrides) QueryRateLimit(userID string) float64 {
+	return o.getOverridesForUser(userID).QueryRateLimit
+}
+
+// QueryBurstSize returns the burst size for the rate of queries enqueued to the query-scheduler.
+func (o *Overrides) QueryBurstSize(userID string) int {
+	return o.getOverridesForUser(userID).QueryBurstSize
+}
+
+// MaxConcurrentQueries returns the maximum number of queries, either queued or running, in the query-scheduler.
+func (o *Overrides) MaxConcurrentQueries(userID string) int {
+	return o.getOverridesForUser(userID).MaxConcurrentQueries
+}
+
 // MaxQueryParallelism returns the limit to the number of split queries the
 // frontend will process in parallel.
 func (o *Overrides) MaxQueryParallelism(userID string) int {
@@ -565,6 +868,11 @@ func (o *Overrides) SplitInstantQueriesByInterval(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).SplitInstantQueriesByInterval)
 }
 
+// MinQueryStep returns the minimum step of the range queries of the tenant. 0 to disable limit.
+func (o *Overrides) MinQueryStep(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).MinQueryStep)
+}
+
 // EnforceMetadataMetricName whether to enforce the presence of a metric name on metadata.
 func (o *Overrides) EnforceMetadataMetricName(userID string) bool {
 	return o.getOverridesForUser(userID).EnforceMetadataMetricName
@@ -580,11 +888,28 @@ func (o *Overrides) MaxGlobalMetadataPerMetric(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalMetadataPerMetric
 }
 
+// MaxActiveSeriesPerUser returns the maximum number of active series a user is allowed to have across the cluster,
+// as tracked by the usage-tracker.
+func (o *Overrides) MaxActiveSeriesPerUser(userID string) int {
+	return o.getOverridesForUser(userID).MaxActiveSeriesPerUser
+}
+
 // MaxGlobalExemplarsPerUser returns the maximum number of exemplars held in memory across the cluster.
 func (o *Overrides) MaxGlobalExemplarsPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalExemplarsPerUser
 }
 
+// ExemplarsPersistenceEnabled returns whether the exemplars of the blocks shipped by ingesters are persisted into
+// the long-term storage, and queried through the store-gateways.
+func (o *Overrides) ExemplarsPersistenceEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).ExemplarsPersistenceEnabled
+}
+
+// MaxFetchedExemplarsPerQuery returns the maximum number of exemplars a single exemplar query can fetch.
+func (o *Overrides) MaxFetchedExemplarsPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxFetchedExemplarsPerQuery
+}
+
 func (o *Overrides) ActiveSeriesCustomTrackersConfig(userID string) activeseries.CustomTrackersConfig {
 	return o.getOverridesForUser(userID).ActiveSeriesCustomTrackersConfig
 }
@@ -599,6 +924,12 @@ func (o *Overrides) OutOfOrderBlocksExternalLabelEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).OutOfOrderBlocksExternalLabelEnabled
 }
 
+// HeadPostingsForMatchersCacheSize returns the maximum number of entries in the cache for postings for matchers
+// in the tenant's Head, or 0 if the tenant uses the default size.
+func (o *Overrides) HeadPostingsForMatchersCacheSize(userID string) int {
+	return o.getOverridesForUser(userID).HeadPostingsForMatchersCacheSize
+}
+
 // SeparateMetricsGroupLabel returns the custom label used to separate specific metrics
 func (o *Overrides) SeparateMetricsGroupLabel(userID string) string {
 	return o.getOverridesForUser(userID).SeparateMetricsGroupLabel
@@ -663,6 +994,47 @@ func (o *Overrides) CompactorBlockUploadVerifyChunks(tenantID string) bool {
 	return o.getOverridesForUser(tenantID).CompactorBlockUploadVerifyChunks
 }
 
+// CompactorCardinalityIndexEnabled returns whether the cardinality summaries of the blocks are enabled for a certain tenant.
+func (o *Overrides) CompactorCardinalityIndexEnabled(tenantID string) bool {
+	return o.getOverridesForUser(tenantID).CompactorCardinalityIndexEnabled
+}
+
+// CompactorSeriesFilterEnabled returns whether the series filter index is enabled for a certain tenant.
+func (o *Overrides) CompactorSeriesFilterEnabled(tenantID string) bool {
+	return o.getOverridesForUser(tenantID).CompactorSeriesFilterEnabled
+}
+
+// CompactorBlockRanges returns the compaction time ranges of a certain tenant. If empty, the compactor's ones are used.
+func (o *Overrides) CompactorBlockRanges(tenantID string) mimir_tsdb.DurationList {
+	return o.getOverridesForUser(tenantID).CompactorBlockRanges
+}
+
+// CompactorMaxExemplarsBytesPerJob returns the maximum total size of the exemplars files read by a compaction job of a certain tenant.
+func (o *Overrides) CompactorMaxExemplarsBytesPerJob(tenantID string) int {
+	return o.getOverridesForUser(tenantID).CompactorMaxExemplarsBytesPerJob
+}
+
+// StalenessMarkersPolicy returns the policy applied by the distributor to the staleness markers received for a given user.
+func (o *Overrides) StalenessMarkersPolicy(userID string) string {
+	return o.getOverridesForUser(userID).StalenessMarkersPolicy
+}
+
+// IngestionDeadbandMetrics returns the names of the metrics whose unchanged samples are dropped by the distributor for a given user.
+func (o *Overrides) IngestionDeadbandMetrics(userID string) []string {
+	return o.getOverridesForUser(userID).IngestionDeadbandMetrics
+}
+
+// IngestionDeadbandWindow returns the max time an unchanged value of the ingestion deadband metrics isn't ingested for a given user.
+// 0 means the ingestion deadband is disabled.
+func (o *Overrides) IngestionDeadbandWindow(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).IngestionDeadbandWindow)
+}
+
+// IngestionDeadbandStrictCounters returns whether the samples of the ingestion deadband metrics which look like counters are always ingested for a given user.
+func (o *Overrides) IngestionDeadbandStrictCounters(userID string) bool {
+	return o.getOverridesForUser(userID).IngestionDeadbandStrictCounters
+}
+
 // MetricRelabelConfigs returns the metric relabel configs for a given user.
 func (o *Overrides) MetricRelabelConfigs(userID string) []*relabel.Config {
 	return o.getOverridesForUser(userID).MetricRelabelConfigs
@@ -673,6 +1045,11 @@ func (o *Overrides) NativeHistogramsIngestionEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).NativeHistogramsIngestionEnabled
 }
 
+// HistogramChunkEncoding returns the chunk encoding the ingester stores the integer native histograms of a given user with.
+func (o *Overrides) HistogramChunkEncoding(userID string) string {
+	return o.getOverridesForUser(userID).HistogramChunkEncoding
+}
+
 // RulerTenantShardSize returns shard size (number of rulers) used by this tenant when using shuffle-sharding strategy.
 func (o *Overrides) RulerTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).RulerTenantShardSize
@@ -698,11 +1075,186 @@ func (o *Overrides) RulerAlertingRulesEvaluationEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).RulerAlertingRulesEvaluationEnabled
 }
 
+// RulerMinRuleEvaluationInterval returns the minimum evaluation interval of the rule groups for a given user.
+func (o *Overrides) RulerMinRuleEvaluationInterval(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).RulerMinRuleEvaluationInterval)
+}
+
+// RulerMinRuleEvaluationIntervalRewrite returns whether the rule groups with an evaluation interval shorter
+// than the minimum should be evaluated at the minimum interval, instead of being rejected, for a given user.
+func (o *Overrides) RulerMinRuleEvaluationIntervalRewrite(userID string) bool {
+	return o.getOverridesForUser(userID).RulerMinRuleEvaluationIntervalRewrite
+}
+
+// RulerAllowedDestinationTenants returns the tenants the rule groups of a given user are allowed to write
+// the results of their recording rules to.
+func (o *Overrides) RulerAllowedDestinationTenants(userID string) []string {
+	return o.getOverridesForUser(userID).RulerAllowedDestinationTenants
+}
+
+// QuerierEmbeddedStoreMaxBlocks returns the max number of blocks a tenant can have to be queried through
+// the querier embedded store.
+func (o *Overrides) QuerierEmbeddedStoreMaxBlocks(userID string) int {
+	return o.getOverridesForUser(userID).QuerierEmbeddedStoreMaxBlocks
+}
+
+// QueryEngine returns the PromQL engine the tenant's queries are run with in the querier.
+func (o *Overrides) QueryEngine(userID string) string {
+	return o.getOverridesForUser(userID).QueryEngine
+}
+
+// EnabledPromQLExperimentalFunctions returns the experimental PromQL functions enabled for the tenant.
+func (o *Overrides) EnabledPromQLExperimentalFunctions(userID string) []string {
+	return o.getOverridesForUser(userID).EnabledPromQLExperimentalFunctions
+}
+
+// PromQLAtModifierEnabled returns whether the @ modifier is enabled in the tenant's queries.
+func (o *Overrides) PromQLAtModifierEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).PromQLAtModifierEnabled
+}
+
+// LookbackDelta returns the lookback delta of the queries of the tenant. 0 to use the PromQL engine's one.
+func (o *Overrides) LookbackDelta(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).LookbackDelta)
+}
+
+// QueryIngestersWithin returns the maximum lookback beyond which the tenant's queries are not sent to ingesters.
+// 0 to use the querier's one.
+func (o *Overrides) QueryIngestersWithin(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).QueryIngestersWithin)
+}
+
+// QueryStoreAfter returns the time after which the tenant's queries are sent to the store-gateways.
+// 0 to use the querier's one.
+func (o *Overrides) QueryStoreAfter(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).QueryStoreAfter)
+}
+
+// StrictTimeRangeRoutingEnabled returns whether each part of the tenant's queries time range is routed
+// to a single component.
+func (o *Overrides) StrictTimeRangeRoutingEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).StrictTimeRangeRoutingEnabled
+}
+
+// CostBasedTimeRangeRoutingEnabled returns whether the time range held by both the ingesters and the
+// store-gateways is queried from the component estimated to be cheaper.
+func (o *Overrides) CostBasedTimeRangeRoutingEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).CostBasedTimeRangeRoutingEnabled
+}
+
+// TimeRangeRoutingOverlapMargin returns the time range around the boundary between the ingesters and the
+// store-gateways which is queried from both by the cost-based time range routing.
+func (o *Overrides) TimeRangeRoutingOverlapMargin(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).TimeRangeRoutingOverlapMargin)
+}
+
+// QueryDeduplicationReplicaLabel returns the label the tenant's series are deduplicated by at query time,
+// or an empty string if the deduplication is disabled.
+func (o *Overrides) QueryDeduplicationReplicaLabel(userID string) string {
+	return o.getOverridesForUser(userID).QueryDeduplicationReplicaLabel
+}
+
+// QueryNativeHistogramsMaxSchema returns the max schema of the native histograms returned by the tenant's queries.
+func (o *Overrides) QueryNativeHistogramsMaxSchema(userID string) int {
+	return o.getOverridesForUser(userID).QueryNativeHistogramsMaxSchema
+}
+
+// MaxQuerierConcurrentQueries returns the max number of the tenant's queries executing concurrently in a single querier.
+func (o *Overrides) MaxQuerierConcurrentQueries(userID string) int {
+	return o.getOverridesForUser(userID).MaxQuerierConcurrentQueries
+}
+
+// QueryNativeHistogramsMaxBuckets returns the max number of buckets of each native histogram returned by the
+// tenant's queries, or 0 if unlimited.
+func (o *Overrides) QueryNativeHistogramsMaxBuckets(userID string) int {
+	return o.getOverridesForUser(userID).QueryNativeHistogramsMaxBuckets
+}
+
 // StoreGatewayTenantShardSize returns the store-gateway shard size for a given user.
 func (o *Overrides) StoreGatewayTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).StoreGatewayTenantShardSize
 }
 
+// StoreGatewayTenantReplicationFactor returns the store-gateway replication factor of the blocks for a given user.
+func (o *Overrides) StoreGatewayTenantReplicationFactor(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayTenantReplicationFactor
+}
+
+// StoreGatewayLargeTenantThreshold returns the total size of the blocks of a given user above which the user is sharded
+// across the store-gateway large tenant shard size.
+func (o *Overrides) StoreGatewayLargeTenantThreshold(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayLargeTenantThreshold
+}
+
+// StoreGatewayLargeTenantShardSize returns the store-gateway shard size for a given user once it's a large tenant.
+func (o *Overrides) StoreGatewayLargeTenantShardSize(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayLargeTenantShardSize
+}
+
+// StoreGatewayChunksCacheMinBlockAge returns the min age of the blocks whose chunks are cached by the store-gateway.
+func (o *Overrides) StoreGatewayChunksCacheMinBlockAge(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).StoreGatewayChunksCacheMinBlockAge)
+}
+
+// StoreGatewayChunksCacheMaxBlockAge returns the max age of the blocks whose chunks are cached by the store-gateway.
+func (o *Overrides) StoreGatewayChunksCacheMaxBlockAge(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).StoreGatewayChunksCacheMaxBlockAge)
+}
+
+// StoreGatewayPartialResultsEnabled returns whether the store-gateway returns partial results when some blocks fail to be queried.
+func (o *Overrides) StoreGatewayPartialResultsEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).StoreGatewayPartialResultsEnabled
+}
+
+// StoreGatewayMaxTouchedPostingsBytesPerRequest returns the max size of the postings touched by a single store-gateway Series() request.
+func (o *Overrides) StoreGatewayMaxTouchedPostingsBytesPerRequest(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxTouchedPostingsBytesPerRequest
+}
+
+// StoreGatewayMaxTouchedSeriesBytesPerRequest returns the max size of the series touched by a single store-gateway Series() request.
+func (o *Overrides) StoreGatewayMaxTouchedSeriesBytesPerRequest(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxTouchedSeriesBytesPerRequest
+}
+
+// StoreGatewayMaxTouchedChunksBytesPerRequest returns the max size of the chunks touched by a single store-gateway Series() request.
+func (o *Overrides) StoreGatewayMaxTouchedChunksBytesPerRequest(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxTouchedChunksBytesPerRequest
+}
+
+// StoreGatewayMaxTouchedPostingsBytesPerTenant returns the max size of the postings touched by all the in-flight
+// store-gateway Series() requests of a given user.
+func (o *Overrides) StoreGatewayMaxTouchedPostingsBytesPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxTouchedPostingsBytesPerTenant
+}
+
+// StoreGatewayMaxTouchedSeriesBytesPerTenant returns the max size of the series touched by all the in-flight
+// store-gateway Series() requests of a given user.
+func (o *Overrides) StoreGatewayMaxTouchedSeriesBytesPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxTouchedSeriesBytesPerTenant
+}
+
+// StoreGatewayMaxTouchedChunksBytesPerTenant returns the max size of the chunks touched by all the in-flight
+// store-gateway Series() requests of a given user.
+func (o *Overrides) StoreGatewayMaxTouchedChunksBytesPerTenant(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxTouchedChunksBytesPerTenant
+}
+
+// StoreGatewayMaxResidentIndexHeaderBytes returns the max size of the loaded index-headers of a given user.
+func (o *Overrides) StoreGatewayMaxResidentIndexHeaderBytes(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayMaxResidentIndexHeaderBytes
+}
+
+// StoreGatewayIndexHeadersPinned returns whether the loaded index-headers of a given user are never unloaded.
+func (o *Overrides) StoreGatewayIndexHeadersPinned(userID string) bool {
+	return o.getOverridesForUser(userID).StoreGatewayIndexHeadersPinned
+}
+
+// StoreGatewayLocalReplicaEnabled returns whether the files of the blocks of a given user are read from the local
+// replica directory.
+func (o *Overrides) StoreGatewayLocalReplicaEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).StoreGatewayLocalReplicaEnabled
+}
+
 // MaxHAClusters returns maximum number of clusters that HA tracker will track for a user.
 func (o *Overrides) MaxHAClusters(user string) int {
 	return o.getOverridesForUser(user).HAMaxClusters
@@ -807,6 +1359,11 @@ func (o *Overrides) AlertmanagerMaxAlertsSizeBytes(userID string) int {
 	return o.getOverridesForUser(userID).AlertmanagerMaxAlertsSizeBytes
 }
 
+// AlertmanagerAlertEnrichmentURL returns the URL of the service enriching the alerts of the tenant, or empty if disabled.
+func (o *Overrides) AlertmanagerAlertEnrichmentURL(userID string) string {
+	return o.getOverridesForUser(userID).AlertmanagerAlertEnrichmentURL
+}
+
 func (o *Overrides) ForwardingRules(user string) ForwardingRules {
 	return o.getOverridesForUser(user).ForwardingRules
 }
@@ -871,6 +1428,20 @@ func SmallestPositiveNonZeroIntPerTenant(tenantIDs []string, f func(string) int)
 	return *result
 }
 
+// SmallestPositiveNonZeroFloat64PerTenant is returning the minimal positive
+// and non-zero value of the supplied limit function for all given tenants. In
+// many limits a value of 0 means unlimited so the method will return 0 only if
+// all inputs have a limit of 0 or an empty tenant list is given.
+func SmallestPositiveNonZeroFloat64PerTenant(tenantIDs []string, f func(string) float64) float64 {
+	result := 0.0
+	for _, tenantID := range tenantIDs {
+		if v := f(tenantID); v > 0 && (result == 0 || v < result) {
+			result = v
+		}
+	}
+	return result
+}
+
 // SmallestPositiveNonZeroDurationPerTenant is returning the minimal positive
 // and non-zero value of the supplied limit function for all given tenants. In
 // many limits a value of 0 means unlimited so the method will return 0 only if
@@ -20,6 +20,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 )
 
 func TestOverridesManager_GetOverrides(t *testing.T) {
@@ -233,6 +234,23 @@ func TestSmallestPositiveNonZeroIntPerTenant(t *testing.T) {
 	}
 }
 
+func TestSmallestPositiveNonZeroFloat64PerTenant(t *testing.T) {
+	values := map[string]float64{"tenant-a": 0, "tenant-b": 5, "tenant-c": 2.5}
+	f := func(tenantID string) float64 { return values[tenantID] }
+
+	for _, tc := range []struct {
+		tenantIDs []string
+		expLimit  float64
+	}{
+		{tenantIDs: nil, expLimit: 0},
+		{tenantIDs: []string{"tenant-a"}, expLimit: 0},
+		{tenantIDs: []string{"tenant-a", "tenant-b"}, expLimit: 5},
+		{tenantIDs: []string{"tenant-a", "tenant-b", "tenant-c"}, expLimit: 2.5},
+	} {
+		assert.Equal(t, tc.expLimit, SmallestPositiveNonZeroFloat64PerTenant(tc.tenantIDs, f))
+	}
+}
+
 func TestSmallestPositiveNonZeroDurationPerTenant(t *testing.T) {
 	tenantLimits := map[string]*Limits{
 		"tenant-a": {
@@ -647,6 +665,149 @@ metric_relabel_configs:
 	})
 }
 
+func TestUnmarshalInvalidQueryResultRelabelConfig(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `
+query_result_relabel_configs:
+  -
+`
+		err := yaml.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, "invalid query_result_relabel_configs")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `{"query_result_relabel_configs": [null]}`
+		err := json.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, "invalid query_result_relabel_configs")
+	})
+}
+
+func TestUnmarshalInvalidQueryEngine(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `query_engine: unknown`
+		err := yaml.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, `unsupported query_engine "unknown"`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `{"query_engine": "unknown"}`
+		err := json.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, `unsupported query_engine "unknown"`)
+	})
+}
+
+func TestUnmarshalInvalidStalenessMarkersPolicy(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `staleness_markers_policy: unknown`
+		err := yaml.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, `unsupported staleness_markers_policy "unknown"`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `{"staleness_markers_policy": "unknown"}`
+		err := json.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, `unsupported staleness_markers_policy "unknown"`)
+	})
+}
+
+func TestUnmarshalInvalidHistogramChunkEncoding(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `histogram_chunk_encoding: unknown`
+		err := yaml.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, `unsupported histogram_chunk_encoding "unknown"`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `{"histogram_chunk_encoding": "unknown"}`
+		err := json.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, `unsupported histogram_chunk_encoding "unknown"`)
+	})
+}
+
+func TestUnmarshalInvalidQueryStoreAfter(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `
+query_ingesters_within: 1h
+query_store_after: 2h`
+		err := yaml.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, "query_store_after must be lower than query_ingesters_within")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `{"query_ingesters_within": "1h", "query_store_after": "2h"}`
+		err := json.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, "query_store_after must be lower than query_ingesters_within")
+	})
+}
+
+func TestUnmarshalInvalidQueryNativeHistogramsMaxSchema(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `query_native_histograms_max_schema: 9`
+		err := yaml.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, "unsupported query_native_histograms_max_schema 9")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		limits := Limits{}
+		cfg := `{"query_native_histograms_max_schema": -5}`
+		err := json.Unmarshal([]byte(cfg), &limits)
+		require.ErrorContains(t, err, "unsupported query_native_histograms_max_schema -5")
+	})
+}
+
+func TestUnmarshalCompactorBlockRanges(t *testing.T) {
+	tests := map[string]struct {
+		cfg         string
+		expected    mimir_tsdb.DurationList
+		expectedErr string
+	}{
+		"empty": {
+			cfg:      `compactor_block_ranges: []`,
+			expected: mimir_tsdb.DurationList{},
+		},
+		"valid ranges": {
+			cfg:      `compactor_block_ranges: [2h, 12h, 24h, 168h]`,
+			expected: mimir_tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour, 168 * time.Hour},
+		},
+		"non positive range": {
+			cfg:         `compactor_block_ranges: [0s, 2h]`,
+			expectedErr: "invalid compactor_block_ranges: the ranges must be positive",
+		},
+		"range not greater than the previous one": {
+			cfg:         `compactor_block_ranges: [2h, 2h]`,
+			expectedErr: "invalid compactor_block_ranges: each range must be greater than, and divisible by, the previous one, but 2h0m0s is not",
+		},
+		"range not divisible by the previous one": {
+			cfg:         `compactor_block_ranges: [2h, 12h, 30h]`,
+			expectedErr: "invalid compactor_block_ranges: each range must be greater than, and divisible by, the previous one, but 30h0m0s is not",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			limits := Limits{}
+			err := yaml.Unmarshal([]byte(tc.cfg), &limits)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, limits.CompactorBlockRanges)
+		})
+	}
+}
+
 type structExtension struct {
 	Foo int `yaml:"foo"`
 }
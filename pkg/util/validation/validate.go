@@ -55,6 +55,9 @@ var (
 
 	// ReasonTooManyHAClusters is one of the reasons for discarding samples.
 	ReasonTooManyHAClusters = "too_many_ha_clusters"
+
+	// ReasonMaxActiveSeriesPerUser is the reason for discarding the samples of the series rejected by the usage-tracker.
+	ReasonMaxActiveSeriesPerUser = metricReasonFromErrorID(globalerror.MaxActiveSeriesPerUser)
 )
 
 func metricReasonFromErrorID(id globalerror.ID) string {
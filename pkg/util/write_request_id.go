@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// writeRequestIDKey is the key of the GRPC metadata where the write request ID is stored.
+const writeRequestIDKey = "x-mimir-write-request-id"
+
+// GetWriteRequestIDFromIncomingCtx extracts the write request ID from the GRPC context, or returns
+// an empty string if the request has no ID.
+func GetWriteRequestIDFromIncomingCtx(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	ids, ok := md[writeRequestIDKey]
+	if !ok || len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+// AddWriteRequestIDToOutgoingContext adds the given write request ID to the GRPC context.
+func AddWriteRequestIDToOutgoingContext(ctx context.Context, id string) context.Context {
+	if id != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, writeRequestIDKey, id)
+	}
+	return ctx
+}
+
+// AddWriteRequestIDToIncomingContext adds the given write request ID to the GRPC context.
+func AddWriteRequestIDToIncomingContext(ctx context.Context, id string) context.Context {
+	if id != "" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		md = md.Copy()
+		md.Set(writeRequestIDKey, id)
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+	return ctx
+}
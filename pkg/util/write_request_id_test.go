@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWriteRequestID(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", GetWriteRequestIDFromIncomingCtx(ctx))
+
+	// The ID added to the outgoing context is sent as GRPC metadata.
+	outgoing := AddWriteRequestIDToOutgoingContext(ctx, "id-1")
+	md, ok := metadata.FromOutgoingContext(outgoing)
+	assert.True(t, ok)
+	assert.Equal(t, "id-1", GetWriteRequestIDFromIncomingCtx(metadata.NewIncomingContext(ctx, md)))
+
+	// The ID added to the incoming context preserves the other metadata.
+	incoming := AddSourceIPsToIncomingContext(ctx, "172.16.1.1")
+	incoming = AddWriteRequestIDToIncomingContext(incoming, "id-2")
+	assert.Equal(t, "id-2", GetWriteRequestIDFromIncomingCtx(incoming))
+	assert.Equal(t, "172.16.1.1", GetSourceIPsFromIncomingCtx(incoming))
+
+	// An empty ID is not added.
+	assert.Equal(t, ctx, AddWriteRequestIDToOutgoingContext(ctx, ""))
+	assert.Equal(t, ctx, AddWriteRequestIDToIncomingContext(ctx, ""))
+}
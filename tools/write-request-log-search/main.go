@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/ingester/writerequestlog"
+)
+
+var logger = log.NewLogfmtLogger(os.Stderr)
+
+func main() {
+	metricSelector := flag.String("select", "", "PromQL metric selector of the series to search")
+	sampleTime := flag.String("time", "", "If set, only the write requests with samples of the series spanning this time (RFC3339 or milliseconds since epoch) are printed")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Println("No write request log directory specified.")
+		return
+	}
+
+	var matchers []*labels.Matcher
+	if *metricSelector != "" {
+		var err error
+		matchers, err = parser.ParseMetricSelector(*metricSelector)
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to parse matcher selector", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	ts, err := parseTime(*sampleTime)
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to parse time", "err", err)
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, dir := range flag.Args() {
+		err := writerequestlog.Search(dir, matchers, ts, func(r writerequestlog.Record) error {
+			return enc.Encode(r)
+		})
+		if err != nil {
+			level.Error(logger).Log("msg", "failed to search write request log", "dir", dir, "err", err)
+		}
+	}
+}
+
+// parseTime parses the input time, either in RFC3339 or as milliseconds since epoch, and returns it in milliseconds.
+// It returns 0 if the input is empty.
+func parseTime(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return timestamp.FromTime(t), nil
+}